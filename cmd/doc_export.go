@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-season/ginctl/pkg/export"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/log"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+type docExportCmd struct {
+	log log.Logger
+
+	format string
+	output string
+}
+
+func NewDocExportCmd(f factory.Factory) *cobra.Command {
+	cmd := &docExportCmd{
+		log: f.GetLog(),
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出接口集合(postman、insomnia、http)",
+		Long: `根据api/rest路由及api/typespec请求定义导出可直接运行的接口集合
+
+命令样例:
+ginctl doc export --format postman|insomnia|http [-o ./docs/export]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&cmd.format, "format", "f", export.FormatPostman, "Export format, support postman,insomnia,http")
+	exportCmd.Flags().StringVarP(&cmd.output, "output", "o", "./docs/export", "Output directory for the exported collections")
+
+	return exportCmd
+}
+
+func (cmd *docExportCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	switch cmd.format {
+	case export.FormatPostman, export.FormatInsomnia, export.FormatHTTP:
+	default:
+		return fmt.Errorf("not supported %s format", cmd.format)
+	}
+
+	g := export.NewGenerator(export.WithLogger(cmd.log), export.WithWorkDir(cwd))
+
+	cmd.log.StartWait("parsing api definitions...")
+	err = g.Parse()
+	cmd.log.StopWait()
+	if err != nil {
+		return err
+	}
+
+	if err := g.Export(cmd.format, cmd.output); err != nil {
+		return err
+	}
+
+	cmd.log.Donef("export %s collection to %s successful.", cmd.format, ansi.Color(cmd.output, "cyan+b"))
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/log"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+type docHTMLCmd struct {
+	log log.Logger
+
+	swagDocFile  string
+	mergeCfgFile string
+	title        string
+	output       string
+}
+
+func NewDocHTMLCmd(f factory.Factory) *cobra.Command {
+	cmd := &docHTMLCmd{
+		log: f.GetLog(),
+	}
+
+	htmlCmd := &cobra.Command{
+		Use:   "html",
+		Short: "生成离线静态HTML文档",
+		Long: `根据ginctl doc生成的swagger文档渲染单文件静态HTML站点, 内嵌全部样式及脚本,
+按tag分组并支持搜索, 可直接附加到merge request或托管在对象存储上
+
+命令样例:
+ginctl doc html [-o ./docs/site] [--swagDoc ./docs/swagger.json] [--mc merge.yaml]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	htmlCmd.Flags().StringVar(&cmd.swagDocFile, "swagDoc", "./docs/swagger.json", "Swagger json doc path, default ./docs/swagger.json")
+	htmlCmd.Flags().StringVar(&cmd.mergeCfgFile, "mc", "", "Specified merge doc config dir")
+	htmlCmd.Flags().StringVarP(&cmd.title, "title", "t", "", "Page title, default the title of swagger info")
+	htmlCmd.Flags().StringVarP(&cmd.output, "output", "o", "./docs/site", "Output directory for the generated index.html")
+
+	return htmlCmd
+}
+
+func (cmd *docHTMLCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	swagDoc, err := loadNormalizeSwagDoc(cmd.swagDocFile, cmd.mergeCfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s not found, please run ginctl doc first", cmd.swagDocFile)
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(cmd.output, 0755); err != nil {
+		return err
+	}
+
+	index := filepath.Join(cmd.output, "index.html")
+	fs, err := os.Create(index)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	if err := doc.RenderHTML(fs, swagDoc, cmd.title); err != nil {
+		return err
+	}
+
+	cmd.log.Donef("generate html doc to %s successful.", ansi.Color(index, "cyan+b"))
+
+	return nil
+}
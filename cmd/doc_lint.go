@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/file"
+	"github.com/go-season/ginctl/pkg/util/log"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+var severityColors = map[string]string{
+	doc.SeverityError:   "red+b",
+	doc.SeverityWarning: "yellow+b",
+	doc.SeverityInfo:    "cyan+b",
+}
+
+type docLintCmd struct {
+	log log.Logger
+
+	configFile       string
+	propertyStrategy string
+	failOn           string
+}
+
+func NewDocLintCmd(f factory.Factory) *cobra.Command {
+	cmd := &docLintCmd{
+		log: f.GetLog(),
+	}
+
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "检查接口文档质量",
+		Long: `检查api/rest及api/typespec中的文档问题, 包括:
+  handler-description      接口缺少描述
+  field-comment            请求/响应字段缺少注释
+  json-naming              json命名与propertyStrategy不一致
+  undocumented-error       错误响应未通过@Failure声明
+  unresolved-error-status  错误响应状态码无法静态分析, 文档中记为500
+  empty-response           响应类型为空结构体
+
+可通过配置文件调整规则级别(error、warning、info)或关闭(off)规则:
+  propertyStrategy: camelcase
+  rules:
+    field-comment: off
+
+存在不低于--fail-on级别的问题时以非零状态退出, 可用于CI
+
+命令样例:
+ginctl doc lint [--config ./doclint.yaml] [--fail-on error]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	lintCmd.Flags().StringVarP(&cmd.configFile, "config", "c", "./doclint.yaml", "Lint config file, ignored when not exists")
+	lintCmd.Flags().StringVarP(&cmd.propertyStrategy, "propertyStrategy", "p", "", "Property Naming Strategy like snakecase,camelcase,pascalcase, default camelcase")
+	lintCmd.Flags().StringVar(&cmd.failOn, "fail-on", doc.SeverityError, "Exit with non-zero code when issues of this severity or above are found, support error,warning,info")
+
+	return lintCmd
+}
+
+func (cmd *docLintCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := severityColors[cmd.failOn]; !ok {
+		return fmt.Errorf("not supported %s severity", cmd.failOn)
+	}
+
+	var cfg *doc.LintConfig
+	found, err := file.PathExists(cmd.configFile)
+	if err != nil {
+		return err
+	}
+	if found {
+		if cfg, err = doc.LoadLintConfig(cmd.configFile); err != nil {
+			return err
+		}
+	}
+
+	linter := doc.NewLinter(cwd, cfg)
+	switch cmd.propertyStrategy {
+	case "":
+	case doc.CamelCase, doc.SnakeCase, doc.PascalCase:
+		linter.SetPropertyStrategy(cmd.propertyStrategy)
+	default:
+		return fmt.Errorf("not supported %s propertyStrategy", cmd.propertyStrategy)
+	}
+
+	if err := linter.Lint(); err != nil {
+		return err
+	}
+
+	for _, issue := range linter.Issues {
+		cmd.log.WriteString(fmt.Sprintf("%s:%d: %s %s: %s\n",
+			ansi.Color(issue.File, "cyan+b"),
+			issue.Line,
+			ansi.Color("["+issue.Severity+"]", severityColors[issue.Severity]),
+			issue.Rule,
+			issue.Message,
+		))
+	}
+
+	errCount := linter.Count(doc.SeverityError)
+	warnCount := linter.Count(doc.SeverityWarning) - errCount
+	infoCount := len(linter.Issues) - errCount - warnCount
+	summary := fmt.Sprintf("%d errors, %d warnings, %d infos", errCount, warnCount, infoCount)
+	if linter.Count(cmd.failOn) > 0 {
+		return fmt.Errorf("doc lint failed: %s", summary)
+	}
+
+	if len(linter.Issues) > 0 {
+		cmd.log.WriteString("\n")
+	}
+	cmd.log.Donef("doc lint passed: %s", summary)
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-season/ginctl/pkg/publish"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/file"
+	"github.com/go-season/ginctl/pkg/util/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultYapiMerge = "mergin"
+)
+
+type docPublishCmd struct {
+	log log.Logger
+
+	configFile     string
+	targets        string
+	dryRun         bool
+	swagDocFile    string
+	mergeCfgFile   string
+	yapiConfigPath string
+	consulServer   string
+}
+
+func NewDocPublishCmd(f factory.Factory) *cobra.Command {
+	cmd := &docPublishCmd{
+		log: f.GetLog(),
+	}
+
+	publishCmd := &cobra.Command{
+		Use:   "publish",
+		Short: "发布接口文档(yapi、http、file/git)",
+		Long: `将ginctl doc生成的swagger文档发布到配置的publisher, 配置中可通过${ENV}引用环境变量:
+
+publishers:
+  - name: yapi
+    type: yapi
+    server: http://yapi.example.com
+    token: ${YAPI_TOKEN}
+  - name: portal
+    type: http
+    url: https://portal.example.com/specs/{{.App}}
+    method: PUT
+    headers:
+      Authorization: Bearer ${PORTAL_TOKEN}
+    body: '{"name":"{{.App}}","spec":{{.Doc}}}'
+  - name: open
+    type: yapi
+    spec: public
+    token: ${YAPI_OPEN_TOKEN}
+  - name: specs
+    type: file
+    path: ../api-specs/{{.App}}/swagger.json
+    git:
+      message: update {{.App}} api doc
+      push: true
+
+spec指定发布的受众文档(见ginctl doc --ac), 默认发布完整文档
+未找到配置时沿用yapi.json或consul中的yapi配置, yapi服务地址读取YAPI_SERVER,
+consul地址读取--consulServer或CONSUL_HTTP_ADDR
+
+命令样例:
+ginctl doc publish [--config ./publish.yaml] [--target yapi,specs] [--dry-run]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	publishCmd.Flags().StringVarP(&cmd.configFile, "config", "c", "./publish.yaml", "Publish config file")
+	publishCmd.Flags().StringVarP(&cmd.targets, "target", "t", "", "Names of the publishers to run, comma separated, default all")
+	publishCmd.Flags().BoolVar(&cmd.dryRun, "dry-run", false, "Print the payloads instead of publishing them")
+	publishCmd.Flags().StringVar(&cmd.swagDocFile, "swagDoc", "./docs/swagger.json", "Swagger json doc path, default ./docs/swagger.json")
+	publishCmd.Flags().StringVar(&cmd.mergeCfgFile, "mc", "", "Specified merge doc config dir")
+	publishCmd.Flags().StringVar(&cmd.yapiConfigPath, "yc", "./yapi.json", "Yapi config used when there is no publish config, default ./yapi.json")
+	publishCmd.Flags().StringVar(&cmd.consulServer, "consulServer", "", "Consul address for project pull yapi token, default $CONSUL_HTTP_ADDR")
+
+	return publishCmd
+}
+
+func (cmd *docPublishCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	return cmd.publish(cwd)
+}
+
+func (cmd *docPublishCmd) publish(cwd string) error {
+	cfgs, err := cmd.loadConfigs()
+	if err != nil {
+		return err
+	}
+
+	// the specs of audiences are generated beside the full one
+	contents := make(map[string][]byte)
+	publishers := make([]publish.Publisher, 0, len(cfgs))
+	specs := make([]string, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, ok := contents[cfg.Spec]; !ok {
+			swagDocFile := cmd.swagDocFile
+			if cfg.Spec != "" {
+				swagDocFile = filepath.Join(filepath.Dir(cmd.swagDocFile), cfg.Spec, filepath.Base(cmd.swagDocFile))
+			}
+			content, err := getNormalizeSwagDoc(swagDocFile, cmd.mergeCfgFile)
+			if err != nil {
+				if os.IsNotExist(err) && cfg.Spec != "" {
+					return fmt.Errorf("spec %s of %s not found, please check the audiences of ginctl doc", cfg.Spec, cfg.Name)
+				}
+				return err
+			}
+			contents[cfg.Spec] = content
+		}
+
+		p, err := publish.New(cfg, publish.WithLogger(cmd.log), publish.WithWorkDir(cwd), publish.WithDryRun(cmd.dryRun))
+		if err != nil {
+			return err
+		}
+		publishers = append(publishers, p)
+		specs = append(specs, cfg.Spec)
+	}
+
+	for i, p := range publishers {
+		payload, err := p.Payload(contents[specs[i]])
+		if err != nil {
+			return err
+		}
+		if cmd.dryRun {
+			cmd.log.Infof("[dry-run] %s", p.Name())
+			cmd.log.WriteString(payload.String() + "\n")
+			continue
+		}
+
+		cmd.log.StartWait(fmt.Sprintf("publishing doc by %s...", p.Name()))
+		err = p.Publish(payload)
+		cmd.log.StopWait()
+		if err != nil {
+			return fmt.Errorf("%s: %v", p.Name(), err)
+		}
+		cmd.log.Donef("publish doc by %s to %s successful.", p.Name(), payload.Target)
+	}
+
+	return nil
+}
+
+// loadConfigs returns the selected publishers of the publish config, or the
+// yapi publisher of yapi.json and consul when there is no publish config.
+func (cmd *docPublishCmd) loadConfigs() ([]publish.Config, error) {
+	found, err := file.PathExists(cmd.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfgs []publish.Config
+	if found {
+		if cfgs, err = publish.LoadConfig(cmd.configFile); err != nil {
+			return nil, err
+		}
+	} else {
+		cfg, err := cmd.yapiConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfgs = []publish.Config{*cfg}
+	}
+
+	if cmd.targets == "" {
+		return cfgs, nil
+	}
+
+	selected := make([]publish.Config, 0)
+	for _, target := range strings.Split(cmd.targets, ",") {
+		target = strings.TrimSpace(target)
+		var ok bool
+		for _, cfg := range cfgs {
+			if cfg.Name == target {
+				selected = append(selected, cfg)
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("publisher %s is not configured", target)
+		}
+	}
+
+	return selected, nil
+}
+
+func (cmd *docPublishCmd) yapiConfig() (*publish.Config, error) {
+	cfg := &publish.Config{Name: publish.TypeYapi, Type: publish.TypeYapi}
+
+	found, err := file.PathExists(cmd.yapiConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		fs, err := os.Open(cmd.yapiConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		defer fs.Close()
+
+		var yo struct {
+			Token  string `json:"token"`
+			Merge  string `json:"merge"`
+			Server string `json:"server"`
+		}
+		if err := json.NewDecoder(fs).Decode(&yo); err != nil {
+			return nil, fmt.Errorf("parse yapi config %s failed: %v", cmd.yapiConfigPath, err)
+		}
+		cfg.Token, cfg.Merge, cfg.Server = os.ExpandEnv(yo.Token), yo.Merge, yo.Server
+		return cfg, nil
+	}
+
+	cfg.Consul = cmd.consulServer
+	if cfg.Consul == "" {
+		cfg.Consul = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if cfg.Consul == "" && os.Getenv("YAPI_TOKEN") == "" {
+		return nil, fmt.Errorf("consul is not configured to read the yapi token from, please set --consulServer, CONSUL_HTTP_ADDR or YAPI_TOKEN")
+	}
+	cfg.Merge = defaultYapiMerge
+
+	return cfg, nil
+}
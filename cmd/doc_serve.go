@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/file"
+	"github.com/go-season/ginctl/pkg/util/log"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+type docServeCmd struct {
+	log log.Logger
+
+	addr             string
+	assets           string
+	title            string
+	mergeCfgFile     string
+	securityCfgFile  string
+	audienceCfgFile  string
+	policyCfgFile    string
+	propertyStrategy string
+	output           string
+	verbose          bool
+}
+
+func NewDocServeCmd(f factory.Factory) *cobra.Command {
+	cmd := &docServeCmd{
+		log: f.GetLog(),
+	}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "启动本地文档服务",
+		Long: `启动本地文档服务, api/rest或api/typespec变更时重新生成文档并刷新已打开的页面,
+服务无需编译通过即可预览接口设计
+
+  /         离线静态HTML文档
+  /swagger  Swagger UI
+  /redoc    ReDoc
+
+Swagger UI内置于ginctl中, 无需联网即可使用; ReDoc未内置, /redoc需通过--assets
+指定包含redoc.standalone.js的目录, 否则返回未支持的提示. 该目录同样可以覆盖内置的
+swagger-ui.css及swagger-ui-bundle.js
+
+命令样例:
+ginctl doc serve [--addr :8090] [--assets ./docs/assets] [--mc merge.yaml]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	serveCmd.Flags().StringVarP(&cmd.addr, "addr", "a", ":8090", "Address the doc server listens on")
+	serveCmd.Flags().StringVar(&cmd.assets, "assets", "", "Directory of redoc.standalone.js, which /redoc requires, and of Swagger UI assets overriding the built-in ones")
+	serveCmd.Flags().StringVarP(&cmd.title, "title", "t", "", "Page title, default the title of swagger info")
+	serveCmd.Flags().StringVar(&cmd.mergeCfgFile, "mc", "", "Specified merge doc config dir")
+	serveCmd.Flags().StringVar(&cmd.securityCfgFile, "sc", "./docsecurity.yaml", "Security schemes and headers of middlewares, ignored when not exists")
+	serveCmd.Flags().StringVar(&cmd.audienceCfgFile, "ac", "./docaudience.yaml", "Audiences of api/rest packages, ignored when not exists")
+	serveCmd.Flags().StringVar(&cmd.policyCfgFile, "pc", "./routepolicy.yaml", "Middleware constructors of policy annotations, ignored when not exists")
+	serveCmd.Flags().StringVarP(&cmd.propertyStrategy, "propertyStrategy", "p", "camelcase", "Property Naming Strategy like snakecase,camelcase,pascalcase")
+	serveCmd.Flags().StringVarP(&cmd.output, "output", "o", "./docs", "Output directory for all the generated files(swagger.json, swagger.yaml and doc.go)")
+	serveCmd.Flags().BoolVarP(&cmd.verbose, "verbose", "v", false, "Print the parse details")
+
+	return serveCmd
+}
+
+func (cmd *docServeCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	generator := &docCmd{
+		log:              cmd.log,
+		verbose:          cmd.verbose,
+		searchDir:        "./api/doc",
+		generalInfo:      "doc.go",
+		propertyStrategy: cmd.propertyStrategy,
+		output:           cmd.output,
+		securityCfgFile:  cmd.securityCfgFile,
+		audienceCfgFile:  cmd.audienceCfgFile,
+		policyCfgFile:    cmd.policyCfgFile,
+		parseDependency:  true,
+		parseDepth:       2,
+	}
+	server := doc.NewServer(cmd.title, cmd.assets)
+
+	regenerate := func() {
+		swagDoc, err := cmd.generate(f, generator, cwd)
+		if err != nil {
+			cmd.log.Errorf("Generate API documentation failed: %v", err)
+			server.Fail(err)
+			return
+		}
+		if err := server.Update(swagDoc); err != nil {
+			cmd.log.Errorf("Render API documentation failed: %v", err)
+			server.Fail(err)
+			return
+		}
+		cmd.log.Done("Generate API documentation successful.")
+	}
+	regenerate()
+
+	// changes during a generation regenerate once more after it
+	changed := make(chan struct{}, 1)
+	go func() {
+		for range changed {
+			regenerate()
+		}
+	}()
+
+	// the roots are watched even without go files, for the packages created
+	// in them
+	var paths []string
+	for _, dir := range []string{"rest", "typespec"} {
+		root := filepath.Join(cwd, "api", dir)
+		if found, _ := file.PathExists(root); found {
+			paths = append(paths, root)
+		}
+		readAppDir(root, &paths)
+	}
+	newWatcher(cmd.log, paths, true, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	host := cmd.addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	cmd.log.Infof("Serving API documentation at %s", ansi.Color("http://"+host, "cyan+b"))
+	if !server.HasReDoc() {
+		cmd.log.Warnf("ReDoc isn't bundled with ginctl, /redoc is served with --assets of a directory holding redoc.standalone.js")
+	}
+
+	return http.ListenAndServe(cmd.addr, server)
+}
+
+func (cmd *docServeCmd) generate(f factory.Factory, generator *docCmd, cwd string) (doc.SwaggerDoc, error) {
+	if err := generator.generate(f, cwd); err != nil {
+		return nil, err
+	}
+
+	swagDoc, err := loadNormalizeSwagDoc(filepath.Join(cmd.output, "swagger.json"), cmd.mergeCfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("load generated doc failed: %v", err)
+	}
+
+	return swagDoc, nil
+}
@@ -58,7 +58,7 @@ func (cmd *responseCmd) RunPolyfillResponse(f factory.Factory, cobraCmd *cobra.C
 		}
 		if found {
 			overwrite, err := f.GetLog().Question(&log.QuestionOptions{
-				Question:     fmt.Sprintf("%s已经存在，该操作会覆盖原始内容，请确认是否要覆盖？"),
+				Question:     fmt.Sprintf("%s已经存在，该操作会覆盖原始内容，请确认是否要覆盖？", filePath),
 				DefaultValue: "false",
 				Options: []string{
 					OverwriteTrue,
@@ -0,0 +1,102 @@
+package route
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util/diff"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/spf13/cobra"
+)
+
+type CheckCmd struct {
+	PolicyCfgFile string
+	Split         bool
+	Verbose       bool
+}
+
+func NewCheckCmd(f factory.Factory) *cobra.Command {
+	cmd := &CheckCmd{}
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "检查项目路由定义是否需要更新.",
+		Long: `在内存中生成路由定义并与api/rest/api.go比较, 不一致时输出unified diff并以非零状态退出,
+可用于CI检查新增或修改路由注解后是否遗漏了ginctl route refresh;
+使用route refresh --split的项目同样指定--split, 一并检查各rest包的routes_gen.go
+
+命令样例:
+ginctl route check [--split]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	checkCmd.Flags().StringVar(&cmd.PolicyCfgFile, "pc", "./routepolicy.yaml", "Middleware constructors of policy annotations, ignored when not exists")
+	checkCmd.Flags().BoolVar(&cmd.Split, "split", false, "Check the routes_gen.go of each rest package too.")
+	checkCmd.Flags().BoolVarP(&cmd.Verbose, "verbose", "v", false, "output verbose info.")
+
+	return checkCmd
+}
+
+func (cmd *CheckCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	parser, err := parseRoutes(cwd, cmd.PolicyCfgFile, doc.WithDebug(cmd.Verbose), doc.WithQuiet(true))
+	if err != nil {
+		return err
+	}
+	sources, err := routeSources(cwd, parser, cmd.Split)
+	if err != nil {
+		return err
+	}
+	stale, err := staleRouteFiles(cwd, sources)
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		sources[file] = nil
+	}
+
+	var outdated []string
+	for _, file := range sortedFiles(sources) {
+		committed, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		name := strings.TrimPrefix(file, cwd+"/")
+		if d := diff.Unified("a/"+name, "b/"+name, string(committed), string(sources[file])); d != "" {
+			fmt.Print(d)
+			outdated = append(outdated, name)
+		}
+	}
+
+	command := "ginctl route refresh"
+	if cmd.Split {
+		command += " --split"
+	}
+	switch len(outdated) {
+	case 0:
+	case 1:
+		return fmt.Errorf("%s is out of date, please run `%s`", outdated[0], command)
+	default:
+		return fmt.Errorf("%s are out of date, please run `%s`", strings.Join(outdated, ", "), command)
+	}
+
+	if len(sources) == 1 {
+		f.GetLog().Donef("%s is up to date", strings.TrimPrefix(routeFile(cwd), cwd+"/"))
+	} else {
+		f.GetLog().Donef("routes are up to date")
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package route
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-season/ginctl/pkg/util/factory"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// prepare changes the project, in which the golden route files
+		// are written.
+		prepare func(cwd string)
+		// err is empty when the routes are up to date.
+		err  string
+		diff []string
+	}{
+		{name: "up to date"},
+		{name: "split up to date", args: []string{"--split"}},
+		{
+			name: "route added",
+			prepare: func(cwd string) {
+				appendFile(t, filepath.Join(cwd, "api/rest/order/order.go"), "\n// @Router /order [POST]\nfunc AddOrder() {}\n")
+			},
+			err:  "api/rest/api.go is out of date, please run `ginctl route refresh`",
+			diff: []string{"--- a/api/rest/api.go", "+++ b/api/rest/api.go", `+	route.POST("/order", order.AddOrder)`},
+		},
+		{
+			name: "route file missing",
+			prepare: func(cwd string) {
+				os.Remove(filepath.Join(cwd, "api/rest/api.go"))
+			},
+			err:  "api/rest/api.go is out of date",
+			diff: []string{"+++ b/api/rest/api.go", "+func registerRoute() {"},
+		},
+		{
+			name: "split route added",
+			args: []string{"--split"},
+			prepare: func(cwd string) {
+				appendFile(t, filepath.Join(cwd, "api/rest/admin/admin.go"), "\n// @Router /admin/v1/log [GET]\nfunc Log() {}\n")
+			},
+			err:  "api/rest/admin/routes_gen.go is out of date, please run `ginctl route refresh --split`",
+			diff: []string{`+		adminV1Group.GET("/log", Log)`},
+		},
+		{
+			name: "split stale file",
+			args: []string{"--split"},
+			prepare: func(cwd string) {
+				writeFile(t, filepath.Join(cwd, "api/rest/legacy/routes_gen.go"), []byte("// Code generated by ginctl. DO NOT EDIT.\n\npackage legacy\n"))
+			},
+			err:  "api/rest/legacy/routes_gen.go is out of date",
+			diff: []string{"--- a/api/rest/legacy/routes_gen.go", "-package legacy"},
+		},
+		{
+			name: "split outdated files",
+			args: []string{"--split"},
+			prepare: func(cwd string) {
+				appendFile(t, filepath.Join(cwd, "api/rest/admin/admin.go"), "\n// @Router /admin/v1/log [GET]\nfunc Log() {}\n")
+				appendFile(t, filepath.Join(cwd, "api/rest/order/order.go"), "\n// @Router /order [POST]\nfunc AddOrder() {}\n")
+			},
+			err: "api/rest/admin/routes_gen.go, api/rest/order/routes_gen.go are out of date",
+		},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, tt := range tests {
+		cwd := copyProject(t)
+		defer os.RemoveAll(cwd)
+		golden := filepath.Join(wd, "testdata/golden/api")
+		for _, arg := range tt.args {
+			if arg == "--split" {
+				golden = filepath.Join(wd, "testdata/golden/split")
+			}
+		}
+		copyDir(t, golden, cwd)
+		if tt.prepare != nil {
+			tt.prepare(cwd)
+		}
+
+		if err := os.Chdir(cwd); err != nil {
+			t.Fatal(err)
+		}
+		checkCmd := NewCheckCmd(factory.DefaultFactory())
+		checkCmd.SetArgs(tt.args)
+		checkCmd.SilenceUsage, checkCmd.SilenceErrors = true, true
+		var runErr error
+		diff := captureStdout(t, func() {
+			runErr = checkCmd.Execute()
+		})
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+
+		if tt.err == "" {
+			if runErr != nil || diff != "" {
+				t.Errorf("%s: check error = %v, diff:\n%s", tt.name, runErr, diff)
+			}
+			continue
+		}
+		// the error exits ginctl with a non-zero status
+		if runErr == nil || !strings.Contains(runErr.Error(), tt.err) {
+			t.Errorf("%s: check error = %v, want %q", tt.name, runErr, tt.err)
+		}
+		for _, line := range tt.diff {
+			if !strings.Contains(diff, line+"\n") {
+				t.Errorf("%s: check diff doesn't contain %q:\n%s", tt.name, line, diff)
+			}
+		}
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- string(b)
+	}()
+
+	fn()
+	os.Stdout = stdout
+	w.Close()
+
+	return <-out
+}
+
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		writeFile(t, filepath.Join(dst, strings.TrimPrefix(path, src)), b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, append(b, content...))
+}
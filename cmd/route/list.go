@@ -0,0 +1,144 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+type ListCmd struct {
+	JSON          bool
+	PolicyCfgFile string
+	Verbose       bool
+}
+
+func NewListCmd(f factory.Factory) *cobra.Command {
+	cmd := &ListCmd{}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出项目路由并检查冲突.",
+		Long: `按注册顺序列出api/rest中handler注解的所有路由, 包括方法、完整路径、handler、
+策略注解、前置/后置中间件及声明位置, 并检查gin(v1.7以前)注册时会panic的冲突:
+  重复的方法与路径
+  同一位置的通配段(:id、*path)与静态段
+  同一位置名称不同的通配段
+
+存在冲突时以非零状态退出, 可用于CI; 指定--json时冲突见输出的conflicts
+
+命令样例:
+ginctl route list [--json]
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(f, cobraCmd, args)
+		},
+	}
+
+	listCmd.Flags().BoolVar(&cmd.JSON, "json", false, "output routes and conflicts as json.")
+	listCmd.Flags().StringVar(&cmd.PolicyCfgFile, "pc", "./routepolicy.yaml", "Middleware constructors of policy annotations, ignored when not exists")
+	listCmd.Flags().BoolVarP(&cmd.Verbose, "verbose", "v", false, "output verbose info.")
+
+	return listCmd
+}
+
+func (cmd *ListCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	parser, err := parseRoutes(cwd, cmd.PolicyCfgFile, doc.WithDebug(cmd.Verbose), doc.WithQuiet(true))
+	if err != nil {
+		return err
+	}
+	conflicts := doc.FindRouteConflicts(parser.Routes)
+
+	if cmd.JSON {
+		routes := parser.Routes
+		if routes == nil {
+			routes = make([]*doc.Route, 0)
+		}
+		if conflicts == nil {
+			conflicts = make([]*doc.RouteConflict, 0)
+		}
+		b, err := json.MarshalIndent(map[string]interface{}{
+			"routes":    routes,
+			"conflicts": conflicts,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tPOLICIES\tBEFORE\tAFTER\tLOCATION")
+	for _, route := range parser.Routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s:%d\n",
+			route.Method,
+			route.Path,
+			route.Handler,
+			policyList(route.Policies),
+			middlewareList(route.BeforeMiddlewares),
+			middlewareList(route.AfterMiddlewares),
+			route.File,
+			route.Line,
+		)
+	}
+	w.Flush()
+
+	if len(conflicts) > 0 {
+		fmt.Println()
+	}
+	for _, conflict := range conflicts {
+		msg := fmt.Sprintf("%s:%d: %s %s %s: %s",
+			ansi.Color(conflict.Route.File, "cyan+b"),
+			conflict.Route.Line,
+			ansi.Color("[conflict]", "red+b"),
+			conflict.Route.Method,
+			conflict.Route.Path,
+			conflict.Reason,
+		)
+		if conflict.Existing != nil {
+			msg += fmt.Sprintf(", with %s", conflict.Existing)
+		}
+		fmt.Println(msg)
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("found %d route conflicts", len(conflicts))
+	}
+
+	return nil
+}
+
+func policyList(policies []*doc.Policy) string {
+	if len(policies) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, policy.String())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func middlewareList(middlewares []string) string {
+	if len(middlewares) == 0 {
+		return "-"
+	}
+
+	return strings.Join(middlewares, ",")
+}
@@ -1,20 +1,34 @@
 package route
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/go-season/ginctl/pkg/generator"
 
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
 	"github.com/go-season/ginctl/pkg/ginctl/doc"
 	"github.com/go-season/ginctl/pkg/util"
 	"github.com/go-season/ginctl/pkg/util/factory"
+	"github.com/go-season/ginctl/pkg/util/file"
 	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 type RefreshCmd struct {
-	Verbose bool
+	PolicyCfgFile string
+	Split         bool
+	Verbose       bool
 }
 
 func NewRefreshCmd(f factory.Factory) *cobra.Command {
@@ -23,7 +37,46 @@ func NewRefreshCmd(f factory.Factory) *cobra.Command {
 	routeCmd := &cobra.Command{
 		Use:   "refresh",
 		Short: "更新项目路由定义.",
-		Args:  cobra.NoArgs,
+		Long: `根据api/rest下handler的@Router注解更新项目路由定义api/rest/api.go
+
+包注释中的@Group为文件内的handler声明路由组, 未声明时沿用同包doc.go中的@Group,
+组内handler的@Router仍为完整路径:
+
+  // @Group /admin auth.Required, audit.Log()
+  package admin
+
+包注释中的@Version(未声明时沿用同包doc.go)或api/rest下的vN目录声明handler的版本,
+各版本的路由注册在/vN路由组的registerRouteVN中, @Router为版本内的路径:
+
+  // @Version v2
+  package user
+
+handler的策略注解按--pc配置(默认./routepolicy.yaml)转换为中间件构造函数调用,
+注解参数以字符串传入, 如@Auth role=admin转换为middleware.Auth("role=admin"):
+
+  policies:
+    auth:
+      constructor: middleware.Auth
+    rateLimit:
+      constructor: middleware.RateLimit
+
+内置的策略注解有@Auth key=value...、@RateLimit 100/m、@Timeout 3s及@CORS,
+配置的其他策略同样可作为注解使用; @CORS的路径另注册OPTIONS路由, 由其中间件响应预检请求
+
+handler可以是控制器结构体的方法, 控制器由包注释中的@Controller声明, 构造函数为包内导出的
+无参函数(默认New<Type>), 由其注入依赖, 注册路由前先构造控制器再引用其方法:
+
+  // @Controller UserController NewUserController
+  package user
+
+指定--split时各rest包的路由生成到包内的routes_gen.go, 由其Register(route gin.IRouter)注册,
+api/rest/api.go只依次调用各包的Register, 增删handler时只修改所在包的routes_gen.go,
+减少并行开发分支间的合并冲突; 不再有路由的包的routes_gen.go会被删除
+
+命令样例:
+ginctl route refresh [--split]
+`,
+		Args: cobra.NoArgs,
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
 			return cmd.Run(f, cobraCmd, args)
 		},
@@ -32,6 +85,8 @@ func NewRefreshCmd(f factory.Factory) *cobra.Command {
 		},
 	}
 
+	routeCmd.Flags().StringVar(&cmd.PolicyCfgFile, "pc", "./routepolicy.yaml", "Middleware constructors of policy annotations, ignored when not exists")
+	routeCmd.Flags().BoolVar(&cmd.Split, "split", false, "Generate the routes of each rest package in its routes_gen.go.")
 	routeCmd.Flags().BoolVarP(&cmd.Verbose, "verbose", "v", false, "output verbose info.")
 
 	return routeCmd
@@ -43,39 +98,168 @@ func (cmd *RefreshCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []st
 		return err
 	}
 
+	f.GetLog().Info("start scanning rest path...")
+	f.GetLog().WriteString("\n")
+
+	parser, err := parseRoutes(cwd, cmd.PolicyCfgFile, doc.WithDebug(cmd.Verbose))
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range doc.FindRouteConflicts(parser.Routes) {
+		f.GetLog().Warnf("route %s conflicts: %s", conflict.Route, conflict.Reason)
+	}
+
+	sources, err := routeSources(cwd, parser, cmd.Split)
+	if err != nil {
+		return err
+	}
+	stale, err := staleRouteFiles(cwd, sources)
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	for _, file := range sortedFiles(sources) {
+		if err := ioutil.WriteFile(file, sources[file], 0644); err != nil {
+			return err
+		}
+	}
+
+	f.GetLog().WriteString("\n")
+	f.GetLog().Done("Refresh route successful")
+
+	return nil
+}
+
+// parseRoutes parses the routes of the handlers in api/rest of cwd, sorted in
+// the order they are registered, with the policy config of policyCfgFile if
+// it exists.
+func parseRoutes(cwd, policyCfgFile string, options ...func(*doc.Parser)) (*doc.Parser, error) {
 	searchDir := fmt.Sprintf("%s/api/rest", cwd)
 
+	if policyCfgFile != "" {
+		found, err := file.PathExists(policyCfgFile)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			cfg, err := doc.LoadPolicyConfig(policyCfgFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, doc.WithPolicyConfig(cfg))
+		}
+	}
+
 	pkgs := doc.NewPackagesDefinitions()
-	parser := doc.NewParser(
+	parser := doc.NewParser(append([]func(*doc.Parser){
 		doc.WithWorkDir(cwd),
-		doc.WithDebug(cmd.Verbose),
 		doc.WithPackagesDefinitions(pkgs),
-		doc.WithExcludedDirsAndFiles(fmt.Sprintf("%s/hello", searchDir)))
+		doc.WithExcludedDirsAndFiles(fmt.Sprintf("%s/hello", searchDir)),
+		doc.WithParseCache(cache.Open(cwd)),
+		doc.WithDeclarationsOnly(true),
+	}, options...)...)
 
-	f.GetLog().Info("start scanning rest path...")
-	f.GetLog().WriteString("\n")
+	if err := parser.ParseAPI(searchDir); err != nil {
+		return nil, fmt.Errorf("%s. you can use `-v` flag to show debug info", err.Error())
+	}
+	if err := parser.Packages.RangeFiles(parser.ParseAPIInfo); err != nil {
+		return nil, fmt.Errorf("%s. you can use `-v` flag to show debug info", err.Error())
+	}
+	parser.AddPreflightRoutes()
+	parser.SortRoutes()
 
-	err = parser.ParseAPI(searchDir)
-	if err != nil {
-		return fmt.Errorf("%s. you can use `-v` flag to show debug info", err.Error())
+	return parser, nil
+}
+
+func routeFile(cwd string) string {
+	return fmt.Sprintf("%s/api/rest/%s", cwd, doc.APIDefinitionName)
+}
+
+// routeSources returns the sources of the files registering the routes
+// parsed by parser, keyed by their paths: api/rest/api.go, and when split
+// the routes_gen.go of each rest package.
+func routeSources(cwd string, parser *doc.Parser, split bool) (map[string][]byte, error) {
+	sources := make(map[string][]byte)
+	if !split {
+		source, err := routeSource(cwd, parser)
+		if err != nil {
+			return nil, err
+		}
+		sources[routeFile(cwd)] = source
+
+		return sources, nil
 	}
 
-	if err = parser.Packages.RangeFiles(parser.ParseAPIInfo); err != nil {
-		return fmt.Errorf("%s you can use `-v` flag to show debug info", err.Error())
+	var pkgPaths []string
+	pkgRoutes := make(map[string][]*doc.Route)
+	for _, route := range parser.Routes {
+		if _, ok := pkgRoutes[route.PackagePath]; !ok {
+			pkgPaths = append(pkgPaths, route.PackagePath)
+		}
+		pkgRoutes[route.PackagePath] = append(pkgRoutes[route.PackagePath], route)
 	}
+	sort.Strings(pkgPaths)
 
-	sort.Strings(parser.Apis)
-	sortAPIs := make([]string, 0, len(parser.Apis))
-	for _, api := range parser.Apis {
-		sortAPIs = append(sortAPIs, parser.ApiMap[api])
+	for _, pkgPath := range pkgPaths {
+		routes := pkgRoutes[pkgPath]
+		source, err := packageRouteSource(cwd, parser, routes)
+		if err != nil {
+			return nil, err
+		}
+		sources[filepath.Join(cwd, filepath.Dir(routes[0].File), doc.RouteRegistrationName)] = source
 	}
 
+	g := generator.NewGenerator()
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	g.P("package rest")
+	g.P()
+	if len(pkgPaths) > 0 {
+		g.P("import (")
+		for _, pkgPath := range pkgPaths {
+			route := pkgRoutes[pkgPath][0]
+			if name := packageName(route); name != route.PackageName {
+				g.P(name, " ", strconv.Quote(pkgPath))
+			} else {
+				g.P(strconv.Quote(pkgPath))
+			}
+		}
+		g.P(")")
+		g.P()
+	}
+	g.P("func registerRoute() {")
+	for _, pkgPath := range pkgPaths {
+		g.P(packageName(pkgRoutes[pkgPath][0]), ".Register(route)")
+	}
+	g.P("}")
+
+	source, err := g.Source()
+	if err != nil {
+		return nil, err
+	}
+	sources[routeFile(cwd)] = source
+
+	return sources, nil
+}
+
+// routeSource returns the source of the route file registering the routes
+// parsed by parser.
+func routeSource(cwd string, parser *doc.Parser) ([]byte, error) {
 	modeName := util.GetModuleName(cwd)
 
 	imports := parser.ImportPaths
 	if parser.IsHasMiddleware {
 		imports = append(imports, fmt.Sprintf("\"%s/%s\"", modeName, "pkg/middleware"))
 	}
+	versions := parser.RouteVersions()
+	if len(versions) > 0 {
+		imports = append(imports, strconv.Quote("github.com/gin-gonic/gin"))
+	}
 
 	g := generator.NewGenerator()
 	g.P("// Code generated by ginctl. DO NOT EDIT.")
@@ -94,20 +278,288 @@ func (cmd *RefreshCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []st
 		}
 	}
 	g.P()
-	g.P("func registerRoute() {")
-	for _, sortAPI := range sortAPIs {
-		g.P(sortAPI)
+	err := writeRouteFuncs(g, "func registerRoute() {", parser.Routes, &routeNames{
+		group: func(group *doc.RouteGroup) string {
+			return group.Var
+		},
+		controller: func(controller *doc.Controller) string {
+			return controller.Var
+		},
+		constructor: func(route *doc.Route) string {
+			return packageName(route) + "." + route.Controller.Constructor
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
-	g.P("}")
 	g.P()
-	file := fmt.Sprintf("%s/api/rest/api.go", cwd)
-	os.Remove(file)
-	if err := g.GenerateFile(file); err != nil {
-		return err
+
+	return g.Source()
+}
+
+// packageRouteSource returns the source of the routes_gen.go registering
+// routes, which are the ones of a rest package, by its Register.
+func packageRouteSource(cwd string, parser *doc.Parser, routes []*doc.Route) ([]byte, error) {
+	// the imports of all the routes, of which the ones the package doesn't
+	// use are removed after generating it
+	imports := []string{strconv.Quote("github.com/gin-gonic/gin")}
+	if parser.IsHasMiddleware {
+		imports = append(imports, strconv.Quote(util.GetModuleName(cwd)+"/pkg/middleware"))
+	}
+	for _, imp := range parser.ImportPaths {
+		if !strings.HasSuffix(imp, strconv.Quote(routes[0].PackagePath)) {
+			imports = append(imports, imp)
+		}
 	}
 
-	f.GetLog().WriteString("\n")
-	f.GetLog().Done("Refresh route successful")
+	// the groups are named in the package, so that the groups of the other
+	// packages don't rename them
+	groupVars := make(map[*doc.RouteGroup]string)
+	taken := make(map[string]bool)
+	for _, route := range routes {
+		group := route.RouteGroup
+		if group == nil {
+			continue
+		}
+		if _, ok := groupVars[group]; ok {
+			continue
+		}
+		name := group.Name
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", group.Name, i)
+		}
+		taken[name] = true
+		groupVars[group] = name
+	}
+
+	g := generator.NewGenerator()
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	g.P("package ", routes[0].PackageName)
+	g.P()
+	g.P("import (")
+	for _, imp := range imports {
+		g.P(imp)
+	}
+	g.P(")")
+	g.P()
+	g.P("// Register registers the routes of the handlers in the package on route.")
+	err := writeRouteFuncs(g, "func Register(route gin.IRouter) {", routes, &routeNames{
+		group: func(group *doc.RouteGroup) string {
+			return groupVars[group]
+		},
+		controller: func(controller *doc.Controller) string {
+			return controller.Name
+		},
+		constructor: func(route *doc.Route) string {
+			return route.Controller.Constructor
+		},
+		inPackage: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := g.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneImports(source)
+}
+
+// routeNames are how a generated route file refers to the groups and
+// controllers of the routes.
+type routeNames struct {
+	group      func(*doc.RouteGroup) string
+	controller func(*doc.Controller) string
+	// constructor returns the call constructing the controller of route,
+	// without its parentheses.
+	constructor func(route *doc.Route) string
+	// inPackage reports whether the file is in the package of the routes.
+	inPackage bool
+}
+
+// handler returns the handler of route, which is the method of its
+// controller, the func in the file of its package or the one of the package.
+func (names *routeNames) handler(route *doc.Route) string {
+	switch {
+	case route.Controller != nil:
+		return names.controller(route.Controller) + "." + route.Func
+	case names.inPackage:
+		return route.Func
+	default:
+		return route.Handler
+	}
+}
+
+// writeRouteFuncs writes the func of signature registering routes, with
+// the funcs registering the routes of each version.
+func writeRouteFuncs(g *generator.Generator, signature string, routes []*doc.Route, names *routeNames) error {
+	versions := doc.RouteVersions(routes)
+
+	g.P(signature)
+	written, err := writeRoutes(g, routes, "", names)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if written {
+			g.P()
+			written = false
+		}
+		g.P(versionFuncName(version), "(route.Group(", strconv.Quote("/"+version), "))")
+	}
+	g.P("}")
+	for _, version := range versions {
+		g.P()
+		g.P("func ", versionFuncName(version), "(route gin.IRouter) {")
+		if _, err := writeRoutes(g, routes, version, names); err != nil {
+			return err
+		}
+		g.P("}")
+	}
 
 	return nil
 }
+
+// writeRoutes writes the statements registering the routes of version,
+// after the ones constructing their controllers, reporting whether any is
+// written. The routes of a group are written in one block, so they must be
+// next to each other as doc.SortRoutes sorts them.
+func writeRoutes(g *generator.Generator, routes []*doc.Route, version string, names *routeNames) (bool, error) {
+	constructed := make(map[*doc.Controller]bool)
+	for _, route := range routes {
+		if route.Version != version || route.Controller == nil || constructed[route.Controller] {
+			continue
+		}
+		constructed[route.Controller] = true
+		g.P(names.controller(route.Controller), " := ", names.constructor(route), "()")
+	}
+	if len(constructed) > 0 {
+		g.P()
+	}
+
+	var (
+		written bool
+		group   *doc.RouteGroup
+		closed  = make(map[*doc.RouteGroup]bool)
+	)
+	for _, route := range routes {
+		if route.Version != version {
+			continue
+		}
+		if route.RouteGroup != group {
+			if group != nil {
+				g.P("}")
+				closed[group] = true
+			}
+			group = route.RouteGroup
+			if written {
+				g.P()
+			}
+			if group != nil {
+				if closed[group] {
+					return false, fmt.Errorf("routes of group %s are not next to each other, which is declared once", group.Prefix)
+				}
+				args := append([]string{strconv.Quote(group.Prefix)}, group.Middlewares...)
+				g.P(names.group(group), " := route.Group(", strings.Join(args, ", "), ")")
+				g.P("{")
+			}
+		}
+		router := "route"
+		if group != nil {
+			router = names.group(group)
+		}
+		g.P(route.Statement(router, names.handler(route)))
+		written = true
+	}
+	if group != nil {
+		g.P("}")
+	}
+
+	return written, nil
+}
+
+// versionFuncName returns the func registering the routes of version, e.g.
+// registerRouteV1.
+func versionFuncName(version string) string {
+	return "registerRoute" + strings.ToUpper(version[:1]) + version[1:]
+}
+
+// packageName returns the name the routes of the rest package of route are
+// registered by in api.go.
+func packageName(route *doc.Route) string {
+	return strings.SplitN(route.Handler, ".", 2)[0]
+}
+
+// pruneImports removes the imports source doesn't use.
+func pruneImports(source []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	astFile, err := goparser.ParseFile(fset, "", source, goparser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, importSpec := range append([]*ast.ImportSpec(nil), astFile.Imports...) {
+		path, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		if astutil.UsesImport(astFile, path) {
+			continue
+		}
+		var name string
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, astFile, name, path)
+	}
+
+	var buf bytes.Buffer
+	if err := (&printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}).Fprint(&buf, fset, astFile); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// staleRouteFiles returns the routes_gen.go generated in api/rest of cwd
+// which aren't in sources.
+func staleRouteFiles(cwd string, sources map[string][]byte) ([]string, error) {
+	var stale []string
+	err := filepath.Walk(filepath.Join(cwd, "api", "rest"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || info.Name() != doc.RouteRegistrationName {
+			return nil
+		}
+		if _, ok := sources[path]; ok {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(content, []byte("// Code generated by ginctl. DO NOT EDIT.")) {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+
+	return stale, err
+}
+
+// sortedFiles returns the paths of sources in order.
+func sortedFiles(sources map[string][]byte) []string {
+	files := make([]string, 0, len(sources))
+	for file := range sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files
+}
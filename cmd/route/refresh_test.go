@@ -0,0 +1,171 @@
+package route
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-season/ginctl/pkg/generator"
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func TestMain(m *testing.M) {
+	os.Setenv("GINCTL_CACHE", "off")
+	os.Exit(m.Run())
+}
+
+func TestRouteSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		split  bool
+		golden string
+	}{
+		{name: "api.go", golden: "testdata/golden/api"},
+		{name: "split", split: true, golden: "testdata/golden/split"},
+	}
+	for _, tt := range tests {
+		cwd := copyProject(t)
+		defer os.RemoveAll(cwd)
+
+		sources := projectRouteSources(t, cwd, tt.split)
+		if *update {
+			os.RemoveAll(tt.golden)
+		}
+		for file, source := range sources {
+			golden := filepath.Join(tt.golden, strings.TrimPrefix(file, cwd+"/"))
+			if *update {
+				writeFile(t, golden, source)
+				continue
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if !bytes.Equal(source, want) {
+				t.Errorf("%s: %s differs from %s:\n%s", tt.name, file, golden, source)
+			}
+		}
+
+		err := filepath.Walk(tt.golden, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			if _, ok := sources[filepath.Join(cwd, strings.TrimPrefix(path, tt.golden+"/"))]; !ok {
+				t.Errorf("%s: %s is not generated", tt.name, path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteRoutes(t *testing.T) {
+	group := &doc.RouteGroup{Prefix: "/user", Var: "userGroup"}
+	names := &routeNames{
+		group: func(group *doc.RouteGroup) string {
+			return group.Var
+		},
+	}
+	tests := []struct {
+		name   string
+		routes []*doc.Route
+		want   string
+		err    string
+	}{
+		{
+			name: "ungrouped then grouped",
+			routes: []*doc.Route{
+				{Method: "GET", Handler: "order.GetOrder"},
+				{Method: "GET", Handler: "user.GetUser", RouteGroup: group},
+				{Method: "POST", Handler: "user.AddUser", RouteGroup: group},
+			},
+			want: `route.GET("", order.GetOrder)
+
+userGroup := route.Group("/user")
+{
+userGroup.GET("", user.GetUser)
+userGroup.POST("", user.AddUser)
+}
+`,
+		},
+		{
+			name: "split group",
+			routes: []*doc.Route{
+				{Method: "GET", Handler: "user.GetUser", RouteGroup: group},
+				{Method: "GET", Handler: "order.GetOrder"},
+				{Method: "POST", Handler: "user.AddUser", RouteGroup: group},
+			},
+			err: "routes of group /user are not next to each other",
+		},
+	}
+	for _, tt := range tests {
+		g := generator.NewGenerator()
+		_, err := writeRoutes(g, tt.routes, "", names)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: writeRoutes error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: writeRoutes error = %v", tt.name, err)
+			continue
+		}
+		if got := g.String(); got != tt.want {
+			t.Errorf("%s: writeRoutes = \n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// projectRouteSources generates the route files of the project in cwd.
+func projectRouteSources(t *testing.T, cwd string, split bool) map[string][]byte {
+	t.Helper()
+	parser, err := parseRoutes(cwd, filepath.Join(cwd, "routepolicy.yaml"), doc.WithQuiet(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources, err := routeSources(cwd, parser, split)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sources
+}
+
+// copyProject copies testdata/project into a temporary directory, which is
+// returned.
+func copyProject(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ginctl-route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// resolve symlinks like /tmp on darwin, as the routes are relative to
+	// the module root go reports
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	copyDir(t, filepath.Join("testdata", "project"), dir)
+
+	return dir
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -14,6 +14,8 @@ func NewRouteCmd(f factory.Factory) *cobra.Command {
 	}
 
 	routeCmd.AddCommand(NewRefreshCmd(f))
+	routeCmd.AddCommand(NewListCmd(f))
+	routeCmd.AddCommand(NewCheckCmd(f))
 
 	return routeCmd
 }
@@ -0,0 +1,48 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package rest
+
+import (
+	"demo/api/rest/admin"
+	"demo/api/rest/item"
+	"demo/api/rest/order"
+	"demo/api/rest/user"
+	v2user "demo/api/rest/v2/user"
+	"demo/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func registerRoute() {
+	itemItemController := item.NewItemController()
+
+	route.GET("/item/:id", middleware.RateLimit("100/m"), itemItemController.GetItem)
+	route.PUT("/item/:id", middleware.RateLimit("100/m"), itemItemController.GetItem)
+	route.Any("/items", itemItemController.ListItem)
+
+	adminV1Group := route.Group("/admin/v1", auth.Required, audit.Log())
+	{
+		adminV1Group.GET("", admin.Index)
+		adminV1Group.GET("/stat", admin.Stat)
+	}
+
+	userGroup := route.Group("/user", auth.Required)
+	{
+		userGroup.POST("/:id", middleware.Auth("role=admin"), middleware.Trace, user.AddUser)
+		userGroup.GET("/list", middleware.CORS(), user.GetUserList)
+		userGroup.OPTIONS("/list", middleware.CORS())
+	}
+
+	registerRouteV1(route.Group("/v1"))
+	registerRouteV2(route.Group("/v2"))
+}
+
+func registerRouteV1(route gin.IRouter) {
+	route.GET("/order/:id", order.GetOrder)
+}
+
+func registerRouteV2(route gin.IRouter) {
+	userGroup2 := route.Group("/user", auth.Required)
+	{
+		userGroup2.GET("/list", v2user.GetUserList)
+	}
+}
@@ -0,0 +1,16 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers the routes of the handlers in the package on route.
+func Register(route gin.IRouter) {
+	adminV1Group := route.Group("/admin/v1", auth.Required, audit.Log())
+	{
+		adminV1Group.GET("", Index)
+		adminV1Group.GET("/stat", Stat)
+	}
+}
@@ -0,0 +1,17 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package item
+
+import (
+	"demo/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers the routes of the handlers in the package on route.
+func Register(route gin.IRouter) {
+	itemController := NewItemController()
+
+	route.GET("/item/:id", middleware.RateLimit("100/m"), itemController.GetItem)
+	route.PUT("/item/:id", middleware.RateLimit("100/m"), itemController.GetItem)
+	route.Any("/items", itemController.ListItem)
+}
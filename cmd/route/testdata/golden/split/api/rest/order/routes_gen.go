@@ -0,0 +1,16 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers the routes of the handlers in the package on route.
+func Register(route gin.IRouter) {
+	registerRouteV1(route.Group("/v1"))
+}
+
+func registerRouteV1(route gin.IRouter) {
+	route.GET("/order/:id", GetOrder)
+}
@@ -0,0 +1,18 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package user
+
+import (
+	"demo/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers the routes of the handlers in the package on route.
+func Register(route gin.IRouter) {
+	userGroup := route.Group("/user", auth.Required)
+	{
+		userGroup.POST("/:id", middleware.Auth("role=admin"), middleware.Trace, AddUser)
+		userGroup.GET("/list", middleware.CORS(), GetUserList)
+		userGroup.OPTIONS("/list", middleware.CORS())
+	}
+}
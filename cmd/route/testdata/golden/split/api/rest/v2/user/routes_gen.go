@@ -0,0 +1,19 @@
+// Code generated by ginctl. DO NOT EDIT.
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers the routes of the handlers in the package on route.
+func Register(route gin.IRouter) {
+	registerRouteV2(route.Group("/v2"))
+}
+
+func registerRouteV2(route gin.IRouter) {
+	userGroup := route.Group("/user", auth.Required)
+	{
+		userGroup.GET("/list", GetUserList)
+	}
+}
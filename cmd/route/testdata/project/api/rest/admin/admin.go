@@ -0,0 +1,10 @@
+// @Group /admin/v1 auth.Required, audit.Log()
+package admin
+
+// 统计
+// @Router /admin/v1/stat [GET]
+func Stat() {}
+
+// 首页
+// @Router /admin/v1 [GET]
+func Index() {}
@@ -0,0 +1,17 @@
+// @Controller ItemController
+package item
+
+type ItemController struct{}
+
+func NewItemController() *ItemController {
+	return &ItemController{}
+}
+
+// 商品
+// @Router /item/:id [GET,PUT]
+// @RateLimit 100/m
+func (ctl *ItemController) GetItem() {}
+
+// 商品列表
+// @Router /items [Any]
+func (ctl *ItemController) ListItem() {}
@@ -0,0 +1,6 @@
+// @Version v1
+package order
+
+// 订单
+// @Router /order/:id [GET]
+func GetOrder() {}
@@ -0,0 +1,3 @@
+// Package user handles the users.
+// @Group /user auth.Required
+package user
@@ -0,0 +1,12 @@
+package user
+
+// 用户列表
+// @Router /user/list [GET]
+// @CORS
+func GetUserList() {}
+
+// 添加用户
+// @Router /user/:id [POST]
+// @Auth role=admin
+// @BeforeMiddleware middleware.Trace
+func AddUser() {}
@@ -0,0 +1,6 @@
+// @Group /user auth.Required
+package user
+
+// 用户列表v2
+// @Router /user/list [GET]
+func GetUserList() {}
@@ -75,7 +75,7 @@ func (cmd *runCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string
 	addBuildFileToIgnoreIfNotIn(appPath)
 
 	var paths []string
-	cmd.readAppDir(appPath, &paths)
+	readAppDir(appPath, &paths)
 	appname = path.Base(appPath)
 
 	mainFile := defaultMainFile
@@ -92,7 +92,9 @@ func (cmd *runCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string
 
 	files := []string{mainFile}
 
-	cmd.newWatcher(paths, files)
+	newWatcher(cmd.log, paths, false, func() {
+		cmd.autoBuild(files)
+	})
 	cmd.autoBuild(files)
 
 	for {
@@ -101,7 +103,8 @@ func (cmd *runCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string
 	}
 }
 
-func (cmd *runCmd) readAppDir(dir string, paths *[]string) {
+// readAppDir collects the directories under dir containing go files.
+func readAppDir(dir string, paths *[]string) {
 	fileInfos, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return
@@ -116,7 +119,7 @@ func (cmd *runCmd) readAppDir(dir string, paths *[]string) {
 			continue
 		}
 		if fileInfo.IsDir() && fileInfo.Name()[0:1] != "." {
-			cmd.readAppDir(dir+"/"+fileInfo.Name(), paths)
+			readAppDir(dir+"/"+fileInfo.Name(), paths)
 			continue
 		}
 		if useDir {
@@ -129,10 +132,19 @@ func (cmd *runCmd) readAppDir(dir string, paths *[]string) {
 	}
 }
 
-func (cmd *runCmd) newWatcher(paths []string, files []string) {
+// newWatcher calls onChange a second after the go files in paths change.
+// With watchCreatedDirs, the directories created in paths are watched as
+// well, so that new packages are picked up.
+func newWatcher(logger log.Logger, paths []string, watchCreatedDirs bool, onChange func()) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		cmd.log.Failf("Failed to create watcher: %s", err)
+		logger.Failf("Failed to create watcher: %s", err)
+	}
+
+	schedule := func() {
+		scheduleTime = time.Now().Add(1 * time.Second)
+		time.Sleep(time.Until(scheduleTime))
+		onChange()
 	}
 
 	go func() {
@@ -141,45 +153,83 @@ func (cmd *runCmd) newWatcher(paths []string, files []string) {
 			case e := <-watcher.Events:
 				isBuild := true
 
-				if cmd.shouldIgnoreFile(e.Name) {
+				if watchCreatedDirs && e.Op&fsnotify.Create == fsnotify.Create {
+					if info, err := os.Stat(e.Name); err == nil && info.IsDir() {
+						if watchDir(logger, watcher, e.Name) {
+							logger.Infof("Event fired: %s", e)
+							go schedule()
+						}
+						continue
+					}
+				}
+
+				if shouldIgnoreFile(logger, e.Name) {
 					continue
 				}
 
-				if !cmd.shouldWatchFileWithExtension(e.Name) {
+				if !shouldWatchFileWithExtension(e.Name) {
 					continue
 				}
 
 				mt := file.GetFileModTime(e.Name)
 				if t := eventTime[e.Name]; mt == t {
-					cmd.log.Infof("Skipping: %s", e.String())
+					logger.Infof("Skipping: %s", e.String())
 					isBuild = false
 				}
 
 				eventTime[e.Name] = mt
 
 				if isBuild {
-					cmd.log.Infof("Event fired: %s", e)
-					go func() {
-						scheduleTime = time.Now().Add(1 * time.Second)
-						time.Sleep(time.Until(scheduleTime))
-						cmd.autoBuild(files)
-					}()
+					logger.Infof("Event fired: %s", e)
+					go schedule()
 				}
 			case err := <-watcher.Errors:
-				cmd.log.Warnf("Watcher error: %s", err.Error())
+				logger.Warnf("Watcher error: %s", err.Error())
 			}
 		}
 	}()
-	cmd.log.Infof("Initializing watcher...")
+	logger.Infof("Initializing watcher...")
 	for _, p := range paths {
-		cmd.log.Infof("Watching: %s", p)
+		logger.Infof("Watching: %s", p)
 		err = watcher.Add(p)
 		if err != nil {
-			cmd.log.Fatalf("Failed to watch directory: %s", err)
+			logger.Fatalf("Failed to watch directory: %s", err)
 		}
 	}
 }
 
+// watchDir watches the directory dir created after the watcher started and
+// the ones under it, skipped the way readAppDir skips them. It reports
+// whether they already hold watched files, e.g. created by mkdir -p and a
+// copy before dir was watched, which fire no event.
+func watchDir(logger log.Logger, watcher *fsnotify.Watcher, dir string) bool {
+	name := path.Base(dir)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "docs") || strings.HasSuffix(name, "cron") {
+		return false
+	}
+
+	logger.Infof("Watching: %s", dir)
+	if err := watcher.Add(dir); err != nil {
+		logger.Warnf("Failed to watch directory: %s", err)
+		return false
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	changed := false
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() {
+			changed = watchDir(logger, watcher, path.Join(dir, fileInfo.Name())) || changed
+		} else if shouldWatchFileWithExtension(fileInfo.Name()) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
 func (cmd *runCmd) autoBuild(files []string) {
 	var (
 		err    error
@@ -262,11 +312,11 @@ func (cmd *runCmd) start(appname string) {
 	}
 }
 
-func (cmd *runCmd) shouldIgnoreFile(filename string) bool {
+func shouldIgnoreFile(logger log.Logger, filename string) bool {
 	for _, regex := range ignoredFilesRegExps {
 		r, err := regexp.Compile(regex)
 		if err != nil {
-			cmd.log.Fatalf("Could not compile regular expression: %s", err)
+			logger.Fatalf("Could not compile regular expression: %s", err)
 		}
 		if r.MatchString(filename) {
 			return true
@@ -276,7 +326,7 @@ func (cmd *runCmd) shouldIgnoreFile(filename string) bool {
 	return false
 }
 
-func (cmd *runCmd) shouldWatchFileWithExtension(name string) bool {
+func shouldWatchFileWithExtension(name string) bool {
 	for _, s := range watchExts {
 		if strings.HasSuffix(name, s) {
 			return true
@@ -14,6 +14,7 @@ import (
 
 	"github.com/mgutz/ansi"
 
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
 	"github.com/go-season/ginctl/pkg/util/file"
 	"github.com/go-season/ginctl/pkg/util/str"
 
@@ -480,7 +481,8 @@ func fillFields(fields []*ast.Field, options fieldMap, cache map[string]fieldMap
 }
 
 func makeRouteMap(file string) (fieldMap, error) {
-	fileTree, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ParseComments)
+	fset := token.NewFileSet()
+	fileTree, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -489,24 +491,16 @@ func makeRouteMap(file string) (fieldMap, error) {
 
 	for _, decl := range fileTree.Decls {
 		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			if funcDecl.Doc != nil {
-				comments := funcDecl.Doc.List
-				var httpMethod string
-				var route string
-				for _, comment := range comments {
-					commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-					if !strings.Contains(commentLine, "@") {
-						continue
-					}
-					fields := strings.Fields(commentLine)
-					attribute := fields[0]
-					lowerAttribute := strings.ToLower(attribute)
-					if lowerAttribute == "@router" {
-						route = fields[1]
-						httpMethod = strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-						routeMap[funcDecl.Name.String()] = []string{route, httpMethod}
-					}
+			handler, err := annotation.ParseHandler(fset, funcDecl)
+			if err != nil {
+				return nil, err
+			}
+			if handler != nil {
+				route, method, err := handler.SingleRoute(fset)
+				if err != nil {
+					return nil, err
 				}
+				routeMap[handler.Name] = []string{route.Path, method}
 			}
 		}
 	}
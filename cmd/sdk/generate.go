@@ -9,6 +9,7 @@ import (
 
 	"github.com/mitchellh/go-homedir"
 
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
 	"github.com/go-season/ginctl/pkg/ginctl/sdk"
 	sdkpkg "github.com/go-season/ginctl/pkg/sdk"
 	"github.com/go-season/ginctl/pkg/util"
@@ -23,6 +24,7 @@ type GenerateCmd struct {
 	GoOut      string
 	PHPOut     string
 	VueOut     string
+	Lang       string
 	Publish    bool
 	PublishOld bool
 	Verbose    bool
@@ -50,6 +52,7 @@ func NewGenerateCmd(f factory.Factory) *cobra.Command {
 	//sdkCmd.Flags().StringVar(&cmd.GoOut, "go_out", "", "指定要生成Go版本SDK路径.")
 	//sdkCmd.Flags().StringVar(&cmd.PHPOut, "php_out", "", "指定要生成PHP版本SDK路径.")
 	//sdkCmd.Flags().StringVar(&cmd.VueOut, "vue_out", "", "指定要生成Vue版本SDK路径.")
+	sdkCmd.Flags().StringVar(&cmd.Lang, "lang", sdkpkg.LangGo, "指定要生成SDK的语言，支持go、ts，ts版本生成在sdk/ts中.")
 	sdkCmd.Flags().BoolVar(&cmd.All, "all", false, "是否指定要扫描所有的接口定义文件?")
 	sdkCmd.Flags().BoolVar(&cmd.Publish, "publish", false, "是否发布SDK到远程仓库中?")
 	sdkCmd.Flags().BoolVar(&cmd.PublishOld, "publish_old", false, "是否发布老版本SDK到远程仓库中?")
@@ -66,6 +69,13 @@ var excludeFiles = map[string]bool{
 }
 
 func (cmd *GenerateCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	if cmd.Lang != sdkpkg.LangGo && cmd.Lang != sdkpkg.LangTypeScript {
+		return fmt.Errorf("not supported language %s, expected %s or %s", cmd.Lang, sdkpkg.LangGo, sdkpkg.LangTypeScript)
+	}
+	if cmd.Lang == sdkpkg.LangTypeScript && (cmd.Publish || cmd.PublishOld) {
+		return fmt.Errorf("publishing is only supported by the %s sdk", sdkpkg.LangGo)
+	}
+
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
@@ -96,6 +106,11 @@ func (cmd *GenerateCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []s
 			return err
 		}
 	}
+	parts := strings.Split(answer, ",")
+
+	if cmd.Lang == sdkpkg.LangTypeScript {
+		return cmd.generateTS(f, wd, parts)
+	}
 
 	cmd.GoOut = fmt.Sprintf("%s/sdk/%s", wd, strings.Replace(util.GetModuleName(wd), "-", "", -1))
 	found, err := file.PathExists(cmd.GoOut)
@@ -109,7 +124,6 @@ func (cmd *GenerateCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []s
 		}
 	}
 
-	parts := strings.Split(answer, ",")
 	f.GetLog().Info("Start crafting sdk for go project...")
 	f.GetLog().WriteString(fmt.Sprintf("%s Generate project sdk...\n", time.Now().Format("2006/01/02 15:04:05")))
 	f.GetLog().WriteString(fmt.Sprintf("%s Generate general SDK Info, search dir:./api\n", time.Now().Format("2006/01/02 15:04:05")))
@@ -125,20 +139,25 @@ func (cmd *GenerateCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []s
 		}
 	}
 
+	if err := sdkpkg.GenerateCallOptions(cmd.GoOut); err != nil {
+		return err
+	}
+
+	parseCache := cache.Open(wd)
 	pkgList := make([]string, 0)
 	serviceList := make([]string, 0)
 	for _, part := range parts {
 		filePath := strings.Replace(filepath.Dir(part)+"type/"+filepath.Base(part), "rest", "typespec", 1)
 		f.GetLog().WriteString(fmt.Sprintf("%s Parsing %s\n", time.Now().Format("2006/01/02 15:04:05"), strings.TrimPrefix(filePath, "api/")))
 
-		g := sdkpkg.NewGenerator(sdkpkg.WithLogger(f.GetLog()), sdkpkg.WithWorkDir(wd), sdkpkg.WithOld(cmd.PublishOld), sdkpkg.WithPublish(cmd.Publish))
+		g := sdkpkg.NewGenerator(sdkpkg.WithLogger(f.GetLog()), sdkpkg.WithWorkDir(wd), sdkpkg.WithOld(cmd.PublishOld), sdkpkg.WithPublish(cmd.Publish), sdkpkg.WithParseCache(parseCache))
 		if err := g.Parse(part); err != nil {
 			return err
 		}
 
 		g.GenGO()
 
-		pkgList = append(pkgList, strings.Replace(g.FileName, "_", "", -1))
+		pkgList = append(pkgList, g.PackagePath())
 		serviceList = append(serviceList, g.APIName)
 	}
 
@@ -171,3 +190,50 @@ func (cmd *GenerateCmd) Run(f factory.Factory, cobraCmd *cobra.Command, args []s
 
 	return nil
 }
+
+// generateTS generates the TypeScript sdk of the rest files of parts into
+// sdk/ts, whose index.ts creates the client of the services.
+func (cmd *GenerateCmd) generateTS(f factory.Factory, wd string, parts []string) error {
+	output := fmt.Sprintf("%s/sdk/ts", wd)
+	f.GetLog().Info("Start crafting sdk for typescript project...")
+	if err := sdkpkg.GenerateTSClient(output); err != nil {
+		return err
+	}
+
+	baseTypPath := fmt.Sprintf("%s/api/typespec/base.go", wd)
+	found, err := file.PathExists(baseTypPath)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := sdkpkg.GenerateTSBase(baseTypPath, output, wd, f.GetLog()); err != nil {
+			return err
+		}
+	}
+
+	parseCache := cache.Open(wd)
+	pkgList := make([]string, 0)
+	serviceList := make([]string, 0)
+	for _, part := range parts {
+		filePath := strings.Replace(filepath.Dir(part)+"type/"+filepath.Base(part), "rest", "typespec", 1)
+		f.GetLog().WriteString(fmt.Sprintf("%s Parsing %s\n", time.Now().Format("2006/01/02 15:04:05"), strings.TrimPrefix(filePath, "api/")))
+
+		g := sdkpkg.NewGenerator(sdkpkg.WithLogger(f.GetLog()), sdkpkg.WithWorkDir(wd), sdkpkg.WithParseCache(parseCache))
+		if err := g.Parse(part); err != nil {
+			return err
+		}
+		if err := g.GenTS(output); err != nil {
+			return err
+		}
+
+		pkgList = append(pkgList, g.PackagePath())
+		serviceList = append(serviceList, g.APIName)
+	}
+
+	if err := sdkpkg.GenerateTSIndex(pkgList, serviceList, output); err != nil {
+		return err
+	}
+	f.GetLog().Donef("generate typescript sdk successful. !:)")
+
+	return nil
+}
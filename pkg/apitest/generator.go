@@ -13,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
 	"github.com/go-season/ginctl/pkg/ginctl/doc"
 	"github.com/go-season/ginctl/pkg/util"
 	"github.com/go-season/ginctl/pkg/util/file"
@@ -21,9 +23,9 @@ import (
 )
 
 type FuncDecl struct {
-	funcName          string
-	apiPath           string
-	methods           []string
+	funcName string
+	// routes are the routes of the handler, with the api version prefixed.
+	routes            []*annotation.Route
 	mocks             []string
 	requestDefinition string
 }
@@ -173,46 +175,75 @@ func (g *Generator) generateFunc() {
 		g.P("}")
 		g.P()
 
-		for _, method := range decl.methods {
-			g.P("r.", strings.ToUpper(method), "(", strconv.Quote(decl.apiPath), ", ", decl.funcName, ")")
-			g.P()
-			if http.MethodGet == method {
-				g.P("v, err := query.Values(&req)")
-				g.P("if err != nil {")
-				g.P("t.Fatal(err)")
-				g.P("}")
-				g.P()
-			}
-			g.P("apitest.New().")
-			if decl.mocks != nil {
-				mockStr := ""
-				for _, mock := range decl.mocks {
-					mockStr += "mock." + str.ToPascal(mock) + "Mock()"
-				}
-				g.P("Mocks(", mockStr, ").")
-			}
-			g.P("Handler(r).")
-			g.P(str.ToPascal(strings.ToLower(method)), "(", strconv.Quote(decl.apiPath), ").")
-			if http.MethodGet == method {
-				g.P("QueryCollection(v).")
+		hasQuery := false
+		for _, route := range decl.routes {
+			for _, method := range route.Methods {
+				g.P("r.", method, "(", strconv.Quote(route.Path), ", ", decl.funcName, ")")
+				hasQuery = hasQuery || requestMethod(method) == http.MethodGet
 			}
-			if http.MethodPost == method {
-				g.P("JSON(&req).")
-			}
-			g.P("Expect(t).")
-			g.P("Assert(utilassert.NewResponseAssertFunc(t, func(resp utilassert.Response) error {")
-			g.P("assert.Equal(t, http.StatusOK, resp.Status)")
-			g.P("return nil")
-			g.P("})).")
-			g.P("Status(http.StatusOK).")
-			g.P("End()")
+		}
+		g.P()
+		if hasQuery {
+			g.P("v, err := query.Values(&req)")
+			g.P("if err != nil {")
+			g.P("t.Fatal(err)")
+			g.P("}")
 			g.P()
 		}
+		for _, route := range decl.routes {
+			for _, method := range route.Methods {
+				g.generateRequest(decl, route.Path, requestMethod(method))
+			}
+		}
 
 		g.P("}")
 	}
 }
 
+// requestMethod returns the method the route of method is tested with, which
+// is GET for the routes registered on every method.
+func requestMethod(method string) string {
+	if method == annotation.MethodAny {
+		return http.MethodGet
+	}
+
+	return method
+}
+
+// generateRequest generates the test of the route path requested by method.
+func (g *Generator) generateRequest(decl FuncDecl, path, method string) {
+	g.P("apitest.New().")
+	if decl.mocks != nil {
+		mockStr := ""
+		for _, mock := range decl.mocks {
+			mockStr += "mock." + str.ToPascal(mock) + "Mock()"
+		}
+		g.P("Mocks(", mockStr, ").")
+	}
+	g.P("Handler(r).")
+	switch method {
+	case http.MethodHead, http.MethodOptions:
+		g.P("Method(", strconv.Quote(method), ").")
+		g.P("URL(", strconv.Quote(path), ").")
+	default:
+		g.P(str.ToPascal(strings.ToLower(method)), "(", strconv.Quote(path), ").")
+	}
+	if http.MethodGet == method {
+		g.P("QueryCollection(v).")
+	}
+	if http.MethodPost == method {
+		g.P("JSON(&req).")
+	}
+	g.P("Expect(t).")
+	g.P("Assert(utilassert.NewResponseAssertFunc(t, func(resp utilassert.Response) error {")
+	g.P("assert.Equal(t, http.StatusOK, resp.Status)")
+	g.P("return nil")
+	g.P("})).")
+	g.P("Status(http.StatusOK).")
+	g.P("End()")
+	g.P()
+}
+
 func (g *Generator) P(str ...string) {
 	for _, v := range str {
 		g.WriteString(v)
@@ -245,7 +276,13 @@ func (g *Generator) generateFile(file string) error {
 
 func (g *Generator) Parse() error {
 	pkgs := doc.NewPackagesDefinitions(doc.WithWorkdir(g.workDir))
-	parser := doc.NewParser(doc.WithPackagesDefinitions(pkgs), doc.WithWorkDir(g.workDir), doc.WithExcludedDirsAndFiles(fmt.Sprintf("%s/api/rest/hello", g.workDir)))
+	parser := doc.NewParser(
+		doc.WithPackagesDefinitions(pkgs),
+		doc.WithWorkDir(g.workDir),
+		doc.WithExcludedDirsAndFiles(fmt.Sprintf("%s/api/rest/hello", g.workDir)),
+		doc.WithParseCache(cache.Open(g.workDir)),
+		doc.WithDeclarationsOnly(true),
+	)
 
 	searchDir := fmt.Sprintf("%s/api/rest", g.workDir)
 	err := parser.ParseAPI(searchDir)
@@ -264,29 +301,30 @@ func (g *Generator) ParseInfo(info *doc.AstFileInfo, astFile *ast.File) error {
 	funcDecls := make([]FuncDecl, 0)
 	importPackages = append(importPackages, strings.Replace(info.PackagePath, "rest", "typespec", 1)+"type")
 	g.importPackages = importPackages
+	version, err := doc.FileVersion(info.Path)
+	if err != nil {
+		return err
+	}
 	for _, astDecl := range astFile.Decls {
 		var funcDecl FuncDecl
 		switch decl := astDecl.(type) {
 		case *ast.FuncDecl:
-			funcDecl.funcName = decl.Name.String()
-			for _, comment := range decl.Doc.List {
-				commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-				if !strings.Contains(commentLine, "@") {
-					continue
-				}
-				fields := strings.Fields(commentLine)
-				attribute := fields[0]
-				lowerAttribute := strings.ToLower(attribute)
-				if lowerAttribute == "@router" {
-					funcDecl.apiPath = fields[1]
-					httpMethod := strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-					funcDecl.methods = strings.Split(httpMethod, ",")
-				}
-				if lowerAttribute == "@mock" {
-					mocks := fields[1]
-					funcDecl.mocks = strings.Split(mocks, ",")
-				}
+			handler, err := annotation.ParseHandler(info.FileSet, decl)
+			if err != nil {
+				return err
+			}
+			if handler == nil {
+				continue
+			}
+			funcDecl.funcName = handler.Name
+			for _, route := range handler.Routes {
+				funcDecl.routes = append(funcDecl.routes, &annotation.Route{
+					Path:    doc.VersionPath(version, route.Path),
+					Methods: route.Methods,
+					Pos:     route.Pos,
+				})
 			}
+			funcDecl.mocks = handler.Mocks
 			for _, stmt := range decl.Body.List {
 				if v, ok := stmt.(*ast.DeclStmt); ok {
 					if vv, ok := v.Decl.(*ast.GenDecl); ok {
@@ -0,0 +1,341 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
+	"github.com/go-season/ginctl/pkg/util"
+	"github.com/go-season/ginctl/pkg/util/file"
+	"github.com/go-season/ginctl/pkg/util/log"
+)
+
+const (
+	FormatPostman  = "postman"
+	FormatInsomnia = "insomnia"
+	FormatHTTP     = "http"
+)
+
+const defaultHTTPPort = 8080
+
+type Param struct {
+	Key         string
+	Value       string
+	Description string
+}
+
+type Request struct {
+	Name        string
+	Description string
+	Method      string
+	Path        string
+	Query       []Param
+	Headers     []Param
+	Form        []Param
+	Body        string
+}
+
+type Folder struct {
+	Name     string
+	Requests []*Request
+}
+
+type Environment struct {
+	Name    string
+	BaseURL string
+}
+
+type Generator struct {
+	log     log.Logger
+	workDir string
+	parser  *doc.Parser
+
+	Name         string
+	Folders      []*Folder
+	Environments []*Environment
+
+	folderMap map[string]*Folder
+}
+
+type Option func(*Generator)
+
+func WithLogger(log log.Logger) Option {
+	return func(g *Generator) {
+		g.log = log
+	}
+}
+
+func WithWorkDir(dir string) Option {
+	return func(g *Generator) {
+		g.workDir = dir
+	}
+}
+
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		Folders:      make([]*Folder, 0),
+		Environments: make([]*Environment, 0),
+		folderMap:    make(map[string]*Folder),
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	g.Name = util.GetModeBaseName(g.workDir)
+
+	return g
+}
+
+func (g *Generator) Parse() error {
+	pkgs := doc.NewPackagesDefinitions(doc.WithWorkdir(g.workDir))
+	g.parser = doc.NewParser(
+		doc.WithPackagesDefinitions(pkgs),
+		doc.WithWorkDir(g.workDir),
+		doc.WithExcludedDirsAndFiles(fmt.Sprintf("%s/api/rest/hello", g.workDir)),
+		doc.WithParseCache(cache.Open(g.workDir)),
+		doc.WithDeclarationsOnly(true),
+	)
+
+	searchDir := fmt.Sprintf("%s/api/rest", g.workDir)
+	if err := g.parser.ParseAPI(searchDir); err != nil {
+		return err
+	}
+
+	if err := g.parser.Packages.RangeFiles(g.ParseInfo); err != nil {
+		return err
+	}
+
+	sort.Slice(g.Folders, func(i, j int) bool {
+		return g.Folders[i].Name < g.Folders[j].Name
+	})
+	for _, folder := range g.Folders {
+		sort.SliceStable(folder.Requests, func(i, j int) bool {
+			return folder.Requests[i].Path < folder.Requests[j].Path
+		})
+	}
+
+	return g.parseEnvironments()
+}
+
+func (g *Generator) ParseInfo(info *doc.AstFileInfo, astFile *ast.File) error {
+	restDir := filepath.Dir(info.Path)
+	folderName := strings.TrimPrefix(strings.TrimPrefix(restDir, g.workDir+"/api/rest"), "/")
+	if folderName == "" {
+		folderName = astFile.Name.Name
+	}
+	typeSpecDir := g.workDir + strings.Replace(strings.TrimPrefix(restDir, g.workDir), "/api/rest", "/api/typespec", 1) + "type"
+
+	folder, ok := g.folderMap[folderName]
+	if !ok {
+		folder = &Folder{Name: folderName}
+		g.folderMap[folderName] = folder
+		g.Folders = append(g.Folders, folder)
+	}
+
+	version, err := doc.FileVersion(info.Path)
+	if err != nil {
+		return err
+	}
+	for _, astDecl := range astFile.Decls {
+		decl, ok := astDecl.(*ast.FuncDecl)
+		if !ok || decl.Doc == nil {
+			continue
+		}
+
+		handler, err := annotation.ParseHandler(info.FileSet, decl)
+		if err != nil {
+			return err
+		}
+		if handler == nil {
+			continue
+		}
+		isAccept := handler.Accept != ""
+		for _, route := range handler.Routes {
+			for _, method := range route.Methods {
+				if method == doc.MethodAny {
+					method = "GET"
+				}
+				req := &Request{
+					Name:        handler.Name,
+					Description: handler.Description,
+					Method:      method,
+					Path:        doc.VersionPath(version, route.Path),
+				}
+				if err := g.fillRequest(req, typeSpecDir, handler.Name+"Request", isAccept); err != nil {
+					g.log.Warnf("skip example of %s: %v", handler.Name, err)
+				}
+				folder.Requests = append(folder.Requests, req)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fillRequest fills the query, headers and body of req with the example of
+// the request type.
+func (g *Generator) fillRequest(req *Request, typeSpecDir, typeName string, isForm bool) error {
+	tagName := "json"
+	if req.Method == "GET" || req.Method == "DELETE" || req.Method == "HEAD" || isForm {
+		tagName = "form"
+	}
+
+	fields, err := g.parser.ExampleFields(typeSpecDir, typeName, tagName)
+	if err != nil {
+		return err
+	}
+
+	body := make(map[string]interface{})
+	for _, field := range fields {
+		if field.Header != "" {
+			req.Headers = append(req.Headers, Param{Key: field.Header, Value: formatValue(field.Value), Description: field.Description})
+			continue
+		}
+		if tagName == "json" {
+			body[field.Name] = field.Value
+			continue
+		}
+
+		values := []interface{}{field.Value}
+		if list, ok := field.Value.([]interface{}); ok {
+			values = list
+		}
+		for _, value := range values {
+			param := Param{Key: field.Name, Value: formatValue(value), Description: field.Description}
+			if isForm && req.Method != "GET" {
+				req.Form = append(req.Form, param)
+			} else {
+				req.Query = append(req.Query, param)
+			}
+		}
+	}
+
+	if tagName == "json" {
+		b, err := json.MarshalIndent(body, "", "  ")
+		if err != nil {
+			return err
+		}
+		req.Body = string(b)
+		req.Headers = append(req.Headers, Param{Key: "Content-Type", Value: "application/json"})
+	} else if len(req.Form) > 0 {
+		req.Headers = append(req.Headers, Param{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+	}
+
+	return nil
+}
+
+// parseEnvironments makes an environment for every config/app_{APP_ENV}.yaml.
+func (g *Generator) parseEnvironments() error {
+	files, err := filepath.Glob(fmt.Sprintf("%s/config/app_*.yaml", g.workDir))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		var conf struct {
+			App struct {
+				HTTPPort int `yaml:"http_port"`
+			} `yaml:"app"`
+		}
+		if err := yaml.Unmarshal(b, &conf); err != nil {
+			return fmt.Errorf("parse %s failed: %v", f, err)
+		}
+		port := conf.App.HTTPPort
+		if port == 0 {
+			port = defaultHTTPPort
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "app_"), ".yaml")
+		g.Environments = append(g.Environments, &Environment{
+			Name:    name,
+			BaseURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+		})
+	}
+
+	if len(g.Environments) == 0 {
+		g.Environments = append(g.Environments, &Environment{
+			Name:    "dev",
+			BaseURL: fmt.Sprintf("http://127.0.0.1:%d", defaultHTTPPort),
+		})
+	}
+
+	return nil
+}
+
+func (g *Generator) Export(format, output string) error {
+	found, err := file.PathExists(output)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case FormatPostman:
+		return g.exportPostman(output)
+	case FormatInsomnia:
+		return g.exportInsomnia(output)
+	case FormatHTTP:
+		return g.exportHTTP(output)
+	default:
+		return fmt.Errorf("not supported %s format", format)
+	}
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// pathSegments splits path into segments, replacing gin path params with
+// variables rendered by varFmt.
+func pathSegments(path string, varFmt string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segment = fmt.Sprintf(varFmt, segment[1:])
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// exportHTTP writes a .http file per folder, as run by the JetBrains HTTP
+// client and the VS Code REST Client, along with http-client.env.json.
+func (g *Generator) exportHTTP(output string) error {
+	for _, folder := range g.Folders {
+		var buf bytes.Buffer
+		for _, req := range folder.Requests {
+			buf.WriteString(fmt.Sprintf("### %s %s\n", req.Name, req.Description))
+
+			target := "{{baseUrl}}/" + strings.Join(pathSegments(req.Path, "{{%s}}"), "/")
+			for i, q := range req.Query {
+				sep := "&"
+				if i == 0 {
+					sep = "?"
+				}
+				target += sep + url.QueryEscape(q.Key) + "=" + url.QueryEscape(q.Value)
+			}
+			buf.WriteString(fmt.Sprintf("%s %s\n", req.Method, target))
+
+			for _, header := range req.Headers {
+				buf.WriteString(fmt.Sprintf("%s: %s\n", header.Key, header.Value))
+			}
+
+			if req.Body != "" {
+				buf.WriteString("\n" + req.Body + "\n")
+			} else if len(req.Form) > 0 {
+				values := make([]string, 0, len(req.Form))
+				for _, param := range req.Form {
+					values = append(values, url.QueryEscape(param.Key)+"="+url.QueryEscape(param.Value))
+				}
+				buf.WriteString("\n" + strings.Join(values, "&") + "\n")
+			}
+			buf.WriteString("\n")
+		}
+
+		name := strings.Replace(folder.Name, "/", "_", -1)
+		if err := ioutil.WriteFile(fmt.Sprintf("%s/%s.http", output, name), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	envs := make(map[string]map[string]string)
+	for _, env := range g.Environments {
+		envs[env.Name] = map[string]string{"baseUrl": env.BaseURL}
+	}
+
+	return writeJSON(fmt.Sprintf("%s/http-client.env.json", output), envs)
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+type insomniaExport struct {
+	Type      string             `json:"_type"`
+	Format    int                `json:"__export_format"`
+	Source    string             `json:"__export_source"`
+	Resources []insomniaResource `json:"resources"`
+}
+
+type insomniaResource struct {
+	ID          string              `json:"_id"`
+	Type        string              `json:"_type"`
+	ParentID    *string             `json:"parentId"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Method      string              `json:"method,omitempty"`
+	URL         string              `json:"url,omitempty"`
+	Parameters  []insomniaParameter `json:"parameters,omitempty"`
+	Headers     []insomniaParameter `json:"headers,omitempty"`
+	Body        *insomniaBody       `json:"body,omitempty"`
+	Data        map[string]string   `json:"data,omitempty"`
+}
+
+type insomniaParameter struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+type insomniaBody struct {
+	MimeType string              `json:"mimeType"`
+	Text     string              `json:"text,omitempty"`
+	Params   []insomniaParameter `json:"params,omitempty"`
+}
+
+func (g *Generator) exportInsomnia(output string) error {
+	workspaceID := "wrk_" + g.Name
+	baseEnvID := "env_" + g.Name
+	export := insomniaExport{
+		Type:   "export",
+		Format: 4,
+		Source: "ginctl",
+		Resources: []insomniaResource{
+			{ID: workspaceID, Type: "workspace", Name: g.Name},
+			{ID: baseEnvID, Type: "environment", ParentID: &workspaceID, Name: "Base Environment", Data: map[string]string{}},
+		},
+	}
+
+	for _, env := range g.Environments {
+		export.Resources = append(export.Resources, insomniaResource{
+			ID:       fmt.Sprintf("env_%s_%s", g.Name, env.Name),
+			Type:     "environment",
+			ParentID: &baseEnvID,
+			Name:     env.Name,
+			Data:     map[string]string{"baseUrl": env.BaseURL},
+		})
+	}
+
+	for _, folder := range g.Folders {
+		folderID := fmt.Sprintf("fld_%s", strings.Replace(folder.Name, "/", "_", -1))
+		export.Resources = append(export.Resources, insomniaResource{
+			ID:       folderID,
+			Type:     "request_group",
+			ParentID: &workspaceID,
+			Name:     folder.Name,
+		})
+		for _, req := range folder.Requests {
+			parentID := folderID
+			res := insomniaResource{
+				ID:          fmt.Sprintf("req_%s_%s_%s", strings.TrimPrefix(folderID, "fld_"), req.Name, strings.ToLower(req.Method)),
+				Type:        "request",
+				ParentID:    &parentID,
+				Name:        fmt.Sprintf("%s %s", req.Name, req.Method),
+				Description: req.Description,
+				Method:      req.Method,
+				URL:         "{{ _.baseUrl }}/" + strings.Join(pathSegments(req.Path, "{{ _.%s }}"), "/"),
+				Parameters:  insomniaParameters(req.Query),
+				Headers:     insomniaParameters(req.Headers),
+			}
+			if req.Body != "" {
+				res.Body = &insomniaBody{MimeType: "application/json", Text: req.Body}
+			} else if len(req.Form) > 0 {
+				res.Body = &insomniaBody{MimeType: "application/x-www-form-urlencoded", Params: insomniaParameters(req.Form)}
+			}
+			export.Resources = append(export.Resources, res)
+		}
+	}
+
+	return writeJSON(fmt.Sprintf("%s/%s.insomnia.json", output, g.Name), export)
+}
+
+func insomniaParameters(params []Param) []insomniaParameter {
+	ips := make([]insomniaParameter, 0, len(params))
+	for _, param := range params {
+		ips = append(ips, insomniaParameter{
+			Name:        param.Key,
+			Value:       param.Value,
+			Description: param.Description,
+		})
+	}
+
+	return ips
+}
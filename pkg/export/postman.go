@@ -0,0 +1,177 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanFolder   `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanFolder struct {
+	Name string        `json:"name"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []postmanKeyValue `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Body        *postmanBody      `json:"body,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []postmanKeyValue `json:"urlencoded,omitempty"`
+	Options    *postmanOptions   `json:"options,omitempty"`
+}
+
+type postmanOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+}
+
+type postmanEnvironment struct {
+	Name   string            `json:"name"`
+	Values []postmanKeyValue `json:"values"`
+	Scope  string            `json:"_postman_variable_scope"`
+}
+
+func (g *Generator) exportPostman(output string) error {
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:   g.Name,
+			Schema: postmanSchema,
+		},
+		Item: make([]postmanFolder, 0, len(g.Folders)),
+		Variable: []postmanKeyValue{
+			{Key: "baseUrl", Value: g.Environments[0].BaseURL},
+		},
+	}
+
+	for _, folder := range g.Folders {
+		pf := postmanFolder{
+			Name: folder.Name,
+			Item: make([]postmanItem, 0, len(folder.Requests)),
+		}
+		for _, req := range folder.Requests {
+			pf.Item = append(pf.Item, postmanItem{
+				Name:    fmt.Sprintf("%s %s", req.Name, req.Method),
+				Request: g.postmanRequest(req),
+			})
+		}
+		collection.Item = append(collection.Item, pf)
+	}
+
+	if err := writeJSON(fmt.Sprintf("%s/%s.postman_collection.json", output, g.Name), collection); err != nil {
+		return err
+	}
+
+	enabled := true
+	for _, env := range g.Environments {
+		err := writeJSON(fmt.Sprintf("%s/%s.%s.postman_environment.json", output, g.Name, env.Name), postmanEnvironment{
+			Name: fmt.Sprintf("%s-%s", g.Name, env.Name),
+			Values: []postmanKeyValue{
+				{Key: "baseUrl", Value: env.BaseURL, Enabled: &enabled},
+			},
+			Scope: "environment",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) postmanRequest(req *Request) postmanRequest {
+	path := pathSegments(req.Path, ":%s")
+	pr := postmanRequest{
+		Method:      req.Method,
+		Description: req.Description,
+		Header:      postmanKeyValues(req.Headers),
+		URL: postmanURL{
+			Host:  []string{"{{baseUrl}}"},
+			Path:  path,
+			Query: postmanKeyValues(req.Query),
+		},
+	}
+
+	raw := "{{baseUrl}}/" + strings.Join(path, "/")
+	for i, q := range req.Query {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		raw += sep + q.Key + "=" + q.Value
+	}
+	pr.URL.Raw = raw
+
+	for _, segment := range path {
+		if strings.HasPrefix(segment, ":") {
+			pr.URL.Variable = append(pr.URL.Variable, postmanKeyValue{Key: segment[1:]})
+		}
+	}
+
+	if req.Body != "" {
+		pr.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     req.Body,
+			Options: &postmanOptions{},
+		}
+		pr.Body.Options.Raw.Language = "json"
+	} else if len(req.Form) > 0 {
+		pr.Body = &postmanBody{
+			Mode:       "urlencoded",
+			URLEncoded: postmanKeyValues(req.Form),
+		}
+	}
+
+	return pr
+}
+
+func postmanKeyValues(params []Param) []postmanKeyValue {
+	kvs := make([]postmanKeyValue, 0, len(params))
+	for _, param := range params {
+		kvs = append(kvs, postmanKeyValue{
+			Key:         param.Key,
+			Value:       param.Value,
+			Description: param.Description,
+		})
+	}
+
+	return kvs
+}
@@ -27,16 +27,11 @@ func (g *Generator) P(str ...string) {
 }
 
 func (g *Generator) GenerateFile(file string) error {
-	fset := token.NewFileSet()
-	original := g.Bytes()
-	fileAST, err := parser.ParseFile(fset, "", original, parser.ParseComments)
+	source, err := g.Source()
 	if err != nil {
 		return err
 	}
-	ast.SortImports(fset, fileAST)
-	g.Reset()
 
-	(&printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}).Fprint(g, fset, fileAST)
 	os.Remove(file)
 	fs, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -44,7 +39,24 @@ func (g *Generator) GenerateFile(file string) error {
 	}
 	defer fs.Close()
 
-	fs.Write(g.Bytes())
+	fs.Write(source)
 
 	return nil
 }
+
+// Source returns the formatted source of the generated code, which is reset
+// to it.
+func (g *Generator) Source() ([]byte, error) {
+	fset := token.NewFileSet()
+	original := g.Bytes()
+	fileAST, err := parser.ParseFile(fset, "", original, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	ast.SortImports(fset, fileAST)
+	g.Reset()
+
+	(&printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}).Fprint(g, fset, fileAST)
+
+	return g.Bytes(), nil
+}
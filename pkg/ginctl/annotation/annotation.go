@@ -0,0 +1,245 @@
+// Package annotation parses the annotations of the handlers in api/rest, like
+// @Router /user/:id [GET], into the model route refresh, doc, sdk, apitest
+// and export generate from, so that they read the same handlers the same
+// way. Errors are positioned at the comment of the invalid annotation.
+package annotation
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"net/http"
+	"strings"
+)
+
+// MethodAny is the method of the routes registered on every method.
+const MethodAny = "Any"
+
+// methods are the methods @Router supports, keyed by their upper case, each
+// being the name of the gin.IRoutes func registering it.
+var methods = map[string]string{
+	http.MethodGet:             http.MethodGet,
+	http.MethodPost:            http.MethodPost,
+	http.MethodPut:             http.MethodPut,
+	http.MethodPatch:           http.MethodPatch,
+	http.MethodDelete:          http.MethodDelete,
+	http.MethodHead:            http.MethodHead,
+	http.MethodOptions:         http.MethodOptions,
+	strings.ToUpper(MethodAny): MethodAny,
+}
+
+// Error is an invalid annotation.
+type Error struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pos, e.Err)
+}
+
+// Annotation is an annotation line of a handler doc, e.g. @Header X-Token.
+type Annotation struct {
+	// Name is the annotation as written, including the @.
+	Name   string
+	Params string
+	Pos    token.Pos
+}
+
+// Is reports whether a is the annotation name, which is case insensitive.
+func (a *Annotation) Is(name string) bool {
+	return strings.EqualFold(a.Name, name)
+}
+
+// Route is a route of @Router.
+type Route struct {
+	Path string
+	// Methods are the names of the gin.IRoutes funcs registering the route,
+	// e.g. GET or Any.
+	Methods []string
+	Pos     token.Pos
+}
+
+// Handler is the annotations of a handler func.
+type Handler struct {
+	Name string
+	// Receiver is the type of the receiver of a handler method, e.g.
+	// UserController, which is empty for a func.
+	Receiver string
+	// Description is the first line of the doc which isn't an annotation,
+	// which describes the calls of the handler in sdk and export.
+	Description string
+	// Summary is the last line of the doc without @, which may be empty, and
+	// which doc summarizes the operation of the handler with.
+	Summary string
+	// Accept is the params of @Accept, e.g. x-www-form-urlencoded.
+	Accept            string
+	Routes            []*Route
+	BeforeMiddlewares []string
+	AfterMiddlewares  []string
+	// Mocks are the mocks of @Mock the api test of the handler runs with.
+	Mocks []string
+	// Annotations are all the annotations in order, including the ones
+	// above, for what is only read by some generators, like @Security.
+	Annotations []*Annotation
+}
+
+// ParseHandler parses the annotations in the doc of the handler decl,
+// returning nil when it has no @Router.
+func ParseHandler(fset *token.FileSet, decl *ast.FuncDecl) (*Handler, error) {
+	if decl.Doc == nil {
+		return nil, nil
+	}
+
+	h := &Handler{Name: decl.Name.Name}
+	for _, comment := range decl.Doc.List {
+		line := strings.TrimSpace(strings.TrimLeft(comment.Text, "/"))
+		if !strings.Contains(line, "@") {
+			h.Summary = line
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "@") {
+			if h.Description == "" {
+				h.Description = line
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		a := &Annotation{
+			Name:   fields[0],
+			Params: strings.TrimSpace(strings.TrimPrefix(line, fields[0])),
+			Pos:    comment.Pos(),
+		}
+		h.Annotations = append(h.Annotations, a)
+		if err := h.parse(a); err != nil {
+			return nil, &Error{Pos: fset.Position(comment.Pos()), Err: err}
+		}
+	}
+	if len(h.Routes) == 0 {
+		return nil, nil
+	}
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		typ := decl.Recv.List[0].Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		ident, ok := typ.(*ast.Ident)
+		if !ok {
+			return nil, &Error{Pos: fset.Position(decl.Name.Pos()), Err: fmt.Errorf("unsupported receiver of %s, expected T or *T", decl.Name.Name)}
+		}
+		h.Receiver = ident.Name
+	}
+
+	return h, nil
+}
+
+func (h *Handler) parse(a *Annotation) error {
+	switch strings.ToLower(a.Name) {
+	case "@router":
+		route, err := ParseRouter(a.Params)
+		if err != nil {
+			return err
+		}
+		route.Pos = a.Pos
+		h.Routes = append(h.Routes, route)
+	case "@beforemiddleware", "@aftermiddleware":
+		middlewares := splitList(a.Params)
+		if len(middlewares) == 0 {
+			return fmt.Errorf("invalid %s, expected middleware,...", a.Name)
+		}
+		if a.Is("@beforemiddleware") {
+			h.BeforeMiddlewares = append(h.BeforeMiddlewares, middlewares...)
+		} else {
+			h.AfterMiddlewares = append(h.AfterMiddlewares, middlewares...)
+		}
+	case "@mock":
+		mocks := splitList(a.Params)
+		if len(mocks) == 0 {
+			return fmt.Errorf("invalid %s, expected mock,...", a.Name)
+		}
+		h.Mocks = append(h.Mocks, mocks...)
+	case "@accept":
+		if a.Params == "" {
+			return fmt.Errorf("invalid %s, expected a mime type like json", a.Name)
+		}
+		h.Accept = a.Params
+	}
+
+	return nil
+}
+
+// Methods returns the methods of all the routes of h, in order and without
+// duplicates.
+func (h *Handler) Methods() []string {
+	list := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, route := range h.Routes {
+		for _, method := range route.Methods {
+			if !seen[method] {
+				seen[method] = true
+				list = append(list, method)
+			}
+		}
+	}
+
+	return list
+}
+
+// SingleRoute returns the route and method of h for the generators making
+// one call of each handler, like sdk, failing at its second route or method
+// when h has several.
+func (h *Handler) SingleRoute(fset *token.FileSet) (*Route, string, error) {
+	if len(h.Routes) > 1 {
+		return nil, "", &Error{Pos: fset.Position(h.Routes[1].Pos), Err: fmt.Errorf("handler %s has more than one @Router, which is generated as one call, please route it once", h.Name)}
+	}
+	route := h.Routes[0]
+	if len(route.Methods) > 1 {
+		return nil, "", &Error{Pos: fset.Position(route.Pos), Err: fmt.Errorf("handler %s is routed on methods %s, which is generated as one call, please route it on one method", h.Name, strings.Join(route.Methods, ","))}
+	}
+
+	return route, route.Methods[0], nil
+}
+
+// ParseRouter parses the params of @Router, which are path [method,...],
+// e.g. /user/:id [GET,PUT]. Methods are case insensitive.
+func ParseRouter(params string) (*Route, error) {
+	params = strings.TrimSpace(params)
+	index := strings.Index(params, "[")
+	if index == -1 || !strings.HasSuffix(params, "]") {
+		return nil, fmt.Errorf("invalid @Router %s, expected path [method,...]", params)
+	}
+	path := strings.TrimSpace(params[:index])
+	if path == "" || strings.ContainsAny(path, " \t") {
+		return nil, fmt.Errorf("invalid path of @Router %s", params)
+	}
+
+	route := &Route{Path: path}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(params[index+1:len(params)-1], ",") {
+		method, ok := methods[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unsupported method %s of @Router %s, expected GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS or Any", strings.TrimSpace(name), params)
+		}
+		if !seen[method] {
+			seen[method] = true
+			route.Methods = append(route.Methods, method)
+		}
+	}
+
+	return route, nil
+}
+
+// splitList splits the comma separated params of an annotation.
+func splitList(params string) []string {
+	var list []string
+	for _, item := range strings.Split(params, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,221 @@
+package annotation
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRouter(t *testing.T) {
+	tests := []struct {
+		params  string
+		path    string
+		methods []string
+		err     string
+	}{
+		{params: "/user/:id [GET]", path: "/user/:id", methods: []string{"GET"}},
+		{params: " /user [get, Post] ", path: "/user", methods: []string{"GET", "POST"}},
+		{params: "/user [GET,get]", path: "/user", methods: []string{"GET"}},
+		{params: "/user [any]", path: "/user", methods: []string{MethodAny}},
+		{params: "/user [HEAD,OPTIONS,PATCH]", path: "/user", methods: []string{"HEAD", "OPTIONS", "PATCH"}},
+		{params: "/user", err: "invalid @Router /user"},
+		{params: "/user [GET", err: "invalid @Router /user [GET"},
+		{params: "[GET]", err: "invalid path of @Router [GET]"},
+		{params: "/a b [GET]", err: "invalid path of @Router /a b [GET]"},
+		{params: "/user [TRACE]", err: "unsupported method TRACE"},
+	}
+	for _, tt := range tests {
+		route, err := ParseRouter(tt.params)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseRouter(%q) error = %v, want %q", tt.params, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRouter(%q) error = %v", tt.params, err)
+			continue
+		}
+		if route.Path != tt.path || !reflect.DeepEqual(route.Methods, tt.methods) {
+			t.Errorf("ParseRouter(%q) = %s %v, want %s %v", tt.params, route.Path, route.Methods, tt.path, tt.methods)
+		}
+	}
+}
+
+func TestParseHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want *Handler
+		err  string
+	}{
+		{
+			name: "func",
+			src: `
+// 获取用户
+// 按id获取
+//
+// @Accept x-www-form-urlencoded
+// @Router /user/:id [GET]
+// @BeforeMiddleware auth, log
+// @AfterMiddleware audit
+// @Mock user
+func GetUser() {}`,
+			want: &Handler{
+				Name:              "GetUser",
+				Description:       "获取用户",
+				Accept:            "x-www-form-urlencoded",
+				Routes:            []*Route{{Path: "/user/:id", Methods: []string{"GET"}}},
+				BeforeMiddlewares: []string{"auth", "log"},
+				AfterMiddlewares:  []string{"audit"},
+				Mocks:             []string{"user"},
+			},
+		},
+		{
+			name: "method",
+			src: `
+// 用户列表
+// @Router /user [GET]
+// @Router /users [POST]
+func (c *UserController) List() {}`,
+			want: &Handler{
+				Name:        "List",
+				Receiver:    "UserController",
+				Description: "用户列表",
+				Summary:     "用户列表",
+				Routes: []*Route{
+					{Path: "/user", Methods: []string{"GET"}},
+					{Path: "/users", Methods: []string{"POST"}},
+				},
+			},
+		},
+		{
+			name: "no router",
+			src: `
+// 工具函数
+func helper() {}`,
+		},
+		{
+			name: "no doc",
+			src:  `func helper() {}`,
+		},
+		{
+			name: "invalid router",
+			src: `
+// @Router /user
+func GetUser() {}`,
+			err: "test.go:3:1: invalid @Router /user",
+		},
+		{
+			name: "empty middleware",
+			src: `
+// @Router /user [GET]
+// @BeforeMiddleware ,
+func GetUser() {}`,
+			err: "test.go:4:1: invalid @BeforeMiddleware",
+		},
+	}
+	for _, tt := range tests {
+		fset, decl := parseFunc(t, tt.src)
+		h, err := ParseHandler(fset, decl)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: ParseHandler error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseHandler error = %v", tt.name, err)
+			continue
+		}
+		if h != nil {
+			h.Annotations = nil
+			for _, route := range h.Routes {
+				route.Pos = token.NoPos
+			}
+		}
+		if !reflect.DeepEqual(h, tt.want) {
+			t.Errorf("%s: ParseHandler = %+v, want %+v", tt.name, h, tt.want)
+		}
+	}
+}
+
+func TestSingleRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		path   string
+		method string
+		err    string
+	}{
+		{
+			name: "single",
+			src: `
+// @Router /user [POST]
+func AddUser() {}`,
+			path:   "/user",
+			method: "POST",
+		},
+		{
+			name: "routes",
+			src: `
+// @Router /user [GET]
+// @Router /users [GET]
+func ListUser() {}`,
+			err: "test.go:4:1: handler ListUser has more than one @Router",
+		},
+		{
+			name: "methods",
+			src: `
+// @Router /user [GET,POST]
+func User() {}`,
+			err: "test.go:3:1: handler User is routed on methods GET,POST",
+		},
+	}
+	for _, tt := range tests {
+		fset, decl := parseFunc(t, tt.src)
+		h, err := ParseHandler(fset, decl)
+		if err != nil {
+			t.Fatalf("%s: ParseHandler error = %v", tt.name, err)
+		}
+		route, method, err := h.SingleRoute(fset)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: SingleRoute error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: SingleRoute error = %v", tt.name, err)
+			continue
+		}
+		if route.Path != tt.path || method != tt.method {
+			t.Errorf("%s: SingleRoute = %s %s, want %s %s", tt.name, route.Path, method, tt.path, tt.method)
+		}
+	}
+}
+
+func TestHandlerMethods(t *testing.T) {
+	h := &Handler{Routes: []*Route{
+		{Path: "/a", Methods: []string{"GET", "POST"}},
+		{Path: "/b", Methods: []string{"POST", MethodAny}},
+	}}
+	if got, want := h.Methods(), []string{"GET", "POST", MethodAny}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Methods() = %v, want %v", got, want)
+	}
+}
+
+// parseFunc parses the func declared by src in a file named test.go.
+func parseFunc(t *testing.T, src string) (*token.FileSet, *ast.FuncDecl) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", "package rest\n"+src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fset, file.Decls[0].(*ast.FuncDecl)
+}
@@ -0,0 +1,209 @@
+// Package cache is the parse cache of a project, shared by the commands
+// parsing its api/rest and api/typespec. An entry records the content hash
+// of every file and directory it is derived from, so that what is parsed
+// from unchanged files is reused and anything changed is parsed again.
+//
+// The cache of a project is in the ginctl directory of the user cache dir,
+// and is disabled by setting GINCTL_CACHE=off.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// formatVersion invalidates the entries of the previous formats.
+const formatVersion = "3"
+
+// Cache is the parse cache of a project. A nil Cache caches nothing.
+type Cache struct {
+	dir string
+	// hashes memoizes the hashes of the files and directories, which don't
+	// change while a command runs.
+	hashes map[string]string
+}
+
+type entry struct {
+	Key  string            `json:"key"`
+	Deps map[string]string `json:"deps"`
+	Data json.RawMessage   `json:"data"`
+}
+
+// Open opens the cache of the project in cwd, returning nil when the cache is
+// disabled or there is no user cache dir.
+func Open(cwd string) *Cache {
+	if strings.ToLower(os.Getenv("GINCTL_CACHE")) == "off" {
+		return nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(cwd))
+	return &Cache{
+		dir:    filepath.Join(base, "ginctl", fmt.Sprintf("%s-%s", filepath.Base(cwd), hex.EncodeToString(sum[:6]))),
+		hashes: make(map[string]string),
+	}
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	if c == nil {
+		return ""
+	}
+
+	return c.dir
+}
+
+// Clean removes all the entries of the cache.
+func (c *Cache) Clean() error {
+	if c == nil {
+		return nil
+	}
+
+	return os.RemoveAll(c.dir)
+}
+
+// Get decodes the entry of kind and key into v, reporting whether there is
+// one and none of its dependencies changed.
+func (c *Cache) Get(kind, key string, v interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	b, err := ioutil.ReadFile(c.path(kind, key))
+	if err != nil {
+		return false
+	}
+	e := new(entry)
+	if err := json.Unmarshal(b, e); err != nil || e.Key != key {
+		return false
+	}
+	for dep, hash := range e.Deps {
+		if c.Hash(dep) != hash {
+			return false
+		}
+	}
+
+	return json.Unmarshal(e.Data, v) == nil
+}
+
+// Put stores v as the entry of kind and key, derived from the files and
+// directories deps.
+func (c *Cache) Put(kind, key string, deps []string, v interface{}) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e := &entry{
+		Key:  key,
+		Deps: make(map[string]string),
+		Data: data,
+	}
+	for _, dep := range deps {
+		e.Deps[dep] = c.Hash(dep)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// entries are replaced by rename so that concurrent commands never
+	// read a partial one
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Hash returns the content hash of a file, or of the go files of a
+// directory, which is empty when path doesn't exist.
+func (c *Cache) Hash(path string) string {
+	if c != nil {
+		if hash, ok := c.hashes[path]; ok {
+			return hash
+		}
+	}
+
+	var hash string
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			hash = dirHash(path)
+		} else {
+			hash = fileHash(path)
+		}
+	}
+	if c != nil {
+		c.hashes[path] = hash
+	}
+
+	return hash
+}
+
+func (c *Cache) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(formatVersion + "\x00" + key))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, kind, name[:2], name+".json")
+}
+
+func fileHash(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// dirHash hashes the names and contents of the go files of dir, except the
+// tests, changing when any of them is added, removed or changed.
+func dirHash(dir string) string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		if !info.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\n", name, fileHash(filepath.Join(dir, name)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	Names []string `json:"names"`
+	Count int      `json:"count"`
+}
+
+func TestGetPut(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "src", "user.go")
+	pkg := filepath.Join(dir, "src")
+	writeFile(t, file, "package user\n")
+
+	want := &testEntry{Names: []string{"a", "b"}, Count: 2}
+	if err := newCache(dir).Put("test", "user", []string{file, pkg}, want); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// change is run before reading the entry by a new cache, which is
+		// a later command.
+		change func()
+		key    string
+		found  bool
+	}{
+		{name: "unchanged", key: "user", found: true},
+		{name: "other key", key: "order"},
+		{name: "file changed", key: "user", change: func() { writeFile(t, file, "package user\n\nvar a int\n") }},
+		{name: "file restored", key: "user", change: func() { writeFile(t, file, "package user\n") }, found: true},
+		{name: "test added", key: "user", change: func() { writeFile(t, filepath.Join(pkg, "user_test.go"), "package user\n") }, found: true},
+		{name: "file added", key: "user", change: func() { writeFile(t, filepath.Join(pkg, "order.go"), "package user\n") }},
+	}
+	for _, tt := range tests {
+		if tt.change != nil {
+			tt.change()
+		}
+		got := new(testEntry)
+		found := newCache(dir).Get("test", tt.key, got)
+		if found != tt.found {
+			t.Errorf("%s: Get found = %t, want %t", tt.name, found, tt.found)
+			continue
+		}
+		if found && !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Get = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	if err := c.Put("test", "user", nil, &testEntry{}); err != nil {
+		t.Errorf("Put error = %v", err)
+	}
+	if c.Get("test", "user", new(testEntry)) {
+		t.Error("Get of nil cache found an entry")
+	}
+	if c.Dir() != "" || c.Clean() != nil {
+		t.Error("nil cache has a directory")
+	}
+}
+
+func TestOpenDisabled(t *testing.T) {
+	old, ok := os.LookupEnv("GINCTL_CACHE")
+	defer func() {
+		if ok {
+			os.Setenv("GINCTL_CACHE", old)
+		} else {
+			os.Unsetenv("GINCTL_CACHE")
+		}
+	}()
+
+	os.Setenv("GINCTL_CACHE", "OFF")
+	if c := Open("/tmp/demo"); c != nil {
+		t.Errorf("Open with GINCTL_CACHE=off = %s, want nil", c.Dir())
+	}
+}
+
+const skeletonSrc = `// Package user is the api of users.
+package user
+
+import (
+	"net/http"
+
+	ts "demo/api/typespec/usertype"
+)
+
+// 用户列表
+// @Router /user [GET]
+func GetUserList(c *gin.Context) {
+	var req ts.GetUserListRequest
+	var (
+		page, size int
+		_          = http.MethodGet
+	)
+	if true {
+		var ignored string
+	}
+}
+
+type UserController struct{}
+
+// @Router /user [POST]
+func (ctl *UserController) AddUser() {}
+
+func (UserController) helper() {}
+`
+
+func TestParseFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "user.go")
+	writeFile(t, path, skeletonSrc)
+
+	fset := token.NewFileSet()
+	want, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDecls := fileDecls(fset, want)
+
+	// the first parses the file and stores its skeleton, the second reads it
+	for i, c := range []*Cache{newCache(dir), newCache(dir), nil} {
+		fset := token.NewFileSet()
+		// the file is added after another one, so offsets don't match pos
+		fset.AddFile("other.go", -1, 100)
+		got, err := c.ParseFile(fset, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotDecls := fileDecls(fset, got); !reflect.DeepEqual(gotDecls, wantDecls) {
+			t.Errorf("ParseFile #%d = \n%s\nwant\n%s", i, strings.Join(gotDecls, "\n"), strings.Join(wantDecls, "\n"))
+		}
+	}
+	if !newCache(dir).Get("skeleton", path, new(skeleton)) {
+		t.Error("ParseFile didn't store the skeleton")
+	}
+}
+
+// fileDecls describes what ParseFile keeps of astFile, with positions.
+func fileDecls(fset *token.FileSet, astFile *ast.File) []string {
+	position := func(pos token.Pos) string {
+		p := fset.Position(pos)
+		return filepath.Base(p.Filename) + ":" + strings.TrimPrefix(p.String(), p.Filename+":")
+	}
+	source := func(node ast.Node) string {
+		var b strings.Builder
+		printer.Fprint(&b, token.NewFileSet(), node)
+		return b.String()
+	}
+
+	decls := []string{
+		"package " + astFile.Name.Name + " " + position(astFile.Name.Pos()),
+		"doc " + astFile.Doc.Text(),
+	}
+	for _, importSpec := range astFile.Imports {
+		name := ""
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name + " "
+		}
+		decls = append(decls, "import "+name+importSpec.Path.Value+" "+position(importSpec.Path.Pos()))
+	}
+	for _, decl := range astFile.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		s := "func " + fn.Name.Name + " " + position(fn.Name.Pos())
+		if fn.Recv != nil {
+			s += " recv " + source(fn.Recv.List[0].Type)
+			for _, name := range fn.Recv.List[0].Names {
+				s += " " + name.Name
+			}
+		}
+		if fn.Doc != nil {
+			for _, comment := range fn.Doc.List {
+				s += "\n\t" + comment.Text + " " + position(comment.Pos())
+			}
+		}
+		for _, stmt := range fn.Body.List {
+			declStmt, ok := stmt.(*ast.DeclStmt)
+			if !ok {
+				continue
+			}
+			for _, spec := range declStmt.Decl.(*ast.GenDecl).Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if valueSpec.Type == nil {
+					continue
+				}
+				for _, name := range valueSpec.Names {
+					s += "\n\tvar " + name.Name + " " + source(valueSpec.Type) + " " + position(name.Pos())
+				}
+			}
+		}
+		decls = append(decls, s)
+	}
+
+	return decls
+}
+
+func newCache(dir string) *Cache {
+	return &Cache{dir: filepath.Join(dir, "cache"), hashes: make(map[string]string)}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ginctl-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// skeleton is what the annotations of handlers are parsed from in a go
+// file, with the offsets of its nodes to restore their positions.
+type skeleton struct {
+	Size       int            `json:"size"`
+	Lines      []int          `json:"lines"`
+	PackagePos int            `json:"packagePos"`
+	Name       string         `json:"name"`
+	NamePos    int            `json:"namePos"`
+	Doc        []*skelComment `json:"doc,omitempty"`
+	Imports    []*skelImport  `json:"imports,omitempty"`
+	Funcs      []*skelFunc    `json:"funcs,omitempty"`
+}
+
+type skelComment struct {
+	Pos  int    `json:"pos"`
+	Text string `json:"text"`
+}
+
+type skelImport struct {
+	Pos     int    `json:"pos"`
+	Name    string `json:"name,omitempty"`
+	NamePos int    `json:"namePos,omitempty"`
+	Path    string `json:"path"`
+}
+
+type skelFunc struct {
+	Pos     int            `json:"pos"`
+	Name    string         `json:"name"`
+	NamePos int            `json:"namePos"`
+	Doc     []*skelComment `json:"doc,omitempty"`
+	// Recv is the source of the receiver type of a method.
+	Recv     string `json:"recv,omitempty"`
+	RecvName string `json:"recvName,omitempty"`
+	// Vars are the typed vars declared at the top level of the body.
+	Vars []*skelVar `json:"vars,omitempty"`
+}
+
+type skelVar struct {
+	Pos  int    `json:"pos"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ParseFile parses the declarations of the go file path into fset: its
+// package, imports and funcs with their doc and the typed vars declared at
+// the top of their bodies, which is what handlers are annotated with. The
+// positions are the ones in path, so errors point at the source, and the
+// declarations of an unchanged file are read from the cache.
+func (c *Cache) ParseFile(fset *token.FileSet, path string) (*ast.File, error) {
+	s := new(skeleton)
+	if !c.Get("skeleton", path, s) {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		srcFset := token.NewFileSet()
+		astFile, err := parser.ParseFile(srcFset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		s = newSkeleton(srcFset, astFile, src)
+		// the cache only saves parsing next time, so failing to store the
+		// declarations doesn't fail parsing them
+		_ = c.Put("skeleton", path, []string{path}, s)
+	}
+
+	return s.file(fset, path), nil
+}
+
+func newSkeleton(fset *token.FileSet, astFile *ast.File, src []byte) *skeleton {
+	tf := fset.File(astFile.Pos())
+	offset := func(pos token.Pos) int {
+		return tf.Offset(pos)
+	}
+	comments := func(group *ast.CommentGroup) []*skelComment {
+		if group == nil {
+			return nil
+		}
+		list := make([]*skelComment, 0, len(group.List))
+		for _, comment := range group.List {
+			list = append(list, &skelComment{Pos: offset(comment.Pos()), Text: comment.Text})
+		}
+		return list
+	}
+	source := func(node ast.Node) string {
+		var b strings.Builder
+		printer.Fprint(&b, fset, node)
+		return b.String()
+	}
+
+	s := &skeleton{
+		Size:       tf.Size(),
+		Lines:      fileLines(src),
+		PackagePos: offset(astFile.Package),
+		Name:       astFile.Name.Name,
+		NamePos:    offset(astFile.Name.Pos()),
+		Doc:        comments(astFile.Doc),
+	}
+	for _, importSpec := range astFile.Imports {
+		imp := &skelImport{Pos: offset(importSpec.Path.Pos()), Path: importSpec.Path.Value}
+		if importSpec.Name != nil {
+			imp.Name, imp.NamePos = importSpec.Name.Name, offset(importSpec.Name.Pos())
+		}
+		s.Imports = append(s.Imports, imp)
+	}
+	for _, decl := range astFile.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok {
+			fn := &skelFunc{
+				Pos:     offset(decl.Type.Func),
+				Name:    decl.Name.Name,
+				NamePos: offset(decl.Name.Pos()),
+				Doc:     comments(decl.Doc),
+			}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				field := decl.Recv.List[0]
+				fn.Recv = source(field.Type)
+				if len(field.Names) > 0 {
+					fn.RecvName = field.Names[0].Name
+				}
+			}
+			if decl.Body != nil {
+				for _, stmt := range decl.Body.List {
+					declStmt, ok := stmt.(*ast.DeclStmt)
+					if !ok {
+						continue
+					}
+					genDecl, ok := declStmt.Decl.(*ast.GenDecl)
+					if !ok || genDecl.Tok != token.VAR {
+						continue
+					}
+					for _, spec := range genDecl.Specs {
+						valueSpec := spec.(*ast.ValueSpec)
+						if valueSpec.Type == nil {
+							continue
+						}
+						for _, name := range valueSpec.Names {
+							fn.Vars = append(fn.Vars, &skelVar{Pos: offset(name.Pos()), Name: name.Name, Type: source(valueSpec.Type)})
+						}
+					}
+				}
+			}
+			s.Funcs = append(s.Funcs, fn)
+		}
+	}
+
+	return s
+}
+
+// file rebuilds the declarations of s as an ast file of path in fset.
+func (s *skeleton) file(fset *token.FileSet, path string) *ast.File {
+	tf := fset.AddFile(path, -1, s.Size)
+	tf.SetLines(s.Lines)
+	pos := func(offset int) token.Pos {
+		return tf.Pos(offset)
+	}
+
+	astFile := &ast.File{
+		Package: pos(s.PackagePos),
+		Name:    &ast.Ident{NamePos: pos(s.NamePos), Name: s.Name},
+	}
+	commentGroup := func(comments []*skelComment) *ast.CommentGroup {
+		if len(comments) == 0 {
+			return nil
+		}
+		group := new(ast.CommentGroup)
+		for _, comment := range comments {
+			group.List = append(group.List, &ast.Comment{Slash: pos(comment.Pos), Text: comment.Text})
+		}
+		astFile.Comments = append(astFile.Comments, group)
+		return group
+	}
+	astFile.Doc = commentGroup(s.Doc)
+
+	if len(s.Imports) > 0 {
+		importDecl := &ast.GenDecl{Tok: token.IMPORT, TokPos: pos(s.Imports[0].Pos)}
+		for _, imp := range s.Imports {
+			importSpec := &ast.ImportSpec{Path: &ast.BasicLit{ValuePos: pos(imp.Pos), Kind: token.STRING, Value: imp.Path}}
+			if imp.Name != "" {
+				importSpec.Name = &ast.Ident{NamePos: pos(imp.NamePos), Name: imp.Name}
+			}
+			importDecl.Specs = append(importDecl.Specs, importSpec)
+			astFile.Imports = append(astFile.Imports, importSpec)
+		}
+		astFile.Decls = append(astFile.Decls, importDecl)
+	}
+
+	for _, fn := range s.Funcs {
+		funcDecl := &ast.FuncDecl{
+			Doc:  commentGroup(fn.Doc),
+			Name: &ast.Ident{NamePos: pos(fn.NamePos), Name: fn.Name},
+			Type: &ast.FuncType{Func: pos(fn.Pos), Params: new(ast.FieldList)},
+			Body: new(ast.BlockStmt),
+		}
+		if fn.Recv != "" {
+			field := &ast.Field{Type: typeExpr(fn.Recv)}
+			if fn.RecvName != "" {
+				field.Names = []*ast.Ident{{NamePos: pos(fn.Pos), Name: fn.RecvName}}
+			}
+			funcDecl.Recv = &ast.FieldList{List: []*ast.Field{field}}
+		}
+		for _, v := range fn.Vars {
+			funcDecl.Body.List = append(funcDecl.Body.List, &ast.DeclStmt{Decl: &ast.GenDecl{
+				Tok:    token.VAR,
+				TokPos: pos(v.Pos),
+				Specs: []ast.Spec{&ast.ValueSpec{
+					Names: []*ast.Ident{{NamePos: pos(v.Pos), Name: v.Name}},
+					Type:  typeExpr(v.Type),
+				}},
+			}})
+		}
+		astFile.Decls = append(astFile.Decls, funcDecl)
+	}
+
+	return astFile
+}
+
+// fileLines returns the offsets of the lines of src.
+func fileLines(src []byte) []int {
+	lines := []int{0}
+	for i, b := range src {
+		if b == '\n' && i+1 < len(src) {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return lines
+}
+
+// typeExpr parses the type expression src, without positions as they are
+// not the ones of the file.
+func typeExpr(src string) ast.Expr {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return &ast.BadExpr{}
+	}
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		v := reflect.ValueOf(node)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.Type() == reflect.TypeOf(token.NoPos) && field.CanSet() {
+				field.SetInt(int64(token.NoPos))
+			}
+		}
+		return true
+	})
+
+	return expr
+}
@@ -0,0 +1,90 @@
+package doc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The assets of doc serve are generated into assets_gen.go from the dist of
+// Swagger UI 5.x (Apache-2.0) in github.com/swaggo/files/v2 v2.0.2, rather
+// than embedded, which would require go1.16.
+//go:generate go run assets_generate.go
+
+// assetsModTime is the modification time the generated assets are served
+// with, which is when ginctl started.
+var assetsModTime = time.Now()
+
+var (
+	assetsMu sync.Mutex
+	// assets memoizes the decoded generated assets.
+	assets = make(map[string][]byte)
+)
+
+// generatedAsset returns the generated asset name, which is nil when there
+// is none.
+func generatedAsset(name string) []byte {
+	assetsMu.Lock()
+	defer assetsMu.Unlock()
+
+	if b, ok := assets[name]; ok {
+		return b
+	}
+	encoded, ok := generatedAssets[name]
+	if !ok {
+		return nil
+	}
+	gz, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		panic(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		panic(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+	assets[name] = b
+
+	return b
+}
+
+// hasAsset reports whether the asset name is in dir or generated.
+func hasAsset(dir, name string) bool {
+	if dir != "" {
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return generatedAsset(name) != nil
+}
+
+// serveAsset serves the asset name in dir, falling back to the generated
+// one when dir doesn't hold it.
+func serveAsset(w http.ResponseWriter, r *http.Request, dir, name string) {
+	if dir != "" {
+		if f, err := http.Dir(dir).Open("/" + name); err == nil {
+			defer f.Close()
+			if info, err := f.Stat(); err == nil && !info.IsDir() {
+				http.ServeContent(w, r, name, info.ModTime(), f)
+				return
+			}
+		}
+	}
+
+	b := generatedAsset(name)
+	if b == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, name, assetsModTime, bytes.NewReader(b))
+}
@@ -0,0 +1,9 @@
+// Code generated by assets_generate.go; DO NOT EDIT.
+
+package doc
+
+// generatedAssets are the gzipped and base64 encoded assets, keyed by name.
+var generatedAssets = map[string]string{
+	"swagger-ui-bundle.js": "H4sIAAAAAAAC/8z9e37bONIwjP5/VkFzPGoygmTJubZkWhMrzrSfcS6PHac7LanVtATbcCRCTZCRHFPvCs4CzmbO7+zlbODbwverwoUgRTnpnnm/553pWCSISwEoFApVhaq9RzvOax47MzahkaAOi654PA8TxiNnMaOhoI6g1BHL8Pqaxo2UNS7TaDqjzVvRPD3pH789P24mq8R5tPf/2rlKowkWXNLLRTj5fBGxLzQW4ewNn6Yz+opesYhBBk8Q7t+7/PKWThI3CJK7BeVXDl0teJyIWm3jyxwr6MmfpsoXcM/vuLrRPPMU2qG1mvxthvNpTz56gxHhfmdbuz312zyXnb04OcKuYjuVqWsvuWGCeJ4fHOKf+y9h7AjCCQvun/3Ybv/Ywa4Gh/duCiOZxGySuN13CEBTQvU+5gsaJ3ceJ+54TIUcLJfcfwlnKe3stNY+4c2j05dv/zW+ODsNeDOmszBhX+hrFoukfxPG4SShsQh4c3nDEioW4YQei0m4oPBRnNFrugp4M41n55MbOqc6YZLEs7y4Tr1J5rN+Es+Oo4Qld3YqpjBqcrLoSzhj0/cxT/iEz2TyF86mTots+br3mzf4bbgcPfK92/BLKCYxWyTZNEzC7MulfPP32JxUNbhX+5s3XNZ977fhMuv6vb1rUg3tXs2L6HLGIpol4aXf3btmpLq3e4Nh2mq1Wg34ab+Gv89f48uPr4fpvvyy32q9Gqavj1+/Hu1dI2ylkdz7rVn3Olltwmc86voq0wNzsecNh9nfnw4m/ZHveX/3ng3o8Sh7vp89f+L72SCKk5GPvds20wO36RJ3zx0VMMMNL3madC5nYfTZXZP2s+dPW4iAhG2gIG/+l5qqLuBsGjAPEdbvmlU8pRM+pRdnJ57w75P47j6mSRpb6X0+X/CIRokn/PUkTCY3Hvd1LrFeQxumNhHC2v9KL+IZ1MeuvB3hq7xp3gmEhhNGxoHdfnfK73ng2YnjwGPBmLBmTBezcEK9tGqKiev6Vo4NrCKehlASJQXReRKz6Lp5FfM5VNjnU+pxf+375cpKuFdq7tsAbccSzJjEbO5Bq3Mc3soa/Swbm+8bHdz4WoK48H07NP56ecNm1OO1Gm/OaHSd3By2fJytZTDuwnQuq6aTXZkBdpg4U/h8Ec9+ZskNTxNNHgAnTPEtaN9k0ZSu3l15YtAa+YeN9tpb+rrRJcKyCpamM8VV6iOMq2L2frAatEZd03AVzWomVCRe3+9Z3eos12vy7PnT/WfVBJ43L+8SeorDlC+BPA16i4geXNPklEbCEz5hAR+0RiQN+KBtYHr8yGP11N970kjXhDcTfnSX0JdxHN7l9VqJpmLCyNKufBUsofJ+sBy0R+Q2iOjSGedTM7ZhQ4JxnwPA6wwAYGuvRVak7/vkNGiRo6B/2OqtGk86q+4Vjz0WtLrs4KjL6sETnwfpQDQnaum8TDzmjw4O2i+ycnK9jR/2Nz/sw4dnm+mP/RG5HZzW66OAHx62n9X2nz61El7Y7/tPn9Z4dz8Ign6t5lUDtV8J0+Hhk0Ilfrf9YC3t1pauPans2eHh/oMw+3r+b2HWgQxVzHshGWYepkHOfhoItUrJOEj//pgsg8GIrIL2s8cvHpN+0CK3QdoYd/sHt91+PVj5y+YiFTcejYC89m/S6LMnSJ/066vD295tp19f+XIIxj2PB2KQNtojogqxAT883B/V2YAfHDypPXs8qrtB4Pp+B0Z+jGPmQZH90cHBC79eUbrdwuKHh7I41rSvanJ9MxrL5i1nkee6/rqrO8ugZyn8GQduGkmmauruaO7ugkXJCxyiXv7Ywb9kGbgvj/qvjl//86eT//rX6Zu3797/99n5h4uPP//y6dfwcjKlV9c37PbzbB7xxR+xSNIvy9Xd11Z7//GTp8+ev/ixvueSVdDqrg6ePenW6yufDVajYDlYjUg6WNrTvvJHwSrfXPOlqQiBni8gU/zvTw5bfnIT86UDC/U4jnnsuSeSOiGVia6bjlyvzjwViXNJndCZp7OELWbU4VfOE1dSZxbkdBPGUg1lA+aS1WoeC7hPBoywIAh4r9V50mB/fzJaG0CLGMFJmqPZmCzJCga+H/Bu/yAFTHrsj2Gu+7AkntXaz563289etPw6pNXbMP+1Z0/3MQUQHVL3Rz5ZaVzwlsHYPzxsv1B4sDw8bO/nz8/U47PHteXIoMUqR4t04DZce9xb/ih4tk/SgTveTH+8Jk9e7L94vo1LmvBIJJI5AmLvk3HAvP2nbR8wZ/PkcX43v+SzWm3rp+YVj3v5o+dGfEpvRTNN2KzJIrGAY8EkFQmfu34nSmezLm8epVdXNA7kD+HN8xlfqjQzS3kaYFRd7ASiVvNE0DJjJL82w9mMT7y68IGunLw9f3/c/zB+8/KX8dGnD8fnwdOW6vMq2G8/ef7kxeNnT57nWDuJaZjQvCF25YnDlYWpZ2F0TSW6/vDhhjp4hnHcH+qi/oPrMOGoHda54rHDF1ipK9hX6v7gq5Y57k35UvWE6YM6Nwkq2QYY2ndXHieqbwud6BOeI7CGVu5q7Mpzo3R+SeN8bmRHXLms8mRu9evD3cLuls7rhPF1OqdRYhYhv3KguFmjZ3RC2Rc6lamy5R9Mh3A2LiIRXlHgoVUq0HUF77pA7O1OlKE1zHShhORj1Xl7o4O1muvuBAHPMo8HbppcvXAlm6QGlIljWP1QA/crR8O9iD5HfKnIBIuuO45b53oqWdDKioyF351RWFAFRGI6/zhIm8uYJVRmHe9IEpUGaVOAgMJrkXG+GaRrmY1deYgoBuiPjC494VcOCOZUGWAcmTiJRBJGE2gzRzrfv/8mNprqVDd481IvUej0u6srQRNis4L+ulz0lH3GmYdq2ZUHKz4IRPVQA+JdsVgkFVgXlTGPKLwnjgWieiEOj+VTY8Y+U72sSrjq1g1mdcsDZdXpZ5mo1eyvehjsTP6WMZMIDfVXbd3nN2FMp1Z+2K9sODYybIdmM+v3ALVJKrbTBKR1ZZIQ8QJZkPVtJQt6vxFNrOzdVa1mHj2DITtBWqulO4AoReKONCKV0OsVVcB+OdMK9c16MfQ8R/pWNrmhk8906mm+BM4nhVWb86gtYCVUtiwTzQlf3HmMtEiLcJ+wNbvypKgBQNbVybJ6gM2UC1OP/HIi3oZvcyB6BRBafqe8knDW5Gc3gOag3loNszSZUGu4CRKnzfmXdciPekmOda7xVixVu76cGvnWTPj7mM0ZnGIr+QEx2Mw4qprOyoyG/Ptysv8Poxf5rhUKQePknH2lCuk2J7x6RUmmYPv+apYMsCAHrf8QC2IBXtiZ9WG40B1S5IYOWr1Wx1o3fmnztrHUrDON2OXCDyw64BvlJtrqpge8m9aDts8GqTw5ikFqZAesAoANbogftGDN5pvUAd8ymC7HHQ3HkKeJYFOcDklfnUueRlOhJqRQXd1jWdbyt9Uqe/qtWrHHZudXoks4stRq5pn1NvbqzgMfQRewmUaYT6r5zLSCz0zzIc4nXrLFwRa+2H2ZJHS+SJyESywLE6oWnzML42saO8lNGDnzcMXm6dwB1Ow4rZVbXzUTrhi69jO/7qJASbg5Hc7EulLYRPg2gq9K2ofPSl4qy7bu/3kdeZNdi0Pd+Z69czs//QCl0lRJAVuiRz9Y/ItmR3U/SRrolnTS4X6ttgOIYj4M9kfIDKe1GqQz3dEWIuM42GnjWux2fbFkSvg9CQV1QzFhzO3gMwgyo7Z6uWRRGN+5Hb0+u5iKfHdHPzZemO/w4QPK9kBOoKdIZpyIfV1mIhr7efn2sxm1apOvqsL9R6rJG7rKoTg8PGzL5MtQ0GdPzBf5ugnBlF6F6Szp2Ltj2mu0O9UA88Bz4VDQTPgpX9K4HwrqwVF6p7XO8VXM+PIDz88rQJ4kmdtpwzR4+ZLPMn7Q8lGs1PIJPwQlXImtcItFWJaxQjYUfNgJhB0ErWJpGJig5R8EHpdP+WeYd5FlnjCnJoMEwr/fGOIbujrH8wu0qNizb858ZQmFWmY7mjBWme8htJPfKotVIkA16H8JAdXnco0WQqXff8wUflcEnqi7bhm10hJqLcOFRinDYQ/4qAt/AjFgIwJ/AouYX7Ipiyk+h7MTrXaAKkhKxkhNW8EGN9tod6uO5qznpQEjLGj5HXaYy1V6zBKydNhBw7y9QPy03n1is8MsqDOJwuNeq6OBaLQBi1uYrtPqzCfsMIfz3lqzAG2QF17TmaAOnPQOWlI/V8jYWld1jeMqtPlVkBD6pLzVcLNN4ApWjQLNCIGCb45w5emL60p4DZgdUsFX55t5vk1r+Wdv3Hvoc3MSzmYKTzqVGWehSE4qMpf6MOAj3YtKZP4Szsz+pvc02VXY0tThxeZFq4cIySNIYNukn+9st2Z0u/a5K63VPLlogyDw0kAR2rS0dPwsU+s5CIIUXtSCzl8b5t2XnI5mYfezjJvnHHVWwT7p7wX75Bb+sL1gP+9ZTMNpQe8KUulVD9ZlRzTh68VJlLSfHR17/NHKXyP2qm2hIbffZcC6y4N+d1mv++zKUzUu/SAI8JkTFHWnvVZn2VAgyxSULC19smykdUi4NXvZoxUuhkZbDdyyEUBZAo3iFzg7eKx+e9jH1dZv3IJEmHWXh0Gru2w0JIws2GmV2PXbblqEs576OwZSBA/2u8uYhp+ht4bpWK71gOajd0NXP2tpGeAEFH6LeOQxP8taRgZgFjnrpkCLVKbU9w/HOApjv5MGY5V/mWMQAC61e+nhcm9fjtjevq90HimqPAxNXYSxoCdR4vGmSC9FEnv7j1Zk3yftZ0p2kZOw1BCEVVcMWH0F5Fen5F2EjbDUR703zViiiIvNdnCSd9YnAovYx9EJY9+sr5g9Z2jMqW0wMtMqtZ6Gf67Xmc+lLgNPY0U9oeHU+drjm9DJ7fab4BXZsop6YJl/fyfVCjfdhNWIPQMiY1DC6rKce93lWm3H83gj2PcPWr5UgQWiqPgiacAOD1+A8uTv+0+facXf2NdPaa7gW39jBm2WpKioVqdBZm3LvbRZ1pF2NpKUaBmq8ouIV2iFBW/C5KY5Z5GheLmYDdSP6lTA5aFgfMC61jF/MJbflwFIicgq4If7j3/sPenww/39x73HHX7Y/rHd2+8gBzGurw4Clk9Dn9x2FXu5kuyl0+7wg/Y+8AnLgPuSYCBn5ux3GLRXb49Ie/9FEHjtH/dryC7cBt7jdo37oFZ/9rjGyO1he/85VnHrF+p4nNeR4sN+ubL8LVVVt59i1e39zIPKdSspuT3cbz2BZm4Pnj7d//FZlt0ePn3++Mljv6rpJxVN9/Hh8cMw5G/9EkQvDEQKuFQD1ye3h8+ePn38tFa7PWi320/a7X0N1HotpfHBsuctA8j1GPZav7PURWBfgMdnJJV4vISjVKvWbu0/zrCrsCs8ffZ4v5VBWm3pm5w+GdeD1bqsopDmTbBy3nMWJcKo9ssCI1QTHwR9f7vBUjNcLGZ3nvxChJShsMB1CexFiKUgP/JZPfh2abVKUpLWg35OyNjaS/01b35+E66UuctKM38fPr0/fjV+eXb28tP4/OL9+3dnHyyblbuFksafp4sFjxNP2pjJfoqy1qXtEx7cX3He0TV4ZuE/2V+vv6UhrGLntsl5gAKSJ/tIR64497RtmxED7rTXa89/oJtZZsmJDWsKXeMzmmU527pT+takcczjLCu8giSXCecy5kuBMqLJZyHHT3KGjpf3zneEHE5necMmNyBUi+kfKYvp1Lm8c36XYrXfnS9Pm6umcyGolfSkuXLYlXPHU13G4bOpaVdV3HTNuJXsV8vSMeIuwphGiUvuaZTOaRxezsCelVzTxJ7GCskUHA0NgwAvSouzXn9/40pa+R9p3KjzAADdFOczkAQHL9o/7hPrCBTYFoXWBmWrdbeg3kYv/gzeysJWEQMqihm3glUQeZc+FgXe4iBo9Uo2AB1zxOC9ipNvKXfzis1muNd2qr9spK/NkNmdkZJ5q0s5xEWVekUpsJX4EyU1XgSWHWNuAaHKSt2PUCJE0RzrPLWa2AmC8ryayid8Doskr1slGJntNv00WncU9ERENCXKE1sS6/vEqoKXqigf3XmTV2itfbKzKTrOsp2KQ/52Ce9letV2Cf7u53JeUSnolfXBYTgHV2kWgMkrCmILUl1zctHM6jhokWXOugEz2R0fLLv1+hiOYcCYAeoOxiNg8eAVagF2TZ7BVFPsQAo4D1iv3WmtyYaBhI0dOg3wQ7FtWq5ZPm5bssItEsHvkygXpHd/XUC30zKyuHyry3E1moSJjarwbjB1p6Rj3YIM7oyJpELQH0ZSmA9IIBtUyqQKOVvRmKmlOJtc3oFIAhjAgxapOqHVUeqnkzQPv0kqPGWwMlYMU0VdyKRzrK9kJlFYbP64buymU3088TZXEJriFBalD9c/UKUOQp8tkilBEymRAu3/2O+CkOK+YMnzjTX656bFAmg9rptVtzaWORpnLDPo/HFDj5aTy2CntfkV5LftZ0FBntt+5vmGWbQE+Ugk/r6/E2xRCSvbANSqbbGcbD9rXLIE9GqaiPCg1eUHosvrwb4PrWvZNa+3DSsMSetq4B/vF4F/vP8Q8E/+HeAf7z8A/JMS8I99YiXU24TX97+nP8+eFPvz7MlD/Xnx7/Tn2ZMH+vOi1J/nm/15VkzaJ7z+tJj0mPD6k2/1WitebaN7mbKl55ZQQvRct1PQLBrxRFHN0yLC79h6MHUGw4+msF8J3imfhDOqgNwK/mZJ+kcazkTeLfmub+hUaIwrJdovN8iGEWPbAwuDkWUwFEX2R/awsmfKKDYHUCV4kvCKwHWNdrfCoFW3ribHTBpuuaRFWH4XZ89r3u+v4d6Vu9t2zN0bYs3qIVgjinrgOs1m03F94h4oDHbrou4eumuyrNU2uPjBchRs65i/2eWHeEJL//Qf4Ay/d34lB5eAcULyDR19Fev2kGa+sGdrla5JkQbqoqcnoGN/lAJ8OwWE2eOiWhfNW9hhblaWQsK4oAyupk08TaBXMSQ5qCKSxrHpIbTDD22DAJVcUAPaOTBBr4Ai+7oMvLFULze8VD6QVaCVzw2te1ZI3s9Z2SVZ+eRW9lYLZ8ZwNceWaBqqKYJWVxz0u/W6AL73diCA7z0diJF/vwzglazwtcj3Lg9WwPeuDpYFvtdG48ksnVJhL1CZUjxJoi4FYWW2Iqt6yWMGu0a7hJFlV6loJSHBdbLTqqrcUuHlDViJf7aRdlUjaMOcV78sKWkKbGqqTAhIwR6BwC6nFbL2cqiwUk8DvqUw0nAmXrMIACiwfoUNWBtdK12kVrITR5KNAXFkxSMfBEoRd2Y8us5FQnTq+l2Jp8S0xvyeQuLyipX99f2O0orLz/5aa6usnjR4hR3HWKm+faMoOESl90FLGoZkGf+O5W1ZYWHvjfFZwfLM9btpluVAqyW7YfqTVlt9SC1Mji/fY/expcz3GhVZKq6NOirNO7bB+NcMPIzqya7Qsu9Yfr99R+p3UzQdKiupyRLtO6rYoP86f/fW5tHg3UiK7yFTR1sHEzDz7WwcpIB0yqMU4tA4jOMsk+yZv14bMvyk9eOzblFRWFAXSZUvWBJV641y+QRHXRHII9JKQbwHOhoQTFgXHyzKldvy/Odb3taqsWcqG9Vo7tfbKRlpeTuwdOVenMr1m+qjtbKnknDzboowpf64HhwNwJzV2LOO12Wl5RYorP1vowm5EabG7KUr4FA3rh6AdCBG9f2nzx6lA1Fvj/wKSNDsUwqHLata8ff2TgCGtdtMk5V4WtLTxElZlEj2QtT5IdtS5kN8x6JrNBydTKgQziW949FUkyzZJdcvAXcSKciAdSTLP8XZ/+DKuqskA5rXY4oNleIafjiGqV9uM/ct35KQZr9o8mtZELN6evgNAo67sWNzaXbPl3FyxK7BeuTZk9Njm3XWY3J04nEcEVAxP9eUXZlG8NoRu4aBe7L/45Mfnz3f//Gp76OhQn0ULMny8DB4Qb7vVRpRmIoPD1XNj/f9ykb0iUUWX5GVXdu3Xln1CBz9J0fgebGPz4qvT4uvT/69EXhc7ON+8bVdeIUXVn9Rxv7j4+PnT59srIC/iGFdZSD313CSJfT1jIf5elwaTpMHdU4Uw7TMshLsLcLIE5+MmwVekuw/hlRWf1Jq5BVPL2f0L7XyYrOVp/uQCkO7KQ0CSmsJgzQ91tS4wJd2PRH8r/8l/INWD07RzD9oqUuqHSGP1gHzicct6/4e6/yv/8WxBDclYEfpcCzBoQQ/EPgocnMMbFWkl6Ex9X9YQ1xt27+RKE3SWJScHgfV307wW8HATabpzUHIwdfscpaVNxCbdVW3JSEJTmjjoE2WWndfry8P4MQ8fhTsP33md2ETlxnry9GjcXdDFrvZi6MHenFU0Yuj/0Av6o0Gx57IXvDDVnUfINv39eLF9k682OzDi4K9oe5HuQPtQgeImoAHgGg/ewgl8OsGKJD6XdDsV0KTqZHCW/UPgnb0IGhHlaAd/TugHRy8yKF7ALTH+w+NGn7dAA1Svwu0J0XQvKph0y/76K/Ar7efPX/+fL/97JFKf/ww9EcPQn9UCf3RX4K+BNio7lndaD+z+2E69Xjkb4HeYo0CaUgh9+E3NLnhU88rgF3go8BdxYxNw4Sq7VzBr80t8lv8mmgxvaKfj7q5rYC+trWDh3rJ+slNVNgdb7zIaTpHThwrq9fFqI6mV9a7eXq0/+jR/hMyDkzKN0oyWcK4SpA8SerXPfU49g8OcqYF/EB9Y1yP/sy4Hv0Pj6vs/caoFMfMPNnj+l0lmRpXz4xrYTDrZoy3DmvFpvo/uqeOHwXt/RdESoK9tCEP2gu+9PbJi0egsd22X1VsrP/2vspVH8zGmUpb3W5a3FuXdTvLRm+WsjfLit4st/fmxUZn/tIGC6asakZ6jfYjMJ5uaErX9jsP77+VG2xhf/2+3atr86zlncI45Np//uxFjfXwxNJqP3/WyliHPQTZURVkR/8OZPW2hu3fgWxze/03dtdvb675rnRwsP/kIbCOqsA6+nfAghaz79kwt++Xf2a7/D9ht1TVPLGosag/Lex9ov5sVPcYDI7/fTT6T27BD26Uf2af/D9hm/Q4DtT3bpPfN6B/cifdPqAoViivZ5X4VxbOGGvVUvydlhQ1rB9o/Kiq8aP/ROPtBxuXso5y13XqdzX/4ht9RwHIg80fVTZ/9J9ovr21eZTSbJNGyI8lzslKLOgjRVAXhumQPztpLii0NZ4F5oD5jTboRaSUPWekEGW58kiBXBWzORH5ub4cBWJvDL4Pzb2oOnuon0cP9fOoqp9H/zv6yRrQ07bV0/pY97XRGB8GwHYtS50dQ2eXf6KzLx7o64uKrr4oqa4Lfc2yzT62CdyhbfmkMFtgN/UQWNWCDgNFkRErJP9Z+PYJ3rPZhFCNaHsUwIp6AWZdD0N89DDER9UQH/0nIJYQ5hDrMX4Q4mqxiAGtyLgVkv8sxE9ILnnPwa4/loDvP9EJ+zKh/Wxj8MvI8+Thjh093LGj6o4d/Yc6Vu5Wu9yt/dKMPf6Ojn23MKWcG/oUtEyfSior0xVZm9fy9ZPbWl2V/uduEUoUmzz6UwAePQTg0X8SwKpd6uFNCqm2YsyKlLrR9rtV9DxttEkj9df5pYA23mKu2q7GB8ym4AK0DqCOWNVqLWXGBBS90R7Vah7cCSQ5kffE3jJr+Y3Vd5L6qp3r4Y3r3+272r5KvX9oC9s2APX/0AC82Oz/X9jN2vvPSaO9/wLuKUnF0v7Tp3VRb/+JHa56B/vLGxgcyZ+TBvy8+Dd2sepd6i9vUtVQ/emdqnon+ssbUe4KhRQcnzw8atv2p3wHe4ByV285f3nH2dYDjY75jlTEyv89e9Kf2pL+3I7UyOn8i1bxf669CTz/k7vUn9qk/twe9b8F5I1Dt51awp/8Uw7UTqvaAjbPe1RZ+dF3Vd5+oPLNQ3Mhuap6+e07gd88FReSv6v+LfDDzSPbNn5xV9gpv/vOgrFAEjc8nU1tkyZl5pFlcOHLJ6m8syANQYvW5blrJWmBkH/Bi1xQGITw6YH0Y8x8khZdyZUuuMmWNh2ayZx8q+GJtM0/T8I4KZpTGYsVsLwLvm0Wu83mJd3atOBpPKHH0XSj4dQ2w8X+FwYvN/I9SBtyfPKkuu0UusHKt0gqfapW3owDBIFYIyzqYev5uyeR5hsX6gQp2pJAEcJ9Mq5ATbjCnKMmvBVQs8Jnd2UyBx89nMjLi/aQdTayKrQq5SO226cNP4xbXKxp01vLwE+V9Cu9mqW1WoWf8NT/Uwa+7Mpr2/c7LT8XBV/9XU8aTUsjcnR9kmXaDhoS5bUX7q/RP9Om3+aeQJdhHfeS8xkNLayRjvK1ONn3u9opqjWiB7z4us1A892WOyPsIL+1DPVIs1RsSXEUgeWo1GqpA1KqFpG+Bls+2ewY3nO1bXmRSwBpk7wCoB34bJLFnugUrwmlGCbGuruHLP/2G0EPetY15FVZfP4gLY/hJu34gDW4BWudQ8iM8d9Xo7V9C0+t/9vgfp1bWputA66sBJNZKITzlk8lWA5dJTSaCofJbsfpJOGx59+LdEFjb5vvCNxy3DkVIrzOA6/x6tt3BDYr7UxiwqMrdp1q5xJrdVssCuc0+H333rysncHuvViPfpffRRJOPpMpndGEOnmma5o44P7Fs4JoCZ0m/PuHoIc8LrkvAUSKvi9kx4TdhbW/tm4yymarIO84KlEN0/r3teVhMZxO30JDbBLOzukijEMYd6EvR7twu0WUr1u7DVi5EMsJ7hZJlTU7DNL6ky5rBI99Hvw+3r3X1tys8Rj4gd17vv69a+AUJjCA/rZp/Hx0smH8KQ4Z2Gbz/CDtXrJrFiU2EXYjt+O6aqnKRTAOloePe9oJKdxj1KKP3u+HgdPavU/XThhNnQNnH58fPXJ271888pb1NsCXrn/vQMaGt/m50cYMvi5f9VGWhn7mLcE7k18JLNLb5vHZ2fjdxYfxu9fjs5dv/3nsqSUIlr7+eqc4QEe4W+tVVdKu8Vyxlu8pcGax9GdwJGGjohLN8uGFcZD8tScHP5+dUkvai8CD/sFl107efnx5egIud/45Buc7AKUqRWzf40WtnrbMR4nJ1YxDIrje0/VvwMP8bcPJskwPC3HDyGFRQq9l65tcmix/dPH69bGp5ujdxdtX591ypq2NyEmXrifySefr36G/x567rQXXjm6XE5UeLJz1Qw63f+8UvFTLywZzOufx3ZZ7Umuf5BuhTxRQ5alyq8Pt/Y73SwGob3p8L98l3b3X63X9+9onZocyINiDWmo+vy3ze76d8SsNSX4zAffz5u/gwE4zohJNmnAJFyffA4dkiFvhpfCYf7j/6NHj/d44qCaNiuIy3+9sUB5UlQUmB/GYNpjf9x89ypXIcKGm4VV+8n2so7rtsY/Ox9zI9cGb1+/OST7YgFXWEO/ej9e/k7Q4uYqAnwZ7g9/qe63Gjy8bv4aNr43xaO+6W3Cgt+lTsMuBcrb3Wt2NO0NSOIkxj7Y6HVQOJ4Gj2nAyyA7B5drTWo0dgHe5J9qHLeY+fPrscftH/x48FT6G0IG1mvI9uP/4R9L+sU3aL37EniUsSim43lyhU9D0+8sAdtgVsAN0/fatCkixnPQoV/fGDejOM4wsxxqyJul7FAOqPQgSuBDEkSQMOGUcAijSBh+N0teeKsZ8y+3vfutJnnd/M+/h4bMMfF2BM70M6rXKItSm8OOqwu39bH//CWEyihdUUKoJJ8xj2hGfvz0CGnA7zoKbS1PQ5pPKNl9k+09Q14tRxLDRagDMjeeyr0nL/6eJE2lHXCxln8DpQvlP8ETgCbiPtpixBKOvQeBK5VjAOB44hUifftlzrrqy1tXrA92CdH3wPhC45orMuhhnwvLvaQ6ekn/JeW84t3njOjs0blvgPnx+BEPGnA/GdTaSfis377wVXA9Y9g3mKhjQ4ywzTrHUQ9NiyisTkeOs1TbT0Gc0POQwFMLD5CDsBEJdJD4KLDdvMkkEbh66T4b3c4mM8CTnEtUdRdeu7WfSzY+mV+1nj5jtXao7lllgzFLU8g/YqA4Dt86dvRai6m7INg34VV4DZc6enFX58pYnr2S2jijHOSvnALeKG5e+MQ/eO7RucK/XpP38x2dPt8XC02GC28+eyEh4925CV8neYhYy4JQ/0BXwQ5gGcWbdjnsRz1yiL//KDOuuMPG7N4R4MhYig7CC4POUnJIjuGotZWn3awyR2pzSy/Q6y3baXfAXCYQ6SD2f9IMpnyDn0JQe5HDH8sBFgvkAIjI6owopiHdaLnM8o/DmuWIRRrAooTd9HiU0SgJBTpthzMKf2HRKo8BNYuCpT5siuZtR8BoF4SeRX9NpCy4w3nngXrEVneYfEr4IWuZtMmOLwI3pJPFaxFH/+XlujMp7DlF5A3cRW20u6eVnllwIGstuBTj6+fc3/Ov2j3Ox/Vta8SWcTo+/0Cg5ZSKhEY09F6bNZqmklCttioQv4HgaXodqpHlTRpX38fsiplDRK4kYnu3SA8fikofx9BUEYbpntZr2hbkM4wjiL8HBFbhSwE1azO/6ZKNAIu/Mnhw74G+FXbGJI5IUBPtkyaIpXxaraAL9juHJM9GNBxr8EcRKVvjcrSwtaIJll0TIPc1Jt9dP0i2ldXtwoOBNHvX54q5Wqxo5/dUrjxxqLQx2X/LpHYgzaDTt37DZ1Dv1Sb8pcIbfosNZRHEB6bcw03Lx9H2yY+qgKzrp8/k8jKZq6is2aEh3JjKXswyFk0YixePDVTpz/e4R3NyX7lTTwuwqT6f59GJNqYC5s1ruOC6Iqf7ELCOVeHCu9GhnmcJ1kY9rraafvKo6fPKXOlSoY7NLquhVOJtB7stw8hkqWMR8voCT+NKSMSPkeRRZb28eThwunNUek4Gro/ALuwa+Hxf1y2saJX7P/f//v/8/bseFUOCuX3fr/ZyjyP0h/e1+KB59pndD8WgN0ej9tWeEZCxyeI9rYVDHhRHCTuqedZy/3X+md2viHEcJjfPVJnshF8j6ikXhbHZ3fwvRCTYF+rfNmM75F0nKe4U3r+93dMLL2QzThAehqWu1ItrLPAbtV56Jwni0XpP9/dbTTvlQiFtdNw1yG+HmdY81rztSObWxgemYT83++TmwL/3z8ybFAOp5cKQ8DcnKRAgZYr1cSWvTT1i1RP33vMbftcNeAf76dPGmcpqm4oEHxgclSU24PjIO0BrEhTDGrCRzB0OYtOu3dgKPFz+Ofb+3rAf8MGiDND543M6y9v5zFIu1AuUfCaKY8IPg6fMsa2+k1WpPnkKoB3c4dOu8HNgK/aWNa7U2ivshJ8+yHXCphIJ/lfCj/Ck2xQ+DZ0/x7ccWvv34HN/a+/u+bE32BHvRsZ+X9cD9v/5//18390XftWcPRPDwi4yIPZ+BmUtintAtNnnR/rH947f4KQxA7Ksov12Lxz5XlOwjyCU8Uc1mp1lWeH8VJrSUdEavj1cLSy464xHdqBzwt1CxJimpdIjZU795bDoMnpbm7/loIT/HIQpvuVYAT68JKA7vngDG7AObU09qXyqgt8vIFAjPuLH9XER0taCThE4dwZIU2Q/7Bv6U0kUful8Mlg+CBgP8FY+Pw8mNZ4uKmH/vcpS+F/RG8ggTiF7Z9WmPgzuCjdY6m5Mqc1ZOSQc/jb37NdEvYu0XYhuL8Ir+kyZGF2CdxtzxGBWT47GLF+klLeuA8HYNnR4HFXQM0aBMfg7aWaZ7b8SyJhOcaLWv2G5VaX2gLRRB7Md1ERSSVdYHvPKY7KTtV8xV6t9vwJrKo6hU4RenKvWzTKlVPtM74aVVddruE8ojzsjSJ57YSAenCz5q89TAb0AlDFQC83gM/AQKv7OBTPnXDYyqRKk8fyVe+ZuwcAMLL48Qt+tTyOh37CQOKWv8jzA4RT55/uRJR1TRO267SX5D42sK3FEelbbskpyJtzx6m85m5Sw7O2ACsLEkQRxSq+2UiWg4y5e6mu1N35hK3a/JmDuQtStyM3Ll4JhkoFw60WrvjIaTRB8jLZrd3N2VQAJOrFXI57WK0P9AKPktceNjaKZJZTuu33nWarced4sk/iKaUSHeJTc0XjKh0YAWAi7tgFs+3sT8wOtWzUoPsG4OybmsC1QDdx/Q6MTq5QbmDkad+/UaXcdDtE1hU2I8wmBObLJsbqfcTXvcb87DhVelyPhWL/G6VN7kNU3+BWs8r8Be+cLXLdoFjo3+9N0y0otbTkRFRdc02czWe+ijcnyf0NjqIS/Xu1CFTkQOjwlABp3sDEYlSWBe5F2kZxKmHU5DqnLuMJAgVgS3MLXgrOfFDW8MKnlVC6vEmVotH+0Kisr9+xQsH78xgxhIDemKro1X1Tb2772KjmtX/paEcsuogERUDfVNKKxZyge5VvuuufDXvifIGNx4V7YFvgurRgwd0PdSsNuw0Q+zvS5ozEBGzpuTVCR8jp/19mpWqSIqhUwWXdukNazHOqb02hsT5qPrNwJQQagGhOvhuVJZcbLSIsM1V8sbpdFeCpvw/dpvhmbpB6n1kmUbpIGkmyMWVKRlGUeRygNwPtyLrmSMimSM5V7fgiDYIHHjng296manuHAw6eHxgyxrM1ooTdwcxJezWY4ED7jbV9xwKfi3ZeeoYgq6vn3sn6YTWuZ6y7iFnQGSQ+7XavtKgxzzcpYyXZMn+y8ev+jYdLuS4ywwCShlv6dREjMqOoIU3TR1OPgXjflXGnUYuS5+S0mRwr6iYhKzRcLjznittn1QMt5fxZR+pZ0lETScdVYqjHenrzORezT86dwSo4bonK6DqtDvZ/QKRGi1mnroLrPMs8Uz2JTNB6x9ssoyb5XnAShKOW6zzLvNcyA4G/ujTOVIIMlplnmnhdAMEvAC9QPUEF6z2YQJ7GotSRrJmso8t6KzPtndmmXBFz75uP1zKm58cmG+Kz6r6LZcefX0ydeH8skkn3zanmkO+5hPft2eQ0m2fELp9kzK2bBP2AOZQIPnkzDPscFVFvcRn8zyvJKhtGujIvHJBHLkE5dbMlg6dVXDJqcMO6qW9bANARJJLR0XO2z3WKMNrrTBCxeYDY7rdT8dwH2mvKil+btF3Et9izGwIa2AJgeGbwLDLGC4T/I49nUZxj4HIQ9lfyrZuYLF2Qd+jtentY6zMqS3EeHZYb179kvnostrNe4JAkcg7QUxtQPJQEjPrjZmSwfLUaVBLM/VjGOP+10hHQ14DE+ZXiqPd4QHwl/jvY+d1toseot1p2FkxBOloAcaKF6v+6FkbUAsBZVJ4Kvq4xH1LId8fU9mhaoxbZCS8QhOTx73oVaY6lrNK+4vY7+Hk2OBN/Y744pD2LhWG9sK3CBQdFVXAPCA6A3exlYkOQPyjPPP6eKfNEm0YRhOgpa2dPO+jPFzFwOlop0HcNkmjKtaLTIRp6yC92mi2c9mGZm8FkHq2UZlIJQHgbw8TBfiP+nJLGRRyugFDZbewA3BYOzyMoafScyjuzk8TacxFQKeYoo54oRNZhSewNIKftMp4y5xL+HflOFffGfX8HfGos/4yyef/0h5AkVA9g0/0NZlmiQ8cok7CaMvocCHBY4DcScU5fPEnTAsJw1J3Qmfyb/XMU8X+IgqIpe44DdZ/WDQGOJOp/CHTjgaGOHzDP8mIZtBc9MraGrKwhm/xgfMxL7AX8wJtejTLHEpDMsVo7OptLG7Ytc5wGjfCiBeccx8xbnsAOhCXOLetOHPPvx5DH+ewJ+n8OcZ/KHhVP1goRvdvxt8A701cWGA2RwgZdEiTfAXuvH5EsrOwkvs3oxe0wgTIP8ctODws8C/8Wf580dKAdY5jVL1wxLs3pxKqKMQRiHiOFE46HyRaKC47jRPEwkIpC7YJJFDsFB/+bXCoD9c4saQJ4bMcXoJOAAfRDiHZCGV3/gEDAs83IRTvoSHeTiD9uWFDngAlXMsH7BIErPPVD7w6Bof7hBPRXqJf+dzcEUOT9AY2hjDr8LFBAYLrApnIaIaaNsUyicwi/B7g3/kJCVsjtli/BNOYEgTyAVDmQKoX0L49oVNKayG5WXsjnwylWtNfAEIcT3NkuvZ3eLGekTLD/OmpiSM2DxM6ITPeJy/zrkaMfWexGEkFK5NWCwXKui9FiFCP6VXiPJUTBCpZ2whEF3xmJ/jLUCnwcLfGGG6+UzjCPEPjdHdGYv0Txhfx+GUyTUCKIbVzUPxWeJTqFbmXEGS/ySyzgWf3V1z/aRqhirDmVVzrPAi4Qt7itHRPTyg5EJNn/pRLSUskRMuu5IovPnCKODXF+zZyCdXcoKu6NFMLqAr2ocxfxMmMVup9/mCRzRKPuBo0zhPFZJOwVv0hc++UKvYK3Z1lQp6yq5vEhZdqzSBbB/QljfhQqclIZgyXN8kMiHmi3O9EK7gKh2XgMHh+6V5OjJP/zRPZ/j0zzAVgoXR0SyVsJ6o+buieDDMn95KAntF3/B4ccNn/PoOX99pi+IrGfs1Bw5OieksjAvdOl9wK8sHNpOVfkjjy3RGowmFkb5T247EXEnQedxYxPxKFpiksZAUm4lJGE8Vejauwgm1nxtS0VxIAnusQoKIJ4X3NGb4HlN2HSk2gbg3ocQj/FWIM6dC/9goTsXNQuWG5xhnZ84EKNEbeu3IUx484OgJPmNTvYRTeesIngQOyKUckLluNprMuJAEOo6xyPwKBm+KT3EIHZrrluZI55H80ylSpTmGqpIQwKKf4xbA8c8XuToX4XQqq1vchFHCkfbHktrNVY+wKFJbfPgDifdcr7y5pK5zSVThTT1p+jpHwjpX63EuIYPTav5goImphnbkk7EeDb0pzMMZu4707iPf9F4GMT/klj0HVIkZFeb5Dp9MObzfgg+KxsyF7Kmg8zBK2EQgOY14Ehraql8aq/msBClsjxFO31IC/Dfs6sgn5wq/JxOKKGA6gpDjLyanCZ+ECwYMy1eqE/h8MaOJflWbKovy3RWTZyGyUeHkM3QPydXltcavSx7LUQb2RBaa0NkM5lyuU3iDiVVvcOGCTi1mC+4v4S/FnUzXO+EzoX4kCQXuK9aJ8lHxXhPO4ymmx1wIHrNr5EPgKoPaPzGmtARAidYsFkwADk3zx4phUF9i2AnhBH13KWd3ypfRjONOPY3D62uFjTSaAG+NTwmNP9O7GyYZNU1TYsOACXxSVOwG7erw4RoJhNoQY3o1CxF8NtUs2VwSUbx5EbMEpogJyXt9ZlGBS5NF1WgBvHIoZhy3t5lcgeFK/lUhFIAeTBnupWiiCQ+SvWNRnkWFqcPHBAdRkcSIRxP5C8yVfNL3S/BlGYeSvaOR4vLm6by4VcOWdcNnEr1g1AWL1Gpa8IVaz+pJReYqvZvFsOBCch6LmKoJW6SXChggt1yvXHA8xqPZHT7O8K8MHo2PX+RfGguZwLHvMV8K9aPYxAWdzRDV4WXCF9Swm1hO3IQyiX3VP8JiMuOJmjKRhEgI5bYi4oki8Akt8CWG6UzCS3nl0mZEwkhoblMiZQo0aIG8o6IR6nKUu2RTnFc1Oav5DJl+MeNIa1Y5rYmShsHacDJJ56lqA9Z9wr5QTYGA52iAXbiauVBM5NYWJknMLtOEKowx7wrK8CubpwgNlL6CaaDR5E69Q20NccOuoKpLKlf8JcOT3eVdga6whcWdphFLdHJDbb34HKczWiQ/PG7A4ooXfKZpdEVqQzK1ooqzwPeYwu6jqB/MDPYBSQa84bFgKpk2PCiHkSJO5pwyRfZN5vvCFLOCPBadXlNFB+iMftFgKpaSzWbqp8GBACd3+lV1NufH8UGPzRWwfg09EvLNqkAyN3M2M28Kjc1zI5zepsKwSiKJqWRh1KtEW3z5AubEkcm61Dh1hdwstHANh9nrfX1E0DyXdV7Q/JKGX7/bx5ScxMoyko4Cg1qYIEQjFkFrsHQ/Q9uf8Q0O0p/hIA0svMz8md7hBQwhn+GKQ0TVC2w8wqK9SDHNsMxQsNPIt0WolM7mmo2Xr9AfQ2pnivM18zKDcJfmFNSQc65e5sx60UREvpqBkK96zOSbXv9woFLSjjyD+Jw/r+xTV3mbkAgpdwu9HaRzPknCLzgm5uIgX1AtLsnxS/MTPFY8sHzQuG22d6DyV3LvWoQsShq6oHX0M6OndpVSn1SqjScqyeTQ8wu8GI2/0HC2uAntdwxhGQN0mMrmSP10eYAnhsFC8gx4mWIyRfyOKWJ4TBc0TCY8jRLzJtd3TPXkxVRIriUGJhGpPkQ7hV+abykFXBbqzGRRFZ1EV/JoiUmw45nJw/bMBIlkOqVfmB57kcABOGEzuVslfGGwEV/ySRRJzD/TxjQUN1LNVkiy6sc0WDeTcFFMuOUsylPmLKHxDEY3Tys3l3/ReJzvkDGwXmbI7kRC57A0U3lCtfdNZBzypzu9hyoEMc8Ng4nAijfCaHLDY/2mJIFy2DDFnhlIMKipN2UgNSmQmjRiSgKJ27JQ4oNLvsInwS7ZTHYb+BDZBHA8jXD6pXGnXyR0jVXp/c7e5Xk8tWiQ2vSXMUM6o5bxanITRhGdSe4Fe3i3mQTNrKAHq32Lb4DG7iD1DlK/wj/OwYYd+JyRT97b/IRkJHB1ynWsmZNL+oXOJIeuj6oTPkvnkdA55Kumvuqj5KWmNOJznW9KF8mNzfmbbRWfNLZoBx0oTZBs7FUs6VjlNjIDRJGsdE6tI5rcsMnnSAolZ/poO9PCaTh/F45UkKBXEzyrTRUe801yHq50Oov0E/8C5w5cHPKkaIhllJq+K0Y75kudEvOlHjLJuSo8yNnYWEMda6jleXQGM2LeckjkO7yogwGTslN94Qif1R1c+1lSE2QSpOj0UtWk+DuRLkoJd/M5TWIGXPEXQ000Xkv0G/nkg8SvFSgHOmrGVvNZB2dNpmo2GZJ1b7F8B79DLWc0WHl7w/vh/WC4HP48epSp3+F6uN67nvvkD8xx8PfS978f4tddWX5XF5dlvmLqbyCvbAyGjeGyOUxbraPnjWH6+vXr16M9n/wss8Dkyyyj+u6eT36SyV6vI/+7ym4SP1mIXjYP2SzhWUJnGZjjJDwTc5FN2DRbzRcLv5MNfgsbX0fZIGx8rTeHjVHd63UGv6m3zijb9f095pNPpoXhsi5HPgNA/Q58/QW/DgDcVqsBP/st+PuyNUzbz17A3xet42G6Lz/vt/Z/hL9PXw/Tx61Wa7R37ZP/li2AsmEX6qQJvgNgo0FzuBw98hr4W/frkAHNLJJEmy4qo4J7Y+Dbwau+by7OP7zs/3Q8Pv7l/VnnjJLjsyP5/AclH968Pz35IF93KXn18sPL8csPH846Xyl5eXaiXn6m5OR8/PL09N3Px6/GF2cnnZ8w5bx/dvL+w/jd2RgKdj5RAtnHP/908uH4/P3L/nHnF0pevevDFf/x25dvjjv/TUn/4vzDuzfj49PjN8dvP3RoYmwO4iS4V8qeTpuYo09nn8De0HlMJjDe53LZdJ4QGiUsuTujVzQGitR5qlJAktp5RrT6g0XXJ0rrCOWeE7iHBG28MBehOj+aR9Cvd9ot8/46Dq8lRG2iiUinvb8mUVIwyfrnjF+GM++hK5vypksPZqUjn9dEWLWMpcXJhzgVCZ0CIOI9n7HJXe4Fo8JYOFde5qnq2qIs7Vu6yK68wGrdpk8D1Ng1kqSxwOwNkV5dsZWL2m+wV3ipJ8JTETo5KE7t1Nwllzvl80Uas6s7t+6xnvs3t846rrptlZtwWtB5Y3Iv33/68OYUzIOFsrw5xzV2cSYT137ZItEpXvWyh82RXXHc+rjuOhO0b4o4ejKQdU+bro96/bXlQ0h+evXuzXvsgac1+xv2A61K+4GWbT/QGnWixNMDY+oMRHBYbkZ6BzHvTcXABO7jZrv5zCX5F3llaRoMRgQCqe7wpsbSLMufmxGfUhiGnSCIE5Os0SCvjolzfc032Gnn7aA1lFkabB1wgyuMjOEyZhrHNErkBJH7V+WlsiIwlx+URk9ZPXduCS7MU6ITziimvMZzNlCjt+GcTiHpTbjo7NKAN+0U6OMb/hWTAPvehAts5zWP57rKrxS7EcaCxkC2EgspOp/oOuBAqc9obmUDVLZgWPALRZYqkgoZn/CK73Ii4FppxceIrpJzBsr3a9cnYVX1cOkMqheuT2YVGRYhjK8EYIuBwm1uVcLKt5SNLtXviqY63tVq5rHJlxGN9azhkt7yTboGniQkTSBWLjZ4z0A+Ptenz84iUesVwD1J5OG1c6NTN5BjmhAwoJZViKO7D+E1TGlnnqwDRqB2HmPXO9fJOkiRYt0lYFlcjbkVY1PtBnCW1GqLxFq6C329G7BIw6k6Wdw1vyT5rnmZFHbNcWLtmseJtWsuk4o98jzZ2CNXSXlP/Jysg0QaJZb23T5+Ie8Sm4S/TAJj9nS/JoNms7mgpNlsTvHvFf69xL9LOpKWTLeFGk42azjH/Cv8+x7/ftBl3xuWA20UpeEQuU/kRPZBwtu53+4OrV3pfQyJcb7p//s1zWZ82UdLZ/aVTo9SNktOIo12f75WsH/3ffJGjhv5oH7fJsFOi5zh31cJkNFTfD7C59f4/Ac+7+Lfj/j3Av9+xb8/499/Yc6f4FnNyX8lAQil44ZamQ3pcuyfmPETFvoFlgX5tTCV/12ayk0VmjYasox4lFHChkpW2lswfdDT6lFWVGkKS8sYcTq/lDoPjsXk4csoY9ErhMprEo1E4st1yRLEWHzIA4m26FjNF65CRhrbvU/icu9VZ3VJab5jTFm0NYU0I1FVxoUqo40qZ4klSpdqK2arlpR4b5vSJuZlBYXunVI46OEwRzYRB+5Nkiw6e3vL5bK5fNzk8fVe+8cfX+yBayn88+bUJawyHxw59nBow231/Li3QhMn7P4sDsKYTGLAsLQwFIvSUIiYsJiE8Yh8lSM3LWS/ioOBC3ZzbIL4JxupSyzM/YGMyDwOrHes6VrWRO4KNX6JNzc7FEH55DIOCtetrunqXfy6wsvZ5iXS0r1YXWhNxlad4wUwFX2kFIozFH+VM7xfw6a+cxdn2V2Mvubwmm3VzTV08Ik3i5WFpPDJNA4a4IPqKtZWyZ5ovn95dn58Nn5z/OrkJfpU83vzuFORTq7jYMusBEEwjXtfOxewwaAdqas3nw8v/3nu+j17+kXT/kiuY7/zMiG35ZKw3W0tCR+x5EkCuFYsCSdG3CK3t5xnIV/9ziImsankFe6Y4/OXr483YJBDGcVwYXsjI8ITxYTaVeEeD9nKAyGrSkxVhYxYVRIDiZZVvX53dnTyatx/9/bD8dsPm/0qfcfy/52QN6XylbNhfcNy92vyoVSuci6sb7rcL0mwsyNLXpwfj9+fvXt9cgrTAFyknYL7H9wVVFMyNuwPOSt9MVwS7JM68eLtv96++/kt1PfhXf/d6XmWqS3ULnp+fPp63D99d348Pnkr6ziCOnDKXr87G384fvP+9OWHY1n8tSluMvzy5hS2YdH8+ad3p8fjV+/6F8BpYfYLSD87/nBx9nb86t0bTPtaTBu/Pnv5T1PgZ+vjh7OL8w+wBD69P8aPH+Hj63dn/ePx0btXn7JMbe4aoLcnH05+BQjewG5vpQAqv4KBeC978U9T6F/Hx+81SsDOL5onb8fvT1/2ZYt9M5ySSRyfHf/z+Jf3WfYTJbM4EE2zSLIsjMl7yF5kOMc/vXz76vTk7T/hBhbZ+rVWu4y9rV+bNv8HgoL3SSEp+M6Sf7n9Td5RQbH5IfhTtTwEUYXf6O1VP8iSKlgfzBP81cp9cgQHPVyTPvkKz8CDtnzyCzy/KzKNS+oDHQcxA3hf+iVpwhZRq3km07uELKhPzPttQs6p75v84st1Kfu0lH1Vev9QKi6lAqJUy9WfqQXY1TdlsC9LJd5X1KBpOZBSOTpB8FKNk6T37xLfKvYusQoADdUVAKmq1bxbqOAkkU+ygttCBbeJVcCuoLAx1WqmQBxv27k2N5FazfsVAPjvRD5JAH4tAPBrUr35+OSfst/GuA6R5o/EguVdQgbaQl7x62jYPfLJOyABlzNamoOBMv1Go2x0dv0maWISgG+T1PPx+3enJ/1PUvpZJemsym0d69Xtxwn1fqjK6ZijH9TrSLt66fV0EXM4NDih4+bVuc4N55+bP/jdvwCPEWj+B4EydeaQTYDAV9ULUpyJLfPwXFd5IzOe1uDSogc1JN4nSsAlrLpeAx82ff1D7qpa/e6yVlsCs3oXB2K9JsflU5Q8RHJzkswPkWDPWc5dPpmWDrVg8lkuok9VtmWNMvxXB08w3oo3BB+20OSOjnzyeTOPFKaM4WvfPiOgddtHsOiD3QNVZ7m381kCol6UqqsNT/ovjHRWEPDMYqI+dvKD8DoP9HzhCV0arshdeKYyfWNqZyeNB6Jp1wrRuIopQRCwuMfLaWHcw0M4fO5sfBXWV3DMVZoE6UflOB6kI79zJLdFbxUP2MjviIq6KltHEUN188z+XKstoSHTzuct7YRxb8erqKpW28EK8I5/RU9rtZ1j/R3rrtW8c/jNsh3Vpx1vyzFqB45RWVYxD76/Ju9shLni8YSeoTQZEOWjtyHsJ0YlJtZSjYJ4VHBfJrRuhPv3HL6u1+Sl3YzMnKtsjMuHygZzzVtR0/NWxhsgVzGfd7itkPlGNXiSN6XYlcebGxD5YKnryhguO7fJQIx8duVdJFn2NfEB1Hexx60mJd2CD7wpLCDhzOLa+dbk1h4KFrFEy3rztYkQpvgDlP1j4ovAPZBAHh7sqQe3XgiewYNPniB7vw2G8TAaJs6ovud3U7jbyQet0fqhQ3atNosRO2FlBj8cwFcH5T3fkNAcHsAWe3iwJ39gzzyEQBvuwR4+H6AM5dDNVXKTpFckz8LvCOikggAHlwceXL79mfpNlHO8jvlceV4bk2lsjyc6migonxST6d/zXKRuhngW2xI9ecVUzlqpeJNFEY0BwmAS99KkM7YnUbv+5ui8L8s2ipsrrrVaCt6vWSRonBxR2Dk8LTQCV7OIxalPls1cDTNojaSnI5+oUenNVZwfTv5Ieq5yXItcCzhr7vyR9DZA6CzX5CTe0OfaehFLEHWjGhBFlUuWCSLIH7R5/tO7nzWbn+n3/rs3hfcPx7/kL+/P3vWPz89P3v5zfPL2/MPZRf/Dybu3eVk8gZ8fY6qciTV5X1gboj/jl5c0ptNtErOvwMtthA4SqHOUW1rFR8tb7hZ9tUXNwKGfyI9hhdZ3qf9gBYYKbMllE4pKSG1qvaUOG7G2ZLkJRd/glr8mb4qDrOjo/VbHK6e1WqHbp2vywa4CHJ+mCf2J88/a/cMdUMxa7cjDB+KBmy0hEcyQZshJ7mL0fkTe2hWKMGIJ+6qFqqJIGIFYfIg99xL7fF7K6xJ1Q568B/TWauZ38EJ2WoZzuY69HE18XWe64FGpRqhQK5M6TCpy6PRDeC067xL0sVgYXw+26DdQOfo1UZXgd79Wm1Fv72CwN1yO9q6JyGnM5icLSTc7wa4U8KBJDlCvXm1eUlXyNSpfi6WVDcoGFGhRVFXJzrsEuY838CM9veBjrfYq9piPKSWJy6akG5sr5SLMLzjqe6ASLRmv1UqZPKsO2CDg0Ljzq4RU75XItmSZaObKbcKCUKfmxBiAYLUa97WTg9wtaaPdTQ+DVrfRMLFuxwFNPPAZQHZafnfcHEtmJ5z1eRolgSdKKVnW8uttwosbxJiwBB1lrUtDv65WG9RqO33I0fN0Rr+Tq7l2kEE1ujDzqhRi8J5lOzjzw72Ie7JYhrkzmcnfYwV07XlHFVgEOKsCDFroS468wZeEXCZknChSwINfPU4EcR0Xln8R3ZU/ioeZz6axifD8damCgPs+gdUcXiU03k4gdtp+Jx+wNTkr0kU8OBWYVBUg71/QSdCxKV97qGCDZxDwpQ6LHJZl+PslLiLzGSDihwQjE82od5wQ7vtdHZ7irVx/y2IqcJ98lGVYTK4071WMzsv+4goDDP9Ly0r4W0WY25vezIvuQL5ZSwGMzawe92HzlUw6l5m+Q6D5F4Yr/avDlfq+RYf0fMYxzKOZ3hn1+gn51UvJKsEIG/knvFImV4NtH6tSzDNwDnKhw3Ely0DZR6mXSt8XHdfPsh0aqxPMK8DAGfXOt7SZ5viqHlpr8qq4MI5CwSZynDdcSJZP4Tt4goKDyefEX5PTqm3erDHc6De39vy7WbvKLCZnyzp8HSCp3tERDM1Oj7mkCMNFiw70eAIvnyldQOVgVqH29bytzm2y7kp6zwtedlL0sqPJPQdaj0KTzpIUZCcrZarRXwdjcgsMx1KqpU8DpVsPgmDZ63cYuAnHbaapQQ1uCWsaWINTwpoaWLC3YE08qf9LJ0l5GSlzMKYzMG7MJ6eBVSl5rbZ7z2s0dnrZyD/0s4PhnocSqgzlVUD2T33//mWMntCLcYOKMPj6G3RE5Sc7OdiF7zunqu3h3uG2JmCD2dw5ToNfvdN851CT/LGCnTuLvY8QnEPyIjs/Aa/PcPe7rdUkzYbnLDPQngb/ldRPfYKyxbLq+xOttOL6RAsyCdgLYZmt1IqS7ii8ilwAnO/fT0JBta0oSnQ7pyXx5anfleGC7Ky5nNXOb1K9U38Np9pVr3jIeHvurciSnPqdYroHaZJh7uGG2Nnd3H0LUoz1xv5asUrX5KhqvUsPFq/evSnx9XrBngAEuNY2aYEpm5MCHrBmpE/SftcvrwJZRPqz4LIMeRt76JOKa0NDm6KvarUKWE1Wn5xCab+7MQSbwBn/o/lo6poLHvW3WPa2K+032rb9RhvsN4i0oSYqnNZS/vTNcQmsZ8COGcU77nD1eLLTaAxXj8FmdPPYqQ4w/nYth/F0ZzQHrk5ymEDb5tC4WSsG5tW1YMSpPKanb1U1ZXFyl9cjCxInvOQx+hVBmd1OpfmlCWQATe4mWTaGqaq2Wd6Mc1yreZ+kSvJTokIlGJJiDg6blGbnneTQ3sCP1Y+Y88SBnNCXKx5foucAjIw4CSNlAK7RYeqwqIGWWa6KDuyUfcyf+iy4jT09f2YGxwErCm2audWqJ4C3JeMSp6746FrNBasA2IjGtswEKVEhsceCcYcVgrOMJY1D6noBbAVQ7B3QxTVksGRtDeQeuOYMCbT1520iQOwebhs6+0Ui7yf8nIAIznXXrFb7mNRq72KPyQO2PFmbILwnsfcp6YkO85VLvOC2QBzewi4M3i+3LPyj2FsWFvpSOvv/lBRQ60Lix9cET4X9YKqkZ6WJ8Ls2lF2/XxjAYg9wyvsmdKN3mzSlzytAoywrvMK9P2DF+8G1ajglfZhon/TRRno3+CPpsSZPE3lg67D88KZJEswUaFIhdg5ecBzVaiX4NxKaKu/WDyo22ox6/03JQ3kA/N3APdhRF3Ict/5Q9rp7OIzc+q5PqvmC3eBXb9c6UVbi2a7f2V1bxEDQRFrQ2Y5ex7H3l+3ofDSrbdltoM+UzVaUISHmb9v5y+fOoBz1Bcz0xrF3b7RwKZIkIAPXQOz09J7FGMyW+Xbt4XQKYrqgGN60gq+BEzjK7gL8m2WDEfkoU9B/9AZJLVWLywOz63Wzq16rixaj1CjxoYJgUFXo5WxWKgfFgvu1lVVLSzZu0qw9f02ev2i1KgIAyGDd5+klBuspROjGwZJhz/kyUCH3wQ1MwIkVDT1o13lDrOHy+yxcWA7YdzxT4gDr0OHa+fJQJvvrhKeTG1pdqN4uF2u0TcFwakvJQX2i+yCDCc9Z5OliBP/6RH4IV3kTRFXnr0V6CWbIxfAAM748CHQttZrMfBiY4uBKX/annOnAylMArghTo+2Twmfd83yw/VGnBEipxsoi32h0tBlf3DEzbfVPp1msS0en1d2GWzdZ12uJSa8ewiMM2SuAG7Hxp0VMTEpMxqkFf9bjdAH+cMYyn6eRUZayKjRusrFYcCjq+gjrk5ZCFR3HcBxOp3APzPKFC05c7dpk0VptRzQ1elqfUZzh83q9m7v2tUvr0Ofc31LtA7UCkwjQlj4QaI3J8KnCJ9bXgOn4CJsggFgQU8vDmEdQKgQBkvG8TCXaoz8MmN/xZCQSLuWdPoFUr4iGQChli9ZiMsOuk75/7A1B+c8O/vZqWeVoDvioaREHORn/D0+Bbr9iHgxoWycDfQMJas8GgyU4Nh/+k3PyF8ZdRa8oUud88C0CPg6KtD3PZBNzvVQKI5KiORWv19d/cuzNKFUMfj6020bfIEk1HugZmtKVdiz98ByUxwaTD8D9smgElR8RX20SXxjZutjcCdyB49atrE3we+K5xIGohM7IXUsbxsLm+yrfaPy1jAIsSltLNa22HKRzAKjLDgKO89j1hQ6HTVjeB3DFDyySSC9jFV+wqhkMTyOCQ+9+xpdyByVQa0dt/HJwOu26fG9gjrUPSqg8IILs1Zo8ft5qPd8SPImwYEN6pgIP9NRvRxm4sEqZGpNBA3rq18Rm0LW8rAg0oKXwlr96LGxingAj3OVbW+TLCCK39MxT58FgOWWQ3slC34q1g2Z4Vgyfb8Tc8dedf7OhtYoV8hYRsMkgDrUVCOrt9ujU+T10DKd7PGd4Hdi/t1+bLGLqACoRPccUO1sei7LJo0l+pHF4OTY3rJz3MZ8zYcf4wJAsphCNYx6b8L4M4nXII4FJ4ySmAnzuxj5JIXi8KavTvcpDT7miWq2c4rnKAWsBCJ8wbzCqDP/m+2uMhSuDUL28jrhI2OTldGpqBNzUYJF7GJHOTmvtE9WS1PbYAQUw3MJPYTSd0fjkqjA7ak1Udg00VN8ERXcPl4snit20oEObjQIm2C/FyS9ky5fnGIERWnfxcC6pPd+eax6uNJS6RhUWud3KMRntYfPebpVvVkYy/eHDDXXcmSrt5gFr0BL6kjr8yoEKclLknNEJZV/oVKb/UDf15wg5hvBJFvDWMjRG0KLYv15hEJSvVLuOTqmA1VpYQjxcWUgiIJo5iooLY8SsqNfeMhB6Pvye/abvX8tztgzcQERp7vyOZ2Qoy2ZEl/kq80STzlniuVYq4mBTD3cvf+wwCC2cQ0JWwRLPBAbQlS+TAkbq9SIYuW51c4mseqpY2hswshp1BivCRp20t2qmEXo18pjfWZkt2BsHFbPng7BopQVH41ptZ4UeQejUv9dPwY7Ezn5ghUF6z4VgEITZnl5nTuc8vnNmNPzsTGmCzkmbjlvXLUAU2rpiVzhwI3qYhIMenZvOhaAOVcgiSuD6TsIdFsG8CepI121+t48St8C1sx6vJpRO6fTnUHlW7DdVpYEg/SYMYcBJv4ke8gINHTGY9z7mEyoEdEtVAZiuXKYUw2QX3zAGYd+vCEECxOjnGASqsYwIuoPszhWLcz0ApkgncWVKLj+Byw18As9qryOf5HWAorUVbMY5VjIAVU+++6l2/E7xu4zUYWUg+eZgrUzdm1IAO4QEnABI+DqKWsqaOoJABzqc5ItjTcaBNTJNcJrnpXlwMANYwEiqeh2MydgCZZajsw2LWUlAJsxi0wYDg5FiNSBiXrdMwsYgGKpYc+Me6w1ymLJsPOoMxqMO61kxfADIAoHMTwV5iB5hBBwsaHXZgdHZ1+vMl1FpB2xktQSvGk6+hlAv6AWxLwO/kLGuLx8YXRYpSR6qU55jykMD+7Y+W/KBGG0L7KIxtV0oapK1qZnG/1YOjgWuiT4j26sMXFSvpzJwkR2wyApn8x1sClMGUFv4Cz9F9q4nuTyM+dWBZ3wyuqJKhSKwNdC+bu6BHViRnQc24ML+uH0T7m4263GSh2i00Q6CN8re5TxhuaSdHzjCMXJJa7U3SvdbJvBugUNyKzZxl9wX/X7Aerdk3Sb6NxH2B8XUyANnwTGXOGhl2bgYgQ9Pc9bgolkKjGMlRKDG5GkC3/FM2XRO8sEPnYhHjYhehwn7Qh0JgDX6eCsAYr0v4QKWTzaOEbYg36wDe23VavbbThDkJx8rwJ48iOhcWebZhSoZFTuD5lVUYoGt3EzKMgnoehtfWtpv7Wh6G1zff2Taov/QJNnbZ3EUpNZja4+vt/X4usx6aAze4KHkSXJbA7D8AyvALxrKWjvCYERY0O6yg/K23WX1us8l92a+gUmw2rfU0Qf4bbLcTtSX/hhC/pudbdnEcrm55rhg8TmWewCy1txSJ3qrAG7l+GRly9xwTtVErzZZxIvoBo99U3kGbrp1b9VzHQ/YwTkVIrymddd3O3jjUdbSl8rsVRKsSB8jqt8GS7UdmT7cFkCu9O6Verc48SBIRkoOVZ0Gt5rROwqs7QisrlD2KbfiU9yDU+9owEa6knVuXbhloq2jSmAff+0dKUci+wM2AfvVTns7HvEo+HbD2wovYgpq/E34Sh++B8bWQzA+KCwpHtW4Ilqo1M3ZSd2QFn5/o0vvognd2i3743dAsjkYfx6sItMeWIIcO90S5JOUbDnLcvssmxZWuG+fFwpLw2NBClrrcgYmbb/zYym8+60gaDQ29pPet7agjqfunUNTJN2QQmF5eUIufkK7Tounte3GK/gsJgnlOGi0ydK+xbCEWwzLRgNMFzFcvuzcYDkq9u9+FRQSyThYSutEMMkaH7QKwyQZf9aUZ2c/l2UKuIhI39mMa5dL5XYeOBLDRYoBr7dHXQExXEFtz8jYJ2BdxIwCwINBCxgSU0Ol/+QYrrLMkCTnQXzkV1fBd6Hqwwj9cjar2CM3v5lTBvDeZcT8PgxmJcB0xsqj7V/jljr5uUXabfzVdaCWAdTiE92TKkCN0Cqwg+Mroy954lnmB8DUL8/5ThB442Apb1QjWBVjPy7yQRU5ytUWh+d7x0/pvKp2Xo/jkPq+BUGB6Jnlbp065Rjw0iWltNHYVgsMQ/d7sH+2ibOzCsmlJUFQdP6hXS4Ol1VrwUp9uOqNTb5wTi+ZOm295CiKxXqld4/7nUJCHkz/m6Ml4Si8bSuCeIHKm6B4MMe0kmLPxqLDVo8XFq/fGYzWa/Li6dMXzztKkFOlrksD5u0/e9xugzJZYWo5oLNKzmfhNYbZS6jURTSVb36AMRD2G9ykA1kiKRbIay6mezlHIVXKqZKeobrQ0qkQGSOcF3STILOhX8KZ6cWXcCZ7QsZSCaq/5Ocn/KQ9dpvPOsFkEXdREq7093N8Mx9R9qk+5eGwybiZxkynX5ydmGSZpL6sCQ4+qlJ37BMwEP18nGRcw8JJhyhWJ2iTVbBN+0T6QYvcBiao9WnguuQI/X/Cn4iukpdxwTBRjf9qsKzXR2siZmm8kGrCoCJuNgM/vHvD6R5GB/fEoI92I/VADPr1+ohweMilTeYA1MNr/ScRitRhdtfd/sFtt17vAwsiS5EjX12fQIDdphsEvOch4JCjXu+P/I7bcuV9KPgMrbZHmKcl264H+yO/g+/jwOqM5wM1wCsVl27ntB4YgNSYeD5pt/zczGu/cA1jgmXKFuQeC0xpP8uYfbST1fRYRz40wf9E/wZMQ6fUy0cDGi20NH0AOjvfldtJAwWr9Jkw46GVH7rymq3o1Btn2TPfJ6f1YLeXdlIdb97b+621B7fB7FpvsfX/On/3tin7CuaTeZ12Vi6HpOXWvzWULwrlBJYzWe1PK1Xl6tt1tp8VSv7yp0o2E36xWMgI/wYCJQGDejja47t/x6uGvaNgp4WpJur7Gq+8ajok16rflL/qlfDmF7GIWZRc5ZRdpxTOUjK/TfgGYqQNBtD01nLmb1h7o0mp2NrUt+aMgxc03iyDoYDw762M3iYYtk5bWtA+efrjC0m6TF1TShevZeSHLS4b3oSLnpBm0bBZIMcX4HUkm8Dkwi4l+5iHCxBtxTScNjD2n7/uFOo9pwlqEtGcsVz/QzULmpRr9kkxiIXwyYNmFsY4KrdZMMJ/OMd0NyIlsCxTNTLxOuZfaeQxP8us4WPyZvRairXyD4RZL12uFcABU5bLZ1QseCRKFqdaporqZLgXimMFD+jOFTkHfJM/MoGJN3Dj6+Q64qgPa68ZPmKqtj4tZ2qtLZ2CmIQLfccjtyI2FKe2d03cWjhfdF0/Tz3A1FlSSDzExOtioouJEPimkPwDJv9ttf+861r6Gxbd0JglniDNZpPn4fkr2HQ8R8jvoCB1hM/UcTTXGVVMutqfqwvCdDLlOQYOogJVdCy6Bl8SYHG4syOaEBZUTSQM2xlGpKLxFvPhy/Tqisawp+Mrlnsf0yu2Crj9RkRzGc4+K9EquA+jK+QmrFrqQWG21nxBI+0aBOSaGyDn3jW68vqagl6AdagO3IU+xX7fvS/Dt9695+vfFeKJRRihN6UZF9qt031Ve7WaV4DYPdiDsoeuv0YZeIlBltnWWH25s78fQLKDIAXu7r1Yu4e/a5vtD/wzjT7EtLiIVA0x53idKLhHJxExjYDVVj2BkMLBoJBvtL6miZPwRQk6zDvIH815TRaA4qUSukZt64+JCV80NSDaIrowefri2j3MTkcQG+qusS/XQjsJCtZTmBFEgg1gD9v+RpeUrAaLvpzNlFcUKeVRaGAq7frddcKBv8i7WuI2Cj1Hw3jyxF8jOovi8Fhz1RzLDKRQ2l+LJEzYxNFf8wNh+S4g74mmXifc73AzxEg3zfByn+SfcoLAA3n/IW8HnVzkHQe5pzDQTPgMDIFxtqquY4pi66Y9+oXGd2jUuQG/8P1enjMYWKWk8aqL1yg2QYfd3KB/0wZN2oKWFoi2U6GrhEbTbStH+PciRTsNJaNGH5IiEIDJ/6J3EOVOToerPAZ4Kl8+zhpTFekiZVQq0DVdjUFvSfbOc8Ikm9NbgMSRLkMKFoDlkUXD4PBgKmJwRQdpZMFo0SbWCHuho35Tkaf1FYswurcpXdgvZSAAe6/sbc5sT3REU+aURyd9DWxvOMBYYcPhcDTKhsOm/2g4yobesNfLhkNv0G78OBq0Gj+OHvnwdS8PkwQxvV42fh0Nh8tHLlmahLFKWQXucHg5HE7r3nDYhF+/55J+4HqNnu8Nh5etweqX0SBsXL1svIYW6plXLPDI72Xyqe57A3o8GjTqo56syXfJLTbgtS4Hrfao7rvkNLjHiMEddzgcDgfDoRgOz0cuiTGGbjShndaaHAX3iI7SuYMaKKLK/eASGk3xl81m9DqcQV2RSyY8SkIWic7gdLQmuw/U8YP7A9YBvw/V8VEDuze89MIsjLLkhmZhTLOTH+YZE9EPSTbl8i/FtyW+XaZJBtFmM3EDMaGyRUyT5C4TENLmLqMRT69vsmseRWF2zVl0nS2Tq0zwTKSTm2zJZrPsjqfwL4YG77IZ+0yzOY+pP7zcWxPlna10gxD4vXuNMpolssdA+YLSgyBwCLjV5dEa7DkUhqZN/UFuGh99Uk6yK4c7nWE+SV6v8+Hdq3fZ65Nf3hxnb999OM6OLv6ZvXv/4eTNya/H2U8v+//KfvnlF79TnHqfpGtyEageeu7enkvcXdcnX6204fCRS9zh8NGe65NP+Ye/qby/FqZe6d4VZKsiplH6QN5+MS97KO9tMW/4UN5V3fX+ntF5RlfZ5CaL6Tz7ssy+3GRf5izKvszDVTaZZ/N5xqJskWSLSbZYZVN6nUFw5Qz+wQxlIpuL7Kev2eefvmbTBcumi8k8my4WK1jDBWhm1CCy1wuGe4Pf9obR6NFwz9+zpt8GOIbAFgsN8N5wbw+xZW+4N7hm8/Ru9GjPrJy9YWRXc0rMohnoUqM9G6DiKoP/k0lxvJRTXVnPuNiZxQNZl8WsU2pRm+ZwKB659WIWFJVe0W/FNnz5of/T+O27Dz+B08OzY6AHw6M9cvIKfKOfHXfG5OLtq+Oz8/67s+OxSV2Stxdvjo7P4HlF+uP8rU+OTt6+PPtkJd2Ss+Px+YeXZx+Oz84hwd3JdoJsJwiyv2d/D7JaVqtltSAbDh/BP3iow78gI1kjawTZXpDtZZ2smx0cZAcHQQb/ZUEQZPBfdnh4CH+CDH8OM/gvGw6BeA+y4fAeNpJsOPwN/kHdGfzDB3j+Xy45/+n46OXbf3ZknJDg0LCfe7/9bWfgADbtmZsrYJ0YxnfI1eAE5EIJJfBoNpv2yVFfYjEbpe8bfmbtcbLXfDS83CO6YrI3vGw+2oODVwWhm9Mk1BjBJQbuFhHQ5VEHv7sdfTuntWNcG4Dr5MKBeL0mYLly9LL/r/PTl+c/jY/P+y/fH3dOycv3787H5x/OAC/evHt13Dki/33x7sNxIW2XvP/p7OX5y9Pxz+/OXp3LxI+ajJP++PTk7bH2uSm/XpD++Oj0Xf9fxeSv5CdovpD2SWMZvv2a4xm+U1pCNUxllPTPzwtJISUyoIN8nVHy4eTD6bF8m1Abwa0PC0reHH/46d2r8T8vXp696kwpOX77anz+8s3x+OX5+Oj4nydvC0ZsxcstoRDsGvav+4oZ4SixaI4xGWciALcoa5w9Gk0fygmTOWiPKmYS/svl7+IzW5xc3YRwep1QCDz8iqvrgyh4RpRYpMlAoUajqk5TG7av2F4ha+HA5RfSrUWBvJFbL2Vogho98VzH1Wsgg/tovtfbAd4PKPjwMhsOhe+CMf5YOtI5whor+0NE87OqO8gfs6zUrnaSX062BExmDWEnzFvQ8q1RmPD5gs3oidwe5DCg1WqTCW3Tq/YOv1bLXyzNGEtuaFygES6O03YyIYfIXWMhU/0mVHLOdKBTqDGZ3Khn7DYMC42m/oY4Eb86NYdGUyeMKTqxEdo/jbNkyY2DlWE0RDm/qvp8YPF1vQHUmR5IZe/y7QFv++p0cEeDgcuv0Ff9NI8TFslY1BizH96vZJSzyNVBHzFOtkhMOLBRtwyUjcdw9U/hjZszmff6gmnFWUZVAqpejxEbp/1OCRn8cma/Yyv/K6WwzL8vkpCUbIANVmmgHUV5a7rRP2xNgBk8x9iULMeuZsJP+VLrDeCMz6olwfqYyXX/MtfvpgMwWhkFA0HEhMf0NY/1URg+EDZoj/wR2hDndKiU0VYc8J5SbXHL1AaYeR6Z7CbzHW2yaDJLQTZT7scaxrrVaW/g32l+dr5fzNJrYM342rqaByfmM2n5bR+OpXNFT61G7hN37tZBkBEKOmaRoJFgYJDacxkaL2Ks3mt89JXA4U06SxiGUasSyeF6OQHCS4HtUFIfyG05V8BML5NA+YVYcGiUR0ELJANn6UytKp5/KeSr18lGWwO74lHACw3Lw8+AE23MojPWbS4njRKkNf+E2IfCE5UDZ3mbqAPuNOmKToDnMTJDmLN6e60myvPvgS4UoDH2UpgKFQSeHxxa7hQdUSxh8BwQ0e/mXaDxGQ3UXJuuIH0VhAcA4L0M7NzqbnBu96we6JiSPGAoOh4HhlCD8MGV9+bHRlvczYPLprLrY+l4S/j3y3owViZo8AhyG0llvBZRmzFYNtgfVHIdnPaYWyPucIj7+KA1GrRGtRq6WVvWYe81F6zU+sLxqHO/4y3rWIC4ni5bq4GZ8dpY5/u+6fnvHkiZ/d/1XsRxoaF7LhzaWSgSxKugtcZOCv++OOZWlmIJM56l/Kqagl9Mp+BrjzevWDTFSswNdF7wdySAKgabqG/dnmlKgz6vRZhPivSWk9QIDc+oSOdwneHhxRynM3vVmrxW4sQynLLHzVp/eiTRuhzHQykyNmq1fBRVfTNjCwsyh7xoGgYgKxcX9j7kDQRhIxjSZk5jGGwUvGmWKqlqNeCEr2MYMBZdn0/C6GVyHs7pe0WMcuGjuSWR9xq3fDal8cvZzAyqPSYFemd1QBIsQeA2peRjUOPdlB6/8pGv1w2CFhDPGutSo36Xb0deSSu4ja2q+Pbu+9IrN5NLOQhKdfrFwBhl6FoPwVNvEwsavZRZrVbuVD1g+e7Q3sC9IMhHzIyeNTGgDVwjQ6lQIT4GIbpgPMI4mxXJ4AqLCCNeq9Xy53w7dwWdXbn+Jmd6fHbWcXR+53fI9rt2s5hzqGHiJDcU9FWNGf1CZ3CTJHS0LLzpOOeUOjrUBDr6tS6MiKY5X7+csVBQYUSMm58gvJ9PNjhvPlUchOYfubw8wURf5jC3O9PuwGbXRwUthsBKpN5lYyCrc/LSKUlaXxRPOcVTCylz5qNtNefQw+1fuetp95wb7jK4OX3Vat44yF+buyqMrz4rVHzySZ6I9h76JSizvvknssmMIdQzuqJzKmq18RZ0eo/Wc87vG1D87iTc+R0cFepKfifO0bsPP5lDkfK43HQ8QakDOR1jiuc7OU6NgzEEWFG1ZNnecFnfI6mG/b1sL+dIxrilMuy6Oqrpp2BveJQNL/dARo0JeSmVAzpNoykQm5cCsQDroaAVKuQAkGg0FT+z5OY9HpWyTGU0jeArWKjDr90UHBwBhoTGcxaFCY+Po6lmguTnLHNdUm4CCF6hDFZeSKkHsoKem0leulRCIqPEXiytnm3wzLGYcENfjLPckCk6VHht5vK6PF2yPhVinfwEv1qE0fRdjPeG3iiFtWh+CWMW4pW/HUDMyQ2dfjRJXjkpyAuUWswPR7ntx73OqyKzc1RsypAaxXr9nKgV0iHvFK+20Ghy9y6Sc2MFG7EaE0kYJ6iww4ee+aQSlPUjtl82CKqoMCe0a0Xnge/s8Y5Uz1pzUm2dYtNYAawZlpGg1Gr2V50KLF2qWcr84HKZstkUMiLLYnMC8tyywelZO8QGhKLAIMllRu6BJ9E33BU3svZVsIjCErDLFj6Re1kYhIAYJMLgvV1EJerM6hUK8LWXgqbJs2/qV868VubuSPwsE4aqMhoBrKohYsH0Y0rf49m6YLuxiPlCdAau3ocxYP6UYvz8hEvPEO6IoMf/DWvee+05Qh/gwfl9Gn2O+DIySTvt9Rq3szSh004uJy/ZoJSK9Vy3497MboX2kFVuao1Opmbs+iahU9tPA8D9CjPjKr+miS6ivDOqN7MICl4hfod7rzqL4yoDI52wdp07njpiQSfsitEpnLNnU0e5XL7iaTRtauOjUodA2W8ZQim2bUp9ZeRkhFlOqRc9NAI1nX2ZJtwqTSoHBy5p6G52iuWLg0BMTfJJCpYVEyK21V4cRzBBBRnemuRA52YIhbyWi6ibUGC4gHfx8XyR3Nkh8jRe6KCHAvYsoEf+2jODI4Hy16QIcgdkD3D7M0ajCas24bmLmLoEI/p7CsslOnZyKzLATDLl8/e4Lu6Nc+OO8b+pUG699ke+cXF3D0wYj2iUdDgxKw3KiwTvc8DuM9F5PNdUdCtc2CnWOvTbJQ3upfP3jsmjA0Z1vHs66wgC55cZNJTQVdJhljYsDSI+pedJTMO5Pp+nWhRRCF8xDjSrrewTVSPgJf2LCxGGTNcDNb96nnNiPafxtWrN+PSQkS3AKt51uzqc3cAS7wo6o5NEwWi7gFVyJH2/qIdiFH51JSiG7snf7C8HhfQO77hI/vB8CV/w0gp+MHfRQLZooOELKs0rdYIJ/aFkM7kGwAENgPZbXv8hcH+oWwtayMHx6z+4P6zHYELo1pMQytcHI2AfdBg804AgpbZylWPdPXRzGNEYCQDBavdMvYVMOb57+RAI1X/oZQer8ZWTeX+NsjA9KJIRhqeuDhpQnCe8c14w5WSW5Csl1jSgXMd6JxzX7v2yGYNhmaBeLsmQMHWn/F7Bb8l82j7evSxDsl7egHiDyxiaPMebvEV0H9Otag8GQsYCrsSTpTJQhBQcpc5SWh2SbeDpY/y4XjE0XgpC75RYi3Lsgwc0ywteElo4ZkUYLMrO83kuLHCzjw+M0MxkHNs5ibqiK1ensJ3Hp900SNGX/Tm7nAEaPobxM479e6yu3pBeN7U/x0Imaf+PRjk4knoZEjkhHYZj0EnXPmGBDVkKfCCMQepLZtDbu4yzmzhj8+sMVZ97fpZt1M0XFVX7ufcZT5AW8FmK2FEQ3qNvA/TAqnd8TAEmjMDeH2gD7uCwcGPh959fnr3tOCBk/V1mAMuORUwnYUKngVYCj+ngd8izhxbIoyzzCpW8MiWcUIDsA/I2HWWuXCoc4L3GJbXWGzmnWuRBVjSQzhw9N+Ja6QeWJO+L0T8eMEQnaWXqGDAJkGQZmJiGq2DP+807GPx2OKofZsMk8+vZMPL3rudgqdc3HBAIfFHEY9inH+7XPxBnyqbINV3xWBosOzMeTveUXMmSAcFZPZ3RHpjt3U+ZAEb/pdnqwVcNhkNy389CFjkYRLtgPaaCIN1H/Ce9dZ7Rzt5vXsQbPbOb+rt7jOgmJbcCuYZolun1OpDs9xreYLhsjOpg8caIZWAuudKGS5Lw8kxeDFBeRwU9OgOPWrpueRAk47HyLNQpG7daVEAa6r3liYHcIgqnzUKP1JU4iybkzJ3xpiQFQa4r9R6bgqCeNw4EyiOL/BNZWrwjSZXPQ/QkoPHXc9ut5vNmC+Lgm4ahCHGQkXSazWYYXwsfeDznktLIyQs3XZ9UVvV+hr7iUkGt7sj6tLEr3sqh4bQ5jG6SZCE6e3vXLLlJL4Gz2rNYqvy5eSv2mBApFXv7+8+fu+jiDzyZ+Qa576GRzthMW2e57l6xmOoIQTkf5pKVLtYPVk3JhvX0Q2ecw73Kh3AluWuYFE2e+pgUqC+yrRLL55I+hNzIiXlxilOyslgWJbB6FSZh0fr4aFMTiltbYWvpQFK3aBeTXxy+CYV1L0nzMEa8x/xaLX8dsNHauv2FnvmO8F6E599L1/MXsDWaQ14583n+TR7pkO//1b4OInLpZlnrX6hbngjZoJBoFDKI1p+M2fevflcE1hgXCpFfyU6LfC3XtJGCcYcWMsSOCIoHtl9JIa9mRQuJHakyvcjNLNChD6V12xDDJ5+aZYtz7TKRWKeyte0YQw2vEdDKwbkwE7d9WOSQt7oXG2JRWz+mzrAVmVDb8Svs+lr9yrs+6Gp/tRWnGKcU1Sv6HGPj9AX4I8AYghKzB4KMRwG6rchhZbINAsM1JloKAsISzx27PtwdRkS39De4QEq6A4xJIrpYrxouZAeRa8Op1RWJ4KExIQ+NxtrqP6hq7V6sPTAgdi3OHnvyli7fFOIp2yqRWu1TfqWhqk950ijLrDefXJTYALDAQEFS515GGbwAp2oXOTTgrudKgmIf+IrQynEv3oQAQoH9z0PJsFpNehLByV9LAdcZJUxPNwhPB9rcbpRXpmSBeB8RzrlWJtSMEFa6PggSaQjAtZb1qrMPjaZCC8B1wF4ZG0I9G6Go1KcVxW960VgjosrhwFiSPS6hsCYPun2hpZ+Wcq/n/apU/xBDdoZSo1ahqiNjY2jz/1gEr5ukM9i2CwPEkK9jRSUUYYPc5nE0si4mMuBOx0oR6jGU55K0NKBGemY6V5hWtqnsYEpZUbrEuc0yxVy6HDT2hsPfdh/Ve03PzwbD0f0aYki7w+FuzUWTH7DQRRspsD7s/VoPeAdaWyF/qZuHZJ+UNiXCmrLtI6nPKZbKMu9XjPlbWIGsVKrX6uhDqD1Px9G0NEtMzlIacLP0czuSHIkuCAx413b55qyoEaVcdJemn6zjLRUox6A5Wmrw8Q26zDa7bGeCcQmYjwfwiyI9sW5Awd71mS2yrLBdZRluTRf21nQRXKgloE7pFzsQ7E0mWb5itWLAG2/iif6q0GUsf0uTMDZSbmKNQK/VMX5NgSzdglS1vAmeor4PPT7dax5wXKuNgaazKxw0IsNPrPTwl4cQI5Xnhgy3ypAB9QEY3U6+3xojgrE27EZ+ZoWEDRBSmXaoz0T91ts+2VkWNR9KN9pylmyqLT0dpBuudsmX8+3SYQrhzcsQlRHBraQLXEsrMHr5bTC2rDEk0PmqtuiMNIoyCgyrhztpbkGVQ/mDOkw4UrXquD/UV/UfXDgASnWs+0Pds9Aty9yDNIKz3fTQRQGa7pJoQv7ggkg3RoXxzVu2FpsEFZx4rkxfxHoL9GoybEe5IT1s06e1WkgPHz9S01FxtX/BExolLJw5LLpiEUuoM+N8QZxleOfARSmHJTRGIwbhJDdhJCeMCtcIjH6tByui/VkrOcVRYGsrlPz2SGnIpbCibwije792ifZZowb+QuoczIm6A0Mt5IjKJnaDss3lUW5zOV5Lhu8jMFIXwSrLdlWxryBA+YRzfNo0J1qIIbtTXl99GWhXdt7LZ2kg9xjFKXb5ThAcdXnANXXgNv0RxjW6lQyGzbZuz2J6BNwh9ST0v0o2MGgRBn9C+AM7absLoWsBhnznLRjOdLv+fUjrdTKjPSzQ2ZKR5Ok5E8yosXbMP2uGRxoVKkciajco0iNu8cOM5iaGgAVU3wyps3WRJJUKe4z6PlHEO78jTT418/uh5GPwqamvmpL7/FoKRvy5mnEee5T6Ksj0x/xcLMyNq5020TKNTyThC2ASZQxfhqSNaa+htZp5tOyIFHkwwTvvTb0t3cTRXed+Lq47pjhR7kY7mmYy2mi3YIrr7VbLJ0AnIDfcYieCX4Vx52Phyo3szhLNhA3wa5iY5QYY7W+XtIcFR0CKF89CJui0w9aKgLF1hZQGT4TSJhjcIZ4aui2yzLY8Z/lxKLeGTUUCJwVLHiTSWVLQ6drAa4A3V27eL+HbMwu9uV3nNpiqhH1FmqOB6RiNPGdQm0W2fJ2WKwMZj5S1Kg8OrVM2l17WfNA26eWe+obTGTcFj5M8fhGy4KZvoBLK33wDrklqWJmlFYYeZtQa2GKCAtXNPzXxbrmgybsrNFc0H+wdwy7Lt5UV5bKNdu543ffJYEX6o2BJjgJjz3DUFHTCo+n4kook6JMjs0fcbwimSgpCVAieNlEgKS+4GFWe9Zxz2MMI+OmCp5TLeODsjR6hc5Rh5KJ3oO1aSSUA22xY6gvVb7nBg8v4EGqGu9J7toDZr4Ns+WNw//1N5sLYB9rdJfJ2RQ5HsndN7MI+uiLobi5Z1fKWwOIm/+WMTz5bG7jKbJ24Ia5Gl9fNERModM9+aVoXCV3TQk4jtMTa3lpYvvhtdMSLHmYhQ3DiMI7KHARm8lEB4rmvwxlogJzLcPIZBPURb5ghkKwb8HCgCpddbUoOhPeglo5rZ3fXBSvuxNsbivqej2bh0oaxSuSdZUUOSF4aweW7mZ0ZPzHV8lqNLkSqzQ3FZmvUKhpNOG/CztKXwZnBgW2uLPDG5D4vV7Y1gIhFnSJZH/vdSoFuCRaFvkupwh9LIyKzSpdK4W7wSgaz6xvTGSV6MR3oQXCMDusqRIOrRehUAnUVEMm7Vit/GsPIEkaWliGHUKLsIO9z/pnE8GZIKmyRIT7ZqWuytOkWLkbrvVCxlV5qxP5S3WBljrW+lAYcuUETFl3jhRRkTEofUJ5t7H67Wz6DEKaks3iGOotyfs93mLCVHQ5GyckxBJhHo8Vw/a6xwfgjpfHdOeq5OfKYYKuC6hQ3V16XkcmXGq8LYG0ruAxoDDrtgrpNCspzo4+YhtO78yRMqC379S5A8/hvg2U757epoWpJBNKkxy8qIghD4SsbgOPlkW3ZcM1EQmMlzQQRpX3QxZtilTH3RYD+fouHhvt0ULqXNgr42raWKzAuNmNVOpzlHkV3eHNDXZnXh/SgKAvtjqvNKEGDAleE2MhD89F1lUu+JYumEP9Y/m7EGtn6wXNfvXujqNwpD6d06lpRSS6lW6iLWq2IQWsfeDSycWHa5CIFAkjs0uSKrd6E8ed00bHNHOXqeK2/WZhRWmX7uMpMJQ64JgFDO+lpeOrQKGExnd05LHK+tNvN1qZycd9WLgpKnb+iOXz6+IlrXSi4qoDc5iKyTPFAvZzXWCHvAbwUGtbqDJIJ6oiOXb73IIvSEb7fEWg7SspLL084gu25YtR/KmTYPvQl/S7m/sb471eN/3blrgLZifgSlMIVrBaYjUJ8+zSm9sVTlSRt6jSvWWr2MTb7A3794a/gzeP/CN48/dEFj6jBOfVO0YVDecfYSHgXwdLsWL4Fq757/v13bUg695/blshFIC0oJd01+skNipwffHKTA2SMQdAyDlKYw4kWgrMrT4qwXF3MQdLGtCNiNBcpWtTqhsBuoMi2glh+Z6lEY6KrLXnIOLhdj9E3dJZ58gHk/ugscYz+mpevTKtBKsOYAcwECjdDucfUauVNx3za3HzWJI22j9XmNxgMyyF9wSUkv3JsCUDq+8DZSVM3E8uBj9YYmO3UGJoUvKabZK/s7kJJFdbE2slIqackpn+kLKZVs174ILtRQMIniISlfN9cfZuL78m/s/gW6Wy2t//iyRPX727ZueHal5EYdDeku2ACjrioZ1q6ccVeTYlzmSaInzPcQ8uICYb3pMBCaB8xnXNKwulUmQbboWdyu/wdi+UHb0vKkPjl+xPJHmyccZAwuyMwdR9sPQBB6ISHPgc8OHygcq+4+d/j0a/DmxRvuQCHIAbl804Bri1nIQXWtq8aquqavwmUlFEZz5Xki7HK3rgO0TQveBCb0sv0GtRMtnvfJbipJaIpwita8a0F38DalPFIWvMGcvt77FoLXADOX1F/wx7rimKAC+7hg9/d4qbmCg9pOcYcFV8vS58/+kSsvfs1CM61F+n3EGX76eMnT4r+lR92i/RvOhIs+VPKgcm5z1AU9Nn3a3Aop/147Q7vtSevte3qS95UMv6+Og37IwfHXt3yrXHbTxNe2LqcUZeYq1sDVZcajr3h7mC4HE7/9o8R/o7B75jr9XYGw+FyOJyOwCnOYHfku7C3j9a5wNZuB2XzuS+z3aGne1PwfyaaZQdPIwi6qXt3cNDoDcUjcL+zrPt7RN0Au7cr2PB95OnSniyEzarnTfeEax9COywf8Fy456o63IchBxu10bpb9ti3tMzurOGwBqQ4JCbTtP438DAZNq5G9QLkyrMdrD7LmxSBLbIfiKbyGubdS79dHfCVMHCvmLhxiQtI5xL3K/7FPxP8+xn/JvJlKjMJeBvlzn7W/u+WkqANngNvC+OmUTuf96VGoaF4NPTgjw9/7veIXEuocQUdiD2w+b2+3OsVud+ocm/tj4qO5ZROQ1rMHmEnFAcD60b3e0S0CVjnXt+wBVtY8NPZHDdGdfB6prJ0XHblJDc0ctAOis7YlXPFkHNDZT/Qtil3pjyizgR2birCiZOPwwx1orOOm8Qpda7CmQC/eSmbJWMWdVxUjjmTKd4kZ1FKHQi64YCY06ErljiSaIBMgS8SAcalN85iOUUH6+Bf3dEkCRQJTkJF4iRsToWTxOHCSeeh+OykkaCJgwPhAOuH9w0TFjko6YnRrU0YTZ0pnczCmDp0csMdGgGVcG7obOEAkzvjE1Bu82ueJs48XFxB56WzewQG/2DYOkUvZYhQ+AOtpxgP2UkjCQYkiRu+SFDwAEyFc3mNwH2md87lHQzmwpncTFkMN0FgcPl8YZzm4xtALx8S+TulYhKzS5kX4JP5rtEZDD6Cc3P5OQmvZVIS3+EvCh+FM2WxcJRcA35BsQzjkUzkD3PoPJ2FCXWuJs7VtXM142Fi5lvARAHk1zSZgVhKJBBwmkUJvaaxc8svhfMZOEM5GjN+7UT8esYvnQVfTOVwOkA0pvh3BvwnTrmAK7OOkJULRAZHJGHiiFTAbUgnSe5gGNLI4sGxZBrpkVfFvoQxuM4KWeIsb2g0ofATw182uXG+Kk2581XGLHC+XiUL5yskzPkUJ+orBoFAdPy6iPmV83WR3DlfpfdK+OR8FXzymSbOV5HcQclksnDX1grvk5xI+eSWgEv1DR97GHnOJi45URm6e2vyAL3+QVHWH/bWQBTXa/L88ZPW/v9Rmz9w9/bm7/704cP7PW8/aw+HTXDc67vADVidNBendD9VD9zfXLI3eNn4NWx8HanfVuPHhtq2g+Gwg37r/Ird065/AaBJEwYzkh2n6MNR1wDD6+4WPZ6uYR9NgwEjuf/PCB386lK23e9gRIqWhaBYWI+KJBzGxCbheCRyR5YX1POKndP9zesFbp3XXWc4nN4/XvuucUhZ3fWC88oiahW9yCrPzfeP18PhpbseFQZEOSitBi5dr824eL3gN5ioUd3xmo96voPA7n4TzCKau47Mj7+WBR/sprm1Gwwr+c6+am9zugEJ45/uZr6BM9vUooWc1pr8+LT14seOsLyZugprd8foUtu8PIIlMHBDob3r8SvtV0+63MMtGN5YFM5md+hYD9IjuoRUswu7U45ndLzSSFyQ+rvExRtxRO7BwAfhnSLi5mFQoAWW3KAbv5gvMQ8WUWFC4EMMrcrQSvBdbePwBWkDZp5RWLTujEKJO0ZnU5kVXQHi+GO2y/T6GlDNDcVdNIFfoNJQPbq2B9DmQEWgazGfQw9W6l15jHfB0nKAvAZkktyGCxTJtQPdEPdt+NYl7om047pzR3gHSrudgCNGchIlNP4SQkFBkw9sTnkqwaVhbH3E9/yzkhgY2KBnVOZk4jW0RvFRApDHVtIvJ+glcUpB3XJxdmI/m9vGUGWUZ6BRVQa0s8Veq8cRGbiGgcApZfCDJgtqOjgik9QkINJIjRBMHXA/5wmPpbcAeWfLJS7s3eEM6z6JEuglGO9/ZIh/bxXpcMHISH6CMudqFbvS7NclrjyvucR9nWOsugfuEhcFNFAOb7/BA+LRmxCK/kzDz/IdnmTa+5iv7rD+q5msF6JAyA9zhiiBY/7sCTqDRERI2s+sl8f71ssL/XzB7GwXzM6HFeavdqZC+Rf9WThf0Gkho7QxhU6z65PIguuIXV+wUgJgCIy3iUgnoaRxlL/nMenkMFgR6HAgTcg5l7gmyhxAqCLLuSPLXe2M88/hDQ1tj49664WNhgji+m7B6+n/KGdxG34JgRFeWAZSy4BD3IGDQww0cLB3iNcN9SE7ZxqGw+a404DjpvY8vvHpMBvuHe4RJj7E6ezu3YJGMqSMcQxsG34rU8+6MuUjqfHty0Zd9wACS6Q99xD0Q2mthmZP91L0xdd2XXgDHCuUhnBtLS1qtHeMv2DZxrsrj6EfmrwutsbrtAUHwn6nlLBeQ5wEcx7k5gTIzCEuzU9uYwiY4GIACG/cw1//kUt2g9+Hw6a3e38EZ+WPgdvKBu1Cnqw1aDWejx4NXuj4EWB1WsVxbMhnfocwEN7u/ce173m797trjDzh9zL54mMkiHpj1JPtD4eXvxue43dTFB5kAenROK+gUECGkChD70fI99iZZLQKKad42XitM6s3/1HUqyhyeQRF2ipze1s2/m6EoyWzPd+WDT7V1ZeiRIB83S6UgssUw+G9YqKGw7WbywVOixEaPhmX9jfJfPa7KuIW2eHfXWKM8sGm8EFR0dcRsblhdzWfues1+dU0NBHif0c7EyHc9UYIhhJjqRv+3f1W5RsBGky8ixx7RVMHi9gb7g0fDR+BT22IgiCjSRRYxC2MbynQxT/0aafubikA5NOa58IkF/CjWjQqCy7r8vgkHnkNP1PsuTCnlVJFeayHwW/DaOQPReHohCEXQFJd5WMekzcc0o8wpMVANMsO74lobji8J5/Ir4RSckFE0/IpP+p+zT2LhVSzeEaYZsuYK7FfipsNbxhS3wh8Z9RiGhkleUs+mdBgZlrLRZq2EPh7mptRfz3yy+EnFmEczoVLqmp98CRU2eaElgSH/2U2UPvsCU5r3FuxAvYPnye3whYlnhK1E3fu3788e/nmfNx/9/bDy5O359BCflz6G0rPxy8bX0clSbiR287CSzrruOKGXoa4LKUc143QfU+OVE/XPtGZU0HHKhQx2Tjt2UJbPWq/DcWjwQ/uKBXU8WTJLBRzH9L21uTPYR0DxCupEQb3ZBiNhuLRHslZKP3R87zh3nCv+WjXz+DpkTd8NPhtb5QNfns08h8NIU4KSIr9R3tkWXdhEXZc3/fJt4lFmCRxvoTzpnUlpVWZn8rB1U0xHkjdzWAbhFNfJlEkw5MgbJU+VGdtGa7xZAWaVXlQzAFktLguyUNic9eDCFO/ef7oUdXTcOjLB7/0mEEPKoKi1F0ENjh0y1J3RRXlty1iIbXYNtiRyqa2Ulc0NzALFhUBewRu2EkBxX9sKY+sKd0je9upfU4+hG5TPLJB2ujwCrP1TfW3yrmeFUbjtrnBF2OhW7jQt67Y8zelV7pWU0yDtEklobPbO1jCqn8ZRM0/YLcH993R3ncMrmqVPKyfWa6BXFsiur/v6QHLIVCqkytHCk4cFL2AUsV9sAudLdj911YLoD2yBls1UQv67c6OqjAFIh+59aXNpu7C+wOzpcRApYFSqXqego2JMsM86LiD4Wi0KQ7NK9NCojUpjGPeLWvlDC+9XmD5E/f3tiPL96ruADUUDi2oTXivaZIJmsCM1FF0ixTf0/JQYFJyMgvudARN3D/VbE5fhv7eutA8qLu95mhvjcLJZ0+fP5eKgqqTtSjaxt5XaPfQI7wLmvxBJVO3hQNEwV3VBgvZLSUvSOrkMYC4mwL0bROT5uPHUdddxf59ezf9XkX4qBjgz5YIj3PDBVDfGu7Rt4ucu2uysqKIDQz7Pqqe9rE/KpXPI+pJHTxZVccTEf59asxlfJ9oFhAFZpXjV2povSb7z54+b2/DmQXYXIsbOpsVMCfXOzd6g5eNr8PmsELxjDrnKx7TEC8+q1vKSvOcRgmbYRZ2JaMgyYsqoPidhknoTO+icM4muIFjBB2pbZYMikw1amclQccoy5Imo/pZXmtzQEmqLl8qWn3DplMaOSroKlZGE9STGN12OHFCES2gAGi4+6/PjUJ34qDY2JnMbu6cyYw5k9nCmcyEM5l9cSaRiJS6F3S1iztnsnAmC+ZMFgtnksYzZ/JlETrTy4UzpTNnyq6uQG3rTKEcvVygojZrOXQBmvTFRHxx6AI+xaifT+BxJSJU4N6snKuZc5U4V0sHLm9fXy6c64lzPbl0rifMuZ7MneuJcK6nsXNN49i5vrlzrpkDY319e+lcz5zruXM95871wrleCOd68cVBfbNzLSL4Bw9fnOvkq3OdOtdfnOvlnDk3uWZ4MncYXTns5s5hzGEAMkOQ2WLOHQZws3juMEEdBkXZMlYa5IUzE84cbllPnTkNRRpTZ86cOU8juLb0hTrzhTP/4kThzImmsRMxJ5pzJ1oIMXFgqKIvDr/+4vAbpXwWWvMM5gVOfLlw4sntpRNPROTEUyeexk5MIydmTgzJcyeew7DHc+7EEXPiaOHECyeG3NDvOIVcX5wYJisG2EV4e+mIcOaIcCEcEYovjrhcOGLiiMmlcpGpLAMmc0cwR8wcMaN04YiZcASPE0csHLGAWhZQwwJqSEJIT746AhqEFGgrodRJ4slcWiEsYdNQau7bS2cZgxrCsrZyf98SFLVo0/SA7RSY9BztPahO2xvugs5hr7DzgCmLMrPqgEWLtH6qEM1stpgT47zGf+jE39x/7K1H9gGEpA8KOwAYOJPqff43fFmPtplGbcJja90NPDrxtx/+gZWtAnuz1oIhPBxE6Ixvk5na+9ue1ovmFR/oxL8dFvtZJTaqmK2mJ+4ivhBMZNJsBN2tZXQVzhczKp0wioynCf5GPKEim7Hoc2b82WYxn1Hj2jecseTOt+e26RmqmF3xeBnGUzCmScL4miZoq22lTsKEXvP4DuKS8oRCUpxGYgEXK+hKKjcg0R+K+vC8vqekSf3C1GjSW9Fh13P1Vuu+nE6zPpDfrD/jgmZ9vrjLjiOA83jFkuw1i6bZa7T+yP5Jk+wnNqXZf3EWZad88jl7w7/Q7C1dZnDEyd4tEjZnX2n2ni+y96m4yc7olGdnaPacnVHs6BkVNIG/kPGchvHkJpMXrLJzmmTnN3yZnYOzlXO4NJmdJ3SRneMulF1EU55dRGDnmv0MO092FE4+p4usf0MnnxecRUnWl3sF/sZUiKzPoy80TvTv65jP9fMHnr1iAolkdjxlSXaMTvPhh8dJhoGjshNUs2YnEUsYOhDITsGGJntD42uavcHC79Ike59ezhh2Gag5zc7DLzQ7v4sm2UW0UN8u8BZj9nKxiGFEXgoBkKE1LgI8owlAHl2xeJ69otFd9oouZvwOwIT1mR2jIVZ2Ip0qZyfRF/4ZhlQasGdn9I+UChxdoIQZeoun2Tm+nCd8kZ2nlwD8ubQUyi4i5Z85uzBW+tnPIUuyV6CBzt7IvSR7DzGSz+giZNCI4LMvNPsALX2IASv7PIpgAl8xMVGPZzScZmd0QhkMA7T1MxDaDI2Xs3/GYZRk72OeyLzYjYsITZhhvNSHC0Gz15JNy85hFj5Qmv0MpNslEMys4Wsz1LrrA0UpWiA+cIxyaJTOXXPUH9xvHGSqZUv2mWKESqKHLB51Alz8NW0N77PdzcYKp87qtvXyzZsp5Nvca9Zka5xebTk5euThKMJjHeRrlXLsor3upjioGt4UxS9H5oCRChZdGwHL7gNWn2Vrr/Lm6WFVWSgEnV/O7jJltJhJVXwGdEaSS3STg3vNbmBvJlbdfEHjMOG5VY9FHRtQaSMUWeMSny4jnmSNSx5njcsV/J2EghrXklljoruQNSb0j6wxuYbE6yRrTGbwhGHEG5MZJKCDlKwxiSAl4olVNOKJyhnxROfLP6vbFlljgvfKswY0RVfhJMkaV1kDjB2zBrQMDbO8IIN8DD4w/ALZmGyIAUhMNcUi+AepBbCYAYvlYDEDDIMsAgeIKbhAMZ01oBlZDhpR5aB6zFtoIuIJlDAN5e3AYKNRYdYwLYqbGfyJs4Zqb8lm00kYT7PGCjX4IJkFu3R7utUpPUclg/1lDQ34X3jQnHnQfDQEkfZguBzVB86o1xt6W5H726LqssFVjocmmpAVrdC6ICnAl0Tm+rrDD2mmtklHCkVQkHURDD6SFWElu3JYmn2SPjimw11k4rIkTmmGghAZNt8uI9Sd6oaly9v7B9iSFWYBdLZVwcwfFgdXD7YRMCi504Ut7R8YZtad3KDd2OWdNE9SNj/46ZKj0c2UTthcmkOx6BqNf6Y8RQYaDXvABMolUqbrhspcBSxwE8llS6OzUTFyLqnk3rbKCG2t5t5g2KxCYt+IPj7m9xC0o5mvRsDxHsQS5yCWsHVcC9BrLUTbHZGyE92CVJjnQ3yhR/iWnJIjsku+QmAH0n6+/+Jp0eD2r9m7/O8yw6kMev6fCIZcIQNazQvCH6Mge9n4dTzaI1ZcBnmUqOqh7F8P/D5iwVbjx3GzMXrU2fNJIWHPL9kNC2U3pvCmNkDNeTer4e0S+bQahI0r+baHp15LN7LdZLZR3qT/1oPKxyP42278OEZLotxQNFLn25wipZX8xho9Vltka0MVuQGJucxTOmFuClQfKNoP7jdFqgb8g70/qXy0jKe0VVUlqxWg4unbdWtqVaT1DwkGrIqYJfHOT+pbMgx+Gwr3h+Dg8PeROmuO1mXL7DenxPnlzWnRPBup30r9xmjXGiZoqrq6vYS/Yop/4fNCxcJeCrCSFV+ut1Cdb5ts7x3sIFKrbh3uFRXeeQUpWZElGZPK/eWvtGNXPYbK5VgRy+blYKfR2CN7jcbhHrkv3Z4y0oyd4aD/6uWHlxZAw1GpH2vCyHbIhr3VfKYL9zaK2gXt7fcAr2iAmYvIDv28Y/kNqUjh3t2MFi9xlKzCD4Z7mOlwzzZPahVUngO0P5K75Gj9EFTSfvHbYGG+b8KFuR4G7NYy+nBvwmg6o5dh/F3AHmYHYBK5NYem9wcVhhCcqK1mD6og+G8o9jD+uTLB7B1uw00YA90Ir7qn0Udl+DegGu5VwrV3CFD82ZZzGnJYJGlFbnMktW3t508fP96mObkL5bYJMT54YKvY7qhwIi41beAAHSQCf+vu9Tr/qAX1XdL8X49+8EBMMAIDsbRSfFn0dfg9oswqcasUxT1sJFcYfjpfzMKENh6UJN9barnh2mru79YHSZbLm+lf7wqQfHK/Bh0yknyfLCutUnO7S+Qe7p+svQY+4R//vkX2197gQ+IMh8ko/9DzOnau/bXf8/CiE1jA+j1vgPn9R96v2aBR31qw5yv7z9X3a0EtrrWAqP1vqUFXJT1msfjttxWUD1dwGlRwDlWmBEvAb6czHO4hwwcWhs4wGYFFopm/HzbyuXbGH0xG94dyxh+KNY62Xxdyf2s0GmA2ses+sL2UjEkHw2F2OPLQkLgH5sl+b+CAWUbkOXV/8JszGvw2HEYyZTjcl2/14TDq+Y/yDroHfx/8PWiMemqoB39vjHp/P3SLJjVxennnfv9Jcfvxyt0ZDpf1HbfOHspz4NYZRiR7IM83qtj8Xhjx2laTrl33gWLD4aPvLniZzvBmkiraQGxAXCjLETbvaJZMTLi1QWvbCA636x+4UmcZOEhbnstSu31yS9IROQoGzWbzdJT7CpVByo6k+n7sY9QZZel6pM+5n14Ci1rFVuZs6x1s8PmyPUWd/tO2udDA0XGcFWRKxhsiY7k3LcmK9IMXj8aNtNEmt4HXPjjo+402OQ1uDw/b5ChoPCe7AeuNG+1Oi3wMWK/R7rTJRSAGvL4rfeHs1oOPZBlc1KB04wiKXxweBo0jclQP+t2jw1Z3Gew/ffZoWZelCJY4agQvfKxgFSytsktTNsWyKyy7qijLrjyIy7D0l0G7cSojhYB/YHDxbjzx996GbzvtvdYj7wJh97ureoCukxd86e2T1CfLRnCqvF/qTI9Wj6w8y0bqrwlvovK1YjDJUg7nCif8NHjxaNkYN2D4oFun0Kvd4AgG9GOwD9Haxj2r9sb+E79hvz9/7nda5CJIe63OstEmX4O01+402uRTwA9aWQad5rVae48ftHrtTgsHkctOhZfC4z7BW2oeh7sjQRC091o9rx/oRChDVsGR3/FWgeVFGh8h+Bn39/D59O2+T/gj79YesMbK9w/atZq3ajTI7aNg3ycerwer+u5h0O593LvtfLTHrt3Y9f1Ht4fBPhSp18ntHhTB7EcAlQJFlff6gccf3Tbavl0JrJB6sOt3vH7A7Q+7FRmDFngoPgxedMWA1S9Gwf7Tp7U+uagHX0l/D7CJjC3cWx0cjLM+Oa0H4+7pYcsutJKFVrLQKRbCz42voyxo77949AkuhT5pPekUvAJuMISef++mgjrKyhljv4kAb4ZZsZvwflB+eUtGeEEnosr7NLrnNPlLkWB4/gWDI+f1WrZwgcgFRSdA5C5ntliLCSuxJzrn9I9C4LJ/0Ts6rSyHX2QhfCyXRJ/rW8qqb7K0eimXP6fJt+Ct1XaYeCkEn7AQKKXuQlKui4nNqna8HZFlO2LAR4Wcul/lbKyYLe9COWNazFiEb3P8ssyuzC75Lp7SuLKJ8chf28hSmCWiH3xi5ynNx5Zc1qhbOfRTMx/IIH+0PyMkgfotlJPNB+bJ/miNUVB4szOp4QjMU/5Rtlo9Ck3d8rYBaJ7TJLD63VVnuH/8Yzw+efPm4sPLo9Pj8cmH4zN8GP/jH3iOK37+1/Gn41fyW7pR9O2r41/013H567uzV8dn+usyMDewV8FT0g/aBwcrchv0cYe+R22l9Aq/01Y6Q/WSU5E34Wd6Rq8KgagwW7DTJqKwwFQ2Ybnk3rGDGb1bRjQ+eeX593laGMdghGG5x5fbEYwak9vGPFx5LaIj1za4r+IdIfmTwY5a3fEB647rdT8djEeBGIzr3HBLad4ajUQa03OIi5D3R4Y8RK/tYKWB0MNDIJrjsYzrQT2Z90OcSuII3/Nql3G4QHyQ3QAfvIrRM05cudzhWcAPDw9lPBm3zsCpfZY92f/xyY/Pnu//+DQIAqY5j7fh2y4PTAAI2KsL4Ne5Hdc4hy8Pxd2yILzhM3qO90eVL2mZBxkgkWVyDHaCgNVq4iBoQJw/zwwML2bgh4Ed7iqWFhJ45JADoEBWH8zIFCJbqY/H0fThMnyzTP6RVUxij3XEQatnoQ6vC7+Td6YnZOiLOYs8ToS/hon5GADHBLzSPvkU5OpPo56RV9BrNfnblHjBY4jd9o9/6DeMQ/Ipy37tFnfJBJ1VytjyEV0l9jaqy2K03TygJwA1DmSPeKetejbghOWI3UvVMht3Ur14x2TKI7mg081GXvEoRxBVQo6MKtayA1iEwoZeYdXOtSJuCY9fR1UbYyE/eGauGE45DgXP0XZZSTTLLVkumLkKTelXVvE6yisBcvQJnCx/glh4g19HMrrRJki5i8jChgtU5pR9pqUuqQVudagcE0yyDbqMDDElehSi35+D5VI0oZ7fKbFMoPOmf3h+R9A/wHhLYUWZh/quupsJN5nLDdkcV95mE5xrHEdJfCcTPqvi1ZAUWK0/189C8zRvEGCxqvU7zLxUw2DYM03Q/s32tzTo4xhhU/5aY5nFImtLBZtjV7rTgc4+ctfEFP3X8afz4GP+/vHl6cXxeXCRpxy//XB2cnwefCUV7bFILOgkCapBQQXtJigYyMEyqlhX1DygdLSl5LrE2v1hsXTn9I8mv6ooCCNmXI/4a8xYgJX+sbW5jbxbhlj2azw2PXPP6R/OvUvctbvR5CSc3FAZRGezph1ZE+ap1VS1igW4iDAZYuhZuQJ8zjGomXDJl/gEvyAjYeVXVEJ+LcNm2irICHKyQ/+QE0YRAgJ7BQYOr66Fx9+ohselejYOATDJ5/QPn+i3wnzoxO/Cl3xVyxrz92rEyb8X8McqZoOSJ28FZkvJP4dUA5e4I3cbHH9l/trfquzPTWPbLy/TxAy5fN62TpPNpZpsrtbkWwuWCciBf/HdOkzpJHVC0q+lE9U5/QPPTIySkJIZJRNaPuScH/83HnByLgvXnNoI5MShHVIgrFW4uUNLAYgqJrkF23O28LuyLum9V1c2ho8Bt2rejAeqKWMBJKYSK6HKMpmw3uAcN+tAQQwpJvSRGg1GNu9S2BnNcX9CbZFCaZ80EgXwp85oftCCugYjO25GFXOgRhFLNBXzBJtsoRrhl/mMAuOImYudL+e/CasLmBEvF9hwwCywkI0Aig/G0H3cz52EGydJnnu8gk2XTmVXHB47ekodWT/4cx98Js6XkUOjJGZUEMiFI6WydBy3bnGy1nRVMh5qQOfh3SU9qczxbwMs3X9uhUv8WYCybHO0a7XycP+HwP7+8X0A4lyMZnP7ZaT9JpZ+Gy0VjhUGV28JpZOfZhhgnMYygjV8gDB5ShDSBlF1d3UQLLurel2W6wfjAestG6vOCqPb7sDBkXv9QXtE0l5/0Bp1VkSYYM6ruolLt1ob8c4Gy+MV41vbO8/DUFdCbEV/NtV41pYihdtWPwyw9cNlr3iS7RRPz5ykPYGdbLSJgEhnEFRno2M83tIzoBr/dV7YZnlPJmKEcw6WiC65d12IRNeRX15Jj4iFY4lVxoyQqrBIGzkcQPAomxJmMQIeVyEUzcDYdRSqBxUSRg8CDH0/C1n07vK2VLE5B/6lanm5Y3mXq3sl/F7hcIhtFopiLCgmEq8AtPB71vG2utSbcAEHNXufs8pbR3RPFDQXgdrbtSwLBX5WhoIcwwjyhBR/iR2QI/CdQAsJduRGATuqSWpvESwoYei7q0pJCNdfsT1PBCa75/tBEHgQMzdP+V5YFNbvWADt5ADRP9JwBkG5Nj9y83FH5/O4ve9PKV0cQ3pxkIrAWMdt7ush38llrCaBqwT5wSQUiozHYE1eKKST9EcrEVQg+nQPAkv1wku6EfykX+XHXEWCH/Ur9wszbIuKW4EGOO++lPNu6JKgqN2EJHRpIE+PjArPt4KwfqHxnbVKcaQliU1Rcub5Eid0kXGtxoQ3BjoPmiyPQX88CHIuo2P4tZopCJK+taxM9qgkAfftJNW7SrS2ztK1WuHV86U6fRyoAVkGogtMMw+W2PQKguz1A25oMrV6i+GFYLh6O6J5EwL+dca9HSaAADevaeKl5NT3O5CSvxOe72ugnNhpr30zpCuNYUEQ9HNcPqMLGiYGkXfwJOXkbnBVBt+3Ni2rDAyeZMNVBFKLsbcEzu29VseWR/sE0k1WbDo0gflC2g2lzGBtc4fKPCqHVfjlSDa2rBz9gGoheXXXIEexZ1YZRFfTKEr+idsPo4gnjkjowglldufyzoF4WgLUCC2S97pW8zhYDoBNvkllvXbH2Bkwn/ADINQMFA7qPIPmm+Z0Q6OpPmmNodWAWSNsDSk+TiibeR5vCH+PNdo+hNXfHOeZGecZ7c42xrnPZzOqORE5vhZj+vJSJHE4SdyyPLZQbENCuuXrOU2KXwrHbTinwSKxT/OaG7UYBZ/oxLwoLIlqoQGOBawo4fesc/Ig12BJUYI/Am8pFTVvE3HkOjurWpIGLOf9pJouSFFPp3lT4bEB76WNcWc8ImOcXIOS43rbkLcHgdkQkXwHJN/iQnXDh+nDLKcgY6K6UK93xvW65DkL02bOPYYP0wEWv3PabE3c5hTKg89A4JxV1XsTWqYkoiThkqUhz7tl9D7mCxondxhBqaqq751/WStJg1xkQmDvMnOwDFrd5UEw7i712WUVpAPeGzeWneWoa+PHakRWRcxY5ueW5cNwPoga3wXk9xxXCqAbGOuH42/hzopA9yTGVHRjMN4gAdaBskAFrPSq6dIHntJo2JHRcBAKe3heXO52cuxsVV1RsAWa+Zbkc0wO5vsaN8bdHW8cMMWGSD6kVttBN8beWMVATut1OdddP1fgf6N3PP73usfj7+yfzdqqzlWhhz3pstb0OxGJm+HJeUEYph7fQB0YKHUw2KQ4lmBiE0tk+v82LDGkwCwsDToZBxVCS0UKtJSga+0N48FyRJYaI8zqxya6Ox4L0gIydfVqZIo5BinEYDkKVkRVuLJqQ5dQFhl5eGz+szhWGpe0cly+Z5uCHh4akrWBRAAcDk0e/hAtZBTi6N5vbmnpoHork8xvAadk0vcpVQr8WM+VRZ3ByO2YZ8etWyx13dXvUKLuqhBPoEvdaPdPcD1Yd4dXVMIiNJiv3DCZsLl9DPC6UR6NMLfvOdALs0OUjXIQuI51xrBbswxmIG+jZHbD/CpoYjhICrpVY1PRffQj/12977U6jXZFHbNQJCd/ph4zMtX1fZvnaHXZQT68rMBf2m2xIiFhOYPJHmj3AR6CpN/PSqYHTKJ9FSVnCkS/Y28enlyAG5BJsUzV4FYcXnvlATdNWZKc8jKHo11xlUPKX1vkUFKtcfloljge9upus9nUKTSa1j3gCPIzX8+FM2ZehC46Llw4l0SgBNafoAGy8Y2TzyOrHb7ZQCV9kFTXEw2rZn8vr8hs5odBq1bjOa7WamBfb9nQc3+zySqSUk1CTL2KlHgiKJGJPAfxeFCgKfkn/yCQ+jGExGuRlt/JXzEfCF2sQ7pPTCrfSMUh8P2qsdwkEnIoCyOJu9jf86qCoGWsKe1BBsYTB9giBmapr3XQjE0wvkGvsC4FKi6UcvnvPBMBOI222esBZDIOeAEb2aO0Y73rUxKTpySLMSLLjRNRd1wPeK+RdlKLqdkG60Pnor8K6PedlMZdc7xX4JLlIfvWQQnYtpWihqUufS8xhGJ2H1DeKR9rtVzchKk0mtZqefd1VrqwaKaiF0XCmQt0tpjEl8RFJH+sNLD/jpwFMVIhV/5sm2FUZi5bYVRnUpYbeQqyswtaZTCLRI3N01mt1tjXRA7evdzemez7PZNuBcGHXBZWPnv69PHTmieyoAVHTPXK4dXs34/SuueJw8PD9jP/UVpnjzwuXw4O2s/A5trPWpZZu5gzG1Ega63dev74+ZP2i/0n2eP9/fb+/tMnz9s1UTCI1aHt5TrMMmVuqJdh69sqIFm0pOfJKyrUtINCeZ3UVncJdJ1dy9AcNTAAGGiHskzIW1t5ZYpPygSencDwHAWvvwX5ZDwSflcc5u9dn/0WiD0rhx5sGDzmr6F548XGKICMJezhOe3JE9NPobhRVlRSS56/bR0vyNXnU5rXqlPksSa3Mchbhhz/dS4VgVsr1oyLXUiD08yNI/3NOPFIi6RXVRlicSLF4ZcUK4HY8LYPns2uq83tA8T87m6I9lBgXhgc8h7UFSu4kvCeBi3ygQb3a0it1wlWEnCf8CJ+5o3p7YcHLSJZZD0zyCHz4HH7Ea+L5uQmjGFcXyYe87OWPcncL1auB1d2BIb40tbleTy4o5I18P38xGnUSTKLGCzpqPh550oK5wvZmgsljDwRx1E6l8S0VqtO36zTrgtEOsdv+ZT+JFdwnhOQmAf1+piSfPnXxjDkYwrk5pL6d7QpqFL46EuipvYpirACeICKN5DmLY8a6H1esNx+RThhTB3AnXA240s6dUIBViyi6SLiXlFfGaLJuIW5WJYsKbmnptsqCNIVu07NO9wy1c/6nCsBd2zA/9z4lrXl1v5bld3fUssWM8vvr7gZLhazO7n32saK27Ei4KVJw65EfEpBsbM5YRcy+nPC0R9z6EQwgaZGRzfj8EhNJsyZkC2tzW0dVHJOzR1LJo4NDpCrwjgk8d19Mfx/ac7v18T9h0tg5e+01uiD3LLqa6/XnhWxr4zpuCXUanmHD1u+9HLu5Wn+PcbvbncMrU0j9kdKT1518cOP+QcdGPJ4RuGnVttIMmXXOAZ3lFxW8gcqaGMXKIh3Jy0NVZqUksH6I0sauOMxm89TxGkgQuOx233ggo63pIF89pbUl1Wd06D9jKwo3MolOSnNhy0UgsbJW56ouKBoFWbpXHdwOzUK0AWNIUY0Iq8TyiogXKoTgqZW1uAAG13YEcByZctFCWnUwoTMgjdRc0uBnujkmZrQzps0wWDFwlaaa26pfMm2u9k5po6AlWEBrFtVHAkfIxggYO0XTGQKvTHGpX/Q0mXSN+GClNhOn7wJFyUzZHXskxZLZmGTnMH7jhEQZZEQz/e7YF9aFNTbh8Zcd3Ptv2FCsOha2hZiSIHP9A4sCjlc2O0KHA54JnzA6mBehicS6M5fsyl/Ey7yiwrFah6WZIxjzpNe/gjZPa1+J5C9w8s1im01puioGQZXG5L7FWVPogfgkVWcwJdTUiH84hUDFaOn7KqD2wZApxuF5cXWk6iqeBmiCnhOK+CRJUr+GUwJ2OMNYioM6knJmt8ptjgQIyxa3cBJtNEEA2NrtI6RE6iVRrrAK0oXZjSueDyhlp2pNNfQIxcEwWlP1tJJywBg6IlvSuzgSR3ux1ze2e155nPQIjnaBei+XuUF4qw6oJPCWYK3rHdkim8RsjJscxpfVzEImH4SoeEfmibiIKhmbBagojrIH9gU8sE6OdmgP22/umJ7Dg3hrSjdrcRITvJhIN4mAleeW7DdnvpVbBDQ6g4b5KYNo/UWgAGDvntwQcaBDt/jbw0wVLsxyN8cjW+1imncr1hBptH/qQmAtnvW85+ZCMHjpIpcqZ0eN1QeJ69D4IPvtHypspqju2pa/FBVvKqywl5aIYjFcQvFG8l+5Wpp9BLDm8tQvJRL3PN7YMwnr6jjTX+vSEIkkSy3b6revltqEmSTJbsZvFmg2tis/mQ+/1YDdmWbA2R6uL0CReXKRb91gwsAfxMuNm5x+dsqot+lh8qpc62WPzcrzCzz/bZeJ8LjYD3KwWSU+WswpyTpJiTWYFWKXPVOUpo30ZuHn82OjhuJtY0QYe8hfqeEOsYcMN9P2piiRgqZ0QD/4sZ5RskfG5fG3rx8L71i7NKg0KnSLbI34QLOT3KEsdkSFMpcN7AEL0csmSs1Ep3q0rCxV5W/xMzarBHOYCJgeV1wcCsDsq2qCU+j5MGagOtmgvHoW1V9pneQP+DFTlrVofjrL1RzZ1di47tdzRhmQpcaa7U1y41CJ58Dg9XzvJLXcTinnvri27d9TBbjWCHXQJe/oJk0XhepqkC1kVdwD0PdEQSVQp0WGY8XMf0C7t6s4hLbS/djii6Xdqmhp2N1G5CMFWPFydhaAIyM1eIIUjIuLoRx6TIf7mq6q2dwj++MBhqeln2Iy9lsNRXIdZIxiKD0kKo7PNoNzBE4yNIvuyis0myqRA29oHPgiTmWwFBgOLqdlTJc0lSjO1a253VvKT/1GHKy4E2t0/LXWoSDqXaxtu0NxiwZq/UB+swYFa4BGa5WNhqMieZnU1LmZEtsrPA7qaFlY5IWWNrSyOaLRXp5A295lgt4zZB4xQydJfZcdDzlU6fvE/W08gn0NV+JnKQE4oeMimfzUxpeycbz5kqXYUwdWVb+tEE3LLTWnFuxa1LMZBZ/EKS2XdwmHUKwFXUg45E87ixJP/CkkbipqmOeDg8PmV8D73gqT9pJVZqteNykxJyAU8IMHOmRPo7soKIX9RX2Y9TxlsHmEI990j847Q0EWY46gyURhfGWqxkyi3y9iyzzRGDzJsYwblxqQRBULDGfQKiyka+0vkZ6YVnI8sFy1B0HY405Il9cKyBiKyRixmjagLgIJ59LAObQtKBBsio6VQLHercB+IkycPQPTrt98MB3cBC0JURHAR/0R10jVD6q1fo7QZCC+DwLbslqsKzXR8GRgal6jgR4ELdGlGJkqgLApHAJsghu3ye3QasLALDuLVjTHB4eBm1/NbgdBe0a63EEQ9/w1VdCBukoGBONoaU9d1lvF0DaOLMokqlhSoPBSHui0gM21hO3DNhgPCKrkmxu6Xete1JLP8vAn2Dpgp4oXc+T0Tp9omJ3rszQGghzSZsBNC3d35LnrJKEA+XE5mRTq1mw4W1C8wnu46g7cj3R4VVVy5Zz0POrPUxd7kEnPoVDZKHF3LS2lAvPWlJAHAhVXzc3LeNk7Pd4Z7yumLqKgWEFGYjHAtaUgT8rZyC/wQZTYIRAnTw9y6z9JcvAkomZfN8juUwD3itezjTbRFoQrRV57tMe63B5axMFtR17xP17KblkeLaoQFLATiMKxgo6hU3BY4PWaGN7y+VS5VvAABBZBtwyQ11KM1RtBAJeukg/SL2VmTwgzqseeG7PBe/jLEOsFDSB2DRfwhmbgkz2fZjcuBILbgPFMZCjYNw77UgrpFu4ELa7IUA7IkWE2Q2C4KgnOrtyy1UiSe/W73hjWzIvfBzAW7JrjcKCL/o8jZLCVHgi8F48ffHjj4+fPP2x5olGINC24Mnj9rOnT58/e+r7dbBT2K+ZXCrliV/bf7r/7MnT9uOnRNSh3AvS3n9e8+RL+1nhDnZyEpXHPe2JjnGol7OWAz4CBtIqvACLsc3yWqxZx1uBaa3G6yD1HBt+S9YkujkhzomwdJ0K+0a3fzCGjcLv4+03bznoQ7FV0Gj7HXwRg349v9i9LEP2Lk0KHKkBrIGAMWk1Z3gModwhi9IOK+FKfbW9wV35FK/Kr5S6fRm0fTIerACgVT2/ujFe71LQZAC/t0vBgHxXS6uJeTqJgt1cDE3s7eMBKX5577UPWwpOYwi/PFjh3g9dFh4jYMoO69A3E7scDdqWn8NtQFQKtjXHmUPTD8a4cG+LUB0FLbIb3Gqojg52UT/lpeR2cIQAdY/qdbkYPwZHB7swRx97+LE9Avzp9G0Defic87NeP8t2Pvq1mkpa+mSnLwnnrbFqBxOBj7XaTr9W04mHQW7cV2TAbpFZQHAuwMrFiCT0ieBrcNG7NQvl1iyUj71+7ygIgt1Gu/dV4lTn6+BoFBReINzJqPNV7r744pOLnmcPWfBVSbs3DyYYI4pscEDfxJgKMxHYItU+2D448DztKxDsnoRfuwW8twQOZrXVxr1cXjAwRGxZGzfa/kiab9RXquFO+jC0D6FW3wKb4TVPBDtVYB9JTp73WAf4NQ7c/S505Yh8tAEnF8HOjvexJk+bOxe12tg+BCJKQX1fA9OXjzVw7Ew+BXlHya/BRe/T4KvmAgml9rn1VyIIr68s2KExSoMg+LWMvAACpbXaJ4OKPxtULHKun8hHckSovDh0UavtQDEwhtNFazXrsPZp0P7ta7685asqCQXb2wrS/Bovpcp5UiXmhzS46FHa+9j5+Ntu52O2S2YqRe4nn8hXQilh1O/klBgS85RCLsN30Z5nzVgQUlskNaPWcqhi/tHPk78mZTb831wVGytCLwiJ+eN8A+otK9D+AWj+41gvCe9HG18vgo+DI5x+oLcXFehuoe9FJfp+DYJgs+CnIJcdInZJ+vq1Vms0Ph38ZFDZPjN+JJ/IkZTCOJ/qdazn10oUozSQuASY/5X8anDk156Xtxt8shGE2ghScRb7BEtITkhBjvA/ts8+BMk3NtuHcENtwMjj6CsQSvyhYekrw/58A833zaJYw/iEY0TuUrkA4n96g+9LIrhrC4lscYjdcXI7aP92NPo/Zz+vkqoXAP7qr4npzTdRND+5MktkDoPes17bo05aXeu30K3A1+GkFltRg+Xd9sZ6XE2TfX8D2/rStsDMZee2VzElPa9QUTC2xm/rRMux73jfRGi1ZjRa+1v5Xq01e2i5ftfVDb1S0qBFxpYngW4KN9jTDX8GY7jWMMrdxjzMQ9lgfqMnD0Ip943tMBpRlAYQz+XKfHXZtC/x5pBXY93Dfi6t2S9dj7DUP+a+LZqDVTiyRUfZls5V91YEucaI8MDSEHW5ugHMSBpwHA8yDri8vFOv46FWgXWvHPmYs22FwkjnXWvb2VQjAhYfHwR4A1mlmQH3v1klo2JQUHT1GDrAyFvSdeOUWjWrKVQfKivBSUUAlwr+7eDoHN3CMAYbuq8lCI4mPEpYlNJ1MbP13JSKsPKtYnWTUXItNOjvPSE/w88++QnfcgUsegQztgcof8Ek5GZKVy3QyJsJVSRXCckFsRkvBF2A2NdvYfrTHu94m/aRqU/Sw1atlh70UdeDjbQIxNAEKKQSBokSyx3+gqO0hyR8KMVC5/1pteVlQRabC+zQ8rKo3lHDVLK9/FS2vYR8ZONCj08gvdoNLExmwQksZv1rto1QNHeYW6po07pROj/buI7p440+Yd3oU2YPMyYSmILXxnKirnCRx+yaRfnhpFZjTenmRtRuR7mJdhmmb9hH4qhbNhql0tutGe2rp/JIIjuDcan9jrCN7xpt+VEyKDJ5oe53trUJTallFgH+PmCcaWpoVQH+HzEKtEdevUzCRThhyZ0xGJzRL3QWrGzzQfmYhGz21ywJJXXY6BLwcsGGS8ncLoxvXsbHhG2rFyToNIFGjngaTYFXw7ASWkDVzaX3rW6a37NBzgBXMq+nRAbz8SvA5VW2ecUWEfFapNHeLK5CnD/Q4e/qJC93kpPGZg+rbhJpK/EBq+7fBnjbe1jRvW8ahkL+By1Dqyr8pmlosdZttqFVVX/L1rJY8xZjy20V/xvWltvatc0tN0ki7FiVll5V6FkB+b/vEKOipQ3nF2X3GJtwfOPmcwv4A8xgEXruf6fXntRy2ANkM/nWfWYG3nv89QNwbmX2NYdfAazHgrHng/fKJDGeldgWn0ob7f5bpn0GDr0LkOIuYO8BtuVfvgN8nxGgHjDJBQXyR8q1Nkz+Tk/OP0ibv19oUOxuznN+LFn7FRzH67b5+hcK/BVsP7+gRugXoxH6Rd+O2FUP5JdcL5Tnkxn0M+RRR/dd/ZSnybz6GdK11fmueSS/WFTB+iJf4WvRtne3lEB+sa1vd60X8kvRcHa38Ep+KRjF7tpv5GPpnCi7e0SveLx5sUKZc/Fe++CAdwy7gROg952y8DINUGpau8XD3OH2EjmLPgCXqFh4DJI/4PqhMD9safFIXslAHoq9cdCv1foF8AHqxgpIC7gQrdWWNmxrdZIebwB8GtCpvI/50ZJiSFXG0rhGOwpa3aODFOVspwqUo1FQNFgZ12reqYEzGPvkdL1lxF9eJTTeNuCeHnFfxgL9jlEHetNo5yM/PvxGKXuAl/YAywOqlwbLWm1pQ1sY36VU8my00WgXRl1OX/UAa4GpKq944P+bvT/vbxvJFgTRryJidHmBZIjmIlEkaJiTYjqrfEtpZ9mW86YpFgoSQ1LIFINJACYlke83Mz29b9PTy/S+793T0/v03n9U5hd5n+T9zokVIEjJWXXndb93/yGBiEDsceLsJwSvoKCe1Nf9CVKP9AV1+nNKaEKSJGMbaANa68IwgC4NbDgXgrXx24iNX11cxDQR8mcJ4roZyhiZKq9mIMax9SYFiKx5az2wi6+prvCe6qfIZ55vfSY/WK0NShSWjMLrYIHBZXFpcHp81L7AR3IcLJ6xXs1newtyFKR7Cx2O+Fm/XHaPgr5Hsp7JjkG7Qi9YIsOW8t7e3pF/XKmo76/L5etBPFytigbsxiY+Lmj/WR06CuayQ/Nnz0KQHVbcFJ+Nfgd2bTfXNXQf18UuXitc+VowGWK8Ow034RrpEjjeRzbHWg6G4WB2/aNKpXsdrC/Tcbl8DNaC4R44nnPZ06eh561WOT1htbPW+bZZdeHfpdauFoFH9mLS12QXPmuqi5O+3EsBI32xvYKU9MVODELSt+7WOenLezdYkL5NbtVJP6dfLGgutfd+DgrGPxcKxpJNUiOLdS1jM0hB6vMgGzXRK1keJAT40CpV/Kn2ZLFVhwpiJGaRRagZqZMaHIh8HtBwdZGPp8PzurxiDnZXKZ8I9CRUCuhkbmtAG9dO2XNvwQSvp3SkBYhKc+rRqBft+WGmUJgppOGCKEuUlnROm1msckhknx+lzmy2oIZoNjzTLaOyc5xf13d5JWe5d8lxgPq55WtyBIf2+GkWkuMFeKScR2S0jIQjJ3l57FonfnA8JO8yk7QL7e4tZMta8ANHFRSo3fzlgDtNVxa8I32tPnlkN4NgMLZlEUU1GW/XYbkMwK6fu6x6KkGwc/qm/hBaNudqrep7E+owFupORrgiXATKsj01rUhnuZ4/GGZiZtpsMu2vctteNSdNXFlQ/mn96VO1DyoLW/CgjkQaqPwuK5fTZ7WuxwLFdgP5e1q+HpJ0L1gYj3sZbbbsoUXVL+3tvyyd/RA7CqnLIMmcUaN0aSldi0Mrd/U8c0/3g7DCybHtm3zus6e13rzC/LDCYOB9s7TzzPbsP7OsEAQHzTU8kiM9GWRXz9A7UIyrvHta63q7gVnA3XJ5N7NregN5UneHsJapR95VUMHnqBIsvO47wF0ghn0IP8fwM68E70zjJ7m1nXvkLpd07HXvnqGmTWXxYG90N6B9qUCgQOL74O7pSS+7xY5By+/u2Ukvg4Gnnv8tTNy35fLds5Nyuf90Dgo0dlNmW1Ea7OaO2y70gNHgqMvos0WX0b1A3pKg0wKAhpavu5QGlMpNF9Fhrg47C9Rk9Tt8vyhfD4Nv4aI/hq6574P35fL7DIKakhqEHSD9Z8Gd198L7iCk+x05ChZkV7AvMx9JsgG+6hv/K/1n4XJ59/REjBY2xa4UWo1p0H/27NmRwLDHEAvjTrwLV7Vd8M7ivqsELqyc99mYeuRoDxuXazYY0+Fqt1zuPwsR69nN9+SI9PfeeR7ZLZfvnp5kyqghHpE7LIL7bA+22F7wbqvxzPFenxjeb9++PIJjfX0ER9rGZldfT+8/5Xrqk2NyRHbJ+yKtfM3b2qCWL20qWIFNBQMnw/01idHC6wosCyczDPrKZ0ZsFVku3X7Q/zSNfXPlhM9MyOnAiIdCzxJ9P6DMn4PjuuX4ab9X82Ok2RZPny7MF7aJcLEzEqsE6PlnPlC+SOwy/824JCkcmnGqYvtUyWLg9ofbDPZMOUNxr5nw9aQv2xoY6oE2HBr1AZDcatSXw7LtCVZDocly6dIkyHXZsmW3EPR1FDw/Sm3vJ25GULVcBLHoKrkOhE4c98hRoO/d667kIRwLpbEjcysuxGEB1oh6bHw2x6eemwZuGCwKTCvWghwIz3TXcO2jjYUdw3jjOYOYXyvPq16M2dTVQaJsBLpcdlPzFtjLYJXyPB84E8oMgHskDK7BLEHJ5hYSk1tIcwDRZ88I7o80l2Uh7BA8sMjSk5QGcxIG6mu0TJTfQrOCEFnIk6DKiXdZuLsFEKeioFhKgfrjrgvXoawAq9aGAJVps1/e6kkXYSENdxSZ09oeO43pz/LBMPNhLN9awVllbUV1bfhWxAn91O++lNEfGY1/xNewi5TnBCvg4Qdq1WXc86v6uF0Z4bgXi2ReK8KL3HIrB0gyy/JzZrW1qU71FYhCOTSQifjBrYLKfTE6GuYF3oztwleRKmdHTbae317NeHp5Rfi2EAKWHQeyBtfjDBaDehRfcAD1aNOE4im+1R+/Yi4AuXRnbEfMJ67tKueBAISpZbxUii3rJa48dw5qw6551KkQgks/BlyjThtCEWJvT3rv/BMxwowJut6GtjXMhq2YZjZgunkPqGVNs1oYaGIpY3XpoFpaNIcGUqEv4+6DHXeK0r5029JbVT5m6cEI1F781NXNiUyk8lceCTMN89nWljOTfUfCx22AMJhbGyDMBFEIu9KKTSwz6QdgfNst9C6Qkj7RowDzXNInMY4AZH+pWW15hK0VV9jThvVmGvSwzMqzLV7/Y/CUCFAE6Oc1cCJ2dxb8GeCzpVZ58cLuBQwus61sLVexr0D3fa++BxkrwrL7lOX3qSyLJbdDqwxoYw+BLFYkaP2xoGrHmF6Skuhrkbg5q+CVAUviK+sOQkwpc/wNUroJAACdkp3O1Jj25U90WAqC43K5VNK7M5RHOvwUuCBqyYGF3twP4Zhugw2aVbjAuUbDwQfme06uBXw3UAGStG58pUJCNyZpb+7399B82wNeaX9Fwq3AwupKDljMPbtfGwGGEiiIWVqsm73qyDVZi9Xj4Bqs9o+Ca7AvsEd2RI6tOL1ZkBKStHfsw2iPABwiILHIBzSwOLotvDoEvVbkz6lwynFiUu2mwmyUOcCwWrE1cqUuKDOS2mJj2zvG5Yyn0w09NLE7SRhkAmb2MnQp/maHUjwGFKeEa2MAXxtxofMvF0MZDoaeINfT3mABbh/mHonFwGRASxUVSuhaxhYdmCVTDIyaUXYBw527PEe7IvN2w2lXUU0u3Ac5osLrd48FoY/cUWJp1ECdYU5Pdp4ND8EJGvFaqjTwCW7jUjBfLhelYKEryPQYFEORQssEP1LG1kIgcB0s9ubd6yC4BtZScP201qv5156Ukm+AacfS5yAw13t9X/FL+io+LCmlQNiLgMHA+QJtUfc4C8EsuJuRO0m1GU9G5ej35P1UmaP4dOWR40chtsK8SMWG7WdcuLNHBc7iyqfBAt1ulGrk+GFwKAN7Xi+XJfc6WFQqT+d6fY8rFSIRaACIzD+GuIdeuXxcggCoHuoOHD8Km4bd1S+X2eOCXHHjnUF+uIaAEzHIR4JVHFfXCy2gWqlcP+t7hVrmUsYc5qKopculQLPjtYjh13t1AnnvlNdMG4+XGlrmoCbRB/rNFRvTT8PMPwFHxsjpj9kz2sp58fBWCS3HQxmcuu+Vy5XKolxOpaAdtsbiE7DrT+kzn9mdXsPLSR+iBT8i4llp++Lb92+cuX/jrgKp4v69DjBC8nEQWvZ9en6OyTWZg+w2CO7WNk5KrvGK9t0+CMmzHVlD7uMPbLq+bR7E6D4FgRJ2H4/ZOPChtDcsZQ7iZrxLsvvAlM/tB3qGRJYGOtcWAnYtETCPXH8C+vUpA+EzeyTriFsejG6n98mcHHVHXJoh9DNxBT0DRcJCKBISgLfhRijSlZJskdadB7sC7duFbLgOrwML85uj/sQctotlzRgWbkNAxY4UnMoggZPzKCkgJi38Kg0G8bAqSrp8M3fVknjEXo+htCLP0QczBtb7ILmGwH8T/Ys9n2n2n0lGk5kqi/FfiBUG8VBgWI92wKMu3DSngIY7qG5nyFOWApUOsErEbgHDEDkbYS44fLls3gzSFK5yTk8A7bC8D/XmwTzDsPbtOpdLV+QLnqbreQQTLsZRktCJW6p5nmLkzugoPad5RnkmfINaUL6OHuqrOq6w1cojgAja7E1s7/fiAgOz6lK9m2kKHUbFaBavTY+7G+8pjaeV+HLZf8q9jFOo2OvZVVbqno/Gi3B5sV7oQ5Soawh/D+Hlwc2g4BUpaZz5FGfkR7KQUoElD4aPJw3nXcVVtJlK2HWbs3itIQS6VQyCOwQNQBx6hJfLJXchd/RT7i2XJWtmDBXMCq4q9NoGcGghaBpQIAqu84MSgoh5IIUcG8z4cjcbTOpXGxiVmygkQy5kQQ5eBGrL5clNTwh49FkxXWCThM6m/NN4ZxbHrFxufKajnrFtW926sZF2mD98d6ZmR8+XS0TJmcsxnlnoeVJJn8kIZ8AVBEWHeZaDtK0jJLc7T+D7xaOjUrqlcLlc/FbDQxn3PHPt9UJ/8VuNXLhKRhZouuAXJSuGqORuWvws2xu13CN8uXR5MKIXUTpO+vxmGmF9SqUnwwio4TCLdw03czxICSchOADswSQDK8rnKBnUVpqacYV+tDez8wbNIWGD5tBbLuNBY7jHBg2sSHtJ62Vh83zAh/JwBo2Vv54ZxAPwCk7Snr4f5tkLomcEZZAlr4l5Rla9yE0i8Iw2zKPtPuvhmeNEzRgOs+ACMtNzEy1EO0A/wbAIAxAFPt3gWjbsSLhwV5aQ6tF1EqZqywxelchAmRjNZDNWveUyk1wSodjAlktWCpi3XKbPaqbGO4bmVD9CyJLBANgWwbTCVao3bOJ6trRki3mPNHyxzjRKomsFIa+NlY86euu2PuFDN11swBrbPJTANqa2g6SnPUsA6SMuSjQu8Bjkm6lSC4yDEW6ZTjF2JFIDVo35Dd1QECYHtW4WjwktyaW8FbW4tsyBDJvteV6B/EYw9yw305It1eM5b4q2fia6NIwSCuLqWxWwqRRIxZNsSDGI1SQj1TxfTOl5Qkc7g5+RnXfDnSSdjilEqrFVWyQjD5WKzCAKYh1JjQNhACaL57TxrWvcjE9B6F6GHCgEajrzjdw5Z2OaVcKxJfXZgFhS+8bNN/iGfrcJgEro6Vl6OrnQfBlxUN6MHYZsk509tzjd9UjeBhqLaP/2np8NJ299K9AbLGR7UM2B8cyOip/xXt2Pn3Lwi20BsnxIGCnJsw+pNDkSVERJUl7H7AN9eIup9d/hsx3UM9wbsw9ys3WzrdgUoTG01/18Tc/5bGThZyQN8pnSd4UdujU18hPoPM5ZNt+2+krRry4M0rtnwFESrAW5lyDKHkxGTBOIsBa7Qq4sVlCh18CwmEQ3VChswSfBAp7k6iDkiIN4JVYctG1AHDFH56qpWe2c9tgssRg7tsvtlKQZvw1G0AGuUTxi3h/ptaFIT+2TfDeYCkx4qsJK1/04WLa9MDMyHGQ3r+TFZPCqMYtF8CoGmlI+39DObzRyEXRL6mwTqxd2kreu5rihZw94jbBqsHxHFNb0UDys9aqON1X1iKglMAmmmOstl9ZU2Bkbmng4KEk2IAnWW0QjZY0d1qOQPND+pugqVqsXUh3sViDB5ks33lb/JntouKHXbKHXbCa5dQYydQH6xAKre/lcTVL2ckp6nLDHRkqxIoxgIzKURnawtsZsYL8UzgiYSpZqxVnzYbBlS2dcQOV0C4l680gu5/Gx8PDKvZQhWldb6snoRsRFtQg3LdvqQAzwDf1u48HCelQpd2tdG/QAhYcrwoOcThCmlmrZoFZKCROowG2dUz1TpKBRGYQYwls6CVtpS9whS0tNA7hNHUy3dZDlOngj9JShf+m2/m0FRCQtAEXYjP7MzfSyV6ytIx3Nr3wXbD5txFrcFjVjXxpaX4UEDESZD+4eUgXOHh7KGkxjF9leepvHI6Fa176H8wWQoEyLB/JI7TiWk7CGimW0FlyntwfO5ta5QxunQQKaLNDIKRET8w6AI5ebcY6U1xkrPPeqmDz8m+v7MbG4cvut6OqDDQZzxMwmebgPG1yZbF3z2iM9muTXl4v15WvrmyL5LFZWejTJrpylwk3EM6yYlfpJADq3UMXVfCpu8sgF4vbybG/5k5fmN70sekk2L02Blrx1GxfkWuOkEp3afgtKUL56oLLf5HIBQ9S7z3JVuOEpWxFNTCjDtMcRfah7PmKf6r3m+QoRlcv+uHH85hffVjC0NgEMNiOh5nKg3JIj5WcjVfo51mxYQszcPgp7qZoL4Obq9zq819HWeLXyHgBYti7u5tsvW6r4fNllti/GA/q/9kEQnIf1MNUi/ceR0DP89iV4z4Qsr+IYSnqt3q1gL8N0gKKv5hMdHD4uqq7QuWLJckOY3S4sKGhoEA+7eXqxl6PqhZbcevtrxDq7yNEpXr7qctnUbdPhXcvjq8UysaRuSKdDsC35iJx70SVs1Ar16hVNVvzgZOXDhP+2jAIf02QnnXyY8PkEYtLsOL9diSu/7ezwyc5vV/Lrj4fVGm7JjFc0I6z8i1dijbhkQeHMxZIG6hZGUF0umW5Braafny1WNEcFnIkNO6qI/LV7KGqyiNx8F/nDXeRFXfwRHI+CWh4BwVXHsxowBeu2JhWyRQPSthIkQ2vsiI29ov/Pd4pu7tNvjD1i74/tvJDMLkDluwfZH9Lv8CwJ8kPoZqQPhjVtZIA541/xdomMY1HFqwvY+paWjGg3zRj5ZmREGijYQiXkNefiMFZFmiP65WR8ZQjGNS5dMru951ooLfOqZ2wycrWtp7c6j5JzDMe2WqtHVCPHNqIXbELN5UI4ub+kib/hRMntQmK7COwAHb8ruzzEsSAnn+xEkx2m7APk3FQdJVGBukGxbWVJR95kbNwzRuuQBeJ0UQQDGFimA9JqHQs9bK5uiah+hLG6pWgbjUZukaH6G1rkvzlOIHZjAsy0WaJYZ7PE+BLUqTlfgrNEOklVPgB1CnoL3bVcnuqcrC9BlYquS21PgpmcTG0qIVNi3Q/hLMl7R5wl654PdVrO8+EseciL4SzZ5MVwltj2J3kvhjb+94YmQJnaqN8b+lgP2W+oYDCjIfQGJM7Nq0uCGEroAIoKfpyU5g1NDFKZrWYrRmmQjvxn0Wi0Wf4AuxZBbx7hAM3FfE0PCTMKKtO4wVplm6Q/G6uSKORaRemE8UmR2XXOe24to0/i8oB/elhIxF1qJUs+tVxm4aEIc8btDx4TIlK4YOa2C2YbYnFww1wAmGxbSQBMyrW8n0euXY7RHnNTh1p2MT1PHjd9UjeXFzmHhOncpGKRhb3K2qqAC7BloorAMiicVYHlfbtBs0MzltARHNzGcj8yBcBzExKnZ8ks+u96Prboz2Sno1x+cDo2uenGzuG5s3wakDwEfcg794NeroubYeurxjOu8o2qiUAW8Ga2dBVVFIKiao5ui4VS26riRZX9KNolW8WnstAErl3AQbOttd2UpIblWdzgdvErNLOZ4OCF5E++nd87MawhLmQlQAi4jyYssKeIzAX4i+1NEhIneUfUb55LP9Q8CTLD6+Zc9LyhSWaGity88IybF+yt8A+IFHPsCzADZdA7JCK+oJenx+fl9a4yfm2zRA9P1nSmjX8jpvwbMWumuK32a7BujbSDD6NJEqiWM9yZvJOqLfi+VcJyUiU+yDmp+m8F6y8clKFYMu6peALkAIjBOBIEXBME3EbV1UsmFYEntygAyH0AjeYb0Wi+FY3midpigVprkQbrqxaZFCg3SZxbKzc9HuG2Pvi18G6rnl9LSSqDhVuV2gse2C/iBk9IlARFfegWuSbbekij38Ahtc+K3ptwVlnG35g+srZmlL3N30AgqI1EOmYimS6Lacpc5KhYJp+Px1kV1DhXryaaz4FojhIpM45y29HabFFmV1oZGUoQWikKmYQZj6UIseyPJOngWxM1KV/VdnW7KxqN0A9oQRwlcMsa7+0Jt6wgPjJWa1J13Ofr7U0p/bCx29BcuWyeRTUFleSi8UhUWE9ZEUqcC/YGe7mS/0AHf4PGCVurEKKFPwNaaW/P48G9GKQuBH65YR58virmRduagzExLQXcVsF6KEwRgkCxdwV2UzA5n4/HecwGyc64IIyZMBHMzNVGhW4bAYrRNZC9UdadhxXfaxzUEtT0xWLOwKzQI5vni9vzxX7cfHGBvefnqzBWkmgZ/VzUCz4qiJBkfwhrsJE4Ka4qv2T56iQAW/t8ez/49LHd+I3qw+IqZdfl0RG4JOAuGGlB3B9Q7M7G+NGd8QpkXLkoP6YsUNOZgD8my+a3qCrX4Hm+m0YVX6ivGe+xJjAbI6F9ehjA0lA6mngYgKT2VIc/7kCgjebaPP+acXtE1QZEWBD1kaqmdqwARRit9bGYOkVlDXvuDCiyBFCZ4GPaPApXIdX2T1K1EAMrSfunNEgzq8M2d4zPPr1nwqiisFuP8GGR6tBRUlVDfqM6TTZEjNpslCvGJjGlQP5j/8YJOV+nSd9+3v+ZoErTJMhNTBYH1ffXZs+4abLmB5eEYja2er3Nbp+8E1wJXNROHgM+Opa0I2bVMgaCbMEsrOgDve3zKbMPBYMonEbPkA0DYJbq+982EeHmNtQVecTIAC0ljDe3N2d8HJfL23I3VBiv0gRwWDjv6YNUWrqRSku3Umlpsjlkk8ItqmqnBuqBiAlVBci9NKK1xYHruIcFpQUCp20lXYsRnlNiNurSG7hSsHK4XoB3xCuSWNpGBeJJNGVaczwroVPCf+fNJpFmsaGsBUzLZUclO0EA24hfoEH777zpiT/gtQjGlngTDb56+XvR5KuXPfVQ1Ky2kts0Q1k3v0q/HD79KpoWfKVsTzJeLrC82PufuDXu86j345be0J4FXcxZyRT0VJN+m78GMldZG+Ku6eW2qa/3UnE9n1bBtiWyPSBb3xR9YGwhrSbtk4Kk91qnMh5L9JZMpOom0KbR+YeiMUL6Y0d5zOKieYLkR08U0sjrlTgDBaKGzoqE4XpJm6WaxZDjCvfjirPjVNbOYo42/yqaTunMq15zNnEdsuN4+BlfEeFBp2BwwiQYT1XWHw8mFUiJYIMqwYu/ibzICW1se2OhMQG1yDDj/kaWg/GVBKVBLpabrgePL7O8dW08vjIkkPChjOPlMliP9lcCF38JfEYStiJCylq8ctZ0Zt2jKsRdCqIv2GTk52PfWeg85Etza0tXLu2BPivoM8prurgT26aF5KaB92KhisPRLJ7A1sks69bKYuN2P+45TiX2HeJIkt5xyOOm37tnPZhhHwJbEV7BOCWlIO2lmi3ler7jwPTzFQHU5xF75p23IjfRtHiGrGUqsg3yVkQ4giheIhJ2HzPTeV7P00YvDEo1PzXcmaK7RDgD7bkhbLo04J6fKqEiIykRBWAuUtXL1+zyKtm2mzIBCjSVYHTCRDWSrk8zRL0svR1ybLABA7UrIF0fXIOMo87cSQFQUliBUO0U4vIJT9xYiABjPkuK4JLdXKHwFMH5Y3bWibciZ2lyHMUbtc5wQK4Mhc3i50AoFJTVh8cA+wzo90tF0FRPQE2YS/B0khRPse0soZe5PKSmCBRWl5f0yVtcU85hr2UmTL9Lo3HhTQDRm59DrppkAfJvc4iBYaEKZ4ghasLb1vLai4nO7ColBvsyxMrVFZhFKazwBJadbaEForYH4QrSv+QbpldCapxJawdq2L3hSLIHQSLTN5K+kFjmQkqDQUjYUN5JqWjzZzS/eBbjvfhCAfdy4P8Gv89u6Gz4zi1gBKuWxU1FxRPwyNp0RzNVro/vkdX9jN5KzvYFm20+tDgOkfB2llIoLhyHPXzVZx2M2feI9Ab2AETK+buTFUhUwdqxGzDwolgi8lRnVGMLNu9klCdkDKYmIhipaPVc1PZi4m/3CkTCIO/rA50ChRmnQNoTOTNmQG4KbpHQdkda8IDrTv/Yw2A+WiHDhAeXTrqLB5jz4G2BrKtoM+YqjEaOgUd7jCVfTLaVfTGxSrP4TXqWVzTWHM5CAllh0r3Yt10XWVeb+2AgAcTI4zfplM6KG3c3NS77m23c0+lqD32gt68uNs6COmEPoftCrP0YDsMHugGMr8h4y6W7ZjVexeOuPvvZ1lFsAB84dJSS3ESLoo8td2f6lruJFps2Zb44Fx+wyaPq7k3oJbgrleYSL+ml5UsNq3l0u1gVL66KCMTvIfQGcBtwGryZCsRiX0XJVfUmWrhCzx8/yV01RSBxw5LC125spcga0XPxw4hm3sdxHtn8wKYnk4SNt8BMXUce6zy6fbj9Dbp24LL7gWmskfxEwkc/fiLh6/xEas/hDw5kzce4fedB5kOzqCuwZ1FxVDaf8Cw0AA59n4/oZqQdSiyXrvUWwE/G/AjCXQuMcppoB3KWnGGagGvEUo1MkwGlw2CaiCsrJtNEsjIhTXKeVaLNkQm+S3lCRQrks0k8peeJ+OoNT2fnG7VSDQW8gi/PryI2ge8kyoFpfJJEbBIH00TfC5IvntEtIvcQAmc7JWcH1pFzAxTy8zyrZos/gU1bb7MfVY3kDuYkHGofA17OKY7oys/o7eZ+PNQHGXwv25WUhOtdAQaQ7AR+o/fIVZJ1LF0kkNIXWE6VVBewSBW7yICTeGhKiZOhPl+n/+INknirBrwvNtaw93AN1s41NTkxJli4RA/Y+1WRDFMPTuXUR2ue494zHLjuVzSb9fn01lZQyn+SuZ8yswqe5up+/Czvby5zzKUqrpD6YQASEwBD2qtbmo0k54IcGtDb+yad3aSzn0bx1asLQVlbJBzr8ZzP1TRo1j9LK9Cbr0DX0YUnqBb/uecta3lPrGnwUHm7kUwTbpyrz9QmsrxMZNb1sdjbNZhSl5PmfrPRqbebB02PiKSnT+sHS4iXvVc/IPutenv/oFM7tHKbMrdJDmSqywNeabZb+41Oo95p/SL2fgFF6i3SaOy32o3GgaggvmGuK+oRBZqk2Wi19tudTq3jqY+87CqLecrsiV/wSqN1sL/fPDhsdSpu/PRpy6u48bNnDS2dvkpAYFOqkSsN1SVDmlxlwboQG6nUDFwvvN9yR4F7FQccEWZP0kpJLrPaUyDA/FESsbr3azOm64oxjX36ZFZCDzgJ/l4dxAMjusjh2paal8Kps/72giDAI8wFtv5iex0aod9Wz4/nXdZ/bd6lqGFaUIWYyTxzGP1kBAbFW3JwAA+jYctlI8DgSaWMukc3zisfxU9rPWkolU4SJSgz0hClKiTxSTNz1nDA3zvrpX6qgi6sAWfS8IhVT1zhnmdxaR6xeTR/aNMG2sqmEZ4+fk3OSt3bwhsp0k31ntaWSz2f4g5ZLtcZuOVy/Ey/9Lj/IJslCII4y2QpZk8UdkqEkipgI6/1NH6qk/y9uiorT6qwZ9Q+6h+Y0jVf9oaHjlljGn2k6xzeARQbbtxWINwJct6ubdzN8qZV5RcEb2luO9s3PF6xDtIWQtqZfKZVeIk9lG18idfR5BLOiTlF2/kPsDH36r8pehR36N1D+HrBhJEMlkUenHbZDlRTAG3XP9DzDAFlJQjd2h9BFa48krvrLC2nFG/izflCc17cmJYNTM7yf43IFAbEvRgugwdl1sraeDufynKauPKI1ZecYbNNixWX0mRbcXYRJfeGfkc2EB9eFp+Akhun0zMzCeUK2/fsdo1W6iObtz54TC+s4hs6c6+XHHpslc96AiWgqyG0DKEmy9RFegcheKjJazqlUULgfpjxm995s1oBfX3QanXafhw8uy9gl2Z0CXtxlS6mfJYYs54dNrmiM5ZIpxfg2D+uxsCUDQNO4o0el7nJIfeWhbOvtdnpJL0Rg/dLdTKfsUQ810Bj44JdpjKvhk6u/Ae7JtyPmc4hbvCW3kz7GR1T737VValW7+3+2qNC1FQWJ3F2n6sxBfFqtSIH+/UOzvJ6T/MKnFDp0ZifmSsu7Q1SEg8hFBO5h/p9tlw6QMWyc9RAfMLPE5rsxcmMRjfOystEHZqzyYjPq5PoI7uMYFw38ZvoI4UmvC15bgh4Ch3HVAotZNmT18flsnmWSyrW9+T1cW9zlht6vsyd07MPLCkuQxbBiJ8j4JW5z8cU3lwnciBUTpzcjml1xOLpOLoNnAmfUIcsqlczehHMCQbn/zxJZuwsTajrjPh8MubRyCFcYRxBECyqKr1czn+RRLNLmjjECc/G0eSD4xHdnzM+ugX2AZ2M+ldsPHIXHllUz8cM1GFJTJO37IbyNMlIrrNfC3NB/bU1XTP6kX+wpgJDajdqNfDkRhq1ervuS/lg8EyKVZ/84jSuLE/jyu6TSxIGT34x2KsMa4tBba8T7V0MK7tPGJkHT35ROxvU6uJ1Aa98UNs7FO/9YBrNYvpikpDrwOHYuoEArHpZLqufquhboKIgYCI5Xv8qpuOLctn8rn8IqRBfd7k8Xi6/VHPlWLeO47ke2VWAY91qi7zTlAM5kY9sQiZ8XsBWPKp+ESW0OuFz11t1bdtPFc5BEVpyAPLCL5Xictk14+PLpQ0mbZPdhL9Mb87QfhmgjTPBN2tOMmHQbAs6VES2GU2YYH25XK71WEYIuLe6ub4KK7RfdQYiY0c0NHSCYFdw/GJvZbkIexm9hJ5Zc6ImpVCQhpD61UVPP4GiazcO9Pfc6/GK4/h8BdMhuWeltemA0wiiwAp8HFdndDqOzqmbEsdR+mzzakJj21s9Wy4XKq3XdxVZ1vAI6zX8tueHOvdl9NKvxKuCC2JEz3g6Oac5NSeMQNyHKJJBjRyBPtIu/NyBWhcMRM+FNZTtcRoizYR0PGvvTeC0w9Y3ps4pmQehsTgIlQ3KccAJBOYUjEuIgmv2XXzF0/HoBdbm6uhz8d71Govgerlkz2ALM6DrdsHu8PhZYEWgS9gNnT1fTBm6MpAkDJ4ZGHmuIU2WzyI2ZpPL5yNgSHndfmCBQbtG7Yl5Z0ZvIjZhk8tvIpaYPvM9N94zgRV3eycQDxoSjz1waIgUm+lstl110vtqzu7K5bRnTTI4D7CmdOGt1nZCbtCEBbkxi/hvhisgw41dByror55rHQlVNzKm0Wi9u8dBTDbPGPfIUW4MixXEnmQX7q6nR7zlc+vja80GNL3EiM+bP4eQs5ozqgEcxLirEX3OGcQoOwpKJVaVYyTzwN0NnJtoAQvssMkO83rvXF0Bq8osrIh7/pzcBY5aUCzfg+pUin/nEb1G1fNock6Nld+OeIelUxhPv1xGOzw1qr6HhzkNroMwUBtkZVV5MbZNYXfwNa+xhpGuF35m2+E28ayaANM7OGjX1i5q5h606vWa5zK302wceMT5Ikqid4zOHa9rgFO6Io36wX6n4PtGo9ZsQLgp5raa7VYDYjkxt9U6bNQx6JJbbxzudyBYL3ObB/BoYM1PBS9cgnOIphco+++ar5gEaM0lWGnCS1O3UuFPWVfH8xrwYVf7gEOns6CZ661WUP2aDWRKcsnCXVoQ5tPBdnqeTwRycpFPjGkS9ImZLchekWatUzjhzU5zf19MWGe/1mxa83Ec3d1+M5OiKO9eSkrnmDIKQ8uEDsvHYQhhJWXaiM3CMKirV8FZxs9K9ZyOK81+CfLfPk8nSRgG+43Ofqd12OgcqNyPjM5F+ZXVPzP8IHVDmzwsLJShPKwS1qxZqSvSrtUOi3Zbq3lYk7vtsFZr18Rua+wfNjtit+23WwcHcrfV64cH9uyyOOmDyuLv4ZbTbRTsu6I8vfmKMsUOLMoR27AoJ78XdRkgqWv1w0/ckHwUxVd6S6IHyU/alCgjhy1YMnrWwIQIQ21NHAoBfqiuwFWm0S37rLhYdqfZZexZsdNXBKRezUfAxq+iaR4sHjRbrXrhRq3t18Sk1g9bh3KjdmqNttqoBzUJFQ/hyFmT/lU0/b3epqqJgl1akKU3aUGe2KMFGWKLFmTkd6gqsiLNRru2/4hl+HrGb1hM80tx2DrYP3jE529okv+02W4fFIEbXF2xis16U4Gbg/r+gb1gwDX7xAULw1GURKHgqqRq3TAL3QEN+NBbqXpzDhcLktFlRkgKcmAZ5tZsqyIr0jxsFIIDBL1izAf1/YbeuZ1mW+zcVrNVkyC20WnXDyF0OnzW2behrXbAYnvVyowaEFfLBQT+rYodF4RrlthyT84LXZ4s1lJhGvprqbAVr+3JgfwVOai3D4vAAe6fqiBXc/vnsN1ob/zihE2SNiok5VCqdrP2GLDzDY0+FICeTr3WbG5g3u0IkkySkPGcgUNfpuObn0cx3an5OUUbr4vp9Xw6AWGgzGwUZoqArqJEc3MJAlFxPRPhVXSSY8AE0m4eNjobhwOzJ3wWZ/Sc4aSlRSetUmFPlbU/d9F7CyN2lNN4tSKHncPD2tYmvzS2GQ83KgMPD4aidQmHFwG03uXuAntQLrvzQVipDIOFnon5akXqB81GEfTqtOrNutfd1MEXOr6JZY1Tctc755XLKdZe857t1WHwtVanqMHDdq3TkihWY7/RljCvtb8vb65m66AlMaxas1UXx795WG8dAj2zxhjLRiDYOBDgHP2M3sqBSAaLG3vA62DlcgiPu/hYOiqXF/D6Tr+Wdsvla0g6ARH90XK5u1y+I3fBSS919eoI/pznD4bk2+DOBsnQ2vsdNtmJvRLETT+WfCjy3lsuT4DTJgo7QfAeORSuwy8uYpqIBGcazegEX7zl8h1kn6UXF3Qms89uE3psVYAJr3QF3nLZd9+Tb8GL6J0IuP5eb9O71Yo09zvNxtZt+lU0ffweFcb1qad2aYjW0tYZ0XGAV6S+f9Bob236a+hvUdvctJg9lV0vHoQV6UzBPo77tXZ7+zhfi5DQOcnEXp3MN922abk8B/lzEA8qlXAIgw6fzsGtFXcZiQfhkITWmBmOubG/fcxvwAjsUwBR1wNBj5liT1mNKX4uHMhWvXa4v7Hd+JyxtzIqeSZg9HTMEtdxAITWD+WFIO7cJ4NfnC5qtb3TRePidNGM9k4X+7XTxcHZ3umiVTtdHMLD4cWw8uSyu6nJb/hsFGcavEHn8MBsGQwBbB+2a0VgZL/ZbEkE+PCg0W4Xg7A4ZpcTVBrTgYjgwjJSIjjgIeJDBOJxa54HpLtcnFkN2vAeqbcO9g8f0yEy/1HACruc6a2C8nzYnSvIwT2AWQuCXCgzmuVS9nm5tLrcaNUKYf/WiePnL5TGSHYn6q2HPoeANYgbDy5hbsyo5c4TV8E+bJwC3k79sCPR385B56BW2JWzKKaf46Rk9f5gUTgJXe6hW36y32w329sbaR429usPNPLiwWaarVoxKrbffKDuzKo6YYj7IgwdjA2f9sSK3eckvRmZcE3ozfvMlg2vPB82RwDApdPpFBEaiIyLSUA8SFy6uJXFpYtLJG5dnEhx63aajU4Nbl3mNpo10Ns8gtRG47AOtyWwQzr7bbgpmXtQqwHr5ASbaMJlfgepbbjBv4UWWnWgTd/D42G90/EIpcizO4DuMGqQgIhqLGAMj+3Dw2bNI+cUu9EG4mCKRdr7QAOPqN4/5ILqZSa3NNAyoM8V73rokDMrXYnghg4JrWRxbocOmVNwCjKng1s6DOZ0YFU4i26HTkHiEV7N+SzF9synH3E+ptGkoDjNp3055lHSbBS2jHmt/cK8F4o6KMiotzblbGjoq2iaTxLcbZka5ubpNb18vlj75A1N1pIQe1pLlbK7bKoheApz+uPoZkpHGwvUWxuzrFGXasTORemW/OQsN0hJQOFXdbIBBPTHfKJQizeULCj5mgrY/haUVIJ6mZPvaNAoc7JLg/0yR6lkuey+pcGC9pgbm898BkIhouH+W21D/RatK0vntqhK+K+7owFTwpw7imKbtzT4FrDa0mv9/bEbk7fUW2kFiG9ocAdlfkqDb2gQnEEQGDXun9CJsPs0xygIvsEeRHYHrt2YvBZu8qCOkC6X8H9Ll8uf0nK5tNDd+Q6Tevcrn9Jcz76jvV03Jn33LQUMxz9yY7KQL6K7MPA5HXxDdYCqBe3FPuq2BO/dmHwDE+2tVl/T5dL9WuixyPBz39Lga6qCg7ML91vd7re0+zWVkSXeUgA9qGdW4Jwy9e7fIgvFNeudClSSxLBsGN4ATRTK5cIKwDrlrWgsJPlKQlMJdvl3aXBHe2IP+O4u7X1Heyf+O/872rug/oh6luQ4dH8XIvqQXFu/C7qTwMgLg3TokTlM59aGyVsKBANwdIuuWgGcc87YSEYzQT3uiD0Eqkd5kxUZzis1O+h+BWsSeno0sdflOdwqUA7WYVVFphr9WlGlgQu6YNaVPQfBQKdWeIHWWvsKi2g3Gx3PTe0vwxVpHDQ2cktgRr9U2vaGvFA4laFg4FquuGmv7u/VvW7am+/t+ZXK/GmoMfz5kMwNhr8zt/GsdrPeKMJNkMoSnd8/aHc2o0BfShVb0UP0TgJ19IH6uA4yhA8DX8wBqC0tl+4iGCDp0396LbkSx0E86A+7/FmtXGbusdfjz+o9u4ljwvfqshE/dRdg/T2HqgYL2cwwONYcjAWMrtUqlBC2m41G3XPzLFdYsKLSUMtjcM4v+ezVvAAZhBpDxAQP9xuNoq1SP2zJFg4PDzuHG1v4iXKibfuTnAcuD1I0pVahW7rCSU5cLjMgLWM4ry4fsEpl6A1t9w5BMO/FvhRzrEi7Ue90tm8HgXFt6eDn47HimihSZB7wDGSJvd7cT0E9AzUVVuSwcXBQNDHI95Si3AOJgh50moC3LTIugN5Gl3IUW3r2Nros8OutCbi4Zy72iQgkNho6voW1jMdDx1+UywugmLTyTw8G5ANnCLXP9hsti+L9EfQc9PanUYzRKe3O4npyRc4JMq1dOzzcBkJ+Ci4KMjvS1JQZBBACwNcrgtEHDbkG7YP9VlOswmGrc7B5G1h0oDUIDsY3c5now7kI5TAOD5pFtwNuCwmFas391ub2Yo2uWyscZrW6LIw+CFIx5latcVgE/9qHtVb7cS0LJzYW/LO81nIIFSH22XIpHvhyWRIdKwGJ2IvFYpQC7qe6FqtmssATgh3aTqY1Djr1uhQ/1zvtliDTDmqtthTrCcrq2pBNx5pqOjIM091iGuhdkKdkyEkB+XP3Y3e8HC6Ef5DT8C15T6hEeBkNkJsawT8XVB7tvfP7kHhOg0i8cKTz3DENxjQIdnsn/ph6QXACFJ97ToNzmXouUi9EuXPEPy9ouXyMunyAShy7mjNRqncZRQtvGpTqK1m0NNX4HgXkkCrkkDC6XB5JuGAPxBdbf0xNEmLebr3MpAbhLQ2mtFy+U4fcscTajgd06Mhk81w2VAYI8pmcs5AGt1RpHYLOIdCmZ7THdQrvFo/gvRtSMhf9pID+Ki4+DNzNFV5kR4nnuVM/LATn7Vb9cYcK2cebDjISTvoI79cPH2Jg4Cnf2lpyfqUOn+KeKdkU6QcLch2U5jC/8jCrndGXwQo0GO0ugMMl8Rk2WAzho+ty+XjQGPaOB/Uh+MIbHA9qw6FfcuFf8As10xXq61Yqi6d9Wc1R4IqaPGCX7Qbx4GhI3gVQl1V3VqduF1iRRzu5qjWBdiJlvODM3ENCL5i7u+QdOULjohO5LXV1d73QfUd2SZPMyYnn31l1rrSzsRXB22HbZfQifhm9tJYVQB8s4EGtXcTrq7fbEvi3DxudloRsgmBAfQXgQAFke/KLU7U1qpVe32hbnA53n5DrQNGalm3CulQIdKyNyvRucJyDW+RdIDgTrvMLp3IkjuCup9VwnwxOT3+xW/2s0nO9wenwfrUcPrkkzunpbtmxSmUrXWrqyqt+1nN7wenpqestdy74DIYiEoYeVLRbr37WA5edu86WnfwySthHmokyAojJcglHCBi/cGgAVHpC73chEbB6q9Zs/1onNhsUKHdikXtjTmynVt9+2zdrjc6+WHDRLlkAV22xmbW12MzZWmxgbC028bUWm9hai00MpcUD/KTFRnbSYhs3aTEouoozHxVVlGMqLgp5h4tiTuNinaO4KGBp2dOtOTmZZMndWhRx/axEhThkEg0LcLHGAVwUMQAXn8hZexG/vVXrY+3aOe7a0LUk1KXSYgDbdigE4u1OISO/1VIY8mHnUMqn2839ttRPkegW4mC1g/Zmpv8LqQ1p+lRkUdCLfUW8zP11Y4beQiIfcGHEoAYBmKWHuBKA6XanXajTdHDQkPdls3VQ+5HCKBiFIP/WWDKCCSPIosHQhETI0CHe3BA45bJjqc45JRCxcSGOZsbQFMnHTqHaiuYu6dEJxaF6GzWBf43hvSjiOe3MzQBD4SnEGmdf3Mdubkz95RIk/As17D6AaSZG2c9IgVEVctv9KnQIgT+2Irgni2SOgC1JLcDa4aG8VVuH9S1cB0SPaGxUp0KLmq+bkKDlMlj9AqYzxwisKN+Df9iCdggD27odxI5MuN5DMqdzWHQPCQxOrGS7fiC73a61mlKnu9E+aGsdkMODlqBzxLjI8YOMFTlEO3C47uYCoQIQF71r91h4o8oMR+CLoWurKhj7uXJ5EQjCF/J9YNQsSFOM9qBxUHtA9naIW3hu+FCI+jTajX2p8Cb2+LWRYh0jw6ZzuL95tMonCmHkiOx693EJBYpzl1v83jm5xi2+u1y6uwrb77tzzxMo/zVhRNeG9RgjyHfBUe8IJotce2ROriuOg7jlrqfYNHqCQDvlXTD3CJBL1+Qdmk5fI54AoywkzvWxluJG5EjUOy21GYTksW+EhddGd+jYAOQjBNOtTlOIJgUp/E6jnidmekEwWa83m1IyKUnl93qmhWSy1Wm3H5h1i75llEQUyMFzSbBNhcyBoYDyPWqhAYl6LqQMI0EuXlAP+ASMXFBrvm+Bku2NqTulZAQxZOR8n1M14UA86jm/RYrXIhSPoXqgD0shLZd38e2NfCvNabn8LbZ/CwQoAWnMnC6Xb2jv2J1Sr4dkq3+knhfw4M9pzz0DihnEqqE7ouhFz39jJ8918i0NBkP/DlpZLq/hr+diteRaVUsp1nsCv+Vy6R38L5dQqg/lPc/HeldnQKSeC5nIiJJbCmwD91ZMjJpwcq40NvFbIub0luK53D9sNLey1QycWHefxXMcRq4ELnwQD1eoE9M4KNRPAe6w132gSbGBtjUrWMHClhrYqo8YBzr5eHAkileKugMCfh0ctLfVn9WOsjypwVm3Q+oKj8ZB2nNTRNs8H5WhMF041l+RVqdZaxRy8xHREszpwwN5yzcb7dbBxtl8jUaPFpCfu4Jbk3okrgjdpcNmvZA3KHUgQsO4nxvNw4WBGf0Hbx0ZE5MwCWlLi4wMVmEOxyBMOQIuf5jh8pPd4GivTt4FseT3vyuXK5Xjp0eSdXAS9F0+OB4i+EKTTFuBJDhZLjO4iEzS6BAmZGxxj0vBrqj62+Dd4GRogLh7F1z3rt1vyQl5pyAOkJt3wcL91ut96wNGcFypD73eYOjfr7xV6r4jJ+QO4C1UtbK07lpt0LrbutD77foBGnaFvUIHH6EUv3KPxCs/zUrs6p2Dw+Jrt9nc1/fKfjOHx29qCvh0inHgbFfGqUtlnBQ4lRl9nJU/tzq5AOFgvVXbdrZMCDrtO3yvbqs28qfg/ocHe/xZ2Kv5YYV7xGUBexb2Qp95mMsqIJALA/6M9Wo+2wMXajUCv0FN77+5VNAMQWCXgmRxDj5Z4kFa4cOupTHcqXXqrUIFfnDQtvEUaN1FKK/9n6c5EGGBD3AYzWUSgodSCQAE6glvmzIwc4wL1SQVJYjKkXHXS4UGqiEzUqj/8OCgtV1KhYqxa/rJ+/vNDqJrILLS50tdzNdBv9fXrKcHxFhvuXG3aFmLbzCen2epL2LX4FUcB8rYMOe6d60M3n1HheoAeCinwakJzbP6kzgI9upPaj1nrwaG6+i3o9DgoFlv1xBzRw8MTzaOa8ZubNZgz3giAwqrUjc8tBAM3v0Y5UbNWn3bgp9MotkDlzQ6yYZbudMplH5ZstkW7GNJMLU7Lbm420H8ySTORURXsloiLlXw3IX53nIpEJKdeLBwQTaETIeDeqO5v22M79lUUc8CFpjYjxl4QOZGARq5afIsS3l9+nTe44N0qPYfcxckHqRD0s+I1OvgonFTdzD8ghBdWhOMvpNgkgnOZaF5yb6SsEs6Due43q41xBzXm40NqNF5FCdfR8lVpslU+HXCG93rDeKhP9eczkb78KBQEwXg7cYm1oCtBWc1NWtUgQHAEiDr2bMg7MW+pVm732kdNAsvn3ajWLP2fMwn1OLpGTocaLLYNi9046pR7ffsKI5w4+CdKF7QoRqyCRod+yqMA1ad3IwUH0NbDMEVAS4GqxM+osA7K5c53L7lclwul2IrOSaLYF4uz/U4giDspVXRdQXy+sGit6hG4zE/P5nE0QXdAvVw+Grk+ciWCkooBxh6s6cAVF3m+etzZIA6pE/B8IAgeG/VC3cnrtjmlVHcU9tMhKOVhzC58Hz1ZK9HrkskrhoTDJJZRcREGxLOSTX60/lnu0829kjKKbS7iKJtEqNfacKrdEHBXYIVf1072Axi84wYeKd52Nx6ARbeb4Ckhcr3yUMLnffxsjPvSeimGIOe598DwEZ6/9OXy2b8/gYXbKwXq1M/6NgaIMr3TmGP+M2Ux/Tz2SWqewgcx6Bde3V0ZqLlkKl6vIasYyOiPAq4u9jrkxowMsTgjitHaI8UdiuV66fHXW93cD0M2OBacCS7oCPW73ruu+Vy/nQBuPruIB3Mh4DXzYciYm33CKSZu4NrMA6LB/NKRaN7u3A1NZuN2qePFANSPWa4qEKmxyypIGaIHxjzNYz5nRzzLoz5RIx5/nS3670bzMV4NC57F8y7kkZ6N7irHMOUHOspQW0090RPCRRJB/2hGr0+Je/gJgEu08arkE9v13YZopoZnThQEOHa/kkbQIEdkjDSs7jcaJawnR4VFiXdDf2x0QQj5y6xrtDMu1+ZOMVy8rmxGbIV9figPyRHwRx4moNjEDIcD8kx2hGtsfeO0OdJjGToogdM0mNyBGxQ8bCyGdxgJbDdHmO/1drfOD4VqDaHCpBQRhBYETQ+2N5Cu9U8PHioiReTLY0cHOy365tMbwdOGJ7zGd27jsP4KkJ3GM4wZ8Hf3qIYit57f8rHIzqLN9nZoMN9tLWBPRQgyl6ppDYt06gVm30gk0VJgQBzL5wJ1NIVFioCH9ETkfNjq/HQuTm4i2D+rN5jg/lefWgQgvmzRg+McdUGglEZaPCsWRhDdtFz53t7oA+q6gHxnbHu7cMhXgTzp03FulqQeVD3iFLMAxNjwIPncm9fB2yQDrvX5XLsAoc7JcYmFsK3AhILyrxFMweba8t0HUUxNfbCeYKEASjUCibMGEUJGRMzbCEsatHm1rzy3tzfq5N+oB0OdV3eW6BG8OLp3JPWx6HbHyyG4LzLsj5myNADxdiNO08P40s+KySq8jrKuO56okFlE/76gdLX7fa1tsx1sBjEvb4PJpE4ZnQ17c4H10NyDbHNzmY0+mCWAkiRenu/kE3UaNc7mo1zsG1JTGwyqacKJhUL1FPo5ka3M5c+R9S40W1CuQy/MKfiaYdN4iSanMPmlB/0Fn7sKYdkPeavRVNYkTp4uC8CS0CrSMDXaXWkYLnV7NQfpotwgH1YLAxzVEwD82ABJ0BAD1RHnEPYH30opddtDpFFZp+DZ22QpPQAeNdVIFnH87mO+aNmrT+Ih65XuUZu+sFBsxCvrmkFn4NaW5l1t1qtfVTwUdo2g9/+f/9Pf2noEOfS2TZSwC/SyYjOHmKOz5G21tyEBXATPBITZPqCCnS73tnuk0bwd7f0JSk+H8Zrslz8rnSDwDe4QUC8M+cCAdNQvJr3f2ByCC9wfpDLJnzQUGX2t5UhfNBUBQ8eLEj4YF+Vbj2uNOGDA/XJ4Sd8QvigNfRWYvMC+m654plrH4DMciIfunPQPEdQd3hYK5Q0ow8LiQoIQILc/cP9Q+U9rF07lHaP9XqjLZ2NNFvNWksIFx8AOv10psMXSg2/cDu8MQA1HxdAY/shIMEhAY6//M7rvhPI+7uh+Wzwbigsu4LwabNc3h3UQCHxBJ7CPVROPAH+/LW7S05Uj9xwT/sm8J6ynhD+zslc+TmCY3SFCInyU79L7tSj/GN7IeB8GZB5vBlkHvnChxPZxTPZatRqRXQnKuRI3hzevmS+1UhDLMCXCPWLry9tvmLQA7iMkBsn7ykAfk2vywMAlYQFBR7Dr12AfwSUOVYradsiq1fLfPxsr97rD657IKDxj4faBgNct+wXosFIWEp4CZSXZDO3UT1/YfZr32zSa7NJj5FriWp1R2a/7j68X396ezZj8pokKLORqr6ESflxRIN6o10G1Wa8QM+FTeKUBo39Mgdh9kEdElCWrRGxx+35W7q+6c+o3PUg2kUt525IYbOfCWtSs91DisjElKKa6xyUx9XxAPH23D2jZC7k6qCGcEaDFJJglFPqeeROJIaQCMPGxFu6F7yhBJhdt/SpmoQFDY5UdeoSxEmzZ7Hw0DByhlaack73QAgNNX6NMn2JMLzFufuaAjNTB7K6BX1uNSvve2cgxz+j5D0E7oPOPauXy2fUBHEjES2XKX16S3Fc8suAysDf+mTubjyZwrj0gi6XYEiJ1n0Szn5NyZmQnjf2663HQ9YHdt/X0Sxh2r5CH0/YZtePhZtcMMLX9pHkKCzywPSoguA0MyXzzcDq2o8VS2F3cAzKiIqhEAoIfAzsE7MpK5bUDKSgfbXGAtjh0S1Caw9qbUnmH+yjaSFqvdQ67fqW+XtNz819o/z1grIOeaeExO0y7/JlcNJrNvzWPtkvu7wc/L/ck15r3282QC4BCXv7nrw6BlBXn5z0rhWieNI7No/iwb82j7I9cHLD5G6Rpe+MfE2qXn4LIuFv7fMRLMjc/VZqiJFWq3NYhCqjzFjqtQFdIGcHLnnpI0jc4KgyBRtUqUw121JlqtmoSZUgOb27enpRJ6hV34fL5mQ7mwsnHdz4yRkvApYNYaldiqhN1P44L8lQ5ZgGdz11RfvofHlM5ap1Dsld8K0UTaCakubMUNqj1D9x37kAAGroUUFnMtpj1H/nMgrGNnvBt71vdQOktV+WPK1zGtwBoP+2a1pB4DVCmxy1F4A3ckGDgZmTcwCgamYQSI/Q/uaCEtA9ioMLirgf/IObLvhvDMkd/DdhJ11QwANLLgNwNOgMTdfxtRdRy+eNf+Ji6t6YkhooWMLFVMaNvgfSDRBplgLu3dKgjSZa9ZZlq0Y9v9koAROl2SxBrpqK3iK7nS8oatmKQQrtrB15iUlbIOOz2XVHtJf6Rx5oJl1QT23wA3DrV4TroPZZ4Z5L44TfvLphiXQYkJWDyTVAeel+q9ARDeoJee79r/4n3/ncIb/6n8Xf/yL+/oD4+1/F3x8Uf3/TdyKH/Opvib+/Lf7+jvj7u+Lv74m/P+w7fYf86h/4zrlDfvW/+c4XDvnVP/edkUN+9Ud857lDfvVHxd8fE39/XPz9Q9+hDvnVPxJ//1j8/RPx9yd854VDfvUnxd+fEn9/Wvz9U99hDvnV/yn+/pn4+7/E35/xnZcO+dW/8J2JQ371v/vOK4f86s+Kvz8n/v68+PsL4u8vir9/6TvcIb/6V+LvX4u/fyP+/q34+3fi7y/5zolDfvWXxd9fEX9/Vfz9e99JHfKr/yD+/qP4+0/i76/5zrcO+dV/9p1bh/zqv4q/P+Q7n8Nw/77vRPD/133n7ZVDfvVffCeB/7/hO3HskO/Fsn0v1ut7sVDf/884/d//AfH3B8XfH8LF+P6PiL8/Jv7+hPj7w7hC3/9R8ffHxd+fFH9/Cpfte7F63/9pXL3v/4z4+99xvb7/c+LvL4i/vyj+/rL4+7O4bN//efH3f4i/vyT+/or4+6u+8xOHfP/Xxd/fFH9/W/z9Nd+5dMj3f0P8/S3x93fE39/1nZ865Pu/L/7+nu9cOeT7fyD+/iHuiO//sfj7p+Lvn4m/fy7+/hFujO//ifgTm+Z7sVu+/xfi71/7zu845Pt/4zvXDvn+3/rOzxzy/f/tOx8c8v2/E3//3neOHfL9fxR//1n8/Vf8++F/Fm//wXfGDvn+P4m//4J/P/xP4u9/EX9/AHfnD39Q/P1h8ffHxN//ilv2hz8k/v6I+Pvj4u9P4Cb94U+Jv/9N/P1J3I8//Gnx92fE35/zndcO+eEviL+/KP7+vO/MHPLD/yH+/pL4+8u+88YhP/xV8ffXxd/fFH9/xXdih/zw18Tf3xB/f0v8/W3feeuQH/6u+Pv74u/v+E7ikB/+nvj7B+LvH+Kh+OEfi79/Kv7+mfj75+LvX4q/f4RH5Id/Iv7+T/H3f4m/fyH+/pX4+9e+841Dfvg3vjN3yA//Fs/UD/83HqYf/p14+/e+894hP/xH8fefxd9/8J07h/zwn8Tff8G/7/+l77yAhf9XvsOuHfIDgAvqkB/+rO9w+P+jvvPbMP3/FUa+yrvCBAWyjZ40SajBt+vdJ7Nb6U0/dWU4SUfYnyv9TMeIrN37FXEccr8CzbZzYbgIGv/umnOJTr1QnwUcy0pECdzKSb9OoNZReLtQsMpFvDi2kUglCqqXGTkKLH4Et0IWHpWEKeJxubz77MiypxUq4tfSewo5kY9cUWJa7fAdyi5OAumq4w4w9m/BNOp90CizHioz2BKDa6P3Jx45QaWuO60USWkQD+6GgOzwwR1iHgtPImbHvYXLKKFA7HECuuv+wkUMhdyhOvV1NmBQJLzQRNSDcF1sktLut0Gp3hW8anbhvvfuJfsgo8omlBhKc/c9emVzKRWWEH1oLMYpvvY0x/+9MAbBWP7evdUAWnTusAuXUvDkBqrafdldWUWmtGJOKL3n2CP6mXvkWxQxdtoPabgdalatdFq3MLbniF3XmtoD735jH1kPhXoBR8Fx7/gRegG4A49u0XuDVCQ+FvSL8iIr+KYF9mw+xvU1yhSlgFtvy6UtxFd54s3s1ThQ6gCEB1w+djMNZgzuJAeztLXh0i4q4qDQUDxwz/OytWpjPT8/OppPU6Z1vtZmrsSkwnMVSus94w13Et1QMN+Bf/AvdEPjOLrEJPmYrUDZ5WUbV2Z4vnF8AOFssp+iSZ4vtZKzWWjY54vjXS+jWfLJcumeBNceEa6YS4EK21i6ywKRb4N3tgsm7YEpCHg3XQYN8k4DBCz/Pli4JwhyYMurjaSl+tbReJ/rpHQrBjvqyNPBkqRKShDIR+6tjG00Qd8LRcdJOL4Lf4wFGp4G8V1svE6QvmJPAkA+RgkWHLAsMEYlMGWrVbo2U6k4JbvBUXdXy+TAynxXSOPc6947sF/jfigHSt55lh24XFgF0O+CvgXQT8pltWw7JwjQ7xRAB1je7Rug3TdAW7vBDa67lcpuFoCLrkko/m5oTNnBQURvLqH4O4TiffC8IMDiO4Ti/byBewSUMBK9wNdSEHQOX0Y0C0XfL5fu+yCnN//OA2D/bblcei/6OKZBRl8IWKLcTugKvxPLZSlrDyg9ca6ngnfTAgH4mJbLY2pzpca0UFJ+Tsvl80xBaN2FkWkBd9/a/v3czdBstwtVu5sHncPaJxhfQEhWYXyRM72Ql4Jlf9Hcb++vq+n/iMBrOe0KOHyFYuN6p2NpmTwgS7i0PB1lFEHmwtcTerTc3oxQNSFzbbq3vZ0Xk+KWkIdVJF8VNhIiDk5HWPSmvQJxRSqP7coPs4YSrUaj0FS4vd9qdH4k/LqkCViMv4xukFNheLTiOgLDNRak4F13HkhYk4IWLzP8rbmGUIuAgXsz0CiABoyHjv5y2Td+OnYWWLelPUBQKrJJ0+GSSg2bjJthiyeJMvvWQaEXg/1Gvb1pJb+KpoCc2DphJgaBYYXCcAdrqv28p5J8ByLbO0Mf4kNNoTNgylvogw5NYcOHdjKav4qe2UsijZh5xn2v8ugFnqMXQrkOnoMBXEOpu/CG9jwjvVO0OcHph7JDbnYam3qmHFqYCQszccFBNabHjAezRrtdGKbncL/ZPPAkeQX7/Wu1Z19dEJGao+BaB51tmHB+6+N2zW5/sBkyLkX6n+Cw7JImr6O59Fcm1mJuDMQJE67ygG6EB8n6lBrbpZpFFgqxsTIuN7MGvM8efst8bXXQH3oEXKwD+CuaQohNoOBJc/+g0EPAVI79Rfxc2z0ZU/tLmljzI5XpUBu8ACwpo8PB0Ldd7MAegz+3QLvDcnYNxFIWoPXBIWDz8GC7fzvcQNL1CF4DCzPe/tZxZMZgDtFg2AWDIpcTtMYhcUZsxVf+IttHcLlS7J6hrWYfggPpi7Ym+4jBZiQJBmE8lNEzOlU5Nv5yjoIsUm47/JJxbLLuvhA/t519aRcb5M4kGqKLfBscu+BFKsAgtRSkknOULhy7C3ThdQyB5sY0uO6CN/wxlUYJ8GvbOdQ9zysFd8tlqAuFXik4Wi7nmDCvzmjMxx+pC+V2l8uFLrfwSsG75bKvE/peKTgBWntMg/U45tcC0K15JQEhQAaRU7RrGrDeMZgXCBuq1JOkaCpVeL5VxNCd0Gt5r96PxDulKmFXJDCd8E4kRDrhxFJAtByajCnA/WanseUW0+7Dt1vyksODxkHWwOD+dHL65PSzndOB9DC2M2fJ1Y5brXinw53Tz54QFjwhvZ3yzpMNAAyEYF/QJGJj7TkiDYyzfg2KehABSgYNYBALA41kmo3WA44yiyOBCCvcvvEhdL3VROsqipX1kG06ZSxu07zFbanetW1s34E/OlBuFiTFbmA8b7ox0khINwAXYfBO34y7y2WlclwKjnq7fqnkHgWF0VH67pEHgUXekSMM0mIcOQk7IhmOXCyZVpc7PU0btdro9DQdtSHUwmk6uri4OD1Na03xWmu24PWCNvD1gjYu8JsRvjZqFyK3RsXfxdDZNHUnE3bOR7bEiatwsqj3t9/ct3r4ZBDt3Q0Hn++9Hy7x976xwqQlRGEewqPIkw+Yuhz8QrzW9jo7wyfbOwKhITZ0BoNCFqGy9dp+Y9P4rvoiitl9NlYU+N2HBQNDFGLiRQH6gQEnNx1IqPELRWHZUajAQA7E3/ImzrQ2iLWygG5qL+C9uo/eiAnGtdw4sB+Ho0NHf0KTDbGyBMBThjOmf04YjtEDTQjfh6nyoSqszZkCOWylbbQlJyH2elCNQeIwQOfvwZjQNLF4TBoaaeYudsnqI1BaB9v6tanVN8oAVMxYYbt6aSv2jujV/Dphg3gYpOWy5lbw3paZ9kVUQ2HO1u78ep5o2UTKlLWjrDU7g23mdaxcXrdPHtSGlhdbB2MvOuiZrsqk0Rn+E3ifpgm+T9NE2ugdFjsoFkZf4mIAMz7JoAa7OXExoOma9MmAjmK2jtfiNiu+WmaQWnE3x3kuYgSLsHKfwNm1NGCv3Ur+W4u5bRl2s1yprHs8fz2vtV+YZznIW8uotzblbGjI9pFXkJN1krdeoN7amGUatFTt8nMgWM4FrGZ7grvFfPStDG6xMvmF0cxx2xlYMQ/ZciyFCp/go+gBlXNDlQhPZlu3r4nVn3ciZ5SM7O28XEJnIYZC6ir8otWotWpZlNDt+cVYYbWCOOHpE693Ouk9Ke5bTGdZnNDYBzGLTV1Ks0EowiDdq2uIMgiHgZs+q/ec8o7jO45XgSTCAiasEdJnjZ5DIGvHAWRZaflz4tyfTp58tpPtuFNhFWe489mT04kjUJZ2p76N6i/GO4GwZ3GfT86j5M10RqMR+vXYTN+zWDq1h3I5B4QCxVsuSyV3gebVMTh/hW0CuK29Ir9we35tOajvdYano8+8YotgFqsQAnq61Rbo6uB8KjQo63VqNQCxjYP9w/1ap1P3MVyUM8GD4QRBulw6Me5jpwS3ksaxIEDFs716uRz/Vj0IauVy/BRs+tCIbGMgqbzq9poTnQ0jkm4S+5LYlzHlSwtjJyXlQ301WG4GawbTB3tET/g9U3vQ882d1Zde0hnG82LAlBTIJDgp2O7HQLr8mAdPTqvL04Hb8we/GJwOh58t3YHz20PP7flur3Ra9wa/OD0dLk9Pq95nvdO6dzp8QhbgK2OThTeLf0ZvtQzZ8j4oR8zWllcPGB0omtWDlzNxE4k3Tbqgg3LQ+1yo1V0uS3PzLArycjm2fDdy4ckf2J+bEGDsu9ryEgvLdtbMPV8uzUJxs+vEi+q3xIaUVyPwrix9Y6JrYdSZLeSLdhQzRWqAoukRsLulx87m4UF7w+47ju5YdhBzwT5YQLw1cMqyDm4ZyHRwL1mCcGvlQE4dGLu/Uk3u3tCgU6VSv1yGQv1BTYS5a3TWNqH0dbkvhuSmEHGvOqzsPhF2/mG5HFY/0NtY/VdfPA+/fv3q7avlEsygeo6yv5+de2G96lRS4HAUTsNXUfyBWuRWqQRuH2CIsQju3zjcgn0W16mZshauWS5n7qqu5V7fLRB0MfSeqepZLoXjEeCDb3QQWtwXuPXPE7Ari2Y5MB0HeEpUDAGIAL9py49VtPNiUlLFJdcEJMaQL+opBOTzVKxMO2LwFEzvutuaLqQ5VRdA2iIErtq30lN03cWMY8+9eo9Xp3zqeoYgAgtAsrenew9u/FDF9LDZ2dj/rd3cTHHm+7jDntaMVz0GDkbRuLt1cPDjmpaEoUbYs63HMj4thu//cQ1sowFJiDZFZnTh01rPrVTM1Ep3sKA+PfQ8H1CeQX0YaFIPQ7sXmrUfqPtIxtCea/ZxUXdvoun6XhVbk2T37T0QnuhbJSU30RSe3PlyGXpEAHCRJbwdtoq96IH8bGsv8ttWLAuJPSOm3soZwdD2P6rln2R8mZt2pZgUXdb/uJqLNhrULOh94cp4/8dVndtium5Yfpwb4+FFq1tYbAdRBpz1ANNBbizU5doE2m6iqRX+1QozL9OwQsvXznr0shjMxRkaxASDlMRD7eoR/PZubhd99a7D50K7+lzgGwiPhxwV1EMxqnx8ubSRGQbSI6SCGoUuaA9q9Vqxsf8NveHsjvaRyrA3b8abne7VQQ2tLaR6ExPR5V3Q7fSE6BV9atluQMBO5dHWgdLMbxEYlpElxg5Dh/TBdo9cS6sWNikeE3iuzQuuwS4DfIHUwRcIWx6DO8qn9SY4pDxG3LmN4WXFc+OghSFy48HhUN4qTwM+aA+Xy2Z7H41BeT5L1IBEIaiRvjOEYb18XC678aABIZsbQ3K0BL2nXs2XRkonAZgKo+6R6O5dEEMC/AR3vdS9IyfCUtk/IfFgHxLnLuSCLwnIkExKF2o6GApXljEYHcMPRsQQVbRkFS1VxYGsojX0CH59iF/DuIMTjxyX+/jWHiqe/6A97MFowUpm0Aar5vbQU97h4kFniOU7MNLO0CPAQAu4cu0eHBEMoQGqHoUOBFAQGAKlJmJ+ZHSEkW+5Ody/0kUWRk35YP/gJP5BQTvgmMXidfTCvumIT1Dqb5RetCRVKniUgtjb4D++0EF8u1Wrdba7OUNto1/Tz1m5jNj9OY1BqF2o4Y2fzOh3KZtR69F10oSNHa8KOJ2274xRpLmonrHJiE0urUdVfqMGOAiSIVDaNgaw0kUoOvKCZWV7m9RCFalLgMRes3mwaQ35Rzr7fHb5EFiOXe4yAWxRgWyb4eg2yztoTnn1zcQcC12Lcx5rjNbnBKL/mjUyHlW0qSZZBOhOJXTn6jv86lpecX1Pheq5HiyGwXzAK4thFz6yfPeKorxSF4V51zsWhRdasHOMsZjda+GGGu/tY3H9gJvJja6iSbjVb+E0mtFJzvekgq8N6ZQQNNNrZK8uYoyBWlduOe+xF41OcfBOdLMeGsbNfOs1MqN8NlJe/Aq8Fi3ApF69MPSXmg2pdB1wmDVgiAWhe02YByb0WnBkuRDGe8/a+5uuv+JuYhnjY2mrT80agVCWOWea4Diz2xfB4vsCu3AhLeBkgY7EgjTjVROMoIvYFAomrQf5oOOLctn8rus9Qioo/C2X4XKpYrS4joUwO14+lCn6st90nuPowsSfZBduPpAFDLKI0TzgcAFmmDTGfaNQPwCTx42+jmMqSKjPR7ZsN0OOSIR2i1jMUxjtQX3/oPNQU1ksPduUxtTRSni7JfJBvV2vr8d9RbOILX34dbFqjVPHCp9GfdwiqgI8YW/tKxpAF/NvD6THNJQU2Hq/Qh3pAOK8d4tHCHIAc7cYMVtfWA4YbjgJ3T5ZuCkoDAmcnGBHraMNUrPqhM8Lm7ris+RVmhh2Ug19oxV75gkD7sI46q09N9xLUaUjDUIyf1bDHV+psGcB+ESTnxuT/tpQ2uKYuuOsaXDW4dNBfb8w7K6g0gtHkkTnH4p5SIIKz7KROrVOcyMbFut6iCnEt1DZ0hxDkGrNVu1ga0tZajp7mjRF3ei064dbq3nUoWzXOvsHGyc2rz130GxtkARji1sF9xBq3VanT03cQLtMKVwulYOJp6D5rTWSLZaOtXiViqAFManLgvWlnrvGJV0RKR9YFaAaQGcLwEMaOhet1dx1LOtQXt5x7ELcZYGB46kdUxr9kxUBxnrtcN2b2X6jdrC/YQ1QUZbno3SJ+MFuLMJaQYPgKLqoQSDe0fX44BdVEL5UhCTG3eudjiogzayejipez1MyGSmUaWSEMt5pwzsdLt1egF8sTwenQ888Lnc9iM8Hgp5T9/TU6z25RA9y7rqW4UAjfPstEFCgV7U+H1H0rKZjWzkZyWWYsRyUPlNlybQX6nJzCBHoeD5bLmP0iQ/uCrMhBVANrgibRFlV0RIkHIVNG1zNL5eW+Gkn/iR38aiMakHx9RiNW8mThL9BB8ayb4omREJrjVAxNJKVDd20cuSJchxER0BmaYu+40JRXDJjNzd09FxHaS/CakFp3ohJlSM95mVdLxI8BlabTk5/z0H1VKfCK87QIWngDD5Jo2/oABaJdTbPB6LOM1X30CHzwBn8QtW+CBzY31bhEW1h4dHFxdC7b6yAbeQM7C6eXVwMMeHc9HnokGusyqmkFWfpVMKK4zkVp+eQY/G5rWXYc8BgrXJdcWXroMWIH6NlQX8o/Q0uIQim51SgqON95mDEYlkO2uk5JIXyhOW+MBE8w4rj9gLRn6VT2a0cbfQumArNwnUopDRZ33nLJeqsNhqNxsFDKwjDOhRpjcOzC1zJaO/u9HQxutg7PV1ctOCnjY8XuGaf770/PV2cwyeLEeSOMHdEcdFOTxfR+enp4qwOaYfwGfxgCwvYBYtmBI/7tdPTxQGs+KIFj4f4eCa2iexPrXWxc3qawOdnp6fwbVTDxQGF0Mnp6QwLNdrir3N6mtZbbShRb9eorEj81cVfQ/w1xd+++DsQfy3xdyj+RJ21jviLRAuicwfw16zVargvB05ljnu0D2MfVXCHDYQah9hVuNeGDjlSO3pe6VdYJYXFFsruD+7td4/b2yfYmqj2Tm7BY9znR7jdvpVpJ1bae0wTLi3dnj9ajsfLm+WMLuNlsvxIQQJLKM0W+mJ5fLz8avn6+fLN8u3y3XMsxGShT4MCy00QAKqMaOGhHNMgohVGC86lBTPAv1PugIqvxBE9p+qMXhcVHVMypep0Dk7gEMNUVpzKe3lUBwtyQpxdJ/cl+RZLUWoVq9wVFRSV3olKyYn8DAL0jj5ze379zdtl4+UXy+brL5ZurzSoN5pD7/R09PanHkbhPUO15HDoOeaTOFk2JqNlczbKfJJc6U8+33uPn/TJObU69BC0AT3muADWTKkCNkhdFYZIOmwo7w4HGLhhHgwGTjS7dUi90R6SgQMcRIfU1ePP6K1DGvCGjsIc0tbP6KjdIfUWpFyM2dSBaCTwMhVe2RzStF9l+dY+pM1oNLt0SOOgNRwWDnU6kp6yjEJYxk2rhfdozSUnrDoVcEnb5WVw8CxUVUgKykcSJULcB+IbzBJXUASNgwdUQA5atfqhCi8NLK2i/kpvb9rFEmDBWfxfLxbGGXBVJFBp51+1YrUTeDu/itgkDA2fuhqGEbYVhyGolNjvHoFsVIwNwyA2z5iOPhRimaFehHb4fqvQkALdqBWOMzLOSVXHjO42Jxw0H1RgO+1eiyPjEgRJWbef2T8umIy1g0L/VvuHHck2QYfBXkZOmO0Pryb8mM/pDPwbg7Cs4oJTYu75aPeV5WBgxUUNou9kqTDTrm2IvRJNWRKN2R3NECCA92b7gFomjVahHmen0+lsDkiRM+rdlya9m4P+INstmiTFLoYNExRsRA73Dzevvqkx75I2RHnl9tWUf/buMBoMGQd+WL2dQsLVWlpwv7KMmzAbrM4bjfpGvR1pPVpvKGPtTvMQ9beUnID0t/KjR/SMp5NsXB/tHREcnQY1cDZTJ+/hh1LwVVCs3vVJLvssndiP/AMFysfVTIZrkgbHahqvg+NAeUoMOAT/l0yllDBvZXO50vHoBdZmKU7tnawFoz1ZLtkzYNSyp7Xl8n25HO/dPQuOTFUJu6Gz54spm9GR5IqBvSKywnLNKHiXzCI2ZpPL5yOIKet13wXAwmQ3lKeJa9dHLA77TcQmbHL5TcQs3hzfc+M97Xd45z0E8CFHkHgHWi8raNTqarbde+0xSM4YpeXydc+a49jzXWtGd73V2k7IjJmwIDdkmIVrSzx0jOwZchLEUgPVBLX1cidzZ0yj0Xpv74KYbJ4w8L2QG8Huyj3BfrxXLaDGgPr8nbe9OquyE81FyoXi3fy5R3ZXGgiD++PlskZSVKEFTxIlVpWjBNu594FzEy1ghR1Uoe0t3LnLqjINP+Wef4TorlpLLNmDilSKT6lH9PJUz+G2HdvwGd5h1ZQix7tyOT8jcI6vg5PgOFCbY2VVeTFO4yvbQUWKMbTX5qa362e2XAhA39SD7l7ahSHw0O2ihFbi0pkHTwaCqAOaDkk6IP2Q8ltcXJymtTrg9GmtfngxRIaSbd73SWT/JlRzRM/SmeXgwBV2yYhJGZ5SmnOUL2xF2xtZy/S7bOy3QGjWoFYxLwWIktRahRqO+j5+Mjg9/cVu9bNKz/UGp8P71XKIDDZFvMvYVYWjovF5NLUCYOmxpWJsWom5Z3PYnNPT3bKMKXjYLFSvQRfknguLeVhvejllBkwsDCrXaGpsvK3074Qj2cVWcfYF01wlWzyybieKTOa+p/ivXSEnVer8NX9uqUJcY8zP62Dh9isQOMkjSgbclCG30Z1LIV1Rb9Q2OnRJqKXS5K730esheiiNew+b+4U6VfVWp6WE2u1DvNPzyq6A4ncz0Q8/n2WDtTcAL83GoDGKBnBr+htjQBYE3Mt5KDEIb00L5LjX5SCgBuuAAOSZhquXoR1e3NykyWY9NXUH5p1Uo5zB5smLVpknnHFgnFL9DQSMkugbagllRVCpt0E2laLbh9WG+JJ9PvlIZ0neA3eB6jW45OZaBC2dP11jBB4GEiXCtR9iuT+L0KbuAgwPMBqVUKAAZ0jRFK6GhQfClPNoSo4wFQlWkw6vYKHtOhdsQUcmB18hGJrrMLUMJlcnQegwVxKuOhdfwaFVj/kh8HRMq6CPIxp9H1hNQrJoEa42Ux2ki9oYheqqs3TyYtLnk4QuEldHXYkw07+PZrd+XI1mtyTCQEvwgg8E96cv6UyCPYA3+CdSHOxrwTBhMW4ZP67KJ8JinGhMwSfCYsV/x0T1QlgsfT1gsnwmTJrfQKJ8JKDo5cdC3wsH6cdysAl/MUnoJYX29DNJONjdYxI8rASbCYd7Lp7EWKf4IkY6Es9inPisxniLb2p8Z/JNjC2Ub3pQc5mghvMG37HnC3wU/f4an02P38p36C55TYM31E2hv1/R5IqPPPIdDcAYNBGzXaDquTkODDA0tEt/8P4TuxYM4p7n506sLU02y/FpbaJX7di1OwEYomWNp7STymVjiaN9Ebk84M8aPb7X8OskLZfTpwHvMV/BZAboIkRaITdswSZb+5aRsAqhKc1FvDI2RlJhz8jSLqj7BiYtq14bUhcMuD1UyZOSVssuZBAPh4JTlKmcXFA3JQWiO5iubohrk6llUBsOA65c6KxlKf2LSXL1+ezycZPAn9Z6df9raKuiDR/ByQKMEUPIivO1tTLLttL2nJWpjTCPpFifBYeKrscCrb3cxeAyDwKlrVbdTNTcvnCDj6TRsRpfqoHGaxgH+A/AOy7X2E6mVFaLTWsWrq2U5awqX5/YmSx5NL6w8cgVohCbD6i7oK5BVZhHxDp63grbkzc2WHeZqfl8disnJtw4MXnUZ8O05E2Ajfy2ZyNQfix7FFoB7zKr+SXcaZa55W657L5fLktpNf7AppgLJ05zkKo3CBmFQSzEw1kEaH8WJ9EsWWNNLHpjivPhZ1BL8fUmnMmIW9eCeyjthSy+FBbiS4sgBNvOfhDqoOOGl7UAqkEsbp8sPMLR7tUkqW9AwxM4zyIdGQMQ/sflmRCCmRnVW9vadicQNLqOToH13KqD4vUWMEdqZlXycokXkXhlQ8/nOVz26NaEyM4Fvnd58BZAjfZwg/bWZB5MqWuUqkE1syuE7ItyWQYpVRpb4AgOwvf1h12B1l0vlyF1r73l8kz8zcWfC2WgYgZmJte+rB9cHZMFVqDmab5aC++GsEYpjypIEo1ZFL/lr2k0xnmIoftVYC9NB2y4XDIyDxZr2l7aG1EfYlxElCzwaRAOfUBhz6l7TsHN9tyzjLJsiJdK/8oWzbBV23kbcl9i5p7zup+K55/0aj4z9vEp2MdzF/7MVtRY/ipLkWQYvmReOJ2LgJN+8B0FL3+yjX5vEfSB1/4OnXYA7pwA4jaLbgfzYW8RZKkdTgwq4/m6vFBoLf4gv/bw/eyhKP73K5QvILakW4kpNCFigBb1ShwMT1z8r2mhhc5FBs8DOJbxPAeeZ0ETSu/JDNRD55RokhpdJKCgHs0uNZM86PcMXJ0TAxFA3yGGzefbaXZZmW/5kRcXrs3IV76Ql8sjhCm9kQSwfOXWSBioGxrciEJd4LLY80gphN9wuXTDYAEtoKFUGHwL34dAxa9LG7gN9ay7zyNh9VwcmiB/kOdwD2YFBTzzxkgIbnW1n2KxaWVsFfRWTANG7mgWF8yv4rbl0+jdLh0osIH7HuSL5TuqMUd9XHaph3jjSmyZN9Tdpd6GGuOcMbhBnm0ofEcz3jXZhXtHwaS1NjT+S7t84yyi7p/VUw6uIkTn7nIzsavwVUBmocwu1dXawiVIOGZn1hHYpRlaVX3lxp4ELt4KKrMXb5dumh9ckBmNxm/55wBvcMYHuVO1S4GdgpMoZ3uXgjEpsIiQdQSMI24DrOCeAt3rSJLQIfCKEmmdJuXTdJLMGI19B2g5Not1youJTnsxcQhdJHQy8h1BiZqUz8djk/j5eGylf8OSq0weJKj8bKbIuWCzOPGdKxqNHHLOJxd8dhP7jnp6yx0izR19B0zuExiZ8rHpO5c0cUgY+o419Q750nfiJD37MhrH1CFvxdvbWUodEkHf6UcKgv5oPP46imPfgbvruUqbR7eiAyhjdEg0ufWdmN9QfDQfvBFJcOx9J0Zo55Aojvm578Q0kc9I5It3iAA/pgAYfGdCL6OEijQeU9+5GPO5XJ5zPkkiNoEBT87H6YjGDhkxUXE6wapGzNStkmZ8ehzBXMKTrEolfnPFxtTKwXeHoCt1nNjn8OQQNqKThJ1HMEffOQRF7Ee3vvOB3h7dwjtLoFdM6D4wPASvzq59Rzw65DpdJGJ6HMJvWIJbBR4cMvl8dusLRYwpdhzXDh6ff+c7cpF1vA/MeDXDUq9m4jX2nQg+YecfsF54gNepnD+HTMfp+QeobCo2iWpkxqfFjcz41G5kxqeykfj25oYmM3b+Bbu4oDM6Oae+s+CzwhyYogWfHd0W5optv+AzseOT6AMVCwVPcqFUolwonSMXKp3IfTajcQKvEwoVSNazQ9JYtiKxsNgh8ys6o9mDhEnPv7MO0h2b4vKJf3qeOCvCzWUR3Nf9gTyweM6zp96B1m+miUMczfeBZ8ogUxpNEqVWk1GpgfAuUTpOYlGRevuC0qlIuRhzPhP/c/mnPr2Y8RsJt/S+cxRN6BBHGiDjE4wCH2aXVFQs0l5dwCMwZhziCOaEQ+S+tcGBddLl3DsymCQ8Qbxl+LeuBodoWODA5IzFHCQzdiP/nk9G8ulNEmHf0wn7LqUvIHkOik8OgQWB7g5JAxZgBFmIP8H/7FYvhH7QkNYC1utgGHe2c0Yv+Aw6JRSh8E/MjVaHcs6v0skH+AckEVZFVoDv6hndPTkks8ccDM9/pNf7ZWbhX+JaC4Ghtey5HWBescYRHeO+GukThS8f2QgfZnwq//TeyoE5TFDPdDKK5QAAxKm7wLlgYzG/F2JWtPxJPot5gSc4p9ajXQjeZcFxBM7OzZMcmDyyX9ApdkFf1rkrGl5fTNS/lfhqPtEPKhlhnHM54+kUJ/4SX3HfXUWx+MXKzH0i3A7iKWCTBPazQNHUicJ6hKxcP4jh6LvCQBEH9Pocom4JZ6xmBesfJ/iD5xFruImmYNgsntBBr3jOwWYQnmN1NzSayIfZJbVOs1zGGyaz03HCpuNbcaIdIu8d/DtSZ1kAR2eKB3QajcRpnEYjdRin0SymLybyUVx0Of0+8SrnS1xC+IeNKNwExz5Nx2P597l5gipm0WTEb8TDJVX/qgGpO+jMKAJleLjhH7EYndIokdDoy5mogsYpznIcAYbxRgA/gbI4MZerCQ9Ub2nr7dWFfrf3cy7FKnUyYd+JKqdjZiCe7A1yt9QRi9OzZBbhEOL0Bj+Cu03+qeHmrztMMM+waUBOliQi4WqWwh+7obGEpP2raJZ5NkAWX9XiJrMUYBYVQJdPJPA9upUPstcSpXLSyR1TkA/c5fEUUoEWcohABgBQO8S6P61nPPFD0vQHOZxXvmqQGt1MM+DtKAvtZDmAL3KKNWyy3mGhcq92EYnhaBAgkyUY0G+v5W60gcLRbS5BdkhABXmgzEsGTKiiAlSokSiIKGCgBS5kP/CAy8JoMS1OVjRS66we9enNrLI8bke35llWhofsTUKn9rM5dKP0nOoHk4y0Bexg3BTI7MyeIOt86XnHtGQWTWK4G9WGUztNT6LQAhbbCTM1nuiovTck+/4A7qex6EPmS1FCYm3IKAnuG/6gTmq48xqkBprO+/6gScQzFLUY6MF99rb060Tfln6d4O3o14m4HfFhMpJ/sFjyEUdsP1t5P6O38gmxXvNoldGzln/PlZF1if1jnuCk2W/T5ApepSRYPwliGF9fTNS/lfhqPtEPKvkG25FXlngSV5ZfJ/oa8OtE7Bm/QazNg28ACTAfALhfJwCWwb9QBuTJBP0MoA3+1fbxG/ayyWVWnQJBpt0qLj6Ut5jkwX0OGZRbxAZMkNQwiUUFM8VsgFWQmCmL4Ae2I1aWhS66UB7GmAwDTKw0DVJ0vTZi4A9MUuZDBS30VxYwWUtDkGJSFWDJp2R6oQGLbjILanSyPMtwNlWKDVN0hWuQRedImKJr1JDFpGgoYTWDsEYXkRBHv0u4o97NThIsXbWVgAS/x8ver61yeyaf/qKw9IuC8hZdWJAq6UMrx9w7KrFhJwKYMOlqf1jfZ7aMSZYIn0qp6xSxgiZZogVWiiDdTF04f8iND+5RSODfAyz3SzXcPer/88zj0a31gn1Trwk8SWiCT0iN+qXaigiRgi/XB3LNStkvqj61CvZzpuyLotLZd/WmVsh+lnXZi5d/l0VwFfSDnWh1wBxlGGxMYdIpToc6SKUaQdQNH3Djmyf1ISyHxXwNcjKq+CE38egZwGK7kjS4X2mxVSi85coQUeCnsBurGF5zr5cO5kPBqQ49H16CQahlfunK9bBzN9HUPmSa1s8uYAaLtJfPYgHklzGHiAoK3VccGotO9zPcGntBLaI9v5A5Il7jCJKkziIHNoGdwQgsGnsdFchT3eJeERSwjdravFMLx/UN3WsBDk3oZuGGTfbmcFQ/S+PqXatI1MzWtdFZffkICtS+ejQ1mr17LNrUkJZyxOamUWRk7qbJUJWKYlRcREO1Gf61pph8ybKyKSrf8K+ylJVvs7Mk/EPKCA6b1o2wtcNKNQIsPfUveluqGVUuOO+oN1WqSXUfeLD1dEo1VbvCcEYjA5fy8A1YW+ofsMhSjQj+FTwZpEW8AWsJnuh38HuZiF9MkqgIPI4T8Ss6m2VfZIGaZEzAs+JBwLO6ZMyjngdrqWHcyCyQT5dUP+jiisyG5zs2lX8CkmVeJAxG/wOdpq2EbHm8AsVL8ErearQ8NZ3g1+4QnJAJ3UvwdIMhEIXyJTi0OAQVf4Vza0NMpXypfYMr3UuwYTrYb3m26iVz6+g6yGhdgqevdrvlmY3B3GazfXDgCYVL5rbbHfBZLfYIRGasdw48S+dSq5tLhUt0uHHYQEc6rWbrsHAKMGJSs+6tyGGnU+ioBnW4pbUezGSxeZutOWwZuYVEpKAn9cP9Ao9j2HxRs9AdzxWkKIRlO2jVPK+bZuXGUn08G/WzXT9obfSktiGEVdYkLhc6K83EIdR6TCHoCaNTkv1CC91Gbb/RUl5Qmo3WprAxa1G70IYCgx8Krb12rVU4R4127fDwEQ28mDzcRHN/s6mHEJYlGZ8I6MB2v9gI5vCgqXZMrbnfKooeCGpGeXyjH8yLgwpeB6m7romglQ9Wruf10iItyRBjLS10CBr4pxRi0JT6a4kra/au4czs79tBdYSBgjzlVlEOprftQq95de0eTEQoK/Y+jhUesw/UjQuXyEQJU27I281Wp8gGRYZXMBP/QIP54CFyvmQrB812oXMfGWfwoVZk5BtTe6kmQt+X6uJfNLYeKScIZAearYPWdk+enWZD2oa2O50mmsCv+/RE553rPj37AarpG5+e815aFQF9lH7+deD2e/0qizPJELYju1VajXrhekiIjXbH7ZYMPSCjiixMEIm+WbprdOR70BJhHEX8F3C56zYP661D8H/yY2I7xc9vpsmt5UDH6JsIO9c+LgUG24TwC+vef4p0SqU/+uXyGj87wl8MfKnq1spvJsYstJYN2yO8CWbC9hh3UyXh6Aq+OrbiX6BRtqw74xw2Bq2aXXW07RAharjouL5WdFpbtcZhbcNmRlQo57YgFlbueLk/5pjg6tfrzWZ9UyNoSixWScTlyIT74MJHpkjLR/rPzuIXr756vjinU6hY5a2tqgr8XxS6C4Mol8uluTiJAMi2D3FbeIUvLcD8KUNT3+VH9xM6obMo4bNNBT6PbyfnmzK/nvHFLSZitAjwd7rp2ouPcYtZqtV544h4PRROkI+rgwE8DpuFXvs79UM1gYfNmoQQ6MLYU06Hgf03xcC5obvw/DTn+be+ObY1i1+m47Hp/Y4VLqXTrjWaPx64i+hZ2yZmDb6rgFsavOOW2dx3czkVho8xV6RrXKbyLKTC84nDeKiZTdfvujdWDCjebNa3z50dWlgc/kWB3zMTZdgkXQcLE0j6OOjnkaSj4FqAt3VX23Z0lXHEJmYG4dSJgFNrcW9LQVpwHBWoljuGZ4PVsOBYxQbJeIZFx3brAVw2hG6xXaqwclkOi3lBcAQns71fGOii3qo12w8emTc02Xhk2ge1+sH25ZMX8/yBU5D3mF10c5bEMZhnD4OKM6cPAzrm+/HnUcXyMT1RIZQ2nkcVos6gW4BiFAZcrNUfnPC3tyq+38Z5R/q2oH6BGj00QkknryGr66GqzYCAfN7kpMUy1S5sUFLhmfYKLWuEd23hVhsj7xetYq3VUYiqwAnnGucrah0o/lysOLCr9vxQLVVjv769ncNGp9Z8TDsvJgUtQYgf1VirXetsdNk8jmILRhfYrMuKIQQ936tbkV8xrkbRdhCeWvNRNrR1WZFPFitOWUE2/xSXLdAN2eQo2GzWQdKA9zK68Ayi9dSGJAzU5zKgB1h2odvaVNs5Cm+4qWhtHsTZetScZesJhO9r4K4vlyGZrzYUg/g8MqmKLq6Xy9QjqtAqkxWkFutE5kBAr2araGXaB40D6UerUet0ivw2pfJhzTVTq7a/kb0gVISV79Ff0+XOg9awOqCroqtFGPearykOvIdgLURQz516QRaBuBwyv7Epn0DEFFmoubUQgdgmynaslNkPXIQHaHYONh7CCYeAXcBNQxdJRSCvWRy2ynBT0qryro2+1Dq1+mFhoFJgNIk9IMAoEjWdTlPGcpRh2/sYkuqwUxck7QG4fRc0bbPdrrcETdtuNuGW3Q2OsxtJHbb7VXeNXt1hylVAvStCtrsFNkI8WGAQMXK8XLrH2lD0WV34yiUQQ/YII/lBmBYIgBa6jBySa8/YZe3qz7q7YK4xdxnhg92h5cs1s8l3AaQdtJuFnMdGU3mFgQtdOqCD0JLSg/rGqPWKH7YOo4FY1zAamcEb3X+R0Ez8PAjzs21RtI2D1kaHbl4uhMB+rd0quuz2a5rtBTeH2iPCK8oC4cjBQVvsEYFoFYdmAD2PbGDmPo7cX5DjNfu6p03t+cSNCUT53PcII8dg4wqn56BVzNuubyD5Y5pnZqu7Tdz3kqm939gvdLpX32/st9fcwXRqnXprjfWDcVOLO8FvcjOwEDMwt+Ju92WJctk1TjeutbMXMfzm/hbH7emZsEBUQ0XXLchQ2/YN2pzob8CijNQP9wsjwEpvbWFQf1IrdvH8JZuwJBN3sac8+QCjfbmMA+lWun7YOWx1mvXmfrvVaNYPDmmj0/jMjZ/Went1v+75sVikml8L5JNg0RcHiDvc3zD3Wq6SCeyFgbp+SxthcjApZz2+x3yO7bRrEBN1k8ej4nbQtWDWNWDO4yDAY5jCImxpv95o2ywYQbSIkLQQXXawVxnWFoPaXifau4DwoIz0gye/qJ0NanXxeg2vfFDbOxTvx4HSDC7ur6H94bpeI/yNk3Cwl7S8x72MXiIuBElyRgvZiujV8tVFTz+5nh93wWcV93oQNMLnK8tJeWmtZbXwFfgqVfxiFvSVRyh9epbLa5XWO3Zjad4Ncrlew297vg6K23sZvfQrsfDefdjYfjHKYDmZuNF6TZDZC17sxc0oID85fmCPCKPudTT92vMRJAzioR+6fcEkxSPfabcLA/mIS1mEw23s1ze1l+UfWDeEChqOvS0CqYcHB60Nta5FmFIw1XGUg3/0z1kYFPGgdui5TsJP0DdrFK9FKWu1WoUBIeqHzabyNrrflLSXmGwRfhq8eRc7ghXOeTPXQCxCAGshoJvxitnDiAULFbHAV+58ufLmi9GGH+2jtrOP4bByF4bkKF3jBds4wO3zkFjAEGwYNkeeXsH3Ly3EX97L7brn2zBzuI+1CM1yeOvo8w5igZUVRx9RFdG86WcwN88kn2nzkgKRadErIgH4Co1C8Wu9daB88R3UGxsE11pfIIMKhW4M60U4/qKAdL952LJbcdKYyqAajpZH7R902vJ0tQ8O2odel1ev2OXVGNQXAv1ErNTP04Sb7mSSLVwYvdxfQwzG5fJ+Bd7zq3F6FtMEHEKMWZwcR5PLNLqksYsIdXU6oxdsQd4FR8rbwwnEKhMHDtx57AZzIYxZB6OC2gotCuuXotAvdy74bAehMtm55MnOL38r/qUDnhMWwf2MjulH2Cd+jYxlbzBAN8EPwCEcYY8qhih4t1I5efqu610HR4OTIUmBYlaDdK8B23H7ZkbdawKT5VVVlcE16Vd1W88W5hldBPS9TDbLZTPCgr7ndRe6PiASqjE955PREY2TYGGRAajKdsnihM5UD208NpsjFjWtFqZDVZnVzMYXthbZkG25DLs3Wb2/nUyyvbnCIAYreNcNwRcbhEzlgg5CmVropdkaaeyCcxVyrybnZXRDfbbyVuT5DUsSOrOOcDQavaULy+wcXaApnIowkorANxiGBzekE9jm8EE6SHUkvyFxMVDqFRuPZnQyMI+mCPAEoQ0Hg61CH3pMYBKVIPatL1A78R4K+OIDuQPjjQP5Gb2FK8GMxaSJ6cSR8CmdvOQjdFSFCXIK3Fi+n495TLHEpobepGfjtZ2UTV8LV4QTSNKADZg1X+AofMZ5As3poYOYvCdHToWhtkOS6BLX0Ymn0USr4DEa+/fn4yiOMROCuBFVjR+u/LCb6moD81gVKmfuvHCEaobM2CAlF6BKjEcOjqMoE6YuiuOvEbZVkAc34JnB/rhBMXtQAIDS3C5Bp+zyqWhAekUzDr+RPDJjEWHVN37++XgMNcQBcHMKCl2wCXon35Sf8J++/erYRrd+mtyMXwrWkA55IzmOztX4Ot5zuusXj01vglftFJbygl2mM3CUA1iHy+AO8uQV8+PuEBAv564QkFFloXzsmWpOJh8mfA78ZpHp44c7LN6Z8ERDNjpSVa11iX/ytcYtCdg1OlOdeyQzHWFIxTL4cjmItT/96xVgbqm56l1uQKYfE3Y54TP6Yjyml9EY1CRX2er7OM1kUaWzGZ+9jlhMR3IQmTR5EVk3q3XbmTvW3GUSzi2qsvfrAMJyHyRH5sZyK8uTGIjgpfrT4N4+QMTs+mCQKTdcFXAtO41mRtaHaJ/UyYDIguZQcTo7p8/F6UarGDa57Efj8Vl0/mGbHK7Hg2e8KsEC3C/gRdMgl+AJWXjuwII2yhv78QpXdQeZJG+AQry3PtUTIyuPA0QdVwlH6yk3G8ZOFareRFPXjYNncVUX9LzVjfKit/EbyF1J86/1oqpEVXLPXOk/JVbwmHseGQy9FXjjiM5NJWLGmeVfB+vTESdTvSpwoYBqscsxgmQIDo8UZEQXiMKALucb+qGFI0AgmAl2swO3qgSKYg1d/w3Vj/wdaAJU7z+1gXyNsoaVmkKDH5hCVtbK8Ds3rL5VYKVU/t14Q2HkHLpi06PrE8EXiK/YRbJpS8rMVToRT/mNXVUZmDqjF7NIuIxb4fpv6gpm5j/B6VpFIs4rZso6Vpc02VQVeAi6pIk4LrHatDzIFwKwreMVcnPA4OMdgS1smgORiwWV1pwuCdT+xtLo3WdDrRAzdOXoGLXmThIi+HLZNTvTcisqcqUtJJ8NLfYr5mxqLf+dC865DOlr2gJuS0eG5hMg7mf0FucKnI5kgZzevR/orYL9eI0EfCWD4Mj1iDEMjl1TBqR8oLcQ5R7rUQkqjI5n1YuFRAsm0RSMbSfWdmMQ069efJmArpQizmt1ySpFRSjJYgGFVym7ErxUZLEc1GsHgkPXbrVr+15XzBWgjvE02n4bfAUGTqsMcPiCJsLwMxgMRYaEKsGxehLJ9qiC48wrAb2z6oR/IcyQlkv8II2pTHDlTIZRkszYWZooyAVmtLrdtVzcGrLIKo0z/PjqRI23XLZe3HvwlujjcYZdNubRqFwW/9k8/LO7mNm9CoMDhjKYfx9XQXdN9suzsuXVTo6rgptYUESypLEOeCoociaUjaGM1DsuKBQJNz/HVZyXggJSOYwcy5jcBUVuqOrLV3RDX2b0AvJf04uCzDED7zTH1WM2+aCyxeqNcB+5aXaqSKnuqawwP0l25jw/PXbmYm1e7NyMJnxueuxy/fzEQKbYB3qAa/ddqPEoKXnMHSfkTsjNNDHVPFCJ9Nq8XpeoSXY4y+nVLF62XLJe4RHWV6Jwx+cXF8qH/10lXE5IUXQxGyQYduuYJjsceTLwBMGn2dPi1pSLwUpQ9zTwKywqfA+ScHNuHe9SFMPd6/BmXvdsRqMPK+Pp9pKqFe4DaNx0N4sZXzOj4T17yODRdsZvXmtcIbOwMZ2xaMxiOquOqH4BvCHhD3+S+QBubbVNCoLAZPdRuexmE4J71V+78yvpYLyKCly5sXsGnY71vQT+xmvDqiXWcL1KjMzdZObWvW62WXCVuD6lK09dnbogDs8M3QwQFvEau+atVtcW/0DfZ4F+srAGnQY6Ju1WazO1JnRDgKJTN6WYE0Q5dgSVFe+E9w/TOi4LnhliQzLxCIPJFRHiC0gNGJ7VXjGi/1DFhUSGIIg0paA+93IofuFo9MK7jKTCAG9D6wSjGq5w/6zTdoqe4xmqESsoLL5GYFpIkzVLK4KYTeGa1mvNujJiq9daUjB1WEdzFJRr1hpNKZiqNxr7Uq7ZajSbEmk6PGxIhZ9WvbZ/APIJQKWaoJ74Dr7aP9iveeREbxtyh+22Wy2PfItmMJ3DpsUFMHTEltCM3cI41p61TebKHGWjCBtKLVQphTIUF+tnFaYzeRgdPnNpWlJxcR7FesmBeaLBNdXzzCY/sqRr8Sq1DnLmyg2OiJ1nkIzgXSYjg50Ex5k82a1A/pOTLXmWcacCkCmx8BM/JBmUxJ+TDBbiL0gW8fD7xMYv/GuS6ap/TDLj9Y+IhSr5u8SM2H9HVC8NDeSfEOsg+HfERrP9b0GlA3bqlqNhSRUFuLPa1+AuzVNTgGfE6RSgl5A0EpblIQQC9UM4PqPjKKuthyU1+h6jOqmjijneKra/UjSJVT7OlCfyOrya0YsH2oAisn4svaluLAf1gqoAHPBPmUGzZr/WBGIXMAl8qi6Xrn4OtIRADBzSHhg4FJEDn0pljMKBYzk58OZ+rXlQOHCgUSUpKmhKXr3cDVLCs1Sfgn2EV3/SCsKq2beEV3kShFVr8xJe/ellEGoikldf3QRhjgLg1Q9vgzCH+fPq2SgI8xg/r76KsaghLnh1/lx1w6S9udIdMYlfz4IwC1UIr8YvgtCCQYRXv4RBWCeG8CpoOquDqslv5h4e1PcPPTC2bDYfwD7knTXPIyJ2vy1M5NN3lqAMV9MZu2EJ+2i4vCqngIt1zicJnSRbmVjis238KxkobmPVUm9xU36QYcRxTY2Bk5213sjPpJ0nGjmYCMaCzOiBBjOK74t516qK/25Z1wJRyIzlIW50DlH08txdJOBI2tVxLHnPZUGNpPnF8XyXBRC5w6ACooi173qytVmcCETSNwmephd5wLpc0IuSPOQWecgyyw1x7R7RpOpm7KYkj8NCAc/z18sRLvOMJ6B1/rie5y1IM8nUnaLf+ELutt7DG5jbKv+TeNvqo09kbYNQ4LmiODN7V2j1pBhXdUbP01nMPlISBoYFURWuT+PeYOjrl+INj3ET5jBhablsNlnVbr5czr67MbmXlfoh0V3wU2DqyQohoA8OBXXBSSjFJEVHZb1qVSFIOcVMHN1aTJDs0ZmMlOzCCOz0V5rFsOEbBPU0rloyEg9B59Hti9GW79jIQFrRoDhFG4UtemsVy1qUc/v8Z1Z1K5qVMMAMqqr1jQQwz7mIJkkU3+6No8noCX7l5Nn/oqqVhJbx9kozA1CfqAQv36Ao4BRMgGgqVxycHz+qAwjz11u7ithkrTEoC5Mc40UASduvCntS8i1ID9ifNEkK6q9VJjrhbL77bMHdRgmUKvwoAVRB4SL5k0YXap5kB4H62qZCddm7aHM91gWyV/dWKxufEnNthect3tqrDDU3KNrYw2C9XvKw+EyV/9ECNHX/PUZ+pvl9yPL4FNomi2Q/ir4pwkAVN6IIB9V5aBDWrBcz68ALhtSOFTTGGros+1jUM64Yojc0iQLuEaYSDD0UqE7LiQ3i1cWM0jvoqWSTMnBhd0cn+KmnKLQQKrUbqE6jGVQACcSkqtrWhF3xemfWq7DychVp3a8MvzZbhflIirayYy2Xs/weO88rl+XwZRWZTLKe53mZVS6Ut+aBl4n/q7aMvX+sSRTHGaWtOO+BycpKZa35sr8xyUG+WPZ7OcBe9uqBIj39Hqxnup6/ZSo3fWq4n6oTnl9cdEMyiTdo8Mh8m+koz0l2ZPprf9NnYe/+A73NZKMwXH9JLIReFcCk3nqS1Z60sPaz+zE/Pb0N86Xrkds6U42QsijTH00c2KiT1FCTNrr9aDLhiYzuBoxb4XxwJ+E70USJX3aSqyjZGXEqlPkk4gQFXnzheF1Fk+ptbhgI7nrzeuODPZxg9gAJhsjja4WGutVqVQwib0cI8RhLolYJmyzj4+ygoMYdjdnugH/cHekAkeqhoaB8R/rOFbf0Dh5tNrncSa50weoOKBPt/FK9KujyS2sCYqFB2hUSQES3ZR7YXkxGgcSD3Fjh6ST2yPkVPVfclQCdIgXP7pmFWvNyWX7AVFtpwKrZCTPu7yAAsMJpRLvQCivc4ag1b+3tctnuDXTGzrVqUTogG4tjPuqBdAtOp4L/qrQcbK4+RrKQdyvILkLp7NpAcEQwzDbgK8ulywKmkEbAWwXZH3B5i9gCGtk7aXssV1t4s9L3NUj+4LKuSreuwMBgF+4e+FILjfcWaFSya2omvCsSXcDgiDeypaROz0pTMMbmC4sZoCR4xV/RJPpa2yZL1wwlc3dcRfHPKMo5ICN7oPTFleN3mDtL7X6AwrpKxdpaZcgCyBFss1Vc1K2C7y3Jbw64h3HCZ1SRrsulZhOvYusbRddnSwdC5irnM1+xTMZ6VJEC+ZWsy8t8FMRdOo7pTr7w3MuuoJY36uKFrtwKXDUVCLew4HjsCF99ygj/Uf0KNxZbE4LlCuKhsHeFh5/eW+KwQq8QWcBszTFcNJfsI50I9W5HydNVi7a4PvY0kamvmgwD2+UEjiHKUWGpYVe51sZH/GnbftfVZpjlm7c9Vrjhq8wpwIK4t7BPsXe/rlKS+bpn1R/7uTOCeuc4QoPMZcZpkn+jozXVPmrMpjiO3Lw+fvxWi3IWcuIcay5YAeWcATcOgxAtUkbEDPsvLionJW6SWfVQzbKYgwzw2PpuYxv6C9lQwpIxfagZLGTGIL7Z2IQsLRsY0fh8xqYFYsq1ZqyipjH7+41NZr6UDYOY9MEJxEJm+sQ3G5uRpWUDmkjVbRRSr1KQOJP6RGOa3Is3P17hTZdBZOfCyDHuelzxh0kcKBqza6tW7Sh6VJzBLViKt1FogcjCRkIke2bn7iBPkxinJ+uUh8Ye17PgaD+SdipoeOj5mCpF4BIT7ZvJyHQ628E1Cp4LCl6ixby6Vt+GojIkbUbdWDN/ULWlWNVYsFTCzXygzM2yTRhJQsUIgnGmmJBjBikl000K2jCBH+jtBpbjB3q7imWJvFhEa2kbAQfW9nEzZSxaxRo/KiHnev5arWBiDfpFn8JLs0TTj1QUQEPjAnYapBfy0kQGXAUZVuY6wgG0HI5sh1+gXwNHjAm0oz5tTEX74lPZgxLHKx6RyEKvKLX9gwdk6ZZXEaHTtTA6XX2tp7U2jMxtq4cx/xGydon3FQ1FZW3l1OSlBG484Fk+yzDgkq/CNzBTCMj1JCJQJLQXh3lddC+Ogl0AILiWyWktsZykQcukMt2UUjAjrBeSXdhrRdA5KBJfgOYjy1ZbQgTf5QED9WwPeCWXNBHE26MHKmGIpgQtF787ORw7A2fV/atQ65VkO3WVZDQmab5x4yAv7aUKxvnrglmhvceIkgYVKVWu878yc7NJuXL9s9y+8byVpoAfEhpCi7bgkCX05qEGB7mOkrUeDDeZE2aqekjD9lOVLdyUhEL2LEoubJULzOoutNrFYrvaRT+Lwdh6F49Vu9iombumY/DQREC4g3r9066njObVjwblkoQvgn8q67FSRRgEaFEVDkIoWq0N4nfevHpZa73RmuTWOGx1eqQOCwwZtKGQUYZWPI5Cr46//AlS67Lwzukvd+/j1ak29I4mCmPULf3Sk8YROQnQugrfx2jGIJ6EI3ylbSvgaQB0L7vi6yVZdSXlXC7LB8U7riG/00hR9AQpFzOY5xkuq0Mn6Q2yWFTly+VevRRk+ybglub84Ud0hqqbsYOQOM0bXEzSG71kn9uEMfr5YFbt4CRbc3HyMxiuCX2e1QRpYzL8dBXE3VTMIIxvFCURTnEapEr8QtJqrPIdslYY8uW9lklVmK09Q+UyX6tbfWstIEmzq5KXU+XXBnSIcCZZ9gLNTGVfce7AS6uaNSg2gH2qN0i+/C+HuVofKp5twOALGhji3Z92kXVv4eo9Nw3W5Gckhaw1RVU9V4R7JM1Nihpo6nn+pjzTG2nuf8XT8ahgrlLPlgMEqdxw2z+yee+PAitID2caGti+Ojc2pG8GERD7tQgxnT2TzlWSTF/T71Iab8iKp3wS03zeeZTQSz67zaejanc2TS8y7O5SlsgH72GYJbc03H8X24ayI+3duLXq5B50sn2blQ/SMV9oRJvU1Srm6ewcosGtN2HZg5oP4LS+wQstndHN3wwe2kbD1Wa4ZcylCiTMGKlQwYAsdFwuNStx054BDStwJSOAkozs54hbJVTJIuD44ytEqAySMiOR0s9VG8gWpeqxAKvbTIMDjIvY7iR5RF9Sw08Brb28UoPuX4G0LP6UviGbxFQH+5VpTYFQK1haUCMMQoNBP/qIo6/DR4w7zo1bdUVOoVpQgr599OVWaDkorwjuZTdoPyvHyfLFC/crbqX165uXy+td4GtIv1Wn3PhGHfsxmyc7KMncwp7ra8VqIXuJfFpLg7WmVOzPwXCVNdy8f9BOa9M6a2+SDxtxbapCuzJ9hIXXpjpMnKtN9l+bWx+PHzIN277JLYGZNatSx7qbQQlNHWvWuqpWgKPQJu9q9YBSBvFydXpgfSSw2nI5340M0ksEXgylbei38RtTiNi4m0Gd81+uXyddjToFQZCWyyJEQKDxA5DRW0gJ0ci4HqV3zwxAY2sYVOYgG90EcXPEAVs7+foSga6xopOkSxBb9t+1BGKb9gJJsyYPabEFBK4vWj/opdTDJbFlDrHSGwjMJqT6fNdMWNgL1bNikq3PkAaysSIy9PfiG5yqtGCqzDWsLlJDfwDuD4VqqoexGVZcMKz1fun7ExjPWliewWMyGyGvzmfW2hvo56HZWkYAb2OA1r5S/V4fOFJIXjfOES2GfELaSKjcFGwg8bmYbYt6skbChCIO/mXpvbWkqjZUytLObAsxwfLERp6yXi9RQL1ZePuG2/ZTOA2Fl7oE2Nsqsm3xzN1otPasumBM3soIt5UPG26LO+zySoGJr4zCGoLzngXUcyDdj1cjumlCxK/eYvaYR3Rt1Njf+zXVuuKxu+u14PfeA+PNfmFGjB24iaae/nzzNWZsnAUFt+bloVRyt3F+JPNEQI/l0mLdwH82G+Jj4SWQu/bizHVoMeKWyyIOBRphfKQzYKK/NW4p8jzI7Hi4tKuykSZfX0naFCZXCIKkYkFhd5gpybU6S2Fzy6UGg+gvvaD19UqXS8swzE5d76jlaVUhsGsH0PP8jCsDv6j2nsvNlT0YepuPh1XXKjtFNuH6mG6t1jAS6/xbx9wwvpVi5YVr3dzMdKjLB+lQy2TWdkeKVxGejgJ0CLbOJjEKC57dC5qGkfVTN2BDYRwHluzA4c2FdDBMXsPiLXRopSEDqoMpeGHcgvw3wwzWy/SJjFv+CMYteRSHFCt7iN9oAymL3fwQjyTrPQfQWWxNZgcst4HUYhjYtKY09xOjHmdNrDiHShcblJUtfW1Yx6xitvtoGgNM3YuJC/7jiQv+o4kLXkBcsMcQF2tOojSBYUGr/Mr8/z4yA52QiAwOrYYXo7xeRYXd9Vt/453//8/oztYbCOtcu33Q6GHAbNWG9csNbhmAHPaFwr3sGu38Hl5DrYNWrWUCq6MLeMttzMo4+pH4yRuavGU3PE3QnXYOfMWYSXma7FxFAnydUYrforWgt8pX1x/TaCa/Karx3Mp/sM5ZOlFViS3JYcPrPukl1yluTGq4J7FkfoTLZYmDwGOtAm5VSvK1JbNbHfkMU1bnGHWEefd2lgjXgSpQ2ULZAsK+DQqsViUrUBxUVRyyR3enZx79/NBkczF4klvLg7pZUd32YvTsF79gNU0TLCjKXrkiFFBI5uAEcxGU6qQPYTEsr+00mpxMX9JF8padf3C9+0W5HGJMiFJdM5F78yA0Hu59qILMNYN5NIvY5OcpRZTW2n1WMt7JC+FkPQ6sPZRrHwJrlGo6JB0P5tp/Q9e7h+QwmONgupVK/ynvemG5HA76w+osnbheF3tmvlqFqC0kBm5v4cyREPuYBUFgz7faiOfZoriTmdnJdk3LZQnCkhXVwjJ1k3yl1q5loBx4LoPo2NuZ2dvZKpLNFpsZtjLUY1bjRUJvLAORi3SilCaR6An4up/ytDqRy2LCJ5rIp8q/9JpF2V4dJ2nd0MxTy8qCepc9zRfoskrF4wO2Vx+aaHcDNuzOjXqTHoVHQGtgrsncxXJp7Sqz9bwVgW9sB17pxI4GqWZDRqDE2TXT4q1IWkW978A5m/F5DFqnaVU+BqUaSat08hFc3qbVaHb5EY5ZWv1IZzHjk8BxzEssCvGJiGeQQtiLYxYndEJnKolPzql+vrhQj4JflC8sUj8fj1VGrHLoDUvU83RGwWQt/7FMfjU5X6t3rKuzV11LM2D4bDJik8tM/tqtMp3xcxrHqrBCteN0CvceXirg/X8+Wo/N6TxxMO9qxGaPawSLbmgivYniD+uNYEzeRquz/1CMo1YHomVl7fy/NLWtinO+YcnVayr0aFfFOdUZ/H4TzSboWh3C02YKksLwpbq5+IrdKI1pMicLhGKLUhCk4oz2g4zi7ngMqwA6/0BvxkAAsVGU8Fm8M2Izep6Mb9cmcOfsFvV8fzmd8ekeHJ/4lzvT6PxDdEmrOycxNfVV0TZQv7reTsJ3ACJBBTfVndc0Gu3c8BndiZIdUCHwnzy5OKve0CdpTPfw4z3TCtotwVr3EWUNnBcTZJZOkp13jCsvbn0Lvl3S5A1MiF5emB7wkHNTZTFoMrAZHQXw3hWX0D0ecB9SyBm7ZJNEPnM+Fk9Qt3gSsjbxLAhF8SzEePIZvSiI52giK8Y2Xl34sndEk+jWC8yXSFC0jPXBhI+o3a6VxSc0/4ZVqZT4KrLe6CI6T/RbdrH84v1J1vZntqBFMOmqghhMB8jBwYFtJ2A2MnPhyHkQFgbOlR/nT50p6rx53n/9/G34xavw5au34defv3kTvv3pizfhq9fht69Owm9eHB+HR8/DL1+8fv6FsyKHzU5HeKYoPMrs8SHjRvScj4Qev7l+ReLJ6xd9fjPlE0Edz+h0HJ1T98lp5cklcXYcT13M2Zh/1kalk4LK6WS98k018arYduzC8j7yXUpntzpdXPQc3Cw5EpARgQMiahXusMl6vBjUEXR6KIpDcZ0MdhcKk1bQtBqEQ0+FHk/L5RKLX0Yv3RQcWaSB44CGgxxeCBom8jn1iJQSh8qwMki9cz5J2CSl6noPK07gVFJNEM61x7XKvHrN2cR1yo7nOw4E/ELNpcLRw3Rp9JGw4Ik7+EXQ+x/Kw4oX9NzBL8rDz7wnlyQFuouDmGhBzwEDEzAzDOTaYyBrMjevjaHXXRvFfLmEudxJYQIG4TCYW46+wL6jfVBsC9E4rHWkk9nDdq2GMRpTjN0VrzuHjCaj54vpengCZeEUxjSRTuZjNKuymSKv6eXzxVTYnYoAPOBzObBfpHOMdJywMZvQwHpGEy2hHmUMUzcFP7KuGytU+Yxe0sV0h8925HfSJNXqDC+XUQmUG5VPptQ3TLcKSt04njClTPgHOhGxT1e56ZAG0dEiEPsWn1HmtPBFipxgCzBAicJUv16rKWofW3gdTS5pkH3tZV/9tfJajSuuzqLJ6IWx35evgX4CC4y8fWF4KU3w5LDRyg8TrRB3cOAXpK+Cv8e4uWTo93n19atXb335/JPXr06+9iV/ZTzmczo6ugXB0YQnX+oET0XUQgiCMkwUBCmPa8j2upzxdCp0TFCKZL0H0uwPptzbqwN4cByyCGqkH7gs0HGWpKEqDP8NHQv2pczyfBliSfFuuoun/e6iUvHSSmBmhg0WQ2Ib15uuunyQ6dQQ1CDSrpyIr1+9efH2xauXvh6qzHjz/K0vwlGLVuhiGqEnONXGtYJVQkWnejHjN/2raNYH2LE2oGuQ++jKXz//+vlb0a6gb+2NAHw0NiFi/wYQTrqHKRW1qTHg6sLMZnfxNFyfEsmcs2YlNc1/+fz185f953LUO3wgS+/Vh3h9yIL9n37+2heh6HPnt1w2QwTlIOUxMUz4q+RKOnmXlXq+fFAdKZqxY2+1yn5ssLqK2zl8GgDL9WlQbzR6e82G3zpQKZ1ar9nwa97K6owSm2bmtUbq3speFKuJrM+aWIAbN/e5Re16fjzYnDtcmf0iWLgYBjZQ8B4n1suGjZVz1S0q/vrzlz95ni8P00cAHnhdZSUFObn4Cai7YFPb90IbJburoRCwL6F1DlQqXOC5JfdUxXFQ68ZPlbJKN1aVsiCV8xZ7KuBCZkmZ12WlIAjLZdEGhK3XJ3bCk95GqAmxAhIp090MW6tsktBZLNUYV/o82UGuKl+BIvDFmPOZi49Qit+43mduvcL3Yk9Zepvq86B4BomB9SxkhaHbbJB6o6VMva0KtDMN8Wm8ipMoYec70DZdTC0Y3t0U3A1gKy6wuNwlQ8SCw6GsDPysYjlxl2FBYrIDhh5XTYK3hkuwKt4/qpNxehlBbAXRsHU3XtJJAYmt7lA1NhmFAcNCN2qdBylvYJzD9mkdHDRbZB7sNzr7ndZho3OAZRbowK3dqnvVo/Tigs7AQLN6WT2f3U4TvlzC803cx7duv1zug5juNa4xigviXkGAZLEHjm5RDVuiWfGzuYXj4DpKRGcmdOTpaCfhfOcmmtzKCnbOblE1VNIei2o0HvPzk0kcXWghzbOah3+h5oxdB7Xu9dO4e10JQm+tuy6TAduvyXUl9KTBREExrL6AuawNGA1rz3WtZeOC/8VQGU5rJPkFs8THoyPBBCuSKLyh5+mMqpkQ1PPOJZ1I5cENfAYW70jGWvV0AsyF/tWMQ9jML9mMXvAF4JAv4FRPaLLzfDEd8xmd7dTrYHhMGgeN1kZWju52KCbCgvYypYgVr9wtOoK0z2doh4m9dT7ZjiwZr/yCzLhc3tJcxq1dEAQ6vaSezbnrqb75ukFixriSFK8Q42h3Dxzil9P4Kz5KQYHtXnhdw3CcvNrn09u3vD9m0zMezUZKbVEcyRCBKp9KfoqKbMXcTgtCWqCG+6Yi9cNO68ADQmcgow87fAKtwcNU6pQ6SlfFGVqLVlxlZj7jqhlSL/bvJcj1Y4vy5vPJz+itPNUZloAU7sGpkSmXNLFYA2LaJe87DbaVUSZnoLOpbJD1FuB5jxrZKr6Q3j4kgVeV+rZnYyrcWSEGLRnVGDhFH1EzV4JN9GY6o9EoQwsH9S5f57lzuLDFXAhiyHDe+bBnv/hAMP9Wo6dmUYzAZR4p1SyxqD3WMLfx8HBKq2f/gRmIe0WblyFQfuhTl4GeVb6fGzpZfEagqw+tEVparzxLhJ1fhm+EG7xM58WNovxLiU/vV11NtQUPVnLMeUwfU9P9iszzWxz2QxrUuulTLVpLKxWPBfNBOiSGtmbes6C2XGLg8wDF2epCCFfY9uMOy/zBw/KJHVpj4skQ17cv4uf6uCjWFUjjrBGs9AjMHBdsL25OjcCbeQZnFjCAg2QqtY5oYL8sl6W6FdsY80s14ginXAydCrppdT5jiczTflDXNiJaM8Jpt3od0+RrNQOvLjJYbS5PzX82dbl8VF0AVXGmwzDgBO6WgsJWt4TbyzfCtltJCk02sAsT9pG+phdAc/XVNSfkw5bHY30nypLLZUk+mbvR8gF44a7lVuOr6CZTpODG/XrGF4qxUarZQlhp42JtM1y5VxdiZ60158oPyGBIbIQK4XaplufkluoompeNFc6fQHSisSumEXRGwsvc5EunFJJzmc8WLsNtF7ksWO94SjSQB/+JQo8/SOUtA3WYAgU9nvI4Zmdj2jfNvMYyGj7BZWhpuSrsBPVxizBUrQDStS2BeaG+pPMFnbGPdLRjjTLeuYlud/hkfLujasLGAXs0O8xAsyiO6Sx5e8XiFxOWMAw5DzfnSgyeZc7dpsL3tumNzQ99rTzAyg5DnaBjM/ltqWLDZD0xHe3s7Qi3CF6mBOw4u8f2NbO2IzQUuHwEFOgZ2Gyl+o+o3gIMGiSvFV6R9Qo2Qd7bXCxK4Q60t/lyllgrIwbo+qUasSEuvCsIi+itj+EEGYlXgnq0UfOSjW5e0RlLzGVt9qktLVHaj8U7Ew/xDl1MZzSOodqbNE52KEuu6GznjKIsB7ZkpA8TGMEYgKNWTIAEYTGpM4ktDPDlVMT2YPMTsVptvmAcXa9D7k0ddaAHyuVCkO/myAQSC0KXB5k7IFfKkqLnciSUi7vWMqD0A+TWfRBWGp+rWaVPXqxJrR0xgwQ6Eu4Dd6I4M93yhK93Ul/+eXSZzC29F+ZJhitDhut8sLD1VhbaYUx+o28CIoFQR5OQlwub0qFRuwL3EHzSH7PzDw7JoLCis7h5pjF4rqoCgQWOq6qCxEK+iCKtIFyhYqmT4yBV7LOq9gwHwNPtQwhDcKqr8j13Tq7Rnc3CnZMjjxyXy8eizUJ6VuZVZZ/L5VyCDKuiPeblr0GpSJ0FC+VyAcZmHQzhmb+gDGE/6gDE61t9gCrEzoxORqANJA7fhYnVCMlqP6tYQrAuPHBjsTCxWhYt2iDGSgOMUreQD3MgsjetmcXf1yWQH6ocuoYkSyBm3+5XMEv3K3Ivl0iwV+ULarMOvfx5Wbmmra/TGdUSbFB8z5UNFiR/GhZEWeVAUuxg23x6q2ws5QTJ15W3IgedZudB1iGyg7x8+910rUeppWWTrki73qj/Phvp12cjfUHPeDo5py8m0zT5zTGRGrV6uy6ZSMpVNULFK+DJOorAIs4NmxwjyHZgf4m+SO1AhzgXfHZOX/KEXdwe3T6HtrKJryZH43SGNf+M3n7B5xN8lokMxvSaXvxe8Kh+n3Hw3wnj4PeZib/PTPx/ipn4+zyq3+dR/T6P6vd5VL/Po/p9HtXv86gezaPK0CAbOFSZMhZ/KpOu7gn2/w32VLaH5JHcJBZkjBs9z6aSXHtTxdUpncUsTlw1QawaJ1EirxOSBkzybTRN1WVwSN9AIVctbDWJZpdUOcPPXDAC9Gdq7SrLwmdB2mPVCVJdLnq0kOnGf53OfoB1EQvWheiH/1Bh2V/xjRiD46ykI6iV9/iJ9jJUYmZqHUFaokqW9EFkEZlubCZcMcZkPYj0WwtDuBv/iF4JejXTpQd7AN/8RpoXpwwbYnStGxdu/LTmqaUtUB1DWwftVkpcWbo4q464GAKWkEhcllmZGzaL5UECw7VSnZgqAOKDzUN3vTcFX9ZwMlaEVS/GaXxV0HMuclwsdA5wYGyXWu8Kl6XAFeknTrIag5M5cGY1xYmXiJLkqBlU6RMbs3ZObjmzmyjHbxG+3+xBL5cllz+rgV2JHHq5rJ4kFEpzUCgsgELaRVoQ9jLL6Vtvv9dAIxUwY4Uk9fomwzEEsqyloYkJ2o5EvvccR6lHr/QsFM+pMfrNHDNQ1WX/rTPUs/eyZKefK7bxF2x0Mh1FCV1jra8XUd5eZEAuM/lqS1rsd6aCXqDr29x8kjBYm2MyV+tCFqIi+wKz/dHMxd9C/ImiufuRrTySCn1nzF9bNlCKzc3EN2w8Ppnc8BSYnJvmwiqkrZkB/JTL5tn1dOWPklrADrGnLrR8Cy4Cl2nAQkzgAH0sCVubSFZdZ7hiMJXqGs9VCKfUPUiO8Q0zjjAWpOC8kt3NIhKI3E/erS9YHCx697pmJdmQryv/2s68Xvn3K8KDfu9etK6Kw/PKP9bJx1AQj+pJcNS7B3/TR5i0LobBBX+cHOYBMLSrZTRXxr5IL4lY2XcI2rhHTqTUJnPotspsMiUfJbFRVq2CM+4QOPi+VPI0k6oEOmLm5JsNGIneQ36N5PYQGl6t7yK/lEkVewgS1U6x5EaN/dbh4UNyI5T/eNk56Ka5OcnJjBqNg4P6QxUL4QZa/LU6nXbDojWmWdYqOliP/SdPZjQ6T67jKp9dPhnx8/gJnc34bE+YJM6qV8nNuMeUXXTgVGKyzbFCJXDK0ewyHgwDp1Jgb2q7XFB0vPMVmwCAGoH59nmygx3Y+R+cSlxxujsfWcySHafCK87OBZ+hpfgFkIw3NI6jS4rcDPR7T3cmfLJ3oyob0Y87dPKRzfgEWsSP8UOsP96JJqOdaDRiMDvReOeKjqcX6XhnLiyR46qzEhICoKneUIBJthOdi0gQY1fin8B/xelHU/RymmEGyiI4+YtBPAQGIhq0cNugZY4GKnwQD72VMKwvFTIC52wy4nPl2R7MQzGhOuLnqe3zfj0nCx08cv2QrTI5Dp78YuB/vvc+jPbuTtNarV/bg78vWvjbxpcv8eVLfGl8+eVpWmseYrHm4Rf4++XeaVr/EnIatVp/D/++gF8s1qi3Iadfw5cvn395mjZrtfreafrFIXzzZQdzvvyiDy9ffIkvX375xfC/1Y6d7lVrex1o+ugQmqmJNlvYTPNLbGa/Nvxs9wk5AhnYbmZffZTOFlLlbkG4CTk/p9MklrzVOGiAJ8flsin+9uEvF04eXAgGaUGi8DYYKrvXODmJNdQNGNEXMrxjJdKBC7JiZCtxNGEJu6Mnr4+DuUgSfkIwSrMwtgsWuI3fwei0wv3OCG6PGU/j8e0bmryYTOjsp2+/OlbGS2haol6Q5UFHO0yXAlOOGY1j6bTs+UjgfNJ5gM7+6e1ImIDojOR2TB0M4Zy4YD9fIFeJvft3cDjhuMMi1EipTmK0KYenUh0JmcHAEWsBZoQxBTGveN87lwlDMhBhQmHyHCKeMRVA6ZdcCoFlSjJ9/l3KPjoEn/covgyHxf0TkDse1IbddwOuuspJHbs6qA/XeuucZ2cK5NSz6PJSPsdTKjlLWqg9fMTUNLC9asKP+Vwa2nnrTUcphLX/SGcxNEUXgov/mgpDb+0tA6fjPI1ll2AB6Qw8zkyvok/oTb59JwKb4i/T8fjN+YzSyU4U307Od6BTX0Jz+PT1OLpFZ4wzPo7VtoN/OtsZMezSSD18zdCB9YuJfFDpr+kNTyjUdBadf4Bb5uYlfyfcntCdKzYa0cnOmPPpzoQLqfzOxOTzKZ3sTMfRbfxiMmYTugPo1yvBshfzszMTczjaic/5FP5odDOmcbzDEnrzBtI+cWc3H7V85+L0gZaDjL6PjwkmxWjOSkePWqAmKdUKTpJzri5LZ8TnkzGPHlfdfvHBdM75GGx7ZnwOfzG7Ezs8mjyq1taGWmd8/gbqIE6cRLPkUXUdPDzBEpt/Mjjd84fuINq7G3pPLs01EEe2jGFQH1YTfjKdqtoMcpFE6sJQLNB3uVscfCADuEA/BF1XMuvDHpCVIYJ1PwVeSUPjIyCQ4uCtmQ9qw3LZeaWeIXq5eK5D+kv1DHoMxk2G1SOtAMItOet0vYCgadHhJ/bIyCulZwEtFEPfAkYMge4FtF6RFmBiqgo8oZJB0qwa65VY/lL10VP9HozJjYPc8kmTyBo58Lxy2QFsGEvGXlcp2FgCTTU8I1HFGFi2EFZ1xJMDZLbrhKaqjONIdvZ15XDTi7QDX3Fn0D0K90RyK5+8XNafKW/opfrKFZ6cRPAoVKDwSKpZRKFWD9vhke0M/Frw+XdJ7C2X6u2IxFDNcTWhceLGXm8XTkGp5rtH4gE2u7dywfeiCuHBegpX0KFNwMIaeRlWCnGcCqhEhHkUpRcPwgx+Mgx0zU29o8tlx/GZ7/IgzGI/JM2nID5EtvfOZQGgWm4oq/cEzhWWyyXcs8BSgw6TtJcdyMs3booE2/oAmQcOsRF7mCR7V5RdXiU70ZhdIrWydxbFFC+EaBadsfM9uFd2VOIeBo7YOY+m6sPzMZvuQcQp8TSDa+acj/lsD1XGptKbVVHantD5iWXedMYvmP5asGwAhxrxGzaJ7J7RCdx/e3DtXc54OhntXLDxeI9Po3OW3IoX7MjFmPPRHlYon3UZPkn2LqIbNpbPALbN0140uk7jRCYkM5qcX6mX27EsqFx24ctcTMfl+HZ6tTeJbqh85DNGJ4kY7xWfsTs+SaJxQeZHOkvYOdCDUGovGn3cW8hnPmOXbLK32GE30SW1pmZMk4TO9mAn4St0gU0u5YhvotkHOtujk5F6vGH6ES+VHf6RznBdpzxm4vSplOSKnX+YwHU/jdgk2eOzEZ3tTKMJj+lefWfKcS336Ec6SeId3Sdc4kmyg+65rK7GCZ/KfuGjWog4mbEPNLma8fTyynQjm2z6Eicz/oHujaL4Cp2P2Qn84iKmiUqBQZxHU/v1mrOJer9hCQz0hukPrB7B65yNkqsd4PLsRZPzKz4Tz8CekGbe+G5GCCRzbjJNkhlBOmHAnNg7YyOmX9BZArwl8d4UZvVm5+NeBJjoGU3Y+c7HPeB+QSsf99iI8stZNL3C9JsouaI3kdg6H+l5wmd79OKCnic7sKNwH92KR72N7LfbnTmfjfQWAm42rOINH9Gdxc14EvsLiG63s5AH/mGUTxIL2nnYCYkjr4huWMOcsSU/Ok9SwFDl2+x8xsfqzXqMr/hcPqIHS/V8O6W/yT460pnffD6vzpvIrKp3Op0n2JqTwdgWN2MfYJRD8HEcTS7lI4J6Z/h70Jff/eoY+tN+ol1fZ/uURGcvQFEU6MEZj+NXuOqPQibrj8DW31VxHn46oxfyO0cnOKIGuapXmPLQbOLFTQZOPDuHwuKbSGBcSL3efC5efs0B1EARU2HDd0FaDUPpju/Fy7fPX7/8/PiNcsx38uZ5oUs+8q3SM78QnjKicx1qz/HI+4LcKZ8l0djxCKUFuRez6FJ+zIryBcs1hJPpeCQqKiKv0JnjkfGG/I9shPnnRflItC+gB9PCHvLZPJqNQlgWj4wKO5nGUzqJoYcX2/LDMYuhnduiQjf0hjseOSvKG0d3t47XLagZCVKPrOeM6Fl6GSaz6JzK2RNeo4uqhzsEyfbCNsb0Mjq/DQVtXdjWeXR+VdwLaJ9NLkNx/8o+zHUflJmEocR+FuV8IwYY2V0p8hn/dD3I9Nc1+dw4mNNyOR7M6XC5jAfO//g/qlacodeLkS5DXtkbShZa3SmKY3Y5Mf34Kspr172hHihqCs0fofFjeXNU8Ax9mVWTGbtxIcYL5D45nbg7n7lRsuP1vCde9w26veMD4YpL0grO6cSpvKEVoSL2NQ1KlkPvV5FRRYqXy6+pcdwGJWvSHw12Cr0ARzP6BjryFpa/uyFdWXDAqFCfE36ybpTtoW4QDNvaYc5UCo9imvgbK1qBHovSy8zq25bL6+q26FJzLRls6Ya5+U+DeLVeEFzpERkfGuuSSlTWx/BhLFKt4VifbFh0/A5GpP12C73TFAyDcg6euNgaRigUBjJJ3dunE0e4jFxPXQShdj5G+tpF9l69W38aLMrl2tOgDz7TF0NwoD3oD7tef28PrSmsEt3F3h7p7+3BQltlsdfghHGxXMJf3xtxSILStWd7e/3lMlNa+DTCDQvp+gp3dqIEVeIcgo+W5mh1xGJguwEFWC5fV9nkfJyOaOw6T6MJn9ze8DR+hlHZroNrU6GdSTKVeB65Xq3mV2xMXTNAr3s2o9GH1Wp1wSbReHx7jweJbDoASqsCGBC9TP3oKjG6Af0xr4ewADTJ9IH8GqGDccMYXXr3WTYBfCOIV8EoqLesHOdYwHOR07Rz3uirROZ2inKP8R4RJWrC52ND/NV1D+LgleoD4BeyunpBdlWg81apwjpqeeaL41gqyj9XADNrgoQwfIMavC5TOPXLpf50Y2TVWLvClPNPqerblwqxEEN6r9K/FuiISI108a8VEiEymM54gwjIV3CDiqyRyVJrJTIu1jJwmSByyhq8iz29eXZ3RZocwbmqxc3NitNXeErFqYLaO6gfy6bH1kehxGe2fP21wojE11PqG3QcdkLXHIvMoVsu3ThwJMuOZxvgctHgwDhfCpzpNb1wUa7sOb6V5ngkFi3fUt/aJlAvD3LDhgyvx/2f6wO1XDpfAa4kqjijPnwTTqNb4Gmjc9uQTVhim1XAxy63/Thrx4SIDuhd/DqyL3NorltwyhuKQ+igB23ZE3VSXf74havXzEefvnD1turHF/QKpXB0lNv39oHHRRMr7BWfOMchuc67uNzxw0sqmjvccPw0VMqxWLPHUUFC5zXn6ksFN523dKHSDDD9eaRZsW3dBPAIac8+ub5jHeBGQ1X5SuO9st7GBnDQqOujjQh3DnLnIUG9k89ASCDrOtAjEsjxVwI3VqDXBur1wyxw39dA/gFfgBsO50aIaj4sPhdv7AtPw7D7LOMf32QIa/EimxEvRrtCvEuYqPfnttvl7fq5tGDUhI+QuQyyAqGuhPqXGRoYrIFQyHbGF44Q5DuzaMQ4vlginndiqNUQJbSwRh/oDCBfNsWYl51YfcN+9rQ0z1e2yyx40J7TtpjK6mqmgeNUwISkK6LgrQmclL9mIdcp2Bfg8TPZkBPTxKjzX9IEfU3EVKujbjGFyRu9XGbRfmUSZIwWvBXJkAaIQ8PgyDwX6WazHion95bpDcvYwJJ7Fey3oB8pNp7L1T1YkTjhU1xcCP9gfZ5feGSmjOiYJnQHVmUl4vJYW+ibSIcsNFIotXkzleGKciO4EoQcNzGLxep3jcG5m8pNJqNz0FHPSWYpwLiLaBxTrW3sETcOUk9HV1Rjd2M0yDW9/d01UhcOFYB+c2I13a20m3rqQSrjeV4G4bPuXlCnAePL5yrMalw946PbtVhHMtn069vINsTmarhqLhYU9CY5uc/qr8j+EFvFpVAxUVYn/cWzHvPjajifRSD2RTXfqjSRkxWvrCl7n+malLlW7SZBRpVNIak0oNYj6eknU1Q21mXBm8hV5YUiufz3mUdyPQ3us131UyITxPAZkVoXYzrys1AQYWkGFGJKL9dVP9MVa5GiMzETIt/l5hOvXEZpuVFt4MhA1Z+eyU9lFXLvv4lUPEKPpLI3RoLLPHXBBEGQ9lwZtNJxbAV8+QCl0eYF3wIUcfo6D9/z2SbkeZye3TC8R1KYHRpT8eLZITPXpZcS3oPKbQ5Kq5zeOQ5X0FTM89cL2psGaGL7A5wdu4Cn4nDoeVcGgvkthWPNJgWlUr6UtT6jM2VXgE7DN4xnuVzPyo5AW67rtSzp+YXIRuWynN8STrYxvVUBKnVI0o9ixGIJuhzhdvGZFSeOLZfc3hh6sdFjtt3LgK8EXcMCgQuLnTPBOEWOVdrM3HZoQbA6Zmph1sye65m1djMvlwESV/l8QmdfSOjqCfanJKpZL9frBybAzxa3N32+GuYJPdi3NEBPWFUW47+lgnuW0SOJTXgHEZcG1Co1a6vWDZ8ypW8bYgw4Z9epsEE4HKpggMp/vaXQHAbrm2kXcB82VDABn5VClDC+cDNpQeiREHlwIl2HaZTZcPchY0/0wXEqbyIwheLiYhd9j+2+43AHoewCQBzNfxiEVsOlGvprd0XTYWHTXlft5uVSlJGKbxBnVwTjWakS8tq2+m2FxjrL+HzB416s7OnZzMup26l73toVmrkYi25OpVHqP7Dj7Evy6ix7f2MjXc0hYjJCowmxTnjuEiUK6FvDzA+n4aE3grfUZSKmUV1vvHzRJhQN2KA2XPGArSTcFCGSQP4U8NWmi1VMBm4UaxFYZoTZm2sdUsuVh/Mn9h3zSho4WTcRMyDd/h58DeVOc8FRZvJCwFhOBec8tRbo+ixDVNFFIjV9uwJqbl5muHHFDrX2qgGupokPZzajVBKM8cdLRfUViEYbtVrtCRQRamUg6t9SGsXAEOcBf746dvJU5CbZK6gH2xTm+CzjgUSsAEietlYA89T7cAZaS9uGAsWA/OIzyi4nr7RTCt7bXr0vxEGvKfmOkl0auN9Ry5BVeQnY0jBuMC0lP3n9Yrl0tIK3wybAiKzqhIB3NWR0X9PgNV0ui80ZXGfEPjqeZ33rPI0/Xj5zKly5LHG9asKFdrrrVZynTzCf8OA1rV6wWZygn7tubL94CqXCV9fOE86hujxXHHboZCSK23lgHldEwHz15vPptFzGPwwUJsI3HPPzaKyC4PXsORY33tbymXgLcgN9J5xygR2p/x21LIO4gdyWm0drUAAVy2WGR3AcydRyuYnqmhM+om+Nyibioa5IfqdOX+YsBxy30B0N7qMJu0ElnheJDNrQ5+kkAco9iqf0PHkNifB6hnpPL0Dx6lWaAMmeTXwzZuc0l/YNqA6JtMWXY7qwHn8y4+lUvr+ajUA2o5PO+Ti9MR0RrzE8XshKLkQNc/X8NSoafaTq/c3VDHQt5NtLehnZua+gg/ByOWOjz2c0Us+v+dx6fD4ZWW+gg2y/JtEsUe997GH2zfpaJNgVyBRVxwWfJN+gVhG8jdkEbHhvpurlpzpLqmbhoxoEn02vIjE9SXT2ht3hOOdsxOeYeId6L/jE+Q02x8bjV6YmVAi03uOETzOvoAb2hVI0yyYJVTOT9pXWJjNpa3WpbbEi39Bg4HxDzz6wBHTbY4c4X/E7hzivbKd7s7OcNbPWaNaMRsO1BI61AKOOz5ZLhU2XrAI1weu7y1tbuaBMe0cH8bDnOk6Fe1Jo7/OKM11Ysr74LOeaCz3EoKj/dkyJEJvnK2ea6hFa1jq63d4eRlScnbmMgCsvknpd52LMI7wPGKoJO+dx/CUmeUrF1VRMQnAnw4ZBuFrZzunu6Aatr29osVszHvAK8JCi2eeJW/OyGu+VGCIyyeiLdY/cUdDMwukSrjSQWv8pDQCFvKGTFKwjcJXvI3nGQCkM/2fyXMMfxGzDo3KFqezmUht2wsMHentJJ/Io4JG+oQnWNo1mEe5nYc8CTwmw0HD7YxMrC8amGRjLLtyfQs+1QrpBOZfLh7DnHBJZbx6SWKCcD31p4+aqvVxtrZqoak29ZWOtYDrghCFiB2yy8+h+t0A13OoQbt5yuaBhzMl/3fBsvPejmd69Ot5TZn87ij4v0IBgeXmtgx5zhCrwAvAdTMxoRss0qbp8vva+F8/O19LSGVtLAz26KFlLBuRIJt6wOAY1UFRT1nYM9bzJQW2FN+q3FAlGs+kWZ0Z1SZg0oGMJ4EfFs3PN/hRmol71nM9mNJ5yjKR9EivMCuOAxZtyPdLE+VZoQC+GsKl0krzEmMLYsd8VHSM/l/80yXX06Ey5awn68LjJ19Tv5rdBo13zvK6l6gTeDka0K2ioLwANJr9LXSuLSOUBntk/z7EDP6c9mvRoImJIxp5Pk2AQD/2f08ByNfblmfDS93Oq3C//nBIeUHRBQpNAjRIHRThG3Sqw9z06k8a+puafZDH+2LUJl59CszidSZLRxfodmyuWJJ75mjCvC4UzDv1+ooprlRSsjyiLlJ9TBYICmgDTCRomMGi7qz/LEJr21Gty2jAlcLHF5fPFmYwbpiP02mVYkIJQifryUCpzH+WQ3LfflK21SvyCp+B+L1PQSssX/4qnMUV3SmsphUW/4h8LUgqLnkzz74XFhN8m302DUqq5LiLKccl1ztIkQbmYtEaSigZGmrhcSjM89QY4Ntx1+O55JA5KqdA/2qEaZMRBqQ5gd00dJiOn0yeO5Q9cs0440ZlW/DZY3xnuTHbh9lEzEZImCfDhikVnk4Q40yiO2Ufw4JKX1cHGBbGbtGKPRqPnH+kkAcE1ndCZ6yQ0ThwyScgk0aWkGfLmglLK8x0VLdTNln55ljW9Jn1yLTb4seRAGjt5tAYTAkLjNLLpdYU2n3J3e2ypd0j3GWIeQZ4IFcd48rgAh4ThWyTfxklwL8tnhIPwTQ2+iVeW2fjbws5n6395Jns2Tmz3TEbObHNfCAtiERk0GqPlbiLCcXZ5VXK7PR7oZyTS44B3R/y+VHL3a502AODYq16Mo8tYGJup0rAz1bPUmIu1R9qmkPhElz3mZyX+36hLoi5uHNR+UaAfdJXBhRRyhiz4wo3NHx4jMxi8IXIfalZVYOstaE2WYk2E31X9wvkrGa+XGkNrt21Z7PuznEKx6JuRMmaYYLrDUjBrFNpAtoVNekXtqYPJUVkGvvDjlXHmG4Moq9s1cna5kKb20EPQIfSjgzDbEVlmbhtwumBaJ1fYu2dB2lXB11dYE0CdUOCd8Kl4ElQM1I+v3XkXq5zb18fvnkGg97gr09NsOu/Og3kVXK+yyeWqaObRM5hElSDCrewjC0KSBnPDYBKuMEugz6q60xfd6WN37hdw8tRnekx97JPITIOQMJ3ZD/q6Y7B6CzHafjDfVv0catlU/ZwwnblefX70Hbizcfh69WAG8uU6NTFLYD/J8Fxt2VDM3PXV83Q2o5NEGE76HJUOeruwJ3Nnd1edkQN1dJfLlno0upMCVZJRKpRcIu6q04C1WMaz5pB24Ss1F/YphQ/PkyCsppMYPSCE8fkVBSt8cKoJposkzWQL/3A6c5r99oqn49G3jI5H5CqTIyO0fh2xSUJGmawJn5ObTMolTfpi5r6eMT5jye0x/UjH5DJT6sXNDR2xKKGqELnN5J/EdHY05qgdoot8zBR5CRTGWGeeZTKP+VznhNmWR2PT6HN5e8zFP8KDN0mAkYzPx3fNRs88Gldfk3Pbfv3Zs2dBjaBPOoic3azvuYvEjb0nH5JlzVvWVmQhaxzzS/JBPh+/bGBr/SRo7ZNXSbBf7+w3a/vm1kvObWZ+eU8p3WoVw7rUcFPvjayy385+TlOvnVfnq7f+P+z9CXfbRrYojP4VCUuXjfpUZpOaLIOu8Fi0nDihh9iWEltHD5ciIaloCiWzCJO2yP7tb+1dMwDKSrrPXfe99WU5IlCoedi1561Sdm0Vu7qOA6P6tnOYaDW6A/Ww3zbaca0dnWmntadz7bWe6GyH7Scm38Huoc64u/P4QOfEcMQ6w2679Vhn3jnYae/p3Ps7ezuHh6axvcP9xwemvSeP2/u2zzBxO3uthlZy1fOou7F7eHjQMpUcPH78eKeta9nd3d/f29vVDR88brcODw/s3LV3W62d3b2dPVNte3dvp/348c6hy6ET9CocHO7t7u/t28m1CVolc/fg8HHridV5dAm6/tbj3cd77cMd1wWbUiKJN/xoF8UwpFWAPc/zq/4gz/BSafmXjYkf0kJnfcpOapSNMDP66rvl+ZV5XzAzhP0Gx6rAaEG11GOLxr/SDqT0ugWbDeMeSeAtnjfYgqDe1GwIEZeUq3H8tGC88a+UqPwLlX/uZTXdLVx3datgwKN0FzZj0Uih/jhlReNRQX5i8ZyJxiP0BXCg7NbNjpiHXsb3UEpdLFn7oMEJbRkF6CyfDfKriR43IQZQm3SFAIsGKzqtp6JDUtZ++hSs5tuP3s9iQcDHAAa5gDz/Sq3jOo+DoxeppD7fDtRN7cKL7Z39ljm75siag/r/c8dTbO9nu6Ud/Kjt7eCFD01bCl98ZDf/fiPc02BT5k5GQ3bdS9JylX4b2vhRPaNbuRmbjRH3Zk+fsjZ4u4gRAINTTVv2+zD0Jnd2TkF5Yrf9E1d+4AwLxaKhruyzoeFXhN1eMkHtibcS3PAEshYNTiBroVKhbKL9O3jxk+RM2G13zrw4I70SGAj39JKJTu2u7vCO4Zyrajmwy9tPnxadtCGW8qw4b2BXz4rzJUNHpuxfqaLvns1Yy/P27a9i+2ksG+yRJN29p7LrwIjstg8SBwn3kjbWNJ7RlzP6dkZfzeiHGX2NhN27GUz8c31B9/Xvkf59MUMT3FeDW/rFPW5hmdMZi8CKKQPPOxv4VNxuzEQxvFYIkHrO8pF6UG4KBsViCKyUjdHFRD1o9wO6jH7DOvVzcbsBDqegIvhV9Yym4lb5FlUG+vDVe1WZPmffsKLP2Td06gUPxe0G8j/Q5h+9JG4Mxe23jWEx27gdyFm2obo1RCeVG9p8AaQBG6jQtaGVvDzDdLc872shkHJOxXPLph0WUhSzKNHTrnBxpRmgRjqzyubwPskGwDfqV3PjrIuvNrd6h6qPqpn1dHrZTQoUeDFrKvVfsBZU6S9HxC9/JWZ2rXxW1ETIyocvtbX5uu9Dn9lQYxwLEn7UsIVTCVdYN5bs7gKQ1Wz0Jk8EHYkb/AhK8gmneHzff5Oz7OYFMAySgno1JHOqONcgQR7wPJvK5Cw9X1FP18OZ6/SA60sajZfq4pEkiWWz3MCSFVRYjrir11SZNhooSHABnuBK1WANqFGfbzJ0BPsfw9hUGlIqGuycQue8D5wYhkYsNO0V0NSC/QEcU+IM0+wsMoHKXRsfZuhKWaPrvuD/LQCrlUEwgMBTHJEaEi7kg6CXY8P1CAT7fvtK/o+sGr9CM5cv80uBZCBZ+aVKzJOhZ6SHuiE2p1NUd/dMdcE6LcdWN5P8ERbB32G0ugEo+LOiwUYN16ViBtbDhdD7ivpD4lRr0xcAZrXGpqvWt6PQfp6AM/8xYwXlekBoHQO8QiwSF4RqaQ4VTXT2ExsO2aZ3hX+yVynOI6ysObne/tyCi17dGHo0z2eNxp/D+Dny9zUgs6yvvvrYx4/90scj9fEIPx6Zjy9mVqL7aUjol+DVdWQw0hDWmztzz4cbhL6eLZfxa2R13kO7x+tp3a0h8ZluFyqymH311PwHo1jAeQZE+um7mVHVuxuM4ncz3CSkE4YkezeriUX2bob2MMHQwDqCl4YGgFRMY28lBqP4+YzKYAEGo7jvpx2ptCNM86b7IpjtC0IFa3XE062ghzFnW9A78uPO4Ug7LVeBUqhT8GkLpsOrpENOh3AWdA7hvjQaWzO7b9HV3Yx9b6J7X835OILN3kNzHfodZVR2cbJR2aXdM7ABOkHnDbnCETrBG7MmHs9mrE0vXQVWyPVsxlIaFpr72qv/Xpt7f6dNrwhAwO/W6umjvV9JwJC9VgUK+seMckIRYSmciYCz//LuZ3JXkqVZfEZv/+cz9mGIG9AWocaDnofQ6Nx9zN2vze0hNCZ4GOY+qs0dYDSGyWzRDUM3vMBITfGcfhjGL9BYLZ4TJaD0KnW11uA5uqagevrFq/fLvfV6rvNS84mQoiln4hYkWYOrgbpu7TrodaF7QBc8aj89nVkkAkTbeMIMmtExcSh7wMz2LqB5ozGGPpnDFc/9bUEajdJmmHss+5TNVw6PqemqQghsDThqrmVSf5SF8x+HwTbV36mTXADOwxYXcUEIIXXiCalqNPOj0B3ORBXdkYDuCA/d6aiyAQZj7D/+DhLjxEviXpwlaBdlKKgGoWCkruuPGVP4oqfAPKrRLlbUkVEl8eTTw4mQBhH3aBWbcvvNPBZGTcSQYPrVEGIewZHlI+9NOS017+I2SkIgUCJq8FWJmM3zV3BVq980TebekDJzr8WtT8t4WTWFqd+QVLPPhZ0DMA8OqZtg6jzyMkyxFcNSK9rPJKAZj30Gn7DqRWbZZ2v3q6lC9eIRwX6Km1ZHEOuEr6BOGbYbvCgpPRd5kGpJWLvcIQFcTXZd8BILFcVEpV9kl2KaXUwKQyQOLmfZ1HtXGfwF9j6Cc3xlfR50FHeHfr4eyOvg4624xWMUDDV40YMJGPK4NaOkfMe49wWfVWlo+14mmm+caoYjomtpbL1hvAI+Gb2G0pbDqZi4zXB1Ncn8reBVNr/OsonftD8wTPCHolvy8+gknSsQUkQ63kFklGNuZjHRXNKrWUng8W0WFt74OsMGNi5mZclGOquy20vM9PbBqpqCl8Vv+jL4Rf/+Wr488pFSlvrV6iX9Ouu48C+/zDDym0I1acpcWNjfZt3fdJy35LeZrz9O59b3UMfpVhWNBgevc4ylZ/IcNaw6SqBbPFKCRYW7g2ceflY8Eirr/JE4Rxy5Y7vHUu3sV9L2U9FtPxLGotjBeTHyZfSfs289UH1SVcDhn8J7hIEbjfly0ySTRqO9a+ge1t4liWSCtlvMXDLtXUJ3d54CIwUzyq70+ba3I6vT79OCX7xkT6tlWiF8HN/GCr1BgRgVVFJ0koZu/7Wr/1TRpy9zOTPhBDASgI4U4NG5JqaAKmDiAuir+YNKVPgVqXgMWGh1jLPFORY7W5wz0RVxShLw6GRXx4Zg0pFhkKrPRkwjEDYwm/3SrSYl6EXZqCooS6ju7Sj5MqK6eg9Xej8Tt7fZiOmPK2enduEHobxVlet+hZ7GuKx0wXhIk6w8iR2pguIF9XXLCTFJoiL/nIu5p6ol/RFhLd47A4eO62bvdkSUswFv4EkptOLarpbKdWUV3aztrLpij4qLi4nqrZ+AYaDXrwZ2WAcO9Du6oly+VclZPktuRytCLxBQ/TyjH2f0zxn9NGN3OO631wOZJS16gQ3KpEVVB9CJQ4vO+A045rq5DZSvLBfOfl4unwOumYs5eKSr7LUW5fLDtJD4vKK/z9h0FH+aEZpNmTJ2/DSjd195NsdoRLMBnyStFaGzKWTMpoROTcZsSu/U9fxn0qLq6SN0e8KzfPanfYK028FV9qf+xTyz6eS37BuUA6JcPQ4m+gFU2tXTVQbuaTBaGOLQyfcRVYqISUs/wExNswkg1epQ102QF4MuyNuVzcupuNG6w/jdaSJ3ZXMm9HMSZExK1awoXLo3OOxq65H9qCCwbLrcsdxk7E/QaYY/HvqAfUFHA/HPM+iWmulHf87MI/3o0j+69I8k+ThjP89Yi/45Y5LQn2fE9e/jff37WO7fx+TjDFQec1z96ZRQiU9qA0yn9G40mA0+TAe5vMymsFEI5V4O2CLh2mCWgZcFtps1u0Lue4tmk8GtzEYgPoM9I7NiZNcBK5j4mxUcjF+IwXT0fDAb1A0vyGCGGJbS2ppBIgx8iD2dTAktSn2GgavO3E7Z3bEcJtGxHA5us4i+B0vCi8E0iTYi2s8uZ0n0bDoVc3iM6Mmtfj25jeg7NGVS7/gcUYwaplKeY3DV59kkiZ4jGzWif/A8id68j+irLC8S4/oKXiL67PZWlpLeI6qYROq3LyCwyivx/e2U58ilhFMWneR8lOUYk28Urehoyu4Ok+hoMPysPQc/SaIPg4uItneSqDfJBtOItncTHeeVtg+S6D2c4oi2H6v2p2IS0fZhEj2bQOqTJHqLZBXdaSUQk0qqnuw8dpO2u4PTtbsLea9AZZnu7qlnNQ27+9DiKKK7B0n0i4BYNruPg5ndPfRmdvdJOK17rWBS9/aT6GUusyl8OnDz24YxvmjDw24SvdiBh70kerELD/tJ9GIPHg6S6MU+PDxOohcH8HCYRC8ew8OTJHpxCFPVSqIXT+ChDRW24Amrhrp3oO42VL63l0Svixs1H+29/XCpdnb2kuhVNhtEK3o5ZXfPJrMkUmAyonqik0gDU9gTs0ESaegZUVyUJDIQNvLUc996+GLlHrWapmXo260mgWrd5iaoi07BeKDR2NwEKwJPMu/QwI23I7z7bvwbBOIzlmPkAgJrenc7PcN3cFULv2gMFOzYQPHOOIu1vAAHRxWbRzLElUlXb99E2d8idO9pjBgGZXkLtoLlUrMUXJUj3TkoBL5qg36BP0w6BKuTFp2IocJiHn71TbPbbDDTZREFqLsMDRJfB/dq5gDHDhe/7vSaYj8cuB011DW/5sPrv9SBv9zGitArBMA3U0K/le4gy0VN0NATAykqr/Rwe0AHiimiUMAnyGccpDIukU9mf+pfwExmc8DhWlRXCiZEiDS9nfKbwfSbAvlfS3ec4shIbAOuuQ/2XTEnRi6hZqVrd0TNYkPLF6VryI888pCbM50yN3GjbDIb1GIs6ou5K3U+xU547n16JJteGuwFzPpxbZUfgyo/+lV+rKkyyFDz3bb4CbHVyWzwSu0XQo9xotIpofMpO3tC27t05zHd3Tmn76fgCjjqOZ4Vgj2oXqEBdKFcmHUgm7Huf6UJaPPeaMSLKTNvTT+bIvQ/q2bAKWOp/kZjczGlPfweb76fLpeLaaNx+BT+tts/scWU0DdTVgeYdncIfTYNzK2uslo1Ec39NMpbwMGfWra/MEeLdExmxcU0ipA7T9CdkM7VqWOvllmqjmlrfbVVIxHZXl9n3tYo+X5VXlWR9gB/hSMPa4Nn5598jDMBTy+n7A6NIsHaFRiQ5hfoIniOzMsjBKeRMrUF6gZMaEWuDPOVdbSyppUSImDAM0bhgAeZDaZDzDjLJupngVa5ppViisnzLAOzW+++vck8/kyjocwTla9n9xy6YzTcG2tgJbqbmy/hwoFZOk8C8ypPnS3PnHjkGIQgtPU0FkxgECMTLxccUxh5asxRGev3Wew+U8Mx1rIYEB9JpWyiqNWE04m2ZwJFe+0b6a06OPTVtMR2m+L4JXTNZ1vZWNZ/DGL0u+cELS7T18w58tC9Yp77ZOW94cPUWnrhNL+eei/vpiwSuZpI7whDjs13U5Xn+ZTd68ij83waRkyyNdJI9aQTEQotVV1IPp82debV6yl7N1VSnNfY5Q9T9noKcKAWlCyXT57WfvAm8VkGqjVQx9spHhujtBGJ3FwPZjWPMgKLo9fJq+TIrITmeOI17F8ujcYsi19NidnFZ+edHLbUqymVFA17Cf31Ip5m4NLD1dvLrCMvI+NBDmIMvYaucBq/nTJBmoPZj3pOEgdkFHPyWRB67rkdQ1nCoQ0SHYahXiwKYvaSGqKr8djfeijXKuX21VpeeJkDq8iaTauKoi1f7Y7R9olcdu2Ts2X4OQvNcjXvFvRwgUX7T8lY+5+gXC03YZLEJhMeKHrputmf4mMQFa4aR2K5vCfKhLBfRejIkzPf94IktAgSlNKZ4bSjGZR6DLWsCtbqFM5PWmHUW1LGzwrl7VXHfhM0hUhwMKSz9JwK8BNm66pRVfo1MxrDHQTIgc+c0N1MFST9lnmqu7RgWJll/hfKigrIjcK5o4EkzuR24csO9MCofApLyH+ys3gH5ZKCao8i4pFcdSTjqyxRfdZtFM08W8zeK0MjcNQaJBhj2xWkOyv4VaEDW6yw54V/XvvB3trEaBqbGC9PKjchmLK76ZvYw1eh3O8Im9hVNXnNkiTScm1pmTD6PRZARcL7DeQ3vv3eavwMqMq4fdBYmwFF9N5RfAUg0UgTpI47TQX7cxCTjtjguZwN8qHi+4IviJcvpoMbA/A7KqCG2sQ1Php0CNs/sNKmoeyaEJXImddybda8ybUOBDaO/sWD8sRCdWtx6t3lr/822rABEMHDHeAN9l1AcSl8JkiaZZPgvZiG7wYvcomkZOkNLmfQ3S7mKMX79dbojTc2WC+KTp4AvmcjWAlaAHfTAPJ3AELhvAvl1Q3+hY4gG41+FpfS7OTqpaU8UO8oGo3X6BUP6lVhRaYz6jv5LZpZPlK7XxDq7hX0VQS7mBPeDFOZoF7ScT5SxmM3PI8l5UqCaGCdVUGJpdK3DXrvXIuhqq9xVMezufORcZVp54lc5OQOAJefEuuQRozXAZ2565kZe0o6Bjgg9MryUXeeeNmyfIRKU7KZLWZZPmo05j+BiU7KCrQGnaNzSfZbFnM6NxJPfCtIJ200Fo0GRlKRTcSq0asVcHZULD0AExgzM0fcxyS/QSCIHxQ8XC71VtEFFrYAptr8C50fVlB53kXMDjdTTAggdLhmsWqRmvoB2dWOaycTzC1jQuc/FV2wPx+NVAWodaumIVY9oLZBkiiH0sc132hQB8AtlAafnVPJOFqOOqDZIe3AnYnV+b7LjCiCToDFjJIAYBxmlzM6E7c24YO4Xam7qda5OU5Yo6Ef8BiC01HhOx2F3X/Gz4mJY+Y1xWQTmqey9O2DuAX4IG6VXP5IEcPriWmge2sRXfpC0xRf9O+W/j0NyeATi2gqxWfeVEcEbYG5rTJ5EjiJ6/KkdOg6p1OD0ryYLpcvpiCfGcQF+MGonv+4YC+mBMFIAVZyd3iMkqIEE2iWj/zE43y0Sgp2p7Y3rDN43YiLMkQryuDsRyAANrWrlPrHJymC00Tt+UkKd5aod35Munpb0a1po/Eyi7emFCdja8oKIC0LIC2/TIG2VN0IaEvh0Zb6swGjmraENSvRlsKjLYsVocLI8F9Mg2v+1EfDwK2HMQk/K4U2OGciTKDcOlrblucsmutnAR/A5xqm3sCDoMqXyMnU8w8I63maxdEzkxBR9wxSCkJtXm58Ca4vYd0N+uXUVlpbRm1BQp0yr+nUB5sSUe8Fu7Wi36fsbkX/gL/u9HwypNN3ZOPrecQXRLJP/GSJYF1QzjBZKbNsqMsQcPqa6Av4+Y9pUDHjoKBukWtghv3xAyJce5TzpjrkrC2XsQ47cDJt+ovlXmhNBrtC92fDBXGvJJjcckdc+WCBvDd1O/42ZZ+yOPI7GxH6SymVexvk19I3qTfCzyo9aC4i9OPU2tr9OWXR4EIoy7meskxTlnLDQf52Mvhmfj+oALvGem0ipDVeA7miM3ID7Tf8c6wN6lCoop4WXH3vg/IYPr35qr+9d2Z3o0I72lQmctnN7Yxno40sH06/3c7waQR/p1Mx3bgSs7eKL68dGGnzO60VCiZ5z7WN3ltjo3dyuzERgxH+yVCwqx9BWjYyr6pHEyHLDdwYJ0zqCXwsqac3xUw/wKhulF8lbfWHSqQboD6KfyCC7q2p1rdMfO5ZJmLN+hnqNo9Qu36G+qfiCkc2HcwyPWfKlFCpk24oRVL84RhfeTCZwDuqlG5o69UNYFieoKamsqfseUaWx8bIUs2J0ibVTanLfUMpHapc2O/5AOMDbyiFw1pzxstLBaw/Ih8Nn+nlAKIUgtMxQ6x9mrJW59P06Z9Tg4B8mhqi//cp+3N69ml63rm8jH+fliB6JPJoO/59etY6L3lq/H2qFejaeHtcxr/hbVWC15eX8S9hug+VLy/jX8OvBv5eXsZO7ZmW/H6pz4bzBV9fwLOfLpCFKPKjSTFV6T9jQyXATa8Hceioi5457VLqaZaeB3nx9P04r971tmZPDZUGKqjlErb++0pcDmp4yxq66MnZMJOhj7RnblvcbpQZet4OM7Vb7MJW5JvbGqNf01pYfdX0+AENHqHuslKZpmdlxWjq5CXU062mWtncTYordozFwnrctKyxP7Zdr+uiV7narrSi1v0fa0CBk7AFpQz+N5vA6zHLzZU1HOS3+oqCXx0D3t4fw4fcHwrwj9wdcOPfAWo+6mC3D3SHFujeC24dnNWT4GvlG3jpj5fOcryo32ezODLXMt685nbDayyAwH55YLUMB7M4y4kHdfPLkCxyjB2lAakC9UedsmIsp46yqnXlBhEFjP82jEnlPLhROdOxnWYVz06Hel37TMw6ZXdzy2XM0cRyMGN9slrFBdU2xS0K1dYo7zpiQGpiQDBw+NYQzkiyJoyGmQ3wAJICnwMVX4BNaimPTpZoUyjVA2Q+EeeXq3AhZltP2QJCxxpHKwWoDY9Zr2kYjbTPemHnUTrFerY5OlY2VmmtgmlMiPGbmF/GKe3RPlhYjZUgCfq0YK3O4qnpU2ehg3KMWay6Q+7pC/0PdES59TLrLdlgVnIf6HHPnwdRL86u8vOOZTkp/86YaE4D0abMcjtKU6UdG3WQylBU+e1lLKikOxgjngNrBSy/PEX00hFooTfWYsn2CL29jDmVtKBaGjPNWaR0zpW3Rjij28j+mg7ykbjxPfbvHhCNV+x4B05e2shmZ9P8nNzhD2zqeb2X6YqwSjsQmOU4RJDjfIERQtB6QtUjHAfrY1qwJyWPt0kpRk7HiGiWSwG9gUgqpldfasRl0Fboj/b20gmVtaLBeATMK+MVR7EZs5Hn42FjL0nZlUkxugApuxytOEubFzwfxYYDAENL9UGjm9MZ8JCdWZOaEc+6RScobFOFiolT1NEuujY6UtqVVSed0NadNsU0cn7whZ+uSLIm+2aLJA+o9CE1+cG9rke+UayCNIWK/dRuiOVyp+FkawUhWYJyn47vcNF4XTKGJWBH2NGSp8Vyucc850/FOvPCjvbply6Xh4yxnsdq7HmMSM+cE/wyYdUK8BgHhsaGdNExEbAXpkPYo7Hq0RiZsfBxTYeI68zY68y43Bkz+AVb6B6sfFPWXjBV8QLMQntWwaCjff/FupdE+f8bgwxtzhbWVeRGhm4NXdMrFKbp9n69CAJbKPgypylYn3IISn52bq6RHvs4RbNe5THQbqiemazfZ7TPKu6/ndKN9rQlRiCwMEC43goSFH/G7GrqHUZnYdln6jmiY8arWVCDp8+UHV4ly30GfeW82hCUX8Y7yHNV6vph1+83H6212iwb14U2nutN7QKD1jHLp2VPOWttVv++NWC9xeuYyaDxh1l4euP2LT7H7Ktf2cZvU8yz8Yv+/XWajNkgyPIzJF0EXTAmhWM2C9K1AeGYHYdLW2cKrK1ox2w4/buefv66qe1fs5z07HPH7NtUiSYMvki32OZRo2HmAu5UesqOugaedHvbkfWbDYlJr3MEJ9zghCf0OysM+PmuoeBHFp+w7yR0SA6Q5wTAjg0P9bHRiE/YR2PFfmo9CMUf2W8X8Xd6Cjb2R4pNPruMv9OP9ASE3nRLQ+Xv7LsBS62nR44D30M8ahz3aN8oc6WELgKGe89juB+ttJPWzfhxQxCNRrrTpRVBvNnGmYo34x7zDl0pm0kiyyVnjH3MIExDH/38euYhyyX3zWxAoeOPYdwHtfL+2U0O+uXxeLnsERxX6kQ8aTdNMCWMDdjthSITA8iV7F1bfdBxNx6zwkx93Gf1HbO2QKSLncI9gD5poNQWOBfoE7Jc7oPLFLW4B+YRsymdK5LEY+0gjRUE0e2+muUjlk/pR1bi3JyyEtvnu55mj81SII4EAbT8+Vb6QPER+6arDVk2p6zC9/nOTAURHAd1d467vaTI4jGhJzqlr1L6hOrddRR/pN+3IwUJ6Ri3GDFCmy3aCyeTndCPagb+GMYpXPcgQY6PdFWnUJWCubQfVnVCj0pVbdGP7Aj6+pGOG40+0foxp6xPv7MWPWFHbNw56Zywr5fxCSHft7fxuJ6wFv3ITjsfOx/hy0dCTvSXTuvp90cnHXIE6UeEfn/0yKSfPPreIaeQfkroiUmHDHr5GDu1sQpOGw1M8Dx8m5vPVK2rWh15rh3UswEi40ZjfhkjxU2PkL7RH/oWcGzpLFu0D1la6DfIc8SvScBuARLKxMgMnR5Jb40eSeDbfwy++PkEdTp6SusDmbXsa2ZVGG4yQK+ALTAln9gL9eHuE3ueKUZOxnoZjjEes159QPLx/QHJe5VQvLovjUb8iR1nxCBPnzDhE3o9Id08ixf0E26kJM6yRiPLYkl7oNJa1ieMoZdhVD8C+ZsuOnCj4UUkVe1jANoeNem0Z8Kw0iwrzTytePGDecsy4inN6OZ8rZlGI34xZVkGQvDCSMBJPe62NWVfpkxLzSv+/dQNCrLzVnihl719hLiVRYpQ6k5PYEphQv06Km4l4ExMiZejFlG1deFtzPF+fD+Fg1x2W1JxSAEFBhmrY3R2AjSz7LSiUghYr2uLGJcWlVKa5WkKrgaZUazDQzGedlE5H4Ms1zZZsoRpNHZ2lH6CVryvK6fGRzoD+NqbNhrRZxGho3RlKARX5HS5rCu0ydgg61a7wSC90RiDDjHPWA5sniT+ZebcH8S/zVhK1npAAEX8FkFN8yxjIosLOsh8NYBBhsC9mMaDjMq1eMgg8xCRDMLV86w7yFDtn/EsMVc0z9h1przfxe4zYEPw6fO06xzg1FtHlDeElkJfZ6CYWiK8rJ9tNIlAYycVvyAGS4wWfTPVRTwPKjbGkcDOwR33ZtpoPJuqkrLs0QIvgBXuFafn+9lp6o6N6Lzcc4Vmbb6fNhraEARcW8IKgu+LX2bMeMRAWwmqHeGXqUyNutd0qlOlQjdj0dQmSxDaWhk0wZO2aMKA1zpDo2EyaB9JYK/WaLSfqqdQ53dDJSKjVc2z+VBnCmOyrCq9XbO43lkR+qyo1VBrpKcftpTRailoSczjb+nU7OhSHho4tVm71dOSjkvqdvFKAkhUkTHtZphdhlH/7gw/N5G2pkTQgLGbcI+FJ7JSlD4mHD1DC1ToDwMcpEyibxNHuuyjowqfbpnjTMw1XhLP2W8XZhqLZpErl3vY9zn6LwvzCZXPEDU6E6GSGWcRqxrP3Jeei05fqR53wEjceaV1NJdGA5ByFdzBapwoVM3VO7/0GXJmmuZMeF5IFEtHjx10UEGLtGN4bByZ/hbhQ057JRCVQuvGyL5Td+O+wiS8We0jfdOnqSY+4zHMF2hUkkZj4c8rp2PaIyRJl8varGZqdT5CuY2tskLn8HZDr1fBWqzU3Zzn7J//Pf3vvPvPKyrhuWi1Wq3lfxcvXrx4/s8rxwP/funFeatoUIP7GAiSTZrT7HYyGGZxntPov/PIJcicRpFv4nLpB9pn30ESQ7EVxQkuR4Xa29n3z87RpQlVpt0k0kFeMlA6vvT13sPQVRA2U8jhlN+6QFdVrXAbL9Hhh3UfS7Zu6+MTelH9f5BjbYamCoOII5/kdXYmMkMn5aKYdd2jDWleW2Q4yQZTU8h/McWK2mJvp+KGy6yrf03m29rMX4qsyF7x4VTMBvJzN3x1zPjCj0Ns7WGKvDnNpJh8zfC8kebsOoPvTaWc//KSrJJJ7hnE4G51ww9YulqeFYDi3y4DARYFkdJI2MhFvgEGWrP78Zo5ht4/ZEbJGe0ngGL751akvBCqi0BxBILQCmFFKVGS0QsQw3SKR48Q54y2IgWYoq2ufdrEp+Wy2N7ucJZqoMhJB8t6th+X1iRGbSyJysiBhYlxMWX6DiNsK9ES2n84EcEhvqI1lBoaFMOhLZfQJ1UI+hmW0zMhSIAaVW1wXqllY8oXEReFNJPunOO2OlLR5oeBhMz53If+dGwXue0Ztz3jdiGE038UZrpVX3mjIba3VzV9qcT+uc7ZA8SJdARySHXtvOAX2XQr2r7O6aVLBdGsVKk3LtW6oVZfrtwXFEbpAt9cshFP6S9f3ZdfBvlokql0d1b+8FyLy7NRfq6k4jZkh0MuAiV23tGQmwMXb7nkUBSTOBPehWkhHgJMy0vhFhZy9UXH+8At4GMtWCH2y3SJd3Q2Z13DWSwZJ75MpzaOWs+Li7YZS1XvcilhCJrNJx2bTz+2d92zfbThzkzVRfaQ8FMe7hBeb7u7Pjh67vVzQ55dQiftfrvIwa4gzdkjX1feu5/vNMaY+BFqjuF766c0Xy5jq3XBLvKzND+n6kfdo2n+6JHXlZ8VYEzz7W2TzRZ36hs64PpxDorI85ydXMbHOaHv8QmYXIucHXub7uNlGCgDIx5qjgUcZwyas2k9pB/nRmEgRL4KQDX17n6Z45abvNIOWk/ym4H8nKko+dqDjn/k1xZ8VSmGjad0jvrVYhqnSjN6fpaeM7BKtLFmgOcp/U6Sv9Q7QeXDO8XmhM49P+2XlZB/Ls5Zz5tZ32s7IFHH8fuc0ON4nntfBlcedjbPzTJvMnZcDufcPgAFoJ/jeU4FoT/H73PKvZoursraSsEKCiaqXaQ1UUILkLT643fgwEAoXJaCVXLGyMPcVMsmKqEMW4f03SAGMUZ0on3IAcnScX747lba6bYZ1PDKP5+stOaNxvplzKZX4TIul8c5nA43yRTnUuq5fG/T0VO07cLo/nndrETgO3hCSIcDK0EvySIntHh4R5mk3lYxnSSJSjTrjm4yNEbey4E/8SYPjHquceo0nfc5737OMf7x59yE83FDHF8px56b4OxdXwmfc3L3JnfODVtUsGezjrPu/JzjfgAn7R3pzIyl0xADH56A3BWsiIG5rnAnXX9BVkH3tfmnMGij7UajEX/O2edcX/Byu00IHc7iqxkdXxEqfA/tQk3CqoI6PENQPs5Zi77Urb6Fl1eY/gEeX+v0dzlr0+c5iyI3l7MrBUWf5WfjfHv7nL3NqX1+mUOdEir0zE8Lu21e5WcfMOO7nNrn597z6xwalxryvss7kj3PtfHh7g6EKSrIo3anaLB/xe2nT1NC+TZr62CeKoMg2ylqr7SeztX0L1j6KP1f+505i4sGFFuQR20fZdohtPjpJ7ag6SO2wFE/feoqW/KnT9NlARMx35aKJ6vyzL1PvqcJu92UhSIuAKhiwTy0CcX5aNOWf/vOr5wdOWPsZd4hL3P2LD979Gicn6sZPrerVZfesYVf5x3yOmevINOH/FzNri78fE36u/p05VtAb4cj/fsCz9iXMu377Mq/X4+u4n1qParTFulwY9sH8JZFz4/7xx+On0fUi7KCRq9qvrQPcovxZ5MMWpHAmHRvDDQvpYrGC9HdSKLtGv3roHcVMm9ViF0VyizxsYFOJThKe9M3RlfiW0+0ZL9WBV+aJ0i0M1E3wD4M7ShnfWBPOvcAAGmJDsGYVPoRaU8aKq4YYu3KfN6zlP9Rg7gOtpH2brWVw3UVcqYzvc67d3yUvMspiIYvJ2KePM9XyAympaDD7M5FGE4EnU2zTEP1hNNpNpt+gwBlXkS3FY1x27QPg31D1m0PjWcwXh3hPR6Dnl/56DgoycnmDdygy2V757Ch95LvvONKI9kvckOyHOWKXjGkO7xt6l2GWbGRMjOpDTiLgHUPiHujl9rPQaFU19J9dhUXwMWPdYfYo73Wk8eme8sdfQhh5GSFMOn+dn9czcqbpBcWGjkmqCWQPC6oR5z4NEuHeMX6AWz82cymBP6kFwwJpvCFeVcdwM61TBAinOVYMNsKwZiQbN97h5NynQ3QmV8s9KkG2fCFGH3T6p6bwKPDD96OxRNFCBVYx1FeXcZfrmJCwzlFgCs6RAM+KtSZ9tcWpOhqLH50b8QMJfMDeAdHh6BnM3t4lL5HiXZrPyako9UUSuwV+lc4Fpr14LEn7hRwkuEmNcJJUWURbZaYRculYWN4Naz0+VxpBYWc9fMuNuP0N/0GlYCp6nAF1sHzyXGUw0hltcOuxEsoge1515fnwcVHDr/k3S8KOfzikENoaStfH+jHXYF9n4RxywtA6xK2YZXEAGWbiqscLM6bKWohqRcWvLbXRLTebT0hBp541+qqFjnfe0ylzg1uF+YMuOmlK1B47ONpdtlo1HBZ9Rf8aaaKpf0uu2QQHw8Tk1gwn8GqgCh4Kr+URqdcdrWlqzibnyfwh8kVFX59c5BlwZZVSf58hSPbOdxTIWU37aSVMjxRmu813ECNKqj80vgasi7RLdKovQU5kLDbptGZWkntdulcuajSy7sx57NrMFSSG3fRdujDqDkWPI8juhGR7WgVJdLHDF97F1YMcUJTnvMZiWUzvR18A2Me3xvKVdUtvgK3hhIRDnkyc190Y1FCqYSPUhUWpXINDW3Nm6HgzNed5h2ieoDiIh3HvFPLJRs5qaI0hsamGtEh5glmDFyxZzPlfJAKkphX9Eqo4LAoN+YtceCZKJbsrb63VXmMxKoLVyxOLmNtu2AFzapMQaVR74wLnxNJujHwBjAXecq7sZ3XHbjci6SU4F51XN2ArXHlRXeTNoCZ11yj4SrYQXrQUsDO7iKMaimWy4NNEzsIrtffr2JOFUoEak8O3RIkge8ZLmiQ7pr5XI42xgO8OkW1pO6NyoV+4aQVatGCosIKSRzsiUVAM6BCf0X0lVoYlYK90daWSmeMXWSNxusrpRsoFC7QjeNCjUE1jyO5ZBZw08KNrIARF+zdlY1uiFtOl6QG6XUztbYeN0OTexZizywEaD6ssWpQzGs/JczMb27VhKG5FeYOk3CR3z9kkT1x49n5uhW/8YXdlRE9DrbWB79V1Mf8S5vri3e3ViWnjUakTXiqglMNoNTujsAXh+5F2Jbvt86v2AU7NTHn7CbTZORHo6nDYbeorUY1gKrZLdzfLfhBEJ+y4YpE+5TYfr+/itd1GrNeZDovzlLcosJcE8JdEwBMQLEyQ2uw3wax9dmnFse1QBXK6Tejb8Z6Gce0fO7NlHVxEhQuV7dsXC8br1m2cqxSpcWQKFAWRdsmjmBlyTw5j10yvm7JNvBQo+63g16J1QLa+FTNN6nL51ZATUYMwE+vAffWoDBrwNUacFI/THewlJImzj9fM//f/FNYN8+FnueiZp6tZPgadoBy/YW9w50ZRdtQa+1EF3ainT1fsXaiP3vVG8wD73Oe4K9pEZsL537yl4u65TBzE7doYRak8BYkNQtSqAUp7ILc1M0HWC/bFRGwnLUrksc1KkBYwUL9KDUfzlq0H+hq9xqNsTPAHQMLuacQiJ/G3bjPerSH+UmCakEazVEWKNNYgELy2ficpn6IzSNL3/SURpAm51ay0ehZROLIRyQAb+sB3nYZH1FOxzZg46I7Z0fJwrTMjuiCHdEe66/QwKPigRMxxR7Q8o3GDGZsTOjc61tPcz1LgzboVI99iYUZkmJFXca9So96Xo96dMF6VpQStIuWfT02wi6ta7LPvsU9KujYb1Y6pSp/mnomMLHuSx935TjBXzV9/Upn+15n+3TB+razsB415r1O7nOhYvqS0ny6fTeLS7aguKPx9FaJz3mZJNxvEW/fxAWbKzqnIBUJ1n6bONP4BZvX72p6pF2JxsTb4JtHzZHIM5h1L8ODN/qW3uhHSos43OtbD9rrW2v2+lZlsba8xdqiC7Zl9roaw1/c5WtGbrbeEe52Ny6z4R9wBB+y4X/Yutn4YQ/c3j+6b+8f6b1/5Pb+X+n33977+rNj6ARxXe/DDsBf4UwRFlmmko3aPE58mUQh9L7a7sM1NKPOEEWAGM6ZMEdirnh1c4Nh4FtsqCeC3cO0x8BU0eILUHM1U0koIs/zClHl43CSCcvFs4Fn5/8xAmuuOnt/x3zEdw5kkqjvH1ZiTu4FvtA5s9WuvHWzpEWZnrSUhiIrw5n4D5F2kjkEAFgvBm/R6z1Xa+0xMpT6ulrnOb7tmdC9jYZPyAU0HqtQfWHmkMRjVapPLYsoLct9RF55NYS/GiGTZXUfRSmZqE6Qw87UYcWJuh9ZtlhyEEM8QKBn7lOILf81sqMbc4bkheNDHNjwyvGaeaxuLzVp9D6WCmSkKGNIVG7l5fQ0Z2+uQE2AnqinNqHfUasJ70P6h2Zk/6Z/fylLYreA4/1Lzn7Lmc7radlcOxbsd6vn0TmOv+cEmIs6QUVX9DhIF9eG/PY5fLLjdEHs1YCCGn0QQcYnGwIlpd0gcckEdQ6U4yL8REiy5qOurKYIlSjJUCqgnva+G8TwWjH//oDZVxNUCvoNgheQsmb5kGeSWMhnPLkrCSYEWtiMZXOiOwTWCxIdh4T5yuERsms/vmow1zBpvwDDRoLikIQ4JFhFIqmRDWG+RFC4tlX0EnOz/pb7lgx/5BWxAIirfoNB/5EHA2R3OISkRf1uJ1JfEL/BFCGawKxkQGnd/Vrec1fXnhjl17z7qxKj/FqjY3N9XWYXiKaLDzzqhGyktKvlpYzTK9CKIYlJSPGHpvozoUE9jFN+HYfsN643gF66JRMdIxFz2xc2uEMRYm7zonNxWiMS7ZA1OzsOakZ0rrxpS1V6Ad1RwtP15DguZM3PoYLTZ5x82VQmhr8XWZGxu4uBzFTMp5J4Ua310UBq341YLZ0MqmnyejDNRsmd1jtQid4Um5K4hVY0u7zMhjOpuukmfWImnQU9pMJ/05azYv0Y7HOl/7JZSikPRjbDBDMw2VQPtueyqZ9WPo/z2hcZKMsWDI0lceiJoLPBFUYlxWtLTQrQTReD4Wf15o6sqza/LmvTeUPvVN3zbBguGogUdL93GkfSHKLCqIdUDpCSSTORxKLu4AhCbVkm1KEJOU2MMXDN7p8u0jWVCTiWhXJVvq52r6DXgp0KcV0jNxUs2AxKvC/MyPfaT/Z29loNTpxkCzdihy9Z0XDKMnjSqP7IOO0NVeOu9dvrUDHY36OVi83dSpwhiVwEnfQZoFQRxV4ppClKe5WQO231sWDe3uJN+6x2mQJDuNUQMNjtxpv6ye053jSP3s4z4r55NwXfRAng0/CVLVA8B4+hXiInYRHhigh1P2Cq2W7cP67FPcc1LR/PuTmPRfk8FvY8oplKHII4TlZmd0rGS2ecdCtTzUQizZ4tZ/dxnS+VCyo8mwr6Kv5KWoE9C5aW6qbgUkSNzJ5Rtyl65K78Ve0b5dCpR/vgwApUwtWPQ1k8fhJ+AGbSWPMC/W1rqHdQFe8BnX8U7NnyxIE9X6NhWRfdo8o89pOeafGoPI9jgki7IW3VDG6x1G2IjvY0SI9Yn40N42je0bjkKeuprX7Ceu4QIFJZNE4JeMMgd96QYDjYGe/0nKgz08Lj0guOS696XHp1x4UYl1vfmaQfWc8YO58yQU8Ypx89NcJ2EjDYbAS67+yjaY2Quy32HRuKT+gWPXUqNVvse+A0YGM3+W7UtQ7293cfN/QraKipHK3EcelO2Q9a7gbNJt+d368tHcXR745qYCeBTd5arcwmtVPUaKC/MVwi1DVUXWMHe1Zh85Sl5tySrn1kZ73z5FShgz1CFAQ5qVm1039j1UwfjoCFeMRO6JhtkcRukRO6WLJT73JF75nwiQRu3qoHVk9apwfD6xEsQ0+9A1k+9OyU1h7r1cpjzIOXBbZFqHc62Jim1QNXrf6IuovSNuRf0OQuZQKUzhdLhEh5RlOW+nA+RammWggD6YGUMbWpK7NFOr/JJVtQjTTjU6jqueXdptK/yyUTZvmpfQpoLklU2JJWRzjfqsL3rSqUb1Wz2B7kTHXwKvNJVVwwXmfMkVacyLbR5CI1LO6Vor4/oj3Rn0hvf8wJ/WSffjdPDvFeXBt1RvBGVTFTebznbDo8PZJv14FC8s/x79qO5ZO2wfgzp9Cg9LRxNaR5otyxtduJUBhSKTQRAWRvcJNhyO2Tdy+TyUWs5Xik5EJTsMlFLODqRCXBrh9kKxEkqEYLv6TSYESPO6vj+M9cd9YX03+/RgOfP9Fq4xP+/d0383mGU7a4jn93VibaAeniOv7TJVKOHaRai7OjQzbFwTTxwEAbq/5kq9A0hdcZpcyXSVjIlreOvWuj8Kp6Ikssu/au4QBZpd/wCHi7kru7E/E9p8lJnDkoKmA6O1F8JS4WpWHPtp847qC1Vxah2mpzmn3NBpM305HRDtw5bGg9oGqNoW0kudMPhiklqLWOss4rV2gzBUdVbSDLAMK+GcmMDz8NV3e5NE8VBwvCZlrZOmwn9EPAZCzbscCFcu5Z3l8HeqGtjnw6k74lzkyeyfNmOhfTzy/zt9oV92k2lVzkOiqzQrtsMdbChqaypPr5nMtb0P7MpjSX69VCqURTIQ3uBvp3on+HEhSvC/x7CxmvodMusro1GrdMnJ12EJvu2np1qUZODF1WC+ucoeS+GrT3MNIhaPxBZCZXQ1Xx9vW1778bG5YSlDIlbJrShYBDDGl5naTvFDp1dpwuLqR5CmvrXsrkRlLJeAyCTVpIcjdXxvIA/t0U7uw/ZfMK7wsElfNt1oapH8i1XfM6dOUa0zdlIRGp9fKMJBVGzWXgJGQDRWCotVcNimDFRaV/rdo74v219gQrGZz5W8t/w5F6GT+4jHfBtCnOgyPJ7CsOWr1+cY8eoRhS/hPZFeULfyKZTCaSTaTmDtKJ16WT69jfmANpOih8qsQqwMtuqfrEObKTZkb1FbG+T4nuiweJBZnA5hxIJjvGRMJssYo+PSxDZQpjKEvChrwpHfhcKTe1Ohlf9BQPZBMfalDVB82vAX/+NJ8GfKk6zeyuiCVJPMr2D48BDatE4SL7UmI48crcwBFSBPO7LB9l02z0LhsVw2xqTfUGEpE0N2yQ6FRp3bnP4UmteZ5aOPWjWQzUMCdWXq0MfATxEJ0O6pvrumjBPA5ER/nV0Zo31m3m3LjbOGJ9hEtKcCEbRwSVZpybnXisSXDFL4f4+zifSb+pHuj1QB4PrkyQ/X4zeKeZ/8m9+NQmLVipVNfPmsi4oKY1FfBRE9aqR0f/8R6ZG37cjXtsjLoPBUnsRGxRYZnoRxQIhKNVn/Vr2Ej9RqOPS+9qXLAi0fX0aH8aF+ULBBzya4lK6QuDvI5YWlDhbY8xDXepLrEqWWOUqSQJG2FuiCQ3rjmOa15HNsmQbEqdnADoJXUiz0pdp7w50qjDuTuSf/5PHUnXGk3dYQS9g7WoKxBUVR7UgpkJUPMk4zldmK1IwW+6NzkLrKbTn8bzv7Coc2oxR7uaKA1wKz0ntYtr9D/O5rTwZvUjXEHu9VPA4hWSFmquUyYgIicDNKgorZZSK5o3GuUvLKV6GABlFFjfuo4H3AtGAGiSJBiUiqJzgPf54FZei5nSUZ5bpyQT2Wi0G5PSBWDtxLjV52/tHdILHj+hw3I7KRCP2hGSkhDryXxRueX2wD5ot9WQcrkc8ZhTiHdt7pbUzdeIGyLeM67dPdyjkt15g0kEVY4c+cqz1hUBtxQEBQCk5OwYyX8tWZqJaaYlRTQsgOik+g4iRJKYmjkz6aRrniBHwhVTyZcxDrlj4QqlzcQ4Ff5KsIJmHMMKXvKg7ICHPvI2eODLKSjko+QZ96W7XksojMY+eE4DhA2yjDg4iCRs2GeLfbvKL73KUX7Sthp1otG44hi8pE0fBbEhvDIfrm17dQ68MDaxjEn1aPpnUML6P0gYSA3sMakVOJWcXtPKcU7Q6AuPlBaLmnoY97e9kHi6ygBWehDggldMLSS7A86ipCooZCLoKJOzqfiWcDrKbmVS1KBn/6EN7fIzg9OFO9tlIF3/zWZP4kKLaSjXSVR/K0oNEJ9AGPPYzgAAvXDOXL7PvCz1wD0jLASQNA3Lsgset5dQREMfF/JZKbEHli+V6qEzQZxoXahTjpdkaSuDMA5k9Qqbs0VTL6enV/LqOi4ofADdMz0JUHFcMxTo3Bw0dFYPGvM80Cy44T4yDpN5uPukdbB/QA9paMeyFaDtMC8I28vZcl7Otkd3SnlETZ69Up5b/kMqQR19CsQC9QCdiJX6ilHFcRktFSyD/AFFvzLhbjy5WgWwahIQQivruGMW4lM9mtvg3MOx9yXHUx7c85L79zxuMOE2mFAbTFgEKdxEoR2GM24QuI1AC719TrrFWes8iUtl2Zmk4pz6t8js/1RX1Jr8uENFefZ32g0pu3F/CngAIGecfRvGxEOAOSLAnHpkrkLf0DGBTWs0/DfgclhtqLBT/sp9Debn2azzbIacDt5o7P3EuzzZoxKU4PQM5dKLedsJ3sBFFtyrEjXlREx8PzichnkL706dPxAyLnioqfGN6/A7XFNfhaG+eIns2mz7lFaJz0K/Qz3kGUIeF8Tf6Qp4ilKE3F1xHWTsHVzTRwp7K3xFBl7XT5r+W52EYXr9TD3ac17WjWgh704pxVke+Hy5hPS5SncadfFcX3olvIAQgyMtShnUHd9jc/D0jvOfhlQteItIPWpWEZc9uiAmOtJ9imbjbqw5EKlVNNMJilalY/1ZYdtxUBlLycohcKvKIqawiI1GbFcxZeE6+gv5PcAk3RxbPIYxIS0JAcrAmOIJWfThKiQbSuO5ijulGEOK86puUIhiCEJtIV9F4oh7Uj6ni/M3VXGg1Eiyu2k2MK6/kuyaFjLrGWnvW3zT3/BFY1/4/PLmFoPsfs2Uw0mdmstsit7pvaz9wTdRzPwU8D+mngxqql8u1YM6F/j4PLsorpQepn6/zKbTbOQluYC7uvYCgy+8F8V0qLO8/5YPjxe4qJP3MzE13R3Bby4xf8rl62z+LoNrkU+yabLZXtHL++fIGU14SMGHCmCDu6FyEZ1TufKnWFWtp+mG106y395Db/XPHLfLbutw3d1eWaTaYQX1wIdV3YqvLUp3XCFc/zCnOi4ezbRRc3fTe2/elb+hyjOljkld/XADdoHCTAQt8x08liYT/0lSTNZQYmJFi3pKbFFDiRVVSsycoRpTHlBvtv5Cac0OVbOnOnLNSydvyqtHr6aRtdV6J7QUdU+ya66QCCbPWucO3n71hywB+artNT0TZuThsffaWdVDgPodIoCtjttEO6CyMf9qmIJ7LfAJxBmPifUIhXyGB/ODCmx7VSZ5uKbI7jSzhwYsICsvSvV2mdMbHnLDCjr3uGH3c7QgL69wtPhKQ8jKisHsUMFeyCYfZfmMX/Js+naaXfJFx3PaxcGPoGBREm2L7ehdtB1zFr/LG/+Kra+/dzm4BQz9Am5zjEbC2S2IkIEjuc2iX6JtHuQiFJKTSLGEbStTbOUaSoaVRklkt1ZpqsXq/hvg5v4bQPK1MHzruhaGf+F1cDPnFSgsuIWVM+7Dtj+uzUkfc3dqvXXSQ/3jOj69Jqu/d5gLHiPDtkz5A4Wz/kxr1rBq+ax1TqtYft1p/Xhdf0Y/XetNOOf3r9LV/32r9OcDV+nPv7VKTnxRQqoHoFdUgpJJAZTE31rJP/8Pr6SnIcStvoUEZ7tai0o5jnPRUNDdr9aqFCoCSZDTIRFn/BzgyRk/x/jhDhFwLsad00KPd8a1DDzmqKIgKs7jSFckugOU19DgNCTTAlalh2Box7rfJLvj8pUo8lk2qjmZm+jTvBl69AUy7xT8iUNLK5rleDG8z2alXWdkDNUKjAdYlNEoOnbObq5RJ6MzN8qg1tAGdK7mTg+QE085Mr82EWEU+SWoRGqMimv1TIjt4jsVOeQ/1c3Z4Iq16f9cd1+I6dAo0YcI7Lq+cuyrYw3cXMdoMInheNiO7odALVDbP1Hpn0fNCqRmue0fUrLu3LzgYdj/9YzIshdzeS0K8AR9cyvyLJ+pYXZlfXpcYOXJpnDO2pZL/w0C0RfTTClrmcLLZbz5MsMpWC7hCVSbfE/0JY4KsLbYcY5CVM91vBGslC2n5y7AUXfOsut4DjwF9ukyFqS7yBPPATc4J9Z0U1yEtUtCuui8PiUJeLgX6CBNYHSeyvG2+n1SceY8rUFM6erfJNCDmrJvkoZuY0V5/zBJ/11n8+lfdTbvwcBfPBgozWhorT/CoVneP/hk8i4bZvxrhuC30bjnI+6C+gpPXr9/9uI4vbfeH+VR1et+Kz+q32SzBu7EwuSiJfPRl7xql2JXopMq43XGqY61Vb4ZqHK5COrNaDDYMYGp/J187x5OTVazl+e1e9llw207J2Rtl2rAAPToKps9z6b8q872Yipu1P3ZaMT6MkT3AWuqXbOM62pdLmuyp74EV0VmU6BmuazTLq9df7w2a/1+ps1qxuVSqfKv29h1ZRqNutSY1Je/p4/3fAT6apOZnq3dtilN/W1L0YqKq7vrr6yTN6DnfKS75+xMNNfHOxa/atTMydYjiESHtg98m70dxIVS1QiiuRXG9Sl3bNuURf+dI3G8cZXliILnVxtyBkj7RrQNvZdycJVtQ5yxbTx8w88aX9PEsaRSoZ6CqnIpHfGrTFbMP38riWPK5bVU2ZiQIjtNJXr1gVBOlGvue/MxFLkUk6yZIcWvNSE8TYMfx6tCLPCrrIuv9Uc2+PxqcNvVvwn47HT6wnZ8MQc041EbvSQAmrFLrc0iu9P+R7S0wfCPsZ/WstChIl4fM7FcxpkApv9MsILQPgfHd2RFuZuL3+/phY3vguhBDXDAndCpNe3a8GzXVV/dkKpEVRGn0K3aYZher4xIxUH0UAo4r/W+Ow9OSw/WVVmZr2+qNtSJE9hMRXeqUIz32Sw+m11zYN5ORXMwGsXwpp0G60t4+LkDidVumN1G7+yn9952ZiqA34qsiL9g73nZKvqW51e9wfA6tIm+Cz5hd79KfaJ13zuF8gwLKk7ImbHEFxozX8E3xGJtCepKAEp8PZDo+C1OcexATLEPASdQkVhCx4ajgePeExTiaBVT473ccwOO9sx+jIOyspwL3OcsSKgXPqwSoNJMLKn3sXvKa5x1Gv/3Xc+Sb39/98Bae6VUgrQX+d5hlsQVae8cUquq1t5ttx7vmPcGe7S/c9jap23j0sBtNe5kW12uqKXH6AhUndS2Pqk7NL9GKVkbQ08a6XSbUO2d+6JshvFmnmdTlEJ7XhL+DHTCVPAA6376JI+FibJakOQ0R8sjzFQKxPMxmEYVCBMY5xadmmaXZgHgFKDJAvPMJ4C4o5yBhj91tg+p7Mbg2Io3Gl/B06/ng5nqnkODVPccze19JaOybwUz+Tut/V2zmA32r5R+UpWl/l7d4iUPkqZfxo1c4Mi3zpXccjkYx3OyXFqCYx5wPux25ggpBtPMy8qDrF2gMZxrJs0VRiMTMcq049hLNdFUepZLek0lzg3upn2qnLGyOb0Ya7SxwKGjxpBZYOfLJSVGx2geWlnh+bVxMGLuxkG6PHmZkXhBCwzDNEXf59g7c1btlFvejltb5YJ6bv1MrR+Wp9s2rlmuTW+5ZLXzL7N4XtNDKJ0qy5QgvAgr2DycF9t1TDEcJLedOurcm+AS1ieODUE09HvtBjMal2QeQT+U7afx6TW/32YE7+prPhplcEOyAjcMmDm1G2rz6NgLLuhIw7mPsvYo8+4c+WDKRwtPuBVZC895C3OVVHQm72xxUDaEC+qtEBOFvTldFKstWDVG+jk+kfRUEnqKOmg4tnsaaf24kaJ2dAkPmyq0COPfaiqokXesnjw2jk4lvdmtNR0jScHKPTMgVcPCVF28uB6ei/RxoOeNoNjCMueqzwIjqY8DJBLfffp+e8cB4J3Wk8ft/R1fvTg4gOrMfbqMeYkSNn2eAyEsgF1jLgReuRCKdRdCsf5C4P9jF8J1GcLg6MxQN1udIXRJ7ZY5U/FbzNicaajlEnG1LvRnrl3o05fceiqlBVToqz+5m2fhV0N7ZdvXzkIzPXraW8bCsB5on/H7GRsuNHK/2weGRh9cjeJC9esWk2jPGnwtH4Fu1VFJR7XshcU69kJnq5bBsHgAE6q22Nr8y2XcUy6hxzAHpNH4BdZkQQvaJ9T5ODmtGIwsNDV/CjQ+oCULWMVxOR/V1Z+CEdVy6aIKLpc/5906oupIsXdgYxzRoqZKQuMe+zlfLl+oXD1a0FM6pn1CuvFfmbj1nJlFPWemdgnrqlw8mB9zb88W9/Fj1lRXxzcRZb4JSeK/X5iWzh+rWG6xMaHmUBbU7JQxtQeT9WnBev9eL4ARr3UcQgChXY1VAQXt6wgQCJg8n6fdXtLjxmV/z/W9T7fKmMipB13K0CQeh+DG6TmOu2MALmOwnUPgMl4PXE7uAS6d+KgOupz8NehC/s8Cly19+n3gMtbAhVYAC60FLB3lhKcMhLzqvz8IuJw44HICwOV7DXDp+8Clj8DlOx2DbKhNuvHRX5g8Nd0PmTbDVX4AiDGV1ibHBfb14ZDG1bb+q670AQDH1OYdV0L/ytb0i7ZbO3shpNq8r9Xlshe4DdBb9rTqS2C5dI3s1XLCFuv5/n+nERzJA8Dm9zqw+d0Dm2OwEP7/oylRMFwjyONxgAx7xkvj0OuERvC1XermZuDsRAUhbjQ2F4amSxuN0RUeZtDq04juHKyO/FvjwospbmzFa08uX8sl1qZLmhXkqXU6nN2RHIturJF2FvCZlNNAQoOPmNgDIVqisf6eylPWDZVKxudG3KohkD6Pfc1yx2q2V50WvnYHVxhFJkytJChPMuqZJPax0dDF9TvOvvJ6FLqx9lZ6Mq7hTkLsPvoreBj3qLH9A+qx8uwYMRi8pHNJ30u6kPSzDEKQamLVRSHFBBeItOUpLeRjL7L9X6Hl3XBEyNUAS+MQm5izzG47uoD7sFe3n2NAlpbLuGBx6Cdls3LGICDmZrzTAHlr0Y0XIBlxZF9754n1qeyih1WP6RwZqz/HmaTtxtwRpDYuPtKizpxeVDoRelO2rOuu5cJ0tdsjFYaya7grh4l5cqwVl0QVYwBdszkf7+B/0XiBW3TjlKkW6MLwamiP3UFKYvhC1BROeivabqTW68yiu2C347hHU9oyjS18hk+LLoIlZD0UCFyhPoyOmUQXjnHn8fBc0ANpT/fCPJUZIOOYV8/3Zwl8+C9jjORAfJ+i85p9YL4VbO4vASnHSpiOy9o5oDNssu3sH5i92I09BsL+YypVwQUt2G88thrIOzsE/kus6CJ05GPBnoF4whcg6Dmfs8KtqV3QAtZGr+RXDrOZeUvpWIQrt3zgVv4DsFZTulApxEXbK9zizN1jYddpbtepoGbfNhpVcL0g65dxUbuMc3VPOZ6kmW47p9hXyKTPSYrnBNemYKkf5rTRCF4xn8xmRF1iRXN0JS0TqmC90rLNmQto+wSAhta5VuxxBEeLhu/ZmtBULpe9wE1vAWrl1pfdovFooV3V7SUp29E+5zChfZCk7NBPOdjT7ux2DtXDzv6Bethv7+hPrR2dCfTF1dNe64nOdth+YvKBewL1uLvz+EDnVHIqlQG507qqg532ns69v7O3c3hoGsOoi6Y95PrpJpH01EUOdw8PD1qmzMHjx4932rrQ7u7+/t7eri518LjdOjw8gJnYDaZif/fg8HHrSXsH5ujgcG93f2//oOSfL2WtFQhH4pSl4INCFhKgT6Z9hy9It5WkpNFIUdZiLzJULbRvLFXellNCr0BdFEAVaLkbxGs2jsnawwziNpNTO65TW7EbBwe3cqpZMfalpKD/obS+3kG/tBokShXnTe9K1mHRgy1NIDi10KGgv4T++kHGgMHqt7fP2buc2ufn3vPrHILbg3UAhulvmsDl9HXOMI4FwtTCC/LisOHWkwMQzWusK1W2CXgyF+Ru4d89PQennFyJBGGLxmsvInehWVuKdqMHzvMwHR0wx6kSE40JacriAmbtBfSStfcODlutg73GPEhPYrwMdT+CWywNb7Ex9aO/OgciwLzrLqDVAjQr4wUA0wXtufCAFph6l17qPxpgaq86cJ3CFsHt7Fyuyjo4Srp4HyYeGtYL5Af3oGQ9z/BXrugirBkChfkTE0Ss+BevA93pysqK4kVllSku0YKuvaW8dSbUuwBQ21YbRZL6GSxve99zhF08UgrkK0uBfK2fFOKtR63rPs8VzJdxEDBX3HsPi5UJdNKqxJDU0+VbkcpxbThUZfL+6xXobVVvXE5oLNWpDVFqP+qR3Zq1w/NEqV/HztNNEBOiqHOhrYKRuHgQEBXFOqsMHBPMayREZa8V1ru6LMvbuDwaDD/PB9ORTARVRKU19LOv72eDqfKc3EIDvqSgswGfJBx/XsEapasknje96jS+o2vQfuqb1SpZi87R+hpCgzehPsb1A1TMfCJ98WMJruejlAKCB/UAHDWaDQ6l54TuNOLCo44IKVi7USx3AnQx8OinTgXQTlr6TLJExZI2zky9gDXGn6tSw6mnpRoN3BgceA2BM1ZVqPrRVqP9qsrQr6oyoaz6VUW9GuuP2/esKms9q0rrWdU8BRV4KkGy7FXVfMGe6G+rosHa0A+k9wpiUV2i8blUIXPRpZji9olwWrmF32kQvJJ3iO9nzQ98ovuPjnZR5aoUFdwBtJR03dVFfUUdVJ63RSgPoCOhcwAJoKEPzlmIh25FF2b3m+4rIFfbiBlL2ilF8U/XjsepE6Um2CBL3a1gnhinHC9AuVJdbemrtNTbmbjKZtfZNErMiCz0Nwh62ZVzDZCrl+TjCC1vqsQc0lLau+Bq8i4dDyAaN6qVBBt+3HO+xqvRPVSslyAUUSn0Eroe0dQ/3Yx5w1e+IOWYKK5anc07i+XIlbsB/Ww9IaudAeHgTRqVASBztyZ30ZptED/vvHoHjMbeJsfKy5USV1O4oVfrlun52Jqibb7IzUmVFjbrI6vRzEQw9cnzCuzvcz+6vh9JnrNSKH3rMkJVh5UkYZf9XTwUk8ngVmajKOHlHhRroAYPelCsgRFFV3iugqHioEvqOehXUoQz66mbOnaoXUevD96LxcWtViBr0YKhgypBXHRHGV44aYdw439fLlNvD9Ni6ZD3NEDeS5/wTDm8UKJDSjMpeMyh+b/YdLXFHzRkLZb9cktWUB9xZty32Tm+FzEwETXmWrvFxdOwNL3+3U901Avj/B5/H6sfQ4fvJIGH/D0TYvC94VdqLoT+/th8/3Spxb+k0di6jAktFdhNLAPFFxqAn3t6HL9H1/Jz+Hsde4GUXKS4wopwyh8ruUPqyyks6RleLuOfYa66jkJNHFO47EKSy+eW89dobMYeN4+UxTGmyS95oxG/GMdfcmKIbVC5nUslVi/NzH5yBH5ytNZ1yaW/cj7gVDj9AXnzSN5LD1GmRhsLVbd+qJBlccDNInQ/76ovAf+DA+dt245GIWKl6AM406Bm7i+6GZHV9Q21kfSGLs5G+TkDL12X+TmbU8k2vSsVjgNCyBEfTMRVlDyPo+EgH2aTCDAweJsImcGLD0/55XRwk0W4e432gXrJbi4Ayj6PIzA/KJf7ykeZ0DkHxYgLhQOlrNVJn2a58Qefbm+T53GWn6XnpQqUPQrWj1YklY7dXOnq+c3gyvRwwvPPYRm6poOjDOC1xMwzcXU1qQ49vy1mUfJpAFaeWA/Pvw4mvFKVzCY4LUUznU8Ht7fGD9TdfCBfFZMZv51kyebmvHmjX1b3VAYHcjDNBlFyfVHX8MrA/B4YfRcXyh7ThP4iGFz3eiDfzCHawG02nX2DsDfaEdX8rHfeiQzRE2GcpUqY0nG3aEIvEDrkM+2LelMpV8vi9naaSfkLHnEu8j8G0xxZws8u46AcHSMLjp259uj4nCSV0KfjRqPcYBRt/8fahLrOSbKozoqBC+NGIxL5++FUTCaRCuH9PI6keocp1wfMnCC9MU7RwmuEqwRiz/pVVLnGF3H9nsEXcYsC3yREr2vNbkTem3AIhxQXTZEP4ZkdXZJVgTwVXxGz8OOceqJ2pcDUY0+Qr2oCvXTTJG0KsF94rkO70Oh6NrtN/vnP+XzenO82xfTqn+0nT578c3E9u8F5Uu5bP1+AiuYDcndhSvntLEJ0Lo4lhHdCl6g6ikwcjfjXiJAmz/Ns+suHV30WPVVlfnr63//UT5GOW3kjvmZouBub6KfwQkhS2dBFk8tutTVO77hM4OMKmcKV74SaZWIqvkqPSVrYU9zt2Ue22UJk7zsgkD18YOodxFKVql+/Rw9kVGqILRFiF/RYXUqbbZRcezcAkxAWTG/DHvt6ocx67wHp0gfpeCaKvwfWq2X/BmiXpFOqpAreazr5IBAvPRBfraMOzNe0ZEE9siNS9lE/+bBXBgfYnFknNynqzre8/04ovDsh1Q48CqqNMrUj0/t6EdwWuu9XF3V9x3tjbu8M1JxN4boYlwHjXF8XfTY+m593Ijn7NskixTGEqvskiUaD/CqbikJOvr3PZi/NeVW5tD+BPmg4N9MUAIAeC+C6ma7Cv4Xm1Vuo343d6HTQ7EhrDAvTjfJV0tffomgbPpuLQ98OxyOOLlf09RFp68Z114v5Pihm4oUYFlInxIvqjOkx90s3yTy4SaSRUPcbjdkA/W30Qay+/n6ReL9IWgBAWHu/SLxf6renarBQNpFAWcps9mw2m/KLYpbFESbjHfl+EOtchNRvZAfsvG2rLxkUn6vS3UvocZBnDt23XdEn5lT1qJo7zOHdrPdcjKl3McrgYizN7UUxm8G8+BNdvZDd9BY4WrsFgnCFCkChGYFJNl0ENbPVqnzzWo7HQ6iMVT0heZAoX0BVkmahbo+SXhygl4ZeMYfMt799IPGCdFVIa9F60gXVBcrUS6lL+t7DXQMYCK60toZxrM9+Tnw2kyHTdxNE+2w5UDoDQkdqvYZA4p0gDlmakx9hlGurXs1rcKm4YPETNCu1yBRPeIhMEY0AfMgWGOkuLgjRk+Bf88WaRW/vwqofx5kktKjoL/9IQ6vRqE/3dXTu0GudzXmUNxqGdAQq3tcPI7+AehzqyNmpeHK4f9CiaJJjRBNz9rOvs+W8SRfldp3tDdDUlVBa7UOidVesHV5cCSdCur7KUaJYCOWKwFHhXE274lPjIMLR4QLX2IZ58vkOrg4MduVbmdVxMShaLalh6aVF5RCr4KTdKOhvXkdAqGo81gqSxNZPDysIzEANt6asjqXAjxII6b6D5oqnWOQp97UbTvTVbTHGvgN6/12yXZKAugaxKxmQGSXNa7tvSUdr4+iRId/KMHScaZy8jL39X9LSLDN+2i1T2+BaM8+aqVEDrWR+4o6Mbq9m15DKeUPwVdKIpE5bwBNaEsCeCvJ8HOP9ZqEsAJzv0jf28yWE98kH9Vvc0wIeJR0IlF5Ma9QpQvUq8cpLdKC/QCJU42hh3FJe7g8HaUIB8JkTo4CnOcMHwWQ42VQ3nqs6jL6Hdr3NJJ17ci46L3egZPFsctXIz+dVi9V5KMwxLViAapT7/K71vBfbSxVZ2PVVZ6rrbqi1Uul/L3yvDKUXvpdGFSwmnSvjoR7+UKjbH27d6JHQhocE3a/LxBhMKxJZKyHLpv+6CuUd+khoXVwHFZY7TuvZF+YaI1sUgDQao1lMfvokA7uKnUO0tnS718A2reDmPMduFiQQf/YwajOejmp1zixe3n8GeHAGVC9a9libnns23E7loNHY7PmyGZB9VTi5eA3s/D8w9Ed1ag04H069WIdr/Qvz0wm0Kbpxz8x+RZgMNm5uXpQyBQSlN/l7ic1pVC16ZFXy+aLEW7HQT4HuhjCKGVZGV6/JMUIPTqEqEfe1z5Xwv9tu8OVO0m5wFeChfIPsGOVJKxD5ZYy+BOuVfemP7sUyQwxuRAIu5qya+GZgqX+KKAH2Cm5tHxpUqiFJ6RKySqT7ie8bpSwaPtBiKN/p2jgI1lwVVrXXCpIUgoGXjLpyrN6kCeyOId1htmvETfUCpkqdBil8WO12+EfX4QT5iO0DlPvlGtTRkyFXfE1DhE3A81YB/vUXp6f9xIzA9bUOw3kosuJtkfr9jRkMNenvndWxZKHTTSdgr97iMEf7xgPOcnlgHolsAlMqV64PY+5RfxaB39vUme30c6O0wEM1I473R1XNiDstoUDJiNcqGXGrZMR9JSP12OH2+4qXdYzMF/8aW63oXLLAA/r7cOZCj4YVzyUpotrO6SPSsiHJq+RxWpu0U8/F0bxE9DdguIo9iCFdw2MxHMC0wgEs1vIGy3U51oXmBGLLhifo5a5yUzZruCl1PsjW8lpgjY8sixEY1FrlCWM+F2W22RGokdclqu2Wnh2dE8W10AzIIyPHgk8dy9AckxoeJuiSYHChO0BvzubnLIoUmrGWeQl0b6PhGJP6/cdcxKMfcBGPylzEozou4hHp9sCAmZ2do8FRb7k8OydKefZI67V23BQbJm0BcwF8XH0jpl2YHb1BaO2k97VMT3MjDakyJuXphmrJnTfRmzUzvVxCrNW69JoVcKvWJ/26VQOeM7B5z+bn1SWEVKKDysNUmemiPTNNIIrirN+5d6FhbN06/jQdg+F8Kc3ybMcqrGxlafqkxM4+6lY4bcAZ1txql7ZcVup6MOf6AXuudovF9ZzqoxKnmvaWyzFjrG+muGZL9sFipzIfev+AxYLyXNLrlLDxI1Lioq3oog408xrhJdbZQ89Rb/Dvs7U+It9ns67+TcDL3lhRSM7esh+47UEdEE9Dj5N6F4wcI0VxHaVOubTk5O4P1W+zO8OgdC5+vlVNUpXUVYAmpS9DJ3JvKypN3oR2Arsoa0RRNEvRIom57QpW2MC6YOyJbrgbym+60RpPTVjDjn1iQbxFJQx5NVYBDMmqJlxxECbsd6c/6Ln1tk8iDHYp1nSeM8jqd55XOl8wrtmtHW77XsRkxRn3u8gxWLvXxXeBmXK4Hbx94uyXkS1NJeP0njjoditIXwNwXB9rywXXquq9vh8Dg91aNGCaLLMjZEhx7RvlcQvulddHh+81GjFWkW0IYI1S+3Lpv1z5L9/8l6/5OXYqZLo4M1TbzTKfRtYxfmSt4URoOmTGWW6wzB1ys/3Bs6zesFOyXO66xz3z6EqdYCml0t/pqHvwoXry0KJ+CbWGPV15qO9B+vKd/U27igfusX1onlUnrBM8YpDwDd97qVXns0P18623HFgh791VTpxKplkBb2OfjsueVKF/mgwpFAlSkMCbOxXdw5L8pHk7gCMDX5sc448oBxAIQhJekxaXqogF82sh5SKcJJCFBHSQpUJRf1obDwox6xm+tO8fVWO+qNToYcEhAVWEhumKfkImsJ2oYKUdQ7j2u5vnP/7WPPPa2Qyn4IH9/+MH/a/9jnfbW32EX4V33Ec7IqUTzyvU7CdbpU/oOY3/safxP5/VOy6eAfUibm747AW/yKYn+Y0o8hne6Wu+xcezIEy2FSl73Jj95I1cLvtjjGlqBLVqYd6CPvMr2TGjtsOE8aeW6/FWMnCTGb+SEO/2rVRDtEt3iGfW7m7pHxBfF4qTRJYSSPK2lOTqDaWl7UNjQv9WPrgrv13Gfm/gYLWDHI0GZIEBX8D2IslvlzFUuq4Xe4mdNPpWMr5OPoT7p+XNJ0whTqpfW0l7u71nlbsBrL2R3r0IeEPIRnZffGQK+NEhEqVRJrpgc4NwdObIPL1y2NKi0QA3GcvlXgMInVe4WcCSvx5zsuMKVsj1Wm+2ulgGhT9ppOS1SW943PGFCd5QFtCr4uUAwbSorSv2DscfOCi5pvs77aQ+HRnA2iP0GwkT/kYSz3lv2A235G/g1FTqNKwNm+7dT4PPPuJVj0UD37WCTXSq6J9hXKHdSoCQZPONZ2i5cymmx4PhtefmXhiIffE59OlNOhx9f6ugyOj7W1gv3wVGuAnCtQ4/h15HLSIYEDHO+KXVKZ5yo51XbG8baoCfFecdE8VgziTYSdMeW3Q05qOr6nUsN7oXQL63IJdy4EFBdV9PZmNXezMoZa07zqbgqsd6lt9nUZi3sqKcAuzdTyA2QW+Q4dWimUVV9BqYH2MfQfXsn+HV38+p2s/Qi/bO4f5eSQiAEyzKrFfRIaPPMRT0jZnc2o0+h6Sn7SEtmMazOjWqL/cCs6FuMIMdTvcahSIy347jXSotPk7o75hgzqxQlKf5DI4HdKl9v9T63KsAPIWd2G/vNBwGwRsNBFqGXVvS1KkM4Qel6a7DSWsC08AoxEWc0ii6r/eA57hW4qAOssaxNV0YwhWCzQafEmAHq9gOdFwFMFWwYhCAMQJkzTVWSujRdADatUociRepyjtv5oObrNEYXGDgKPr1AqNNdxQ/EN/mitG2YK3O4unYHPrFNttRQzpi47PFOd2Cn+22r+R5BEqeKd26X8nzqLuV6Vwh40vowrNBnNIjukX7VgWvFzLGL1Tv63nXvPrRMMmVc9g01Kttemq1nXu+Md8MQzu/nOtQGmpyT0B/MaV+PnqC+ovg89FPtpjDPFBYrCk/r2g0JnWZzGP37DyJImiTrFJlzPOw43ewBgz4NOB6Nb8diD7h56BVj+6wR1OnH/fQju3Wdsw/2vweMzI8GRejuOLFbW3bATZpsIGg/QCp2g2/URDmNpxlI7Gu5a0CWlqJceVuNS5/QT0C8GQ/tzhM6isRVNMqDtH+lChBJ3qmBuVO7+zAnB4xN4VrkCUXagMQq75CrI6oGfEbCf4da8av9k2f1YvRaSzrhgza2ZtHjYZpFG/HsWSSHpXtRI80R2WLjSU7sjezdGjGCYtP2VhqPzL09EEX4dtxvEdP6WndHdNO+uPY/6Z8vJ56t0Y9z/d7PQ59V7BTypmpT105rKDfNVpd1nv9rhqq6Cx8fwBaXVCOaEhwZZaGU9keelJPw/bI3dXneIs4Ua1R4Tnpxie6MnYK/PITkmDW1RE7skiMQgvVzqNbTHacmHlLLZGDN0fk7ohtKbDBtjzQ0u/WxmCDc/RtkpGmzGZGatGd+29gJCRvJ4NvEY1ykYPyOr+5FdPZIJ9FJJk39WemvoLUwm/X3NzxmG2VlYKx5UajIuKyLZLu2FSvfKL0VCHb5vTC696C3Id3rAyX5aB+3mDKvI57MLffjaKk1Pl7GjLtxDs7m7qlRmNn1zxbm/atMgjaQo66hStbRvi/FXIKt+iW+RYI/1XxOh8jW7W80y3LO91y4n9XwRFkaTT0viPYqKYPKp/KPFWTEwtZ/QAfMDxJam4nBXMN4epRXllAQypsHfmvOvxZlnhKGWYxSloZH8Y2skHBuCV7PDWHGkrHoFJFQIBpoY4HxXZ3GoW9tAwSbGKgN9ij3V1CkT+HLG7wdh1c1vriVLhvsY5g65y6GuZl0rvcecBl1m9S6Xq2AvdnDeFi7zXYo73Wk8d+lIjPhs+HNws3ry6HTbpz5K+vyB/cNmr6xpLOjY9PXMwdtI/E01KYqBD1t/9y2cP131yofD3/YqdGLg8YvXkql38jOz3WkxqDf4O19SRbULizwY35Zt/cpKnf9zGLF94NiX1eBCo7Je9j3fHnOLWOMsddRwUvAN6Du3jI0PEJ/3mHjCWbwzzPFdcFWFvmCEOXaE+yHmISq89wNBTMOXz8eKfkYcIL6hlb/5cptDwniSrqFhHf7/ye2OUS8MgvY2xC+DQg93XB8N4pZ9NHSNyDTShu7u9AAosSAmHErwHGsGcqbzQ230jiqTSRohpbINaq4iaQnxcWAFXZZkoCEpKVLlpA6QvpFDV+uOOUVnl3pRi4tY76V8bFgE+4ulCAEhzkz0OT9Y3dxMRMqWenaf+uAblr/N/Y1cDXAKwZhqfJ64mbsBsLOPIBOqQOX2lxHJNuz20VbkO3rHGmoCb7bxqNjZ3BGFA2l/AQV70XjJtyOsSwiXI6ZPhWpuc0JA5cjbSfWF8i5m4qaxIapdOQyPH1JUsIoQI8/tFxy9c3LIN+WMrLohWzttiRp6Bpts1Wo3ExAiRytbr/jtglZAUHD/g+9kC9A6G3jwgLKqyIVPFvhBL9G/0ObGTlOocOQTS4Ak1yXZrZasbSXMCrsbTJHiS6+jEkqu2DAUgGVvp82b/Tj/EP+gHg7kHgzQWqsnTL70A3lWcaSY4fw79aJcEK3HOhS71ma+Fj2IkU19mFObRla7bGvNThfQua7i+3wDbWb7P7VrhXu8I9cterW+Fe3QpDNR8kfS3Zq8HsujnM+IS+K0eXfM7lLfQvm9Ln9ZEn++XkI8jfE/klv6JHYL3yQvWeftG/W5B4Cn9OJDu5jFuEfoe3P/T33+DlF/jzK/z52cgw9e+fkPhJsvY/W/R3nZYJUMuaKd0eOtW/OaZK/SYEa1EOfwY6ZSLYozYdCtZyAuF3LpTtQeNIdoEfkjxqbzI2Ed2JSCYCWSTufHzjnpqH5XvsNI5kowESuC3Z3ZKNR1vSIEBbueertRu3GGNDQD2Hgn0bxoTQoSBJSwm/n81IVyaSuXCuks15PhLzZvY1y2ek2z5IxqNYMX9J0vbghxcCFCjl1lMuNPstmAQLCg/3Cek8G6K3x4JQNQLJGHsBIW7UA/hHieELSI1/kUvGCd3Tdns9wH+3JCH0+Wel+NtWVyD0G+oK3cB+Urym7f1Wi/byRmN8BYwnz/VbSVphovzi+d+02ebDskK6LLmPpiZ8rnkHHlu2uOVKfRHsRiRyHbW+D2bqtJ7OOwYD320/ej+L56DJ3H76dAFODM4W551HML5xtwdxTDfjXqPA+Llni3P2dRj3qCAkGT/VWlXYnu7AErzpzxvsX73VyoX4KFgBQ1Hz3N2SSQsNUFuoUuEYbgX6CAmnw+gimbS3Uy6mfPaNuVBzghWNR0VNHiV5tCDEttBGPfiW0drpOjLnCvZ7D51UX8PzKj4OhIqEJNdXlTR6mwdhtzfjA7WNYN1XxKhtKyxV3yfPh2CyfGdxsqtZKLLn7Nus5HXdXPOcfZ3VeyHnLJ2tOHvxOeb051InVzVzKMqTzb3L8efPVr/QAROKIyuZhOw81rGkK3uZX8a/fI4xWqmfjhe2r8T1w01S8hO522oUy2XRKG2+pSCCvVQnVM22YIX2a3YkO0fgDaCjLr5fP8eKKnshleXsFvxCLHIDd/1D/JuaC9LpIB+p/9lgn44Ef6WyrLbABPqdC0Uzp0eeWsoX2RWslcQv/PtCsO/qSmzZHQuEJ5wu7Tl+MYwlxrhGt0Ts9We0QyBmK2vQx/6QqqctQnsaTiGoGWGwOd7R3DFBev4cgW0Gsx32KO1NPc92S7yx+JLm1WhWJXrtr9KN9dSL46wrp5ozMc0keaBcHXkKXhijDuh6KHGTpk03+9N4HgMPRm+azbZDQ2zKaqUd2ylC3wyglrbmhHthfHUo1DuLwVTNcUQtP05Yfpzw+XG6S62OcPiLKDPczJdA+L0yRVdxCuDZbn1qds9cbRyqdYshjojZPXPcPbjfyIP3j5WgX/Kcy+ts9IeYfmYpdQl4DlkBcCNAlZOyFRlcyL6TyP3kLbT0UdLfZYkYBt6N7k3c3m21dnb3dkAMx1Cbrt16Ggv2p4Rz+gg7SozROMKTFtErBCzbOK1coaSB9v8FuXsXExrcpstK5ob1DNyc8ZtMFLNfBvlokrFJHr8N1U/USCy/Y7VmeHvB8MA8dWevpQfnbS3BpMKK1JWeskftTutpUbrGC9KZq2s8XjBxtjgnP6XopXkBFpnsX/OVij1CYdIKFrd3Wj/FBYK5RwXptndayd5h66eiu3fYStotfISfpP1kB5+f7LSS3Wz3p6K7m+0me7uYCj9J+8lB6/95LePin/BEoD5y9+BZKtbPUj1xu7vzBBigxgbR26WlGw1UCkpXYRLqMr8OkLGfpfOQaiDifQ45Y301eGGtCN1RGO5L9QmtM9hHGBfj1Cmkv9Dq564rL1BBWsOLj7L7UTKZfJRoBwJKpZNv8UeJvqdMid5nhyKKBvvXr5LCzy+SlrfukolwdzfYvwSqkpbwxU7rqeiY6VD7ShBawL7iHXnGzwEXgEYKD1k4xpsB7pd1+AHgAR11behzaePnEFJdRk9DTE9jR59qray9g+xAze9HMNdpGfsWVkBkZnWo8GJFbH0tmBP11yQndUDL7o4ABtZdn2WoKKi3u2lprJ5dR7AfNdrSxuvNtA3k9iXPB5PJtzuknI6kCnxdS3j4BhmfzQaDiRSKfpFCzahFW72lss2r3vSlR9/Rgj2bmWs3+KJwm2cz1qY24JiMXZ+fzVhBwxKcQvMwEDwwiDm7foMJ792pZCd2nulxfOIfBH0M70rroqmHcCFaFlkNwBOsO1LDHMma4Bvivzk6ZrSYHFFq1V/qBFJGDOXMzQuQF/nm5io3qsKiMTNiIj0Xe1Zq+/PwMqw1Jw/1vo6uQ9+YG3tYqqyhoVmfaH8dfGslg+tYd8paWJdk4YYxCgvjy9jA65FkEvghEv2k2/XSokTJTuEkBEyRXyX7RTJkjXyUzPBE9Gz+mmvoxlod8fRX6w5RbG+TwE4r5uzX/EyckybPZ9l0kg2+ZiPkT3rvTvM01erGdM64IY89LHVubtY55uqoH5ZSVYotVrYUK1a/5oGvdw+kv/IAdMeC1S/SnBskF6aOXBhJOpS+mE3UhRsBQGclbUXziy9VwBvf9k1Nu9GtXg1BjxNWScJsTyQbSCb0hBfwjd7Ch+cysLczjj24FW6bBSd33yVrw0oKwwPTN3mWePqvJjvtMQ7hwBWHd0vSnrk3MdoXdeqklTjv41rzgzFq81r/ivSI9ShwzbXtBlwvR8iWWS5binXebutfsAHa0hLjU3bksepPu/FRoFd46r/Ro3BBytog9Ej7GjlVvyQp1YYzelTnE2ulFOhOeLzwYxuckLuTIGzfKY9PKMRwasHd1W6caPdT73kMLhAF+KLsa2WcWLATUkeKAWtLjf4j6le/z2adj6glPSYl17cflQDyu/7qhOv2Ar9zTYNzK5tj7GLT7RwQYpUnXqA/tB72W3XiU2XYn5CTgt4lPjlnYp8CTOuUx5/cTPx6Ff/K4zGGVjQ9WM3ZmOlUurfp/HDtWH81P8vuz5Kdzc+TnxWuBfywOVuAJYIB4IG/vLnpA/YOkKFHwng+Ajzr9jqe09c8btExFa4vhuffY2NcnCzTiqiUw1MgAlDeQExDdQGrs2xtGFvniIxntWV5FogJekAfW826qWg0pgJV53lGgJ560Hh/hx3QoyKceyOV1rYYFrqu3n+OfSMgoS6NQPGu0Yi/SOZuF0/DSsEYB2aBnYPbSDLHezG42rsAvFqvTiOZeIAatu1dS++PXf27o12PwX7Zs/sFmMabsQn4t9/4TblvcQm/gEpg73P8ApnGro2XdajdjuZ+/ZgjVeJCfbiHC2VuFkAMfW5U4aEvIXa7AyolZsYC9tR3b5agzUBg9kV2yMnn+Is/zH41U6OxeQuC+kreE0/154P0bXSppKeSdMpWraHtqlkS0X0PFSVf4CIq3V6usfdeY9LYGfGymNYJkijeSp6U25eBvlT2QpZ44QZEQ6HHaKaE25f4JqMm83fJDmwW5Z1JhlehLDtKK1smr0oxq2LOjrFLMG22V9VuGPNwK8Et5RSkA3+ZDI+sIB3fi+G+t4ZvP4emk89mNA1JBcR21pEIzgTzsxPrjMQdkCFhH6SiAtfSmJw5VB/Q/46xYgjw/46vT1JiLD+IfKBcWf+qPVbW+34MPXmwEEPhSFzDUu650oPu2Kk5GoYSI1+e0/iX6IQpTFTIf9YKqX9895nnDSx0U6BfjHfZwE/N8tkgv5p4OQVzqTdZPpM22J7jUnWUa8Y6BgO3yKtiMKAOVPvp07QjztJz8KIIP4/aVOpfjtyrFTIuqZXXfZHMh1TgenQHYu3w0KKo0bDpJm5KDnAVZKgt+uJz/HVGffmN8Rv1WfuNWmHwFFR423/ypGWroV5K0OByOSd38xK1XN3+Ovz9s1kHzoEOWa9vhb0KDu648M7xg8jZ9xl9ncWSvcoM61Nr0XCRo/O4iOcbkqiNcychJZHNMAvN8pGfeJyPVkq8prF4pK7QJi/0wgvaOMYUg2fz5VIJcAmy6E1tkMl/j/EQF0qEXDSnYBXTUwoNnBXNQT68FlPEgwx9o5LeXF7KbIZxB1EDCLMU5k19VWSVNZWlc/voUA3ukSkb2UotQov2YJ+N4U+ftegRa4EuNj1VmWeB0wJE0ztbyot6S9FcSgHYtIZeXhbbKaGQa65yFTW5xmyxXRC6y/x0cDWzzbacjrImep1PtxO25Qdp6JBT1Bs+6ZhulhSGZzDlpwq92t7uM0Um9oAxfKr8qm9vHymGOnQpbMiP46vq65yyeIudEs9OebXFTlacoeC4t1xqAbLynKl2XQ+32dhoUKtLjMMU6gwtzNAKMuCABjm7w0XORhDxIZHUbtV3sHsSvqLfZ0C9ghaIr8uD13ksPJ3Odgsd0dZKeiTxgr2DLqwLy+VXemeVgxSJVcIfIBLTX9OO9BX0Sjpqj5OytbLuiKXdvpeMIj55KYogzbLQf3jGsswXovkKi/Ea/cmPnsbkR0I/kU6WPUQBkvFsVVFtHAQdKmlBw8YZZM4efpD5EWlYFCVPwhyNxiBrjjRI0hFBMM230a9m+ZGG9S4h9ysw+f4VnTqcrFNSkjVKSgYTZC8lRe9E9PtKmfWPQH4PpkRZPMgJ7uxNkdNBzoR1/2KuBk6vMTcY617PFMbfA7xqUbpw5upUeSURDwIxodUlklQIlpKKxghtKRgRa+0j4i6jm2Es/4p/iHdC1DuHgA/gGUJqA3/tRvxftsP6QPmOI1aB64UySx2RVji7gJaI/F02FF+zKaA3uMgQ+a/DnwrDUOTb2yRl4oyf0yLWQmV6Z8nk97PB8HOCdmnDz3TErzI5S9KmeljhlZYZSbxS2ZJsJqzelhErqRBigjQaniwDMY3KpLcb866ErS66XGxvJ7HRbZIkwUdgkisExeLNlsUeLn4KO6LwvdJ6vHWg1tx2lsIQ0s+HscDgkUFdlP+Y63/wkwTlLUdrC6JtBtpWeC6ZFKH+Wh1av9smxClwoJ0UIkdj6RDwiu7mHIwPgHNrjQ/aB41xYNrcY/Na+/6eu+DHrNUZP+2Z7TE2igh91jsbK2+JY8n6ldaP4FGD/aN7wf7bcXxIj8DXl1H2tR02+nTE2u4cwZi31lxIiocZH+E9Z7wIn7CjpnH2eRm/H8dHhMId31+v2ntK7oz1GzuBBk8d4DpZKb3K776b8k7thVQexkdy992Pzasp70/so+ls52PoZusj+xSSfh/JCjoxR8WNnWqgfGegQWwY+zmMYGEQ2cqkYUV7h6AmAdMW3NfzHy3cEzqnc2vMb7l5Vb3VLCN3WWa75F0KWebYpNJ+UZPMM29/663Gs8qaD7LAYKVj5mZRPzeDjAyywEBlkPnTsyi3Yaeo502RpxXdu3eafodp6oW3aI/2wlu0x9CgYSyrWPkEPLVXZ3SSkbuJHUbPm9FJMKM9O6Ng2AtKWAgw77uo3go5W3tZ1XzEC8u/kcDruAGzhS/v5KXrWFj1HU+v9U/LSJEsv4ZnhlxkAQxsdE7Txn+CvYv9+KIxqra2rSBb+Mqmf3hOnFy8dGwKsI0qRBEdm1ejrX+iLZ/1B2Mk6vrrQ1XYtdPmdUzr5bKmTFHlUzvm9lQsl5uaU10A8Stg1gSV7HeOPzhr0sya9GdNqFkTFETU9LkyVjTjW4k6a4EPvOwhDPgpvcHw2gnlIPQi3isZ+pphD9Ep4tRwfOMt2eBEe8BRusfKpx5y3Zzu0xZ6Z9ySjcZ+q/UTqu/8KbtapyH5Veku653g+v9RJaDGAAzfGbIL9mZGN70G4jezp09ZG1VX3sys13ySCNY2qp7vYtLxwsRfW12XZ0M9T6oL3N+MReCmMSRTAB/zVkfbU0y/wSwR+lHV5aq6CJjprTr3Lu3dxCyVI39StkaimmKjqddoJzRpDeq5Xz2pvUfIqn5TVAbyQg9Eg43hrLRuWx5bdHbNcYBMUnz8nH1jXD2a+xNf1GWLj6pa9Wx7r17hkKknn+bDCxhTeT7KgDWi67n0PgUOJIVKC1xSYkowzyrJlysGeTxflCodulmo54AVjimXmiuuGw5cdroZUKxOfJ9orie+hI5A3VQfXbmpNshyNvdXwOUdeD4wN+NN5HTeTsVMwKyS5XJTNrlUxiMGhPk886s1Dos6HpKuA3tyBv2CZcdwZJ+zb9QE0AoWLlhGytXySmPl6NbePw1erHYmqT8z4NCRl9e5XKlZBl6WVvDSohCb2UZE14tIfaY38xeOarY406tncrrY7RXZUPBeNtmsQpxgNwZ7Eznc/oamPHhlVvDkB4cRdcFhRBgchrrYIfbQUq7Pmj5z6JTpUvnMpdzT37pyUbapVVHZUUGWZJ3TXElwnwJHkbWdK8pKeEhJFmzf4IIGlGowmmUmYsKHq1ivwTTLoQvWOyPPkgU7pOmSHfpQc2CKxhI2cXuHgoBqZ5mWtu4go+aIzqlUZUelsrtYtlxyVFPyslTySW3Jy5qSqR3qLcjS9Ait5cVlXNFLCbiH5gra2lJf9QROYHLarVAPYAiJT8K0W8xY8vb2DRP3wsQLTDzQcVvMt7KPg92WJsFlVyamw+C7wETviAVM0KJ2fiRVGBtGvDLzJDx8qAIs1XQ/ppIWgAvYeGueAPl2XF9oZ8eW8vuQujXigYviO+MiJ9lsr/wGfr8yWJpf/QFVzIh13XpfLoXzYgPqS7vpu+4xOTvX0Niv1A8/eBdwNhNZctCnYWvP1Icd5De3avwoMEtkM0xY+SswmDhw4OEG+joOGjMIQyDexBSLxKpXwx70b3nTQ+96rWhHqsK+0DOoQQE/ZjsGL95lXRGMqnQnTGTfh2BMqVJDeSJ8etQ23wJRJQvG7CWV5ZzMVRzk8yWmGhMJpKr+GPs+hhEIR/3O81GWz/glz6Zvp9klXxgUp8qgZCn1+/oeIzwfD66yqY1P8HwwG5RQmIuJf0WgbtTYIjMSNb3stoFPyoQIaIA23TR8XrFkh4jtQwzKo6t4l1o1QCAyfesmPzpcNTjbnT7MSUF9vfmE0yFsObXlHVmsDEnNwXiPcy2zI1Hko8GUZ+rzin5GDS3v+I4mmgu9aTUsjnMI8Q1SrwtEzUJRhSSbKkqjYcJWpPgtQjqepkiNHGc3ERUxBqiKlrS9+GXsQkqRu7BMSYLySk/eq2x6lSkH0r2w1oA2LStolMfQdtrv0tHqXKNvHdUzbjVALq7QQFbY0E4eqMnu2VaxZBeTGD5ttqikLTqnLfxO7FEfTWKNBTq+FoRDvLmOCySSU/aNQ1eIhQXazYvQIT1pfh1zOgc5h61BA92UIsmZWnulItgcl5NydCIbQoDOsfEFNJ5a7ScO3eVW3UuYUXTtE+OJKIM2TmMB45mDo6rm7eDbRAxG7gDIlRdi0to8Fwp9LFQEDzv0wo/elV+jz9QFEtdo+pzSBehBiGv1TAhduOFeTRxdEntscs0j7MbS+QYxsQ88KUrJOuZ6UokyIWtCo9bGE/P8NSmCuuM9s5Z2VPhUdHkiPD4L103qpik26OKRNRr6w+pDOciJ3010G1MiCOCUBBTNcvneRZ9K0V+uDZwYa8SjwZfLIFyv3iUpyuycrbx1ll4LKj6DsQ8G+Q2V9Z+B2l7oEscPQDe8Kn3eS75dx0GQ45ITx5Iqv2HOBdr8NC27adE2lD/H33NwbKOnBHUPSDkh9Afu/KCorb0uImxNsOJuXImJ6YUFhphxKtwobxiPNY427Aoz30ldLW6vfjJcO9K15BbucdKplisxfEzsWtsBbJpQP/4s7BVrn7wwner4w/CFxWlNML449cJBqgBfKiKkfnae0jUpjR33h2tMBX1ttjXR8ITlhIxMZw24t1O1MqOAPQ58wXbrsfMNrATJavujHndr73D/8YFzMVNcxYK+zalQxCxxPoBM0/7Z2An2aIeP1amXpaOqpYAfL8EriTu0HagXeI0pe30dKwSFFiAPNwGT5uw9WL/Y4Zt1oRVSLnWORGtFBGaZGg0Lv1NL6nXVgWdtWhfuuqRQrwzMYeN0Y4hoTfGckwRiYNeWhz6pI28adyld/auuyc8YETLVzU3ZNxmQJClNy6gQE/Qlj9WscUIFG4/dRG7CbQ4xD/TwWrjk80bjK/SY/slN1lSV1QeFUGH9FhShTy5Ay/SGWLfWtICDUjRTnvMZiYtmqq9T4kjSVIkfmItWYUwQa7kgevmRGdJtJy0nT1KF8JKxZDtj7DYzZdrtDsqrGfvm0vbModlZgWmXZD0eFxR9/xlxmGDDsb8jecUQQLDr+3NAlo/83ix7iWBbXhbohw5vTLzcJc7AbuuAFj4jYEOY8A763RxImpYXZ6jghlqCUFmo6KYJTkSKbYeBVdfXeP3XatxNFGav7lSrWl0a4SHo4RblptK6qOWmTTpRUgz65RrPgyLIScf4PKq46yrYwpadhz6WUSdmLf2z2dYE0KKJv/eQPIvm2m8B5bTwxItyFQKc5sVAZgqazCvwZU539g+ckrtgE7McnKYgQ3MmPLuEBAYeMAUAiO4vtVcqhWocOevfE6veV26k/ZwJ+gK9snzJTeDjkzwWaoEKhDyaNcw7vEMMq/nRrtHVXYIXzCDKjcHzACWjBdODsFeg7eyf3AyLrCx4Kx0aG4732XUchOM/RiC5fuNb/9eyW0JVFTBfsNSxW48v8Rh0ldvMxDkRxA/zIAjg7g6hVxq66hEsvGky7vBCWYPtcBBP2AzVojZhmN4foKO0cgKt/Y1ZstNgJRNvwsPuth8AST7yvwRJHpsadaOl2so9OLSOA+8r5lasMoKWhlz1GHlpa4hKkASjlfYwNH1BrbUVqGpNY+2YHwhGhE62o4jImJdGY9MjimrPxMrqDOgWEKaqix/cjlgVSOL5PPX0rpwYJVS9cjpbTgmrF0hPrHaK0lEYW4IcPXhoXodW3onHQI6j8cAjTggkamOrPpuvcU5jfTNCNNOmvB5MsxGx5sHGmXR3rIyAx0msn47whx7pdEL7phAbr1bWVM66f4jHvhYVwfApJg+hF9d2DpEfQ3v2o9HHYqph54663bIjX2izQqe9iwDDzK0tcejmypnExAurikQqDhBAG29RHUyPLcLB9MLBLNQoFk5BSnU70NGr0d9yuikLNjdLv1BLv2DoK2mxxkflnC08pVy7I03d0BddaGETV3O2MPA+DU/xGsBvo5inBiKVwZ2rxhAqBSvilGXXcUp8apeuh3t7rhUAYKapsCFCtxzsc0igj4jZa+pirMFWTT0u++Mfg9sfQFl9ARnCSBM80iN4kIg0M/OzokJSYugRpClK1IjXwSdJhew2RK/+4EjcEmt0/0DToDoCbiHqot5Os1sxnWGhrvecOK4TARGPFJOsmWHVkqycv8UJcuKQh59yTW2B1hjzmJM3D8iTTwJNA8u2dgrwT0rv7TCDp3cg/t26Go34MEhYLqMNJCgfYZSFR7eC57NHRS5RarER2czqmvL6cjuBMPL2dRpIs4xhXE3QSIAWzj/DGm2z1PoO76R+2PqvqNt8NQFb8x5yXJWX7InyWkpTYmCT7diXoGPgLm0dnVmYfhedYl2jc9eoGkE20cS3EtbSzTZt0SiitxPP20zNNLAFlWc3+TlbWHa2vIzvCWyYSELfgar5AlFwDE4zGWg0u0PCoIYp6dw7xt7aMY6DiVW3OEqlWjA0HGLr4YMc60GO/9ogfXs+XNuxUt8hhI5XKqYeCgxM47g2q5uJU+HRzB42qUnzAYASElVPsGdmKkoX6V7rCYSeV9vKStbIigbtFypsCZvUJVYmfn0HkBleB2KsDaJgJWlxpzqD2mGX11u4vnBp4HVV6bw6/6kcXmejYpJZmWUwecBYMVP4CgzEJbu7mIjh52z0RgvEZ4PpVTZLJL3VwuFErDrOJSrYcGzNtJa+sqoQjYZ4ujU74+dNUwatOzpbs6a8nfBhFnPaAv5IS+lang5xq9LxLOhcjUbhbmLmK9B2FQ/wFWbkIbNhLAKRsXJchYHfUSF1ybVCqjJlsW6ZAu9O2qVsyIgvrZrqKmpmtkld6G1Q4bxCVVnaxqiOhFCUvrRhNl7OykvV3g0liaby3b2d9uPHO4dhI6Zm85W+i8FGexKUWK3o2we0g56IKVftCRJ4/r5CLV8qvPohTgt9FZwSQ6HPVvTDLJAbmdnQZi02I5NUhE6r+GpF/8wqQifD3g7jsfHL+OIi1ixVjiHmqItAx4MIdMLE6JUdP8hyh/Ag6HLHBPL9UmTTb8qgVkyfTSaxavQMGmHR9q/v37xuKg0vfvktjqJtQbb/cX4GTTLdh/N/EKp8KnHfpZLxXHUmzjuauwMc/ealmN7gosCDkaM+vwBPUyAsS0sQ7gmIz/8YAGv04gKxwZXviT+MTCfD6KU2CoCaGww8jYQraTQuIffmJndh3gTEdVut6M8X7PfP9JcL9k6Z/d8KdldIkM9OOBj95bPpt7eC5zPgvR1/zfKZTM56F7TI6PMLenxBX1zQ3z+fr+i1YHeXPB+hkv/Rt1+EnL0EYJYPs+SPIb0o8tEEr52kRb9mUwlaOlH7sLnbbEdU3Q/Z9O1g+Hlwlb0e3GRJpLCjkbiJVvRSsDuvimvRdG+2umvR1I+1FV6LZk2yzar8k/u5VAoVX7PplI+yX4T4/N5JDCrJz1FX+e1gdr0mw7sMNlo1g8e5CpLWVYgfy5XJTGHZSrVoahMN+zNM17eLMjZVacOye/d32WWy1vU7LLW/xkffcOEDHD9kk4FQ8SKWhCiy2g/bTtdtnGvRXPPJc687nsRBW6tK5+QLAaOZZvI6HH99ohAzO306RzB7V5mZEDVmTJxmQ5EP+SSbnoa7+xEwHB5dtnd3Dy8PW4etRzutnb3W3s5BtEJUschH2SXPs5HzM5Sm746f9T6kz49PP7x503+f/tx/c/Ssn/7y5s1vqcbQbwS7PxsCmBsBlymXgFOMGo0b0ZTFLVBkEruOVjrHMwbZcpDqxcB7nEOCts75koHBqGim6fvj3rvjD+nL1x+O371+1n+fPn+Tvn7zIT15f5y+eZd+fHOS/vGy30+PjtMXL98dP2e3QCoPp9lglr0V09lgUnt97DwdTK/QwFhabMSK6uyns53zrv+SGM8jm/kEKOKSU55WyznlcYGKJ6Ehyu7DWt71W97VLau674zkK/lEP2cqGJtVTomi7YJapUdJQ6VGUdZY5MrzuxGerOzcIc4Zzpwet6yM+8kT61J8s00LFkU0ZYXoBMoEYCWCKmvCQzdnUz6cvUJ3aDFnmy3iAoaLivId+kSuJgdlqop56FK37gNgxIrUaXukDuCayNVQhIz4C4QMaOtNYF/ALAIweP7mFXwrI0xqvWTZm00YN958lRbnLwmCoYiVbIu1slR9rZQ15g4PidFAk+wNitZBOVbGkjTHgudxRCNCnbupQ/RK7xQTfeAqaoErMMsKef3+Wz5kNeAZnZtCLo1x1ykHbYofnbKpJXUEsJu4X2NlB1O+fg/vtfaJCX9gAxBwU9VIqXNK5XWNprDN57DNF7DN/ZgFaovzNVs8Dbc4r9vi85rkoEztFl8wvm6LI8dCz1Jbc4F5l6v7I0UFwEXtdkdXz8otUKsjnxYG85Roex+nLOasOJPnpJleZTN9/5CYN1OJ02Vka+JHSrHdH+ZgZ5ym58kP8ymHgJw69UA4lDfmUFYYAn93q7X1VtMEvrUZejarO/CbonbH2bo3N2u5KY1GXKIRTR90RzbbgSek2kq0QwNH+mMgfYjCrPqvt+kFIlg6fpoE5Nz7qKbtvbIZepnPhKs9nEpa2PHy+sl04G+5tNCrqu9b1g4IlkE1BTcNjkEj3+zHiA8FuXI70cvOfrqLCpltqBMaeaFchtfZ8PPz3rFytfDXUaV6faR7izRNm4gbPTBvbAs5o+YKh3uFRq3ZAtEvxuOdnf39NlnRnZ3Wk3VTgXCQpozHT/Zae+DtwrGBp2JU4CPcUj3oQDaFicq/DqZ8kM/izTaNXt7ogwpEA2SUGzANamZ5frUxFKNsg0ts8fY2G21wv+pmRFadOVrkfSn4NBuxuVbrcIqzGBS3tit6p8xXnVJu7InHBdRXpNlaz6bTwbcml/gLmkfRAJ6iRG5wjdKLy4132dXx4rZr9NHCj2nz5UzB366bg2a0LZszoWBWTJCrNYujjYictc4TT21bYV696wHPoZw/KvAyHGxPHIqn4u1cYfRYGcGkY6bG1PvpoNt7dJC0CO2zg07/aa/T394m47P+o4NzD/nsn5s5WbDFcglbIV0uo6dPB7nIv92IQv70U2SukrPivCu1w3t1pOmZ7dY5sCqHg1k8BguFbp6ZUx2Zpd2Itufb0cb/jrYX29H/3pgP5EYuZhvyNhvCDYg7439H2+l29L+bEUlUCyu1gnYmvJABm21PfdDbQbV5W4RW5t+s4PviYjgZSBksg2f6Wy5mlntd/ntW17kO/DqYcPTs5C2sWlSYZyQ9RNzTjpDHzN/XveDO07P8MscaS5MsLtVx/N/R9hjegTqbwFzPhJlrupEtbrPhLBtBilSzH/ikIWQVR2ayYI/TuAbPcyeiyaV5RBtHAdAJKinY3YTL2ZvLZEFvBrf4K6ajbJqNXulXmc385Pf6Fd374BPXNeMLEMXTkcpxPQAzPUMGSdVEz725hrxE6E4yh5zJ3MuRzKEjLuk9vmAn8NOXZK7bTua2R8l8RZrczgHo2MJu/OFWi16qrBENp1ClggKJrfRz9u1hVf6WfatUiGn+FVGsaHt/5/AxXg61VwNn77/dXIgJsBdjxTAzgmUItFzz9RYp8QgUf6sfL6eDK112XvNZtZyCCXUEagk1tU/FJZ9k0wgCo9V+/spH+Hlc81krpUQAE2s6J6bzwXSUTrPLCDzy1PRPs7oiCMtd/Qwyhggc91U/TQbfv0WEnphPuKAzMdXOwO+4xJCQ2SipsMbBjjLL0ZX8CzEdGpaal89+f5fdTgZDpVBYm+F9Nqt+XNGPhi4cSMmvcvqJ3Xk36rFBnpVRnbLopoHFoLCuCCT7RD1/AlNwI/jd86kQSJZ//vdrPvZkW2Wzfna3ov53qUdfZXaYK37Tsx6ucc9tbYpDtDUyFcfNZpMkG7PB50xuDPINVSvAYKSWNxBvupgAiiQ21DA2xHRj4C6Y+TUfXm+ohb+/imZEOv50NEtLHMNHJGFs9yISzsel204BHVNXrbf1TM2RVx6qfuGqZl4zxqX6z95nuLZedDJUSpSzaTGciSn7mX6Ms8zvIaEZrOrbYpqVVnZTxwrJWIDK0UFmtrIb5vVAvpnncHlm09k3OgH7X0X6arPFYcbugLu22aLT7BJ+0lRmE/OEyFyy2fKOxCul0aPlLXQOO01pPNEeM1weI0ICMJACbuMxr6bZJfr5xKeArfU5+4ZEPgiH4IVQQQaZktuDLXijsTksjylOUb0PfPKCn16iQz6XkcNHO4b5NCaekmHhNM+ejh1y2deY5BhgYatz9HTcOdreJv2zIx9/PNreOe94lfUxiAUEFtTG+UoJys7BmJW+WNIQum9GMcZRlHmgnMJDIpEVusC16lGEGsmcpugBN5lkhq/hWa69cVjKfX4CoNemMcYYR9TzNmP//O9/bv/zyq3+7z6yd3+F6gmt0V04y0wOBz51chexKIlYK6JRAg870UqPPdoCgmKqwHr8zzOWnP/zqhb5EmfyXOFpUAL2TSKAEkFhY7x74Kn3vFOKdD7GaemjgAKGSP7RhRCTbJDjK8ZkVTZaaodttgMn6mNjqLdh/JBp0av2aqHih+fFzUU2jRLM7YkXLZm1xlsDx+IbBRZE52Fj4miXeMwkoZJFIMWdd6NmtP17PAa/S3PKs3hBunEKfDy9Isiic1N7m9Foq/HPiGxH/4wIfRcvcIqiqHayJXie1mJQYOS9iRfKk4cLXuZvkeoOVmp7sI0FbmPlUERtZX0Rmg0tm+phtYoXNN2ONxewusvluNEYwxNjDFO6UZTA4uMLWTOwbYm6jcg6A/NUXL4xWpO7eUvm21ESwaR54R/7rNXpPzWQBGlJrZE73/497jGgJGmfdMbb7B0EoqUpPaILF1jkyM3Ns6roDiJpVK5gLeWo8FdiyU4aDXl2cr5cyrPov/7LIFPROUQu1o43SZ3XlSPN5DwyelDgU7qzCf1Htd2YkOZI5FmHqHGwnlbNxvGooVIYO114PlssBGA9jRcIpg+eNJz1SF1LcmMwzZDqVcTaQG4MNvBu20AQuhFfiiIfJRvRdhyd6ctfFT2P0CGAbm1jzmfXG8DH37iLtuvZ+huw6qsoEWQ7Is2Nl5cb30SxcZMN8hkgIIrXtzHYGIqJ9toMeIZVjN0AYmCQbyBrBFkf2WAEaIdeu7HnpqNk5+vJPIwH+rNzmrKWKQswCOyc1p0wZXOtHOhubyMfs3DNfdDc1kdKkJLK2WBWeP7L0mkmi8msEwsmYEln15kfCViogCK2YKPxqO2/A5gzz6xNbX1MkBWhf7OenVI9hIa9x9hmJnMrzGxFRmakvmYeZDJXqpHzmHS8wUYVhOcyY3fOKEknfsvYXb0sPhlldE209eQyo5X47Mkk83ClP2Nyp7qlT8JgOENEGZiCyP9RAuMyZ3DjouCTkdxARhzQUBFZiaZ1eXIHJPt7eimmx4PhdVJm9MOO9DnmQrOtEHe16AusJ7D3h6LIZ0lVe9Du10p129uwhGJFZwLRpDq9hLBQeH0sl2fnKyrySVgSuD5vyhKESI3fDL0JpRzPBk/yMONfs43BBijWTDINUTShrk60d3DlCpjpDpk+pqL5QlPmLKWi+VZT2WwBL8U0c3l/pqL53gq60GrNKICwI/q3xPjfMiqag+GM/Qki6YnIM+0JPBQ5FAF0qZkfv6imxD5cZxtmsTduCjnbuMg2BuH00I2LYoaQ8XYgJXIp5XYE06Utm+O7FdWXsvIGDa6FFtoJWI+ZC9pH+e+sxNbD9hfqifaYQ1JrUP+5h/pLrY2mfkuIM2JgNV9QG60Ph8mnHPr1lEMf7dzP+ufO94U46597igrj7visf55AqnKo27+HsOiTtJ6wAOfGmqDoE6smqgiLPhIW4xrCwqtsvPoxMjVHZGqhEanUoFC9lVN2MF5BqscylszVPaaBzVYiw/cdSJhdT7PBCCNkJC36VrOelLC1J3JZ3Ji31MTiwLpU0tVEXAwmqDemeKxNU4PXjR41hmjgp0Q2TbVMuhGZw/LKprwYAD39rUYb+lUQcNcxz2far5ir9V12WdXSDK8RyKx5ZkFuWcV7+1oJLlGgh8tTwH5Mz9+gH7Pv3+6t4pQaY/PkTl+DyaM21RcdTA9apScfsAVgxZXYPOUKtww1CZ5sB9MscQdAYZ4Ca8IQGB/sZVkzqZeZH1EpeAM+Grpl9vRVwwyqESt/NWCwkFnPOJ2pG8bGyIIQPy9mWenya3d6ubDKqJU0Cpk9zy6KK9yrLGAi6o+X2XSajUrf11UeZHdNaLcZDxiayukP7OWoRn84LPRyFNvcN7fZdDDjX7WCYkUloL6CUimd2dSZy2wKdTx8GKUi/nj6g2+imD28Kj+/X8+rdXu+VB7y+eXeZaNiWKMrUVtYZw7no/74V0peuvUv82HXlMFsXqlv+fB4oYOazMT0gYtZKRb2vu5wr6nJZY3rFBKiFX1ysL/XWids94XzKHghK3p40No7TGSd8EUEbHgN7adTMVXCbaWEUSyXcaGieRqMxZ9Wx5EQI1VY2/LduXDqgCAgXuwL0V9lUg6uMoN6qdwV96SgXaTNG8lK10yWS6htVRaepjy/zqZ8JvtCyMyEhXZMYU3EqnHGwn0h1MsWsIwl0EkpfktTJlaxHSWVhNoXcAXSSb06UB+/wB+aBnWjtzxgprHUM87LszeXVlhQVh5wDrU0c8TMLJPNm8FtXEcCWPYAmnb8tNONRJ4BqRMZKbqgoDZg3mRTon1Mi/JHbZ/ApxEFEQKwds74o/Z5gvHXf1jdWeucRqqgS2ufkyS6v5CRC9+TzQ6NrMpbNjp+9y59+fr0Wf/l8/TN2w/p6bP+yXHAAXBQ6x+AvCP3ZSP6x7bY/kcEBCPXUu5LMd0Qt7g60T+25fY/on+sCIVW1FGg97b97N3P6YePb8tNW9VdisrNld0uGg27JxAvkH/w2XUJ9qBXbDmbxpt8ueRPW91Wss2pCWdDGIN9KmgEpG9EunHBIqRM4P0ii6jyI6g5vv8fSP0nOoRJTEbIZLuR5aOaTjh1UejDT2ZnEtS9tW/UdBXWiz8yXaRcd1LSyFJPESEpi2BJ7LLLYNmL4E2dGEEjmLmIKGaZtsG0fef5cFKMMhn23fCHXVBc7DVGEeTbppP+mDTXBQXu2KrylAU0XDe61ZROlER2LJ2U4e6K/uHG8o9o4x+2+/O/MDQD59JtFjU33ilSfKS0LuxcmaGQB2zS9x/eHT97lb49ef9L+uzFh+N36fGbFxGF3ZgNbhTzlmwMLmfZdAO+rKnm1fGHX948R9L75au3/eNXx68/HD+vZbepdQWyd+Mmm12LkWHNWBXybBStyJqGTH/fHb969uHk3XHa6795fxzR6O00uxnMimm2MZwIlJrfV/758fsP7958XNfF3iCHHsFlpbuqZmCwoeYF1YlGmZxNxbf7evvqpP/h5dv+cdp71u8fPev9FtHIoM9YezbaMEZMGzN+k8kf9Lv37DXM8duXAE5MN2/5bUZxCoFGBNT+B7X88e7lh2Oz3K+fRzSaT/ks04PM8tEPyr8+6fcVOH0f0ejV4Bs2ruoAakZBUhQ8q/mKfrgPT17/9vrNH6/T49e9N89fvv65fl1O8s+5mAMgGgownQTWtXz4Lj95/f6Xly8+uJGnx6fHrz+47V7k8ppfzuyOz/LRBrofjjxNluYLJlZ0Z3/3cOdeRUfG44P9g9YBaKd4PPPl0h+YQr3Ozp0x6waG6tRyE+7o5pWH0T0vbifZwsSNjff299o7oMPC4/bB4xaYYMb7BwdPDkmsctK5Y4P0WBovfHznBzGuVA2uwFn/fLmMa1JZ+OoJAVVmw3JE9M/XblSfnZPIbO5KGEt5pQZA6CJ81YEcJmL+y2By+eY2QwdRwN/eVExscyIaDSxj39lmm1CdBzaun8e8+3mCNkzGUsNt48R5mMURnCIq8iwfEd/dAabExhLbtKQMhdGl9HJZoHToAx9+jjEK6+sPWG9QCSbDhMom1rfSG0zJUy3DrbxKNDIt/sKvrv8YzLLpq8H0c0TvshxYPfAFTCPB1trHzx2JUtfva7+u1YrQv9qZo+LyMpv+e71oNOq6dpXNVOUx+Tsd6+OR+DenR52rv9K8u1ke3rJloaoumH3+vuQusnbm4poyTduJ+pm1n8mKSr9XMpshYPsf64/R2lrXIQba6WRFD3YPWg+gjt+CNuP1VBRX1xZuP947aLeII4e9PGuhmJcnAGVh2U4RALCVBdReNloQ6r16JGOKzDw0w64YmxhG62pF8T74sRK+up28qXinJ5yah+Y7fwlY8Nbh8e7jVusxaaIh9fENn82yaaeEdh8fT7icZTnYHxf5LOAUyab5JmNBzDnBa2yvtbu3D2qXPN47BN5FUx1jOmaxZ7N01eXNq6TOkkLFoO6qn9ocoHTVhT/J3Yo0T3g+O0Qi27ufyZ1yDNSnR7ApnhzuHpJOnx01GkfNEfAtJ+Kq6x5jjUZExJ0G/GYr2qKn9IR+Zzw+VAP8CPXuHz45IPQTrsjOkzYGzg7gM80yKALMG9J8oWP31pGYdGA/1SL2dGK/12PsdGgz3I8zOQzDbpqFYgndZuyjMhN5M32uziQdZewswrSIRgo/t1AuotHtoMCUaQYCh+jcHbxgxylVInJXsGK55DFiYKCFI5fLuxX1lIecK1+w0fQPaaFRBqXXgFLFzU3/laYG/HhZSu/LJZQx0OmNTdd1B9ch+2Q1GE2B0tWb6mIXuMFRW/G7SlEHwgSTACzfj22F73ioTI7LiZhb79GYghiFRUyyfKROqUuCPkER8y7BrHJT15dn2eidw5d0JaqGSroZXR+PtF+nWtX32h7dtY2rPrKtQcU92BpsU5HYNkF9HxQzofcTrpn3rjK4C8C0oCVhx5paYJWU5TIqZpeHkW5hPuCz59MBz1krmJ5XYpp5lQLjbhrMsq4fk9w7xPJbLuMtOLu77b02ab4kpTogrljsSpBShd6XVeVM6JsoPA3Ve8lkDy4lr46O810UnJROzSXM/LLmTOL+FoSW8GuFhq+xHR6MglteI+q1alR2YW0J/c7sFxLQBd5Umd48G41610X+2Xfh1Y+j8ueICjUdcxS5h2MPXDkt/JPj4+Xi0jaEq9OPI5caESrUmSQuJq8wu8Gwdm2CQu07aDFsTPpjoQGFoQ8ts2+bCQ9GdduJKURWulFYE4FHvAtny9sD4Ni7fNpF+agvl7GoHv8W9etKgRWLDDFPZy1dLmMPJRjCVGgDIs93Qif2mPfS3cceI7vX5FKj85Isl8F+Ha9ILMhyWeWjehahYrkMAXnMcUvzLI6GagecmQpoZMiSyHUmOkcnxro/HNQkBSF0TlBGMndjXgTNgDMqw0tskVpu73IZFtG0grLEUugfcAKBW9pzGCGsiZvZtLAd/SDsRLnZu5yKGxADxNDngiy8G6GLA4C5GGYkGbjzskATeH9gagPb/IOM2HgZC48sMAYlneCsLMzmbjQ2OQQcsglIdmNwzHAilLqKmrxuuWNtktwMvl1gDKdXSti2INX+t7VuKMivgu7UFDbG03qgoEujW3+6CO/25bKFCrHmmNl1GJTADWiE6cu50WgxN55GY1MfyFiE14+6/uJoNJgNIuN7/wcHnRZdCx0MJ4uTxKY5iOGf9UajBAxIZVbEOv6CKfU/S7zeSw3WU5+19fyIrEQWQXVIJgv7aJ7qMqVFrr+yj80ivy+nyRcKotBusS4/LFudsxla1E2XgU2Fv0fAB0pSDffYaMSxAKBYlBEjglFaPERGMgM+KMY0jiJClWeVyh1rbYG0O4OaIendWSeTX1edNqzFs1xXJZdvFT5ZY9PGajeWOZK11YEDKoOF+b2sIHQafdKYHOnU7zGF7gla99Vie/cUtZksG7d28c1Jv84GIwoWCDYmdYekADcUjC2aAFbQCzHyGjsB96q2xuEkG0xjQqNIRUa5JyuCGUPVlLJokibVD5hHkcaXGWu3Hu8+3msf7uw5AvBazF8Vw+sPAtYo5B48ZS0FgR001YhOt5WUQKTcRF/zFgjbC0X4U4bTor8kJksSy59ECPgRDQtSPOxG3NwWs+x1Ng/oPF8j9yd2mXUlu8ySWD56RCEc+E8//dQ2DzvmYc88HNo8BxS8pFC5Qjgtn7qbUSZm8K7jZaSuRVs+L7oE932Ndh+g9OPIzxmV7o8qoli+YdZhkzUV6wUitBbTTB1n3C+YUrluWOnDx5VC+/Y2sGi1xdj9r8vlZnCFa859bG5tTw5XM5B2dYK8rWmqc5v0KSvtNgqZY5/Qqd7Yd8KnXhF3D6jZYDaD4ikAb7Ku8lTVjr6DN22dfrctovJUlPGlNWgQ6TjaR6V0FOkUtIxXw0YLRXHwCNE0uV+LcuVeGmfbjUNZmb4rMyru2SKihq3huJaKo+kt9k8tKsrsDpwZxepwAAiu40o9iOqRn1qgga0qib1FyN+pUb02ayYDEnYD1nLGh59rZsmnh6FavYRYGt4jaueMuEcglcw0izomjtvsWAkxu5KGm9nukJr+6BLrph91y+MIcvnAoeM1YGMOYt2kQzpe7VNxA+sWXBv+vlOqt7EIUSV7JWhZcAIO0uVPrlTs0ejuAlFqUhFJMJaqSS1fMxjHIvaQcqPoYfVkROnKJYnXpaHSyYYRmR4QE5zICxGe5aMHAnaXMaLCowdJ8KZIf8ND8MGwK//6AxUlQOx/9DkiwYeI+pxJ6hSGNv10BTCca8M4ZGe2qPBFuxrqYkt4EjxmIXGwJpQgdZQSlZ8XOC8mcDC4FjE3AEgTPc0ipQpk4aKqvkULp6XHnxbofRrslc/4OfKQDBNBY1+P2qsaPBQGFSCg+sxHFA1QbwdTUPeFLQ4CK98NebDWFL0KX8YtZSkc199KLRX/p0wfxrGiwQNgbtGMn8pfEo/VsVzqfWO5j7r/yUb56i9ft/WYnb+zYZwk8etRSdQYxbeUL8EqDklshaQGLDQalVaodZOuogr5E9RxI4Nkp4QDrP3Yr3i5NE+PZPlyJI1GP47U141JJuXG7HqQb8zh+40SFCC5ZZENC1m7ekrB/5VAnRV0xgIrmoA0ox9HI4G9ijzw7piB8BQOv4o4+th8Gfcltp62f3tUJp7DxNvAWHHB5E+tbgCjVUjObrn59XiQZC2PMfIIo9X7bBRSHZhB1WqGyDf19teITGUX2JCihSaAfB5NAVajtfR+5QzfqvqUZ88sjtS0EtDvq6sAJD213maxknougLZrLzwxkY1JqBOZDEPOmuQz/UDluc6gmQMmh6LwJFn5tW+zNu3HESRsoIUj+18jUJqV7H+NI+rnNEz2BYs3xXKJ0h6cc9JoSB3RciSKmfeWTadd1JxJihwq6ngsd5WC7irukNuuEmCvK8f+aaOBLIAUzNFO8OMI4626VzgCnmfdbJAXtzFWp180OgWRMfoaa4uNIFPk+FCXQ90dkEU91eUZwVaNaK/umxaZihwf6nLowVIzbEJ5tRanirTu6/rCanOLXHEMwBsC3Sy8QwZoYll/Y7OchGdNZ+/FZBXXqUTh4pkLG5NWhc+h9tCOBUm4r2cFjkabIq+Zjo5SebONQeKbHLvikeR1n1/otHdo8xXfh0V5paIAApHgrdHw3x490sDJzzAHVWJFCOhIfoZeMNg1eF7kpKOGa/eOc5HhTSvUEws9sapSbbQhLbMdug9IjUrAK0sdFrj24cE/uY2GflNeFPxPP7W1arTBhiwQgct2c6zuocsBcOGxKaBFbkUuM7qhxf9FMHH+G7A8uCKiyjtHGZmYzaOPC9H7OX7ImUJ3MDDt6gNpNG41z9trB084+kL98RE3bfvl1TezyXWZh8CUamUmBaqyoI43dbK0KuJc7ZDg+Lp9PsX4e7Zd51GhVgJbyu5cApTrgVo6spmi0iz6uNGPZ+K8W7Jl8T6Rrv/mCy/8dPDW67+fJ3gKrAp+FVoqEFGF0yq9DjqrG10BEQ67UJ9Axa6C9A1L73JLpgNHrp7PXbq7w0hBZRT9zt1IyWZ7ZVBY4V/iHqvW2EHXfZfq9tSfABuD/807ofrBxKUOlEk8sNOmuIhqVgxwhbZBJEVNJzal8UKva6KpecI6Ow9tzXK356zVmT9NO/PtbVKczc/relCaLeJzsXVULWulYYGRJbZAcrdcmi8mJNCCtknQwUesTctzjJBZDUj/grESvXeaareHyGvRukWQxdcXRNB2j9RHHXcMrBoXNbwrLHkf+wqRscKxVzwFHuBEuMyKUoyLgElQ0yQYDNZ0pKhwpgt/0xV1akYIOj3qqrBiT8e9MmndKlWYFI428VCJMl8NVwvoFF67ZIORBXasdkVpLRHvA/kfrnmYvbT+ZqVLK+GNaA2bUw1szbBUk88mE9OqrAFai3sL1Ps8qXRX0VhW4CuXywd3XazpOuzOdbHQamWjMoTqFqBLtwdrFNpoiY3q2OwBa1Sz2kvs0oBrpr4aPrteW7hErE5cez3QwCw1xsNArIC9En7fMAMB4use2SdRJ/6eHApxUygaua+qQEPQlqgvEqr+1Q5zPh3crrk4Ka+9Pwv/AkH/fwpJRgrB96ejmJDQAHgIVxQH9/VSNGtIbyRe0sfCG9HxpQwxrJ9sWDzp4V+u9ZTc+Y3j56D1OPUaVFg6xIjlHo9acb0hWDkoMAXKMilAAmd/KYwwFu22QSXPYtGqj84jIswoOkWsQwDxQ6yzOGJK2e/9MR3cxjX+q93Xd/jlRWXLIrKnwEbgJ2kFQy7hAiMjPEacACd3lJ3Nz92eU+5G4JXiF0Kqcm3m+9Py1iFVPFW4XjEcDIo37L230tu0ZmqUi10IqVDZwmfqW3MAzLGX2m3cec3JtWtw2mjEp+BL/8n+PiH0VKszrjMcqdO+WaNk/BcNWMLT+kD7nvu68zctfB6gCKQEI3+vVy8U5Po3p8eqkfx7ikgGjJbUkFLDIWXm4W8M9G/aMdXpbkDnHnQOoLv1HkLsfj9pNOITxuP9/fb+Y0LoiS2NVyMY8aAlzo+NeD4YuxhrwmOtJVJW3GfwMDefy6a6dKG/fHj37PX7F2/evUqf9d8dP3v+0aW8fP0z7VWy/fHywy9p//j1zx9+SVt0zIxytuPNoHWp7XOZpeuZ+WjN46ZN0HSSHqW6IIYXnm5y4VOIRgqmCGNgN89Jx5QC7SpFkdl6mA0Dg4G1sAq8QQAwGl3xtBa1Sn21yjgN8HvA8TQLP61IPVxlcfmj54TRzdUa8yubI9Bz98t1xjW2o6WpZnfh0iThq7tiCIUBunyA/HhrBO96TpULLjfl3rs1Y1ZOEesOXZlMqstjhC9r9e1tzyzosSnM+7pG/R7jbdmS+MZ0qkHvANO5nWaGoWIfvRW0aQGC3qlxfO61s1zWjdeq4XRHIjeiFLNxSeKV9/1wAn8L82t/PD4j0UuH3SUcgys4QH7MWd5o6LPBCa3wuzUKphwHwkbsQcnKdgvOtZd74SgVD6m0Jld229ExofblfpXRALCXehFsZdhJ41JdJRp0Vdvo/caJ2XwjjSOXR4u7aivCw8FqnaWsAZDAbzYwjNPCh3DSvFo9SwESjGDm79bDtbgIp0fRq/8B2LZm7BVhoc8wLA3bmKN4wwW5sD+0riivbiuJwywOrLjF8aukIpw/kGkEUHHtOtYrOo9rsgQbLPD8YMK5rCi6NniwVas92z0x/QzL9aXI5Cyc0I42c1vMAmuu2fSb966glo+2b3qMfVX7C8wTblRdU8evEWmaTtExG04ZA4M3kI4NBz68ePSIpjF36rkr0Rz6g5Avplmmui3Buw9gjA4V+kMDImoemn/4oIkFb9pc926U3U6z4WCWJRCb62Bvl/xfbIer7GW37rGXPfURwBN2Wm8s+52d3ocZfmSnazDDT+zUN5H1nLLQLAu/WUczlJe+eE5U6KD0reSghU7M97KbFLDXPSpZ27qtn4vbIARKsPS4W+cPt6ado7vYv2hNO3+oNa25Pn9gTbtlrWnXOLKYE3tqB5MeetixjCkrhPYtYgPOVWAuaxS/bIJvYKo1GLR3EMWvUc7INFQJktjm4m9ZpIYWwDBkv7sKKJivgemuoorfTsUwk9IvI/Ly5RrCM/zqEyRl1IYC2QFt0XlIf9RgcnMPpfkIecoNYY060As6M/GGKEOiRDolH/VhkuXUPbLWCk2bSGUwyg+hMfyck7tHjzxYS3k39jizc1Dj8d7VHngFashK+lJ12gLVe3qIQ5i9Auy15nHx8PzUaynWfGF0uoiH1Hk0W7A8s/cNByNIty07C1BfVLsCnsqbYLncNIn2LlkuUc3UGAuizNPXtMCbHuAGjJ9T8CSUuLTYpK30PeQ2ql04l4LL5O9P2wsv52QgZ0drv9p1MxVZssI7p+EUt/9D5uWlLgeW99XrGQ2CQtSjZJtsgHGIknjgNbTsJipIX6Ox2VfIkr3nsV6f6PVq7tR4SmF+nlojbucA6R4jblxSSxbimzmaawhJ/PY1LPLVlPn6t42/68vhBWBL4RvTqWvNxUfC7OogAqZw27cwyPDwgi30c0WZ0jMU6QoDcZECyrIYLy4QjCS8aychTqnNiDoPqgi079KdmuUqZCQFnSZ3fLksw0Ct7wRQvaxWay9Fq/Zpr0ir3wgJkYJIAsXjHqZaxFXYtQp06RxwQXFZ9WrS4bWslrs9MppmNvsGDeEBMjltg7pDSVPc4UrLpAAv+nX4c2eu8XJuBQ0L1qI96A7vkPRscc445crifLmMe6ghzZnqAV1ss3YnbQ4mEzU6yXrU3z64DbR8GkP7zJtGycSbv+1tKtZDvDk21Y3rus/UR52HKc6HeBggEs7IoAzO0ExaGffwjokGypuKDuwzbu0QARN3xAu/jIPBgyJwYH83tiFjaZ8eefNY35FHj9zZ0mY9mtblyr5ozZSRVaU+xmndnvPOkHehekZ6Gjcs2x3oblSuA1R6NDcRvujue2dhMJm8APhjvBErqOTxqDi58zbHo0eUNxqIH6DRdnDVOQtxmxzVHESfyVX+qBE8b/iKvwVTXMO208fX6wXaczk8e7mswwHNMJdLHyzGDxkOqnaHZyVA6wOZup1dJVU3wErrT5lKlFJlTbN2CkVoK+Ko+TJfaLMAhZfAWMTXhiEqLJ0xF7EeIywTz97fC0KD29hjj1j3dUGAapUUO2MTrexQ2pIU/UrKDrEyaqDuAoNfsaIekQ3BA+plS2v6R6MLI9cDUdK8adkIblNvBLiH6vrP2Wzmh9TG3nsDAxFTCW1WDYGrWNvGqLlxIrONdR7/XBApGj0/fttqtXYjsvJCmmMI83tEWeoXtMRfapysVjhuxNpVwa9X8rwb99nDctL7l4BWi9A79Lea+NGeMf6BMYA3KKOWfCiuOmOWFQTmVKirGUylT+kHGwAElCTpM9/NzuQX1x8fjPqVKCG6qWqtgQO5G3r2EZ9IbZEfs4hDqnWOzkfYZhHoUfybHmdiF9Zk0WhsBplhTv+GZxZQA6rZZALdUgsq1DVHF13BzOFLBHqBqfpwoDXgWPm3zsUt6MopqNgNjmr2DFDK43wU3hHg56Vj7iIP7CrlW63qmMQLD/dEBz8lPyjIbuyE8fh4d44N8KzqooLDtRE47FF5T+7x13OOSpeb8+UyHiqvPH6HgQWATiRUpwsga0FFHRTWvatmzsJZeeNhsYY2UJJIve28eIyK9eONHC77gOFVwzVynos2PZ9AsTB+N6AazxmwDn/QKTYZRoXkeB3aPUELtlABlMZlbzFGRbLj/MmMFXeVrTPc7vSXy5A4aBHlukojOculQT1NoMZaHK1Tm8rulFy0oFYemlI8SQmnBsVM5hSWUEtKj7pHCu+trTCpIoG1+dYgn9usrbwFlVDasV51a+9nt9CCqmUmdF4LrGBqfPhW575UTd/2dm0FRV6uwtdxDCrqGG4E2siqR3Ay4ZZKWhaRrGMRyftZREZZsbafMps9L3E4fYWY0jcty6/1SwOYykz0xTyb9gaosEY347PoOltEVHNI4ecR/A7kkPOIRhc8H0zBn+TFQGYHe5BjKHfUz6MdVaB9MMnU0yP9OB3Mo3Przz+W21FESk3/9KhNPFam4qzc4/G3wuXV+mP33+z/F3pGvq9r/6MepGv21hqpsBItf48VwphpsfLa8l8VaV3zPctHD8cnjFpzDecJ3ANIamwdNGX+gCvdxgp2DFNFwhTuQJtHppmaCi7ExF3m3v2b5SPHCVTjEVbi0arQipQHxJHPBEY7nNBmBi4jzyVA4DTdwkVO/vbG/z/iebuuA/8B92UPOH0Pcl9W7xX7Xq/XdRtbZ2FH5qn2dDj3ZUee+7K6nPe4L1tRUF396zL6tLQwYXwZ38PhTJhMv2XfvLB4QQZ+w2f8a2ZdTlTDPS+XNo54EDqYM2loMq+i844fYtPCBa4DO1OxXEYa5kekU9eg1corOu4esVEr4ui//strTgUP1QUGG7c2Hek6CE2rJ8bdnEx0FR6ZvMaANkQRE9TksHYYEgfnQaGuSFTJWCjHkDzfkN368wLLomlNTv3D0aJDjNBb2HezS5PN1ookaL3HqVyZiBWHBzu7hxCxQk12HAF69i6TYvIVzAx6pXScTLA+NsnaVpP2XQq6PSD0KCj6dipuOFoubNn0awyy576c2i/GfXc5Dhoob2szYD+641cdrXMk8gwCOAZuZ5/lIz0et0vl2eLcaZAxJ2M9Oz03Dmz0NwDGEoKUKuHn2cI+9fSTiCud40DYEN83ich9PzCBCYrfRRDdQVdOWL3bUd96AuwGvpt8spSvfJALBpwhHQUmBNZnp+crhdN7nNjFLMBtqRKKnY3DadO16CVsTnF/aO4l5u+fV/OotajMmTrW4OLUFT89r/gxVa78VWVlrUJvWv2Jgm53eQw/JBH3trzCfx3frePZEY65IJz5TXtU6eD2hZiC2Vqg4Gc7gPS2LIcjJ3cQW7fL7+1OIs62zrGCFYE/q7jQUkY/tJaZKr1x3QLNHzz5c9ywnXCIWO+Wjf62oWeDccpXK1pnVBFY93ilYKtWdiSNNCh0pTZSlRSqpv544f2dgoMPVdckrP7Dln6FDnnpiR+NoQSEnNttOEvP/PFbCEN5KbDhd1o5khDokZ5SC70CSF2ZLkEXJqvVW74vd+8v5R7/pdx92+mqC03rP+pHlWyt45YablfBuLeri27MLRjmFgxzC4ZldXULBYaTGPNLlRm0ZsO++Y6kbRPzchh3EIE2GvfFPkNtBuAkmWsG2vN5bAAzgdi4dxwCWY+wMWN+Nj5n+kaA2Vicd5zfmx9UU9y72QnlECgKhm8M5Jw9r7urvMDRwFSBY4/xMtYhlu66m+e/Zd+kz7r0Im8B1c4vY3fFebHJFVixduz35YFqBFqJFc1LPpllUx8uWABUW8XzTA6n/HamNJJI0yFRK5wdpW2tTOIQ/lq5kYNVCrd8f4s7VDopkGDtjnhaDpfeESaeF2dqFW0O8MngvyR3q474XztdM4tqBDEnsHbNSzE9Hgyvw7HWIu7g0AEkBD+YgXosk2OIxh8VjcEjdqWfazq5Hpn90Rqhy6qVC7soVw8mWUqUyv80Yr2mX2o2Q790Hf7Uudk0m6Ng4oyfdwpvRzL/BZjWyIlwvQG6P8JORzzfQLtJX3NoHdEtaWlqCjicCs/4f+m8/zN0nh5XSac7xUjRu3st0uS5vM2GMzpnaFE8LORM3CyXkU6PatCU2Iv/rCpEv26+emk6nAykBPF97zobuiAZm3EgWBOE1E6eH5VzsIF1bQzkxsDivJGR6rj2jRZxNhh5aoSzAZ94r0bNthpFWl1mPWiqFHlXNBo1R83jJCmOWt1x5OuPRmTLR/TOHfX2Cn0+u2HRs7vP2bckgusioiWERkv79ZG5Gw1mg0R64pOON+ifWl07I1qkkrgZE950Cbq97RVcrajqgnbWU+mFTl/TEdvGqmOsz52XPq9Rf/3W9aC+fdU67i/LmlMFA+mJ9fttMKZ22J2uaz/cOknpA1YEY6OPHnnlqVzZjqIcpdJRTDVSoZqGSnvUVDYWPK/UBYkaayxNqwZMUdRx+IJtkXIWRdtCzYNA/5aLWYfwbSZNqkVE7GDQcW11NJh8bx82CoycOYxbzshfUA7CNaY/neRycJnF4PO8BWwi19Mea3UWHYKBQwazAeVsTlPWAw+DnrztVlsUYbWE9rZ1drMoC7YIFGHm/rCUT+TquJzTDxWwxjqiDzeS5ya49MGEVZeEhl/qM0r0/SsZK3/2d6ZxUizUa3qVzWxQ9MQmWZUD6hYQXRFXxompJba6bdmWdc1UKgh6cFfdaG1aML2nYPHlI+Yk0nbnafsolY/OmfzJWNl1zUOCrqfm6NhRfyq2WZrAH3+mlRPYR2xOyF2QO97GUOmoY+kBmWYIn0qHkSSxl5eqDtoG54Qol5mr7W3u8y+s8QKnRTCJRsBXM4lOTURNYuloEOrtL5oy5fuEqx0CJyAWhMpHjPt7pmMUL/UUz/VniHf109zMzDycYl0bdc5raYsu3MwuCLlbgBc9b2ZTysszy//CzHKq+sXmemYX3symtTObUmFmdk7Xk/i6aIqN0ZCYCt/uVnCH360oWMfNrpMWVWjQS4UD4a1MVqtz4qEbqxh8FoCB2sNCQ3eCqAWI5zzLR2iWYFxm2w86gVrDBUgoxT1w6XcPcctpi6AXz5KXis0qm8XPrvQmdbD1VXUcpv8VG2rPVvFOM8/8eJmYUPFym/6N0EB0/jcEcgaqp8vlvBuLLvBFElkvfevW1eRbnyyX8Y+yKN9nQVwLPX3UqFokP/hOlX+z+O8EPXKunf+atNGVsyumaK2QewUGK7LLy/PG75uRbmm4ek+jfhn/O1PpThTla+ezlCkR3XhtPxSzLFn7fWXWZEWLvLLFbVL89+JUGYumUoZyKM8qi3R92SAy6N/cEG26Nqb5PR+DHodf60w4S1nqrL+qtdz7vWQsRlY0tKZ16xaml2wky67Iyu2oKI6etvhyWYDCuJfSdfriAhQuymATuKAgNv3RzWIMn5vreca+ibCyEa4RO2RC3u8X1griVE7FRiYdpbZ4twIExXeh6wlCN9udsrhKMQJAkQAscBy7qszPpIVn3QO6qKzVSZ/yToqeQtNzV+AsPe8EXgmBw7SK+XIJgza+bFzcCOuKfGojHzgvgGhFZFbU5pxbBWqnAEMX/qgn2dVg+M16PpFeHWPAFXqsetLW7mE6ZnU+hVG3uYcKoGgAiswqsqJ9VoUt94lOjljFJzdEEaB9qHoeVL3F6pwwO0YZWdFTVuM/GZffXrFgzNiNH9ZJ5VgR174g1GuJJHPQve7GD5jI++tRnL8VPfF7PtVGU8r38xeUWRglqDFZmWiRtgSXnqsJa0wks9kv2QAd/NVaKw4uxHQG2t/deQ2ehqqcIi87O9d+jSHgpf4Owccm2SyDrulMWHNETwk6tPvSPYlJYiQv2M+InlhhDNZ/ZN7cMKne77jSjYb6rCHTVqk7p8S3KanxcB32sfw57G/4zeuxGo06oV/WOuWubcFN4hrn24v7XPmvr/LoHm/jW/c0d4qgHX2gJZL9dFfLWnUMUc0MDfysgV0MsEYHXwd8Aqng/XJ2nW1cTMVcZtMIVLP2Hz/ev//y0CDRXSHA/1U+MF6+f//y9c/gO+M9XbDUv1usi4vSraKFl5qXK0NDOPgqfbfmynu5H98PzULigF4Ick94ngVGUJWrQnhXhTRXhcSrQlSuCqij50PYW3Hb09rnwVnWRG6drduZJUfb512YhEQ2b+GCTeBlpVVUQj/oApxHg+olwydLZT/d8bjgc6OcJCNilfVvBreeoEtCAAxF06dWwPOoctEaCkubMqAZw/wv3dPqfkelT3W5agGvnU2MUTA3oRc2250APvi6Gj0tCrYeAZX3TaMfhk7n6J25CxLhpF/pqiomN+gIhEno4H04j61ezbhuMFr80IMIBab0OAgG8u+Cc/0c16oAW4TPQ/30NTSPxXKJrsC0N3qcVEnnNP2pFQ69h9bJYN3XaPStBBQOGYFbOy6njeOe1TjSIwKcZ1QMsxiaAliBjnX+EqJpvOu8eftBebXxRER3ZQc9gR5t8EU16bayzRioiL/QkWg9eYxWny4FqZLl0FRdCL+lxCAQMoZyT3HJGPRIMIedATdvuXw1mF2Da04xjecQFHe+XM6ftryTWcRplyfRddkNjZnaoAIrRw5Mcg6S9sHu4R7cAnslNQc3hzzefdxqPSbN469ZPlMo23RFD/eePH68bqHqpUyCRmmayVcCXFNHRu4MB1GvrGcVl8+yqbiFI8CnmTbf8E8C4li2sq5M7kysIAmodvxkr7UHSljo5PPg8R4ojescNU7OygAcgopYcawD1m1fbaF9nljLt+arwS2tqHxUIZgaZqW1Vm1rLb+11nkiYnAp4HfAhVye89n1q2I2gHZkqITAa3QTrIQZONVn/JzEAnQRgKyZk04M9s1oRDeAeLKLmTK+jAvKwYZNABTSSnr4b0XdbrGzvKKHT/af7K7bIaL5C1Nj/s+vPW5NNBP4xRknruje4f4T5dH1396vtH4zuQ5G//VfANgW/3z5+uUH9JTfBNDbjeCA88FE2bmatdy4HUiZjTZmQqvcvZ+JaRYlYJP+lYtCbkjMP82GGf+ajTYuviGSpWDnNFq3ADs7B+3/8BFdszregVubA+ef+Mr3/95K162Bs56ZB6dR4T+b85JMEud5YyQyhcVeD75mGwNlP2omt7nxavA525DFNMM5r1szcXPB8+ydKiABJx7kGwrQbsyvhdSKFXJjMLWLJpuRCUYGx00PhcTqXnCQhcuXNzcF4h7dzdpk4BN7X1A3E/BzLs1jbN30RB+us41oe7H9D+gl6I3k2QKECjASkGBuHJtXO9CZ2LjIYERGUwLK2dabPTGZZBqHm3rp77KhmI42ADLhxF2KiXI0vXFrdRKSjegf23MVwfQfEd2I/kG2/xE1/6ExONmciffZl5jAIqqHmVDIK6lqwDmzcwFG6p5ilfLk2LFGz1YDITpxw4+2MaZNz6LZupvfosQ88kxGZPsf0OleudMbl6LIR0D+/AOm15vImQAPGCPgV2ygMXq28TkX89zsBDMf3zbywU0mjfuANXOz4XXZdWtjzicTWCZ+lYtpNmr+Qxus1gIGvBH/U4CBiupdwfA6cT19mX8VQ7yc3g6mg5sM0JVMysGVzomFXg9usvBO+BEouQ/aIPADw9j1WXYg6vt/FCAFQ7FHkj58NlKvTHVW524Jse//g5eZZb9aCkmSMivgHxriwUYVcATULGWjDettc2N+neUbaPgCJz/6xzbHixByD7Cp5sYHoXctwBiVSDe+iUJpqWULCJDEZ5NvG1b4mm2EF2PzH2TNTn+8v9M6TGR5iozOelSnT7YxzW6zwQy94/lmwo7artcSs8dysGGV4nSgrMIpAfLLeCdI2ZY2Np6CPP9PAZmEilFjp18QwaTqMtqI88uY20C46f+XvX/NbhtJFgbArVC4umxkMUUD4Bt0mtei5Sp304+2LFXbNAsFkykJKopgEYBJm8T9OQuYXcwi5sw5s5TZwGxhTuQLCRCU5O7+7nzfnOnTZRH5zsjIyMjIeCAVey1KvkTxCs4UruPS954FSsEpeWb3kV1l2tQ1uLklz54RG0c1oqwOAsKz9IbSFPccq93YB2Qp9NjmmSklO1CugChXsWm4BhpbE3xkRqSmzqQjWwYNDXmkUOMmjpeGy36uI8MVI+uysD19VSJSRbIyzWYjK3QVL1WGY2fp1+Hyhq5UVkdr9yqYU5lxZMtbEysANoYdK/e+Dqo5Mb+RdlrdNr9utB2rBWQnMG3LchqIubPtwoVVgxYAUmofDQn46t8Ccrle/f3btx9wFIPngfEEROm3+JTc1lkKPibjCeboebZakZA82yZcNgoqfG/C+AZ2WRwKFK74cWUazpO7BZxuIRiTg3g8qUfx6kM4vPFX7J4CaBKSS6mpMXwa9pFYkYBcjoe12kQszOfPhlue88VwT7m3n019Ha5mp3Aa+qtvplSf4PA91YotwsWvh0uuVck1azAq5P+q5S/C+NeSIjOtSLCIi9kv8i282i8R6WO4CWIaLf0pLRQ6LwykpJw8I558nj2pxzSCi9ZAVFLrfvby7P3Zm+GZUCFRAbYDCLCdIrdQfvjL8/eiKLhJ81fDcEafxxDlHqXa8H7TIP6FXgeLwvCPtXz29JLLHRsuM8/rG7/BLeZyPJwMzAsQVA1rNeReSIe030lSj8M/6CL4LpRWL4X+DISXRf1hjXwf2xNcmMT52QdmhvwdqMIijN2LNNd9XYOtv/gGGFsYoMkH+FHtn5/fv714pzYQXtE7CvrKcNL1jQFMgqMuE31yJLYnoKfnYIMYzCvMxzrnVens9Bu4LTeOZPoijF/ms1yD20xm2/D3Vwt+gbhehckSV2Bt/GlMV5W/HG+D9C/cdWLlL4O/aNvz95o5hO2JP9bliNmDuJj9R4SP+a8RmJ5+xKfkIycJOiyQ4cJJcZyR+4w2XCzuwP0UnVVQnirwbk+JOSLHXGSM6uGSyREGI/lrrH5lYmZ3tD+CneGqkkwAKH6TsSg8wTM6pzGtiG9+rfwE/sKyLthEP8GgPumNb/lSU4oDin1Knvxmfp7VkInZnwEafE6f1OmGTjXcU2atPh0wpdBTBRxFRgFFKc0i2vsUMNW2EA4o8enYmQx8Om5MBnqJBivh2k8sl1JAH5+OrYloHO/t7XdnH159ePX2Db4LFlDhzt+4ARX795TDHSxXDm9y22nk98bAcO+Z0P0jsNgA7GL/evO1f6F5mzf/xLqvg5/+9fEf7EAS3B+hmdpZf2AHxRTCFvgxFXsbDMM1vpMxthHxwGas2S1lExhv0A9zJyQxEXkmhpjU370959PkwzS+GIxBL5yV99c55XUYtb+/5G+8JHggubfcsQEhkRg/c3he+NWbD+esnbFs5/3zNz+fYXgodJtdHIduq5NO8K9v378oFNRWptdKcUn9Xgfq245TmttuQW7PSid1oQcOgzERwr/+8urD2fm758Ozwz2muCTVtsqT7fJkpzy5UZrcOFC6Xd5nq9O2SqfdtXsOTLzrWOUtdp3GwYzGoYzegYxu58DUnW63NKfdcjq9dCIQPsrjmDz7GTaYiB3/R7bA9vjXR1bg91fg7soLczQoNP7qUcV50xnfd2A0Cr/25/DDVXmXgtMprzT+AVRt/BM4MVEjSTFs64JmwBbuJ66FGZvl2lhSCtfB52cf3AZm2Ok2cUax3RZWzK3bxtCh2wGdMrgz3UNOvOwKtSZby7Vw7PbwwrUt/NW1bXzl2g5ewSz7oXajKZXIk6i+oss5cOVPzM/jz5+/fJ6gnfn5Mxp8/mwO3MQcPz95aZ30Jttminab7NNJ0c60BmPrpMM/pub4v56ffBp//vx58tsAWhlb8eLr1WqC0JNr/YVUOezb4CG+ZXfhIG8NOCLhoOsmGWeRYLuNXC9L8FjCJkvY4C5yhwPjv56fDl+cvfz5l1d//dvo9Zu37/7+/vzDxeWv//gIQ5v8VhkYyrfYELnr8e0EXJQwwQRT3RiK888cScnok/H482Sb/nZc3w1+qploIi4rp8AqnxK4/dVOET6FNx8c5vl9wszLyDOlFbGGWcN19ZY8YSCGV01zhnbgz9L8Fe3MF2hnniO0MyFz4H7+jMw6QPO3z5PPnycInfDEAUuZQEG2Zipxgp5cC/bOXJNbzvqBqwgEtgNje4KGIgppdoGUopP12NGy5eUwy23olXM3uqxMUyuj30CzEq18iVd73bQLTRzoqaOKlZwB5nrcnex263FvgvJMDZwM67FtTYq8Dmua2z9uyHpsOxMpixkP8S3zWPgKUGfSL3SrEYvNHgMltLTMoMArZRcgZjlpMORhRYmUX2bytPNvi9jfCImavEmt6HUyB9Z/s1zRKArChVt5YtSimvHErRi1kAuournHrrylKQvGyK0lNB874fIbyEeLlsosgi8Cs2QCj6OZqtC5f0V1b5yaUQf/Tj22tcBylZnMqB/cdqbweT4P14OMtCaumY0o4Q7veW8k6xjh7HdmeFZwvuFlOQhnbXr4QDt7ETOl6u2C2foa5AHJ53P5VMQktvCSBg9GFVEb9YtQ0vtm8Liv86Mf6jzfsXhG0B7j1Tt7ONj3+TpgfhWY8R5y5W/1y0I42R86X0pSUAn6dww+gxo61C1g0P/CrpM6dKDMwZiXtW7zwLNDZsiX11AXUkUVz7NPXSaXtp5KR/8JCU7sZ8+e2dgj0ThhxvBHpvX0GqJTIMT98FdoH/JIyBQWiIcDkmh2+7oLfd1x/oBZzIL+RFb2D81ItGghCsX70mmWNcEwA3a3hEEF3HcWZJBAzINPAFzgyqbgyQlm00+ervvSR6/zk5nUbHQC+tLgVAvfkk0NNJyljynr2bU5xAFCt0+9ahW+RniIBiab9AhDMRLghNwil6cNuXOugHnc1Si6Vj8oAk9vJ01T5dQ304rKr10UrvhJcBJmv/vaTTkYBG5UD2YnYT2YpZpjArWplnQF0fkOejHX8uuLcC371pL7YT2Bl+cvc+otdHxXCx5ATXAnqpxEJeSFH1NG9VnWw22IgideKnzFjSd4A/8MiY1vuT30iDTwKSimH8M/l/DPBSmZU0TjD8EdDZN4kP3kroa+l5Vnhtiyhv7B63wk5eHr4ld3d3QWgF2T/uHygH9ySX/Ou2q5MTdKKBb22T5Q0Rylz2P0h5lDKWCv/RWbyFMSCZzqQyGs4QUJ4SgLVuxlE8riK3MNGvm8U22z/iI2IIMgjA8fHaPMjdcNmLMeg5D5lflXzfNXYfDV6t/MX7A2tpNI7+SvjCPd8qVi8c2PbPzd9CkC4eEJCFdlBJiEjPoQgwCg9LMZgL8/GIPs6bZahX1VmN0zFgSrWj16DcyaNBy+zcUmKVlrD22zMhKtIDJlEK6C+NuIfqVzof7g7fX5lIB6CMkjc3kwgeFA62bo3hIO12r1D3ONMMyS+5qGzz6fb5otwS3iXvmOrAz8H3Pg/8jB/1EDf4AwPAhIsnKRMkOq+bet2j4J2z5pWobPC/9rcO3HoOavDmmVVo9Adp3MWWCUe7NB7WWxTOJ3MozKIwoJt08lBYV8GlNwL4sDyifC8AfPKWnhKfzMNttrRVCOzOIqnUzp0znVNM/fm2r7ASml0j1EsWJ/Son0HnNkMUwNSEBN0IRGCsjB4JOJXDM3UkESKywxLUfIj+iTTg4/mu9Rqu4eZQsldBWGN/5iQed80Ese7iefhWeUQJCFcBU7ffHDroeLO16KvMe5nmdQIopFG6Y+/ly5C/M9tlCaAf0VIyeURJiCo37KHAR9MvUQNH/jxMCn5CLvKnFvlcDzYIBSLfkVc9rJtydpYT1HklyVbevZo3CtMpp6xhs42eYqr6HnvVuFVwGgHkc1LecioqvTeTj9I1hcq7qOXmIKx+Vc2g7kGMMoT3PSXLVwEQeLhJ5t6DSBCjq0j3e7093OVOQY5apehaspfQlKLO/9QnBH61m029lO62k0AMlOOBcmj6aRr1SJ/T9ANa6yDCPuhShYAD8aryldVKyKv5hVbKeFK1ANnrevoGZl5cc0YsrZdFWJb/wFFKpcLSNpkhIlS0A48M3qzimxnkYDTSXapo0nEXJbudlc03iYrFZ0Eb/TyXEJtzAq1nsZrKJYQv4NRBvdr8RorF6Pub3JrRJ/Wx/xR/WKzRQcKg7/03D5IdgoPHKEZJRy4jDqj0jI6IPoMTIz+jAiQZrrfeknUfma54oJ26d3frCIDxdKFr8G8Y1Cy7yLZjGt6MC02J8m/9Ny85OLSEObXKRPLrxvcoKE05LNkAUE8fZJrRjqHhubVKvSFeEgIcWLMXPJN6Nz/xuqVq2nycCrJa7nJsTD2aS5MciJrT2CVRz3ljgtS09qubfEtkC9y+46DT2nCTm0WVj+W9KiDaXfT7bBzB3Waln8igDneAs3wurEdhOc5y/cW5LUbrFi6dwTO8XJMw9sHFUiSfCVucER9xyv+IqI/dqArPByYOqMlnsJxAO4heTEQ9xoMGvtlrGJEcJ7lAZcT+XW9CZM5rOPAZ3PyGs9A1zSllI9vmFGBzTxyzaMbrab2RXl8QzMVHq9rnNIg1K3nWAXZpRix2o0mg/ZteS8ovW5n7E34YJd2Ct0E9PFLOK6d1xYvkqmwglrlCzpypRl695yRZf+ip5DsjxQI3TI/RebrQHap0ox0JBtGfc7AsS8w6m/jJMVGI1O//iw8uGudyCDdyZbR2kU+3EwrRwY8VZbm7+ev31T5zKb4Irp9LKIWjJbuf5J05Q/JXhkvJW6ta6YXs6zfoq1fMGV3FeEqSzcVwCcoebzrXSC18rPdz0OYRbMEJE5DRfGV8NgNQUJJzG3TKYb4YjShRviOPTcALMD8yxrdQPP3y+Yb5gh5j5ibtPsCWVEgt2uYOwXocEY/GyK8DkyLtvtMzKUoo/RgRtLJEZdrR4xRbjxWjq5Ntnb0HgNjlX7vO+QyOKmEiMJlQ8oiMMUSZUzVmEcYG8SXkmnpXQRr5ivOoRKRsIcF6hQVx4ajEAUZIyFqj1PnxhuiYWkV62aXrW6R9e9AbweTufJDHoFUxjZqFyTieGat7UaZsmF9ZLLNQ4mYsWE5gl65JohlzXraRDJ0CnUMSlI2eVxP2bNOJxUq+X7epR594RiOcQ8gpjN9+/tLN6QblIX0VXgz4PvXI7ISBrZZti3VdMMCHcVKbQJLs+8V29egj3Mx5RIU/y99YjUORsNDgCbQ3o82QPxkZXBOBAwBl+lpcFSfh/LqHhu5Xgb1YE87HaGvwgX3+7CJDLSye9ulMIO/fdMGAxFdWeTnP7pesH3AaNaPSpuaLSVW06pZGc77n7IAVkJS4GFw1RzPy7JNKequGc1ek7JGwt7e0F77yu/+DJ6J/d/8gUuL9KbmCl90fEonefBdyq9PvKCLCUSKTzQOjSoB6di8dsLvGZpUKeQhCq+fQTek4UZJ0Kaww+tb+zpXzASvM7kuxuiBsW8Ag6ZnFc2dEs2/+nhEWGXjbtgYa5Phtg7uQV5k9U/fTrqn9ZqKBjf1k7hfWlYO52AeuQIm5saGaH/9AixpKsoPkOQ1eSCzczpokbWIsRNASiz4JpGZTaOqup/FqbW177Bq6/tdPV1EI8fOKzZCIfPSGHRlH8eMVatJippRgYbIN2f1ICYaP0paTq9Zq/dcXotpNcDkkQvXi3ihnN6ZgZFFDlpahLChJhZK9UAgRdH7BEzOEnQE5XT7h9u39trv4vw4eJJyXDSg/DoZ/EL6t4N7A5FY6PBGoydlAPF9d7CekV0L/HNIMqoJ50APF4AC8ttBqPki3qCzSg69AO6Hl1rf2+X6Pef//KccZzFOGni1SQZR/wBJ9yzF4lqxt7lvGKuacWfTukyriyT+bwirpsRyp6hoIUw7Sf16MYHlrrTtdsIs08btEUanUZHfDtOE0q0O7YlU1ptSGnalizT6EKZhtN1ZErLdkBH3+n2LGDVof0SKsfD5zK1FEYIuZJ/jvbBi4Hdsrsty3KaPWx3W71Op9XoNfCJ3e01LavT6nbxSbfXa/Y6LbsJrwuZp4iupdkind/40k1XsAhiUznHIkPsaVFV203camuSrlUYzxuWrvvy9GnD2oFDUycrdRVnoTH1V7NBWA12/x1WE9dRn2E12QXVxA1/C35L0sQ8v/HBten5ja9hJ4yRlAfDqHs+sTsNp9Vt2r2G3DCkaTkNp9Fo2h2RNCVOC7wvNGzLEUkz4nTsTqPR7UiSREnD6bYaHTDsEQQwP5C9baK/fkjPld4aJ8TaidFhT/3+gtfq9xQP1W/wgCR/UzwiVn/01G73a7URYoEFuL8mSRWaP42E8c3oadfKSgXj0Ulj8hv86fI/dlP8bU/UScSPCVbvlBPvY/Lf/22ePnEsiBlk7UwzJAl6+rS1C2FVO6h2FZvH4FEDD1HttgaBEmqb8fGkf0uGeEjWeE0EWnhMEwIn5DKVS5PUxK+dpdbGE2lfVNqUrEXaVKXNyFCkzVQaJbcije6svbUBmrcfTHKd8y/qWBlR5NRWgjVbMAvhA3lfcPNg3hR3D+bNsO0czKSghAXCiIwcnt/4KWaU538HSmH/06SilacULU4oOv/D9MQWBMX+34ai2P+HkBQzvJ+qoKdPbUYmGvZjCQxHi+QgVdlZ/1vQFfv/ZwmLnWLGwdxPWQRLs86IzGaPyOg0o93M0wzHaR6kGut9qsH2qeM0xU51nObj92oD9mDHthqOwoSe3bRbVrvdUHjQtZ2O5fR6PYUFTbvZ7NlOu9dRSNB0ehZ4E2vJpCtid1pW22pYTkskXRO73WtanXa3Idu6IQ3HalqdVlPcbdL9ORzAoU0eh7qHcOgwBh3Gn8PYcw/u5DCnLOsKO4cGcY2d5j6+OU4zxQyd/oWzzLbbjZbTtLrYhkOr2Wk2bdywmr2G02h2bNzoObZl9VqdBu617V63Y7cb2G5Z3V7H6vUa2Gm2Gu1Gq9PsYqfbsTrthuPYuNF2mo2ubXUt3LCtVq/btGzctjqO03I6XWw3nXa3C61hu+e0rE630e1ix247VqfrWG3stO1mt9u1rQZuOM2u4zgtaKrbaDV6FrTVtBzHcZqdThM77Waj2bE6XQzupaxO2+niTsdyWq1et4Ftp9mzW5btONhutVpW1273HGz3em2r3ex129hptZqOY3W7Dna6jt1tNBvNHoQldXq9dtfq4oZjWw270QZgNBrtVsfu9mzcaHWbLafbsW1s242e0wZgNBpdp2l3ey3cbrcbVsexWrjTaUBTtoNtp9fstDqNjoPtRq9tdx2nZ2O73WvZXfDVhO1et91u21bLxo4NU2g3WhZAuN1rta1GB7wHWOBDwbFhrLB5mraNG06r12lYXcvCjUaz1Wk3OzDWlt222q2u3cGNtmU1Wk7XauKm1Wu2OnbP6mEHtlWj0WziZsNynE6n0cQtq91rdtt2G7dbPatttVpt3O02er1Ot9PBvVbXbvRaHRvbDceBVbG72G7B2MHMHNudZqfbaXQ6PWz3Wq1WG9YIOzBKq9m1W9hh3VjNloOdRtvutpym08RO0+k2G+0mrGWn1e40mna3g9nOb9jNTg83Gk6v4bScXndyP2lstf8phmp6U9ALDn6Lqmb4W6CVufNvC4WiargLqma0C7ViUXB952tsF4tI4ewY84V+Y192Az7tnvh0WK5tFRuxC420oZjTlo3Y7NOWjbTgs6O1ce3f7Q+kwyq1ZBtd1nMT/QZfDXFUtNryqGi1f4St6/SsRqNjZWxdw2427UbT6WRsnW3ZsH+dps7WdRp816rDwm60et1ew7Z76rBw2pYF28VpqsMCNl+j2W601Flht5p2s9VznJZ2VrTa//Mc4U4S9FP1+xofq983cBvsX3Ku8RJB9MA9rvFScI2XT9vNrBTcIRnneHniTEBKZnd24dOnNqwoMIt2j3020G/sy0I1KNqZ1AQ2wJfdmvBk/e56Qaz+Be/qgp+g38lxTeDhLapNb8xbPMKnqLYZX0xqwfhisrPwRyLQPUE12B8J8w65s/rH5BSfkhHzgnBLhrXvO0vwnZ7gN7/XPu6s/wmeU6HQSKRdqbRrcirSrlXaDTkWaTc7qwyDHsOxNpz/X+A2yrJusNMtYURa7RQzwdz9jAiX1D2e9bXbhftyo/s43hdE4bbtCO630ZXcb6P7eO73hsA5ZHWsRluSmC83xG63msDvthRS3hBgdVpOr9FRSHlDGq1Ws+002jKJ3sDN125araYtm7u6IU6jB504Delr/vqGNNqdlmV1W4oxvrkhNhz2badrS/Lqz0u48/k+ez7f58/nJQz6vIRDn5ew6PMSHn1eyqTnQf0oJh1crWjhijnytZunZ6aQUBRQMsTJHpoGOKk11bNLrhG5rgqCsAtLSny5UfDE3dIS0xsFXrbBSorMbhS42W4qKUJvFPRxwyktcnWjFgM3rf2NB05AMdtV/5YbQKNt9TrtTrOVuwy0LafbsztOK38taDeb3Waj19NvCA5Id3qtVrOrXRaawHM73WbH0e8NDavV6DaaTruVu0L0Gp12x251evnbRLvZtoAH1i8WDjAO3Uaj19TuGLbdbvZ6sEn160YDuE2r027qN49Gq2fBjHpN/RLStNpdUODqNvT7SK9nNxpt227oN5N2o9G1Gg27k7ujNDu9TqfZbXf164rTBsa+AZDVbi4AYRtuD9odxmk0bacNXJF2nXEsq9O1rF6jod9smr1Wr2f1LDt3yem22s2G7TRa+n2nYXdajt21G/mrj93r9lpWt9HRb0GNXq/XsXuNRk+/EHXa7Q5AuK1fjZxWu93qNbudXu6WBEpTjU6v1dYvTLbVaDZbcKnR705Osw03jp6Vu0Z1Wt2G02509RuV3e527E6v0dbuVrbd7dqdXq/d1K9ZrWbbarbgOqPfuFqO07VazW5Lv3wB3LvQR0O/hzmNZqPldBq93JXMtuwmLFvX0m9ntmO1O62e3XT0i1qj2bQ6nbaTu7LZTs/qthvNtpW7vdkAjabTaeQuci2r1Ws57VZHv9PZVtuxO5bVLdzugDuGi5x20bObDafjtDqdtn7ns5vtjtWwW72mdv3rtmy73es4lnYRbNjAcDc6LUe7E9qNNghv7V5Lux424JDotlq9hnZTbHS6LatlOV1LuzQ2Gna3YXWaTke/PzbggHIaTauhXyUdy2pYjWav19JvlY225ViNdreXu2DarQ5sBMvO3TVtG/aJ04Pto107O3a7ZzUbVlu/gTbhltK2mrm7aKvXaPcYWPVbaafRsG0m+9cuqHC8NsAff0O7q8LMWj3Hgvk2Gj271e617SaQy26j2+ixRbZbTrvjdGzcasNDLuyIRq9p2d1O22rjRrPVtNq9VqOJm7bdbTcsKNq0wLi+1wMxht1u2l0YrA0nf6PbajUBXnBRAhlBBwhjy+kAwvWAWMF6OVaj12tYVhs321aj13DabC5tBxADt7utJpO041a30+y1u4027rYcu+n0GAJ2252e0+XbAXgjNtF2q9UEjAGgty3LAkLowB6D46QN7BgQymYHyKPV60LoAhAcWDZgebOLm06j2bJgq2G7bXXsdqdntzA7PzrNNpxGdhteCQAHnWYb6EHPntzPK7Zs51HCAMUrqsrDf784IAtTwK/rXbinNcWtLRMPhNodfV8cUGjEbrLLXje7zYf8Ns+a6bFmGlozPwuJQLEZqNaQggTWiCNEAp1i5fljawtpQ1iYCWulZCbq2srhwcbe4GNoF2vPH11diE1CJjZJdQ/yQ3+1+pYPHgEqJ0+hujWwXYtfHFq2Iy4OLdv5gYvDvjDkpkQaclMiDrkpkYfclAhEbkokIjf7IpE9mQi7ODStbs9ptDqOlV0cgER2O41Wr5XdHJpOx7Y7rY7TyK4OdqvX6gCn0cuuDk7P7rTaLbuhXR06Tqtl27a6dVxDc3ar0e21mh395tCxGg3H6mUXhzyo75XYKHlNkMlrbjThzZcbTXozvdHEN7MbTX5DbzQBztWNJsG5vtFEODc3mgzHn+NL9fFlji+yfsBYVPUDVqCqnzn+lPUzx5RmHc1xkH3dMFM1q+/Tpw2n79MacVA49um+mMinCENOzS7LqzWFFMmnT+22JVpiF4Q5JVDvpGFN8FT9Bpd6S0oEpZhTPKWg60zk7p9SPKcIX4kU25TNNLNWmjV7gvA3WURV+iLy4U3cU7+hx7Xs35ngc/UbcjaUzGiNiXHeUbKktS+0prbvhuIZBWnTO0rMd5S8o7WrXDbZ0No3qPsNiqHaei/3HHLPIRdz+L6jCpwbmkpE+wCL8YHD8AOH4TsY5wfKhgg/ajYPCPOeEjgamP8YhP/kX8f4EiIQHVMpLwsgvs939XmMA4R/FZ+2OcQfEf5FfX7EQ4Q/UrJh/f2D/wDo/J2S4Y05xLd4hDCN4eMjBptHhOOYBLT2C0xvFZPT2q/a1OMYBwxuq5iY4r9VXPt7rgiJ4xqNdxamMYPdx73cf0Dj/+CQfbeXu4HcDesGwLKIyXda+xMSo5gc09p7rcYixt9ZSRAZBpRQygSHlJJPID3En8hHkB7WVnFW5yP5XothfN9h8UCu+J1cMNniBbnECQnwJTnGAUws0qodw9gWUC+OUSaAnBP5o3a806ii/FG7VKlTmTqd1y5U6kymzuZM2Kloo/xR+6hSr2Tq1bz2SaVey9TreY1mcssbmXwzrwVZsn8jR3xTCzTYS9p0jLJpyKJfbmpJseiXOb7Mik5l0elNzSsWnc7xRVZ0JovObmrrYtHZnC+LOsDkj9qwWJTO8ces6JUsenVTuy0WvZrjT1nRa1n0+qY2Kha9BuKalb2RZW9uaqfFsjdzvh3KDp/HCHtzj1D/f6lVidSqpMj1jUJ73Cwf7s2N2gLwWrcn+2qB676u1T7kJpyYppSGRaTUzgKHMv2axlrYgRc0mq6CZRyucFBeAvzERzgpz+RGQxCBWGRnCJUPb4DX+yWkEcmrKDOQwJ43o1fS+DhcEoFZ5BlzeRQOIpawzdkv3WciIlxBJSlymbekBHtexGJYX0JGpPw75VwrqTCdyFOx8lG1Whgcs9OEVnk8DJQ1AYZAZojQ+nG1JWOeYs9bJl/mwfQlsyBUgk5zv64RMdhn5kPhIKwZhgsV4P8bsk37phnmXZ4lMLsAARQTDKGn3GCcTArmYChF5gZvX5y9fH4x+gBRq169fXPuAo7dYpl6cfHqhTc6e/Pzh19YzhBLa0/4Ok5liLM2OA6fBYxm+KtvruHPlzf+IrkzcHSTXF3xVZrRL8m1e2Rjbr/gDvE0TBYxXbkWOBjndobe+U24ii8WwZ8JfTXL2xfCYm1z0BMmg6IdA+GyXNbvoTzYYOVZX+kqAmOdwxWZ4aiBrcMl3vsLMOUbT8qLgAfnFXOmerCVZLl8qAh0NGIgPdxKEsxEkfIC3iy4DmLvKlhFsedH0yAwGCG7p+zcz4q2DhVliOCxeeYb73UeUUPvwnYaB2rc0E2+pNW4t20G0vxo2q1H1ND76NkHKnCLbA/WxFvB6kcG3jKARe5YHEZFUGM9Petlkt47qHwXc2kBIXs5AHm8n6v1iNlM95vZAxnez330wHmv/yuGnz5iFfP9/g/Md5Hc/XvQAf+fs8Yw57Jl/v/uzB8cchmG/LND/l8LZaB5hXHS6Yt/cqis73zl++Gbp7gHB5kfYAlpdHlz+xl4n8a5+sBKiu6hm7s/k5Jqe0vu7q/HfrXCntbrFLLwPRuiWO3QIEtRs1i5ZKgFJHGzxdNS00OsybWBTbNer0eZRX9IxpDADOsgWC/5fRwBu3aSMH7tJJhNwIgZvBz+jo/A8oJ1yRiwarXMiZNwyaMMjMW3tEcWn/V5eA1DCSE4wYFzl/HNwXf6QrGhMH7B+M9pXAlg1FG1WrRfhlhaMtwmCkjEzTihRtgPwPMen4Pk90hIpJOBhPzuHW+jVAfn78pmV0sEZ5J6GEAPZcEwI83ldMTEm6o/xj0SD5h3lpaxiyRfBjxEspSMWyyWsEvmUWi0X/h+SgptDsKnhRQ3fFZI6Rd6ebjNGrHd8ITYKODuIMp8Q+cb5eE+AQel7XlE6q1+QALmz8VkN2Vm97zyF7PwzkQnEJNZvn4dwqGIxqXIozoXC1uCaqxoBmDOamdLIC22WUJE4yG/rzB/xYfG8ieMAJFnos6fCV3EgT+HK5mJDtfSiokGGCqzSyaLM8ZHJW5M/Vm4DUn0n4WR44ibjcerZDE1oyeFbISDWja5cThJ1zfBnJrMLYe66er91IiND8J9BXcb2AgcNdRVZbcbyvnzdWRzjw5NPitzT2t8X4O4oa88Tka7XfTU3jMTVm6fockKb6TybhV+DWZ0ZshwVYYBDzT95GnUT2qAxFkAFzOHgj8VgViPw5fBhs7Aoa9tob01CHUYByrIbngIjFd3cYa0rCbzXBrHdMYBx5zYllfVC2qYzzdXQLbHK7ewDvhYnCd5nMPHsUiO/btlmqmNKrf3n4/Hq2jyeba1cLr7fBxb8M/YPulBmo1T8F2fo4nClYmFHVD+UtCV6Q6DnujIOI4MAr6xQOJhovrSn53H/gr81xuWgVzjODZ4lPWQFwHPvi7/JcIIlwLI/+oHcxCdwByjUvxC5Bkb28t56Md86Zfh2oQDkzkUp3FGxZCK0BPpaHBoefzlchVuXvubU3oVrihEOg2YaCIbR36AZnFsJYOL/lyJX+9ePXF+0gbiHB7JVHb9bhV+8b8E8yD+9vhR4PB+oPEKh2ZrRuhJWS8hetTQOYuyoFH0Q2BTnI8+TvvkwZEW4Kl27zN7YLvhU2tguQd38jWNL4XwSTsDhDzqQB2230q2ruZrsCZD6oNPYvTEpg2U+Xqw20zAue9qvVrlUTfl6dkv9e3NSKi1R0PHBgstWmFuOgQJLfr5rlwzb/HCe2K4qtA/E39eicOKbWEDG+GqYlWCKxaKc+0vYh5y2l9XbujGn9FpcAelgzvKQTDhoWqNzwsDIcWoRSc97HFgrIDtKNBmM3lmtwZ2y01YqNbimZPI9fv9eLsWESkBaCwoJURTyyezRC8H2/T3Q4vN8Oq8sHrA21SrR09ia5ejjTwQRoj2D6uhv1iEEKY0XvnTuMLcbwALVfErnLpTcZCxQMiLMIMYSIt5YGQoZkiYBSQchP8s2Q7ZkRGRZ0D+JKM9qd/5S86cGSsDIbFQBoKz5J6yUb5sfF/ZWC+bMrfp2vGRPHB8VEBUb7KA8tnMfzPrPw2QGW+7OEVm/Sd0DHNVbxaRtvAywimWP2qJ+AHuBplzpS4hJCg6exd7EryB/qQHXGwjtill+bIdZjDUqfAClYCzLEaG+FlzJeME79Ow8e8fh1YxwUmty+rcw7lK9tbgGKHzgSQ6VE1GGS4hYeHgMN8dIledqhmzIcK8GqhOv9LVN3bJCjT/aogNX7mKy78WmYXPbYpvWfDI3EQsnF1twdNxdkmQN0ZBr4nRqjt1y+C96a8kgXocSeSTiKeeRNaZc7JK4VginrpIaJAAbNy7YKyRPsyQ/5WXyWuNIcnSfv+ZLujKj8OV8BIWB4x4MLqR9VcBL0OV422Ba02Zn1wxiUpEWdj24xwOpL9nvcnroXB9DV+oZHJkP6msgpg1KXyXFP1TlvmzLFPjZ0lJ2l6Vlbxurxaz/Ux1jBSPlb2iV3fiinl1F+9n6jw62U/aq5Dnbcr4nf0qBxgacm/uXjNlXCI5mLNXPePUSOF7r2jGKZHC9/66Ap0k2c+9AtlBTArfxaJp2s+TsBE2xMOogUf8UfQUH2cOX0/JBptmglkwKnG+r0u8QK53uxIngWv26AwX11t4dA7MtXqyTvAt2u3Aqf5mt4vgkz/4wlnIQm3pj77mkITmGt8itNsN61lO5mExAa+YQPAOxmg/hXdjPYK0CnKOy8R762AxC9fc850siFJQrM+5lciaO49X1L9TnmhBf76D6mdf6SI+uwviWPfrx8uaaJtk6s8olYZCJs8Gqsh/1d9TfwbzBQ+3rabtqIxfhSoBBHprd6yuyniRLOcUbPqcVqOblf+w8hcRbD4SmJ1m27ZUzjs/ij7crMLk+oZ5BGtbWd5VsAiiGzoDw0HwBqMylsGSzoMFdN8CkzWVwf8ImKjSmW5FsCx6Hcw8CGZgChczP/ZBVsa1dGCm1eqRzaLOQAI1Q1StBnXmiFv9MDUV43AxW/kBaAwFTCmTNwG/o+RO+2WiNKiHC9OAHg3Me0Y44mnQBEuEH5DqBdF5PAvC3S4UA2IxQHc7k7dCQTAULuhihjBPmc7DiEIa+yGc+HkQ/VkbKwsfvfV2O9MDH86RiCetT4dV1wuV+psV7iurVfWz0IwIysrCJ0zn1F8kSxNhuC8l9XkQxXCSskNIMDmsvCG59yhrSlVmAL4Lv9KRqL4Py738Alz3W8jDsZhbgGlZfTZsLOZbNoZiiQNjENO8ZxSqRPRQCSXO5aiyN8SyVA2ttKFoiKV1X55K74CxhJ1n4ACUuFLcbdhN+yGX2nkzRYgHFERni2kIYT92uxLf+2ZEDKPGXydy/g1FoPsbujGYq3ruTlT9PJG/uaIC//0lYCJt8eFHtN2UNaaRk/08cbKG7Pacas1qnyt/bbgiuoil/L+LBDtNcxQ6WFy/oNNwxiJ3MfoUSuf27JSlAgiZY0fF5kv4qIpZGa+0ELh7lIIKDhblECDs91He/X8J3HI1/xnwZA2wb97G3I+DhV1YCVFS5PX3F0ZfPrbWykOvBDh7/+Cp/YiYDFvyqIJDcmSlqRlx0Y7wSJspk1Wrpo6HhBBvtzvy2P1o7555sfhjEa4XFblgEPEwE/aHu12UKl+6skwGaQ4flhnTTUxY0ge6iWWFGU86W8xwSJp6gG6+TqzYVTCfj/yIVe++FB/F8hKMWWc8Jd8bT+PdFSNZ6NcudkCSiHkwBT6BYgj7Y6p2eM7ZYib8rc79KH5D6UxeEOH7Qxj7cz2BhdVNcmq3upUVzG54Q6d/nH6Lac7lHbGdzsByo2fPWoS0Bw78ahJiNwcN+NkgpGENmvCzTYgzOLHdEyffroRawSGvGuZJbhY4IPujOgMZEwwt0sIk2k73iJh2z6nCwzBSDqV1gBj/7//H/91gr7Aq9ZldrYbZ82uxJbu0Jbu0JUdryalW9Wac0mYc3kya8tO5JDgjhJXLweNpFtDPhNvM8pup8mFRcYgtnKuB8queCQVzGwUXsYVHpzjQgRwD0nBR9neiRojyGMW3G2cTgyvTlMVOQvSfDiFWFm1PDVFtXBxyOZSKk6gHXdWESVAoeUZaLafXrlaTp6TVbtg9pO8nDfqF/dHMQwrUC6KxGqUzKWTbuWx7ggMl6Tuxtcfe0s7tYufOvZ3bE1wOFpwVKUL7bDHL9liUKRkMMrICDpYNhsZ5lNHY+EMbU9Hd2gH0ygZp4cxvtxZiMSOK+tUhhxaNvuZDMxjoIOC1ATHc/OBJ4yQoAreBbdbA/SB2zX9p/REuHV+2QoG+IRT5f+QalWHR4DDwZfcWbuTXDbmhbgirThWdyh+gEahYUYxeVrtn+GlYf0VyzBjOfWmXSlarGMu1JEKpIcwScFCCv8GVyWkoIcQMSe7sZtyFaiMguaq4cIbysGsBseQCBE/VJEMJ/5jjcIDcwic42zfSg1OF4zt3uv3L6MDOEzc83GWsR9pifRY34P5x+2oxDWG5YyqPW06EQ4X7jOw+VTHdLXErzrMR4TjhNgzeM2LJot4zq1rVDlHiwZOAB8VOTpKnwW53Ag5zvaxpaKCsZfxAs86/rVlWc+ARy811ADrfil7JI10cXEd5/NzfaCImQ4j6qiQnXUFfOqAXwDaDwobOXh1KTmuLG0ft9YMTdBhJFJdb2IUHOBF03wDuZe7+TaxK/5/pOeNhDrAvLLpVt9soj/IccvFstl3Yp+Ju9u46B4JPy9Ba0K72MC1qyxgR21QLq8xQ9amTvYfzYAohcJ8ztpa7Hf+LNwTcxFlgjQ2KVoD9cBCaQxLVA1Bke3tlGsTAGxkfua+K3OpF+rzILfFUQMzhs1u03ZAoC5IvCg5PbFSz+zKWIgtaNyIs1BorZkZMBo1PCV3MZMoQLG6P1VspOMZj20YIVsG8l1Ody3xLw5oNb2MXelu3+BL1G012Ymhc4iWqVvdTL05sFqitVsMXJycc3t/VOMDKuB+MjyckXn3j+8T8jtco3ZDbmi2UzjZPPbUHA4jTpuLkZMihkhgFFVH3bgmL48Twmu52/O/F+1fD8G4ZLugizsehUqh0W45K4ZJ1xZupBJH2Fgug9PjTfYQeQESI/5OvzVfw1hSkbM0bGj3U0Fd/vt/OKYlqBjFqI768EolP+yr+a1K/8zfPr6l0yK3pj6gsVjmIXgYLOBGP0b0A4XXyQzmtEaNfee1vTp5fU2LUjkGZM6nPwjuQYDI5zoZPUyXe2wcvU9bHC5ZDjJpsiPe09OMb3s9Q9sOS7u0FSpT18c6Pb1gPUIC3z8IZ0khuGpXAIKfQMojYa7vi4VQEMUieGExTgG3ACKXmJdrtguiN/8a8rLMHmbdXJrp/wKLTsjGf8Sxi1C7rcXjxYShIPUqT+k0cL98u5t+qVZMX/kUkGKif1CM6TVZU5Z2zT5az9FdxAB3Tmcp+l6XxjZCoENNK1LkXKikrM8h+5kVbrt4OF7OFa8MVnYocMgrXhi4agjC5yd1esdc8WS8JAV33yv0CiQVZ0b0IIyrmFiDlGBL5d/Q8iOl9UJBlBtnPPShk7cC4jywx5nORTM7FganNbe5vjGKxkb/JlRHn7CNaW4QLulfuDST+AKTkLAqQkuQpFQfxnnmxZFFwsp9XME72yJPfxkf/cfyf1b/8VPt8Uv/N+33339ZJ7/nJJ//k+6R2/ASvyZPfTGOAxp8Ty3Js9m/jhP05Zf++YB+N5+zfIftosZwWz+mcTX76bB8/wRtoaVyfDMb+yXfrpDcxxY+TydbCbTuV6WiAoNwjiv10/CTAQ5gEG4qlDyXr/fhJJn3PHdXs4jALVTwl7RDmB0vDOWKKsT34g5S8gB+ytVr4NNCOWdWHdvCzHph7lvBZ0EdlPZ2c3NdXzU73O+CsVEYjT+wjovNG/2mgwYwWj264pmnPaxn7wMPA6cF7ZfxOU3OeBEHm7HbTabtR7nV6z3XCLJyy0xYUD87pnIpcRuaZqsc1Ze9/aD/ya5q9TWTN+NM4+ErPeLAszKxPhHa51lg/qdWksQRTeWAWHs9jM0EqcHBYj/1rMOsH6r5cFh6PXr15d/FBPC58OPvHh+fvz54bblj/Mk9WJtqL68widWua3PAu93w+Z91GEGMym5Ux9FeU6VhHddiF1WpZBX02u12QGcOolthrtT+bsSomt/thLPZVOE0gnDRcD2y7bT388Ga1G9xraKfR415DYQ9tLOvk88axYOP41ufEbnetz4ljQTL88eFfp8v+7bF/r+Df1tXnpGFZ1ufkil5dTWpsq48/Lz6vPseTJ9d8gwqSIv5aJ73aSX3yk/v5yecnT/AteeJ+ngG5GTEiwUryYvXayeQnFw1MKIkG5vjz5yeTGvtx/jma/ISeBPgUOvBPvj8/+TRxtc0er4K7Eb2KdQ/pIP7QVHwzncY1Bu1IzuCNx8Z/GNgA1yDGBI+NgYGNPxO6+mZM1MJWIn8RxIJ/VtskiM6XdBr4czPkZHcaztFAV/X//OQaG08M2It4bDwxQNs1vmERcKGr/zKw4Sdgtm5P8PiN/wYbN2EUG5gLj7DN0p+45ucZaF1iA3ZhlqvXYW3q9Sb4kmxhUq6N2XRcW3uYnIfzqZ+LS4gTYh7WYBnwP272MlC/HgT1a7esSkTnVwP4B+Lc1uch72m3Y9fIbYrXoqAZgelJwh/nvszDLy7fNwqWHvMHeLGam8mCRlN/Sc2oLiGIMETRlffBjHsgXF0oBLXerIEISuNLJGLgeuNwktVVakhkjbaqcoTYn0tQkYB4jBC6FfWVXM+rR3M/uqEQ11L9JoKdjuo3K3qV2Vx5qcb0SqzJ+N6rYE755EEBKl6q3zdx4SNSX2v9p/id9SL0oN8JUHK8jYgZEW2jZBtiAxsCs/CcW8Fs4ISM6nRDWYRQjyRjezKAfwocmGHgNTk6SsYO+G+EH40Ju/CLma8Hw4EZEMivQV4tGTehACQoJeFxQ/5Gbla4WA65oim9Fa0mZDUnOIOmN7h9Rpxq1WSWcULdGbnZCnhowCu53mC9V45XLt3lrCxURHgrE10PCyxw17tdVs1DMp3Re/cWr2gUu0GarRbHUPmU+NAyHTFJkx7D9mI1R7oyddYel85g5lQMvJbh7+RYxUb+qF7C8Scmh2Yu7Ni5LPfEESEfq9VMnARfZkBCzE9FhINqtURGELBSHhdSwZEDynd7OAn0GYckI0YhQkgBuVo9Gsp9hT9pO0z82u3WcCBmJWRFMlQ/d7tQ+20YGOoD+LGZoclQ69IEzmxY1xdstztVsovdLhtTtWrqHeUrPXV2u6MMB7LBIQTI833cmJDxE9Cmf6IfCqhP6dPvUtJGaa2G9qFrjsj3MaUTNDBvyQhsXy/ICAxcb4/I7eDT+GJCInfvOnU7+G/zkhj/BTO+HeQFZ7fIzVjKWzbCPUOY0diZDEzeunppvEQ4ysRVtRF7aXbzpXJloAqUuCS3krhAb6zCJcwhV5SPCSHM8uEftuijcWNSrYbs0zDwaNycyDbgn4K+EHIjMjIj/An1AS0/1dlxSAL5CyxeNESqVo0ngOif1EnDOPjnsWkxuOTzYABHhIQqgc1GfWUiuBWNwvlXTUJr6FZFoRb/GKyUgGmQWvzsZ/ayW5+Gi6nPDDhUPlh2KNsLjwTjBJ5s16CXvwH2+eSkj4w69AimfoOAVZ1SM8E2co16lmPmsvCmVkPuplplD2BJtWquyZGFcKEQCC4ltQddyGQR3QRXzP4AG3UAD0R8r/Nfu53g3CE3EKYqTwyUalDDGjgRvm85SndYfgWMJ0Yt+0Q4gcxwFWsEA+125qc6sFGE/2GD4MUIjPNTPYnoijUHTUXROlzNwPT1Ux34t2rVhL3FP7KrmWsgNDBzdVkBbfNomfvyV7PkXpdVgF2RjSXX9GXNzmU+tmlZAfbMPzEyGAEbFfzQADXI2qoZrlHLcrReoF64Cq5BWMkpM190RZkPLDVfsEGWVjOesBWHZNeAQwqWCdgx8km7C6QXq3kmLCHbiMauJi+P9bdGbhDXL+jP8UvCPqENMz0cpvhtBrudPAnhhMPrcTQhoS5DYny9y9NxYoZ4rV0oQE1tzbFzrbCTARKUD/ZyePOGgfQOZKbqZM2QG8ZXI9AU/0ZYNBcWK6uKt9IEb8B02gUNcg0kmgRSGC5N2RJDoFBsc9gQrqlnyEqF4crJG24GCBIWlPvWiiU4CnKV5WkqlAfhYseVBdlibkhWgsVCNZ4YrvEfRp9PMKMuR4RsBpta6Ib8FX5/2STuip4kVgtYlWybMDdLdvVzuQe5MEc2+v89HJjrbAeGimYMEdbSH7cz1zrNWGdkQbY6rNm59Me2qpOLHx5UCNoo4szjQVePJdszqtX4Up2S4/Fo0j/l5/t6fDq2JxMi/hZOeeVsk1OfbHSDdZ76ZDnaoPF6n/isS4nPWic+HJUH6wLxWeeIz5oTHy2uPMLrFMuvjOqofNCC1tlqopkZmxHcPFm54Oob6uuhzhgXAF1DZBg1pj5Y2ogZCeReZ1pbAFhGAnhTG7KumYE+84zdzoAQaOQJZuwaWZT4QAF1YG5qJPvEgYJ8tQpZsBpZGnAbwJ4iN0sb7BdTpbKFCkoXKtAX6iiRbF2gWAExiP8yEIZNBxzOOMn0qXY7QeoCRa+goYATSlAd5mCDASW1rFlshmTPsCngrOYgMsUv5MofYhgDzkYq6jMwBgbQHmCSgITxYvw3wps0xXCAFa5UxSsWKyMFMNk9iyXLC6a6abLUPyPiaX5ZL1bzFHc6dqv5oJyx3Wpa0h6lBHXF80QQDdSvDPMXed/9hIA6AXenstvZTyJC7Cch2u2iI1BLCo9ICFKkBFDr/NtieraJAcXm53G4Aq0DlvGeXuEh/3l2dUWnMb7lX6/pXYhH/PcLsAdldq79sLS1X4P4hgu2w1XO1ggHOMEqbPOGvR6jzKcKOa5Pk9WKLmL5BgrCuEtuSGYLh61QNO2rkuSSHzSXWeX+MbnVpMJoq0DGDJrYQzH7s2GWPuxenpghwkpQd1qtXtZl11LJSrygwnBPzQCHSniwJkphdE1CeKkLyBp7ZqSVCYTTU+hnT2P4tFqFFodoELgmjGdNhlzSGoH1H9xIhkTJ0IIBgMANRDPj/RgMvhkCcccCqsMBr+uWlRxCSXDGNharM+H4eEHWZoSP4aJ8DBrVctDDHGQzKAEoBYTIBTR3MUF4ZF4gfJGmuNNt2t1DuyHbOYHJtg1Kca/Zbja0CmoJuUdfM+IPMrCWQf2abVeGe/61dAN2ZIsnGiUxPLJT/nJSrDGOlKMcrs8QVqtGvEoYryOOl7BgJpHuP/BUZnS5olM/zi6sYrTGInwh8mB/K6yIxBPlkd0vvPVkbYFZGptmrkH2Lppvs2h8EaK+Kr3ypzRXeiCdpLEssIiXCWt/BTIlHIAZiBwnmPHOubIWlsoiEXtPcdrtVuchOgfh6VkUNk2gO5yHUbC4/uBfZyv05OnnJ7Xxb88mtWdPpPgIiJYuB6bzq/KqrN7nJ1rNvh7uBN6Ps+IVMFgZGFlLhqt18nZJF8UOKlr+fs9HuSEzB3HMJqZaPVqLlIGRNWu4a5aSn43hGqDZaZTgVqZiqsCvDCCqVUVJVCYInvUPdwt+LTjDvIhDsCYLmWLpm3BGo7cLeIQfBQsK4UEgIN6kH5BgtzMqlUrFELQrewihG7rKAJBBhl1s3i4++NeRli2FLk/Mp5+fDPjyoifXCHQWY7rSCIqqw+RDETtuTeZiyYwQd9ixX1ZYGkcYDk03W+n0QD0c4qFU5oo4xcIj8fLY1zGCEDKqVtcnJ0ILKjEDvAaVt9PaLXt+0dZTlK3VsCfPruE4BIHSBfvhTEqa5qtNCLkUz56FFi9EsnkMZn2ntQs+2pqgs7VbvOFiofDEweGJnTH0x2nmPWhT8lKaLc4Qbl3sTEUID+9ZkqMjcMOh+clJU9ywm70ee+7muLk1gGmu+nfLvoH/YvzFNap/JmHcN7DxF5axDCP4eAofc5b+DH5ex30j7ZdQVP6A9TJc/eP1qKi2LuT7+tuhOa4aT5/9ZYLA2Yq24lmAKvDVkoJ0Ex7re7bTKIkZ2W61rTZ//WUT5DEjmfk5EubV/TIRpdKy1inWlPkp8vi+y3FsWdiucLezBGT582tqwu0kgGS8IUwBbI87BlG6uSHROCG6l8gIja3JBFWrG4hOQO/UWSO+6+y6mWD5GTAHGyTIEthISVhIiMhaS4npapUsY7KRaZxE4FtQPhgB+dBJr+fH8Sr4kjDjL7TND7f8Hf+WI7ZqRbWQZ3prfyHGX2qeGaLaX4y/ANjYgyMjGdJglINrIwRQAoxuxnRuhBrtps7GWa0WRizSEUx1CvbU1eqIj840nh6Nhy+ef3g+NmoyV3MC9HnyefIMXrMnk8nkmSr7zEA1YzJ5BtcgOXt4FwE+b5TJeQ/s231EGBhsgAbZR4RBEfpiLq7oJ4e9NRskTSMhihoCRyeKedL7DR7udtkQi0ofqvRGUaItoJubYIUywMhnA3Jv8TRcxHQRuyPMUdENsMA4dy1+uaH27Mf9l2gvf9mrmwBIqNgr88gG9Xxp+qAGAd7U6qJjKUBRHSxX4ZQytovrJRUL8k3ukSyDS+7ZRUadxB4bm+rR9ORG7KsJeChN2RDBkdhAns0RyAVqZsg26sB4+sSo8d81Rit5Hi8KN+sB0GJIZq+Kge6AIes8zIi4BgUQZGZfwOIx21SsJ0KKAIieDqgamfCscWQxp6oc1HIK2vDl6OXIs7WXdjJGxajl0vkRUzEQr5MMZGNs/q78erIPDoBjBo9MVTk3OlWirwNot1PrXsbab+6EOsA+tjF59VZhqtT/D+sROyoGQOfX7NqKPXj+EPc5NRzm8jgbHOO4XPkNqgK35MjqaxeDOXNgtr0dJPUF3cQfgukfTHVNX3u+mOoeotASRuvVSIhwVK0ebfjzPXfwx26aFsvYSEU8r8+70+98gfBnwF1MJNynFzEMbY/6M9G1wHU+GpxRmyEeQoRBhHULZu6CIzMZjR7RfYSwTKCLGXsdk75GAMwyj/tkYMeCZJH2W74lRzZMhplkzP0VuypVq/qs/nE3f5HlZUz5Vvjtcg27bhlYmbtnFjC7nXHx4eVJl/E5UewvZv48XFC2B7NPouchDHDcGoPN3dxwt4xyAykEcHvqlIF9gI3BM8Y16CNnKxnJQ2QQlR20imXpBzVbs9cDSR1hrj9gCCFfZZcvK0sOBqYOaTAXdT0tOFGdcnVEkqUIBUWNueMJSi9yyzgJV2IJd7mdqeYyuTtXbc+uoAjDkeMWjJ6OpOspGOueU4JFGFf8KAquF3RWicOKD3ZAdBEfCXEutz0U4uEcEyRpt9a8htQB1iuww6xmBgzPEdYRLRR1gYACSmKGnrlJqA0bVausVa64qVwlzEQy2zNp5jIXXIu0Hbvllrj4gXB8/QQ4tEBXSt27sYPBQBx8ped3YRjfnE9X4Xx+toCFnr1dkJI7BMjdIaBYeLdMYjo7j7/NqREslCMnI2ItMV0xllQvlgZuxYhYTydf6I3/NQhXxiRljG+mLEfy+nW73ZFpSC3YrD950AqdrDv/D8rnQIvCSIn7TIWr1+th4LhBwgbOhEiPY0NEY179Q3BHwyR+NcuBICFRiqM4XPJCOmCnc+qvRC0zQXi/JfDljeHGGi5BZno2u6Zvr64imm0STcGYGdXc+RsT7OB4pbfwQHcSoBRz2H0IP2Y12Xqz7ZANTzoa2u3AjOmptdvdu9hmVP8Szr4hFNXj8KMZIOxVq57JNQjFKy8by0cTLiraCAN0ssZDYirPsWzEAXh76ifQPy8bLLhPVf9LZG7gNMCatmU26G0J6CIaS9iaBRXrkKjWbWyWjeFkiJ4kIOPShqwZLa1rm5/M8Gm9NXB+Cn8K3fAns3ni/BQiuGOjvoJG+NQGEntN419ocH0Tm6gWPOUwq/MV4ekDfTKuPvRsaXCvJ8GLUoR7ILyAO7ZcWSCfe/irlt0swyKgF1BMNvJqEYeXAc1upyzYlyff60OYCPOvHyyuh/OALuL3dBqbqH7DZgFXUR3xarDCudnj2wwdRuS2NuyXDitET293u3UteDYc6NMTw3E3teDZaJDNbXMyrHFsdjmAMqgMKfBwb6/0KQnEz+of3Dm5wT9xamay2z0EhScOsNrAvAuiC8iZLHWCmyhtaosrm4Ic0AxJhLApt5/QhkR4K25PLxJ+bLshpgpUoPCI49DVoYTj8KNK+IgDsahufo3xlEHGLQKK0SpXQ7u78Kv+oGpmV4WE0aaPLl9SLAHnZjAE9MzsEOQPaYlwTeMX4fRjSZBocQrwsX3c7aK6HOYyxSHRSba5hd3kqk54wWBxLXrZ7VQWFGTA0elzrqsPIaAAEvMS48MKB9yDIw0WC7rihWC0U4YW/DvNAFNCtA9jUxwua2IEEGqiHrIFBwik7CYZ1rnoaP/sEiE1RQ8lwAr3oBBmACaRmP7+ZEN9Ge6DiiKvYQ4U+GFooTJw6aJiDQxsmik2/NmM+XKUXt101uJIdLkIP9EFZzPgVnzvoZZDGCSf94ybIIrD1Te9dQO4rvPYjxk7IwrghID+LgfVewpp/FElK9IHtT/xu75XkBh+Eoeg/MPHXpyfacxDH6JbaICHBg+cePf0c+cvEn9usOPkcG/LcBnF3IuyviTsKhKDVPo7XYitCnNU6SE7BsVnPSsEx9eRjVSP8i1dPMnfc3BHcGsGamqiekYEYTdEcpl04ylBAk6/vZqZe32t6JVUtPoPA7EnTN0bkXYmhNmZEEDQF/Y2wk+xk6RvPQVNTO9pr1rdpyUJvIEBdPmUxUs+MBzv6fXZZmka5m+7z58jpOGn+flztDtGBuofXJHpPJj+UVgOqfcDHFfsr65pzHRUnnMdFWG41UdrpsUD1xl42WE2ZebRerfjFnDrm2B6w+gtiKz+Rr/B7zsa++LnNF7NxU9/DvmIMZCJ1AJTuCYwoyiLzOUPcl/uNu1vNCxRMO7DQ64sKm61bM9xzf3sFTdN+TsUuz48VwJhA5baQCA47wu3M8NMMew/DPYUx5/m1nUWL/QN0+oST0AWHh1EqqHmxdzmbtWN/wCAD/k1cyRlerdEQ6IRSJCWKwpr+oIf7qa4WS5ewCVfw/oC2pJhik/LN8FptWre6mh7e3LKZH7mfqsSmop8Mfe8Bh5ykzi2b2+ZegDmlTkaH9mazydT6kMQQsxS7ZRgEIh3YRAQuAFoBGeiMw+l2G41mhbzK7xNcafXbXTlR7Pb7rRKnfkGpmN1W3Ax6rTb7ZLnmW6z27L580yv1dDiTnt0E9PFLJK3gMzJihqTKEGSgWeGJEEiCi9yy3ZaSOx++LT43toPpaZdoD23hpN+IZKuCXZceXNi6RiDMX8Q34vpjyuY68Gd65kvZeGOVqQLbjGTq2A5KbEWbFW1N/r92f8zvaS42e41HeVXhc2/f3SfGCEh27QAgL7mu1bsw8jMAB4RZtpi/QjUYQNEhMtThtCoGWGmNsy0UswAIQ2+eccvvESU8/2iaw3uhzXJ9DbK3h0izUPVlVmMGCfrZjPXF4x728yc/hyRw3br1epRVlC31FSxCozxgvE/lWk4oxNN30QrLURdhtHPBbiOkPDfErHQ1NE4mLC7iw5h2JCZE8+i0FmugsYED6JBVGNyfjeC/zKkHJgaPCTWZUkZSmqJyM0IU1Byu8hKpoo+jScoT54Cdr3GbfCq/ZD6SrvX1L2XJynugY/xh6rZvY7VK1Rstp2HqjWtXqdVqNboOIe1bECPsG21EA7MdrNlOfCj0W50bPjR6zXAlDkwO1arg3QL52Y710n9+fX1il77MfeykOKG02rf02en17C0Btt2p9nJNWgaL+WRgcGFLThnwE7DajyoFtntOl1LPMDDIOABXraV7Yf+fVoy3Ot/X1OOXO92ibnGcBUP4ZMVGHggUMe9TrN7z0zthtTSPAA6sVW5UDnF7AB7jLZbs2112qDt1mo8pnir0ei0wBM+nICPqdBtNS0bAdZZnfY96GO3ej1tKWFPFBCQdf2gOivsikJFNoQHUb7tFKs5dqv34L50nJbAk2a7aQGaKC8hB7ADmFmNGEf97HVgzZ65a0YliCrsiUDpNTFdF9uymg9SCpgsU3QTHkb+xQFBgKa/xCwiilETo/MjeLCQm4ANrenYLe514gAm6B4kUtxot53mI5ak2/r3zaQA2kVFHJ4w/E6z2Wg/OJ5eT3hkaDS7TaGT41itTgthLih5TeObcFb2PpGTnIi3Ta6Ki2/J2hwieW+4lbqjUbV6YrO+R/iUeOYG3yIRCTY4ItJlye2z0z4KrkxzRIbj01ptgo6IvBIcCTeUquAp+N+4MkHD5xQO2iGqVofj0wm8NyiY7Xanu53V10eR6opRW3nEu/qc2UO8uPEUMmwAcK/RdDoPn1ZWgX6PJ/zNDQhIq2s9rgWweje3qWI0YKES0zBEUwdwR0rbGJ7gLnPGm+JOo9fsPtRry2k7AjMEPVizWB1dGAyE4ei0nXYTmYYcESjXgXoLJ9r4lhjPJUPJXqjMEjVpWSA1UQ5Gg7W7d/awOKE5FW9uMXWh3rFcoXkUDYw3YO2yZw6nszVF5zPA9ep3YjMkQwDaBoH6+O1gDdcZ463yzmAmBJIQCM5DxtpRigbanN2E6cK1eq2H92DHaXJQ27bVdDio7Ua32RagbjrdZvkaZ55++O7z+O7b1K/wiKzrV/iUWP3Tp0PJ759Kfv8Y9takn4A2Ongzq1YT5ltwt7uFJDwyQ/A0CLrIrU6j+yB71QPuQh/jUVJuLPASqKUA+kuNDWchuVYJM25gvLs4/a9p/E4WenvFXp1egj2cVpc99rZ6rZb1KGqt8dBKyXUWLqgbck82bedBstnoNZsd4cmGLQ5bsk6ra+c3+6C4UlJNun7FUtamDUx/6h4oB1hJAhbagjX+w/PLxRyyqxHCXHk9kWkOpMlQNO6R2YRvDhUOjq7VehCDGcwOIyj25HgqIPPTwh0N+ATdRHTAZuq0Gg9z860el1YILOE0QF2ID0EFNrxnJjiSgZTCAjwsDRZWKqVkAdomzOIyk+OkmOHAv7otRHudI1I+E5Aw2TyOVEmtNAUJLEP/Zq/VejzUFCMClktSTIc3xDPXjKKtxSuJkNo9cL5sBoXyoDe1ZY67ek73XqTdDs/P3ydzFlrGtfDw/JwpLmjaQDyVCRVkIfXeIxJevH2d/+InUvb9IfyDLtinjV/4sc+DRtHVq5jeiVIvAzWIXz68Hj2fzyGmGnciJtL2El6GqzsxY5HCzbOyNPBm6ItmXwd3FJg9JrlwLQxX6RlIk1/7S/gMZ1QM8Z0fwPz+TGgkJ/VunlwHC/VDtnF++TOP9SeKnV/+/IZJV7Jv8Ih5Tq+1hDBYxNlnDlbnlz+rgFoyKUxWU8qD5cgkWKLzG0plM6Bw/2HlT/8YJrSYJL/DZCpGCco0VqNzL2s9NrTzgLvE0gReBjaCSDsVwJmVyHoVnSnqYmADjIum/pzyWbIE9VN47jQmKe61O73m43ZPfeF/Da59buzBvNYmEV09vwY9pmz83uBcxp8Fn+/YsTq9+3ku7OG16oQd+2xQwFIxm94pjSLgq+sv6AJsBofV6lDGtYx2u9tq9VZ+4lMwgxjVv3aZeNsDZuVUvtrUDQQqzOC+Oxlbk6fNge3WTPhZS1iMD3zkVaugwnhkJmRTv2MU8AnoGXx+Yn6e1dATkPlA2Wek02TWp1mx4c0qvNMKsu55w5rQyYMLbKftPJb1ZfcjyfGKtwyAkYAx8ASeaXzfTP1oBh5KYn/6BwDuyefF5+gnP66Mf3MnP7nj3z4vJj+B2zfhGmuDDp4UwZV5W60WOUjQyTry4BUCHoPOoR9Acoq4TjP4NomYhd8Qa2bITH2s1e02H3eOMvrM4COuY41utwvTZWCoT/1lnOidHz53h2gLrqQ2A+YTErFj1mDQMbDHTCOBw8Osg8edZJzlKfI5hUONC5011UBfweLIFP0zmehuZ5afevowbdxBCHfYQxtfWXbg2bbVs3/gwOu0LUfwaT2n0RZ7TNxthorhrl/hWx5KsAHKNlIuhU9hIboN2wZLJbVSl4p974Nw0Iaznr0mrvzlUGNoi7eZX1cg1N1XPgiu9vxpiaLKN21RhM8NMCqWq9m/RMypQ8XOpTHLRUh2CslaTBKVghOVBha3RQtFidlicJrzkij7jUVuenCTcf1JD1yB4Y/4E6YUBxT7FM+peo7FU/h9PQ+/+HO8FKq8MZ6prvAVJVM6SNwlHSTjOZ247F8gb3M60YbzjZUbuSNI3+2OzRGeU/DFB9/4CyXfaFaaie+/g0QhRB45MgNya07p4Ls7pzVzSQdGnXnqQLXvmKkAT+kMqN0VrVYvzSuKvyP8iXyj4+8T0D4zKQx3Fi7ioT+f/0zjcxoPTJ+SIS+LqlWfcvMz9woqgUM0r1qldECpG0Ir4LoFfKMLQvRJvY98ZDpPVMhmq1VvcGp+hJdDCJPhLyGhgI7mR+TOaLW6MT+iwRq+PmJQOLjx73a7j9XqR/HzE7i0gZ+oWj02A4oN+DDAOAEfm98o/o4DijA0ZV6aI3xB5rRmvMuEaBzIFwBjfGyOxhcTWGgeUNCfV6tfoGaw2x19YXOGXr5QVoTRJUZyHnfl0e/yR0eRKTn3zM7OAn6UEYEHiYbdspiMbl84jtfE448fwCyza782nL13+/f0CvjBalX84FV3OzMZbNhqmWtUwtdveDlzrW85kPID/XnYyWqjLUgek/YKwQ2f04YkZsJ6Rg/cHJklb/ZUHQ4idy2Pkf0RR+pFKG/GzLr9V+9I/DjRElIOO3mmlPj8A+XAwiOpaejSXXZlarY7jceyIHlo8svTJhPeDJmcvul0EDs+BLhHCoXwKZf8cYdpcIawT7DJgVNkq9PI28GITTAznWbTVY8wngh/DMYwGWYmZJidDdiGcWT1QVPOlA+9p2aCh7qyvx5CRz96bvfEF8zJg3mJQ5QJnZjqu0esvvc07Hu1GkrG3oQY/tioeTVjYvQv4Y4/Mo0h9g1saCfN0DRqx2aCDQyme8hQRw7UYC+lzGJTBfJHbijwjOX1lXgTnlTNW+0UChC+hetno9ew/oXtXtzgycDjqOeVbQJBGUwvtwlwo91tNH5AoCQwquxtDvZvtZoJxjT0fkGj6SpYxuEKgxRwjQ3mDQrQYAh8bHhH4xvhlTYzLJepbFNBBX6xMI+4Kvsma6euy0n0zbo9+8er8w/n7hC/e//23dl79xYP3755+erni/fPT0dn7ggkiHa3Y//oTrtH3qnJbRPTMx8ACNRC42CCtEMBiBOjlA9zkF05rKIov/BKk73Lgi6y9mTjMat5DNX/1aMH8JEjAUdEcYjgdV7quHHve65RKWu1m/JUmz06/wBrvfd0WxYI2xtHOm8ALiHBspIpm1yZa+X1Rbh+AnkbHpINWFxnTJkoBHdfkNfDtar1CNk0Gx/2slGvM9bfl6AufzmJ0CASihHpQ0dmkTV/6gxU6yZMCO12WYIHCS4kA6saTcbhZLfz2KfHP9lbTfPhtxr2oMPn5/Qa/GE/MLsduyEeEHqNTtPhh5R8tgliuvJBtnIPRjMfGxoW4yHa7eCv8V//ldXf7TZjQPcJe81tPEKBA6jy3pnKnk/FmcpendloYfpAw+5/Jc2wrGQJ4BnHDVmoKDNQ01mbiRlgOPyy99Rbc5N7Tw1iIUdil2SA7IMqFnw+ngL/A8NlulYKwAGSLoUSM2Av0ICte28Dez1PIU7bAQuyqA66jYQwFcdqNdKRmNXbd4HA71ofboKoWs1+o93uQHmp1s3/Hi4HbthBT3l+dbhMUL8GP2/Xh0vEbFwxH9E+TYOM1ES7ndpnhpZjIJPJyjsP3wKy46jRc3pd/uC6p3qnL7A4hniBbGwVnpCjFOJFNmT8QbfVuPfhCpQL2k7TfvBVihHDgrKQMuTDRsGQBPSGOo12s/sDvIkQAjEeGB4c8lw7c87zQ68ba9OYBV/BN69v3PfKUfe5zKfda7Yfv3D6aOXDNR8HHnJZIpOJ6pMoG//RxjS+G8wZ+J6I2WSmpoP9vWecZ0EA4BAZAtk0kAsUJnU3KW40rUcIBHVVHPFgAzpids+5jz9iRygeap6QE3PDPStIGQC4MgEXhPDaMyTaGQv6E5kjQ+4ph0k0+eNco+c8rEGktFweeBpEW9AWqVaNqZ9EzBgkhPFEWCRgeFlPItCjxu1mr+E8KEHn54fVatn8ABHn/W02qJEaFJPpCW77mEj5HZPp9ZyW40AwvcBk2xPh70Q8/lf8ORhof6sEiyDmkexmBv5IhnV1RuFPZFj/lfp/vPaXcO5sdrtjrq3Pl4ZSIr53O1P8YuLST6hPKfCxhP/BlIVOIvwPfEU8L6IxTvYl1rxcPrDdR/N7plRav/KnPojORFusJg5TzYeYdnzwQZhAaIEGrQ+U4V2mqbJeDSi5ZHLeGIKRXYwDOgE96JIBn8JNjj5iuCNW8L7BiqYGEXTnHhyvKJZqCtzM33KCgfp4+MYHByl0wUR67iGWEKg3k6ZvU256FdPVy3B1L9stD31mPHLLfGOYAVPYQMwhFQi4c4AweLRZPw6+zGllRac0+EpXGGL914zKiv6ZBCsWyk1FagLmvdHp2g9yxJwLxF7GG7LHlYypwxtScBRw/xOwblhvenVWlxtpbsZrUMZiVyBGztyobFQljkrleVWtyl/1/HU8k05pvtrCMmJc6r6W+fZM3ccVZwp17cYPvJIotakn/7H7nMHxc/0JDqKXTGJcyhMOx3BGKLYwAKdmu11wRMgtwBbcXQPVdI+OQpQCvRON1RfgRSEXbFNbIOXOVw88VDeK7hfxMGsPXIFAjJzbLOnN8w+vLs+I8cbAoyz13dvRx5evRiNivMspyor8FDNe+LGCXM1lJISRYcrd3dbjTsp7myxx8cT9UpII9jIgKFM0fdzpdv/oE0Y1pUIau8jd/+B9ZIHiS6vXfByTV1TME7rdcPq1bLvTyjTxtD42ZVsj+nb3JZzrmL6vewe04ZyXQ9l1BU7utanLIOGqxe5Kze7D6v/iNc3LboQHr4GMpvFTXOimjrI5wynO7p38FBdXRvYyZ4HXU3yR3R7xexqBKUR+3zGXH2C7vaTgqIl9rnjBMMXfCa/0ACnMOK/CSxaeUni3AmFHPb7hT1dH5hE8ewT15+fe2ZsP71+dnSN8paW/Ovfenw3fvn+B8Ld88qsPZ++ff3j7HuEvesabD2fv31+8+3D2AmGPghYuXlLEzNLL8PMjPFN9xAanGczhDzevhJmaR9ycG6RNL0vlIjM6MOEgxF/owKNmBP5sIxYyJQ6XyNWTEHJFIS0TMR8kVxR9JFFdnjoqCNY3ls4+mRjC/ESOAbO00/Hi4FUdGh6an4SAmlJi4YCSWxNMgegzSnkkG1AZ9inhMzQjFsIGVasj8zv2qRIS+EripAPHRulHcsqiuDC38XNKrsBf4oJuYvcj+9M/MqeUeOacwlsWPPzRPhdbZp1OxYOfJpVkS8ImCSuSlvkn9Ck8FZaMNC0faYrZLvgBmYzYgWslTXrERQP4ob70HGxumAqEuHcbiBu7innBmSvWMcgYF3iX2uBIAwXzk7chUXqgKqyy+NhkJGmDcABT7tjdhx/KWrbdRvVXAvuUaJIBAa6LLV2rk1Eku9mVJEmwTbxnkI+QA7KIg9DDtxx+IxLWjIochpGZV2bPGaCyuGXotTZrR7fw+IEwPMaN8JHNnmOH49GEZINhV7W2ZXcfft8CNYo9KgynFZ8ye73gUxbHDbtLAYA5FbZbvY7DqTC/l+LjDFKX2WUL7lJMmRTuUooD/ZiB8pNaE0wp2dT5cwJs3k1df1HAPkSv2V+2OSSfXvz880fv/PnL5+9fKVJ5jqeUfM+xt0tKDPDnaAA15ppgkQEU2KCLeBXAx7+ytBv8HX/C3yja3poBDvGGq4V8odijeE3hxsAnEISLEgMLFniNkO/wcCx3+HtudT6HMJ7VKrzTVd6pzHfM2XY+KExlSd2iJ2GYsplzlRpw79NAkVml2X4lDp37q13tVxOAPFQvPfgskZUD8nVO8/sDb5gLund5VZMPlLyj4ynodbyjY10+Pdntvler75gmxntKGAA/0N1ufwnM7wj/ScFuYeV/M/i14h2ti3nsdh/YCvwJOgtfKBmZfwq3aTBeOJ1KjEtBxYEdCBDaYrcbmV8oIoT4cPE/HZyCtoNPkTs0v7DBo93uAtKmVMM+BLoTXyg+p/jIYpsddLo+js+pvuMR7Jpq9TshZMZmCP+xJ70jnmIeQUAsOrg031HxqIdnFLnmhhkpv6fk4IJ75geK+XoghL/D4elRwjWoI7cEkDOKMKCa+2nwnpYVANZEwLUs+4qiFHYPnK5ryqJnUmTO6W63oeCejKe9o3BkX8B81rCxxms6EVE4E3PLtYfcELPFAM1urqXj8mZS7FE9VNjRN8pQZ0rhtvqewkq8YyvxnmLubvV7ivBHeMt+D73BAyJQq8eJpMS9sEhGM5GUOHBOM4p6nBHMy4xgXijq/B2ELBpJ+wj+7scT5qaWBc0DL9jBwlwTkWhCCDCPnJqn5voQupoJ8ZD7kXmsPLo1E3jdKVHG2KrH92T8fSID48NNCqShYKLuXrDGRmYC7m1NKMbUgRL8HZsH6CnSdUS3exTeTXA5eXc/MmusTtN5SIzObg8Pyrttx374eifV75iWi112udN8pk1pMJdu0ZgvNK15lhivksVUezNg3/L+lZBaJOBtJs+sQeCGyEzY24Fj9R4UiPcsu209qO+j2VSVPKKBg1h5SXZ6rcaPPBpwgfw64zAKG8Lpdlp8P3Q7dsfi+8FxWp0m3w/i/YWxFfAAAPshZxqML8qfF/B34mlKN/pt+7KI12BmWa2CO5dLc/vFtVN8aV4w/x7wMqGb0Vi48E5xwc8044shrUoaWK9gp+A0H2+/uA64ev+iTBn7cjPhkLBAAvyObRp8WpUZjamwlAVXsP6X6YxeXd8Et3/M7xbh8s9VFGfs4jiYkA72pJq3gcr9XIdgx8K2WofPNcURKEfsdkOTfYXKG70BG9rTnzeEf0/lhNaUCkXMu08RbfCG2BiCa15BUND6Vd97tukjWe0jhludqjPegKEnppRcDr6zCxy+hFucy34GFKTeolkfAsMG9JlP++gjoXTs01ptghMWFOICf8IfmWh1/BFCcH6caPJR9zLFjNA+QLTzt6Cm4zgCa5ndgpBJ8HeBW0bRm3aboy1/FcOn2RvCMdEUz/AlMbhaioEvyKlpvDrz3r1/++GtgfDZ3TL+Vqo0jLbMM1+wjD/412WCradG7RL8WINg+OkT8ZFisIJk1JPvjsvAf85i2v+D5B1SrVdBTE3Vg8ncPIODsRBkgn1p+s/9H/3KXesI4ZLEPm6yF+71qU8C7ocJe+cQw+BFTOMmvptDBK2cVyK2N2CR+sUmy+J8SwnxQHObd+cHi2o1GRwEg5kg1wxBNS24WnFtqYAYt/5Xn4HQhfenCGxd6rMgWs79b8RYhAtq4FvhaXZ4E8xnJjxK1KPVVJojBAibUebHXABMDgzVwyVdsMD7e3BXg38pCFpuIXBUf4nc+2ajfI54ZCMtPj0wShChxgt1x8eT8WbsTZSsuZBvorQ/hEi2uhMbSWq5wZd2ZPEETZKdD/5DooFZRPHx8YSwh/CAWwsUsnFZeYZoAYwqQm5A9oas66wG7rp+xYJKMXN+x7F+4NhqddttoSokbDw3GV0YKtv5W3mC9cP6FWFv4IOy0whYXc3beD6HQw1EWmoJA6bRGcJY4LEIj4gnCSDY9I7ASH7NzEhBt+qUUUBQctONTti4f8SWFdQB+JTF7AtT7ljNjpXTx8GjA2fvKXlIT/GYGNkxyUijrmMIBNKQxpgGB+5gPSgH4TdNQXXDNCs47DawGcuec8AJoaLK/KrHpQDwCB1UqxcV/vcIME1yYadsmcALYTK+mDBnU2C9KoLdBGSrj9+9ZE0MgvElWCRcTnSe4FjmHUPe8USzOgVuQSzhSMwqdUfuPzFvD2l6kqqtjMKCZO2aMhfPEJMoEj+RrhBlPJ9OaRSFKy5mjZIlEAHt1TEHtRxAuL4As6r5EWYxE0IJFnCTyeDyu04g4yh7xT/NcPj4IfQTGHXs6iFVDuuPcpnMkMOYbchTHbzHrFAeuKNcqLqNeQR2tVcYEnk8FfZS0nzY/YnTsJpCnYIzIDLutsEJgoE1ZOZ0qHTyzHmTRrFLcvN3HBZLAzOuXLkoy43wYFeck43AV12v4/yABwTtTUvw/ZuMl4L1Z94ImI+DHPckyRDYPpa+1AwHo4IvgZJ3rrXJPYbB88mtWrpwfDtR4Sc0HRpdc69aDXXVeXDip0bh5rJGg1MebTHF7AXrxz2IbNN6zvyVO4Bqth6vGaV0zOV+4m4mmo02kj4e80wus1YAv4cPaDTihMEQj4iFT1nAonBlMg9oCTryzCHzfgbibPh7a57igJ94fRmm+tmoj1g+CccjuBJAQPMNKwiuKU51H2mnsIG6D1/o9/bPvtIeyEe0nSFC/extBUaSyrcCx49tWqomhpMHT0NmTHwUcFnK1nadFNt8L3sDPYjOXtv6K60QrvZVWBpwJ5udO6kbgMNIu/fgjmQa+/uKZ02n0RNPIeC3qQSQUW6L7Xam4XlsI3ieESy26aAoS8IhiygCIiWgpyYgeFEuhbVGMDuoEDID8D6HQxLom4vJbjUyvBdKLzc8U/f4DGYkeGMmCDNNXDOECLnMA2dd9U4AccF7uCm95YHnkWa38yP+dLi6dk5hX/PsUxKDWd3zxvw1kHmtYvJYsK9hLz2th9+7tCc+3aOPNGV6pIY1HvZzfh0J09gLSOYDkQdnHBKhXy2J6FBoYMvggG+v7isoOzj6kQ4yxmUjfXxNw8VXuoqFXywIS7JcBXcB8+LIORdmmgFud35A8SKTaLHzW8gGuPBqmHHMt4fET4lpCItAAxvhevE3eInS1JkrIk07mOrwqg1S/gCc+yiRxuDWDHEAGdzjHlg6PCQEbTW7zoMqnPzKU7+65/GXO7YE/38RDvC26EilXJuXRQrEkZ7HpFIBSKVS7rfMaTw4PGHc4D3afVqGG57CDX8+r9yxF4dKuAC1utzliT1h/si2FhDD6+zRs2AhyCiFYF7L3GcdBDYeySj0wSBymVp/xuOAjwc4IW+ZdcYpvt1fC+nQB+ERD0C+Nk91XxhDbm8LXNbD26BrC2OatojimJj8LfUhN2TjaLLbmfCH3fq5cZztPMK+pbNvvgNeggDAhudNwxU9uY286MZf0RmcEcPMxS7xxpvJbrc2N6Cs2Td1pxnaBwFXpjwcUBZ1qlFv9CDw1F04o24yMJbJihquwY0iDDwNl99WzJe/8f/8v1Ucy26eOJbTrLygiyCqvEuimz/8Ff1aMb/Pw2AVTv+orxJk4HkwpYuIusZNHC8j98mT6yC+Sb7Up+HdE1nyiZjTky/z8MuTr3wgT0avhmdvzs8MHDGnLI9qwQANVbZoD97CuP+CB94JEr6K8Afi+2xTTkCbHevx7GcbSJDwxcAeJ/Am4y6Eaj6PPs95T/E5DGcUkka667sfclMoI4me4mOyNjege4svCZz3+IIcS5GZtEKFQD6Xz8jFIIL3D2GaYybk1jzGlwg9bbWcXnu3S5612g27t9tdsmBfF/C2y8tA6MenrXbDsXa702etTqPZGESDIavuJm40GMFPfFlzkHt6wgrWzOSEtfv0qW2hWrvVarTzvgqnDAp7DgkxB9ieB0NmefiIOzjzUFNiN8F83nA6oT+gHN137dszBOEPHfJ5g6sc6s8bkmFdM7XJI8EAmhHSWDteG7L5L+aZoFplEZeaNrMPYT4sH9QU5cjnKafveC1+BosHzLXgJFFH71Nr4JlBLcQWctdKyNjrPaxywx+xPIXyD701SmLJRv6wCandaaN7vfiq18RKeAR7mAtLweqMGyTZjv2DQAwW93aowy18BnALcc+yOnav57SanabV69mIOYZid/5H8CaNXub87X7wefz8Z3rEltP9AS5Zu/gwzVxOpYSFJXvJB7ZbP87xSBOMAg0w4vCd5DeNe50NsbDmu51ucSnC2DNRR4RPmWDiVos7yZlvMySG8PDN3i8S85aLmI6ArEGLmdFjoLHIox9hkYtvxKJf4ckdHvgjjv9MJvewArUlleQ5YB9CHiyvAznt40HohjUIkIkZF3Z/r9u0n4wPsF0TYnw3tBcOdc/6PoEbDi8JD+6YnVaPNsfdo5pFi/FzqXtNCjyqwiK2SIswWyVf0EG+NDzO4j5wIo7vzYPGFvcOTZNrhma050pFekFNuVVW77HnviUJ7cpfzMI7k7sjsevZxe5+9X15fNRM3f+r4cI1GHlGbWPWal5tjRttpsoPavAPD61bcDpereaPlz39eJErFW/gQbndtn9Awr3n7gSs+cvMJpvOQbtJvXSKdPGvVEFoOvk3hYK3VGYi9wMm/kpikCjDNt1oErxGPtGCIzzh/tXEzlkjFnbEaTd/oEdxz1hnN6iNunIM1dIx4issHkYkqfPVYc6ljfUfkQEPAeBP5Spc7XYjl7nFWwfxTZjE58xqa7fbPOQ8+RQzT2WnwPEO4eo0whEajMbRxD2We7gOt0eEoQxjuBqt7g/IEzRxrHZxFPYNw0yz9pbNu9WTOmNwkRc6MmBNKswYQSeI64wxY02uM8b8z3HVW4GLH+XN6vCt85OyVzQi5W5uBIeDgQNKPg0c18Y+88mlnAuCHq4POhmCmz6xJ3hKSZKLo0LZYTYV9pBLSqa66y04Dj/CpgDJH7cERdWqeMNmXlmSiOIj5d97ShlQZxTuBYxsGsyaIjTN/DnLubhj89MgdCPxUIxwQj6xaNJTEKW6/Fe/aNcWwMIn2LijUeRfUwPDlcFM8BXFCXdLhx0eRhbcpiyFvmS1Cs524Ce+oggXNWSeBcxdWJJlgPEiwknKoyJdaVAhAAw+uSNC5nQwHDDnYaDDect/CD1FcFzrfgfnYSCgmYJW3wgKTCmmFGH529jzYgjaBrc8F+Gjj+x9a6mUSOfMEHiplEfnFOGC3+YrWiJ6pcwqEQJn/IAuutgc68xxX0FpjG0xxNyM5wJsgGGaZ97CrjjalFFVUPEamWCYUqdQAQxVUlAk3RwyXWelmVQF8M7tpIhjICxSYm65NACkLBoo4NNfBfE315F6p6cp3ubH6g7N23w0sqK4Ol/e1F1Dr0vc8qUIn7LQ8XDld6xm90cgzqnNOlNA3WSEZ5gRnttMWbVgSi0eaY8zwnOZEZ4LJiztOg1BgziR+njQkfwn4fSTUiJenoDiHAJM7gHKM32x/fqbQUA2TEH7E04Gay40d0Hl2gxIMoBP99b02a4I8Ee5wZDUIuHiZ8jLNv53LjUwAxxQHIi9b5dsbod5zdP2tjPhylRDeBgTy3hhRphSvAXLMHeY8oEYHDNBLoeDtA/+OwOKP4EyHPzV9jlrz6ck0CnFrfkp+8JbHS1PTZv57BOzYd+GgTBrkH0UthNKH4Xk+7gdUMBCFjnncIgUhqTAV0M4ncfHqmCROYT8iBuPCN6gIb3cSFksU88DOxhxYoKTeKkn0GlLZGXn4aXQ/NdMDS7IhpnafyebujLyNi/1E/OWR6TCvG4uuCAOlW7olj0DX2KhlJ4wnSd4Z3Ut/Ae4egtTMOnZF5l85yiLw8wfJjyFsLq1Gjspw90ueEbk+6kWk4oV5/pSI8EyA3FQZiJ16JnbinD5rat0RQIWdpHlCNuYLA9k9XpMu5E5DjAk8lSEZRWOnB/Juq6iIrDfK/8bfwvivcJ7m6oCv6UFDsJHp9XqsdTMieDc+8hOI3YyDc2P8igSPK8sl+YcfWEWxukHKKEmZNbOHnHgDInxK/3yPIro3Zf5NwPeeMbDCR4ROCk0l7cwqsJ5gTnO/My2Eis2hOQ9X7Aaq7JN+wGwnczxIh4h/PBWtOV5M0pZjGreqTbmh3rmno9vwTvV3iiGNQM4XTUWgc9DHMV+/Nghpf17ASFZuPtPRnEgPnAcIvxQV1/9+aO6k+X+9S7f+4tr+pg+s4L/hk7pFV3RxfRxHecK/+udn39bxP7mMT1rJf/1bpUQ5YFOVbl/vcuL968e06Ms9ugO79u+pjEM75bB/FFz1Yv+m3ofBYs/HtO1Kvdv6vd9soiDu8chtFb04d5BRaZhdX7kxABPsqifEUQtRl/R/EzmMNeNzMACb+Gn6wG/xILi/UDPzBYn17MQzimKXLiF5AxnRPf8QwzAaj1i5qB4KV7ouEyLv+KtMy5skzFew4zxuiXCL5bGZEGcooiC4t5aY7JuUX8j5EfYkM/UuUUe6azVLeZCUNfTWKs8T1UBgzhN6+hUc6PLK7MI1ayukqQ+I9L97GCo8VAuU1MKsIewqFEjyuwFhOEQqJuhEgsCeJgB5pElU2w7rcOCZRluUhNbOt3OHrMibK4zh49Kl3zIFfDWJuNVhghvxsMJ2XBeLMXtXvPRUQxZyEzwds9GrBvsJSD36li9R2jjNYqBP1lwzocqQjjJfL0UM8G+WmHPW9MvS3/6hye8I3me9o4AJtLq8QRudkq/UzYpAo1DSbINZm6EIWQ8nblHtiBJEXiWUk+A40jYPnpZ2GCs/d4fDsJenbcJRhWqaFpStL4gkQgJHBKm8pGFFR5AvGcV2ZVFf1ZvemVNzcwQb32mABKCtVlpbK1BRJ6VZoBYLIL+lB5c2dTqmrGPDGZgw/OsGRDYafAOhbvVJHuMKvNvAW5SWNUmVA20Oefq2W3ILoueXY9v6EKVTfmK5mxWmAwS9cumsDI9EcAB8CoC/1VjbtkEcadxaDKVQzNEWVzqDXHYSPt7U9lUq0f/HamY6RvU35DQ3CB0WDnb3GhmexF5xnRlYHUB1TLF17VceZ6Hy5fcw2uEvbQ8l0gl1lyY4hCVFQ6Z4Ake9UszeUjjQ4E8gjLrSaYFhsqHdk3yZpn3+CeVy5yl9LW3K0C63Y4FjDvoC1TdE8s6Eg5NJdDZF6h+lg47lBDdCzBdFq0cipY2syL8xW7fxI0/NFSr/G9dE5cdBv5+WXVX5pvKSBE+VNnI9p66YrOgaKUDX9zNYOgm2AnGN6BPBZZrYCC3ogsWI1p+gKoVKCnzralMyU1zj/IfwuutTvh+fSXEYMwsvXxXR+jAHonwdjg6e/6etRTGmH14px/ZdxDjN2e/es8vPvzinb3nZSKedv7ubKjSVvk07/T5h+EvLGfBcz788v7tr29UeVpM1WrEMZ7Oqb9iH+wX/z79lqWcfsMLun6exDdnK14w+4Sc8yWdajniU8s5BawvZLM0KPMBXqEXWn2VkMvNtZFPTaXN6MH1CA+tR4i3AO637199OmONv4mxSvDewk+HpY9i5Wb+jK0QS30Z49Hbn99efGBf72P8TuTu1X8R4/dn5x/eavnPP7x6+4Zl/hnj81/eipV/9/bdxTuW/CHGl89Hr148/8DHdhpjP4lv3oXLZMkS1BdLD1fBd6rS2VeWzu2qQKPt1yC+OfWjYAorSBdxMOVx73IVHyx+qGWIUffOX/l30X0NZqW0dpbLeflYsoys9Fv45eQL8rRiGejvHV1FQRS/Xe63fqBQ1so7P4rW4WqWryZTs3IigF6+mEjMSj0wmv1xSNVaCvDne1JPwfPwOkx4r/ynSCnv6EAeXvKv52IcDNivrt5QCtwoVLyvAF6uqMygr+5gtQI+orIMvKJRHK5orilWuiwDRzfh+gUcFQF8cqwqpIn9Hxze/8Gh/R/grYI9n+gvMZ5lTb8MV+95cXZfZyUOZ+s1P4TPc3vybzFwIkO2drydv7IUbR6nnCljmaU5OIie50erJzBQLYqw+jVOUaaTVA6e5BB4Erz98Pbnn0dnHid+56zJbwt88Q6oUi71eoFn4XoBtw0+Sw6sXFIGgtNvF6u5nKmWJG9AkOMzJiryeBKOw+vrOQcm/4mT5cyPeQr/mWrcdPlU14emuub+wMWIREObww1tDjW0wdtYxrTkg5VfqXyOO9jo8FCjw2KjS6CeNKYrL1zQ8MordnJ7uJPbQ53c4q0/52JGjjuvF3juRzFL4SfSQjQ/Otz86FDzI7yFU44fnAqFXr4afTjjfMpIJY6ef5SH6guV+PrtC3EOLkD5eHFNX4OePKRkn2wT5LAHEgSmvAzmMV1p+MITRO7I/yYpqZ4gZnx6eManh2Z8irfTZLWiC96q+I3FX200uRQcROewk8UGZ7/ZtM6Tuzt/xRmz4wVe3/ixgoD8EKM9Pjza40OjPcbb2L++prO3S+Eei2NBMVF0cXm4i8tDXVzi7YqfiOeLYLmk8c90weVy3jRZzb0vfnQjjoIHSuF7S0zvZg83M72b3d/KMlzTVXRD5/OHG8vKCvBcHAbPxSHwXDAaxP1rjPzFdQJP5tD194U4KuAWcrZZ+gtJI39lOWowfMUuWGJxoOpg2csQQ/5+eMjfDw35O97+NQoX59Mbeucz+R4EOYboxvyMK8/DJekQwfdQHcjT6ryUVDCfpJXwfKhaKMMT9VJfwnBO/UWxnEjWS/K7erEgT9XLCbFwoRxPTeUL9UEwfzwE5o+MNIfrD6tvr+K3SfxSEORiIv7iRxRCMHOeI8JTf3G2odMkpmwoglwWEtkzaiJZj+NIfb9dSjZHcp6FZCyczsCb1iX3zycK5lMlzXu3CmfJlGYt7iXrPBTH8AjTTUxXC3/+IpzytPcRvgoWs4zXYan5JNgCb5+fNwSDNhOs+Gk4+zbMhif3xMMlob138tSV1VQCOPL/JRTsfxziG/n7lwgHi6uQXy6Bg2MxuqFBjhuZU5azPxN/Loj+/YVwEMF4+ZEcFc7oPyJ8l8R+rOYhYb2XWijHQUtDHEpSr6FMIS0rA3eJ1zT286VkalaOt/5npKVAofdhGL9a3NBVEAuK9nOxyAdfMMz/iLBie14tpvMkgvHQOA4W13KS9xXIamuoWkjLyuQmtpe6X+7026sZXcTw+lVaI8vP6kZslDP6apGvk6Xvl1VYW5qDmTSMs0kRXop9xb4vs+/Cvt5PlgejLJF9yhze5t8jvKLRMlxENCuqvlUeL/wpwhHDHnH/iXBEp8lKQuwi+y5eYr5D1t1Xse9GEY6WdMp+ww/29Vd+C3nF84D2su/b7JuhG43C+Vc6O0++xCsqBvKal5F5LO1dPk2UV10W0llZHrmeFXnOa5/HfMBvIxz71y9o7AdzxVOJT1zKc0UssH+4irxiNpTnZT5GOBF3qGEEJrdBVozb4/FyL0WmH9NTehWuqKD9LLM0R5YPwoV2GygmYmm3yth0yUR8Ony6fTp0un0SUlHv/dnfL87OOeO/DrFMPH/39s055/3PZKqUjHnvnr9//pov/nkI4rhcK16Iz88+eK8vPjz/cPYil/WFZ+lJX2WS1uM3nnY+/OXsNU95G8orydnrdx8+8hF4r94MRxfnUqR3p8r89Vwk3agkcDTNpH/e67MPz2EqF7zljSrC2mRpVyrt/dn529Hl2QtO5veSvfOL0w/vz3hLQ5UNYmKWlKiki/cjvu9DXAbG61Dcr4bivGekUbto5dJFWXYSamXYt55XoI+lObK8oEfFfnPpXE6tS930BJnL6Y+ezVN4/qXAfk1iWZKOqbZh5LVSpMk8fRz5JBws5CYrUI2hP73hjd5fBCR6evvZJ5wOEf0QKnKnfUtCXUbCyrPwin+fS+KqfeOIxq9z7IKgU4VUKFcokMvR1kP7hjyN2VBf4mbO/PKxtVCnunZNL8kV9RgHLieTTxIlcjQ/n1QooYOvNEeUL/Sn9SUFXupLkWUN/fJJgqZSepioUnqIqlKKtyXYCcHZvQIWyyUqAVuueCkM7yvKipVMM1e0dM7BPXMODs45oPw++g7ehTKe+H2IPX9FGfPMB5B9YmYopE5OlptP4iXeqQeo7JPnvKdAlVZZnkjAM0qXw3m44FjzIoQrBKNdp9/ehcFCu0fkUxXEcighU8Wc1FemlX8QYP5BgPlMYf9u6a8EkeS/8TUXDsj7Ef/A4ZeIrr7yRPEbJws9VX2JUc3vGdX84KjmFG9v/EhEjuSM1WufP3qVpOOAM8DRcO5HktsspKkywmdAroxIA/n6YhHGnMnhnXD+M4EskB7oqX9C6imXFejpp5A+DO+04Jf8JgvpesIbSGB6ilriZcIuiWDprCcfQzJ4H9UTRzyxWPYFJPNndj35JSS/gxOCB2zR8/7G8qQhtp6zn4yD6D290stcQG21HHrOLyyHiT/05PeJwI/pPfgxPYgfU4q3QQScVaY7rMP1CvNcfnvWsz5cCZBLV2iFlX5xJa/oueFeieEu7xnu8uBwl2y4AlMysVAeyoeyAZf8+fyLP82hyas7jmTLcEEXcQ6t3vGsRezn1/81pJ9t/LvlPAeuDzxdyViYbureHngDpX6h/iyPa+8h+dXiKsxB8Y5BmfmdyeHrXQnCn97pUhE9h14DHi/p4vkyaHhWbu/diSx/GeRwXKSv9sZ/fMexn9+ZczuO58Q3IGrMobXMyIH3+x3fAPt49yvPUdKj3AbjeZzfye0QPSPX0V8hZx9VfmbJ4qasPT/qZT7qZVgTuT7/wbNXX/OA+JQlX/qrADSo9Oy/Z9m5ca6kZHN2z+6YHdwds4d3x3JFZ8HUj2nk/fBG+eAd3ChvvPKN8t4r3ygvvMdslJFXulFOvZKN8hISs5m8CPx5gWj/6ZVvpWOv7OzwDm2l6EzfSnYOn73yrXThHdpKv3qHttLfvANb6ZeSDAlJecyU55Ztwb96h7bgz2U5hY4OZR/evB+9A5v3H96hzfvJK928f/ce3rz07N7NG5+Vbt7V2b2bd3EmNunVPZv06uAmvWJ8YvhHUJA9el+SYD6jq8jjufiGIf6hQjyXCSkPFYE8/GdCV98OlWCZYjbf7pnNt4Oz+Ubx9jSJY8Hu8594GAppWjiH33N/KW6q8gMzWhEsxPzUF361WIqXY/YLw75kn3P2hOwlcTCPPEjF78Xz9Ptwjc+ZlI998p8YHrqerygXOsuPVEbzOjzVLwen+oXibf5R6G3+ueeXt3tPLpdvy55Xjt/mHjku3maPHL++zYua//o2J+n9+e1hye7beySX7JaYiUJLCwrgePcAxzsIHI8y3c5DOjsf34rW1/e0vj7Y+hr2jDiRooKA9+8cxJxAqNcr/qk/72Sz54kizyrNtIACrEFgvHL2CsgMHPETnFMpOcPze2Z4fnCG57QgufVO3774mMlmz96/f8t1O748x+UFheTTe64LYw828/W5lGE+H0JoYO/sH89fvxudnXuvz16fCj2Sq+eZaFRrLi+gnZUXyka0vKeA9/7sw/NXb7yXo+c/s8I3e4WHb998OHvzwfvw8R1v704rwsXL+2WuVZnzs9HZECTW52fvL8W8Ei0XEr3L5+9fQYhCbdTfnufkonCQSWFmJg65t0RZ/YQeqpdQDLvn3u7uK4AjqfAg+C1x9ZVywLI8TcYI7eVlggfyCnX2B3lP/n7dhJbXSahWtvjKXJqjS0VLK+xl8Rpw8BT7fjn3r7OKh0owWSujC3TG2QVZJ5/Ky+nsRG7iJVlSae4eUrI5SEo2FG/9Q4hw+xyOnouIrs5m8FAME+JH8HMp5oYk7bHqeS6j+BjMn3FyRbLJbVR6cUFePVcvmsWsd88xJ7ZCSecAvtxTAkc58HOu4Lmg1mdXV5QBJ2vsjczbX57XxSwOkw/PcXQTJvNZOXLwZ6Xn95zHL57f85L4Pss8v2F6KVKfIifFLOQJnHlHSZkxXa/dalooM7gDBUefaYuwpX7NvYpkJri/vw4WwVVAZxUQwW4qFEpV/uN4G6X9ytcgCuKKdGG7ggL126gerq6fcMQZTMMZJVAYOqrEN7RylTCfzaybSriqJBFl6YtwcXIn+5rRrxW6+BqswgWw26wyq8i6j+qV35lZ2AdKmM1JiRVZwdaGi1Bh4XY747/+K/sEGyLM3dlxmRozyMo5uVPGNyYEV4iSL1z3yOwgLVQbD7gG3rPeU7J99ebVB/f3//ovBpIn8HW81TsxUfo7fn/2bvR8eJaVEwklRd+9f3t65l28+dsbsDeBg1qcuapuWYn9hjRLyyB6N/eDhfLTqttPKYulKIuAL2Pe2f05jSshiXjUBxEaqtzqMER9FB7O4y1WDlksEgKuTUX/hwqlhehV53G4olpMnQw1sklpbhq5QfmBTeBwH14l2BWWWy7udmWppWUzn0KNyWPHY903HuVRMWD2miEOCU/SPCEdBEnw2CHYmQljYGoQR9x9KKBGQiIww8Zr5kwF3hI2ZI2HxMK3EN9Uiy4cJSs69Bf8ufMN3cSjIIrpgq4iE203hBAIk7tRzawzw0pp+sic+5ghZnFtNVwAlUxolNsf3j52do1sdl7WGOz46Sr4QuUu+RdQqomEE9hHlm8hJPbbkdW/H2J95rmlEpBhrSZnweET4AhhNZ1kkU2IzSf8MSC1EeqziB/3Dwdv6tzfH5iKrAmPl5MBFeLxaZabR0Vy9NjRdDhAFfZHdViSx9buih2lYlXI9fyhVuzOD65qD/FY+7fMhJwkpgcYfBUs/PmcpdrCNZO5JgV74m1kIhZmN1UQ5P4T3tM6nDOpxOCtzMdqubHcFnhFl3N/SuUzq1qVfPq/Ad9toFlAEfZHK047sEYdf6CTbBDZ4Wwi7vqsEhE1CTk9lWCG5WdXdnaEjx4tkLfCOKgkJHIkYT8SAcH5XzMjNijN15Fj1bacG5l6GZTy2RfiKKf6VgHfIs/ZzyEQXOksr+gcv16vZ74FQzMS7jqhPRyA++zspATRfUShRuZqhW8e4SkjIs8i186ljK2JG9VXDDMUAeadkmeRGfKfQIWBM/vzAPfZtMDHaWlmfWH+SRH+Xl6za/fsHsK/Hqr6nSL8y4GqvRa4nftYnutYjUYT4X+U54Lz/ibCfz/U7T+opLs0JgZdrbwFXXsM3RYeXa0MHJeme19gMxh4peVGSzrldRYlqbJGpOWBoR2vEYpUJk8wcKB/el++GX3Nh0pm65ux+XxXUlB/+gbGYa5p4Y+0HtFV4M+D7/zWjphX6tKWTvMOpnlzcdZclK8nLJaLFVYPVSjtZnGwlrCoLlaIyiswYJlgg54vHt5T/PSbGbEbw5GVrxTkKnEU8TP/GpU7/w/K48pmIdnnoTDW3ab4JojicPUNfoZLygUx2xSzQLLyg9mE5GIMg83GCz/2c6kpO+IyfwQHvCNwzwsR4anKO0ZYCa/GBnRlYON0Hn4xsCG7MSYoBKcTvIqMHsm/WHhE5ZwBABDOKXfYCmgkO01FeOJ5XLr/7HYHvA5NY2KWbu2mBS79yndu1zqUZdlWEyGcxAd29TRGeFk+oE7D6iF8c6jmMkZ4Vl6z6TTBz+rdoaqzGOHrA522nG4X4W+Hql7HCH89AMAueOH9cqjm1xhhr7ym41gthM8O1fRihNflNVvgGxbh80NV1zHCm/KqbadrOQj/cajqJpb0dhiTY2qi+jmN6+GVaXDf6QY/2Q1sBDG9iwysIitg487fBHfJnYENumHCq6/0tUoC0UMxUyXd+ZuRjNN5FyzU76Ufx3S14CVeiQ7vgoX8mSyCPxMqv+iCN5bM42A5p2+vDI3Z0C1f+JuFGeEtf4Rww5QRJeCT2Zxf+8t6EL32l1msiS17HfJdmW+izM5BSNbUKzYPXsldj4rqxpdw9s1gB/41jU0jWBhoINsUafzLwFkXD/bhZk1cMUtMxTkM47pUFTNDhB41WjEOYQplIMmThaSQkw1xC/H6/6DfzumfJqpfBasoztgyfXqvFuY4qx9iOdvJI6cbpmlJqwpoaHAYiD+wZoy3enuAvnTBpVj9NLm6oiuxSZ7HWWgR0JW4A/ukL3NKIvKM9cqcuH2Z03oQyZ9mpKElZ6qDq2+aUz6pCGdGaKC1CZ9RPQ7/em4il3nZUq1crcK7v56/Xc3ois7ErSJfMzuEYJ31eJB+XIfDJ1fgSBtCLoMpFNaFYqGWKSjFn2aE6nf+0swNCAR8cHE12SXuS2wiM6oLF7YajhUETW+/3IL5yy9+dENnMtQfjK3YgBqfbGibQpB676efxhNDuD8LVybc8r0Ku3uKqiZCcDsfe2NrMtntEv6jWhU/WHR6P1hErwVB4d2LXLQVP8i2WA5cOHGu3rVTHI5/P95CyfR4G6THsprg+9PfJ0SMAAv3/eIzzResEfsRTXlje5LSeaRaYSkqsFCqRRliSyZWCPZGWLZy6YHCpcussYZwSABbK/FEonYRfwaRC/EgNKHpSz2eSUlYcr1sJluV0VwhannRR1WuCvi5enwHDw1f8J23MUkAu/VdzaCkXSO1KLoAPXXPC3AC97xgnExIaEbjZIITBPdJiMWX6i1yocEPNLplKJ+1qkRvJUDylIcs7uqSe24M0sIwom8R2DbfJIs/Xgez2Zyu/ZX2mGEqoYgbKmGIG6Sge02eBaUPCcEgMGEruqEZaD3JxxehZq6O7BAHOMEe2uqn63jChHhrcKm1ISE/CYCg8xcIPJRpgprPDHC0KdIk2wL+z2WS4EzAD7pI4pHD8bH8FnwQOEXP2hGMC/jyz1pSid9los62gIf/rAGZ9kmrL9MolYmSLVIiSUqC3e4IJAxD7FPC3wwiPKckoLudT6tVgxmSAwNyCtHjjiCsxpFPEZ5SsqlW5SMEEGiRVa0eQWAMCIV5dKo8vPH4j4Z8C6tcBXQ+qwQ89v1yFX4NZgy6ax69ZLc7gv7mNL9QS0qkSBBGVK1GeEaJNsZqtbjVQBokvIdeFYoy4gQHTB3UC6NYlJ6GySJWYttvlIyXLATIFcW56tlIJAmA8RhXcCMToys5L7EhTO5FGTPa7Y6YRAdhGfUry7FERrCI6XVpjtqQbEh7NIwv0REhUbFo2egn9Si8o0yeeXQUIaSW9Rv8k/xzq5nv1xQo4+12hp+5u3pyG7HNTTyEGP0JOFLtD5N5qA8I6LzXmQ1YSRwtNULFuotIXiK2110SxZUvlBOLCjQFg01ZTO8bP9L3e7UqiP9QYAmqVuVPk/3OSYGz155xNKlWxTi2y1W4/Bv95kaYXbTdDHQy7jgD3VWYLGbgZB8hnA1mqUziYThyL2uJ++8vHHc3pJwaBuNwgsGTLqOIfTHIer2+YaczTCQbciiGHKVMiIhQGlyZlPJVConqEugqCHLf0+uzDWMLWKAqxWgZlxnor0J4GK8IelQxamGKWLQO1A8V0ELW06ccDSrtNapWw2fE3u2ibLM/lST+dx55gnUrGK6KH1fm1I/iyvE2TCtwIT3e2ixSo2G4RmSkv7PhfNofzccHR6ON4VnZGBZhNo67cMXe2BcPDOSjPpDtgoIJ4l34lTGMfHEgyEVwZX5/zPBMI14ljESFgviHGjdtWvhPWucMGjKFE+c4PKeCDVeTC+pR8F2gQSSqndMY8WKhhpOCqwjVVTMiz8SmiuqUWaahgfxlBsiNYP8gxDp4ZgO9I1Hdn83MhCEghifTiHevYnJkiMtdVKud9iaszBJOZ2hUYRoZdFY3AJvrcciPCZSmDNDfdUDX63W+6Jec2l6WwnNvrS9zFGYRVubh4pqCjoUv1nl646/8aUxXx1sImRQOjMhwDbnYl/tYd3Ffz08P9JxH8vv7vNjv85ZP+ra060OzndMoyibK277db3vE2x6Vtn1oPtfsdrcqNj/ab15nEUQXDCWJAaTwJA7uGPYfDxjFZlfeN/4b84UfU3WilFEtAGoFSn2AFqB35BpJEszyrUVaQH2mpDIK13Q19CNqInz05Lfx1pwMxtZJzz+5mmy76Yn63XzEb9tJxyidDI6f3ENf2Uh/hpHxUaqhZee+9mR4oL4oyFrAimWurPsZsNmNESCu8zRqVUWnnNzAlgWFC38eyY8jS/wFjDw4DNk0G0dONlY+FI2HKo7k6MlvJ4PPs5r5uQ5/0COAKFp7bOc6n3ao9wc7XVRkM4/tVqf5UHoG7NoVzeqwU0nnEoKHuYQIC1YjEBeJ+/kFTnaDPW5BDVIxxkXAAAqUy5YOrQpr6gHgyCRx1+Z2CV/i0GduoDlVEGVCkuv9bTyI3LcxOwRNfS9jI4mvTroGwmGWaoDJQbtpoBS/isk2c+hzHq5iunK3/nx547uCxEXqJnZjoDo8LM3pkBsim6Geh/AdU0csVOSJh6rKXJQy3ykHRlCoCk/dzOBEvh5lAIJoalIGxryYR3ztEhKNg0lf6S+Bw3iD/w35AowDbBAD08U0nNGL96+UVZqZoLrQZjCf/KdjPbnGRs1AE6GoB2OXqyKSqgDaiOtSMjt2EOoRFdHg6OgGBHsBNgPy7Bv8hLGxsFlI1xqI/EUQB9/BG4EmyNmjhrudYcjwt6aF53H9ryinziauBtGl8hFz8X6kyZLMo2i3y3zTGwzaN2EUG+gZsXJZttOpW3WrbossYxEu2BZREiIu0nxB6RIsasCwBRZn/1K02+UwmKMmSH1XwZ2pAfxzBPD+T8cykGsYmEZTf7nX/BkAcb9nM9IaEivnAaJeU2ZXtogA6wkLZ1B4VHjy2+ZEUD32psAcod7dhYsH64krwk4JSXZKMrITApidkLpoHWSrPqN0Of8G8IDLDEca9S58QLOyKELIdC13u6OwKDdOSF4Atk1xhPolgrakqDDEo30HJgTFgLi3Qo4Lny78QwqDZ+UA6YEHTjKM5Ngg3gLK78w5KX61yp8DBGPNXwZ0UcK+8ABpzv/ZzVvrE0Mh7MhLeCZlFAQye1TmYGRbSyer2UzYK8uHkPvIuQroCt7aeOXn87nLtNsYHWDS/cgNyJF13xNcUb3I2OvArazolAZf6QwO+3Bx8uoOmqio956KDztrmcRKapbIVy0WIQ9ixWhvc0qeKDGAQfvGj27AIXi16lVZSAvFsYJgPq0fb5OUFTo53malQTEYYV5jr/jvCIu0BOFwsHbXY2sCxGsPmuzwzIl/HwAzRCJQB3vIwmPoVzdF9SOE4E1B9fel3YzD03bzYjU/Y3R/pklkIo0I1YB0nBg6XXoiqEmWRCDFkIQwiJjvGzYXIBVHERdH5h6rdrujqC5KAkYHs5cs0kvUL1NGZt7qNGE0V03mx9u4XGAdILWuRa1m04/rnvf+7MXFP7wXZ5cf3r4dnXtn//hw9gbssLzh29fv3p6fed5uJ7S8UBY+iSmEad3k9L9C8kzeoDkoQDIPCQzVpNyc0bTtY9XpWgilApvXZKsE7l5d/lRqgcA1sIsweSaTZEqKNyS7d0cQi1zRPTUsTaVtg0yvLjMQ3tbrdU/1k6YplEmAaZzO/SiqMNDqEVaFBtCv1EQiPlXERr1N8XKeXAeLyIXIGGzh3O1UuB/fpviKKe5MlV08fK2YM0agd+yTtfROtAKRLb+AJO5ctJXiOAznX8INy4p4MGoGK1aAxeCpe+qbxR8TIbBYVgRT0d4ppVf7nE78Pl6VImhqAlbjvJhG9OLHtDg28c34O55iHtkicUWvgwh2NfsSMEQp1GUDy6k+8kmkqk6EQfVZxrGchndfIMQJb8OMCqMwEerzhWEH/kwMmKXggMk694YJQVLn8+dXMV2NQn/Gg7vo9Uo6KWsm1b8iNmbO26rafLIKNXGwlyW3RZ83n+HG/tkvq77PEMzMFoWH2Js9X8xOw0Rqj0ZmeLDEuTRrNYPylZVDY6QKpGcq+eVC61h44weqGAkorSiDjNQpRqmngTK39NqE0+LUCk+KEhCqKTc/aiyh6uZgrG8ZzhzKvZpNQCYVynLSdWjF9EgEapuKBL2hV3dM2wO/p/40dt/RFGuoVteoxW4HD5ueDtL8NuE1BPlJS8op0lRWPo2yChHalhQhUVpcuW22QesFTfHscNZrlARy9Odzkcs65lvk0Dsxv3mxeMV5zcm9DsyQmYn8SevMeZUS2asAcX11n2Sq/SyOXJIpdYNdPPOMu/o2ZIdPgljv/RxLSaJB6Ebq4pim7A6IcMifw5mQQJ9LiAoxnYGmiiZNC/9C678guBib6kGeHXECACsQD6Y6Larr5wdiJBR0gzJAgpZ2PQ4vlkspCaxF9WgeTMFyR84le6s/1DQuPNEHIvCeJ+axHSe1cOJ6LNbmNY0z6pFHUjk+Q1nNG6y8pEeqtJh/vpJwZmcgBhVdw0AX5AqVDDWycAIKt0wqdJgQRod6LpTKeuJvhYcANg4FlC180kGTOqCTaEVCPRhki6w3mhB4JpOlkkFBhylBu52ZkHEyQTgpqt+zBkDPmXVFpDZ+WHYw8kyhPfVyYapq+vUlC6RraFOowFNQVInDihiln+3n+MaPRXIEVxu6zvLMgJt2rvkiVPiCIiOLglfcddmgUoSj3U6FWlWx2BBiMj/2T3rfOaZfRMoWOV8SS2IjGfMgW9AemmDv8NInfLlVe3JynlzwMN/4mngg1JIgKC74GhZ8TcbrCdy8CqSQN8CnLDtky+6BUwAzLFn2iJ9SrxZwgWMlMxwotHQPJsgi/0tRQRtKinB4YPnDVPyTysNX3886+T1IOctPGH69DhQ5Tws8z7+lE2a0bqKSroCPkkf31SJ3al8t0jTHm0iVesVfqrNblgCKXaoVFqJBWKQjpQQDITe7gg8O9uKW56Tl++zAjswKoeLZM05yO7Gfb4CLY/mVTu2vPbISIGEMxURI42xLeGxLJBOJhiX6X2DWUNLkGpkhAAmvU/7+sH94bFn00UPsfz+3fvqhiJercEojJqoss70b5LgFUZi9JbqmuEOL6xJMt8/1WCKepYXLJMrmhz+lHFmPMf4RNooqOmtaWJAQmyF5dth2TaBBuRmzYtfKTd76D5moP1YuASas0lpWqN/yz0Q8PkgJCFD3fslAvWqVq0TuD9SDPZ0q+JhyfUKEI8GUvPaXnK6EEIUiY4PMLPbo/kVP7UvRwguBR7KRnCSHCS+0yg/fB9ktLipYCebv2mGZBni1eqSpy/5d9BshV+myDooNQbchcrVqmXinpFOkKKl+tcfbjNa4Yf7mBhayOf3z3A0fGmW+cEc8LlrAJbuCF+s/MMVSY/6o7svWq1UzAavePYKhlUGZkIEdABqoykURmMNMH3WSHoLgoSb26iPkJkXVWSE3AREYDpXEe1/dfpvm9fBDTc89ZPxQthzVqrzfFHMyWq82nHaEVKv6F+xEr6h36aGBWShEPLjBTv3YZFczqbRe6Bn4aRd2cbH22MPB5L5a8CiSu+MVysgr3253oBFBerY5WWCQCuVWCdAAIfkiCg8DlUASpUTdxjLoJwhNw0UcLBIqGte4d9fDOf7UXackyfXlIZR7ffXkbmCM6qH7CIZckggNQn02xW+moriXpF+SHsgeBxNAogeKsOHKxX+oMNcvVCBYF0CwliBY3wuC9b8BBGphHixwHxj0QmT9ICD04mAbLjYvOzeYJw9FF/YJGd7Ow2vhGCpUL2NFBkrjVyK3YKSuvfJFGolimXs6tmG1WrQexcKbBPNzdMCQ0LbbFsKvDxkSvlOGhB9iYkAsQG8JcX8N/CYmhopgauD3MTF4jFcDv4iJsVxRT2V7Ifx0DDzSK6nU05gYUunFwC9jYiiZPGvDwH/GxBBBWlWr7LFfs9guxGYtWjN/KDdPVqMpln9TZpl8MFouiwjOIrpKchKmXMtS7wCH9fvC2ZpIczzEgVkc1fuSUR0IrXt4SKrph8dTGmG3pGVMVyv5wQxFgC6wIm6C4/APunA9HHAtDaCs8FzEDQA3GB5u3WFKEnxLNsIsYx6uDdQXR4Mf1yPuQ/Li1Xs6C1Z0GouQy4avwjuDpsbtbrfe7YK6ZtTOBvFq5g5xxMMkGRyl5vQrnbvG2l8t4HUeC19brpEDReXO/wZKTski8q8oriz9KKKzCqMVlbUfVXh8mBljiJnns8q7QpHFX+R1H1TzV+EdQyNRGsLSe3zDDn5k2KxGNuiCEoAHnJ2GegdCTZuFBUp1/CtULiLi6L7tcaC/R2wS1dkjUHMvJPah9gEVBbYFHNsSnER0xX56eCmqu2v1k5mSbvB0HtBFzFaB/zyn0xWN3VtA4BHZXq/8RewxeBiyqoGjabikblRnfyOhNlUxUHmfKWYBXqN1APR8g7ZTP6KG8AN4wuyN3aOMyNF4KAb1fDHjw5FPlmHR+CvCWz5sL5gBKPrB4RIRn1mQotQc4SHoCX9ZUf+PPhvOFz8KpoZ7Ws8tBjFYfPaKUcvU+cxhzXCNmqouzGpdeTbBfjN//5XvOrci4g9VdMBXjreblG0uvtsw09LnZtBgNMJHXAlmFX8xq/CB/67pqRVjoJtbgKKbU6qDYMHJau4GwiwMNsDFam4giR/cX3TkngqP0FuObyBQlW/1ZXHiH4WCHDHcBAtkUGi2zqPZBtBsSLY5oLtlQF8zoG9Qim/zWClWd7qiTLvEn0cSP5MMMdP+j8HuFsmRl4OQA0qBcJjqm/VQPH4iqFGEV4LEQ9giBr08QIMiQB+GI56GM3pJV1zHaAhgLcApx1R4UNxglVy4+MwozvbRGud3zEaN10tWgRuyat7XrDN1Vf5ngJscAO49IGXoASgDCy6w8n8ctj+CsqN/fin+vaAfPQr0Cq9vc4sgGuYE4GrBLWflW3eAdSgnOrfk4dCPGtq1kwdwVN8bVjX7HsolqtxiPomRIFFwjqjBQRxuPptLNpsLPvrvjPXyZzPGJPvzv0NNrvkngmR9TMkwp6IAT+xwD97UuZsNE0kro3W9zEesCcm6Q1lQN7klr2MTmRdg52YJLXSVtqknqzlYY1iov6f2+LFaNW/rbIb76h0iA39UkspP5FbTZWTP2ZQWK8KGWcbunsUjrsR0Ez9Zzv1ggSs/PfnJwIbwMHECJ5ORr7I5Wa/XJyA9PUlWc65cPTOw8Y8TgQx0dgI70nCNf7we/RLHS5FupPgY9cP6FWWu3mB9PkkFc2MZRrGh1pFSubpYLLZgDV4tYrqCaYQrN2BKxcVk5TJ4v+heeorqQDBMpaGQPZbrpp31mR/7zAiN3bAZFwpajuC6kCexgmcqvR/Vwz8GwW63Hnhl7O1lgaHNM7sH2NsQ2Nvkseztd8Hehily/4UxcI2uJILYCMwktM5v4Gamnp9J1MGxAq/HjfQkxKvV7DeH5VYK2AsZ7D2C50VkT4U4GGirEiA36Ed8MWANauR3zD0AuxVQYGU/QUNV/PRmWQQqVV5L02rpRdPfNaFDmv4LoAwZ/LS7hrruQ3vFm8bL8ou7EAfkDppi1T9LLin33SrYs6O5zRPcSCfknJtjVi4mKr2hCAFvP1tXhaczEwn17j4zQ4C3Lv+a1iMag3cAMxONzAxcwHlwTchI+DsQvxDpWBBGc/h6TELsx/VwSQEyKT6OyXb8IZ6AFulWAobNKIIxmEZBhGLAi//4TVkFoeiR13sMEVba2NpUQA7Iyr32l8jMHOMyBy7S5ZAyhhqHOJhIIAuRf8DfITPheT0q9CEVcD0SKDdF0ocQd/ow4e9i/jL4G/1mCJP3mzhest8oIYlwkhugfmZIx+49rIA487Lmme06eK8StzpjAsrn+/nqYjhBfdEL5IdYlJjgrboYSuailGOKGMcEd3ucb0f5YAryzpRQylyglgErxePR4XXFRcEACftJnX3sn8HgYKSACIlUvH80MqwZMxGbQU6XPygd/RpG//4erCzptb4G7d2Ex8yKONHWjKJDMACRjnhDxN+q+4exLYAhvLxnJwntQL6D/rynoN5qYTdphCNHLf0ooqv4VfRSHdU4JAbdLBnPpamaYGaERP1ZZlVh1NQb8iN81mbaLSH3V8p9Gb/iEn5gdcs9AeX8tb2gS7qY0cU0oDmFjELVsTVBwn1qf08bUsyYFX17JSceFWc+nzOrfaZ484Wq+lEJIJiPfeb3AEQKMPfIrRj8HS+q06909Y1hSenzO9KPbfmiWFZy8LuaA5ypgNa7nZEs4MfMSE30u5s1Ki7kuGKgftka/A5m1mPwGTX5HaWpGWJDqKdLrSMOiYgDAqxkTpRG4T5IviTxg+DAYcojA5jqNa/gWn4r4ihcxkTznamw6Vfq//GeXg3EX5cZE+TNCIS+7FduS5LO6IpeFRQkWV6qbYE19f947S9f07uQSdbFIyzf/tvItfBXoZWDQ+YSDi+Ffzz+7rfiYUjBfDGIvw1v6PQPNwEqx84QvCZW1tcd72RmSoY4EI0I52CblCgv9co8MyIWDsimHz0N+lGtluGLKipeJw/4xy/eggJl/BXIkyish32p0sFY75BEjAX9lQOnaCAVoL5yh5IMzPBeOAm6G8DDuxuShN3YVMfLfMdL0fG/vVPBsg3FwtwCwJhDjnqEwPHTV3VS35JI111SUEZ4XathTYPZG9QZhg3qJhoMPBGYIapWE+YcAS6bJGIuLdbV6vrkBPU9srcat2o1bktjGdwOAByXsXmL3FspmBzWI2LjYf0rucUqUSJXnbnJZUHXue3Q/WiMVb0V5cF6knkcDbmrpHyuymDtru+pyTteEytrYC9shCAnmboRU5dS2F1KA7eiNTeS7fL7GXDTboTzLTuE21vJSxVOCPh+t/CabLmJ0zJcmiVygg1TQNtkRRDeOyg3+PdDFHNRCZN4mcTaoXPF/VtQTkoLFNOtjI+3smsgyNLfKgFLqgDgsk6xmvptceojcHLmr64jQcPcU5InanqmrHQMlWb06+twRhnRitxLsk1TMsQXJHc2gyzreyHpGJyU7R/I+BO5ze79MMHwbik4Ja4QtcrIcVKr4U35TkuZkuCFBASl5FRrdqZ1yobOWvVqtb7cmdptcA6rwwIayoUSeMrVqyS2jSdYEuEkJRnx9YjV954mfa9WQwG3yozG3kQfdqgF6EjNj9o0ZHJIPukVIjgL2Qy/F82JBStMKRbnCuCbu1Gb6H2W+AnrcHA/Zp/f3utwF6HiMdujL+4rs/Vgv879SGxllhji1X7JBIsdv99Gou159zaPlilKy+db2Ld4Cyz2B8Y5QKOF7BQVNzpjKC9iUk5Z8psB4e9x4ebBlHLJRQzjP9rjFKUiVp4/lI5aH8EY7+lPHmKLzUixYYylSVZ0tkdemEddFcxG8GJqOJX1DV3RCvWnN5kDsiCq+BXJvpUM2NeHrKQNeRMhhctmwNnUkDwD3+MI1IcFmc0HK+BuLKMxaG1OSIgjrngN96HiAn+PU9kve+tmpr3415hcxCZLEFY612WSBVAJ/ptelEte8qLvSN3pQhLVSwJ5mvnb5DbNrqLMN1x2vwwPCBsiHE4yc4bczVRKBJjdQ0ACad4Nd+og5W4TtLGcfnvj39FISWZK55J7BTV+ff7+zas3P7uV0pYAA2Z0uaJTnyPvrLIO5nPAnBXzN8aUDlhMMLqJK3f+bbiqfKWrKAgXdWH6HhyCG04OwYnx2gU4ZSLOHISig0BNFFCBmeaX+ggOba7xAzGLdH+c1WrC/IdVq6Ync/gjKRj0a46p9QElyrMvszU3PeLJG3zEHZNJoYIh3Iyd8yaxhxAOgYlkq7tmZWG1pa8a+Ez12LQvw5UWMZmvcR5+ZXLDNNPYhHUoD/eaobBcCR0VC6sT7C9JQMI6BHBle5v7nYn2PHmDKXjAtDizVeJbndM6tSo5L6mZfjdfQCXVCfMr1BcDlW4YPVSt/kk11w9rYKvXB5bRKywjLFp+GSPC7wUJ5ksmO8ZrJERcavFEjSTFv5TTocOSKNjVkVqWWbaPy1ZV7i1dtCsXqQCNEA2OjkIh99UdN5zYhBTotZIlHB3JPYOQclBzZMOXYHj4NeCv5bOU0ieYFOWREmE+hamEhZfGgL8HsxDZiXyO8rByoeSuMd3EKz9yNwoIQ7LNYKCrHM1M5hyzRPadC/oclJOnavVAhmyDj5wX0BrJ11TFFQcT3T89URFYsyGweht44Z0zTXCJD4Hc01vtVSDJPe+CuBSvSSI8NLLAXuVPBepWmnsOuC55DkDgM8yrlz566CuQ98MKvqRYKGthI5ppZOrTgRFyJ5H6s2/5iNWr1Fg58MfsxHDDdCKxmSWweSdEE7hHORfMHjF44PZCOIV+wtyymLNwypilOi9Ffj/eilJcOJwSEIf1K+f+HT0PYkrehAvarzCkoL8fjKliMMZNvgIxdSLWfgX8hs0qfLRwsBb6rxs42gMnV6TcQ41KQvKwhEB/9dzmy1MOgGpSCvCiJn/AEDxHQKU81eMUA3MuSIN7ePBFBNYnW4SScsFCvJsE1aomRCkpKV5A+mXLlpJ+5bW/OXl+TclJT/zvd2YVff8qsXPg0BoxVcrSVQLUS5lU8udyreeeZXd6CH88pPX8M2g9M7cno3D6ByzIq2m4qFBm9RFV3tG6slPY3hWslDQDIOERJcLhesEy3Y+xicywYBiRsyYEHfPFjF2ONTIjOtObClNuEgcXhggHJDINGCwMNDMXfUfr/G5yNmesC5OtSbHaP2KiT0/M+GIx/z9pzny4PzDrTzHJTzGv8poZl/qZJZQQUWt+J0gxAbRkcDGxDgfXW+hI8AriiKjnHrz3qy1XVFMuBGwn+0ncX4YUTtzbBntMJHspuRbyLnl0xHD/EeM8yNxPMZYlVLzm/aL5rE9x0bEPe2TcSi8O7nGMfaVPHmVsCdatdLLDzi0/2TAnym4JoU5TzAEfuTnLny0/4sGZ0JJOC3mCf8rHGtwDpQxpIDFY5we0IXtpgedit8S/Roz7EFGMNilX+9yYaDA2skUxsCEZG+YSlEbGxB0bJWySMcG3ZK2sd8f1en0I91y5P24Hnsk8QGz50Z29PweZYnKSYnHC30ouKkWc7SwFA0dKqYt8LxSSw1DwJBTWDAobsv5XoDAkXg4KGx0Kw0GSg0Ig5zs8ON8gehPGN9yL3jbvRY9Js/6uRa5b0SX1ufSAmxnDYWswGWVArH7wNOwHNWKjpEbUg2eSYroienf02gfFuk90FZqFIJTV6hvmDbf+5uzn5x9eXZ55r968hNimHwkh9pMoxfGKbNWQsTTiyiKI3kbeN/9u7u2HYLnP+WCKhZ/urCGRcH+EGB14g/GERYzBHEru32Ocn6xLV5gfPVkv/DsHUezxoAYhEnAFucaa5J3LyDtTP3iacJhHY48Zf7GgPZlvQP2FkdtEC20u1SMxDKbisqJ+FC52O8NMFn8swvWiwlOQ0ddcoK/+GJj8L3v5BWPoGvlLsKgYf6lp6bW/GJW/IBzUiGEaNVljHixozUZM50OmTcN5crdgqcjAR2G1KjKiRbBc0pj3YHxefF4YtXwWwknNqBi1IGfN+vH569HZZkrZ+8KxzWfKJp3Zm4mzBUZKjFwFg+fwqRPhGwE6JcLDhlD4IjowIQOD0zPZzTJmvtf86R8fVv6UDg6k84qsWe35GLnS2dr0D2IqBTzEU5gDxsIcMycZEk04r2DyflUuwgcr6gMghVL31JJqqUTbN8opZ+69m2GF4VaM2h7kIsHZrvZ6zildjIIF1cPsQJU1YO8G/hkSFrn/ah6CJf4TB53YigSdhM+G8KpLkpNhzVwTo1Kv1yuG3EQIBycJKxGQpDY8MTe8hFZgG8Urd608KsF5oLmWjMGR5P/r//J/NVBtg5dh5CYnYW0tKutHrD87j/1VnLOoj1d1QVeNioHDE+l1H9U4AV6s8g6wzjnyK2cKYWHNRfBjhI/ATd3VFV0hjaz3w3rm6HZnal8Erg/St3kWSpmJahYxexTnP4ldWg42d3RKr8IVZYW1b9I4XIMZrGcV2CdxkApCCmfMk8+rwefF7vNq9xl8AntkbE2wCKl0YvcDktSBpTHVhPtIeC4NuKQJJK76dy0Ab1lyceFxNWIH7FMiClSrm6cWC0HviWInDurvpdlcUWSIb/EIkPCUI+FdsDAjTu30aWGJEkj33s+TWPgmtRYnCti101qDA2NI7P7wKSkA+sjcnAyfWqg/rNXQLVHbREACe+PNyRCsldmfbKYnpjfeTE54NsLHEGKqgIliBKim0FZM6mRYs/Up4FNUMyq7ilG7rUfxqmYAqR6xQZePiI1HHw3rv/boATzcfaG1EwPrAK3dQs+oZvwGRfOAFfh4ZG5qw2dErdg98K1x+NYOwLcm4fsD83sEgCVxG2l0aHH8hDm0xdGKjI0/gsVMBh01sLGiUTj/CtqbitQzaQh36f32CtQ6wfZkyiyWjRVdrmjEQmJmv+ERJwuseh5/Y6GBI/j7fB74EXCuOIDOo6k/91eQCTr8iymUu/OXS1D5nrBN42t0DV7gjm2NpIXs7llwqKdERLKeiP/OpM7RSgmVQ92b1Wpl/uVCMDQhO1aAVQmBQQmiyh2NmWBMsdiV8AryI8iHw4jpjdX/wkTw7BzjbUSSHYj9a8klALxJyP5wEixZCQZ3Espfu50Wolm4dLfSAhtAwuy3ViE7VCNRI1tAEmof+gjUspIw+50folhfEma/SwsAApAw/60X1DGDhLlPvRjDSRLyvxCdDAuWJ0MkkotZH8zpuZYJcAAcAhU53U8jj/ZReIDYR5sAbcH7QUlOhLbhWDEwExJwtVkcpsybQjYCecgy3Asy3FOYcAgHIQ8wTBUUmAjXRbDxmgGbXo6C/SJIeEi5jJPXfIKPwwPT42AL1P2hDDxw5w8YaoMYO/avQUDL0Bu+4Qck8EWEFPaLM1j8KRligUfsV8b88Jgfx7aGwwwG8gqEUllC4y95Jtm7L8l5jCeYTXsvdOxKaclkqu97IWLhBVz4yhDFuK7sbndUKFsPhJ8AcAlUzKMbkZdfcoPPR8wh05SIbsJkPuNhRoDw4cqY/a1McCVcVfwKv7PrRMncVuQA3Arc+ie4Qje5hEqKmGmSLMeWI1TTyybAbGY2qpAOA20maViOPgCfPVAX561QeR5EMVBUhcZRxeRTDBbXc8qnze/lSMYsi4SbfC0Pnv55PKrQn/2NoZ88YGDHq+TCOD4wFZRANshrsGFILQMJFDVQCfx6RTqGqHCqDRJ7kBkk0xsxERGLMEqWy3AV05kaJNsNh4fCsh87ite8sH8dVab+ohIu5sx1AxSEV4NIYUHd4Dvvf69VA4sD2KhJ4Y6yv9OVCCtRmEo4MZWfu914giSqhggnCltFQbopKQgPdnVBLmdyTYlOPxNsyC4MvfDZprww3ZQUhmm/9pd7hxYL7c1oFY8LsuVIyBzCC8YIfgvOCH5e+fP5F3/6B0uG1XdlnfEkqzOeqDrjSVZnPElzpwRTOpR+ggVmDkxBuseclE8kncQivS6bkxnIVWXH7DiYkKyQTInSK+ZA38JhphAuT5noadiPQEqlq4orVNXGqWsw7q9byeqAYgRDsfmKSKzC0xXT3fZXphH71y4IBOvh6ho7luW4Ubwy8BZm4youVbFabgmnJRmLQQTh7MBS6d7m6Z9Z84r3fWwHsIAIL+/r4M5fqg4kR/3o9rcQhhDPePvzlblVp8h4usLJCi9XE2H9cHXfIKDJPSgK/tbV7Rkh4aN/N3+TzOeCEimfjZL37fOzXGGLGLbNo9cY/23wwDhN/s3DJ4s0443++2I0ErGNSgBSUUlqPDkIpVjxxq4urhYDL3gDT7Higd3t1F+Ei2Dqz909zt74bwPULdZ0NfUjWpLPJpPiZLk8WITNK8VT/47ODxXhrVCIS1KSbaQp1nlx11BDMsRy39233BAh74eW+5SH1Duw4vaBFVcrLMOHwqp+0H+/vzhjv9Fu15KFecA/UeJl7uP56PzssRihDVlA7cFRHECZ/ZbGQilW5EyMTE1qX5bKxdQRyqFYCQppd8EBH6srYFGKTrnibAquAFApauWL89Y5cO/HJT0I/jRmQYaypprdp3BBi56SVkt/gnpBp4dLdpgY9Po+/AwW8Q+h5yse/fB+9AQ5s0JRYa9xZDP3i0lWFJQNT4ANNUMCGvngJrQG3yGIV0k0rtW8CcKGBWvO5RVeDUhbktE/ZpYL+VkFtIXjFGwJlNb/lWl4ekesLcNifVWrhs1+ZANbg38+aYparfK6IYteuhFjeVwXRyYshhmQqD698VewUM+Z58hqNYDl2e3arackYF8da7frdeSXbTnoUQMKf2xAGmoVhvRgbylvkuRABd0+3KdC0sf0eWQerXc72dPD1EfDSIF8JMIJYfh1AtFLgyyon2cgcXFToj+Ph7JCWOEiSLhzuGicGIJiJuTERjgkrAkZIgICbUk0DZB8lO1zFFOoy9E0GNsTWST5ianmvVrEpmzMQdhBfYVoD5e226iv0ODh4l2UluRj20IHCLIG3AJB5s/Nj6PH1Wr0nzYhVrV6FK/qew/ZOXL9JVj4q2+l1PQZsQaG9QUeM2UPpoNc42QvTa1NisNp7M/vaS7MVe2y5oppWnMzOg3uDjSYVWEAvaEb/77ivP9Nri+7jfKhSGA4DxTJRlc8XkTvBtblbwrEYwcbX0CnT8Bo3MVGOI2NiZrk2LZAZD01JrnJjO02Nm7oxmAs97cV0ULLG7+ZA3d8UpsM4K910pvAP97kJ2QO3M+f6/KLZdOzCSvKytXQYKcK1Eqz2dfnz3Vz4AaLq90r+O/NS7TjSQt/sXvjv9m9ef4GoWODM2dfV+TJb1or9AlL/nLfmXg1D/0fOxVfQo2SewO8hhx9W8mYwpKsReNIvYNNHkHiVPOCwKmrHuGkCejt/4e9P9tuG8kWRdHH+xH3hcJS0YhUiCLV2QYNsyzaznSV3JRtKcumWDREhiTIFIKJAEzKJNc4L/cf7n3b37I/5XzJHXNGg0BDSnblWnucs0+tlRYYfTNjxozZljBaPrg1IKnOdstrUWdr28kY/oC7nvpNxfMyKI1CkQsHw90jn7aj1Evevf3wKqde4q3SO/GcRiSjTvPOm+CNF0qMI+fC1+CcwmL+JMZxxV9aGxC4tQrj5FCOraQjk6w1NyzitolFLghR/hm58s+YkXGeGjNOXXpLNARllvnm2RuVachHKzN445jAzKtWHYmuu0cBe7hyFK/evFw9ilfRRWkUVapF9xjF9tphbK8dx3Z+IJV7qYs2G00nOxl5bEy/qUMYkk52+TsgW2swh3jhOsqcDmJ/FGt2+9zwk3sXMb2J6WVMz+HtT1/E/iCm0yI+NFjQ+odsu6t+bDqEfvjhNjqFX4A/Pyb9Ra92dpb0t0gu07P7y/2QSFqm/EIklsYWfnE/L1zAo6TYTa4+6RDS0bh3tg7JJuENE0lwM/khRPtR16pEtq76nMZKq0GFB97w/Q8m6R74NteLedGAehmdUamy0KRHMlJI9gpyudUxQYxq9UpVKV4OfPs8SJiepHTVJRniob/Fe60+TeHvbn+7RQfwtdenG7y3b0g9aAmacOGfxsnHrouDJdjGFGrsg7rHFu8dgBLkFu8dQgjuh335ajj24dvEi9kj7WN1OT3Za5PjLd9pOu1jf+vY+FTtPQaX6kf+Idv/xT1s/gKjbPa3alsu77Va/cWiKclpmK0uu31ECL32V4xVLewxIfSoXr9uCJbAHrjXjUv1RbaPCL1e0kxO60ETd6LxKoAB1PDqw1sT4ljxcb6u5R2y+JL9EKi+hhrWNfbkieKCGEbYktBu7DvPjrrPX7z89bdXf/v78es3b9/94/2Hjyenv//z0+fgfDhiF5dX4fXX8U3EJ3/EIkm/TWe335ut3b39g8OHjx5v7fhn0Vns4BTermVFIdn3Y8worHKPx34TIy2rB//U78aWauugHcrH4YYLR8TQHvI1+AzDNpCnh/vyucifNLMO0i3/0LxY/vLIB8v0O7lRZsw2L8JQR72z+Czy+5JGogM/tUdNZ36TdnVkfe432/zJoM23tgj/yz4+YOAV2JXs/dnTp63D+u7BAaFZyqNcwu7BQX1GCJ35sydPDhfZ3FM9d8v1AOjxuqE/+Mv+L4ek8+O9eK1HSKnZNZvFmruYQLzWLpSt17OcfVkU4OckjJJHUkbbXUWlZctcINOyyj/OrKs+w1U76jiKr2SgbqahTm7bVG3bntm2dMuf9QZPn7Ye1Q/3+tT83M39PMx+He7VB+jGzR08efKIbAkr5qDarOlf9kjnZ1v2duV+mfLNXPl969eTJ1Zbh/t94rXk/pnKu7nS+7nSWTWaAtZ5Fpe35SoQb6VlDYuTW3odr945PPev1qEabotaMlnOOlTz9gbDwq8Rc2R3MCgvdJWLD46+lbqaH86fhG0O8jJoKPW7Pd5H5iM18Cmn1QfmzrVSbE5JnqM1APlySlDxQZWA+xSanBZ4VoDWpjmeJvKcZuakD6y2Z/KwDcgyU166C53pdVkl8Xp310a9XrdRkyCMxQ/u1Duoc++tkrs0xTFIlDIz6qNy72Yr9m4Ge1e1be8qtg18QQH7bpBT909JIZgsbBuo2PQGoAib4p9+/we2Y83ke/0/ZfJq4sWJ0IpxG3+JAAgf7zzUOLw360WwSVlCug4WPrCkejEySNDnVAZkCi/cj2r7QspJ5sgMHJGYPbr/fqgBrJLXvo/9F1Uvt1lMv8Z9mslw38b0VUxfx/QNPuWe340hj2N/p3c2aza3z2bNR2ez5tHZrNk9mzVfbJ/NWi/PZg9fbp/NHu2fzR4dbp/NHr88S1++fPkC/33ZX/TO0uePoHL6/Ojly77b2YCUrkyBEmQBj69/5Yst/kUKxXbokRzIo4OzdLe5+wj/fdzfoS8hnZ71zvpn87Nlf4f+Abwwt+NtLDY2Fhu9YPv72XZ/a4Ns7oR00+T1/pXVgffdX+BlF2xf9Oe7y4X8/n62/R/ts52zjvfXun+2dbZJB2eNjf88++XBmXtGoHaf/LK5E2YirQGak5ajga4hCyxaY/Di7bFVt9WUhHRrD/7mCv7+26uPLwYf3j3rvrAqPJbl93bL5T8M3r4vNJ8rvVis6e3l8dvfB6/ePH/Vffbx7XtbBrcv6zxuqb97qo1d83GQbw3sl39jMy3Lw7PTrpDodcT2/iMPBEUu9/d2F8Dq4igv6vDtxw+3Wk1vu5U1KwDk2QsxDCbsg8LuOVkhxud2zpqO9/ih/P5/Od5jnXzueK3WoZqDSksgral+RPBDl/7meK3mrvpxATn76kcMOS354//teHuqUM3x9mSRB84Db18NYMfxHutWzhzvoWr+f/5/HO/xgfr+H4738FCVQah3vEdN6/djBzROMpRxFcQvY34DyzvhYWTjDPHEPzw42DvoKPiDnegqMZUriFeVfHCw+/hwyxXbUPMQKDdCDw73dpsmrd5q7u4RstTGGKexdQnsHhwSelJK+R77zfb3+MnuwWH7e7y1RU7j3ve471du4veYdFpek56sL5MdQbTR1qriSgF6kiZaB/siHLNI6ijrT1vvWKrV+Vx9LBbvY5nBIxW+xefZt11zzC6D4a3P1Uemsww+9n2Of7JE1M+LWOxz82k3pjE4Op7yrZGVNZ4wM1HaZVUlleaZHiVa8ugPmahNEvymHlzE7G80PbATXkkzn6Ze0lgkH4PzVxFYPvjbaoraS4Hwe/2lZaYVsRis1ItGhnPpC9rsCpX2E57avyzQb4tQPWAvGzuFcXlyeFSaDFq529ZErAAZykzQj2JQA1R6jhwcHVuBq2M+tQaLvytmUaihAETWsaCnXrd+yDsAd73coPQ9/Hvsz0HLMiMLroJoNEai5Dk6PQ+/MW1/DBWkdagmEBrKNVa9npuHM0pNQAfQ4/wLdFEb6fYcQqVAWQJJsXK+cC3AuBKgaBoMk/FtjUfMqDI7hh/opnDxKhGXZr1ukc0dyTlEYTQp9hSOxxhpglnK0fwCVWJLIx74RtKbAjcRcNXUTtuVaTCzQbGjNJKzCM7Hqmm1cLo7DcwOoWZRUYJOgb3Dhl8B9I8g+JPwp092sZdpvb4r/xRAwkkjoxR8R29L+vHZr8XN/xhcrtr79u6ajfv47Nc1+5ZMuVllAbqrcn4DlLfTP5RgAQJcrtykJLhUQ6y5ee9/RE8tNwYHKFBFCYGSKKCptAgFD5JMNXoSs28hT8X4tjZiw3EQs1FNpBcX4UwbRaRgFJGN4wGhm2rkg7tGPokZNOQKNuTR6M6howObgT9iQz5iJ+9fGecd7kC7eNH4wvRodQO2RcFYdg9GrwOy1GuAkWpzerrKLphdwoAytwuw6YqpjAbhT0J8o3T9PNIEAKEpWotLZpJhIwyeTNsDeI0B1ePO/G5BcwXIwyc+Gjv6rVZrv9VqFRfROJqUMc3AFxPSIcEwYcDgR5nWsdqDbumIw8KKJGbBTaYoDmrikziM5HE0jQk0X5DeRrf8rmU2i1zr1/IhJ6qXByaPYjUTQ7c4kWEQRTyRbdXUq1C0cecnMf8WjgDWMLSH9mAZipqNOByi+KqF13mYGePPnnTbM1jvqY9Wjhr6OZ2CZzbem/b9sDeFp++070PQIDNHkfBYzxFe55mhsxbXyAkf06N2yZRFRfGV8hx3oNzQZ68ShBM5lEE22uMnR+1jGG2hsd5xv7SPEZMGB1BC1IKY5W0famEkwhGrwYI4hJZcLQzq9RILBCJQyPeU6hH/+qVyudjv920Rm6toiw58JTAZZLIsFHKBi6mVEhNU1yst+9Re9mlpWctgO+0d900sjopskycUPfncMBkAvW1k8AQ/lbGoP1ss5Be16bnuYmH9tCxTwb939otWUw5spM8I7Ckqlg0GCFAD1P0YdNQRkN7xNTsBRkbn0iFNGsOp8TaalEXpDTO/pnGY6G/ppWQKkT17g74/1dG3Q8uFBbfj4wQjcxNnD8xWU2uPSLRoIblsooR0sh9bWx4+hHnHtRMptrS+mXo9+7G1Rbz8+gU1WPIaxokEBKLRJxIWkLXlt3LbZO1EBamdTV18DScf2CSQzoc+TEAKpGgD/TjT8qt1o2+DF/hBW1ZqF1gNA9LGm2JQr0uT2sKApLfN/Bittqs739qyu4drrF7fgwfwgIz4/O4qy+lVOGZuqykJu9ae/Is/iYqFjqyPASEyPCdMrQgpdy4MBS/g+edPGx/4gzaxk7e27jFNxYmVeptyrGm9bjfzJCyTjXCUhjIMKJbBnQZKzXp8MJE8V9Sjggae1xk1g9DcR3f/YGPV6QiBJt8/lAqifKMS9MOtFlmTuQuIKNzy92hzfTcbOT4VBxdpVqDxMGEv+XjEcNOU87UWHtCMJABGC3/aQijUaZZFPZjy8+2W3S5AwRF47sqYRjlHO9K4FcVvQTS84rEWylgykCLE52Us5iUm6+PY5CcQevq7789kGKk7j2Zar1d3W69bZUu5tEiHnlt0Ve0mFQne1xD0WUgfyzkQI3T/AK+4ej23S2sHvNUipE2QHt1o0hwarUZWG/i6L5wDnxMlT5L21HeuklaiCn3ZjnTxJtgbZHRRTvfoRgvCLlLVrgYWsnZYd3ZM9XXr++FiYc/hKQgAcPNIfhvOg5G9zsqyU4FiDf1MO9mEcsvCFS5TwLbRxf0Hi+wB1VDlT6lyOmCJvPRs/RndaBaOwsfg0lzURZUgEC7OFNzv7UkZ1B33aU5sZQ4CWouvZUcE6jkmR+IQetj0V/WXQ6uk44K08h4ImHh7e0iluDOoEPrOxoZzr3pQ1KHcvpenZP4jN5W6qA535Q2VFXii2XIdNy094X7sHi2SHWlk3mlgaq5etOrhBS7uw6gWABviPEjCG1h/RwYslXSAGnIeRQM1IFcRfN50yi9d9TJXDyv1vKux2XAc3OhA8/FX4RDPDc2M1Hy3WzSHSQwDovxww7hSNu+h0CGaZNuvSIp7zv08qroPdbJ+W14aHkm9ft/VgKD/gAMA/HmcG+Yyrdc3Nte2CVO/Y7rAW0iVt90qZkW6llmBHRRZFakxr5h2JM5JvRIfJyQqT6f0wv5W6jkbaMKh8pwNB9PyieVHFpTKM4XSyDCArJ13HmyFWw+cBxBWN4/aniFGrMBuiM8ebfi+BYN34rPKm309TotqspyN18I7IS4HpXgMw+IxDBXtVJDShXD33g3RRldQJ8n3bvmE5ScR8RGTZIMGuyCpjVkgEuT/Wgyg7DZad3Ts7Sre17Y3N62YSo/oJj2lJ/S736Kf4G767BfuGS1Xqdezb9fhExY5VBAqYS1CP7B6hOoXXpnqW12W+Gvqz/yuvy8veEB8IQWqfS0t436SxI9NDXS++y3PTkI69rvf9PJXfL3ufgdLLEKB0v2OvIR2+Z5eLCoBvE3WjawjB9ZFGuHfGpynqWEgQbr+p8ViILns3+v1/Q1cK/fUb8lVk6pg3ONbLXriVwto5Gw70FwVfX5CFovc0cYCik9SEoVkAKNo+c2MlocwUvSk4HlCUpnf5Y5/kn8+yz+MAaCF7M+n/k/Bu9o9qP/jNjJ4GVssKh/gi4VV5c9/BNxBAsM1PdVU9yHQiMf1+gHg1uPFIo+0UqlmNtOlu74NANc2dbWRxwch3VX0u6KADWUMAVa0rvcda1ngaxyTNjm+F2/iAKT0xyQ3l7trlkiWoDa9ChMm4Ehme2Dzg6xIbF/Z7TbywWpCv+gzku0cgF+z4hxCGT4Ey7xixJX0E7I0FbuYfvc/+RK0CUJ1U0H4AP75rs7LJ18jwcz/UciyoMSARY8yFLpJN5rtEmMd4QrOKlwhxpuNGnUNtPPataA25GNUOKndhEIAS2y5/FO6zC+SfFe1taMdZMd9Zbe1m+BWg789SORuyoHUDgHpH3fcf3+R4Y2y0SQeY9CaWvRmkWoPI4B9ZHhqFa7SosHQ8TY269au8VjFNWWj2vmtcryTBOfpGBm36AQC+ZCO7dsSOI/Hfgpgbp2oiqesnP89zi4pvb334foZyDY6GWh5n/3sFc4Qja1e3c9VS7v26X4XPiB0/YQR9d7n7Z4DsTue7pqGhsW8JyyFzLzyc/CvX/lGl9HQLadQCcK1ndBTUrw4X475tCtd66iIyjkf0xW01kaTflJ44XN2jzJWdYcCHKFimHtyJ2E98x/v0WM4BuC01SCa1i5cIieWZrHf2j2AgjI05vJH7tgpoSd343jY6hN5y64EKU7o3XPyfX9GStQ18pBh/z5l+/dZ799xx+ygV8WxmQKi+95BrbuTIo1uLg4MjgYIAShzFiUyhOcFT6NR7QF94BSRjEQaQHXfBLVzlkwZU8/SoYENBOgQI5OdSlqo61/DdiFGPPlRriASitf35wpyIt9KiG7SHLoZ2KRCEdu0NKdvUwHtkUEz6zqjd20u3bjWXEpJ4CKRc7JYSFbn3WC2tveVszi1kORxpwJnTCljdJMe0VP1aPK6HeXnuKK0omlzFYinyh+tHyPC4N2HoON+v9+CEO+7v9Fa/gzTqgCqDkanPCWdz/5G03Nn9XoFtx9twioRnt+iCNiIjI58Tjf9Jj1VFH9rF6c9vROfpVBBYzFk9FnGEykYVADmMsxZZaJmDrrjIB6aIvd8f29ln0UiE5n0UwJIt9vp+lhz2tnzdgtHXkpE5JU1vAI9weiydoNEBNxQ4UVoVDekfxd34KOLl5kPYe1mqKE7Aw3d7RaY1WtSvIks1fI9aYgX+8KchqPkSo5B0mdyGdq1MNEsLXBkyISoJVfo2hAsmI87K6cSVTRvz8dzj3y+NdjGzW2SJVq6594BU4Kc3Ol9Obnl6shNkrvwIw1pEeVUERtTqVGroWBeKbEs6GLeBZVtd+M4T9s8OSL1OsowpyUZ5vRez6GN47y05ukRWsHaSYSauZHNrS0DVIWBzPcQaFcK7q47ra1Nb1MahHXr9euclA+KkLYkq2Ct0k5pazruqbxw7uiAeKdYtLW66CbcYR4A+2anMI6aQ7zV9Yh3Z/cUr0WkcDbRcsZi/5WgpE3usU0l5bHQ9qu+0cJIVMoOBTFngUr8gD49/5HyBIKJViBOhPnHP8FBXYP/8oRB6g+KbNC7+0LkufcYvRUBwJXWIc2tQ5PQNZPY2qqaRhMFApZ+hjVme8TKsYNQ/NpO1VgGOIQKQfeKa7iFVFF+ZYiX4+NadFK9vkozoHOfO1crpGa3rvL0+geChULdgGJXrgFp/8zlXt1NJZw+5+n5WjjVLxqEV+BMut2qrf4T4DX00xK8du+G1zkODPCb6rd2x9ltkny/0lMcWpV0q2G+VF8BZfceQL8GEM1DtwtWHfX6adzr9kmG7E7gd26spoYLbzqU7177XdLZ9VotMJC57ux7jw7w45HXJE+byvebP6NTiHT/tNkebG8Td+bbJkX3mQb4o5r67vTJk32yNfNWvKIsR1A5ddZsTmVjm2l+O+SpL0K9dPLO0ILFyPpBhzEHMzmM0a3CGADSP4Mx8v38N2KMEZ7POzBGbmw/9Ryo7sZ+E2zkBYPjMBA5bQc4Q/tAs7vpj+GH9CfEeWlRnJdWi/NS0ibpf4E4D2b/I9K8cK0YL5MCGw4MLQnsH6SRIctHagSZ4NYgVZuLE/bXM/iQdCGd3Ma+GwdhlF0EK6RCyBoAtG69rtvqZWD25OguOFgsyjt2BNrzgMGOFou9R/Lv/q76vSf/ygfl0WJxqDMeq78qfe+hyle/H4M14FFOscY9VI3h6+8IPT/mAOpOoQ0Mv14vzyD9kQtw4E9tWghVhQDAj+S9hoKTI/z880YnCX59k+wdVHextgOQYFrtyJfJj2LEFSM8skRUCpUfEWOhAe3Sa/WBHdBj9Uu+ilbjcQD53LMLnG+iUstdkNqGAx5GKVtas9QiYL+b0zy+zvXhH6v31XJWr5evIzhUG63q60jFhCJVMEJo4aV2JOPh56WKR/fVqK2tHNjGhobWxUID8qbFKMeXEPLdUVQNxwhuC20DoPTWFPfd6TiEeFUFzC+JvEqCvwzfqigfwOe4CsAeK7rVuiHIaSX0vpxtPQn1KP2OQ+/W69XSc0JyQyY/3Ish3ZyOY1qZ51jxsmQhCIcMC1O8CizDO1wWKIXGXBtPOk9BtwcZQllIFIN8cOUwqKQKkvMAHs9NKeyx7We13cX1k+P2NdhdANLcLJbqXfeJDsCUqYSSuf70N7N4S1m+4uZvwr8/vl/6RJkF3XA2zIKGF252nSoDXxVlYbFwdHQFpy+HQMimv7aYCsMgNw82a1My8uWSuUdZ7Vzwh1wbpGopsV1jnnvUu+5jgiwJYhCdROab+K1mvblYVNPGoM+18QSdwQaXW85TxzoJGrA2cXCrwMpZC1a65QeV4PVga1MDVCWIbZaARG1Ax10LKqrUzxzqSjs1NQoU7o4Y0r+5VcuYrlKZs+A6ZIV61HDMBUP9KFtf17gXzNDa52Xh8S1vxbLacJEmkHSENqFVWlZFE1ptTk+18mClko61cFX58t19p5Yy7MA62nJwpzAox8t82gSqDVV7ieLczu6nk1x+HFSq3N7dkHqM9PrUXUuu6+P8pFVSXjRWwvhWsFWDcgx5W3clqsnWnLttiO5nB6SMgH5Gt9oy+VHYJiPBcmZAf9qSp9qWYPVqL1X7JUa+RvO/x6A1+3vcC/vygU+8ggnQA40jzfM62ynzhFquBeemIWpfqbit+G64y6bt7kJbrfsV2yU5mzp/b+3TjnizlcApkA8AOuQFS7q8BNWe7XaL7mtx6tplKuGkev1IO7jO7fGU5pFJYcccUL159qH76pVl+CcNZMMoYfEkZipQlxLXo0qO3l5RslD5cW7N/uE9tveHdqTCYmG7hELKLBqMEGYAN9Nly+1ddq1AzDY9IWXwpcN+ImIXvgnDSIzDA2kGKPLz1EMkyjpwdbYrlCyJHh7sPnzsF8o2sYgvMgfi0qVqiC5ubM8zSlVSxWM4azoSKaPKKJhwhdk+prZaZkgx3k/t/DZhOoBcoDS5UB9zkiYOhnHDTxht05F2iGF5i0MLQZHQFvD5LRrail9tiaqzjQ3NsrRJ7oIPswhcGZiir5K/x/4cNu3ZeOzldvHZeLzZ0jwYRUlAQJKiyTav17/xcFRrKp+Poc8pl/pdKkhcESZCafute7NiR5sAESZOtHR9kJZcH3A3hSAeEIQpGOUHrt0IyT0uAyR03rQcbiDR3vKtBL1UvWa/nY+mlyl6aiGHORphZB0bW33nhscMlBGX9Lc17hDp3+726vZr7COE00+xP296ztlZ06EP4W/g0Efw99yhj+Fv4tAWFogc2mrBxzeHtnbh48KhrT34iB26i5WZQ/f2vQdnZ84D+hjLnJ1BISz1xqGtQ2xq4NBHu3uYf4yfmP/OWdJ/QmjgW4c6nxzq3DIBX/LfFx8c6vDIoc5b/OeNQx34wL8cPvCft1DqAmIVv5X/vnzp9OnnXHgEGXfBU58EflgRGzZ3MscbLAJ7md/YrMQg5v66WBVUPPHBzWzoOzOHpv5upgmo3HBBVgpZ+5aahXji7+8+3n98+HD38UEx/CKMpIaCBks8hv3bqquXSADHkkJrzl6q/6GAwTmBDh+ppwB4HdsKtyw5dNOh6TY30eUtu3pEbbAGtpMsUXKSpcK/yxDnwcxtUZ22WOyq8MgRR98IiqlU+G1F+v0aTl5F6FPEz/3KioDizzH7xsa+9JfPYVEuXSuDQCgI67cVRLgqFuMHlbFGbmi8m8s1nC+VA+j5UvnpdVM/H5SalBDOzAeEQ7v6/uK9GQTWkUZQM/OS3iWoZFtpDTUzsW4IdYGHWHD2lQVfnIHjjL8pAvM6F6GYdpUiXi4VBHc07M36ftcKtWjtO1UraCIcyzweJ39nt8LPPm1vZxH7HfRxfOt7sXjU1CDxnl0IX39k9SIOdmpB8pqPmJ//mRUCZz0swogbUz//MysE4qAwuoTl8R84D/Bit9JA7KiAisdDKUQWfu5X1pbysR372b1jrrAst5N9ej/v2I3N7ir/YmaXNzZNaqSaO48/je8OcMkmk1LBRs+zZKianXp5dDWVRXnmSSKkaTFWPVEHYBvMhB1HaoVryO+2CdqruNMcWRQ5oHLYwVeqBOgBNNMlnp1Ep1v4DMc/mW8rqC7t4dzZlp8SOtvyTciYWdnj3Bs2U6H5YSYaB0bOVn4iGmP9wnO+OX839hqWT0XtO/Nx0WXmO+29KFdYu7ds7R4uFq3DVhbqbvfxQb0OV6IM8AA34ga2fPBwb39fl4OLY69eFxu+/2sMQdUO9g5Nm9I7kz2INwIcOb6NK4eeH6NuVBPI9bqko3NzQnlacJF3Oeav6AdczuNTOpeqtscNO6mX1uv7+7Kzxy31V3e+az7ANwWMb+9ABxpCSRKv1zcGKHar6J2TYs+cKJ8isKSq/sC2OByxd3CzPktyuD9P/fMsBPFT3LPDej194h8c7rUe1+t8q2VeQ0i75uuCIOupj84zVVS8vf29Tqu5u/+Lm25ja2Qr3MYCW7iznuXWI2JsJG/IV9pUONvLnX+dRb/UdnT0JWtaV5wLJkWgeLcVfUbJidpxPugmaL6h+tuJL18rFMz86Ce/ChRehrFI7gFVRTgARoHcz0O106hBLe4PEhlE7Oma6vDsmYKqqUPV1KEu8Fj9Vfl7D1W+7voQQd/NQ0UTHvdVa3Ac2EtQnunBo6rmrGendHqzWMzQwPPab7avDSS1j5/isT/sXG/5u9711pYONpgt9LGfb/raiG1rB+1P/qd63RqtCzLvLkjUjjP7/nv1KZ0oVXW2iTre9bp76p8uFtfb37dbT9N63amhNKP3favVp9/960xwlB/+PUeLjZ/U65Xta/b25mJx2gmfPq7Xq49M58CbdVDjtXPg7XqnnX1vz9v4tFjMFosB5Gd5rYL7GVuVQFokw416k5GRLi4TrBIvPP92S/TGA8d54DkPHjioRZsnbbSHl3/GWfwyslh8VuwnTsi6dh9sceACes4DZ4tvOQ9kxBR970bJLxZ1HoIrBGVIaugyoJlNGfkRRq5VgO43CbV+b08J7frpYmFR2k+BIRQ+9a2ktoqgVcZKnHbNtUtn1M15M3p2cx5epiG4DjNoJldAE0zvdRj+HArXAVokQ6ZKLBg+SduhkWXlZYJh30h7uKX1qT5aS+xqSWhuE2iObqzXN2RUhWEgWK2lIn7VOIYCq+2q37hbWfSWBxC35cEDh+AGYsk9XXLhbKG+/G8sGLHY5WbpyNYo5pMX0SiMLt+wKeyPmyPhwMsgka3t69ae/nRrZhMu+HhkE4mZ96Qd9yyCmFz/Oov6v5AdcKSkVPQyChBgB8G92+l6WTDdxjgQcHLZzO9S6EGSbuZ11CWozTZDdh3GGmz2Fwunpr8R6qXbx3bqD3R8qra68nz0EXvsg1tYeBljvWPwdzrd8q+33NliES4WDqZ2HMfDkW6ZcRwDvTvzQ+MHY+lyOiPZ+h6o9YXzaBZKKv0ZrqWhpjkNgWKW7tma7UGGiVONiQeAiSH4bFrAvwOQP3H/U9xL++idKUOsKVDWWxiiluqG6nWVtNXqgzObLeCoZlyO1A6vzwkgkrYKGOfluREhcNhFeD5Woby8Wijf50rlroZvRGBWuRZFYoOaxVlbgas12BIPV8fstBVV0gx3ywW2arnILkCFSiUdZ8vxUtzPbThbZ5HlPL0M7sJ+JhR77gjbJbVn+7g30EpVTFwdF9xApzEMbFsvKjgwtd6/an08KE3lTLRJr8EeKLRh2O3qaL9ke/pURuydPZ12Zl6XXksO9pYw8gkIquGn8HiaZW96VSyjQ7AdaOR6y8/qzshWrrXZVot4dglCr7PArPkLs+RYreSVFaD+WoqY25l32rCCYwL+Hmn2olbmx/qn9oGZee6EaFQujkKb0W21KHi8wv9H+c9iYZjNs3q9WBaJYbs48EqA6IAt7IKl35Zf+bJE8U16M4EnFF6s8CMvROh0tyCiqYd/ag7tbqlipC1f8NeqDejI6fVtIGUJG+LltEKnUUViAehxB+hSJ8dC8Ao3nOFOTZ/M2lOtHON2/UFv2ieNLAzdYtFtmABZsBYb3VxuFTOf13QJflGzi6v6pr1c40jgoBJfpytVUSB8l4mU9Sa4YcpRUCEVEIXOAKaZUdWiVkFsGWBOcwF7WLgPQ7BDY2YReV6qtUf3sb8pWLNbJKk9EpfTa8t07m/lCkAz55Ep6G10LZ0UrdwRGyfdShHlGrxb48gfgDMqq9HedR97XirISTOjHkOt5I9n3r8OwA+ZyyVTShnYTkhzIBeCmtxiUUxrSgFNl16bFVIQjR4FUsRQFi34pJmnFrmsj4bmfpXb3uvFwiRnQdeQJXtar7ubkoRALaaMy2XojBCU3goe6JyOk6m3bC4W+DhUtE+9zp82pYdgUB3crNeLnLLeUZ/IGfrOLzG7GDhbR2bTT+v1zXp9o6IOClpLqWCNSCtnTVJp6WWzlNXCGldxGxVYN+cNp4B0lTccxzFW0acFd9X4JpHIS/NyySl+upbyXxXzs1jcSjEVrbT8KdDJUt0E3HDUzjkfsyCSomPTIbEui9PSZXGMtJTC1EeLhXu8ElN3/bA38097g37hculWXC7ALyE0d3TsOwXhxb3210CZvhjkXzXwp8ADkhL4u64NCFLacTz8U3MIPdb3BgUrxNXTLI65CwO+vn+fnuzTqzn0aMs3vRJ9XW1qZHG0WDjzpYPPIpUGBOqm6cl36uqwbOk2vCIAA+N+vTcn2OBjY9FfgF2p+wSU9JGhop8MIMRmiyDB4WBIPNjkLd+hNdS3sIUGMgd1/YDumPpHvbR/D+JjikRHHrVOkXhoZUttb7oaAm6lIQG23PxoMGSQA8Sq55TygJCtOcVOZ1mn11t+t7Rdx3q7nLmzdb3l5DdsxXY5NcdsWYYDKlCyRlm5+XbcgqCvXt+YIZbtVJOK6P5RwY9XXeTfArBcQ3kI08ILTZRKYApLwDT1w15agIvpaqI0xdchdasBxCJGpwViVJGiWAz2FOF3JqUeDl0JFCgPyZOVXbPxPWdrtuX0f2rj5/bGy8nJKJMkl3MSSc/vIwUWtslKTqCb5eSvg5wOL6rvSpMl1X7CazComrN1TZYWmlWrZ7h1CkpgqY/JciV2RqQvH8In71+50k0l5vSa/Y6t49xSVCbJ2DUbwK75y24LmRmFquDisutVSXHhIWHekK1HpAMi4C2d1HpEPEkYggq0eRDY+5GReJawKzGkxXt2wWIAclHky/T60s8O8sYiMWHDREHbgE4JleyyaYlBxm3aSob47E17Yb9P2rwkSTQxv1JbjpbrrBC0yHLeWKEcJIh2uecOfJ5FVyZEihZDKxRpvR6q8WW4issUQWg+RoMgVmAUUaImciOGRyg3MRiyagWhvyDkjnZSJDmgKdSc+kecSVdgZ/N6R0a3zFX6b6QdGlH5yg0Ptc6UyPS1LEyV+mEBU80dx0uXFIin3D0W0iZNFYnTCeUlhRHxHMcKURNj0K6RLVytWYzxUjB4/aqq4aFwtsSWUwtFLWY3/JvUdbsW25BX22/UTgTT5TiUi0TCghEFJ3rDK6kmN62J4IKBtzP1imsA7wlWgEV+ENMk8scxjSN/FNMo8gcxFZH/IqY88t/HNIj8v8cNUPCiY/P5bDymw8ifw4y9f8RLiUjSyI9jOon8uQw07r2N6cWYB4l3HtObYOJNYsTY3kVMMeys9zqmgiXem5gmOkq7N4sp0LbeTUzDKPEuY4rRPryvMYWgwt4rqPOHl8ZUJLE3jJf0KvL1Gjsw1WMejBzqwEAdQkcVuc/GY1UAvgi9KJR5nt5MHHBUezNx0DBMJLWbyJ/Ds85jEf2AigZeEtGXz14df3j28sXgQ/e3F6+feXFEIc6P/hlFtPv2vckVEX3+4uWzk+OPOoVHUrkuiKhWEBxHFNd1GFGIgPViBqgeQrqlEU2QUzGJqJ6Jd5V9Q+VRRPUEvItoSS8j35ExRcQgnYyChDn01kpL+OXlmDkZtMpCNrDOoVPvMqKT4BbHOu+JvseXVpQh2Yor8lVusypiuZSrGGAFMYBUNvJd4j+F/+ZLOuLTCFK7ODJf+E+5/3SOteYXkTe/YMnwyguXS9+ExQwxePglS2Sdo9uTeOzj0P2noakM2OWZ7NZLqZq4ThgsffRzrKPW1gaN/EBcQRrJFYvciM0SCv9YERXhpzsg84HNzsFjvFgMGiIJklQ89febzY6bNuTKwkaF0eUHzHOdiwA0VWRXDqE/VuokHrsOKnVHgo9ZA9nNru74I5sl6k5M4zGhXNp+EI+7rlqkOfi+VhO/ifBou0J7vc5k18CwgsbVojUiNv0IWCt6EcduhrkEIRSD7rqDRgKrFRKyxP3RmyIalyx5FRViAXHS4R5E/6XfIn/eu4z6nimPZ991m/QPhgE4//aBwH0lwYoQ2rvNSsv9roW+KYCirUtmq+aKhoDfdCMluThiEizejdPLELCygmNYSSYThaeC8whvHrNROmSx8L5FNDCgJdiYDRMeC2+6XGqAFyz5LRBXAM+icxWKhMe3eN/KewspWPzny39szsXyC/GmYTTi08aYSxfZoJ96BXxuwNjnkT8YTNn5JBh+HcTsjzSM2WDgPjrcbR0QOqjMbUTueaSx2IvId8bBLU+TgRjGfDweJNyh0yx1OGZBrPIcVelD5OMRvGTJB8x4F8S5YP2FDIk99G5o5V2jE/1izOBPe8ySWurj8b2ZpOCbRGpi6LEOfCc4F3ycJjKKlBVWwOedHTdIE76QI11chaMRi8iOl0smO1IJOpyxUb4Jo36MlB6MhPuizRF0YmuMBAORl8bICXU3BouFAwASDp2NXNv1+lS5pm/wbywGZt5W9vnJ+v6nEVhnSG25pHmwkzvjzTWkzeXkPnI1SM9CeXCgkRYKGxdRo7QvACMuUbZgMgtEm6SR8NzBz2MUAcdYd+oJ/6krEfyLDMEXKUeITi8gjjbC0wdd1yWm8jRaEgr687cfucz3iqgbdKgacvYf9NnK5vSR/53duqS9yVzSCIWb0jyaEPgm1A1o3FVYO9jJYpHckF1ClhRjZT5nbHIcRl/hOMtFyFXzOC0M1Quzu0l46RJQFICTSxoj1ZaMe0rm8ihk1hNt+VRKe80+UqSpySHU2VmVZc5N2hCTcZi4zo5DGjfBxHUB/YDUFuhXeBCID1d8Gv2d3YKflpN4DJPSAfFozwigHKfv4/PDAe14xEcCdXH18RbrGwOV2XY3k2oPHIL6D64GsGkQR66jjII8KQcGvW1YIDAL+mq7g0ZLzi+DL7VpCIYgrGZHCw2j2rf9RpPWUsFqX/6y2/yiqeGGQyhviCs+dUW2GtkbdQBvVOALEJRmmbLwa+mWRr9YXBeTyP+6GU1/YEbgJRV/aNieosVEdm/N84fLE+rKdh2RpTqErtnxDPX3Qpr2M2It7fRsIALme98Lc4nbYI9Lw77X6y9parUKvbwyfco+xgx7oIOsjzyQhp0e5HrFDmQOdrKk5iaf917YdIcoTpoimpG4JU+BTKO+XCgZgK+wVks6jYPJM4O6r/gUeplbmKECcQCucBuNRqpwhpA/KM/jDgLoHhZCUN7307bwq/GwQQxhY92ySvHGIK+iJev2pnTW99Gh3YaxIFK0DaKZNgZ800xNnSMvGo053wXJlftlZ3NuuElZsJUpWVZnzMjyC5HODQdGifSn2v9C1t5w8D8ksmbVRNbubvMxoV9X0VgzQ2MZeBvA1k9YrMlfDLBZe6uzf5e5NTZLWDQStXesYcY75xG8AYBsVM8Y06oXLqW6O7jEEHQO/LOUmuxXI3zUNBKu4rkSILTm2ZH1poXsKXj2zh9OODiUF+7F3GUN1p0EAoZHoLFingrvmCIu9P3qiEkQOXQeswtPhYuHiS1pqaSg2azw4ZCtYxJc3rGWH4PLH1tOWLX8QqZ+FS5qr1+F9L96FdqWggObDMby4Juueh8iuuqVkkM88sGtERygkxBwi5BfbU1vVYRBqnqPkNKylEgk4G7C7QKjMI0Jz4Lj0jmh9nZ6ldu/XPaRddWtPqP7zUeHTULfrjqk3ch6vSdxEAmI3WQ9dTMCYS4fBY4yNZXB11CsTxz09YX34g0TIrhkDrEUUtGpEVAFqolSUUmwhVutx0QTatQpvFBNcbqiepOGJFOguwm+sjds+loWyk1IXnHyzY8ib6CwfWPuCeRYaoRvHbHl8LjmbHEv7YVbrb6VudsRwGSkNUflyd81xxNbnFAncxUSgFs5CNSvB7FUQfufVW/bwaPWwSGh16u27Zm9bWAcfcMSFg94xPjFwNpGOr8WHyZs6PFltgDqIf4q8oGu7VfsP7Iv0NOd3DDdyny5pKH/NnKJ+yqiLrKO80yTsGH3zknjIhwnLEYA2tgQxIogpmrk7x3no64OiAuSPDQYEktQnbXsiYvtGjB1NxR8yKAV9br6OQmSKxDgQrBQmNW7yJ9DEa9Jx6BY4jnYnUMVXHnOiXbAiOlLhRJeR/5Jgj0JQt2MIMRSwlFvruNQJMQlQHB+xAqvI1V4HIgEMrJlZ3GcrUcBd7E4trgr8x5LkCab62cmx0cUzCfUcoVAiPAyct9FlFP5snSQpR45S+tIKbZmNmxYOrFY5IcvBSH5ZyRoyJDK+mX4gbdiL6kaMwgosj3LdVCahyjNg6Du3D3nMOTRMEgKs+A/NAtCe/GalS82bcDUDyXuguE71AHOq0Pov7n6qBRzdOtawCdh/Uc3JvrTNgYn9r9kW8S9tyU/+vkS9F1WbVSQSmTx33lMeOVM8H2xWGzksQzJtILlVAvZNnoUIGn8wP5wSYN9Y/Gt69r8JM0OpgOf90KtJA2svBRc0OCeZlhDcp/nsht4lS1pL1w37nX+Fu4xdu6KO/pfGn6zsN7t10tbGsMn4qVs0xY5CtORusdkiG2LbrHDP6uyBk0D+9e0u5TiwzfVl/nDw8NDQt+vusvfmHdSEMd8OkgnvjtHah40VT2hAgqC+YzH/d0mvWLh5VXihfANr2BY7woC+9ulQ9/DVT2f3Ywj4TlXSTLxdnam02ljugfKBTu7zWZzBwt+C9n0iM88p1lr1nbh/x1qjUF3b/qmThCHwbbkMjuek8Qpc+gFH6YC4/GDJzjBnCVolJfHhncxnY8853Wt9bCx33pUa+03Hjcf1brw+/BxrXXQaD08rLUeNVq7D61fe48fWkUfNQ4P8ffhvvyB7ew2H5qie43He49rx7VWs7H/6HHtsNFqPoaazcZu63HtoPFov1V73Hj4aNd8H7RUqeNaq3HY3NVtdGutxt7erulA/zjc1+XMsBqPHu7pMe829lqt7MfBo5YuCIOqPWw8PHxYOy6swmfApVSCA0jf/ncCCLUSuAfDxu7D3e3G7uGjxsPmY/nx+OHjWlM0dh+2Gg+brVqz1nh8+Hi8/RAX7mHj0d5wu7H7EIpuNx421QdWUoW2TaFtmQkf2NQ2NAUtV3V53GoCKOwejHGA2w8brf3W92yj/rfaI1iHWqt5LE8KgPXQWky99riiDx/rDFhk/Pv4EHYDtqGGOzLEVYYdVcuOu/oQd9WU2daFcLOwH2xH93v4uNTxsR6n3KaheXQP0Hfh/1Y7tt/Y20f88mj/8XC7sX/4GP7bbjV2d/XX4eOHCsxbjUetx+Pt3cbhQau212jurq2CWdY/WKDWlNnj3cbDg0fbe43WwTZ8PsbP3WFVpUe6kkmuYbL+NAN81Gg92hvj8Lb3Gs291nBdjZoeuskHAJOjwzE9quGYatn3cGWVRxqWJrfr4Kd1kMFP6/C/Bn5aB7XW4X8l/Fw6dG6IVagcRGIM5OwurW23iLNcDXMX4XjsOf9xgf9zKPx8n0KH7BuL+GjkUIBKuFyv9r+1ftv9tt36fnOwffjb7rfW1cHpw+83u7W900fj7b0a/t+37d2rg2/bu789/v56v3FQe4wFdxsHp4+/QzO78P1tG1pqfb95XGtdtb4BkDV3GwANrVbjYHe7sdd4uN1oPW60AEBkzsPG3m+t4Xbj4ACAb7uxf7Dd2m6d7g+bkIY/a63t1tXeEGATTsbj7d3a7vZuDX7BKa41Hj2u7dZ2r/aG2EqtVWvsH9Ratda3g6vt1unhb61vj69azW/buzDUg6tHsm3d13brt0elAYgsd7tV08PAduHrtz1TQ2ciXBIKqs//WyG2g8aj2qPfWvunB43DbmsfDnFzr9babRwe4ibAfjxs7O3t11q1Q5V7WDtoHJ4++m0fV3FPLuPh/iGsY6P1+PFp6+GwWWsc7D9u7O8+grS9x43HB5C713w4hjIPG3uPHnYPGocPd4HYfHQIZOPBQa31uPaw0aq1Hl8dNB4NoQlEHPsIhXuASx4ftLZNM4fb0M6wcbC7v91oHT5sPD7Y2248PJAf0N3h6WMYUrf1sPYIxlhrHTb2DnZrj2py6t9ft3Zrj357dHqAxQB/PTzYrz1qPHz8sLYH898bthq7zT0Jq5AHmA5mqXBZGv0/QHNvoDm82v223XjUbP3YSv/fA9QQXEJ4rEpFxfnQkmM8Ayr4ZPJqyCNPv2QpJj7n08hKhheNzMgSaRcoMvxdJNNol09uVc7klh7z4Vf8BUBLT6Kx/i3BGPjEzzPtLcl/GchfDj0u5ch3vUOPSjkQ5NChL7N0EFWXFEOPMa+o6fk8p+lZqKJ5Efkqx9VVtH6o0sDgYABoOBh+xOObYBx+Z1q8rRhyL7PG5ujqDrgjIO7Mq6kOr4Lokr1WKva+49yj6aOKpmGpsGXJx/gDtBafl7QH9CZkygK0d1wqpTfELvVynU4jqnJENPVXKEU2cJQVrG+s5xSUpOZLzdWH0aSgsglMriNrBJJrVWifplYKrEdOevUqcnsOpAqnrxmfIaFuiipIWw4ooNNhGsfo6DBj68oVIzpLQo5VQC2WUUDxlakEsnELOwhSFFlr1cQJZhcZsrAEdHqlXOvJDgBU1vVhzRduC97HRjaLopMNNWx0AjwKEx6jik4SXF6ykZF9Ci3idkOqVUBgDwa+0CltoxY9LemOzGyls+7S56gxdysSduNCGIiuS+j8Jpg9D8VkHNyy0cfgUnjHS/8a+ZNH/qyRW37XgNJRvb6BcWvQ/HfayPiMA3pECD1+6jcxa2BElMeE0IElw55IQY9CUKv0bLXCoxEE/ZGp2R5bbM+jJRUopwPhcLYCmzlu6JhfCtcIxcVSa65xfz5i5+ml16RhdMG9Fh3zS2+Xgv6Wt0elMG4f1ZelK03gz/ZEf7HYbqFGxZhfYgaoEQiKSqL4G00trd5dgTBB5qaAIE/BBZwSBfZE38UCWlo65peoRebDx3kYjaSWsANpDpC8l1J6WMzHRFUAplTMhzSVjTMv5mOiQ+g85jx5FYH4ADaDXy6XKEI8jTBKgZ4ajwY6Bv+qjcx2x+ghSGyE4lUh9RAQ1KHtJhXyN6Gy1N8EeNhmw5LWgubm80YG6y5p8KirBqRB9jSq1yvMscHqXrDkY3jDAAxTCiGkI7RoVwoRUh1I9sNmSRwMwQA70x8BzYDBL7/0+k6G+DJO+pNmR3hCyfY56TX7jSQOb1yypMrTzRUbI0Q526Oa8oGy86/e4Gxnu79zqf3VdQT6qhJF70dnZ9oBkmqu+/q53Rg64jZ1zv4Flf71L8cyyzs7c3Yu6QN0QvzASsdUJ5d0FmF18PJDOlaz26MaZGyPapjnVQ4fnPwI8JWjBvqOT1ks9OTRKlIvQCYmCS+wV92Mhq0vf3XOos25NYQvMIAvX3IT28S0TYcszyLnr18gusGGGVqpyQeb89LqPnDI8sGXTHVBSSuGaTwOL259rUYh7wSFmTE8v6P15YPR6Hcej4QPsCSI/3SK8W+3pHIJJ41rHkYu2ifror+HyRVPk2Mm7T5AOdSuXV3zDZuCLTsSp9Mt/0ttcx4uv0CO8iHsCr+lu6852penQI2w2kxLoq7Q65BwCLg5cWCezlaqptLVhSD57USqh6GGi5IHNkIBf1zwmAuOT8SLm0ly65J6XU8Np1HRSDZ519n+p6XnklzxkUPsGbokm5S7dtHcL5tz1RDMA5T+6KxenzVE+J0Ro+tfQ58M+dk3VCR/l5D5qq7bUtkyBJ3TdaNwtn9zKAxl6clNAYHjAM64imWwDZhocyfUEFmv7/wLPbpMgjg52wEO1PYoSAJTJCQKG13rHTnno1u5FddgpTCo13vOu7cfPjrUeXeC/z772P3N6TfCaDhOR0y4xRXW64Hqo/yido1LIIWnRNF9GfIDuPmpLXG2X4K+sm0jFSSNl+GYQRgsNJPOUDNvfAvGKXt74ZKOgY8vsIb+5pw3YE2W8AHFO1/a8MfXv5dfwPLyC/Gq+iq29leoBTZ/dzdXHsfyCzGhqa4rOiM/B7vbuOfb0nyy9uCvm/NrOcQHX5SF+U9uwKjmyEPP/ev2H6wBjrBD8RpxSmdd1ZyJD27VER/dvr2AqkZfStlLK43sfna2JIiWoErYUBWSdlq1Xcoq+Uut5sA5crza/Cyq1Wo1DNIJLjc256lcHfySO0hVGfily+BeOriZkIsAUmp8cw52mw0JjuHFrZtKs6xd63Jxz+KzaHEWL84icNnnQGuIYtRl8WUOFxRXSJrCtbg8i5ZfIAIn8VwN6xuWIyiX+4V+OVm/maBWh6AHjn/wvKOhfu5kLxbuTwMK3H+G5J+ChvwfKRPJhyicTFjyq/SjwuMB4PLBJHefqyvSFaXbnjpfwKWaA47aHLK+zXNlLlds7YNs6AziLdzRxPBmVNVC9/VzRcno6gMh64uBeVUMkIT1pcGMfBy+DibEJfQE33N31aSuTSSal210mQaXTKg4sZh4qQdt1OhkR3r1QaGEZzdqJ/TCog4Hz5A7vtwJgfdKcVGEj+ZKF5FUVjmJXKEUCFUzhqB28ZHzZdXiooVin2TmlBX6LmkHbbw1eyMCo3l4a9sqOoTQ7/dfTLVcAUY2OlYriS/n33+4EWUC/2I2CaIRw0sQdf7/vkKf+PDx/i6hv61SZvl7ROjfVui0Pt57/Firuvwa+fNhGgvQ78RAFSx26DiM2G+S19uiI/kc95wwgvTtizGbORQCFVzGEOGky8dQOb48d3cPmrSm/yEOnQSjESLmJOE3ntM0KR/5BH+e83jEYs9pTWY1wcETJTRz0KI1+R/RRd4HozAVnrM/mdXgv2YNa88+XAUjPvWciEdMl9XdYdqSfvozZ1gYWmX/a6dzzyWpnO9NEF+GERbcPpjMdMJ7nEYu6ZhdmJTv6JHVcx4/fvx4xRIV4dR35yrJEwV8JjJuBqfK+hSZxNI0KDuvTeD8pwIcXBAV+2/gh65jcacdNPlznRxv2kEXTq7z4TZKgtlv4eXVGOYHmwY2Yr0uve5nraOtMvAYq5CLSzqZyt0Fuj0HTcVjelTRQgeaeJ4/hFB801/VOD31NyHL7RJ64p8qRmDkEODRXgXRaMw+sOSV0K3ha2h+5G4ck6XK/5UlR0mElruI18G9TOdT5P0aqQLvYvaNRcknadQBgMNueTRSspSc1Uh8yRKP0xEbJ8EnyfpR9rgK0FPKLy7APkn+HBjD2QkY3vB2+nRQr7vKnVEI7v4GW1PkB4XoWU80JnI0apVcslTI1izmi4sLNkyI6+JUl4T2+oSuyNVmmtIwDNicbqoZfI3hVTgegSsTUdRcb0R8xMDdBQwIhVTwyus0VBR0Id9y20N+cxNEEo1meoY8fhEMr5Q2ZjAavYDpHJtImjc8FWx6xdjYudf6g66lEOE35m20UOiLEyv2I/2j/JtdwR0K6yn6VSK1UfjNoZbY0FGHdlufaIeCgU+6XFkXHVR6cynpc1rN5l+cDC9KhHidiiS8uO3Kd6JM3RZJECcODcbhZfQqYTfCc4ZM4liJijSqaQEyWlYN4GrfoXMedcfh8CvaYVecHZdQNcIiKl8uqaNxk1O1NudpkvDoR7rQaFwhdXMPGHyZhMmYeccdp8vH42AiWGZZ53iObNBKWtLjTmlc09x+oezNoWb5HS1rhe8l8UrVBz9SHVjg9fo9wCZ3dO4EFnVzycsG91dfZu9155OZNaoyTC2XdNN6hCnSD1/9lWLt4oB/Awm3c57A9koqzEMcutR7WUKzgJu/sltPUBsckDLuSi9W12BzCTh8JazKpiWyng8lcYCm084SLXO4vAoQSqQxwL3OLEhVtxO+PRyHk3MexJU78LeoAWLYj7yrS9F5wmaJd7JcA/vSv8rKUUB2OW9G5/p14KnbTeClDHKwPMzUbkLAWrDrDpU2hxgPAjy9eO4csXnMIk9Qk3wasimLJd1f6pqXoNJZgj0jhkIHtwdKWlMmXErS8Pd58sUrVlnSi8g7LTi2KNA83jwT7ZyAH5m2NPIEQdzY4IBK807phkM7uIJYfsKfD1WVkcGl80ajsXQoU7jIg7hViGRwa3j0Ef0Yecoj0VU4Yh/Y+OJtJHENFBcTNgQzYw/NwZHz2euTZdtEteZK616kE6WAj8Ii02VIS8NKbTPUNn7KN+j6WotFbmEa9uQbxeIgy1CL9TwcveZpBNI3SdSU5imyFeIo7B4jhOTMZduiXuf1epbQ0MvnhhD54eQNusUyvf4OKnFsyMJvDEdoQubJyrpD5GXoH6p5AaynIGFuth5ZmSVZSu9Teh0UAVgxsOrRWmlmqnQj2wXTE6GrRlNVekmWbcvoGGz4c/0XxLaZVHJ1mdWuT7hlNNzQIEpUf4XlcQktdVFt21zRJhV5/zhgoGyMnxWJjAdKSNzFhMdzUKOIxIoFy61OESQ7Ky2qMwQG5jdANWStaIRIvPtWp3cbaGeUjlTP0uN3vIpJ2RgcoWv7nM+2gYiO+dgxl2PVPi2pqNfvMe6MRKOC0PtUkAOR3TlbbsWwpadUg0McCOSyUblnq/rLIu3JGkV8RAi9CwbM7mk5bCrYx+Acns1hFCZhMP4YnHuChuLFjA3ThHmcygiAXkjZLABJsPK6o91fmDfSa3bD1QvJneuyjvpwJM5TC4VKYPDAmlZWt71bDqDkoE8yphwIdsJ6fYN3hDdoqPYhAIHNI8w/4sn61x5vGL2ZJXp2UT+XqLtS/W6fgq95k9hVATCJNHO/dkVDPmtRuCJYgkx1Ip+V6wczg6nV6ymQc2Z6WHNGOU37RNOLsFNduGCD8y4qYXnHNAnOwf/eUq72QNX23bnaRGE2kVt7HPr2LZxStdS/x2HC3kbjWw+FsSr1PQtGmDiFxBwfJa8mgy1+sOzpDNTM1bWKd+17RHWgBHZM7XSJpJ6zSXLlHS1RxWbTn7kOZiq/FA5wMmauY3gtXVS4A27Lif8hcYl7QLK4uc55INjhvkPo93WZn9Zlfl6XyZh/3QjF22cf9tzcNoVM7kzAcvs1ZktfHcDc+Tu294aWty4zwr4HbS4xkz6GVcg3HedrJMG5Q2M+lp/jUCSV1cZhxYMmCc7DhN1kj5qQ+b4fMA3IS6JaVoEk9DPzzitB4XfheN+VDq8kbOGSKPRB83MB9x4CPI+CGFDKuTKsFI68E3NljFk2bWdJw47zYhQmtVMQnuLrGCup34RUXyY/tiy4O3/ConxeuSjYA60YkFqXeRjpMS1JfpWwKq7Rp1VrxFjHkZ5bHU8eTRQiFHZk9Qs+r44dso3cRsrccXDOxmM2Or91vJPKfXwXRGqg37PRTWRiEpwrNnLTWVK+WJSGcipvVseNeE01WAu+BeEYNMIdaza4Hvefi3/HXD5VrHY2k893zKQ0ik2aYfkfQMuU2Yi24hrIEH7FxQAcmqX9przDY5B8IPjGV+oKqrleX5Ujva5ZuRcpuGJMruCBlNHN8D7MLYKwFyFPP2t1YOFqCJZuNHOEdeXrAZ0Frs7WXvyqhns/xoqhb6s2PFRmEjZ9XnCCZp5TKR3hBreyF7kiyeWvHBSU3pCYvFg0cbczNdCBFE9annFUSlWs6g+3N+d8DNop+FHMaISJFE90dFWrWVVSLL2KTFGpjqi7Ew2LjeD7vknf0N8w04RDtY4em2c6pNkcrXnLWAPw5mZxEmqP9ya4mt9sh0+4cWgPEU2lc3R0j5A2WJTesBiwi2//wJjfaUOq1aYyf6NJHVTeccIIVSzTBvhKV3mEKlI5NyAQzqdAPYOUeNWob7VHfLWKoN5TE51V7XE6x2HAM8AM2NtoUnu08FuPzttoLoknerzvh9QKVyf9pdkxAgrO7EH5SqVcsuTtNNKjkLsl9FquKwPNcOXJU8tgDORk7hsqm3jOxDAOJ5rpZO0PcqOlq/9GMJmMb13w/LQ0Pmazlb5kyTsNVG9z5yOfoychcqmdbFxWqneP5kVjMEBoHgwWi8pWkDlcbsAauyjkWe4uBuIeo18s7tWWNVSfUzhoFYWtYen4j93sNL/HluxW0V2ICuVgawRVuRDpZG0HQrA4+XgVileSCA+/sxEsa3jhmkApdvwoE/pAhRcAhFm7CkT0ACJJsaimiPlQsFFtuyb5liRXYhgAGWB5KAOFJo+jE5B/Rv48F+wgGCaD8OYmxZM1mKQxy5iAAyZgPwvH2VQOhQp8YoEJkDsYJFqAAoxV9HUwOQ6/skLRYogMP8OJlagXmWorcq4C2S1+gaIcFnY58ULvKhDWOVRx3SiU6fG+F+bCjryK9IQ11k39Zhv8zRi8i7EFhX/v1eulW1t9+s+IEN/3/2l5szY7ZBk9seFXV6Ei7gfxJTrhFsblW70u4WbDzzJ7rX7H/uHNwRNaqe5uZd1du+4u1k2zKB6SLujCqAoeanQRN0RoLqmGhsQwI/5gjVAQF+4oCrGIERPnfZ+GpFRc7gXsBNXbEhJiBdcBgbc6JgoSayDFqrHZBF8qMm4LxFyTY/NqzhbYCsg2B6DFb18Q2amV8PhhAgzW3VyAW7/V5k+KC9vm+h4OVdS0bEG5vbocVrfN/7Lb0XcVhhzMROP2TVJ5qYLf/SUh3h33S/VtK8mJu6rCVnhmfCsGt/oyv+vmA3EDTCHT2AcyM6R5l1cD5Q0q1XrUnyPfJtDMXr3Sp+9dGrPMC2YZWw2Q9gVuWlceMRXTdsMVtj4rJ6QSwLpBFPEEcWstqMlXSSBywfyWSIzT6hERuuaewXrFq3NFO4o+wCoGsrLlzOYbRlcsDhNhZlp1VdXrCg3z6ml/SOWLaxIzIaBZDGnIwuSKxeA6EmqXghqKjO7VF7lEIy6GpjCZdG4R0J6iAoVN5xVpQND24PV65YW+YsGAzW4BgURnsH1FKrVeryC+rcGCrWFlGUAmYmX3vTngJOVt0ySfqOAucs5mfJWl/oTbQKuoytsFH2HSZustHlHbiy0V1MnlgX5yVUXkVgtLLkA5dfKZYDjaJytOxNK1H/BSr/MfEWWiWj/zAFyOJmKFZicTlhXfQHEIMiygU/JeKDu5X9K2juTegffH+zaSN7GwQVO+FqL1aqOKBAEiH2zbemkfY/BZSJHqIavzjnyk7LwrrKiUogZjJbhc4fPALMh9/B5IzZTdpvGqAJ+2FpXscxv6HIhvl4PBeCS2VapDkdEYf2PPMEzYe2A4es7sdTj69DocOUabyOgPvbpBd6ZFtaJ3KjqH5yiCVxO+dqH3aCFVLrIsuKJp4n9S5PaPaLFw/xH55aUahvFwzIBLMvMOmnR4C//G3t4Blf5i5BBFEvOvzHP+4+DgQP96HoirAGgNz2kd7jce7+0dtvb3Wnv7h/utg9rBYePxw4ePHrb29x4+2m3tPdLVfseVbjWr2LFBFN4ECTMOZ4G/myRxeJ4mTO5DkmWds8sw8pymcOgwGA+B1eSh180gdugoBeVb4YCGD5hQCs9ptlsOleZlXZ5GuIKI10LASdJpZsyTDENBndoB/H9771B9KY+a0gX1e3YBo/LzyvTGFOM/W2CAsWOb/v1nE5L+0yFty0FvhT9rrpzy6iJ8mWMKGm7g50irlQAagytM+Jpr+TWSalSNULyXSGOU52MmApRh02hYKiHZXZX5eW4nFpGEo10ogr3CPEmDal2vN8V0qHMhUyCgGlXYbWQl5RhpkBqlN+cspqNSimG14szHociNqch9zXoocWFN1rJ9yaT0TO8zOqTMzGid/9hRIBTySOw4pJODDNeChn81fvmPM7v02c4OdRxCvHKbmdbSjtzM+zSdVTrTtUwX8r6E2bxnF1K+4Cv/4fP8jlaqQvDGRQh7oMfuih/gDRdAxgjg0my3BxJipgoeZtlWdnOgc13axePy7h1VMKM3gRktLz7FkqankIRvJZ1yAinv4vAmBPGNzbr+7uvXOv0EWtCp1IHb3IzZBQoxrTRMwhgU03r9E0aVxeHYkOR+IoR+zuLTlAt8JpSbZLNnwIcrWsa5YGKOTljBsz0Eqv20WMiYO9kY6WeQw34mxMtVh4u4hX7WwfQUGXzSyZD/maBVckruihZQZK7XlOYGajwuf0TXA2oMBgmbgXj0erGYErrxuUI6ZF//dK61W3dtBVP8sTTBhhjzQyNHNjs1YpOYDYMEuUZiGibDK3fmG8py1pl5Gxuf6Hdrc9FijywW3+l3Mh8Ggmmw8FYt06aSKVjTVVXyUgYL4PNnR58VdUCyQXuMmeNyr1MBywFDxjt79YhPyyOWNdaIRdaP0jrm9x2oUlxYOciT1aKaVUjonkOER/pyad21olr0hnpu0ORRIBjsHKr0FdTRbMmVhL5Oz8nwtEMdhaidvtdzrLvB6bdx9HmFJOzCqTltqctckP1JVZB8vK+8OmQohX29RqOhUUt+Di6hog9vvrzWpBWdsqH0Yd8zwcff2OhDep7EjN3VplE0lEuKd886oWSFkl9lw/l2jRYjyds2PtM0pGsJiEn7nr2vnRrvwyAKl2H+witIj6tCsOU3LbUhd2DvIMY4tsAEtGJGfIiqPQLeDbOcyo+wdX66S3/gqosJ0f1i0X3SJBZfuq2N91fNFu/C4yyamntNu0+b9bp8JADO1Bj3yM9AHuymKtSMuGtsJvDu1WWsxO+Q+Lf0ZvKRv0NPcSCh++RzZSdmTMc++7xkOrZao0cwybKx76DjjryFRC0U23yCruxkQkndUq7sKouAav351fqYxyXtS1FWvYRjn8qze03RZmytSuORViERWodEOKTK8OQTlq/QPP282kzglM5D8XbCIjbyjpd0WmGzMe1n+owzH47PNZ326bGf6aPPCAUQgYNSxCQzJM0wC3zruEQ5r5rB1ueolyPSOZI67vALwCCXfUo6p3Y2Y/6nnDnGYvG58HtKQ2aB9wyC9rdDVq+DwOqTIpI+41+A+x9DkeZwBMyvUjZRF5P93unqa+vTYmHNxCaJGaNaBcKmm4+LSKcSJykUcw+MVLy4N5rlmxsk1HTM/FXAeW9FjHvSloyROxX3wpH3RdbanE+XX8q6zspYkMXIKlCFxbZCEqqWrVc0rUQIPzALsX2d3kzAvAfdX1bU/G7Rg8fLqlN4opplwl7KsgGGxuRFSgLUbfOKKzZJoQxOxqwIaZmdxdQGtbvBp2ym0sx0afAOCdmSBgyFJI2ES/EYascoEj5Kb9D949h3Dc88D+JWlJGKuyT11+JM+RKs9WoOFY2hND2hTq2/9iIp7S/ggm0YqrOkzosovfGcqssglp1W7GtI5xWmN9SR4wL8KpVktZMlWnPUMI3WmPXKraZc86SK1nBD7MNzGx6q90WaX+iBjTumig8zy8Cpm+3stQ0ljUbjKB8VMI+TNnOo77SEck7KGOf70j9SfgYlteF9WvqbSOOIMmWDgS1fwJIIBMvPS3+qiBrGfON8QQrtUKhDQ5M+MbIPh9DAJIORI5QNxu/sAmNTwNwt4WLB6dCk6xeHQ+gEEi2nGVwHPuk5N8HMapc6N2GU+w2TQ61KalRw+7loKXRkzSx779IL5mdeIQFM4igYP+dDaBRs7PqE3q4pYq9SX99r58wflOm1Aaa+DuKvI3RxCWlTmab4Lh+yX9aBnWGqFh04hH7EBAjd5xCa9fJBYmp8G93/5rkLBZ+zIg5+z9ZjkLWZzhzOaaPRcO4otwRUVabUStNVlJvjELrJ/M3sfanhcjx+e+EQaTT4vbJEdJuV+L2qBI9YVuK3qhIRT3T+J+ZX6yWv5feAY2gTDk6x036kkaswAk5KvgHy01yn8VqKYkUbVeDzgdF5dllydaF+YpVYcmPjerGYPfFPy/f3e3Y/wuI8DoYMHy41w15y5j8GS2uh2O4sjCIWb5t+ylUSiZLutVJOgg64JPgz1qkoEOebsnFPZYMjfZiskp5D1hUtZQ3g/PM0HjKPoaKiXJ6LShAvjlAjy+0RYMv1Y7QR648O8iOj2tuHMzgfB9FXh14BdSqCKEzC7+wkHrsXjCzpLVssLpi8D+6xxBONde9aX8NDWz9y6RZeLyI8qEKGr6hOyOwHpLkEe8AzsgJHxooIgIv0BKTHOmOqaQFkyxJiOQ4I+5ogTP1PwPcNK+7Brl/wETlkpF4fssyJiACHxD2zJNvgXqFvAobX69fSTVuh2SzduuhXLLx0QpAt/3XmQXPt+gvo5j4HViQBmF46v1TjxtUANmWKz4pPI3nit8G14DZ42Rqg18glPaJzPUmvW6QVs0tUrYdFSIFkOkdLGtNIRVRuoS+V/JtAwtDnFVC8AollkPg//4eBw88dsdrjBOoOzbal4zrtKnqX5AKOw0ujwxsJ/9sH9cclHsfGV98ilVvuME2Wrj9yYu3+Fbz0hdVLF7B6PVDH7ycX8Untl9pTz/lJYMpDzEbrTpBZQWRXgU7ACrAj57z501NFQqq2/XT9ZDeZ8VXH17grgW3nyz91aZDOA5Z91WqIwmKY9fj5rUey8c71+P6/bD2i259Zj99/ej2QSL5zPX7/X7Uekob/4fX47afXA/RF71qNH/I98/Nzx9dJ5bx/K2IJOW1CyA9Q2xgJBa7VJVyrE4VRJ+wHvBjNmNxz9MKMF+sXCvfj3/F6gK/TYOxx/EL1TZs0M6PW4spMmeEejJ97yEr1NZzmGDNGV8NmFdkaGzkez7UfVvFVjnVymLAbwOVHOsHwS2bAjt/0wzJnxAfOiIoKLOvnGRJUKz3kuRYFxsipH67ibigGyMnqEpX8j+++KDI6PvmizNn47GeWkZQxqXVi2BwhJig2R8AgksWPP6p/9gF89Ce9fz9R5WMlYJbN5BN/UH7iOiAg6sM56jl0Ux6iH/EExv6MM0SvO5VcePkCvF4Sb3MVwVS2MdUQsFRtn97L4dqdj8bwPq+9U/CMtVicrsFk1Wj2893Gr9kRVwhWH15ECNiswhzH1MbTCotIVEudvpOx9WPhm92oTbTqlaNY2UYX60cY2QW5lzWDUA40zWGugRpeXvA2K+uQFbnH3aUfKnm60MHAyXppFGJr5DcbB/lSo4ge69+g2hokjhSPYsrsBtDEpv6J0gUpIs3jSwgPTk90ch7dfvcvWZKNHd60n3yxArNiD1QjWDWgn8Cwqvk3PDE9fFdWagSR4B3ol/0o/zlkKPjJIeAA00AUoxHuGFMshDtkVfKiCaYqNDxi/ul/Jxo+/ZPQ8JBpPDyy8PD0iT9bg4fvK+iSvNspmGPcWzaGIlSY3717wSAUoBC4wnHliloKbJfUcTcdekwdAp5oJA7/9AO3y/iHb5dYZLdKt17/Lvv8/t/W50mn8uZQV9mJvpQ+/zmX0uQ+l9JnsqSMLRafCaFH9frRqqt0rTRljdS0DASxWFLAnMCjOPqBpdctFZc/3/K6wTn/83/A/zlUUIjtQJVTI8B5tmBbsSUqNiFg2jXAZknALQXieDeihg9boZxY5dhQ+1M0lyUTnqjw6qjUvpgrjKPsH3WUqNx9yXZAvKP6y3yOrSpMFovSKHRlMxoeSX9PqO5XKi6hUUZoIcu2yTL67iDyA5+RGIfCC6mlulNwc5naDaPdXFHRT68jzc2iWom+DDXovMah86vkZvySx56j2rsnElalzW3jKJiofHxLR0bS9zGunlZhkb+UTR4HTznWMIRcR+vsVNpbcUUWGM0M5G+C5VFJm8MG3q7WvbmDvlP0l7VPoqDPVHJAb79BQUHeuGBW2wmW0FyDlqu8z+t5Zwa59fpgBbICp825PU+phoZBDqSEwrhLdEUQVRv67e4fPnxIqFhl6RcJTeRw4atVdhyqd097g1VE8HwJGyCBxMmR4hh+T3jS3BzkHcQ1iiMYyQ4beMnjm/v7sOVAIK/y2wqkNhgyKUcQpwo+NLSZCRT8tnZClxNvAwhSUa+HLkTjrCb31Yw41e5UTJMltRUMRTkKMTDxqIrEh0hiShrdKdDj6pVYypf0O+YiYW93+SEcsygZY0S+gSvoRovOL4JwrNOlwtyxf92pquV2O1+yLRlszq9B4NJdfvGKyV+IB3oXWaJySmDA+HixcI/96jIVuOK48iloFhqXsbC0FYufsRizFc8wQGkkPwRvuRugwimt7BtcZoRjBh42Snn27nXMpQHFRa/Z9/LXiK0prrvO+7NVxw3V0eGxkQ2vqqoga42n9OHIHIXKpc8ZTNmy7TuhOlTCz05oPyI1TBdz8zB/XMq3YJ4elXLDSJ+H8MLli4WLQYwpGCShoCzVgrJen3bJ3HIWJtH5Go270L7+UmWP3HFC6QbDAWQnXzsmL4W0YDzmUzY6lTad8Mzp9s0iYyZGL8J8b2NgLSYtXpbZ3i61NfKmP1ssjgDWeHzzPEgCgDcIQ+s6L3VKGNWmYTTiU2V29iqapNZEjy1IrVDTPlVhnmUZ+jNLsOLSNzPdrAosINC+ALu+rtedSSDElMcjGOV1J/vpOfhq/alx6V24gUA1kOw16Yid8zQaMhX51Ns7aFI0dLzi4xGLvel9ZlOBZ9CKyUYzOcP81VfbSqfp1HaDLmeC/76NEZ5QnUARUMSgQ4V/lveiqTVqW9lsm2/4fjYMmVpySa5WGUIhqO7ztTS6LFQrDtmivMvuy2WGWxwLYkSIBqIr2o4KTVeuHiNGDjI/oIDLwcBGu2LW/SAhDrFUoGlfrG6Q2w3yxoiNGfqWqGoRItBBc1lMGghHln8jyWCCpf0oTbtdHE9uGMg+lSY2qLSprDtLW5KXkQD9UKl0Xzmb4kU0XzMiUb3Aq68ocxFV3VIXkWch0Wn+SVW6BvIekVLSSb1ev62tR1I75k/J25IgoMGTK2PsN0UjYmz0HqGEZI8XIeNSE9DqKe4aPfY3Ntxr0N1BFXDz4ZKnTSOjQn6kljvhTdondLMiD+/Jfl7SpPPUHZtJmcw2S4Nj+gm8QX/2zb2wuVhk0/c363VHRuCEX6dw1W7W66cdEEHlycNNoBpPgUZ0wKKdBeAIB+tLq071wzh5g47cqma+QEC8xeLzYuF+QleJR/8tl7cMOCv94khgvbZgq3C3H5Wvc36fO1wK4Y6kkdaPOIO+FjzalidgW1vJHneuFZuJ24ELZ34+ij/QITnIVR4AfN8Pq+C1f+e44NEd0hWjw2i9AGLOskp5KttsPIeAByHCqX7RW6uoaVobrUt3QNa2KFwwy78T4Cn86V59Q1iYf7fvKpLme+XT5t+co8KAJ4XJIh4ExDqV0SYrJZfZbn05T6LaeRJti5ta5cZtyxuvtjnvlk8Pchi+qBNk8ruek7cixFllNydEbKZObbvm4B3iSeP/P2m4wWhU25zP7hiryZ/ZY8Vxqvt4SZ1no1HNoZudL4CIahAlV0r+naoHZQ6I/v1n5c8/BXMXqOaMmWe0/YALM7hKq1iHEOVV4DOK+0V9w16frqPcS8Q5X4N6TR7PiHPxQ8R5eCdxni7X7RkgnR/cM6iybt/0o/6nt65wrLMd1FMKKx7dqfXOg3CXi0X1g3AlUk9Xvfruv4HFrchWKhv7oPKtpEiFH9yJP43zoZa42lXMCsoy9EN5MsLsZCAtNTMOPDppmf3R9Tdmi8XGgF77GzMIT4+q6tS5CMaCOZoQPb4XiXNsI8hwzTaZvDA7Z0ZQtJq8mXWAaJj1vesSndNdT+YoOsfoBksjQXR3iM4YhKE3sjdnRkgr4WPHfHmKVmlLc8syXd4RXjlRUjId9dfwiCA6S5h5h5UK+6oQHfhfdjbnpuPlF3Qz186c56rG03bmTceMPDUjN19eqkaed0V8HrPgK6QNtmSHyy90I1Wkl8xMffVbB1LfnA+WEJM9XX5B3yrFMySHuJrhYHMWNFuBLO9zNVWfrKVyC/L2vreYqXuXp//SsVxxW+kQRsJ14O59FrMgOy3l81n0hdsJKy+zdcqhg1JokLk6beaMLUnx0FWeAje0bWqt688Kem/Nu3Dc8iu/zHn1LzNvjIfGgu0J+HEurIognfyzQZCC9EY57RA8GkhsOTioiH14I0MAyYA3uWBG1PbMQXNqMPKX/FdQo0TjZcbY1LI2ppn+Kc170fLMTyMjUxJAWpQEgpXwdwirCMKyoFpY1nrc2t0jdLxKWBYIQofVVfdbjw4eE5quqjoUhE6qqx7uth7vEXq1qurEiOjEFWJnXI4Xf6SB9HfD/afFra3X8ykcQytLKPUzz9uQKB0D61j4EHAU/EoTQsehSHy30WgISFdYqBsMrzIy6nUwmRvGl8EIVrBlBGH0Bo1aW9HILU/BFVb4ZMBVmpfGyRIu1T+zZenBfAm6YuasoEH0upYxlMuK5k10tiQci8ENu+Hhd/YGmZE+LGDG8MOVA4IuTVzShn8amOTjv4a2gwwZOFMN3CoZ0nRJR8KfK//beL1OgiRhcdTBK9by/RixqZvCHFwBjk4iVzl+zCau79HlkrimHWKuVyr/DthNEI7xjeekgsV/Vae7MeSgzKcKjYKEbSchuLGAki74PX4eJIw0Ev7qw1tFghBqFV9bsCHSc1nWbdJW01RM03Akx7J3ETw6uDjc3z542Hq4vX9wuLt9vncx3N4dPj7cuzg8DC6CQzOHKy4SVNHEqrkZqBLh5Nu+zG09ftQ4aDVazWZjf9fOP5T5u81my2uOzh95B+ePD71ms9mU/+zvHl54j1jroXe4vxs4VDqPxEpN9WNwMeZBopLCKGGXpoAijGFTLX6aoXDUtb1YmM8lNWqteCELX9IFcKMIGXcVaoNcFVmCMlx76o9E7wvoHkkju/5iMUInu/oyFS/TaIjcUrgRnJPoa8SnETqxBofvogGNLqnWwnrPLqD3EWOT8S3sHtBCrjB6nNXcVUA8mVdKhzzdbhFCL8Sdjgn69AYLhTIQOOQHM/nZp5cqK7xBPdObYKa+2Gw4TgVcGmFUSlKl+vRWVZdPUtmA+u5T9IOY0WC+VNvx51aExdSHCLtiCdReLwtc5qj90jxdVB8zarCNRuNCwL83+O8l/nsr+rn47vDf3BCVaU/0LUfVHH+6kIrfMrA0ITQr0dCPnNK9YHLA9NUib7MK2be6NMAHpEnzITZk1kpu1HOrqhUTcrGw0qW8gqD+NroyVxtN5qn1C7vMfsKqIzVvg3yuNtLyUGKAwXKI8kxtvI83qiNYDKT76EG/Xod/G5nl7WJhJWub4Xp9I2wUnInkChobYrukkbDAQmRj7g36hWn2Bn0f/qEb99xAvMm4vejyhOG0skXvFNd/QDx7Rwd9kjnA5w2UHmAD+LVY6C/YBqq/C+dDl6GqPg0JgdtL4KF4GfMbWe5XFrE4HMoLE8NsSCCkqb/RItKXn0JJAql73CFfKEpfgsEge5iFi4WwzobQQd2KyepMqmt36m8M6nXESmjWZj60Y/gmnWVF0BLQfJgi+OIcgFvWxWJGMhFGBqLTjmpWKoJg9V5Thu/YcMtrKChGd2jMbsagCoF/XAF/ffxFaHmi1ixDnUYG/kazzcaC1cILN8wdM5E/ZqLqmKXWHMLVxyy93zFL5TFL8ZikxWNmJVvHjJePmVXQPma84piJ0jErpPgpHrNw5TELS8csXHHMRHbMRPmYidIxW2pNmvkSr2xQLb6WPsOPTRzRI5oN19ukVVbL4NYIDpp3soRALPMlnReWzPtednP0aenz9rV/vVjI7muflbc4xugkZhfhzAsZGreISTBkXsCW/jUYOszxloMwZ4z5oIHtRDwJLqGkQz/7bggOGLYcD1hTZIsx9MA17/ZC1nGU0/ytkHny2+n7AVtCY2PW+9yHO0U/ePAg/BaIZ9Gtz/2nvCH4DXNd7j+9F6wBIU0IRGvfOK7X3c3F4nSxsBt1LwTpHBsnzN5JIftGZivPtrmsS3hBH/uOpOwcKikj85t4A4A8uPJRSS4jq1U5oyen5ipf+q/D6LWiaGDC4CxAhhQRnYYmdep1l/vc+A6wcgihWWlFIxHluqwpGVz67ffEKtImSnOA98Ktrb/oIlkABb5EYxaDBTYlYpgwMGBpmgmIF7MhYyM2em0Tccg0ECY0CnAErdwcWi7kjNjTYiIdBtGz0UhvM6wRWEnJQFnFwotFxokr5mysGq6LXkAAxDZcxPcZUhBFWiiXZGgcgDmXk8Wi0On2iG3L0NGZbZeurdmUTaUI0jTvv46oJq/4lm9mN2Y90e80vRYh3v2Kf+535LMZJpqjJQmRDdHSbLf5kqDCApz9CfPTjhtSffkSE+d/yICBAD/wC+8r84WoyfxqmCAMGZM1j3llUThFpJN9wwUqu6Vcta2ZTqn6rW94tYxQ2u32sp4BVS0WYT+jHXiHe+ZH2kmzH8B1No8tOSRClvm2/FLTS7xxMRkCRoI6xWIha/uI5yWqDZfqmp36K6gjs6ganimnA5qSdv4suCFA7ohtbdH8Gk5JRyJW/Lcx5NEwAEeNnvyNJ39KyNKD7VT7WGoaN/SeSNcZhSCCvAmjIOGxg8ynXFK9fr+W8rXgRTaZINIstdhQWfdtmWZOwUQDP8tNavtRDNEAyhNzTe/IGJzVI4BodirwArbbECyIge9SXRxCkAGKBqjgbZQILCXkyLRVMIEAoQEBNn25pAOJ6wcob/CtJ3r2tAs7YQbXR50jz9DChG6kuMn6BjPP9UG9rm8r0CMllpjiS9uKVWfYFYPFoqJCbWDHJkGXMpMgFswdlFhTtcESogQIvDnzwDwgHX0d694IVSnYGSLXYp3qmHqDbGQDIMiWRu2oI+S7Rce3COt1N8wo78UCSSYaZsc/tI7+Nf7Vp3qQqdys2MuQcipoSjJ25dQvkQPgWfW6MUV2+qijSKUpvRIucbtksbAO83wAeNXrgubFmPlTOmawnFZgyOM7FyQH6oP7kfYDypEGRnTF++qPTch/x4dYLtem3iHARDEfnz3mM7sxOt1elirXnff9AUQFnDCX0wGeLLOi69cJVkgVlAurincGXs+UooN+5UrqpRKwVEN2v7UaMgp8ejjHQs5V5F9CuYz8EuayCus3Ycj2ltR5t16vnu5q4oeYZcBnKZALpyTt2M3k3x+e8yy6Ta7A0mIYRBDF7orFDHwUjFkjX7QFT3zAVeYxelr1Tj7FaJJrzormEehphtYTGUGhXrfeI/ha0znEmsiA4DiMeCEjUMNoFYGazxmxJ4W0TuH39oh5e+YwCb/VFk983hYQ6iy8cO+7D2kmBIGQl72C5ypnS/T9QcPMmVqz5MRcJ5W11OWRgT8CeBGhnqjxSMG8fvydIEo8kShRdAyBd5LhxZMyXiyQJyeSE0JI6KvPOxFmmVNygsElMsggxMBYsTdkwcje8PPP6w1Xyt1IFwt036dwtdnKVe2f5EHa792rYH9p5N/luyIk1B6Cvi5CeicaJF64hD2+QLgTRU6IfNEScsH8CMQKEIE5lwP6UGYttK9kaOqC+RkNLQgtkRkXTJIvXJ4z4Mq35ZHkyFgpcvHrdb61Bf6GOVHqIvjesuqFhXoys14Pt7ehXkiWVZcguOJ07cfqsXrr5R+qOhUmdsGy13iWZ17jckJVzehUoqeuXukXzDzTs0JtcsG2/AvW41tbfzEl+kgqSWWuDYvaSvWm66vsgtl32QXr425fsCUNowsWZ8G73MzODXmt8gc8CCcW7nM1I0PdhYSCdByVKP75+lhpBCg5iS0kWfnIgYLgWkPiu7yM0mwOvMvGMKGUzkdsOA6k8a0MGzBCdxNnibMkZVZzNpQ7RtAiNJYRFuKsTk8UnSByUkwJSZ9+E35eFuwWl8Q0Tuh5qXBxaFbhgfB78+kVi7wd0MnYoTK66QfdPcbo83p6xfrLPn0BEi21PX1QIh5IbQ7sZCCyDYd5p3RgtgnMU6ZLX7iEzvxpQw1v9KEwOqw3AEsJtTszeu0PRCNmo3TIjHMW3oBhNxImEhAsgqoZirl4o2oKfU8Q+kIYwu0GJOLXKEgUvu93CekU1n0mdXh2iTdb4ixvg5vxj8/ykiWI5G3DGV0FEUwX3y9d/yZqjNKbiXsTNSBUGAQQmY/DSAWg3G4tqbZwiqIloc5ZBBdpt6e1p7dbILDr+l2DNLIcUn4LwVj5mEkdMcSc+OXVhrB4tYiDcXDE4iBhNZh5TYse9QXRzQL6nSUQJrKGDmBhpWarFyq3TANcJjiZHJnsUhaBf1EMZFLlDY/In+t3tcsRUywWXIuQeE7qwBtVHG19bT540pndjGvfWCxCHvlOq9F0aiwa8lEYXfrOyceX24+cztOz6Gy2N9zY3q798/WxXgKgSGF9zplZolG7FnOe1JhU9KrBeGuhqKUyZCcb1ba3z2Z77AFOVk5BImfhq5+NG9yhnbMPv5ztuGcftsjmDmln0/chTLAhqAbm+HQLmECts9yJyl3wHYemQDTbXLYC7KpzUhHEn3dQTKbYxUpiRisKDkzBgT8wBXdmN+MdeWpD0sHz8c+bceF4wOHwdlyAu8XtzZgUKnwKKmoAzvBWnTeZvaQ5vTO1OsJ3MTzarUjYDRh9WHqI1dgNjkvoV+MEyEv9ylMAWQO/tC2uwUqw/PNrQxIeeHPrDi1dPavEnqXrkq7CtN65oKvAyPsmaMVaStcVxQ3wQlreR+nRIJcyKGo85KiE/3vOcLlE5cBptZre3sO9vX1CP6xS05saNb0ZXLuXLHGoM0nxXy4SVAQZs4Q5VHk1EQ51rlgwggKAUBzqJHEwZE4f3XwMhHZCMpCWwHABLhZSR/N1MCEuoV+Ff5K4VaXlfSm10seBkLqoDqhZdO9VJ43HWPrtvUpDPriKcxxC6LN7V/mArqOwYsSTbTYKkV1M6PW9moDz51B7QWDEr7DutVAlFTYj9N292lT01qjcbkVIMW10nHmTi/MFBCr48L56tREJcvHLSNfMxRUDflAxCCvPBaDocKWj+zYesZiN5OhkkNbfw+TK1e1TaN7j9PX6Sd+3MWu9CS2slJmqWSWA1Gv0BfjRdE9R8LUBcqU3VqLwn0qEijGc4jdsigthIneE0QWXDb//gVo5n35Y+znWfqOAwhRURAVC+jEWeS6L7HzruL3m9uP+L+SsUf7aAd0KNnQFUSRcC1o4whZeCxueJkFyhWOgL4X/AR4uP4Ua+oT+ga0fyda1BFGgE6EnLUUr5RS4lSQxn6ZZEbAESjzYcUUmKVR6wJaEUmWpHubLdr400omzTJ0vJU+a6JtDiZTzSubSR1aofWSl1LhP8gR4iPoCVg/b0v2/dDIPdo68qJpOCN3Mw4PM/8AS4qqVB1yc3mi4PL2r+CTm8GhRxU/yxVUZwYZpHCa3Ds0NByp8X1vhuRWAFUrng17nA60iH2M1PskFc4UoodI7XGpVknixuqDR81wsUik2X9Lf82PPhzh1c+2Y+iU85dn4Ejbo75Urcq5ifuIy/FZZ5AqOBGT/rTJb+9IqIehfsXjvD0E3BT0VfepmL37L6jmdjIKEuY6BPMecKCEfLgWkrHlY+ug0pmFy9TpNsLJQCppFmFsssp5Magn0FLaVjktLgGi3YVJXthFiG5nqXmE/4H/00/qdToJLUYTtdrVFCV5Nlo15cc0qTmwSXD5nSRCOhQZ495NUCM33qJu1m1Q6/hYYyFC7vu9zkocE+k+c5K+CfhLUNTd0gSehlGKsheTm/FiQIZekny1DqP02PGl1st0xKr65mSioVVx4QrzQHoWES9VCuL4mQVcllNv15bTQkYm9IkXURCvueNmioQeS4PKSjd7qWaNRiZvz0KwjIM5hOT7wOJEGuaaGSkqXPs/umH8KvB95nBzdmhHDbtlbkPoV71EQUr9KGllnvTALjAOK6XAjIO4yYXkUk1Gqg1Y0CXzDV0mjOORe2qdTf9DhOE5Q0ePt0vGZZ5DrZZ+4hdYaeNMl3lf0830JzQmPRBUi+8c9G8BgsBX1Gb9P/RvAYODHZFUzeoQvucUH/SykPQmeFE5Dff+o0eTK/mNF2XzPuSqMV1dB25uP8e2r5G0qawA12aSTIA5uWMJiIFhfsyQ4un0FXOAwua1gPb+2B6RIMyTQqWMaAkOCqiMDIklT9YYlgUN/sAUDwWWT2AFuyZfNueXqbNkwP+XJXn6Ba/5+RUHkfAV0FH50+Yi5UD8H2xVkPy5ZCmBcwrfQDyjBZL8MFW1QcW4M1ZhnmW3ZqwjlMjz6wJIkjC4zQLCYj7WBjzRhA2nC7A6+104MQt2FcEB4v9EiyzLIVHb674DKqrVLK1aMrAWVNeCNgwZGmcFAhfncCfh4vH4Q1FfXmfqpfvGY5SncRRLuU//p+mnJc29pw6wGV+A+NkSx1ylZto2R7CVL3uk8s9Gatc59vlj0+vSHT7bxtdO3t7v0mi9tt/WwxYSUkDxLR6kgJXwtoSZJ5PYKGRmv17kxS6jXnR0HVTubfbAmNwtjZiV9ACigKa5MCbpcKbohd252FcGVwgo4GJcRzVDNUmg+B5o0DzAygWcnOavo0BRtCQrevGC+QpLUABw4yI9cgthFyGKX0zneKL8F4ooJb6MFlixAYRVeq3CflxdMvJKavq8imJvvOPhsKJlcG7G/UlpfAyAKGvja7kA69md1KD1tFrscSo/90FEGFGSuYEEJ2Vdixn7F6uXepeWzVVll4Cv/x+/Um+el8pBorHXCEvCRxQIsSdWS0JlGRvrpNVBQ1FmR7lWv9pRKuTrpONJdWBAnO+iIaBQkgbOmlvbHAtqKk8k4HOIh2pltT6dT6coojccoxWIjR2sZ2ujOLImirLrZ3ngzQ5vZqYOlvZXVS2j2UrMa1uym5BcoAwS8q3IhCtO76CHVtVpi1R54rMm6M4/aZoE9kWLYkdzSIVfDOh6q7lvJMFs/QZrava6bZJqbJNgGcetqM+OVc5mVWx1kEzdT7lZNuTDhKcal6ubOIkLof+f8MkbFuvmZUlXzK2YW56cusrzTdIti0U5aMiGElrb+y+0F29/78E9z+/HW2Xaj/wvxdgCiyp4ee61+LlpmBqqSfSQpGeSwFDOcgXrNy7E5fRkCx3GWYHvyYsaGacKK4+45V0kyAa5tkkxy8WryM1UVwOCZojcRzFPucvRNWdzif4s6oanf6wMANJFAl2SB0RFSfUAQHxwD3PLAnpODAUssQ3xcsuQNEwkbVZW0uZc9HJcV3f7v7NbRSw133YjNHNKv5CSqMkxJqzrlgRWK0JB40oO4V8iQnOblsr3aJYp5iBWuyc6quUrOg+5PdsFBy3pthWXGPLI557lF4w0xGYeJ6zQcI1Og2y3tOqbhGKpczRB4saDmD4EEFEuKzCuWa0CsTksjRyttyac3sPzlJY9rD+C99WBzjoz8AilX0tzjHQxp0gO3Av0vnpA/G/Dzi8cJBbFg50stSGrQL7TNlw/A2R66ORLLxheiDwZ6uJG2wup8sCN2wWOmDp6GNoDm4gHCLAXhIO6FkPLvlQmTYjYc8dGtdWf6eW7gPM5KQVCDiqt3vlzSdD2DPnc6rRYrj2fGFJCCHDSvzhqXg3f6BO0PrMb8ylKEZsmKmHP6JBcI2zbQN68KUNoE/nev1c/jQ8A2dvuZFt9dRZXgtR02yqvY430/XaLRf7ikoXjNRmEAGVIQn+kAoaOV3Ps8vHBdkF1A1G3rOttoKkvwn9sZmq1W1R7BOZP7s7tYbIDHPdNvyxh5qH5Dmi3HxHZcUW6XzrJq6f2rKZDZ2Jg2mHREM7MfvFWSUdsvVA7RCU+Rza+DCYYRiXml8sOjg2brgNCIr1B+iDmhorrq3qPd3RahfFVVwQkNq6se7h0cQqi1VVVDTui4uurB4d7hQ0KHq6qOzXMi5T4qIwwkK34A3w6dFFKBFKFXhUQpaLsopOKF7NCbQjIDN10yM+NLOfRSF9PoTte/1RmCJQNN7zv0Wz4ZYdih53aq4q9muQOdO+aXWeoLnTocsyC2upgWM1SFD/n04oBnhQkb0mcAZIt6BtCvhVKZwkV3Rc5AyBPs0LdQgiUDTaol/EMSg7Qi4i6R4Ow42UmQ7XyYoKDeIDusBI+eCqXIdpU+uFZil+50Uk4nwS3ofXp8uSz0pfVDshMnK33NKolSJQhkVig/WVceNZbUpOxKV7lKcrpoYPeRQxUp1bEDCPFCrCP0tJ4FrNIyHyyUxKADGtIpWsmgIqwARwMDF+4cBAhXh4JzsNfYWQJXUCvJipxS7N1KrmkjYlOY5ou43DIds29s7Claj94wIQLwrtyIWSB4REEb1wPyLv5ar8u/DUjrWN9bLfXsXhoz92nmr9zs/rTDG4V1nxIgAxDxP+P+RkshEgWvUMQIOHPrHRbWO7XXG6MIzS9YMoSQoaotb0afffjogXeIpR1w9Bo35xkEQdELNw3iSGpVqRYb1nhAwTWzLauJMBqy2re9RqvZaNaCaFSbhuMxaMlKD84jMGT7tt9oNprtWipYTaGAgvpTLYxEwoLRhkMoLERToVTpE1Cbu70OhjH3jjO+u0w40tTVqyhh8ZBNEh57m4axYaeeLv1rl7QzW360S0gbMFnYFNc4OwKGFqq7po00Hmv/MCd+F67Z4zBib5ByfcljII471ck65tV31QWgC0OnzVyzR5DnCQoaDc/5ULtZhfv05P2xy+mID9MbFiUNKHHy/hUh9E9fGNJIrlgElL0ajfYhvFTE0kCfTeWz+SqWAYRp2UFgxjPO9J31A8ktnU+BhwhivabjMa7mifudZj+VF1x0LHdlF8s+s7eNLovH2tfHWhuYqEFT0ZBowNe0XewoN5ENqbutV9YV1FE4waFzMEtiMbjXzFz9i4bKX0qdifYA0M1H7OhFHB8p7GRMrBol/A4PFRlxF57JCgW84j6Hq8jNIn3UhH+de0HpMIFCajHLwCQC49eG+EoWLHFDCo615HsvJFoRgKKDDzYFx4ukjR1TSxsrELfRUL9oUAOfWN4PCijWGbFzNR1NIXu1EY8egCOPb6wW1OQAawlX7BpW4xGtBec8BmldQ96WG7xxETXiXEPren0hTRc+TEHnIN7ujkMWJbVRKO0YJjH/Fo6g8y/5Jr/UpMYYrY04WJpGPLlSg5Aox8KkeI3ZWBYQa745wLbqFAN+nSF+zSPnbi7W3vXS5/TYn1XjkQ4q9mkdzyMw7NB4g1Yhw83imT+tOvMnlWf++1LKTdRV4BK8izVxE0yDMKll2i45kIDLPGYiHSevg4mKrA+Mgq4KN6jB4Hqp28kWF5bkk0R5n3X2wL2mnM5XIMCuRMH3RIP/3pJIm7FGMB4rTgDB5xqYBCP6O7qVgiGFSdD2zpKDLBYZRsnyhNFO3lApGnE5ZIXbVMvhDEcnIaSIaD+Rev1TCdF+yhBtGa8eu0f/18Wr4Vq8+rle7zZCAYwacLvjZI6FUT7Ya/brdaNEqYOQYg6YUEkofBfzm1Aw2Hztn+SbFF59JjmpIJ+w6BVEpY6U6b5cBy2XxqNSs9ki8zQee6KRq3cSj38EMK3DGapTM3U5aYdIOwXREEhMhNnFIsSIQal46u83m5083tR54P16y6k5WxxOFymODkROvuVZI2wkbJZY1HbxGpdMmADurBnl9DMELxrCL04/Q2Tse+KLJaF6GxQIuVZNV+LDAvUIvIy87FvzjFZ2JNuBIiDJvi5cylm7qCiner9j8ktC9w6M+lKhKeVdeX7NlYzf6C+ryxsfSamkOsNGwhUKJBK4zE8U8l8rhWjZhNBN8CWhrzhoxmQP1yE6+0a1Bc37At8u9mPvInvs6QblCUwlIpUBHeT3K4gDEop/3oy9wXK5rOqmoFdi6Uis7A6btsKjyg7SpXGJXLU52cMIm+2umgUHkFIO18utSHfI7pp2ejoOY063YkkMUxlnLQrDuSw081pqqQsqUZQclZwXOl1/J1dX8XJsPqVq8GZVgyv2SAqRregLwCW14ALustPcDIpMgA8reizve1dJ6rAjKVu0G5qt3BqMwVyUoFfAlZJ0/mT7BfGxASvBkvcK1WbiN3debEsZGHDAy164lB4Vb+GwYQN42u9Z/w9T/5uq/zqnwPjDzZxDM2N+qesLu6aQZQZQhkmZR67cReTxHGla5ipYjIIB5YHYyzKnhqsDA0QAnOlBdi1jjOulL+i84qI7rrzojpY+MII2/WvD9r+Q4e5KihqVifY9Dc4apcc+LFaIRwKGAdmLR3lITBvrlArd3ox24U6wtaQt3SOCXlDNaHz7BzrG1Pe30iOSAM2pXY60XZALhPW6FLMC9SndgWZlerkR9EGNB0VdooHChxnQFv7rxCWu5l5YVwhE9N/M1JdfgXtR65efy/M26/UZev9x86V4g09ejdxNOqNdFBZp2itjj37ZnM+WHsaDbouGYPE3FvvThlQkZqMPmIDeDUuJxKzUVFU8DeIQCEThzmWCp5u0nIrypbn403vUNKXboljWV/Qfeu8PSRYLJ6WiQgzlTysSXVgaLF7SsPGnValYYbFwftn5xTGBSqa2rE4CDLZ7nc8pQisWaqNMFyOaCLuw5UisJMbJqcp5IjOi0DJbtxT9Q3ogkUvkbYQiO2OuAILlWkVLMGSZVxgNuok51WseCBFeRhAAT5D2qc8b52k41igSXDph0FX9WzQ0/qGiIfEPPSVt04OFXHxJmHOjHCLJ6OMGKAfdqlc3BWdfdFAeTGpZbpQwd+UwBtJRdaMCz/lHaoNPfIhZ0Ij4NOMM8oZC167QXDkYM2+MUnn+rDrbJ3o5ZB+VA+Gohy2pV2zKeRmEYzYCjgxyL6QOpXpwoZsFOFS+41CT6j/45ZdCrcYvv9RqZ9Evv7zjQoTnY1Z7j0xz4f3yS+0sqtW2a9237z+ozzcsmfL4aw0aSWOmUk/eH0vfvKx2kwr0ryAVYGo8ll/CqV3wWLak9rTxgNxj1kpFYaNJWRwDbkCbikBedgOmtQBKF2yj0QiX0h9/qq64jKE+KHJ3prkrdLb0U9r1p/J5IaSya4HmFK4+B3iIK/R6CK3S1Tuu1NU7WvrTRlndsicoN6JzuumjC4ZQulQ4hniv00ZRbRer0E2DFDWfupEnHlyITRAqrtdAMnK61BAB1kKaDkTGg8lPSO68d71iZmRZoFiz/S4SgS/KRKAeR46glK2oM1tsZHq/RgRLcjpYuTbeWm2o6WklGd1YaEjQd9yf91Le97Q+TEkBu6Ea60hurrSPpyaZeIL2JlkDqhTKmE2hLcchtHdVKqWEzqgQoHTi3axlQntfC1Vy2hBOf13VblZVuRnRrztcC7Dv0sUzzTqxzvY9LfYHOj60dyE7mmeiVJsu9ULrgZSaB9JAfnlT9VKYqTdnF5iz1/60U1b0nhIP/ZQ2MJqaibnX7Via5p78dsozKqnbhTl1O3pNj4sKGjOc3s3a6ak32apJll+BU3DTDsz2FAIKkoLIVEr+fg/iCIIC1U7ePX/28cXgxet3Hz8N3j17/+z14NWb7vHJh1dv31S5DAqkpvnf2S0omQkTINtetx9bmZzZzawP9hi0d1lYEnsluH5ggy/k+9hr4V1fYcBQxILavvJnlCfBrZE79Z+m97d0SOnUDsucey9MpaOstQ8VQEzT3DNlaj1T6LUvlfTmerUgkvf57SQQQqu5da/Y8KsHwTPUXTi31KLcTD+LGGn8NEP4Co0bbSy4F23zGeP+0AxgaVZa81Vwske3qiinU3BQTGcQlGZKZ3ReNdwuLbeXalCrOtNUq0AW9+waGXpTgofwwzqIQ0D7d8AD5HOuyJuWC0s7s1wc/0d7t8VhxUzgtZtzjLDMNq898LmKcFkgbw2pFMnwrSyOkQQ0ChEySf2ikoUMxoAqI0tYUpmib3TicTpogAsKFgvffJk4EVM/h/e1JWtIS/h+kOk4BknjaMzP4XUD5hM2C9xkuVN/uqJlKiMO96msDjZnS9r7Vl7OP1Yspz1kbfRaMWIOu3T+M82WTGpXtT6rAk2LlwocMBsE/IJW/9SvtKYpa7tLYqHRaAzyyu6Z1tXqvNw9PuiTjploo9GYwszyIB4S4okl7b1YMbmMjsJFky5PivuMavm0N/2JNvSiqybeFptQdF31Job1eppN0BgD4AZy4oHKaUc+Nb1yqZLJALzdKPheHeiHS6aLBuzCvBoW9O/CHQr7LeQX0oFGd0umVTaptZLu3ex6rrpm4vRS8BY78K8j9LWp4Q8gZb5s23yWQUGpGGsMKESSaEj/f7xRLV7J7nSMWkbomoKWZJCWJIL94tIUGKeVSxOCi71GxoZ1Q0KBa0TyTZUFBvP8U1I3JtwQVlcz1OhknF6GkUB9UiWrQBv8dzLdw2a8OXSmxwVvNMaWVBIUsUx4x83zF39/XmauGzDh03Kp45q+5r5+77hkDikyVOcHjP0rskzrEVUoorZWsOSd9l/+9mKxmA8G6M98MPB6/aWFuZGfVK/nWxYNU9zny8Uin3vBYxfGBkLQGie84B9dxrEQGBABhOhLmhth3qSi0LL6VRsMXCKjGVvBkH2xLLckvdrKifrKSIrri1Z6r3M58dzBwCrHs2/UxxkMyHLpEvqR+3c4f8+exjd8lI7Z4IqNJywWg8Ia2Bv0kWfRjLL3rAoD/Xc4g9JxTZHFl60092GUKl3bAFGICRQ+0Wpf7XBri8CC+46zFbZN5J8s7Accd/34sJLAKyJ0goZGukcVeO+uSULMrNR4Y1dtp9YkR4xNumMeYYRZMQ2BGWb0cufDQDATu6kcWKLgJxb84mMN4+vTVEKlVoXEdZqteasnEIpXMmonDMesLqwkWoXolXwCUcPRGbjLfdEYXgUxUFnPAMmQp/7+o3qdP/EPHpLMjCDc2lJaERvNrF8mhsEEHSaZsNl25FoUk5hAmjsORifLJ/6nA2rRItN2/k9Qdv7PpmMrQO9gWsuxwCuN1vZds1tsQfUdu8X/bGKLdoNXgTjR664A1oqTbsw40A3THcDcpGG22PxJ2OZbW0R607e6wPgPZkm1N/iSgq8guuHUz58pOvBT1Qud+s329MmgPa3sKe1N+1Zn2kKjZVtzJ8MrVPR4LanxlxiUVTklkLgaPL9nJyiV2BFzBn5p2LyX9ouOkCHN+EKGH23ja3wACgp4ztItBwK5DizlRqkvdAaeRKC3N9YtIiyk+s7MQftFplM5vplfxLW06wuJtSCHrpk+VyHnQnTgPcv5hBtQ1NabLkEgAQ21jUtjGXSFdiWkA/8248n6A9ptQE1/SivvM7dLZxmKJrRr+OX/9kBpV6/ra+5mK0YFodmvpYt/CH3P/TecPud+hujoMfchxIZnX22WZwaAa7nc+Fqg84hNnysdOyCMqdThLlWXEG7H+pXUMzZYaEW1gcQxVYf6Hg2uH1qu0TVDvGSJ4iK842EEKx9SbBSIRdJO0eGDdTGkRN4/Ax8lQsaPlRvSOZ94juzWkWQ/NgQvliVpyAzDyayuHYxGdlX4Us+0wZKsm+GQT25/dIYwMPLT47GXpDS25ZImTCQroKowDyw5CGKmI50DiGQbSyDW1CVb1VgtK+lXANdyuaRH62G8fN1yfH5O0DCW06Y9GO8OsJOnla8+GZVLoLfS6rUFwS3+Sw/EMW/AXwk7x7wBf+VmHHOUAsllP+YN+Jv3clOAKLxV4L5zfN/ogYu2vGcQkgboh1RZAq8AOvRxFsrRZ5dZqZB1HdjXOkbNgkC+mXmGPL0bTStpgH7Hc0lTZAQ1CYUWqh3EIRQIfCaDUqx6puHV1CRgcozCUcxUV1Ru8WWYbjxNWJBPiJGcWeV8rnZuhsXVzt+zikYwvlDV4Zeuu1g4sLvrm6rcVoklaK61et21uiOqP4AY0wHszAyByLdPNlXDJRRDFMoSBFWDa/BoeM9d5yOzfTbULlCg7FDn44sPHwdv3714/+zjq7dvBi+fvTp+8dyhU8qpIO3KGQk6W8qVtIefzd5aslw9pGrkrBBuCxW5RjeyTFiYgIHV2hc++VLTgf1qoUBZCI9YDUgqXUrUFGkHJNj7l93tw8fNXYc62VTfvhu8enP67PhVebrLFIKY+bmXi7ygur4rIRIdZWuD/x0HOPuCHvstNB1QBOemjoR9qj9O1AdSiCer/Caag9CWTxD31O/2jvsqbvFp7mFQr7unfjWhfwqOeODwmYe8g/ErHUM1we96/Rg9TFmEnwORLo63W31i7QFIEyTh6ADJuo2UkAdP4PDiFuw5vphuvtR4XPtiNbhjepQbB5t2HkSwOxc8rmleUE2a/glaCy/wAqpNA1EDyjuNJ1wwWpuMWSBYTbCk9uU8iAw5+BpGobzMiC+1i3FwWbsI4LkAlnEgrqiFSS3hslW96o3aax6zWhhdYIS8i0Ak28Bt3UYKsvb+xbPnr19IG5lQx/zxfX/TxNn3ff+6d9rvbFpxQI61v4cdh3jHvn+03YKwxRqRZfG+N+v1E4UBN8F3dSnm57UKebjtYAC3U/9av5d0cKawXt8o37OnpHB0XswmqONVC+BNCHIHNqqBKcd2q1kLZa2aRHi1m+ArbGZyxbB6zC5YzKIhG8kCmIGBxUz0DXCli8nfWcy3odlRTToIsU/bu2cffxu8Oj5+8euz48Gz9++ffRq8evP8xT/N2auaBwL3f/7nKQZ5On7qHxE1axvp1+unT/XKFJHeFasBiw6ETmpQtdcnHz7W3rz9iAFFkDcU15KrIJJTRpsgQCRqdhgP0Mw5N6PTZ8cnLwZvTz4O3r4cHL09efP8g5kM8A0AFbsz/4j3YJR9+ZLi9JqeUlDu+tNx9NK8j81K6TEc/7eNAXRG8UzAtX/85AjiMF8vFvrpa8KLXhdhtCujvExYDJ61rBEECa7/iAmQNqL3kTJgnbx5/+LD2+PTZ0fHL/SglgVi5520Ich08W0yZy1NA8esaGtZGD42XhPAho6GmUZVEBnA+fDiHycv3nRfDN68/Th49kaeAYe0K68azUCYWkw/bph+M79Ju77+3Z496bZnW1tk2pv1/TJd15v1MSgXHdAZocKHYva9bNw7FS75aWH93kuOdp5SLneXAX/6J8BXmIOv1B5iNryMcDSGDxaXyMCcFWN8sSjxplZRG4q+CKKaas8GPrWXb4/+9qL7EcRWQGzjAhzzHujt9v87qZis+1I4X/XyXjkYyC0PJ6ipdsqYvNynVEaFhgrsTJVoDOZyg3iwehB4iEQSxOqOcXacB/cZSEakizyRDj9JvV6xOEiOr1wcyC0vziRmAu4/V3m4AzXMMKp9gc6/IN3xBTr+Yu0myS3ky/dvXw/ev/jHyav3LwoTUNebGr/1ZFEp+lWgZmSFyJcPgZVTwez7zyUYjb7Q2hc1ADUr6Hz1rOS1+KdMK8+hVVP7Xz+37rM3cO67b998fPbqzeDkzfMXL1+9yc81JNnTFGejeMDqLGTvhow5nZZToaXBhu9Pt1r1On6svzUDNa0fvT7VjJ49N3PIeO3lXcrefSpBv+aEfqPKWUII/vXjhZHlhhrgAIEeS2ojzuQGslkokrvu/OKw16EAxUzQYmG3V+SlSISgeHLKw0hf7eysXl87EnyAYwS9H5g6dHffySPaqJx86UY0erJgwlqOqS7+PComvHA5scgsi57hhOaoGwrOt5o62q4fLhbZezcT4zTb6RMjH0pBYgMymj5NTfxcsszMQgHmbF2k9xa7TE7RlgTmeSeytp+x2JQEq17nFb5kREWalv1I/plfXGQ684vUI3Qwrddn2LebiR3JhiHrMrEiLErop+3mhh9ub7cBs2zkGLthn0pTdVPFiMygnw1/luVIJkZB9CsH0c0GkStgHPKtH1JJB6CbH9Od0xj4UAPmMqici97RDfAewzd8jpKul1Iw/jsLvr4OJvSPTPBV+4MDbCDvlp9LOyPhK98bVCVf+2JJN61Km1wCBebDe+k8GH71Bc0143OLcZtGKllW5I0swZKd5soocPFt5u/rUNk0aw76S2lsJshS7VGqbxG72ls1JAjDp5uwFA+sqStjoKWbIpXuo42SzpWYM8XlwXWjLzkqQwoVo127DpaRw0OIaJkqjl3+eMOByhaO07ARsZnk/iH0jcI4uUWtUVvRResyu6AjBZyYUgm0IPgY3jCeJq5slOjGBUt0TtY8WbYtNQFD7U3DaMSRO40fELj0xTcWJaALDIOAuyMVLJ042TgIXVn4K7u9b1Fsd4T+Gu7Z8v0LS4Ndu6wlHEZ+FhPgayZsSOzlCxpmgLpiJ2jlmltuCuGWXQWANuRJMaXLDVwgFIaE3meDZDf336PK8iu3aXXrqxZ/Vfs/VL5qv2hqLRmcPY6aSZscQ+OAo03r4KtNkugk7zlro0WUkEijELXppD3IdleJOHQeFRpMqOMopSr5W2H/et2637PaqpDWHzIJ2Zs9C2PgChsUM/Rar2ffIAumtvqQPVWLdcMxQMC8KmYuWhC8fWMFzX37xs1xVWwlEY5OPPNaI11Qy7/2Zyamc/v6qd9sX29vSxR87Ivepj/rXffR6erGeh0pTjdJzq3MZt8KIX9cryO7pMRhKtIRFWU6bqYUgkQsvqCUpc+Ws+NsVQkGNklZ3HwM4ma7pZzIfV1bS1yuJvHWjUV1KEqdyMdFvhTYzRsKEZb7CNesXSK8jut1GZ3/uIIqO9KZR/V6ft2Oy0t5RDoZoB3TIwC0des3IN7xhg9N/1fuQG5xfqixI+mFObxwu2DlLcF4wwA06ktdA0Q/0Znt660tCdyb7btU/jb9ae+6X4TpxcIefabk8GMjh5bIkuRsB/nNJIjNY6Ysm850F3XA7hzSSAGjhYBVSrbFIaMBo3PQwM5Ue7w3nJoBWWOjFXOglbI3pct7Wu3YtrX/cH+f0JNVjm1PjWPb7wUdi1owGuWsJ/Mrbble0RoORYUJdWm7ItdE7rALUGVg8aVVaRyeD0x0qbzZpbSYQD+40BKWqhgOpj9nbJI1apLu1yiUrGhYOV7w8hFQZhVGpo7KqWgE7Sby08Uku5FayYm6QeG8XhcdUBTxBIb5o9lNmW+0KIiQ8ix4A8+XVK0diGy4P0frJRyolBzW6xGosY3D7wzuMwA3JYomS4JcDe25AUd+dKtgGnuS5dp58A8zvYHcuHpaBVRWoyHp24wVsxdGK+HOjmnqn3CXWD22hb+2z5T0SY7Zb/rPSf4sFZF6HRY4FHKOru6IzHP95N61qkgxoqMrKtHYl53NeXkLQrL8ogFJttcL+9JzGvh/rGaklQaPTuSUoW6KlqINdJoC0ZreSYoJbDDAXqheD8Wz0ShMwm9MRzBF358FdVs9u8Wiel1Q4AVQlqLhEbYN4/ZTM+T8DoHF5n/9sDJogrGthqhszO27gddog4OhJIsSaOt1/nQWMgxjqCz4yzE0ZNAB0BzntuZ4uOW3gMEBbBmgU4ETUmZo0ItxkCQsouD17/aNhi3sKRtZRabtdHnMgkgmqtZcWNioWB6eF/lEr1yOhkL5gMu6N0lWp7mtg5BUMuGlUaZD5xnwkJdWRW/Y1JqPSZOLDLxJ1W6WpbdTpYDVRNHdNFxTVoU4vEHgq+rIZN6/R1NlVdehMEQDDUVXXi0FlJ9PtxoIhU6RmjdQJnNnuKQqH+IqqLNEy8eLhuJXSebw2O7TJGYdOj1JHddMnt6rPnRbMnjRTouU0QqBEekaXn6zqQYEz4YKGgpJSuWuP5Vor2TJieLS4qyV0W1Ws+QMR79Tn7Q6joPYWlguObecnG3DOgMKYmnwLL94YmnRTrJwXum0TKtnftAAQ4G6bBVQ2mTA6tMstBugMgAQMz+uxWM0VG5F0WEhXkDFrnOHweobyZBen1YsLHajAsVXt9IY8mgYJPB4J8Qr4IdOnt1sYkiWmwIOf6k1DvdyLzRvcZDElGalJyP9C8lwSa6aErGctWrvTlWNgtcmDESouh8QVDwqm7HoRipXpmJBqhrWl2xhrbLG1y9a9VpVdaT5ML6GqDTHX6m8LVYfMq8n+tbiayDVNXp93XWj0cjOX6mdrKKHd1POT0xUHIawncsZqlsQkp+GtYymZpUIp1jrpWUpY9Urc5asmhb6VwKoDKerPQTEwJe+5oQZyjUnQuV5QThflu2Osksgd4PY/S8WuRvFvJ6yK8VqrUSm2a1aiaA6epfE3jxZ7N/6dQBpxO65eA3esTsV1F/u8q7WmL87LsV8uUTR0e/Vz/P9R4cPD/QL/O/cH44DIWrPJuHzt6+fXV7G7BKch0Jjyu5W1H7nc0vd1XrbiXRiVKakCAmNnoqmVphKq6LAou2VMW3ihFaAJY6lMQwmSRqzD0kw/PoxDoassyIdm6TFIRBpjCKgGEqBlJUYkUlU8tLCih0L63WFqySjyA2pMwxSwUD5Z8NV36G0KTGHA1M9sfTDthwK/PYFLVMEqLETDL9CE0KviBznl8159mt5FnWfnXx4gQHJVNIXYCS17R3Mbxz+QqPucNj7cHtzzscwi1dqCH0bWGErV5RZLDQOyYioqqKSnvo7qicWACYPLj8BLKIILOK/HVhEHliq5Oe8CCz8h4CF/5cDizz2v3HfAheFC/6WxwUfcDHSmI3yMPUbn9+xt2uWpzBj5ROPF1g3uCkhwjYgsl9XxN46OGweWr6HB6F4B/j7io9HzKbC5XhEtbqD9OTac/76V91QMN6ZZO04Fj0wGKZxfNuymjZZFy03Y6VBk0F8iawdTbcvFoXxcdK5aHlCOYnEKZs6ZFnsdLey010UnWmr82KX0vq81tTm4he7aFxea+mEihHtenqSru7GtSw7sT9wbV6wRC+1VK8XkkLZeKnHcne83J1Xbuxew/TUV2k59yqXc89V+rX3XdC9uxd0z9MbaI3UCvAuVJ9LZfpf2/2xNd1buaZ2j7y6x5Xreo/RVgBK9ax+AlRKSWn1TKtWpGry4X0mzyu7/TfW8n7Nhfdr7j5H5Y5NXXNYqmumVTWrR2u+9RXzifsyBE3DmLxkXlZqeTcVsj1XLJoYl2CZw+nKv3SZ2SMz7snhyVqMNKktdQjEk2ZHMze3hGcE+1nPHGL/D6+CGJ1jeMD0tJqbGNLdOI3xOS1p+PktvCWlhr6JU660ZsEPQuh/4u6AdHB8AwgaHPYGfaPkolb1n9zXCMy1PTdcfQiSUFyE0slgDt7lCHXoWmtph2Mesffs8sXMjr+JypIqUUVdgdgu4+BSdNRfDyIcj/l5MO44l47nOGTLFY3wMuIx6waCdZzQpGJQegxs59yYRJGEw6+3HefWpKRROOQj1nFSkzTiybPxuOMITCE5/cY4uAV9nFdJxgm0XZ30+u0NdGsCGj3g/3DEI9YmSn5rhDRmbS1wk54tMTqxXklLaxNjw+s9fTKQ9osI+r3U9q4B+13x1AVRc07mdocrHm3QpMnp2mdeZVSpWglFx3x5RSc8r/Ido0KodLC9WLR2hO+3drhn6R4iaP6Drz5Z2pF4aCtXFU7oM31/4iH9h3ISlFE5Hfucm8IVR726IUGW3h0tyCV3oDhjDppNuVJ4/SL0N+Z6MhiFaNnQuvWvxAsTOch1dCGH0Leh38uZklLpmfXthUMdFCVo/x0OzepRp6phCMWe222scsyHwZipin36rmJ5axlZUj1gCaAOAX9Pr7MGUHQchJEooivwsWRQlQRpcLKUVxnOQTR9H1qAuFEARODpLRbvwvLlUfua+YIyzKyNzLtOr99WZxiDwtOB/y6EWxQ0RGG8XPps2sBdhXOxWAzqdT1hyW9y055Wyur7Uhf5RUikeu7bjCPaDkHliciW/LchqBkDU2jjdYgapPV6oSEabvutzAFUFQFkppc73mqKnV7fyzM9keTDU/R76L8P1TcL/HLDsMCFFw3clB3nTToeO15mIdNxTjLXUXdei8qs9xHEcc9fDmkU/qG8rWa65JI4lpdWJRbOGmB/5PDNgFmNqF4huoP60mzcjTwCdqu8wRXKsD+kOSShFSMC5Xz75mCFqYQX7mepEJ1BupweC5RWMtilsCAnGQVxdwX3tOdcBFESiNvtcRCNdmRfTn+xqCjMVxRWnfxA8/X6qhwkaH+g73p9VY5aiqpRqQDf1ZNUmWsmpUrU6/pr5aBNSf0Fg1LvtIHys2YuAMdTv8GsQ36/VT7YVs3Dwur1uqOEwoBSMujRX+APGw6jdPVl6NPGDXJmd/5larhn01/IDmnnTi0H+R2Eh1u6uV4Jse/m9nnMgq/SGdwR52MWRGoikqpWP9RFAbPSMzHYmC8WG5+N6OzthUsoz74teLa6ggAQ2NoP10QmkfFWp3h7oAACH/V6xrezIkLImpLaxF41xQlKR+pzsdDEJibKz8XCpjcxI/u5WFhkJ+aZX4uFJj5lD/i5WBj6E1PVN8noN6NYa10fU7w+UDLSm/YtD3G1VP7m7em231oWQPR1MLEWCYLw4ILIWJBVWLfBoiQGuh72IPs2gjHwYkqNRAw9ysll/cCSH+xJBSU0m32/fu5z6O4LxhYM2kCVAxP88SpKHtkdnYRVCd1xcDNhIzv9VZS0DosF8ymvomRvt1gkn/JyzIOqpMN9Owm/j9KLC5inPCp5XshGaylt9X4PFYqdGV1WSKswTZIGTvldufZz+4KU0tSflWBVqnbPelOp1Y2kzzGFQEkb+mbkveM+FfBPl15bBx1B2Wj2qGjigV/iZtSYZXhWcff3+qA2lic19HWeq2RnS2tr2zCfDlbeR6o4yXvi5Or4RQrqwKAbCVwkg1N4/ZSIYCjgDqR2U73e2hmA0b9lJpRRxNutpVTZV+2VmtL96nFmzQ42/MGqNs3h1UsACyAPnbAPzHnuYJlF8VZ4Eq1qj2e3o/YwSyqLLyvnFwfKTo+smgtQ67RJnvqW69AByM+LbxFL14wOpBq7mxLpsHQAzxKBameEQvuGWWM1+kfKE4tWfuA82LI8gZ6dgR7M2dnZma0d0zs778v085zSzIVMvMglRjIxyiXGMjHOJSYyMcklfpOJ33KJTZmYU9lxdi7pg7Mz5wHZeuA8QEwRBb7F9RnZvLMnrWbHaUruiTAR4YIqfgFGebIfBa8+vJUY2HqQW6k5VQU7Pff+XlXhkiUnH7sv0/H4Ewtil2w5285WFLg65zUHjhfZapVyYJwuIVvOx1zybzyNBaZ7+YbCKE1YVc4HNuTRSOY0nC2rxngcCp2502J7pJHwl+GMjdw9/TjapQdky/ns2FxJUNmXHnQq5Ajmob5xD+EOvlmk75H78Ju4b7hNNLUOAFc4OQjylh95lmtR8cQIxvRpfuqDr6yM6xIHt/fkr1qLMwoFmkEhMyLPjbSWSCHYkohFlQxdbfZgSvSKZbdb8iILAlAPMus28JvtQREBl09B2hO9Qb+fUW34U+1YqvTEshkjMBQYWLQJD2fSHgCqCy9ce60/xkEkwBZ+hSiy8oX3178mUA2gId4RCZs4/SXMzajoy9FBKz8yQPC2aawl7wTJsp6RgQiZcU+QUAzC2cVRINggjMKkgpeFIuvZRWHqUNjpu2RJdXUZLTnn9XdtC7I8Pl0lL3Mc2L2b2f7zpVS9yoyiZxc+V75OfaGXTRXLpls5YN+eLL2rjh6in5/jnfUkXFR7ypfjBpFF9bLIulgFImGoroAnmu3/TGmjVWA2nhMTjANsSGobDTNqMI8BCjwF2bhDHfXRp7pDWsnuseARRGA2vlwpQwP0CMZTECICf9AQvM0uKdLUxLq0LqzdX0mE9PqS/FCUBxjF9QYZZ7CCGpHrQgx/Lw38YaC+R1Vkc8zkibPmnQZu/p6RTbbtG1eeOPNhy7WspocpCAHnEp1y++lgRmxocFjJjvOkG8bDdBzETx2vohsotKQ3weRdEMYrYjYgcVclqVQEGifSy3co3aAv4dWpuIENweME440rIv5OXKOo+wpxgSJ6nWwkpDavFRnp7drSdbZwyCEVWkGa1hyy5RDitAut47WoW+7ZFdXa6qVxBR0F1iqYI7Xzr7PR1uaOjJuI3Et8AhKS77tf6Fo9obPOSwotHQdOpirn4vJanJsthzieHfc93zwQXFnb0BCkuM6WG4o3wZtcU53QfRO8IZ7azjDA4UMH+TZfB5N8kxAQFsYl7y9wDeOKqorA2bZqAp+7WADeVHeuhSxWvRQo+dpu7TQ7znYztzKtZmFtPrAkP48PLCnPQ4NueTpaWn3HaFX3laM1T5t8y4bhnzWePfoMx2HVe1nPWOOHHHRApeLdv2G9glN1PzpzZ8uCeQnLOUheOlox4KZSvsFLL4dK9KaYBzDUyyo8mpj4m0AY2jRNUdBt5iUz7knTuGKF09j/8//4/0Lf/+f/8f+rKQ01UQuUU1F+gfIb1VMtELUwEbWLMBaJQUFtwNMs/MZGNVhK6Mcg57ygXuIMbkmPbgP/Mmjbb4pQx2dRCFSUNJdKhBhfQRgWNJwqCLD1NXfLNWl4z7p7VXVpes/a+9W1wcHCveofrKoPTsTv1cLh6hbo7J5tPFzXBu3es5VH61uh1/ds5/Fd7dDj+0JU8+6m6NHdjWn8lp1KdSJf5s5XLeHqYGR+v2oRj7Yj0HcPvzHjKTjiBce5LHJy76NJOGGVvEr7MGWBRVYwAYjGh9+q8KF6qx+HXy1W1ga8dEHmvbEBur9V2qzuhq0xVa+70m2iVkEVeW8lBc8HTfRmWUjMHtsYHVJj3/PAr3JuI5XCO/JPI1RyWM/561/1t2OzUXCd89yPEv9XmS9bzvsDd5oJy1RG23AupzprFY94WniRyA6M7LXGZZO0spTpaMOf9s6DfsavwEqQBL5YVvQsXQzlq6wuLLssDcuMpPIikrlebRyKJAN1AKYaj2thopRPchA9e/ZjXCjYBZf71QwLow2F8uTCixf+jpw+mXO/mClDHPelTHFpaVCVGCPmbc9lNKBBFSVwHkaj/INEXYv6XeeWj60+qtzGMMS6aV8E/sC+aWev1HquWrtQqaC1N1KlhHbX2qWZBfu/s3ym5/ssojUhGX3Wno79tF7VBmy4+yJwiwXkrCi+XTVDaBr4+YOfAz+aHwItLrHEPrMVzJzf42BinOLZ7BvIuIt5Y7VYukygCDqsDPWDnI1qukeHLOn6Hgyrp4J8EndVvge/R2A4T2ymwMuZqhWx2DYzuETw4HwNytqkBTw8DSqfDR3TsifLZ2ekG/hf7TMyBPdfL3kst3XVxSmZFkUOb1txicMMCZbZwiHwgVU+soJtZZQNi93b76ygIjzI/FHObwg3ouR9PcvWsThdB2s7GbapYUJhldd0GdICL+k68J9pXtKrjHYodZwE4dih14Hboq2dJrE5R0jBrGbBl6AfylvPmqQ2ZoGQ7rx1ZYe083g2259mX6PcbuAi9UTtTPoqcC2ySKOJN1VIXRF7H3kOjJRezWIBEtgOxjvVi/W8qhXQmjSPRGWspAHsE3eFUsKGEh4Hg2DT3HFQpX4d88nbuLCjb4A3+jzI9K7R0VcFqWe/eKHX7Ra1NQNfBv5R7jRB9TerxSrZ1YPqk0069YXyqL/Ras9MLM3FYvCkdNrkMby2iimKMmeI0JuBb6zB0zLgdK59yPXca+t4DvoU5COEpuDA/5oWmrsmHfCv5oGGAZ1ZWqbden36xG92chKLlHgKvl6Do+mbYOY26ZTQbF1SGupjuVm1+dkCmuXDeJRGWZWbLoTVbA+F29m+fA/8zQA39bcq+h0TrK39HmSEh729fwv834KcuYNtrS0VzO7kSrQLHI2c8wuwwNYZz8RtNFyZW+08o1R/RTF1bH+tWvMw+sa/srznVliRrVaOCivcARBq0hxQVAPOFie0QmfW4IcCkvC+2Bv4K1X0803ghmTrQeah+ir4Bk9FGYq7Bspso5rzYIuDTD5PHCZj/1fAtg46OncsfDuK+eQ4EMnvV+GYlYxF8irJ9boSM7SJrXB8HbhNGm61qKJ5xbiaAnpe7koTQ1KoFbMkgDcb4Acj7zJ0Uq76j0m77ld1HSWUHx8KOe8W7t234x+QmskhSPwvs8ZgN6KIrBXdyULV7Qt/GrhqKrSwD2TltqwW3a0ZhWxnzTR1R8oWhlBRbK1AQRaA9y6hoFBRoBU+SMerxIJ9Wmib5n5l3E19rICd7MiL9Nu4AudejMPJCpRbsAWUF+T9MEUWnaXXhIDzaa/V93meq5PmEcFg7H8bu2baWraWvSLH/t+CauV2SZolY9dxCE3H7gClW/QWE4hblACGP/z8lAok+pFJrSJGkyRr/mbdYxDffLjV6l03Lr7rAvtZd5N71YUVj7oPK1Daa60ltkY8/zqY/Bi2en2f11pRMP/633mlrT7QBv3AwX4tn3D4yh3fT6R+E0wc6sC/feudMZjd5J5+K87rB31eiVXXKObdTwDLiwLYjDTwstPI13JeMoYpX8UwJQXBl5ZIednL4y7lAFQJEG6FUkDJTFmvwdJ6e30d+7OxfueOKyidIOft8x7GF7BpnapEeFpXCuyCSQf+cXl1fqfSxDckLneBMPam4xzmqeK4KiF2pkzQ5kAGKuOs1HeFL2zHU3SQaUtMdYh9aVaWtsm0NzW6EqgkiqoSWZFBvgg3RTQHArQpvsJDGAlwoMSFeVG9rdqEcXiRvLGG73+Xiipt62aoAsIc/HTHslP7qUruR122SP4+eDb238rgAK+q7i0Yru2Ualz9VHg39l9p2Hs99t+N3XJTEbda2hAW5H6s6jkYT4OcwVrFwVxmI3gz9j+OVVA5nfZ+7MeB+2bsZk/usf967L4fmzdz1RbBw60qOEmNZ7ebCC7Ya7vcU3jfPzV2Fvyp6HBPLOUe26XbJvbdhsU7ChX46pL6xFD1UaiXCbl963QJjysLMRTS0qlpbkBv0ExMQ5lpaFqvTyHgnuZMICegalEm43T4NXdxfB27z4NM9UexBMb+kQaEzcptjW7fBULkaaFu4B6PaZO+HBsDUfTi6lYwD7jfpKERG7X5k1CbhfL+CuRszOd4zlA0432EIxYl4TAYF67Ie7raMNVXyKNraRTEt690qTucsXTypT1lBLgsXgI6XetTn459ayCYdDIG9NKiSLixgJ6OXaf0RHayg/i9onzuXW6V/X3sb47dnl3UKkVPxvT7uC+X9+/jCs5UpUPHWtiw0i24+m3s/13P6m8Vo1QGONnwfq3qc3rFopIPgpB04Prxwqy3TxU9aDtrU+ifY//XsftpTH8buzu9xi9bnX9tzpcuWfTO+mdn20pnf7PuKCD7PLasnYEfig7UskjjpdB44JQp52VJDkEreVhytS+OVu7i1PliCZGFHrQGkn+M/eIOPBuP9Zps5Ado2awbr2rKSYSO52gCO/LyqF5FkzSRaiZCjiniOK4vUEVGKjZi2i9gEi67oZ/hboP1cPCbU+39DlfMITQ/zA8siIdXajWlco1bdtG1WFSsZSFRQlHVAousj/L6wkwCWM9LBjCIMYMkOrccVvxtLJ04/hP+UucyY0r/NnZTKafAWmwIwLdH/zEmNB7C+3LXLMCz8djR8CeG/u9jV5G69iNblySdeOixIQ3F7xgNRfgu8Z/+k7u3gbvzr2kY7RDacybjIAFVcKdPf+WEXrIEPQUM+dgX/lP0+Sf7k8GeTt4fZ1y92oux63gOeIZWdZQLQHW6lkvKh76Lx8hqmD4fEwqNhxfur7xxHvOpYHFm0KX7s6pYfb4HBApilHCs3Tju/KsXbH9/tv25r9UWOUZ0EL8lyeQkljNZ26pzlSQT1Rp+C/yxpGByMWYfbkXCbsAToq8c0Btf2b2dv+zuwVn/D4fu/GV3Hz438fMQPuv42YVPCp/7aE3zV6dPU/84cDda1PnK2AQ60aPCEDADyDU7Rx3zCbntMUtqU3/EwBb05P0r7YIAkoXfbIsnRiYutvxdMvWnBq+GPdGnYU9stfqynZmPS+nt7MCUpw2Rnkuwdpv0IWmA24cpi8F4NXuIzyBEkO/IGr2H/Y5d7RHx7J8PCR24YDitSrf6WPnL5nzaa/aX3ubcLt0iyy+Epp2p/0WNamdzPl1+8dwZBIaZ+gOXdKboY3i6/EJU2xtyQGIInoMB7TrAeHe8s7PinFp0j2BZ6LyR8JPJRE9uKz8OQugUwxP8FoirHARlVmfOf5hjDPHhQcJjtQGvoP9wlhR+Tu5qRbozNy5+OLJb3dC3G2zCM4OGS5rG48FwOsIDXXmKaqZPV1olf4R3/JjLOO2Nq5hdGDzi/8obw+nIJZT7L4PsTPT0WvYbRgWbAwoTWxlkuqQDRTz02ExjJvj4GysdEo05ONVfgjqqMACe0krRKbBpYYZTZDNz8BUEPG5PUImOPU6vAnEFbvtDvW5ii2+lmUFJpi+6pCKIwgRMjRXq4UPXEIeuvTO9nbMOnNK/7L106M5/4OfuntM3e2TNHvcoLNrwwYxo6LNIH9CweEB57oDaTYBUj3J1QPVElsQtYiJUw7RcsmbY2Z61jY5r2Xhs1GGZ9f3l4AiG33Nyac8hrQ9qOTSN7FXEFexUDMyz26fzCwa+2cMhfc/EhEeCecGQ/saCEYuFN4bkP1ImEm84pC95fPM8SAJvMqTQrHc1pEdjfu6Nhks/A+V2wUptMA6/skHC5S9JVLmZ7PWpId0xMpX51c7bVVgRv8Gqk7fDrS2SomFnL+xn/lVM52N2GQxvB5KGUWppIat0YmM2PNTBflLkJ7TTBjP+cXz7x2Kx0ZJW7RfhZSrzN5rKqw842cTI6Y1pHCYqj+ggG7kBwXKk4NcFXaDZxsua8ClJrKWHzJU6dKD+U+FitbOxwVd4afVydJbNx0UuxaiwdSW2j9xkiEU9uOJjpv30lPyDG84A6iKWmdGmn8EYPJ/bjBhF0Hbgr7du7qJXUCHsLxaoXKR/O7Zs0FiFI/uJzvwNJe+Goys5TaERlm64M9/N+N7SORjeV1IkYnjjG9yOLQWuhKBh0s48MIPgmg58sbwIo2A8vkVKbqbJ9bAhV2qx0F8uMSUhdJWigQfLpWF1Scshe1XTSKSTCY8TNiqvcKbqrrkidnj0jDAnOQ5XxUFWAfV+yGFQW7t3kLGqCi5U8FotuvWVVxD4wPDxgYNOKmz9HsuYQoV6z9xLyCo7/3I73km4eEWixO14jxatw8XeLnE7nvL2QDrYiKZSQ3Ln5MuLHvEoW+wYmrF1wnLvL4zuWwuShN1MUMt4xIT2nIsKxrodczgbZ2fRq6jG4xGLocI5M2qZFGsobU1cXPWqU1Lo0qlwiZJMN9Ddl3XmYfsGgHZy7sjXHTl7t3zfl+kdR+BfxzMOzI2zKYvqwdunXneLSX44JLSqgrqZ8lVUoj9eUUndYflKKtEfrqwk78NiLZnqByuq6UsyX02n+pNV1cJxoSdI8a9WFIdrN18cUvzRUJ7IwSDRsJYTmBcfzyAHHwyC4ZAJUYgsm2fFYBRjxNtW0yZ6t7MVYkOTOPwWJOxXlqxqJ9cZ5jkxC0Yy0reqXrsI2XjkEBp2DPb1TNDfrJtno9Fdw+1UjTYYjTDOuAhumOnzhoG1V+2Gx0yq7/MIdKU9+ybmFMIpf2AJOPbEkHMwLhkNOLcAH0oLQNN1SwBkAoMjXVyBtJOqFaCh3Re8MxI+kPi6SiXiXqFrcG1GEqFVNWL5oWmE4jRkU7QN2MhTJwDWkAdxjYZ5LwL0cqivBgjJnFkHPGdiGIeThMf0fFi+ParcE9LBirYkvhH0RUVDeZuEjDDl02iQEXMD7X4v42PnHe7R0B8AOa3cs4Z2APbzodkhaT7MJTmAvyqE46EYaKrQlpQpBov2ouKG/uUQ8+1wkmFHfnqhoSxJieCFmP4Z59LYeviu7ezP8u5iYXyiY4P6Qo8LvBPgWwy9o5qobXnLTxB0aGiUZY0NKLrMgFDJHfPlQnAT01QZ7qos7Ozml1kXxrjtPs0hFljV2ly5FvOMw7Hlfdo0hrUVrS4WaypqI9QfrWc5L66YhXHAgGOX4VfsU26T4dpquq2rNaQWhpudMssvKdRcaktwxXJQJ69TcZpsj5V0AOTuFFhEM18CoSGwocUuOKJyBakgUtob7sB3u/51nuhuQ4vaL1VX0t3tsHcMb8Hj/jIjtKfQ86xAaA80oX1tCO3rSkJ7qgjt2TJzp2weMagHP6Rvh/R66M/zK+JttGh4A+FmzsfM22hZXk6mwfir/bIpSpie7loxkE1mb7ffsX9410Pt6LTXp2qW4Y/uS9ff2AgbZqDt4ssOxir9OaB6r9/t5FFMCEr8xxDy/ghGsOnPIz5i3jWFPwNPyKhfWeyjlKhAg17mBwFCTMlE4Q3oV3brZd5TIS8U7zlPPEBbOpmO2Tc29szPofI5oLyZ0lAcs+AC9iDiifxswic2tAH5aOKHmySOA/mVTkZBYkXJk78zZdrSPrUq9wkYqPav9mZDzmCxcDcbcqINWJ7eJuxB3xeEbmKCLyjEtTmCwL1LKiPDe5ZzHEyA8civWlVrVGQtrA5wezHMD4V0cj8boIY6ZC42SVvEu2eHCF6ofHnOLnhsdS1/Q9fHDfntiyUNLhJmBcLDn7IMfkKRid3KRDcxkfUnXFjhbeGXyuYigXyRcCuqLvxyESG0lvR8zIdfrfHBT5fMYSLLJboGMsaAm+0CSAxEAoBRvF3NLSp3M7tJ1e85Lpio1+Vv8AknvxAwIMuf6boAEhKI/SaWg2xNc1iy9jZ/oo9Rm29tQWTNAUjadQcCetWnw4csZeUlo6yZTuDsyiHAmNubDXVu/A1dBGEUD5BMg69lfj1kZGdfWbBuKqjOq0acwvRltezLPSVUw0W9rr8KzdCNo2xHfmjl6/WNbBHkaT5pDySNtkloxSS+w4J8guvqc9V1xRgNmb4ytWNN1aV7onaLWNjhpHPi9forrrfvvsuYH7LCBffdXHABo2Pml1hxjKnIhruEDpk/ZmARM8GPFvAtcX4TBks7kes6KS5qb8Kgipz0iPkK3WeZpN2t118o+lYmQ/l6fcMmYu0Mq7I/YmrrRqwh8ay/kdG3AdMrZdG3AdMEbsAypvDWkBUOwXZLNop4HM7I1pBRefJxplwkeqowgLQxgcNv0QWfYIs/F+iC74YBxzIOHKukDD4pyuDzcjlQjSvoVNirXlcfRTjeBEoNv5GCeDWs1F39GAffWCx+5v7p2D+862F7w47cEAgxgBFJq6+MDZhXZuArnFWoBzQ0UAtq2Bp4pQgMyQDMJNTw0RMguee6zPs6rEp9a6eCjxRVloqq9LdDys2aFw3D5TyLLIF6faVEIHsxgng3XFMS3t5i6erp0d4cSBbnkiWOCm5qh+nLvylt/ki2EiCB4PW65RnTEkKgaAP2R59CTlN0u1pq6u2QFMg/CPmFX/nHX1r9+EuNe2ZQ2uylJoIIxIGXk7wKRGmSks3yf71JbrTy09xommmKir2UfBdDtFdPEpgD0iusHcNlu4VsgrkVskXPM6QDsli4EK/Fn4NOhJ8L3dKDwmAzkG0BamwXxpbX1sb3RfX4BJ1bj5ImLb5Z7rXgS2IGo0Kulgak0m0DxapzvXaklUMhhFaWNyNSHg6KA4qNIYLewFaFbh/YNHQq2/d4O2fwo+aXc1KGOZIwqtdDiCbh27rpqbICpKkZLDyOygcKU5VNmwCWy/qeyVxF5cd8qIy9CNML3DTlXjD1Z3uByoVe0NNQqRdMtXqhHP6pOj5vh5k4Pc+wqNwQUr1PmqNRHEG60sfnQFLNYGxqO2nmvUG/vYp7thq3GMpTBbuY+vZ7GQxM22odU0IVm3CqTDZ+6vXepNdAph5XkalHdNOfuekKsrPru0f+ZoHo7Bqi89Q/UlyVae+076tl7J32bSLqGgZwXCCiupqI2jQ01GYlCXWtSIzjZeavGikpytXf6dII9VeAwXLZJ4bUAOuXr0NkCwKb/nUwoW9zP3GhE1W62uAGSr8aKp1eXRQ4YasEDaZCSKAY6LZRU0+sEwOYmimBgtJJdlb3KhD36RPu31yfN8HkPvVugkm+njr096mriubrSxx7v+nKssUZI+a7c1saCkFaFRGZ3V1R4TyrIoL13RUVGpMaoO+Gvq6vbSqGvgO67aHU39q5FjyiNTvlNrgZO/TN0Jfai97OjpgGl5csboTcoe+N3OIiZuw7c+dpPAadrSWhx0O/p4NAhUw4ffqylPIHpCA+CqE3AWGjgji4YQmL4UesJJbwLdgwBRP157ni4C2URyxKxI4YXrGboJBot2Al53qx21CdfIC2cIh/hyHKpnfYLABXKBD3KmE3wvzuty0RycuYsfEtxEtBX+VGLa8nbNag/XsXlDaFch24kynwHg8zN/Tk6XarXt+Gy/9llhySxeKP7GcKpRaLvw8bgt8wDK2emkwBmXZMueBc8HGahBe5WNtywL2QgtEY8tMSVCSkA19p8HS45zh0qn6GndBzHKnx2UamvVKOhUj1M1+p3rno9AkZ+1o7UOe8GdIBodz8FnSaaYm9GVLHASWUaUMkQZzI+EewSB3ucUuFsZVFxe982ZzPlv+xOU+XX7yZjv479Hf+5YI6b3/LO9s521nAP2QnVJGk//bh7Zv37CIf9vdvfK6q/20IrOJf8yj6H0O/J/ynihrCkLrNfr1uQS2m7UGaAhWVdNCn9604lAFLdL2sqf8/e+/C3ratJAz/FVvHn5fcIop9uu95z1JFvInjNGmdS+MkbeJqGVqCbDgSqRKkZUXm+9u/Z3AHCcqSL23Pbs/znMYigMEAGAwGg7mIL//3DiAxBWp7WxqB8d//2Qaa2x48yYbzNuD/0UDzH3cCiylgLqL/9AO39jj/+PcGVv+xWsNvGw3/z/o9Mms4Fu7/WB8FZq2gBen/roHTzVZrPVC3W6xViOr/3hTUSsTfR6TE4oqwlZNRB03H5SlNIy0eaMveGJcgQCm7RBD/sEr4xoPRC75oHwwzuDwz/Ognxaoz/CgTwZBNoD9xSC9OEkaeZoPoshL98BRGl4UyFmv494SWHGCztYCPI2q6mQRQ68FbMgo7aMHrMKQ7RaNyPAbzW3BkUm7AOCcjFovDgUEynn1Q4R7i/eNdyP0mjoMnaAt94HL9E3x5dXW+Jw8cQkfz4BxdhiIDpZDJjfgCMbYEvhlaTMXJFB0iCy+OzRMHMScTgWz0eJyTZDh/kb5JijMj1AFqMf5+wGXeMuzFV1dBLLg7F2YhWKoa6Mx4NfEr3bvsB5al+rjU4aRqmRw6HXmEgzsB4wIrYYNkSqzW77IvJA3NoV99rgIaoktwYABnke/YlAwmyfQBjPhRB84zVn1G+5bLxcNkPH49+vXhr8Nvfn3ILcs7OjESUMi7nPALQABuy3JBgWQyjPH59racphfs8Rv+OBbsI9upEebpEHc6ciqeYCpJleFHwSGgeVg93Fr4BwbP+p9RfHzY396G/5qmjV4ZugyvrhqfLxHfDcKC/4lBy/I0DeLj/T7m/xWu2fKt9jIMq+AQPUEzVHKbn27JiIrurlP8W7KZTxJ6osUwkTeWm+9+zfTkU7hnace1J3vBB3xuT4IwHT4M0ZbYtfBUpO/hW/DcgBsb9PN+Vo6H3J1NSkMbORmRnKQQ3VIQgH9LNLdqGEbBFiaXRZ4MCkgQ+TQbBE8QR0hIblvdOOYcb0//FW11xXYEfiQt7q7fj83OoXu0ZavlOQSV8ZRPI3/SpSHa6iuv0pZ1QF+xNe8ztIUWW7z395Ugju3tJ5sYX2ro6GumtkAwQ5p/PqnCPmdHTphsHmGCO+A1+EVonLGYVjNl/Ban7oGB9EgJZJ5lHtcf8f+GoP3n8/Uue5wOCOPJOVUeZpdZWTUsB+asa6UlAetiW5bHO1dXrqWXOUkaMDk+oHMIyi5/LkRfwRyyZV+o/o26xDLhr6oKFaW69iWM0dM0ICVaDLPBfjI4I9EPA2RxejQYkyQXJZZuS33jr0TqLYjtyff6HwbHzM0c+8PAXN65U41dE1yukb2TkCZacLR6mg0QP6sic2whd2cgbrkLIAyS+pM1A/xbwNDiTHqZLB4PBmRaRC8HFRpnyfBoSgbR5k4VdoszknJUWbcQqirr2yTtQm1+4lcKF9RkIKhM/Qy2ClFe4qLs1a9z/HTVb2PPByrdgvidWY9jLut5l1MyBDtOxXmSjZyMRZjo928P0QY4SWQlN8sGoSHZUEdxlyt+o41/Awb1b/oz/5BV//ZZM1IJWST31sZIxmRHMxqOPneKUk2pyNiUa7tm83f3JBvO4c5X+9QdwEPlRvO7spH7bOzl0NqsmAIr7na7GRokJSMRPC5YSntLOtLZyr7pdELrQm2q15i0w3k4fXP5cXt7k3MEmaYVjPyNEy1TnF7kt3MtaeUmlQ27+qIdooXi+4B+fbeb+pIFqTUTWypgFj2b3jlF2w+Joqo+a8WAJKRszxlSBrf6OppZGAW8ES7Krr0nZe8ZfiTLM3ioCBH/0Zxed149Z7XIRqkMZL/bNeE5RKMSjiEu54tJpiNLsd66r5p0pM5QfqCboHvkkgJm6cYwG/DHHeusZ1Xo8RLzjsHv8+9/Ez+4nJJBQYb6KoA2TrNiI9nofKMbyn47D80NK2DaexT8WVGng2Wub60wetgReto29mUtT1uV9rEo1tFzPRSFv/4buP2x4DMWG19Kyv9vV7pPWp+4x/T/A8lb6L87zp5sxWlJp8fHnQ6ye+U9OAkOf33Iv+12wn7fdqiU18VdFVlMrvyTrEyHST7fP0ty8IzchKzTYimurjp/43/06hcfW7YW54AHWmDn/1a7gnUHZ0n+uNBxq0KeFUrgBhdZK76IlSMThJNNqjCjCjMK3tl/48lT5KhSdaPmN5fGlRrF0u847k4IuKGIf7tc3HMuxRsr3LAt77DNhkOf5pu1LcERM1dkee8zOlnWVXIupohVwtF9cxft41jZ8o6CmSuq7G9vB/t4n3OlMET7GA6O/QrtmPQrXMbZr6W+7UkZZ7/LsVKxkK03z3Plvmek4wV0YT2EKpdpmIXSFig5YVyGlmjHff16LRNzIEKAM+BQ7iRJp62WOZIosrDSyE5IfkqeEjINZshECIyxdWrwWDLksRRp3pLRGzichAcsWsircURBuoNode/z8bMsf5UNheRRwlahtq7kmB/V3W6XmaswKpL8lBQ/wuNkjI+llqfDia3Tr8CsYaGIzUz5G37wNd6ZuWHRScJtMTkBa9d2/u78hcxhM9SdFM2btKZH/RjOI4zJG63+CAqC5i1Jg0El996WXp3G9ztGcCeueOgBeAiztw5a+CeRPx7UppCixiSqtSirsCdXuNvtxoLSu1LJtr1tUSq/+BnL6hnSWj04vb1Uss+B8ZW5utI1fKDE6nFAVYWY4jf2e49Hj+dx+TUJQo3s4spTPCgbzxNhz+aM724tKZRdmCAUh5V21G+84+Nd1cUlhud8fkmFd/yuDM2Ey64ewMtkkGcB5NIxApsdSEU5x5n7qrUZM/1gXddk+D5DikyU6Sk0D3fLVKEweiYGK9ECjsG4rMotfM2YJtmQjJWDkxhXzPPi3WRgvgHEPIoVf9/ZNyqxheXzqRMn5FlWYKk0iIucEGmPB9shYFdXiyqstCWVOi55S/A1UactuEoDo9W+vVxAdMAKo10OVneMMgT8V2dOrD0WLgZndDzMSRrFEJEiPi77e20woZC/fkfwV+uIoEYlrfwgOUctuqERfjWO8nQDAuZrjnVJC2ELd2gIhZ6BSlIOAYk4z5zoZaAC2VO5vV0KY0aujansec1MfD+T0Oe7XaGU07//vqdxipi9KW1tDVWHoSVHCj27nuWy0vFSNiFKw/Y2eDfAX63zCYjw0HJQq1LxjbOssCMjtDW2H+/b1tVgtwC+huwMJm1tahHJxApC3IpFhZg11Zjuwa6l1ifO5UWDKpJ/IEfdpFfUOgwzKzSLqQC2+84v3IYwRVR2xaSRloAmz5g4zbKpiE2jtjWoW14m0wUrkoIO4Lr5hrMmkAGdjNxT/bmy6gqh4vUI5BxnGVhXZklVoX4Nu3APAVhoIZDAo/iQnJSnh9zFp0PTUaY4JYuO+/LP55QVWT6PFhUieZ7lvAgsG2EpRT1xAR5K7OAToEygydR8k5MIXy19fwSs0mJ2iJ1ls6eAFngKJZxhKggCIWDAUYeJieygMT0R2TRY5I7UdbSDcYfoa4YW1stXBGsDJVUIXWWzl6RIVIfgvMLkzgCrJP5HfEqKLk9bJACqUjkCU0l+aNQ9S9jbMpX1xI9GHVAsTclQUIaSsMQPfknVlUQNq32oyICrKVQcQglWroWQRr4Kr2oVyRymM2ypp1TTqq78raqL/aDkXRtCWHH6ChjsTmW3aUgO7mTb20Ct2Zh0x9lpwKtVFyQ/yRjRzTryA/fDdEG4zT8fg4aiv7Gx8Vk0rcwkWQchD6D0lLJBTic05bnPaMUh89OilJYj+nZwbC0AEF9/L4gxQyVuloSRM/HgFV9iFjU046UVApHT549kDiLtUCGrz2x2VJ7Y8dYaN0M4X8gFyef6tODvP3BqhXUvw38/VUEvud23kYEs+eeYIdoHvwSHlB4PhxRUqy/lxg+1N06onBq++5Z8q++wc3Cq/3dlvRUoNknFTYGaw8DUyUTCRToK7OnK9NUXXhK1S8o5nh3vwwvuzEQPswS+ED3B9m/xgLiPMT7k71v1zQ6PfpmQ1/uWKLgPaUHtU8RgA1lUJAJSmN8P0Qdso34eovdYQgUpa+vq6gOsvaf39zxf9fs+j11UmzvIsXgZgleWGIG4nGlRU1NIQHmeuu1tiIkTcCD6HAnO0T46RKW4VgmXNA6HR6Iz8wrKslrLDPGK/G0S2vJY9y6nLQUHikO0MMdWxHg0maurDFGmg62KraB/QtL7sALLWEn4bjh2lxF2R9ALaCgU11XUyJ5lud45On1iKCHbx5O47Ypt6x5bplldnQyyubcFz57NH0xkOjguor6xj037UqCYp462Uz/9Hctw5/Q9zviLsQH/tkz3szIt6pk5PP3LgTVQeycyMTjYeZr3jE9PdmzyoPRBKjMw1Uq8gM14LVjRdXdiN+rpJNLgrG/Up9neg90okw6QNeQad5uls+uZVtrH3q8w2chbolJyVO6xx8LF16ybZvkkGdOvRDFnV68G1u/NN2bejRCtlOpJRENQT7/u6eHc1MxxGHQchDqo8z3XjEOsIhnOgJ/KHSSeDXhLLWttb/PfRtxSmCDnvUMTukOotV2hWhoEnRrveOxhjgOHLQWlN/IK3RweMyodqWhSaPQ4AEV6/GS1JBL1HRbHvaGDvEDk7Rx5ph8UT1UTyKKZzFUgsr1dP5UlgmZaa/xe1RDaB/G3tlbK4BThEbzFpIhZ4K/gDC0acqq3iyoEHzDRVAvremkMRMxVKaCWctlkfQfXF9k87Pey73b2bDq0HmYB7kay4TSGiD8g16T/VmwUZyQnmx2Zwafeh/SNz9BuWPlIqH5RlP+aZzbNG/gsS4+ZrBKMwD9qn0jLEye6diZrtc/E3VCusn1s1RIQsaurHdRkgDxUY20l1WtH7bPOW8hPov0yz0lqddc4KzwHqL7RiMbyqzjavOdtC6uvnwi9tq6D7JtdG12vJDCwy6DyIT2pVTEXwSqWmiKjYxPKTXsnhZV1Tasfo3WDNMaVaboyytqrK6VbJW93rSe0PsLbZvwR6PF2wkqllQlcpTuSM25LTz1hM+HU63qEIKhn9Elyx6jLcNd0aOjeW6gZllAoWNMrVQUWc60cArBOniBT90VcO4RC1HxbBxM2dSrz6cPOr6sr2+DdKZKeP25r6UgE8/zNbug2OM76j3Z3mvKgxEWMuTZgfZTIS4GVoPNn8m/cKAOsiOAF+yzJhxs8+ibcMnZ3djZE3xt5mTIIVc9XKdvEuLkD5MHiF2HrbzPXSa292gB5hC2rGqcOW6WF7B+hsRPy0kjDcZxckkGpRDnu7ugMDWfW666XiXHnkzorfLCrTOWcC0ZoLnMlTHMGzwRdxT/C0OXUpXT5cL/W21RLpAm35fEyjRTT1yVIOdI3Dnv6/HCE3QwtHHkZHrCMN5+ZcSczXP0gW1Cu5f4q3iLmr1x5lcIGqDXhvrQqURwt8SInIxblJeJvqlFaIvNUFbESmUt3lJVWLKZJ8oU8M8YwKDPPlQvpVfEiLUg+IFOukEHKBsr+WlY4g1iuXbDt2s8JT/ORjNleR75edqIOSybkQZbTU5p29N0FP2LC1Suzjd7Q6h0jn/3cwGAQxa4FHRhu2XZFED9j7KpswWjaOLdqmTJNLugpkO7VVZBh/SuEiEWdtyQZFK+4kRuPyw+be1gOir3NgFtaSAF108r20LRH6ZY5Db2RfUVgTOeOAp+urgJfbREX06kNn66ugs1NbzhD4a66ydV+TVl6e9ubSguSJrgzyWG/HjUm1Pciqs24rQWA9MVcGQ9fwD8LoipqHyr46L64oQx3OohygmXllOQBj0yOqBS/hkmRYAhSNi4heJt73ENhZcxmnDLdQu8v9nY0+PY///mPt4SR/IIMwS6gEz3c+9tx/782t7b/LQj//RvUwx3XSQ7pdu/T3Gop0kHsPPjPXx904//X/2brIVXWlWZjiljoYGGTDGATi/EqMB2F8TF/Rtenggh31+jVjnnXs6u8NRV4hFuWjAQF1x61ZIz8d+SyOOCI5T1P1OGuQBp60xh93gEDKj3Tu/8I3awG1WdlFvT30G72/3Ez9VB5NnTCyv7bimk1B0ap5d2Fuk1g5mKo6C8L92pzLL7zx2l9GUEAl0QUkcvpGAK6lUhYcgFDEeZkVDkQmQTrGnsORU4UpEIONe6oI7xtxskJGdutP3e3FqsAgGDFnzmISVLk9HINDPTrZcb10aCP5E6OwPE6VQ8mHHOL1Ag+Voj/HXLHSeiPZ2HhvekLXLn3eZs3+xx1UKe3EhZ8GJQLjB02TQbkKeHSFhk2gSvY64D+LKxqRQfApe4a/pWEL5pUPNlgnWtmdSKTeqJ1qEzZDLmK7l4L8c0aj9OqvTMSniIwVrIlTICYBQSzUMHIthblXgdD2hz4Pas+V4YEGlR72267n6NOt9Zvt9mvRerb22Vr3wZu73PU6Qm4eGvhQ6X67AXvge0YpjTGVbrjotA/hen8LDbUZxhYheojMpvpbiaSz962WLVrl9Ki3BqZvoG3NxBjWilV0GfZzgVddFG5hN81qrZyts+KO7U1acynH40hIVMr8n57ZTNF5qypsYQMYj62CTnLZao9hkHe7goZkHtahFFDwwRNT0RwXUd0DBg+0nGGQf40jyR7PrEhjJQBRFLiBcwUUARymW/U+f/+vtNBDsOMOledCo1LvBiwC9GGXciKBf9r5z9FE8ar9uz4R5METCJ1EjlE+XRJE5lsPCa85jNeMSrFO//BZFqIJlGMGMkp3IL43er1FP4bzRAfIE1PwY01Q/u4IVtnV1eNEx98/MVL5zmme+L2rGU/sFDUmWf2VQwHuAce4nOpEtcG+vvb27FyEDs+RJ1Ovy9thkGM3Q+vrlxBeD+0au+LyjqwpjtLT+ZHzREHDO0j8YIIVraw6Y7lQC+7fFMi/VOeIuYDn1Ml4lm+k86tAbNQvZ4uxC7PrPPIAQF2Y5e9tZFfAawUB2pmrZdd6W4NdjXCztj+4oRyqIcf6Vi22edN0Pt7+1FtC+5rlQusFYi79kUkYKhzMs5OOmgBICIHkyrs9yvTm6Ss2wIUbRsEvr8H4M6D/bDfj1xS3w+3t/eV2QEsdL0tC0Peep/LOeeWVNrvRwJsc1ZuiMpeZ1KOC9rh8aLsTmVXLg7j8ri8uuoM2EWnr2rA7qpaeEobwUnvbONRz4nv6kqcEGiGja+N2jB7+vSII/0RMuGAVoabEzrkuqfuSZF1HRN8iTxLcVN2vARbfcF2DK95llrZ/Ro8bO/4eB84kK6bNWc4C/dmqp6QW1VtMX++AjHXSXkc80munYfxnitr8rYBxY+O9wMuZRyDVNH/bOMFBi39MIxm3rYMP6oP5JiJBhLBtlaftxb7PEfD1sJtW33uhw7dVjUZBo6U1/lPJRHP/GKKQaqSgKIMb+5ws3wtZJ2UdDx8RVhBhsIFB0ujR/xIZRTlicOnJB0GGchQtbWg4R6tS4wNoBxmyR16I2qrZyBLgA2fdovsxdFrdUB57hd0rxZ8l4Z298clivstKPC73TEIpf3PKBbY2H3DB0TrwX2ZY/x6nCHabx0iDUPkcWsCBbEcknEtzPZKk4zNHL5gfxLU3JJr19eFMY2C0WajutBBEePWViCj47iuPFNseC82+p4o1mjx9PahJhlIx1xZoTAF5yEyf07ALK0pV2eCZuo3oMCIIi5wcVtgCqGV6l4AfHhlaE7nuiKjtBwdpZyRNeQM8ZgJunmpET1WoUoeAMl0IAeYKdqXRe94EeAFWUo5u54mefHrQ8D5AejIlGqKhgZxyz+VDz/mZKDS+zQzsCynoWvXETj65k4YNpwr4jC03QOy0UasK7k6TmMfJvRYoA8NwEWgpym/BOs3aYClP2UmqYYHahy6ngUCauxCzZpQ9fVtAwxcuVwg5y6sAu5yNQl74l+uw8yQUFzjTIHiv5rcrgyVq5doDUKVUnjwycWZcqzc66gsrZ0OrH/serLVPRLjsGeTK3WNtVlXdBtwv9mZuZ1Uyh0lABtSFjrpRaUdI1fMa46+3bFYw+c94FX8OhsGM9QcMA1hosp8jMtvLpEeO995Hs9wvW9lsiy5b+UhrKrZKnlJunuLKrIUncyysvSRNA+UgNu61ZmgFYLGx6uDNjrhnvZ+hV8R4/Fc4Ka3aOFB4l1EuLTpZxS4btm+ZyVeZF8i1s2+IOBQfNbACJAVSVEy+V5aMvkbFL36G/zQDy3NOVRsBbI/4bKN/0Aso6srWO1OJ8SPHgYgpF9dTsZXEBrwilsu/3ryUIcZCPQuCWZ7IvRBxLon/CGDdU/4Rgx1aj39xAOXhJLXxgyV8g0AzUI7T7QVsyNnBIKGuQ9A29s8GE9mTFibl4urK6v4G/Ht0U64x6VnDheu9CY8Q8V1iL1SbmRUdrOTc5yZJ8tStBL5ypgJ+xmGVcLm6cA81J4VxTSG/+jnOt+jTcBwkGEWwjqDGCiXBeu/uPsGVKkfZlkYqjrb2454plfatZ9TnFWXw4HVuHFRblJgajhZadNvwJ15oyMCBGfd5gsgtMbJLIFneU8xPxKzsNfEpXEOZq3nIDcz711zDm5vB5LRtPaBmhXcnkRi3FKMJ8i6JSM5f4W9uuKRCUL4lo9RFiJZybPfS+6+wyer8TLKbdnNbDXKA4guXlr2d9ytV3r3s17TX660eMHV1WcFckN83aBsY2uh6lSfw56AlMkvWBUh9WU/GxL7qwIo6R9lFceom31xD6bbYMMa2DAvNibQBy6RtRXlrPCbJotzAkfARTJ+//YFT9nMg78jWou2VnJ2G1fAhbBKkZHJv8q9MortPBkiKKa6Fta2kJ0YzSQ9VGEeWpKkAR7v374I9+ShrNIwz5AHhq4dRrMKqZECr9kfU5IWJkG4zJmcISiMqPXcll1d0asrzaSqXo19STsdbgpCB0dFnhTk1BhkC/OdDIFTZ0Sb7j8lRFxueorEqOkFGs2Q6+4aXfpMC/a9pgXniH2hU2WJwVl/dAge+t5YR9ETxMRIwMJiC1b7A/ZRClx53+PmzML3r3jL2NKDpY8IaGcsgWozOcze6hAsC3BhoGU3JyO+nDwoEsStBv2l/KyDnmDX6uM9Wqw+LZY57LEE3O95LAQuTRZAy+uYhb66M6eutlQJxSHCFSOY2nW4kYtCZEsyOjsbAIhAtSAb0sUPpAXbKkfQGzNucGrjfqi0013mIUMvDTbIzUuULRRUhb1DngkJMMJfjdW6/GToYKvS/oQb8gyNoQZNIKlJkjORiRq0vDkb8FxTQFDmuP354SlFnbhj2Rtk0xdDqafvdNDi4u+vpyQXZk1DuJcnBT2hY1rMX3INrhAt6ajFvsUJByH+Dlg3MyDhydkOZ8JE2EH5kLG30qhciNZbFh1CDCRwvhGWp0I0vmZIVA+JyqBRPIRit8gOs5m2Voi5WYJB/PhXtvlff9v6//57+9+DMP4GHy9+7Ve96LtHV92He+jXX/+t03nQf3gqJlsHoaJXVzzQlBN3OOaXHWSJRQFcy/6OqlACMCX/HcThv8spM9Xh7vnvW+JzpV8I60PYWqw4uzyWJNy+r6cG62JiyLbG0oEpch8/BnOdoRL824CCKMhkW1u64dAYn5j7PQ8YQo1il8p4VuJMjK+uNo8VGSLDYvrmbiVJMw5D2Oo0LYkOARNbDMrqkkKXsYmpEB9Tq8vLlbu8bHS5j/l2u0RMBoDaDxfl8X5/D/4juNxlGMEPfHyp4hoyDB963NBWJUAKPRFaWDeOhaFdMraWDbd8v7pythFyfsEW2K+2Ftk3u/B6bNQhWmK5dPagFaZ8p9/LWjDJ2jBxgKHMwWS/EhYdeqX4qWCkQ55Ww/aY3Chh/bKwI4zxCG9Qihi+rJyY3ypYO//Nk9GUfZ4YB0nXDCqih8547ESDAJ5ZFZBalLpOioU1Is5ASXUJquxFbUA8X51Nf/AUwCtCdaMhFC5829viX5D3pE8f64JT4/a2+JcXiFgnrLulS0yRLIMfkGOXdyLHI50RL3k8NAiNqCN+uFsVpDH1ujwo8QLwgFxQXLjrIC7DcHdunn+ylTOYIvHJClSWVUiKjJGQfxh+1CpDVqgs8aBU10D2ekrSx29efLvDJVetBFhkU5ImU2rbiTUurNn29sP//vbX7s6v3eB4Z/fv3/YhU/6DfAA/+uGeypKfyWuUCi2xW1XI9Lx7i553f+0Ge9Hx7oP/7P86/PernXC1LjG3q9RD59YIFkIgak71Ukl8HvxdLRaE5XVRlpkaHJT/3t0Bcs0aeIQA/u5W2n95kGP5u317cINDekgD/o/OSjxV5DFsTMK3D3asaXDn8Pca1Lc7649qVOKhGtWkxDFY7s//bkWVStKh69S0vZ1V2lHk1NfkJCvOnDax60CvK8a8pgEtfORERAudYIonBfcWVNGbcTApQ96XwWle4jwJ7HA2FyV+OQ7mZWhnyRAxN4SWKpbXZDNt3nLsuYGIBEF2JjWnQGcO2vOkiJc1WRV5Cv3m06o7xzMWY6y/b6q/TVq+PYVbpDtEbeOv1LMmbpkqg6GV36kVmoqsVuKvSbCLTsvgokQnZSjyNh2UGEyAApKgD+OgI1p34CFAFkwSlCfBJJEPevBGcFTiX7LgtAx+HqNZGaLjjjURnX6ILmVfTuJxEBbjkvOzzYPSGDVv7vaclPWNtN0qCs/mvOSKwqMysGntS4kvSw5iv8RxPCMn02TwJYZLMc1JHAff/sfOt//HortYKP2H7kKz485//VeRJymDwvyhrNTp77Fo4RaJDAERQ/4WEPmX58Z8XXpzY/7ymGfbV7E7Lkc4EzbvI8yQ8taCV26JHNQ3lFRDk6a06PRxfDmCUBcx/ERLG+SElWNo4tsMsnOugJbI1Zqzgkw7fRHlKkT+OqoLzt2WIiOg+TMYiSnhKx6YWdlFDOsFvA7F3ZDHD+I4OC488aV4eljU/KgCN4HS61LwNb6ajw2fjZUOguePPYZ4kZ0+4kCRxbbVMquMZpBEkn6n3P178gYurDDMZqDf4F2T1NE4BJ6X+HEpIx49TtNMuC1pF4v9svt6UvexQFR5VzDLt4KIKI64k2gwHXD/3OD2/zVqKIqcnpQFYSI8KlTpcMfNDektsKjXY7oeDx4nsH9RYoO0Dsc2ATxuNYSBgNFR/bwpsQQrO3mT5AzU7uXY6Wh2sGZHUwNHTFYypbW5UtGtnMfRLkeDCH/WhKYMHqQoBCfsjmjOCg5L7Bh2Q3hyv8lQGRJYDZbswerTLP4q/dq0wuOlyImRfc6SPKXp6W0gbW/7xiYB68EJT9A774aD1Z1Qxm1Z23qRsbCXT0tXAqmkvkaQjpE+xYKA/AnA4Yb6dmyF493t1WNwFCQtuIZZOH9zYuDOPuqAfLArNK2B3QKicXBmrTbIyxJbW0JFUMvKfEBeJtNbbRGmoIgNMs0YhYk5KpK8uCFpKxh8vwAD2gkd2Afp8K4g7wqupr5LkUW/yjA39LB4XuOzFBwzYczazbMZUn8PsnE5Sc3PsyTvh4i6zUg6lI3gL92E/+ANeplGnd/tLbQRbS8SPsolD6pilAIC83cl1suNzhImtKwqFFbTGUYnBc2EX+JG5pWKQc8A4J4kjA5knGDQnTPMPFCZggoidsyKLCdD2ahDheBdv1ezrlsPGqqETtAETZX7w8Fvq42mo1t0xLAC37i6utb2tvUjCDlRwJD3YRlW7FIumeqwZhSqVpRbKMi/bds3Tjj8NqaWXqsrWYgok1MD94AV8SFm0iEYkd7MGDN0RsZTkvMFZMFCk4p3le3ZdxHRM1SF6FWJJdAgCBY+OJEDCZy48SOKH9HaLDw/vbpi/D0/k1sUURD23t5VB68npgNFiqKLp3fVxZd3VhfSfUR0cXhno2BOF+Ox7ODJXXVwMrQ6UO4uoo9na/dhuBGkD8KPSvyorPV3dAb9laI/pb6HnzTogJmL/sHvaOYnT9UJv8IQ/XYfiM0Otrc3gya6oYWviCKvUAK2rH+UKTujIzMWnidd/q2SgnPkt1ZB3tl/UeYMpm0Ab3IL0wkR1CgwkBuM9//hvvp/Vlj9j2n6xdf7+/vqnb2weuch4Judf72Xzl+UNoUYaVKtviaaMEQ/3wsGb2wM1H1Kda9YH+//x3vp/6Xdv33N8k3B83tB4Z2NghFjfQhA4DYFTZ6VXNts9Wg4k1DeO0USlqdEs09PmToePEVqhbytxmNvgdrfQn6RRVoqhAE9LwMm8m7wa7+ZE5gCKXPIRO/Yyi7hyYNoZRbJGuoEoRU1aSM2N38r+XFyXgbxOKBdGYo64K4Ole56X4g/qmu726sr0dbISA4MiQxh8TtbO6cVLqb8mad8t0KnpPhAGS2epcbPZGFitmf6lXkTl0LFQ70Cs/aELvUTMd0ru2OSXBDwZEoLkgvv2EZTE2WqsrIpl9zPRbRnpr2NympolHIQy3uu7Hxw6AcwCIC5gRjeXP5k+JF4a2fKno11AQqiDOpAeUPGt9qDQMsTl6vKbqCfNmUxMgWvZ6ky3nlK2CCn0yLLGXe75WklHo/HsIKLC7Ga35OigHcybBYYpRIZWUaxhSA6yUnyReyBqMQ/lNJwFMrlVxHzmNuicYg0PbVKZ6DNJJfTjJGDIS1YdKmsvjVB7cuHhKADMHhiT7g/qoCIcp/BVXcc7Ks9tOBLz3NfbqEP6D36KkxiPuJD9AlrzQIRgRK/Iqmg4J6YXF/5tVv/hD5iVpnkFYd4p3doklccQhx/OgrOjw/74GVrggtnATs+7CMafAxBG9tCVPvG8mBfGoVDq496AIS0NJXWHJmisIzbkod2Hq4s6DzmL3Z8mbOcp9li5XSa5QUZ8rQUUDrh+sqFrBRxBCRhRPsyahjE9Q35ID9qU4qM5/3iH0v+cSE/xiZzmG1xIVPyXZrSjziDZdmpzCv9p72PkWhRIb6fIXcLkgsqFvM9juU6fuXL+KG5jB+ay/jeWUZfDhK+jLFnGWNYxvehym6x4jLGffReo/7191/F2LuKsGCxWjDHIAbuwJuwzPoBWJhSyDmBkNL8z32I39VTbORYPvfBxHaAZ5yzblnQsQwTR0fz7qBkRTbphP3/mSxHPIr/CzMeYYW6lP38MftfzOy/JBeozamHF9zlZhS98p0lBDMIgvISEoTJLcHTZ3Axxd5GsXcbzZZuI9g1yN3D+0v28Lmzh1MeqYUM6QDwOcRCHOKf98dZSp6l0ROsJR80JAUZFPvzwRhs0xvOzx/AHHVRce+X9+gr+ojrwUzQJ8hcjAjBENSMgKldQrByExnzDwP4b2+YLQj5Bu9qQZAQjPEnRRkiF6soGxHQ3MIiUaLKefhToG6KQR4fqALF1sekO82mQQjdf0Vf8UD9HhEguI/hIiE4ITKimPBwZHjHyqKQiSwKlBi/uOwB61HYmHsBNBXRjUsEYUeBkKOEgD0gFZQDHTwJEhJaMBnKJMyEcHu5ihD8XriHoU/4Pfeegnl73yXAMtFHXspnF73H77vTnFxUina/Ar1aLiFf5YR83CMk+nRMCJ/8rxAHKIHJnVl+HwkxlqZjotX2MA1z4klgmJAw5H4rU1F6yL84h+bnF+lFMqbDjcdH7zaAnKKNja2FdCZJCKRnXwDhRQkRp+PW9vaAGKUy70KtW09hVynT2/0gQ+dQScY/n4WLRsqLmhtxCS7d4D+mE/4tbP7EM2j4bil7ZZChhCCKvqIxQQMSRl+3twOYSZyFCALrJDzwdtWbEzwTPMdtAXGjlskfOJgSPCe2K86UKOKdkrsSSjItkczEnM8JPy44U9K/L6V1gG/6qwannBOwbpU0c0zRnPRDtKnyPR8Gc/86JjBeYd0wJE6kHAA4ItvbFsxEwnyPF5L8o4+Ib5OIEAS7JPqE+BaJKEGwK6L3VYMfAbF8wh/3EhIpf6ohwR+OORn1bW+qIdkbEshQZHOuxv7i9CqS6oAPTkKq2RkdEzM1702+Uptb7VFyrH882O0f7/YjVvX40qwlzvHz+a/z5p7Pm+FdnjfDv86ba86bUet5s/pB8z/+nBkRKeEuO21GNeY+Wp+5j2rMfVRj7qNW5j6SzH3qMncAOPydmPvUz9ynZG/6uzN3QQnfl1ianwHP4y7J2urlh0xEbunVDF8ssxeZg0XeTwITcxjLKHWhvot8VD1BAltPb9+XC1X1F1X16Ix7K7bUFhpRAIdV/AATcoYyHvaPYitav3TV4omPdYVKpLg/S5gVWpjKnAy9ekKd/bKrorm8SWiu7JcgwmQmI0xKB+lXJYS10ijyTRZl6BIKYrsghoIY7StjGBs+z2rb00iJzA/7IdqvPIhlheLMEwjQwrOjm24YKkOUYSvKsqgF+Xplz1kRZI3e4hDFvt6+/8cte/v+H0t6e1VaKckzcSJLapDLoloAH1L2XaFsqX+HmfoTW7ipb6g0XmD647LVXhlaPVmPxueaKeuZHnQje/YEP/RU6rUWaHWBOaI+lkGHD2pjmmcXdCiS6WhMNqw0quV4CGl0TogoHoLozqmcVWBMr5p0WTIi2j1IdmkNzXXygfcSudXthbWn2nLPt/eW7Z3v2y48iS+ygVrPkk6EI8dfgWtftLWPNuJD8tFPhSTlYT9i+OSsP3zgXnPWe57bxBQ4Dc3nEKyBPHRCrYWsEZ87vkCFfGqjvD3TnG9K9asfrUL5e/7Onbk3fUVL6dBZHuzLWlbfdjY9eXv28umsCJW7trX2giPVaIgzvipsAfT9P1YG9P0/bECvLMeODd+iMRXPA5r/0r4rZbM1N6Zs1b43DUNt257ymh7XnjKfwcD2pH+MHEwn+k185ZSnP26Jjy/5G7v++lZ8FTKx/vpEfH0inv7156fi86vSAXGovo7H+tsH8e2Qpl/0t/fi21sy0p+kHYsanPOwytdsrzY9ka7Cr3TSGcgzOejjOEQ/lXjhTE2ko+p0+sieHqfAmaLoGCy4OkiYbnX6yJkqkBXdWYIvzgSJD+Ox9dNMAfyyJgl+Gn8E+CW9BuBPyz7ah7W2k7C/qlzU+qb9QUyVtPPuTdV3uX1ep+/y0vx4loyZK3UudIuIYbBGQHbDKENOUxUAwbL1xzrbogKk3ItsQDhzv3FomFbiKafmGqQhAcEEVl9uEjwbfhg5nzh4ZYmsqMmrs/+pRN1ut5TaB17FrVHXevho09V9fCodrUdjM4gOw56D162VQZ6BrAPz9xq0WBIXvM9GI/AadYR74l8fQkAXZIoXB9MzMiF5MhacwtlTukzcPe2ibrf7U1kJaSheSjJk2phpVXXpLNZmDEyDXDXY5m5TY2cvoSh8kHLfqDYlnt0Aqjy4kHVUO0V9zYHeiAbVaDM5L9Q3L2tSomxrYP9pZ5Jqki6m8mrt0hiP24077sdOT0uMIOeMSE7SAZFRp12lgOS1qr5mrarNcb8qsrfqZ8MbS36viox33FYuGGu327U7C2t9adklbwxVbLX6WMXXOxzsolpxsKLnoB5kVuZyc6srUy6I2nkg1XrONHBNCx+vOmwdGQQLQ6hIytsucFZTf9RLhTKk64zKcf3JrXtw2GuBou/rWVX1atOPF9JUTyQ0UDNT9RypyBrEscgZLtVN/apny1X3O9ji5oPlSNbGKkm+MlTHbKVVzxX0nGir2pazcmS/ljqOCNkKR4uMhi65UaORHgMGPCUTz61KpVzi7PhbdR1qbC54TzQh/8rj0qgjW4L9iXO0lRtGKjCvwT2sLJHWGh7cNbpnORmFe+Jf0wTC8apkByooZc5imexXXQbeCj/9p+KfJ+Ifcf2wuo88R1XAeExkuSHDiHHP/HQqfae/tWJUcOsp0Mb/SObKuxAqlyhWoYB4ECAqnpxQhjP4N4QcsDw2M8/vD+HBxQca7vHvEPsHogCFEfwZWoBCXXN7e1P+HWswvI0i7tgEGGBTnE75MLLWYYB6ozYURWpTUzeQ+Q1UHzoDOSBkfpXhngcotIyYBFGFvBODJJ3ibCr/Hk89EUE0xLf09MxNt0ttFF3saRUiN7THwACPIR6Y+l5O8XkS7KAHuyKwxNSHhIghYvftBByx+zmb4qka0HAKwUN+HgvIIwV514JMuMMuczaw8C70GDEfd0ZJWiRs/mCcpMOHvC0EevB/D8JIQ5N/2Jo0fxd2jbburq9jd+3thdfbk//eGlENrvEtCKMkge1/3I9iynSM8L1OJ4qtRPoQazumdoQZw1N11JgqsML9xZSB/DhUz8cLJxiIDhyhU/6oqNGK7x7LRPzvaVr8c3+cTBSsPrBgyI6narxIi3/6S97T1qIXabH7j/ZWbWUv0uLbv7c3ayt7Ns6S5YX/+A9/4RN6+iJdVvqe1oorwc3tpebx0SE/hjQ+1JtxMsUjtRlPfdtPecx7NmCeBAxNQCNuBwiaiggxP4/dh4Nwz/kZ2bFp+JeOCYhxMcWnZTCfotOpYAwnHOi3qMHMoLDEgylPzoZi+Kucco9Zkdx7OOW+P5sXHEtefYYPEifa/9k0mPFYILr/eIpPplIAfZVMCE/JZTvQn245DvTqEVGL76eUFSR3vb5elI1i7ZL1plnmuEu9bJZbvkzvSu0CfjDl4pZGGgmnCv278XSgS9RsfCkDERWq5LHSUVah2RQfTFGRZeOT7BJD1DhLh8UieIMckwqlClQ0m1YhOprihWyzD0hkeSR/qsumSA7EosUKqg/btBksapEK1CLSPrOjeTqQXjvy7VT6LGWuz5KOol3i8TQ4mgLZ1NGM6wjOKlyiSxwHkDHI5KJkwSX4jZ1jZS0eiNxJh0mwqFBHAgG/KpgisAnb12Eh42lw3JnmoA097mvCO8R1kesccoxJ1OutM1bI5uiw6nkm5IYarj9wBtfBGNVma622T/D5taslbECsPO9HpCjGZCgb1pawHvf4MGDoibV6K0EzSxqiLX35J6M8GXCl2EL5PcoQyBS0EbabGuzpzByiG6/Edg6ar39ld93Hv7K7wttf6NuZIJmju+MGVRVWkrO9FXOTcY1moGlXTlmQ8cWkiE8bhP/jrttdNaM1GAEvDRH3+l5WaXbAK72eLKv0esIrnQyXVToZCkhsKSTGK315t6zSl3e80rNiWaVnBa/EXiyrxF6E6EXZWgMOsjftxXCWvWwvhqPsXXvxu1JfR7LJtCzIwfBUeoPaigQ4g8RrXJAJ9YTQoITIbia/8mwMopLINtmsJr5nYRjZcWZUCF+KHy0EBNpoS8XdBukkF5dKVfcuT1LGzeDy2Pp7IR/kewTauy9DsggodeFE4GFVoSGIW5bQV8ALL8bYrut4pzA7abhdqyd+8MlVFl0Um4+2SRfdo5EzZqc7fRqYxsrgSCktxnDxldqQ4FkJv4Kmh622U+I1OL9RaslSnBhBjMCGCD+6xPBOEVCV4AgEPr4+GTfVCSM4VioeuxJZY4p+u7bv39r6jvGj+LpeY3+vWxLmkm63dLdfyBzz9LTy7wz5+wtRKa3TVG3xq7W+BzMnylNYVejLVLwGPx8HD/8fBCz/fzudEMGvXx/yn7s6paKdF07RwL4i/R9Ylr7JaFqQvGGGp06116oygKFjLiW3ttufLorsC0nZmvZ7ohG3b8jkjz4MlLB4wLttGBPYDudwB/78ECLCAwl8mapsWA8h2btM0sKDPSqzh9fToAO5GzbkIDYGZmwb2WhDYMATIpZQToYbSSoin3Uh4SgvhqwHSckgyRzowXmEQp/KZZClFyA2OVqXr0mwnwSHY7SDno2DjhhHBzibdXmS3APrezsSu7dXU92UKOa07wugqqtQrrLiV0Bzgzqf4sdTKyojHZK0oM41UoWnfmFunbqauPW94ddAfpu0b4tCPdwRIRue0ZQWRNR/Ka+eb+St8R1v//M4EC26uvYer3aQ1Auk1UEYRi+n6Hwa5Ak6fpkUZ93ROMty9GLal7FVX8me3k3VgN9Osd0R5OY4JbmnJ1liunolNJBPp/6Qou+SL8QbUzRVjzUUm4CiUHu9iKJLW+iQoqqN+HBNK2/sT4Eq+ATIgJnq2Er3WHRNuM/MfZlIH+Gd7W0B8JECokOH0jCilcCwFhm04FN5XWjQp1MZGlReyaatwUEBHo8OCn+Y7bVR6CWTYLn6lH23s7f7cCeSylBBSE88G9tpmifB4VR753IDUWubPZviJ0p789sU5wnoeDjgrRZyeppn05+5qXVLnFpNSrrmevR0fbNWorq+qZey1KPYyPrTxFvcYD05NP7yY9PRMoKrLHRqZDQ0U3gdLW25tPShnZY0UE5Q+pdFVUN35azQs6jUd/Qe/a7c3paRZ3sh32+GCCnafbijyE+g9H6Kf0haaI8z3WIMNIU+TCHyCgtDNOcfQpna9KstMeyCjPBQSgz/b4dLDJ0QBbUj1pNY1mO1p1H8WQkK0hNlmZAwFd/XlBJkK5ypv0BEKHP6LpP9OGozx3KSmXSDf+s48TqzPTuRTBZGnb91hDWlHOP7KbThtEZYzPV9yoL0t6l50z3mEVs2n01B7kDMds/5eWrccxyxowPpcDpd5yPbmJQQfJyc0nQDkspsdB52PiM1Zfx2bKdj1KsPC/plHHydhjb2LxKd7sCVgQApBxkYGU1PQf4RePnlHxsVRwASCP2oLSBAzr1WWlSEIKXGUULHZPgOfth/v5GBNXvqVnRLukH1qI+i+3CpOCpZr8EKZ/avRrFCGme+r27k1syW7gmLiZg8HUNSZFTi606xokK9GwNqL+yP9YUleiU2BCIb3IlHL3hxRjamLkU6i6xuu5ZfSShXnlYqU9IGtTNMg95ebJFnJU8fDFsjAyMIeEotw/AG6KrvgDHMJOBaVp2N5DShQHmJMmaRz/HOGKTITpG1FFGJPAsTxd4BWykp+GWw5M5lv5nRiRdubYUOQfbfTqU8CSO+pyHbJij3O2IzlOp+hlKm5HJKBgUZbtzTgPgNSJks7Sfj8Uky+GI/GB2drRv9XALRNh3Pp1gBNkHWxenJbteTBiOCO7PBGZkk9UDcIqabKJJR4lVFZUPG7CogpsqQ5TxZoReeLpQQTWUHpqmmoJrkTD6wplTCtao7gK2KCjK5TCbTsR9dVSah6qoOTF3JzADP4fgkG9K2WbAq6JmwG9Vmw66uepEZbX3wZZGErCo6MFUVBU2l3jqC1fRjXauiaKLW0KWNWhPVG8QE9fbBCyRkUcmBJ4oVFLVjvJB0oYRmKjsQTTUrwcEPU2y2mt57aZEMiltuPA5D7Dp4PPUhDt8lzryKgy4vVOMv87EPQJmPZXuo4DSHItWaTBLqbc8LFMXzSi6582Jrtr6H2eIDk1PlJOO81YQNbUhi2mRW0vmrlumzyxUzsJu47MCurCYGnBVBgPcAl0USrqrogFRVrAn6OMXOlMhpOgCtKpxit5khIoGopB9cYuCW+r49YYrVrrAauPvCqnpPHKeYj71Y8gIJSVRy4Ihiw72n42zohSOLNO8WFWusW1RR0LjHFBm+JYzkF2Tog1qrIqHXGzq91JtYpPHLFCsyUFQhTpPbEYWAIU/3cjJJ8rl3pkWRmmtZ0Z1tWUXN0FBG33SswQxEq1hCtRs4kO2qCvqFMEZtwuUFEqKo5MASxYYmCpKnyfhDGzSngqYPu1GNSuzq1up9mmK5XHrxRM2nMo13I63PDZbSA7HzOyzFzQ8XOTk/weR4kJdT9Zyzg1vNjeAoHS1z0byxZeU1saOKO+GeK4aJj5F0gD0ZBpu7Rh7jABuiGG9i9sM0J4OkaO3ZVHD6tj97erfA1lZKN3N4FrfYa6X3WhWbZ1kNmzzLavKn59rX8mwfx17Crx1ubW5J7Zck547kuyLVbxxLLhzufcN73bjH+4s8+yHg8hIpwpUgeGWfBCFYgjQBHpIRTYmK5BwIHmB0/8jlRAvo7lrOhhgp3J7r7AyRtAR3jpMxAUtC7Ux2hgUCPaVsHmW34kc0HWUd8c3N5sMLxNwWtPCvPC+QcyoqOWMSxb/PKVyQfMJej45IfkEHfmSdGgprt5mLvtvAprRkULRRGdyVDJUlg6JJYcmg0NDGdEBSRvz3Sl6kb5aiYu1uKapoWYTkrGV+ZZGSR2RFVyKRVawDMT/DQGKS2g5Fd7ciOIXyH36flCMsz7Aclh5k+uWWI0y/iOHpDORvyciHpV0u0XWaOHg7ldXwrRznSzt4MazDfzFsAf9i2IQubTEYynrGxYu3tbEK95TDGcPNQsvpzHLeNikedOVOGDWgvxga4BlulFmwrSjeftii2J2Mlqn4HTR5Rk82X651mzd0bvM2jdvvdfPhwkbuV7tBida28Wo1JRuvYO3DIezDVGmNX5IhTeAif6udOFFQOv/LpCGlYfFClGUKoqrqQlSVrBUanWG9LHKZXj8ui7NnECfnNsuUKShimZKyOMty+pVvwfd+/l6vowTkelNXRq430sIDPJ+09KTKZA+6qgNZVzLbepQTdtYC05TqTa2r1/a0rmhE+mzaouvmJZqIebUaEfMK1pJenGG9hPUlZXezpvK9hk4gVCn1ik2qTKKuqzrI60qGJzM2y/KhnyOLMs2PZdUaN5aVFMTBmJK02M8Jt+9Lxn4dfb2S7KPZ2Oms2Uz16lDlfssNs1HJR/D7jVtns5m1+LG1+OrF4PWUpMmU3iolciZgeG8VbEoGD7Qo6quhCxWaszMssbJwfDyl38Y7t6NQDcaLKYxAS0FpM9mykitSUZFZFevChJgNudxwr/JuAnHfYqqKS/xQ6B67rP3cZc7By3wnr/1IWZy1SDXFmRFoirMaHFFsLkXq6cl/L1Kl+mqkq9duR7pi/ZFv2ete7VnP/56nIRbJqRdL+C4h8SoOFF5YV9Y+zQZsma4WymuqWt7Eq6nlla3t+VrQvSBRQ/r57fWzRr69o/n4V1HXr7kQN1jq+7+T/ateRe7HxOLun9P/HAryu+d6S0+NdQ4NyZ7OOXvK7eeRN4qmbsWdNGXelYqIpv7zVp+29bPW2RF/PdD89UDz1wPNv9ADjeZCf9QbzYszrHHQnLE4e1GQyS0ZowAi+OJW7tcpw3c5i7yKMwJe+K8mtQH07w/e+aCeEkUxUMGB8v3BO936zXtv62mpWr95X2v95r3V+vWRv3nGdPvXR3UAr48MhKcHhwfvDvzTMiaF2pmymgNHfNOQXr959+L1qyO/XAkzpnalqugAkx81tOcHj5+2WUZJOLyKAwS+mLl5/G7/ecvtcXCmZodXcqcHPmko794+3vdOT5En+qlOVHKg8E/3di++DzlbCk9vgEWI3WxxCHZr9sC0IfZb0YPS6+jAirfqQodL9GpMpKbjgakV3gGnkuN5eob1GPSY9C3jlqPScH4PI6W7Pwj/KFFV+Z7C0ugp1Isjrmqx+uOWSySA/B7rc7cWo/ez2re3RZdr9wzWrrZStRVkd7JyTC3dKCnHLdIXL9JLJirWlktUsQbwoxmAivj0/Ez64L1PdTJEfVV1XfCe80APvDK48XCT/+RW4zVgnubJqPgPMWw6bCMAqg5aWcUZLzUXha32m8KWc1XY8t0VtmqXhUk5Luh0DCnrPQBNqYRpVXfAWhU15OSSTsqJF6woUjBlRRegrGKuIYNxyegFedkOtl5HX0tqTWvXk1ojjT9NW/GnqQVfVXTxp6kDzXTTDrZep4G/r6NGI2v+D7mPdcsKiEKzBrJyfRVkNWtWlkClqQuVpl6oNK1BnSZFQfK0RXyEIvP8wCvWHyB4FQUtGQ65+1syBrHKyx1rVST0ekOnl3oT1Rtt64NakGkTHnWgTJLLVmRVmVkrD3q6krVS7RBp6kCkqQ8iTV2IZUp/K0krUKtYwrUbOKDtqtYMyJt7i9+ZU8HMhdWoPiFWdWtWrumFps1eaNraC009vbTIYDVZax1F4HQp0tM6xtMWdKdNXA1dL58YX73GvmmbJm/j2u5f3n2jkssR2jpuNrNUqyQdknTQ0qFdrkURq0ldcWoqG9ubFj6fGt6eNvh5avHwosUVqjA+UEXD+amwvZ6S8dh/tPMCo6ysH+iiWENJ5y1Q0rmBks4bUNK5BSVLW8QMXiChiEoOFFGsoKSZV2hMMyUwQgWnPRSZVR/RlJNiy6LrYiN+6gZ1EVRX1ev1L2AffXeCN0AbZfkk8QITJRKWrOaAkhUUpJPEb/wM3yUUXsWBwQvv1i+Xmwp6TwYoUCcCr+SeBLzYnADJ8HU6bnk/FWX6BJBVayeArGR79p5hc6uwbit3o9NxIHn1OucsS+9HqfP9GXa6t40/b2/4Ka5eJzRN8vnBEoNIt4YiOreZS35ug7th28oDTRpZWsboT812vrVdugVLTM9ZyzqemXU8a6zjmf2MkJMWG0dj3NiwahzfMe8skvyUFEetV2S7XMG0m7ig7coOf2jzUdaFNp9o+iebagZqcZYN/SChRMPj1WrAeAXL4rcNO1lk7H2bmKkqd/ssKUm6GOIaGfPYb2zoyxKSTF+fnL+AYFVkKGIbqWQlSZ4n87cyyI5qENj5TCCBCoaoYmUflSgLEa1CtKjQzzTI7LCPdIjZUP49HnqzCbwSEQSsXAIYs0qGwxv4MD9LGCjcdbA3O0Ln1dV4aGK9be72TFg0SI+90yu/U1V7vPF4GFAIocNzxDAYD7VaU0yP4WMfld/gXRmBaNNKl1AO8WDIUR22oOpMbTkMjlnfyX4yGeKhmqL50JN1Bq4UR0lB2YhLxW7KmeBpwvMCA0A0JCZPl4rnJNcBv0qcuIl0yOOtwwzIAP+ToTk1trfnw+DjGOkPVrJqCEvOP/cRC1GJedSyhx0LNuRc6LLpmBYiVhjVoZt4V3sWmjxNA0QaE4+8YK/gy2lcC1a+14hRrrKeUuyNVb7XEpxcNrPz3DppMmT6eWRlXn6fjgljr4szks8oI0dTMqAjSoZqwrMurwYJEyh7SfJTAo/WJnHT3pCQKY9uH9jDDZjIvhMxdEqKl6RIeNtnkghgNkxKl00rpQsPYK/r77lJt8OoUQPAP9YzsE4ntVatXdXqoYshXvCK0eYOas5JpFLuXl3xHLsosQJN8cqRjpPMF2eQFEEW1rLoTBII1BgonNpWidNbGKLMjt9V60QHYxaJgGurFDm/DdE/E7uImSDhHGBsAM7cRO9x2JvJEM3Xo4x0+GkhKc74fs+szjIUo5np7BI3cn5Bj2NSbOz3RLQ0ga58troMt7epj2IzvfdLLA6my7C37w5lFqJ9OZRA8R06Cjy0lGlyzE9JqMM/yh2hDg3sVLP5lietEQQj1xCqMIgRDXnOMJEyvB3V5bMOtNAruzmZZBckuGyswD5fgbJCFqaKC9W2nPu5tj1kYWUCihoG4WZKQzMVUB5DDoOLoUh+2bv0LJsO4V5iX7EdzL3cK6OLoacSuuw2tqKGG2NPqQ023osBbKMOuuw2N58GO8O+YhvubG8GcJuVVMhxzEPl8RCZwT7GmDMVN1H5kkW/1AGr8b43i5Zn2Hueb3ztLsPINxxZpuOwirwbPrYfXIbyhEPCxzJE53bOjXZeLlqaqiizfoTovNJUBplHsLVrN2tJ9u14giC58me9oPOM5qzQib4glqeGuMHOIIM7pG9P0g0+NV0dCtWNpy4fw4/OImaiOjLOhu1tQHm8cpcTH+/0w1AFA42H2EkPGst/F944nuGimYwUBJBBNp3DGjxOh2ZahfxWz5dydZW5HyCkp/iEDebiA2Jy7c4SJtHXicVlbiUOSgj1qqSDbJdd8xmOr8Ar5VxdMR9OFr3YmJnPyE3YoiOsHqhJfSbNtuWk6qtxPFx4k4ere7AtHqAfyqpCMMOgKmYqNdNx3z5x5bKckuL1TKmU5+o2k+UMNjYI9HCMMaA5Ggqzro3ymPV7PitFITOXYYW4jeL+WUJT3jk+Zn1bQDZ9v1HGjFxP2nNuJpjuZZGBFFDEY7ci2g8rdJbkw5f0krevjZDzcEWsFncOgm63y6zkSsxNrsRP7EwfjExnSrJwAPbGp6VHu+SC5HNewdBFGPbUGc4w1RBYdwoP5XCKYdhNnGUyGwLGuAzD8CQnyZdehkslxmdVKNCunyP1VH5opqZVpHUJ4hBdYgv12KxnBuvJQh6Altl1rDXP9PQ82O1lj/BOL3vwQDSBMWT93oNdjOHIkzGYeQ4NTXTBDJXouGPxgg4/Q/shuhSnOsQcVaOc2TcBnKFZhcSzGuaTN6JjIP0gUKKp6jMLMaZhCIL2i/Q0J0NK0gKSewIOjXtZ1rqiYlQ7KMabO2J54164iLG81ELxTMf5frDbm8F8zGA+1LWJHs/6xyVfWEFxEKgYwCHx85rtFmTIOjk5sJ1+VX7zTcU30+X8Jb0UW6m+7iF+BJz9DVSCZFoLd1dFkDIuQ8z5GKjQwvKA2ee58jbkY5uoBSQCQClhG4Kihhsn842CPZjQS+4Fj9oGE8l1um7M3lXjaX54ZloaIoe9zAM7IrKLuqjIC8yz4EaWXjeEs4RFXNuCH+nd5Uer5GjpSlwdc0oK1TpY1ioLobbIBgPVF4o5tc8AEJKO/hCHbePmb0TrDVrn4j2mfVyizZ1KJo9faZqh4npTnM3SH8mccUIUPNVLFzwZYmhLJvKKL26hhgkw+KoYAAu/2wlDnmAHzYac2aOjIcT3nM476FL/9WWIO3Aid9D+0M5lxTUA9JKm7FmW78MJDBxnfyhzAyF+ggt82evRUzLI8gROR81buL4LmeNGsK0ATqr2YQLk5RWysB+imUrYDPznEjZkHM6OL/t2JyZ/lFTn6SAkx5f9eiKp4z6ympSyiYktUmsCx8xx32RvnlViPhSqj9PhS66vbZ8XfpUSzRcqxGfUNqlB+2C6qnF9SIsKtY/H2wpGtahgaQH165GJm8iIpvUrmIXKrIlKsw1crxYQZR/JLNLLJhFdon01kVxSjFYiAvHotS4dNFspUkCsSAo6iK6jg2WzJ0CsN3vNNnL2EE1ZkaQDsjpKl02UFBC7g8u9Sxup/SZSvlb7e/tyUV8LRgNMBjiPRuSIJPngDGthVKe20F9g2Y0cCkCOSIFK9RdfciZTsPfKLqNfyaOdXuhI69YhYws7ltxgCaeW8BUC24EOYptEMtzgk4ErkmZ7GRCIzaU3aZfr5JXIrHGbhWU3GcLLT4/KP1BpEqdJofCYi4tK/NXYvla8OQydzjZZaN97lbAGqbN3rZ/Z8U4/yurHTG0D8q+hkHDMV/uEWAgRWSOjes6uroIML2D1RdZDAIQyS+XEZ1GMSSw0so8PZl8Z9FLBmsyGRrbEM3PJiE2L41nfnRRfZvKQ51A8oSkZvkhpoRXSqiqgJlPcwBipuCrQWkJ5lOHF8awf+UBVvVhI+FlY2cMmQ0E4pgNJ4qILc3XgnQCxU1E37Klhe9VEUP141hdZqY5n/RqqMMnAPA0GZmaleAD3mOGec6UMYmQahFFwiWO0j80nLZXz3XiJ7FfraL+CvMDyeK+J3hYI3flRvXPG2Q6CFLeyZacfRrpLhtRc20TCbzHnFo5AMJ00S0lnE+MvQ8MRhCiE4eOehzcJ6oyCBosy1OndGGKrW7qn2ia84S6UHMDlIYYzCbYcK7YcNw8vwZvMXY0F52EP7mfnOAsrTi1AeMvODNrspnkkSXnkPEQrwSybMH0nipBX0Lm11JymD9ETNdeH+Bw9wQztC6ZziJ6A2lEMdxkqjRuykbNAUSFlFiAjap0uNN0wP0tYDAr6nZLfYaFy6VY2P/lOB/VRSKEyWulSrFRkj32PvlM6+FLPybWoGq/P8K5M0w1Yc/nIjDPxb8hfm9VEUvNW/EJp5KTKesAdLTxqOTYlg9cn571pwhhNT2UqZS7G4+OOLO303WSz8nPE4JTNKmXhshC/hImLrCPSwuWUXJA3ThfGkOHxUOZEaqLAQYUawjNgDs8oGQ+53Zjz0iyTPTOuqGGoMzJ1O30HJf1EJBTuhhVn29uSurM9ubhfyByuM9FxXyMhJ9FSZf6SBT+PUR2FWqd7HixrVSLvQLbkh/oo6gi9kLuP61kWVai3BW/VtgjWi3bgVDTDDAORCVusblVkMucyGWoVu0r9vnAy+rYjSHW/5YbiGtlo42C4vR0ItlIqtlI68h3G+GC4Z6fQiQI38X2GSvEMatJXyT3xUmupDWnUd8QLsSPAaqRHT9MsVxWbGwDqRAw5taJMPLXZO4LaOwIa6eybdkucXV0d9ysnXZRN4w4Enp/XnWN7a5i3ZveR2aTAeiuSBtMuTXliaBY0H4FLkJA3m5he06ZGdx5qEXpwZ4MiHyhuNjLD8uXnTR6YZS9DICHegf8pBp640axmNMl7fDCCLjuuIV7jzZy/CK899qurdqAG+VjY4CzBnjnIiReRhktcjXQDIZq/HKKDoVoE84wlyLGWt5lP7Q9nNep8N4akkjLSfgdJLsk6yPKj6/S1Jdm7IW7gJDfcK7Xh3iQ5PGXxKo9nSa44wmLKC9y9Jb6ZRObiN2ZVhShzTFMP6RdiGQHVLRWk3auKyl2QCfPP2YsheiWnrXX/tXcNph9LJkw36fSjFea153sea9tJIGEAcdgZ19qe2OQ2mh3UCL9pViysBR9Ipylk8RDb0ur3mhBElw6/54yGj4LeZHtJD/0htulE0vFTRcdvpS9Q/dQ4GC7qS+DwbfX26VvcJStg/JNQpk+xQ59xZDIevxFXmGuyebNaNm8ld2ZYmMRJkTP7jgqjx014K7OvpDrxt2X7mNUsHo0Y+qTFkjMreMQhg+/mKTz9mobPfOKyyqypDrerq0yZf24N8cuxSPh9WgYXJWq2JrQ4Iy6EmLJnJkH5nq4ay7qNnOW1KYC3fW9BFb0ZB8+GPG0+jCo4KdHP49Akz/0wxIfD4PjjGG0N0ZNhX31/b1gm95ZaX07hx9sb5WtFhiD6E0jQ+WxlEcYLIuICYOmINuUaog3yWWSp7KZ+pGnYlIe8go00xwN6Xe/MzkDGacfA36Im5dpCWdzKq8p2YSYLUbxUmKE+YWaqCOTeBJpsVYGG3lCgETmaFcW/TKZ1Kn8/XC7H+Mnmw1BLS8a0w3/y/2xO/pUFJm6FtOoharuD1rcKfvR7naE6MMMQN2ak5/CbP37Cms6lf/y8PR/itvlRqQotr9M/aOZcL9k/ftJ+GGLPrMhd/73a9QdpOfmdxCnh7GuJUh8VEmCt6EHiiCdgv2MshOcZyqr7GKIGrhjgY3AevvbWsw5Brn6BUH7NLbeT/033il+G2F4JtTjpXH/63Rcnnf+1ODL74hDbK6HCeqdEf/q9F0d54f+1OBs/DbG9Evq41R7/f9hpa4Un+OMPWzLCzTmx/JXZH0TJyt9/PUpu1Rz7J6Tmydrp+6kwuzkV5iNsz6LP4f+O1LHfr6eOtcglbH88eDk0z69dp1YXAq0rt4Z6HjE1e6BHVfdk/5JrN80HslwIIbL/EfbNlBTCkpEUwrbektF9CmEGR5kE2JIExyOPOy0dHYwZqfnRfk2Cl0lx1p0kl4FSlyFlGYCo/MNSsm3EEs51yqS9Fl1SRP3fHUXbYORRtEHmjoS/lVtbTpUGrncw/Np7sBuxgKIs3NuNdiqlWytHHl0cy/LC0aUJZyBDZmxMB0SQV4Z2wq5oYKF8NsLliHcw9CBvYRynxVmwg+y2kxEejuTfp6Z1LEwyhqrafARawbH+fTLCp2Uwn6Inw9DYNMVFtp+lrJyAS5vyZapPl/R1ByvnrCyeZzK4Nh0FDS8oxzkeOP+7TJVV3HvVwKSFCFVtagDETpny44UMjT/k0Xxyko3VAzU7Fr+7HECR5f2rK1XS+a//Ul87/dBZnVGeTTxYlCYm44s6QtJzXiLG+Ga07bHaBwu+mMqkoVtkYh8LgmChII/gn+jBrn6Of62e4zGFVxyLZYLhA3a+dNNkQkLUAT8naHB11Tkiou2eM9ao81jtGFnx4X8He9F7evUiTItgL/rn1e4/rr79exjsRfvjZDIlw1BA2HrYLQgrAhru+UenHDLr05lmqZrGo2lOkqFjkm55xr1IL5IxHW4kRUEm02KjyDYYb7CRZukDRRv6gbz7a/oClOBDkkPVE7KhqiDegCO5Ic+HjUkJeyy5IBvJRoNaglDGr+h2wiqwDN38I13IR/ns6irT7kLcZ8x4IVnxE3ZQiUVjcK/8LuvRb74JSzDYZ8dUv3CV3A4oHuEpnZLgbBQMRvb2txX9ykFJ8dkKfJEmI/Q00S8bYYiORviHxAWhnWKxYqJizZB3z+tTZA5WQHun8N8oM1zncoTHo+BkZDH3IWU8qvaLyZQHpxBvKbHtDvY1CbKWNxfNzPX5wWUANXfSECjrld98E/JAGroBOOtI+PtJcAQmTkgOTZ4M6NVY4f1FHbGPxzwxTkHTU8+DQiJL1dGsXgrM96UGP6YaZpX29nNOa1NFmOIFi6l+XmBIv0hkVYgfjUcBQ+/GMA2v4OUEUXwJLlMth32rzEjlG6k66vfVZAgR+nXeJsFtfBktF9usAR9bA2kX/c0IV5PoKmSBJSx+txIAfTXoay0Qj150RwLqT6sLqLxby1Tg9QjbmPjDKd0RmsVwZTQb1weTT2eEF8ocK1pwsTE6GCIzy69zn2Qb7Y+Q6ilayI6ihWkWLZRRV/RuiCxDmGhBh9EC5O2o87eHquuHvOdOhWTI4CU1TPTfZZVEfN0lNeqheJcBo+k1NepxcZdjJuLRLu/w2jryFWFJjVoMWVgybvAQvR0iFcB1ORLXVbEiqy4fsXlTWN7hSvWUzUD0dIjM41P04xD5An/CsBsvLtHzIbIfE6IfhjyrSvT9EIHcF30cIq7bjX4ZIq5HjD4NEddZRT8NUZoVfAMYdURERojHBluCtxUfcWktHvNwSQ0RynBJhZOELcODKyyifIR4YDBPRbWxH8p9/VBwuQqpMIHtwKsKOezCYgPZyGUDHEQyqir0UuKhar6u1XQD3y0ZGV+6ZbjV+KDVp59Fu3icAeBldDlehts11GGHf4v25eK8Wzog5SHXXkEGWFtSg6kOK/4/VOTJBclZMo5l1fiUFK+yIQE4WEbJelWaS+DnrYWljjhL8sdFsBN2i+z9dEryfR5n8xtTQ1yKdsNKCgyfK/RmhBf1+PWyNDrWOQj6LmH5KnA68hXUFtdXpdvt/lRW6N0In5HxlOQMRLezhD1JGB3IBtz5BYxJVOQKPrcZmuZ0Qgt6QQ5+iygIdiV+5Bj9/nB2dcW4OWoWNEP1IyiggbLVRmBhil7dCyLf1xGxQnD6sHh7L1j8ZGMhI5n6en96L70XQ6v3enBKLx7PRniRJhPCpsmARFqLu+B8Nqsw085l3ZycUlaQPOicN5b5h7MQ1WtY8/99rVhOzE+1zw2MiyFX86EPI/xshHJxOcjyuMiy8Ul2iQPjP8KwcAF/pQYTfBiFxvtXyuEsgkvPgCDKHM1k9LaskDUPVWX1Jv8ChxVjd5Vh29b/WnnecQSYjstTmrIIgoYgZrt4gFtGic9HFZjj67HFONhB+2WXwPUJzbAdWK+ESBtg/j+YBhmagbm/8u+YVfqu5RrZx+gyROru+0YgczRPB8Gl4mWIooWc5H2Y1iyPGrOPJFDpjBAtvpD5y2QavRmhVDLV70lRkDxaynirKrQnW6ziW/Ub2HIg3RPwo8aaBBmyTeGEvX7vh7OuWrNWwMGNly9E398dfGuTOF38dDddCOnGBV0M7wR0/d7ldKKsw9zbelzLBvPDuvF2VSTUtpDK6nUPuNKD/5BxlXWmGKPFe34WdMTnjVNOohs0lThuCIQp20gzHgJ9Q2tXu568Mh6IbD2IbioaF6L8fhMknaQ1Ppg3QPNfMR/InzMrg7skjRo3WfCW/A3L+7kBEdTSPbg92IU3GUQzMUQr9BugfvssEHeZM6E+Nl12s4mrZVdog32DaZMJBVyY8PEmiJrUAw1oN0BNZSpwofGvN0HOymnQhHcD9FQKBBcc/3oT9KxkCU14N0APnoXgBcVL0rJM0bWq6hK3qqQpnLJBTieU69V9YJ0KinadRk4HbnXVyyynBWlLCqELJXRT2YFsqimolxNv1P3LiYq6DxUcGFBk8pLx54Tx02zQkuLZlOt8ZFaTWi4yq/L9pKNuTYjeSHu+TqJz9WRxJHPlS5crHjr0NokWWBOezsv6fIQ93dUQ4XuC3AkOAlTnjk6U+81R80em+udyJmnNeEDsjAfEk/HA4HBCkpzkz1oz5Njl6pixmziwncqqh9E4m3m3LS+QMEUlB5goVlCyKUlfDPezNCWD4n0+9ueUdutI2I2mTjeNRnYuG0P+gjI15ecXJL8lxQMIQemlfzilHkFZR7rMx78TlV8kOYVjyLuCulBCNpUduKaanU0H5hbmwJnTD7LqHcytAtW5ozxnd5uT6v5WTRnWq/lV8yDn+V1yeqvJLZLTzh3xv/ul3PuVGpQR9gi/S07l1P4yGd9qakHmuaOp1frWNjC80IIlKjcAimomwSMZ0Ut/ckco0YkdebVaUkdeQUHSwbJ9wHShhGcqOyBNNSO8JtOpX+qSRVpwFRVrYquoYi0vmeBfJmOpcSsmUuOm1HfxSpHSefSXv4KlrxAsPZ/4g6XHbcHTi8mawdNlR+lkSQyoeGlgqGKyYmAoxCWLx1P6PUlJrt+fzPcjMknSwhT0e94GPX/1trBTHqSiDHkhRxT5YUclaPtjx5gtbkSvEj+9kDF1CmvgcYlcy+AsVN7uvinNwr/iZP0VJ2vFOFn5ZIU4Wflk3ThZiLI1Y+sgNsHPSpTx/1L2WuyEA+AiTOaJelsGDMgj3N5+Ng06lw98wZ5EDZ0LbtIaqCteErsrnbTG7krSI6G6criexpNB4HzmLbKiUTSGt3JAjWv7jyi6pvtrudW14TdOsmxMEl/0jeunh4a+8B2lAnDd1JXhX4HN/kyBzehoxYXf3tbLs2R9g1hPDlvNd48oGJ2+jn3ixZj9iUOxSXbwbbzjt1mmE5RP1rFZfr26abXsm7/Gr7aFN3dag+nQyUrugeq6kExwY+jWlCRT6p+PVM5Hq0cfzMHszLOelmvG0qXKapOaWS6c4wl2sVOec14pWU+c55B5SSYnJL8m3JojlC/xSnS21gOzLxxKe5GOsjuisXx1GoNe1yMwOdXlBFsYy3meqnn+QHLfxOaTdsLgw1nH1zOZ0gcXop/O0uk3lUzMjP0sLZJBcUcT/v105QmXHd9ozs8m2MVbe1cMSMrIHQ2mPFvDq4J3fKPBDCfYxdtyFbmjkQzP1vIPuSWjVYS8IqeVFB7UHbGzKcl5dy+G4dVVa+lbMgpv4a89mmBropXHrdq+r61e7nUPL/PXPm1g82L4hyEznyyN2RdfE8qv/dqyLJTf9WS4e583mrYQgdffdMprbzo8d8fMuenM7jvQoOd6VK5+PSq916N41etRvH6cwzsV3+ltxHe6ivj+V2TGf8HIjBeTRmTG2BOkcT65ZZBGOGzeJHkyIQXJWe1tSeSp2JjmdJLkc5HBhptTPZjqJp3eSi9Qziq5nXZt+PqiczLBbjUdK1F98AsjF+sKIycTnzAiThrjjRpPcKNn91n5jiTW0cqikej3RjLewQQ7WEuimymie5+P7/VEF8/nD7j5gSX8C5wcKpwd+KlQQLgR9cle/GR3pGaGuTgtv1WvvLxHkzWjMbHJkphVigIiTa+/a3iwywl2Z8drZ3FHG+OXdTeG6v9GG+TLBHtH4R3hSmxTkrwxVLkx5epu/RS8X0ddUbL+fUeMc39y8xXRUQkmuI6WHTbBExv0pvQzWjN0wjuROWt90nk8wXXcVbL6SWsokHhJdJB0cpfRQXimkz9hhBD0YnIfPpXPp5ZP5UA+8vudKd/cCwI/OAhkk2mWkhTIzIfCy3tB4XsXBaHn8vb/7l76/2T3ry2Zvb7F99L/T27/2syK7/yk3bv27b1gU9g+xmckGZK8xcn4XrrP7e4paJm9nR/eS+flmePhLJSU3v6f3Ev/w7Oah3WLW/W9dD6zO8/Ec4juXwZ3E/3/duv+wS5rX+T6BVRi/Ci2UXnNUYkNKuI9qYNidzbgZ8k1+vB3GKKte5mZ89rM5Es25Yd7weCFjYG+3PoxeH8vGLxxMSjOwJ3Sj8DXe0HgbR2BljPq53vp/ande7489sOP94LBoYvBbyVhxZNsOPfj8PxecHjm4sCmWSuD/OFeEPjRh0ALIXx/Lxj8PLIwUB7jvu4peyK0xj+wLBVOc5bN1BNlM6UuUFqjp5TNDyyf806IPt6P+OmMxuMG5R3aL/cjifpwke5QXjQ+3Y806qLBNRje7n+6l+5/aXav76VeNMjpfaAxakSaecf9ynwY5Kf3LA4cTSxxgAch1ZKA9asMtNaNCwM9OziE/5Z+vsotvTWU443CON4qhKMVvrH1GfncfkbmF2lvHO+fJ84Dcf1JmJECVG0qc7F15g31szDqaO6k34fTU1zL9igyuYhb1Z2pdq6Lxi9vcS3h99cMvb9C2H05vk5/DX1M6wperGUI4OqxZYr8nyehq69lK66o8fUNrVVlp7i2gnUtmCpeRdXI2QlPR/NAr9MNtI2Nvl19o6eFRRZqZNkpbsBRcd9PsZd44xZi3mCnq1NwduoGYOX9yzh9686hbHa7OZRA/Drb6Slu1FQ7W/68o509PV1ZDap6ttS1o1Ncw6fdY/yOEH4+WuV5bnKK21Go4bja85KoezMtvWjrX+pTg2kNrzt6Yjo9XfGJiY4C7lkQ3jT1h2fGl6T/ME/tToltq3nzp6j5Ka5No7YnVBrXO3pH+GEdk0LV940eEuJT3MDeeNje0XB+Wv1Z7V1yeqNxXJ5ig29PJfNdmrll7WE8Xf11x87asvJgdu/KrPCu87t8OcUtuV1+O23mdom3/iDDQdd0444W/cXqi667/kNsSTee1RZdWgyBrNhYe1OkhE1yGrqWaKFf4DQyi8/1JQtdmfPDKa4viO9GF/91v/s973fvT/GS6ZcL9Jy/Gt3RLipW30Wi3xsdAl9PsYO1HIl4/4r/org/juJ+PsXXr4JDeOvcRQXoW91D3U5vcgn98RS7QFQOWnVEygm4j4voj6eOb8vdXYyu1dGoEOLhjQxfdJCXU+zi7ZCCLFqDEhRWNyaEfWVtex0d6PHrXKOn2AEhqYDMsW99Yv9ybfyyxuJ/MovP7Bi6d8S6f17DJk7yoRuw7mKOfcijhchDkc8rfD7qqogAXdV9V3beNYGYu5Z3rpz8dI6byZJjX+bkjXzempk6t5Pf2ZXuX6GnGKqUrdBCJOVgt5qVTM+KlRs39qUs3mDts8L+RLMiMpTQW81KombFTkob+3IFb9D2WaF/olkRWW/Gt5qVgZoVHn9ZzYr9Q8/KeL5oFULG87VuNHctjLSorJw8oxUXv3SmnPJW0zZV02biKWufsfoXPYHlkgks53/cq0KDsHi+mbNbTdBw3kzQH/uS9Z8191rb6l2bvGvkoixRmcyvSRsXX5tPbrRk5UbzP0b+Vvm07Si5dyQZfFxdS+l0v55OTKm059g3Bt/wXibT6YovME7w4AcT0fAmYqMPAb9ufj7Hvso97Yk1vbuHmPl8lXeNizl2uzVxAO+IUsjqVvy/vDy8kQB5MscG37oucJ3LpDbMu9V9stH1Ta6U8Rw34EhudaC4lUb3Xi6W8bypWF3jOmbm8hY3snrHN7iUzea4DkXO41FzHu/+hjabW9dz9dgiTpOVSNJY9EtTshuRZKNrP3+6nONGTUdne1f2H5fz38esQ2vH/pS2GjWpSj64zLE71w3qeavMJtekH2NueSsK0t23uIfNsaeufpaTv+9KR/U70ZHC+89KSWppO+aFuwb6+WS1N56zopg+AEoq2YNBNlzppef1HNcXtkG06/mAW1R7O09wHwLXHyJWn5quH8+xD1ib13h8537kj38nWreeMv+cxK5Xp8Y5z+d4hWVoUOY6AqJFl7eREJud30REfDHHTUByeAbRVWXDm5Lli/nvbKH5r2R3+WaOV18Jz0GvnEToDQ573vbBCW982xPfwsN/6r+c45b6+uS3vt0R6b383U5/46zzpxUADIouCb6Dw7k59w1iEy9X65KZeHS7JX3Jrv2U9WqOGzWdJ8K7oqZXvxM1KSOHPychiQVtlyPfripHitdYmdHhWhHy7Ry7q9m8MzsORWvfnWVrcYm+LUOs4eIn3Kdz3NrCm37qrgj56e91uXaw/9Nesl0sXbI7nGP/GjTI75An+VuP6HhiwNtRGu/WT19P5rhWzwq5pWUM+8dtCevJ70RYIibnn5OcYE1rRPRsjlunvEFH+zJEyLq0pEKL3JKedPd+mvptjj111SDU7zsip99+J3JSeP9ZSUotbY2stua4PuOuM94dPdB8Wv0pT9/B/ggb57phuwq08gEEiltYuH+YY3dC5fPA+7l2H7M6+sOM2w98cWXuykljHRLwoHEzi9059gJzaV05x8XL/dTWD/c2vYmf2p/AtF/euOoELz/fs5j+8xy3LUjNnXCN66SCeJvLZK1b/+n24xzX6nkvkvHd3it//OteufReWadSCCf8FqJShgKPnsrM4iPPXitNv+IkTV36fT7HS9daa/OLs5bln6+7/G/XcSKSUXnWYTTL4vCCB0E7OcxvTw7vfWwmq51qMhTVA4j586Agk+k4KfjZ1gyt7JR7lhaqdJAdw9ld3x/m2F67hu5xfkenx+E67oCW8jD8H+3x9f0cN6e6uQZrGJFYWuz5bexImr3fwJLk4xw34Uju9IsSFiXGMWB8D+YkHy1zEn9GyZvyqefTP45PMfzo4SLY+45cTnPCoMWj4/+u+otd9Pedf35bhdXDbkFYEQhJmzty3+ch935F4Skv04JOyAOD9iq75NMc15auZhxxRzzq2To8Spk3/I9kUH8qEfqnOa4tdG3515CcldnHbSTnWrd+yZlc4Fq9nmJ34mt8ryI0ufhLhP4DROgHqU+GLi7waqteo+u1jn1J17c68p1ub3De5xe4BkTOY3qBa9R/9wd9fqEPeoXEyk8Qevpu/ADhdOlnCewCO7XqDOHeXiHYxV+vEJ5XiMzalu3PESqR0UZM2atycmInHu8ci8FviIJ+B2MsxmUNtMiEECbGyMLqIsk36AWOB2Wez/8eBLqHPElPicgED/zI7vDqyvqZhWFxlmczyL7MfYEO8jzLg86TrDjbSPJTvjJso8gExI1JCSb/ZCPlzVkn7I2yPOBoYJGMm32X7WUPWLQTohKz73b2sm9eJsVZNzlhAQuj7AFDMd7pxd+VvZAex30cf8NQ/A3eVWtFK52uOLnA9MJM21nCXuePh0O1j6DbEsUqKxTrsRktBmdBHC4GCSMq7HLEfwiMO5HsZQdjzLa3dx8yjB/sPtzZ29yk3Zi77B13Hux0+ldXQba9Hbgf8eZOiDZ3w0hm6abdOE0KekGOSLGX7QWl86XL6FeCnC8JYB+iRi2McRlGzuezhE8YxHLcUFjsMfvXcdzAMu4fM4NlvQwvKuSv2uNzpDJ1RXQUOP2KuZ5htrcb7ciFsiYs7h/PfKjMNPxKTnsdIbZ3vLmLNnf60fHmDtrc7UNtgYxadr1kf9Scb27GJmOUPX+hGLL1ReXm8s/+Metbk12mQzKiKRl2Is+ENmfTXSoZQxRWSqQrxyysg+lCSQ0Q/6ZBDckoKceFRCCIr2c54b3OjE4pe4HV8gfhwvDNI1IE4YJzc7Nq2JMhDmiDp+QhBd+r4tQSHeJFpcgRAFqDTYZD069mzZsW35FX6bBCtaZnCfM0tVnW5q7dtArCHuypgY97D+lInjuChRsee9xHJd5BMWbdMUlPizM0w5n685If0eMLtI93evvfzXr73+Dd8JJvgOx4vy+Yda/8Lu7Jr+y47IewFsdUAulj/g2Vfo5cXuDBhXKZa00Yb27Nc5AulLBgJRzvqW+1FI7+NIlWQytXSa1plEGSFOokSaH17IYWJMzcoho4nLVIHwuTK6WWvJFWPMher8jnC1dKasOh5wTn8yVmr6fbk/H3nI4xpEenMPryQiT8Z0GIsrCPVlQboCXY1qbFmxwSrajNrgYJnM8sXAihw+02Q0ztSxP7b3qBm3RWE3dZfI1ry/Ri3Wejs3ayuV6Tw26gm2ujwnv3qblGNchfUcjpweU0+Pzfwe4vv1z9/Zdfrr795Zer//jll6v/88svV1uL5CLY3dlB/9jZCbvnGU2DzhXIx1ufw9U1h9OLfzkHHnWvt19Eemsl1qQhj2K/gh9QTQ1wdoHbiV+5Zouz/X9GpC3LB+zuY23t3cDV39BM9HzN0AHN1e1IOcy2jBleYHcFe7W8zHekqT5fXVOtu7aDBl/gOkZ1RN8lpytlfdS5YR4UyemNNCdOl37NyeQCO7VU2mulVYJvHjOjladzcrE82omdMc1SwQ+Swg2WK9PFujPp9+O7fj5v58bn6f5mXnzzC+yBJQeZTUkaJ1MafxvvxOt79KU3iLA2O1gWYc0ZwXp5Pe/ZM/C6PKA/TwIKCR7Wd+lDd5pC9OICr7uqcjueqO2oaXgdkti4uFgjVMeFTsscq16bD+lxuxnDxvnkX+gYtZ2p/iRRKx33KcvoUvOKtcy2Dde7ldV2s3P/eXJwgZtVW2y24xWNuNUq9lYm4oOLv6y5l1hzzy7wtWtRJ7p1EgoYmrtNaoFG19efsro7k+L6Ajfg+FMRxHecmuDoYs1T8lm5hBi96Qf+qFTYF/iauWsSz8q5zm3auXHW83rHq1CO6EwTzheHcGSSdF6yr45FNx947E+evnG5hrT85cIKcyTsmu7oWvPmRpafd2aA4+NqWysrEaiyysE+nUG3yN5PpyTfTxgJvJaa/IY5IcVZNlSqgzvOTPD6AtcWTBLL4wsd16k44/r2+E+UokBgvMbu5GaxMIrb7M5avzfYnOcXuAZETveL5nTf/S49b+7S9W6iZhZvdxNt9n6zi+ibC9wEJWf0ZXNG7+vq8cbMq+sIe1fZbNaIIF1zJ76BS9G7C+wdhTobH5fF2bNxNrurXD3xGoor3feNRvbqAjewr4/qjgZ1cYNB3WhMby9wHff6kI4G2XQ1X/8sKYuzB6NxNnvAeKMbyTBuvy1+/Re4Vk9tIP7jrpz4L1YJ33l4gZ1eTfqmlZjiTZWc7brNJxdYqDTrmszYo9W8qZj/ZF0xP5ssEfN52qk/SKx/doGtebHnCC2siMnRbxcrR1pGWxd4oSpGCz6qKJ8g1SRayDbR4rVIzB4ttmT9KJkgu9uFdN2JxhNE01EWLUBmizp/e6g6eKjAPpRQH75IR1mnQlJ+iC4nCE4xtkpToHPWqZBxe1+llZUFDboV7D+anyKg7ujZBSLGy3QlgH4X16qq0AsxB2q2ytpsFbQYE08PgrIqNCRskNMpgFtSqyD5hL0egdBEB8vAgYCeDIrV5ohX7VRoTAckZWSVRoeiaqdCsJKA9HRSVWhfd6vm4aw2D2kyWYZ3mY+XlJJJQpeUVxU61ENQCAzvEIGqQkJeteAf1OADgNlkxfW8kNmfWfR6ooGrlNBWJ19qnZC0nCwlJmE3dUty4wtqbTeFTXzqYiODz0Zf5kg/+0av58jIttH5HKkgddGbOTI6VUpY9G6OpJNC9HaO3KAsLDqcI26rHD2bI60zjbbmVYXeSO6hEPthXiElOVufX1+4+PLxPr5ArJzAKXXrXXlKVtpo5n2yQtNy/SYZW7vNkIxJQdZtlfEBs3WbwRquPSiwuFm3UZEng7UHpQ6dFxc2Xb68qCqkq1k0M6rRDD8wTu+OZu7o4EFaP/diuKQ3a8QnF9bum0fxhbVnV8DDGBFV1m6cXaAhmeZkkBRkGR76CL680AuyD0vgHZ61HF/n7nKsNsnXMXN9T7Z6+nC61nlB01uTQi50yMOlgFaY3GQ8zmYHk2kx59FIli1DMV8qjJDL6Tgbkuv6ekv4Gi5dcH40RO9PFftf2qs8IA7mSCoYoyNg89azoLVQ39+IJBTgX+YrzHtVIZ2WzOr58cR3/kXpWoMcnyIV3CIancIWUL9MRz/XhiiRl+i0daHO0ufz32+h+SoZNqKlv4v6GgnBZHhhNbDq/3SjNVUDLi708qYXUmjIoCf1mGb19GkOTEetlt78tf7vittfXLMhnXhF0fs5l2RTG99R8/Yl+P5bMoomE+ccOJ3YDD+eOAz/tkNhSgC+9qgQ0iynDeH8Z/Pz05ss9P8CTvnzWkzk46mmeAJE8y6x2cflekfZ7yq48P0v30Lsi86pR1T/Ddij0WKsRHu8Zkc0sztaQcCRlTsV0v0p9Iq571b/20WX/4Em5big0zFkm/vtomt+oUlySSflhH8VfyJyORiXjF6Ql6as/g1NaKrb0bTWzpTVv0F/h9xfQvYofgA467P6AUI4rBF8lH8CAJ7FTbbnf0Nz81H+jcqU/lYS/d36CUBMOjMJyXwAcLVi+4PZ7L9ddNXfiKRyxGk5QTzR2HCORC7IdI5E+sNsjkTGv2SO0qyIJnMkct0N5shK4Tado2Q4pECOydhCZDJ3dsJvF13rJxpl+SQp4Kv4S9+1eT3+JwKPHnmBb91rdqqnVcjSzZRVoVlOC/I6HS9j6JeT8SqgIbXS3V1Irudeq7DoqkJPa1Ok9uBpbQ/KJZ2/Ws7dZBKv6AL45C8vDy2IJ/O1+CQUs2myVP82zcmIXi6pkCgV8JI6szyZTq+ZJfdNyxrUu/oFdrnQuBrzv81liCkM2yqckCQn+TOxwdqrwTvOaroJ86zG78npi+F+lqZkULy/5l5oWloT+qo2oXQyHdMBLdZChd/FGZtl+XDddoMxJWmxn5MhSQuajNm6AOAVLMvpV2EM6JdalgGwJ8aal7e1eXG6WTrTqMi+kGuq5GSUE3a2vJJ41YsOL6wNYVlmWchOTivB6viLo1UwmFQwQMLiIk8uSM6ScSzL4lNSvMqGBK5dYLkILuuvShNB4/PWwjL3OEvyx0WwUzOD+cbUYGM6IMFuWMmno88V+nCBF+qOIr9GxzroRN/SwvpLudLdV+TwUF8Ffel0PkoW7n7zsHsfRJns3FMCLyS+71Jn7yvSt29foSbGpYXeKZOvW9/GOy2leev4TOY9b6FQPreVeZHREqe/UF/d/MXiDr2szNupEG29Jc0NtKwaB0T8NfILkreXqHcOX413yanvc7fb/ams0PsLvDCnsH7NXZwkjERZhZmyxMq6OTmlrCB5YIxv0fNpiJwS80qIfmiUidcx9H2twJHgOuhjrVjpdjrol3qJ2F0d9KlR4JOo0E+1ajLUDyrO3O/wCNtBee2rfNProLJRkH7poGHt60RtuA4a1YoyfRKgi7Yi1kFxvUw8E3fQzFMg9mAHvW6WScU9Oq8VGXcQ9KJRJC0l0RtPCeugt7XP2hqvg542iozVPzpsFErvOvSspYR10I+1IpnRD/08qn1v7rcOet5SSRpAoR8a5VzXgr73flc7rYN+qZUXyWkH/VT7eDkZdxCZiHTOXy/w+ws4F3Nh8pDlcZFl45PsEgfGQpThQU6SgrxSuzL4eqHsIY0XCIvA631KENU86VWJKHPsK6O38Mk24oh+gy+OVWv0DD69JJMTw2G2SqTCPewLixR0lqiOjrIyH5CXydSW31lVuSOTf+GAIcuPHh93lMgBzpAtvodAzB3UURJFp4+m4/KUpiyCuAgVXlRmukoc7KD9sktCMGKJ8ZBoUgy2LkI0A1uWYDANMhSHoYg08PrkPIorbWSqhBP+b1CiWQiXSv6e9kb0ezRPB8FMyR2IooVcuH1YqiyPfIuKJNzX6g3wC5m/TKbRhwuUShnoe1IUJI+uE5WqKqzNriCRt+o3yFJBhqiYGd86BJm1DEzGT+g9n3bVOi0DH1y/atpfxF62EP1wdx1YJ4vTxfd32IU8oBz4n+4Mvorr78L/eGfwXcWG28svdzcKfRw7Hfx0h9PkPbyd3oqzu+pNBiN0wed3Bp7babnAyzsDrkyHXPjDO4Sf1jf06M6AvzTykdPDxZ31YC7cbg/x3fdQZ0qzu+tCH4eWaqCDtDDYD9Hre+jMHsz5XcLPfdv5xZ31YJyn3R7e3GEPSjR2Onh7px3U6enpnUE3jzJuD4d32IMl7zt9PLvDPtS1wengxzvvoL4QP4/uqgf5ruaCf3534H13IVc2u/O+1JXKlc/usBtxM3MFmzsGby54rnhzZ92AIbwLm0zuCja8PzmwpavTz8rVSYnp2ila+y88ny5UdIQfdW2TVNf8qVv8MF2ckmJDqQpY4AZAOSWF0SOASxZzKjMZ3Y+51SB2mMLjucGDC+ax/Fdj8L3B+QdV1xGCY+eXbvfRtPtetTuoJ9wx8YRN7Y+6thDlVW5hXfeTqfvLBV6SUir2ftVwfjJwPik4QlKV0Z91zeKMr4JQifiWQJTI+ZfVnMmXFeyZ/0n1CeJrDP/R/eWiP6kM83Uoi2SPqqLTpaoCfXLshXGQF31RpPCXFd0ByCpyBBzxH1iWCg77lCZjMnACYr+eKEcd+c4snOjqTYR7LYsePoSbc1dKXKyb5acPs4Q9/La7+3Aoqj4EfWknXM3JRznZdM7rPXZ0JPgT3EBHrk1xglUCSj6JsfxXD7AUK0T549aIktw3raZUzqxV3Zlcq6JNIanBIv3Cgx/r/odnmnanqpYW9mP9l64/ug8KnqietYge6790zxcG03mjPjMNDM+LTYtYtxB0Ect/dd0Zr6urcAXtbs3Jbj160Xre3Y7PARauA2I/SVL1TaYskrOpKjrTqaqo3QnqcC8Nwd1WUg9UcekGChWEBqH7wDV3g4DdbOx01GymepVm0V6S0n7XzKroEpWsoqBxxXfbGWefb56zzUAxDyQ+UKZUwrOqO0Ctima0QgjzD1dHD2F21dqAZSUFEQz1fdDgu4TEqzhQeKGCYFvC+CDZ5RKi08SB7FRWPczIyVmWffFCV2USsq7qQNWVkHGvnJ1gs2Pldj+ytrsMVKX/MgGiBCuzXjx8eLlZ5FmtgYOdXdVmb68VNvq+azzVNTYv7oOxPjY9C5ktVn/oft+Ifr8/eOfr9JSojQ0VnO6+P3inF/bNe2/raalav3lfa/3mvdX69ZG/ecZ0+9dHdQCvjwyEpweHB+8OfDCEJ5CEIqs5cMQ3Den1m3cvXr868jNj/jQgYamKDjD5UUN7fvD4qQ8UvGVKOLyKAwS+mLl5/G7/eQsjG5yp2eGV3OmBTxrKu7eP973Tw12KJBRRyYHCPzkU9cKmKMbJyRy4b80hqjUXsf5LV3t6tpARXboi1roOkeJpZeK7oI5lrNVBQLb+FTeVwgrBOJwxOUAg7jfYVJKcGy5u7lQhuhFqWqxtQ8uIxk2UbJm4jo6c9zcn2IOBLHx50oyQZ4fG0zN/aCSit6qNFFhMwFr9h272zDQ7NF01At3q+j8aOtDQxakv/7mVYCX5HCfsrXZ+ueUwzC0fx9yyWCaHdpENkpNy3HK7sYoVVKuBC9mqqqHToRcqVawAKrhQ6NC0TtLBWea9HsgiBUVWdCHJKhracJ4mEzp43A7UqaFgu83cLtwG9Z7ektGybt6SUa0PaODtAKpq6HkL2NzAyxuAchvCkIyYHzMyYhonqFTDBoo1lEE24fEQfIBkmYKlqrrgVCUFkRt3+8DxAglLVHIAiWINJZ23QEnnBko6b0BJ5xYUblbug8ILJBRRyYEiihWUNPNOT5qpmYEKTnsoUq2pFwGqeqe1rqnptzgjqa8tfJeteRWnPS9UEMjYrzyB7xICr+JA4IUKwpBMSToEewzhM+4/uNw6EnKjae00qzXSNyBuid2u6zPF6jZkNXDvRFZVvRptcKkFkTZhUQcKNzKjKYND1n/DEuX6fmVVr92wZEUzeuXX4B+8KtVj19VrQ9cVrZuliB+/tIN6JXPjrDWu3z5rzfQ+9nhseLe1p57a5T4Q7qb3Na7NKHc3WDapooI7r7KRb2plddVLmRIwbk4KMmwl3Xod2VejqdNdo5Gnx+VT663Y7Lttcv3NNY+aT70cBr7LPngVByQvVBBAbPRBgO8SAq/iQOCF1m5kRcs21BcxUam++Zjh08b7ywfKlEp4VnUHqFVRQxbOYV6wokjBlBVdgLKK0Xa4Pmd+jYdbR2s9ak1rmo9aI40/Tds6kkUKf5o2waoqTfzbwdbrNPD3ddRoZM2/cJxrWQFRaNZAVq6vgqxmzcoSqDR1odLUC5WmNaiSky7hzS5H9vJhe+St3EiVmXF7uI+uZI26HSJNHYg09UGkqQvRckD0cy9drHmWaVDjVKaqNQP78oxtmYR996y2G9SnYr9+Wk9ouhQ6TevQadoCnaZN6Lb3ZQv2DY7uNqqPwMPBHSfOllE0e6GtsoBbXfWiHELbNJVQZqkpedWGjpJXakimb5eAblSqy6ZvvZ01m6leud+wrydeIKGLSg5EUWxw16qc6zVBrNZgqVJIQOfPjX7IMjGMhCoq1iCKKtY8S+/PlgmWpWZmVfX6lKqKhiaSIXjE+mlClGmakFVrNCEraT29crL1KupVodLU68quql5XMyeW8N73n1SiTJ9QsmrtZJKVFEThiuyDJ0okNFnNgSUr2DcRkhbKnKDtMmJVse4kdsPGtcRuUutNv64u6U7XcfszTX0dmka1Ho9atWVOBbevI4/mzK2uadw24PCSuV1BUbrTyCV2p7rq5XIy9sEG7w0BESo4cKDo93vlkmS6hMxdKvcSuf3C9dsJ9qtRpU726wl2wxVbZmSx55sxIxppve5PdRi8BxK7P405kWlJYicOvYxsbcx+TM3CramMt2L3pzHlMS3z2OSBgoigxvTG1ElVnV8m4/iXyVjXIRPP0/4dBQaenayTpgv6vkESQmlJcEQmSVrQwYEnWVUzQr5NexLA9yQluWnPVs1DqeiQxbgxgciKq+oJp1qLDprF4G1c4a0LuWhULRpVtku1hdnI4tVX4ydwhF6OUDNSZ1LHaaxwGriWbHXMkjUwe349Zs0QnoM6ZqXCbOxaE9UxG6yBWXGyAmZOSKZpHa0zg5Y0L6ojNF0DofSk3S5sebDk1nQFAJaceDI9QuJWe6O0xSrOanhmVrziYYzb8LxmXhsxVUf1mZ2omWU2b63P7miN2SXxtcvdGo31tI7evIbehZ+j1/E9XYc8r8fXF6ruoo7qiUKVe/rGhWPdVkfwYg0EpycrTOiy0AwHdVRnZlZFuzhffp7X0T9YA/2vJyuwTE8s3KM61peGa3rskOsoHq2B4o/X8043/teXOm77CrdCiA91bL6sgU1+PUH6Any9ruP0WOGUN5/16/i9XgO/N9cvqC/853kdvxexTrjRMFqq43e+Bn6vr8evETXvTR25lwq5M9vMuo7WmzXQ+gRE9i7GZ2Q8JTkLgmBxlrAnCdPyEmhjWMSMDzdnORma5nRCC3pBDn6LKHj0lvhRuUFTViTpgGSjjZ8vrq5YUIbb25kdjQE+0EAKiPAzDNGre0HgRwcByxjRh8Lbe0HhuYuCdN319f/0Xvr/aPevr1i+/g/vpf9f3P69PrM+bJ7cCzafbGxUXA1f98/upfuf7O6F6bGv89/upXNyYnXuMUeWmDAuS0pMtu4Fk8LGxMQr8czEh3vpP3X7T1t40vt76Ty2O9c27L7J/3rr/iEcBo+MEZWASowfxTYqM45KbFBRVvuxOxvwsxSG+xCdIkQ/38vMHNVmJl/CHn68Fwxe2xhYcWd8GDy/FwweuxgoH14fApTVgk8pXy0VsmzzeWxilm3u9uDT2zJgItee+a5z2zVviaKqvCkq6sQYLhHZaCPb3s66Y/7++Ghne3sz67IiyQv2My3Ogs7fwOLzh3uZpRf1WWo50r+/l97f2L1bEYX8i1QP9tVYpe//+FX6eC/z9NKdJ8tFwDdTv9wLDm9dHJQ7tg+BT/eCwKEPgRZy/eleMPjNxkDZ/fq6N1GbYsqeZNmYJKlR8GjlKX70BGIhbm8bxy4VhSnonIhmD0C+eCA7CxE5uI+BfXUG5nMk942yuBdcfvLhohzNfWjk94IGiR00hCO6r/v0Xrovmt0bR3UfGuxe0Jjai2EFuPNgoNjqgXFU0q97j2dJTqwMm96CxZQXOC618lvEKvm+JX5jpvW7IGzF4I35bbwbM/tlq/V5JjtY84Xmt9VfaETXN3igsa4R4tVf3iYwOdHBseUMMEbTUxlkjJs+mnyowkS88ZDDG54l6XBMjBMDsr/Sof4ip9iehd4NcyW3pCYO1sxN7FRfLV28YpgQiE8y4LvIQexnyhm82f7gcXkdk2JDB9Rk+CKjw42dTYyXPMltb/8WB0vKu437ZrjXFGTWAxB5EXOf+ra31WJ6i1s9eq/DblUwUQuYtn0TIlbVKR6ExLfjwKLycHtbiIiuu08YLic5mp6RnBZkqFog9T7voYKwR8aMgHfBT/FKfeu4kK+SoGXUAkJ3QopEuWbXMIIjYWlbWTEMe+uOFV5zbbaht5NLRqKfPZP+3O79tL0bcFg67odhBKyXZ1lF1CurG9ensPdhGFA4pVTqULTqoKC3TD9P0wN87YEimWGiDjrLT8uT/7w17fzavMfiOQ8cNzKRB52DHWuk8j8wGzv3a2pN2Lv28csd/NCqMyNcrm56YK9wukpSGRxge5xy6JJgHoMHlSxxfjSfnW8yHbODVadDenqtPLjVEgDTUfCMR3HX9NOW2/eaWfdn/s3CiowZ0cANC2mrfuNMweUBvm7JaiubznWJ8+N3X1nhfffXyras7NSsbNuSuSv7OiW6xPnxe6+s9Ij8a2VbVvZMr2zrksmVfVrzbPyDzqSmV+b9H0/DA9wyejk3b4xL5h9E547/6F/U3kLtowPcXCqXcxknD61jqX/5vUnedo+9f2KfaI6wwlT0dPAR11FWN2gp+N2nsOkAfP8zeXqA154YZcmn7h5yKSyruNhnIXen1xF74oTDK8oarOJuoas718WBjihZTtSArb/tAd8LRsI918LoRGH0tO489btdUD3eXhaCcY1UZFRNTWm13/c/g9p1CGXXmpa1Jdc7OKgbYapRDikrWkKUNuwuD1bnJj9crK5kvnZUblq/o/pYLtVYLoVnRMMYcw2801hrClzJJL5OTFuP217O1+eXig9+OcBLcevVA+YqCwJWi0sk447CS0uSz7kJCbYM6IDLnz0QIS1WCybqULGn/67dlR7P/gH21O25kd3uauL3D2oTz/Aj6/n+kH4hZgfvHV8Xt7wfHV8Tmb1ftXKxi/VeLByGMaJjYCffx6ErXLIVHyG01UlY8YcIuRav+RnrzPg1m7OZbftxfYOeqw1K3EjF9Y36eI2N+vF6q2lvVGMLzxd1PN8YPEXLeHh9mOT6IF6sMYhfVhhEM338yzre7xTejn6Y1INI1zF9uQam31+sYHZdnDmJNetovj3QJtc6tl4dp1dr4PTiZAVTdTtDusLsaR2zwwNtrM7rxye1CHN1NJ+ugebLFVwQmonsn9RxfKZwHNTDpteRe7IGcj9f/HnY4ZODPxE7vJawhI2PtWK/1Vdsy1BVLfJgfcV+W2PF3p6sjJy9Gz/UsXvvZRp5M+xhHdkPayB7eD2yOnCthezXOrI/W8jWA97W8fu6Bn5HJyuxNaAMC70f6+g9tzlbTO1AtHXsflwDu8er+1418kT/UMfx+4O62xXzuUDX8f1hDXx/WmG1fSmYP9Zx/UUvd1IWZ/GoFgG9juPHNXCcr4GjheKnOoo/NVE0GNYR/LQGghPjH/qzDAe9itiuQ0ffQFJX/fjFczLDqkINrzsSycnsL5G8eQb5QD+3QXO/XT94SQwP0gQME5v2CVnoSv3FDNfWFOUzbO0S3izauuiqT13+Bfn5OdjOWHuHxW4mcekO4YBTgLoSTleC6VotVdILRIXX/bVJznm+uwo1THaiYYxkRP31kZAN0VSKu9diIVJoVUjt0KiYoYHtc3otBCvdZIUU+74J5qIlgrD46zeHVsiOCLIK6v78iZCSvRY6gdaIpKDFmCxHEiC4GEIbZMU6WrO91RLJmNXLstyTfMJej8AXnA7WRtVpjAY6bMMKxMCrdio01hEVrm2kkjNWCGh3/ZmRraoKNQNMjGsrB0xnOXgJw+kBWqEyH6/fsszHiEwSeoOmvFlVoWZsinLtQUkYzUGZpDVLiOnakfvAlzkg3wizMKnhfi1sAaExqStvJE97eyupsAlsbSi6pR6mJ2zDvDZckpaTVXpSoJweoTGSNs03BiLbrzuBLbA0DL77PLEKLmsTIB4OWPTlAOnr3HV7Q4F1+tatkfaSuxkg0xyp54wbwVGNkfEzojcemwUBCSfhm0GSbZHrD3IzWDUYCNxWbwaJt0RKV3QzGLo10jnxotcHVYXqqr63B+Jb7Z78/MAlTM79liKigDhoQDN9Hq/fXLZcfTd6odgM7ZSsdFKbBLMVmpbrN8nY2m1EOph1W2UqKfx6zYDw1x5UUgzO1m3Es7is22glAd9PMFrCX43peYGYxlWFfDqun2ubYyWhXEBpiuUr7Q5/+7W3hx+MvT/u6JKAtLLvxfAmKFnNV15LPyTT2jp5bjTbVnPrcF5hjkwO4GpVru7HwDB1E2v1ZguuWq9+JfWRn2y78oXcC4M3rSp03ZPfm9qmW5novYBbN8C1Evf14IRs7wtl9GLtq4mG4rmcpDdpStN1TlMfBHu2VMzmm8BRbVcm5jZ0NDEn43E2O5hMi/mHhtJrRWg1EIgV8/GNAPGGiFxOx9nwRgBkUzGot4RvlOGNh6QAIHHDWIVtSeOVSsn9NxsEb7ri1WEpDIZkZN+bwJBNqwr5n5UPb8pcLHCtm2QlvNsAycYr7rQ2KKp1VSFfTMIT7zX07olE930LIlkKgyFlOnEjILItnEZN2413NRqRK8OncvmJIWH51hVar3aF9ULRF9jr2ZS3/Rpcytv+BkzKC8dpz3dp8xn8fWOPrqDq0YB8Wh6rI6ufrZvzAgGrlRGstM5eKGqdV2QlHgiKj6yglvC25+1AjdW0d3l2ANJb3ZTsvDaNK91zJJjb3XJ8QFyl5rXCgQ8Eb6YvSB9uBsRpztXXTgDjs+Z7mxCa316nfgFA/ivUWzJa/TrWDmaNm1gDyE0uYQ0gVsvVaaEBxXkoUur36485XpM/fjVCgL6Mb8gvBKRbytYeIGsL1l5EbiZVe0DdQKT2QFnjpPK0vsE51TaSe5ekveivIyG1A1jxAPEAMAK0G9B4f+0b7bvktHmXXXnP1Fvfg+6KSwXNMMmP43U14hpKu0q8/VXRnpHWWvC2pqhQ4UnrmvtWOlVdWVEfouQAbdHhssoiXe+yGk623RUqwtm2pBYUjA8Qj4oQDQ6Qyke7pA0PTxCVB4h7s0fTA8Sdn6OzA5RmxVpblo7Wql6ckXStBmTMyFoN6l6/0fAAWS6v0egAcb+PtYCqLKVrNTLOmNHkADX8CqPTA+TL23mTPkQuzrVa1tNq3rTxDREHK7RofsBzpkcXB4hbay0hWZNVclklkbdxSY16isdlwGh6TY16vsXlmIk8h8s7vLaOpKLlPbWtp9XRdVWsTILL+9pv3xlWd6vUsnPzLYe2Uj0tHi47Q2rektHJARLWWbc8eLSdxdLOlfC5bBAiv9uSKjpb21JClXJRfIBE+rQllWupz66vaWvMrql6tL5MOKz5fV7b0nH07FTocjJepdkvLw/5TN3N+x7R9/120aTNo3VWk1BsHr9conIgujdMCwaaJNPptSrAdliyeVUh13v18mBdWfeXl4dNWRf+w6bJ4AZteTN52q/XWrRBiQoNsV5r3QzN8mQ6JcP1mstG3BKsxefh+9rcFtdqVV1QTn/QeB0LrlZAFgx0/WovAbTSg92S9jQV182bYyCaoxOS5CR/JnjkTWHZQNBIeoRcb+GhnUCEfUD6YrifpSkZFO/z8Y1xqQMCkw2fo8ovNQKjk+mYDmixFuZcQGFsluXDddsNxpSkxX5OuElpMmbrAgBflSynXzkH3gclyHoA7Imx5uWn2rw43Vy7MBqiyy5qMFCRfSE3BabaopyMcsLObgjGtEZskE0JuwkQ0dLiY/68WbO4EidtylwbBm+XumJX1QN3JZRM6TCbxCmLpe+E8AxmcZEnFyRnyTiW1eNTUrzKhgREFBW0/lVpYtZ/3lpY0SrOkvxxEeyE3SJ7P52SfD9hJAi/MTXYmA5IsBtW0nvmc4XSGdaOrvJrdKwyrXb6lm2rv5Sbb/uKnEPYV0FLac5HKXy43zyCig+iUG75SsB03vdd2m/7irRo6CvUNLS00DtlJpllS2neOj79AO4vdBJSNMq8yNQTJNQKtVbeXyzekpaVeTt1Ytq7Jc29t6waB0T8NUCp316ijLp9Nd4lp77P3W73p7JCbIYXRsyDLcmv/4uThJEoq7AO3px1c3JKWUFyO//WzxchckqsxFg/NspkxqrntYJahuIfasVEZ37+vl6iclB9bBR4k0P9UqumsjZ9qn0X6ZR+qn315DkiJ24VnYCoaBRAZqC09tWKJz+tFWX6OESTtiLWQfN6mUoCFHsKVEqeWbNM5cc5qhVZiWteN4pURpnHnhLWQS9qn630Jm8aRVZGj5eNQpVq421LCeugw1qRCtX8W/27L7fD15ZKKunCT41ykQ2BxL7vJk1BUSsvktMOymsfeV7tNBbxALIZZjOUpAPCiiw/pClJTsm77Iej16/eZDQtSI71Ht3IMOvmZFgOSBAEGaKoDMWRulUG1IqiLYKMeSJp0+4XMg/DngwazcJKBer+rQzYcfng730NJtZg+Hf1zNKl6ZBcvh4F1MCJw0oxjSqESNaKhxAWA4OgYwIBAdvlhlyEKMzyuMiy8Ul2iYNQj5rhQU6SgrxSXCvIZqqHhck7Ei263e6QmIwP0asSUeYEXIvewic7oFb0G3xxXHKjZ/DpJZmcGA68xUHx1dbg81OkcpfsC79ndJao0qOszAfkZTKtli6udeNm1UoTJP/CAUML5docZfi4o6S3DlqeSRt1lCzX6aPpuDwF3SDFx/0KLyoz6yUOdtB+2SVhwEIU4yHR2znIZyGagZN1MJgGGaT1Cjgur0/Oo7jSq69kQP5vUKJZCBolbqv/RvR7NE8HwUyJd4iihVz/fVjxLI9Wpxgke3utvA6+kPnLZBqlM5RKAfR7UhQkj24mvVbVagQsaPWt+g27F7aqmNvV1zfIrOVlcHjTKuz9fNFV678+KsH1NLKvz3mLSEL04313awkRTsfP771jKaE4vX68514PlBTj9PrDPffq6mbdvr+/7xFric7p9pd7n2ivVOjg8OmecXguRU6n05/uuVMeosDtkpzcb5fmeLG0Eh3kEaIhROs9I6Mc090pSO+917TOOaf33OVLc6Fw+p3c92qb24rT7/z36rd+UsR/EHnLLgCF2e+Ggj3wo/vvNffxzdf33O8bcxF1+n187/2qW67T7Yvfods6Tb+55z5NcCO335f33q91+Xd6fnvvPSvNgtPt4e/UbX2Bf7vnfuVDvtvp1/vu1KdwcaWf3wkDpc1xOifxfXcuVEVOp8Xv0qnRQzmd5/fc+bukLten99wj2Is4PcroeXQmo+e9yooXcMWClmR4kOdW1LznZwsVlD1R9bUYZYLicSXRoky/pNks1eW4k0zhcZafiA+zQUGKB6zISTLp9EQ0tSdz8fgcQA7KPJttQCw6Ogs6bvHGhBRn2XCDpnbnAhvKNtKs2JiTYoOaUXQ7YTWi6fDJ3AMaPt8M5DgpCGtiKz7fDKQKNMgFljcvPJObzBa12WK4cyryInZM2Dz9CWPM9pSs1ZNhozoRcwLmCYg8OJ6VVTgLQz1vDHe+7e52dzooa3ZHne6yvc8X6bCbJbRb6xZvLVj1OaoXf7O1yCq3Tg27dFjHjYZheHXFi+uEptbFmRcJ71kSWAM2Uxjq4Ipjrp1rLkDgkG/bAOUcLav7DVzr1mowTybjWgO1bQdqG/L4sFImMX8typz2hmRanPW4FruXk9ERKXoEtjVzk+eWOY14CAKuFN1BompEpWNVqZLqljnFMgENL8Gl+MFb4kz8EI0xFb9Ed6AnVeyjVHhrVI9IscizrHhLRoAl6w1oPijHSe5iCUURgEKqPMrw5i5XwC5U17yc/1CVcIZMzh9ekgyH3RMgLPgVhpCUdYPRryTQtKKhySzuVTIcBswtPktYwMKrq8BUls8Dch7EiGSyS4yx/XWPRfZPpKeNo8QBVBOSnxLodZTlgdzcG9loQ04+C2T2UcAtc6JgVgI3zRE+jiFYJ4v4CqqKT8dBfYvB+mKs9359xHyMsqD6d4XEYg63un/XNarBmCRpYC1JPQKnHKqYGB1e050vVF8CDKkppjMMmZ7BwF4/CkadglwWD6fjhKYdxEtzFh335Z+vpxCfqwLLVJaNL0jE32zfv30RdTrqm6ivfsgWiBV5UpBTSnip/DVXpfwhIk3G0eaOtsGEvyfJ5VO+kXYf7lTI0v9Hi+UA5aZLy/HYAWKovUNP0ywnHf3lLZmOkwHJoxdTRCeTsuDR2DZ3KnRSpsNxo8u2LvTePJvheFDm+fzvQTAq00FBs3RjTFLG45sqYlAlAW1+KvWnL2MDw6KkLGCoDKsQ0YAFJZAapLzqXST5xlD1/q3Ve8JYxvX5Kn4uHQWwmxRdhAouB1FidrzT79FRoMof7YYLKInx5ljkfo1hd5QI/lSJyTEGC8NstEGPy/4e/Cf6mAXseLcf7h33o0XVy7BBhAs5Vmhabl8jQtIytBuiDJmnPT2OmLe3BgEdhNvbScIfIQXyx/zBcJAUAdUbujxmfZyhshKjWFQ94AfwYwYSBg3j41kf0+NZX7WIRYu4CkreW6WTro9meDjjE/WCW60Vc9xcIhlgloHVbaSLzfKrpkEmDs8KTXwLl13oMMeqJfNSRA1eGAY0FCzOIH46w5MZms/wGX89O+7IzdpBHbmdQYIezVpKQiT/lrmf4X3ptykwxTIfx4PZMAgjhsqCjmPOdbGK56uEnPGUf1EzfDoL5rMaTJhoie2FOuKe0TGJ4T/8NNYsqzdMiqTH+dNbwspx4TuODYPLsMPioHFEkdXce0Lr9upghnbqWLYa45IfgNpozZyCH+UJUeY03AvcB/VxwooX8k270+3omcke4Z091mXlCRPv4FnYLbLDbKbs0qJOpwoNXPhZZPLNnO+Ljmho9qRGPrQPIz6FdBToHxs0ZUWSDqAJ36FPytGI5FdXxx3rZ6dvBEmSmNYgUlrVupR9oGRml6tZARHxHbksnpJBNiR50CmL0YN/dsLukH+wmigeIEdnFegEXIpQxMtu7cqVLcRDcz2KuIkhvhgkJSNR1uX/ViqLG2+FM/kHnJv8DxYL2RcnDF6Qxd60nq6TWUKLjTd5NqGMgG9sQMF0PoinwTHrI5AL1DJTfW+QO6U8zvphWKG8TOvgx6TYKHtGiIlBiKFhkc/19hI9A98SbDSGp9vMmCtw9KMY5YLcaVUN4D0cmEjJZfaTWdARszc7o2OykZdpStPTDdGyoyaKIQWq0qujxpsTOAqCUu/ieKazo+lDvLFCqvKBqvw+nQBqZGi1OpJHvds6ni0cAeHxlD59/dLMnpo72NEl3twFat/80U1WyfNJHp1xP2ZIZ5Y1sm/DhHVCREX6kzKAdQJwO/LSE/OCi5lgO1lXss+uZG4Om6E2UxI8xLCZCqwbxEK65AaGcKk1Gx10HKOsj7KuNfiukVRCGOjpNJiFobldH8yCmDOMnqabhaQGVslu8zINOsNmRzNzlu6JbPA7YcQMCZle4lnw2SYjAwyIaQSfOlsLjkjV+aypqjI7eqZ3NMxOK7kcOdVyt95sputduvW4DWLP+N/3mDKYAT0KMZnczFlTu+oBgIhZIQDgDrWDNJyI4s1d5AKLQDAxS8+iGO5z8sQBgOrIMVDVmaPhqoPHhawukAY2jvVUflFjf0LTJJ+LGdCTdDnzJzUAeyYQcDcx2+NyNR9x54TD6FRhNUhSDsqSQPTtzMVOSpBXV74yfYowY+8dVlMF2XC3DJcpYYNkSgJuogm7SptuBKxrTr8QtulJkSVBBltUbFjYDDubGFMl72r7Lpko9PUEhEXWtvFLbbSm9oAk/Mwm/CNN+DACIPYO2Ji7dJ4BnavV2TfXeM4wFI8TJOqhOZdgNJzXCo5Uu3wb79Ygmowb6y251KE8+PbBrlx3CVicoersaWND5mLsQOJXD+jAHIbixgprtL1Nuy6z431VnKdv5HfcPR0FWrlAXV75b04ji4+RDXXvVKrI5CKhYx7q+t+UtC1or5yhGBuZGGyr9B3a3H53a5dceccsK2PRJrgztQcaMBSHqNRU8LhGTfm6ZHSuADx/9+6NAmLU0rNFQSckK4teToY0J4OC9Wa0OLPcd3o12uI/RacZ7pwVxfSBHH7eQRJaRPH/Id8iDTMq8f9BNbhRzJVUNoH2JN0K4EpskzAVp9RAFZOswcWxIOnE1tNQ9rwopu/zcSDEaz0/L2oT3HoyvamvhFvzBQgti8cnWV6AGViejcckj17OEP90RE+BKN7NKnw6zk6S8bszynqaSM23bg3C9nbQXohfzkLUCkT06QEgCvC7mSLrVz4aOZolp6ck3+feXHqY57MFEwVQVxRioIEY/tNrlO1n6YieuvTaqBQxAwK1gIgyvKiE4SLXaxoORyVb45QoWz8QTmgdRUJNnFlLgegNZ3D3A5IRX2sa0EYrzcgE0Tkp02uApMRZW0q0YIJEygpTFGNGineC7IOAGy/TbgItgrC2K0C49HVT3xXysK593evI47oTdVgyIQ+ynPJ7wSU2mlm13/Y6XGndiTqjbMyNdfZrNR7t7LkfIkGdXDYVExgIppcFPOMD34xIDR2VjOTPCATf9gj7ouGIcOEUdA4AlKqAcV0RVTzgRpgkLR5wM6ZQnOkLMefq4TygXcidiAT1SAAR1BDmdSzQYGG6r+1D8ZgKDSz2NtPsL7pEYsaifaDgZYRXhWEXdBrdxNy5g9AnmbzRkskwm6XjLBkukU5GNE3G4/kCVNC5IqyY66hGeTbhah08m0r7f64WD82abWRdqCXfVAMwOO8qgU7NsJqELyXX3k2GQUcnjWLhnguBhVHWLTKT2kox2beKE3Gj9uWCrc0GBAcQGaldDmCL58fOkxLUFo5McE8RG9iWgdUmvjMhGFFcE+rtevorl182M6nUEddbqn7t8EJqL469HLbQjDZ3xNqYY3Bzt9J/iAFruVxpjPTFJHSEYN/cisa5vNVuDDPC0n8rNphIpb2hIbENEbtfv9BlUoRHFNsYa62VuS5tb3dAsqPdIqeTIDQ6bXONYBgoyyZKaiTQVulfu6yg7G7E/qeKcj8+fnm4NuXCc+aD3Qd/X069XNkJVWuPo/zT/wBKnqRd4GMOGSMVojdNqzsm6ZZJv3OytmlVDZE64/JSvhbsmCF7dQ3hJG8y/JXXkHl5Z2R+WLuVwrb7Nt5tEvyQFGQggnKeHlxO8UNt2/or+/foV/bvnWDvO/mAHwNzefTtr93dX7vBXnS8++A/+78O//1qJww7D3vXbB11n+MM6tsHu8u2kN+Kodvtjmd18wdjJ4H85fz4CMN/4V1nHi0sYm0eKlmojBmcFe1yg5IsvMMNec1S/lnOG+eoQSU2ltoB08/zObc+gzfsP2CXPqntUjiTvl1lkwZ730HdR/8dHHf+rb8XyhX59e9y08rP1tYFJurfur9+G+xFv7JvrrbC8CrY+w6m7dENuUD4cLIqH+Bc/XfkA/zw/R/LB9R59fszgZZ1/Fc4nf98DOGZzxBhmmfTg99qpgh5EjD0NAngm2Ug8ZvnzW99zTYYWGz5TGnSLCWOKc15GXBfb31DRJmFzYcZ3hLmGu9nOI5n5GSaDL7EMlBgHAf/3PnHP1Xlrwp1ed1UZhWu/u6HTJh3tL8pZ0rXtolxtr0dWOZ+mfjX2KqqPl4maXJK8kVZ0mGP6o9Td7YEB4gY/odlswdNOMm+n5kaSvljgeJ1fibJF/AOF99Hc7nRNkVwHEM5X2dBZz9J06zgKm5VfaM4IxsqKs7GZ/nXZ64JJ7Q4I/mGwLbMQY2wASuTFPRkTDbSLN9INlh5IgaejTaSVM20MO3tdpCynBF7CXhUkYCd4I9kHnTosBNub78t1Xd4h+Qfw+3tzfq3LvmtTMYs6HT04QyzobeoNTHSENExjrCLASZzuYN4NZLqtJQAeb8YBmHYa4PBSCEoI6tGWS4gKha42YKQIh27RCqWGNxwwsru29E7Ak108yQdZpP37188DcKqArRr1CaH9LN3zyfF2eu6+dEr2PMxFOltj4z3Ory3NOx+uAZKBUuQ6bgDEz4hYkjAfp2+y0sS/VC2+fFDh+jnmXywQcc7fUS7ghFqrdCPWiukvT9lcOZWbf1zvelFdFuapdc0/tE8Lv+gGr9IL5IxHV7f0vv89lk258L0hgCwISFwjl11up/DqoIYEvwjWF89/O/jxw8+JQ++xn31x86D/+w+6P/71kNx2rIQlTl9l5k2+gQHHXDC1Rpqst9Pg87fOsA8VTzrWMX7baxpICNqbSp8XN7xw8yCyyoeyKHELp3wd7mfYk7lzXghrCtxCPnDHOhw6Sh4Ow5Ku5/ncLrpVdsYJXRMhhtZusHjo0Uwc3DOmVO0coiVG/KsTa5LyVOyhu//ETRI8/smab7PaStZflxGlo2G388W2jxX0l9ORtxZVI/QfuvMeMh1yfB6auyaKBrrIevDMvoKLc6bnpGcFmS4xfkyivF+oi2czOstS1JagL04K3I65Z1mIbzeM3Tc7XZL1IqCjvHyeWsRV1uLzt/4NWyv04lo9blCUn56lznBslQIOH9pd5AMzkjtFFhatXYi/GaJcZo8lgKwjoPesor2mS27myTzE/LW2waGSdmbnE5oQS+I0Zrv+fsA9ToDHtGy1Rcch4hW2K4DRKCXjYUQoMYG+jMtzrboEJv9rYUh1t0SJ/Am5bOdWbGD6jsz8IPlrIBPX4aEFbHUcIXaGovy5clC8/6v2V7QshnosL4X1iJzteQ3oXXqp3U6DPthFQYlMhSVYYzLKlS8MLZ54cc2Xgh2+vIM+Rz24IFshi57+rFb8m/rnIC53AtmuH4KoEtcqxUFM0xYo4IMMQQ10Cy4BFPunk0/rZT9i3aLE4HarRsEN7RvGv/Jhp9qDeXLtKfRC9Pop4a9of+9/g3w1ljeLWPwJJS+hRo5cCvkl03X8tA+UpR1oIcYYJqWGwVKu9LAC93YG8p/cx6t2aZ7ikWcqa7weLR4lPyeMHhYDShqAuPX0CrUSPgsE8GCooOOGVgKUmV76JzUP82kNUXT9jBzbA9zCaqPSkP2vpvti5qdITS0LQy9l9wQhqjgtk2nmH/+39tNpQ1olWnkeqD151FAiqgy7Y2dCZ3aQPWcblJHRRF3KeN/7tVMeaHPWW2mlSahNtNdbqoPF0ECkLqfwzCKfSu3Irz6yqldxFkmN+AvK37Xlxfpx260s5o/rQ6YNh+MSd0rS65BkOFHmZICQu38UhmVmaV2YLax/BEp9rgk33XqRlJ9NSE12Nw3DII9eHzToE1ovlH4loUifC043HDvTW1HIA89coQdafT4aD45ycZdgNNJsyE5Z92yoOPulK8vHc27g5IV2aQT9lFxhD13QwTOUEJ/J1yksLgLith/W2VQhnul1GqwSBzFMsRdUMLVtTymfczCmlPut/GuxdalgL2gqbB/4Ia3OjRdT9fryZzuPRXTjimpaR/OkoaXo2gVMSvMXabzwlNkdydMczXcSFhz16gJ2b1FwskVtChSA2PDU7ZmumfLq1OgpSyKJD7KWk2j4EMAVM5x6KgIOS54VhXZE2FobpG1kj7cjoUJjfcEUuroIntrDAyNCtaA4I6qj7A9AH1mKIc4i2F9mgWf5aEskCqhyQaHApS9tVgKqdo4S9jGCSHpBrkcEDIkw42TuWYVzeFxIccxqrKnBynbSlphty1XeSuZ1PLQSIfBs1mQoU6Z005orDulu8VKUkFQpnrSs5DbEtmDRtIds/ZZHBzI76RZVXC1ggkeKLcD6WnM3RmeEgIHh/RH9q3gN7tCw0a576uiLNW1bU+rvSJds2nV72ddHj18CK7onyUi5bW9d4VLsLIHiqsiq1O99SjC1dmkCDS7flVqwfu4Zce4+4pft/qS0OsBnBVzQzGaGbq3N7bF20PzVMLRO75E+31FbnU8QMiPEe2H6LxBkT7Fh7hoH1p3rCaZg1rkHD3Bm4f8NuXdQ8rAeHv70HnZ8ddWDrjb209qg9vYktQukbcYRDv6H7BiQeeovVqvOcnS2EZ2/d69UXwQF5iv9q0jeI+2xDGkNC4wyK9dOsTFUVerub+GyHMz/mo5AvnQNDc+Pf3DB8pajTsbfAaaf7C1aLZ2ew+rzz2Lm2oGznkODcOvuFkEHdOwp0LlUqan/pB+scfwFZuQTTDSr11GipekSGTWpLkXf5R5DhRoGVD0VXS7+Irdwwxwkh3z2LNwzbY6bgdXVaDPxxh/tRV4WdB5SwZlzugFMVtSytIb4gmRDDvmYc2hFSmhtbIuz3n0i3UeWVXNgdSpnUg+eFXHcyzR9LpjiY6CS8NEgPQggrL05TfBFjZ3kDSfVc/APlxU9dps7svPG/bQ7Fns5EKcWw04d6r+iD7V3z7YC77DkMhJYwfchjNhiwsaPBNH697n19pAmPTj4CM37scYB5/asbLd/Y8d74x+eHWl1Tmf9sSf0adu3cvf1kV93PsYXTsBuqEVHmLWlVMIGqmgbMjJIdqk29ulFs2Jxcn1astQDZyXb2IsZowSfCyXHul1srxtr18vWOHgydUVIVdX38fB1/DqihJ4HbvsujcgoL59eQA7i7tMTLdF6y1LtHZZhCtdH6tj2P7a17K4Iwg5QnaDlVgy+mUV9r7KU4kcBV9Rhu487HKI9s1jW+U5qLJpoKYuIa4b6VfjuEOarJgOO6g4ct8Nka9iTkYPRjLg42L5dnJSO/lqWeWhTrXqqyjLwqodJ2X6z6m2vZrl+KnOnAcwdiOi2kckXELQszL4Gm5vPyuDBMg2YPrt18IfHoGd3zTd+Lq9HSSkm5NJdkFqtSWC7lck3FjdmqCU1T3KieC9qb+bPelauhf1RfWgawB4l3kkpIV7JESKqbXkJA0pdfNtqUhAabD/Z8qu60iu/5vkVoY/w3P8A1qQyYpiqF8GZeFemwzKwij4ih+fXC/pMQRdXCvpAVVvAFkrSa9NWvlL5vvTynya6v6S+f48Mt/zmJ+fb8vgq5jyv+S/u5H/vLyIi4DoeWyx40y6bPKT86vKXdO3ZJ6vXKkQOl90LlHGc9usJjFaQeECpvTymRJMmpuQhiHKFEbKbLTiH2WbDpAMNyq9jShatQKoyY3ZnYmNX3FWeXfqV89OpYpXfJUyFoTPXy5f6bxVb8kIdpP78cXQlr18DZr1XQ5uYbBhN91I0uGG1W5DLMFGkpONSVmU3MRSZyTvdoSYcelHQzD2fb3b3bd6z2q6rcGC63oJsNYmvlYSLNE53oxXkQTjNSTBc1cSPFxHEnSHwE9wR27L0KErt/kEtEtbQNMQrxXQLpcLaJdLBLRLbCLyB5ftAhp0celeVy9DdLl8xx6KR2fpM9KImST3njrP9/ETdxatk3r/6mpfqFX5ZSkzUf4v67ejJ8s275OqQeQvhpLGz3vLdLp2ff0c36AM683GQ7iSLDwmjT8Lk6dXdcy2t53fyjo5g41VI6e34+CyxiE+19NbBhYsDCrokJtpj7IyHXb1E1h83Uqd49jB0lqo86ur8xUXKl62UHEl2azMPrSc06ot/AFmxGar5l7OJ0sbgtcauHzV7XKDt+Q81Wl1PVeVkaRWYYA1fND+tRzwEjjg/ioccP934oD1MdQ2fI399Z6swTu22ihS3oXxkxptbS2jrS1JWjWjxj9IR8IP3/MbMhTEX1MPK3yuuazfnjc4RCxEWxZZPQG1hW1ktlWF6D3+MJMxiVWMmw9h7Zm3YTgGktTmez6Oj+jTtZT7BBGCNz8t0YbwwBuj4P3V1ddwcVjfP08UVTD8BGV4iWFrcN5UlXx0jFKFNe3HpUA+huhjXb/yMUTXbbtPa2w7QmRlnuASxu4Z9QpTeyim9n5w661CpE9CszqOqPgkXHOtPjri043XyYpYCYMNvm5vO0ZYH3W0Q9bjr6Ue09YnodDA/EsugB7D2vNfN+W9xSL8RdZ3TtZSXfqxVV0qwPwv05V+FDv1/M+vK6UEJcSjLf1oaUtFUByZFgnO6PO19aWUaIVpQm6tMU2IEqISslRnSskeJb+L1nRsa03PW7SmYt4Gd6M1BZXpmFxd/RQHH8WF4qNSmw68atOPt1Wbnv/RatOPRm368Q9Vm5oE2jFlT7JsTBLLw83wzFqkZq4A/Gh8A1ZUlt63SvP8rlWa7t7JWvZOBjP8U+xME5Djb0IB/dGngP7YUEB//N+mgL7z1frYpoD+uOz6+lFxvvwIL2MgKL2Feb+Sdo7wEjv8RqDg324bKNgJ4CvOyUxuWtuQmTsFahvnvfHMH6Ak+hoH6vDLsJN8wT7UMjWzmfa+FQqCYS2csDi3tTW3J88/mFerg7cWYVgcTM4ZuUcjGe93JgP/Ck082sdxLxbqW5EE4hLH2qycf1Km5aDBtQ2rRaEMh2APGEy/+RF0ybeO11Z7ezuIReof5b+kQHe7XdYwFWcqKgX4vdiphmbyrAtDdIlnAnHhyiISHTGpvNjHOkTsOR9FfmSfepfWqVfqQwzC9iq9VHoU7OujniODzu/hcDLHRsu0zVSKJiulW5nTLiuSvGB8S3dsi3ew6vHPsV5DxQ+Ch/9tWv768NeHD1Gn05zy2Ey5pHgf9YHPlswShWb6r8OqQkXG4zM43v5iHDzJTvD3MLTUbrBRhuWA2CwcLBRKnlJHtZchcDwsvfuFzEOInc7ZeRZWylj5tzLIjssHf+9rMDEWH/QJQGXyE6rbm6Q/EL/kuK+d5zPFvV5mQzJW7PplMsgz5UI0aZRopyHXR3oxJskFiYyaEMZsuShPBQxKGDdEsz9wLb75adYtE9P2rASFvh0nSIRfaqDmmcpMnbxgk8bT4XQQs+K58vRGaKZ10TxMeZ5neZBZnnK9y+6oHI/BwAZzGVJQg1JSItYPUceMoNNXRBaU7VcK62/ux2hx3PLqygAoZa66lgozLCLp28Wzq6uZSFhSokvw89PxtpU3V3PyImazkEXLFNe9rDIFeIMqYkrG4zgbxY/H49cjRUbLaMYiGceBnMd7ej2ydRa/lYH6KpYOnK5tCQ34kVi+Dq+2MSkhmw08T2zwiL5WQqAVlpTD6PR53KHATPhNlnRmr9jMs6RWBTgU6kt6eXV1KZZ0hjJpDSamQnmZhnbUf6aFPzEGEYxMtVC8glyQfB78FKtXbHTeNptyyUA1uOHOrMrjevuJ3b/RxO6bedvH+56JtSqc4/3GxPIXOT6x+3xiQfrrmXcxNX0q7j7/De7VrNecY+XNPCRkynMTwHzzkWsJHcbvvrxtCfH86ooagV18ssxmiXhz61jHBw/I8JJMTkhulfcgNFamPEiFraxpXDk9y+/sOqhsKVhoXjE1PkzVX1Wd5yj2ouOSyQ9WtoTkyKSVEfnindNo6nzVJ9HfSK/+kosXJAWRBs5r3tffCGYVkodUaH2V2R6rnu5y6ZEm8JQ84G9kr3nkyJJQpDO89L2W21HvpEOlO7Rghi7h/HYPLjvOn2+/ir3KrPQ/PWsz1jZiP0R/po3XoBZ3Qnynk1vDczKhU1LEIP7GfEJUuBqBFBO5yRTXZHC10Cc+ZjwvWE9cVmQas16Y6T91EAW5AGNFuOKEe0tGjfB2FVrM8mTKv0eDowrnJSpv42E+9XuYW0sW35u3uZOdonm31uPOjxYlI0o/fCSD2BEmckVxLQFQNGG9k4QRoFOXCurVIgaaai9InnQXKTAR5TdN1O12Sx2qtAytfFAWVEU7XrjK01xBxnQlZ1f5SLvWg3iGyhWcBg7XcBp4ssLj0RbefLKKocSTtRxet2qD2/hwA8eB99px4PA2jgNf3Uep98KowH1q+oo+eF7D4WVp6j5se+zSPt6jw6vb+3UOrx9v7PD6ERpbDq/8Xe22Dq8fRbeLj2s4vH5cBm6Fd73/BQ6vWeNB78Of/0HvEu1f85xXc3j9sMJjnsp1G71HloJZklbE3Le+S7yCxLPiS9++UoruL33nu9y7vItXvmzpqV578bMNBj60PPiJuSV38+C3dXX1iXvGfgzBYQKe+DLPE58wIi3dh75Z7aHvGsHC1n9++CNf/TLUEE28okWLrNIubhgJRpGtdBJU323yOt87j1ov2fwS2bdeJ0t4nZzdx+tkubJTL6059X7kJET/hH669Bo/3Q/iuYve2E936vHT/biWf+7H7W2YOr9/Lr1L/9xaT7qW7mWpf653Z3A9sPCjoqSuDTF2/kYAfB/2VG+iFjJhblx2SckK/JISVw18iJ6gLf145V4dgwzyvA2OghItYB8+zQZR83i+7qyaCvkzutZHREq/6uquOcFhOyc43DtcgRNYKkKtdntyo9v/E3O5f4KfeG7/VoUt/KRx+9+6utoSt/8naAb66RU8s+WV6laWx8Bs1Mkkgv8Hx50BnFGdPnKh/otdy9a5lP1vupJd68vdvGG1ugp9XOIq9NH25f7Y7ir0MQyvv8T8r/Ll/h96nan5cv91nfmXus48b1ov/mtfbf66u1x/d1nukP7R75B+M3tA/hL3r2rq9+GWpn6Nq0/LDcGKJn9q5P6220FWvxwsMSBczoAsY8K/7gh/tjvCEr/Eu7ghrCHzc5n18Fa+iU8qfKi9OFt8E58gBs6IVvATEMNt38QPFXcyXO6HKGNmO96L74Wd7eZXPpRPyImx4r+ZbCFK8CYh1zsofr26+hguntTvRVvGV2jrGu+gw6b8/8njoPhpKZBPIfpUvzR8ut5B0XhArXCxog0PRc+wV5ndJ3J27wm93irUutXmzbUVrrlen5reXDdZq7qT4scbOCluSSfFf9U10MNYewn8foo3WodW4m6eUFvhX/R+c3qXCoJPf3kv2tqBT2ILH/6ragc+LfNdPFxHP6Dk3idoK/wfrSoo11EVJDa/OWzRFYh5Ht+NroCSq6tE+Dp+EtqCT0pbMPZqCz7dg7bg8B60BWtf/VdUKdhaiLvXFnwy2oJPf6i2YDU/zE9eP8xPy/ww7/Qaf3jX1/j11XzK1/KT39fyk0+38qmhW/n0P0i3cvhn063AGdPUrXxq0618WlW38mld3QrQxfgo+LyfleMhj8wlUTQnd7SxtSi7E8JYckqqz7fRxNyxZgUJ2+LyX0HDYsduDBcrRBqDC78clWDAIm/Xkibu2l8iY8t+Q72aE14ruj4AV3N9L9vXF4SZ1vV14Laq0P54I3u+wFJSOjvCK4saaHiEuTfL4/H4Bj7SI2Ua/zbLCglysdzU3+OdlvGPEdXSSgmm3XEVao+M435PuE3xpHZnR9xjNDb1gZl3VCLuDsYghkMWuO1tq1l25PWNy2pgWJHTQQF+1tRpTo+ChVuz2SFzWiRHS1wdSpNy/xIPj4IZWtyVy66bWBFIk3sKosntneJPm07xbRTW8JBnRw2T/17N68fjkgqfrCR213oQ7KLalGfCWcCz8tSURCXupHBpE4vvZLc77gM5ziq8qGyn/lkVmrx12L5TPZBJzh4M+Jx0ljsmuOgqj4QmupiaEpUyT6PJ3fHi/p/Xed/jA/8/y39/tIr//vo3KXeZfVTcQi6CrHVhfVd4SM8KLjD5K7jAHQUXqGtDnCgDy/1JpD5IOJH4HE2MTsh46EqF1NFZIC4rWWVdH2jjckWdy5XOiK+4+65i4iJP84ZGfsGmZBBlqKATkpUFz6UrLspweufkt5Kw4kVakHxApkWWRzGAnmYpI/bXGQKR6yllg5xOaJrAt0ueXbS+T/aBs/sVF+dQxL7Q6assn8B08ktJdOg5C560ngVbpiT64J4FUndGCYveg/egSSQskr1/rXDbtKGP+L1Jqg68SQjGIeiOKXteFNP3+Tgo83E8mEGIoD39Z/RqgAjBknXEOSlyCtpnmZeTEtVpAC974rkyIb2vMiXnXkLwV5nWPQoSgmfGgC3jqXcGwkRSiCsdoeXuyEx4QYYSouWTsUg9/rIMjhPSD9GAgCp8kE2mdEwgFvaU4A4oUAdkr9OJPv9tazGAC9qQOCrzAQGgaEQcrk+JZM5jUMHMiTxQODNl0fGIgNDdAXFsAEx+ThRfkt69CskTgo9VxsvjIemHfRQTIKUZqScDh4Pcyk8NIk/MheBgOjPZru1T9PGUPn39MmCIQob5IUELOfnRQmltP28tKKm2FlMYuSzNWcRxejULFnqnXF3tkv+wt8vV1e5OFfaRnUA8WkgZ4vm7d2+EaLWfpSN6Gi1W31xVZdMux+T1rF+p9KkmUep41h0nBWFFEIpChfjhTApZPApBBPssK/MBgWNgc7cKEVR6skqlt6tUerpKpS/XVOpXtpIiWqhXimh3Z6cxH6dHHilyv43RNNiJl5NIJmId4idErC/PpDt3DNjFYou7XxSTCplDaXNXnx7R+V6HnqZZTjqR1mg3zpbofG86W6qLj+ofIC3uEcGBuL+H4tC8nAYLqX+KaBV2izxJGVd0Q7WQb4CZ2MyXBB/uHZHoYzeVzJcER0RtIXFKNE+5SxIiM2TnAdh5+/15enXlfPhxGtqQOcuWgI77VU++E1dVr40hdznL5jzmZ5J8eZlMJfuYH7Uy8Z66523E/OCUcwXY0qurgHJoF0chMvUso2Udy0vfEd2Q8uzqqiPibHQ2cba9bW6VmzirjPXKBusBmBLrXgbZFFy5OfRYBHeAJwc6ClTih42Yu7pDhRk8yFFpplyGiIXyljjNsyID1ODgeD1LFTELzQJDM+ErPuvjbE+N/3jWR5s7iIYR/KkZZln12IzCWpIEEF8MEkY6op9OpCoFsmNxC1G/TknxRmHyegStwx5vzj3Wrdb8d6AeO1Wtp0lBdCXOSgC2lPJfjwJV7y05Pbic6ppiQOJjwGSdF2nxT9kr//2e+j7sj5PJlAzt7y/SYvcf9Yrulxdp8e3f61XcL8/GWeL79I//sD89oacv0ua399T6qAhHxrEKezLahC6oKiCNiyNNU0G4MEQsVuZlMg1UyKBkiheVuHmK6cc7Ohe1rm1RFCOFAW0uokJbdiYSqassKZNkekz7vfLqKrB+Y8g1Dy8zXEo5ZijrhzYC3+DdCnn7BvBW52oTHvf1lqCwJViYCdiN7VBkIoiX3AjHtB9aF5bzjKZB2NL3qTNukxxb4Pwd3v3nTqiwyAALNWL9lZpJyfqoxDu98jsq2/fKb/AuBxrMMD0u++HxTh+upmrXz453+8KcQ7AGe7q5DY0BHvd5l94OONvoXdNNZU9BFYToxE9Nv7zlUdOezHWUCkFTfIM+S5UyhP98PBgoNcgXMn+WKs3H5UjpPWg6LQuGF5UiPw3erMJx57/+i59cUJA/pCktOn0cX47AriWGn+j6VlIs7jcpiZORWTyBUQjMlwvgNp7c7w/LIdQ6YAWZdvoBs+sfZ/0wbCAC/w47/XDBMKsVXgjtdO8kJ8kXNSX2RPGD0o+AGiE8eq4wIQLf9l3NF8x68beHRfvY/Xl1dUyRPFSc5Uebu2EfuZWPd/vYVHqWBo1iMMlh1iCqILTO7svcocBQPWrooZThwjpATo40pQo2GR/heFDm+fxV8B/ouI/iIWVTkFxAWAvgg+4BBbrbvEH2Yk/GlxAwJghM7ygWZTNMgxi0T0yQ0gxBJJfjWT+SM5XB7KBYT/Hl9vZlG7XsxfLPoAwjcYxfIv74qVrPkiBGi0p8VJepAzXWvwfx4IwMvjzL8pekOMuGQec0z8rpk3kHxUdB4NKBPnBkbEMkgxJquDOlPlZXdXJUZHlyShbKGAx+E0fLK1eLrzYTtVUUJ4bsr9ygFmfOt6PyRHym1SkpNuArCYSloSQfq9twAZdouVPdrrhw1egs7EG4w/DqKmBYK16QrznzNUfwng1dZjLslg/zsAeBIaEPbW00O0Csy1rqg4COmmMzL7rNsiqZTkkKWq6FLNSm01dXug+iTIArq3xhAxW1rNKqQuKB/QgUoQxzHYjWv/3KHp6Czg3pq0OscybFdMhiWfNoSgY0Ge+fJTlX+b1PhyRnAxhUDeDPD08p6sQ2yNdW1id9yVkoTbiNnRXjs1SmdDt7N8aNbzi+LfCjz1uLm8NxcMy6RXaYzUi+nzAShGFY3Rlo0P99DgOYoaptSXCwsAk4Yrhz+UDXHXaQlRpLb/EcnmA861EJPQzVOkVjWBOVKHEN899lPxy9fiWNN6PYUq/PKkwRVxruw3/OQcTjtvvqEipVz9GC3+ym9Nt4V5nSiUhy8FJ9yDfX7CjIEEMmd9cDOmSdUAaXC4z9xMGR0Ohdky0tRPv6OY6kRU75PFvmK0KixY8WboCuLOQh+bS8eHWVWY1qRPx5a8GqrQX9Zrf63Mvs/sWYuvIuz7oyKq1g9fCEee7oumshOu2BOK7FS8Lb1odP8b6l/1yq7hY2MsnYopBOCGbEEOfVvD1tb7udGOOqLktGJKAOBojZGdk8PXCZQRTHcUsFPlX7+sqDzs2fhzrGIKq5tyvaYsb26VUScMfRDlo+E7wOHJqXOkiwJsBLYYRWVageFlF3yO0wztGTcNX13MIxt3p4gkoVwvLQsPGtsO5YvvLqv8fPEtAPf+VDn3AB4rrBnxXF9IGsKgPFBx/Qe/Q17H2AhMSN9ffT+MfrlvXDCrTxAZZdnnrosCuPyS2wtUCWHU2kgt2Gi7JLmVXCMxtakZItvLe3z/WBCv/j2p2jlsvT+5T+xl+7uJilLkNS1AG2qUQhWpAJw8d9fS9SLde7F13bSt+LVDvxYYWW3guEUiIoouNj1YNDmTW4cI9FgfmpRc1rrlewiw12tZtBqae3eSvInFvBEb8VyBvBpSUlNy8DCiiqCcn6ig/XehRjxeYRN3Mpv4t7oTsPDFEIBN5HM5ACZ8cz2aCPaYjgqq5FeUuC/3KEL4+sY1xrrq89w+WxnPmOZbr8WC4rnBkd+QFP2ImlDLS5SbuU1QOx8g0StJRlpkzsat2I21y0l9K0rSxb2jKTLX0circDPtoo0zB9zWja1oimofgfipXIMltLZJk5IotZYFtemV1/PqEMlQhi0i54OinuSAhTs59NpllK0oLJFu45YCybWLQPz6JUSS9qNvfDvVjq7brd7r629g0j9blvYRovOdjsWOKXaF+dqOdwvMQi/LUrP52HV1fcRE6piWupPUuBkrDyg/ZWFo3D2nn3BP88gx19bE8x6sjRdPoiueSXo6BG+Egcqd1u97zP4/WbHQjrNr8AF6SZOlQOxSlg7VhGBmVOi3kMD340F8fbPW9eToUxug0dKrQf2Gh3QtQgj6yrqsKWi7H1u0m/KDaEvIw+uLGpTIO2KiVrejhHl0vpAbj2k54lUSmErTM+5vKJKuAzc3RhWQLHjgWvzs2sNodDEQ2S0CQUq/jcvws9rEUNsZ8rPTARxa09b3Mnl/nbizoTm5671qBDWJ79VRbXlX6FO9bVlWD7rpu5LPS2DIwJrq5nG+GqJTzvyhGG29vNxofexoeq8aFqzBrMR7GqmSLNzdjQ5pPQEvK5hKngbG/DOMXV04zT9GJskPRHbThVu1EpW7nQMX6W/YlBWd3q2RRytV52uGjFirafhKHID9AKqQ1QB2XHO30XVFg1J2B7O7gRlse2jaBa0b4H9TWx9oGtj6K+3c9IMvwX2usC3es2+nNe669d/tcu/1+9y21DWWU+q7aySmawyV8XjP2HWszjFu1ssNA/HfWr1ntn0xdDp1LEKihCi4u/W4oP2GxJQU/oGFIPgDET2FVVofc6GYTXyaxBuFSEcYprLC8I+9oaT16w34zLU5qyI2nLhxZFlo1Pssv9nHDz0VZbZGn9mOWxbIEkj3strbPv3L65Ciu0f4QXjYx/DwTADuL2oJDIhDJtdhQwa0JUWIxXJb8pfxH/bLLu1pbh/KE2ZQwyvIywELPMHi2DUDALaLEH1U4DWUDhptq8yZaaYcyPhHkV3xQxtzLy1K7sbGrUGQj4+tPKzny4wQe+t2xQEKCRacvlSBsqz4/4k/brI7x/hCbJF/JW1IDdJSpl+FHQYtfsMbKkXiPLssIMlDgtZrozXQJ6TWHICd8vcXZ1JW3tky/kGSkGZ3AggxvQGn2H8FK8j4WJO0f9suoZsx9t7Ni0Qb4EQ3c5Z8E+LIoA0u12wRHg8RG2pixYGFDR8ahEZyUqSzDM5af1EY7jGTmZJoMvauPHcfCP//znP79V9PPCKDY3RqmwMbWeU94doQy/PELCiACVuCOqdM9ZtPFG1o42Oj3aTVih7oTcD6Bg5meRJwNifoLhzkky+MKUfiUWQgY6R9whFe+gD3gHvcc76CveQR/xDvokfDkUqmzOCjJ5mhRJoF+gk4Jg1n28/+7F/0/ev6a3rWMLw+BUaB4fbbICKZLvpjejEytJ7VQ5l4pj70q8XQwtwTYcmdAmyEi2xO/p/z2B/tc9hZ7CN5QeQQ+hn4UbwZssJ6lz3u/tfU7FIu5YWFhYWFiX05dSAHsdhwwfqTcB7RwUs2s/j0f0cM0sOyDC7sV3MEY8SjYa4/ytZ4jhsSfNeBkAyJeDukkobgvp2/NM/RioH5/VCB0X3ahE9ZqBDvPyFNCIRFcfKX9BdEDrM/Ax7sTpGDM0hZ/pKFGn0wT7BBcfKTnoR5grzMgFt0hkBVxZpqR1yMnbBPu+H5yx884YWnHnIyw+efxDqeVicm8jbLj9EJGSvnAddG4nYsFILSDC1i/rc570IR3j7BdpZjykVxFQny/ugePkwGYC1iAQFzRsoNBJQB5mRdGMTwseHdER/EP97gH9NVCaVBQ0qW7O6LkEKC8tykwLZY6MMqL/gdJVq2tSPksEZ1QBqbFlWXRaKirpulAL1TsF1sT4LK9OKjhR6g90LMq09CKN6K/dutVgGbuLknBmqcZhMVK5CKFYIxpb6SjhSyWj2/vGYM7S837x05NYaurMSi3bmWnhu9J4zmA45zr2naHBozzH0Ni6DMfszv3iGgvS58s7847OaDvQovlZlrmOq2hSqyV/dACdHKBDA1fRr1ZL/tCZkBWyBDJClhTqHPpjDK/dwtMM63x4+wbxpfBGOAN/Hy9neJgm2Omirqv3PUfWS+yv9ZSG7mfeJRY6upaiSl79NrqElzGLV7DYtbDQh/iCAKdfRM1fvgjtWYt13r578/zj4DdP9Md3q8p6+eb9x0+e/NClPhfpoO+rt1ZZWamtlgdnp1G+e8XwbOU1fs7S4RAz5l1ixHM8OWPxBcaPHuuQEfzQwECiW0/1L/gzPPKKI0S34eyNzPkEHx9jjIWNwQlnHX8jCfPudeS7b+GYjMIEf4huFQ0Rho0+POVzk3nB8JNCN88CpZDECRGdfHj7xlmfi+eGzPXynaRxlevCA3ESoGJP/C/sjsH5VWgZPCkU+8q+ICissf9X34rxbUgiEl1ZQyD8UCfIvqAc/MzNJDKRemQCxnVat/dWm4zkY/icOpZoUyIhpxlgtoFHnS9uHY6R0tlaLCNQD46OYrlWS83Flw2VGle43dB8E6Z+55xPTPyWWQKZ+QLyn9kXl18nT158BHS6AVQCrOFv5hIBP/kzUCPG2J+efRKU+/zgc+dklLyG33CE80S0vlg4936B+KSj5AW+JBEeObKqi+5bLefEV8TuiUxHp6ImvOMLuDiSkHVGdBo5Jwhj8VTmGhFD1F5r3xwc6cE5nzmXdugiR2yR1TbIyYuP/5faIA1oUpxGEfPVW3RxAxRR/9JZox3M48au1sNteCd5Id44bwaaXWUTkUunuo/c+c8cRGFDZj9xPz6ML9+5H13g3z8jgl3YUrBd5BHDhZRy0H2xN5jeLaeuJ/fcJN8s6AYVTx9QZNDHPGz5ac6zHqmdILM5pwr7Hva/Ovrvn/g9dPrshG/iUxedwrd5H6gwLXz7YgyMCcYd4DxyTD766FU00nhvwGQK5VbgSdPO8JqMRzGO5H4HBTc9zDwX6BRxUQ24XMHOHmQun3MRFwfPxTBSRf44u6T9zhyJIUnCBvYMZUIFl5o1uO+k6MbvlgZ/VB68GApnhfUcjgpzGNTPYR5onigbPClxPuimnJIFfY05Qg6tNrncfiXc8G9cT1fQvZYLdYvsZY6A4EWmDrofXr6vQheE9Ep8LaArKMEh+IVUQsvPxc0rxMHl4YgLyVSAHh3l43OCmoU6WHOmz/Sx4RbwiD7pAZLKXtdMyLdaZiofTavlHAHuV8COppWUozU1swP3wFxZ3tJicfQMskn037peQdN6vX1TXK8pOkKncrVOBDNw7wdnJ/K4RZ/8m7N7+QHrCHzAUZEPgAuaYgROFCNwVL+ZCqe+KIvy3hQPgD7l7xOaC0gPPvHDP4XDv5Fxdu7RZ0TRWre4y8RJ2Wo5p/4Mzfz7Dp0M6QizwrUohWvRKXpMNz1XuMe1lraLPvOWD9YXi6MVKf7UpPgSUPeC7KdVsl+31B8//LWB/lbRivw60MQ3BWT91R+ckfNWC/791U8BvY2BN6Bsr2Egh8f1A0GBn0oZkqKe5NLoRI/uSfBrvq3nigIzv3vAfg0OGFBceBQ8I0/Y+ZqvGz1j5+o17YGRB1n9yI+MkUOXqdwpQQMgtVfPoDovkDdMhW3wk2nzfKZ6PqkvZoTSZ/7OdquV/urvd8Hk9om/ueGidM3X3aw+02km8LVEfmunf/LioydYoWre87cvctBI9mEdSGaR5BIXrbf9XnkUXVSWMtTc/0tDrL9uVZfgAf6OTmDoFon43pa88/r8c4FHq8737buP/33zLU6lccYmWJbMF4a++nyXMMZHWUGsIl4LafzFzdYXi+mT4pyfwSH6yS8n18i2hnQ8xsOEM8Y1LGY64TlivGhaJn7otO33QE2o8I4QsqTxEYGLvLg/QjTzTZkq0sLaXAUYKC9/mJkLps+2RYx41m5bz7oHLn3i25adR4Yvvi2AbltEEmFfdKioyPqBaSeQ/5z5XZSKRyM09Q+1Vr2U9q773YP1X5UF0gFgn3sqnZaerRdEuKJoUFc0KBWd+arFJ6o8EtVnot7gbL0gozYE9auLh4saB8VHAOAq/FMtNqYuWq8TFBvvPs9hdV1AapJ4FsjVuPx+mciYz8KUEbPzLEPEZF7g+W9tbXDGYNly4YZK1mDi+cDB+OVDTa2lQgepx5+CiQD/JR58hdEyF7B4YLYcCmGLklmLeyPrHL98E7z/8BKpMYtCFAn0L1QpSCjyZoZfPYVp4MQY5ptOfEf648xHPtUjRzP/RkZulXNoHPnUGPmsOOh3xx+XjjotDjiojvXobHbe0e37U5FgtOGnZpI6xtE0EwQkYmOgkVTuOIJSfXEL/O5B8KteqgBwPPWPzoJzsEk6SzuFkZ8jsCtKDY5NLkufOPo3mqJUjoRXQmlhXIi6HoQedQzwPFjDhZlofpArfmhSwXJDI/as2z86Y+3eeUcAsQv1NMfNXxn14pJOQv95O+aJAi6+bXMfAurR/Yn/y6/92e3YAuUFQiPf7nW6toWjIR2R6Mq30+SyvWf3n/0R/YLIE//LrzGlCd+CzLfX9RCfbmQ2F36FQ67XbK/P1dtTZj/7I/oCle0/ZpvDtXbb4qbalmBlrHb7j9km/iOyoYgkv+mTDRc+aQfaZB+lAwZn6op2uANlbbAW+M/mQc2KAefUM1t1xRQ4ARG+GtfngZD52Jzyz0SKsVCZbUnIGzlHpYk9NGpUaBIF5RNSTMn1nOpYn8JgnynAVQatgPcFpW3Oj4vWfn0Ky/SMAzXL0Jtjf154uwVFE1AzY+daCQzO5RHmaoDPE6cLt7LCLHLdIcEZ+0wcjGv54ytdLODJ76BobkbceeorzyPUzR9tya9+V3Kxtn1glEH0CXGLLgzEGDqXMb0dqGE6nU4HTPLRx2N/DtKnHgLhzwYCIcUmgqvvFoJb0TaCK8kOAvZ+FwGX2+sh4Ap7GwiYpd4mkmJYcAwluK5et4cEz9brQimVuokkdnkb3S5S29vb6Pb4x7u/e5vdLhKvSR7LeUcmOcGPx1xQJqf9/OijfaDSB891+uC5kQ7TkekfXr430t++0elv3+TpMGWZ/vHDX430w2OdfnhspB/l6UdGOgBKpp+8MMYDkFPjf/siTwdQyvS378zyAraqCv/KcwWQZSb/yPME0GUe/zB7K+S+fVfKV8sk8+VnKR+Wzizw7vhjscS7v5v57/5ulzzn2CdvP7wcvPvr29efX76wjj8+//jSzvRzH8PxNxwH4MkvwbcTfkTl/rHlSwbzfT8fr5CX93zfL9qxBG6FQ5KaMb8wK2U4tkZhEupHiTCyQqhnuwfKxMYWo2mn8bitRmOjN8clYsUH557rmNsaEJk+4lkgJ/YtjAmYuf3PT0qN5HETKk0j4OT1f5m5tGE437tCY5LgWBnd/c9MRw3hcVN4e1xUvLqKQ4gxr7Su5KdQdPbtwqd0r8zVkeAyln+BvyKpfFm7C/j9qCFP6FR0EWGHX2PQfzXb7ZXbzRGx2GaeLtrr1be30dSeRIb6RmWmaHmjvuVN3XK+LrK1PEG0sFnfwpZu4fnR+9+e6+rheHIdqrpb9XW3dd0Xr//6+qOuOyJXJFF1t+vr7ui6v73854vXf9WVr/FsRK5U7Z362ru69mSYtDlji0e6iUKaaGe3vp093Q4IRfgC5M2YSaKVvfpW9vP1TS/aIzwmt/k6mEmilf0GrMvROR0y2Fr5SNS3RLMmvM0Rl0xi8s3cBXmCbKOCq6DlV9lgShTemME3IVdW2kS3JPJ6oDDj9Z52s4byPVW+h9SDmne2gTbPmypsqApbCpObSm6WS5a3dcNsejWz2TBG10OV8fVqJrSLBDvunfU2motXprPRVHKzruHt8xJpaZjTxiNXaOPhFUJbaBvtNPVfndheU8nKOu02ldwql9xvKrldBdb2XuNYd6qld7bOS6S1AbKbj4Ts5qqQRbtoD+2jXhf1eqi3gXqbqNc4qBzcO3oKm5uNpTXIt/lIN7f5UDd3mspv1bS+19j6drH1rS5vfXu/qfxOtfWdXmPru8XWdzZ56/u9pvJ71db3myGzX1N6u7F0r1sczP6uWPONjcYavWoHvY2d5h4q+6jXjE9VitcIld7W8l231YTvWzX43itSx/OGls56RWjtCLTb7zaV31gJuttNQ92uGapEyT2BkrslJqShoZ2H55zv2vOGNs96ZYhvN5XcqNKj/eZ2aw6G/b3G0ls1pfcbS9fQ0V6321h8p654r7H4bl3xEvrsNi3K7uOP6d2lx/TmbmPpyibcaSq5ubzkXtNs9lZHsfOGFqsIttVUcmM5Ku4tRa6t7cYR1CDXVvN4a5Brv7ntOtwqE879JvDuP2YH5+duU+u16LPZWLpmP282jrwO5Jt7jaVrQL6531i6BuRb3cbSNSDfaoZJzW7e2mgsvVdTuhmC+zWlt5pXp1vD/jUDpVezmmUGpNd4+el1vw+3NE+Hetuot4N6u409lg/O3o5kqbY39rcbK22UGaXdrS1ebWdrf7e52mb5kO52xWm5s73d22+stlWutr25I47rzV53p7leiVmE0rsbouL+zk63eZw7pYpQWgx0Y2ejt9VrrFhiIHlpAZjNjd2d3d3Ginslrnljd2dPLMTm/uZGb7Ox4n6pIpQWwNna3tvdagZOmb+E4tsCOtsbWxt7zdDplVCGF5dcz97+3kYzeHolvOHFtxQGbG43w6e3WYMDAkC7G929/WYA9UrYw4sLCO3u7WxtLIFQCX+g+KaA0N52b39nCYRKCMSL78nLxO52dwmEShi0v9Hb6Yp57u9tdjd3y2KYJsrRW4VynDe1VqYK27ubaoNv7m5tNlYrc9V7m115Ret1t/a2d5trltZXFJe7vNfb6nXVOilv+rn6jkpxlBGHrRVs2BPfPlAhBq1jLva0Tj4cWVI+Cw/Izw/fvrKECR88eEKVGkGuZVmWb/X+4lhK3Gk9tUpyVMuVDfxSzpD/+ZY9t6066atlZza8kddUFvmq91xUaD21DPEjjEbL/6ynlu3Z8O9/2ZYr29VyWqv4n2j3P2cbPesp/Nlsb2yJXzviz15785D/2nwh/rxqbx8qUFn1/0G5bVF8+xX/s9Nr7z7nv3ZfWk8tKWi0nlpKXvhgi8Zs3QcKw38HVhjdWScRgRq5coGFZ0M8STxr8PEIWcfvkVqzpU1ZL/5x8u7jS2TZf/xiI8v+T9tyQkZG2II35cLQkFrH5Q3av0I7z+CfP/6Af/8F/3yBf+bwz8JGBlLYasIH1kDNhFkjUDkisAmY5fCBfHg1sHa2d7saQFzWbi629Z+zrV57+7mxKDCe5+3P1lMrbN+rilzQXqq42W1v7pcm0m3vKwgK+bpZRTTy1LKfc2w85P8O+L8v+L8v+b+v9DRrIAXvqW0SMRwxHgdHDVD1am4C0SusjhyL+KNWxNg8sqgAz9N8oG0+oA7/N+D//h/5xsw3mOppjRdZ5/+2+L9//ML/OPxf125AhqeWZf+FF3nC/0X83wP+r19ZdLVZzLV43m2/2H0l9tar/W63/erFQH69eNVtv3r18lXDJnkK1Xvdbrfb7r169Urs0g3+vaG/N/n3Jnwva2aLF9vS1bb597b+3uHfOw81s8uL7epqe/x7T3/v8+/9h5p5zos919UO+feh/h7w78FDzbzgxV7oai8BWO2XZjX1VxEvc2VeQulXe2pteGOvdGMc8N12r6ua++VRB9QPHE3feSj9/OPoJx9EP/MIWvHw+SnHzk88cH7SUfNHtPrx8h0Hy6OOlB86TFY6Rv6IVjo6vvvQ+L7j4pEHxR/Rdx8OP3ws/PCB8MNHwQ8fAo8m/39EP0zyfzG8tlVVvEh0BQ7BhPceM8YvqLW8Pj6g3HERfLw/RvzD0FKvVUE596u94MaqWtPk3G9U2HqwstAo0R0XNKTKlTta16iqfqScSAonYh4VTo2ct8eodqaIuShkUC5kySpQTjDj1hZzEaLXo/5aT3q/nOvovBbxV1suGRVtjXTEeDvSdYjUUl3raR9oZ9KEONc9B+9nylgsFe4qIagHhPSorAyPnSa6oq3WWuDCUA3f8icfjhxwo5kkE+Y9ffptHI7ILYmvaIxvOkOIwo/WuiJY5lwNTapSrdHFIihnZUgQScGenMphAHLyYB9577YQcdq+DlHZao0wVD358Fp73nSYC6azlV7AnVufeTiqqZDH5vnP7UOI9nNmF9JeQNq57aIPx76pOtaAmAizgKUXLCFJqpUqC9F8U+4LbS4GE3t1AMhQp9MhGQoeiR5BPXpov4zcfX9QQg8dJF4EkxdhzCWCfDg2YyWpwOUQEobU4Y+AASBRX0aUEdY33Dyln3bknB16RsCE3vsyX5+TLPvikdyl50zELYRA53JcBrEYh+xawlQqDpa0Bvu5ziKUXaLh5xmqtkir+bk82vf/CkqxMI4fVoeFRlaEl4j68n3gkrviET21ZZXv6DGf3Z8pju9W7JSX/Y7eeL3gNoy/4vgxXbVFlR+a32UcXvEgcqv1q4p/R5+q6uMmqmp9/1wVfgd4Nokx44Y+q/Wtarbzmj+nf+H+tUbj+jFDafNGHtKTbhrVi5KmsUmSgOz/bM3jEqlRGqjF1BW1jQUZMZpYWa+Y98ewRGFzEDpxRT1iuddlE/JrRQ1isXk1dTLbyFNX1CguEoJCUypxRf3ifGvLVvKEFXWM9V4tT62asaK2cWX3lxsszvFhvWNxYMtW5Neq2sbFM8VEntK0VtA7riUtssnavCZtZNnyxrKWC5SiuZNCMdlfwz7obT5OIbzXsCd6W49VUO817Ije9uOUy3sN+6G3s4qif69hL/R2V1H17zXgfW9vNWX/3t7P1kkvq4ftnD+oll6vO16nZSGUfuVD6oM66eZQttB240C2lmtq1SlSPKgYv/OoIe/WDXkP7TcOeW+55nedXkS9stwPaejvPKyhX69F3ntobZ92m8qvtrq9JavbqP2/vfKgV1jdXs3q1o/gv9OgYHVTgl53ZVuCRg3oldX4uyur8derXT5Kk3oZCLcebTlw/qBitQbTZlPRKkSbplfF5t52U9EV7DO2HmWfsbVcD3G3sXSNHuLOZmPp/Yd1M7eW6yGWFQsfpb3ePMZH6a4vQ+xlCuu736+ZvQyxdx+D2KvraPc2m4o+hNO7q+P07qNwevdROL37KJzefRROP0oR3UD27R9QuG4e+yO1a5chU6/7faZrve7qqNTrro5Lve7qyFSrF9uMTbXasM3o9EhFxI3vUERcZvO4zOixt/GwzuESs8deI5vSeyyf0vtOy8fexiPQZ+MR6LPxCPR5nAFk73EWkL1G3qm3+b3GK71lbFRvp7FsFcK7jWVXsGDpbT7KhKW3+Sgblt7m44xYeo0cY2/rO/bp1qOMnnpLucS9xrKbD5Vt5HN62z/DNqe3vXyam83FV7DO6W3XzLSZBextL8Wnzf3m4isY6PS2l+LT1hLIrGCi09teykdsLQHkCkY6hWVawUqnUH4VM51GPri381jD1d7OIy1XezuPNV3tNfLQvd1HGq/2Gjm63t73m6/2lpkX9hpHsIoFa2/vUSasvb1H2bD29h5nxNrbe5wVa29v6a4yzFh/wBYCgrhbH5daQRSelqqKhT5/8bLOLPOtRHxY59aZVXph0N/WudnBEs1F3+LPC9ZfHMt8YJKprnVWTC40q1Prmu2pFuWrg/XUqhHma2sO8Ri0bJx/cazC21NuCFJMbhjL45Von1r2U/5v31Al9HNbj18Ka1LXr93XWuZ6YZZMr/z+lM+wkvPzZ6hnVUap6qz+I1fW51iyZNHsp7psARl+3irpcddhV3Esc9ta/p5kGgg9UPJHh/sYW5PN/b0djQolTVk5t0eZWJQ0chV4mgw6Siq2qvj3WmXw/56uYplR0n6WHX+faY2uXNGFXmZdI2s9ysLmlxXp/7+R8v90mv8Tqf130fn/fgq/Gm3/Xqr+30PPV6Pkq9Hwfx/1/ml0+99Hsf+IVqfSP0SfV6bM30+Tv4MafycdfiwF/nHaa2ijlxXFvl/bv6j8de6bLVf17CHXL2qdVsoIZc2S8ma144J61Llvql9WmtT6kVWVyWrDZbWkc7+k9FhpXmgbl7SPG0CltIwkpORntXCd/tC5X6OGuFJVUyuothVDmbHB4OHFMSotdtnUQWxNONW12vz/mgr6R6CgL/XGiquCSlp3ZX2+B2G7bIfJCOUrq/oXIFnV9G/aw/82Hf+jB3T8H4DNT9P2R4fH/vyCju48LXOAr8OUjKEViDnvMcRD9Hs0c+c6djJttRzWgbI+dTN0jUMAtm5EfJea4RPACY49KpskmTtnHVGY+frXYjHPkO6K8K5k1pmIynjuEzcTeuJ5p/yzfuhG36JPXtaXf3l/3KKAtlr2BaVjHEY2j14JGwtCyfk2hHjGtnvQFcXO7Ci9veCITaIEXwF101FMRD33Wbsn6nZtjkfUlR2eETmLuQx/Q2j0isa3YeKRTjlJg/+Ax27ioVZ4jM+XEEXzFDKNoNY0D7UiOjlQfdJzP//Jp5x/lhv017pZxu0MvIJG9MPQRRchwycfjrw0Mzz/mwMLfNZJ47EmJymybRdN/Ye3uxOguQIdoOMBb8hPn0yzDF3S+BYCtuYDVikrocR/0/LDmHzxRy4B/Pz34kOl14MKhvBBcASRv8zBNaBHlsdJYjgWsfCBHrvKeI3mNM4OJ5MxGYZQ+ukNo5Ht9qunV595TtGmB8L+O0AZVGAK4/Sz/nb87m1H0WlxvDl57yzLXNdFvJDoiVxCg64nZarMzQzclrgQXAhkEQzVqnjO4Sk0VQPEkrsx9qYIzyZjOsLeDA1plOAo8QaZTw7MaBhUxW0TC3JTtzN4wKojqDbIY1pJ2v8V3zFn4J51zw+OVtk/N2h+FsDWQfp8pP4z8TO/djj5clLEIMCgiBr3vV1APJHkjrc3/4rvPIF1EqhMwWuxsBm5nYBTdA25xWKthzAbhhPs2VqHOnONrX+0dBE5fVu+8cG+rOY4ME860pEr6GoewwSRuRh50bPuOd/1aYXop80b9+cRb9WFomsGDUPdPKlru0hR5rlYiVTDP0C85Q8S8N4U8LdyhCmwKpDwTf5uAv96KzSZZY3Q+Pcca6KdV8C4hsMhniQ2ssM0uaaxHLuNbLmIbaBMthEQrgbFBGOynMfhOFbP5qw5r471wSGYm2KENDhGXNc8SUmrRasYaWIhNbHwoMo85dgLRlFiAfIQPYtFkQgTt9XiGTKG02JRw48Vdjg1dziRO5x2+N+afZ6vckcmtVr6p9r/a73MXU6xh5R+Jfi7uE1tyi2PInLwUwDeGfAhQfRtAZLMX5+XgP9F47/Zn7NWXoPForuWr4Gbc1M25QMBhiVttdaqa6dB2bdtzxjKl+pAgyfL1lGvRGVFgU9YcT0zV23BP499O41GItCevaZgfzWmF+H44zVh/fynV1eS4fFlH/7xpiQa0SmaXyQ09NaPM//PY3R67K8f51uXo8kH/GeKWSLYFEH0RCBLIFYExSL7EC5EKWJ4mMYkIZjBqT7BQ2+KwiQJh9cDsdgf7yb4FY05fXkf3o1pOPJmmc9jTM5lW0ZROP/ZAc2fd4EpujvWvTiqDhyKed/Un2fIGCV88tEA5/EtjK1A30Lh5ssyNFfkDI+8mT/PMp+igU86stG7xSI1fvPQl7NWa23NxOiZKzENHflOlI7Hvp8uFuKH74BXg6G6TzN3sdDUI+iLn16guzgeXuNbDKXmGeyrqd6D0wIpnMpjeGreygq7zNUUaO1msVgbVCiV7tNttdbyL7Vp9LVd/hjkYeOAtzT7Ym4xT50+Mx4h0j86Y+fCZUORhUt92uHbZbGgSLz1B5Lro0DE7XBC/o7vYLMGrjST5KSVRBAzu8wpuMgWECoUUrTXLCaIX6GYSDKLacaDe5MQg4DD6em/LkJGhutPSSeB7UE6jK+Zm3OcaSdlOIaGFgvbRtRPO5OQsSmNRzyB+KfHECaWZd76nGZf3AM9zs5z83T1vxxCXxbIJb5kT/91gcMYx9WuzZlWWuB1rPV5CiFs9aQo4P0Gn9ZiYQOz+no0oFEkqWOQz4Z2EvoVRxzFUp+dkTN71uZJb7nsabHgvAFjAU+0zw9E8Egm6gWwsAcB3Bb5SGwejblwbC8WTuDbYqBwvW2ayvo8yMRE4L4CIfumWU4IaJEImbRKEKTM1XcH0jHIF5/ZUeGMulFnFOS56NAftFpHmvUYAJ/B+dXFYsaRYtBqHbpUI5stKVn7I+eJ/IFGpjXjYnLEzz/gLRsrMjTwmSvZPuhkaemBkMCxTozZhEYMGwQVOHOVzCqXIxwlMVBVs4wph0P0PJcaEp9fJF9HcDb0ulpWRp75G91uq0V+3YQ/X1PjqM9ctxPjUTrEjuMwdCYaZJA/DBOnnj9wXXR2DtcXQZKedc3ZdwRD6jMpoUOW7boZv0SI+x+5dEpLJolKfruetafTaRsOZPCmo6xFfd8fLBb2bTpOyCSMk6e8wChMQp7FW9F8hDxeU3G8rKPTAwVaoP9rvu+s++okOPU1Xp0Nzs2T4FSdBKcdFUHUNTj69f66N88OqBBOzLPCNSo1qC+RQSaDA7j7B75x7U/PyLm8+c8DH74yKgUq+mISINW7x84IvyHwnaapRlkMIad9gu4r0z7R075vnPa9mvZ97bRP+icwbZiKnvqBwkFzau5BGY8NmDhnBKUcfSvzTRvmK6Ak+0zl9lOfB/KLC3TTrPCVh7cu8lKBJDdBE2fFKRQxiFaJparhkKaoZpt7s4fZLs5awSHrNwzyJ/BaiuGu4bKmqqDs4c6b+WfnVb5rhm78aZnVmhqsVs6YvcjffmFigWaaggLTFEimKfhfmWma1nNLc36YegTgVGCcZpy/kvzTIPMBbLMHjmh0CPcmcnYjzup1/mUe1zUsmHFw5bzYrEOivvz0JPvF7IPgjJ378I8A+xk7P5spxionJpyP0iQ1lUvlBjmFLxz/qgDff6vyWgdpB4SPO1tllgs1daNZLlVT3joNjkkexOv+eoGrWS9yNf11T/E0zZ2tz9eBpTkE3kywNMEqLE0qdhrJXCRIz2JBpch64E77zSzC1CsiNFxNIpbClaN/1ljt3M/LeeUdsVoDeTkv7Wh5A2u1zC/NcjD/mX1JpMM38UKgtuaSydUe2St3J19ARJck0h0uZblWYCfym8TUvCKsNChY2sqAnnUR8X90Vs+6Bw5bLIi7dHpT5b5rbdZqlTFnElNg6ODdIf9YyqsZxQymDRmnJRm9iuktPEG/wck1HR3hq3B4Zz6VcKFMYaNlbdjXX6SkhDsqeydOPNADKD+S9Jl3do4443Dy4fUHfIljHA2FCkqdN0HFDZjqKTwDvR26iPj6haTDkjBO2O8kuXbsp7bbp1zvhL905D873Pker9JTnPP8OmTXHoXn6Wt0TVni2Tb/y+vC7xhfwl8akysSwS9F48Rv2QtBExrzypOYJnRIx/Cb4TAeQvPih/x+D/jCdKr45AGYxyFj1jtFat7S5BVNoxF3EmfhWYKjEbP+RucS3CfwKG4wNR6e4WGa4AKnYzx/qWz515nD7dpj6BInMEhUZoRej7yUz/CteDG65WjhTXOZJfNmJpkcgMbCjWZBjny2WNDFAroJ4B+4igJbkbZaTuo34FuApq66LR76J8edAt82bxhk04D4FI/kuBRrcMjpdqvlfE0d8dstcxsyGbao+OmXXuhUAXTkHBry3rolcJhg1elBPcPJp1DHUAZICBk4v8mbeh0lOIb9TGNvpuuYqQPxjDdLTuKxd4PghH7F1/dIRo72DhEreJRk3roA0yli5CoKx95JqcRL+UJ2r4SGBrw/5cCXD9jM+6xfHTGGKvLegH3CpCbwpkPcg8+LhfPZJ7h/ib3DY/GCGGJ/nsZ88wxjPMJRQsIx805brdPOlCTXgzyxb8t3W9uzWXiL22KL2lLLg3nzDEnZkjfPsoOTVssJcUfM0T9x0UwkVEHrz1w0UJkVEPsDFx2JXA1d/0ih7BjnrP0VTvRm/hBOC6/OrNUSynGsr8tfkmikKxRK6yI4HF4XiiAiHE0yECWJm3EueF4s1mQndbkiRzHOcGWUCMrTvRSW7pLGjrxQWCSyUjdPmPKEs+CcD8B+//zD8zcvP778cAwH3rST0JPJRL8OAVKSKMVa1wgqnk0Fuz2rGd2sXGXgi43D6khSsTeDW5tl6ManjhDPkFbrRk13kGUcfGtdV8iL4dNx5rpxphovXA0z6etzLa0Zc1r2uQvyZ2ObS/pzRievR06KGCIuajx1iYuCc+mTF56mWy3Gn+KBU/XEgOFhhgNwjA2fovXnh/NFp3M5nhXRxLqE7C/uQfmJYYjhoijnP8E5xEc488f8joKxFjdQH2NTdkD71NDTAkoQjx1mvIzmVIB1YE01Yab5XqAh2zyUdSsLT/O1kUQtNcleUKFw4uJbS9Rm8nligG6EDgM6BHvDdTBiEyM+leIUlj8sDHy1ewyRysDMH5zR8yWZDoErklvkpooVlIBm0BEDZ+ikOpCbuoHcmPk3pYHcqHZvdLv3ql2VxVTWwaFP2FsacZZOOCI+IixxTt3+qVebc+L2T+pz7t3+vXf2YXiO4Pw/8g87QO8EcnMtCd9PXUFa1+HXwfpi4ZwdnfuHaN0/ghIc2Vdyr+2sa+/aa93MbRS6gozkqKN8FLOsKCmlQs3RYfB6TzojfBmm4wQx10XzzD1Y9wsOnaFPELkAszE19EpshdO57G7Wn3mvJ5l20FsUBr0L2eZJPAZudiCQ2gGTSkR923aLgmCTjXZYq0X7SsGFIuZ6zEVpTUHqosAH8Eze0ug5OMhzSEfxre5iUcxK8yw09UkHuGN4moO/aOYTzV/z7TM4GPjA5vX5k4H39On6fPpkln3xZnKT20/5Pf5sIK8r7d55f9BhYzLEThe1e643yJx1FLiZw9ycjrBpeHWF440yTZAcEjX3P/FtO8tfwKsAANpacsvM5IMO3FLUz7PuOae0oPW3WARLALNYiKcqNPNZAUBcMgLUAuggUHlI4IC6OeAythkAaioANXsyyL54gwKgbs5uDEDdFAB1AyDKNE+smcUCB7iE6zPIukHsm3g/FwHHE4+f+BijNXXWWSM8xgm2Qqwe8lCID1TJEYZKom2QuExw9qV0Vn/yPxmqDXdYIhQ7G2EuwzoIBK9Vus8GiuG6wH5BiDfPDlhRvEfP4Pp9vlg48hcoYiP5+4wpzZBMXzsI6EnL6ZVklI0yRQoa03l2CmyC8AIMOWfpuRIykcx1zs7V64t5ZXaG2JAluG5tmbtiGffgAtcLMz+L+XFcSw+40M+QaAD3yfElzH8BOk9wnBDM+AXtE1APzqcChFqtTwpWSB8mab9UAuR8HRJlnfW5SMy+nHsO52X9Z1TLRyh8QLaIKuA6ojC6wFpI0mu1YCp0jDvTMI6cL+/VtK1fdOO/WIRZ4e0FuUppyqwLPAxThq3kGltSDcOCzWFdh8y6pTHkhJFFI5wr5lhwreA1+EW+0HgYjXgOXPnxqE0MfR6LS4GZNSIjzkqJ7sBd+y8k+kXkdr64SDJJaVHLmKnDpNXKYcmBrnNcZOYwfk0hMR61Wmusoo9X9ij/5YMsbQxYzwtgBkOexPQbGeHRF8EcYxMnDG0dlSi1jGseokB2kxZfhaSeK3Pn5aHZA5qOBcx4YUv0VIasJbqxQsYVaG03o60WFVpTITbUppTCZIpM/lUJM4yLqJvlOzzA4nmkUIdrfYQYbqSF63sARMz1lrwmiRIop4Gtlkkb8nSF3fUqsmY5gw+h5vtvXuaMnudeFhzWt1u2Z9vuk5vjjqn2SdzM5Qq6IS6rUTHFf5gDr9Jz3ZqabohdKYIqHIggx2N9lhvg/PE72N/Y4rqiaGsNa1gnKwRk1OJSUfU2/Io/CPbmOL1IYswlhyG7i4bCb7owsHFESsXkRt6lX0YJifHHGAPKAHq8oMP8lc8UpUxrBSyzHPfehMOYegN0S0d4/F4QzzuRyCUvAxIP03EYHydxOkzSGDOQwiRxmOArkEocZj5B64V77QvChjG5JVEIfdH/8QGiU82nc5p9K7e163YiUPPnSMZcdOKH05Ak1vNjyY6cAhu8LtRo3+AkfA8VMfPWuoh9BZwRlcXeW8uvhD1gadSFECSFRcSgXFFQvw6c8PujT8vbpXyfgTsQEoXldd9FJ5nL0UYz7Wnmovtj36nimTM3gHJ2maLrFKXpeeYi2Z8DplqAaUz8MtSsrjA8HV+SK+a4ZREWqBVEHS5/LWf5aeYelLnfYDgmOEqc+VC06DGUN88tn6RU+zLy5rxZzyG+lr/Ccd6ZxJiLrOAK0JlQlqivABRm/Wf8zpDq32DOV2fA5zAhqGMZlKmQCF4zhT+BQzi+uOhBWTWSiV5JUo3kpcYzVqa4JO+OkbkqcWH1PEUQNInQb+TUgTvNvGZ/BJ1qYnljBZ1iQt0eDWrki7XbNqiTNaLGSSrSX4OsU1fM1c1M0s0884N3hEAQlSGWhAl+P06vSMQ8jVrzaRxOnnP0Y94ctBfwyLs/zuChAuQlPPZFLvhOEzJ2CvjHrgWX8mcajv+O7+CFA+r9fuwHwRRfTMLh10AyNUHgbHX3d3ou+nt97u7eVm/PRb8d++8x+tuxf3x3e0HHwPg6dozDYdIGAjBry5uP7aK/PkZrd268kogNBa04QrL623FnGOMwwTJZXnz0lYX5fz0++9vxeb/viB/8nepNOBESbeozoAFOpRm1ghQuJn452xFEinVYTV1E+dMdgPPTsW+OGEU0eR2RRCz0yHcA2yssWHr88phzYCQvugZRlaDFf9aAt4PHmMeUcNHnuuwJjZNwbLvoH3W5OiKFi/CsJl9IagLYcLaLkroik5jCozToKMYN+cDLQn5Ul58jBqsdgRAm5aVoXalLGk/DGHjAS9tFpLahlE1wxM1XwmX5wZgw6GdcV+gW31LbRcO6vHF4f2e7KJ35TjWTXl6yYYxxZLuomisOj06sxCB8mi6azPzxLMd/2CjvLoFzr1O2Y62WvNEYAl3WWV8X+QdsSqR92zBk2Prnsac5V6FMoEoQWeIfxx7/m8zEXyz/Evk3nHlKx/FA3ow8zTxz3Zly30y2zGQLkfxL5d+h/DuWf2PdA9M9aF2yLMt4oc/HZlpu3pHG+BWJQn5OsGM8xsOExq9C+PdOhwiahzE+BiLLODEE66IYv5tGvJJImiJVxkgE0TSXqaEbdIQO0To69dd6+VJdh9FoDGSfwRETJYNwPGbOKTpRS3Pvr02dE3QDUpa1wDlFAwRfWkXKP0U3/gm6b7U+9UutvsVTPpLn0egtnvKRafIOL7vOAJqlnRGe4GjE3kVqPvBi6lMnRTeui9Z94sDIb1y0njmud9/UTd42q21S9fj4LutmpqajEVjMJvXXZg5FRxpARz7lwuq8TdnoekVyW48J/DVbzey0X79gUMYrDfIViRnPLrQw8APQzEMa/mra5qxP/bUujBIqGqgKx/mbcPKR8gEOYOYhd6JQeWOEQ0Hl64nQXPLMHGqwpsNySd0gUUAqF6muoL/WQ+VSxtivcPKiXMVhy1Cmfyjso52aPNfrcRGMuEU0QehVKi6R9fB5H9PZXQ4cNB8RNhmHd1ycSgzhs651q1vmdQtNpzUTSDt5DV7YK6WIu42bHTQ3Aphg1spHM8IJHiaSUD2PRqc4Bq0G/pxcqMFQXbsNCyLND1LejhJjV58/QKJb6CRYvZPARaoDUNhDqYEmgkd6HX0Lx2T0PL4y6LACNX8zrWGJvshaUvhEL/k5aK3P1bmXgZ2+BQqMVhhfpdzJ1fQac+SO8JC7M9OmRlyFEdBpgkfanwegRtb5Yu5Jedy8wfEV1guNiBos3EwJUtdTWq34ltLJoRK0McflHOH1zJ9HNIHldOcZXBA94ALPzrODEqSAEA1jMsnVDWD94JZ0PUOB30XTmuNmcB1GV/h3PjnY7LMOjTg9FRmtVinBMbRjkvhO9nkB5Dd48gSRxcIh8GTcCUejt5gleHScXjg1fbnwLKlr1xVAqV+aIUiXcITjgXZOoH2U8ocdyv8oXbDhGPPQeYXMDGlgVqDucPZacvlcV+KAHrhUu+ZxXER92onwLAHpP6yFceqcCeE8UVUJVOVPBqAX4BNeL1drR/nkiZhE6q91taYB9eeqW48gqCreriYx/gZeGGQ7AQgAvvXFH96DH3iwA/ObXMSMNUpzho/Lp9d6KODV+uIPb8cXzXlU/kB1vfA/HpM/XGCtHLeIHCeFroN2GxFh0wsUgzgAFUH1UNqRi8WRVYkjZ/7cxKL8UDVTuVC6gIja0URqIJikZgS2gKQdsNgCX9e6CEQH5SG7mcIW3RvLB1HOAeh2FHJlqAajEWF6mKO8ITM1P26nGTKn5dXuumM8vnTc+XSxcKYwiyIg3Kw0Ja9hdVQzYP0GKFEFBOC62n2ME6BU4+CM06nRzF9zjDu6Ph6ElayhsiASOiM65HS3OUfekl+Ky6qLLme1QoAo/EauwoTGrZb9Aa5Gb0MIzg+PHTpLqssm6Hbmj2aLxeWs/9txJ2X4KLyjafLy8hIPE08kiY8DY32Kql4+6MTwZx9hHU0Xi95T5vu9p9Rja7Cx6JpvaOKakhPRErzPiEaVilH3wLiemRpfUvOjTuOL6lxa1lQiftFAApUMx8UTkWSZ4D2raHYBxwSNHUGUugfpr6roQfrkCZgYKFsP/mIOw5EOoZR8jZNMONXhpXSxWIPZnsHHOaLir5t3pUDA0ehq5s+5628pAwHNSS5mHubfpU+eLYk5P3b5t8HArXURZz6KJXhSTL7hET/eQG+LMw8NebreLZmBZG2ti+CBVQ8gEQPL0N1Mhg9c6yIBNVlWQIoPPhyPcax/8TTFhjDxAdY80Nq3mT9XV2Ix79tJGOMVplw/uouZPz9LZ+deodUYRyMcP7LRDJ1NZufet1lR3AYQI0NWexVhb/AtNXK0dML3/fEM9B/632bexewsl0GcLxZXgsIEM4XEggQoZEMvdQaoaOZYCINmaNqQKyQqDB035L/AgpuiMZqZRd6rlXx3ib7qDL2+BoNFCUve0ojTJA0Vuf+VED7fzTz56yy/mM1mfKO2WmTN97/OWq2GBombiaPtJa8wBUVY4hOlbzCFVDd/wygsEQrMb+rqfT/1uwfTfN8/eaJNLmY+UZqezt3sbHa+WAStVsB/pK1WejY7d/NJHMMVfOYKK8qZw9AMEeM5GdgG7TqJ6+3N/KLME72b+Wec9YF/DK8ow3CSpLE6Ww1+GyTZcMtmnWEaxzhK/BQR/ZtzPPKj1XL0b8EkTgs8jJBkGoTbPAL4cJ/PfPPqHOFhIgcxBxGCl6KysMg3Gq0RHPnmaVErRaqU4PeNkVFkUCwi5Zwf8KV3I2/mQDq9I//TcQYvKHOlnn+E1gs3XN63ukCr21cOhX7NZZD16+7drtd8mbNLHdmu1yDc4Py5MwcPW0Aq0GlhsC8ImwBaNY/X1INoGG7eF/Wf6da5wDXgbxLBBYlG4h1lAFOiMTOsTy14oja0rFNQqmaGD7qz9Pyg4QoNx6IP740MdGucQPx0tVYh4RrOrut6PxHwJaAtgT28HM5HsrjHxCJQeCrOF0FffFdGlqK0Jq8vBl8rq8lLCclLWVpTuyVSKXsNxCW43O5tpU2CNCUe+EwmqHrTfuoMUCDcLQxcTzDf8BMFcA96AOy6M9vlrHRFbpDBlfHe10Iv3bGpEcY6xrQXC6ENtFjYWkIB7jG+SE8UDog63C/wYsquQWPnNyG7zC/2zLtHRTjWSTw8in4vJXsM8WUp7mJvXaWWUMw7RcVV9E5QnUC9QvcapOxV6mewI3L2r+S3FnueETgizn3B8HOmRFz+lWpJOEw+wHZ/JWgn5tSTCReuPtWa/+ohCoEL1cxFXDFhVmn20EVnBB2eu2gg89QL38xFN3qdaYclNAbl+1JKR57RdVlqS7roSDc0MMoNZKPy/F/3b/qyqiez0Kl/1B/wPvi7Ge/JW9edopPKhEwbl8bXknkdWtAmtCBltEgB2oFWgnjgcYY6DAUuIuJPKv4wrne8rmGEQCP9bP3cRWef0Oea5Qem5l6pwr6b6SfhGgHbOroxVPqNHBASsU5ZOtCBcyPfydwRCCDMOrpBg3MXYVwZzU1/4IHMcIDM5r1PUO0GDdCncxcRVe0DVvqILgrNxKmLxnWFhmbiWs9Fk3LCqKbawe1MHsMTnLNUXcShZyb1MulzRIDwsjo9+N8Y56zY1Pf9UH/38yzvxMmRETQ8+CKi6bmL7qrNMv+Z8UakhRgnkxEQGJNDRDMkn+KkzZVrCIYyac+/1kPCYEROZHiNh19f0Vi25yu0WQcnB2rERe9MzKfG8A+EUPaEc8TE55iq6+WM8YnP0KnPspPFwhEDAHEZWGIpAM3Ur8XiyIEzSAOfoBvj98xcp0Pgb7WbmaJo1y/NDZFOUZ6ESgUcF+npQ+OkUxYcoXIXnMk+lQqup1nm3KN19AmdoBAjgtEQowlGY4w+g72DWAjYrOcCbBf4YC0XbDL8mtFbGk+uydAU44ClxfP4SkvfFcpyZS4KOttu5tTcHtCZHsSUj+FcXFsusD+YOXcYXWJ02oeWTpxTjofeJa6oxo4MlHZY5xYzFl7hJ/6XP6KP19jC3Pr3NryzLrA1pHGMx2GCR0ptmTALZKyEpkwU9f6I1ud5mx2WhMOvGcRs+OIilqm5GUWUTHVcSSF5ygUuaM+WN9Fvx0XZG1DzTqdzgRHYTwd8bweIoQt8rnmTchv3/Uors857qdiBpN8YjDMUYNcLsIvOZijACONzJf89gnGB9oRTOse5+OqoU+ZEfIaOTKbIL1Ur5BF0k8vufzvu5LckxzGdOUOibAfyzGtgZX5HUvuwgX+g4ENWw4uWhkPr5lN75aeI6VtBbYEjKCDUf26MC6oCvjMX5z/TV0Gah9tTNniCC0gRS8ILMibJ3QA2vweeH6MhthHhqo3JnQKuyJ7K7CyXFtTzWBatO1T1+ahHWDj6KCoxKSnfjqn0/VgZa9MgMyAqTJwCBlOWj5EZBBuKnucHn3IzVBhX5s/yZS1SPFphBRAt09UBf8DOu2y1qrUUsaVVIlvuUkCDT3IGzIV21kYXi0/HB03Ye1PZnbMMaCXHo9ezg9cz/+8cTsd30fDlLMFxFI6PYZ2A4iqGDHGzlcEMLHCc97i2vMubfD+rVQ7c29za23PRm9rcTuS8n6kJAc08vmMJvgXNcarX5pJ792GOK10w/K7LabcL73FHb7O5kH7mTy/vcQkyFH2IHNcBo1PHFWETJ/zE4D/lJoLnItlA3mFhj3/J0531OYEFf5FnO9SFG1peJkMwwQ+UJr7USybmFNPyFKHkd07wZobkjqMZquQSPf3GqRfnDiOpzhxSHXhKL82b6HlDCTFrSXFlqA3Xf8Yf4xl2SF8BhUs+vTczx0XPYW9KVQDTXxa/QsBDOpj85SLafqckchLq0UAWgagwg0hPHYIC4SAxXzvmuiD5EC98vAFf8ZUwgpKRvZYNTX16FtSKf6at1tQhZ8E5Ajt6xMTTI/T4Rp4IURKSCMc5PBxqTBfQIeDoACadDkV2TGlim8hRaqgeT3hjcSqVYIg7Z+kEi5/mbB0gnRSMZrOTt8fPX70MtKrE72Q8/oCHmHyTRak7r9bM0cjNNG4qZvlT4rhOXgKl/aKPPu8s5zkqqDrlJ5+BiqV5V7GyVMBZnwdlBJ1qBC0VzuTDibn8gcZBWApIT13Hfj6Z2GpV0FyMGZAYPPf+fnwwcwK3IyFRMyfO/otH2Jw5yFGBn2NSFb7uaSE1DMrg8UaqDb/FeGSF0jILWQm1uI2BFeaaLx3r95BZV+QbjqzQsp/oBrXjBwI+A9VDcD/oizlyl7zGPuZyf+q4rldKdL0Z3FwuQzI+JmMcJeO7xYI5bmdMr4ShoGFgBoYr+dg8G6XCIE9Axlgxbrr0sHjsG8FT35mbUJU2GMeCFvKffM+DEpgR7AfIxU3CXUxIgW014oPrOmbLDkE8rgEKeG1QsGcBMLfkHr81W4KqTs3edwjqggBDh3ei8E4CO4N5hTl4KQIzglJ1bnPEX/nEHz4esxrsHASa/kVYZlnGARWMuSsL35lzVtNjBnBoDjOCiuBMCwYa4G/Z8Hna73zDMY+BZTg/nT7zezut1vTX3h5/n1Fb7FF77OD344f2E6frXLBbgAKIiAqwDcyYIyM6jcB44iQeCxMLuPiNMafA1HCO489VSY+fLDiOlfEFkS4bBbvEpAc6lRuYJkDTTCokSeufGcgelVB66hhqmxEIE8UTIrUIl9kPYafyrQ4u7eBoS9kzf6vbdbXuUMrv8Uc0BMedx7yEY8NeBGdriHQiPP0IlCN6GcfKs2zIGLmKnFzXzqHqkpv38xGYgie2ZT9hLpozmsZD7Nl8EjYcpsChOmuqdKtVHXOrpefGpQ7vKWPkYoxfhWT8AYeMRqBwA9dzrq/F1SdOPhzZJLLCpG84F3M9h/r1GiWOHYLSfAecgvkMvF4nyYR59pqvuIUhHbdaKhnkU0lnTIWPOl2gYvxZgdAXNXjrlszwqC1duFqEsRT3rY/cJPkKW9OQWcIyx6LfcGyJfp8+tS7SxApBW1F3mj19CtPjVQB9yCXBo47F7zdgFZ1YdzS1whhzwhkm4FODKzUmlHchRmLJkXS+1KyS2o/8NlHCBcb9A9OO8NG0VgSMSHwMWIYxZUw6fLKcwbsPx64JHJioygQgiN+Za40oFrbP3I5R2nZfYShVHVDm5vDBlrjkWmKOjCdxacwwsX55zj18toF0xnTcfg5Pqe2//GIpe9taaFUhlEuGRC9cQe6gYdfJGG+2i1SB4wkeOrTD+WuUdtJ47LhCb0+VEI54MgbaQbIAamh+LD75rhaqdpUZoJkjTAARFIbehWZIxQZuUGMDpw0Ma52Y1dnC6RqmY7AGz1/PuZMIrxLPCf3l6V/sLHM7yTUGzJolXFPSzVANEIAQS+VOoU+ggYIUzUN6GVSSoMS2Ms1u87g22hc22DUrrwZY4LRQIQLrfG2YTyOugbw+L/EI1AXvqcLdrA2bOBCjDuS4AkEdwS2hcDfMvQoKpaz5uDC1E/FACxeZLvoTd8BcDSQXPJHbq9mFCrYym9XC4HnRbBCG481DfWQJg9xY5tQP1JO31Ip9Ke3IGfSF/VtpLEjnux7LEMtPxsw0zuF+M47IV/yRcuPhoJzAu5/LsBF0saDPlFEAd82pTQSEkgs3evS7KPWVKfIB+ZUekCdPXHDpDe75tUF+mo8ioQPufRWcqYhhVFLqHq75WOEGQdPkNzrGLKikSButsuW8Oq1XmD7XilgsdKc86OXFWNcofcsO61QqhW6U0hVmZ+K7wxtIaHy+WKgc+7/+S6Xa52qsYg4QOrg6qDRi6WRC4wSPXpfG15yl1aZYQXlKYxh7BJQQFeInUNXoJFR4HRUKi8yVDnn2wCGPsnh4p31nEHBjYFySuaJVIYXfMlxkvwl5oAtwC3aMRd1+ASqe/Vwp/MmCT//l9L0TsnjtRonT9/YWvZ3F5obr9L3BOLyd4JErWlh/KmNWuP3VZuspeWBxISIaKSgfT2IcjoJKiuPOa2+OyrpC8hXAVDBeARptKzTTPF3nj+h1ZNF4hGMoeoE1ZiJegY9aOg5h1m3KEus6/Iat0KognuNawv1Qx3azgi0CR4RA/ClpGOp0v0b2olDdZvxHOUN3rd8UK41bLPNqMkHdqLm7Atb4vq/T19RvQ16lxubpDlF5viaFUhqMf8d32lmpX8gntwRUpHNtxJJucd4u+JzMnbGLfaPpgdHUeTF2leg19YncWIguFrbUcxFxKZu6TDXFSw9qke+//svoVmCL2vzWRKcLjz22eicxSIZP+2LPe295ME1X0CikSuh9X0IINUDq9qlHn9h2wX7H0EcNip9FIyCH+sUFoq5LQD2qVrWV153rSFhReiv2jdSAviRXqf6exiSRvzPXE07uEDN92XKJXyD/5tLgUkaRX+8XvoQWg1tAd3WYUr93QH/VasxKc5Q+eSKQgfg6D3zdqGpcRY6AdNmtVSKHGz+8pIJ2HFcnz/VFUWnkHR5ugTMAuTq1WzYoC6Txe0Cn0d+5ejxsAjHCotY8oOky1WGF5MvKQDOUm9qk2l3WpaGOVPRL1qSBzMfYydefBxkVDsnkpAFMuaagnnIgdpkk549aLXHCm2vWNz94GJX/3Og3AFVMCPTY1rqGIzVz6ivsGtCnAgepD4CnfvcQru7xUFWHuK730CQaZtC8Zx9aT4KE69ei8vPHmT+vmvUld2MsR6KpeXnhwL+apr75MvXMNevBmiFSrbthFNo4l+xCRUcS5FLwnABCwQG9vSARv4gpvrXr50a4i0XP+CoeH1THGepoUmt9nPEwmg7/mx9VEzrF8TFO3uP4Nk3y7lQ7EmllI11f2OLAnz7zNvjfM3D7iGDyyLaVSjxPg/7zhN65WyjQKxfonrvn3ma5TQDY4xquFNhYoeflBcotbDzUwsajB/lgnzXTejyEHwuZx0/0weXZeHDmHBHoM3+rggfsbPNnI0OlwOZPx5beQ138ODptPNTF5kNdbD7UxeajIdn78RqbP7wJHq6x+TBGPLaPze8gQT9cY/OHN3Pv0bDqPTjz7qNrbPx8EtN99A7sfceWfGwfm4/u4/GbtPvjNTYephSPpy2P7WPj0X38lCP4saTi8di9CkX7YaL4EyhY7zsO0n8Hw/L40/qHafPGv4H0/gQ62f0OvuPfwd49nrl5LHauQp1/mMD/BGrc/Q427YdpaffHievjuarNx6/IxnccCj9M87vfwdWudD2REn94PkL8Tp05rCp+0rJqWwSzhNdlkCxp97t5SUPrvShSKn6BhqyrxDaIVmRvWoCgY73lTr+EUMCyK5W0DUJ+8Y/oCCN4emEgHGHXGCcoVZ8o8A2P9vPMS9HUZx2wF4nGJBICFcZjO3zFd2gglD2kxAjiC3HPhugIfoRXMFp0qOKEcsk1dyoJwRyUZOMQko904OKy26KB1K03JY0V+YqTaliQJ35POPoyliBFgS5QhMscoME19RUsPIZKs/Uo+orvPHtIR7jNMPff2daIRpDdznEI9KJANAURiaZcCDqVwYkL8lHyAJ7QDpuMScLlPPXiM9aRIb9ATRRU9ESABegcgSPis3Owgh10hgprWi3jI68s0dftn8lf5xCY8FOx5r2antlE85ZYO8nbBxfWEMDGfwD1BxBIBs1181490n/S8XvEknlVOZ8xRlRUn4FuFJpzSyYZU/pnDc7oGRx3w7J/9k8d2lEGIs26t0dlebwzB5ybZWjdRZ9d4S/47cw/s8dhdJWGV9hGtmrWRjafFSSlLKG3x+qLjvDH8ErYSyO7hNdQ7ZpOj0iExWuOShGFSulJGIMSVJ5qI3usP7SqpOo6z1IpYBINtZn6TaMr9T2WMmD4LVQPefvvYxi+jeyBmIicko3skCV/xZF6Ozf8hpCr6zG5uv7/jxcKY7b/7qeKKmD/r/VKsWT8/00PFB9m/tM/oqdXObI+H4+NTWae0YDlgqggoj+BbqX8y9xrpRP7ckzDxLPH+DIB5acR6Ap9gHl7dq87mdnqRI/yvYlmeQtTOPOnPExTdcc3Ui+5K+eCJFeILjcDQeZjSPPMgRIwRGr7z9mU3GCseNDrzR749AlpHi+bhJGNOInltCc/zqmLcjIvHaqzuygJZ22NQjhu80qRpINi2jUKC2k/dQLXSzODMQ2Q/UdkC/xw5ny6HjUChUTrT5Ftu4oBgLKygxmqgsQbZKbTnSuclIm3yVdIfTnlwRxJFs2T8Mg5Oc8AjZhlOwcRM0F0Zg/prWiNF1cwWQ1053JqNMuNKM/kKDmnKCPasOzc1GZjDN9ejI1JigNNaQtwuo0CX/k28WzCgdK+GNPhVxvdkuh3MkquPQi9ayxNqlWaHB3g3+5sbONbiA9d2kz41kYwxOdjchV5dgzJNg8yK9TTPTuiEbZBab/WewvwbZ4O0d9AyRuSIZw92FBnFR+fUR3Hr0DLt9W4uJ1K5zQPhTEO4yvMEmPjAcWo5Qz4TUCf3miQU5IZUJIZgqiMOT925BvBF8/OvRt0KFs221z3WafAIaBTlcK/Tnz9XAvRcPvNUKqB/KA/cIjrDVw42cilc5IPzzd+9/PAZKuoLRo1O0lMbh21gZ/+wZ48dV20SiNHrusdgZ9PeWe49xsxPeVE9YB20ohdk8vEqdv5BN3nh9B661BEiYEG/CaQ6R1zOcYzO0OyvOui1WnHSb6XTUe9l+MwSXDEWbkY4yKX8p1v8mfntW/ytXU3zotP9WfnSDhoZKaDRnl6cGdT5n01EPH/ZSC76nab5/QrODfo4yrrDkpaxxjOHm7jrgL1B/m9gY8K7DDVIWLcuo1Lx0EZxnkTaFrH5uQyBDkxJm6vVVZzEtMhZoxvwKojEDFAiBdbWWVx9we37Gfn6NRv99CJ3z2oIVwOQxLvj75/SY1IIkeqbrdf6k36Csg7LYgG6leVoZxSelNUIpveAFWIJpzXdSTTC5AmmB4xUOX78bhEQIHFM8mnd4M08eSOFGDW6MjwtC9mfhJNC5AR+o1pq0VbrUArojWRpAHizgrZUpLEnZXnfDEHf6bIwH0u/AnGlE4c0SfzD89OzpF5kp11zwVeIVKIMf4WTyWGGsIxEajrw4z7XINNTTSPaHJZB0HdPUDxEzMIPbguV+YJcMg1bErOSlDB5fETBk472cqNb+D7oVSYPn3SQydagrSUtAxM0sJqiQAIgGbuwbrYzzdSxAGz5lRMBVBVO+3w7ORJ77zVEn81gCsJIG+9f2DObmbI8j75S2dyv8pMDg75Ag2xc/Kkh7rok5jNXEhWDFBCczPEGqiihAWIUXR1jAv1ByvUx5jHTUSn/kl28uRJdnDy66E6Otx7h6PW6ZrvH5aA/Mk3V/pQW1KQS+dTq/VJfVdn9QkVcK4wER0mfN1zjvyzc4U/UmBwhNbdio/8D1KyYvKGMZ2yOmlwSVa09MK1RLLKTMkqqUhW06Jkldc08ckt3G4I+02xLX9jRW1xI5yoZm2ep4lwNPqi3lnG1ubuzp6LjgwvL7pufbiLY36b+S2/zDiGsrYSzKEANLf1seuTDqvesim/ZJOOIa9DN74R8XyeeQN0BCUMGR46zIsc9Q15ZNrXcsH8npa6XkFU+ug7xuzsF+j9jPfzF9/o4vyXc7c5+xc9AvSLff6Le+5mHrhHJZV3hNN8PuuLxTo6AWiVbgP3Obd/0mqdoE+yTPUu8jlv7dNi8QlhzIFfESMQnBfEuN/zMEYhlG2SZ6JxMVckDo1mxhhWbIzBExwxrioj/anvMpc4n9AIQ5RRdKeaF6t8YTR8xxu+wyiAMko2iqbwJcSj6NgoPsV9exJj25tiNIMyUnKKPhqFZrgvJDbeDKMPWCIZ+tMo8gH3S9ZUgdsPIAo4eO60be8DRutQ0xTEonvsl7WnpZVWQWAn+AoZmDKPIadD6T3cyBGlDD/QkvImqxXlGXhfn2e1mvIMbNfn3C5XP12krjukUUIieLoCFXq4FJQ8yq4mRJ4+KESGgYrbyLTgLt6fnqXnyBgUcSESuDqo7l6zl1psrGZCQOc/UHZ4mtsK4E3qLbAG2F8Hrw4cTL9j/75fEZAV5XNoXhQ5eiFGWiTpHQr6BsNChlSRRx6uyquIqsqtSP7EmQiJi37D/qxzPb5hi8Xs7JdJ3EB1Fos5hPW4imkajQZ0TGPP/o/Ly0s7Q5+wXzwg1rHbt6FJ27MnMWG3N8xG/8T+ab8iobzHjcLL3zC6cTPXq62TU+B7bMgOcp7oE0bwLKs+zVKu9wmjhk6hR0Ctw+XX9Uvcn0+vCdg7h0MMs8RtIDZ25pXT7QzJxly0to7dJtHoMUb/xOh3XPVk9BGjQ/Qndt0DR5MJiIId4MXiEryrOhPsr3VdoFSQVmI6OLb9A/t14r0/cXaOcOIXI0/mcgLFrBSIDRdmqTM3lVJyLr+SPUMLp+rhuYga1FUbs46TacugWIQlR7IDcKuiLJepmxWiOikRAelLa6MAqXHJaWbetE9zxoQ/53i0yKlApHBwyaDoS6sl6q4ZDZttpPCA6akeKwbzeUbmzE24ees4H17KN6P3J0ZlmHn/AP5bxsjAiYZ0q+XgRNy9/H9IZ2RJ4qs0RbzAkCBJ1Bx8XxeAm5uInu4kSSFV1DcuZJpRhj7ixC+IIHCCJhhdYHSPPiOCUZI8Ee4nL7F7sBy56zF77XOr9Tscs84ceGIvTkzWdVZhXU+5F+/MeTEDZ04HR7NOjK8IS3CscMZ/UU2THkgOZ/7RjM/rVT1vur/d3dtXnoL+bHLn9mrmOgI86/XN7Gzv7m6oZk6bmlnXzZzUN9Pb3djbVs3cNzVzopv5vb6Zze3NrS3VzN+bmvldN/Nb02i2NzdVM39rauY33cxf65vZ3dzqath8amrmr7qZf9Y3s7GzvdtTzXxuauaf0AwKLxMcc8t/4fv2sIogjn3DaGSj05mLGrJt9GdT5ux2bKP7pty7ELL/1pR9nSQTG31qyr4I2bWN/t6Uze2N2DUej230uXHw4bdQPJPySWToHzN/Dke0l0ue5SMNeHG5HNPpPz07TBOqHzM9u9uBFxmU8wGe/R+bm5s2Ggp+gJ98dob42d8Go3Lbm1/SKPkdi7ebCzoe6XyWxDS6WlZCsMo8X3A2NknCMRnqDv9jb29Pl8a3k+uQEVZbQxVKQuhRVd/ZGO5dburMb2FMgJ8zSoQjvH1pVMe3k3GY4BWKKo8QbTJaoRBnTpaUE1a+Rv7G5TDc0PkX6XiMEyN/tLmzuZPPDAi/kXt5GeZZJBk35TERr64hN0ySmFykSVPtP1PamAch15OARMuySaRwqK6IfMhsnLKyPm/K/4rvpjQ21+ZyONzYq1mbIs6USo25qf24uYB6pfXmdUg7wmNcAjHfUTW89nD/Yv9CV4zxFZ5NjGrDnYuty64xruhrc+4tTsLCkAttw36Xg6qOQ2IeMoebZSj56s/DqzDB3j8g6EESej+FvGxsGF2FoYGa6QUzgRqGy9H20kZL6Ex5iba2thrR2MwrwdHMqmzZy8vhcHMJfpbyK1u6lF9BgFJ+ZX90u8Phzk7dIjeUqNmjmxthiPFD27RUqrpFSgVK1Kmcu4TmlorWUaTKYKKvzblVorCzs7UVhg8RhVKpEjya2iCrdFQ+GUrlyuT74qLXM9awBmalEjUUqFRCL0BxzuV26FDk/8CBv/wIz9AtjejXkPwk0rK7sWdSl9Fo1MAmXO5v7OxuLDs5RIFmCtN0ljxUr+Z0ebArBeWG0ZeP1GKuZLlyZBvt48s8l3vm7dQhXpH3q9uIF5e7+6OLZvpXyq/Qt1J+aSOXW68wTDt4YwM3U9dSfuV4KWaXd53MXoYA5SPpwSrGZmgaRZFuPthiDUGvgKWWoJeBo7AZlnmFYhOG0xFtLlhzFJVK1LHbJVjUE6mmUg83WGUKdrd3e9u4kS8oZdfQ1VKJEvNQrv8wPS2cI9ViGYpoPPpJxPLl5tZWNyeWL/ZevHy537hdytn15G+v97w36D1wLu69OhwcHq5CW8snpaz5ENY2Fatg7d7eoPuiuxKXHF9dhE5vZxNZvf0usnpbXWR1O9tuHXY0VN7vIWt/F1m97k6pbs02Ls2hRBhKuQ/ASWvuNc5clbCe1Z8F5fLVA7O08tVjrlSgcliU8ivM7uHW3svnL5oPk5eHg8O9w+bj4vnm4cu9QSO9L4FsEsbhLWvG/8ppUxp+6dQttb6SYOOSxrfpOFyC1w9yX3CietdAF2xvDuLjF3hIY+7AxuP+FuMxiZrp39Zge2fn+bJLVbGAJnANAy6Rx+2Xe73ng0JuDWLVlXpocZfTgzpWprK8tadmGUeX5lYZhNImWko6a46z0hiXnXxNRYtd5qANL6JLq9MInXzgRsHKDs53YDgJh9d4tQbNojUg01AJ41FKImp18gUqUcy8TTbBw+TG6miMMUHT3dvdLRR7mOY9AB2NXsMxvUljNZsyeujmisWWwHFI/1xlvsPJxOos2R3GABlbqUFdrLofc/iNyOVlPYzzDvGqoLsas/EqQ7uKKf12Z3TrRTRxvEsSs6TNVYXc2sFehzP8EEKASP2hMuMRWW1CY8ImDyHCOA1XmfMtpZEQ9a9SOroi0WylvWeWrG69nDpMLlfpV74pgnf3FUqzZTiW73p4N0xXKjgll8lDi/eN3D44NlR7IsN7z/LWM0QvGBmRMPpJ/PnexgXc/VQ/uIs38NYSDmx/c7i7s/l4kcWD9aq83OpdkZoRNrJ4l5fD0cbG0nN6Z2fv4qLbwGYVJRYrizYqqC+ym2dX4T1Hm/u7W9tNgoxSbpkL2t7t7W00MQXDi4tw1MhwiNxl4oEyl1psr3zJK2XXyCtKJcrXkocHVN12pSE13ekailXvdMWCNZKIUoka9qk8y3r2qanUww1WTmg83N3pdpsvR6X8mheo3h7e32nk5EvZNZKMUonGK/jz7t7G4YtHyDTU1vseIXKG7ITe0jim03YEGbY3b4LA/s7+3hIIlLJr1gheSupk5CsUDa+aM0t7tpRbTyibCpUXo1Su+oJYzK9Z9vJgK0+weH9z+3LZ/q0rUb05lUpVj5RSgRJhKeVWbuel/BKJrXQefW3OrX0D7w53dpc8BF5sX4x29pYIwov5FbJcyq+hWaUSlatKL9y4wMuunsUCVe7hYmN/6+LiIV5Bl1qRwylwNL1R73Kjl3M0w+3h3nCnwgY9SkiylHiQEf7+R+v8CbVbmofQkS2eoGb3EY1vw3GxhWXUcFn5Gkrfhf9rYFPrif+DNcrXt+5eYcwcBQogwJfwfzX4VlkGc1Ywiod0iZrK5xRj5So5kq9cpYT2K9eT5Orx/ZDvG546CFauaVK15ZUuL6unwQPYatbRZ9Aj6kiC/IgaFQrcFSi75EZSpsnVfT3q4hEeLdsnFcL9PY1U5brf04pmvB4W69YwMDs7XbwbrsTrlIrWvpHAyRBifBEufw0RV7y9i9HPo/EZir/6/5ghHY6TBRXLMt+Zay1tZphKUt+2zTCHBLFiVTgOUggWqW0tA9+2M7cQ9tHtlGp1kmt8q4wgmDdT+uDSujnzU3Tjz/qds+l5vz9oVLA+nKGGYUtLhxvwXCEMdKKvtfq021u9fRexrw2KtNFXpWtrQE9PA9TXfWd+ScaYd8t8W4VP6yRgb2COSEWZBNwpxd4ExfgwGtDJnanMP81hOjMAOvCdLhLRuj/gS9cRETJv/NSxK8tqg7MkHQ8Yf8NR8ul4GNPxmERXh/iORiMJS5+pDuYJmJYnMGI8TsJPPEA3mjNe7TeBWimil5cMJ/IzQCL3I514Uwh8mT4LWi1uiTxttciv3cUieDJ9BjbN5FnXhZA6EzGaF2LdHR3cTM/t5eUlHiZuIRA9843ATIPOMI1jHikSwPSWjjBztVss5j9bWxPeBz9y/X9pYntEWKJcGjHHviUwcJiE7eahpFlums2jsoWj0UsYLVTGEY4d+5amDE+vMR7bK4EXzSchY+Qb9tZ63N8Wn1W5nxjf0m/4B7vidgI8njzlIfMru8YekW+2aUxkazMPISxCMb70BhkKWq0VKg/p5K6d0PZwTCYXNIyBclWq/S3qAHp/pANVCnGq7M1qCtsXaZLQyJbBlF1E+s1lzKGoLabCdjIb0WgwJsOvnoD3V8d1ZjzuNLJfyMK2NAmrdHFjEJepsZV/gwjrFBVwRwbsfT8OSQQRVb2CLwedfErwFMciQnFN7HZjLhTcc2ZoBrGkDdJTasl3jCoMaLXhC6WuD26bhealyZhT4d1Sl7twCJYfGSKGoDMvz53ko+Bh3YPCcUA7+XlSczKEw4R8CxM8QjNVVI7BsUuzz4OSTBeL3PeODo1H+tMq4jAUgDlUJX3GsQ2yiLF2aWXtZpmboePKISjCMfa4oYUz55YXENLSy20wUCEKdM0xqk7D5GvxOIy/ZiYOePPKUnjL1gkVjiuv6RyrTLQZ64C8NEFgQ0IA0GewdMwPIhh0I8AUXBv98A7OGlegeWTnSIawZkHZMkYcfH99/TF48frDx08eQ/B78O7Nm9cfPYrePx/8/flfXwanLz8cv3731iPo8OT10Yvg4+s3L7008+flAvZ2p7fX2bDNZuyr3mjUu9wdDm2U97TWNduyP16nyOruWm/pN2uju7Fl9ba8bs/r7Vp/ffPRzg7CRMXhZr7xm1vDGt8dNg2vrnB88tqfyzRgOUjyAX8j/IvC1wsSJ3ceQyCQGn0kt5gl4e0EgnlqUFUQugaG0vkoreewtnY3tvZcNG5isahmsdKvfiFEK4LQ6jyU3CGwxmF8B1wK1etVYKEIBERPM59BZF2fOHahou2iqZ92ivHSwT+LeAH5vdyRirQGPKfuQxK6PBxbhYgESHJPnLZOUd0IqwcNRR+ACs8zlFwT4RWEiZ/8HJuJI527hVEErzJgM4S+/6WS7azPp5n7BTkzn7p5uMJWa5Z/dAjj8eL1iFstp9pPXvw2nEBYWPyRB8ljPm3Oc1GloQxdhuMxXHx8Zx7x86v+xKowHKoeXH7+v//P/8f/27KrILWJ4B6QPaDpeMSD+4rVs2xkcxNR1rcBxjljb3sM2chiGMsY0wIVbVdhyQoYwpIwIUPlAUXAxUSJcpdFDIG9pmbH4/vrgi/IaAAWt1761bhqwQyzA9kn4DaOyTc84pB/FdNbPmDDSPc6ZDwJqA7fYRCn2Ahu6XQ6HebOWTrB8rdAQ+gB+0b1nqzOB5BVRimsm3Nk7lxGnfpCmd5SNVuaIQNyNJ82yfy8bbC2zgfZUWOUDUIITMd+pTCm2VaXIIGE4K9K+1wQsLEmX/3C0iMWXuJAjBw4MDVg4Nk9Bo7HLtPx+N03HMdkBHfotV7mzzNOSK9wcnzHEnwrmEA5ypSHQTuzn08mNrIPQ4aPwjuaJjayTwW9fR+HV7fhK367sZH9Orqk2mmIjexjHiOdFZKGcMcuJD1Pk2sak3t8mERmumjWTHk3wUJYwswPswR/X8CJcNesbr2F0nJMNrLf0BEe6x/CH1psA2vIzlHgj4EtT5Fw/JEqO2y4yo0dx2FofinZWdqpnAjgN19f3OZNe6Z6knjVlohbZLOK5SdfkUIjT+3RDJXYnCDLJK14PpnUU4grnIi1bcL5Mc891qG1qYntiPhU3XsdF/wQOATu+Dr89WLBL8xVgnjdkxTxF+sttUQnMhrqyLqksWX/ggj6xbZ+ccu70mJiBMbQG/cRE5c2tXUuv/rPJxMFE4l+HDve00naAKLhmDIsGLMxTuZhmlw/l+HNWWHrC1+eL2ASXODGnLUelyTI0avaOTAZMluj5QMax3FeNkVsgof5d8BJ8vPjj97Un2dZYVlm0q9oZA5G8TUDYEegX2aDoIY49gBm+HpIoyUUqXrwjUg4plftdFZ3w64WBwwdxXTCK6wkB7ilo3C8cvui9CMHpbpokwjoyOPqXONw1FDpelMd+M+/hWTMg1qHJr4B7JdIEYSXEPVpdM6Rsc2HkEsTBHMGOVmd3EDJLR4zNymysDM0E74pjvGfjiv8nzkzdFO3qQfCp/QN4kiJRjnuebMHUHv5tighPkis+H9ZVtzJcJA8wCrDHiRMVxh5jPuP5NvfoxqiZYFoYZ+LbRSAiDOs0BAh45xC5hEdfoWe+M7iyTNIPonG5YxH7DrosT2VZ9YyaZVRifXtiyTSCIgtGAAe2V4pOY1kRg6Huh6Ez1uJ3qqy7SJWFW9MBfI1yTdcF9EaqV4gM+vWVx/6Dyy0OMwKxLqEZWW8Wrbm3K0dw8M0JuDs3iCqCERIBqp/pHq0jlzw0Bh+TmGDfg1Q5qaAsHqeTF1UQN61NdrRrY9AfEXusYHQkF89B8rzdBXjXAS25pwad5UcLBfWsw5L6ASuGGCBTmjkuAdjrMsU+QYIG0Gd8tlY2peFUdKaHUh8mm8yPdp8t6U+NXdbtUTztqsT5xb2ehBcJJGNbHh9a4/DCzy2YZsVyliilXy31eZWNx2fqPzI6nZVQTRry+pZzS5LCwV1T1lxa7EGvse4ifEblLiIwc/CPSzLaATNDK7D6ApzZIDVlPcXnx2IwjjhF0FnDnE1WOZmByy9uCXg/ulaYlD5FeagzG+VkCjHfbjPv4erCUveTTj25SN0s4MxvaJpYiLrw10Vzq/CHQ+Bw0txEgqZM3XdTkLFhcEtTTfVsY5kYYcH2LdBsg2BdYAEdsT4qpNI3exAsJ/fCaCHGVJzluU9VyKY5VO79lCkDj8TXif4lku5qGNTaGZDnYDUsQ/F7uLDB3a0QMO403z1biZhtrY2AHYfPJe56MjIZ/4z1Tz3WgaFbK7aASUP60v6lZKPPX+1R0A7Q2trR5zs1r1OgeKADUT9mKO62Nk52mfoSOMRqeOoAsFREcTg9hx6DPFdRcrrZG5ART7yFJSD1xuU19A1cRetCoCLJDKYEAEACGvAf9QebOZNIIkswWgq9qNE+4ztWqSw9gf+JFlkpUHwdsRr2LWMhmSmBdgLzHR1ILplXKLtIAsZ34GX3xGOEhKOGXRrcj+PnrQFQBzRCNcz8uJWJvC41TpsgKxYn/oXw+rasSGd4PaI64FVi09kQ/YxFGNWGGMrZXhkJdS6isMoscLIAg/LZChOMDAnwnD3t8b4Gx4zi15a4XCIGYMqozAJLTjn8HU4voQ8kGPiaASNxh0LXpqt5+9fW7fhnTXCwzH0RyNs0di6pTG2+GhZx3aXjRUakPJ7xju4pOMxnYJNi6xvCXS3ptdkeA0dMOuOptYUJqRnllDrWDxSWCevQciKViQdhYOgaQlgE9MakE/RvLA3i9ucZm5hdwo+TR/dUDMgCb4NlhziBVpfar58JytQkdSkGkGRakxrKP+MSxUm5O/4DlqxXcTlDBchI0ORwKn9jSx+VITjAZsSEMIeufNhyLBsyPZu/LptxcFZBlZpiMXhV2aqZpm5BxcxDr8e8G75aGt7Hfx7epWS+boei6hjn0RfIzqNLHUJsfKD2wIYWjY60rLiZY19WZ/TrH2T3k6+cFerBiPIRYwr4JES1ps8EVULykmB7SKiEm4xY+EVkMhUJTGaxkP8KGkT75PV33glLUiRbdnAnS69shKD+dXo+mgO+ECzuPpwLl8YlZCSexTVfKHgmUs1kfZXmpfPmWhmYlWRw5Pwg+tUh0eccM4Ysnlr9rmbKYzTTLlKqIhxWUc8bsjAEjrwX+6VNx8+ku5VSVZhdl3EXbke1JOdEtdS2DpEbKhGpvJ1NEkTxVF+oFNb8pIDOua0hop7NkdhLtekjv0mjL+CEo5gPY8g6W/p7eQjfR8m1+IGCDTpsLxU3Ft7OB7zxkArxDgJJAZDX69Htgtx5x7kHxuO5+utxiyh+MQz08VC9gkAgpPJ/j//X44gkG7NW+MRKHcl196ZXSOvsFF6ngmOomY8OxWpzqju8J2iRg0lsbE9OeCRjAoITBqPzbpyU/n5zne/ZS+HURFA39fR62jVbkj02E4EE4nm18nt+BWNPTjfgq/4LhA7NUM2xzzPdtFhf+kQbOsv/D/LblYaqrtGkFG5U2R6otZ0Qcke9MWBvqLDlHlrXa4nuF4VBjdwPgOkjgkkjjBTYHuouILvo7uSoNSRjCrd7aQMx1CwSoBTpFuSBLg/V6W9IPPgWaVEkENTQqVEHFWCzEDDUxJkmhNkUIxZmSgrKqtnqWi0miOfiowuHYCLfoJKohZ1rABZLghEVqLP8pBa7dL/PfS5RIzRUYVo19NnvaRo3U9XJ9Tkhwm1zRG3dP2sJYTLSDD595Hgo59IgqtECy4cCvRAtE7k75Xolo0Okf1dZKvQbYFsyYvfyLPVL1tgrZ2XX5W0/ShgJiFj3HgpQ/Z7+fvfTdAL/fIpwf4d4wR7doSn7TxPQCX/FlDMv+uh9HNovnZVhWchjC54Kf6CKpB4ppEZcL26ptNTSffKzzFA3NMyV8df2gqqowXRPu2vcrcQ3dsZojW7pqYFZT1X10oQqNyVXpPLtfJXZVuCyXphjmYJC1OHJpIa0HpqIHXNCVwfrkPmyIPqf37Kp2IcjZtSWJORyztHzkwOXE1J/Cu5Ddkok3Igg+V4n8b4AW05OVjmrcPB8yacOHM4TiPRmCf00vxnSj1vTK8c+8XLw5O/euV+pyHjin9X5BuOQJSmGrGGSiGM6yC5SGrUyPp/x3dimXhsKKA9nGYdBKo+qL7PCQNN42uckKHiJnJFr3lNKFhDH0411GrVJDY0Dg84AY1e0Fs5COBmIGboSj0pVR6aXzyFpSQeiacPBkExIFa2soLUSyzYOD15h9YND8xasuwAKEgBlqZ+tV5ZVgPw6v1YvD9wGcZXfCeoIfdhxDV8RX6q6Q5ZLILFQqJLdmBqgb2haZSrW2lMYkgPiJY1CmuAynJtKKrHAepYX/Hdu0uIqlSCE6mFUxfgdPL2+Pmrl7n2++9kPP6Ah5h8EzG5ckJdA6h82GCZBaMla74x/o7Kb7XWCKcy1JXNWcwneuzUJ41jp81jLz34L19URBinLG/oiFwSPPIIIkx98BytruOl5n4L6pj81UmdsgO2MxT0GwOyN9fLSaXgPXJKyTyr0WLIlp0ua7mtQxoXOQBza8vLEZjL9e0gePPuxetXr1++CILT50cnL4PA9ihERctQWjM3OpHHhmiktn6G7DO1ChYvd64mxR6Qr+vmhXRWdEIzdVVn6e0t17dfLODt0OBkXK0UqM+Sk2iMhUkeELM/cQd+dghPcZjbZ15+7DCthV0g8hyHPuCqhsoKJ03KcPxbyF6OSIJHh3R0xxWb1TI7XfQnhvPH5QeQRG5YUybCXAXBi5evnp8cfQyCt8/fvDx+/3wAsEUMJ2JAH/CfKWYJtMyH+WocXglDtOyB82zJHD0rotYXVf1LHi0STrtJTL8RbhUtzrV0MgoTGWHp+zoyWljeV3ZQECJoEYJSgqRiPweVE6JOWnvGOmV4nxcWZByyBC5DYu3EBA0KKCtDCX5N5gUQVAKrP5bEOLwVlWiZHh3LU9FsroIoi8VDna35PpzYWYHEn0S38igyqi9FF64/EFxJ+cIrGg9KYDHP1wqKshoiaoglztj5YmFAlT99CTE0qAoFbEm3rLnXWvmMWHyjRV3eoYiVejNmJ8iQVuZfPvpbHF/hFxhPwLLH7NjQhWHnIEbPDgLCBqWFOw5v8XNmomUNdIvLXAtiazmmw5NmdhAo6corGmteyZzt3Dzm6WJR7aaGjjoOKQAF1F1q3inc7KBheNWFlcwZqxuBnmhpJrCmZnnBuhaJTRMTDRw0kBSSQ0ITy7RA0QJUvyZTVKXss6Kkr45+DLKcRDXhPdeWaZw141EUa09cP48YagVO3coNXKnltWzfOfMmcgXM2UEN25q2WmkTpAWYV+m1jnTeNJJO2mrNFou1tWmrNV3z/Ru4vy0WNYMLWq16YNwAij6aSy7hgckpIxOHKtgRcBcHdUgxrT01ZquhymAJqnTy7YUYNwuokaXKG5jvAwEweKFCLs92OeoJdSAuZLboAfVvOPtv9uX2zS/vpnq1Spcw/zy4Oy3eNmph32pBqan4M4PoiysfeN0ahMyBWmUM6tetiQdoQtlgsYChDrKK9UsT4Tduj8iAaMWzRy22lWlRGcEE2pUnNWsc/sq0Ky3zfEt0Am7QvDpH43ZnbCp1hSm23XzXW1ubtVozQJHKDTGPrd5qDVottubnU6s9TxeLIDOFnkJzKNAPR/kvh78mVawdcByrD4KG0tONUMdRfm8C7uJG9C7s2rxpVnjMmSGh/eQNhOj3Bg3HBEfJ65F3BNTl0J+JXQse1Wx48T47V0o4h1IJR0uHPbkkHBaG1q0Sc3O2WqjtaOUw2+MpoteBoTZnpgemPl1TL8/zRvOOuJYZl/aK9gpPMk3pgXA+u96ZpOza0WaCAZB/n+cVtIHILXRMkqYaCf2KQY5qS99W+hw5arVUDTlPMvLtJziCPk4+vM59SBy54onr1E87AlE+4BGJ8TA5icdAQnWw4VPXBA/pRHgqVXYEGr0eeTdISnuBFJORfJ3i+jhSf8ZGUhnHsyu9WQLVUqEjbxEhrAQcwKOO9Y4XL758WcMwgjIX2JrgGHRd8ahjZ+5BDi7RepDGpH7+p3L+J4B+5LIUdX3g9k/8gZC8mnfxAcRbPvEHhmocOpGGms+64rxhftDhW+AYT0Ie+3exsC1bj43n1Q/qpHNDScRNOTNo6t5PEzJmwUVCQyfCU+tFmGB+wOnG+D2xtrF710WahgSdGIfj2xw9+Gd9RVkWnPPUYLfv+4eLRR16q5x8h0CK22oFnZTh91+HXFn+ebnFv8ZhZIhljWDUPHQyhjKnOAaiaLgcuNjZSujhztZJPH7JpzByjhPHdTY34PIm4pxzJUC8s2W7bsZllLptQd+h5cF1OIb1ww5b1vZXaNtm1+HG9o7tdgT37TC3MyJXmCWFnmiOh1A5GKoufPsJcVF9XnCLk2s68o95B0iE1VbT9mnGVQCkP7Vw/I8Ux3diku+5f1PvU+YHPJw9R0GIu//J1av/6Yyd66U/Ywi+z7l8q7r8rsBB2+f2/iK8riLZhUU/icdStRLjA4z9af8NAImF8Nx8j0/isfPZRVN4E8tXxPWK+bw+wSjE/hnGaF123rLdc/GzDSGtP+sg4Hbfdvt23/agyAHBfk4rAdn6tDOJsUIx/FrmeRwDte4H0H1B1jlCanzlmNg3DwCdBQVr6nuNhV/R+FYsDRJluF2UE2Kkzl6+db17FOeU0DtF6kXGIxjO48GFZ1LczJUvS5Iq/gzlwaLmaNHwYloy7oV7CkcFAkz9tODBiPteuQEmV2KLfgl3QUiLjowsxRJA1qCjvnixw0qxYzyMcWIWFSm8+LpRXLENH7lm72IhtWrRqVFGMCeiMfF7sTg7P6icpKetlnPqn8rA6IM6iu4WrZHCyYQLugcd+QuVAS36g0XWHBEyJ+QdIgUz7wZN9FO+jcyZeetZ9vNNczQSNNibm8whLXpCLKJMimjINk2jWROFuBdEME4soM4B7QzHOMwZCgCB1BQQVhqKv+BfcI1+iLfNV6bErgYdxn8Iv3rkm+SeA/0MKMrByb4EAIo7nha4Y/CJdUAjbsVQUrVSzgT5BXt4jcEcjgu0wFiwMwqTkCkdWP6S1WpxypdPQ2KrpoSpq9iNapkhjYZh4pyl52U92bnEQSYvsWuk1arWz/t41u61WvVNVKuZ9/Y1rp4q4MFvi/XwmMuZe8pqDykNXwBU6nObPZJVtH2zA7FcHNRM7gTRpgZmOB7364dueE8sXMj5RpVHHdhy4NGxJhcN5RQ9cUGAwByYslff6dl5pg0CV7cFLNzJahSVSWnnpMu3TaPBH+DKitp5y23zxCCDkolzveGGob03yLX3bnLtvSPTaA8dlnX51ut0+U6rCtgnoicC1z0451Snc8LePT/e9O4zf8o5kU/+vQOSIb60dIKj16MBjSJxOsuXQc0U5awHwtg3VJoI5s186tdeAutujCbTjEJdve6uWnexLVx/+UTG2F9bc0jlgHYfZsXREPuscEWHOyoY2RHcao1xf4if2Ba4jbHe/33w0vaGGI10lcq+YcV9gi5hZEXDS14icNEdfoxSZeC66AL7a3fYLGfeQHObKWX24UpD9UCPt6CY9INKmgGyLecdQHUDWTaaYGS7dZrd68u1NAOupWmQV8lP1LxxX29rrc0cA0CbvFpbqECKcdaZjJ4+oMCJLvEj9EY/tVrN6mLgGil6/cKSW8s6+XD0oAb8J7iOciz8vFhIbHSXdlLA7pX6YE23HN03xrpz+SN8YBQfQVTDe7fRg3qqcgBcvKM6rm274AYONmmG7FdjOn1wihPsumgIr7IY9yHFq9GlrMfzwcpaqbSir5sa+rqX+GGFXQN9VdUGFd4bNE9Akpp4vS4aYfY1oROv161TBySc6gsFVrpEt9cGBkIGHViizmtwNSvo9D4WeqZS78RQ6n0Yeku0en8IWM0qvAWANWv2GgD7URipTrg41OYmy3AomlbS4PxCurl/NM6Z160fxzutDAWwLI68HnQfedYy8KGH1Z3E3Tcr00Gp8equ0EIsnqXaF3R0Bw3JZyqLf7tuThVDRQw/l6kjBurorF3ixQKODwPE6gpcRz1XxoMvWsgdrM+HOPvCA2bKpP/G3XLC17YymnqLAQkYRAQ/aujsFCFTxA2V+vC2Kq1JcS1+HNaMiyoq8BbJ/2MwL4zqAdAKYctyKmaWfBjka4DcI/6/pd4SKh4R6s2KrzcUqAQbXcs6hHbus0lM0bgQy8mE47ENJxKSNMiCBPexjeXVIxpxO9ARftAco8nxQBUQXBhyQWv95s3QXMxEPPF7FJGRtCPnS91Wldvrc2OdYRGzL2hEGODSyLvESIlc6oQdw3E6wqAdLHFCj6iy8IZkJ3NX2TQrDrXZ2ZkJKZLg2xU9GHKakz3MPEoGja7AZ5r3AXDKL/4rO4lAd3hlq53DGqud73QEc4FbLaeO8hw95AvF8LtS4wrmh73AfHf/Or3WHYww5RB3zSW+YVz0+AGt6hxGmdQOxjiMl7tp0yJlNsHDXKLKL8EUiZevkjdjJuRaH+S7t0MRcZFO5IwITzNkVo/yqQbThZnyBi064aHeuHulmI6lj7V6t2g2n7CttbjH5BsO9Pv8b5y/Yr4zF5wWW+rOu0mioK/1av6Sb6t33lmOZWGGseA7Fb2Qj+u+Mx/Jnz86LsELqta+Z1zItm5Z7lX8iHzDerq16MSuaToe6e8T9Qg7N7UwhYhWrQf3zaU+CkJcQD6eCz8KOQIfeZ74WciV/uUlABRgeen6rLIalRpNVbBaeFWpb6zGGS/fRVO1i0r6nfDW8yZNwgSPZEveAEnG/jgikwlO2MtInJI3GX+cOfIH/aBzW6j0isbOFM1cD3QDCinc1ZmQsSVhkjIulT0SKSmIMNCpKqBRuJPQvx07IJaVORFNXtBhCljDpUf3KgNLQe8nlcDPNRd9Vt8G/mGsXIdwSfypC3LY0zNbOpQVcffOF4vTM3sgk/hF6xyFGOTDal0O+fUGjbGPJZsDwnoe2MEvaomcnrFztw//irdq14PfzULE6okuQMIjsPEjkGVCDoRskORQ+OC200Pswzv+GEtdExDJVqTcI572obi4IuuSZw1hQVbw+sq3+FGrdVM9TkcYzSUGeEd1jh8viwXQequ1rJcVTnt1DYXBZ0tt5BVROvlw9B30aL2e+8ibFw+DlsKT2i745aU8flGetXkmPy/apcTaiSWAHY1wSuKmbpZ4h05GxUpDOg5EcT2tNqTJvQyHHVdO+4Gmimyj/QInIRmzBtFmwmULj53ySlOtn98hOumvhIOychqNckKVLYu3YZ0YRS1bssfuY0daBN99dbATrAX3X9bntuGNUuhe9L+szwsJmWd98Ww708naTVb2RXrkRZ+q/YQYzSUp9T4h+YvrIxCM0njsrSPF8ZwWT7KSO13ZxbCGWa9lpZDmpMZYVU5brc/V6uqQRDmT81nWKHjtfhcBxT0VD0U0PgxHV/j7VGnMefKX/W+qUdDmSfmBWjDoAkApnyL5ow/oQrmoUFerX6Z9+zpJJsx7+lQXUGGNOoTmiTbEKyo3W2S9c7ah1lwnwlMHdLhch3VSPqQw6SgxqqnEtZpJQglAtAog0H+o+K39DhiR1WFEMkOvvTRCVuKdJnjIR811B029taITHD0gda7alPMhuTIRbbVM1oS68hgXLzBGa2k8brWUU8lT/ZJ58uGosc8Vyqfx2F3J+PdyTMOkHcsDsV5MJBU37OBiHEZfbRTjsWdHlE4wGJ5GNMaXOI5xbKNrCJ/4ZX1OsqcjfJFe9dN47K/PazROS2OtlYfo7fr6NrwCmhcPReOPaBaF48Szxfa3NBStCyAAtvZKochEsccVCERu61lQDBvTcIRHebCgtV6WNZrlW8wHRV/e5QHcOvPgbKVtopoFexQEJXnrtUVlv7Iki4em3QqLhyvZ4ZNLhxcuWr2weJjr7uYjpyuPnK48cspHLgaclW/9xlrzOjX4Tm6vbDTnKCAUSLSqDq8mRtVYD/CtOG+OTUZSOE5KHu3z6EGr+EhdQqBNnxVXV3iUN5yHoAFCSJvEz3lYEIh6Q/Nxqcg3JLJgAGt1MiyDX6f8RsRHJgavR/IxvDL9OYuR/Nr7kbEohQU3q/ZVshFdHlChrJJYiS00lSnPh4WoIeJYGBSOBTBQ46RDj+YNv3kzfnVOndooUXAHOyxkfgyv+FU51zWKVXiW5jDPQqyeF27bTyhKwqt3Fzc8SNiVR39wqmXAAVz5SS0YgpUE+vnwkvDKiOeynt+mdUwypbjL/fZqL61ClmGDexNtguGc8WLMRgSl5wpIXEnyxlBYbFBqONIuaAnI49PsC58aaI15AaITJY0kSo6SCnBmcP02ZNymfRVhzy8YHacJ5wlyMRTr3PK4bk//5fS9s7B9f/7Ec/t/PP3j6VPi5tV5sMnDkInaiOb1++WW+7pSOBq9j2lCh7S+R9kLMP1TEo3oVPNvIijhkI6B8c++eCxzmOsBxQZuAfrv8AOb5iNk4SU+hFHKEaJ5UUfWIxDiHBz9zJP4Tg2mOMGHq5JLZ02ZBHPV19LkXTVJFR3TL0COoNzGvVgVkEFPL5XTy1PK8IFsUSirHzEo3wOY5UQrRP5jePWoYIh823L8Bk3Uvx1z9xqAdbZtSLVVLPLCJs9DAJb2e1rZ70Fpv0/N/V6KjjTQ+/2mcPzwcA10+HI2CSMeNfUIjTCeHJHoKwTsPcz8mQ7yte4PuPbmOJxwGcmpPygLqk4g6YVsgIv7Bo4tf3+C38/jmE5PJiIwGPqsk8A6VCRKuxMQ8Wl3l3YSXinxAio8V52LsD4obCwN6xZH4fgFHVYqc1ngavVARnXugjUMYa/SaOikbqulfpY0zN1+YX+NMbqp4Fxa0UrPXG+M+eyH2D8zzg0gtjai5yAaDDqEHUP0HWeIkQ1BGEEX8mixsMeEcUuZo0d4wJ7gvq0eSICiS49AFmFtOsGR7dXlNgYnKwQamnJjBBjl2gS7RoQEUuyz2IcV0TaskA2vwcNcSIvZMJxghVhA27kpnbQpatuufBbnDVL5oWcxwfVaBliKxw+RBKo3weKwEEUL/cEbMp5xMQcidYwYu4U3+AYJV66DSUCxwFta3UV4JUUDEl3SIFCIOgJEzdDSlqu592jOqad5CcbY1S9kXEG3EoYJle6KGQKtFIy1+78lT3Xi0RMDxRnhEawOKviSgvS2xn4bJSQZC1RVtCfnLG3P5qRrZCShZjTM0KQGsJ8KQA2BHNVGPvpcW65O2rnO9c8nOMIjjnyGf3g9zkCfLo/06aRbEJDWj07ySwjo+Yfmg4S7FOC1XMdF0oFV8TBS9xRenuWtUt0kQQm9uhpjsVNSRKOP8Z2KqQKsVvQBMyw/p7AKYTTEY/E9Q2A5j4dpgr0BRJm8KZ5PR+bZdYjM1+T10rvYacF656RktHDPj02V+6l0iH4uMPwYG18dDVc0H+FJjIdhgkcewZo4hJI4jLFiJYcYGMwJ5mf3CCPdxGuumcI15j/Gd6+Td2ni3eGml78LjBJZSr3YBRhhAa7X0fuYXsWYMW+KMx9j9cY2N44y7xgjRQfgwPJmWBinYeZ9xJk/wfIM/4D9GS4eTzMuQ0GnUjBYPqg+1x1UtrBF+JO/pKnjk07gRL3H/p9YMPt5zFwX/Y5N+99E+D9DVDCJnFV6neAYJt8hTP00eMT8tiAtS/W5XXy5o26femdUXyOsiqU3cfvEM5rLnD8xOjOj/Z676Dfsn+YYIQINw0E+xC76VDyebTTC6BKfo39i/wonL2Ez8/jxzp9YsU7/wP5RER44gRSjUxclPEkuu+2imH8PhU4CiviXwX4xnlDkvyhPkysPATP4dyVeMYh/Cxkv+dvruFRaObpDQ54h+Tj+8pzP0kuTzD90uO06RKzicSNAQpBbza/dC4SQQmZafFV23VZLlbAlqbPdAwoij+oTMnjSglYniX/G1+Ncgvg68U87uYmItDSRZR7BExn8iVYeyemB7YW4/6Wcz9XAFNf0xavP/wI8TQ0j8wnnbEzdNXycoJzm8zPAwwWiVCTLJbK6nFbmFL9O+SxKjHMsxCuKCMSUpWrxn7jV+lOoTi4WcCj5vv9nk31CTMdjEl0FIK+DtyGQVnv25sZkZqMpGSXX6qOmP6hDoqt2GJFbqbwFfFqtYctW/ZA5oxFENL4NuTtM6/cwjuAWZL3I199Fx7jx4buuVYNKL42xukoDdRVZolnLY6Gz+gH3H9G8OjbawD8uHeGqcHtFopGItjVST8LoewcEwYJxxwBBq7XKc0dtW0GwOiiLndY6uR8m1SeTh4cwBiKKKiz3B2BOP+T8s7FvqouJEzTPTw7vd2zsY+mConCw5Bzjn1grvoJ7o0nyWC6uhk+pcDl13N1yhg7YKiFr9STBLrKDy1k6bftWhRRJ0Lw0kjoWrng8eop/Yfq4hEoq95TrlTFHex0cY6SLnvNIuMfLWSjEKkU+dSppohT8OoUb04X0BqWKVjLQVTmZd1xbTpvIqwrjNC9dtJ/PDNPCO9xqfeT/W1kZXm0DxZPUbDsK204zqzULVEQeZfTPa3i/8es0H+FagBeLtTu8WFwn8hn2V7/bcNZUR5rzDm0RGMwSfwyaaL8f45Bha0jjGA+TUmzAvAFZ04KLaRLfWeFVSKJOna5/qi7m18kDXonHxIjcJjTJLGmr8/DkAsGY3WEetbt9FdN0YnuK1zRmKFa5urCJyYQAGVm+oUtbtLSBa7agcTnUuv3TAu7R+oHFCZoXx1JpPVMKOtOVMFZxEmZA1tWr2Rod6/bHP7gOnbwFePfYvFxLCvohv3k/dEMuQ7yRRJZWaxLTUTrEnJTJn9JDPuh+KpZZFxPU4VS5G3wvkwtlKweQedeprnfzRRgOD7XsadJq/bOJ1IQJ+O7Tt5B/4hK4cvWgiji/Nt57UfKyTD1Iyu1LJyFoCRVdn5iaApXSNdd70B6olfjAhUtexry1bkniUzx+17oKtu8MWcRarwnia73s4Dac8Df5j1RqBMB8xSTpxCOVd4fUxMQAHJ0UnxCmhSeEWd2ABk3juUEsnUxoDKcgD64rn2DBw2Dg8KfWjgEOGVcvl0BoIaAdBDQmVyQKx0bHPF5WfXlaKkUnr0cOKb3hAjPFj394LucjU+05NhnxN5GiaIDCsz5aP0cn/pF+y9R1n/ndViv3x0c7hcXs005hlxdzYfuVx+KVWoAHmPrZKvcEYqqlflSm1kCYm4KtdUTYi3yBFUbPkImmh49c9SIWnyA1BO8eFUL4C49b+VDNTOfe1dfiVL+TnObPJFMX3XCXIsD7fuFcXGd9LoCY8V8CjtmXfI+VYaMy6sCvqXm1ktasL9dZojA0V5MpR0VVKnQfMKPjb3ynJDEGD/Os1cqxSVrlys4rhR8RoiOHhgYwEV58modS8qGrmqj4HKqlgy4ixkzSh2ZiSEBytcji46hWojDwkeToUhuCrnly6WKRO2BcLJaODjHxBlEiDIico7WUe1Eyrli1qlLlw2PN0G0q5Qm3TMaV7uc0mN8Jc+/pFjVg1ilwHPK+I70flZ0gizANB6XKcqGE7+RSBWm3Ts2LIhMjk3xj7TRrUAvUzQ6qS2p6wS9pa5Vt2XJWJ63TtE37jG/+MhqkUmzvevX5WgcGEIPbvtVhU3mcjRZ3K4yyAV/NgTYUqY7VeESiE73Xyno3mqgHRaI+LezK4kEy0Hv0xiDeR6UD4zAn2OuaCp/WnUEnTWfQff2x9imH5OcS042LtyBS4kBDbPJJY1zho4a4pMAxwQVx7QiX5LWXuMDm3+ESny/Y5wDXELMp9kNcZGSOcTOBK4adQDPsi2+hzuI6sMrHuLjM6lmR1TMcIpfzGzY8megXtmMt+s+FrItFfStGGWhorfd45KrhCuUQVmYhvxNBfypWVmmbQcMrmRW53TKC3/hWMsWmEGCG6zddDg7zYYJ3aCSUBI88u5BUlETK/DylJJmU+UaSIVOQmfKzvKGN/VvZ3w9tt8r+rdnjD23p5URDbGg3y8BM8farHwRTfDEJh18DaRwQBE5vc2Njw0V3tbmdyLn9qixxy29736t6wL+/T8lAM7UF9CgJO0gBamkJaAEqDWSaj6JsJWvsUoMo3Ci6cQQP+IeFDS0lSKcFunFSSzfu6/byp8yf6jd61f/nzD9EGPtTJVmW1y/1QEywT3L/ZFi3d5jIl92QFyiv3xulTjuuzX4vtG+HPLPsTXHCUwd0cveRDsZkckHDeKT7G/HcorreZZ6W6+uBbz+MW621NYw7Q3F/Ae99ICvucec0XIDTamGsAnB0CHt5O4H7Jbjr4xLbC7z6C+0XLVOWSFz6bq/Pj+odbzTqILG6lxvVnHQdkCsW1Xk/CTGaK5zKHvXepboBrFv1tXCMS+8aZOmWcNF6/ztGVHwuu/vquM7nxWKgDR4/tVrO/WJxspoJUrn1XJmcgMElb6jJknKC0TzBs+QjBXwFZW/h9LnHLRvh6adeyk8wmpc2vqkdpg1zgs4o99P4inI/FCTmbTAHY/cgrfhWZm5Wt85DLF1AzjL3YQyUrj++AMZa6/PTTown43CIHVD2vkL2/+f/9n9/Ctabdegp0bItFNFQFaWT8OI1SJw8u90rIC+rt/5eSQnuEjdowVWkrAUS9eMHTekYuVW3wJWCaD6MjaI9G6yoEnoC+24QMuw0zwu2VvOsqoaIaqey8k4tReg3dc9y+YQ4kwLOcZc52loOcZD5VJ5C8oi58QPp8/yp0/f/eOo+vXLzuAJ+74D9eiOfzQ7YE3/DveHFh9hhqFuDytOLWL5IsUwHFDjymakA/ogVIf1aihgEOUDseqKpHHzx316Q1XrGUbq+gzJMa1R9wcQie7o+n2VfpNLvTZbH9sw1OPPnB98x3yJYxTBaiKQsAr4aTKUr7hb5EbFf9fQfExu7VGmZF4HcJUKu4GWv5rqJezt44NFM+lBocgLwoFeEpqxGhwVCHQWPR7b76HqnKvz3A94MWAdHSXxnesU6Y4ie173iEm2sxMBYiWZf0AyuiwzNTsOxjG4PxBTVYFkQ06nvzEsVDJsr2hdbvq92vkeFX+tGBHsAqGJ+7rLcvx2/e9vJ47YRGPxBKRrLb8nt+AMOR3evR/C45NuBbRg46SPv7F9/TNvnT68QVbEw9CNioF5F2WPscMovqnzA+vGzKNE4Mz1tP71hwjxkyauZ0qNRz6G/C24N5KOmMWl+wewMC+WFZPOs5LcIVbwVnSPmCgksn8Qgd88g+vedueQEGMQpmSTCuYLHdOBiI8qkcZ0lgvyl6iZUDEfNWi0iRLGVbovSWLOBsigwf+lmDz5vE/PeW3WFdAnPisUH6VnT6gxy1vdGjPFIjfGwdIVfL9zwTwsn8UmusCzx6phrrILfFOkEgdvtqvCCapfRjiSCzHmeuP3n8BxkujHfgKcohz2xbfese+66HUbjJI9OmOUBhe/humX44+BGVARUQXJ/WtyKiuTv7dqAyjcxSwKu1apJFO/rNRlezfbrmFtMl8wvsUFHON53DMtGCLnPMXMg8BR0PFWDDjM0wN/FIxzj0ZtwAq3oD0MLnFMzKC9GXUjOLakvSTTSmjS0w5IwThi4xXfsDdstemyQdqkSyrZ0pa/jfrk6Tn+umrxYlEYLISV9h1RaqhRzNbJIAi076pMCnaaLRSpsOPkDP/arZPTL+vwwg1uDdqrCvnApwJf1eYizQDzAfHkEa6Hb+T4t1UcxFx8M5RADYxo0tcquN8e42almsX1LLoaViN1TZ/30TRITuD5JBGVeiPk3j9TvNTRXtFoSilQFj2SmKx0uTZR0+vUIBHtlB6S1p8mqfjPzxSORYL5o/1HOuT4h85H3MZSZE9jyKSbJbeUwayTZmbsqwjQamCkHXfJmTb5xx9t0TJLiWpUddIFafohRTMc886qJr/7f1GlX/lnYiyv1YDX1AlrOfLxH/IdSxfouTnpwrq5P9z43LilYj/g+6+cxNKWKmg0GSk2k77O8tFZYg5xrEDpszOVXa071vRMIOSLYkBwG92hIR9g0lxtU9kctczbg7ruRUEUrsHPGS0c9v1dw0DUr7s2Qq+2qCLVwT1jvFNPe4NsLHDtH6BDlKGoDj19ghEoUoOIroeAt4Qonf4/oNIJ4yuHsN3J1zR3v4fgojK7SUARgrASBHIbR3xiN3oex4AJyPwNra/xSMZE5wjTf8Ikg/LAAmyQTaN/mDLuQExWvDsFyx6NL9PwKnn7i6nLA00a9vp7GhtKjaebWrjiXRGY8fnwl0whWX1eV1qNX0eFuSSuibipg/kMVY8+aGhWqEx+5zYOBZKZSguEC1cRTWoOahUEqVSLxJFkzxMWCosCw1Zet2yg9R8Zb9RwQlRMIFddZmlRXooAr5CEQXLl4b5kIG1hJGKjY5CTf5KlBAoKcbEyBPFT8Lhgb9KZEHI4KMDqsB/uyK8qcRJc4PhbRvE64AQCftEy5z/wZ+uQfaaouVVdLBnyfM//G0deGz/2iEWMqD1yC/bTk7RWFOk0QfHVxGWNw7KB2nm5MPEUNzBYm/FvTDBEmS74/DRz7lo7wWCKNeICquJy442laXCKNFC94avHuFPC0cmxwNDWTbfcAHlqPMZopk9mP+EHUPEQfJCSKuPkRV5ATrWP/A65FUbjYfMqdhalSIlib7R4cY5/1TxymFXT4uswg1Xx7NfpfPm6kmj53vakIfH+sF1T1Cu2nPMa+TupPdbxjkeCJEGv3GP2OwXJ2rQc2snPpfh+uLO+iMaguZ2KK5NL5HVfm15fzkuDqEAZ3pnWIbbG2jvM3wxxCSn2lQpEcboXzBo9ICBOVOX4ebEwEHXAP7rFfU9BZF0Nj5cVTYZd934cXTae5Ou+AOW4nwjO43PNvl9sVr3UFrLUCYQkdbFe0XEkWld0DqD3/HfvHmIO50+l8wkgC+/eYJFhDWwEqR80c5T6CVSwTXTHZtJRw/xP76vYMl3NhtslqLtvEX+XsN0/n74rDRgt8p4IHGssuPJKhXALJQ2y7zr3zO0YfAULoN9y/x9KlJFAcd7k8tI6Lt+wnTgCKS8rRiAj4R77fPS35QX+xj3FtW6odBOqSWPu6Jy0g09pYcS76zF/yucwI40eIvsdY8N1LZd/SR22rlZaEKXUvzPINpGnO4ixdCVS/RY7rVOq2b2Fjy7v8fFl2ux3yI1tljXFse+uZu8Qby7JBGy0HAbeuhasUJzONkowLrEQZDxD9glCiAuZygmabJC97jP8E7Z6i8KKOcTXlJ2LkkGc3qCI8FigS3Mq/Mb8Yi/V+KEyeLZgr2Mgy8kJHXU8fOnj6j9tzYtSa4H4vLqgGDExQJ10dHgQYzKJEvreuQyTLKrDflp2aNBJskZf6z047DCfPC2y7vD2KiL5pwWwXtr3ArZm8HRnXS5nOZ0gyoT/JcUMo+0v483/RP/FicYzrNRRylRb8KDZbxrsVFzEpkHWOsYskpLx/6gPU5FZycrSO+7XakKq+ZBiWgbbMBy4bP70liXjkyEdR53NqiHMX2mX144E2e/zUf+RJo6Q3Ie6HGJxDc/q+Oqm/w0rEwhGFIWiwLFYccHq/xM36W2rJW4WciHqA11d7/TJa9yr6iM0a5C3ZmQrRoJyVuOJG8K1e9XJjZ2d710VBk+rlt68umtZX3etu9PZcNGuqOtVamx+M+BXLFWmwP+dik5Gkx1JzJuXxVN6bOYZgQfl+L0aTB6fAa75PgVsHsZtFIpaE0RC8Xh+O6YXLA2cQ7hn4FRnzjYPjA7LMPXBxbATOp3ScwDkJv8PRc/YR2GUqIphbS2tT02u5C7xu1YyKN1AzeUfgk1klDznTWIlHX4dfJT/jCn5lkQd4OyfadX7qz7PiDgiqevqlOarY2nxp0cwPaq/KAz/HZPuJAwvyIkwwl4N8JLdY3u1v0BEsKvEJ18B3nv7LePH+4ykdJjhpsyTG4e1T0kl4GCZ3sUjzoCovCJtQRoTvxFbraZgk4fAaNpeq0FRYtKNeR0artFNbuDQe05djq/UU0ND6GIcRu8Rx3ZDM8qUhrdhUbXnwYOQw6fQITBfy50Ny6diwW2wSWcItqXg4Dny6WPCYN0/hWctGU5+Vd1ifcZ+m8NM5Y+dozmPYBZmLZr5oq3Py4UhSOfGmCf5Pp4ARZwEiHZZesCR2SGccsuS1ND61n9ruk56LZjK0je3xwOxNEG9GAEAmfYe9Ub6etHwXz5I4HCYASKC2r2J6K5sxWhEyLv2aDk2ePb0kYwwHxx9/8c/+9cv5k1/+mP7lF9s5+5d9/sS1D/pPCaov45z96+D8iVss4ds8+S/uQd8upOvkp+S8w+gtdhziP3OoT7j9BIj/gUxwGujmv+EFQq5vzzWc445wxUs9PeudG+Jr2OF0LL2Yg2DblGc7N+6B7AIu5QOfudmRHyadKPxGrsKExq2W+dW5ZcfhN/wOXGdHHFseF4wntKXjx1lBI3ZpF84UDVx4NKJTTuMtgKXtut53dz2SLXmDmmbFIXDkPzbcT96QpL3WCCfcsYp1kSbWHU1j6yKmU4Zja0QxsyKaWNLs3FIjAoceFyQC9fJvJLT++dsHy+HHXscWEhiLXDpcTygnlkonhAs6VpGMtFoO9YV0xD0AXLrxS/pUhZcQ5VvdshVWUXd+49vDMPolsXgpi1ewrA/h1BKnq/dH9EdkP2FZDSBnaK6FKFQvBncds9ZFl3LrwpV9kHVgmF/QMIy4CjbEHr0RS/R0djvOgdB3bvwAVMWZUNl+S0eYvYuOw1t8RCLeMIlGOEog2rNlwY2wfmQPDGd2Oy6PxvWOfIOm+r4/g4HAKfYUkv94OhmHJHqqh/od3V4n5X6ZK4JyPh2yb3V9Dtm3H+pxyL5VOnz6L3IbXmHwCK6bpnm8U5t9u7LdpRQhdyHjLYuLsOScYeAI8um/wnREaGEgj92v1fK8TRtpxTaAylf+ZFRTWMir5HiJCPQqhEm22Ed5tBb2PfBPZkkF/uqw708fSXZLkVcJkKuTKMZDehWBgYK+3HBBz4El9SWAGinlk5BZgFidutv/d8xmWku/HzvOlKtXWwlVI1ZSFSVrPer/cKBMEZ0cHRUjcbzXns6+P47LMByPL8Lh11PCCAdbD+UO1HRit+G5t2jJ+EoyQMIrP0CnZKpYU6Bij9+runUx/badneevjmfnuS4qD52BSF1M1rnUPoVpHd695lFlkzsvzQoNF68kqRMg0WDexYBGLL0tqLs29aWKnko91EJPxSdq6hDE3OxAmAR+DC+gXdOJHWhf9Kv3wcoSoepSgrtalVrfUKVOt275oSHB72pg6JcfP387N4RiWvG/AJ2qY3/zgTeoM94WL3AnDMcvRyTBIxAEOJ1Oh8JVgJsmpePRB5yEJKpztkDdg8Dwq1Cjy1seNmgCRaTkiIGMYINy5nVeKj5dLJzZYhEs894gYFfuiJSiAYtZkWI44EKiGglHZQZsV9kDQWE/sqIhMTWW1iOlbZZW/Rv+0PO+ueyH5sxLj/oljDgxPDbe1+ADj6Sgp8FtSEQoBTWRQ2FlxiOnVp7BCU8b6B2hzEsHjm0snUgeYz9otVJ4ts+1CODR/sv6vFYpdf2sd57Bn+55FkibcPbF1Qqp8K5/r7xD553xp32pliufTkknxqAKqRV5czMHUZ9EuekMOyPn/b4PdPGMnIvnaeoiUGvhGiGlprgkZxhynh2dnf/PWeAMV/JIl4QXy7QIRXnzFmcY238ML0reQA1DWmiYJPjWKgSur9C+VssW6jt2vVntUsew9kPKuu+NwbkuktiRU2y3/z1TNuo/POPSAfDvnW++81z98vHjSPDQmIpgRmm/Ti9S7HDP2OqVY8c7qT+K1l2ENc2s8c0gHf2UPDQsIdSwqNRZd/UzxjIMXWUX5ZUKVmqXKvJy/+caudV3/Z2axU1Z141Kv4XZjgMeIDZDNpSz3R9qapkG8YMqvpd4uZvTT2jOT9n81JXE3JD2L387i8MpR3XO08DfoxxdwAbkDU7CnAF21hFzkdJLyA+WrKM/ZWzdLw0a+6jMGRezS/zyAyzCcq6gwEAQptybjLE0G/T+DW7JJ8Zz3KRAqcWzZDMVXWU/qSrGlrQeOUKCkWbgmWe8slYPEtdEK8ZNm7uo3XOlOptRUjtxHeJWa6Sd1jUdj49nAawyS6BioUu/+T/f8qbOgQY/HPJlDTiZsNbnI5yzaCTGI1BJyz/gUNQR2eWlvNlqh4zq4y9hrbBy0omrFxNxpBSUVdSotHpjweNMrqqSb1SmniArF7baiw/X2DEgDHNrc+iUDHsKtkECDiXTIG3pM8Gr2vA8Eu9DjOb8tlV363s1Dq88JnU4mos0QQGlDMe/hWylY3/pnioy+caXN8IoLg0px4TC9XW9UPE1CDkZ99aeJOBg9qSzJLdaXwQGKdYSabKsSViLR0tVh/2k3rwCGkKFuaMSJMT78vNKgxpjax0jLtHGKSxhyRxLpJa1c4zBmfo5hRzYEHo3GSqhykGwRfzGNUv9gj4VD7tjepVTl67TeqFB4bATaJpCydeRQ0FW5Gany6QN1Y2t5vFaSMm5UpeeU7ktjUe1A6FSsYXTC+PNv5mSZTJKPQdaTnB/VIPFIN1LVVjQ86/+XAFAGpwgwiQoRtL7twdWKCWpqgmt1W3vn389WObWttwvy5Gs5A1FiLbgxRjeu15HBOwRZVeCZqSZzw5Iq0Wd1M2gGTz8ekFndeYzNT0cUId1RBSPzhCq4pFbcVimhusxMAhT7unpaq5nlgm5y5attnzBCTCAOxBa6qisp2ouel4uEJdfG81H+QhrNVBJNEkTcTLXd8ifUOyhhKSde+SnSMLIW6OtFqvhiE3gZy6yj3E0snjj1jfpT6MiuP9Ap99rnIWTFwYurOjTmD/SokpgaWOfp/ktIgA/x1Of5FfimU+W3ihSFLiLBWhuCFIHSg0zf2bokQbeDAk9kLnUTGQZaNJriAj7HXjNkTfyaQYBsVhfXklwlN7aShotnsmpP+vPanK56s8A3eTZch1Ufq7C0R/4N15Q5fx0RC+ul0CVAZUyhljz/UGrNYAWWi2NCIAA8s7jaJ2QKIUT66O6yWmTPltkmA91zLjwQUTjgdu45qXu+COIv9bTTndQvveLC1vdvhC7GF4IFgvWaoGZh/SOwC3TGYIHEQLuScBWTx7DAoX8/7aD27iEFc5tpXvKZ8dVTjPj2cYk47VvNlIuXtoHtW6xiXkvRlP1bcpjqVSg4/3xEeUHaooCNOWvPaWlzF1317lgfqOs8fQKVh9SwFlm7fsJW7pj4YWr6PH2wNibs9q9OdV7k2nSkCn7t4Hy81i6qojGq7aIfHsGnZL9njNTFlEDNK+z75EvrOTSmRre0qeFbd5q8WuMvaYzYJ0EESRQFYZ/PA2vrnC84bgu8fWuluVnbW2B1K8mecXi3MhIAI7HUBalzt3+0lxv1mrNdAHlaOM8V7TJYezOZ2KBOYlnGGyIFbhcw+CpiS+nqLCDqrvGPSiDoGQ4xZCEbWFWtfl1wNEoMWiAT30Br7wslUWpWRJHwdXIdfs1ibVV1DoUquhEb1pKyDQGksXiT1yKublYOMQv+InKjw/Srz02CMcLm/JnIa5ZI3oUJjCt1k2rtTYtWVK6DUdQaTw3bv/Gy0dzA1zJQQEN5lofF0SJdWcF638pywjbRRniF2mQXsFUHVjhMioxvNrbDI3wu0swioSvbm4cmaoCYXRXV0COrmh5Tju3OL4qjEC1R/r9tN+HR7KsZAPNJ55T2+VeQfJLs/TVVJGKzkqiz4FJ1W9yKcJRiaofFuCz7g8esGY+5dYlA3p7SyMj+STzUxGklC0WDvOpi9ZojUkj97mUA+oVjW+V2yWhO8Hv75yr+uwbi40w9jWl/dzgyuYTmi8DIgecAvxQAW7QUT+NIE03ri4grkGDjnERPRVPqDdu4aV1UHgGrpdmKzF1zfKZS7xsKbOiT+OSCbfwY1w04R5jDV6c4FgEox0WE00GxlbOi4u23HzWQtiFY1u5MM4Nu40z/RLXHupMH+ogBAPL8qV8ww2iZb5hvdVyLnHTCXWJXYE6FxgFGE0x4obm/iXuX0rh5iWNb8NEKk+hj4U8QfhEzgcjhx8bJMG3zEai0LkstY593uKLMAmBgn6G8Mz2K5VCIitMIDQzK3P9YBB8h/t3uHhnsG0wOT7pCywu7DCYnLLg8k9LTgSUL6t/gHG4IqCa/rNW6xIuFxcwJ7ml+GzMQ+IC950A+xfYvOSgaZ6SH1ceby3IWxOFXR5HLlD+qOWPZxCC6R/c/BmZI3J4I9O8kbz93Ap8KsuwSpFjnZjbb+tqEELWyUsY7FT9AxpYJSsfpyCN5seNVzh7kJlPItszaNMqVsr174UPi6B+x327/ICg0cgrZ3H/uuaof8f95a/l/+f/6y92o1vopQIxvhEysAH/gFutL2fr8w84O/+CZni1GLJw4LTldsyQ7azbaIaR7druY0dieLDN0HqrxaoBL/rm13dNl/DXWMdG5qHUNNoHVr/4xMtqLMH79e8RwnSc1ZqOyyCd4Cs+WCzW/tHkszvETVPk2xjJTuxfybPn30Iy5oqnQmno16fkmeVZ9pNAPjMryYPhPYgVXBnxYkLO4Ep7GATq9a4eqLHXHz1gRQ/MMcsbrxixHvAU13V5vKxLo015xunWIBA0Wsc8rnrj66T08wtPH94DRhH6wHAN9M157lWN8zVkllrnT0wL5kpfuYmyMj0vyGHkw8ppbglf5mxKDJhcoo/0FVzFZVtckVQYToNQ/rDxNnnz4G1StQNPZ69B8CqGZoStxU2LfM91IablEX+TDSBFZiEAtBbRrgXI2HelM2I5zyeC1nrqzBeftotynslFgKGXdYGWqwEJco6+5C6m+eZQZit1z9pQG9d7OikQF42hXLmTc4zSl3O9BXU9VEyG03gq8QyWsPzYyBnz0gFnUGPXfD1YIywfmfMb1gRytQ02MozPWY0Q4kdQ9txddtVT4zxptT5JPyCf/k1+QE7z8K///Pd0kb9GSCSsf4i4DqPRGBcUjzn4miWWZdFqIRBanTSBdr4V9ZrPCEoh1JROP8SXNMZynDI7OygOzXjBzMdWdmdWfvhYWR0dTf35LWGMRFf5mz3E4BJpMmjG6O/4jnGzhKCgxd2gRW6MLBOXo5mQKMM9TDVpNGE+rQoMQAM/rT5C81eiGz9tgp+oepRXNRuGytzP8I0SEUw75Zn7a11Uo/C+ZH4ojxAutRCmcOr3eE8z2dOaeLI5NEZ+fM0pmQKGw/14LwcNmHXKQsWpeUdmRr6MAx33a+1wsThU8dN7i4Vz2Lmk8ctweM39V881JMz1Vh4zwbPFzwFKGbcJ1w6FF+aQlTZeHgUx15tfvukKKqkSJVqtulQev1O693Xmy9pvHPCrkIybBlwK21gYJKu1dzjjQRcRE7b9NE0ch7fNOt/qCmYu2uo2Do0/BUl7mGZoO663pARMzxFPTXnAUWEMXq1lEE9Q1KorYWCOw91vt1r0YEn/jmme9N/ijV8LSoUAUvNeKwaj0TGATFWzJLIkllmlAD9BcJFEefwexaTABzK6BrdAvL6tzzRp/hwIK/sG+7iC1FfWqEYwqT6q8Vgm8KaOYy6RS31akuspCDDgaOqDuFdvtHIES3VSr7dq6xgekuSMvXpV56qCtmzhJ/h9Fi21eVSkFdSsZfEhHT9KO7tYrejE+bG1hTz4R8Kc5B78c3Uv6tYI1wNlu1MQDaCpTzULq11S9mn1xVDJNvMsmYJmPl32wPiAxz8RxGgFOUkBcsx9bI2gugNSfZEPsmY519JWp9yYe9avizYDOxY4Xy0GR5ACTPCM/xpAm7o9BbBMc8clt88Fy1txXK9AVfCIJDTODz0cxzm/SSsxM0kprGb6oNscawqucQZ0PA4nPAQGPPMA1REBWcHvgAveima+mSRUY0TUfTEXxy1E6kiuY6BlXA3EEMAvFuJubGSM8Tc8hk3EWbnBYjFQoRB/7RX2AXfEw7WJRHT6M9HU+zDC9jl/DDnyBzwiyOEdHwTT/nX5Jm1G5IqNPW/4AUJ6FdN0UlNtJbIrOjD90imJwSPOPNkG53TahYMO+IhUhU4vQOnGzdBN3/6NjLDt2QDIWimnUNGY4IjH/QsjcsujqoHR+woHkRiZnaGjgsWKjONVQAi5KAa+gIulME2u+e/qxvzIC3JwvU7wrSBAVAhgtMgLPgDZGMpRFqIKejab4GFDy8cTPGxqt9yOVB9zTdXU0sh8Z15TWdg1A6azCnITv4Cxj7Cs5B0Z6Moe6zFgS59UfADSL4TbahU3aT+hHwFjeXy/Uln3iW3ZT4rl4dFLpvAIc26z/0rpWSNMrFKVZspefXeQTi0tiU1a/i7TV3x+ENDki5AhUI/t1zviUbuNdi5INOKO0cDeH/FwseDPAZqwbETUkSD+ZKiAaQ14wj3hGNHvTIcQRZASv6ShUQTfQyCvQlwK8zv17okeqOx6BSRutUDd1SH+Aw3RSEGrRLXRfyf6PxK94YnNRuRRuPlTkPIxGFmEZxU7i/lFTM2j0RUgox6EQB/Etl0ZGhMc4gh6D2fvWfe8GA30CZM2Lj3XVSjGvTFx4ik1MPjLYxBROhF3YaVTb0hhHhHFTkunK63LlwAOoIKh1AqR7Zb4SxTXXbPBkmREjMdpKKzVg1fSxS53VGpLah2XUrWfIyb9qi8WxrgahlPVEy7KBoz60h5AtF00BihEiWKGIRg11PRJcTlSwxwskIs2rRMVpOD3etWLsmmRpsgGuCnn3h3rPU+LiAcqQpJ+g/MYKkQjpqihGx4qKXjgFUnOD0aRoVTvpepw6ES+EApXsaIeg2tV8dIh7xx6F8/46OApw3jUNfn2tTVmbs5OEpNbR4WUHGg704fvLZfpeMyGMcaRxxB8eBR1Oh1S8ZPqNoe5Ve+gRMi2U99mQtnWsCJ/4tC+3YYOIHCRe/Bwax8iB9wII2KihAQM6egklLpG4NrDr/78ll6QMQaWhktBEs8WUbASG40w+5rQiWe35S8bjWEjeHb7emTnRGz8AOR4Z/NrwgMjfcV4IukeXPVk90T1nupeA9nZFCA8q0AYjmD3gWDCIoiGuNSdneehjS0SWYdfRZxB6WNEu17qXIfs3TRScixuw+wcfgUkhPUhUSquidQ//HrGuM9L3l4+PKlKbQxYliO/9tz5QL59RjTC9hPqHqhWM5XDZX2QhVTKkI7b9hPyhLpZxlotlXxNRiMcSd3DG1+u9sxY7U6nM3gM9sxM7JEhjoXDYTp9YJGXs/WqA9OSq4Kn5vmhp2ArBsg1hiPc2TzizMz7su3s4OFBq9vpD4xbNmHuN/Bl/DzGoV9L/hI8S8IYhzz4FtcMqC8nrbWYcgYt5CSPAMZtOk7IRHg1K72I50FCTCsrYRpg2FrBnY6T577867GOarV/ZtvnnJ4Y/tREIZLpI1eZnIDdlUqDtxM1tMLrDAr8s3PBZ4n9qE9kcWRAPFw/7Qea5tdp+XTEaYdHIMJq1AUS08k4w2+e+qjkIUyH0oWrFAEH1QercTe84ppvzE4ktVq1PegBFZWx+bLhEV8zYDs03GhlRUn+JlAGar5CcjVFsOiO4y4W5bxlVERyEfnGqN0RxhjF3JoYB3PAiPSXMAqiHeAr7HZbKcKy8uoCCjeOvsB0qJFpc7QM6Z/cYlcRICGPDNKEjFkAEaa+lzqG9TQmxmPPBpYeA2MS0Rhf4jjGsY1WpD5jHvXKoD1v6ZswviJgVzy8JuNRDDzMikbFEW3f8soAaO0mND//uZD1EQRIC+HWeoYUrqcI81uaPJepOeiMaarqVcxQk0S5JxIJGTnluqt/RMXTg+QXShtNDY8hPWqKVHOl91ltB+JA6HXSEJe0OEjiyrfUmrkrbHv3DcffCF7BKFbwwIJS89+abn0Mr7iAWYw3MFLErRqS3cxMFxvHAGJBCC/ksFAkg1/vJvqomBfKlUBEjHoFQJf1ZcpvALT0BkCKbwBl0gbU++oKDMrl+zxz3Nx5YuFp4LsMtSveteQS5e611KLxKKpKYJwq0VegzXr0ACG0mn9mtBReMR7NEF4k1PPAzHxHbdq6QMryduwnaaPw3hSty6WZobWB6SGNkxLLHJWkAoO+3bY9+4mN0gdk7YOirD3Qd8BcGykPGg9XymkGC0gFb84trE04oYE/RTcGUM5mEBO2ESrvzFCAAjpTVNNr+sRu208Cbi4UgQUSx+vXMH71832ML8nMmyHurvzLf+hRtcGX7hf9VCFgWYmSWvgEtRhyj3/t1SnkXm9q4fFbauWTB0pKIjyCKwdsmTXLMKAvTPQ7nN/K0fs6hhN8FciDTFN7VgGIlQFEc0hIcBbVbQgoo7BztJaWyAAZFRTTeFUioJ2upExROZmla/m0Xl/CfH2Ce5fWteBYD9KTvs1HwS/ije9EDVSiGs7pywUdj4RcReghZogWJYqryV3zZmwhIFE4AB4xSDgGl8z8DtGEBktlfUQ0cioYU8Fi8GvHB3wp2EG/obSxnOLar5yvFFx0UGTW8QhcVFdbXnX7UUxTCrzSpackd2qQPstMkFzSw5Bh0D1eTVRBWeIxdCHrrOrYo/LgCg200xiWyD6zYAjWyYcjj3NQFNnWuV0Y40k8bg7mUxoihJJZppODuDaOiH3aOGKC5uKO49nBxTiMvtpip7EQVucen8RjzgOvgpBymjC3HPIkuqQBTG0lsENp8KkQg1iNrwHJ16D85o+gxTgKxy/okHlTpG53xzj+hmNvxpsZFEByo87cbziW0WeP/DrTFHSoksVp7qJ1n4WX+DAl4xEHiszF8S17dwldEnhNAbP88jgyF52q1riAj4eYPckfRYc4AjYE3Re7mLZa0pQZINvU9Cc/L1ecwmc/KGlfIYz9QOEEIvzjVEDiOAlvJ8IWM3BsOLWfv399qqE05skSRYUlpvxW20oYYPLW1XxGWKhgyCk/4t0X8GBl7YTbkNT73L3eKBZUjNnhcm+sN61WrS2DxBmQitW7XqMhkxDz7I1O1+Ze3shikTZEaSvjt1Kjp61Wrf56Cf35LslWe2V7IFDoZ61xdMTjEbdaKy5SECSU2Q2u/B6kLOvwbPcRtpAFDl7UJnLRaV/bYdaaOZQgAWaOYORdRwBAQHOypLlJtTm5I72T5hbv+7UzrkAHz5IRHTIbPQiLezdDnxaL+1Jguz+/+pqEHuQHxapPFtIcvnSzKh4aJWX/cgx2OFIvKTCrPuuk8dgB5TzWUWjLeXPWAWQGryQ+65hkmfsxIZ0iHB2gutQRu3yl2xd/BZPaU1XIHyFxcKR8eQJxcMzyjTUtnhSD8vxLq3yT5WvAAS6JdiAp2Uog5yjJHuiJ8AFXL665sRCyk2tsjeBZnnIROJoWDwlmnhBp5YQgmVgeXgjfhoQfJCswBk3bXR1gGZoutyKs5A3QvIL0U7e8MTIUINtqW7/jC0YSTgxmrdZKjX2B6SXUW5/Psi9uhqb9L9LVWEjGoBiwPg+yL94XtYz8MxePnX71Swst95skB4E82FZafkVCfgYG5Afq96z99y+1YkwAko+tFASCG6yLRvMgKZy6GQrqdXTyUzrIV+7+q19aI7lyf+N6Rysw/WbUNf0oPLn7SAdjMrmgYTw6TKIVXocLi81qlFKpz4Ajmty9HtLoUWvCRS9jEmFxLx3SyV07oe2hGqCNOHvj8dYB2/OcmmX4W9QpTVBE5jLl9vD9kUKxrC7+ulCo0UTyFaXJg8fR6tO95M3ZWd4+f9h54NyjkSxW9vQocU4/2oCDyEa5prAXFi3xd50HjtOqoLJoK1k6UAmXPYJQ0LdlWDmhNiw/jnk8eH7M2pchGeNRfTbot0tLYjlWHuUxl5O1xWNYW1yYzxWyTfP3Y9m8i4I8TQ3JXSrpWOuB86UGK+Wa5RQjWeolP62pYam3X6Um0NtY5r4yrz/IlT3QZBwO8TUdj3Ds2QJU1sWdBXJM6dcSUN2uvEWZuCSfhSrhw2b9Gbx06veqoODVFQ/8amgn053qw+F7i08n/lz7AFpBo0s6DiqWnGeG0TceVN364EHNA3DFzaZ41SXcH/QhncFDjn6Lyx4IvCseLcVDLiQb7zSrqYgta4u5mZnj50Jm4bIqNy4nJX9JqW/b4Noz8M3whSlsxEJUxxT2XtCnhh+cYHYLasFmkjA50O5jZioa6tqs1Zr27Xlme7OD+hffLJfGSv0zEKX/8xaY2xziJJPhMIOirzQhrGPcbMOx+cAaqnvN9XgVs6cDkVEB52VUJXBwY9AOlKh0K6YPO1Jxapgi1uDNsBjHzBgSRWJGJKuJKa0kjkZxBdLAgCkRTjvz9rkyv1OjALhY4IHLCyiryXdR5YwZlmKbFWVxxYiY/F5lahccFJciVStGjEkYD/I6MdMR0l6rJF/HvtEQgZcWx9yszGzBdcvBskoUQYXNKG6e4jFYCP0fFE/AaQ0nNPOnjq2DUg3gSynH8ODD09q41keQXohdJS2jnbSfLnXMFSDqetQt+LEoRQtA635a4zItWOYy7dQv6rxygLVaNYlCi7Mmw9MnQceg9Dpbh0QwMOG+EAT806rRZU8guuwnHV1WLMTnxsBdgQjcFfDAXRq2ZuiuR/CveeAEG1X9VtGlfquo6bfqvtWq0QgZNHQWBPxsf+IcavmBbZGI20XzBx2koFs8/Yu7PHO9k5pb6E1jp8pqDulYup8ydLKSqC5vpi11m+x6HZhlNfGIJA3Xr7zCfZ/bWw15+CWrZviiGWmVNSJJc5ni01qJIGXovm+LIE+2Z0OiUshfwf/555VjhRXjbdTA+khtpfWiMvZpvvbssbE+Gno31Ls/K45QXCvTeDVlWUltHnrikcWOIzKZ4OSvOALen8bBMI3HwUXIHTAIO+wKWbBXUSQwwAFNtof09jaMRo3GgDKemZBJrNJo5R77mNtqbfBfpbkpL6iPe7BNjfDX8Hp3baMSCCwjUDESi/Z+HJIITi/PVK1COvmU4CmOGyI40AqQ+XtuhohAGxEeQioj+44232XabJcaZrukto/qa1095GRYMbcaLUJeWorRJB9lsvKjAXELkeBq8nEeKLcaoW6th6QXz1zJTG+1asw5cyi02DPRHaU1/QRIe+c39+kMHumC1ffarG8n8V2bpol1kURt8ejlqTQwouivbk8rawVBTuxzek0zpEiz631fm3ljDJ5z4juLJBYM3a6X3a420BhAnjdNeHwpSMppqXxsex+HV7ehvNc/4i7N/Hk4ZhQUiKTBlNq6/OvibgIbqgZfZA5DuZd0j6qFJ0g3mtarBi6jR7UKi7SW46kREQp4tCccIIAl6NG1gkDZmjakt9vh7QW5SmnK7Mcqw+QqTSc6NriALVfuFJpNpNFnxaSx4SG/IIimpS0NsJFWGI0s+4HydIKjcEJ4+UuCxyMGmySi4JHXmgAaRokw78AWA1emcs0tGlvyndwYeMd6P8Yhw1aMb+k3bNEIw9smVBaNd5ZOzT4W7hXxSMFfjSmMsWWvNnWg3L/wF+hfFBCSa8rAfDhMrNswGV6vChXP2ux0OxEHjnNJY0tyfehxDXShARcMb13Xo4sFefwm+F8H/6Wrq/8p7Lc/XgNe0m9khEdGJcNF5wQPyeWdFVr8mlPEpAfwT2IviUYgS8S6jRqkLzZr/e+PuYoZk/MLGOjMQJh38d2kTG/ag6+gMiZbKyjau0gOKpC5vmNqmyzvuA7Z+dDt7JHjeff8WGh3oRHGkwAeosDKX/JD2nOZZM1rxxSW7QpYcjfG+TnP+tzBySTG30B8LE5rR94WxUth/8t/POWKkzzxgeshcV3Evl0FIWM4ET7iHrdr2bcrG81nt+MIfOIkycR7+nQ6nXammx0aXz3d6Ha7T3kZXuSfAJO6cr39/f2nM8gtXCjYt6u2GFo9OcGXrHlkd7cXFNYXngZBKmR3ra61Af/PrXLtNAI9Vlx/WYO1gsBgnv2mt93Zs/Z+622dbnd2Br0ta6Oz2920ehudnZ1tq2f1ulbP2u1sbm5ZPWtH5u5Y252d053rjW/tzl63N9izNju721vWXmd3f9fahEqbw15no7sJg7J43oa10ent75/u/bY1bHe2tzetbrtndXa2dto9q8ezervDrtXZ3trvbG3sQdrmfmd/G3I3u7tjKLPb2dzbHWx3dnY3rN5eZ2+nZ+10tret3r612+lZvf3r7c7eEJqwulYPmmlDK1YP2mnrZnba0M6ws72x1e70dnY7+9ub7c7utvgB3e2c7sOQBr1daw/GaPV2OpvbG9aeJQB2b9crXz24Lv/2VfnfBL5vehvW3m97p9u82KMQ7LvXZjimDD+4NFudza09q7fV2dvaH7Y7Wzv78L92r7OxoX7t7O9a3SNYpF5nr7c/bm90drZ7cKZsLK3Cs4x/eAGrK7LHG53d7b32Zqe33Yaf+/znxrCu0p6qpJMtnqx+6gHudXp7m2M+vPZmp7vZGy6rYamh63yBBzA6PiZYp972hpX/HjZW+YE9BG8n7TBucqpnrtZmZ2Pb6nWPdjq97r610dneG7Y7Gzt77c7Grvyx2+Wg39/dVxmd3W6P/93f2be6493O3qa129nv7g2hRGdjt8f/7nZhLlBx3DbKtFUhaLrH++HtqH539uWvvOMjNc6fAZP2SPgJWg6Y3c5Wb8/iYBl2NnY32mpK4sf+7r7VZXyqu90en+YOn+Z+d8+CyQ45gNQ8xA9eSRZq60IGvHlTHBwCPtUu+a4BYIz5ANu7nd5W76dAJZ08BBNLAqW3xWc5gG9A2u1Ob3cH6GFvY9f42tzfNYrudXZ2+PfOlvjg7Wx0d3XRzc7+5r51ZPW6na29fQF6qNntbPT2re3O3lbP2u/s7m3o39s9WeoIdkx3Q7UxAJK9uaE7UB87W6qcHlZnb3dTjXmjs9nr5R/bez1VEAZl7XZ2d3atoxIUPj8O9lvWxpaAPTh+aif0QUTct3a/bf223dnbHG92OIHb3j/as3bG7R1L/F+vs9Vrwz9HUMrqbf620Tvdvf/egeHZpEmKbY6ra/X2rre+tTeu21vfNu7fbFo73zaue3unO79t3t9uWrvXvY1v7Y3fdr5tPG4ovW2rtyNPHDqpj+4OfGcShxGDgCMg/AsjNg4T7Gwgq91zlw3+kozHnv0fl/w/G8HnhxSUw4CrpqORjWB6gEvXW996v218a/fub7fbO79tfOtdb5/u3t9uWJune+P2psX/DyCwDRPdv3+z1dm29nnBjc726f49NLMBv7+1oaXe/e2+1bvufYNTqbvR4exBr7O90e5sdnbbnd5+pwcnisjZ7Wz+1uO8CpxW7c7WdrvX7p1uDbuQxj+tXrt3vTmEwwyO0v32hrXR3gDWpScO/r19OPevN4e8FatndbY4f/Rt+7rdO935rfdt/7rX/dbegKFuX++JtlVf7d5ve5UBsDy33bPUMHi78Ou3TV1DZfLlh/8OwKlkMsg9TI0wXC5filfo3MVUMvCTwWIxosMUlq7RFYTrdkgU4fi3j2+OfLtlP2FP7AMbJQPpFgC6iwf+A05M8uGQaIRngQzrEVyHzLSOX1tjrVY8kC4WQN2hoV7IGLky3CYUnTOE8RWfFEM9N3cUrpqCSOEiXji5dFQovzWlUEVd7uTOgmC58DwnPITTJ/ZtyrhITlZwD+SUv+I75tC6fog7Z2fk3Kdn5Jwbg8FdkAMsGvhP//jDOfvjjzX7P9b/s/WL4/7lCer88dQ7+NV/1v+vsz/O/xV8mS+y/6N97j69yqGXRpgNwwl+Myq4jODAeXfp2H/8Ybu/dvvMY50Yc70zJxoge71nm6Bk3F+1QAnQbcjbWnPYM397e2N/p9Viv/rbu5tbm/Boz9N3tvZ3RfrOdre7y9PXnJ3t7c3t1v/BWi34tbXmyxTm6opdXmkPvns9GcqKZ/S2eM5mTxftbezypN72Pvi4c9izXq+31ev1BHYbswB9Mhj8e0qiBGZALh32jPftzgHIVEzkieOwtg/pO+6zZ70u+A7Z3tnc6D5xet2NzRbTb5DitYkrqg2uw5hDhv7/uPv7L1eS4zAQ/Ve6i9e4VYNEN4D+RnfdNoDuNkfqYY/nftAzaAymACSA6i5U4lZWNdDdiT3kUJRoyVqv15a1luS15B3zyyJF6sOiSIs6x+/3fcN3zh7N7PvlroYzFN/u+x/eicisL6CA7jtDreXH4a1GfkdmRkZGREZGgpXOknRu4ITyurme0xtW4eZzTfgWC3vN2xLZKE2N/fW+TVjdXH/7c7p+WJk0rEJPJe9ORSP6aRjrdjzRavbkHL1u+T711HV4aM42iyQwUyubRd1gq6YZHAaVjS3TBBNNBWoVnmTM5Vg9tAHK5eYRwTa1CVh7skYJ3MydsnF4KfRwZHmcvur6OpNLTS8bpLRtVObiSwYpFQ3jMD1BNjyZnNk9m/JsZM7N4TKvk6yBkXNg1831Ru7gkdZcJ1b8u0+cunmr5bSKlrOGo32NaAfw2/Hh5yP42YefD7WHFS33PGD+vjadm4inLrd6FKY9AatTb/Bm3Cm5NMGIJZHHVuPNjcO4H1adzNUL4wPd6NTN20T7LlpBX9KUF5F8nj0yuXoQoVA+ZPIho75njQYtUNXBHPIGa64BVcvl5E84Ms7lNNtFn4QyR74U5inGEcqUQNWfyyUql/KpylpO1J8vqxYOUxDny0aFTTv1NbzTDKNLEUAzopJhn7SDOMujcxAe0qWwZTPLp4p2sJ4oquWDOqZiBbBCMgut4JhguUPtYOTRRweQ95GWT05jg0WjYeS1g3XMcrA+8hLtVLR7l5Sd6lG3kwaK2ASeaMe1PSYTuMbUYE1yYWoaOTXtNTAJkPfawaZTPfcjSd7E1Mfhm5BceYxcP+f59X7S4RyZ33Zba17gUC6haXUC7rMhGTeKzchLelaOBuRoJmDevzAfrqBG0dQe5k+TYzC7yBOb18QwjPxD7eE0nMFoArT8RV57BNfe1wahrUgulwisgaHxdTKZNKKXRnHMO5avjw1DiAQsUQ5j4TzGc6P6at5iFDgit93+DNqm8G+g5XHCB6dwUwrgT5VcirpzZUPcH3gzOBIW0u21CZgnnQX+oXYw8FbWgYodDLxHmpHqTDtwHOq3HJv7Cxdd4MSLLZF9KciBMzNqWMT26XBhM479SJvJOtNEon7HjmBiXpd6tJvVh8RuCAMYerw+YA5iD5Z8VDp8uMJ9y/MBO1VkHkm9ZiTGOtXM0r6z2b6nCe8SWiOpowZTNXqkzZTNalP2bVVPUm82S8ALEQFfVaEQ0dWA6LONr48AT3T7MKJfWjiL7uV8J4gtIQnUhmL7Dj18uIJ/YVCXLPi4gFztcFMB3uqHhp6g8TFUhD/k9MQJKrvqw8MDawUOX2bak+vGoz2sPR/kW9HSw74sxjBLZbOHVp8u6O7DFe51slrkXgcbhJtyv4AhiRDXHva1fJDXH65YDg6IzG05/uH9qGqYHU65Ab8fag+NfCufohcr65rCfTkC6PVy4bLF1GiVyLyLR3UmN5C/xTVD6kzeJTWnc4Nl5OKaIXUm75Ka07m9xdV6j6I8S6rzEn26J8HyB0iwLMfuu0iurgGNQAIvYFwFlgb+SlCuqI1lgxbm6t4Xku6nh2Tp9HVVLu57bG4rjTPK5HTexbWmci/Ze+gwzrO4tihXlzoLq+pSJ5FrcWVxPtvlC2uzXZ7Itbi2ON/Q8i4XVgeJyXyLK0zk5EF7wVbHg/YdPC3mULWMFtYyurOWcDscWF637VHrcgHjs5JifNrA+Jy7sKW141XHWc+/ow5M5ofLK6to0TqmEz97W1/UK9kZf+igeLGEJVAFovy+1b9nbqvd9hYzHA8PIP3TbEiPHsbVL9k+2+2QGsJFWRfkM9ieswjMFwJ85hPbsbv5krHmM2XXC5bhWs/1aE/L2/vJrvKg/WoXn0EP8ia4eItjDfIQkCqUOcL2C1DLo4hP+FzPfZi38w+1FbtrIm/wUHvUgCitCdt/Eu2iLuB0ZXNA85z3Q+C8Z4DgBU5HGmLSw8riDJBs5B8eKO/Hc5kgwwFz5gsDe4qls+BePF0MePyDddVctFSi8kvwyLFxAGE0l00kDPTcjACXHyJU1Na9uP4ot+V2BszLxKsQcZaBtQCn7AycWolRRyHObHfaVucSkexvfvVbgEKyY90lm0UsWnUXC2BdP9xRFjNL3XD/7C7ddFLtLcnnx/mW7dpdyXHhiUJQNzt1uAZVS9HWlTAmiTU6M9NaK+OAx7qkGQEwpalCAQXbjBp4Ay0U0fpZOikFlYSZeRqA4roRPR8sIU1AHWvq4DAqqrHVwjpb4OKZqJiO1RnQVks+FhMVw8uMr6L0ldTaYBmQFdRFYupemYH8qW5QmbYMoq7UM5kM+TBA3GypNMbNYvTzNWtihvWqsZNp+ERKlJG6oGAwNS0VPk3mwa5EXYNXo/HqkLlaUhXa7mWqAESoS4ZRxXgr6qmLFpC0+7jDRpSbxXhksGPg5wPrTixTGCEyNgsldAMjO4ZeekZMukOLAEI/9nFQKaEKJRmfBYARqYyzUgsFUiihx305tixfIqn+F4ltlvYx8WCyj8q0PfAqoLdMOewJzTnmMgzDzuf38c4zZN4wTbMlFaeFgm3cBuZqcR+Zi2k41Wv80h6phRCdIwSH+liNwKKRNSoLUuxCicj+1FO9uSDjmdmottteiKVyMloEdYvkgpzCoG6W4SgmfT6QGvW90myGUjpDAYYrobBvVrR0Bn1szmBGy4SDtayF1GgapGQYB0UhtnZnGx7nk03DpNbNVohOE3OcL+9PDuq5XAmuurdmZ29i7E/y+Wg3ALRTRxhkbBCYP/00ihvny2QSvtBhqLV3WChV9ADZInplS0eoQszGmODbQN64N01zJrEBW85FE1iazATz1CCTxPmWy7whetGEwdO5nEJmzp+c7Pve9S1Tp0FP33gVXIZ0AGmgUIhz1E2kzi/bI8p927Vm9li1eFli7cKsbhdx2mcGmRnGbQ+PSvP7LFxQJRxcOzN3+Lg0Ysp2GR6PizTPZrr3SR0H1hROVQl2F4OsrkYLLnxxGMu1DHypJ17+SdrWwvuHe9hwLsfypYPJIcub5QrL56chbFPoUmAWoUu53EZ5dWF34LTSPtgoC1Eq72Bn9g1YRMn6DagfCQgMzmZRJuXzwaOSgYQD12VJRhcKwUFRRccgrYxXTROe/mqZi0aFsLuHJD0QaohgQLKwA5+oSuGFyUgrwgn0xzY3INAVOA6Gq2cbe/LvJizPcTzxCl8IjsQ4l9PH5mbJ2GcHLYk+C4bajCtZWTS7CwYnkChDVoupmW9lzfzcSnyD9qhH3Q5NnVdKShGd7cnDFyKf+Um61Z0Z2ERlC4g0qZEH5Fk2JS7+HVPi4melxPoGDK9+kUWNDSGQMFwougyv4GSSohaZzDRWM1vJaSan5kQ2TyZ5s/TyTUOdWOgUfKTkcjM4DwAc6g9mGlVNGhX9AXBIE/PUkC1jw1nbjzHJ58PtZ+lGhRvNMzML5cLjdTI2UruMF2bhjWdye0nFmLfSMdcDeX2hNsVtJmSrExY78i6QxIU5+RfGSarFYy4cSYDiTcdmEXyka/utQgF6awYN3hhLrh6OC8f5PGIXlI424smULITjU0CATKam7efzYyAgqXMSBcrhJG8m3joIER+Sw6te2FolowfZ8AdOCvhWq2e73VbLTD7tEoLOzLTAEYJum4XSPoOBs3t6Oksjn7eba641pNCNkOiFypJCKQsCcF1kOzQJhKQt8vV7wkx4IGc/Cci88RI+FaOu1sAbeJbjZ+diEdXhxkExl2PSjxfHpz8MgyTFqVvwNZ5pkJXI1WBNEFeWg2cbt3YMng5XVBHEEBaG5CtdqwTMXuu5hgJufvQsf8FpkJoWObs6B+9pthC304j6BgnDMWk0Bs4ZOPVWoBd4Ma7HArdbgecujf2ZaQ6aaz03FAxT0XAW1IKvEAvk07lOtGmPefMWBmqDme/K2AxSXWl9lq6gDUIHSGeR3ALiVlh8N76I/pqI5fiVcdinqXG/Xlk9n3r/tTuVL/2iuwVIuQDnJILNQIJILCHgMxAwhCB4WQhkJTNKNm7iIxFrNse/aL9UafAmeIeegWg58TBXS5IMZGZLkcVo8mDS2EFxfsYAdL4ytFwLb2sqRzpqBuFydNbaYs0YlqIalHsOjfKrl6Lm+qKhMf5+dLH0cl3sUx+bNDMeI4MSpmmm64nmP9pn9NmmGryJCEgS7OUMyr+uVFeJLSqhFFNYnvSOqE4/KklFF0FtWGVO9xVifqwfWwZKqufJcqqnCXDDniah5EaG8i3W1S1qGYfqMZ09Wgp5BfV6I+ZSfMK+fWDyfTufNxJJCELR2I+jGrxpsqWt/iPqZ802P5hr8jBVbaUolcGjuqmtnLu60Wg+PNfWyOphQUtaTPaPUfRKVh2LR3qjoBvnjfPm7TR/+Mrag/O3Bal87mD1/BxMnYl2fo7WytDOAMyk8wVxvna+Js4P5X9idXV1VRAiCoV10oUcut4R/lB4YmScG2Bt26ubt52K9l++pRGvov2X72pkVNH+yzc04g8r2t/8yr/PsO9E9VpX6syybFH1OVvUbp1kv/DWqzdY2nYWrb+hQ0MwS30IBqr98FefXMPPwjmXo9Jc308Yap9S36desvpVoBpCJOw/QfNwHVqZNljTmDOzrfrhvhJ1jAdt7nt6kTAjb+ejMMuXJKRXdbPRUA+Bkqg+DEvqLpnr11iXHqaXg1q0Mj1etlIIj4VkN5KRUXxWK7lIoBSvNIqk1IwdosBeVuHSWlNqNaRQTyINO0G1OwlBMaZN0tBAzZaA3lKziwSZhNqtsAhKocl+GaidMEvENsehqW1pnx3YoI0BTj3D5nacNMhKCCDj2BY3y5h2HBviSgoQyBJq8PaD6PhkVddbZqzfDUgI8exQgPxu7BuBGSi5sRUO9X6yajMgYeVC6CH4YCwGCvIQLhU2pjissXiZGNw4cvkQI3AJCdWcjQCeh7zkRNw10NnT8wuaiFhE/68yG+FCi6mGEmpTsxDPAfRK3h9oxftKgNtKYhx1ZrYadtNQYxX2Sy0+FgI8twBZ7DIFoYtOcn3LdhJAps+u51AmVoGZRfIAzqqemZKXl+gSFueozFQHaeGPJY+waklzBTlrEXJwqSHQH8BYX4AIemqqUxiyWjIqWWXjI8y4cIk8Q5X+adO8wIL6g1zuIuIVVh8osTjVD7SLVRr0iZmVSDLJbIbtgqZoaS2fB1fLJpxzTcKpDlfNrTwYW1ajrMuGV9CSFU5whWG5Q12HZ62NVPmZVUcQXcEBWrKS+tK9Qou6EG8BiWbnys8u5Nk2C4UavKeLVeB85nJ63ZSzFEc2DTKPSsqcvI7e0LOuQaiMjahESKCUwikeYzYK/Yi0zIlc9IHrPyoexoFKicBZQ3DQ2g/yeWP5/EjbCDVDaMZQCcJxmxr741wuXX5s3IFCavASYza9D85lFIt23/LM9ltestJB2Y3PUs1uFqnTMkMibhj/Bu0fT0ZC6M9MTX9bNLT8qK5uQWiafGU54kfD+xBgtdg0dC2fvMGX1dSSR5oVv1SCtbzGmecveO6XhfgQIsbUWACVALAM/cHL9EEjsyOBSn35U39GtL5myLfE0rmI3ACezm4A6b30acNWW6HcgPWWjIoofGIzfgRHZIUC1oDyGuwhYxMkUyOxnV6gmngcbR+1Ncfi/qvAZZtF8K4sZSaCr+U/MGtrdEI7qEBP5Hx0Eb0WkCkhTtQee0EeSAY+/wCusyluWSHq6RwViUzqwqcsMlACScaDRrnZDKvJIGdpaCD38jZnFhDkujAT/S08aGyE4O/XIw5EvziYRIF7jUey76mZNPF5c0XrWio7yAakTsIgiAZGxAEiG493bFOcR9IqJ7q0N7Pi5cJWbAPgBkOaSj1Es5hbjLFrDNg1LhRm8DOiveNZNE2mLMPWGFXtBKrCGYo5LxeCY3k1pAYZ1ENf/Jg7SEg2+QKINv/l+1ryTHDttkymEP83X/p6MkFvHK42jb/50tch7UFpbW0+8XZTlnxQgv+S6SSslKRKvS0abxeaRqFQ0OGHeGCs94dQ/m++9K8elGeynnOjUNDPeTLXv5zL1Xi7cM6bhqzxnDfnchvEjplYyfrxoeX5eCcviSOJ2Dv3AnJD3iRvEUqXowxF86Y3MxDnTZjoNxcjzpsJxHkrFR2dBtFQFWIWgZfaZwdvRbyUopgJJHorgUSrQ4UjNpoQK+aujnkUmXtqRtUXSvtP8ab0qk5p46nKcWDWjf2nhYKxH8PxNF9Sx20plAyhohVoZn8M9gp6v54gsGPSMvt1SVIDQ5onXJirkZYhIC1JLwslYHdUIF8iN6b2EDoIntiJfpoqghftLvC0/oG5ekqemasXOJ5ZfdNrJnaOpDtZN2Qn7Z5eWwPfhg6cdt3kcolMpmnWjduw/M2h/lajJucrlp5idcd8Iqkpg6sQiyQONsrNJOrGNURx4aDMF91oGkblFwVH8dPDUWoaRgJPn4Jjfd92A7pCpw8OKQ23BoCjwsiI8UpUFw525UZtDPWpUXmWy93kcnG7S2HR/uZL/wY84qJN3MsXmzabsbarzjwaaoFVD+HAMDrt9SRvA7/2Qy0pN4v7/OAq3BT3eagc9aSWGzp+Bbe0AXPlj1JSQYZK0poUQTOu4YbECNubNfNMG3POWHombEDTZp5teBGVR/aYNB30Hw/snh8F284lLF3XT9hm0uRvMBNVQaUWKEXAKf/ypuYx5is7zm5X1VcoZZiTepQHDhp9RmJ33bwwa8ia6bFBakRrLg5O9y9AmrR7+ticJE0ZLlDShQQ0ihgbt7V8PsLMKQjZUzSCGOdyF6umeVooCaFHMfl8crjkRNaN5JjJuAsjOXAyrmZI2AHuunkBKs25uiYROzhX5UxSsuaikR76ZLUhqYuRC2w/a3inFl74gFyhKjeyrgvvaAZmWE+DNfNho7CZPJL8DA0TDSE2F1mhgcxo5HKbW6to2LS5AX+FCA5aynRtvgQanARpmM/kZdgFQC+ClKTAhB07yJcema20GdIiqA82d4WwH23tpM199qUdWDBbz+rimh6Zm7u5nA3OSwzjNrKnE2JzG/4qg7qwsmlklHv3IAGBSh4deaxDefrcLGJn9iUvCroXPWGx4kXHbSDCGbNamaDBmvjedUyXEg3a/Hg48q/NxPkARiQE0wRCNngzn0DgBm8+MhO4Dp4rspsBFMB6T203cSy4ko7PsGtRq2KfHzBgY5bCcpAGxUDKHV3XWALZ45HVmYVKxmVAFBvPS6CQFEUpiSnm9wcAfHXMtI9Rmcd3KQDsXC6KTLVtmiZ7ufZrVucyAwaIDo96gGU+iE1qpeJ3nz+yke8DJzFZwBQKPPL/wPOluwDqU38GS8KYhVaNptTsPDKZakfTIOI0D8s0jFsZJ2lr47SZf83yB2tD29WTeHTaJDY8hhYcJtHptJkvVdIRJOpraCM7MZTxIjAV8hyfFdCsp7h/esD2T/N50srnDf3CTLdoPLJB9jZtg1yAvRPoNIBdnoH3AswC86UDBu9v3wVdvdFqmlkgqtGoR1ofeQDbRh8xDc3yfLvjUI1oFre78Dd820CLPaJAgHWvNaJ1LPfK4vhDPsVNtA5z5Fc9bK6BczbpkFjrQlLX14hGh20K8dKjNvXxZz+upWf3Aw8aUs9mwg8PHloalOBThs8GfDbhswWfbfhQq4u5w2fVtQGE7J4nX551bI1oQwsSlNctoiG8LPDxwUUNktBjtDbyWN+jHHonnxqHH/LCG/ySXp41vKEOf9WI+NCryNcZ0fwe8kqaP4CPh7+oBZkCaBd8njOtmW3n0Qb2Ei1MpBO2Fvh8OkCnUNXCW+DkqbQ1NRrn/Hz9UXOdHGPy+XpGhkepQ+CUUkUtr9QN7tR+HLGMuFPH+3GsufQ6ofbSJkHBNtKbfyabsnDPH2e10XpkjsEKfhvYlGB+P21JNgVtiwMhWtEVgZiUt0CqtB+ZY9h5bTyRHuOJtHSUHkHcQR9cCZsp0pK6ggjEQmJIDjbTpuctMwDL8f3gQBJFHm6xIB4H6qrPLbIbUY1BqsZH5qahOIqWmc8H02iYkVMPsjXushPxyXhEMxlpkc3UJMKjc7iUwcRLnY0zaepOQ7ViaGgCVu3y0G32cLanDmYXkWRklhfN8YNZvq6W33j0IHV7Q811xsWpGsz0HqS2UiUuzBrRx6ZeU/Mut9AaPKtcuDAONlKZ9YmqWFLGGnkQ38+JLDTe0YxHZjFVLghDRUXr2f6qns/XH5k2XtfQa+ZF3O16otv1pnGgJzpebxq5XCL1IDFHhsKduZ7L62EJ3KnP4o4Q2QNwME6kqCVRM4yDB4ZxexrfMov2yuR0KdSr5/XTw1KluOjQBzBCk+8q8sokExnzJVInY0TB+2AeacQHZLMbEF5cbgJuJqPTdiUYecdlCKkTXIiqb82i6puP3pq5ZpTF4L8JBCmZTfXrkRnJ9WtDa/IFvJnQX4ZiyGDO12/kcm/m83jfMV7aafG+bjYSvWqSRMB8k4xN/U3zzYO3DlMo8aZRedN4ZL5FJmYjMQxNkgiYbxYSVeFiVteCZuWRxMwAGwSEsQU866quJ0a8lRjxFoqniUFvNQ1jvyXVokuGGtUFimzCqN3IA/4ieWo+CNnlZwdP95/Jmh40noEBfovYeEHp9iZeAXZPv1EVqROQBKAGmYRxMcAkETALpY2NHam8Wj5zWZUnAveYoTsgScxSq4mDcmomtTgkrdJJrqLM9T3jbk9TK7hmNhgpzixfPMKK0AIrs2/kSmwZd1Y/c3oVk4QUxKek1ig1TUk+YK73n0WnVzjPUfef5VnTrDeeJbAYoyaNZwkuJl46F/enPYOkNdgg+3ZskraQixi1JT3R63kzpZ25SFGDzfKCbbCe0My0crm9rfSGKA++Svv1gwt18W2yoA5ZHvUUk7j8RG414/j6mj4+QMetgRC62hHkHbmMyYRhuYvAA4U37jnM+GvRqSAk3ovI48EPsSmxKHEo6VAyoqRLSY+SawoUAIbpgblYYYXswJuQM2bjVIFMrRzmj8f0TbCkfqm94GZ+zh7AUcrMHkHp7EkVefNQX8x/PY1cqCR59xp5UKgVSsaCBTrnN08jGFV5qqbaootIgVHJrHHWmaB2Z0WwiRCbAnHv0MX7DqKLsb+qr+qtA9sQQtefyV0lQUgfwPQk9pnDUuWtwgPj0WYupz8zSwZ5dlAKf47NB0lqmn+WPURpp4Vhd5zF3amntu8LU+nc8XZ0PF1zdDtKMt9KbcVJIjYmPLbzS5N6uZqyyTNuiGHRXG7VpkLosDpKBgy8XMsF9qiUy8WyjYotGSkI6ilIJxE8F7N0/I6xXLgdtEwWkn+Hwl7QQnYtHo8WsOVCxIC2MBTtkTM8N6raeohdIzgT6VKzE1nKj+hBl+6PqGQgOrQxoikWokdTPESPxtev35QEeDFlaaExpywb+oJYTFcSuZFGrJrZVMJIsfRZg/zmoTbvplJLL8yFo2+pIVd7QYtc01wuItHgD+wJTPfrIXXn8xoAuRDKyoCU5cvyEfnUkXs5MiUKU8DMJDzAbWUa5SZzKvOLOCpyKywXRiJnGGnnzbIx1Tmh9A4xpJk0SM2wRV2islxAmmuz/MFpfvNRLbUp7JWyZvw0LW/sbWZmypfS2e65FUlZ9zRf3q8f1Pbr6nwum8GtzwCysSBTCjvrcF6XL6PP9joc8tSim+IzJfP5uhGyIfV8ftbqFZmTVAy60JiJA+PwjKwYjfkvUuqB03yZ1Avl7GpCZxuF0h3GkDP2wQRNRCsXmbtckoqlhfHJ4s0gtb3VjUI9JfLVk8Q5b27ObAoxFieqTBJIuYjClPys4Bm2U0gmGHfsMgkAJknwCghe3LNxqietew/0IuJlSL8UqYUt+XnpXznJ1MuYOzn71Cqe4/Lrj8w0X7+xtYivBz1lOrvi5UkrezXso1v+Vi5XP7jI5cYH5va+AZfjF2WP+PlH20JgGWT+W+Gqukjqj/D854JslEndIPokK2mL1A3jUeirYK5NsP3BM42JQe6UGxKOsTUi/VdXxnfrirAXL3FpSC7rOrmYuSwULsLSfJOpm0PLoVdYF4P/coKPlHjmMdFJoWKknQZURNVKOK8TqYxMqpgnaVZnNjGlQ1Svc+gJIjRJYPcENTQJLf2kifRY39wyFyjnAaO35YV29DKTVE+2SGCQVT2KjbX1B+Mwd/YaU7g0eSlckmAclirlu5HqpfGpRaJBYc37Ylah9BlwK9mdOfWpet4EyVroOTRJ2MK4l1RaIPMSTQMSt7x5SlZjDgJqThG700cbQtTz5VkiuF1cvZuB2NgwF2lASohZG3gV/zalOp1GHpF2pH5jde4K5INSfJV6oyyi58zZI3NvJ5djB2apXJ4CJqb0MTtRa6v62JwjJ+hbTT+uJ5xRRSz9kgKt+oz3qlUYmna9MZ57NqW5QFs8lRd9gBJMDiLTCXDiHok/M25jwhW0AONinEnhVgZpzDp2mpAi0rn7cNDqDDWaIgzfQ6UDA8Dy5Uf2zEGQ2l8kjl6kad/cKZBC84sEdbloJlUDF82Zk6nNBVv3JKmQ29pNK+TQnm3hyWu+ZJDV9bcbhYpYaeYfrEsz33EaJ3S4zaBucQjNMA7McppJeABmfnWzuF8/OI2s+xWzvqo/M08b9WZ4zIWR4O6nLkTdNM3TyNzMCE3q9iMMhvLrb1cOC/nKYQjcswRwW7umaT5LjsWzuLrDBxKv5jMVjUOtQ12felpF80D80oxKZjZVhebQHuQJg5oxDZ1xLBvdjLM9oaXHFoY2Mk9/+1yIc/EA7OA1IzHiJDxLWI3Ha9nZTea6it3jhwqip0tU6FkVRF7wE0pWuMd57wq8e5bOQKUFEKkKpUv3B41689NBNru7JhB2WYX32UD9wUJpYMlcecsOIxbOzUuWid4eCGfkxoTXj7JRAqhbef9CHqMtInK5XKG0unRNXGSvCWl7Kw+L7rMg7sCvT49R3QyMmqutJima1F8DZ1wqb5qmjEzTj1KlSLITMRzTqlSwkghF/Ny9ELf2KRB0Md7svyxyLlP6aYn3K+6Po/E7HVllnoIq8AY+FyFfPn+Ihlt9l/ZmDnNUzP1PcFKEVo8t0A/gMboZgyO4rQP2JZGvXskI1QzwkVmbYRyi1VRbwjK0zFQTNVTGfgqV2unckU3mwHedFGW4WLhVTExGxmYtvKyjtNkUtNLkLVBpZ1efPnlpTMjkUxPrBPs3IVIsS1gdle4SiLDpe6yT7mKdtLLtzi6W3jLrZqOWPZBvRqcwz8wYudDMIe7OTSwDjZt4xTilk4syJmpIlsiXSSJktuJTnHFz8blNtHaylGrjuaObt4TA+S8ZeCyCwuZ48cFNDM5NBMKbaQieprr2gCQ6t+BErLtkM6wDvagpyEjtUWhJv0KXLcZEluy1iBn2x2YNWMO41n182a5GktJQnLJIUxJlgMso+ZKRqi6uClQVoHIbz6RHY7qg0pkOjBPnQMsoeNdZPKoXOKohFa4ResepzIPSf3vnMqdG9uYSB0nSM66Mm/NCLTcYFJhBajxAi1ElNhspufnCMOQJyMIT57hlQ/FmydLIVaWNPi9mlW6SXreAUsurmNENtPHBZH8sK6g3xnDoeBEeOrZSZ46tNOoEZoIiM3KxiB5HG3bsiiabhC72w/ELUaUFvxAl7F2eO1Jq2NRtQem7UV7dM27vcUlwvPSS4Di8JDhWlwTJreX4FRVfRnd2htIEGVNjmmg+cR0ipPCLndeS1qLLQHjjVkKI7EHdBMMewM7QgCfU5ycu4UwMolTIRkrCmGSelkvfIuNcbhU8i+qcTAA1SwY6G1E0UC7fOsnYdtBrCuxaqImBSAPAS9Fo2NEhE8nnEXZpvoDUJN6aZspICht2cjqVdxweg1u0c/fcBx9pE/Dz5kEwKBZ3t5qicR6UN8ub50G5WN5Vket9cgn5gmLRKiZfzbb5E+oNbdfymRc+4cvHtnKR37E4XSkVK/h3r6z+bsu/m2F4S/3dVH9L6u+G/LsR/t2Vf7dVfduqfKm8Ef5QNW2oFjZ2VM7NMEPYtCqxrZra2q1E+oou7VmB41cinc8ChEQCuMhnqiJdRen5zAhfUt/XualzM/Z0d1lHT22xR4BJncArLUlp9NxH8+tcLlXycdJHXnwMEqo0S/IhgYR8ZxvwNoadB0/O4H+iogemtrKysqKptafbhXFhYvyDTeCh7QK8ABCADxY4ho38mYcXeqW3Y+xx4ioucGAt0pKMTCvaQ6YRvaiD9xFyBtLvWf2gvLW9fwaib70eOVtMYFbVGQ2sLwTDpHcYeQUR308XAh5O38LQXhFDe+qF83I5cfOiY7lH1LGHPPv5hVUwEEa3VJHT/dNMp/uKCXhUPJxPLZSMCnjTkMdvM6ljMFc/xbPIcHrGj8yLXE6fIFOqt8xxAUS0zcOJCbSposvXEAJzfHBxmFFfBUkG3uiFg0pZDZaxVbQNVgEYvQfHUadwfzweUDwWk6USsYEiQ+CMhNx2LBe3N7Bgt1y5iVTqpItDWWlNp9r5+ep58o396In96IV9LeFrJ/NiT73emPG93zRL4S2fqnrKf+W+b/lDoYtPU+jVugn3X/A6VJfZGtEurCsruuxkWZb8cvjTZng/yupgdvW14SJTx8M/Xcu38A/e9LLxblXX5XiZC69I9Xwo06cMvvIzGsjbWhtlvMzl+yP1B4rZ1gS+sil7iB/52+1BcXuEAc/m6s9am9Iwam0y6iR+Rlmc8Y1GNKeLFQ0t2/GhqiH2YMi9kfoD+Yf+cwwGo66FBqkuHUO828OvjR84z3BdhJuNrOcBBUqBVEEj2oip62RQwPP5CK+QeVd2h+IvztVlMtVzbtMrTLFlCMHgQ/y6Q4xj1ijsZvSb4z20PnypI78uXqnz5ZADpRpSzq0+VO67G+WdIvzAVnyYLrg0SbQrOhxCr7CXY7z5NukwV/0pBJzKmZ4MHW/UCcFIhLDEEKflZmNvbavoRb8gyerawXDie4hWvRF+MR4n15JI5/tWZzCkLgbGcFpE+cDCG3lt2+8wG+8iMochFjpy/joDjw1p9KNAJz51eXghcVigV9T1257t04Ll+9TtUswsOU/4dcXxeqJrFUaOdS2dWCRjIKtlu4itXR+q7V61NaLRbvkSUNvqUN9GCHpUXWx0+5i7Z3PAkT5MT9/28XszZCOPqfuHfd9yoI5BB3FXDrg9wq8ncbizLf8gFvuIDxcWVH6Bvy/p9cjCrI7F/d5QITjisNWXqDAMw96lDEf4MMQ6hrwwoI5EfxfnYxgM5dXG4RXAIt8RJBpDFBhZzhD/AL99cwNYM3IsX13SHHlsApchR/waoHoeUA+Cah1QzgIPV4CH6Obxaxeyeb5Ecdphbtexe5CDSwzmfVwml9cjjBzC0HPmdC3oCh8x3+5BAxyHmvvUAig4wg0BPqLWpVwIbh9gCLpQa+B61IIFE/jlYnETV4Drezbi1pVNx4UI0jFtdzDr2LdwiVx37PE1zOmkZyNyTjyIvx7yvqc1yeupq5rFwt7aKlLlV/Ln6+ZhRInz/zDO0dQPK3Go0Lwtku3SNJFuHOqHlfO1lyphvGI8WievpaBZOy/A1dDy1tSo6I23Dx6dT4rFwvmkXGxC7jlPxqGKIGRGgE3AcSHMZEJoSV9M1OnpyA+qzPYh+rKS+YUISJKZg4wEihiVhJM5aGuKFzSf1M0YhHX9sBK4eGrcFXKBtlSoy4J2FDLWDV0LM2pkvfG29tA8ePRO3Mk85EjVoJH1h423HzZfeQhJqeo0sq413taar2jrhm6QL8yCdM7zlu97LXCkjsFXzHP+CkZdWU5AjUMEKMoDEMlpaFWa8VRV1lqF5itRTiyqkSd1aPONVJsHjWrhLatw01R/YZ6xPbsd+PSVc/7K+frho7AmjNTIF7Cio1RFb+uHFeB0Wr7VF8jnyF9sCNRXKGcUttsXXdpxLA/91YkObPPYpbCsRt6oG7oW1aCR9YPz9Swoz/krCJhqAjKuFgqFwiMBf/XDSuGw8fajQtNQPwtN45VCAYvE0ECpxmFz7ZXDxmET0xLgYZXQZDN/zvONtx81VYuSOVk/WD1v1I+qT6rnDbg2/bj5yuF58xyrkczXKayU3Od0/bAyaViFHsB9WyK7U9GIfhrG/rpNapgT1xTOo0reKE0hGSs7wQvG0nXegssrkDjr5wEZ8n37IGTMwQx/Tt6c5Y5tdL8Vs9o2mtaMGPizZWjqqrzJ51M2KJiF2HjpY8S4iYoltBly6XjGDbCKmdcXIrg96Wony/wreTximxEk0TkbqhdlX8kqA0WXjZKfMigMs6d7K3OVluYCK/hoYMemXSinPP8p5ywZJceGcRslJLLER41FAu9WxY8zJi1nwkentVmdkzQFyqxYOUiUuvhkbdHz03O17Ye13S836hfz+f3WQbDIVrNl7ButpIEQoEQrvAQunz9biMoyedazD6BXK/XY394ClzgpNMnng4PWwhfbAsMAKTqXA4yr18GttAI5C9UbQVOhd94sE3nPCxUFSo3GhEgP4uIJJHCHXzn2MTN7sY/X/FMDGMAATrMB1M7PtRC4fDjQ8C6wb3cukxfuo7hMPXa8CPFm/FKbOinQ1wlYz8cLb78OaLG3nW2tv2/U83mJQSnyYRP0Yzwx6/uhxYFMTlyihxfX9kM/1GM0T1vYUmihBoc1hTE+fZYys1mZm6tZzwdJ87pxwuVkY+XcbeZnnghQfhBKGQprmLgJTEj2vLUijAohDCevS53U6bazZMKA4F0kCGg5c/Iu0kuDpUIX+c1HZj3Dd8J8NZ/y8gdarl1k6X8upP6HTLIaKxukVN42U68zSuDM1E3T8CLJRL2jN0ljamBe5Mv7Ab5wV95etubQq9dFfiP1jmPeDAoXZMkGeEEC+Xhj/K7vRb5MWuFDvvnSQV29PJrdPOYycrllyXlUdY8XpALNl3Omq2dR8+WD+uGCqsqhGgxLjPFxQvlDuro7bBUKFYyaqKhJLteC+wAHcBvVuLXv+aiwfaiHSBq9LRwOjxrQ5JkkdRZa/WScVxtkvvji2xqy4RAaPD1XgNWBX6mEEwdHD+oJg7QXYX7/RYg+7j7zGtzc+PuxBDc35lbg5kZ6ld1/AW5u3LX+Vk3I/Xe1ArPbDxfg5sZnWH+bGy+5/DY3/j6tPtvln2X1QfFf4OoDJVNi+UHw/utvu/SLWH/bpb8f62+7NLf+tkvpRXb/9Ycl/yuuv+z2w/W3XfoM62+79JLrb7v092n9AYp/lgWI5X+BK5AORwOL28lNMIxaYAcjvQzI1ViTP9Hmbc4HFQoZcMnhQS6H3kkeLFmZNjyanDqKrBnGmjxMI6sXa+Fp2wzG2svwtSZ7L3H25e4wy2Gq5W1wjmQ3JX4fnGa725KtYAczMSVyAhLMdVIBGHZU9hOnV8pBY7MuX28BT5P78P66et1+cjA2btWpMJxKx8Y9k4I5JnhfI5SIQiMHHLExieqchhc7woy3NjpqT+I9koXYd3I8EdG7D0Ek4EQu6FoLlkQtL+dLHcQqH8EzegnfY9FlvPklUlpSNHancb+VdUddi1YZuQf4916hdrxCT5MrtBatUB60k3rzoJ2pVgvfwl8uII6XbY5juHfX+rS3/iXYILXLtdK6hyz0EkQWzCZLoabyUA9SC32MNsRyJclrcvLhCHjXUT8/Pz83Xjnn68ZhCKR8GisTRaGqOdUBTsGMnX5oixYpDqp1eJNCM6Zzc1yK57iVnONxYo5HqTke3WeO9zb/fkzx3ub/P8zw6O4ZvvgMMwxv+Cc3WQwv3GFJDcw7Hyi4n8XT/1RFZfh7empEl2Bv8LmL1WJWtnz+qWEQdA1y8yk5XBvfAdED+Qbhqe1enoKHCp2Tp3OXHPS6GeRLxsGzXG6zuMDBh3ycJJ/fr0M2yePqpws8HiDne2rgjSDlMOFZGk2fmnUSQXaE8FvKBK2ONl5o7ndZl/MbChdGZWxqGnlq1j8dGFDmKSiZc7mo8Se2j9dT69IseJLd8su3h+r8ialp4QAIsVlaqs1VCuYHZLUEVUS3niVAeEf8UTE9jJ9+GPZK2ROdy8HcAOrU51Gnjk7QDtNa46egezYqdfMpsP4tIfQrZndXitJwXOEWmdU0o5SC3inSz3s2XOYNLce+Sbyb2TKaWWM0Ni/WBh7tkYl5sYZPcs2od/EELFr0AakdzgjKQ7SZ4F6nMlZvek3wifQML8izSuWKPuvhyg1lBoApUWEWoxNNKPq8yRQooiyFAsloaiHzEtK9etzzZ5Hj39CpytyjoDMpGUQvpm/hkggvbaEvADCyX7DLIFItwDcpS67eQdjA8M+s58tkVc+iaFCLgRfG1OHop3Ei5Njcn8+K0fCGJSLwfFJ83WsO2xb7s8E3GVsZxxRJtBhnXCnLRJR5oMBhqHzOh8e++vhIbtLGNI1cIb4EyQ1yEnqcSKMNwj6PMx7t3Qth0LvExqO0tmR1brpWsxwz3cNpVv0+TrPqn8FpVgA4uB8cTNAgfrHTrJnTz1LxHpn2FmhjZ26BwOalPGsFatEF+Tyx064o4I4PEGNJiHEYFri6spvZi+bO5bCkxoPioX7XalmAtNKLlk3ka6fF6bJmzJZRaS3rGRnPpaqG5GuqZGlqPn/3Cpavqmb4BAqPimcXFC4nK/AZLMDEUgqjlh0HKxTYLi6ksYlXbxQiJE3wH2mKRuKzcJJBf61uGIer+qv1KFsw56JElZqY0c4MtFcfm0Gj2FQNlQi6Gl1djWjUleXYYGgr88akedmeOck6uV34bOV49obRXP75jXJ2ivKyE9GZr2FUVlf1VjQ+r9eNrL4rU+OKlsc3TGeGYfE4TP7+jkNrZhwSvobQCizlaci3+vP3EFJMwYwDIeX7KbQKWuAraGwIoaRfxOaNjQUPWYGxDmiNN9RDVjvyb4ZboPK93ALh9Sh9dXVWoA3l2COJBvOmJeHoZFksjMk4n8AuI9ssYR4D1chT17f968TAy4hMpQMZx5YfsCvt3sN0q5UvHYzRbmor03gIhljqQ5OdaoVDchqLLYDQtqlN4BoXkI9GcYaCHOJaeNX1JXVRa8UgpW2jkkoiJelIMFQU2/wZLJ9j7DkABheAeh4bwu/Xme36um1U0hHbW1sbG4vWd+S9aUGvanVDDu7E7FIwRpFNI3AoO8GPhG/tWUOSyaJ2s7NruRlzobkLjK9KdvxeNxhPlt5gPAlvMJ6EzxzuY5YkgTKTgfj2UTI2Xk9c2d2k5zp6OQ0kUhZaqOIw2pTrLLkjVTRDCMzZ0K7a0U2V1LUVddsELT6bUVEW3smpaAY8jTlNjtbMg1ioxMp+hW3hXcuWGV6fTSrzkBR1rM6A/iOKSrxHRUMx/vL4QD3qts8OWuHrr/LJNlzYCgeQKVP1PIZ6lNJsP0SEjLTplCzoYcaV0sQzZy/ZSaAf+ONgrGy8bLj+d4DPlDAzaNjYl5Jh4F1xqbuUKooR44/McdLt8ZzhnupfcxqlwD0zQM7XZVA3FnZz6UXF+Gbfq6H0mrjatz9ztU+9kfW8bt6G1yRv1V5VuQWKDiZkE/hxFuBVY+wTh1+O5fZf92jPnlQ0+B1YfVpAJxSXTyyvT/2KppHE88NQRj7CWtH+5ku/9zdf+rd/86X/Cd42JQO7P3DwLjO++B9LHJVycUo6bDhiLnV9XrntMI9WbmH6eEU9j4KXpqQAr8WaEwwk391Ov7Cczqke34+ZWt+yHa05VUZ06fZCF/7SOi96PigqrJ7qQnNu+URXwt1cwvml8lWe8h4nvcU1p0T2KG455pKTFpTSIC9xIhrtk5FB6wyjHm/SoX43NoaWnFRzOp2SXuA4/w3gQTRB8YAB9GCDz1w4d57rQzHRh+J/Q7gsMfQlMfJTouH90W8p4t0L06b76deF8Vlj5QYgekKYxa8E2+n3g6NfqceDwweBZS9eY10avfQro8zwR/hwMOtcmuqvjPLgHp1HPTP+qZqIccBMhWIG4Q0KqBf6eTRuNWlWr62aQLxZbwXvLZiccFNTNFczCMvlAGFWTYb6KLt3nct1mMuZQ9fGlufqmopekV1esfmKR4fsinbXVp5yuhImj5ygb6NhoE+tbuXcPXft4Yh5fgKwFeASVx56UcTD/ShXWM9slnWVAFnllaaom8ZawKmu0g247J4abcid3MmSox6n1eLRh5WBKYAOMxMim05OyQwfSDLfxO4wV767qD8Hjk8ByKkPcszt1JjG3UlyTNRPsRLpWwEW53bf1ZPtEW5MSWZVEQDzzBfyJMqvgD/w2Biuo6wcex7zdO2LcDq/8k48D++swKVb6pOVzoB2Llfg5tV6eN3T2I9AyeUY9i+KAOY6pj+53Bm+Erl2Sa+5nkzJuthtG7fJLOC7BmlDLsfC394adSXSZ2WUDlMXDU4ww87EF/J0ybvI+VpQep4dCl97jFghpCtYFaTuJx8A7qiHijuUc92Gd+UlGVnQmETEbGjxzmCIcSHGqh96/Ay6HnUoQhtmLOubXDafpod2iggWyafvcSYML9fvV+PTlLnezzzheaZ06HZPx+dmE+Co9PgILCygHDrIcSni/Q8lAYbunIA3b9jN5NO5dvy4dQRB4NsOb0niOn+BLbzZtW+jB75CwVgFJSTDqvHhQ3W4YJNS4rlQXNhP3YHldh3aBfFQLnBu3M6t+SjbCvoy6TFvBW9NVlYeakBIOhZcrNMeaoaBl0kf1ON5MW6jJj/vD50noJwybqNDSI5OR26nagZ8q/8Fa0jhHBnDlu97MdG0XZd6n3/y2mmUPh7YPuUjq0PfoH06MdfPeX49XVX0C6/PJmpVf4WYrz787Q8dIcLAk9dOoYpQW6B6k6bOT1SjSZqaXNwRUIp+zFfSz6gksw6EhWRCUfX9BRQBK+jLHFw34M1btgyUmZqW1LMEFJ5Vg6L2yT0rro9w4y6o+KLhUZOqp/DHWABe3bE4X9hBBT9cuLXARQPPrMbqduerSQj5Ztg5zKUbodA/g7ukZdqHtlKdBEalIY9IwvA+A4/U4B9KN/YN1MuEhKZFmJHLtaQmaWY3icd0DTthttRr1Cva4iGWpOb/jk61Yj9oif7BHTap50t2UV57AxvjkKJNgKJ95t7259DAuF1W6aKFN7D4InRCyAGK/Myw5QGyWOe2ouU5Ri1A11cjCrUIZRM0bGGHo4r8obMM96PW9GzE72cCtACeRaPWzwRmbgZiWDJB8VnV7QyY9xhFm2Q9UgMbVqNIq24QyeWutQPb6eL0Pva9SEHa0A404FtMdghzwkDa1h5pJA2NP3R0g2gH65BXe6Q144feM0BMtZSEEBjtmFjF7+qnIQ8pIzMbzZivWLHdFW5wwL2zsfu6x0bU86/R+xKT9MDOPzS1h3lgBvIPtYcRdWDxyoignaqb+c+y9285xE+sfg26Qr1lG7lLx1+03S4bR6Ku7wXAKNBYBIIlkNrt40KJ30LM1RD/FGK+tsTv5H49C/3s1GStBOCBHtT1W7XlVjRLIzhLFdmmRy2fypnhBol4BpmqOFrVLjofwImMwwY4IyRLAEs0kCGm3eJ5ZKLSz4OthzENqbMsXeeSLIEZfWwpqjNFIe3ZCcM0H5VMptZqOxaoWdiaRx1TcxkchFJvxWWoDvKoF8rW4YzkcqlgRN+zYg/mBiSxHUijMXOuewZh9xizsNcLpNsYRZSaPOao4d0V5c8zrORx0OvZEwrLr2UWyThSze+3DsbyyWS1+ea1gpYP4IHgWOKJ11m4tJfCP4c2WYip6EKXPVEjiiR6Sa1xzkUjEs4NCv5MiFU2cx+c78sBCuNJAD9ZB01DVXelVhvPGg9jUUPV/BjSZh/8ByWTadqHcEiZG1DHsUf7GmmZGyQwd40KHvdH7YU/JZnCZfvUczJ6pGmxXwDeGdAhuq0cMA5vq7G8Gcbmtcr6Oh7zJdPgt7zxIFExb2rrWl6GIR6dLKmEQy2vIiCl51n9oXzBDRI/p+XjOERchPkxxYgMATow2TrcB3vN8gdrHWo7cDlkbBZKr2BMz2Fg6QR3WeIejg+KaAXBQ5vIMR6wqkCRtIy8nZ9MQ3MqXHgmS8/q2GSFFplkERkgJ5zEVinrb0tHLHmjcr5+vr5uR6ge4LKVA4tnzcQ27RCO5GG7QfRUfWuvHBr6oamfH4rPifN18cAw1uWpP8O5eJnKztfT1SXrgvl7qboOo7rSFeF8v0xNn8OKHkQVhDiBdQBiAHWGq0cKlXDw66YKZo7U+ecMI6p1vw4ooOAKS0UYAO6/I0MHbobrBty3ThdhBQKjFoX8Yco/iacqxuPx+do6PFQxUytZiGgXyuY6qvsir+o1yIUq9Ci6xbmimpTxpskOVcG4b6wA+G3EEeN8YFSSy0y/IOOZdOl/KAJFrfNTuc4nap1PonV+Ktd5ODnkVHpLucifGnMgJ2MRYAzH4BoV/SKfAu+UjAsXEd7MAHqRN0/xTlpMRhA3akhc4th9CVAtE6DaDEC15QDV7gCoJgEK6aqaPgTrgTlJUVYF1oMQgIMIqgf5ixQUUOfFPNZcSIY0Se4eFfGt6IuwdJo4jtfLYOSlX8SVx8QUE/N2/tlMr8DTuW1UwDdl16ELtq/XMDHcv5ZR08X7WmDuwt6GrnGjfa01s6+FxHi2zwkSn91nvqzPk+w+z3Xz2O2GfVStR1mwF+pe65w/4HA0HrFcTl/U+w2jkug4jzok3TyxQgBrmfCpakHCOM/YTHWDPM0WUl6zlGdoZGparQveDTqXR8FweH3EOiAhmXgQislIV2kXuaxQCGG9HhyzFCMtomrTTAbmi6dCqZrCH6EgghCmJeDXEhUtkIETtU/JbBWc+meyseQGngJivlB/vlC6SQV2VsFZzni+ikYkcl+P4Cm9+WqQ5jyx+kv0nCEfi1lRo2eoegAD0PNoF04b7A7P1nemsyidI6f+6yEUZz0hblsthKrVAmf3cFBpuR04GEVVfy6XrpmvRdlNNhUinZqSzJmhWkwpiRKC+hp4VUXf2rmcPAqA4ytjmu6aPnMi4XPHbrco327JbDw6m9DCPPHhbnSOa7LEYR5MilLu4zyqgeJSr7+i5aUiRWegobL5isv8FWsFzn99L+j4zFth3grUqyX8abdaoSlcIqPJp/OdITweElPSCpMdqsGSApwOG1OrlcjH4t8E+tBqydOGljr4zMCjKCmlbY7nLMowq90EP3mW1w/QWGf22CbM2MI5jvPB1N1zwltywlsw4QlhkU9JCNGaNRo51/IELWrBmO6nBmkmFE+/GU7/42AEx7OcdnG6c7mZCNxwburki/W0pjccoGMwppY0NTrj47IX6iRUCPgRi7sUSgA1DX8y+ZfYkap2Dn/jVkDzH4dmzkUAC+YmWcPatenCYhi/iMhg4cVlY8VDRsORnfn9a1pG4ecqmOpP6wb55QUz83mLD3yr/1Jzoxxih0o3e20gK5GBZCpLhhIZWfhr2XQmQYMJTYbvNaWqDVAYLyz6OAHtghFNdGFZTSplUS0KmGU1ZKNJQoGbHExmJqvdT79loflj28dXOsOh8P0Rr6yvq3iwZVhXRde1PNvHQj2rQ9uMXc6VGo/Ha2FidlHc7vqeNZwrG6VgQToZOcyj677V53Fp3wYH7JnNyiQsGzYYWnRmHDBfumzsrqhhQhuSFZ+tjJjt+isK5hWfVeCAxJjePRXZ6wxEpsVTKpfb5xcst9eoC38//XILJ5bYa0NZl6klAyz8tXwlLl51SQhh1SXD91p1qn1tuqSoSlnIpcrkZTW87LpdXFP2qlPrabail1heWj7Zl5dbJlllOQvcbsdhQXeucJy0sPT9ltg/nCn6KZeaKq6W2twYTu+ejgUrbwa+ZfUsky3Cyw1LC8Rr0kgcVCfwTk+ehITnZKieTUIl6cEvLaAHrw8YPD3zEtTADYZt6slFP3IC/hgY09USiVKY+pFMZ9HPZUs/BgYWfhy617IfQXZturAYxn9BgrhgwUq4F1exvHS6DbxnsKCepeyYTx2484dVhoN2qOW1iqYZ+XuBeTePlpLC42okqvyjBajy1Hs5DjrwFP8cqILyTUqpV0PsAaA7zAkrllgUxr5BHcu3r2giCTQsI2lPbt7KesDefDweV+B1P5XhiWfZju32HzsWH+ArgGvyntXr1OtQ1z92Owx9aWOSrEZWqoyv3taROh3iwYBxuG4Te208Hi/Lo6MOWeWd7UFYBM8ZEumvexTuqNCu7F1qmFgqKFNlZNbAsbmohQPJsuNnRpclQ9kDyzIiFw41y45fRgyinnCDhL/vRQgCD8So7CJPk2O8YHEkB35JPQtY5MBzUpZLmaMtRCotbGMmOsIPIfS4dhP3TtBK5znJzo8P5PFFsC+jPhElgxOQhC/snDUc7YMr7JxmLK93luykzT8SGkLduHuc0BgDkxU6pvNI9NTBsV8yE0RGav1UBY8Tiz272Hg8ninzxfE4s0AK8eMy0ugslRgXzFwEs2VnkrF09mTO9SnzjD2aRQn7LL0jmmYsrj3q/T2qTtNI5SJrYc3Zk3mPZjLJ68JeZMxHRhvauhY9A4g+y6L33dVrgonXFhbNRubsZRxFx91Z/wfl8nrfJloO7hrta0YyZVumwMJLJ+zIhM9t7KUTNuoywZmpaeNYxvchft/3rsNxldj49I1X6+HFAp0Z00548qBysWncW8kgvBkzCCtv1u97SLHgCGJK/gk8sqneflknb0HovNtcJ/8Yfx011wk9A4vodeKfmeuNh1pznXjwS73PUzo5n+ycNNeJe2auy3rOJ9Xq+aS2dT6pVc8ndch1tH0+OdotnE9Ots8nJ7uF86BYrpfwu42BIwwcFzFwvInfOn6Pz4Pixg4mbOxs4ncbvzv4rcqEI/yewHcXk3exkY3dKn7r+D3GqGoJvxsYONnC7w4ENndL+MUqt8pQ2dZGCQNb2/jdg++2jNqF9reOELCtY2hm60QGTsrnQXG7jIHtTUjZ3j7GL1S5vYPlt4828Avtbx/L7zZ+MesxZj1BULZP6viFqJ1SEb9lSNhBGHc2jzBQhUp2atCHnToW3EGwdk428YvJJxCzW0TYdktb+MUoeEm1uIsvqe5uyuQtDFRloIbptW0ZgOHeK25CYG9jD78YtVXEL4793jaAsie7u7eLKbtbMlDHL0C/t4cJezgde9Vd/CL0ezVMqZXxuy2jsC3ZfB0Gau8IqzrCmKMTzHSM7Z5g6RP4XS1is9ViFb/QbBXHsVrCZqvY7WoZm61uYMpGGb8b+N3C7zZ+MSv2ubq1hwW26vgFcKo7ODFVRNSq7G119wi/CFx1DwGS3a1id6uyu1XsbhW7W61huzVZHjtdxU5XjzDTsfxiVSeQWpM9rBXr+IUe1rCHNdnDGvawJntYwx7WsIc17GFtQxbHbtVwKmvYq9qW/I2w13BCazv43cVysoc1XGU1ub5qOKG1vbIMbOEX693DXHtY794xfhHQKlZV3cQvok6tilmrskLsfw17Xpf9rGM/60VMr2NH63JJ1LGjddnROvanjv2pS6Ssb0HN9W0sgv2pI2bWZU/qiJl12ZM69qQue1LHuarLuarj/NTl/NQRvjrOT/0I+lXH+anj/NRP5BcG+0hCf4TQH0nojxD6Iwn90UYVv1DV0SZUdbSFi+wICdCRnIcjhPtIkr4jJHpHEvyjPcy2J1OQFBzVNmSghl+suY5L+qgOmY+LuD6PESeOESeOESeOJRU43sRcSBqPdzF5dxO/O/jdxW8Vv1D58R4CfLyHyXt7MgAwHldlSzjdx0itjquYC2f7uFrDLxKzY1wMx7gYjnGwjxH0Ywn6cR1BkB04qmPUEbRxUoRyJxL0k80d/CIMJ9sw6CdyWziBaS4VkQyWiuUqfDdO4Lslo7a28FuVgSP4wqCXituYsL2N32NM3inidwsDu5hr9xi+VVlXHYvUd/CLVR3JhBNs9wTAL5U3d/FblQHIVpawlGHqS+UtTJcQlRGi8rZM38WUXZmyiyl7MgUGsVSulWVgC7+7MgAAluuYXpfpCGa5LtOPsM0jDGwAhpY2EENLG7BnlDZKMgUWU2ljF1vbAHJe2pCdxn21tHEis50AUJuIaKVtmIfS9vYJBgB5S9u7MgXoQ2lbVrB9jIETmXICXdyR07UDq6e0g6untFOC8d4py5QNDGzKwBYG5BjtYKM7csJ2cMJ2JNQ7gFylnaMd/EKuXVnZLvAXpV2ZaxdQvrS7u4MBoASlXUDa0m5NpmN39ySAeyUY3D05e3vb0Pe9HRmAbaG0J+vcA3wv7ckK9up750GpKiuolmD0qxKO6haUqcJCKUkqX0KaXaptysAmVFPb3cBAtYjfY/zC2NZqiBc14DBKdVl/vQzl68g2lOqbkK0uEasOfFSpLgGsI2LVj/cwANxYqX6MI4g0rVTHTtdPANgjWfNRDSo7loETRJUTiSonJaj5RPbpZBNTNmUKIvuJHK6TrR387uG3hl+ZjPhygvCdSPhOgCUpnSBnUDqp1fELPT+RGH1Sx/S6TK9julx5J8B7lU6OZMoRNnMsU7CbJyeyghOs4ERmAyasXITNr1wEtC0XcZWVi7ChlUvFMn538AuDWS6VNvC7hd89GXUEX3y1vlTexu8ufmWJskw+wQBsXuXSRl0GoMUSTnm5tIkpsD+US7vYCOBnWU5vuV6GhPqGDGxhYFsGjjHbcU0GMAU2pnL9BGqRk1g+Km/hdwe/ANGRrOtoG6OAiy0f7coo2HDKR1UZqMrArgxA9Uc1mVLDlJpMqWFKXabUMaUuU+qYciRTjjDlSKbAvlo+Bn53o1jcwu82fJE/3yhuYNRGDb91+G7KhD3MtXckA1i8KlOA+m5I6rtRBN56o4TLawNnYaMkay4BKd8oYQc3SjUoUzqRASyziWO2eQTUdVMi/t4JMPVVubtUN2GrqW4eyQBQwuqWTNkGQlbdLslA6QS+sBVVt8s1+G7KBBAdqts7JxjYg/KSRFZRaqjulIAoVXew/E65jAFYudWd3RoGYCKqOzgR1Z3aDnxR3qnuFkv43ZCBLfyqlCp+6xgol+Erwdnd2YDvLrazW8PAiQyc7OC3hl+Acw/XQXUP8Km6hzhU3QN2orq3LQM7MAJ7u5sYgM2+uleHfuwdy3TA1+re8bYMYMqJrPMEOiXpZRXZ92pVAljdLON3UwYAmqpsrQpyY7W6U8XvMUYBjaxWgVGqVmEKq9UaZqrtYTLwHNUqbJPVah3rPcIxrR5hgoSyeow1ylGoAs2o1nCvq9YAR6u14p4MAMi1kkwpYQqSw2qtLAO7MoDZ5GjVYIOt1rZwHmrAe1RrOzLlGMCRK/5oB1iqIznFRzuwoo526jUMwHScyI3ppAp70Ul1RwaO9s6Dk5pMQUBPpKxwUivt4PcIvycYBSN8IsWHk9oGZt7YlYE6fo/hu1nEbwm/G/jdxO82Zq1hwhE2gjzuyRFS/ZMjYB1OjpDRPjkCTunk6ESmIPzHEuRj2D5PjnewtmOgxycnZRjOkxPgm09ONmVgCwPbmA13hBO5I5ycYNUnKByfnACmnZwcyZQjTDmSKUf19fDFZH5mumf59fOgLNmP8k67dx50uxRq73Z7EJDidLeNgY4M9CDQo0WK3x4oGYpSh7GNCTjzPQo7cbnYlRtJrwiVbXSweDuqpVwsds+DjTIIURtl4Ks3ihtd/BbPg/JmpxwV6+JAdbuwS3W7O1QGEOBdCt+9EkbtWbN9oIixXQrbWJeWIJ2WMWGjjAkbGIXz1aVbuAtCk+UibkZbllXAP234toFyb3UAui3sSHmrR8+DYtHawy/FXa4cbnLdThH2pW05wKjnKLeBHJXbgJflNu6q7VIbv9BoG/QM5TZw6eUNALa8gakbuJtudGDUNihuuRu9DfzuygD0ooPpe7BnlPc2cC+WW3mp1wsnPr+enLIT1AttSCURKoH2pBIIhZKtGqqKUJ+1BWSiuIXyyRaw1MUtQLqiJPPFbVS2bG/WMLAl9UEgsmwjM1LcRnXGtlRkbKMebPsYyx/v4lcqhI6PUAmEqh6kFMUd1DPtVLGWHZSednCfL+7A3l7cLW1Llc8efjFlt4wp5S0Z2MHvngxAA7tSv7ELfFhx9wjlul0UanePpbqmCBXsbUh1zQZqYjZQqt3bRN3OltT9AFNX3NtGRc42FtmVCahE2KthwZosiCO3h2O2V0dtEFKy4l5dqngw4RirOt5AdU5J6nYwgDBUJQy4ExSrKARWQbwqVuW4V4HjLFa3UH2Co1/dkb9RzyNhq+5KFQxWKGGr4nxW6zsygFoZCVwVgasiWFUES+4AxRqChUSyWJNgIU0s1hCsGoJVk2DVNqWuZRu/mIxDVtuWqhb8LSGpIZbVpO60hsNUkyq/GkJSw2GSu0OxjjDUZet1bL2OFLlYx+brKHQW65tSTbKFXwCijs3Xsfm6HJU6jkodR6UuYanjjNUlLHWEpS5hqdelSgRrPMIacYTqOEJHcoSOELojCd0RQnckoTtC6I4kdEcI3REOyxHCdYRwHeGwHCFYR3XUntSlkuQIq8JmJRNZPEIVwZFUxeCqON5AtcaGVFmg8uV4E6f3GLUvx6hCOa7JdNTzHKMi5RhH4BjZ1uIx9vOktItfyHSCGsOTDdQ/bMiYY/yikkKqRE9Qj3KC6uoT1Kac7KLy4wR1JydSd4KCTfGkjhqHcg0VBxuoXthCuaSIslJxS+oitlEXsV2WgU387sgAah6w5VIR5dDirsyGKoMiSu/FPanxADaztLF1JIX8ExS0y1LehpI7GzKwgYGtMn5Rjt7B3zv4G0ettIOy1g4wTKXdIsK/W8TA7hZ+oWO7VejEnpQO98ooGG/IAPD0pSoyuaVqCQNbKPiiwrUkebtSFTjJUhXVCVUpUldRFpQMTqkGO0yphhNdwhVYqm0jMDUEtiZFSlxkpRpKCKVaFcCsIfdZqp2gzFzGCuowsaW6FCbrR2X8ypRjKTOjGC2lyDpKkXVUYdRBWVuSok/pCOXno5OaDKBIKUWfIuiuy/VjlANR2C6jmq98dCxFIxQ9isiObRSlUITcQXEP5YdtKSzAZFS3dzZlACWHPRQm9lDWgEaq2ydSBkAWH9nV3SJy8GUpFZSRqQcVaXV3F7PitFZ36yguSA54FyvZK0s+vYy8Py6j6h6gRVVqPKpI7qt7qJCs7iFXvYcyTLUs2e0N5L2Bc6xWN+v4RT57RzLd2AVUk1erNcltgwqgWkWhplpDKaCGgkEN+fo68vXHsjSKD9UTbO8EGW7cxKo1ZN9rIPhXa8eS6QWeUQpzJ8egTD85LstA+STiDNmZuV4s7OG5jtzbtzEgD4G2US2/I9WVO7hX7G3LMwzkJqRQU0ShpliVKVVMqcoUhLdYkyk1TJGoWKxhSl2m1DGlLlPqmHIkU1BOLx7JlCNMOd6S2lM8xzqWR1fHIAcUpWKmeFJGYiI1aMjZlaSWo4T4VdqRWpKdYwjsIktTQpaitIc0u4T7fmlProw9qLpUlUuruosBWVsVa6tJnU8NKVhNrlpU9pfqEoI6QlCX2eqQrSqP1arbAGh1V0rVu9BOVQo51b0iBmTKnkw5kQGYkmoVa6tWsbaaTKlByskJ9ufkpLQXzbF9ZvKzPDsjVvjDOVPO+vrHk5GuNSLXVdYZ0ZqaYZDOmanph5WGlmdnea15WyIb0/PzNeN2Y5qK00hwZmoNLW9BlCwBP8/PC63mbZFsl6ZhonGokT71j9jQsl18EuVxpkclDW71avngLK8Zxvm5lufTuBzYLC4sVtHycw3o3MhD0vn5WmZqvmTkNeO2CG9hT4WW70Cz2pSMzkw9PUbYi7Xz80LzlbiTTc0gzplBBmfmun5YmbiFwhX1hn3q8jb1LD9w+4XRuC3mEqhX6PgyvuN0Bp0itYrtcr9s7fU7XYweb3q7W9TZ7Q2Cra7rWcJlnj8YU+5Tzx0GfmA5wvesK+pQj9suDzzL7VABrfw//lMSAKxtiwcbmxd7G9vtPpcx7c5lqb1nbXU9utnBmGG/bQ1K1sbg4tLrRjH2nnXTfz7avghjqDfatLa6m5aHMZPLTrnrbFhblBYHs81Tmad3c813u9t7wVV/GFW80ym2227RCiM6o+fb/ZFVsqJqLWdjcE3LlrCG1LM7lksneGNHXFLPux7Jm0Q25YJbbvfKvuwwjw0t18cK7FJ7u12ytq0yxfBlx5vs7HRLk03ZgNNvty2/ZHV3o55ZG9Zmr7QdQWRZl52d7lUvgrBo7Vk3HTl+7vOrnR4vFukwhq9nDW3nWrQt13Itj46CtmN3RAcmxvMop5bXGYgOY5e22+8MLNeljuyLw/o29+0OF2Nq+QPqhak4d5vtnZ5vFTtymrafP9/b3W5vTGTqbtF6TjtdT41be3O8XdzqUTWDPft5ubzb2RpwDA7KbY/2N+iVHJML5/nmbtEte/0wuF0K9sY77cSIbNALPwpawx1rdxAFrwc7/VE0XO1Bydop0TDYsXa6N10WBu1N2qGTURicbHa6RUs25F11SrRoDTeosF1Ab3SkYTnCsXs0Rm2J7eHIjJnjU49fOsFYYVmncznuTKgve047l1ddv7OnxmHU8S72OhsqdNMpd/ZouRMNysVeZ1eGfPv55t7k+bXEiuuexzbt7R3Zjet+2y5bw+FERPf6bOYKZ+T0bNdyO7blCN4ZMMfy+MAecVgO3O4MqBeuww1abO8Ud+QgbW61va3OtZzHzW7buyx2aDip3cngkocBTgddOVa710WruL0hB73fufQ2esUIQfescTsKWO1yO1rG/WC3HE3UyCn3okn0N7oDle2y1A42FTFw++3OlnXTDQN0j6qlyvrtUW+3J6F+3rm0SqNhR1gdfI7Gcn0u2pbXcazrjuV1RduxOpc9z+5a1wIdy7YDoGCi7dndPuU+c6noWM6V7V461HZhYH3P6vjM46Lj0a7tBy6MMXX7tksp3MUUFJBk5NmcctFjrOtSf8y8S2G7V5T7eD9QLqsB86nDhWMN28zrD2zXFkPmM69z3XEoF8yxri2377FgJEYD5kuPwddi5FjX3EckFCOPdeU0c/jdB/pjX1Hh0W4wbHvUcSwxth3HtoYD25GDUiq1N0NUK3Uuy7Qk5670/Pm4rKauzBEhJVK0uV8sSsq40eWbmxsKWQZxns1yp9zdsyK8CeO3tp6PN8sy/3bPu9lVv3eLFisrmrB36flFNXudzmW5vaF2nhtve29T/u6WrM6Fiqe9q+tdtcx7HX+zvHcpf1883ymrenrOeGNLLfZ+eTLZVMunT2OY+5cbll+iyUUmf0/83dKmjLeH3lZpw5X0Z3tc2lN9v7jySrt7ckwuR964pMbzcuRdb+10kzQn/O1Pyopw2ZPdvZJcCO718+uyIunMD3b2tmXZUb/Ni13Zx+d7F9dthffe4PnV3raEwWNXwe6urJPvxfBzyre2NmUef7vY3lL1XwXPd7fVXI83Pb5ZlPgwGTzfKqv4m55X2t5sixff/Z0X3/2rF9/9Ny/+6DdffPe3Xnz32/jj2y/+6N0X3/3eiz/6zcSKEtbI8hReW5yzjm35FJYZv6R+23Ic0XZHI8uz2xYXbUYHsEqoJzrWyPYtB5cYc3ng+LB65KrCxdkJbG67iMM08NiVzXGpTXzPQs+Foge3cuRCGFDL8Qcdy6PCHg5Z23Zs6grb7Qbc92ATHlqu1Uen/mJo+zxo23xgC5dO/K7t0Y4vEhs2mDzSjlpgQZe6PpBOj1oOhYVHRbh5Wq7wIAq2AB9oq0V7PYd6wrd8C9cyFz7tDFzmsP61CFwbSa5/La4cy0J/t664Ys4lH1t9KtFso+h5O9fy52gS7F6qRfW8VFJrrd/27GG4vCZbEmO2/JvhVrS4+Fj+3CtaG5YsttdtP1dEdo/6W+UAf3bKbW9H5u30N9uXdrj0eNEPfwblaLfeVuuxZz/nuzz8ebMnf9qsaO3YaiEE/ob8OZz4z0sSXrbRGW9KrB5xfq1geL7ToQpHAzcYbcrOj/vtgerxuN92VIbrPWvDeg7YR10/8KiwnJ7lsSFlwnLkPncjrCEQ8a41FJZrOdfIuVjPAwvoIUX6Tx3mWkDxGdw06YvOwLO5P7S4gEcAAhemSOIF864F7QbSe5agzwN7hEjUs2xvbLtdLuLNtWd7as/oAT/l2gEXPY+5fpcxT/QCz7UR5j5zuniPSwxsbiE6igEb0i4dMR9/9RnrcvzFqcuB8eC+7Qc+/lIMx2XALuXu4Fhux8J9y7Hcrseu4Jfdo9y/dqgA18wU19YQNn7LcbgYUqfNAs+lYmh3PMZZzxcjy7U4c+2OGFmcAz7CSlDMo/BsMB3uOrbgA9iVbMcSnDmB3HtwWbQt91L+6lneUHCfdS4HzBnGgoC4suRA8hA7bfmrU7Ku+iGldyRtpCVLUb3eoE0lLl2ULNoLfw0lJjklqxNxBd4kZn/x16hkdeSv5xNLIZHfuRxL+n41eB5ciWvWZW0L6MGHX/3wax98/8Nf/+DbH3zjw1/98Nc/+Iaw2kF3YLVtYdlej3kdQDlHkgHL95nn0uuQpUDegvYY8+FH37JdiOFUEkEW+PbzgIq2Z3Up7zDR9pjVHQPn4QWcAyvQlibD8AMoH+dAJKnPxq7ogLiCBNLyBwwZd3vk2ZZri47tX1PL56LjUMvFPI7tYlsdh/kDjGHDIc4P+FgPAFXgIf4r6l3DD2b7PhVdOmQdz/JF17aGDFC7a3Ok8vIHoFWXjV2HWd2I4xGAHpzD2vBtbjmWL+gEuOA+FT1Ku/Dug+jZ0Jp/DStk2LUcIN1M7g24PmzqCUD5a2p5ou9ZcoNAfgfWbj+wvK5tuWLQ7XWwFwPqcHg2QAyYA1bosFY4bifCdnu2a/u2sEe2Z7l9S9jct9x24IiL0ZB5fcsV+NAGDIsTuN027VzC0vBs5vtiaHEQDW0x7FzaLqfXYkiHzIPlPaRe53LIu2LIPL9v9algbbh1ST0BO6ALEzcaWN7Q6lyLkd2Btc7FyAmGbWhLbTDXwJxJKuFRx8alzC3Lg6UruDXkHSdoC047gQcjpq51csEHbDSCeviAjX17SAWsWrnmWM8fw9bH/Y6kCDwYjRzYynwYTCh1ha+TeF1xJQkncv82utkYA7GxuzRck/KHy1TMqGTZ4QIaimt2yQbW0BIffOPDX/ngTz74iw+/9sEfffjrH3xLWG3Wt7pMWB2rS4fXwupblwPLFZZjt622JZA02V1h+QOH+pawlHxiBV277VBhBXzEuC+sKxgUC+XUIZ2IthUMrICLNnV9BxYb5X47uBZtKanCMgKZVLSDvuX7dshXiI7lWVcWrByPwtSEohnQMRjZzsB2fQuXj9WFeCdoD2lXdJjj0D4wJQ7rI3MyBOoKf0eWey3/ehRlAavjhxKz6DCQpiyBK8aDfMEIKF2HBR7IAh3P7lxSX3S8AGUD5Cs4rK0+wttF5Q8XdDJinHZFqE4AYgrw94BCMVf0qOdZno1/qcfU/kNFDzgaty96to+ko4dYDn8ZbG7wd4z1MI/jEvRY33K56AUXuAP1LccBetAHuNy+6LOu1e1ei77HOhgf2L7lcTGwhu3A6wtY4yyAPcy3OgNciihHDRi+WyNQvKGwMn1wlSEG18Ct2cLmELTFBR1Tx7sWF4Frj6gnLuE+OHXFJRtaAI9jdSx7YsGeNoH+OBYHCIVj+R5rU+HQjkO9jnDsoe3TrnBst8McV+13XAxBCKaecKlcJS71e449EaE85tKA+5YnYPassSWY03Wtq2uBJMLuCObZfZiP0cAGd1piZDMkeCPm2L5NkR/0mSc82rFHlAuPur7lwN8rm47h7wQwE9c0CNhKDQRs4tDuIrs4trwbwTs2RSIwsC/5wBLcdvswbty3RvIv84DYwJpmni944PVhPvg19+mQC58CzboU/oCiSgv/elT4iG5c+HavB3jrMz6w2xaQBCCZYmw5Q8vzxdhy+30WiDFeXYt0PGJM29z2qRjTrsw/YHw8YGKM7mc5/EWSN0F6ey2uraE1CVxxzQI/aFNxE8DWMBDv//D97/3k3fd//P6P3v/B+z8UH3zjg29/8GdIPb7xwbclGfnmB9+BXffDX5XBP/zgjz/4xgdf/+D7MvgXH/4K7MQf/qr48McffON//9YHfyK3Z/HhVz74xgf/8cOvQfqHXxEvvvtrL777TRRU3kPp5Y9efPfHwmpbnj8QVrsN5N1qt69sKqweMjXA5HSuYWdvBxz++NQBejWyroXlcAs3e+6zobCG1g3QKvd5ACTNs4YdJqzAHzBPtK1r6rmiTa3AB/LkObYr2gPL823RhsVwiX+YC6TqEiWfIfUsRZhEx+K2y0QH9QZIpZBIAVMrOoMAlXK213FgN7fsIZe7e0d0WK9HkTxxCiG3y7giOkqKUqRGgIzk9uEPD1zRBTkG9v++RylSISA+FLcCJQt1GTC+ohu4DhuJLlTpi27gtS1XUNwGBXWp178WSiCiVyj+0QnscEIpGnuO3bkEvtdvMwfJSzASfcvrUlf0KfP6VPTtKyQ1DmtbDrAAfYfCvu/bXIl0YkC9IVARezRgIzFgnUt6LZTqZBD0AWPtoTW0fMkao5IusH1xYcF+Jy4YEquLgPYZF5dW0AMSY4PshX8cKi4ZH0jeuWMDufFhOhxrfA2RtOdZl8KRcIKGCCJhrF3hBJPAuxZDq+vZXTG0bOCChtYlDUaKCImh5QNKoUBKgSJZfADswxByMt5hYzEMOA2GQunMXavPri3hWn4ASnWbc8uVf65Rxc5c4bKxF9wI1uvZHaq0RILhGymCeRYgCvOQ/2I+Dy4tMerZN9STOiQgZ9fcZsifUF88D2ibdoRndaB/SNKAQbE5BdF2ZNlA35DsSLImPAYKQ+GxPqx97zq4vA4Et3oUeBXrEqDmlst6NqoaGWocA4cKpV5GvgZCDuAZH7DgwhacdTrUE6g6cAT3PWqB+BB0bSY5mWvBg5vg0hYciZTg111gu33LHlFb+BZrW0xIX9jCB66cCZ+6rg3Ej7pdS/gXQ2syEf6l/MOumW8psQSk8g4biivbugCuyMbN/Mp2HAtCXt92hVI9XDFcR1fsGujxVUAdxoGM+pJadqyhmNgDIBDXVge23muQ8SfixhqNGBfv/+f3f/yTL7//w/f/+P3vifd//P5fvv+j97/3/l+8/5dAE7/54dc++MEH3/zw1yHw/Q++8eFXP/zKh18Tik5+/4M/UsTxw69+8MMPvv/BNyHwVZn1g2+LD7+MzNifIw398KtIMP8ExZcX733nxXs/fvHe9168996L97774j/8pqSTfxwrd/7oa+Kjr/zWR+/+wUdf+cpH7/75R1/5xkdf+ROBWk5hdQPHFxb1XThH6IFIPUTKORoBy+bBxm/hRFkBYJd1A9PbtuxuAAQR1K3I3AEHzKS6VbQZxjPeGUiJB84gGDJqXWTTOBA+ao1EB8QOYNCA3AE1EeiDRXSYhUcVXWSrUDy6HnqB6FrtwBNdZIeAzAGT5fiW6HqgFO0GbcsWFLcD2qWXlkBXXoKOYOH2LNsfiB6FCQOWygFGCX4zLxgi9WKibwEp6ts9H75X8BtfmgD6xUQfawOq6oq+Z4+okMx4P+h0bGCfulSK9GLAACkHzOMQE8D32vJ9YV9aLhO2Z/OBuKD+jS8uGXVccXnNfCBJQ6RIMD4O7cMJBJ0EXDi241wD+9OlAjiVa+EwYOwc1oFU5vsUv0yAZIKqsD4TQ9q1LTG0rSGonWE5DRlsjcOA2x1QjAVcuPYlEBzbvYADvksbvmP/SoCGwhKMW0BdLM/m8PXl91qgoxJJbsTIvrmxBN7UFSPc/EaeNbGFZG1HHsgxI48NGVAi7gvgTZjw6MBqSzIEigc2ALJzyYXHupQJL+i3gdg4zBUc9iHBYf8BxYQ3EhzcmAJlAdbp8npEBXdQbEJmhw+BEHM4AxFSicglU+VbXQtZLYqU51pI5QkPoF0+tjlHpZ4nfFDywJc5wh+CCOszYHt9dnnNhM+YwwVwbBADO6sP7D8yXVT4XsB94Qcu1BDwQSACZE2vaB+JDfTuinUvLVAKXjGgLcCQIc0b0zaSGbvNBPCvHL5OV0yoxybi2howJv763/z1t/76m3/9P/z174kPvvH//mdIR35VfPDND76BHNX3xQff/N9/jETj2+KDPwHJTWb4E6X1+Jb48Nc+/CoW+7UE5fgPv/HivffEi/d+8OK9d1+899sv3vv9F+99U7z4+h+++PqPX3z93Rdf/+MXX/+xePG977z43o9ffO97L7733ovv/6Z48affefGnP37xp9978afvvfjTd8XH/9M//fmv/bOP3/vGz7/6P378w98WFsyWhZJf1+oIC2QZ67ILAqNzLVD0szyrLSwPhElvZAnL86mweNcSFrctpDtIdkTbauO5ZlfgcLbbV5ZoU5AJAafa9qUkQKDwgw8oYVjPEm0GJRi7FO3g5kZ0rB6e9oDoOBwhewYfUMJw4NaALMH+3WlDArB8nYHlI3mCzzVKkCAOOvAZic41A5IE9Mfyqeh2nEukSkCUJMclujZF5coAWC4uuldWX1DPtUUPdpge0JMeymigUOuBFNqzLfh0Qe5zhqhyRMkPuC0Pz5uAYnVBA0lFL3C7SLREv23foGQn+n1KkYSJ/rA9QF0kfHrIfYl+AFxZ4AViAHs/Ct6gdQGNywh4MSoGff8KhTwx4O2OsDvwoRQU7902at9BB8OE7VuBgGeoxQWlI2DEuLgYeVxcdru2uLS7XFzaY1tcjoZ9cemBBAcz6NA+E06/7QvH7spDVuHADDr2kAGVQ26MIo0TDiguHQZBv2sBRwb6TrsnhhS4LzqkYkjdQAzxcM0G5T9rA73rWnjWJkDFIFDsc0Fmg/MAAfwAkD5grhwm2Ii6YgTzAa/RiVGnMwbuCQRC0OoAQHAkB4oeLkZs4IgR81yBugw4n4NPTzwHttyjVhfImo/CIp4doGJVeIwNhcevRsILBh7yUwKFdG55juDWFfyaMME7zAdWCj70UnA6uQayNwbh0UIVEXzGgtvOJQiSIE36VPBL2xXc7fQEZ4NAcAb0kLnXQPyQ9nkgVfaEbzmXwgf67AMLBkcXwqdXlvBtCz4jIG1jFxgoIGBtCmolC9gj+FzhByiYT+HDULQEkuWJsU1dMbYvbRAbKRIwMWkD4WJ9S9xYniVugADcwNbx/p++/2c/eff9H4iffBkYpJ+8K0kYUq1vgnL2w6+I//1LYcy3P/zah7/6wZ+ID/70g29++JUPviU++AukYO+KD/4CWCTJEX3tw3c/+IsUNyRefP07SMC+9+LrUeAbMvDN77z45o9ffPN7L775nnjx7e+8+PaPX3z7ey++/Z548YffefGHP37xh9978YfviRd//J0Xf/zjF3/8vRd//J548Z/+8sV/evfFf/qdF3/+z8VH7/77j77yP3/07o+Bk/ro3e999JX/jN//KD56948++sq3Pnr36x995Z+Kj77ybz96972PvvInH33la+JnP/qVn/3o1z75vR9+8j//ivj57/7ob7/56z/73a/97Bv/Qvz86//mk9//zz//tX/2yW/8U2FZFkixwmrDsTYTVpcLi/aF1XOEZfcFHoAwBzg0IJjCGnNhTUCd5ol2uyPabV+0O33R7oDICtSxK9o2E0Ag2kMu2sOxaLMh0EXRBk02mwhUt90MRMdqA4EE+giUUXSA/LUt0Wm7otPmokOZ6PQs0ekBjzYUnZElOh4XXasrYI/sgk6ZXonuwBFd+1p03ZHosr7oMl90/SvRvfIEIDftMEG7gaD8uaBwiGK5oNYSPZAqGRM9NhE9zxE9H8RLV/SubeDQRN8CAfNa9LsgYVoCpckhcGYTIG+iz0aiz3zRZ1di0GZiYF+JwSUcucC/sbDbQwFCld0JhN0bCtvtCFjksMaBeNjcF7Z/JS46bXFhM3HhOOJiOBIX7oW4YL64YNfisjcQwCld2kNxOXIFWCuBVtyxxsLpcuE4HeE4I+EwB0wzgHABRyaG1kgM2xbwZUCzxNB24KRSDJ22GMKRzRBIFgiRQL+GwK0JVEb7rhj6nnCttnDblnApcG8+0DPh9hwBvJ47uATOTaChljcWru8L97ojgBbCggN6wFxHMMZA/SWY7wt2NRCgBxlRX4wGXTGy4Z8rRm4HKBqcjIpR0BajcQc02kDRhGc7wgP+zQZC5gpvDHRrJLjFBW/bgre54B1L8A5ouLngdAw0TPCeBzQK+DXBGZAlS3AgfH5H+FYbSJLwO7bwu5cC5Gl/0BX+BQhyI+F7V8IPbOFfcRG0uQhcJgLmiAANXHxxZYM0BzLcCFRZQH/EeAgkaCzGfkeM/Z6Y2K6YTCZicn0D6itxHbjixh6J97///p+9/yPQYP34/f8s/l+/+/733v9zEON++P6fojT3/l+K93/8ky+9/yfiJ1/6yVd+8mXxky//5Evvf1/85Cvv//AnXxL/2/f+tz/43/5M/PU3/vq3/vrfSrL0TfHhux/8+QffFyDdffhV8eFXP/izD74vXrz3Wy/+wz998d53xYv3vvniP/zai/f+nXjxg1958YPvvPjBH4gXf/ErL37w2y9+8O/Fix/+hxc//OMXP/yG+Ojdv/joK1/76N1/Lz5690dAXoC2fPmvPnr3X3705W+Ln/7gO5/861/72V/+C/HTH/7Oz7/1hz//0q+Lj9/71k9/8KNPfuvfiE++9i9+9rXv//Qvf1988q+/9/Gv//7H//YPxCd//geQ71/9gfjbP/rhx//uhz/94e+In/8v//PHX/vWJ3/2dQH8WVdYoDgTVl9YtrAcAcIgE3Aa6wmLC8sXViCssbAmwroRbUu026LdFW0q2j3R7ov2QLRt0b4Q7aFou6INJ2CizQUQpCvRHgtg4oANE52O6HRFpyc6cDQrOrZA0U90hqLjChAJPdEJROdKdMaiMxGda9G5EV0quheieym6Q9FlonsjaEdQKijwVIJyQX1BA9GzRe9C9C5Fbyh6TPQ80bdEvy36XQG8T0/0+6I/EH1b9B3RH4q+K/oj0X8u+p7oc9H3RT8Q/bHoX4vBpRgMxcAVA08MfDEIhN0VNhW2I2ygHsJmwn4ubE/YXIBOioqLobhg4mIkLqm47ItLIBbiciguXXE5EpeeuByLy2txeSMcSzhtAdTCFs6lcDwBxkK+cALhXAkHFE9i2BFDIBdi2BfDgRheiqEjhkMxBPWSGI7E8LkYemLIxdAXw0AMr8RwLIYTMbwWwxvhWsIFaiHcnnD7wrWF6wiXCXckXE+4gXBvBBuKkSVGVIx6YtQXo4EYAcMjRkMxAvsIAapxX4zGYnQtnlvCo8JjAnRDgfDGgluCtwXvCN4VnAoO51eC24JfCH4puCP4UHBXcCa4JzgX3Bc8EPxK8Ing14LfCKABXeH3hN8X/kD4F8K/FD4IX8IHVkT4nvB94V8Jfyz8GxFYIuiL4FIEXATXIrgRwJh0xBUVV0AKxJUrrgIx7okxF9dUXPvixhI3Q3EzFv/rn/2vPxL/65/9P78KS/9PxPt/9pP/Hpb2r4j/4yv/4//xlX8l/s/f+PP/81f+HP78Xz/6U/F/vfuf/69f+b74//7W7/1//uw74qN3/+Sjr/y++OkP/vXPfuufwQr86V/9Afz5+Hf/Ev/83m+In/7nL//0B78jfvqXv/vx978vfvpXf/DJl/9IfPzl//DxD/9cfPzV//jxb35dfPzVP/z4n/8A/vzsd39dfPzPv/fJX/wY/vzsy/+L+Pi3fvXjf/ff458f/jb8+eT3f02JVeLj3/vmz374V+Lj977xyW/8lfj4m9//6V/8D+Lj7/6nn//uj8THf/FXP/3B74mPf/zdj3/zd8QnX/3ax//6V8Qn//Q3Pvm9H4pPfuN3//bL/5P45F/91ce//gf454e/B2Th57/9I/HJb/+nj7/3O+KT3/3Bz77/m0JxJp/84AeffO2fi09+/Cc//9dfEj979y9+9nu/IX72jR99/OvfFD/7y3/x8b/9Ev754W/Dn5/9x3+Df370e+Jvv/yvfv6l3xF/+70//+Q7/0z87Z9+52f/9Fvi51/6nb/942+Jn//ur378u/9e/Px/+Xcf/8svi5+/9z98/N9/FbmeH/yRsU66ywzCVz/34B/kHr6SXzcP3269cyum/10BbcR76UJvR4UGZ6HpufYAMg7P7vKoD75OlWsz9UQDh1fKMh3/p32EynfXnNctz68PLE/6yeyeEelasuM/cboyrndG2H3c7lMv7Xg//SKd5XHpVpAueA/WnHFUFrrdnYcyfCc2DSdpmeplWPUyzAQHuX9G6ubtUD67WNFsjdgVzdGIU9F8jfgVjWmEVbSKNiUXZpGcmkVSMyf7Fwfj/fA5osgz3YURelo/la6gV4oVPGP4AnOx39VuF3RH+gNjv+1R6xK9L6+UZKbXLNt5wvS4tkLJIOmcZZnzNOzyTEUbM8lHbDbHpsxR9cFx7UzalkyTdvwwmDPp28n0z1+PBnS2hp1kjlTjKVfRGS+CnxrTi3w+xKKONfIDT6LDq71nlmN3q273DcqpD88jxq+BZA4uN261IXoMPGzTvu3GGKeXjIq95lPu69zI5WZTjWm64nBC4OETrSJrVMWZcaifmmVSUzikR498RD9up6RmkNuBxV/DNxykC0XwhWsYRsWDzjxhX2AJAPBNe92o1OHJbBPel9fj5k7NckVbAyDY4am5UdH+Yfh7c1lls32KUQeGaU12Kq6PY33RGAmxpOpFNR+xZOVChDUv63LUIvbz/o0qRObG7egsUcXWS1SRwPfUkOxUtEL4e7sSVy/EUvTMrF2tFqi/EI6KamhpZZWZXr18y3OTUXjJZvVTc+t+aB41GOL4PaGdXYbzL/6WDQJvTUdQ3NrdSeVimqhjyXTfKpIdZ14KFzxxVFtLdid2qC39jNbW7O6EXBj7643CWvPBuhwqfF4JH2pMOCNVT63V1maowGGYbceo2Pt6BFmXUY4d+Lx1RRGwJ0436ZxUPmqurWnG2oiNdAMeHya2ydZ8dgoWMkBRY9+9QQja1NDH8fPtMIpflC8Oq720wkjC63jFJvJZrorsKp40VcZTw5guGefpNLW3T/U36wbpxwwKTNySx5zt7sSUacCjQPAQv5WCepl5ZgjN1dW5uChjNHdhrihiSq7PMt90C72o4qhbPgO+KdyP5tLWbI6/Mx8EW9VZLidf21bZnnq2dASsh47YIz/WR4zyLzAf5jv0uXvm4aIly/JW/VNqcf/MpV9kXlcSL3yhP9Xuq6N4T1R1oeGX7fLXAse3Rw49Yj6kJtzzznUyqiXDXW6CTQ3H/iRwnNh9cJ9OQpcZErJRMi7C1JA3Va+Qskx4smDPgInvz7ysMw9RxALoiUWFr/TKp1LDKIgoNuO389fWNONRobRksKKpzgJM9U5NqsqIEBmwiHM5Bq1lLWUNziJ4x7NHfkVbNU07l9Ou2smILJjuQrIF6LvKhWC53B3DB+hZKMnFGo2PZhj3BGQeg5dAswqora/eCVGI/wOLh7VWez71Mmc/E9Ks2s31t0MXyI3CWl7+LBb2mq/I14kzO5yc3SUVrGfDsBj6lGRYabx9fs6brxw2tLwLV4E1Iws1RwqKBrSJn0P5PV/7lFF6Bf++Yhyerx8+yOgEvJ14dWbqN/W0/Lt++Ln0ZeZ87h9+bv0fmP9dSzfEwwevnJ83zs+bt9PD1QpZ23/7b37vXzZfuTMz5DIONYMkmmrgtWWi6RqB+8PZ4w/3qDemFf1wdWk6zLKhH66ed/Pn6/A4sow5NKKb4LPXqPWyQTRDI5qQIGj6+rpxKEGRr1EsLrqdXXQuX6lowKCsaSShFoCuFLS8jffCNVkR/NMPK3LK8sahDGr5m3pImjXjUGuqR+Q1g2h9G7QL7Vndhbxurhmkdbb8FRLq3fv5qr+DV0MkK6M0Fldn8ECIWk6yK2b77O/vixpSS/LU+1Q6klAnkhyBpDYk7GHLXPBUAhmbi99CIJM5HUwddCoth7FRqzT7sIRtwnZGTk0Gb5jXTNbYbJIHJmtsNckzkzX2muSpyeTmQW7MB0I8I2/GypSnhRI+V756fRZurrpNTg31DLIG3IDtBhTff34KjxdLQfPNxRlucrmLGVyQm8Gb2bWunx/Os/fh+8Z2/AwBPCuOQ/55iz9125ZjuR3arTuM227/dcujrm4bxqLS+9ThFEfsLTOu51X3CrocbQJPHNn9/bceFUqzsLxlyGdKKTUb0QskJHrHS2uu9Wy3q+vzzh1WV09zOdzGT6NtnBtTA4eeUjWT1LSjVEqN/UTjNgXh7DQZfJo3bYrwWNQ8PQwfFarUDrXxeKxVNN/pasSh5urq6X49lkn+UVommWTLJE9J8g2YikUhXLHJ7HstFYeSzHdLKqurNySxGioBmV8IlRbJXANSDCIXuBL2FduqM9NeoxPaAa5i31DrIZbD6tPsBb0cZ+Y5SGJnvoIBU6UZgPu2wUxN1xCjViC2GcU2ZCy+4DtFjlGh/Gppn5narTYNqUhgFknLLCZUpYXSfutgvN/K5yUgkxiMlrE/QT1UkM9X4Jedy+mBiU+DD62JHhRKpIiCI44ECH7BHaORgfkzrCFQBa7AL5T2JbEp7jNcFgl2tKIZJH4fxFbyeJBWruv62iHssI3CmtrgXmkU1K/z87Xod97QjHCSk2IL9OiwUKrodt4MGqVm6uFxbDgRa5D1txtvF9YiFqNyDhzReqRusSuFkpEcIClHH89sxa15xzLjs7vfcH2JfXnBs5Kf6tXINAizr7X+gs4BkrAEuC3Fqv6xWSQTs1AidbO4Pz5oKdX9RYzH40h1X59T3VP9ItRjJ/X1X2Cu6kYkg19kaetVJviTkWkjlQlIXTrTXZrzujEdL9Wc6wYJ5jXmFLWCn5PKQL1ulsnEHBsV5ywhG9fN0qxSMaPP3Lh1EspRvW4WZ0vNjgA3buvmcVTocCPR7mGpUswuHo0NN26Pky1md1rNd7pmY6kWUGr/JrjBjguTA7O0WZSY0ooW84SMDSKFml9epkNrhfvVhCQws2IT9S5spaUqLBlTYz+Qu2DdmM48PBsSgMdnZiNeh/FjvCTxaigJ3/FskkmaXkTUAuQP9f/zvHF43kVfU43CeXGzuNY8NA7PdYjcmJ4bh1EsRiRDm1NDqCr0w8peY29nu3neFbuNvd2drY0i/N6G3/Bjq7GHEZvl867YaOzubEGo3Njb3d7a3CiXILTX2MXfxabYbWxvlktNsd3YDqO2Grs7Kq/YbOzJACRsNPa2VZ5yY6fYFDuiZERwgpTWjXt2u01K5el5N28YeoPsN/NSGvrcofFKJIppQjPikSrqjRLIgKVp4RB/4TgIGVmeFg7TYRhLGVlORaqcpalRkGMnio2d3b1mUQWj2K2ZmEhEBIFMM8jl2V2vhH6Ww96U0DRZeqSbbDD9FugvUFxJEHg4uF3Aat1KBTsKGhNzHD+s1Xi7WNgj+59rwrt4GqzYVX0VBJFcbpU1yk0DTnPNUPw41LQKD1lYFVcokRKeO8zE58fhrlIySM1cVSy7vn7eXQcF1GkyvC/7QrmvXudsbDSNXG4mFnTztZR6/pfSpCVIkZZxSFpC4Um+OVqZkPBJ0kp9GvNcremiGYogyHrY7R/XQ8KZLi/pUT1NYP7hLEdyWyJbxSloI1qakUoxNIXPZ3NVrM3VsXFHHdXZOgqttUWAFBbWcnEPSMob0zkwloL26gzL9nasPnn97O73vz87q5ZcVZSbtyqpUj8j0Z5ROTsj8fvQleoZkVtI5eIMlC8uc78Yi8t0Yr569ot4OVySjXTMfQhHimik+b05OkJ5YzZbM9R7zHYr1HokaM4EaA7IGYGSM1Ym+0C3EnQomKVD9UibkWAs60pAA8mnLkRLLqoLQxY5RdIVk63ztfwDRbFq5mnEIexPYtLw+TRpGKdIw2lIGuozXId6E7tSA8IQztJkOjMJuLZjZhEn4vP+0JFiV0i34byRuWcj6j6x+iTAEAiuFIItDAIwZCxT2BAaJxMMHbEOzLHioF47U0Y1EadeM4vkgVkkz8z6/sXBqRrZp1k2NrU0o35k+Zb+NItRf2L1AVj9aRZ3fux2s9M3osIwhPrTLDuaGoV7dlXf9+x24NOMjFuhwc3iLMquBmXdZfl2Mtt8ZjnBbM7dmUYxzxEL2g79xwHzaXcm/15W/sd4lT8zf6mYVeCp+zwzcymrf1giu3I1MY+p01PqkMe+5flPrP5szo3QcMq7DEZHtONYHnroyZjNkpoxhY1Y42yWrfksRxYfzGbbTmWbTd1JpR673awqdmczzWbYm81Qs9zZ7pdDxJdLSn96X5GxJo2tZsxFYPmATHigycN5Dv3/Ah3DuM8KdOGKgfyrUoasmaWNinqp9VCvmWXyTC3xbKORZwa5tbma4NBiRDV+mGq78k/qsTVKzdy4V8UAXqJivSZpSkZHcKVx45YmbF7ubsEFsqpEyLASUJuSmrm5qB/R6Hym6ktlo6I9+uw10aENa6rqdl+H6/Es4EC0v8C6VDeSYy7EW4nfyg4uMgwDtMm2q0oQVkCTRykjsESx5PTWzI3KnRVnEd3U/Amhh0MNwxWN1rIeL5oyU3XXT9TuxZiS0Z2auTVvpbYQ1sOauV1JQ2uGgZ3PArmf0OTcOaQZe8/yEf1Fw3jPgczc+2Yhfag9VMDtVrSHIaB7lfXGI/Od5vqyJrPBrJml4sIpnd9buXGrQMjl9JpZKi0vm9pnYaU81O5dNtpyZ3Fq86WmZTmxX7xxZ7T6C1p3es3cJAnrPnSyOaT1wPOoi1pAq+OjuuOiUJjOm7lmMQ8JKqS/9N50B7msmZtzxsNZTAlYEhekCWaoVrggZeNQv8jfa8eEjb6idSRboKkNATaco7P6kzdfP56pecdY89nT0Si0JsJ2du7dTldyFqqdctG4mzin+KvY3hUQYquyZBOomaXtJVUdyZf6k9XtfrrqErXIJbazoAsh68aN25oZtlvarSyqGLi4BIotR/qYadqrRCbBAM32YmiQBUyPwT2pb8ZohEzjvSG+g1LMz8GtYrlSDcdFb2tmKUbEeYveZdDE5rAoHz8gz8BS1IADwJbOyAODREvENM1na9DTw7Euc8VoHadNwjT4G/KOuZytP1sD/knVD39CqpDLBelEg8wPAXlgXuRLc0cOERum+oEV5PVE7YflSik6ZAy7yciFkTYOnD44uMjl0kNGJ37GAF0Y+2pkJEi6tBd4LWGe+9pnM8+FcTTlHyE03+prMh6GyJR/0FhZ1haOMdrnRqEwUY2CSlShKXmSbblbDXwGrhWot6wD6LyYuWacO4wioZEt5JYBtHgGYyIMoU+YKKTOb8xV1XGlWYnCLh1/Eb3MRSXusHFaYIoTFV9o6YSpvhe4Hcun5q3UoFSKBG5EobJHo25XUw10HItzqSNUk6CUTicuHhaQyDyYTnw11Qo0y7V9+wYVQXGvpcKIJ8rGSqlEJA4r/nKZN7Qc+4Y+9Rxe7/V1mWgkR1xrM+ZQy9VMExCJ9VZUyqH6W4kzJ+cmqxymHKq/lThzehajX8o+WoXSUxv9UplUaHa+s6CIUg8TvyvpgvNYkh6wx3GKHLdEVmMh+mRBM5/tMCuysqDOZfiY1VxmzsMF8ZXFlWfgYTooxKo0A2FmcoJQgQu2TQxsnEwzdYSaPDeNVeCJQ9TIjoQZBqpSVmCzkioUzZZGKivvqKbeWen0+isPo2MDRrSHK4UVTulKl3W4pmxPbDOJZRF8toLv8VnUqL2sUVV+tlF7vtE0jUjj1RMVLZEqzGTM0ovE7/ASQRieJSSJ3yprFE7Tl+iXzBYqpBPEGf5kWoTjgMQZdWZgXjynSpRH3fV9K8DMczVEBxKzpCvruEyaAslNZ7VokIz1cKiIvzraqHDYAuKA73SjwLQykzeqbjWxmpM5hJiJSFaeVTpOFiIZSsKRVS5OFiIZmt41eGky9hnHUA5eYqCWjFA8NFhmwWCoUbizG4lVk9EHTZ7FpiBWGzOf3Zgr8b0vqbHlM+csK7a7wgwG5OJs7L6ufHCjLWrM5jTsJgxYw26arGE3Y7ZxqnMhbqckbP8LCNna62ePX33y6rPj1qtfOHn1C68+eXMWrEWrYHZFhVwmHkvaZgNuUGvoeQ7+4tUUrUmkNWGjGYKVPFO6jU6RKslq7YQl6iOzmMsF+fyUyEOlREZio7kODEIQGgLEsgZc4Km63bpkuWO7QbYmHbbOU9d3sAweCL6zMgy4vzKwruiKP6ArD/sPV3qO1V/h1NeM/WiCQkOz4j6ciYXGgYay5gkFcdvt6y2iemUYRCXbjWLTIK3QtDYPYXUMFl/gm68IKpjqnKzrObfNR/si97nSdnFf5BwffuPPvvxZ3hc5UBBBYGMTv3v7xnrfBusoe3+81mPesdUZ6PrcqNr/oGyayvgJTvhw2lC44GRi7EtTBWVZ0iKBMZ3ko0M8PGIk8Zng8snNshYl0bFhqizEK8E1HT81wqNddHDe8RVN0ltRgkeH7Io+dceW69NunL4A2dP1pLCer3Hm+bqeubfwtT71z/AMVjcKLBmaGjHmMDBXPUjY10ZH/Q0Ga8ZOFkQc6VP/tfigVzdi08Yg3wJGguVLUYU4hbzB8qVmsqJ4pUzMODWr2ketQ1Zh+dK+vB2Hdm8lYz+0jp9mVn8wNm6j/CxfSpWYsthKkS8Y9Mw5ytookkyUEIFvO7wlC+ucZFi5ayqvVI/1qQ+m4/IYJBYo7lUR5lxUjXQeep9qMOeiaiJh4x4VqbyLqgIJa5ZPuEetgTfbx1wuNOMP458mrPBnW0zyFp+2ObgqcJ+2kvzIp20LLiQsbosv2xBh2cywmdHezPCCadEgDJw5FON9Q5KkaO15XDeirYSMzfBySGx1H5acmHaj1UxZyOgcrNuK5MKchMXqBxf79XzemDTqzTUeLVCWx4jEig0NTxUhD8gkshoLFnR6hi9PG+Nr2v6cqIbcHI9NXA7AvgU2K82IIx9hZB8ik0RSrirFnAOPEfITRTQumR0lOUKs0Wru2/M7Z0Amqb4bRGWSG4dHUWvvdwZvYF+eWY6Ou7WZKpafLKKZ08gia75pbCy8X7doy8mAYJ7dSl1ujqQrRLR0VGwzFspahBsG0SRIMYPKDllltYR3OPhc1ypsBeVktwNZH9QP2ZrPqm5nwLzHsmtGha+hI2zU6s6lLuhrAvWTOj27p6e0S0ays2EkCtI8WkJPIrWTDgutARzd+CxlI8VTxlDJzWMq74wOZ/Kr6Mvs6NfvrF1RZZW/NZc/cflo7m7eS6hjXlaPMjWa+1kjarLF0xQP7+z9Pj5rNBfWDUcZc4mo6X9W128Xa8GUYFVJ6SxIpGyopHUP85Q5VPFqG2ul7bWylkyTWJnozLN6MhkI1ROrbz5IxarxMW/RsUVleBaa5lfGZ0Thb+XNemhEV3n9TFrKVi7P4AZPpXU2nasurOyL9aiyX67LyipP46o+X1dV/VIdq/pH9WRVUx05K+6vfOHMfHKGK+KNM3MdxNh/KM7ltfTYjs/mp7Z7GZpiyFlaf/vAajw65831yPfOdKYA8u+pEufr1jl/5VFWEUm9oJjdsyNDdA4kW7qJ+MKZfptE/NUSXtxbLSr/IqtFEtGumLtnxq0y92Pxji0N/3Arr3BJbW+BwFVCM3akXlg9U1s67OQJ4ynFx91VWhuid5GKlsd6cOb0xLb1tkpft8Fkcxpunqul6TSUw29hxkC9Y0WzV2HTadrG8gm7pC5PmLiSgLTImExInVyQU1IjD8gz8pTcmHzNx7zkTVSy415pm0USmDfhdmgfBPt2Pm/ATT8bn9YA3uamYTfxdMaAImBiyUy9JaM7A9vpetQ1YnmEPQIJpVDAWgDsVgewAS4O6mOz1WBNQ9aGFwoH/tBR7PUYo+EmXox0Y7kDub6Ryz2Ai7gPCgWSxLFUhnzeIKv6g0dF5MzoxE9W+4ayN4hLIARvCqE/M/U3zTk0lNpBTp6ab67FU2DgbQFVBXmm+m0WyVPMrk8MshrGGqEMg4ONN55rJlwCuKIOmK/uXxyEWfcv8nmDr8lRX7uSzhAQGv1Z46IJDCtcrNJPzUkkBmMCdNMwcrl6iI94/o6dJwrKyiRyLXRqEGwdrHlJughOFRtRVyMDj/YqYbPEt32HVjQtLJnPz5VNNxfBtbwtiRYqT6EAuSZmCOppPqolHEywZZY/7+htotkUkpqgTQo13q1oTJhB6iQMsnwJdBB4DRYmjRUK+4CzctJWzXD6crnEgK2aiNeIaPsGKxTiReogPl1L6b/DPLrmBQ71JPiaStVIYi3L89WjM7PVGtP2yOpctjx4gNOjrZa+Wd7d2DXIaWbqmqsfnRn7p2e6sWZ1u59n7DKXS4Z0rY1mUI8Vk33s4NOwXMuSd1b4GuABXAPi1I+seHTNo45GNJdB16m34jKPykfZNNhWjXB/qSVPiC3vEt5Q1G/lvaQKT2zODM4V+9SvM7dn93nFNnXDfKTfBpw+dbnVo2Hpymppakwl3VC8aKyINRKaYAVBeF0XXiWCFxf0W6A2sF341yP5rjT1IIjUncMvmJAn+KJORWu1Hcu91KbGWsCprqYK5J7ENHZtjjU3NEXZ1Xhq+O4GmlhxramGJKNHralp6waoYtY86nbxIJpMzFAK8vQxySwV62pzuXEuNzl8PZQC1JzqWte+0shtPMyfd3UDDpeGqhbNIF3L7VOPBdy5fkz9V12Xep9/8tpp5bbVwpGaTKdGBQZ0mrgGGoHGs0Bj5mppCmfnt3IObJOBnf9ho1lpRCpeBEqLOEpwehVViz5sLM+l3Sq8HXpERx6VSuZcToc6mUPXxpbn6tpc6ytdm8OjBCsdRKXAs8KN0hpSn3orNl/pqgppd4XbboeuPNpYK2+vFVcst7sCj4avtOmK1AN0QYt+tblWXCuuAW7fDSKePeCKCzPDKFWPjlrVJ0/eqDQ0+VqT1iQnZ2/UXj1qPan+o8eJgekxb6g1SfX09OyLraPqk6osZ4fZMRRMjem9YCnt40Cv1CxOTy14oG5F3alZeZ2u1dlwxFzq+rcK89SU3VLPe4wPVTEPuA8+op04zORKVUUr9lRJ2h4bcdA+6trjq36Vc+pzDdWPuvaq22N16YALjk9hiMYQ/0wy3a97Vn9ondiOjxRkAklwXIGd4DJ/HSJfY13qqIgLiPgibQ8Yu1RRpxD1BhvjBRhb1+rM0cBoxdY1Cmp6le0Zwojvl/IZqJ5iEuqqZpNuIKmKD9/ZN7TmuzPpb0K67MNMyltwtYc/Hlv9PvXKukEoxZiz6uMN3SB2HCrpBrGoucrWcMh9kEodSIaXZ6U5oh9w3dh3qL/SoZKLQyZLpgOz4wD/1KHm3eRAs90e06bkHjlV/YVO1LOXKQY2gKA9gGddaPe/MpCDzXQp5G20KdFOELoVn61ARSvV11+FozV8Upe5sPrn6npAYAKSfZMbmOxhSP8YnG1Y3JcnReis7RAlAl0bUnxvWjMqmrZ//+FYkY0VJI37rzE4QB19miaymUOkjTQcJGIbhgGa/9UOzeWshdM/2FT5tS+wmSlYGcFT+F3aXdMMg3RouN3fo/Ncrr1CYH/6werQiK8ZhVcXpfK1i0FJNHSD9KgJwsaIrnH7hpJranZpLtdVwTZ4rmFr4TPHR1H3eOzy5TP3KJCIOZ8wJrcxJaq8RSTZqVBKLIezxwM2rizC8ulC/J9POJ1DWW+IOLIc/WrkVj4YWSmVM5Jb4XIjPSrAgb1o08P7jBVOzZ1Nz5XgK2PPAvtnjSTAkm3fv11ekJiSar+XUcEz2T3ktkhWC08TGVS2rAG4SWZT+eYyvbl46rILJOeGdCm/9Nkoe5om0TTZNJe7AzfGagO/P2Isb/wiavzvoGf1iN4b06kkBSAItDohM8SV3BJHVG6ro1Gld0ksxTngMnidjYJRpToXRawEf1FJMhskk/OI85+F7FKqYDIW83JM41jbqz4dViCyZft02JIJEMaNqlINfxEGkeXKGf4h1sj+ZXoNqZVq9JO0LW53MLIW/iIdh1pepQ5fAi+XvUH5iLmcVk4TAfIqkD80jum+6o4CnwC5qDy/JCmmEUNPPQf/Ajf7uuUPCCZ3/MqzS3JqdyjUfXNJfikYjp4wlWF0/YTVHXvUZpbXhWGQD8ZGviZrVrdPK2cZkSRmQSvRQPI4thL9akXJJPr1OBgOLe96LuI16g9Ydy4aofXUmPBK9KsVjhOPUqPEKI2EP46BtweOOs5Dw6iofI11rytvJAIkko545fXoZxz7BuwKiQABW5XAp5Vj+ZcMqNWFwupv6/PyL5FMdwWxiIfKGXS8Vo9/E3YFJyB0XDlTP0iPMZ96lRP8Q2Z4aoKgINwdULPWQUEVmjdVRuoHeeJdv+qfBX4t8H3mkkg6rZ2RWBgiGQJIGPfYt4ajijoWaHEIxbN2DBrfaPqjMeapHDBuGZlaHhuTaDyhpmikE7MVZXjV7ThBlx4PR34Cm+C2fBSIxwY0ptXXX1VdgNZda2S3VC/IEaUjUChWupSOWqDPIJGsVuFXfbjIQ31OjifWcOTQCpV/WyocxnMpDM4E8XLXG1RCAlY1sOsvp41ra2vXFLK2LU4xvSFZSv66E/Rtl8CZPHFYnxNEDPi0HNq3OtfgrqRvu7wFkhJgGrGBIkfRjpzgCw6TBxux1dpKh1pcQk9maPgs3ETxWq2OY1PXRxJJAJeAS3zqOQrUaEjB5rgnUYm5WI9DfRoBBro6yv0Wd+3RCAabX7u+NWkN7P7AsfsDH8qrCeMEdButUDoHnYGpF8lzuvaaNTL0hLcF5jrXKE9GyjudERsGfG1tLTDMR2iXBVLHY3w9XzekiBlK9ZE4mpBaZNHQiXTYVOrwV7eNSuTsh8kC4db4/MyMoHrMdUOXmyd5kIjX0ejBfMTNR3wJcOCAEqVyC+ypDfIsVYUsH8O9pCII/RKHU0jI9KqrN7R4otHyEBpBfZ3qlBzrNZu/Zo3gKQ1WOTmD08OnGSDcs+UBuCnUG5qSIDRSbEKnbhI1PvX11zjRwzozAFXyg9JVaE1DiJDzAa61xcOWW3iSENEKuf9wUw28bhOFIGwBQhyyNWbxjTghsz44ypfTT754Zj4/I7+M38/j95fw+4/gqySoNxOa4Rlwf4GYDFoSJs3f1KWqaDChiEY0j3LmXNHu46Dte5TCyN4xzJHWVbcJk2M3h/8wfTh3BtH127T2jE8BV7LlP8gupwVBt9VaP7W5b8TSITvU2Rp1fe/6MX2uG7Edpt7ghDWNaCkEplIz4IU16elT8nKgoAhyOZXcc9iYa8aCOgPSaoYQjRVEPY8Nf+mxod9C0UqQZm7x8FrWPBuvGQRPIBNZwjBoVztsRHmYIENQAhiGVE+AS0cTYdzjZEoiCg5T0cts5PZGLmL9tsGblfGaJM44Q/GtNAOstWBho+NbOM8JcjlNMrsYggd1FtXJpqgDgu32VdABubTjp8c4lQS3/DSFn4G5OMe+Hsi0vme5fgs6z1s8GI2Y59OuZgjRSA9xC+w24HrKEOwobV9rJqayFU1ikBzhZIW53MKkNY92gw6NKfYaWoXB0bVB4vEA7W0WjrTmcSTIwJEWdbsjZrt+ElOCBKYkMyh8GUsMCVGbpAbyaXiMPzPAiHHL0GSyHE2mgCq2UbFh5uNtGChPpBr/omeNTixY9tdzxCwwH2XsqHaalB0qWnZoL6LNcyIqJ2/AIc/tlATEJrdnno3IOa/VYSQwKjOnKVBpqPOorHRY4HTdh/5Kn/orSC8NpQmS5OWfpHkREpPwWMVkqp0xYRwSJsGQpLZsPVRqaRHPMWdulstp5bUiLC021e+33aYBgy4WM6DC+AyQFAe9FKT1tzfO14rna3qjWCpvNMGtZcHrQKBpRD7AmfHpATbvwV+EXUgg4x176tramsJE2FGDO/jBlsnDMos5wtZhSw+MSmuaOIkN2cG3zswMgGLWaRmjpse80j85QwVgxBCGjEx02BgzTHdu7orZI6yptGFBojTICneWiBzpCRFIBmxK/vGCjnJyC1aVbatzCYdo0EOQ+oGEJBnYwJwdiwUM10IONUWqSUuxTKsz+UL1OVcs2dicKTi+Z0HwzZZkHRTZ1Bsc+IZgzZYCLL4UZKwNrVGCU9FvY3VKgpgkYtnUIEPscgVUEv6gMibhMKIKsZUYydCyowH2T7xpIHEOwQ0TJ8bUICnOaj7PeD7PWt9jwah2LTm7tSQMYbcwwWdVz7OuwUZ4zWdn7QvaAVvYW9wxwlLc1BPIwOMusJnDVjt92BpMY1SHix1RHbGCalHFUzhzleCsXdJrvN4yMQNdm1cj4LHlfsLreui1NEPrzUeWmziyidrWjMoCFTnmHUcjtiDX7SW9rrzz4JZP38k8qCqrirhBWg3elNWxjOomiaoKD27hNpI/kL8kXk3fIWxUYWsRzhGwq9TinoT4FxaQaCgrSgzwWviTWI7DxqECCk1W5P+m+A6LvK73hlQDgB4LFSdIx8B2LznBSeEPFWZAeYzDQ4mZZ16XerSrtt+xGTHOQKQ0kpEJMPKXHqN/64ilUaWUqoeju9t0HDg5NSeHrQiYKC+xiXYF0GtNo1KPVhFukWDyhJzbY8ysxPcxYeRWUYQverZPz1znGj0ZXMD4xNQ20pO0WffaRFMg7/MWP+7aPu2iCpMTLx7DCiPezIhW7GQUqtFQr0d9ULFUgmSqVFFWWunVNk7aJE1mVmad9NzKRUqreUpsrnSilVqMGQ/gRtrAcvu08iz6mVTrVZ4Sq+PbVzRUqP0yva7ckGDUBQ9KcylvEk59qWibxaITx+pX3koQCem/78R2qAlbzDOdr0kDFKDTlMMtwilU9ypXAHXPUIbiJlfiAjNx/XDCB8AQHtl8hF7PXRtQm6jbp3LEV4vTkDlw2YrEDBCDEJ04iSPx9bS1+RrReoZNCaXmWNdCpa00o7AxbggmIMcRYloYmRgGQBAGViQOpnw+1KrVUTKCejqYsER3qRlklMyDAieEM3WymkFu+YCN4c4hqn2VKrjSo1NzohtwAMwOM4TUw0N1hBclh4sckqQ4Eq/e/VPzFA48gXaHG63HffmKo9qJW9Rso929frqISFCzRe8mE+RxnC9e7JJHmlDzMT1U+zgndoxrgclVR+Qkp1mkQN3l4YkMi8mhzsgpscmFQQJD2RTaPb1lqg15DQyAObwgctiqpPZpstqSJ+0Z1oBPqKkx3AEBK1s0ImjhWEj9QtMgb4BbENu1vOtFOeWRNuR9AHktTrc378wLfdDgupQtDbTWWcenfoH7HrXw1eFTIYpm8jkdzR5afbquGXMJVtC1WVYC2EjIhDeoEA8iOxQOKIxHbJE0s1LLkiCVSS0QCC0mXTElmWZt7XbIBKhFIxc6X7E8uuIyf8W6smwHDCXBpnZFy9jSpe4CKzk1iLYypF3bWgFY+JqGtiNPwGwkNX6Twng8LsD4FgLPoXBVhXazB3KIDz9anr+ugbH0WKG3OjCyAcEzd0zApUfF1CCCYCL3sxOw1oObQ0nqcDzx0Yrtvk1EQoSZ4rRteNAlaxHfw/TAh5FOnalfZ53FU0pCc9xrOs2qGSvSFpycaz5szio1BfvEyOUmSalAsf0TUm8qsaIuB8ejVhd4AC2UK9RaPTVVBubSs55mSMpSNA5DFuZBmMFyr7My7NdnFFEXa0Pq9Wk8eXo9rOv08PDB4eEtUBnY8m7MHj2UnECKpOt1aU4RKrGjGVb2111tVmaIZJ8JmAHWU0pMm4YRij7gblbP2ClgL7NDsjKJWS7SSccrI0c4C2jBFib33YmBHm6gY9fUvJhjyepktZTNkxn7eLcPUEe/pqbWsxwOkBeTkUXNIHO22JCaILVvybwxY3hNs24TYikLpKdEoV96fPYFdY3zOjbBalMzLo5ZE/TaBm9hMUm26RLLKt9TssYkYY+uxSfiGtG6lm8VwoPlgmvBc2STLKHE76ZXYVxLocOcFpa8j/3Zm4dx0RYWW4kwrDKbpE3JhLx5h2T2X37/FU1ZBN2HfCSakD4cyVvZxjyqkVRZj40KCqOnRNMfaMSmRDM0MNvK5W6QoB7eZBAHTmwgDhmqypQEZ0/fIZNfRpZ08sxypAr2U/UtNgTXpiRaeFGccZgMVdTx5UtPe3IpT8kyGmwBDa4dLpObs7ZrlEO6CS7athzFj7cpkVxIpZ46NJnMCjpIUirR5XOHHl7TikNJiHeVN0Nrjg6NeQIlUpDGpAkacfImsrTz3EGXkttkoaf6BAR3OxI6KiNK7FkRpJIllwAltfmRvHTRraCuZc3m+Fd3qHEIMq1DlcKismpz5NIlU+lQUAmpmZRSudJO3lDzDmb0BvwgAhH9orK7ViSlT/1fdtnYfYzH+JG8Qb1Ty+0HVp/qNxTEnS9SU+oUF2KpMvy7xz49yTD761CSKSSH/HtlQklHusCV4qUKPOUgz4wCPxSamStlIpzdN9GxlZREZYZnocj3hJ2ABBLaiqwWZ3HqTikVJ+JlEd6i5PZKgUpDsT0lhd7QpMCdBirzLMSm5PZlBH86GVlu94iO/EGllJb55WKbkbGMhC5AXfuK9DmnRjhD83A5NEVXgNkqIHFptVQZjTgKyypfpFMSb7C2IcQNNUjmtp6JPyNKbkNIJhL+G2OZPkTNnzFVd0xiUye8FhepFcHg6F63TqBYhRN0On/3JZOUcgAXZitxaCObfhUPSWdj36A9zcAXKjE+sdcbuVxGZKw04xnM2UsYbUcQFKCr9zNFv2P30NoLmfOEPMUMg2RcEAsiqhJNZkY9Iy+sJlYVr7yjkRbR3smS49qetsi4OJEWi0t8RSOJG81d5f0hdLo1x1uy2F9I9ACZenL/3NVCbkIes9qPiodya+D5kqGcaKxoRp5VmBGGodRULxLkNOMlNJadLhugYrmdLu1qbBBMq+ayhZDUcKozLVO/Vb8qPCTK8nYSnAZQX1Ie2lVxtoyD388sz4ZtUJK9gPRnE6RC87jXo6hEDBMh9zihJJyYOleP/IanjDpekAenTwz6nykZK1WPbIpr2dnAvcyqCZd67Ru6rxdhFANOJUyGPP+7ZULYOg81WocJAOAcptE0yIKCsaJ8CfjoKbMV6oOQx0m0lmxsX2/dr1dGWv8VhFNYYQRYVRv4qUpELXBv0lBTN5X2A6TB4HxKCTMXZtS9ujp1MLAvt3asr8U9D4vaTbjqtqhIfA6qCvZTV3dRmAm7h/fz0i3sZ/elNcWmAzh/eJlrKurYNotyOVabOhrBG7knzFueWZ6oagk28kLxrIzY3WRZLvuRkCwyD5mYJKkhL5HEGux2MmJKkiGStQvkcu+sFFYe3GalTd+RZ4HRuSCp53ILBm8BLZ8bWCAjgU+7oPYCMauuwitP3zit3KFdG0sjymWXrySlWEksg8+kG5q8hLQXS+R8kYwdHvwtSbXlPFA3GGrG4RJ8mlkQFR7zjqfTdDUScnspNnFFrCs26IJ1BmBGqwh41inehMvUoKJqlqQcGshCsh70gB4DR+YgD48ZjYglm73kei82bMZekaSsPysMw9WOlNDmjqeTbFrL5IlbcmM4a1YAxVxTC16uRI3Ayy2Ieb2DaqkQXVmM2sq8Bhduua3ZLXeNp/Zb3cjag9fm4hbtyvZadkLGZh3eKZzLl7mBh7mjRIwOxduQHfGr5onirWLXTAo75k7NkujxeuDRGEW4b/l2J3Q1C7ctuBmTYr9K5iXP1dJUbm9eAJgj+TkejKj8GboeR+/zigZHrJei4ci7J0U7IMRhq1GmKfqfO0pkM6N9MIaRpWVEe4oPM0XImnJtxUN74VDQnBoETN2n+2F12EJcOgFU3AcD8x+xYaJIbF2W2nMzW2VTgkwOJkYNMFzeT7/wuHpyHF9S+KLtOG/QDrWvKE4OGHolwMMK8e0F/JXLqR+rphlPQ5iWCcz83EwNspqoLzm6YR1ztc7NFGgXIvLjUD8thkc3iFiKrkSzkgAeX5KURzX7scGaBs6Yqh61XkpMi+VsLfMW6qxHjZRgLun2rfK2DgY7ynuOBO+QKaGDrOCt73BbiAm7mu4Ib9DYVC7Yz/v+CG7WZZPxJYsNxGP5mJsdqihmSXXoEU9Oi7EfD605WzLclabTOP9t1AKPLyV20xOXcPbBwlMLHp9mTFOLC+qLF2/m/EtNQTCNVhNphQZO8YNEs5ho7AeHrUbQNO1Ky7RJJrK31Mpjelwal10KVdVw8PQWyEjKeUYMZGIcQgRl0XkseKQMXVdMTBa6rqjDz+hKJtrhsFmziFOIiq88ysiaqfOECopK4WPmBRhUmjyYnXlwj7FmOQ42yRMGfbFsBRCBagVdAstDbbz/CYcttfDaxYPDBzI3bA14UKEOz5YvxAX7/WBzYVKCsw0iZY9s0ICTjxUdLPrJCl5MNbTM2+po0dXQMm5laCRoguUey2LZB9uq4dgxSDeb38iG/SJSwGTKDcZLVDUvTsEsFXBeCtEUTIn2VP2uwLn14dLh1FY0wuBrVBbc8s4kj3Y3u3GS5Gyjg4XE4akEK86vASNUkB1bUOUs2ZQh9Jd3wjoBV294/oLGcWRxPmYeHBdpr6vf9xrHV/B/n3Ego9axe3V1sbCiuXScSHPVGVQYloMehxcOapwle1BxHJ/NSdfK/jrjsvwt7t4VjgIQXpEJr0ppbWp5+NCBWTv8v58QYOOfmhI8+G+UEmCvC3K3nRJN8sHgH+nvGnmTDadoQBYqpjLfd3V/RqysvBwK0uHCpFAbH4AB0+efPHl9BXpFXV8ZLFVWAje6VLUi9+YVjbzz8MFtbfrwnZjVi3XISlCN1chKrs1mAufk0oQsJM2WQ2tmO4s1w5ge8zr0KR756QaJM80LvTon7zy4ZdMKnsob0/1sWfcXDsNcCzpcJZdHR3xkdWglAdbUmO7354ZFN9Iw8RAmthAmCcBcVToaJlSgwXfCppIApm+oyNbssLXgPq0la9NvU520oeFg+g5ROls+JUwCkaU0+BQTEYGRVV+kKl4y9DPaJTaDxupWRxhadu6Henyey62yTE+PdkK9BLonIRgc8h3Gx25oPOMPtJc6sms7rHNZ4FTds4+O8JZpq++qpiA9ZdyvrG+1l+UfbGY3Na8HM4yXATWxARVCP0j3aT/0aTYl2pMB5UDexkQroMvUFakn5Svsinqe3ZUP5Mg3dVbkgIZZMr2JBYv1dqEU8/hu7V0k891LgRfnvo8OLwYjGb1QiRdmz0qcJk4Vvap5W4+u9URXU0ioESAJXV5lwSUKEq6x+SNIMqsoJnO6QTK7/1Tu3qIISx59Vmh1StzqrEdWfG2VuRDWjH23uobop5yrUlf5VpVnDU2DuFWYDP02002rogW8ai68iKv/QhzQmo8yj6bTLmjhnZuQ7M1XFEi/r62Ep1e3mnABm1lEyuzjRZdRW7kcONhe8z17qC9Z7nd5fR1PybzXWAka7YbgJHyWY39RSl4+8nAdmcMlUhafPod6lNmjhLQepZXUKo0JcwHrFXM4kTJPfWoycgGnDOGqiG/t4p1+MAVNWuAeZnHhwBXWSQSTrHkpLDNgRzzrJMtOHlgBGCh7yUBlOaMylM4ePaBGpe44z4kUqhKpedgtRmAiZm1qGvJuTaHNJho8JpNQ1qzigzTzBpLwAJcyaEpGk5Z5nxMb2VxcsAC30NFdKNGOotiKdq99K4iNO6akNV/Aji7GJzWJqdHpKoOupHlXnHktES1EMUmdrSWTmZ4wgzi/2BUi8THCuTGsgQk8CYqYfminDcylwV19Ll2uB5mKK2jmmkg9l4tpjVnP5SZpg+tJyt56kr2ykrdK4nlrhVdLwwdKNdCKS5V5lNaqJI8+gYkdZ15k64ZmoXwtthFdtgw7VfM2Iq+8GvvFY9VZX1opHw/FX7yTh+ICLw/FvxM3D8X7+HlIGJSoJky+kHdm5JZZPBwtbWOtKI1dSHzhoiVxqOJUCa79ilXNdsdnV6ckqJoaHDW3OPVb0fX/UTI2yd20lMpgsDCDh7aoLXiKUSPdhdns8KKoRnrJTPKGZmh4yVtDim91kmFWTcq8U1rPk346i/LMl85zPd/dVni2H/btalnnYfxoC0mCRtphTvTBuDxva3negGpJlxLzGoDo/UK5woMqGVnXDrO6ldv0UTr4YkkKimyaeMoEjYVnzK7Ds0eUEF8LxfN0c6NEc5nZQyK91BrZXNQWMIHYzuDOdoz9BZ2Jrh5nNyJ5DHumX9072gtLpYcwfVn4NURRPTyXSxVXz3rhvWU7DMmddAaSXgKSl60pc4ITzh/vNcXDu6c43YpcYAubSao90i31F7SULJFuLFMXlaEUSdrSzQ7wdWrF3FE0hc5JRMY1jYd2pj6r0iJqzYNfSskz2AnUvkq0f4+CU0N6dr1/84m22ovbgopB135HvWrW+dJKG8VmWC9vlJoLYA7o4jpbM3VGWabyMfbjaubrLNvF7V143aC64HWW42oopCpCPusoBlR0Gc557u+48dM76lGAPa6aC2CL1Orxyx6HDUa0NJnX8J2Pmaj95BO3r7p4PwGsYQ0yuaM55KSSTn1wBtFBGiQSrR1OZsIJIrn8rLVKbqGWqnu1FDlnWu7FI+nQ5zZtPxfM3CZpgRuJ8TTttRLvus/NLMcnYuZIKLQndQ3xjaQF4MGTRyPaeSPtGElvoFqURz2PCoHChZNgzcraVuYyp0MGGafVA6R+rzl52XFDjcgEnzrBUaovGSXIehGnJy942UZ08fjlBwk106dG+IAbBFPXoS9AUr5I3H+9gFOqY9f3bMqRy05lB5JlHDaQisHvpFMFo1nhkR8XwyAzDhHmWwJz4xiDluDGKal/hqnen5irqxe53AWYmYXzPoEFfvaLWOARD6U1I4t8ZYpvkOpnbSFxcVrRj4s7q4RX9lLVxo4yVCrRUoOZrP7Vz06eZjE8Wf3rn736OR4qWf9r96lfurfcz77CE2o25tmd1tRk+4HZOmy0iJZhEMs1AqbjlcaSRHy87TYwF+VhkGdmRwpU9wBln9xjC4Te2S/duwB6Z5vBYSNY2LslfVM9sxf3rDnN2GkzbrBAN79wj27ik9PkftPYSnR0rKaR2Ob4MEK18aJOB02jEiPkwjzYffCZSmzzHtnBvWdm79V9wSByvZGyGrhtma3onU55XNE/noz0d24f3I6roA03ptN3iNbXDMJxJFvweaMaOzUOheykGMjfUJZOCUURaPrgnlrCiY59Ly+RqgcNqKDZKDWFaIRM1moQjYzastbW1lpZBByAwXXNJSDTaTQG0V98frzRJKurCwgGlExzYYaBhQ0jFB/o4wH6RAsHANgk3N/DiMQwJUiOumwwLzZW7GRC7PFLusdTI9Pcn3NeGETOC1vyIMWMBm0l6RqPZXAQCU+6XHLlHA4UjNuszOCRLpV9HDmi4cZBMZcbq3e+I1+y43T+IMNAVohWohRpTclR1UTP6g2tT32NaHiBRBsxDn+61KE+1Yi6nMI1osExMmSw/M5AI5rvWR2omJxWzdtGUG1WYF6WaG9YYoGDIJjE2sOG/ZKSAJd0F1SxpDGabT283ZUQvlSTDvXRK7Vp7y9zT7nGs7AV9+UEsirr6JVxeL337iIzDMj+jOcbIfRJyjOu4ixhqdabJpMoFuvW0xMvHX/H9tFNePN0YHGdG7ncjH8gIfQx3KycxRS4YgQYct8hGMMEDD7VBNxjsOckKTnh3fu0F1pPR822yPjuZsH75wzXSALVbG+2WXXdPNVqUqUVpFRarRiUMZlkghLzgfAGcxYfKCEZ/t0N+DxvKJvsL2syaZQUOqnPaAJY22z+EG71NK7nqYi6TJogHajRCtTF0gQZGUdkJJtrCxazRpA4Ozhj0oJeX82CNKPSm1dyBdO5jUSRXi3csVZ6NnW68Jyoy/zoaTrNIMHa0ObcdvsRxt9BkVISCEn7rGpJ8/qozrD1X6bXPJfLjFZnZpHLstuMPMCdBfFoSfced6tXZoC7BR+53Hw0zvQND9WxZR0D3imyCF6ZedfVpZMRbhsrHuWB41dWHh8/ab1x/I+fHj9+0qqdHb3ZelY9ffWo+uS4dfzGG2dv4MvDpNG+Y67Tm9Z91UrLOIlPMauNZnJPaDXNYGZPaB1+5klpfYpJaTTDWQE7wkYraywT5x2KMNkkaJpsf95n7TzZSI1p1NfUwLaMw2WEZ77jao+t3LeUpkHvEs8ZAyepRxr5xPNEXhWfUqnHMZ0q4b7lU/nkD6/gXdnKLeSKNVNtGjtwr4zpFK1FZjO16BRZ2Mqtpa7SggXrYzolcto8XjmtJuqB1AmdTiP9f/hwnjn3wMjMfaRp2jm6rDJ8NzfhNhq8nyd5YBvMONi93EP//9h72+22jeRv8FYojIYB4hYl2rGTgQxzJcWZcEaOPZblxJH1hyCyKbVCshk0YFIm8Zz9umevYs959sb26+5F7Kl+QwNogJQnfnn+k3yIRXT1+1tVddWvUvVOb0d/qtoYqsY7jdDOeXRffqpuihe9I0u/yBBpRIYOy8jQEg9aF2DFhdZtt8NCG5DbJhZgjg7tNcBDw4roGqGnwrGIahe4ZcfI4pwXJ1m5MMuQeDDkP8Blxae4lHgaj4URmuuAjd6d/CQhUF+omuhkKO3dNVMYCvgC29QkReM+BJHofWUrB81OPe5L7zci3xHrsA5E3MAPG1YZdNA+rDJRDWvp89NJRMYq0/zfGHTZARj0O0JJzNHSMpKoNNoZXDKtndbP+JKRhDvQhO32RoVdQB8T6m8vQ7CHR2nv4gRPhy0M3yGAMLcM9y/kkIif1lmCrt51imTgNDknqZ6GSxkyko877VxTlnBEJzVBEFZShmJU83NUSPw+N4dWBDcFglckGesFcVxMwvGEPR+BBEwGcssdagrAF42n0fh7OmAibduaZmnB64B25FJwIXg57ciN5UK8clZ2FX2Trzj0K/xthjp0eFxy5jpFuycBVMpEDHcRpynSv1UgTgFRygsXh4EC2mbgySqWKv80459yi6DvSSRwOIwb5d+JCX7NgzQUiScRmdZeNLZg1zeo+UixbIRf0VKuPA4WYAXwK1pHdXlQdOTOV6vQa7etMHewSv05UmsXQHis+72CX8cQRMZM+fNqu20Nyl09U5hY/oAnupGjgMhluAm01mCVvdc+bkfQsuN2e+NaEmrlFd5scE0ce+CJADuwBRbaYg863GlM4AVYmjHAEqXstJ5mrGgOLYlvLH3Ai2RIB8xBa5t86GVoe7U6tEzDTNbqZYVglkOxjz7sQqtsx+q1ViGRj4gb8g4vcOcHCLGKp4neQ0TER9twcUJnRV9lV8FIGADbWqJZLfnZd7jX8gcxFEQwFLxxWzWNq6xPLko1OshUskzpziSKr8h0M3IprN2B9k7+OgM8TTip87M0v64J42938qmSsiSm0ysDMhBuk85N5cwHF0mhRJE45awVCdT41pCMRjjG06QFoil3EpL4IpKAju44z2nGfTI7LaEvaqqBTjFHrZf+ReNbruHRXpud1osxjhhuYZJc47hFJyTh+UVfaKyUQS2StOYkua42v+Mohl+FF57B7ohCAbUQuMvL21nEQJYzovVTFa2fyD+6fpqH7Q/R4JqMhzGeCmg/1uhXPfd82m67ELbK24Rrl+3cEe10rBb263KFoVw1rZrvOzvR5JJcpTRtWO11buEPZJJzyl2GgNEUfhDcjRHGX/px1y3v9U7leXw65LSi6XBdFIM8eJxa6aw1iKawmi5xaxZjBuuPTPkCYdEEt+RcwyKS+8ZouF54MZ7Qd5ivUzrKlx7rNHbNOdF+x3L8VZtgsa/pyley636Lx9/7So1Ack0ZbiXXUdKawKPSpkPitx50Fp1bPjLuiCNPiQjTNdu6roxuh8PPe2JH+YAjuVql65b+l7PgpW72cy138NDUumsjU2tIsVBsA/9ARrfiZCalpbNmwcnlSqZDMogSrMuwrPJisa3//ksVlHTcNQgWDth8Vnz5GKBq6fgRzl92c8l4V8Yh3HW8nluEFVNWC7v/o7t7hZxdxzM+7cGn/+F4+0l8K/WdrSGG9p6+7GuuEawbBjBCigR0va5R9H91vv7LW6M5b1V73u7u8niwEtf7QGKUjmg8j+LhSzzyuPtlDW4Snb6iV1djLJ0vl1lWDIYGMEcnz38STMT9vft73fscOMkcSYV6tL0d4xEw+QsrTqqy8yAQj4wDm84BjbKWfw2ReCacKw86pjChOW+BIEgIOIeBzx+INj8cBIfSx0UpaBViYoUlRyJSff6FyC8qR1ocqND0U52DFeTC4OpzpYUYJ8b1FKZe98jTL0ToOKiLf46WQzrgneIC66FCz+MjzZ4aHnLbWTDnqohtEJOcKZ1iiJd8iE4D0iEMmJOpe4xeg0Yi5MBW42jGw4i8CULrlP4K3w/imM5PZ/0BP8Iw1t++p/Op/ArCDle7+ARibRk8pigLZv0HsJVpRA8mwWm7vf2ki1Lu8rgVUKv957G3T9rtrRS2pXxhqJLAUjpF2xrZN8L29edxEw8IG+Yeo61Tke+ca1HqcH1F2wL+7BfjaHj7ip4MYjoeu8eIKZhkEaSpPKYv8QiOGJqXQqulwFPUMWLipdhejpj2gARP3FTHFQ09/ZwmiiDn+03jmHJUx5oRVCV4mQokt3WzWm0/3qt7WpCACk4VlE/sPkB4JWwHzmHHP83Ehh3j7G7IQpdpkvBKBGKN8ATFQ8c/RWV/VsajLsV0zB0Wx2Twmx/hrFmj5Mi96njotGfRLQGRhWvBShfgWXUtBF53pnjon2ZIHgBYWhwfleOw6q1AMDjyjSn9LZ35EtUtgxmrPRvfSLBePrK1689lXu59LPEE9anZtNq4sZPAjkKTNEmjcZiMWQhvakHtXVJUc1Tg8f5NCLoCshRwBaJdr45PvBqegDTjShUKPM+8Rq7qGa+s9er4BIwPlBVf6/JWcH4HL/q7+smu0zrCcUJGnP8S3NMkmkZXwFiRqHVL07gliadXLWFzCMzSLo1F4mVM5wzHzYyeDbEqxyCGmWIhONayO+NWFkBis8z0+tcgkcJ+BxwBSliOZ/QcPHqyfZZeTkgCWXkm8OfG7/A012FxeExop7pyixhAtKP9/X8myfULHDPCEhGPxkSIzPbH9IqmCd/1G1eFcrabVeNcFOxSqQkjXupuWn7vd9kZPQdYC+Z5CEKbIdoR7at2IuWzRRn+0AGCm8xY0u5Wt4yWafaywvpB0SYDVIBbqMXOVM7aCj6TQjH3xUMDB9E8FMc2POWQfA7F0w3LA1WL8draEhGguN3qsZEOzLksmkPoGh7z7baj934l0YNHHmsxQZVyu0SZF1slvsNDCcdUM8MAHtcrtDlKAEfYP+EbRiLf6M0Dmc2oB3Xv6RoolqmDvsLo59tYIbvlX0xwjaPySigA6aNNB+AyMSGMxBAEQXBTAzy+KJRwmUxbEzqMxlAMP83ym5033tj0JUS7l0JRozoUyYcR55jnsOPpSZgEMewOamyILrmClXkAQMutQYyHeJqQaAwKtRyZ0Haar+t0CwZxCMx9sff82IAABPCvWPRyifX+zRAHbEBneGeIR07WcPk4J0Am7raU4SFoQq7iaJq0omnLCBVqaHw5FBV/DYoGA8wYZBlGSdSi09Ylvo7GI6VXg3frFO6/Fhjowt3amkS3IDOPo1hq4OLWhMa4xVu7TgUHBcgbmwnFHQVbELh8Zf6WWO2t+TUZXEMFDC7i1hw6pHuWUK1DOe2DPgFteMw0YyMahjQ0s1oOFLZmcZcLJMV8c0olwHbDeZOvhu0v62DJkV1+PwgM/kXp3UtQ7iq6lND11mEz0XZ7978evO1033bcnn/W3fnb+dvh16s9b3u3k2DG1S5oNA1F159LR5yuauQH+gV3/wjHYFTTpp/FmSqbBu1yU6hAiYbkLs0DcxiZu755896c1+TPVQOpy7MYQycqrB83czTSjcejPAYFAJjiIKTBE1LX22YolyWNyRWZRmNzVesx9I3xrAV7QdsHwZqmghIuL5QZwhINmAiIpLMItJiuadfRgAAjYGPgbf1jNMK0JNmkEacfoxFg9rJxC97ftQUl5Cew2HS9knFHCjq/ohYwCwjEm+ZGS6lsKNfUccj41KplO7Z9/ye+BQjobR248LCJ6h0dRJfpGIxGgINtoCRgGf+6iSKaDq45xP1pE9XwdhpNyOBAEb9vIuYqYPRmg/JERMBfGynxiAlFZAMRQBnyAP6INNEdjMfPR8KQqoFIhLVG4yai5yI4Nho0Ef1EoUWzJpI+FDJsonh1jac8hG8DzVMRHvq2ieZ7PMNT4EpznddlE/2LGI/IAsQ8IA0bOyGJ5k1E0ooZ6E6a69VB2yFeZhNllCQ4nhYyvGqc2eGQC8LRuJDn5QbNuQXWGIh/byI+nWKwM4gSrMdke0P6QoveNy4IEcX858YFweNmoR/XTAiD9flmLVEcEb63ftlofSl/F8dD/1q3IPRyhB2eNHWamwh6KEkam2DGbo+bSWVsQDRtJjPAFVkT5UsdTh/RJjodEBbOKivhwWBA46HoQ2SlUK9AeKYVLWMr4dE1fhfT6UuIiyyfbgacEMxjKsTC287xAKt9wG84fylfn+S95HM8T+bv7gKX1ZGsN+vQ+GqXRmz3Qae7K+3Bdi8jhh31evVUKu6PuQToLzrmq5bxqKXC5qrR9A8pHeNo6tJOKcUSYbdCq5O8zAj/6S/znvs3qHjz+seoesn6hyi/T/1tVLw6/dfIekv6p8i4EP33qHr3+W+Qec35v6LSheZjjMy7yycYmdeUH2Fk3kj+WP/+iSb+QP/qj/wZRsaV4g8xMm4Pf4RReSPLi8K/xah6J/iX+qv4Herf6qT350ZGdbr5JxjVnd/+QqfZjmr/FUa2U9l/iVH1QBWt+t2WZBS5nQ8KqH/e54MyTSf+z2afWOL/iFHlXPTfYFR3BPq/FAZFj6n/L4zMg83HCaqeYX5ifOXbxY8TVDmZ/Kn+qLcN05/y7UETpI8WnySofIr4UYLKB4Y/TkDA85cp8Mw87LgfdvIfPAY3FCOC3lYfgs104JyNobdQFwiyrJ7VHyQSR/Tng+D9Afrnhwscy5IqYzRV6g3B4kOUK25smwp0qH8edMRT/M+FI1Qenl5dg5uzCcFlX8nj1JQkmKFXt0gTR7bvFWnipomqIE0cB3SNNHHYRKGlie0mqrI08Tqg66SJ04BuKE28D+haaeJNE00uTPzaQKZkCYybiKQsQZqIlCwRNREJWWLcRNKXAgldJ0vMmmikLDFsorHIEqMm+qIscdvYCUl02URkyBJhc70GUz1vpLTIEieNM2uXJRYbNEfLEq+aiC2yxMsN6Qst+r1xQQhZYrtxQQhZ4v2aCeGyxM9riZQs8eNG68uQJd6sWxCmLPFLY6elLPGv5iaYsgROGkmVLJE0kxmyRNxEacgS0yY6U5ZgVkJTlqBWCossQayEFlki4oSNsoS6wZvvvyDirxwfV+gIO3WmdDvdityxtVeVL7b2asWIRVmMOLKJETemGHFcFiMOa8SI7YIY8domRpwWxYj3FTHiTVGK+LUoROCSEEEKQkRkChHjohAxKAoRs3ohYmgVIkYlIeK2KkRc2oSIsEGImDcLESc1QsSiXoh4tUaIeFkUIn4vChHbJSHivU2I+LlBiPixRoh4UxIifsE2IeJfuCxEYJsQkVSFiNgiRExNIYJZhAhqESJIVYggtUIEuZMQQdYLEeiDmPHM8/bXHFpGEKwfD4J/CtAK04+6xslIwoIUHQLtr1bcE9DyGCL9WV/wgn/g5TZ4AabypUsWC7HwIUg2+sdGYlP1aawSJ6MYOsyIdExRCOHBnimjGh4cxnjEKZrbpM1BYkK01CU3hJb4+0HwjwP05g95AbKNPfSBNb9EEdmUXw4KeGTo14PgNMmtymoxBj314O3lSCRhDjxSWD00EDlt5YjxnGs0D9XgEnJbj/q/HGToX7x1ZzWtU6W4Hqqh4E4m2gRS4NU0kNuxfnVjz71zlEPoGDZ2RHgnVJCBSI4MpI3VCLe8vRXxKbXdGYC7QXu0kwfzvLJhrpsHzPONOTS+0szTUOcCgiTNIUiMjqAU4EMN8zHWGdDpIErcEKzHTbASz+twP/rDWxn1GYpVTeMfDFOJTkKFdbHreeY60dAla5YJ+PeqJSLzrFshdngTVVO5Ag2JYFjZIo1U4KEyHspdCks5IAK+aVqxAoRCJxZgrTusHK6ubpXaGumqtYmIsrjQay8a4cOUjIfcsRkxVEJzhGWTeaXO97m12Ijg+C5jQHQux5x/jbFyh/mXeTab/zIOS11zNUBGYf6FU+47PKYzo+EVnJYNC+XgJuVSTuM7lSHW0uhTraVSI/+wtVTEQqnruznriRSE7Ugsm5SgoCvMMspwKZuUUzBhR3UALpt3rJDN8dDkU01uTaP/sEluwqWpGxls5Gka8BIezp3LFPso/FRDbWvuHzbOdmiOutEoc1w2zIVmPA9e8p0VHUc3TfykYfkPLKV7Jz7M7p1oFFO4IZjXK98YJc8n5bjpmawchzAsuJry8SM5q3RGzoOwJ/OuViliGXeq0I6gPvew4JJXfgUK7llJWi4B3j61dF3LWRKIO/XQwU3QbHQpnAgLK7hbzy5w3uynO5VZKxVaXJgkHHkaGBiWpOcW2V6Fcpw7nRXFLlr0rHDTQMaWBAR2Ma3u8oydC1NjGO7UExhHh5+N/9qIvMRWGYcDFyFIb5MzwU97F/m+HC74dpScGNuFGOAdCN164b+jZNjagxA4QmnCQok7XHKXkwKnKT/Do2MWULA4vI6YVLqAOKGiiUBwBlLvZitnPnUZUijIzkbwIlDijmjcDpS5072/I5vfakjb2VG11DtYblhNGE6jCW6tpdjZYXhAp0MBlOU8Vb389+sXwDbrSXZ2+GQBJIULFyBvgCft7bN81n+ZjNfOOGgXep3FZLxaLTME8192NpfLIWW4z5QiG9Rs41t/jsBJWZMusiCtLA10xK1Sb4KtLZd0YABXK/EvR4KGHzOuh/XQ2TE6PM+9n7lXm+ceeehsG72uJmx14TF24Trm28J7+GB5SrB6mgOF9nDPrY7gubXWZ/uQy79bzFMO1xg3AgwcutRDr10qyU3Q1CLWajOC6pvOCwHSESMJ2b29ERzUB++rxQRgOW9665G8KhSnaKn8o/3jPGjrr59ri/7y7Nip8ZAutpP7NGGcNUCKfoKWIh5rmXSiJInJZZrgdvsDWxOGuoxWc/LOziSF98AMOfpb3hLhOTf8tO2QlTpeA8DYH1jvLCYS+9ChfF9aK07HFpyBxllWmFwAHbARIZzuAiBj6Phbx5mXWXESq4B6/FC1zdGYrF+3M/kE5XzMU+WzXdLSUf8T3tBm9WJmgP82Lr7PPlGfdTL4EHzuGeGNENMiWJD/3DkR/f+cEyKZQPFfhr4noBaakGmU0Dgs/HoWzWZkCgGbh+bnsvNWrzMRhMDZ1rFdtMh2lVQFtPwWsx55fntJswv0Zc82+2zTTMXkagmlYZY3lFwKS+ATyDBErao/pZUvSVopLIP/LnJLYT/8LyLBlNrMD/bcuOmTShDFqv8UI9Dxx+Bv1l/UqHRPk8wrXQPmA8qG57757PPxj313i3SMh6p2e4uAUvkzqKysRsEcLlFGdcDYlq327iD4DpdH9EkuD4w/9e1hLqY/+PIg+PMppkWnWkM6SKFaAT60+TUSfcZrpK7xfx7iG+qCCqfVH3/qv9EWkKR4SsONn8bjP9VQtlUt7BI+k+xli1izPiAGv+YyMamels5zAS5fZk0XN5hIgrCWU3sGutC+zXZX3N1pQIwQRnfBvftwUaI5hM1mNQ1ojDeZMAvdmllLdQgdZo4nfxBH2zdrbY1tU+eh9zebGCnb5hdVrbJJnUF2KiY1bMI5KMkP8wbaXyawnRYNFPnj7FEjlXH93yGEjQ3kVQ2Rzdo/RNYRtJHONyc92px0UUfKbSn+eRO8v8m3du66cCezgQJbndqMBTiYUdW5JCw5VMybDA3QoiZReEtzGCRun9whjP/rsl5HIeVCfFH1t392XsZH4r7Hc5ehRS0Le+zdNd7lBx9HMz0iTlbDhPDFWFJdHmvVJQUwbSPi7VFubU5hf4UuRazhZB0TqeCkaBNmR9/gaNmYvrOjpsABEx5L326QisytWY1hZdXMxeLlTiR3OPzydeYVlx2Yai3LPni06oJHzHstnyN5rS2zfTXcFeszg9hwBEDcE8DdcvmTK1+sonJvtaJgC5WnzFUjvNWKGNb8siaIZvRU1pZ6qiF/vwkK4W67YTRKcByOaTQUfx7TaOjCTi5tcugkq+w1TwvjbsFaLD8KAKMgh9Pb0kEtLDe/aWpk2/WqhzR4Aot2tUpdatgXrVZUmrDQikKYFlUFPIxFs1sX9fblSEaMkSuJ7zyaWs6xortYcSFlXlYYgDyqR4fxdv8Q04koDuIJ3Gl8SwEk4FptWATlFViD/B4GECtXlnPGEJghMsAyNKALqfjo18AkikRhfnYuTPTcZbm3vmUCyjSo/OHveIpjMtgkrySVaI2/PDuWTIAtb5kGTfCEAjzoyQaNrqPVhRxt0II6WlhM3ESX/6pvhIUMsr6JJuMNspbJIOsvG+X8pZpxg1yFLBMcX+HcDNk+OgWSDFHXq9meiHiZjCAbiGOs+BzYsUGDrlY1mKGI6BwVU1kjUyVNbp+lPlH9v99wj1O5h5CtMr+uETWV+PX1F/3CVfRsX7mAoYrVt2+JIYkKXpJ+KeYC0iCYvjVeLzLhQv26eNbIxPP068IzozofU9/q0YpysBr/hwPjF/NFZA6U+036vx+gZqnBL5vPono5xDeNLtEmso2/ySMo2kBi8a1q9KwcuH4JM6YDuPunB0hN0fYBUvPw+gDZBnsjd2Ikxv3nAySH/McDpPD5/b8fcOxl5r+pHXXTVXz7BjVDXPj/rKUwRIh/3GSlaP22QPxmYIJXVEOk+z/dZBkS4f2NKPzKdZm6vx54SK5mv+L0WOOhaHc1bPTB84nblFz1XvSpi288JLeQX/HHq3Geq/N/s3u0Vb761B3d2D3Aaty6dMfKCU0+XM0uWagh4rZP3cnNJt5LDU5I9iSfuuGNh9Qhq/tl+kqritWRrL2TgZy6/zpY4/CzxmsHFSJ++dQ9uvEyVFrnMnio1UWluooObtR9Wlj/FTpo/uENAKZnWYZ+uQkS8MMSenOE++LnJaVjlPQDVyQCuAcgVLhnv9wg3D/nzsdaAFQQZq6IbbSf9IGrn40j/pAbOBUABEcKOXE/sJYDDvK1ZXBQllJB05qCtrre/rS2JOsjmyyR1ZQIni0nOPGQ1l0EeWAyhUPDMuPdTeVM+rlzUlZ4zczB6ZcGN0DXFELP2PlqJVwIuJYlcFkgXUlM6WNdOSILhELpUeGrA8XxUTa61mKWUmJdyhlD7F73nGfN326N/GfsPFDFgtrJjrRDs8DQCeme7rAne6WixbMwr6Darmmfz89LHs0GD+UuK44PGbniBwjpUsatlsT6ClyPBvZUJR2LdcHDoVFQDmSI9tdEUxTaEgj4U9WTkODsPA+3ler4imE+I/MgV64VxkaAildHy/XQ2Q06rj5zL1YreAI/RNv2t/HX6LQwe+8Dt25m9RJ/spfBe/WbYF4Q110GWpA8nuGvMCtPqpPlep3riOMruExgeFdJGH8On3e0s1MOi8MTIxyY26z4ij8uJ1agDwd1FAXYw1kdFRlKgG5rqoY7HNVRlKEOb+sIBczh5ZpyBMRhWEuFRwqI25qeQxue1JAoWMNFXbpENHxVk67ADF/WpAscw99rUvsjCZltS5Tohe9rkiVw4c81yRbMwh9rSItwhW/q2irTf6lJN0AK/1VbkQHUh5MaIgs0YVJDW4NKGCeN9WtAwmkNnQWLkK0nLTSB1tBLBEJSkyzBB6OkdoxZIuGva9MV5OAgWbMyDLTBtL7AAtDgrK5bElrhurZO05JoWEulkAUn9W3PQQWvaogMPMHbGhITSvBdmaZqJVUX2HXbPeJmSEeat7SQHHKSQx2r9TJptG86hgeTLdpub3NDLnBypQji3HnoLD33ULhB9m2X6px7Kmft40vcr5hCnVpeSqZVskMLGauSYat5UhQnZDDGDlqCPUCInGGURDvmaw4PoOX4rzd5Eqp7CdrBk0s85EFU3yM7xYDEA7gYHf9X6xPRZu9s1zgCm4837fbWrx9i2hVhwz7qJrftukwsTZolaMnhTPJ3K2aN5/kuKZfKra7CxGp1ZS+2WuokaX6DvlqTfrsmnRrpiLAjOUEwP4hgzYe12wRrsKhKIeMkd5no8LBn4DjBcbtSDO4TORwh4497G8x8/YvkJR3WvkZCWtHI6gYW2s0mRlYViuvi2G392m6/+aCC/oWbJwGvmaRkTTpbkx6vST9Z077FmvRXa9Jfrkn/fU369pr092vSf16T/uOa9Ddr0qdrxveXNfnTQn4LAVlTQbQmfWDucoswacszXFPmeE2nBmvSZ2vSh2vSR2vSb/FGuzdcU8zlmvR5IV38x80m+wHt59Y3Umw0TG/gOYv1OjLho2JqyDo+m51kLjN/JltJpoZZB7HMZfTQ+Ls0Pfq1saw+IXXqkxSFVRUJBbsONG9QNizqjfbDktG+VqD0OkbDe8qwObfLKCR/dLMmozLHHmlU80ZpznEtPtuKNJrrfXFW6HyJpu12xdbIaPZ6V1Zt6MU2MvQyyt5JY1LHYZXIuKseKO6A16KZ9wdM6AducXH0ljFztsnQcuaS4cc9b8nns0kXesXPd86SoTpj8zkQykzLPIiEjzsXoo7PNx9ak/v55kQ0oTovBRWyZXoK6R93lgpVfb7JKivVP9+cFVpSnboYjywTFuPRx52muCZ2+yeZHPGQ8fmmJMaj2j300jofeeIn2T0vP+fsFN6RPvu+eWmdKzxidSw+ny48Es7HpMLxp3Ucf4jmVY6frFaph84W6MjuM3zTIAscr9WRo8MSScHXYbtelpiXHYBfNyq456DbPlrr/0vXOE9YtNmLj+v/K14r7S4IWgwJczFk+/NtGmioTQA5LreUK5NfZ//ZHrMhCBjhJjrYuyP/WMWlOzu1HkonF6a0+jSzuFmq53LLlSFTPu59ISv5fJdFbi7w+W4K2YbKNcENFRquiQjSV6uzc0S0hU1auTDCugtjjhbVSyFdrUJ43UQ39gvjuOHCOFx/YWw3XRivG9/PT+uvk0X5OnnfeJ0s4Dq5qVwnRbc+8I0CYzOq7pRNrpOjj3ud8Al3suZDep5fJ6efcFsZB/XBeNyiI+ttclhuKL9N3ttuk9danb3k/fZpln0RZ/8czv75Jmc/lWe9WHcNJ/3FXzaCd2s677fleS+Kam0vCfjFcCMNl3nZBSq/DVQ0VNzuqem4md7+edz8Rx03MOH/Cxw309s/+LiZ3v553Hz044abUTYcN9yW/8/j5j/nuOET/uUfN8+n+I89bni//zxuPvJx8xNNGh7V+elCGrYvgA5s0U4OKOAy5Exp4nhV2IFWGnyOhcntzj8FkBF0O7OC1PA5SvVkdKYUjA6NWeiP/vBJIKMvaA76o08zBWS04QyQUXECwMPgD5+ChLstfDGTILwoPsU08I5vNhFAWpwK8Ob4w6cCcxeRL2YqhMfKF7UjYISyEtZc0W+mgSkdlkhz/vTPRxrrI03FHIyuVl/+u015mr/QJxxjo+k13GKyyX8+5vynPeYYTn0NR9gsp/pTuv7Pka6Naf/yZWyxkluEt/aPk7SNMfhT3v7I8nbjMfThwp5cEV+MvCfa80kY3Lqta5P6gLbI5Cpf7T98SgbaCfyLmZXcL/1TTIwegM3mRpFnpbtbeZLb8XFr5qqAEcotxtLN4WnDpqn+wBgLnx+glmimjKC5AUO5CNIcoJYA8giPCl1xjXfJZqi15KOh1i6s3guhXEpELaW5xYHuKEetrYAqhJUvTVximTY3R7zjiqGfcMWUG32nhfPJuHlSx82bj/EWmIt1x8EysmQC0FyG0qaJs53qkRVmw3LCh5/lhM+Hp2UcE5/kuLeOTCYj2m6AAhCiTxCqyhiqaDymczx0PM/f6n6hbRzR+JIMh1yL7NsiE/D9Eqr9Qqx3p8B4WbtJzNhdG+wO9HkWuOpTaxpNzJVd3qezIr5N7xNcyLIuewiJ6kSVBYMy0s7aCUtLGT7wREsrCD9fzGlmDImSeT/FSVYZkU2n1D6bd7ioUluuf39ev8irypzcT31X2cfmA6YZ9GiBawVoocFWtxBET84yVxgAFCcDzSLtOa0zhbpz7viOU9//j6p5vthekmx7mWYXXoYAeyuE/61ZsXY1H0QLnKaTj3voQg3OZ7PjBc6hxfWTrNlJnPGh0Mg8ejHQHPTQ3Uys+qSO27yZENJTik3ly4qjq224OCyKEZZ85CtZtf8zaVnYJ3acUN0trKoO/5pPXo6IF7jLIuJT8GSDxl5YD5O8oNaa9J2dHH3qAhw8eOM9FPXh/pngCXXzJnqoooAIK18gInn5m+gOB4X9ZPJ1pRGfaeWp6lvzazxtcZR1PFx3PtWChhlS/Sc9e+ZRPCXTqxwyoqis1a/PH0VjayA8flFq2/wF+9Mpb/Ox2FyDq/NwPok/jIT8/4Er4fQY4CXnCp4qg4TSgAHEsH5YobrH6QdeGmHI63YylFauMgOPsya6ovT8M4L/9f5z4iEWgh5WXPEkTOkHcgJDBXL6kcdT1PK5DFBkHz8/PyAHwsLO5ViypZncErjynaEmqLtN/3A1WH4NOEMT6lY3WmHb1jRZxXP7ZA1WYfAdqHmHCkRd3VoNs1vTXB1k7tO3l1etGqyNT0L9V+DmRgUs2Ooi9WLv09wiwohB2irrKo6u8buYTl+Sq+ukP+BGK6HVmoQCrr9L0RbjdiQMIinW33aXwgIGLcGEztc/12ynSHXLySFgP/iY1YUZ9g4Zohtt9vrHMaNUMqC1FhQlsp0dNUuOz9BmOUyLC2Z9XkuRCAoCWoGS7VFY/lBYKHp0i5e8bdpJ8GRJXZJPO/3jp100bGeI8WxHZVEtJBliPedIjkUrGo8dX1pa8R8QjxnaH5oLmUd0sEzzO9DQLCbjKfOd6ySZ+bu78/m8M3/QofHV7v29vb1dTjMnw+Tad+5/46BrDAWKv98RPD+kC9/Za+217n/Tuv+N9eKaRcm1g5ZD33nW3Ws9Ov6u8/BvrW8733Rb3Qed7ret7v3xzjedh9+1vuk8/Ntxd6/V/W78aOfRewegL0fTMJ3NIJ5RzHjwEkdcE0bMxB6HCh5cR/FB4u55nYSeQo6jiGHXyyCNjckAu10vuxABLCWXx9ByTOlv6QwCY+BFgvmCzIKljdUDNhyW1xacgJxFU0w36eQtdE9461wmSXi2vOwgCKi3NEtSeFqNZSmiYiMAHk1myynJ0MvER8fJkNTfQVdpMMXz1s84+u0EJw39yyOUONH01oHPpEPYIaVjHE1zwcRlWupgPU7qO1P8Dsc8SxVCslwoVTE3zO+0Ew2HoGTijRO7JkWmzVeIuHYdIgDzqeRqPNFHGWmlqOALPU8HlQm1AKn1moiC0EaCeU99miPq+aI1omUXERT0+AwW0g0lU9dBLcfLzhGYVGVPLiCU59xbFoi3l2ZxQKR6yZOj6e0TJ9vnwVOnNGm3eX1BEOh2wWc9NHJYRTeuo+lwDDfWJQGeQ7KoLHAJSq0jwM7Iuafb524v4UPNSIgOpl7mXWSGSJmhRXBWLDX1eqkuRHTL4SFozTlxPZ+pQp3WquXAyCrStEx65kBdDnIuxVpztIkxkrmQM00nlzh2kEOmCb7if8nz4Dy3DIG2+a5aD9JE4Dpiz+dT8d5mBM5YrSrppDalaidV7MK+vbr8wcBSpv2V3kJYtYzQC0QOE1R/BiPz4L4YoUffmMPCIDjQJMrXlRpDkW80phEM9ZCml2PcmFHOgrW7EzIlk3Ri7cIkWtSm4cVgnDLyDj8j0w2IGkqapOOEzMY8wkuxxa1Vy+xz3RBbSxVDYK+QTI+5+q6u0ypVtUYu2X0dFwr4aqkDXepzWH/SfRiPeR69QYy4wQVKlc6J5WTV0LZ+4skdwvpiZLQytqfXh29OePX+LdZt9K10Dkk6r3oUmjdGXbkyuXAsQdCnmvPQdYQvMhLnTj2ZQEhYT8ZxW5DTarcc71wF55a3YuGIyzUIQzzGCX88W6xW/IjPkOUm5VxNdUpRroAIpMGwuPoD1m5XR6vdLq886iEzGlbA6rUdOXb4alUEgxa/yVD8K/gQ8XcBVFJ8ivGokPZS/+ZgeAYkFf8hwY9yXJIcMsDqprta2RxHq1+FL2P1u3Cs49VUncDKbhU1ZsvVhPxSkIXk5nZ2I7zN7LSqdCU7kSpB1TyikaZYW1FryH/Dc6C1vyypGwhDJ10l0Dq0DNh6re4JmFjZcOKtVmf5HX9Jrsg0MRgC407SbGVP8b6eXzpvvN4FZ9qASzHr8wps3IUPO7HwxIksQd5eguaA70NETO1B5XilKKx+JFor3G6HPcpNuC6kWT7LLnxoKM0ER3kuv6W9iydByyAKexePA04iPwjWzNJYVtjr3EGIv+ga10HOmPc6+YVZeEfg7Gme9jjYKyd3+SVVk12w8HkiBFxkkKty41B1J1wo6hYd8Z5f7Csppbv39de0k1A5216HzcYkcZ2O4511z+Xz2b69mK9JtgvDdgER/r19eYqSdpt2Zim7BiUXBfFcLTzhzEMyTz+luKxoWiuZHGG9LbkaeAvodcpsDAqLXyXt3LZwFtWPBB1VP6bopvoxRMfBUbvtbs1XK/o4BXesG/i5WK3Ik5Czpu4cYi+22xCD8UaP+fbyuOe4ju+cORn8nfpiJR72Qh/MLA57juf4zrmTXUAhvAwz6xPHd/6f/+P/drKWys3peB2a7rDnPOZ0/9PJdNkX+8XLPJ+bdM3cpJmHWE+ypRVayYJIWsW96ukMg7r97TqgRYgGCY6Zg8Q8C94NiXkWP3QzwzVVh6KZ8g5YQ3wxiZLBNWawuXWe7EIUIc/WZ3hIIpA11pYFhC0uP7e4dqSUv1Tu0+mADsn0al2xWNIVClWZsws9xPP6IWY9uBVOp+T3FPNbquek/Ic0m/PlTSuHn5PI0ed/68GfVxorhUTR1rluzKJpvsXlrY32ZK3K+0RWrH7quhfNdS903UcNdRuCoaw2v5FlxfkHz9wrW0FwVGmBkpWVK6KHKFcAWUwRBDdJDTZIpfXEHTqK6cQt29ZXqb1OjIfpALuuS9EZQSmY3Jf1BO224bTBvJ4rlDzEA0UL9ZCKcuyBP8ucJNeVEM5CfVXUypnRi5d5Dp/0UTG4ql8Om4OqoVX9mhAuKI+v6ptRIFAxsqpfjk6ArHFV/RqwfGQEV/ULqOyoGlfVt6GFIzOyql/EqEalqKp+BTgWmUFV/SJ0KTIDqvpFnEFkBlP1i6BgKA+k6pv4PUiHUPUNQBlkxE71CzgnyAib6hdgN1BNxFS/FhMCVQOn+jbHa2SGTvWL3pCoFDbVrzjnoUrUVL/ZQwzVRVD1N3D/QQ0hVf1GTxRki67q2w3yUU2EVb/WIhw1BVr1m02PkeH47RfMV5ERb9XXhp/IDKPqF+3+UCXEqh/1UV1IVX8DQy5ki7Hq281+kBln1TcsTFA1wKq/zsoDFcOt+mXDBlQJtupbHs1RKdqqX3mjRuVgq371YTh/evUtj7CV1zd/7XMcKr+7+tVXLAThzjv5uZ8hGQJfBXhXJz5/y2L+7q7xuMZftIZxNEp25TPbrkhwkD08/J6qDqoAgdVf5k8zfuFZSj8pqfcWm5bHUOcU1DK5/OabQqpVrLNe56Kdo2mWoR+fHwXU8tKXVKEKbDa6/A1A8Rk/Pj8SDN4iYcGycif7SZ/X1xkSNhtH/JwImPkLUjMEUxCKkQ5h4MPufRFIv+YCB5rufbjGy9/WXet19Btc83VZy9d+HV0dG1BHvxlbUJe7yiasqaeWbajNZ2Ej6mjr2YqaHFY2o47WxnbU0FrZkBraCltSQ1diU2qoLGxLDaWFjamhvANbU1PCWjanrs82tqeGtoENqm3VpmxRXQEfxCbVra+7s03N/Wpmo2ry3oGtWl/C5mxW3Wqusl11q7nIhtWvkSpb1kSr2bR1W+NObFvDCl7LxtWNVIWtq23xHdi82jJq2L76UWpiA2tyNbCFNTka2cRynma2sUz9AWxkuYhN2MqizkHmk1yOVR9RqQUawJvCCJ2qrMDlTPtrOEdgjwqMz7LAGxo8I3+xQ9xYEThSZJotIgvEV5Zl3v67KG6N+0EYzvHlLBr8FkqEijB0u90HD7oeGlhTO1N33Fc6LK7a4tZSLJrMIMo8aGLcslJmqbR1PkFKWeenKM01fX6YBRQt1YOZP0eGqs1fIEPT5h9lAd0f46R1E5x1Oh12nps+zS3Pn3NvaXtZWHjt9uJJNzcBuulEYCO2P6KxC6XToLtPHy/26b2g69100im7JqME8JEqZR15ma0KruCCoPbuTaAszfZQ6nmoQkq8dps82fNU5SzY27+RTxePyT4TbeBKvZsz9leVpA0MOT5ByGsy1HRSf+begCXTTea5FDEPiQESRlHJdUznLaB7Gsc0dgFmuEVgKid4yi2VM3R5m2D+bHSSuKCJR3E0HdJJOCOD3+AzEwOHZjEeEjhCWHiHp2zCchqhVgTKQV9UZabC9+Y6XOatVtUCtdVYK+0H3MCy9RJfEZbEt8thlETBMtuP+Qccc0lnmVwT1oGkM3Ye0Cyd5sneUthlcIsmTRcsM1+86bfMvNkVhtqlhVUxKUPcHIfPw/2vv36w9+TJkz3ETXPUt4cPdrroCk9xHCU0ZiG3w+GJncJ3YZejEvAkImP+w0kZjv83vOB7E8RjB5HhNDQI/r//83/+v//X/14kuaYsmYLMxikquYvJogCZOnsnmu50//Zd52G3093b63xznyc8Egn39/a6/t7w8jv/4eXfHvl7e3t74n/f3H808r/D3W/9R9/cjxyUxkTkUAK70Y5dnhzGeIRjPB3IloAt6S6ZDvGic51Mxg4i5SLytu7yVGsJkkgUkaZkKNIejKLvHo4efbPz8NvutzvfPHx0f+fywWiwc3/wt0cPRo8eRaPokWhWgiezcZTg+uYPyYDzjvHt7jLB8cTvZuKPzBFC8YySaYJjUcJutHu5O3BQjMdRQt7hsErS3d1z0DBKcJgQOTF8738fJRiMXvsnz9XjKCdrpOiw9FJoGNw91N3z0NoyjRzdroeGaRwJ3yNo24sH3zvmWp1FjAnDFUj9Wv4H3bvCC/Hxv86inffn97aVXeOszw1Uxc79gb/Yqf3bEvazrJX2l39JgiXfUmITIb5b/PL2QWKz+JXtI/YNcshwusP/dHy9WfSeEMnql6DQabD++VqHdYAcCFutV5jjF5YsLD/kkCJFYUnytScKUStKlKF+Sdt4uRAc31wWyFGrZadIZF1EyIFFsQPz7Ph6GfGVgsRfckaRmjzfMqFiBrN9cRx2Oh1+2v0lyfavcNKSmjTmqsPQpMgyJB5iD1701ROUM0qnfJsYz+C9Wb9TOKh9aRzCUwqntD/rd8Thu6+LFoBgoh18pc36ypuIKRPafqDJOYs0tLNI33x3/7tvvc5hOhrhWOYNv70k/PIa9sX1y7zcKsGJ2IAQR/BdozsU+p0sdGQrNE1G38kyJ5uXyZ9qcczCSwKHEJQ+sZUukh0P/Z7SBA/DWUymibYXEzyS42iGiQR7++Sx8lfeJ/fuedoWRhjhH9EhPkhcwk0OHoGJSOrRe4ETPPje2cdjhltk5KZPggcP2u30cfBob7VKnwSP7vNf3fuPVqu/QZ7V6sF9lVdb9xNPl9B9IKi6e7qGt/HbqVlF9/6jvHHpFLNBNMOuGJjTl/3c68co3/P2TfaMPU5VV9m9e6Ifzj3X2XPupWZvzSHtPvI8yQju3C+7I/DWFYvZLKOyKsz4QrjafCFcRgx3H8FsXtnm/xov5OK6vVuZD+4XrIxu65cuIoFzcHj0/dMf/v5j/x//PH720/MX/3p58ur09c+/vPn1/oNvHj761uHMfhrsgfmUg+bBHloEe6W5oOZcQNI8mD9+/N2KFqcCLe4F3+0vngQP973wXkDU7M6fPHmy2HnYBrlnsRM83F9wvr1A8vjxw50Fp0gD97ud775Wdf71offXh5XFwVsS8ulUivNQzNC7u43mo29gNN9ZdyhPlpN0eddi03gMJV/Wl5zGY0fbxpi38FNxhNRdww4chY7PT0TkfCd+wD9InCl+6QhCjjhidvQR4/jlQweJ1YrEAkOigUi380MvHtmSdTdPWHvzhOWbJ9Q3T1525eoJK1fP036Q06N5P1g6oCzYnY0jMnW4zkDb3IiUAWPye4fhMR4kNG4tW5c0HuLYb3VnixajYzJsxXjYyvJM72Qm/trTFUYh98U/DxQVZ38F2ePZE2nN83h39kQXE43xdBjFkujw6d/7P/mvjw6On/70/cFLRXUTvYuE+krSQVfpGHeGJHa/+hGPx7Q1p/F4uPWVt68yLVTVXz2e4ZjRaSu6woHzYM958g96PW19T/HjXZHy5CuZ5+viAGXoBMaPTKIrrNK4GOvef2i74TK0APooHRK6Gf1vQA/PZhvSH/HyZ7MxSK+ETvkDpOzm0vkNg48UnwIn+woVCMfDewVawXi2HDUWlQyLHRA6hjuz65mawt7setbCg2va+urx7IkY95/5uMOcfrXf6j1xioXEyUhkFl3pxNH8Yvk2Tkbdt9EQj8bR9Kq7d//h22jKCP/fYHbVvf/w/tt00L0ot4ddy9bzJjhm/U658bDy7i3qV59JvMnIy9313Dy9tK1b3fnV6XTmfXg9PeH/X/D//8b/f9T/0JNmomo1zhpud2s5b5S57PPSsZO74gUmTeEA0oaxTFnC7jue1NGkASBkqe+76nu2+7W2uHyujq/VSv5J8j9TL9s3+1E5155XzrWDfmDmQDCBt2KaTLPkqs5Q2VSC0lAZW/qpUv6FNRbDUpMGl3cQaqUbsSndpH7OW6pbG27w/VCp3VLOIoRn7N69v6qP55m+yhHMFZPP8kUdaKEfJVNIQBYuWVz6KSoYyIMylKGltPP051I48hfw+Sh42ucz8mzhekINqmbuJqh44Mx79uEWMF6gAJN5p3jupsz1QOvWucJT18sGYHS6LPruZJnnzj3EPL9S1aKXm0IvZYvBrhuR4Lqfo59YVjvpEdcokZv7+qYazw29drtSYdpuaxcp2ivHaFitKppg2ivZ81PPrxke8QWiQdi6mppdrcym7PTBB3S6pjWKCLS3R+6Nl4lNJBZ08yYSpuZC787/SlHZDt0PUdkIHXxoKTIt9Bfw4Sio7CHm9bq+/Pr0xUn/+PlPUjdfNVjvEYG7clxNS3upSDsU27pCIHTb0sL1pvcsSq7BCta9QeG9I8+H/6FKpnm77R6rTMcyE5m6x2i+c+T58D+whX9y3G6z1epmtTperQ6rxSzgpWAvfyk4/Oti/zAQGuBD//DeYoepY+FQHQuijMC6I/gAab8PUt219N/dSntiOe3VrBMOgq5aKl35PlZT7Rcc0V7tcGGxOUkG1y71loOIYemm6isK+OV6+yrp0Tdm0qNvcgH4Lr2WLxGFV4nc8UXdYauV/hPdCA7iRUwXt+6Sv3zJpxRpkOWbFwISC8A3VwOSQ+0XBh7J6v1CwzhehXgo5K484DXuKwGlMgk2D78eO6PnvISL0+lvUzqftl5Jo3/Kbfz7/eBMe09rd+p6N2rT+xoa5ZzDI+RTodgOpBPRlvX5Rc72Vle6/khtOPMpkn/6RBnbwRXP1LPWlluNvaPE7idB11utcjfZdLXSfxMvQ3iRxNEguUsDTfekTdvYamxhjwoOy8+b2UvzH3Amih8ZetEPxLLyz5SjQ8Eh3fAnRI7xIOpwN+OjQprKoR5eHeQYr62OxSHwXC5m/6zgfWB3QbS5nJe9EHnlhfSC14KDHB0TgWMjFUOyGZ9e5mR2F8VztQHPVLMc7ZRtumCbDtfIKfFl+Rd9kztlx8VzvYPPtDN77rpucVS3uKUXnNDPs/0X/Y68K170O7J4yTk/6+vzFpHpCMfQqB9iOnkNgiJXCuVPkfy8UL6SPbFBq26Pcr/7tns8d+rWj7MjOuZGP2rzlMtrt5mxGZfcLVA7dMi6il7dzn6JSIWKKwMWaO2h8doMdwUZuf1+nl87CLZopu4BM515PSa9IfUQWjFP1h4Nz/oWbBL4qpFJxljiknAWn8lTAlBSAjWQ4Nui5nQrvwx1Z83QGi/63j72lVYxDfb201zNmYJFwFJ5rdGz9BzNgxf9s/DcMGDY26eP5yoHNXKkwfyMntuADlLPW5Ig3L+McfRbC2dZlhme/XmTDd47zTmj6ip1U2/fwjawHgCCW0tWpQ24pz2G0lhAGuBLVJcK4CW6JRK+RB3WeiJSrwhjkgZmhcqrHyC+St85KICH5qXvAlPAQ4uAI7T0jAbAb6Uy3Odx9Far+WoFFijm0jhLUYgg9F4ZQsDz6uYgv39dpkeCBsWbT0B0W6aGWqcG7ieSWZAKyGr1rC/4p1ChBxVH2uOJRxFLNrLV4Kwjj1rBesspTfxllvnLqvint2KP+UvQo8RXOOT/l17XUuQhI3dtne02N5VRmENbe/sb5uqaubob5KKqLnqXuqiqixbqKhxNxvFDq4n5ici0kzJooihor1gmDrGEO4oy+W+Jg+FfbRK3TNGedlO4Y8fkPS5kBN+AQQRPZ/znfsr/tdklEbGyy9XriFblljEzyU31L24HpgqFv3NC4QKhfp175x6iBhADjEH+Kz9QzcIK4Y5MalRKLBR1Dh3Pf4ONERzM0gkW3M/DvDqz2LP5OY/GBAdJ5et+qBEh2+0tou45ZYm5WoU5BKNJoO0ue8aoGSMIsUI9de7AtmTgRut5flpsQ2BsPneBQkQ8fe0WV6GIUgfKOPMz05/dVPxZKFEmI5mbq+1KxSoeuFqykeKm+lepfPUZ5SXV1aL5PntVZrKbFj9ZKtVpqFQ6rz7N0Ku+mQtNIjINhUYRTmxB+3c8xTEZKD0PIoG4hVEabHXlESiR2tQFHYAwJGZo3yKIA2Id/cdJu+2ygPE/Xc9DLFAHueRrWmGQyyyrVeHe0Y7dW2F1w/JrkfOJ/C/FLcITro2c366cnP+lyfkxBzogfm/mJ5BupsklznuyNl8V6O1vuSx41XcZIsAOdBaTcbtNxD8ug38D/stDha4VbljQLLthsLXnZcqDe5lxpm8xGfs3KN8o/jGyBus6lAh120gbur7OAhF87TQwL1a4S5elze2/R+Xd7L8BdfhNcMPPBpinXyWgNcYSG88nGMEXNosG2I9wFtygMYaW22CuEh5Rd7WSh3dw6qG03XYxDjBerQBrJ7riD1Do18AlGJBKCM78C99xvHsYowh73vLoDFIEeCRPv/Ad/ss5DyKcQYFjfPbreQAhdiWzhwPB+INa9lgsuRlGQxzsaXA79nQxwHiIh89MOZK/OliQqoou8u126Qu8EAzxk6D0GQ2i6cFQu2yAy9yW+yGle6vVVl2TXVhIWwATu1V7v4k46vkHuRNWqy3jmykFcZV3qRk7Q7yjwe7uXJdiI/b2lTSh8csN2hGNn0aDa35p0HuBPnTG+Iyd9/b8ruf5NeTLEv2v573OiEyHHEE3V+2fsXPPg5IA9LPSyh0KWF9P9rhkOMNB2nMJUqeV7DprtwcYhAX4wf/iO17/xTeg/tXR6MK5YFMcO0EqosOYB4/xXWp1oFHGESJa4R2d5ZXBblqtyHmZaRek2mRJDwez52acUrdXn4uiGEQrp0saVErZPzpLz4Ob/hk9BzN3MdtZkayaS9gt8c+rFWddD1crUW/AT0YZ2BKcDmAhzYOmq01kRBSFKPX2i7tRnMBDfO8eKs7H3OuJA4X/X/Gfc88Xv7nZ/NzzMh+WhlwTlaLF4uB25+CrOojJhEyjhMYAtzGbkekV3+eFpI6p8go4cJAFBG8bPDG9Zc7+0RaZtspFqUrIyN3pwkSLfB2Go3hw7daQn1EQgcd8tKmQ2jMxI+Lb5mMN45plKBTPoSGsXPPS7xG/KlZupd6y5rUmH4fgNAf7CQUCUEWWDVcrS4ZWuG88UfLHu1kUM+yGxdfJVii0FBpA9NSznHihmOLKBIV5bWFwFp5nwpjx7HzfwthKWXQbVuI2P0W2xQnCr2C0nW+UbWOT3PB/ETFwZhsnZhtRxHUxJc5U1v4aan/Na39t1v46r/21rfazxkpfI4oUO8llGzg4SHDT70TixtAv3CTz0E1nHkezGR725GVO0DVzPffIW62MbbfkWO08cq0/xgFBY5wVIB2D07Wzkm8cBhsn9IpsS4g4yz/gF44pHr1freRHUyZ6I04n+LyYjHv5ad/jByszDzd2Du//5z5XI4X8ItKapObeQj8loRgeSd4L/TNNhcJzNR7m0tXrD1SHttmHMyItLwGmb7LGZeCVzs7XikWWb3j8l89g/sMs2N4nomedTkdSbraCOa8dIuqZiwo0WepCK7dCCAqqFZJ9t7aCp/0BreBHhLuVrlZpu62Xs9aebL5XVOs2z+Fl9qnd1lNbOFvyqW08X8pTu73x1G5/xKnd3nhqt7+Iqd2+89RuF6dWP5bXnJxmkzY9PNEYez6pnpza5hgOxgEunYwDXDga8wAkq9UdTkvOL3L2Om23j9ptewPrhR1Pn4Mb6D8PvXb70Et7ZiVFWdp3Dqa3yTWZXrUG0bR1iVvXOMZOBvdLp0jaBR0J8DWajbbsuUNDnXCIwmaOiRQXBx+SytVF5M0Ce7LdNoRmzkflaZ7RydAzGXga2GRO8zlVyJxkWpZoH5e+9kq/d4bYf2CYqnf32eOAclv1pZJVNpjFNH9sWGb79Kz0TOzcg5uzo3uOjJ5ST/On1lySEc1vT86PjbAVEnuE1Z96illVq8QlMqA1hbWS8lpRmdNAAtszREn712tcMBXlX758lIg4wiBznZ4Xj4lMC9tukYEYYZODGOFzfjaMcCZUhgK355dnx8ruoQpM29xgoEZbQp5OS9DdhUeiMTQoRcshHowj4SDmb4EHKzza+87bxIFQLNbK8kZt0pauJ0qJMaPjdzjOc58xVDHpK38h3jn6qR+kCRmzcIInlLzHP7nWsSpW46GX9mzl5paySYXV96bF8XMO4FBnbvyhFsXRjISUl2yYFOebhLbb35fsh9H3yrrXQ8f94GwJMRZ9boy+i9g1TcfDEzVy/GHTP9NRFLJzdAgmQ/ICOgek5iucCFdCMSYSzgle/1yYulSI29I6bMpDc7jwRjrvlOENOnKMhyel0eUFhR460uZ46CY47uf4kqLbtANd6SSYJRDlgb8CiccfW7fOfeahw75m5ieJ67k3iL98BEFw5HllW9GFiDR53/MXmer5bTQZ/wE9v8LJP+AbL8joNJTBVSZHXAw+CibTzjCdzNzJtDOm0dBdeGg5JlP8Mw/Cs9PNkArKNZ1mHnLegqI/ODo7Urqy7nm77R4FR9ouOk/xpEhtuKsrZw3MoQGYhxz+l98awIC2pjRpST9Q3IKhaEk7KY3Ef9SJ8WwcDbC7+zbZvUJOq+V4evQW9YNXGLqQDx1/t2y3t6hU2vN/4eEX6a/iUl2KoJhcecLpYNkA4jhR6O2zAuK6TVOvbruvHvcWk3HrHY4ZODQ73c6e01JIt4Fz+uqHne+c3pO307eLB4OtnZ3WL8+O1SgAdwJDdIn1KA33WzGlSQsLeAUe/b9FWCudyiinrZ2dt4sH+CveWaU3Ehe1/NnhUMDu7tuTr9/uum9P7nnbu95+3v2AnXXP9eUZ1m+zo9LZJwdez451ZsD/LgWmylSwltZ4vXEq1W9NNKD6rclCGGpCsOFXhOAYtCs2OPF69n30y2Rc2kawifxdF9bn6nYy9taV8CayFAEHkL/xzhX0VoA7OawscMGg8+SWJXhSijZZe6pyG4yg9ugRSt26vQWpYWCb2zxwN8zhsgo7U77ufPslWHOJ+003vMz0XN1hfuFGk6lPtVec/1R9+0E5afvX6tMzw8nEP+ij8uXu11z5NdeO/7KP6vaK/1MfWabep8iygHyCqgvTT+Fj4UsoDFv+ocfff9UH86t9tT9aL2LMcHIwIzlDcAb+j4hG7IFttTWtQJ6pey6fMskU7pFwNk6vCDeKFkCMoylgpE+RAcjITJhND42iQUJj8p6zfyHjC7rg/Pszdj13OY5uaZr46l/WEX8gYXEAlfA/MsRmeOAv+f/hrInHPuuAdyeCZx/MkpMpmc0wb0fpS4ZYh0xJQqLxSRIl2FOWhsYnrUnkiNR01CoBWZfIPf06lbbbwhCqRc+IUgwvRXf9pUACFWPD/8qQGEn4NOOzxhCDIn2aZYjRNB5gFv6e4viWv1+aA8Y6/LvAcno6BdfXYU+84qnXHeArT18en/CXgRdRHE2YGyWdMRV+cfLJQDM3spNgevNUddSDVzN/mZVo1EBQz+SvziiEsAyecFD0EeE6etpTvT2NxwHxnTQes44MGQvPIZLojJ4HxGdBRF1P8u0IuM9l5uUjkcbjwF3y2UZyUDmSVMRup4OWNP7bUuZX4u06vze2cvskOf4HPIH14IAWA3l4exqP8/w6ekNhXPPhgJ7QCWFY2fzD3xzLnEtSrCO5/YDyF0kYu4Dw90kY1zzOT7E9peaoHgMv9xJPaCIBvECKkosbpP94gGcJjX3SqX5EMWYzOmW4TFj5mnHmdpmqlvMl4KFUdTRDcTrNoV7sw3I1ppfR+BVIIGqxcfsJGqXJ9f2XeEhiPEhgQVxsLy3EUFlCB3Sc7e7aCa4pS7KavFFyDSxOAaCmkXAcwWAN8eL5iDs7etmuaOlOLJvKcX4uYNrQD/1AbxSM32N3CaoCMhR+SUM6+YkOsfghDqmMH0/ilBI2xkhvCPFTnnbOYcTwMf/bQUM60HhwvjMmLHHAN+t7gY6Lh6+iK+bvdNXJuMUdtMkwSmgM5WpAIf21w+bR1RWOO4TmHx1UmRKpbkAzHDPCkoM0udYHN1SjDjFQmYnGPJ9hIdb3h0Agv74Ui/D7NNZZhxjPjsn0N7DB3+qiJL7tJ8/TRB5e8MmynOGSYZ1BGo/F9c8gGg3zrCsaxDIQT+fPUjhFh7INsFGkjPyMDvH4JZ4OMfcEULBVTiFdYQLOkmu/W0hhxSSo6inI6DBLzN8SX47oZEKnxe+AAngUY1B5kGjMjL6qe6k6CirFX+YwOv4SRiK8jNi1L0PMO4PTl8ctFz55DmK30yRa+ADMcO1kiFPP6BzH7BqPx6U8LyDhBBKMnDm1yj+YDEsZj559X6krK8Ngw7CDA3gaXWFlXM/S2YzGCR6epJcTkjzDyTUdgvbgCoMTxizl/6ew2h1xk4LTkZh4BznXOBoKr5IB+GYkcTTAzjmq3oQwjPJG9c9yhuhcX7gQ0si4w2E5A1ubmTzMMpNd/JFcXY951NplNEjIO46JubWHkmsMEXijqyiBcPtUbQR2An2Mxe5OoqvCb8B3hDWXyFNiAgtLGRg8iwYxFd9ncGHjBMf5N4nH+LsdweNR95tvvvPQdh0e4+99D722Z/32228BsOS0LutrDeXI7UMGEUtwzEKN6wghoiqBO7ghNkWFHMqfjufhtojCznq1cpI45cwAW6268lNX/t7qyph1o2jMuH6ctdt78tue/E3h8A2ndIiL4cCEj0vu/MKKLRIHaIEX1fHA1H1m6wL0QLoJ5mVJVqMBIoTxMYGGwDqVI8isQ8dXgabUZbPacosZpMd541jonhb7IT2N+CTtdM2gKdyuos+R3rt7enz0K8RP0U8gMlMAhy+UqMAkteOxRG7ko8H47qjrmGnyUe5soY68w3m3xP4Nr9UGVjOTV7+lFptYW+KHWpN7YqzMXd/NfIq0JsaoNHeyWq0cTSAKEGnGcL/vF+PPKEMs6fVZGrcsv3U3I4R73CQtLYqscBXXlql8TOW5zr1B/B/6HSNzVntx1pYqnVyrhVrLyWpu7tri8x7WXNv/TsNYsWVV/ufuY1kpI6vjoD607FI5WZG3XD+Qgr1tXk6S7TWJ1GmcKR61tiYl1I+m69d38dJeR630Cev6WOGs77pIygVktmvdOoTqxMssrLiZoXzkZCYjsa7kMmNi0ovTNxP8RqT5jTVFWsWDO69QWym5UqS2OOFVXylMZMs043fX/CJbZpNC6teuHJFKadVCqsqpNeu3rsxclVUjQ9x5HuzlZFYh60OGwqJmsEpKd264rZSsLJd9UKnl8koi5QeVWSxDaTHvuAggU60gdcclby/FIvYYxZZZqmqhxcwZckpfOpqdcj5gHOvKstTD5TNn/fFviGi2k7GsqLhzm0sFCI3Q2lZxfZH1GOaTmaGqLnV9oQUtUeOdXlZZ3LnXpQIyQyQAfrmkSn7fL+uSzQpJsfQ0O5f2+dt9oS/2ci9J4t5M5UewvmLBKdCA+br2VmjBm8SSO7p6kmXOPYXBGsCTyLUkOLmdXNIxOKS4jmRzHHhBM7+bU5DHiD07L5gkc7y23ByKV75fNMSjKK+CO33TjvyN1LOC+uChStbKavB6bhrQs2rCuS7OllgOg03hZQUsyUo18s+o2HnwcQxop1xo3vxyiodCbe+lHAT5W5Cw/NQiJtkK7FMBzn5qUALiobREWGpeySNEdc2dV1oWpIZU7M4944XtRKhST/suy2e08FwjwxIEUlnuei5fMyqfVPh3hOfnMkOVFLncGSKIL+QwsD2huSkgDBRe5eDTQjgmJzQGH4j9RW7cc5YqNgnNz/MQs4uOfmZ2PcXbCYWaiAS+6DD1JsGgZ0flJwt4nMBD18tQOhtGCT6Z4QHPucXn3BJwg/GbrN0uukbDN0/7c/bcI/5F1SLKhjcRx/GQLe2YRmB0AfJBylyHpYMBZqyGGBrploO3iyZ4nm9RRhQLGdL5FPoNNpa8j1v8XwbGMzXN5gQespcjUz3gu0DOVXAqTG/6o45IyT8h52A2c3IHMJ4QkmG+Lod0kE7wNBHvhScSATYn3NeFUlmYMLxUWiNJt1qZH6Dq1apo+eOc/Abs47AVaym9NaWtC5H/okXj1oXMetGaR6wFY0BGhIcP0U6D+Wthz3X5w55bAEkUaT7LghSRIJpHJGkZ74Ol58GjzIMNETZsvZT7+u4XF70beiiP8m6sltCTkwPORbCrjzzfLeVNPWMTuKnOkQJ1lrdEbLiA79PKCRComJTM/6Ev3vx986bSGBr5Zaw/PRNOX8x/3zePFingBEtuDRDNCPNz1XiBUBwRwRIEMx/EUiT7JpvAXnAK9L3QBMFUg1IoHAutEHoaxz6OY/SDkPyFgI8gfBIT8ZOMAEgPfdP84KGZciKhtQoE2jKhEvLRZtpQJrIVWTZ5EG9wvhyFUNogHNMr5o/pFUPPZxjsTh7s+dyiQv3syp+5WE6n4UD+jV6WZD9VuhS9QiYTECwanQpbRE3M0ZjgaeLLd7xwwH+i04SM/TQhY/Sa4Ln/juA5/+sYX0WDW/47HPO/0ffynIH9M8z/lnN5UmSeYU7LrD5M7Wth5MZ8ae3G0Ek0wkIx57NohEOx2tXj+c/9fO/xDQO2WnJpw2/P2///BwBuP+RfgsIVAA==",
+	"swagger-ui.css":       "H4sIAAAAAAAC/+z9e3PbuPInDv+/r0Kb80zV5DumQlKiLtbO1Dqxk3FOnEyc2yRnplKUCEm0AZJLUhdbO+/9KV5FAI0Lac/5nl/VnpxkbOLTjUaj0Wg2LuwnO3e1QrGx8Q+LEIfx6T8G86HlWLNlGKTG0iU+vjtN3CAxEhT7y2f/9T97QRgTF/v3qL9Ikt523Df7Zu//9q4uP/be+AsUJKj3f3srP11v5v1FSJ4FaBFiN3lG0/3Xs78alffWKcEH7AfIWCN/tU5Prb7lzAySGCnap0bi3yPD9W42SXpqmeYPM2OH5rd+CpdSnOehd3cgbrzyg1OTKnHj1F9gdEI9S3yPfrIMwxTF1KM1cj3mUeBuqd8TtEj9MDh4fhJh9+50jsPFLd1i65DrOBP+1EZkVsrYH40R6dGSLv3Vwo0yhifM401MS0tcX1ZpQVGpw0KkNzSjPS1XfJiH+0wsP1idLsIgRUFqzMP9rOwZcxZuUbzE4e506yf+HCOKPorRoWk8JAzCJHIX6KT+aXZsuIUI3SeHubu4XcXhJvCMwiLT2A2SyI1RkNLd7qFFGLuZVozk1o9Ow/kNWqQJzW8+j/+V+ilGfx7mYeyh2JiHaRqS0yAM0Izhc7oJPBRnZiiq6YjoeWGaIm+mAtDGSBtJGofBqtDWrlCuH6xR7Kez5rN5iD0UU3wWIWOlt3OPZu2S6AHd4C2D0jrTO4xO/dTF/uIv2tDiW76v/rFcmrPyR9OkjTghLsYNm58wAzXZMNrZRA302Plh1vQP5iwKEz/XeIywm/pbNNuibES72HCxvwpO526CMhK2lkNpAUbfdph2Z3WmYXRq9NkSd+P5ISXf1vdQWI81P8il44dcTngahOmP/8oGUxzi5M+nNVluhVWTKDqfrCqLLTohg9LCblc539M4DNOnh3pUrn3PQwFtd5s0ZZyHH0SblHoSRmnWmRHjyDBa0LjM4t0YuQd4jmiaVeaqOa8OOmORhAepswGoCoEP+bDMXccyjAmvvH+ldxH6OUYJSv884QuSzZz4TAlQWTZtlRRF6Z+HynG4UYTc2A0W6LQoAqovaU5PDRLeG8twsUkMPwiYuaUpqha0FF6BLSrnQbzVzSLX87LZwJS04cgm9oOVuAFKXCW9BFiKTiMO4SbNbO3Uival7+09z4Ef0T5lpkGEvQSlh6ph/YGDSK8/zv8dcW4BoxUKvOa8WM8l+9LdVY67GtipO8doRty9sfO9dF0MhVqPs93aT5GRu+HTIjBiJtFwFaMkAd2Llp+rB2k9ftxNGgL9t1ijxe083EOjIHY9P/xT0G6ZUQQbMkdxZoHlaMgty0giPzCAYSQgCjcpTXQo3YigKQly48UaHIKZOvJen5VWYoTLZYLSU8OO9mJWR1mKB8Yi44bFbRDRHQMESDrOOx3plz5GxibCoetVShB6mNzzVpZIz+codX2c0KEiCjaSUDHZEOLGdzUC+0lq+CkzMhZusHUT9Sz4r2JS+pOZSEiE3RRRsyFF1vfQfLPq/Rc1tpMQ+15vFWIPwBr5yAIp/rFcLiGKOXYXtzAFG8OUFKvY9xrBTzNE7W1i/KPnpu6pT9wVehYFq1k2QEfDE//z83fXO/Ofr1bh2dnZ2dsPn9YXn1bZj/k/ly/Ovp6dnZ1fnI9Hb7IHr36/fvnl1+uPc/ub6dkv7769f/7826up/+3D89fzLy+Db59f469frp3FAuPfMoK7dfT55dr8cmFdvSNvt/MPzrrAO8P572fF/853z9Cvz9df7RR7L57737540fzG9MfjzbNL/3n07dz0P99/fnt1Ye3e259D99N6tCCfP6Jb59PXQRR/vce3lzeTny7P98N3wTpdvLKw9+pihV5ZyTy4GqFz0//65Xr7lXwaZb/Pv3w2v36Y+Je/rkbolbXzXiXTy9uXt3P7Nb58uX776cXz8/ngNb48/7R561s3Vx8v9pfnC+fNzYX17vzq7u2H3erq5mz/9sNk9+7DZH/1wtxdfQz3V+fh3dWLs9Vl9fdmuPrt19e3326iD9cXX2t5FuSa/Pbhdej9er1750+23sAbvAkW92/I9O7b3WT/7uOt8+b+7O7N/eXdm99f337zrXv0xTG//r5K58HVTYPvxbff394sCN55r/B27j+/+/bq6+jrl9db7/f300v/8qiDV9bu4ydzeknWpvfr2ejN3XSzuKv74mZum1v06uXuzf3F5urFNJ3n+lmn81fO/bvgrfmJfKZkngdXm1Knm6/2NH0zWK8XLyb7Nzdn24V17Sxefdp+Ip/v54PPd1/tzx++ffl6c+k/H86/7DeL+yjre01ZCjkWF9bbd7fX26+Dz+m3L4754dP76WXW1y+cW/fL+9HVx+vzdx+/Dt+an+y35ueXVx9fvnx7s7Kv7r+dv7+53b29fT+8ulntri4uG/zwZjG4Xs/JW9zgF9H83uvxO4/OF+Tz2ns1vfv8arqdn5v++0I/q0+v1lvv1fTefTXdXV68Pb88v9xdffy0+ueFtf5q401e9uLW/80/m5T9NLp+9fnO/f0b/nbx7W5um6vF4DrT4cj94tx7r15uvtqfX1+fm36Gf0Nw9O08HLy9Wdy9v30/eHvzdfD+/tP+6tMn+/3N6xfvzU93b8/Phlcfz3ZXNxeDqw+XNb9vr6Y33hcLz4PrBr9rmt+9kt82k/3NALDJzFZfTHO7/HR7/arAFeMuH4cfh6vfzqfDxauXN6792bx89XmTjfeFf/nst+T5h59eXWUe4ubDp+vnn3+9cb9u7589u5+cv0nOh6vFq9/9rxdnr55f/vPV8wA9e7Z8Ge3OVruz59PJmfvSjm7wp/cZ+fPX15+ci/j29Wq1+vnnp70YRchNe2ZP6EkNa/SIzvR59uvZ+8KZni0nz6br/+dM/58z/U91pu/Ov95d36wvrs8zZ/r17qHO9MjvvR6//yBn+v7j4zrT9zf/Tc50++KDaV1mzuiqdqbkevjM+en5y9+eL8+uXpJnV89X+8n12asvLwLr7Ix8WA2G70e3z8//efXq6vzF7vIFurpE5OUz5727u/Cjsy+7T+vdxc3V+XZ+9gV/ud1+fr7bTLbvL8+en13snr+I1pNPo4WZ+6UL/PLj7YfNe/LihZYDnhh5vNv0wlmwzLrfmwjV/vfZ9ObZ8P3Z1+vf19G31dnZh0+3/6cMZI//++1Zcvb85YX35W2M3mee+X3lOJ8N31/t3V+vzcV5uH1jO/dvSOHH3uS+djr8+vvZ9urDcPdmdzb5bbDeff3yNv72+/vVV/I5cL8Mp5ebn8bbu8uV++X99BIvrI8fn79dXLzF7it8iYLX+NMgevf51duRa185315cPstt9Dwbv8/Tb79fr48+YjW9LOt+R5z7dyT3wdtszOc++dfr+LcPr6u54uvHz2er9/b07tuHM+vNzdf06/3F/urD2eDdh+H+7cfV/dXNp6QcJ9ur89vt1cez9Or8YnT18Wx0ddPwsS8uM79f29dRnswWTb/WzcAbeHfO4OrO2S7IYnv18dZ592Gyu/Ind1e+ldGni0E2Fl8O33xx7i/vjnyvX32+/zp4HS1+vY7m9nCV+e935KU/H3w2f/tw2fCTmR8wfa3+uPsP9M2/n43eD17jr79fbxf4eju3d4158Xk4H1xvF/Z6u3jx/Pz93dnd1fmFdfli/fbrl7fRPMjKVlHB5/nbjx+j1/Pgrfn1i3Pz7RO++O3D64z/xv2Cb9/dXu6vbi7Ndx8X929vvBdX9xf764/fXlzdvj6//nhpXp2v9lfnZ3dXHxf7Br+Luf3Wmn/5vPEujvy+vaL4mUp++dxZzyd4QbD97cv1qwWZppe/Frp8R8Tzwvubi7urm/fO2/sL+615eZ/PC7eXd+9v3768unm/e3d+sbs6v9y/vb/wj/zE89aR36WS35v7oy9lbPKny/PdNrdL/PriuvC5zVjop0s6xsJzkslx6f92P342Xp29K+eqs2957Hr2/Nk0OVufnc1frcPo1w/4+Wv/2/P3/mT7xnz962/3w59+M71frzv+Pbs2o39+fE/enk/Cf55Pf/3t/tOz6wfwK/4un+3Ozq5fnJ1dnp2tzs8+XJ6drS+en+0vnj+bvD/7kvnN92fA/74+P3t/ATy/vLg4u3h+dnb1nCl4fnZ+8eLMvLg+O7s4f87zvL78+PL5py8XHy63z9yzs9352fuzFxfvr67Pzidn4bvg42fr/cfz8evXyfq3wfbD1eC3Z8++aQb4ehNMq/g+m19fvL/9srL/X3z//+34XncOoOPwzLdqzlm2lTbnrTKG1J237j//inffPkw/fPv97db7/fVNZkvf/KtHi5914vosMXB1+/qiSLpcWXL/rX7vOPL7quT3aPH9/ePG99fn/13xPRq9HtxmHuKeTZaYn86Tq93VzYfr8Otvzqv/M/zw29v59c3nq4v31vLF9fnqJz94NvrptXX2+tM+WZ1dvDo7G1jPz15ffTBffS38+YdPn99d/9N58fXyUpJAOS7F0DtYTlR7XLIdMSfS3QQevZnA8+nNLR6mf01PoMU11S6abF32hN4RQ/9q078O6F+H9K8O/etItWEnW7nlV5uLRRxEXB//KSotl6lExZGbJLsw9oSAFGFJ2T4VFm5ihrBYlqQf+dx2IOpBSDeaXuuPYgRtYqKe5eua9BMP3B1AP1zTv9J9scHwEiNt724SoUVq5KtoB/EuEDGRYVij/bRa8632ATmjvu38IKOa7q0RS2WNx/3xWEo23A9YqrG8osF+yNUzGPQHAynViKcajfojhWx8ixxTSjLZO1w1dl/eIGc/4aoZyasZ89WMrf7QVtQz5uoZyuux9hZHYspJiv1l1e4hs9q2U2xyQUvKGt15EuJNimZxaafZriJz1tgLcG/4gYf22S9//W+CPN/tJYsYoaDnBl7vR+IH5c6BgYnI04NYriB5wGjIqDsNCIBQb0wAhOphAdWmNTIAQp3BAdWnHh9QZRpDBKpMPUogPeoMFKg29ViBqLSGS0b4N4wYjSFTPKs34IwUw4g8ZBSRjoOIdBxDpMMQIh1HEOk0gEiX8UM6DR/SZfSQboOHdBk7pOPQIf/+kaMaJfghowR3HCW44yjBHUYJ7jhKcKdRgruMEtxplOAuowR3GyW4yyjBHUcJ/ltGCbsVm97JSYu1yPZYNjfD55uf86f/0ydRGKdukLIkQZod1eCJ8ucNsraxYV5tPut2kAemY0V6lMm3kJN0E5M8WEqhRLibRFhHIopyvjIWKEhpy6nN1TF/mDWeF0mg0yAsf+JYpWEE8knDqBWffHSAnPIx0oZVMSxBXkVRK27ZeAZ5mVI2bYdP3SvMUHhIx4hYdeobEbOO3SNi17GHROzknfQoDuXYc+TROo48Yr+RR+22Rx1XBnn0TpN2EH60DsKP2EH4UTsIP2oH4S4dRHEqd7fzu9xBmNHYhcmT9BqlMLlZE7XPblU8gqSDsCCVhrxNukdKL1S8SZdmkG6tINqNkAiMuwiMuwmMmwLTpu/SJ9FylrPyURmKM2d3+vO0osmcA0CXPRbR1iffCncAUBcFIvo5fcIYYlCWiDjgikM+5gH6/LmIOgBO7lHqaj8U526Rv2vdDQ2yLj3RIO/aGQ0W3fujwaRbl3Dqg3rlccIfN88Xte8p8qCOIg/uJ/II3UQe2EvkoZ0Edwju1CH4QR2CH9wh+BE65IGezMAaHULTGMU5vopOcAlBhguQG4Ngy7I4sOfGt8Yqdu8Y7GAw4LDE9yCo4zgcFICNx2MOlvh4i2IGOJ1OOSAuOhuCu67LSxqGASTDYrEQsAbACCFYt/kRTAa8HGZ/ODwIXS5hnDE1aeg6we6P5on5w0kWpZ/0p08FhBMF4UREOFYQjkWEIwXhSEToKAgdEeFQQTgUEQ4UhAMRoa0gtEWEloLQEhGajpzQFGrHtFWktpjWUhJbgJJyF8PZbLya56QnJmivBdFEQjQREY0lRGMR0UhCNBIRORIiR0Q0lBANRUQDCZFQ5baEyBYRWRIiS0RkOmIiU6gJ05aR2WI6S0oI2WA+fcXIY1332ByavJflgcvl0BqMBHMCBB/bDj+FhLEbrHg3PxoAs3N2rp9DzscA8g5hHO5oLH8rQC0tBP/Hcr60XH46jTZxhFmBHWQvXEfAHaRwh6PB0oY7JfIDNv7wHNOc8L2yDlMInXXNnJ9RQeTEFE7sIN4deGNRKIRQwMZNg/HY4ZsJQqfudDwUxhg8wRSh+YLXSeBu77hQz5oPh7DUc7xBHHw4nCJgpHHIgTNGnsiqAPx0tFgslzAeJSDJwkMQyc5N1siDCJaj5XKJRASQItFk6S0dEQU8OJbLBZqLSKDR7y09vhXNVCJFIEwPzQ2jvMWEJoCuNunP49pzx67nb5JT1lXEFgPoW7YTMzeZ9OexzcJA1IBFQaAhA7IAjGGZrOT82vI8NiIfYwY3nU6nwLtfveR2fFHM37MqvTTfIIu3QJHGjGJBkXrbA1mVZSpu5bKimh8nM8+rWAmUVC9pZusEWGw2c1Ei++IxIhMDkLCVAUDQ0HicyNYApMjceKjc4ph030OMjs/bPdjuNFlqmh7PTdv6HifRF5uNrJvQIomuQRJNeyR65kh0rZFoGyNpZ4uP5v+41OTDLZE8piGSv8cOBTaH1TaHdW0Oa9oc1rM5rGtzWNvm2s24dM72QTaHH93m8GPaHO5sc1xgnl/RSOd5gStzc2gecTLQ/BkHLc8Zc2l3DpillIELVlsHCFVDuJUeaVt4tKQ58Boc2CJwvemR5p26paRNQ0mLdhLtZpLWrZS2CLdpEW7RIqzdIgy1iEbu6teFag2EKbfoctj97mwGBYIGDAjCDGkM4HV3Dg2xeUhqmAduYYv3PuYBWL0yubUr8wCtUJnc+pR54NehOrwd7JpBtahTOIioX3gg3DU8DuwdDgZ2EIeC+4iJ8IXdxK3cC3uKj6OFncUuwz/uwvmuEYsK+5BodiHR60Gi1YFEp/+IVvcRvd4jup1HtPuOdOg6QTdhZTdhzW7Cet2EtboJ63QT1uomrNdNWLebsHY3YbibKGCydr1wZ1jFce38l1OzZ/aG0b5nR/uebKGlpLVZ2ok+7aBJa5d0LeoeQvRZ/aYOtdOkHpY1g9Rtp5FKrYWb66xZgLyNchnyLvoVsNBXMcNAquVHcf616smDNE8epHjyYL2TB6qdPJLWZRrGD9IwfpCG8YM1jB+oYayvYYpBFKP6Cn9jn998X39/x7grv/VRPzlNFnGI6W8J9LOpxMw/a8L4/WLyMA8x8PmRfjlhmPXpOro4nyXMA85P2fH1WXl9/ExYVGmVVfLlZa1WVSuPyCu2ior50qxuO6/bFtRtl3Xborrtqm4brtsu6rbBusuGG6KW1003hG0/Nt4QtL5qvgG3v1SAIdJArQJDqIOjEgyBFio1AOXVSUsj+3hB43RmeSKTOn/Zeq7MjTmbJgB7LmwyK4TMsrT2rFhq8M0bCwDDruoWWWZdvdD4jxKI7b8hhMDIKzlEVlrLIRwIRznEY6Ehh8jgK0EkNn+sSWb2jbrEll83S2j8tTxi+2/IIxkCTXmEo+Aoj2ogUDdhgGPhUSKaYoAQyfgg0uFBFKODSAcHkY8NohoaRD0yiGpgEPm4IKphQdSjgigHBVGOCaIzJIjGiCCqAUGU44HoDAeiMRqI7mAgDxoLQrvHErvHUrvHCrvHUrvHcrvHKrvHarvHKrvHcrvHKrvHarvHSrvHSrvHOnaPNeweq+weK+0e69g91rB7rGv3qniIJl4sT90le3Fi9nSOlmGMDuXnOU+f9J7QXx6D2RwWGLlxdgh1zQIO92FmWsxjXFJkojJFFbNcfKZsLq4oKIs6LbYt8klULNKxFJTqWAwJdix9vDWzReboJPISqbhEJi3RFxYWTNa3BpYKhmWCYUowqnSJ0b7+2Ff2C11cfj2MQjWe8byM7HX4kP10avWsXvbbLGt2fUfRUQcmQJ0vAefU/IfHcsAixBsS5BDD8+PiOszT4ikAj8Mdi43DHQDcxW50qH86zf4BxWNwQShAZo+NGG1RnCCGb/VY2Dqajm2lhDwOdyLaRhHTwykiiZGkbpwe8o8X5l+US3JbKB5DeBR4PBoFHoQtr1RpwotHELj6ciIFBz+nWEseo3SxpvDlMxqeILyk2pk9EDYzRx9becRyjcyRVBtzMNTEHMq0MAfDDSwlbrYvR4PNyz617S/vyhZWv1WzkaiZFS5rKUjDNbdGFS1miaBmV5g5SncIBRxN/s3NqhQmdfMLFQSURSF/CRAK0lIdhfJUyqhojt0uV0WFpzpfpoiKoFIETSFRQ0VYqgGikyuhaUM1HWRGxYKTecj/y7rootAqCy2o0C4LbahwUBYOoMJhWTiECp2y0IEKR2XhCCocl4VjqHBSFk6gQuwmaVmebTWbAg53lblV81D/DM5pOchqgCwAlKxjP7iteBW/gdxKoEUBrdZxW04dJHpTfxOonv0z9IMCgJqBNAaoUJphAAAXRwI1WB0M0FCteIDnrh0SCButFxXASlAGBhlZq9gAJJCGByyFOkJgKTSCBJZEEScc4VqhAgSXRQsMXhkwMHh1zMAQqMOGjKBD5CAkUwQPEJ1G/ACRaYcQYmJlFHFUZ4tAQkCkiCV4Ko1wgifSjihEpBpBBUAqiSsytCy0OJbD0cWxHA4wjuVwjHEsh8OMYzkcaRzL4WDjWA7HG8dyYchxhMijjtqXKwIPGieLPWqkRvjBYq3Hyc7kLIlmVELaBCXkgTEJ0QlJSKuIhLQISIh2PEJahyOkczRCHhSMkE6xCGkZipC2kQhpG4iQ1nEIaRWGkFZRCGkXhJB2MQhpGYKQlhEI6RSAkG7xB+kUfpAHRB+kc/BBOsQepEvoQTpEHqRz4EE6xx2kTdhB5FEHkQcdRB5zEHnIQeQRB5EHHEQebxB5uEGU0QbRCzaIXqxBdEMNoh9pkFaBhiiowJpBBW4TVOAHBhVYJ6jArYIK3CKowNpBBW4dVODOQQV+UFCBOwUVuGVQgdsGFbhtUIFbBxW4VVCBWwUVuF1QgdsFFbhlUIFbBhW4U1CBuwUVuFNQgR8QVODOQQXuEFTgLkEF7hBU4M5BBe4cVOA2QQWWBxVYHlRgeVCB5UEFlgcVWB5UYHlQgeVBBVYGFVgvqMB6QQXWDSqwflCB2aCCwnpBHQXwc7PnMyECUzyvi+c4XNyyxHOG2gBRKYsC9hB5RxBYuqCLjQVizyF4ab5DgoZxAUOByvS/iXhs8ZyjKDdqMBIAkUuNBStoFnF0xtLfI+9QILF7F27S0/xR4xtCrVfGvKC53AV1P78cxlpAEwEbAc9DYAcAEOzsJg62Bg4hNAgeKbUJEC41C0AUtWWIiKTGkRHJ7eNR8pZeYBC5zRCVyRCVxRBNgyF69kJU5kJ0rYW0MhbS1lZIB1MhHSyFPMhQYKPAcqPAKqPAKqPAmkaB9YwCq4wC6xoFbmUUuK1R4A5GgTsYBVYZBRNjHL7Tep4tceimwI7OZSyAAvs7l8GhKOu0RXeZb9HVl0uMBkUrVoJq6R5pGcggbSQmrQQm+vLCsrXpZQO3kg1TslGliRskRoJif3lYhkFqLF3i47tTw40ijIzkLkkROXmO/eD2yl18yH99GQbpibtFgR/3ArSvfj5ZI7xFqb9wjz/1ArRBJ5v5Jkg3J3E4D9PwJMj+SdAqRL2Nf+LGvotPjlIw0nGCrVAYr3z3BALn0hmCFgmrKKl4Ah67CD36m/jZA4roRRgkIXaTExIG7iLM/hPmL4oso03so5ghzZ/13mYqLQEi+lrBFAdG7cf+ELW96DiKB9CvQup0jbCb0OTFsxNJN5VFur2a+gTRdeRPIOg89MLAp7DP80e9q48QfuFiP0lDiuBF8UxAsXJjl4SBRwtfPgRFcpNbFG99jGk7aTyHyMpGFFdE+amLfeZa52ViBGFMXNwEFk9au/L81UO3PgZbVvko/jkLZ7XFIK2lAGvE+jVCqqaxzR7ZFQn7IXefOVXMXXe+3FkUwOIBNgWwecCAAgx4wFAu4nLnUACHB4wowIgHjFXNnFCACQ+YUoCp2f4IOWOwwh5hEVCnsBioX1gM1DUsBuodpcTLncNioD5iMVA3abR9wmKgzmIx08f6dj893MV9SNRdSNQ9SNQdSNT9R9TdR9S9R9SdR9R9R9RdR1r2nLiXVL7PwCqBLQOrewmrewmrewmrewmrewmrewmrewmrewnzvcQsN0eb1IhRgtKDsUPzWz/Ngnjkxm6wKL+sZZDwnn3IdNEmTcOgYHN6muOX4WKTGH4QsMdTGzXy0PKWsezL7MXX3tnM+do6VJ9x587Sru2qjD9nux5UZUO+bFiVTfgyp65vxBcadl1ss9/YX2ffwVlXNsAVjmvKMU+ZXf3f+Fo9XZwv7OeQxhI/D9vS0m3XXHFTPqC4KSFQTMvIAjLRtpCULHBdnNIsAdnPbHn1rYcSUv7ael5f57OxxHgaxZD9NIohE2oUQ1bUrBs2pGb1kC01ykFzapTDFtUUQWxUGUptV5SwoGlR4oLWRQkMGxgtstjGeKFhM2vwk1haA1UZ26MEJessmJAZIJHaH5GaH5FaH1EZH5HbHpGbHlFYHtExPKJjd0RldkRldURpdETP5oieyREdiyPtDA42Liw1Liw1Liw1Liw1LqwyLiw3Liw3LqwwLqxjXFjHuLDKuLDKuLDSuLCecWE948I6xoU546KAaewubpF3wChNUWxkecMs8OpbiIBAI82YsXCjbzoiAoJWLsfezuCt73crOQYJx08uLkChkhiqJBf6cS7iqmpq2xDSuh2kSzMkIuO2IuPWImOByBQFXpffc8gXEauxwmFSP8WIxvRth4MtwuiOQTmt7bMSKbcdtVQcTCQYD3QexwxrgYmWvERTXNJSWplkWEsyrCkZ5iWjcdkHD6s34RTtU8NDizB28/27+esw9JDnccI9OXUXqb9FQEH+Agw8X2cXtwLPBfy3fuKn2R6k2A0Sv9quHMa9vuUkPeQmyPCDvwTVH8JNmmkm+952r/jCRW+xiWMUpC8yJixdkh6yf4rEtpHeRVBegOyKN8DaAoEpemc1y7kAguzsRrkNlA8a5UOgfNgonwDlTrP+EQAYNQADSIJxUwKoikkDMIJknDYAU0iG4jaiI6jT6nvRHfnLkrxHGAjUKTQE7BcaAnYNDQF7h5EF7CAaA/cRIw3YTTQG7ikaI+4sGvd4+xHKTiTKPiTKLiTKHiTKDiTK/iPq7iPq3iPqziPqviN6XUfa9Zy4l7Cyl7Cyl7Cyl7Cyl7Cyl7C6l7C6l7C6l7C6l7BeL2GulyjYzjqI1LqzDyJ97gYHkSJ3w4NIgzvnIFRdZglVKWsBO8OuymygrOLKvTfvsk+fV26DL6vaMBjwZVUjBkOubFjxHPI8narM4ctGVdmILxtXZWOgrGrfmG/fpKKb8HTTqmzKl1lmVciPt52Rrv3Yq5XTH9T/45G7sEAnVdtG/VH1vzEHz5PYBTD7sfXUXMy5Yns9lkImeyyFrPZYChluo17YdhsAyHwbkkEW3CiGjPhYDNpxoxgy5UYxZM0NtUAGfSwGbfpYDJr1sRi07EYxZNyNPoHs+1gMm3izTwRW3lSdnqEbgb6tH7HZb48T2eQxi2QIENkIILIBQGT2TxTmT6TWT6TGT6S2T6SmT6SWT6SGT6R2T6RmT6RWT6RGT6Q2T6QmT+QWT5QGT9raO9E3d6Jv7bBlY5llY5llY5llY5llY4VlY6llY6llY6llY6llY6llY6llY6llY6llY6llY6llY6llY6llY7llY6Vl47aWjfUtG1OWTSHqLwllmaU5Pn5q6LR8IIAXnx06oovfBeDim0QH6TeKanAeZtVQftGjBu45mY29Quo9K7exl0u+Z2U39nLp97T85QebBOA7vgV3ihbccS24k7fgjmvBnbwFd0wL7rpFu6xh5feMtbEtikDDvCi80sIotI6RNQm07Ywh0jI1hkbD2hgKHYOjSHRtjiHSMjuGRsPyGIrHiz05iyQtDZK0s0fSxhxJS2skHYyRtLdF0tYUSWtLJB0MkbS3Q9LWDElLK9SxONzS4nA7i2szxTbBWhaHO1gcbm9xbadamkDL4nAHi8PtLa7tlEsTALFakrqpvzhEYbnyV/xOY2KE3WwJ8oiqnsAfpTniqic0rrjyoAblv7b/vG0uaObYtWSngHLxKaikBRSuaMTjfD22aBnRbBjRbhfRaxZp1ypxC7BmC7B2C7BeCzDXAnqM5NneMNsikt5xd9sY02NZf8oWThqF7OUxxrhRyF48Y4waheylNYbTKGQvvDGGjUL2shxj0ChkL9ox7EahzSvhWMgpwXSOhSYnkWk3i22+vC5lv4sWpm6KjKFT7DZYhjE5LZ79OHQ8tHoKwqcmD5+aQrg1ANhbAzF/awJUYE3ENdg2UINti2uwx0AN9lhcw8ACahhYRQ1tfWWt9fwqkVaKBynkugdJFOqHaeQ9ANIoOgGmkfcDSFN1xaN4/GP/kLbdQ1r3DmnfOaR935D2XUPa9wzp2jHSTsBtOwG37gTcvhNw+07A7TsBt+8ELOkEimaO3cVt5szz7WWnzS+UT59C0AkEnYDQMQQdg9ARBB2BUAeCOiB0CEGHIHQAQQcg1IagNgi1IKgFQk0HgJpsu3Zrv5h7C+w6wTn2h5Ms4cx3WQGfiOATED4WwccgfCSCj0C4I4LDTR2K4EMQPhDBByDcFsFtEG6J4GCXlth/mOxhzgC5sUFBLIsJ9jw3vjVWsXtXIQaDAXvAwKMAjsNEfM3C8ZgJfRMfb1FcFXO3KeJ8rzcNcl2XkSAMA0qExWIBsWlCEEKALnLtVpDlMPsDqL8GLJeAsmLk1ZWMzaG5ZF9svCP90BqMIEkp0Nh2mOaEsRusGmKMuFPFqxA3OMy5k753CONwVyIyMCQFBfrHcr60XKZ7ok0c4VoQB9kL14E40Th3OBosbUB1UbZPugR5jmlOGN2tw5TCZAqcMz1El09M2BRolDvwxqDZIxTUI2MwHjs2a9lNwNSdjoew3TVgU4TmC6Zdgbu9Ow5Saz4cAtLM8QYdQcPhlD0v3SgfOGPkgX3aRE1Hi8VyCaBQQgMXHuKAOzdZI4+CLUfL5RKBMEoFaLL0lg6IY0xuuVygOQikRoi39LjrkXAY16d8C2D5G6O1lVFHHnN3cbsqbtjVCEIqwomCcCIiHCsIxyLCkYJwJCJ0FISOiHCoIByKCAcKwoGI0FYQ2iJCS0FoiQhNR05oAtqpoyCOUhUQ1cQTDeKJiHisQTwWEY80iEciYkeDWKiwoQbxUEQ80CAeiIhtDWJbRGxpEIvMi6fkw7H5ymhEZDwBF5zNV8YxPuPxXKg2Xxl1tMbDucBtvhJBuTBuvqqCNB7MBXXzlUHFdTwJF+JlktdRHo/nAr66CgEBF/5Vui8CPJ4ACgbnKzGcnS7nKyN/EY7cGAUpT9EohPs4m/CAdgAR5nwlAEPxZq0oEQkffc5XRhmAQng+Fs3MKAtHITQfmc5XVRDA4fk4tZZeRAJGrfNVFZACgwCIYetahFRQRFt1XB5q8iRQfDtfGXWIC3ffnDdBIZqPfY8NEdHwkfDRx2QRHOCUgLh4vhLDgSi5MVhhIihmzgZsFjZDfpWPoKtW5OEqRALE0ysRGoqu6zYIaKBYu6JBiZAMirwzt9MIvoFuBOLwI5FIyVBUfqQSDzAgRj+SibwKH7HPV3W4zhGAkXt+kLWYK6HDrs3i/GdhFqRAHmdeMbcGhmLJTcwFvJ6bxRyPEIohN3MX6GryFvOrERQ7bmYvwHJWPBtu1i+AxSwuZlSWU6y4mKCANsMCMUMKRbHl4oZSK1XoIFFcDaEYcoFFUwI5xwaGYsmFHg3bysMJhf0VGIolFJwUFAqGEK/lUjjOjKmpGGoZoslO9gbdJJsoGU/EjCcSxmMl47GY8VjCeKRkPBIzHkkYO0rGjpixI2E8VDIeihkPJYwHSsYDMeOBhLGtZGyLGdsSxpaSsSVmbD0VjjTpAKkRTcaqPEGTdKJkPpEzn0iYj5XMx3LmYwnzkZL5SM58JGHuKJk7cuaOhPlQyXwoZz6UMB8omQ/kzAcS5raSuS1nbkuYW0rmlpw5PIjKQE/MuwI0Wcuiw5UyQFxRAZ1Glqam04kYaZioDkH82EzvSKtgo0iNVFBNq44pmyARf0GEOV+peUv5CkLOOt0k5UxFiBp5qZpQLwxlgaJ6BEFpM6UlVz4TmmqkvxgBlVVwsapGxow2cEWgScNEdYjj2PlKowYFc0FgSyXmpPybOEEtwjQe7W3kgfSKjaVbLUwxTCY6NU20aprIaxrr1DTWqmksr2mkU9NIq6aRvCZHpyZHqyZHXtNQp6ahVk1DeU0DnZoGWjUN5DXZOjXZWjXZ8posnZosrZqspzIHpBq5TJDfel2QYTTRqW2iXdtEXttYp7axdm1jeW0jndpG2rWN5LU5OrU52rU58tqGOrUNtWsbymsb6NQ20K5tIK/N1qnN1q7Nltdm6dRmadcGj+5qhU2RqM0QdC4PWH4r8FJmHB9oZa6ZRZRyO0IYnvzSXUFQrN6JGZblDDd+Ya9AZ6tyYl55KcOJX/QrsMUag5hXWd7kxi8JNnWiYkihaCGBZcOCplgDFPMsy+n8O7Co2JRAxZNCUZyhhceGwUaCm0BZCMUT3nmXEVSLk2KWNYIz7zkY18u5QZz4VU1KR1J+DQzDlV/3pNZnEAqUazgZhl4VAlZGq6UVKUOIF7BsSi8zSDk2QfQ6DLCyWpBki6tihnkps6zGr7o29JMtVSpUmEMYnvyybJWklbHjOUFLtk3VyPk1MLT2gEXdBgVKtBjXMHrlCVj5LYgai79izk0QbevA4jBFojAlCkVxhhaQKRqVO6Zh7KoUt8pMEUlnyAaG4cqvQ9NJNSnbJkiYNhFGCPOVkruMsThkaG7p0cj6yCsRxRD1JiBpDVQkobdbqKaVxxUVQMxaFGXUG4ykzCkj1NiJxChVowYg+NDbvlRzUIUNR4gwbSsMTJg9TxotUVQljlSaG6XUg60xe+ttqqrJ1dFLEyQbd3NRjlLJXsFaFNxQG7d0+kJejSjaofZ66aw4HOcAvY1hx7GrrEHBXBgP0ZvJtLLesorEAVK5AU1aQyNM0tunRitYEeWsuLhJb2dbIy2m4C9lLY6pqM1wGl0grUYcZLH759Q1oURRmTjqonfdSaviYy+9PXosA7UFA8GY3sY+loPGfAVFZ3rbAVkWqriEC9f0dhDW9MpV5AZGUAG4phy55kHwCcnIteqivu1wV2NGrn0sh4oHdbEFlA7rUhsoderSIVA6qksnQOn4WC9/oWeE6wYb2bf/uVZjiy4Hm45tBgRhBjQGUAIe0hBAE9ihIYA68IiGADrBY0YWQDHxUTFx/tEXVjOxxQBA1cQ2i4JAAwYEKCceMhhAO7HDYAD1xCMGA+gnHrPyAAqaHxU0D9M0JJyG5haLAFU0tzkYhBqwKEBJ8yELArQ0d1gQoKb5iAUBepqPOZkARaVHRaVhxGkptahiUEWpTWMgyICCAMpJhxQC0EzqUAhALemIQgA6Sce0HIBCtrzlzGQa2grsaKbU2xY2rZlKmVvQ2GYKBW9B65splL4FzXGm6IgtaJ8zRedsYYOdqXpszc4SM7lvXIOzxkzDX66hqWSmdqFrYHqZKZ3qGphxZko3uwYmoZnS8a6BeWmmdMVraKqaKb0zcbPvZ8UrP2D7hrhWVQJ1AHHtuhgqHVSlFlA4rAptoNCpCodA4agqnACF47pOoKG4aigYvxBsUcVgk7FNYyDIgIIAjcdDCgFoADsUAlADHlEIQBd4TMsBKCSuFQKOTRJbdDmokthmQBBmQGMApcRDGgJoJXZoCKCWeERDAL3EY0YWQDHzWjFwvELmFgMAVTO3WRQEGjAgQDnzIYMBtDN3GAygnvmIwQD6mY9ZeQAFpbWCgFmYpFazFFRNalMQCDFoIgClpMMmANBI6jQBgDrSURMA6CIdUzIAithyljKTKGYLm81Mpa0taEgzhQa3kGXN5ErdQpY2k+t5C1neTK76LWSJM3lvbEHLnCl6aM14/ZnU5a2hSWCm9oJrYGKYKf3imp8rZipHueZnj5nKc675+WSmcqVrfoaZqXzrGphzZoC3bXstZeTm9zGK8y3NUkHKhYKAWZcmAky8NAFg7qUJANMvTQCYgaFkAJMwDYQoD8NBBKkYHgdmYzgYmJDhUGBOhkOBaRkOBWZmeLnA5EwTJsrP8BhBigYAglkaHgcmangYmKvhYWC6hoeBGRtANjBp08QJ8zYASJC6gZBg9gYAggkcAAfmcAAcmMYBcGAmB5IPTOY0gXA+h0UIUjocDMzqsCgwscOCwNwOCwLTOywIzPBwMoFJHsjSFHkeod3ppHpEpqiR7REYpzrhI7BWdc5HYL7qtI/AntWZH5GBayR/+NlJmf8RzFZ6KSB4CtPKAoHTmk4iCJzpdHJB4OSnkw4C50OdjBA8RWolhYz889KCvFCjUJAaaiLA7FADACaIGuVgjqhRDqaJGuVgpqhZP5gsOgJE+SIWIUgZcTAwa8SiwMQRCwJzRywITB+xIDCDxMkEJpEaKFEeiYMIUkk8DswmcTAwocShwJwShwLTShwKzCzxcoHJpQZMmF/iMYIUEwAEs0w8Dkw08TAw18TDwHQTDwMzToBsYNKpgYPzTgxAkHpiUWD2iQGBCSgGA+agGAyYhmIwYCaKlQdMRgGWJc9HicxMIyUlMDx1Vgq2RGViCrZMZW4KtlRlegq2XGWGSmDJ6iQVN9uo8lTw5KOVqgInJJ1sFTRHaSSsoFlLI2cFzWMaaStoZtPIXIFzHZS8epQPeWQZLSJLaBFlPouo0llEkc0iimQWUeSyiCKVRVSZLKJMZBG9PBbRSmMRnSwW0UliEZ0cFtFJYRGtDBZRJ7CIZv6K6KWviFb2imglr4hW7opopa6IXuaKaCSuiG7eimimrYhe1oroJa2IXs6K6KWsiGbGiqgSVkQrX0V00lVEI1tFNJJVRCNXRTRSVUQnU0VaJ6rIA/JUpHOainTMUpGOSSrSMUdFOqaoSOcMFWmdoCIPyE+Rzukp0jE7RTomp0jH3BTpmJoinTNTRJKYIqq8FFGkpYg8K0XkSSkiz0kReUqKKDJSRJWQIlr5KKKTjiIa2SiikYwiGrkoopGKIjqZKKJMRBG9PBTRSkMRnSwU0UlCEZ0cFNFJQRGtDBRRJ6CIZv6J6KWfiFb2iWgln4hW7olopZ6IXuaJKBJPRCfvRDTSTkSddSLqpBNR55yIOuVENDJOpGXCiXTON5GO6SbSKdtEOiWbSKdcE+mUaiIdM02kZaKJdM4zkY5pJtIpy0Q6JZlIpxwT6ZRiIo+RYRJkk7Asm4SV2SSsyiZhRTYJK7JJWJFNwopsElZlk7Aym4T1sklYK5uEdbJJWCebhHWySVgnm4S1sklYnU3CmtkkrJdNwlrZJKyVTcJa2SSslU3CetkkrJFNwrrZJKyZTcJ62SSsl03CetkkrJdNwprZJKzKJmGtbBLWySZhjWwS1sgmYY1sEtbIJmGdbBJunU3CD8gm4c7ZJNwxm4Q7ZpNwx2wS7phNwp2zSbh1Ngk/IJuEO2eTcMdsEu6YTcIds0m4YzYJd84mYUk2CauySViRTcLybBKWZ5OwPJuE5dkkrMgmYVU2CWtlk7BONglrZJOwRjYJa2STsEY2Cetkk7Aym4T1sklYK5uEdbJJWCebhHWySVgnm4S1sklYnU3CmtkkrJdNwlrZJKyVTcJa2SSslU3CetkkrMgmYZ1sEtbIJmF1Ngmrs0lYnU3C6mwS1sgm4ZbZJNw5m4Q7ZpNwp2wS7pRNwp2ySbhTNgl3zCbhltkk3DmbhDtmk3CnbBLulE3CnbJJuFM2CWtmkyjC4Hio34AUHRxP9Rt9qLg+1m9YQGl9rt+wgdL6YL8xBErrk/3GBCgdH+vlDTFgDu/DTWNO74PtY47vQ41kzu9DLWUO8EPNZU7wQ21mjvCDDWfP6MMtZw/pg01nT+lDbWeP6UONZ8/pQ61nD+pDzWdP6oPt507iwwrgjuKDGuDO4kMq4A7jQzrgTuNDSuCO40Na4M7jg2qgj9zDOqDP3IMKoA/dQ62nT91DTaeP3UPtps/dQ42mD94b3U4NB9Q5FZG3oyCgw2siQJ/XBIBurwkAPV8TADo/SgbQ/7H7V0UukMeBXpCDgY6QQ4G+kEOB7pBDgR6Rlwt0itxJDJFfBICga+RxoHfkYaCD5GGgj+RhoJsEZAM9Jb85XeQsISToLwEg6DIBHOg1ARzoOAEc6Dsh+UD3yRyvEHlQDgY6URYF+lEWBLpSFgR6UxYEOlROpsfbzB40910J/SxRuVmi8LJE4WSJwscShYslKg9L9Bws0fKvRMe9Eh3vSnScK9HxrUTLtRJNz0r0HCvR8qtEy60SLa9KtJwq0fOpRNelEk2PSvQcKtHzp0TPnRI9b0o0nSnR8qVEx5USDU9KNBwp0fCjRMONktZeVOAxsdJjYpXHxAqPiRUeEys8JlZ4TKzymFjPY2Itj4l1PCbW8ZhYx2NiHY+JtTwm1vSYWM9jYi2PibU8JtbymFjLY2I9j4l1PSbW9JhYz2NiPY+J9Twm1vOYWNNjYi2PiXU8JtbwmFjDY2INj4k1PCaGPCaFWoQYu1GCDvMw9lBsVL+fVj/MyoIkchfAFrckjf0IeQb9DeYgXRuLtY+9H0PPe6rzMeaaz/Ezy0om3PeWGWH0uHBfVK65ND6VrOQCfTO5YFQIo+Sg+kJiycxz41slL9l3VDM+t+hg7ND81k+NFO1Tw0OLMHZTPwxOsR8gI13H4Wa1nskKaa6bwENxVixkXCNmwhLGfkNDzTUIAYbZw9Ypq0Iv2ava36UaGe8HaUfGWKygR3n/LLVG/j6lkb9JZ+TRVSZWD/771IP/JvXgLuqh2KX4kKNc7K+C0yxIY8rjZnketzCARROwQEGKYgZx00TcbJLUX961HvkpzgaQQlYGAorLYGCJGVAl9KOMxhQbRNkQom4H0WgGad8KWGKlmRhYLTHWkBiDEtOoyubS2A2SZRiT04Ub+amL/XvEQjELxeEOxQs34ZAbFrmJIhgZsMhOc1nasEWtlgBocWMAsLg9APjxZp/0aKt6zSQtWklaNJJ0aKOgPbhNe1qYoIFbtAfD7aFwS2N0wjxYI9fLw7VlGKRG4t+jU/6Va2k4LF2ymQOk/CvW0moUD/hiu1FsQy9yy0EDYQEvccshBYBYOE0EXzxqFPcnY4DBuIkoAG0H+NLIVh5E6s/GnKIHAOpGJ9AMoH6gEVBX0AhBb9AguENYDNgnDAjoFhoh6BkGVGAexVVlPUaEHUaU/UVk3UVUvUVUnUU0+oqou4po9BRRdRTR6CfStpsEXYKFXYKVXYJlXYJVXYJVXYI1ugSruwRrdAlWdQnW6BIMdAmFIchNNjE6HMdJNnZATFZOAYciYODGcbhrQG2Opx94KEilu1uL95kCeGqxDBLiYmws3Kh0DkvkplndCUpTP1glp08SsoiezPLCrRv7bpCeHonYeHYTLNwUHcItipf5Nxx9z0NBIUL9EGHsR4mfzPL8U559Q6dBuIvdqPVsUesq0dY9g1Won0aLekB1Q6JuJxjBY/ZDxq17VzzK3FApk+h3D2nTO0Snc8jj9A15zK4hf1vPSHoB6/cCbtMLWKcX8OP0An7MXsAP6QWKZUViLMIgdf0AxTVr4+40WcQhxjRF8SpPrfS5mzSkN1tnTxjVx0b28KCCYQpWs2/tYQsx2Q1yLSTl9pEJhYUqeRw3VDaCdG4D0W0CadsCsbS4s7RYV1oMSEuLgv3oEIWJn6dCl/4eef/TJ1EYp26Qzr7XJe48CfEmRY3CjPQ0Rov0Ryva9xp/n9JFJ42/T9ubJ/ajzG7+nTI+jklmgpP/ILlFMuJ/r4yUALvECMKYuPhAO+DsEZvhz5zyAfDTFCyKEYWJ4vYpyFqozO705OKRoGgsLJPuUYztKDLRlZhoCUxayiuVTbeXOSAsG+ZlozBbY+4m6LBFceovXFxmz7Nn/ALS1iC+x0KJ73mYA6ZhxALTMOKqTglXcx4XtTbHohmZ6ei3BEALGwNg4fZAIhRNehQbLttJ2jST6LeSaDaSdGqjuD24TXuwfnuwZnuwqD0U1vPJIYzchZ/enVqzPHleOP7yYa9vOUkPZQ3yA470dBkuNskJ93idhcg1377TnrG7SP0tOrKYyFmEG2Z9bYXD3aFVrRkF1J78Od2glopa+x4q9r70+vl/akZmV0alfkp+JyJU3hwVKG8bK1nLJh4X4XNukB5ZSP7v4+0EWGUpNYOE90aY7I0irUfCMF37weo021WVLFyMZtm+o6W7QMbWT/y5j7O2Vm+I9cpR/hN2U/TtR/NpUxE1pNe3xaYXCwwprg3pWFUu1Y9W33SeAlzKUcDC+1MAbGA3XqH/FhX4AayFQiSRLspSkUZsYRvFamGVGIV+kNa2ttjESRiflg9pZLJ2vXBnQMBZHQzHCLtZzU19uBj3+k7SW2zm/sKYo3sfxT/2rZFz0p8MT/rD4Yn1VFzVqbtMUVxtZIxdz98kp36wRrGfzubh3ijAp2bP7FmjaN+zo32vuVXOfjpbhEGKgvT0yZPZGhUnuU3zh1l5wP7oariYflYmaICBrmjQrJj88nru8+TO/tSwJM3MTaBo7IkY1VRJ7YhorvOV4QY+cVN0IngOmVujtGFuRZvZnYiUl+Pt+t4wD1WTTbbIqosstsiui2y2aFAXDdiiYV00ZIucushhi6bTaV04nU6BYqqcAxB3f5TWGo6Hk8FoOGZRpZnWyPJ3HuanvsssPN0bmyBBR1o+kxGgJEVZl0R3RjFr5LbdC/GJFi7Sg23wofFrtiAFinFcI2vQrq0TfazdAjtogR22wDotsCNGLzasGOwnqRGjBAl7poHIlZ2kRpLeYWSkdxEqdubRV4LI7uVsdmORU+5FP0X6Ceg+l4FuckxQhGI3DeMm04yJ1XcEdD5Z9XyyOnh+EmH37nSOw8Xt7Pi+k7vI448CHQa3Sc89lNuyB84YeV7TLfN+ScYHcoAMopzmivqmo8ViuWxTX/ZaHuXT1j5bO83Ci3IGm4f7WXU/Wq/MbNaqGI7MaD+r753t2dmvIt2EUa5KI3VXRoIWmVy1kpcY7WfZP4bnx2hRybwhAbsccJc58P48DYzMxUc8h9O+1bMLSQUX4pZMvn+fpwF9hQq0Mt0Q+5C/dhl+ikhS7u+b1WoqLs2J9r0kxL5XTOnO9GTknEysk/7g6YyJQSjBK0ktM4sIsn+s6h8uNLEToYClGRwnQGoTvmk/FVLWpjofWo5VrM0sXeLju9PEDRIjQbG/nDX2Agyjhl2YPSfaC3n3g9DwULLoJZEbHJaYn0wb4F6yXR3YJg8TCT5bOWLFz+qw1a2wslbkv+4Krzg0zYY5Z+oXVvyL52/LxvSsnuWYAKuHrq430yxDM9o/PYikKdRwoiHsX2JV9v1gGX7/jvYpigMXe+FCtd04cmOXoBTF379nzl9iRSQMwryFTfXbjM5GDfU70b5nCmrLl+7iENOfcRxzLh3tXRJhlHz/nvopRoxPbwiSzSKUJGOznnPKoS1lX/kz+QzD4fOZGsXNPSvTWC2JE4s4GwnCaJHWDfWDfM6v5jDN9pRcuAcGwoigID2IXDyDP7YTu3OEdZspvMyrZN9S3Y+v7a2PdkYVYN2yL5e1V3Si/fEV7fimGUanA8CtO8msWpYXeJ3ypbIxyfzDNM0Z/aqZeTTmNXPAvGFa06dN122JfHevn7rzSmn8PGspiYqfs+mSewGvfezQjPYtGJ0u/ThJjXCZB5sHmk/P7JktePXLlNt6WMxMXF9151VlAY4T8SpGd7P6DGhmGvxbftZ3eSDimD9AL/d8Luf3Hw3H/KF+e7cFMVffT4wwQkH9wEg2hLjx3UEUvmSWJWh+zYIeVVBsdGw+e7Bw8pS2UgvIhFhP6SApy9GXmnKaceekjJlayftL4ZIAqek5jLZ7ZUDBzmhHf5KPtSKE7iDoL4WFUqGJxNxhVr31EAqULM1ACb4mma+zMC2DoHQdes0xALirQT4QcpGWy2WHiC1XcL14M2naRZZdK18Wm0dyiieU5ZmM3fHh13g0EYVfwpbTZvGXptrC/I016zTfO9Ejidx03QL6/buHohgt3BR5DxgAYEQ3AiK6XRh7xjxG7u1p/q+RPXigiv/NWurWlRmbPOo2knXsB7enVqu4XsCQTkX89VfHJj3yYVCtnjrQA1mTQ/buGPtRHvG1fE21Mv8C25++2poCGFWmBBg3smRGHO6KRxn9afbPbOVGpSNnXjmbGRTOXhynpb0opS8MNHXjVJCB0VMV6Ek0Uh6Orh3oxt2CmNuWxdyac1q1ssoIQkX8ZedNov1DePbz9GUaGgvsR/PQjb3ypcsWjZuabxQmKZcCGg9ObHN4Yg2HeVx1vGcjG03D6WIxNdVcteb4zswOXYXqFI/r8d/wmrQd+8QaWSfDCaDJ5cK1BmqmWorsyuvQUaRuatRh7yGMUsRrcjg9GdnZ/wFFTgdogHTY6umyO7tDd8E6alSjhhXiDXM6PrHGzontQEN8ZLnLpZqpljK78jp0FKmbGnXYR266WHOKnJgntj0+saaQIh0TDRa2BlstVXbndugsVjd16lSQceW0mU051kRgllPTspdIzVVLmZ2ZHboK1U2VOvzDPF5LeG0OTqbmiTUaA8o0Pcd1x1p89d7WH8Dv8ADRumlVp4rG6w+Ym5raPwB6RfPsT73vpj/SrURLywX3ziwPkKz63LrpWlJLQ8DFGhGUHNqm6QqyXr/8wSiWblSpr6WP06wRx9fN4onhB3kUV6TO7WOC05t4HhpXbw12zpRahxUuZHvhLsCh6xmbGFdvVL3+0vUxYnIRlVBFWfnyGiNPh1/2xA9WMMOy8CC6yI6EHsJGuQBCLZOwaxmpO6dz+8f9HMVWDtEiujvvYZ95H2feyPTSpc2t19FeXh+c/oc/vZczV+T0Ia68vettz+MT9oUcWUqs2LdSGlS0h4QoR92BSW5C0N58k6ZhkP2W7/9oypr3WWntZpnKq7YfNvuDelZWVz0TdMHRf3AJBXjhuVpINrKVZDk0H+TfyxNEbZM8NrcX4TiQHZnXgZrCbcCStkaIbjYIyLt3aJN+C6I2DYg05I9ai69cLxCqU/oVZ7RHi02KKvjxk9P5RhnpVgWGtJftIaLmpqHM589DL0unxS6pQ7O2e5d4FtQz5PnpgdoHZCrpizWsZiM0aXrlPoGyiwbQjBqjJAqDBCWGHwSMrqVIbkxw5c5DhkM5/zsqKfqLTVwcd1/XO3IiahVxhMiscfSbX5qrWGbR1fckddNN0mUkKJk2Hm4CL1xsCArSOmD4x9TM/qgXXyx+8UVSdTF1dBvYVTZ6aDa+slp8KA0RVZ3/fa1thoeNzG29/M3vIVHz6PWb6xPs3hHRbsJsYB5/K8O5ckt7c2Nkc+fO6QAR8fpxwTKKUZ/4izjM7/ulgvbBYADsFWHG2PouWqMgaZ5hP2XDYvqoZu5Q+TUWF2N44aV4mhE1HwrWgJmOF/W0RAW9fvF6k1/rRmmVPYm1WucExiL0kGovCI3+panyBzkcMd9eLhak+8ZBbqgX6Aoyn2gsQkLcwFM1sn4vKQPeRLHH4x9jb2JPKyNrRqL05qRirJn0yn/LfRZDYJ25VPIg41zerFmJXknbWHQSxusWE0tAF3kW76aNG0SoBO5yCW9uYbYZONQ2LLv58jMA5n+2zupJwq/mocADViPppUcLUlNuWcYcpTuEAs3qf6l+yHppi+Ij5KAjw4NrKXfyyHYtQMHZwzbzGMUmbQcKnDq3oY7K6jdia1Abo/LKTGG1p0GY/ni6dpMfhfU/fXpgTaEyJM1Ken13k67rwDyjLjwAZ2KpG6edmBa/hbF/j0SWVeye5iau6pOtnLlVE+/RZFuu65e2JHA3dOKlORNYjcFebl0cqaWrH6lct5iyzG4weZsqo1GCnmgMDhMYHLLNiwIbzlMjGQmwuzHf3HiS/fNUu3lztAxjdKgjqdM4TPMfelbSy4IAN+75wdIP/BSdNE5Hyg7RcsnDYpnIOSn+38gYl/Ncng8qH6VhBHwQYUSlkehorwoWzOZ20Mrv5A7heKYbPgLq1EeUcqP637fobhm72YCqtHFIw8Zp2/wp+tFKN3Hw9C9hBFzuuYe2ccDJRZbWyHezFLk7+kvFLSgNw10sUJRWRRjFleOkcuGrmJvDJFy/fy+5EpQk7godGkxmzTsllZIyhw5O9CWQH1LIqmb3pmdvW43ezdOBKD445g/HGwn+4q4c8NDxBSO/U5m9gb+o5dAM5EqjhN8L2Fw8Dt206NlD/jOUj0m2K8NNEpQmh5I3dKAZ2p5TbmrtrQdab68UrdsPwjy5zRyRgEEn4NPTzEMcX1irrCmT/Ga3zoGHaZcuxpnjaSTyjy9b3LEDFCd+GBhR7K6Ie6Byz1Vci0jPlFF9/17ZNx9wV7ezIkLltQVzG3PI9pgHODV7/exFRxFCC+RqnFSaNXe9LdZa5MVrEv9lHA9lf45TbpbvK6Jy9n0WfrPMswXM8S4xsp+sw10gTeVnK2v9NLxFgZGkcWMtx3EcETAIUyPz127aSJc4jjOTVZTnORuvJ7nXj9wYBSk/sZWnFKQHSgRb9bskktj4oXn2y4YOyQwSrm3ZXyMhzBZdqqczVhzdvzw/cecYeX9WGwkz9boYhzvkHRd7BxxhdciSPmTjMBoZPOUlXbjBAmHeOvkuqXcbLUfmyJzRv2m4uby6Y7TcosZiW9yM/o32lfTQl9RbHfjMZoAskKE7BVzGZei3q8PSxxjerZdhy6w+MOKH06mJbK51+cNj3oHjCB0rbr6VCwjyH8GzrTSkXGosrubhBlqmj95QXskpdgUMctL8L6OoSqH5RFQcexasGNIrvrk4/Ds6zy4Lvxe3yBPd8QFRbAKGpj+E1rCzYVbGSid8cQKXsdsuStDBxbhxF0vz5JPJrV0XU5AgwuFkg47o0xJCCIGcBfQQbtJspAFpQrSP3MArd5MkJ1BZnaSWdbWMazb45Jzz4Vm6gcbJf/4tm2Z8kB2wpqHl3uiGGzCzPyqxD/y0MSvox2b2h6Iv1tJlVwkViL4fbF3se41Xy2Tt3qLsdHrPovKgS5RvGFJvHOU3eT92hpXyYzoJUmFS9NjBvBvk2lFptSH7JsY/PvHc1D31ibtCz5Lt6qc9wbPF2o0TlP68SZfG5OR/JdtVb09wkPz8x5N1mkanz57tdrv+btAP49Uz2zTNjPKPJ71clp//eGKN/njSK1qW/eb88aTnxr5rFO85P//xJI036I8nv/yvyE3XvcwMfv7jyQ/2YLlc/vEk/92INxj9/McTtEVB6Hl/POl5P//x5GrYs+z1cGv9OrwnjjH6dbi11s49sXuDz2NsDHr5n61hr53P0/urUd/pTXKM3XfuM+L8p62R00971tra2gujb9p9e2L0Lavv2EZ/0B8nRn9YPBv3B78OFkbfcXqmYRn9oWNYhvV5sDCzZ/mvPcuw1tkDK+MwmRp2zzbsxO71J9Oe3bPXg0VO3rN6/aGT3UuwddaG9dn5dbCdri0zE2y4nhQcqxoMa21YXL3JsdiwelXtOdfsp1+dmqIq/OPJs1/+V9Y7vzx52nPMH3pBaMQoQm5KGWyeQcsvQ7Nm1OGoyj5tR2lf1YVh1A5VNLJHSzgor962i+80yCY/xVtLc/0EGr/l2MvWF5qesbGyIHKSCs7ViKrCrknXYzOA0JWko+aqSJK6qb9gXrzzLEt1csyNIuTGWUxbqC6/qI59yP5O+clx9qf32J4ha+/zcP/zH0+KdZWebdbjPx/Z1qA/tCa9cX/iTN3+aOpkf4tgp9efjie97A120h9Nymdmrz8dTbEx6E/NSW/QnwwyqnH2t0AY/el42jNLhFEimhyMjANY1RvL7FnWPTV4cnMpNjoWRtgcSsdIt7iJxYz2lQU3tjcOreFwiHTf5eiVKdt5/Ne5PMntlHfaAFb1L7LBqR9h9OeBN5Eq4+RQmzQdkNHfMU9T68vcIo2dT4htzrMKGFnZ8eW/2t39IuDEXFJFNQdaL3vwyXa2M/Jtt//K8po/I+L6+M8TQenSx0hYGLlJki1qCwEJcuPFWlicefM/6XvPtDrqP0n6o6DW2GEPH/8HCUoVZk/cGLnAwjh7h4k3zf4Anooa9JRtM5c/cC6F00rlFKTaUYFqLamApbZUsFxrIKjS3t/gy4r669zXCeCJ4cJKpmMx9AFyN/tTTR6TyWTG59b+klXJHGeYTCZ/tRainPoaSZ7GEkmuv4OVHfYwfzgAi44D70cj+8aBeWI+/cs2fziZCHF2BRuYP5xkS5VjMcthhR2aP5yMhLga9pdqNIE3qsheCx/hbq7GAradX7VRpSiovJHs8ETVkjLFwcctwLG9ejTkGzqpM7yiIEdvO5mkodUKV3PJnvI7eSNjlLPgUymLNVrczsN9Pcua2R/6bjPudr/GajTMrZy5m+/0AmDU4ia2fEkr/7jrgr3ArrG1TKShI0QgSsPhVc/+lKw+Sul+Ku7C6edXSjUNAU2yP+wqPH8VlplHuxVccD0BdWnacSNLZQaj5s78SZG0hTeWcXd+lYNiFO07Nlr7/nApx9P8J+Qp1Nn721I2ZjNlM6Fe2Qa9cc8yexMgYzPMLi6+kCdtRv3BYNCznN6gZ1n90WiMreyJkf07zDIkb4rHWS2D3iQruxfnLdivGLg4XBmb/aFOGlcHj6jP7pRv+2Z56kd8L3PNsNfPtO/FYZRzh08e5Q5ev15hVST0XMzUIwjNypOPqpfI7NVuYPKvkU+b21fqlxnHpA59DQq3KtzHIt8XBN1bLlV01foq8dncr+vkR0CYDboz8bkHCdujB6ajkUc7/yOru8Nxo4l4EoQ3OUL1S26CE10zV1oYfBmuHe31q82+gRUmyMgfP1qOSrI4ZmnZQykcv1dF8+Y125SFJ/TVy8Aa1UNvZB2YpnzrfV5Lr984U5wt+p4oMGm9fcE1sz+iKIKj/CX1mDOgj3yPVV6jkYarFUYHrQCB3hJZdk3mOXr9ASJHz0ZFA6No3/Bs5f67qemh1dPjcyOM/fwN2PyhV7tC7qMdwku9m03pL0KM3ShBHr/tL69VTMqfh/274gJ72IwL7CEVGOTB27BnD+vIoMjlmr1Rb9J3pr1xf2j1rEHfGvcsGxvDvjPpDfvONE+wTvDIGN3LViiYtGo+o6i2aEpXPQsd3mxIZKRhcXWb6jMkKgaqK7DgWdTo81+FLfq3OFmvI0UOra6qKh6t/SA9NDbL5j9ikDqHCsKa8VPx+1s5O1Rerm8h0iu2msENtfoTRGb8Bl7VN+gKNxMdmrfigirr9aM4jFCc3tWn1aZT/j1KStmPYp/4eRhf8hjNsz8gEXVKls7HZPmPEpbGfeCSvNFoNJNtWRNz6NVelv/QFENVtcqIw10/Rv9n48fIY+ilG+Ek/LJpQvFpNDk1JQR9FqDPHlSUceonqRsLrm9g6LIOC5JGL4zHYy18Jm5ja0+7dlNm0l37vX6MAg/FyLty49vs/Fcvolg1P8MB7cktOCX8TdX85xCE+V7g7BPNvf4KlGzTBk1SXYB8aO5tsvmVKIgmC6f1v/IgidmZVqyHkstXR2ax8U9ygaI6qG9mKh7yiQlOcJ2PNABEgs8/cMh2n7FgyevD5cVmn5Z6gna8seYHzOxwlOdoMQJPEVItdiTDxezVR9G0vmvWRiBFR4yftmJGx+2Ni3daccEuz8TstWVTPkjoXjxe8eQId0I55XUuE60Ksxww8zop2Eko0rIF9T34JtIc4YJKWtjuUMik8Tp21JgjDjDrK+qyP3rDUcgs/zQT+1Wo4uM90LsWxOcovbFz46Cx9b5YLtN0GPA7ueA8U+b68ivcq7huHrsLZOQZi4Nqv20WihiBS5D0sx5wxQVx89ssjuMCCBRsiOx4fA4qzh4cqCmKhhXnSpNfum8x0Dlf26zkeJMJ9YEu9git6F6XkldvPexXB2OqOLG0Mu5Ci4okD516aXw4fhtZCvRE3VcL3jhEYzQ3cVJn7LIHGp8WZisX3JwlsBqWulhGoI4cqTTaX4Qk2mSjrLiGpfD2/PkkEF8c41HlW6h52zoqj/ddZSWlQ5LvamG3/lSXfQCmQp/GA98oGgvaef7ltPpBcum3MEDPN/ukcWZLNfWszZvCkYHwQyrZn+wIWLXlhNVlwa9IayaNRN4jZhg17JuWovyhvvBO+928tG9Ar9Utg1rqlSi2cbtdw0CB+51KRllLCkYnstK17iuJ/XT2GDu96Jx8c5dsNvkKPtOV5LfmiK/HOZrZdPqDLo/SFR3d5kB6cZeEEX1oWHA5n4S+T+qX5BNtGv4F+wDfk9b4tFo+97ftxRHwPTr6A1v8B8CYOut8jvxSQhFV8cInuPRSRETdT1BeuO7wZ+Yr+BM2/65eiDdGwj7+/r1Ox5yIEH5wPBp+18LdCfdZjCT6bETatR7/7joRidK771sXb9D3ch1E+N28wVHjtSeymYjFifYt6qqHd8NSx/X45o9CSZn1q21ix/eUMXehJm7e46O+5I7zaQIPJsKL3YaYQttpABe8URMQjG6Y/d9t22kYzd0Y2LRnzbM/sgmypK3+2+5LLMC9Q1qfVtGoU//TKSUzV5L346/C0v1CV74uwm/Q3lPbLESLpcejL8xDuAGFZwdWofmX10qB0CXHwEcFBzO9e4hkbPkt1MA2Q9s1B8uZ8ARuYwcg/WJJ7XgUBR1S6frlxzRVH6Bbmtkf2hoAqUamLPzRl6SZnmXjCP7ybcVlq22qbUZhYBfVW43q42On9mP0A/WUP7BI2wi0q7Zx4LrdR+xGkgh7WG6mauefwOEl/EqYHyzDavg6gKfNystLy7NxuPRX1HeyG5fAlDM082HwyUTjDr2skt46U3bUJ64fHJjL8OTonst+lI2HRzGSfnEsB2H/BKAEnhWv8w++/rVoiAVUsLahhwPo4RB66LS/DqegdA/09QharZqpPtJdsKa/TG8tR9MFYGz5rS+y7W45s/7cTVCeTnp4zkFjazMkAZXR1jWCwSjaq7mWXzJnjnsPpzbjdZyxaAEA3opUpHSal6LbhdcSvR85fIYx2UQoVkheX8mTpC6JgBBvMp0vzaGCSz5o1c5UdQVVfm/gPA3gUENxOygUfLIMi2s5vDBQ3WRGXYsIhXZ6AY/oIkRqCeTY60bjxLHqEsScdWMRUrFXk1kp5ReWZdzp5bv6OwXiVWoZs2wJvXlILF8vEG0lZEj7VZdoWdKRTnlqTkJQnudjDo5KpURxHMYJdNIKic+9ZEmCxzjtU+7/ZfKWOgL35tyoYG+AXLhR/sJ4z11jGkYo6a3th0+1NStuhoM+VMLdHdUYVKL3pk1QXi09E5YAIhkeWsp3opRqPL5vHs//LVyMPkX1lZWqD+o1I+pyfVO4lcDWGM60YOXvBjuYwHVMkDSR71YHjXUo2RKtVWUx00rWMQV09cIPew8339EKDv10HWdXxDEf89Vikf83r5Ld//loZspUXcTdqkyLBovuXyC3mxENdaCzGBCH5pWXM+581OTpX2l4ON6bygIs9trT6/xoX9yvAqFjKlF8dk2U2Ds+j7nrQNnEnqo8rje9WOXXAFrcvf9XZ7FixN2aUvajaZrcOsNxcRK6sF4gRbbkqxCDudxRY5MU9Um/bp6leWhzzG1NaWjoF40WVDDZXoubJAuisxuwXcM2bdOwbD4A6LY/jFrF6+X/4V08UL/m3i2QVHPDFkRrGIjMkechj1cA87Gq40Vu1OzNomLoQ1fAflZQmmyRtwqPi9gg2vc8N1kjj70Ns9IQ/yIhYmwYxyMaYhcDUbuLRRh7+VVvjVQVdDQb/KaSlOX37/nG37hxY3DzlbMVJ38RwlxmjUuN2DfPoluFX9XWqNIwihvioKMvxiMfudESR3UU598pjfYdfhCvQq+Gh1BU508bFkjv+yt/c5cucpHmLgR61GrY7S26y+Y56q1Qm6ph660GeblnRvLxQq1KuzuAmlW+UUDrzp6iaXrdTLE3jCj28w+PAkF79dnImLi4Nd8ELcLAa3AujsWoTtdIOeeLwSA/zQVM7ppndXV/h4ZKxn+bijLbC5L0pDVZtYO2ceCjHCuVbKqLSOiWiPOastsrCn1ykRtzRfmMy5XV8VCREP3rEZt/zMFwW1/ajIWf2tlF9fmLep/Mz0+UdX//7qZp7M+za5Q7ZI34ZAmT6MHhDvpcjVwSbhBx25ZV9GRz3DWzit27dtT0zvA2XWgYf/z/tuHCnW+wG9/1NrjrZKKusVmRsYl9Khk8cLqwMIz6PfeRD1ZLVQadXgS9defZi6pDuH8GNAvoFIPST+lvrWx6Kc2JoZRJJxrQbqdRn2wtScZ6wVNFpqO8h2SjNfa8S7u/lNNHyS/1mCziVNUKgFRZx89lFx+P1ovYKnLDqDYy/qJ+7QYh+QUTMt3TGyrzWamcG/7riXzru1ydbpqiOPit1mrvEbQq4S5rIfD57pN/T131dFvNUUftPnvSor3Z8ZvMLCUHfDpqsuScfaud+4pDB8v9/j0PFGOXPtFfL/Gajus5ujGf9tc+20Vyg2jfqh1G9QUWvj3eaIpsJPxehGK+iVDgoSC9rob439LBUDWdertxSBByRdlHBOU5uqcdHRSdW1QfBJyHe7AmwVwnb5f82yiqzz8ociwnbaigbIpENkCi5D+q5/7H/3j2X//oJeEmXqArN4r8YPXp+s3PR6n7iyTpEzf6r2f//wEAUWLKGghSAgA=",
+}
@@ -0,0 +1,66 @@
+//go:build ignore
+// +build ignore
+
+// assets_generate.go writes the files of a directory into assets_gen.go, as
+// the assets doc serve serves without network, gzipped and base64 encoded to
+// keep the source small. Run it by go generate with the dist of Swagger UI:
+//
+//	SWAGGER_UI_DIST=/path/to/dist go generate ./pkg/ginctl/doc
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// assetNames are the files of the dist doc serve requires.
+var assetNames = []string{
+	"swagger-ui-bundle.js",
+	"swagger-ui.css",
+}
+
+func main() {
+	src := flag.String("src", os.Getenv("SWAGGER_UI_DIST"), "Directory of the assets")
+	out := flag.String("out", "assets_gen.go", "Generated go file")
+	flag.Parse()
+	if *src == "" {
+		log.Fatal("the directory of the assets is required, set SWAGGER_UI_DIST or -src")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by assets_generate.go; DO NOT EDIT.\n\npackage doc\n\n")
+	buf.WriteString("// generatedAssets are the gzipped and base64 encoded assets, keyed by name.\n")
+	buf.WriteString("var generatedAssets = map[string]string{\n")
+	sort.Strings(assetNames)
+	for _, name := range assetNames {
+		b, err := ioutil.ReadFile(filepath.Join(*src, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		var gz bytes.Buffer
+		w, _ := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+		w.Write(b)
+		if err := w.Close(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "\t%q: %q,\n", name, base64.StdEncoding.EncodeToString(gz.Bytes()))
+	}
+	buf.WriteString("}\n")
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
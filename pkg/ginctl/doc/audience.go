@@ -0,0 +1,309 @@
+package doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/spec"
+)
+
+var (
+	audienceNameRegexp  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	definitionRefRegexp = regexp.MustCompile(`"\$ref":"#/definitions/([^"]+)"`)
+)
+
+// AudienceConfig assigns the handlers of a project to audiences by package,
+// besides the @Audience annotation, e.g.
+//
+//	default: internal
+//	audiences:
+//	  public:
+//	    title: 开放平台API
+//	    packages: [api/rest/open]
+//	  partner:
+//	    packages: [api/rest/partner, api/rest/open]
+type AudienceConfig struct {
+	// Default is the audience of the handlers without @Audience and not in
+	// any package configured, which are only in the full spec when empty.
+	Default   string               `json:"default"`
+	Audiences map[string]*Audience `json:"audiences"`
+}
+
+// Audience is the spec generated for an audience.
+type Audience struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Packages are the api/rest directories, relative to the project, which
+	// handlers are for the audience, including their sub packages.
+	Packages []string `json:"packages"`
+}
+
+// LoadAudienceConfig reads the audience config of file.
+func LoadAudienceConfig(file string) (*AudienceConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(AudienceConfig)
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse audience config %s failed: %v", file, err)
+	}
+	if cfg.Default != "" && !audienceNameRegexp.MatchString(cfg.Default) {
+		return nil, fmt.Errorf("invalid default audience %s of %s, only letters, digits, _ and - are allowed", cfg.Default, file)
+	}
+	for name, audience := range cfg.Audiences {
+		if !audienceNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid audience %s of %s, only letters, digits, _ and - are allowed", name, file)
+		}
+		if audience == nil {
+			cfg.Audiences[name] = new(Audience)
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseAudienceAnnotation parses the comma separated audiences of @Audience.
+func parseAudienceAnnotation(params string) ([]string, error) {
+	audiences := make([]string, 0)
+	for _, name := range strings.Split(params, ",") {
+		name = strings.TrimSpace(name)
+		if !audienceNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid @Audience %s, only letters, digits, _ and - are allowed", name)
+		}
+		audiences = append(audiences, name)
+	}
+
+	return audiences, nil
+}
+
+// handlerAudiences returns the audiences of a handler declared in the rest
+// file path: the annotated ones, or else the ones of its package.
+func (p *Parser) handlerAudiences(path string, annotated []string) []string {
+	if len(annotated) > 0 || p.audience == nil {
+		return annotated
+	}
+
+	dir, err := filepath.Rel(p.cwd, filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	dir = filepath.ToSlash(dir)
+
+	audiences := make([]string, 0)
+	for name, audience := range p.audience.Audiences {
+		for _, pkg := range audience.Packages {
+			pkg = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(pkg)), "/")
+			if dir == pkg || strings.HasPrefix(dir, pkg+"/") {
+				audiences = append(audiences, name)
+				break
+			}
+		}
+	}
+	if len(audiences) == 0 && p.audience.Default != "" {
+		audiences = append(audiences, p.audience.Default)
+	}
+	sort.Strings(audiences)
+
+	return audiences
+}
+
+// audienceNames returns the audiences of the operations.
+func (p *Parser) audienceNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, audiences := range p.operationAudiences {
+		for _, name := range audiences {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+// audienceSwaggers splits swagger by the audiences of its operations, each
+// spec keeping only the definitions and tags its operations use.
+func (p *Parser) audienceSwaggers(swagger *spec.Swagger) (map[string]*spec.Swagger, error) {
+	swaggers := make(map[string]*spec.Swagger)
+	for name := range p.audienceNames() {
+		sub, err := p.filterSwagger(swagger, func(key string) bool {
+			return containsString(p.operationAudiences[key], name)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if p.audience != nil && p.audience.Audiences[name] != nil {
+			audience := p.audience.Audiences[name]
+			if audience.Title != "" {
+				sub.Info.Title = audience.Title
+			}
+			if audience.Description != "" {
+				sub.Info.Description = audience.Description
+			}
+		}
+		swaggers[name] = sub
+	}
+
+	return swaggers, nil
+}
+
+// filterSwagger returns a copy of swagger with the operations kept, by their
+// method and path, and the definitions and tags they use.
+func (p *Parser) filterSwagger(swagger *spec.Swagger, keep func(key string) bool) (*spec.Swagger, error) {
+	sub := *swagger
+	if swagger.Info != nil {
+		info := *swagger.Info
+		sub.Info = &info
+	} else {
+		sub.Info = new(spec.Info)
+	}
+
+	sub.Paths = &spec.Paths{Paths: make(map[string]spec.PathItem)}
+	tags := make(map[string]bool)
+	if swagger.Paths != nil {
+		for path, item := range swagger.Paths.Paths {
+			kept := false
+			for method, op := range pathOperations(&item) {
+				if op == nil {
+					continue
+				}
+				if keep(strings.ToUpper(method) + " " + path) {
+					kept = true
+					for _, tag := range op.Tags {
+						tags[tag] = true
+					}
+					continue
+				}
+				setPathOperation(&item, method, nil)
+			}
+			if kept {
+				sub.Paths.Paths[path] = item
+			}
+		}
+	}
+
+	sub.Tags = nil
+	for _, tag := range swagger.Tags {
+		if tags[tag.Name] {
+			sub.Tags = append(sub.Tags, tag)
+		}
+	}
+
+	// keep the definitions referred, directly or not, by the operations
+	definitions := make(spec.Definitions)
+	pending := []interface{}{sub.Paths}
+	for len(pending) > 0 {
+		b, err := json.Marshal(pending[0])
+		if err != nil {
+			return nil, err
+		}
+		pending = pending[1:]
+		for _, match := range definitionRefRegexp.FindAllSubmatch(b, -1) {
+			ref := strings.Replace(strings.Replace(string(match[1]), "~1", "/", -1), "~0", "~", -1)
+			if _, ok := definitions[ref]; ok {
+				continue
+			}
+			if schema, ok := swagger.Definitions[ref]; ok {
+				definitions[ref] = schema
+				pending = append(pending, schema)
+			}
+		}
+	}
+	sub.Definitions = definitions
+
+	return &sub, nil
+}
+
+func setPathOperation(item *spec.PathItem, method string, op *spec.Operation) {
+	switch method {
+	case "get":
+		item.Get = op
+	case "put":
+		item.Put = op
+	case "post":
+		item.Post = op
+	case "delete":
+		item.Delete = op
+	case "options":
+		item.Options = op
+	case "head":
+		item.Head = op
+	case "patch":
+		item.Patch = op
+	}
+}
+
+// writeAudienceSwaggers writes the spec of each audience into its directory
+// of outputDir.
+func (p *Parser) writeAudienceSwaggers(swagger *spec.Swagger, outputDir string) error {
+	swaggers, err := p.audienceSwaggers(swagger)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(swaggers))
+	for name := range swaggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dir := filepath.Join(outputDir, name)
+		if err := writeSpec(swaggers[name], dir); err != nil {
+			return err
+		}
+		log.Printf("create %s spec at %+v", name, dir)
+	}
+
+	// the version directories are cleaned up by writeVersionSwaggers
+	return removeStaleSpecs(outputDir, func(name string) bool {
+		return swaggers[name] == nil && !versionRegexp.MatchString(name)
+	})
+}
+
+// removeStaleSpecs removes the directories of outputDir which stale reports
+// by name and which hold nothing but the specs of writeSpec, left by the
+// audiences or versions no longer generated.
+func removeStaleSpecs(outputDir string, stale func(name string) bool) error {
+	fileInfos, err := ioutil.ReadDir(outputDir)
+	if err != nil {
+		return err
+	}
+
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() || !stale(fileInfo.Name()) {
+			continue
+		}
+		dir := filepath.Join(outputDir, fileInfo.Name())
+		if !isSpecDir(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		log.Printf("remove stale %s spec at %+v", fileInfo.Name(), dir)
+	}
+
+	return nil
+}
+
+func isSpecDir(dir string) bool {
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil || len(fileInfos) == 0 {
+		return false
+	}
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() || fileInfo.Name() != "swagger.json" && fileInfo.Name() != "swagger.yaml" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,151 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/spec"
+	"github.com/swaggo/swag/gen"
+)
+
+// Build generates docs.go, swagger.json and swagger.yaml with gen.Build,
+// then completes the swagger spec with what swag can't read from typespec
+// and writes it back into them.
+func (p *Parser) Build(config *gen.Config) error {
+	if err := gen.New().Build(config); err != nil {
+		return err
+	}
+	swagger, err := readSwagger(config.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	p.propNamingStrategy = config.PropNamingStrategy
+	if err := p.applyValidations(swagger); err != nil {
+		return err
+	}
+	if err := p.applyExamples(swagger); err != nil {
+		return err
+	}
+	p.applySecurity(swagger)
+	p.applyFailures(swagger)
+
+	if err := WriteSwagger(swagger, config.OutputDir); err != nil {
+		return err
+	}
+
+	if err := p.writeAudienceSwaggers(swagger, config.OutputDir); err != nil {
+		return err
+	}
+
+	return p.writeVersionSwaggers(swagger, config.OutputDir)
+}
+
+// readSwagger reads the swagger.json of outputDir.
+func readSwagger(outputDir string) (*spec.Swagger, error) {
+	b, err := ioutil.ReadFile(filepath.Join(outputDir, "swagger.json"))
+	if err != nil {
+		return nil, err
+	}
+	swagger := new(spec.Swagger)
+	if err := json.Unmarshal(b, swagger); err != nil {
+		return nil, err
+	}
+
+	return swagger, nil
+}
+
+// WriteSwagger writes swagger into the docs.go, swagger.json and
+// swagger.yaml gen.Build generated in outputDir.
+func WriteSwagger(swagger *spec.Swagger, outputDir string) error {
+	if err := writeSpec(swagger, outputDir); err != nil {
+		return err
+	}
+
+	return writeGoDoc(swagger, filepath.Join(outputDir, "docs.go"))
+}
+
+// writeSpec writes swagger into swagger.json and swagger.yaml of outputDir.
+func writeSpec(swagger *spec.Swagger, outputDir string) error {
+	b, err := json.MarshalIndent(swagger, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := writeFile(b, filepath.Join(outputDir, "swagger.json")); err != nil {
+		return err
+	}
+
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		return fmt.Errorf("cannot convert json to yaml error: %s", err)
+	}
+
+	return writeFile(y, filepath.Join(outputDir, "swagger.yaml"))
+}
+
+func writeFile(b []byte, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(b)
+	return err
+}
+
+// writeGoDoc replaces the spec docs.go registers to swag with swagger. The
+// spec is encoded the way gen.Build of swag v1.7.0 does, with the info fields
+// as the placeholders SwaggerInfo fills in when the doc is read.
+func writeGoDoc(swagger *spec.Swagger, file string) error {
+	code, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	start := bytes.Index(code, []byte(docStart))
+	end := bytes.Index(code, []byte(docEnd))
+	if start == -1 || end < start {
+		return fmt.Errorf("spec not found in %s", file)
+	}
+	start += len(docStart)
+
+	docSpec := *swagger
+	docSpec.Schemes = nil
+	info := spec.Info{}
+	if swagger.Info != nil {
+		info = *swagger.Info
+	}
+	info.Title, info.Description, info.Version = "{{.Title}}", "{{.Description}}", "{{.Version}}"
+	docSpec.Info = &info
+	docSpec.Host, docSpec.BasePath = "{{.Host}}", "{{.BasePath}}"
+	b, err := json.MarshalIndent(&docSpec, "", "    ")
+	if err != nil {
+		return err
+	}
+	doc := "{\n    \"schemes\": {{ marshal .Schemes }}," + string(b[1:])
+	doc = strings.Replace(doc, "`", "`+\"`\"+`", -1)
+
+	buf := bytes.NewBuffer(nil)
+	buf.Write(code[:start])
+	buf.WriteString(doc)
+	buf.Write(code[end:])
+
+	return writeFile(buf.Bytes(), file)
+}
+
+// docStart and docEnd enclose the spec in the docs.go of gen.Build.
+const (
+	docStart = "var doc = `"
+	docEnd   = "`\n\ntype swaggerInfo struct"
+)
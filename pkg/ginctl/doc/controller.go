@@ -0,0 +1,122 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var identRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Controller is a struct of a rest package whose methods are handlers,
+// declared by @Controller Type [constructor] in a package comment, e.g.
+//
+//	// @Controller UserController NewUserController
+//	package user
+//
+// The constructor is an exported func of the package without parameters,
+// New<Type> by default, which injects the dependencies of the controller.
+type Controller struct {
+	Type        string
+	Constructor string
+	// Var is the variable of the controller in the generated registerRoute,
+	// and Name the one in its package.
+	Var  string
+	Name string
+	pos  token.Position
+}
+
+// parseControllerAnnotation parses the params of @Controller, which are
+// Type [constructor].
+func parseControllerAnnotation(params string) (*Controller, error) {
+	fields := strings.Fields(params)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid @Controller %s, expected Type [constructor]", params)
+	}
+	for _, field := range fields {
+		if !identRegexp.MatchString(field) {
+			return nil, fmt.Errorf("invalid @Controller %s, %s isn't an identifier of the package", params, field)
+		}
+	}
+
+	c := &Controller{
+		Type:        fields[0],
+		Constructor: "New" + fields[0],
+		Name:        strings.ToLower(fields[0][:1]) + fields[0][1:],
+	}
+	if len(fields) == 2 {
+		c.Constructor = fields[1]
+	}
+	// api.go constructs the controllers out of their packages
+	if !ast.IsExported(c.Constructor) {
+		return nil, fmt.Errorf("invalid @Controller %s, constructor %s isn't exported", params, c.Constructor)
+	}
+
+	return c, nil
+}
+
+// handlerController returns the controller of the handler methods of
+// receiver in the rest package of info.
+func (p *Parser) handlerController(info *AstFileInfo, receiver string) (*Controller, error) {
+	controllers, ok := p.controllers[info.PackagePath]
+	if !ok {
+		var err error
+		if controllers, err = p.packageControllers(info); err != nil {
+			return nil, err
+		}
+		p.controllers[info.PackagePath] = controllers
+	}
+
+	return controllers[receiver], nil
+}
+
+// packageControllers returns the controllers declared in the package
+// comments of the files of the rest package of info, keyed by their type.
+func (p *Parser) packageControllers(info *AstFileInfo) (map[string]*Controller, error) {
+	controllers := make(map[string]*Controller)
+	if p.Packages == nil {
+		return controllers, nil
+	}
+	pkg, ok := p.Packages.packages[info.PackagePath]
+	if !ok {
+		return controllers, nil
+	}
+
+	paths := make([]string, 0, len(pkg.Files))
+	for path := range pkg.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		astFile := pkg.Files[path]
+		fileInfo := p.Packages.files[astFile]
+		if astFile.Doc == nil || fileInfo == nil {
+			continue
+		}
+		for _, comment := range astFile.Doc.List {
+			commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
+			fields := strings.Fields(commentLine)
+			if len(fields) == 0 || strings.ToLower(fields[0]) != "@controller" {
+				continue
+			}
+			position := fileInfo.FileSet.Position(comment.Pos())
+			controller, err := parseControllerAnnotation(strings.TrimSpace(strings.TrimPrefix(commentLine, fields[0])))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", position, err)
+			}
+			if declared, ok := controllers[controller.Type]; ok {
+				return nil, fmt.Errorf("%s: duplicate @Controller %s, declared at %s", position, controller.Type, declared.pos)
+			}
+			// rest packages are named apart in registerRoute, and so are
+			// their controllers by the name of the package
+			controller.Var = restPackageName(info.PackagePath) + controller.Type
+			controller.pos = position
+			controllers[controller.Type] = controller
+		}
+	}
+
+	return controllers, nil
+}
@@ -0,0 +1,436 @@
+package doc
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+const exampleDateTime = "2021-01-01 00:00:00"
+
+// fakerExamples maps the faker tags used by typespec fields to a fixed
+// example value, so generated examples stay stable between runs.
+var fakerExamples = map[string]string{
+	"email":           "user@example.com",
+	"phone_number":    "13800138000",
+	"e_164_phone":     "+8613800138000",
+	"toll_free_phone": "400-800-8000",
+	"name":            "张三",
+	"chinese_name":    "张三",
+	"first_name":      "San",
+	"last_name":       "Zhang",
+	"username":        "zhangsan",
+	"password":        "P@ssw0rd",
+	"url":             "https://www.example.com",
+	"domain_name":     "example.com",
+	"ipv4":            "127.0.0.1",
+	"ipv6":            "::1",
+	"mac_address":     "00:00:5e:00:53:01",
+	"uuid_digit":      "0bd0d3f6f2cd4d4e8f2dd6bb5fb1c7a4",
+	"uuid_hyphenated": "0bd0d3f6-f2cd-4d4e-8f2d-d6bb5fb1c7a4",
+	"date":            "2021-01-01",
+	"time":            "00:00:00",
+	"timestamp":       exampleDateTime,
+	"unix_time":       "1609430400",
+	"month_name":      "January",
+	"year":            "2021",
+	"day_of_week":     "Friday",
+	"timezone":        "Asia/Shanghai",
+	"word":            "word",
+	"sentence":        "This is a sentence.",
+	"paragraph":       "This is a paragraph.",
+	"currency":        "CNY",
+	"amount":          "99.9",
+	"cc_type":         "VISA",
+	"cc_number":       "4111111111111111",
+	"lat":             "39.9042",
+	"long":            "116.4074",
+}
+
+// formatExamples maps the swagger formats validated by binding tags to the
+// faker tag whose example matches them.
+var formatExamples = map[string]string{
+	"email":     "email",
+	"uri":       "url",
+	"uuid":      "uuid_hyphenated",
+	"ipv4":      "ipv4",
+	"ipv6":      "ipv6",
+	"date-time": "timestamp",
+}
+
+// ExampleField is an example value of a field of a typespec struct.
+type ExampleField struct {
+	Name        string
+	Value       interface{}
+	Description string
+	// Header is the header name when the field is bound from request header.
+	Header string
+}
+
+// ExampleFields returns example values of the fields of the struct typeName
+// declared in the typespec package dir, named by tagName (json or form) the
+// way gin binds them: embedded structs are flattened, and so are nested
+// structs without tag when binding forms.
+func (p *Parser) ExampleFields(dir, typeName, tagName string) ([]*ExampleField, error) {
+	def, err := p.findTypeDefInDir(dir, typeName)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := def.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, p.typeSpecError(def.spec, "type spec "+typeName+" is not a struct")
+	}
+
+	visiting := map[token.Pos]bool{def.key(): true}
+	return p.exampleFields(def, st, tagName, visiting), nil
+}
+
+// Example returns an example of the struct typeName declared in the typespec
+// package dir as it is encoded with tagName.
+func (p *Parser) Example(dir, typeName, tagName string) (map[string]interface{}, error) {
+	fields, err := p.ExampleFields(dir, typeName, tagName)
+	if err != nil {
+		return nil, err
+	}
+
+	example := make(map[string]interface{})
+	for _, field := range fields {
+		if field.Header == "" {
+			example[field.Name] = field.Value
+		}
+	}
+
+	return example, nil
+}
+
+func (p *Parser) exampleFields(def *typeSpecDef, st *ast.StructType, tagName string, visiting map[token.Pos]bool) []*ExampleField {
+	fields := make([]*ExampleField, 0)
+	for _, ls := range st.Fields.List {
+		var tag reflect.StructTag
+		if ls.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(ls.Tag.Value, "`"))
+		}
+		if tag.Get("swagignore") != "" || strings.EqualFold(tag.Get("swaggerignore"), "true") {
+			continue
+		}
+		name := tag.Get(tagName)
+		if index := strings.Index(name, ","); index != -1 {
+			name = name[:index]
+		}
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && (len(ls.Names) == 0 || tagName == "form") {
+			if sub := p.exampleStructDef(def, ls.Type); sub != nil {
+				if visiting[sub.key()] {
+					continue
+				}
+				visiting[sub.key()] = true
+				fields = append(fields, p.exampleFields(sub, sub.spec.Type.(*ast.StructType), tagName, visiting)...)
+				delete(visiting, sub.key())
+				continue
+			}
+		}
+		if len(ls.Names) == 0 {
+			continue
+		}
+
+		for _, ident := range ls.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			field := &ExampleField{
+				Name:        name,
+				Value:       p.exampleValue(def, ls.Type, tag, visiting),
+				Description: strings.TrimSpace(ls.Comment.Text()),
+			}
+			if field.Name == "" {
+				field.Name = ident.Name
+				// swag names the properties without tag by its strategy
+				if tagName == "json" && p.propNamingStrategy != "" {
+					field.Name = propertyName(ident.Name, p.propNamingStrategy)
+				}
+			}
+			if header := tag.Get("header"); header != "" {
+				if index := strings.Index(header, ","); index != -1 {
+					header = header[:index]
+				}
+				field.Header = header
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// exampleStructDef returns the struct declaration expr refers to, or nil when
+// expr is not a struct.
+func (p *Parser) exampleStructDef(def *typeSpecDef, expr ast.Expr) *typeSpecDef {
+	switch typ := expr.(type) {
+	case *ast.StarExpr:
+		return p.exampleStructDef(def, typ.X)
+	case *ast.StructType:
+		return &typeSpecDef{
+			spec: &ast.TypeSpec{Name: ast.NewIdent("anonymous"), Type: typ},
+			dir:  def.dir,
+			file: def.file,
+		}
+	case *ast.Ident, *ast.SelectorExpr:
+		if _, ok := p.basicTypeOf(expr); ok {
+			return nil
+		}
+		sub, err := p.lookupNamedType(def, expr)
+		if err != nil {
+			return nil
+		}
+		if _, ok := sub.spec.Type.(*ast.StructType); ok {
+			return sub
+		}
+		return p.exampleStructDef(sub, sub.spec.Type)
+	}
+
+	return nil
+}
+
+func (p *Parser) exampleValue(def *typeSpecDef, expr ast.Expr, tag reflect.StructTag, visiting map[token.Pos]bool) interface{} {
+	switch typ := expr.(type) {
+	case *ast.StarExpr:
+		return p.exampleValue(def, typ.X, tag, visiting)
+	case *ast.Ident, *ast.SelectorExpr:
+		if basic, ok := p.basicTypeOf(expr); ok {
+			if sel, ok := expr.(*ast.SelectorExpr); ok && strings.HasSuffix(sel.Sel.Name, "Time") && tag.Get("example") == "" {
+				return exampleDateTime
+			}
+			return basicExample(basic, tag)
+		}
+		sub, err := p.lookupNamedType(def, expr)
+		if err != nil {
+			return nil
+		}
+		if st, ok := sub.spec.Type.(*ast.StructType); ok {
+			if visiting[sub.key()] {
+				return nil
+			}
+			visiting[sub.key()] = true
+			defer delete(visiting, sub.key())
+			return p.exampleObject(sub, st, visiting)
+		}
+		if enum := p.typeEnum(sub); enum != nil && tag.Get("example") == "" {
+			return enum.values[0]
+		}
+		return p.exampleValue(sub, sub.spec.Type, tag, visiting)
+	case *ast.ArrayType:
+		if ident, ok := typ.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return basicExample("string", tag)
+		}
+		if basic, ok := p.basicTypeOf(typ.Elt); ok && tag.Get("example") != "" {
+			values := make([]interface{}, 0)
+			for _, v := range strings.Split(tag.Get("example"), ",") {
+				values = append(values, convertExample(basic, strings.TrimSpace(v)))
+			}
+			return values
+		}
+		if elt := p.exampleValue(def, typ.Elt, tag, visiting); elt != nil {
+			return []interface{}{elt}
+		}
+		return []interface{}{}
+	case *ast.MapType:
+		return map[string]interface{}{
+			"key": p.exampleValue(def, typ.Value, "", visiting),
+		}
+	case *ast.StructType:
+		return p.exampleObject(def, typ, visiting)
+	}
+
+	return nil
+}
+
+func (p *Parser) exampleObject(def *typeSpecDef, st *ast.StructType, visiting map[token.Pos]bool) map[string]interface{} {
+	object := make(map[string]interface{})
+	for _, field := range p.exampleFields(def, st, "json", visiting) {
+		object[field.Name] = field.Value
+	}
+
+	return object
+}
+
+// basicExample returns the example of a field of basic type typ, taken from
+// its example tag, faker tag, form default, validation rules or the zero
+// value of typ.
+func basicExample(typ string, tag reflect.StructTag) interface{} {
+	if example := tag.Get("example"); example != "" {
+		return convertExample(typ, example)
+	}
+	if faker := tag.Get("faker"); faker != "" {
+		if index := strings.Index(faker, ","); index != -1 {
+			faker = faker[:index]
+		}
+		if example, ok := fakerExamples[faker]; ok {
+			return convertExample(typ, example)
+		}
+	}
+	for _, option := range strings.Split(tag.Get("form"), ",") {
+		if strings.HasPrefix(option, "default=") {
+			return convertExample(typ, strings.TrimPrefix(option, "default="))
+		}
+	}
+
+	v := parseValidation(tag)
+	if len(v.oneOf) > 0 {
+		return convertExample(typ, v.oneOf[0])
+	}
+	if example, ok := fakerExamples[formatExamples[v.format]]; ok && typ == "string" {
+		return example
+	}
+	if v.min != nil && typ != "string" && typ != "bool" {
+		min := *v.min
+		if v.exclusiveMin {
+			min++
+		}
+		return convertExample(typ, strconv.FormatFloat(min, 'f', -1, 64))
+	}
+
+	switch typ {
+	case "string":
+		return "string"
+	case "bool":
+		return false
+	case "float32", "float64":
+		return 0.0
+	default:
+		return 0
+	}
+}
+
+func convertExample(typ, value string) interface{} {
+	switch typ {
+	case "bool":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case "float32", "float64":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "string":
+		return value
+	default:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+
+	return value
+}
+
+// operationType is the typespec package and handler name of an operation,
+// whose Request and Response structs are its examples.
+type operationType struct {
+	dir      string
+	funcName string
+}
+
+// applyExamples completes swagger with the examples of the typespec structs,
+// on their definitions, properties and the operations using them.
+func (p *Parser) applyExamples(swagger *spec.Swagger) error {
+	defs, err := p.definitionDefs(swagger)
+	if err != nil {
+		return err
+	}
+	for name, def := range defs {
+		schema := swagger.Definitions[name]
+		visiting := map[token.Pos]bool{def.key(): true}
+		example := p.exampleObject(def, def.spec.Type.(*ast.StructType), visiting)
+		for propName, prop := range schema.Properties {
+			if value, ok := example[propName]; ok && prop.Ref.String() == "" && prop.Example == nil {
+				prop.Example = value
+				schema.Properties[propName] = prop
+			}
+		}
+		if schema.Example == nil {
+			schema.Example = example
+		}
+		swagger.Definitions[name] = schema
+	}
+
+	if swagger.Paths == nil {
+		return nil
+	}
+	for path, item := range swagger.Paths.Paths {
+		for method, op := range pathOperations(&item) {
+			typ := p.operationTypes[strings.ToUpper(method)+" "+path]
+			if op == nil || typ == nil {
+				continue
+			}
+			p.exampleOperation(op, typ)
+		}
+		swagger.Paths.Paths[path] = item
+	}
+
+	return nil
+}
+
+// exampleOperation sets the examples of the params and success response of
+// op, so a full request and response can be read from the operation alone.
+func (p *Parser) exampleOperation(op *spec.Operation, typ *operationType) {
+	if fields, err := p.ExampleFields(typ.dir, typ.funcName+"Request", "form"); err == nil {
+		examples := make(map[string]interface{})
+		for _, field := range fields {
+			if field.Header != "" {
+				examples["header:"+field.Header] = field.Value
+			}
+			examples[field.Name] = field.Value
+		}
+		for i := range op.Parameters {
+			param := &op.Parameters[i]
+			key := param.Name
+			if param.In == "header" {
+				key = "header:" + param.Name
+			}
+			if value, ok := examples[key]; ok && param.In != "body" {
+				param.AddExtension("x-example", paramExample(value))
+			}
+		}
+	}
+
+	if op.Responses == nil {
+		return
+	}
+	resp, ok := op.Responses.StatusCodeResponses[200]
+	if !ok || resp.Schema == nil {
+		return
+	}
+	if example, err := p.Example(typ.dir, typ.funcName+"Response", "json"); err == nil {
+		resp.Examples = map[string]interface{}{"application/json": example}
+		op.Responses.StatusCodeResponses[200] = resp
+	}
+}
+
+// paramExample returns value as a param carries it: objects, which are bound
+// from json strings, are encoded.
+func paramExample(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case []interface{}:
+		for _, elt := range v {
+			if _, ok := elt.(map[string]interface{}); ok {
+				b, _ := json.Marshal(v)
+				return string(b)
+			}
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,649 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// maxErrorCallDepth limits how deep the calls of a handler are followed to
+// find the errors it may respond.
+const maxErrorCallDepth = 4
+
+// ErrorCode is a business error declared in the error registry of a
+// project, which is a package level var initialized with its code and
+// message, e.g. errors.New(10001, "用户不存在").
+type ErrorCode struct {
+	Name    string `json:"name"`
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+// Failure is a http status a handler responds by httppkg.Error, with the
+// business errors it may carry.
+type Failure struct {
+	Status int          `json:"status"`
+	Codes  []*ErrorCode `json:"codes,omitempty"`
+}
+
+// errorPackage is a parsed package of the project.
+type errorPackage struct {
+	files  []*errorFile
+	funcs  map[string][]*errorFunc
+	errors map[string]*ErrorCode
+}
+
+type errorFile struct {
+	pkg  *errorPackage
+	file *ast.File
+	// imports maps the import names to the paths of the project packages.
+	imports map[string]string
+}
+
+type errorFunc struct {
+	decl *ast.FuncDecl
+	file *errorFile
+}
+
+// errorAnalyzer finds the errors handlers may respond by following their
+// calls into the packages of the project.
+type errorAnalyzer struct {
+	p        *Parser
+	fset     *token.FileSet
+	packages map[string]*errorPackage
+	// funcErrors caches the errors of a function, nil while being analyzed.
+	funcErrors map[token.Pos][]*ErrorCode
+	// touched are the directories of the packages the handler being
+	// analyzed depends on.
+	touched map[string]bool
+}
+
+func newErrorAnalyzer(p *Parser) *errorAnalyzer {
+	return &errorAnalyzer{
+		p:          p,
+		fset:       token.NewFileSet(),
+		packages:   make(map[string]*errorPackage),
+		funcErrors: make(map[token.Pos][]*ErrorCode),
+	}
+}
+
+// handlerFailures returns the failures of the handler funcDecl declared in
+// astFile, ordered by status. They are cached until a package the handler
+// depends on changes.
+func (p *Parser) handlerFailures(info *AstFileInfo, astFile *ast.File, funcDecl *ast.FuncDecl) []*Failure {
+	if funcDecl.Body == nil {
+		return nil
+	}
+	if p.errorAnalyzer == nil {
+		p.errorAnalyzer = newErrorAnalyzer(p)
+	}
+	a := p.errorAnalyzer
+
+	key := fmt.Sprintf("%s:%s", info.Path, funcDecl.Name.Name)
+	if funcDecl.Recv != nil {
+		key = fmt.Sprintf("%s:%d", key, info.FileSet.Position(funcDecl.Pos()).Line)
+	}
+	var failures []*Failure
+	if p.cache.Get("failures", key, &failures) {
+		return failures
+	}
+
+	a.touched = map[string]bool{filepath.Dir(info.Path): true}
+	failures = a.handlerFailures(info.PackagePath, astFile, funcDecl)
+	deps := []string{filepath.Join(p.cwd, "go.mod")}
+	for dir := range a.touched {
+		deps = append(deps, dir)
+	}
+	a.touched = nil
+	_ = p.cache.Put("failures", key, deps, failures)
+
+	return failures
+}
+
+func (a *errorAnalyzer) handlerFailures(pkgPath string, astFile *ast.File, funcDecl *ast.FuncDecl) []*Failure {
+
+	httpPkg := ""
+	for _, importSpec := range astFile.Imports {
+		importPath := strings.Trim(importSpec.Path.Value, "\"")
+		if strings.HasSuffix(importPath, "/pkg/http") {
+			httpPkg = "http"
+			if importSpec.Name != nil {
+				httpPkg = importSpec.Name.Name
+			}
+		}
+	}
+	if httpPkg == "" {
+		return nil
+	}
+
+	file := &errorFile{
+		pkg:     a.loadPackage(pkgPath),
+		file:    astFile,
+		imports: a.projectImports(astFile),
+	}
+	assigns := assignments(funcDecl.Body)
+
+	failures := make(map[int]*Failure)
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) < 3 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Error" {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != httpPkg {
+			return true
+		}
+		// the status which can't be read statically is unclassified, which
+		// is documented as a server error
+		status, ok := statusCode(call.Args[1])
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+
+		failure, ok := failures[status]
+		if !ok {
+			failure = &Failure{Status: status}
+			failures[status] = failure
+		}
+		for _, code := range a.exprErrors(file, call.Args[2], assigns, make(map[string]bool), maxErrorCallDepth) {
+			failure.Codes = appendErrorCode(failure.Codes, code)
+		}
+		return true
+	})
+
+	list := make([]*Failure, 0, len(failures))
+	for _, failure := range failures {
+		sort.Slice(failure.Codes, func(i, j int) bool {
+			return failure.Codes[i].Code < failure.Codes[j].Code
+		})
+		list = append(list, failure)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Status < list[j].Status
+	})
+
+	return list
+}
+
+// failureComment returns the @Failure annotations of failures, besides the
+// 200 of the success response.
+func failureComment(failures []*Failure) string {
+	var comment string
+	for _, failure := range failures {
+		if failure.Status == http.StatusOK {
+			continue
+		}
+		desc := http.StatusText(failure.Status)
+		if failure.Status == http.StatusInternalServerError {
+			desc = "服务异常"
+		} else if desc == "" {
+			desc = strconv.Itoa(failure.Status)
+		}
+		comment += fmt.Sprintf("// @Failure %d \"%s\"\n", failure.Status, desc)
+	}
+
+	return comment
+}
+
+// assignments maps the variables assigned in body to the values assigned.
+func assignments(body *ast.BlockStmt) map[string][]ast.Expr {
+	assigns := make(map[string][]ast.Expr)
+	ast.Inspect(body, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok || ident.Name == "_" {
+				continue
+			}
+			rhs := assign.Rhs[0]
+			if len(assign.Rhs) == len(assign.Lhs) {
+				rhs = assign.Rhs[i]
+			}
+			assigns[ident.Name] = append(assigns[ident.Name], rhs)
+		}
+		return true
+	})
+
+	return assigns
+}
+
+// exprErrors returns the errors expr, the err param of httppkg.Error, may
+// hold: registry errors, the values assigned to it, or the errors of calls.
+func (a *errorAnalyzer) exprErrors(file *errorFile, expr ast.Expr, assigns map[string][]ast.Expr, visiting map[string]bool, depth int) []*ErrorCode {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return a.exprErrors(file, e.X, assigns, visiting, depth)
+	case *ast.Ident:
+		if code := a.registryError(file, e); code != nil {
+			return []*ErrorCode{code}
+		}
+		if visiting[e.Name] {
+			return nil
+		}
+		visiting[e.Name] = true
+		var codes []*ErrorCode
+		for _, value := range assigns[e.Name] {
+			codes = append(codes, a.exprErrors(file, value, assigns, visiting, depth)...)
+		}
+		return codes
+	case *ast.SelectorExpr:
+		if code := a.registryError(file, e); code != nil {
+			return []*ErrorCode{code}
+		}
+	case *ast.CallExpr:
+		var codes []*ErrorCode
+		for _, fn := range a.resolveCall(file, e) {
+			codes = append(codes, a.errorsOf(fn, depth-1)...)
+		}
+		// wrapped errors like errors.Wrap(err, "...")
+		for _, arg := range e.Args {
+			codes = append(codes, a.exprErrors(file, arg, assigns, visiting, depth)...)
+		}
+		return codes
+	}
+
+	return nil
+}
+
+// errorsOf returns the registry errors fn, or the functions it calls, refers
+// to.
+func (a *errorAnalyzer) errorsOf(fn *errorFunc, depth int) []*ErrorCode {
+	if depth <= 0 || fn.decl.Body == nil {
+		return nil
+	}
+	if codes, ok := a.funcErrors[fn.decl.Pos()]; ok {
+		return codes
+	}
+	a.funcErrors[fn.decl.Pos()] = nil
+
+	var (
+		codes []*ErrorCode
+		visit func(node ast.Node) bool
+	)
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			if code := a.registryError(fn.file, n); code != nil {
+				codes = appendErrorCode(codes, code)
+				return false
+			}
+			// the selected name is a field or method, never a registry
+			// error of the package
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Ident:
+			if code := a.registryError(fn.file, n); code != nil {
+				codes = appendErrorCode(codes, code)
+			}
+		case *ast.CallExpr:
+			for _, callee := range a.resolveCall(fn.file, n) {
+				for _, code := range a.errorsOf(callee, depth-1) {
+					codes = appendErrorCode(codes, code)
+				}
+			}
+		}
+		return true
+	}
+	ast.Inspect(fn.decl.Body, visit)
+	a.funcErrors[fn.decl.Pos()] = codes
+
+	return codes
+}
+
+// registryError returns the registry error expr refers to, which is
+// resolved by the declaration it refers to rather than by name, as locals
+// may shadow the errors and imports.
+func (a *errorAnalyzer) registryError(file *errorFile, expr ast.Expr) *ErrorCode {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if file.pkg == nil || !packageLevelVar(file.file, e) {
+			return nil
+		}
+		return file.pkg.errors[e.Name]
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return nil
+		}
+		importPath, ok := file.imports[x.Name]
+		if !ok {
+			return nil
+		}
+		if pkg := a.loadPackage(importPath); pkg != nil {
+			return pkg.errors[e.Sel.Name]
+		}
+	}
+
+	return nil
+}
+
+// packageLevelVar reports whether ident refers to a package level var of
+// the package of file: one declared in file, or one which file doesn't
+// resolve as it's declared in another file of the package.
+func packageLevelVar(file *ast.File, ident *ast.Ident) bool {
+	if ident.Obj == nil {
+		return true
+	}
+	if ident.Obj.Kind != ast.Var {
+		return false
+	}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if spec == ident.Obj.Decl {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// resolveCall returns the project functions call may call: functions of
+// the current or imported packages, and for method calls the methods of
+// that name in those packages.
+func (a *errorAnalyzer) resolveCall(file *errorFile, call *ast.CallExpr) []*errorFunc {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if file.pkg != nil {
+			return file.pkg.funcs[fun.Name]
+		}
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok && x.Obj == nil {
+			if importPath, ok := file.imports[x.Name]; ok {
+				if pkg := a.loadPackage(importPath); pkg != nil {
+					return pkg.funcs[fun.Sel.Name]
+				}
+				return nil
+			}
+		}
+		var fns []*errorFunc
+		if file.pkg != nil {
+			fns = append(fns, file.pkg.funcs["."+fun.Sel.Name]...)
+		}
+		for _, importPath := range file.imports {
+			if pkg := a.loadPackage(importPath); pkg != nil {
+				fns = append(fns, pkg.funcs["."+fun.Sel.Name]...)
+			}
+		}
+		return fns
+	}
+
+	return nil
+}
+
+// projectImports maps the import names of astFile to the import paths of
+// the project packages, except the api ones which declare no logic.
+func (a *errorAnalyzer) projectImports(astFile *ast.File) map[string]string {
+	imports := make(map[string]string)
+	modName := a.p.moduleName()
+	if modName == "" {
+		return imports
+	}
+	for _, importSpec := range astFile.Imports {
+		path := strings.Trim(importSpec.Path.Value, "\"")
+		if !strings.HasPrefix(path, modName+"/") || strings.HasPrefix(path, modName+"/api/") {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		imports[name] = path
+	}
+
+	return imports
+}
+
+// loadPackage parses the project package importPath, nil when it is not in
+// the project or can't be parsed.
+func (a *errorAnalyzer) loadPackage(importPath string) *errorPackage {
+	dir := a.packageDir(importPath)
+	if dir != "" && a.touched != nil {
+		a.touched[dir] = true
+	}
+	if pkg, ok := a.packages[importPath]; ok {
+		return pkg
+	}
+	a.packages[importPath] = nil
+
+	if dir == "" {
+		return nil
+	}
+	pkgs, err := goparser.ParseDir(a.fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil
+	}
+
+	pkg := &errorPackage{
+		funcs:  make(map[string][]*errorFunc),
+		errors: make(map[string]*ErrorCode),
+	}
+	for _, astPkg := range pkgs {
+		for _, astFile := range astPkg.Files {
+			file := &errorFile{
+				pkg:     pkg,
+				file:    astFile,
+				imports: a.projectImports(astFile),
+			}
+			pkg.files = append(pkg.files, file)
+			for _, decl := range astFile.Decls {
+				if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+					name := funcDecl.Name.Name
+					if funcDecl.Recv != nil {
+						name = "." + name
+					}
+					pkg.funcs[name] = append(pkg.funcs[name], &errorFunc{decl: funcDecl, file: file})
+				}
+			}
+		}
+	}
+	pkg.parseErrors()
+	a.packages[importPath] = pkg
+
+	return pkg
+}
+
+// packageDir returns the directory of a package of the project, or empty for
+// the others.
+func (a *errorAnalyzer) packageDir(importPath string) string {
+	modName := a.p.moduleName()
+	if modName == "" || (importPath != modName && !strings.HasPrefix(importPath, modName+"/")) {
+		return ""
+	}
+
+	return filepath.Join(a.p.cwd, strings.TrimPrefix(importPath, modName))
+}
+
+// parseErrors collects the package level vars of pkg initialized with an
+// integer code and a string message, by a call or a struct literal.
+func (pkg *errorPackage) parseErrors() {
+	consts := make(map[string]int64)
+	for _, file := range pkg.files {
+		for _, decl := range file.file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			var values []ast.Expr
+			for index, s := range genDecl.Specs {
+				vs := s.(*ast.ValueSpec)
+				if vs.Values != nil {
+					values = vs.Values
+				}
+				for i, name := range vs.Names {
+					if i >= len(values) {
+						continue
+					}
+					if value, ok := constValue(values[i], index); ok {
+						if code, ok := value.(int64); ok {
+							consts[name.Name] = code
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, file := range pkg.files {
+		for _, decl := range file.file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, s := range genDecl.Specs {
+				vs := s.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						continue
+					}
+					if code := registryErrorCode(vs.Values[i], consts); code != nil {
+						code.Name = name.Name
+						pkg.errors[name.Name] = code
+					}
+				}
+			}
+		}
+	}
+}
+
+func registryErrorCode(expr ast.Expr, consts map[string]int64) *ErrorCode {
+	var args []ast.Expr
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		return registryErrorCode(e.X, consts)
+	case *ast.CallExpr:
+		args = e.Args
+	case *ast.CompositeLit:
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				args = append(args, kv.Value)
+			} else {
+				args = append(args, elt)
+			}
+		}
+	default:
+		return nil
+	}
+
+	var (
+		code             *ErrorCode
+		hasCode, hasText bool
+	)
+	code = new(ErrorCode)
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *ast.BasicLit:
+			if a.Kind == token.INT && !hasCode {
+				if v, err := strconv.ParseInt(a.Value, 0, 64); err == nil {
+					code.Code, hasCode = v, true
+				}
+			}
+			if a.Kind == token.STRING && !hasText {
+				if v, err := strconv.Unquote(a.Value); err == nil {
+					code.Message, hasText = v, true
+				}
+			}
+		case *ast.Ident:
+			if v, ok := consts[a.Name]; ok && !hasCode {
+				code.Code, hasCode = v, true
+			}
+		}
+	}
+	if !hasCode || !hasText {
+		return nil
+	}
+
+	return code
+}
+
+func appendErrorCode(codes []*ErrorCode, code *ErrorCode) []*ErrorCode {
+	for _, c := range codes {
+		if c == code {
+			return codes
+		}
+	}
+
+	return append(codes, code)
+}
+
+// applyFailures documents the business errors of each operation: listed in
+// its description and x-error-codes, and as the example of its failure
+// responses.
+func (p *Parser) applyFailures(swagger *spec.Swagger) {
+	if swagger.Paths == nil {
+		return
+	}
+	for path, item := range swagger.Paths.Paths {
+		for method, op := range pathOperations(&item) {
+			failures := p.operationFailures[strings.ToUpper(method)+" "+path]
+			if op == nil || len(failures) == 0 {
+				continue
+			}
+
+			var (
+				table  string
+				codes  = make([]map[string]interface{}, 0)
+				failed = false
+			)
+			for _, failure := range failures {
+				for _, code := range failure.Codes {
+					failed = true
+					table += fmt.Sprintf("| %d | %d | %s | %s |\n", failure.Status, code.Code, code.Name, code.Message)
+					codes = append(codes, map[string]interface{}{
+						"status":  failure.Status,
+						"code":    code.Code,
+						"name":    code.Name,
+						"message": code.Message,
+					})
+				}
+				if len(failure.Codes) == 0 || failure.Status == http.StatusOK || op.Responses == nil {
+					continue
+				}
+				resp, ok := op.Responses.StatusCodeResponses[failure.Status]
+				if !ok {
+					continue
+				}
+				resp.Examples = map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"status":    failure.Codes[0].Code,
+						"errorMsg":  failure.Codes[0].Message,
+						"content":   nil,
+						"timestamp": "1609430400",
+					},
+				}
+				op.Responses.StatusCodeResponses[failure.Status] = resp
+			}
+			if !failed {
+				continue
+			}
+
+			op.AddExtension("x-error-codes", codes)
+			if op.Description != "" {
+				op.Description += "\n\n"
+			}
+			op.Description += "| HTTP状态码 | 错误码 | 错误 | 说明 |\n| --- | --- | --- | --- |\n" + table
+		}
+		swagger.Paths.Paths[path] = item
+	}
+}
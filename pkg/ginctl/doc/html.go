@@ -0,0 +1,432 @@
+package doc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+const defaultTag = "default"
+
+var httpMethodOrder = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+type htmlPage struct {
+	Title       string
+	Version     string
+	Description string
+	Tags        []*htmlTag
+	Definitions []*htmlDefinition
+}
+
+type htmlTag struct {
+	Name        string
+	Description string
+	Operations  []*htmlOperation
+}
+
+type htmlOperation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Deprecated  bool
+	Consumes    string
+	Policies    []string
+	Parameters  []*htmlField
+	Responses   []*htmlResponse
+}
+
+type htmlField struct {
+	Name        string
+	In          string
+	Type        template.HTML
+	Required    bool
+	Description string
+}
+
+type htmlResponse struct {
+	Code        string
+	Description string
+	Type        template.HTML
+}
+
+type htmlDefinition struct {
+	Name       string
+	Type       template.HTML
+	Properties []*htmlField
+}
+
+// RenderHTML renders doc into a single self-contained HTML page, with the
+// operations grouped by tag and searchable without any external asset.
+func RenderHTML(w io.Writer, doc SwaggerDoc, title string) error {
+	page := &htmlPage{Title: title}
+	if info, ok := doc["info"].(map[string]interface{}); ok {
+		if page.Title == "" {
+			page.Title = stringOf(info["title"])
+		}
+		page.Version = stringOf(info["version"])
+		page.Description = stringOf(info["description"])
+	}
+	if page.Title == "" {
+		page.Title = "API Documentation"
+	}
+
+	tags := make(map[string]*htmlTag)
+	tagNames := make([]string, 0)
+	if list, ok := doc["tags"].([]interface{}); ok {
+		for _, item := range list {
+			tag, ok := item.(map[string]interface{})
+			if !ok || stringOf(tag["name"]) == "" {
+				continue
+			}
+			name := stringOf(tag["name"])
+			tags[name] = &htmlTag{Name: name, Description: stringOf(tag["description"])}
+			tagNames = append(tagNames, name)
+		}
+	}
+	declared := len(tagNames)
+
+	for path, pathObj := range doc.Paths() {
+		operations, ok := pathObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for method, define := range operations {
+			operation, ok := define.(map[string]interface{})
+			if !ok || methodIndex(method) == len(httpMethodOrder) {
+				continue
+			}
+			op := newHTMLOperation(path, method, operation)
+			opTags := stringsOf(operation["tags"])
+			if len(opTags) == 0 {
+				opTags = []string{defaultTag}
+			}
+			for i, name := range opTags {
+				tag, ok := tags[name]
+				if !ok {
+					tag = &htmlTag{Name: name}
+					tags[name] = tag
+					tagNames = append(tagNames, name)
+				}
+				tagOp := op
+				if i > 0 {
+					// an operation is rendered under each of its tags, whose
+					// ids are unique by the tag besides the first one
+					copied := *op
+					copied.ID = "tag-" + anchorOf(name) + "-" + op.ID
+					tagOp = &copied
+				}
+				tag.Operations = append(tag.Operations, tagOp)
+			}
+		}
+	}
+
+	// declared tags keep their order, the others are sorted by name
+	sort.Strings(tagNames[declared:])
+	for _, name := range tagNames {
+		tag := tags[name]
+		if len(tag.Operations) == 0 {
+			continue
+		}
+		sort.Slice(tag.Operations, func(i, j int) bool {
+			a, b := tag.Operations[i], tag.Operations[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			return methodIndex(a.Method) < methodIndex(b.Method)
+		})
+		page.Tags = append(page.Tags, tag)
+	}
+
+	defs := doc.Definitions()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema, _ := defs[name].(map[string]interface{})
+		page.Definitions = append(page.Definitions, &htmlDefinition{
+			Name:       name,
+			Type:       schemaHTML(schema),
+			Properties: propertiesOf(schema),
+		})
+	}
+
+	tpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	return tpl.Execute(w, page)
+}
+
+func newHTMLOperation(path, method string, operation map[string]interface{}) *htmlOperation {
+	op := &htmlOperation{
+		ID:          strings.ToLower(method) + "-" + anchorOf(path),
+		Method:      strings.ToLower(method),
+		Path:        path,
+		Summary:     stringOf(operation["summary"]),
+		Description: stringOf(operation["description"]),
+		Consumes:    strings.Join(stringsOf(operation["consumes"]), ", "),
+	}
+	if deprecated, ok := operation["deprecated"].(bool); ok {
+		op.Deprecated = deprecated
+	}
+	if policies, ok := operation["x-policies"].([]interface{}); ok {
+		for _, item := range policies {
+			if policy, ok := item.(map[string]interface{}); ok {
+				op.Policies = append(op.Policies, strings.TrimSpace("@"+stringOf(policy["name"])+" "+strings.Join(stringsOf(policy["args"]), " ")))
+			}
+		}
+	}
+
+	if params, ok := operation["parameters"].([]interface{}); ok {
+		for _, item := range params {
+			param, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := &htmlField{
+				Name:        stringOf(param["name"]),
+				In:          stringOf(param["in"]),
+				Description: stringOf(param["description"]),
+			}
+			if required, ok := param["required"].(bool); ok {
+				field.Required = required
+			}
+			if schema, ok := param["schema"].(map[string]interface{}); ok {
+				field.Type = schemaHTML(schema)
+			} else {
+				field.Type = schemaHTML(param)
+			}
+			op.Parameters = append(op.Parameters, field)
+		}
+	}
+
+	if responses, ok := operation["responses"].(map[string]interface{}); ok {
+		codes := make([]string, 0, len(responses))
+		for code := range responses {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			response, ok := responses[code].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			resp := &htmlResponse{Code: code, Description: stringOf(response["description"])}
+			if schema, ok := response["schema"].(map[string]interface{}); ok {
+				resp.Type = schemaHTML(schema)
+			}
+			op.Responses = append(op.Responses, resp)
+		}
+	}
+
+	return op
+}
+
+// schemaHTML describes the type of schema, linking referenced definitions to
+// their anchor in the page.
+func schemaHTML(schema map[string]interface{}) template.HTML {
+	if schema == nil {
+		return ""
+	}
+	if ref := stringOf(schema["$ref"]); ref != "" {
+		name := strings.TrimPrefix(ref, definitionRefPrefix)
+		name = template.HTMLEscapeString(name)
+		return template.HTML(fmt.Sprintf(`<a href="#def-%s">%s</a>`, name, name))
+	}
+
+	typ := stringOf(schema["type"])
+	switch {
+	case typ == "array":
+		items, _ := schema["items"].(map[string]interface{})
+		return "[]" + schemaHTML(items)
+	case typ == "object" || typ == "" && schema["properties"] != nil:
+		props, _ := schema["properties"].(map[string]interface{})
+		if len(props) == 0 {
+			if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				return "map[string]" + schemaHTML(additional)
+			}
+			return "object"
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := make([]string, 0, len(names))
+		for _, name := range names {
+			prop, _ := props[name].(map[string]interface{})
+			fields = append(fields, template.HTMLEscapeString(name)+": "+string(schemaHTML(prop)))
+		}
+		return template.HTML("{ " + strings.Join(fields, ", ") + " }")
+	case typ == "":
+		return "any"
+	}
+
+	if format := stringOf(schema["format"]); format != "" {
+		typ += "(" + format + ")"
+	}
+
+	return template.HTML(template.HTMLEscapeString(typ))
+}
+
+func propertiesOf(schema map[string]interface{}) []*htmlField {
+	props, _ := schema["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	for _, name := range stringsOf(schema["required"]) {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]*htmlField, 0, len(names))
+	for _, name := range names {
+		prop, _ := props[name].(map[string]interface{})
+		fields = append(fields, &htmlField{
+			Name:        name,
+			Type:        schemaHTML(prop),
+			Required:    required[name],
+			Description: stringOf(prop["description"]),
+		})
+	}
+
+	return fields
+}
+
+func methodIndex(method string) int {
+	for i, m := range httpMethodOrder {
+		if strings.EqualFold(m, method) {
+			return i
+		}
+	}
+
+	return len(httpMethodOrder)
+}
+
+func anchorOf(s string) string {
+	return strings.NewReplacer("/", "-", "{", "", "}", "", ":", "", " ", "-").Replace(strings.Trim(s, "/"))
+}
+
+func stringOf(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return ""
+}
+
+func stringsOf(v interface{}) []string {
+	list, _ := v.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; font: 14px/1.6 -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #24292e; }
+a { color: #0366d6; text-decoration: none; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 280px; overflow-y: auto; padding: 16px; background: #f6f8fa; border-right: 1px solid #e1e4e8; }
+nav input { width: 100%; padding: 6px 8px; margin-bottom: 12px; border: 1px solid #d1d5da; border-radius: 4px; }
+nav h4 { margin: 12px 0 4px; }
+nav ul { list-style: none; margin: 0; padding: 0; }
+nav li a { display: block; padding: 2px 0; color: #24292e; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+main { margin-left: 280px; padding: 24px 40px; max-width: 1200px; }
+section.tag > h2 { border-bottom: 1px solid #e1e4e8; padding-bottom: 4px; }
+.op { border: 1px solid #e1e4e8; border-radius: 4px; margin: 16px 0; padding: 12px 16px; }
+.op h3 { margin: 0 0 8px; font-size: 15px; font-family: monospace; }
+.op.deprecated h3 .path { text-decoration: line-through; }
+.method { display: inline-block; min-width: 64px; padding: 1px 6px; margin-right: 8px; border-radius: 3px; color: #fff; text-align: center; text-transform: uppercase; font-size: 12px; }
+.method.get { background: #61affe; } .method.post { background: #49cc90; } .method.put { background: #fca130; }
+.method.patch { background: #50e3c2; } .method.delete { background: #f93e3e; } .method.head, .method.options { background: #9012fe; }
+table { width: 100%; border-collapse: collapse; margin: 8px 0; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eaecef; vertical-align: top; }
+th { background: #fafbfc; font-weight: 600; }
+td.type { font-family: monospace; word-break: break-all; }
+.required { color: #d73a49; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<nav>
+<input id="search" type="search" placeholder="搜索接口、模型...">
+{{range .Tags}}<h4><a href="#tag-{{.Name}}">{{.Name}}</a></h4>
+<ul>
+{{range .Operations}}<li class="searchable" data-search="{{.Method}} {{.Path}} {{.Summary}}"><a href="#{{.ID}}"><span class="method {{.Method}}">{{.Method}}</span>{{.Path}}</a></li>
+{{end}}</ul>
+{{end}}{{if .Definitions}}<h4><a href="#definitions">Models</a></h4>
+<ul>
+{{range .Definitions}}<li class="searchable" data-search="{{.Name}}"><a href="#def-{{.Name}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{end}}</nav>
+<main>
+<h1>{{.Title}}{{if .Version}} <small>{{.Version}}</small>{{end}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{range .Tags}}<section class="tag" id="tag-{{.Name}}">
+<h2>{{.Name}}</h2>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{range .Operations}}<div class="op searchable{{if .Deprecated}} deprecated{{end}}" id="{{.ID}}" data-search="{{.Method}} {{.Path}} {{.Summary}} {{.Description}}">
+<h3><span class="method {{.Method}}">{{.Method}}</span><span class="path">{{.Path}}</span></h3>
+{{if .Summary}}<p><strong>{{.Summary}}</strong></p>{{end}}
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .Consumes}}<p>Content-Type: <code>{{.Consumes}}</code></p>{{end}}
+{{if .Policies}}<p>策略: {{range .Policies}}<code>{{.}}</code> {{end}}</p>{{end}}
+{{if .Parameters}}<table>
+<tr><th>参数</th><th>位置</th><th>类型</th><th>必填</th><th>说明</th></tr>
+{{range .Parameters}}<tr><td>{{.Name}}</td><td>{{.In}}</td><td class="type">{{.Type}}</td><td>{{if .Required}}<span class="required">是</span>{{else}}否{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Responses}}<table>
+<tr><th>状态码</th><th>类型</th><th>说明</th></tr>
+{{range .Responses}}<tr><td>{{.Code}}</td><td class="type">{{.Type}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}</div>
+{{end}}</section>
+{{end}}{{if .Definitions}}<section class="tag" id="definitions">
+<h2>Models</h2>
+{{range .Definitions}}<div class="op searchable" id="def-{{.Name}}" data-search="{{.Name}}">
+<h3>{{.Name}}</h3>
+{{if .Properties}}<table>
+<tr><th>字段</th><th>类型</th><th>必填</th><th>说明</th></tr>
+{{range .Properties}}<tr><td>{{.Name}}</td><td class="type">{{.Type}}</td><td>{{if .Required}}<span class="required">是</span>{{else}}否{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{else}}<p class="type">{{.Type}}</p>
+{{end}}</div>
+{{end}}</section>
+{{end}}</main>
+<script>
+(function () {
+	var items = document.querySelectorAll(".searchable");
+	document.getElementById("search").addEventListener("input", function (e) {
+		var words = e.target.value.toLowerCase().split(/\s+/).filter(Boolean);
+		for (var i = 0; i < items.length; i++) {
+			var text = items[i].getAttribute("data-search").toLowerCase();
+			var matched = words.every(function (w) { return text.indexOf(w) !== -1; });
+			items[i].classList.toggle("hidden", !matched);
+		}
+	});
+})();
+</script>
+</body>
+</html>
+`
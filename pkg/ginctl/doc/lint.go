@@ -0,0 +1,408 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityOff     = "off"
+)
+
+const (
+	// RuleHandlerDescription reports handlers without description.
+	RuleHandlerDescription = "handler-description"
+	// RuleFieldComment reports request and response fields without comment.
+	RuleFieldComment = "field-comment"
+	// RuleJSONNaming reports json names not following the property strategy.
+	RuleJSONNaming = "json-naming"
+	// RuleUndocumentedError reports error responses without @Failure.
+	RuleUndocumentedError = "undocumented-error"
+	// RuleUnresolvedErrorStatus reports error responses which status can't
+	// be read statically, which ginctl doc documents as 500.
+	RuleUnresolvedErrorStatus = "unresolved-error-status"
+	// RuleEmptyResponse reports response types which are empty structs.
+	RuleEmptyResponse = "empty-response"
+)
+
+var defaultLintRules = map[string]string{
+	RuleHandlerDescription:    SeverityError,
+	RuleFieldComment:          SeverityWarning,
+	RuleJSONNaming:            SeverityError,
+	RuleUndocumentedError:     SeverityWarning,
+	RuleUnresolvedErrorStatus: SeverityWarning,
+	RuleEmptyResponse:         SeverityWarning,
+}
+
+var severityLevels = map[string]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// LintConfig overrides the severity of rules, off disables a rule.
+type LintConfig struct {
+	PropertyStrategy string            `yaml:"propertyStrategy"`
+	Rules            map[string]string `yaml:"rules"`
+}
+
+// LintIssue is a documentation problem found by Linter.
+type LintIssue struct {
+	File     string
+	Line     int
+	Rule     string
+	Severity string
+	Message  string
+}
+
+func (i *LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s: %s", i.File, i.Line, i.Severity, i.Rule, i.Message)
+}
+
+type Linter struct {
+	parser  *Parser
+	cwd     string
+	rules   map[string]string
+	naming  string
+	checked map[token.Pos]bool
+
+	Issues []*LintIssue
+}
+
+func LoadLintConfig(file string) (*LintConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(LintConfig)
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse lint config %s failed: %v", file, err)
+	}
+	for rule, severity := range cfg.Rules {
+		if _, ok := defaultLintRules[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %s in %s", rule, file)
+		}
+		if _, ok := severityLevels[severity]; !ok && severity != SeverityOff {
+			return nil, fmt.Errorf("invalid severity %s of rule %s in %s", severity, rule, file)
+		}
+	}
+
+	return cfg, nil
+}
+
+func NewLinter(cwd string, cfg *LintConfig) *Linter {
+	l := &Linter{
+		parser:  NewParser(WithWorkDir(cwd)),
+		cwd:     cwd,
+		rules:   make(map[string]string),
+		naming:  CamelCase,
+		checked: make(map[token.Pos]bool),
+		Issues:  make([]*LintIssue, 0),
+	}
+	for rule, severity := range defaultLintRules {
+		l.rules[rule] = severity
+	}
+	if cfg != nil {
+		for rule, severity := range cfg.Rules {
+			l.rules[rule] = severity
+		}
+		if cfg.PropertyStrategy != "" {
+			l.naming = cfg.PropertyStrategy
+		}
+	}
+
+	return l
+}
+
+// SetPropertyStrategy sets the naming strategy json names are checked with.
+func (l *Linter) SetPropertyStrategy(strategy string) {
+	l.naming = strategy
+}
+
+// Lint checks the handlers of api/rest and the typespec they use.
+func (l *Linter) Lint() error {
+	restDir := filepath.Join(l.cwd, "api", "rest")
+	err := filepath.Walk(restDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		return l.lintRestFile(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(l.Issues, func(i, j int) bool {
+		a, b := l.Issues[i], l.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return nil
+}
+
+// Count returns the number of issues at least as severe as severity.
+func (l *Linter) Count(severity string) int {
+	count := 0
+	for _, issue := range l.Issues {
+		if severityLevels[issue.Severity] >= severityLevels[severity] {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (l *Linter) report(fset *token.FileSet, pos token.Pos, rule, msg string) {
+	severity := l.rules[rule]
+	if severity == SeverityOff {
+		return
+	}
+
+	position := fset.Position(pos)
+	l.Issues = append(l.Issues, &LintIssue{
+		File:     strings.TrimPrefix(filepath.ToSlash(position.Filename), l.cwd+"/"),
+		Line:     position.Line,
+		Rule:     rule,
+		Severity: severity,
+		Message:  msg,
+	})
+}
+
+func (l *Linter) lintRestFile(path string) error {
+	fset := token.NewFileSet()
+	astFile, err := goparser.ParseFile(fset, path, nil, goparser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	httpPkg := ""
+	for _, importSpec := range astFile.Imports {
+		importPath := strings.Trim(importSpec.Path.Value, "\"")
+		if strings.HasSuffix(importPath, "/pkg/http") {
+			httpPkg = "http"
+			if importSpec.Name != nil {
+				httpPkg = importSpec.Name.Name
+			}
+		}
+	}
+
+	typeSpecDir := strings.Replace(filepath.Dir(path)+"type", "rest", "typespec", 1)
+	for _, decl := range astFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Doc == nil {
+			continue
+		}
+
+		handler, err := annotation.ParseHandler(fset, funcDecl)
+		if err != nil {
+			return err
+		}
+		if handler == nil {
+			continue
+		}
+
+		funcName := funcDecl.Name.Name
+		if handler.Description == "" {
+			l.report(fset, funcDecl.Pos(), RuleHandlerDescription, fmt.Sprintf("handler %s has no description", funcName))
+		}
+		if httpPkg != "" && funcDecl.Body != nil {
+			failures := make(map[int]bool)
+			for _, a := range handler.Annotations {
+				fields := strings.Fields(a.Params)
+				if !a.Is("@failure") || len(fields) == 0 {
+					continue
+				}
+				if code, err := strconv.Atoi(fields[0]); err == nil {
+					failures[code] = true
+				}
+			}
+			l.lintErrorPaths(fset, funcDecl, httpPkg, failures)
+		}
+
+		for _, typeName := range []string{funcName + "Request", funcName + "Response"} {
+			def, err := l.parser.findTypeDefInDir(typeSpecDir, typeName)
+			if err != nil {
+				continue
+			}
+			st, ok := def.spec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			if strings.HasSuffix(typeName, "Response") && len(st.Fields.List) == 0 {
+				l.report(l.parser.typeSpecFset, def.spec.Pos(), RuleEmptyResponse, fmt.Sprintf("response %s is an empty struct", typeName))
+			}
+			l.lintStruct(def, st)
+		}
+	}
+
+	return nil
+}
+
+// lintErrorPaths reports the httppkg.Error calls of funcDecl which status
+// isn't documented by @Failure, or is neither a http.StatusXXX nor a literal
+// which the failure responses are documented from. Errors responded with
+// 200 carry their code in the response body, which is documented by any
+// @Failure.
+func (l *Linter) lintErrorPaths(fset *token.FileSet, funcDecl *ast.FuncDecl, httpPkg string, failures map[int]bool) {
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Error" {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != httpPkg {
+			return true
+		}
+
+		code, ok := statusCode(call.Args[1])
+		if !ok {
+			l.report(fset, call.Pos(), RuleUnresolvedErrorStatus, fmt.Sprintf("status of error response of %s can't be read, which is documented as 500, use http.StatusXXX or a literal", funcDecl.Name.Name))
+			return true
+		}
+		// the generated doc documents 500 as the server error
+		if failures[code] || code == http.StatusInternalServerError || code == http.StatusOK && len(failures) > 0 {
+			return true
+		}
+		l.report(fset, call.Pos(), RuleUndocumentedError, fmt.Sprintf("error response %d of %s is not documented by @Failure", code, funcDecl.Name.Name))
+		return true
+	})
+}
+
+// statusCode evaluates http.StatusXXX or an int literal.
+func statusCode(expr ast.Expr) (int, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		code, err := strconv.Atoi(e.Value)
+		return code, err == nil
+	case *ast.SelectorExpr:
+		code, ok := statusCodes[e.Sel.Name]
+		return code, ok
+	}
+
+	return 0, false
+}
+
+var statusCodes = map[string]int{
+	"StatusOK":                    http.StatusOK,
+	"StatusBadRequest":            http.StatusBadRequest,
+	"StatusUnauthorized":          http.StatusUnauthorized,
+	"StatusPaymentRequired":       http.StatusPaymentRequired,
+	"StatusForbidden":             http.StatusForbidden,
+	"StatusNotFound":              http.StatusNotFound,
+	"StatusMethodNotAllowed":      http.StatusMethodNotAllowed,
+	"StatusNotAcceptable":         http.StatusNotAcceptable,
+	"StatusRequestTimeout":        http.StatusRequestTimeout,
+	"StatusConflict":              http.StatusConflict,
+	"StatusGone":                  http.StatusGone,
+	"StatusPreconditionFailed":    http.StatusPreconditionFailed,
+	"StatusRequestEntityTooLarge": http.StatusRequestEntityTooLarge,
+	"StatusUnsupportedMediaType":  http.StatusUnsupportedMediaType,
+	"StatusUnprocessableEntity":   http.StatusUnprocessableEntity,
+	"StatusLocked":                http.StatusLocked,
+	"StatusTooManyRequests":       http.StatusTooManyRequests,
+	"StatusInternalServerError":   http.StatusInternalServerError,
+	"StatusNotImplemented":        http.StatusNotImplemented,
+	"StatusBadGateway":            http.StatusBadGateway,
+	"StatusServiceUnavailable":    http.StatusServiceUnavailable,
+	"StatusGatewayTimeout":        http.StatusGatewayTimeout,
+}
+
+// lintStruct checks the fields of st and of the typespec structs they use,
+// each struct only once.
+func (l *Linter) lintStruct(def *typeSpecDef, st *ast.StructType) {
+	if l.checked[st.Pos()] {
+		return
+	}
+	l.checked[st.Pos()] = true
+
+	fset := l.parser.typeSpecFset
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+		if tag.Get("swagignore") != "" || strings.EqualFold(tag.Get("swaggerignore"), "true") {
+			continue
+		}
+
+		if sub := l.fieldStructDef(def, field.Type); sub != nil {
+			l.lintStruct(sub, sub.spec.Type.(*ast.StructType))
+		}
+		if len(field.Names) == 0 || !field.Names[0].IsExported() {
+			continue
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		if strings.TrimSpace(field.Comment.Text()) == "" && strings.TrimSpace(field.Doc.Text()) == "" {
+			l.report(fset, field.Pos(), RuleFieldComment, fmt.Sprintf("field %s of %s has no comment", strings.Join(names, ", "), def.spec.Name.Name))
+		}
+
+		name := tag.Get("json")
+		if index := strings.Index(name, ","); index != -1 {
+			name = name[:index]
+		}
+		if name != "" && name != "-" && !followsNaming(name, l.naming) {
+			l.report(fset, field.Pos(), RuleJSONNaming, fmt.Sprintf("json name %s of %s.%s is not %s", name, def.spec.Name.Name, names[0], l.naming))
+		}
+	}
+}
+
+// fieldStructDef returns the struct declaration of a field, looking through
+// pointers, slices and maps.
+func (l *Linter) fieldStructDef(def *typeSpecDef, expr ast.Expr) *typeSpecDef {
+	switch typ := expr.(type) {
+	case *ast.ArrayType:
+		return l.fieldStructDef(def, typ.Elt)
+	case *ast.MapType:
+		return l.fieldStructDef(def, typ.Value)
+	default:
+		return l.parser.exampleStructDef(def, expr)
+	}
+}
+
+func followsNaming(name, strategy string) bool {
+	first := []rune(name)[0]
+	switch strategy {
+	case SnakeCase:
+		for _, r := range name {
+			if unicode.IsUpper(r) {
+				return false
+			}
+		}
+		return true
+	case PascalCase:
+		return unicode.IsUpper(first) && !strings.Contains(name, "_")
+	default:
+		return !unicode.IsUpper(first) && !strings.Contains(name, "_")
+	}
+}
@@ -0,0 +1,396 @@
+package doc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConflictPrefix renames a merged definition which name is already taken
+	// by a different definition, prefixing it with the name of its source.
+	ConflictPrefix = "prefix"
+
+	// ConflictFail fails the merge when definition names collide.
+	ConflictFail = "fail"
+)
+
+const (
+	definitionRefPrefix = "#/definitions/"
+	defaultMergeTimeout = 10 * time.Second
+)
+
+// SwaggerDoc is a loosely typed swagger document, so fields ginctl doesn't
+// know are kept when it is rewritten.
+type SwaggerDoc map[string]interface{}
+
+// MergeSource is a swagger document merged into the generated one.
+type MergeSource struct {
+	// Name identifies the source in errors and prefixes its definitions.
+	Name string `yaml:"name"`
+	// URL is a remote document, a value without http scheme is a file.
+	URL string `yaml:"url"`
+	// File is a local document, relative to the merge config.
+	File string `yaml:"file"`
+	// Paths to merge, a trailing * matches by prefix and * alone matches all
+	// paths. No path is merged when empty.
+	Paths []string `yaml:"paths"`
+	// StripPrefix is removed from the merged paths before adding PathPrefix.
+	StripPrefix string `yaml:"stripPrefix"`
+	PathPrefix  string `yaml:"pathPrefix"`
+	// DefinitionPrefix is prepended to every merged definition when set.
+	DefinitionPrefix string `yaml:"definitionPrefix"`
+}
+
+type MergeConfig struct {
+	Sources []MergeSource `yaml:"merge"`
+	// OnConflict is prefix (default) or fail.
+	OnConflict string `yaml:"onConflict"`
+	// Timeout of fetching a remote document, like 10s.
+	Timeout string `yaml:"timeout"`
+
+	dir string
+}
+
+// MergeErrors aggregates the errors of every merge source.
+type MergeErrors []error
+
+func (e MergeErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("merge doc failed:\n\t%s", strings.Join(msgs, "\n\t"))
+}
+
+func LoadSwaggerDoc(file string) (SwaggerDoc, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc SwaggerDoc
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse swagger doc %s failed: %v", file, err)
+	}
+
+	return doc, nil
+}
+
+// Object returns the object field key of doc, creating it when missing.
+func (doc SwaggerDoc) Object(key string) map[string]interface{} {
+	if obj, ok := doc[key].(map[string]interface{}); ok {
+		return obj
+	}
+
+	obj := make(map[string]interface{})
+	doc[key] = obj
+	return obj
+}
+
+func (doc SwaggerDoc) Paths() map[string]interface{} {
+	return doc.Object("paths")
+}
+
+func (doc SwaggerDoc) Definitions() map[string]interface{} {
+	return doc.Object("definitions")
+}
+
+func LoadMergeConfig(file string) (*MergeConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(MergeConfig)
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse merge config %s failed: %v", file, err)
+	}
+	cfg.dir = filepath.Dir(file)
+
+	switch cfg.OnConflict {
+	case "":
+		cfg.OnConflict = ConflictPrefix
+	case ConflictPrefix, ConflictFail:
+	default:
+		return nil, fmt.Errorf("not supported onConflict %s in %s", cfg.OnConflict, file)
+	}
+
+	return cfg, nil
+}
+
+// Merge fetches every source concurrently and merges them into doc in the
+// order they are configured, so the result doesn't depend on timing.
+func (cfg *MergeConfig) Merge(doc SwaggerDoc) error {
+	timeout := defaultMergeTimeout
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid merge timeout %s: %v", cfg.Timeout, err)
+		}
+		timeout = d
+	}
+	client := &http.Client{Timeout: timeout}
+
+	docs := make([]SwaggerDoc, len(cfg.Sources))
+	errs := make([]error, len(cfg.Sources))
+	wg := sync.WaitGroup{}
+	for i := range cfg.Sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			docs[i], errs[i] = cfg.load(client, &cfg.Sources[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var merr MergeErrors
+	for i := range cfg.Sources {
+		src := &cfg.Sources[i]
+		if errs[i] != nil {
+			merr = append(merr, fmt.Errorf("%s: %v", src.name(), errs[i]))
+			continue
+		}
+		merr = append(merr, cfg.mergeSource(doc, docs[i], src)...)
+	}
+	if len(merr) > 0 {
+		return merr
+	}
+
+	return nil
+}
+
+func (cfg *MergeConfig) load(client *http.Client, src *MergeSource) (SwaggerDoc, error) {
+	location := src.File
+	if location == "" {
+		location = src.URL
+	}
+	if location == "" {
+		return nil, errors.New("neither url nor file is specified")
+	}
+
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(cfg.dir, location)
+		}
+		return LoadSwaggerDoc(location)
+	}
+
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s failed, code: %d", location, resp.StatusCode)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc SwaggerDoc
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse swagger doc %s failed: %v", location, err)
+	}
+
+	return doc, nil
+}
+
+func (cfg *MergeConfig) mergeSource(doc, srcDoc SwaggerDoc, src *MergeSource) []error {
+	var errs []error
+
+	// select paths and collect the definitions they depend on
+	paths := make(map[string]interface{})
+	refs := make(map[string]bool)
+	for path, pathObj := range srcDoc.Paths() {
+		if !src.matchPath(path) {
+			continue
+		}
+		paths[path] = pathObj
+		collectRefs(pathObj, refs)
+	}
+	srcDefs := srcDoc.Definitions()
+	queue := make([]string, 0, len(refs))
+	for ref := range refs {
+		queue = append(queue, ref)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		def, ok := srcDefs[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: definition %s is referenced but not defined", src.name(), name))
+			continue
+		}
+		found := make(map[string]bool)
+		collectRefs(def, found)
+		for ref := range found {
+			if !refs[ref] {
+				refs[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+
+	// resolve definition names, prefixing the ones that collide
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if _, ok := srcDefs[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	defs := doc.Definitions()
+	renames := make(map[string]string)
+	for _, name := range names {
+		target := name
+		if src.DefinitionPrefix != "" {
+			target = src.DefinitionPrefix + name
+		}
+		if exists, ok := defs[target]; ok && !reflect.DeepEqual(exists, srcDefs[name]) {
+			if cfg.OnConflict == ConflictFail {
+				errs = append(errs, fmt.Errorf("%s: definition %s conflicts with an existing definition", src.name(), target))
+				continue
+			}
+			target = src.prefix() + "." + target
+			if exists, ok := defs[target]; ok && !reflect.DeepEqual(exists, srcDefs[name]) {
+				errs = append(errs, fmt.Errorf("%s: definition %s conflicts with an existing definition", src.name(), target))
+				continue
+			}
+		}
+		if target != name {
+			renames[name] = target
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, name := range names {
+		target := name
+		if renamed, ok := renames[name]; ok {
+			target = renamed
+		}
+		defs[target] = rewriteRefs(srcDefs[name], renames)
+	}
+
+	docPaths := doc.Paths()
+	for path, pathObj := range paths {
+		target := src.PathPrefix + strings.TrimPrefix(path, src.StripPrefix)
+		operations, ok := rewriteRefs(pathObj, renames).(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: invalid path object of %s", src.name(), path))
+			continue
+		}
+		exists, ok := docPaths[target].(map[string]interface{})
+		if !ok {
+			docPaths[target] = operations
+			continue
+		}
+		for method, operation := range operations {
+			if _, ok := exists[method]; ok {
+				errs = append(errs, fmt.Errorf("%s: operation %s %s already exists", src.name(), strings.ToUpper(method), target))
+				continue
+			}
+			exists[method] = operation
+		}
+	}
+
+	return errs
+}
+
+func (src *MergeSource) name() string {
+	if src.Name != "" {
+		return src.Name
+	}
+	if src.File != "" {
+		return src.File
+	}
+
+	return src.URL
+}
+
+// prefix is the name prepended to colliding definitions.
+func (src *MergeSource) prefix() string {
+	if src.Name != "" {
+		return src.Name
+	}
+
+	name := src.name()
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "http://"), "https://")
+	if index := strings.IndexAny(name, "/:"); index > 0 && src.File == "" {
+		name = name[:index]
+	} else {
+		name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+
+	return strings.Replace(name, ".", "_", -1)
+}
+
+func (src *MergeSource) matchPath(path string) bool {
+	for _, p := range src.Paths {
+		if p == path || strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectRefs adds the names of all definitions referenced in v to refs.
+func collectRefs(v interface{}, refs map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if ref, ok := item.(string); ok && key == "$ref" && strings.HasPrefix(ref, definitionRefPrefix) {
+				refs[strings.TrimPrefix(ref, definitionRefPrefix)] = true
+				continue
+			}
+			collectRefs(item, refs)
+		}
+	case []interface{}:
+		for _, item := range val {
+			collectRefs(item, refs)
+		}
+	}
+}
+
+// rewriteRefs returns a copy of v with references to renamed definitions
+// pointing to their new name.
+func rewriteRefs(v interface{}, renames map[string]string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			if ref, ok := item.(string); ok && key == "$ref" {
+				if renamed, ok := renames[strings.TrimPrefix(ref, definitionRefPrefix)]; ok {
+					item = definitionRefPrefix + renamed
+				}
+			}
+			out[key] = rewriteRefs(item, renames)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = rewriteRefs(item, renames)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,233 @@
+package doc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mergeTestSource is the doc merged by the tests of mergeSource, which
+// /pay/order refers to Order and Order to Item transitively.
+const mergeTestSource = `{
+	"paths": {
+		"/pay/order": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Order"}}}}},
+		"/internal/stat": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Stat"}}}}}
+	},
+	"definitions": {
+		"Order": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/Item"}}}},
+		"Item": {"type": "object", "properties": {"id": {"type": "integer"}}},
+		"Stat": {"type": "object"}
+	}
+}`
+
+func TestMergeSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		src        MergeSource
+		onConflict string
+		want       string
+		err        string
+	}{
+		{
+			name: "paths and referred definitions",
+			doc:  `{"paths": {"/user": {"get": {}}}}`,
+			src:  MergeSource{Name: "pay", Paths: []string{"/pay/*"}, StripPrefix: "/pay", PathPrefix: "/api/pay"},
+			want: `{
+				"paths": {
+					"/user": {"get": {}},
+					"/api/pay/order": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Order"}}}}}
+				},
+				"definitions": {
+					"Order": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/Item"}}}},
+					"Item": {"type": "object", "properties": {"id": {"type": "integer"}}}
+				}
+			}`,
+		},
+		{
+			name: "same definition isn't renamed",
+			doc:  `{"definitions": {"Item": {"type": "object", "properties": {"id": {"type": "integer"}}}}}`,
+			src:  MergeSource{Name: "pay", Paths: []string{"/pay/order"}},
+			want: `{
+				"paths": {
+					"/pay/order": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Order"}}}}}
+				},
+				"definitions": {
+					"Order": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/Item"}}}},
+					"Item": {"type": "object", "properties": {"id": {"type": "integer"}}}
+				}
+			}`,
+		},
+		{
+			name:       "conflict prefixed by source",
+			doc:        `{"definitions": {"Item": {"type": "string"}}}`,
+			src:        MergeSource{Name: "pay", Paths: []string{"/pay/order"}},
+			onConflict: ConflictPrefix,
+			want: `{
+				"paths": {
+					"/pay/order": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Order"}}}}}
+				},
+				"definitions": {
+					"Order": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/pay.Item"}}}},
+					"Item": {"type": "string"},
+					"pay.Item": {"type": "object", "properties": {"id": {"type": "integer"}}}
+				}
+			}`,
+		},
+		{
+			name:       "conflict fails",
+			doc:        `{"definitions": {"Item": {"type": "string"}}}`,
+			src:        MergeSource{Name: "pay", Paths: []string{"/pay/order"}},
+			onConflict: ConflictFail,
+			err:        "pay: definition Item conflicts with an existing definition",
+		},
+		{
+			name: "definition prefix",
+			doc:  `{}`,
+			src:  MergeSource{Name: "pay", Paths: []string{"/internal/stat"}, DefinitionPrefix: "pay"},
+			want: `{
+				"paths": {
+					"/internal/stat": {"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/payStat"}}}}}
+				},
+				"definitions": {
+					"payStat": {"type": "object"}
+				}
+			}`,
+		},
+		{
+			name: "existing operation",
+			doc:  `{"paths": {"/internal/stat": {"get": {}}}}`,
+			src:  MergeSource{Name: "pay", Paths: []string{"*"}},
+			err:  "pay: operation GET /internal/stat already exists",
+		},
+	}
+	for _, tt := range tests {
+		doc := parseDoc(t, tt.doc)
+		cfg := &MergeConfig{OnConflict: tt.onConflict}
+		if cfg.OnConflict == "" {
+			cfg.OnConflict = ConflictPrefix
+		}
+		errs := cfg.mergeSource(doc, parseDoc(t, mergeTestSource), &tt.src)
+		if tt.err != "" {
+			if len(errs) == 0 || !strings.Contains(MergeErrors(errs).Error(), tt.err) {
+				t.Errorf("%s: mergeSource errors = %v, want %q", tt.name, errs, tt.err)
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Errorf("%s: mergeSource errors = %v", tt.name, errs)
+			continue
+		}
+		if want := parseDoc(t, tt.want); !reflect.DeepEqual(doc, want) {
+			got, _ := json.Marshal(doc)
+			t.Errorf("%s: mergeSource = %s", tt.name, got)
+		}
+	}
+}
+
+func TestMergeSourcePrefix(t *testing.T) {
+	tests := []struct {
+		src  MergeSource
+		want string
+	}{
+		{src: MergeSource{Name: "pay", File: "pay.json"}, want: "pay"},
+		{src: MergeSource{File: "docs/pay.v1.json"}, want: "pay_v1"},
+		{src: MergeSource{URL: "https://pay.example.com/swagger.json"}, want: "pay_example_com"},
+		{src: MergeSource{URL: "http://localhost:8080/doc.json"}, want: "localhost"},
+	}
+	for _, tt := range tests {
+		if got := tt.src.prefix(); got != tt.want {
+			t.Errorf("prefix of %s = %s, want %s", tt.src.name(), got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ginctl-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := `
+onConflict: fail
+merge:
+  - name: pay
+    file: pay.json
+    paths: ["/pay/*"]
+  - file: missing.json
+    paths: ["*"]
+`
+	for name, content := range map[string]string{"merge.yaml": config, "pay.json": mergeTestSource} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadMergeConfig(filepath.Join(dir, "merge.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := parseDoc(t, `{}`)
+	err = cfg.Merge(doc)
+	merr, ok := err.(MergeErrors)
+	if !ok || len(merr) != 1 || !strings.HasPrefix(merr[0].Error(), "missing.json: ") {
+		t.Fatalf("Merge error = %v, want the one of missing.json", err)
+	}
+	// the sources merge independently, the failure of one doesn't skip pay
+	if _, ok := doc.Paths()["/pay/order"]; !ok {
+		t.Errorf("Merge paths = %v, want /pay/order", doc.Paths())
+	}
+}
+
+func TestLoadMergeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ginctl-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		config     string
+		onConflict string
+		err        string
+	}{
+		{config: "merge: []", onConflict: ConflictPrefix},
+		{config: "onConflict: fail", onConflict: ConflictFail},
+		{config: "onConflict: rename", err: "not supported onConflict rename"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(dir, "merge.yaml")
+		if err := ioutil.WriteFile(file, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadMergeConfig(file)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LoadMergeConfig(%q) error = %v, want %q", tt.config, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LoadMergeConfig(%q) error = %v", tt.config, err)
+			continue
+		}
+		if cfg.OnConflict != tt.onConflict {
+			t.Errorf("LoadMergeConfig(%q) onConflict = %s, want %s", tt.config, cfg.OnConflict, tt.onConflict)
+		}
+	}
+}
+
+func parseDoc(t *testing.T, s string) SwaggerDoc {
+	t.Helper()
+	var doc SwaggerDoc
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
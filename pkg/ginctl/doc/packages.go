@@ -4,6 +4,7 @@ import (
 	"go/ast"
 	"go/token"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -51,8 +52,20 @@ func (pkgs *PackagesDefinitions) RangeFileForInjectTag(dryRun bool, filepath, pr
 	return nil
 }
 
+// RangeFiles calls handle with the files in the order of their paths, so
+// that what is named in the order of the files, like route groups, is the
+// same on every run.
 func (pkgs *PackagesDefinitions) RangeFiles(handle func(astInfo *AstFileInfo, file *ast.File) error) error {
-	for file, info := range pkgs.files {
+	files := make([]*ast.File, 0, len(pkgs.files))
+	for file := range pkgs.files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return pkgs.files[files[i]].Path < pkgs.files[files[j]].Path
+	})
+
+	for _, file := range files {
+		info := pkgs.files[file]
 		dir := strings.TrimPrefix(filepath.Dir(info.Path), pkgs.workdir+"/")
 		if _, ok := pkgs.excludes[dir]; ok {
 			continue
@@ -0,0 +1,174 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// requestParams is what the params of an operation are documented with from
+// the request typespec of its handler.
+type requestParams struct {
+	// found is set when the request typespec is declared.
+	found    bool
+	isStruct bool
+	// comment holds the @Param lines of the query and header fields of a
+	// struct request.
+	comment string
+	params  map[string]*validation
+}
+
+// requestParamsEntry is requestParams as stored in the parse cache.
+type requestParamsEntry struct {
+	Found    bool                        `json:"found"`
+	IsStruct bool                        `json:"isStruct,omitempty"`
+	Comment  string                      `json:"comment,omitempty"`
+	Params   map[string]*validationEntry `json:"params,omitempty"`
+}
+
+type validationEntry struct {
+	Required     bool       `json:"required,omitempty"`
+	Min          *float64   `json:"min,omitempty"`
+	Max          *float64   `json:"max,omitempty"`
+	ExclusiveMin bool       `json:"exclusiveMin,omitempty"`
+	ExclusiveMax bool       `json:"exclusiveMax,omitempty"`
+	Pattern      string     `json:"pattern,omitempty"`
+	Format       string     `json:"format,omitempty"`
+	OneOf        []string   `json:"oneOf,omitempty"`
+	Enum         *enumEntry `json:"enum,omitempty"`
+}
+
+// enumEntry keeps the values of the enum as encoded, so that the integers
+// are restored as int64 rather than float64.
+type enumEntry struct {
+	Values       []json.RawMessage `json:"values"`
+	Names        []string          `json:"names"`
+	Descriptions []string          `json:"descriptions"`
+}
+
+// requestParams documents the params of the operation of funcName bound with
+// method from its request typespec in dir, read from the parse cache when
+// none of the typespec packages they are resolved from changed.
+func (p *Parser) requestParams(dir, funcName, method string) (*requestParams, error) {
+	typeName := funcName + "Request"
+	key := fmt.Sprintf("%s.%s:%t", dir, typeName, hasRequestBody(method))
+	entry := new(requestParamsEntry)
+	if p.cache.Get("params", key, entry) {
+		return entry.restore(), nil
+	}
+
+	rp := &requestParams{params: make(map[string]*validation)}
+	deps, err := p.trackTypeSpecDirs(func() error {
+		def, _ := p.findTypeDefInDir(dir, typeName)
+		if def == nil {
+			return nil
+		}
+		rp.found = true
+		st, ok := def.spec.Type.(*ast.StructType)
+		if !ok {
+			return nil
+		}
+		rp.isStruct = true
+		visiting := map[token.Pos]bool{def.key(): true}
+		var err error
+		rp.comment, err = p.parseTypeSpecComment("", method, def, st.Fields.List, visiting, rp.params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = p.cache.Put("params", key, deps, newRequestParamsEntry(rp))
+
+	return rp, nil
+}
+
+func newRequestParamsEntry(rp *requestParams) *requestParamsEntry {
+	entry := &requestParamsEntry{
+		Found:    rp.found,
+		IsStruct: rp.isStruct,
+		Comment:  rp.comment,
+		Params:   make(map[string]*validationEntry, len(rp.params)),
+	}
+	for key, v := range rp.params {
+		ve := &validationEntry{
+			Required:     v.required,
+			Min:          v.min,
+			Max:          v.max,
+			ExclusiveMin: v.exclusiveMin,
+			ExclusiveMax: v.exclusiveMax,
+			Pattern:      v.pattern,
+			Format:       v.format,
+			OneOf:        v.oneOf,
+		}
+		if v.enum != nil {
+			ve.Enum = &enumEntry{
+				Names:        v.enum.names,
+				Descriptions: v.enum.descriptions,
+			}
+			for _, value := range v.enum.values {
+				b, _ := json.Marshal(value)
+				ve.Enum.Values = append(ve.Enum.Values, b)
+			}
+		}
+		entry.Params[key] = ve
+	}
+
+	return entry
+}
+
+func (entry *requestParamsEntry) restore() *requestParams {
+	rp := &requestParams{
+		found:    entry.Found,
+		isStruct: entry.IsStruct,
+		comment:  entry.Comment,
+		params:   make(map[string]*validation, len(entry.Params)),
+	}
+	for key, ve := range entry.Params {
+		v := &validation{
+			required:     ve.Required,
+			min:          ve.Min,
+			max:          ve.Max,
+			exclusiveMin: ve.ExclusiveMin,
+			exclusiveMax: ve.ExclusiveMax,
+			pattern:      ve.Pattern,
+			format:       ve.Format,
+			oneOf:        ve.OneOf,
+		}
+		if ve.Enum != nil {
+			v.enum = &typeEnum{
+				names:        ve.Enum.Names,
+				descriptions: ve.Enum.Descriptions,
+			}
+			for _, b := range ve.Enum.Values {
+				v.enum.values = append(v.enum.values, enumValue(b))
+			}
+		}
+		rp.params[key] = v
+	}
+
+	return rp
+}
+
+// enumValue decodes an encoded enum value, restoring the integers as int64
+// the way constValue evaluates them.
+func enumValue(b json.RawMessage) interface{} {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil
+	}
+	n, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+
+	return f
+}
@@ -2,29 +2,33 @@ package doc
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"go/ast"
 	goparser "go/parser"
 	"go/printer"
 	"go/token"
-	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
 	"github.com/go-season/ginctl/pkg/util"
 	"github.com/go-season/ginctl/pkg/util/file"
 	"github.com/go-season/ginctl/pkg/util/str"
 	"github.com/mgutz/ansi"
 	"github.com/prometheus/common/log"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 const RouteEntryName = "router.go"
 const APIDefinitionName = "api.go"
 
+// RouteRegistrationName is the file registering the routes of a rest
+// package, generated by route refresh --split.
+const RouteRegistrationName = "routes_gen.go"
+
 const (
 	// CamelCase indicates using CamelCase strategy for struct field.
 	CamelCase = "camelcase"
@@ -36,7 +40,7 @@ const (
 	SnakeCase = "snakecase"
 )
 
-const MethodAny = "Any"
+const MethodAny = annotation.MethodAny
 
 var builtinTypeMap = map[string]string{
 	"orm.LocalTime": "string",
@@ -53,7 +57,48 @@ type Parser struct {
 	Debug                bool
 	Apis                 []string
 	ApiMap               map[string]string
-	cwd                  string
+	// Routes are the routes of Apis, in the order declared.
+	Routes []*Route
+	// RouteGroups are the groups of Apis, in the order declared.
+	RouteGroups  []*RouteGroup
+	routeGroups  map[string]*RouteGroup
+	cwd          string
+	modName      string
+	quiet        bool
+	typeSpecFset *token.FileSet
+	typeSpecDirs map[string][]*typeSpecFile
+	// typeSpecLoaded records the typespec dirs loaded while it is set, which
+	// are what an entry of the parse cache depends on.
+	typeSpecLoaded map[string]bool
+	typeEnums      map[token.Pos]*typeEnum
+	// paramValidations holds the constraints of the query and header params
+	// of each operation, keyed by method and path then by in and name.
+	paramValidations map[string]map[string]*validation
+	// operationTypes holds the typespec types of each operation, keyed by
+	// method and path.
+	operationTypes map[string]*operationType
+	// propNamingStrategy names the json properties of fields without tag.
+	propNamingStrategy string
+	security           *SecurityConfig
+	policy             *PolicyConfig
+	cache              *cache.Cache
+	// declarationsOnly parses the files of api/rest only for the
+	// declarations handlers are annotated with.
+	declarationsOnly bool
+	// operationFailures holds the failures of each operation, keyed by
+	// method and path.
+	operationFailures map[string][]*Failure
+	errorAnalyzer     *errorAnalyzer
+	audience          *AudienceConfig
+	// operationAudiences holds the audiences of each operation, keyed by
+	// method and path.
+	operationAudiences map[string][]string
+	// operationVersions holds the version of each operation, keyed by method
+	// and path.
+	operationVersions map[string]string
+	// controllers are the controllers of each rest package, keyed by its
+	// import path then by their type.
+	controllers map[string]map[string]*Controller
 }
 
 func NewParser(options ...func(*Parser)) *Parser {
@@ -61,9 +106,19 @@ func NewParser(options ...func(*Parser)) *Parser {
 		excludes:             make(map[string]bool),
 		Apis:                 make([]string, 0),
 		ApiMap:               make(map[string]string),
+		routeGroups:          make(map[string]*RouteGroup),
+		controllers:          make(map[string]map[string]*Controller),
 		ImportPaths:          make([]string, 0),
 		ImportPathsCache:     make(map[string]bool),
 		TypePackagePathCache: make([]string, 0),
+		typeSpecFset:         token.NewFileSet(),
+		typeSpecDirs:         make(map[string][]*typeSpecFile),
+		typeEnums:            make(map[token.Pos]*typeEnum),
+		paramValidations:     make(map[string]map[string]*validation),
+		operationTypes:       make(map[string]*operationType),
+		operationFailures:    make(map[string][]*Failure),
+		operationAudiences:   make(map[string][]string),
+		operationVersions:    make(map[string]string),
 	}
 
 	for _, option := range options {
@@ -85,12 +140,55 @@ func WithDebug(debug bool) func(parser *Parser) {
 	}
 }
 
+// WithQuiet hides the routes generated and the warnings of parsing, for the
+// commands printing machine readable output.
+func WithQuiet(quiet bool) func(parser *Parser) {
+	return func(p *Parser) {
+		p.quiet = quiet
+	}
+}
+
 func WithWorkDir(cwd string) func(parser *Parser) {
 	return func(p *Parser) {
 		p.cwd = cwd
 	}
 }
 
+func WithSecurityConfig(cfg *SecurityConfig) func(*Parser) {
+	return func(p *Parser) {
+		p.security = cfg
+	}
+}
+
+// WithParseCache reuses what is parsed from the unchanged files of the
+// project.
+func WithParseCache(c *cache.Cache) func(*Parser) {
+	return func(p *Parser) {
+		p.cache = c
+	}
+}
+
+// WithDeclarationsOnly parses the files of api/rest only for their package,
+// imports and funcs with their doc, read from the parse cache when unchanged,
+// for the commands not printing nor analyzing the bodies of handlers.
+func WithDeclarationsOnly(only bool) func(*Parser) {
+	return func(p *Parser) {
+		p.declarationsOnly = only
+	}
+}
+
+func WithPolicyConfig(cfg *PolicyConfig) func(*Parser) {
+	return func(p *Parser) {
+		p.policy = cfg
+	}
+}
+
+func WithAudienceConfig(cfg *AudienceConfig) func(*Parser) {
+	return func(p *Parser) {
+		p.audience = cfg
+	}
+}
+
 func WithExcludedDirsAndFiles(excludes string) func(*Parser) {
 	return func(p *Parser) {
 		for _, f := range strings.Split(excludes, ",") {
@@ -184,111 +282,109 @@ func (p *Parser) ParseTypeSpec(dryRun bool, propertyStrategy string, tagFlags []
 }
 
 func (p *Parser) ParseAPIInfo(info *AstFileInfo, astFile *ast.File) error {
-	index := strings.LastIndex(info.PackagePath, "/")
-	curPkg := info.PackagePath[index+1:]
+	curPkg := restPackageName(info.PackagePath)
 	if _, ok := p.ImportPathsCache[info.PackagePath]; !ok {
 		p.ImportPathsCache[info.PackagePath] = true
-		p.ImportPaths = append(p.ImportPaths, fmt.Sprintf("\"%s\"", info.PackagePath))
+		importPath := fmt.Sprintf("\"%s\"", info.PackagePath)
+		if !strings.HasSuffix(info.PackagePath, "/"+curPkg) {
+			importPath = curPkg + " " + importPath
+		}
+		p.ImportPaths = append(p.ImportPaths, importPath)
+	}
+	version, err := FileVersion(info.Path)
+	if err != nil {
+		return err
+	}
+	group, err := p.fileRouteGroup(info, astFile, version)
+	if err != nil {
+		return err
+	}
+	if group != nil && len(group.Middlewares) > 0 {
+		p.IsHasMiddleware = true
 	}
 	for _, astDescription := range astFile.Decls {
 		switch astDeclaraction := astDescription.(type) {
 		case *ast.FuncDecl:
 			funcName := astDeclaraction.Name.String()
-			var httpMethod string
-			var apiPath string
-			var apiDecl string
-			var beforeMiddlewares, afterMiddlewares []string
+			var policies []*Policy
 			if p.Debug {
 				fmt.Println(fmt.Sprintf("parse funcName: %s in file %s", funcName, info.Path))
 			}
 
 			if astDeclaraction.Doc == nil {
-				fmt.Println(ansi.Color(fmt.Sprintf("[Warn] func: %s missing route annotation, skipping...", funcName), "yellow+b"))
+				if !p.quiet {
+					fmt.Println(ansi.Color(fmt.Sprintf("[Warn] func: %s missing route annotation, skipping...", funcName), "yellow+b"))
+				}
 				continue
 			}
-			for _, comment := range astDeclaraction.Doc.List {
-				commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-				fields := strings.Fields(commentLine)
-				attribute := fields[0]
-				lowerAttribute := strings.ToLower(attribute)
-				switch lowerAttribute {
-				case "@router":
-					httpMethod = strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-					apiPath = fields[1]
-				case "@beforemiddleware":
-					beforeMiddlewares = strings.Split(fields[1], ",")
-				case "@aftermiddleware":
-					afterMiddlewares = strings.Split(fields[1], ",")
-				}
+			handler, err := annotation.ParseHandler(info.FileSet, astDeclaraction)
+			if err != nil {
+				return err
 			}
-			if httpMethod == "" {
+			if handler == nil {
 				continue
 			}
-
-			action := fmt.Sprintf("%s.%s", curPkg, funcName)
-			methods := strings.Split(httpMethod, ",")
-			for _, httpMethod := range methods {
-				switch httpMethod {
-				case http.MethodGet:
-					apiDecl = fmt.Sprintf("route.GET(\"%s\"", apiPath)
-				case http.MethodPost:
-					apiDecl = fmt.Sprintf("route.POST(\"%s\"", apiPath)
-				case http.MethodPatch:
-					apiDecl = fmt.Sprintf("route.PATCH(\"%s\"", apiPath)
-				case MethodAny:
-					apiDecl = fmt.Sprintf("route.Any(\"%s\"", apiPath)
-				default:
-					return errors.New("unsupport method")
-				}
-				if beforeMiddlewares != nil {
-					p.IsHasMiddleware = true
-					for _, beforeMiddleware := range beforeMiddlewares {
-						apiDecl = fmt.Sprintf("%s, %s", apiDecl, strings.TrimSpace(beforeMiddleware))
+			if group != nil {
+				for _, route := range handler.Routes {
+					if _, ok := group.relativePath(route.Path); !ok {
+						return fmt.Errorf("%s: path %s is out of @Group %s", info.FileSet.Position(route.Pos), route.Path, group.Prefix)
 					}
 				}
-				apiDecl = fmt.Sprintf("%s, %s", apiDecl, action)
-				if afterMiddlewares != nil {
-					p.IsHasMiddleware = true
-					for _, afterMiddleware := range afterMiddlewares {
-						apiDecl = fmt.Sprintf("%s, %s", apiDecl, strings.TrimSpace(afterMiddleware))
-					}
+			}
+			for _, a := range handler.Annotations {
+				if !p.policy.isPolicy(strings.ToLower(a.Name)) {
+					continue
 				}
-				apiDecl = fmt.Sprintf("%s)", apiDecl)
-
-				fmt.Printf("generate route: %s\n", ansi.Color(apiDecl, "cyan+b"))
-				key := fmt.Sprintf("%s.%s", apiPath, httpMethod)
-				p.Apis = append(p.Apis, key)
-				p.ApiMap[key] = apiDecl
+				policy, err := parsePolicyAnnotation(a.Name, a.Params)
+				if err == nil {
+					err = p.policyMiddleware(policy)
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %v", info.FileSet.Position(a.Pos), err)
+				}
+				policies = append(policies, policy)
 			}
-		}
-	}
-	return nil
-}
-
-func (p *Parser) ParseImportsToMap(importPath string) map[string]string {
-	pkgInfo := p.getPkgInfo(importPath)
+			var controller *Controller
+			if handler.Receiver != "" {
+				controller, err = p.handlerController(info, handler.Receiver)
+				if err != nil {
+					return err
+				}
+				if controller == nil {
+					return fmt.Errorf("%s: handler %s is a method of %s, which requires @Controller %s [constructor] in the package comment", info.FileSet.Position(astDeclaraction.Pos()), funcName, handler.Receiver, handler.Receiver)
+				}
+			}
+			beforeMiddlewares, afterMiddlewares := handler.BeforeMiddlewares, handler.AfterMiddlewares
+			routes := handlerRoutes(handler)
 
-	if pkgInfo == nil {
-		return nil
-	}
+			if len(beforeMiddlewares) > 0 || len(afterMiddlewares) > 0 {
+				p.IsHasMiddleware = true
+			}
+			for _, route := range routes {
+				r := p.newRoute(info, astFile, route, version, funcName, controller, group, policies, beforeMiddlewares, afterMiddlewares)
+				router, handlerExpr := "route", r.Handler
+				if group != nil {
+					router = group.Var
+				}
+				if controller != nil {
+					handlerExpr = controller.Var + "." + funcName
+				}
+				r.Decl = r.Statement(router, handlerExpr)
 
-	importMap := make(map[string]string)
-	for i := range pkgInfo.Files {
-		astFile := pkgInfo.Files[i]
-		for _, importSpec := range astFile.Imports {
-			var key string
-			name := importSpec.Name
-			path := importSpec.Path.Value
-			if name == nil {
-				key = path[strings.LastIndex(path, "/")+1:]
-			} else {
-				key = name.Name
+				if !p.quiet {
+					fmt.Printf("generate route: %s\n", ansi.Color(r.Decl, "cyan+b"))
+				}
+				p.Routes = append(p.Routes, r)
+				key := fmt.Sprintf("%s.%s", r.Path, r.Method)
+				p.Apis = append(p.Apis, key)
+				p.ApiMap[key] = r.Decl
+				if group != nil {
+					group.Apis = append(group.Apis, key)
+				}
 			}
-			importMap[strings.TrimSuffix(key, "\"")] = strings.Trim(path, "\"")
 		}
 	}
-
-	return importMap
+	return nil
 }
 
 func (p *Parser) ParseCommentInfo(info *AstFileInfo, astFile *ast.File) error {
@@ -300,11 +396,16 @@ func (p *Parser) ParseCommentInfo(info *AstFileInfo, astFile *ast.File) error {
 	typeSpecImportPath += "type"
 
 	typeSpecPath := strings.Replace(filepath.Dir(info.Path)+"type/"+filepath.Base(info.Path), "rest", "typespec", 1)
-	//typeImportsMap := p.ParseImportsToMap(typeSpecPath)
-	typeImportsMap := map[string]string{
-		"typespec": fmt.Sprintf("%s/api/typespec/base.go", p.cwd),
-	}
 	p.TypePackagePathCache = append(p.TypePackagePathCache, "	_ "+fmt.Sprintf("\"%s\"", typeSpecImportPath))
+	version, err := FileVersion(info.Path)
+	if err != nil {
+		return err
+	}
+	group, err := p.fileRouteGroup(info, astFile, version)
+	if err != nil {
+		return err
+	}
+	var stubs string
 	for _, astDescription := range astFile.Decls {
 		switch astDeclaraction := astDescription.(type) {
 		case *ast.FuncDecl:
@@ -313,105 +414,141 @@ func (p *Parser) ParseCommentInfo(info *AstFileInfo, astFile *ast.File) error {
 				fmt.Println(fmt.Sprintf("current parse func: %s", ansi.Color(funcName, "cyan+b")))
 			}
 			var (
-				httpMethod    string
-				path          string
-				acceptComment string
-				funcDesc      string
+				middlewares []string
+				securities  []string
+				headers     []*HeaderDoc
+				audiences   []string
+				policies    []*Policy
 			)
+			if group != nil {
+				middlewares = append(middlewares, group.Middlewares...)
+			}
 			if astDeclaraction.Doc == nil {
 				log.Warnf("func: %s in %s not found doc, please confirm the func is deprecated.", ansi.Color(funcName, "cyan+b"), ansi.Color(info.PackagePath+".go", "cyan+b"))
 				continue
 			}
-			for _, comment := range astDeclaraction.Doc.List {
-				commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-				if !strings.Contains(commentLine, "@") {
-					funcDesc = commentLine
-					continue
-				}
-				fields := strings.Fields(commentLine)
-				attribute := fields[0]
-				lowerAttribute := strings.ToLower(attribute)
-				if lowerAttribute == "@router" {
-					path = fields[1]
-					httpMethod = strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-				}
-				if lowerAttribute == "@accept" {
-					acceptComment = commentLine
-				}
+			handler, err := annotation.ParseHandler(info.FileSet, astDeclaraction)
+			if err != nil {
+				return err
 			}
-			if httpMethod == "" {
+			if handler == nil {
 				continue
 			}
+			for _, a := range handler.Annotations {
+				switch lowerAttribute := strings.ToLower(a.Name); lowerAttribute {
+				case "@security":
+					securities = append(securities, a.Params)
+				case "@header":
+					header, err := parseHeaderAnnotation(a.Params)
+					if err != nil {
+						return fmt.Errorf("%s: %v", info.FileSet.Position(a.Pos), err)
+					}
+					headers = append(headers, header)
+				case "@audience":
+					names, err := parseAudienceAnnotation(a.Params)
+					if err != nil {
+						return fmt.Errorf("%s: %v", info.FileSet.Position(a.Pos), err)
+					}
+					audiences = append(audiences, names...)
+				default:
+					if !p.policy.isPolicy(lowerAttribute) {
+						continue
+					}
+					policy, err := parsePolicyAnnotation(a.Name, a.Params)
+					if err != nil {
+						return fmt.Errorf("%s: %v", info.FileSet.Position(a.Pos), err)
+					}
+					// the docs don't require the constructors, which only
+					// tell the security of the policies
+					if p.policyMiddleware(policy) == nil {
+						middlewares = append(middlewares, policy.Middleware)
+					}
+					policies = append(policies, policy)
+				}
+			}
+			middlewares = append(middlewares, handler.BeforeMiddlewares...)
+			middlewares = append(middlewares, handler.AfterMiddlewares...)
+			routes := handlerRoutes(handler)
 
+			funcDesc := handler.Summary
 			if funcDesc == "" {
 				funcDesc = str.ToCamel(filename)
 			}
-			methods := strings.Split(httpMethod, ",")
-			for _, method := range methods {
+			failures := p.handlerFailures(info, astFile, astDeclaraction)
+			audiences = p.handlerAudiences(info.Path, audiences)
+			for i, route := range routes {
+				method, path := route.method, VersionPath(version, route.path)
 				comment := fmt.Sprintf("// %s\n", funcDesc)
 				comment += fmt.Sprintf("// @Tags %s\n", str.ToCamel(filename))
 				comment += fmt.Sprintf("// @Summary %s\n", funcDesc)
-				if acceptComment != "" {
-					comment += fmt.Sprintf("// %s\n", acceptComment)
+				if handler.Accept != "" {
+					comment += fmt.Sprintf("// @Accept %s\n", handler.Accept)
 				}
 
 				comment += "// @Produce json\n"
-				// TODO optimize => module => file
-				spec, _ := p.findTypeDef(typeSpecPath, fmt.Sprintf("%sRequest", funcName))
+				rp, err := p.requestParams(filepath.Dir(typeSpecPath), funcName, method)
+				if err != nil {
+					return err
+				}
 				typeSpecPkgName := typeSpecImportPath[strings.LastIndex(typeSpecImportPath, "/")+1:]
-				if spec != nil {
-					switch spec.Type.(type) {
-					case *ast.StructType:
-						lis := spec.Type.(*ast.StructType).Fields.List
-						comment = p.parseTypeSpecComment(comment, httpMethod, typeSpecPath, 0, lis, typeImportsMap)
+				params := rp.params
+				if rp.found {
+					if rp.isStruct {
+						comment += rp.comment
+						p.paramValidations[method+" "+path] = params
 					}
-					switch method {
-					case http.MethodGet, http.MethodPatch:
-						//comment += fmt.Sprintf("// @Param object query %s.%s false \"请求数据\"\n", typeSpecPkgName, funcName+"Request")
-						if method != http.MethodPatch {
-							comment += fmt.Sprintf("// @Success 200 object %s.%s \"请求成功\"\n", typeSpecPkgName, funcName+"Response")
-							//if strings.HasSuffix(funcName, "List") {
-							//	typename := fmt.Sprintf("%sResponse", strings.TrimSuffix(funcName, "List"))
-							//	comment += fmt.Sprintf("// @Success 200 object []%s.%s \"请求成功\"\n", typeSpecPkgName, typename)
-							//}
-						}
-					case http.MethodPost:
-						if acceptComment != "" {
+					p.operationTypes[method+" "+path] = &operationType{
+						dir:      filepath.Dir(typeSpecPath),
+						funcName: funcName,
+					}
+					if hasRequestBody(method) {
+						if handler.Accept != "" {
 							comment += fmt.Sprintf("// @Param object formData %s.%s true \"请求数据\"\n", typeSpecPkgName, funcName+"Request")
 						} else {
 							comment += fmt.Sprintf("// @Param data body %s.%s true \"请求数据\"\n", typeSpecPkgName, funcName+"Request")
 						}
-						comment += fmt.Sprintf("// @Success 200 object %s.%s \"请求成功\"\n", typeSpecPkgName, funcName+"Response")
 					}
+					comment += fmt.Sprintf("// @Success 200 object %s.%s \"请求成功\"\n", typeSpecPkgName, funcName+"Response")
 				} else {
 					comment += fmt.Sprintf("// @Success 200 object %s \"请求成功\"\n", funcName+"Response")
 				}
-				comment += "// @Failure 500 \"服务异常\"\n"
+				comment += p.securityComment(middlewares, securities, headers, params)
+				policyComment, err := policiesComment(policies)
+				if err != nil {
+					return err
+				}
+				comment += policyComment
+				comment += failureComment(failures)
+				p.operationFailures[method+" "+path] = failures
+				p.operationAudiences[method+" "+path] = audiences
+				p.operationVersions[method+" "+path] = version
 				comment += fmt.Sprintf("// @Router %s [%s]", path, method)
-				astDeclaraction.Doc.List[0].Text = comment
-				astDeclaraction.Doc.List = astDeclaraction.Doc.List[:1]
+				if i == 0 {
+					astDeclaraction.Doc.List[0].Text = comment
+					astDeclaraction.Doc.List = astDeclaraction.Doc.List[:1]
+					continue
+				}
+				// swag documents one route per func, so the other routes
+				// of the handler are documented on stubs of it
+				stubs += fmt.Sprintf("\n%s\nfunc %sRoute%d() {}\n", comment, funcName, i)
 			}
 		}
 	}
 
+	// typespec packages of different versions share the name, which swag
+	// only tells apart by the imports of the file referring them
+	astutil.AddNamedImport(info.FileSet, astFile, "_", typeSpecImportPath)
+
 	var buf bytes.Buffer
 	printer.Fprint(&buf, info.FileSet, astFile)
+	buf.WriteString(stubs)
 	tplPathSuffix := strings.TrimPrefix(info.Path, fmt.Sprintf("%s/api/rest/", p.cwd))
 	tplPath := fmt.Sprintf("%s/api/doc/%s", p.cwd, tplPathSuffix)
 
-	err := p.buildDocTpl(buf, tplPath)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return p.buildDocTpl(buf, tplPath)
 }
 
-func (p *Parser) parseTypeSpecComment(comment, httpMethod, typeSpecPath string, depth int, lis []*ast.Field, typeImportsMap map[string]string) string {
-	if depth >= 2 {
-		panic("now just support parse dependency depth 1")
-	}
-
+func (p *Parser) parseTypeSpecComment(comment, httpMethod string, def *typeSpecDef, lis []*ast.Field, visiting map[token.Pos]bool, params map[string]*validation) (string, error) {
 	for _, ls := range lis {
 		if p.Debug {
 			fname := "anonymous"
@@ -420,53 +557,37 @@ func (p *Parser) parseTypeSpecComment(comment, httpMethod, typeSpecPath string,
 			}
 			fmt.Println(fmt.Sprintf("current parse filed: %s", ansi.Color(fname, "cyan+b")))
 		}
-		var subTypePath, subTypeName, switchType string
-		if httpMethod != http.MethodPost {
-			switch ls.Type.(type) {
-			case *ast.Ident:
-				obj := ls.Type.(*ast.Ident).Obj
-				if obj != nil && obj.Kind == ast.Typ {
-					subTypePath = typeSpecPath
-					subTypeName = obj.Name
-				}
-			case *ast.SelectorExpr:
-				importName := ls.Type.(*ast.SelectorExpr).X.(*ast.Ident).Name
-				subTypeName = ls.Type.(*ast.SelectorExpr).Sel.Name
-				if typ, ok := builtinTypeMap[importName+"."+subTypeName]; ok {
-					switchType = typ
-				} else {
-					if _, ok := typeImportsMap[importName]; !ok {
-						panic("un import field path, can't parse un import type field")
-					}
-					subTypePath = typeImportsMap[importName]
-				}
-			case *ast.ArrayType:
-				elt := ls.Type.(*ast.ArrayType).Elt
-				switch elt.(type) {
-				case *ast.Ident:
-					switchType = "[]" + elt.(*ast.Ident).String()
-				default:
-					panic("not support field type")
-				}
-			case *ast.MapType:
-				switchType = "object"
-			default:
-				panic("not support field type")
+		var ft *fieldType
+		if !hasRequestBody(httpMethod) {
+			var err error
+			ft, err = p.resolveFieldType(def, ls.Type)
+			if err != nil {
+				return comment, err
 			}
+		} else if len(ls.Names) == 0 {
+			// embedded types of body only matter for their header fields,
+			// so the ones that can't be resolved are left to swag.
+			ft, _ = p.resolveFieldType(def, ls.Type)
 		}
-		if subTypePath != "" && subTypeName != "" {
-			spec, err := p.findTypeDef(subTypePath, subTypeName)
+		if ft != nil && ft.def != nil {
+			if visiting[ft.def.key()] {
+				fmt.Println(ansi.Color(fmt.Sprintf("[Warn] type: %s in %s is recursive, skipping...", ft.def.spec.Name.Name, p.typeSpecRelPath(ft.def)), "yellow+b"))
+				continue
+			}
+			visiting[ft.def.key()] = true
+			var err error
+			flis := ft.def.spec.Type.(*ast.StructType).Fields.List
+			comment, err = p.parseTypeSpecComment(comment, httpMethod, ft.def, flis, visiting, params)
 			if err != nil {
-				panic(err)
+				return comment, err
 			}
-			flis := spec.Type.(*ast.StructType).Fields.List
-			comment = p.parseTypeSpecComment(comment, httpMethod, typeSpecPath, depth+1, flis, typeImportsMap)
+			delete(visiting, ft.def.key())
 			continue
 		}
 
 		var typ string
-		if switchType != "" {
-			typ = switchType
+		if ft != nil {
+			typ = ft.name
 		} else {
 			switch ls.Type.(type) {
 			case *ast.Ident:
@@ -487,17 +608,13 @@ func (p *Parser) parseTypeSpecComment(comment, httpMethod, typeSpecPath string,
 		if desc == "" {
 			desc = name
 		}
-		valid := tag.Get("valid")
-		var required bool
-		if valid != "" {
-			parts := strings.Split(valid, ";")
-			for _, part := range parts {
-				if part == "Required" {
-					required = true
-				}
-			}
+		if ft != nil && ft.isJSON {
+			desc += "(JSON)"
 		}
-		if name != "" && httpMethod != http.MethodPost {
+		v := p.fieldValidation(def, ls)
+		required := v.required
+		if name != "" && !hasRequestBody(httpMethod) {
+			params["query:"+name] = v
 			comment += fmt.Sprintf("// @Param %s query %s %t \"%s\"\n", name, typ, required, desc)
 		} else {
 			header := tag.Get("header")
@@ -509,12 +626,13 @@ func (p *Parser) parseTypeSpecComment(comment, httpMethod, typeSpecPath string,
 				if index := strings.Index(header, ","); index != -1 {
 					header = header[:index]
 				}
+				params["header:"+header] = v
 				comment += fmt.Sprintf("// @Param %s header %s %t \"%s\"\n", header, typ, required, header)
 			}
 		}
 	}
 
-	return comment
+	return comment, nil
 }
 
 func (p *Parser) buildDocTpl(buf bytes.Buffer, tplPath string) error {
@@ -537,91 +655,36 @@ func (p *Parser) buildDocTpl(buf bytes.Buffer, tplPath string) error {
 	return nil
 }
 
-func (p *Parser) getPkgInfo(importPath string) *loader.PackageInfo {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil
-	}
-
-	conf := loader.Config{
-		ParserMode: goparser.ParseComments,
-		Cwd:        cwd,
-	}
-
-	conf.Import(importPath)
-
-	lprog, err := conf.Load()
-	if err != nil {
-		return nil
-	}
-
-	for k := range lprog.AllPackages {
-		realPkgPath := k.Path()
-
-		if strings.Contains(realPkgPath, "vendor/"+importPath) {
-			importPath = realPkgPath
-		}
-	}
-
-	return lprog.Package(importPath)
-}
-
-func (p *Parser) findTypeDef(file, typeName string) (*ast.TypeSpec, error) {
-	fset := token.NewFileSet()
-	fileTree, err := goparser.ParseFile(fset, file, nil, goparser.ParseComments)
+func (p *Parser) ParseAPI(searchDir string) error {
+	packageDir, err := p.packagePath(searchDir)
 	if err != nil {
-		return nil, err
+		fmt.Printf("warning: failed to get package name in dir: %s, error: %s", searchDir, err.Error())
 	}
 
-	for _, decl := range fileTree.Decls {
-		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
-			for _, spec := range genDecl.Specs {
-				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-					if typeSpec.Name.String() == typeName {
-						return typeSpec, nil
-					}
-				}
-			}
-		}
+	if err = p.getAllGoFileInfo(packageDir, searchDir); err != nil {
+		return err
 	}
 
-	return nil, fmt.Errorf("type spec not found")
+	return nil
 }
 
-func (p *Parser) findTypeDefV2(importPath, typeName string) (*ast.TypeSpec, error) {
-	pkgInfo := p.getPkgInfo(importPath)
-
-	if pkgInfo == nil {
-		return nil, fmt.Errorf("package was nil")
+// packagePath returns the import path of dir, cached until go.mod changes
+// as go list takes longer than parsing the files.
+func (p *Parser) packagePath(dir string) (string, error) {
+	var pkgPath string
+	if p.cache.Get("package", dir, &pkgPath) {
+		return pkgPath, nil
 	}
 
-	for i := range pkgInfo.Files {
-		for _, astDeclaraction := range pkgInfo.Files[i].Decls {
-			if generalDeclaraction, ok := astDeclaraction.(*ast.GenDecl); ok && generalDeclaraction.Tok == token.TYPE {
-				for _, astSpec := range generalDeclaraction.Specs {
-					if typeSpec, ok := astSpec.(*ast.TypeSpec); ok {
-						if typeSpec.Name.String() == typeName {
-							return typeSpec, nil
-						}
-					}
-				}
-			}
-		}
-	}
-	return nil, fmt.Errorf("type spec not found")
-}
-
-func (p *Parser) ParseAPI(searchDir string) error {
-	packageDir, err := util.GetPkgName(searchDir)
+	pkgPath, err := util.GetPkgName(dir)
 	if err != nil {
-		fmt.Printf("warning: failed to get package name in dir: %s, error: %s", searchDir, err.Error())
+		return "", err
 	}
-
-	if err = p.getAllGoFileInfo(packageDir, searchDir); err != nil {
-		return err
+	if p.cwd != "" {
+		_ = p.cache.Put("package", dir, []string{filepath.Join(p.cwd, "go.mod")}, pkgPath)
 	}
 
-	return nil
+	return pkgPath, nil
 }
 
 func (p *Parser) getAllGoFileInfo(packageDir, searchDir string) error {
@@ -630,7 +693,7 @@ func (p *Parser) getAllGoFileInfo(packageDir, searchDir string) error {
 			return err
 		} else if f.IsDir() {
 			return nil
-		} else if f.Name() == RouteEntryName || f.Name() == APIDefinitionName {
+		} else if f.Name() == RouteEntryName || f.Name() == APIDefinitionName || f.Name() == RouteRegistrationName {
 			return nil
 		}
 		if strings.Contains(f.Name(), "_test.go") {
@@ -651,7 +714,15 @@ func (p *Parser) parseFile(packageDir, path string, src interface{}) error {
 	}
 
 	fset := token.NewFileSet()
-	astFile, err := goparser.ParseFile(fset, path, src, goparser.ParseComments)
+	var (
+		astFile *ast.File
+		err     error
+	)
+	if p.declarationsOnly && src == nil {
+		astFile, err = p.cache.ParseFile(fset, path)
+	} else {
+		astFile, err = goparser.ParseFile(fset, path, src, goparser.ParseComments)
+	}
 	if err != nil {
 		return fmt.Errorf("ParseFile error:%+v", err)
 	}
@@ -0,0 +1,237 @@
+package doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+var (
+	rateLimitRegexp   = regexp.MustCompile(`^[0-9]+/(s|m|h)$`)
+	policyNameRegexp  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	constructorRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\.[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// builtinPolicies validate the arguments of the policies ginctl knows,
+// keyed by their lower case name.
+var builtinPolicies = map[string]func(args []string) error{
+	"auth": func(args []string) error {
+		for _, arg := range args {
+			if index := strings.Index(arg, "="); index <= 0 {
+				return fmt.Errorf("invalid argument %s, expected key=value like role=admin", arg)
+			}
+		}
+		return nil
+	},
+	"ratelimit": func(args []string) error {
+		if len(args) != 1 || !rateLimitRegexp.MatchString(args[0]) {
+			return fmt.Errorf("expected a rate like 100/m, in s, m or h")
+		}
+		return nil
+	},
+	"timeout": func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a duration like 3s")
+		}
+		if d, err := time.ParseDuration(args[0]); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %s, expected a duration like 3s", args[0])
+		}
+		return nil
+	},
+	"cors": func(args []string) error {
+		return nil
+	},
+}
+
+// reservedAnnotations are the annotations of handlers which can't be the
+// name of a policy.
+var reservedAnnotations = map[string]bool{
+	"router": true, "group": true, "version": true, "audience": true,
+	"beforemiddleware": true, "aftermiddleware": true, "security": true,
+	"header": true, "accept": true, "produce": true, "param": true,
+	"success": true, "failure": true, "response": true, "summary": true,
+	"description": true, "tags": true, "id": true, "deprecated": true,
+}
+
+// PolicyConfig maps the policy annotations of handlers, like @Auth role=admin,
+// to the middleware constructors of a project, e.g.
+//
+//	policies:
+//	  auth:
+//	    constructor: middleware.Auth
+//	  rateLimit:
+//	    constructor: middleware.RateLimit
+//	  tenant:
+//	    constructor: tenant.Required
+//	    import: github.com/go-season/common/tenant
+//
+// Besides auth, rateLimit, timeout and cors, the policies configured are
+// annotations too, e.g. @Tenant.
+type PolicyConfig struct {
+	Policies map[string]*PolicyMiddleware `json:"policies"`
+}
+
+// PolicyMiddleware is the middleware constructor of a policy, called with the
+// arguments of the annotation as strings, e.g. middleware.Auth("role=admin").
+type PolicyMiddleware struct {
+	Constructor string `json:"constructor"`
+	// Import is the path of the package of Constructor, imported by the
+	// qualifier of Constructor. It's the middleware package of the project
+	// by default, whose constructors are qualified by middleware.
+	Import string `json:"import"`
+}
+
+// qualifier returns the package name the constructor is called by, e.g.
+// tenant of tenant.Required.
+func (m *PolicyMiddleware) qualifier() string {
+	return m.Constructor[:strings.Index(m.Constructor, ".")]
+}
+
+func importName(importPath string) string {
+	if importPath == "" {
+		return "the middleware package"
+	}
+
+	return importPath
+}
+
+// Policy is a policy annotation of a handler.
+type Policy struct {
+	Name string   `json:"name"`
+	Args []string `json:"args,omitempty"`
+	// Middleware is the constructor call registering the policy.
+	Middleware string `json:"middleware,omitempty"`
+}
+
+func (p *Policy) String() string {
+	if len(p.Args) == 0 {
+		return p.Name
+	}
+
+	return fmt.Sprintf("%s(%s)", p.Name, strings.Join(p.Args, " "))
+}
+
+// LoadPolicyConfig reads the policy config of file.
+func LoadPolicyConfig(file string) (*PolicyConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(PolicyConfig)
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse policy config %s failed: %v", file, err)
+	}
+	policies := make(map[string]*PolicyMiddleware)
+	qualifiers := make(map[string]string)
+	for name, middleware := range cfg.Policies {
+		if !policyNameRegexp.MatchString(name) || reservedAnnotations[strings.ToLower(name)] {
+			return nil, fmt.Errorf("invalid policy %s of %s, only letters and digits are allowed except the annotations of ginctl", name, file)
+		}
+		if middleware == nil || !constructorRegexp.MatchString(middleware.Constructor) {
+			return nil, fmt.Errorf("policy %s of %s requires a constructor like middleware.Auth", name, file)
+		}
+		// the constructor without import is called in the middleware
+		// package of the project, and the import is named by its qualifier
+		qualifier := middleware.qualifier()
+		if middleware.Import == "" && qualifier != "middleware" {
+			return nil, fmt.Errorf("policy %s of %s: constructor %s is not in the middleware package, please set its import", name, file, middleware.Constructor)
+		}
+		if imported, ok := qualifiers[qualifier]; ok && imported != middleware.Import {
+			return nil, fmt.Errorf("policy %s of %s: qualifier %s of constructor %s refers to %s too", name, file, qualifier, middleware.Constructor, importName(imported))
+		}
+		qualifiers[qualifier] = middleware.Import
+		if _, ok := policies[strings.ToLower(name)]; ok {
+			return nil, fmt.Errorf("duplicate policy %s of %s", name, file)
+		}
+		policies[strings.ToLower(name)] = middleware
+	}
+	cfg.Policies = policies
+
+	return cfg, nil
+}
+
+// isPolicy reports whether the lower case annotation, like @ratelimit, is a
+// policy.
+func (cfg *PolicyConfig) isPolicy(annotation string) bool {
+	name := strings.TrimPrefix(annotation, "@")
+	if _, ok := builtinPolicies[name]; ok {
+		return true
+	}
+
+	return cfg != nil && cfg.Policies[name] != nil
+}
+
+// parsePolicyAnnotation parses the space separated arguments of a policy
+// annotation.
+func parsePolicyAnnotation(attribute, params string) (*Policy, error) {
+	policy := &Policy{
+		Name: strings.TrimPrefix(attribute, "@"),
+		Args: strings.Fields(params),
+	}
+	if validate, ok := builtinPolicies[strings.ToLower(policy.Name)]; ok {
+		if err := validate(policy.Args); err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %v", attribute, params, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// policyMiddleware sets the middleware of policy by the constructor
+// configured, importing its package.
+func (p *Parser) policyMiddleware(policy *Policy) error {
+	var middleware *PolicyMiddleware
+	if p.policy != nil {
+		middleware = p.policy.Policies[strings.ToLower(policy.Name)]
+	}
+	if middleware == nil {
+		return fmt.Errorf("no middleware constructor configured for @%s", policy.Name)
+	}
+
+	args := make([]string, 0, len(policy.Args))
+	for _, arg := range policy.Args {
+		args = append(args, strconv.Quote(arg))
+	}
+	policy.Middleware = fmt.Sprintf("%s(%s)", middleware.Constructor, strings.Join(args, ", "))
+
+	if middleware.Import == "" {
+		p.IsHasMiddleware = true
+	} else if _, ok := p.ImportPathsCache[middleware.Import]; !ok {
+		p.ImportPathsCache[middleware.Import] = true
+		importPath := strconv.Quote(middleware.Import)
+		if qualifier := middleware.qualifier(); path.Base(middleware.Import) != qualifier {
+			importPath = qualifier + " " + importPath
+		}
+		p.ImportPaths = append(p.ImportPaths, importPath)
+	}
+
+	return nil
+}
+
+// policiesComment returns the x-policies extension of an operation.
+func policiesComment(policies []*Policy) (string, error) {
+	if len(policies) == 0 {
+		return "", nil
+	}
+
+	// the middlewares are how the project implements the policies, which
+	// readers of the doc needn't know
+	annotated := make([]*Policy, 0, len(policies))
+	for _, policy := range policies {
+		annotated = append(annotated, &Policy{Name: policy.Name, Args: policy.Args})
+	}
+	b, err := json.Marshal(annotated)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("// @x-policies %s\n", b), nil
+}
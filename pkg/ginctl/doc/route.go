@@ -0,0 +1,550 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"github.com/go-season/ginctl/pkg/util/str"
+)
+
+// apiRoute is a route a handler is registered on.
+type apiRoute struct {
+	path   string
+	method string
+	pos    token.Pos
+}
+
+// handlerRoutes returns the routes of each method of the @Router of h.
+func handlerRoutes(h *annotation.Handler) []apiRoute {
+	var routes []apiRoute
+	for _, route := range h.Routes {
+		for _, method := range route.Methods {
+			routes = append(routes, apiRoute{path: route.Path, method: method, pos: route.Pos})
+		}
+	}
+
+	return routes
+}
+
+// hasRequestBody reports whether the request of method is bound from its
+// body, and otherwise from its query.
+func hasRequestBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut
+}
+
+// RouteGroup is a gin route group declared by @Group, holding the routes of
+// the rest files in it.
+type RouteGroup struct {
+	// Version is the version the group is registered in.
+	Version     string
+	Prefix      string
+	Middlewares []string
+	// Var is the variable of the group in the generated registerRoute, which
+	// is Name numbered apart from the other groups.
+	Var  string
+	Name string
+	// Apis are the keys of the routes in the group, like the ones of
+	// Parser.Apis.
+	Apis []string
+}
+
+// relativePath returns path relative to the prefix of g, or false when the
+// path is out of it.
+func (g *RouteGroup) relativePath(path string) (string, bool) {
+	if g.Prefix == "/" {
+		return path, true
+	}
+	if path == g.Prefix {
+		return "", true
+	}
+	if strings.HasPrefix(path, g.Prefix+"/") {
+		return strings.TrimPrefix(path, g.Prefix), true
+	}
+
+	return "", false
+}
+
+// parseGroupAnnotation parses the params of @Group, which are
+// prefix [middleware,...], e.g. /admin auth.Required, audit.Log().
+func parseGroupAnnotation(params string) (string, []string, error) {
+	fields := strings.Fields(params)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, fmt.Errorf("invalid @Group %s, expected prefix [middleware,...]", params)
+	}
+	prefix := fields[0]
+	if prefix != "/" {
+		prefix = strings.TrimSuffix(prefix, "/")
+	}
+
+	var middlewares []string
+	for _, middleware := range strings.Split(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(params), fields[0])), ",") {
+		if middleware = strings.TrimSpace(middleware); middleware != "" {
+			middlewares = append(middlewares, middleware)
+		}
+	}
+
+	return prefix, middlewares, nil
+}
+
+// fileRouteGroup returns the group of the handlers in a rest file of
+// version, declared by @Group in its package comment, or else in the one of
+// doc.go of its package.
+func (p *Parser) fileRouteGroup(info *AstFileInfo, astFile *ast.File, version string) (*RouteGroup, error) {
+	group, err := p.declaredRouteGroup(info, astFile, version)
+	if err != nil || group != nil {
+		return group, err
+	}
+
+	if p.Packages == nil {
+		return nil, nil
+	}
+	pkg, ok := p.Packages.packages[info.PackagePath]
+	if !ok {
+		return nil, nil
+	}
+	docFile, ok := pkg.Files[filepath.Join(filepath.Dir(info.Path), "doc.go")]
+	if !ok || docFile == astFile {
+		return nil, nil
+	}
+
+	return p.declaredRouteGroup(p.Packages.files[docFile], docFile, version)
+}
+
+func (p *Parser) declaredRouteGroup(info *AstFileInfo, astFile *ast.File, version string) (*RouteGroup, error) {
+	if astFile.Doc == nil {
+		return nil, nil
+	}
+
+	var group *RouteGroup
+	for _, comment := range astFile.Doc.List {
+		commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
+		fields := strings.Fields(commentLine)
+		if len(fields) == 0 || strings.ToLower(fields[0]) != "@group" {
+			continue
+		}
+		if group != nil {
+			return nil, fmt.Errorf("%s: duplicate @Group", info.FileSet.Position(comment.Pos()))
+		}
+		prefix, middlewares, err := parseGroupAnnotation(strings.TrimPrefix(commentLine, fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", info.FileSet.Position(comment.Pos()), err)
+		}
+		group = p.routeGroup(version, prefix, middlewares)
+	}
+
+	return group, nil
+}
+
+// routeGroup returns the group of prefix and middlewares in version, shared
+// by all the files declaring the same.
+func (p *Parser) routeGroup(version, prefix string, middlewares []string) *RouteGroup {
+	key := version + " " + prefix + " " + strings.Join(middlewares, ",")
+	if group, ok := p.routeGroups[key]; ok {
+		return group
+	}
+
+	name := "root"
+	if prefix != "/" {
+		name = ""
+		for _, segment := range strings.Split(prefix, "/") {
+			segment = strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, segment)
+			if segment == "" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(segment[:1]) + segment[1:]
+			} else {
+				name += str.ToCamel(segment)
+			}
+		}
+		if name == "" || unicode.IsDigit(rune(name[0])) {
+			name = "g" + str.ToCamel(name)
+		}
+	}
+	name += "Group"
+	taken := make(map[string]bool)
+	for _, group := range p.RouteGroups {
+		taken[group.Var] = true
+	}
+	varName := name
+	for i := 2; taken[varName]; i++ {
+		varName = fmt.Sprintf("%s%d", name, i)
+	}
+
+	group := &RouteGroup{
+		Version:     version,
+		Prefix:      prefix,
+		Middlewares: middlewares,
+		Var:         varName,
+		Name:        name,
+	}
+	p.routeGroups[key] = group
+	p.RouteGroups = append(p.RouteGroups, group)
+
+	return group
+}
+
+// Route is a route registered by route refresh.
+type Route struct {
+	Method string `json:"method"`
+	// Path is the full path, including its version.
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	Version string `json:"version,omitempty"`
+	Group   string `json:"group,omitempty"`
+	// Policies are the policy annotations of the handler.
+	Policies []*Policy `json:"policies"`
+	// BeforeMiddlewares are the middlewares of its group, its policies and
+	// the ones of @BeforeMiddleware, in the order they run.
+	BeforeMiddlewares []string `json:"beforeMiddlewares"`
+	AfterMiddlewares  []string `json:"afterMiddlewares"`
+	File              string   `json:"file"`
+	Line              int      `json:"line"`
+
+	// RouteGroup is the group the route is registered in, if any.
+	RouteGroup *RouteGroup `json:"-"`
+	// Decl is the statement registering the route, in its group or version.
+	Decl string `json:"-"`
+	// PackagePath is the import path of the package of the handler, and
+	// PackageName the name it declares.
+	PackagePath string `json:"-"`
+	PackageName string `json:"-"`
+	// Func is the handler func in its package, or the method of Controller.
+	Func       string      `json:"-"`
+	Controller *Controller `json:"-"`
+
+	// relPath is the path in the group or version of the route.
+	relPath string
+	// middlewares are the ones of the policies and @BeforeMiddleware.
+	middlewares []string
+	// preflight is whether it's the OPTIONS route of @CORS, which is
+	// handled by the middleware of the policy only.
+	preflight bool
+}
+
+func (r *Route) String() string {
+	return fmt.Sprintf("%s %s (%s at %s:%d)", r.Method, r.Path, r.Handler, r.File, r.Line)
+}
+
+// RouteConflict is a route gin panics on when registering it after
+// Existing, or by itself when Existing is nil.
+type RouteConflict struct {
+	Route    *Route `json:"route"`
+	Existing *Route `json:"existing,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// anyMethods are the methods gin registers a route of Any on.
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete,
+	http.MethodConnect, http.MethodTrace,
+}
+
+// routeNode is a path segment of the route tree of a method.
+type routeNode struct {
+	static    map[string]*routeNode
+	param     *routeNode
+	catchAll  *routeNode
+	name      string
+	owner     *Route
+	route     *Route
+	staticKey []string
+}
+
+// FindRouteConflicts returns the conflicts gin panics on when registering
+// routes in order: duplicate methods and paths, wildcards clashing with
+// static segments, and wildcards of different names at the same position.
+func FindRouteConflicts(routes []*Route) []*RouteConflict {
+	var conflicts []*RouteConflict
+	seen := make(map[string]bool)
+	trees := make(map[string]*routeNode)
+	for _, route := range routes {
+		methods := []string{route.Method}
+		if route.Method == MethodAny {
+			methods = anyMethods
+		}
+		for _, method := range methods {
+			if trees[method] == nil {
+				trees[method] = new(routeNode)
+			}
+			conflict := trees[method].insert(route)
+			if conflict == nil {
+				continue
+			}
+			// routes of Any clash on every method the same way
+			key := fmt.Sprintf("%p %p %s", conflict.Route, conflict.Existing, conflict.Reason)
+			if !seen[key] {
+				seen[key] = true
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	return conflicts
+}
+
+func (n *routeNode) insert(route *Route) *RouteConflict {
+	segments := strings.Split(strings.TrimPrefix(route.Path, "/"), "/")
+	node := n
+	for i, segment := range segments {
+		prefix := "/" + strings.Join(segments[:i], "/")
+		conflict := func(existing *Route, format string, args ...interface{}) *RouteConflict {
+			return &RouteConflict{
+				Route:    route,
+				Existing: existing,
+				Reason:   fmt.Sprintf(format, args...) + " under " + prefix,
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			if len(node.static) > 0 {
+				static := node.static[node.staticKey[0]]
+				return conflict(static.owner, "wildcard %s conflicts with static segment %s", segment, node.staticKey[0])
+			}
+			if node.catchAll != nil {
+				return conflict(node.catchAll.owner, "wildcard %s conflicts with catch-all %s", segment, node.catchAll.name)
+			}
+			if node.param != nil && node.param.name != segment {
+				return conflict(node.param.owner, "wildcard %s conflicts with wildcard %s of another name", segment, node.param.name)
+			}
+			if node.param == nil {
+				node.param = &routeNode{name: segment, owner: route}
+			}
+			node = node.param
+		case strings.HasPrefix(segment, "*"):
+			if i != len(segments)-1 {
+				return conflict(nil, "catch-all %s is only allowed at the end of the path", segment)
+			}
+			if len(node.static) > 0 {
+				static := node.static[node.staticKey[0]]
+				return conflict(static.owner, "catch-all %s conflicts with static segment %s", segment, node.staticKey[0])
+			}
+			if node.param != nil {
+				return conflict(node.param.owner, "catch-all %s conflicts with wildcard %s", segment, node.param.name)
+			}
+			if node.catchAll != nil && node.catchAll.name != segment {
+				return conflict(node.catchAll.owner, "catch-all %s conflicts with catch-all %s of another name", segment, node.catchAll.name)
+			}
+			if node.catchAll == nil {
+				node.catchAll = &routeNode{name: segment, owner: route}
+			}
+			node = node.catchAll
+		default:
+			if node.param != nil {
+				return conflict(node.param.owner, "static segment %s conflicts with wildcard %s", segment, node.param.name)
+			}
+			if node.catchAll != nil {
+				return conflict(node.catchAll.owner, "static segment %s conflicts with catch-all %s", segment, node.catchAll.name)
+			}
+			if node.static == nil {
+				node.static = make(map[string]*routeNode)
+			}
+			child, ok := node.static[segment]
+			if !ok {
+				child = &routeNode{name: segment, owner: route}
+				node.static[segment] = child
+				node.staticKey = append(node.staticKey, segment)
+			}
+			node = child
+		}
+	}
+
+	if node.route != nil {
+		return &RouteConflict{
+			Route:    route,
+			Existing: node.route,
+			Reason:   fmt.Sprintf("handlers are already registered for %s", route.Path),
+		}
+	}
+	node.route = route
+
+	return nil
+}
+
+func (p *Parser) newRoute(info *AstFileInfo, astFile *ast.File, route apiRoute, version, funcName string, controller *Controller, group *RouteGroup, policies []*Policy, beforeMiddlewares, afterMiddlewares []string) *Route {
+	r := &Route{
+		Method:            route.method,
+		Path:              VersionPath(version, route.path),
+		Handler:           fmt.Sprintf("%s.%s", restPackageName(info.PackagePath), funcName),
+		Version:           version,
+		Policies:          make([]*Policy, 0),
+		BeforeMiddlewares: make([]string, 0),
+		AfterMiddlewares:  make([]string, 0),
+		PackagePath:       info.PackagePath,
+		PackageName:       astFile.Name.Name,
+		Func:              funcName,
+		Controller:        controller,
+		relPath:           route.path,
+	}
+	if controller != nil {
+		r.Handler = fmt.Sprintf("%s.%s.%s", restPackageName(info.PackagePath), controller.Type, funcName)
+	}
+	if group != nil {
+		r.Group = group.Prefix
+		r.RouteGroup = group
+		r.BeforeMiddlewares = append(r.BeforeMiddlewares, group.Middlewares...)
+		r.relPath, _ = group.relativePath(route.path)
+	}
+	for _, policy := range policies {
+		r.Policies = append(r.Policies, policy)
+		r.BeforeMiddlewares = append(r.BeforeMiddlewares, policy.Middleware)
+		r.middlewares = append(r.middlewares, policy.Middleware)
+	}
+	r.BeforeMiddlewares = append(r.BeforeMiddlewares, beforeMiddlewares...)
+	r.middlewares = append(r.middlewares, beforeMiddlewares...)
+	r.AfterMiddlewares = append(r.AfterMiddlewares, afterMiddlewares...)
+
+	position := info.FileSet.Position(route.pos)
+	r.File = strings.TrimPrefix(filepath.ToSlash(position.Filename), p.cwd+"/")
+	r.Line = position.Line
+
+	return r
+}
+
+// Statement returns the statement registering r on router, which is its
+// group or version, calling the handler by handler, e.g.
+// route.GET("/user/:id", middleware.Auth("role=admin"), user.GetUser).
+func (r *Route) Statement(router, handler string) string {
+	args := []string{fmt.Sprintf("\"%s\"", r.relPath)}
+	args = append(args, r.middlewares...)
+	if !r.preflight {
+		args = append(args, handler)
+		args = append(args, r.AfterMiddlewares...)
+	}
+
+	return fmt.Sprintf("%s.%s(%s)", router, r.Method, strings.Join(args, ", "))
+}
+
+// AddPreflightRoutes registers the OPTIONS route of each path whose handlers
+// are annotated by @CORS, which gin doesn't route the preflight requests to
+// otherwise. The middleware of the policy of the first handler answers them,
+// unless the path has an OPTIONS handler already.
+func (p *Parser) AddPreflightRoutes() {
+	handled := make(map[string]bool)
+	for _, route := range p.Routes {
+		if route.Method == http.MethodOptions {
+			handled[route.Path] = true
+		}
+	}
+
+	for _, route := range p.Routes {
+		if handled[route.Path] {
+			continue
+		}
+		var cors *Policy
+		for _, policy := range route.Policies {
+			if strings.ToLower(policy.Name) == "cors" {
+				cors = policy
+			}
+		}
+		if cors == nil {
+			continue
+		}
+		handled[route.Path] = true
+
+		r := &Route{
+			Method:            http.MethodOptions,
+			Path:              route.Path,
+			Handler:           cors.Middleware,
+			Version:           route.Version,
+			Group:             route.Group,
+			Policies:          []*Policy{cors},
+			BeforeMiddlewares: make([]string, 0),
+			AfterMiddlewares:  make([]string, 0),
+			File:              route.File,
+			Line:              route.Line,
+			RouteGroup:        route.RouteGroup,
+			PackagePath:       route.PackagePath,
+			PackageName:       route.PackageName,
+			relPath:           route.relPath,
+			middlewares:       []string{cors.Middleware},
+			preflight:         true,
+		}
+		router := "route"
+		if r.RouteGroup != nil {
+			router = r.RouteGroup.Var
+			r.BeforeMiddlewares = append(r.BeforeMiddlewares, r.RouteGroup.Middlewares...)
+		}
+		r.BeforeMiddlewares = append(r.BeforeMiddlewares, cors.Middleware)
+		r.Decl = r.Statement(router, "")
+
+		p.Routes = append(p.Routes, r)
+		key := fmt.Sprintf("%s.%s", r.Path, r.Method)
+		p.Apis = append(p.Apis, key)
+		p.ApiMap[key] = r.Decl
+		if r.RouteGroup != nil {
+			r.RouteGroup.Apis = append(r.RouteGroup.Apis, key)
+		}
+	}
+}
+
+// SortRoutes sorts Apis, RouteGroups and Routes in the order route refresh
+// registers them: the unversioned routes before the ones of each version,
+// and in a version the routes out of groups by path and method, then the
+// groups by prefix.
+func (p *Parser) SortRoutes() {
+	sort.Strings(p.Apis)
+	sort.SliceStable(p.RouteGroups, func(i, j int) bool {
+		gi, gj := p.RouteGroups[i], p.RouteGroups[j]
+		if gi.Version != gj.Version {
+			return gi.Version < gj.Version
+		}
+		return gi.Prefix < gj.Prefix
+	})
+	groupIndex := make(map[*RouteGroup]int)
+	for i, group := range p.RouteGroups {
+		sort.Strings(group.Apis)
+		groupIndex[group] = i + 1
+	}
+
+	sort.SliceStable(p.Routes, func(i, j int) bool {
+		ri, rj := p.Routes[i], p.Routes[j]
+		if ri.Version != rj.Version {
+			return ri.Version < rj.Version
+		}
+		if gi, gj := groupIndex[ri.RouteGroup], groupIndex[rj.RouteGroup]; gi != gj {
+			return gi < gj
+		}
+		if ki, kj := ri.Path+"."+ri.Method, rj.Path+"."+rj.Method; ki != kj {
+			return ki < kj
+		}
+		if ri.File != rj.File {
+			return ri.File < rj.File
+		}
+		return ri.Line < rj.Line
+	})
+}
+
+// RouteVersions returns the versions of Routes in order.
+func (p *Parser) RouteVersions() []string {
+	return RouteVersions(p.Routes)
+}
+
+// RouteVersions returns the versions of routes in order.
+func RouteVersions(routes []*Route) []string {
+	versions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		if route.Version != "" && !seen[route.Version] {
+			seen[route.Version] = true
+			versions = append(versions, route.Version)
+		}
+	}
+	sort.Strings(versions)
+
+	return versions
+}
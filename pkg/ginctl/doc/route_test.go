@@ -0,0 +1,150 @@
+package doc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindRouteConflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		// want are the conflicts, as the index of the route, the index of
+		// the existing route or -1, and the reason.
+		want []conflictCase
+	}{
+		{
+			name:   "no conflict",
+			routes: []string{"GET /user", "POST /user", "GET /user/:id", "GET /user/:id/items", "GET /order"},
+		},
+		{
+			name:   "duplicate",
+			routes: []string{"GET /user", "GET /user"},
+			want:   []conflictCase{{1, 0, "handlers are already registered for /user"}},
+		},
+		{
+			name:   "wildcard after static",
+			routes: []string{"GET /user/list", "GET /user/:id"},
+			want:   []conflictCase{{1, 0, "wildcard :id conflicts with static segment list under /user"}},
+		},
+		{
+			name:   "static after wildcard",
+			routes: []string{"GET /user/:id", "GET /user/list"},
+			want:   []conflictCase{{1, 0, "static segment list conflicts with wildcard :id under /user"}},
+		},
+		{
+			name:   "wildcards of different names",
+			routes: []string{"GET /user/:id", "GET /user/:name/items"},
+			want:   []conflictCase{{1, 0, "wildcard :name conflicts with wildcard :id of another name under /user"}},
+		},
+		{
+			name:   "catch-all not at the end",
+			routes: []string{"GET /files/*path/raw"},
+			want:   []conflictCase{{0, -1, "catch-all *path is only allowed at the end of the path under /files"}},
+		},
+		{
+			name:   "catch-all after wildcard",
+			routes: []string{"GET /files/:id", "GET /files/*path"},
+			want:   []conflictCase{{1, 0, "catch-all *path conflicts with wildcard :id under /files"}},
+		},
+		{
+			name:   "methods don't clash",
+			routes: []string{"GET /user/:id", "POST /user/list"},
+		},
+		{
+			name:   "any clashes once",
+			routes: []string{"POST /user", "Any /user"},
+			want:   []conflictCase{{1, 0, "handlers are already registered for /user"}},
+		},
+	}
+	for _, tt := range tests {
+		routes := make([]*Route, 0, len(tt.routes))
+		for _, s := range tt.routes {
+			fields := strings.SplitN(s, " ", 2)
+			routes = append(routes, &Route{Method: fields[0], Path: fields[1]})
+		}
+
+		got := make([]conflictCase, 0)
+		for _, conflict := range FindRouteConflicts(routes) {
+			got = append(got, conflictCase{indexOf(routes, conflict.Route), indexOf(routes, conflict.Existing), conflict.Reason})
+		}
+		want := tt.want
+		if want == nil {
+			want = make([]conflictCase, 0)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: FindRouteConflicts = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+type conflictCase struct {
+	route    int
+	existing int
+	reason   string
+}
+
+func indexOf(routes []*Route, route *Route) int {
+	for i := range routes {
+		if routes[i] == route {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestParseGroupAnnotation(t *testing.T) {
+	tests := []struct {
+		params      string
+		prefix      string
+		middlewares []string
+		err         string
+	}{
+		{params: "/admin", prefix: "/admin"},
+		{params: "/admin/ auth.Required", prefix: "/admin", middlewares: []string{"auth.Required"}},
+		{params: " /admin/v1 auth.Required, audit.Log(), ", prefix: "/admin/v1", middlewares: []string{"auth.Required", "audit.Log()"}},
+		{params: "/", prefix: "/"},
+		{params: "", err: "invalid @Group"},
+		{params: "admin auth.Required", err: "invalid @Group admin auth.Required"},
+	}
+	for _, tt := range tests {
+		prefix, middlewares, err := parseGroupAnnotation(tt.params)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseGroupAnnotation(%q) error = %v, want %q", tt.params, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseGroupAnnotation(%q) error = %v", tt.params, err)
+			continue
+		}
+		if prefix != tt.prefix || !reflect.DeepEqual(middlewares, tt.middlewares) {
+			t.Errorf("parseGroupAnnotation(%q) = %s %v, want %s %v", tt.params, prefix, middlewares, tt.prefix, tt.middlewares)
+		}
+	}
+}
+
+func TestRouteGroupRelativePath(t *testing.T) {
+	tests := []struct {
+		prefix string
+		path   string
+		want   string
+		ok     bool
+	}{
+		{prefix: "/admin", path: "/admin/stat", want: "/stat", ok: true},
+		{prefix: "/admin", path: "/admin", want: "", ok: true},
+		{prefix: "/admin", path: "/admin/:id/items", want: "/:id/items", ok: true},
+		{prefix: "/admin", path: "/administrator", ok: false},
+		{prefix: "/admin", path: "/user", ok: false},
+		{prefix: "/", path: "/user", want: "/user", ok: true},
+	}
+	for _, tt := range tests {
+		got, ok := (&RouteGroup{Prefix: tt.prefix}).relativePath(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("relativePath(%s) of %s = %q %t, want %q %t", tt.path, tt.prefix, got, ok, tt.want, tt.ok)
+		}
+	}
+}
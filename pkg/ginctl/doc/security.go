@@ -0,0 +1,216 @@
+package doc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/spec"
+	"github.com/mgutz/ansi"
+)
+
+// SecurityConfig declares the security schemes of a project and what the
+// middlewares of its handlers require, e.g.
+//
+//	securityDefinitions:
+//	  jwt:
+//	    type: apiKey
+//	    in: header
+//	    name: Authorization
+//	middlewares:
+//	  auth.Required:
+//	    security: [jwt]
+//	    headers:
+//	      - name: X-App-Id
+//	        description: 应用ID
+type SecurityConfig struct {
+	SecurityDefinitions spec.SecurityDefinitions  `json:"securityDefinitions"`
+	Middlewares         map[string]*MiddlewareDoc `json:"middlewares"`
+}
+
+// MiddlewareDoc is what the handlers using a middleware require.
+type MiddlewareDoc struct {
+	// Security names the security schemes, in the form of @Security.
+	Security []string     `json:"security"`
+	Headers  []*HeaderDoc `json:"headers"`
+}
+
+// HeaderDoc is a header param of an operation.
+type HeaderDoc struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    *bool  `json:"required"`
+	Description string `json:"description"`
+}
+
+// LoadSecurityConfig reads the security config of file.
+func LoadSecurityConfig(file string) (*SecurityConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(SecurityConfig)
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse security config %s failed: %v", file, err)
+	}
+	for name, middleware := range cfg.Middlewares {
+		if middleware == nil {
+			return nil, fmt.Errorf("middleware %s of %s declares nothing", name, file)
+		}
+		for _, security := range middleware.Security {
+			if _, ok := cfg.SecurityDefinitions[securityName(security)]; !ok {
+				return nil, fmt.Errorf("security %s of middleware %s is not declared in securityDefinitions of %s", security, name, file)
+			}
+		}
+		for _, header := range middleware.Headers {
+			if header.Name == "" {
+				return nil, fmt.Errorf("header of middleware %s in %s has no name", name, file)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// middleware returns the doc of the middleware named by an annotation, whose
+// arguments are ignored.
+func (cfg *SecurityConfig) middleware(name string) *MiddlewareDoc {
+	if cfg == nil {
+		return nil
+	}
+	name = strings.TrimSpace(name)
+	if middleware, ok := cfg.Middlewares[name]; ok {
+		return middleware
+	}
+	if index := strings.Index(name, "("); index != -1 {
+		return cfg.Middlewares[name[:index]]
+	}
+
+	return nil
+}
+
+// securityName trims the scopes from a security like oauth2[read, write].
+func securityName(security string) string {
+	if index := strings.Index(security, "["); index != -1 {
+		security = security[:index]
+	}
+
+	return strings.TrimSpace(security)
+}
+
+// parseHeaderAnnotation parses the params of @Header, which are
+// name [required] ["description"], the header being required by default.
+func parseHeaderAnnotation(params string) (*HeaderDoc, error) {
+	params = strings.TrimSpace(params)
+	header := new(HeaderDoc)
+	if index := strings.Index(params, "\""); index != -1 {
+		if !strings.HasSuffix(params, "\"") || index == len(params)-1 {
+			return nil, fmt.Errorf("unclosed description of @Header %s", params)
+		}
+		header.Description = params[index+1 : len(params)-1]
+		params = params[:index]
+	}
+
+	fields := strings.Fields(params)
+	switch len(fields) {
+	case 2:
+		required, err := strconv.ParseBool(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid required %s of @Header %s", fields[1], fields[0])
+		}
+		header.Required = &required
+		fallthrough
+	case 1:
+		header.Name = fields[0]
+	default:
+		return nil, fmt.Errorf("invalid @Header %s, expected name [required] [\"description\"]", params)
+	}
+
+	return header, nil
+}
+
+// securityComment returns the @Security and header @Param annotations of an
+// operation, declared by itself or by its middlewares, skipping the headers
+// already bound from the request.
+func (p *Parser) securityComment(middlewares, securities []string, headers []*HeaderDoc, params map[string]*validation) string {
+	for _, name := range middlewares {
+		if middleware := p.security.middleware(name); middleware != nil {
+			securities = append(securities, middleware.Security...)
+			headers = append(headers, middleware.Headers...)
+		}
+	}
+
+	var comment string
+	seen := make(map[string]bool)
+	for _, security := range securities {
+		if !seen[security] {
+			seen[security] = true
+			comment += fmt.Sprintf("// @Security %s\n", security)
+		}
+	}
+	for _, header := range headers {
+		if _, ok := params["header:"+header.Name]; ok || seen["header:"+header.Name] {
+			continue
+		}
+		seen["header:"+header.Name] = true
+		typ, required, desc := header.Type, true, header.Description
+		if typ == "" {
+			typ = "string"
+		}
+		if header.Required != nil {
+			required = *header.Required
+		}
+		if desc == "" {
+			desc = header.Name
+		}
+		comment += fmt.Sprintf("// @Param %s header %s %t \"%s\"\n", header.Name, typ, required, desc)
+	}
+
+	return comment
+}
+
+// applySecurity declares the configured security schemes in swagger,
+// warning about the ones operations use but nobody declares.
+func (p *Parser) applySecurity(swagger *spec.Swagger) {
+	if p.security != nil && len(p.security.SecurityDefinitions) > 0 {
+		if swagger.SecurityDefinitions == nil {
+			swagger.SecurityDefinitions = make(spec.SecurityDefinitions)
+		}
+		for name, scheme := range p.security.SecurityDefinitions {
+			if _, ok := swagger.SecurityDefinitions[name]; !ok {
+				swagger.SecurityDefinitions[name] = scheme
+			}
+		}
+	}
+
+	if swagger.Paths == nil {
+		return
+	}
+	undeclared := make(map[string]bool)
+	for _, item := range swagger.Paths.Paths {
+		for _, op := range pathOperations(&item) {
+			if op == nil {
+				continue
+			}
+			for _, requirement := range op.Security {
+				for name := range requirement {
+					if _, ok := swagger.SecurityDefinitions[name]; !ok {
+						undeclared[name] = true
+					}
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(undeclared))
+	for name := range undeclared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(ansi.Color(fmt.Sprintf("[Warn] security: %s is used but not declared in securityDefinitions", name), "yellow+b"))
+	}
+}
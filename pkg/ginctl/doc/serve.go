@@ -0,0 +1,256 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// redocAsset is the bundle of ReDoc, which /redoc requires among the assets.
+// Unlike Swagger UI it isn't generated into ginctl, so it is only served
+// from the assets dir.
+const redocAsset = "redoc.standalone.js"
+
+// reloadScript reloads the page when the doc is regenerated, and shows why
+// when regenerating fails.
+const reloadScript = `<script>
+(function () {
+  var events = new EventSource("/events");
+  events.addEventListener("reload", function () { location.reload(); });
+  events.addEventListener("failure", function (e) {
+    var banner = document.getElementById("ginctl-failure");
+    if (!banner) {
+      banner = document.createElement("pre");
+      banner.id = "ginctl-failure";
+      banner.style.cssText = "position:fixed;top:0;left:0;right:0;margin:0;padding:12px 16px;z-index:9999;background:#c0392b;color:#fff;white-space:pre-wrap;font:13px monospace";
+      document.body.appendChild(banner);
+    }
+    banner.textContent = e.data;
+  });
+})();
+</script>
+`
+
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+{{- if eq .UI "swagger"}}
+<link rel="stylesheet" href="/assets/swagger-ui.css">
+{{- end}}
+</head>
+<body style="margin:0">
+{{- if eq .UI "swagger"}}
+<div id="swagger-ui"></div>
+<script src="/assets/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "/swagger.json", dom_id: "#swagger-ui"});</script>
+{{- else}}
+<redoc spec-url="/swagger.json"></redoc>
+<script src="/assets/redoc.standalone.js"></script>
+{{- end}}
+{{.Reload}}
+</body>
+</html>
+`))
+
+// Server serves the swagger doc of a project as the static html page, with
+// Swagger UI and ReDoc, and reloads the open pages whenever the doc is
+// updated.
+type Server struct {
+	title     string
+	assetsDir string
+
+	mu      sync.RWMutex
+	doc     []byte
+	page    []byte
+	err     error
+	clients map[chan serverEvent]bool
+}
+
+type serverEvent struct {
+	name string
+	data string
+}
+
+// NewServer creates a server titled title. Swagger UI and ReDoc are loaded
+// from assetsDir, holding swagger-ui.css, swagger-ui-bundle.js and
+// redoc.standalone.js, or from the assets generated into ginctl for the
+// files assetsDir doesn't hold.
+func NewServer(title, assetsDir string) *Server {
+	return &Server{
+		title:     title,
+		assetsDir: assetsDir,
+		clients:   make(map[chan serverEvent]bool),
+	}
+}
+
+// HasReDoc reports whether /redoc is served, which requires redoc.standalone.js
+// in the assets dir.
+func (s *Server) HasReDoc() bool {
+	return hasAsset(s.assetsDir, redocAsset)
+}
+
+// Update serves doc from now on and reloads the open pages.
+func (s *Server) Update(doc SwaggerDoc) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var page bytes.Buffer
+	if err := RenderHTML(&page, doc, s.title); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.doc, s.page, s.err = b, withReload(page.Bytes()), nil
+	s.mu.Unlock()
+
+	s.broadcast(serverEvent{name: "reload", data: "reload"})
+
+	return nil
+}
+
+// Fail shows err on the open pages, which keep the last doc served.
+func (s *Server) Fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+
+	s.broadcast(serverEvent{name: "failure", data: err.Error()})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/":
+		s.serveIndex(w)
+	case r.URL.Path == "/swagger.json":
+		s.mu.RLock()
+		doc := s.doc
+		s.mu.RUnlock()
+		if doc == nil {
+			http.Error(w, "swagger doc is not generated yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(doc)
+	case r.URL.Path == "/swagger" || r.URL.Path == "/redoc":
+		s.serveUI(w, strings.TrimPrefix(r.URL.Path, "/"))
+	case r.URL.Path == "/events":
+		s.serveEvents(w, r)
+	case strings.HasPrefix(r.URL.Path, "/assets/"):
+		serveAsset(w, r, s.assetsDir, strings.TrimPrefix(r.URL.Path, "/assets/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter) {
+	s.mu.RLock()
+	page, err := s.page, s.err
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if page == nil {
+		msg := "Generating swagger doc..."
+		if err != nil {
+			msg = err.Error()
+		}
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<body>\n<pre>%s</pre>\n%s</body>\n</html>\n", template.HTMLEscapeString(msg), reloadScript)
+		return
+	}
+	w.Write(page)
+}
+
+func (s *Server) serveUI(w http.ResponseWriter, ui string) {
+	if ui == "redoc" {
+		if !s.HasReDoc() {
+			http.Error(w, fmt.Sprintf("ReDoc isn't bundled with ginctl, restart doc serve with --assets of a directory holding %s to serve it", redocAsset), http.StatusNotImplemented)
+			return
+		}
+	}
+
+	title := s.title
+	if title == "" {
+		title = "API Documentation"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := uiTemplate.Execute(w, map[string]interface{}{
+		"Title":  title,
+		"UI":     ui,
+		"Reload": template.HTML(reloadScript),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serveEvents streams server-sent events to a page until it is closed.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events := make(chan serverEvent, 1)
+	s.mu.Lock()
+	s.clients[events] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			fmt.Fprintf(w, "event: %s\n", event.name)
+			for _, line := range strings.Split(event.data, "\n") {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprint(w, "\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) broadcast(event serverEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for events := range s.clients {
+		// drop the event for pages still handling the previous one
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+func withReload(page []byte) []byte {
+	index := bytes.LastIndex(page, []byte("</body>"))
+	if index == -1 {
+		return append(page, reloadScript...)
+	}
+
+	out := make([]byte, 0, len(page)+len(reloadScript))
+	out = append(out, page[:index]...)
+	out = append(out, reloadScript...)
+	return append(out, page[index:]...)
+}
@@ -0,0 +1,282 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-season/ginctl/pkg/util"
+	"github.com/mgutz/ansi"
+)
+
+var basicTypeMap = map[string]bool{
+	"string":  true,
+	"bool":    true,
+	"byte":    true,
+	"rune":    true,
+	"int":     true,
+	"int8":    true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"uint":    true,
+	"uint8":   true,
+	"uint16":  true,
+	"uint32":  true,
+	"uint64":  true,
+	"float32": true,
+	"float64": true,
+}
+
+// typeSpecFile is a parsed go file of a typespec package.
+type typeSpecFile struct {
+	path    string
+	file    *ast.File
+	imports map[string]string
+}
+
+// typeSpecDef is a resolved type declaration together with the file it
+// was declared in, so that nested fields are resolved in the right scope.
+type typeSpecDef struct {
+	spec *ast.TypeSpec
+	dir  string
+	file *typeSpecFile
+}
+
+// key identifies the declaration by its position, which is unique across
+// all typespec files as they share one file set.
+func (def *typeSpecDef) key() token.Pos {
+	return def.spec.Type.Pos()
+}
+
+// fieldType is the swagger param type of a request field.
+type fieldType struct {
+	name string
+	// def is set when the field is a struct which fields are flattened.
+	def *typeSpecDef
+	// isJSON is set when gin binds the value from a json encoded string.
+	isJSON bool
+}
+
+func (p *Parser) moduleName() string {
+	if p.modName == "" {
+		p.modName = util.GetModuleName(p.cwd)
+	}
+
+	return p.modName
+}
+
+// parseTypeSpecImports maps the import names of astFile to the directories
+// of the typespec packages they refer to.
+func (p *Parser) parseTypeSpecImports(astFile *ast.File) map[string]string {
+	imports := map[string]string{
+		"typespec": fmt.Sprintf("%s/api/typespec", p.cwd),
+	}
+
+	modName := p.moduleName()
+	if modName == "" {
+		return imports
+	}
+	prefix := fmt.Sprintf("%s/api/typespec", modName)
+	for _, importSpec := range astFile.Imports {
+		path := strings.Trim(importSpec.Path.Value, "\"")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		imports[name] = p.cwd + strings.TrimPrefix(path, modName)
+	}
+
+	return imports
+}
+
+func (p *Parser) loadTypeSpecDir(dir string) ([]*typeSpecFile, error) {
+	if p.typeSpecLoaded != nil {
+		p.typeSpecLoaded[dir] = true
+	}
+	if files, ok := p.typeSpecDirs[dir]; ok {
+		return files, nil
+	}
+
+	pkgs, err := goparser.ParseDir(p.typeSpecFset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, goparser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*typeSpecFile, 0)
+	for _, pkg := range pkgs {
+		for path, astFile := range pkg.Files {
+			files = append(files, &typeSpecFile{
+				path:    path,
+				file:    astFile,
+				imports: p.parseTypeSpecImports(astFile),
+			})
+		}
+	}
+	p.typeSpecDirs[dir] = files
+
+	return files, nil
+}
+
+// trackTypeSpecDirs runs fn and returns the typespec dirs it loaded along
+// with go.mod, which are the dependencies of what fn derives from them.
+func (p *Parser) trackTypeSpecDirs(fn func() error) ([]string, error) {
+	outer := p.typeSpecLoaded
+	p.typeSpecLoaded = make(map[string]bool)
+	err := fn()
+	dirs := make([]string, 0, len(p.typeSpecLoaded))
+	for dir := range p.typeSpecLoaded {
+		dirs = append(dirs, dir)
+		if outer != nil {
+			outer[dir] = true
+		}
+	}
+	p.typeSpecLoaded = outer
+	sort.Strings(dirs)
+
+	return append(dirs, filepath.Join(p.cwd, "go.mod")), err
+}
+
+// findTypeDefInDir looks up typeName in every file of the package in dir.
+func (p *Parser) findTypeDefInDir(dir, typeName string) (*typeSpecDef, error) {
+	files, err := p.loadTypeSpecDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		for _, decl := range f.file.Decls {
+			if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+				for _, spec := range genDecl.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.String() == typeName {
+						return &typeSpecDef{
+							spec: typeSpec,
+							dir:  dir,
+							file: f,
+						}, nil
+					}
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("type spec %s not found in %s", typeName, strings.TrimPrefix(dir, p.cwd+"/"))
+}
+
+// resolveFieldType resolves expr declared in def to the type used by the
+// query param comment, following named types through any typespec package.
+func (p *Parser) resolveFieldType(def *typeSpecDef, expr ast.Expr) (*fieldType, error) {
+	switch typ := expr.(type) {
+	case *ast.StarExpr:
+		return p.resolveFieldType(def, typ.X)
+	case *ast.Ident, *ast.SelectorExpr:
+		if basic, ok := p.basicTypeOf(expr); ok {
+			return &fieldType{name: basic}, nil
+		}
+		sub, err := p.lookupNamedType(def, expr)
+		if err != nil {
+			return nil, err
+		}
+		return p.resolveNamedType(sub)
+	case *ast.ArrayType:
+		elt, err := p.resolveFieldType(def, typ.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if elt.name == "byte" {
+			return &fieldType{name: "string"}, nil
+		}
+		if elt.def != nil || elt.isJSON || strings.HasPrefix(elt.name, "[]") {
+			return &fieldType{name: "[]string", isJSON: true}, nil
+		}
+		return &fieldType{name: "[]" + elt.name}, nil
+	case *ast.MapType, *ast.InterfaceType:
+		return &fieldType{name: "string", isJSON: true}, nil
+	case *ast.StructType:
+		return &fieldType{def: &typeSpecDef{
+			spec: &ast.TypeSpec{Name: ast.NewIdent("anonymous"), Type: typ},
+			dir:  def.dir,
+			file: def.file,
+		}}, nil
+	default:
+		return nil, p.typeSpecError(expr, "not support field type")
+	}
+}
+
+// basicTypeOf reports the basic type of expr, mapping well known types like
+// time.Time to the type they are documented with.
+func (p *Parser) basicTypeOf(expr ast.Expr) (string, bool) {
+	switch typ := expr.(type) {
+	case *ast.Ident:
+		if basicTypeMap[typ.Name] {
+			return typ.Name, true
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := typ.X.(*ast.Ident); ok {
+			if name, ok := builtinTypeMap[pkg.Name+"."+typ.Sel.Name]; ok {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// lookupNamedType finds the declaration of the named type expr refers to.
+func (p *Parser) lookupNamedType(def *typeSpecDef, expr ast.Expr) (*typeSpecDef, error) {
+	switch typ := expr.(type) {
+	case *ast.Ident:
+		sub, err := p.findTypeDefInDir(def.dir, typ.Name)
+		if err != nil {
+			return nil, p.typeSpecError(expr, err.Error())
+		}
+		return sub, nil
+	case *ast.SelectorExpr:
+		pkg, ok := typ.X.(*ast.Ident)
+		if !ok {
+			return nil, p.typeSpecError(expr, "not support field type")
+		}
+		dir, ok := def.file.imports[pkg.Name]
+		if !ok {
+			return nil, p.typeSpecError(expr, fmt.Sprintf("un import field path %s, can't parse un import type field", pkg.Name))
+		}
+		sub, err := p.findTypeDefInDir(dir, typ.Sel.Name)
+		if err != nil {
+			return nil, p.typeSpecError(expr, err.Error())
+		}
+		return sub, nil
+	default:
+		return nil, p.typeSpecError(expr, "not support field type")
+	}
+}
+
+func (p *Parser) resolveNamedType(def *typeSpecDef) (*fieldType, error) {
+	if _, ok := def.spec.Type.(*ast.StructType); ok {
+		return &fieldType{name: def.spec.Name.Name, def: def}, nil
+	}
+
+	return p.resolveFieldType(def, def.spec.Type)
+}
+
+func (p *Parser) typeSpecError(node ast.Node, msg string) error {
+	position := p.typeSpecFset.Position(node.Pos())
+	return fmt.Errorf("%s in File: %s:%s",
+		msg,
+		ansi.Color(strings.TrimPrefix(position.Filename, p.cwd+"/"), "cyan+b"),
+		ansi.Color(strconv.Itoa(position.Line), "cyan+b"))
+}
+
+func (p *Parser) typeSpecRelPath(def *typeSpecDef) string {
+	return strings.TrimPrefix(filepath.ToSlash(def.file.path), p.cwd+"/")
+}
@@ -0,0 +1,726 @@
+package doc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-season/ginctl/pkg/util/str"
+)
+
+// validationFormats maps validator tags to the swagger format they imply.
+var validationFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ip":       "ipv4",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"datetime": "date-time",
+	"Email":    "email",
+	"IP":       "ipv4",
+}
+
+// validationPatterns maps validator tags to the pattern they check.
+var validationPatterns = map[string]string{
+	"alpha":        "^[a-zA-Z]+$",
+	"alphanum":     "^[a-zA-Z0-9]+$",
+	"numeric":      "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":       "^[0-9]+$",
+	"Alpha":        "^[a-zA-Z]+$",
+	"Numeric":      "^[0-9]+$",
+	"AlphaNumeric": "^[a-zA-Z0-9]+$",
+	"Mobile":       "^1[0-9]{10}$",
+}
+
+// validation is the constraints of a field, read from its binding and
+// validate tags (go-playground/validator) or its valid tag (beego).
+type validation struct {
+	required     bool
+	min          *float64
+	max          *float64
+	exclusiveMin bool
+	exclusiveMax bool
+	pattern      string
+	format       string
+	oneOf        []string
+	enum         *typeEnum
+}
+
+// typeEnum is the const group declared for a named type.
+type typeEnum struct {
+	values       []interface{}
+	names        []string
+	descriptions []string
+}
+
+func parseValidation(tag reflect.StructTag) *validation {
+	v := new(validation)
+	for _, key := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(tag.Get(key), ",") {
+			// rules after dive apply to the elements
+			if rule == "dive" {
+				break
+			}
+			name, param := rule, ""
+			if index := strings.Index(rule, "="); index != -1 {
+				name, param = rule[:index], rule[index+1:]
+			}
+			switch name {
+			case "required":
+				v.required = true
+			case "min", "gte":
+				v.min = parseFloat(param)
+			case "max", "lte":
+				v.max = parseFloat(param)
+			case "gt":
+				v.min, v.exclusiveMin = parseFloat(param), true
+			case "lt":
+				v.max, v.exclusiveMax = parseFloat(param), true
+			case "len":
+				v.min, v.max = parseFloat(param), parseFloat(param)
+			case "oneof":
+				v.oneOf = splitOneOf(param)
+			default:
+				v.setFormat(name)
+			}
+		}
+	}
+
+	for _, rule := range strings.Split(tag.Get("valid"), ";") {
+		rule = strings.TrimSpace(rule)
+		name, param := rule, ""
+		if index := strings.Index(rule, "("); index != -1 && strings.HasSuffix(rule, ")") {
+			name, param = rule[:index], rule[index+1:len(rule)-1]
+		}
+		switch name {
+		case "Required":
+			v.required = true
+		case "Min", "MinSize":
+			v.min = parseFloat(param)
+		case "Max", "MaxSize":
+			v.max = parseFloat(param)
+		case "Length":
+			v.min, v.max = parseFloat(param), parseFloat(param)
+		case "Range":
+			if parts := strings.Split(param, ","); len(parts) == 2 {
+				v.min, v.max = parseFloat(parts[0]), parseFloat(parts[1])
+			}
+		case "Match":
+			v.pattern = strings.TrimSuffix(strings.TrimPrefix(param, "/"), "/")
+		default:
+			v.setFormat(name)
+		}
+	}
+
+	return v
+}
+
+func (v *validation) setFormat(name string) {
+	if format, ok := validationFormats[name]; ok {
+		v.format = format
+	}
+	if pattern, ok := validationPatterns[name]; ok {
+		v.pattern = pattern
+	}
+}
+
+// splitOneOf splits the params of oneof, which may be single quoted when
+// containing spaces.
+func splitOneOf(param string) []string {
+	values := make([]string, 0)
+	for len(param) > 0 {
+		param = strings.TrimLeft(param, " ")
+		if strings.HasPrefix(param, "'") {
+			if end := strings.Index(param[1:], "'"); end != -1 {
+				values = append(values, param[1:end+1])
+				param = param[end+2:]
+				continue
+			}
+		}
+		end := strings.Index(param, " ")
+		if end == -1 {
+			end = len(param)
+		}
+		if param[:end] != "" {
+			values = append(values, param[:end])
+		}
+		param = param[end:]
+	}
+
+	return values
+}
+
+func parseFloat(s string) *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil
+	}
+
+	return &f
+}
+
+// merge fills the constraints of v the value of swagger type typ doesn't
+// declare yet into cv. min and max are lengths for strings and arrays.
+func (v *validation) merge(cv *spec.CommonValidations, typ string) {
+	switch typ {
+	case "string":
+		if v.min != nil && cv.MinLength == nil {
+			cv.MinLength = toInt64(v.min)
+		}
+		if v.max != nil && cv.MaxLength == nil {
+			cv.MaxLength = toInt64(v.max)
+		}
+	case "array":
+		if v.min != nil && cv.MinItems == nil {
+			cv.MinItems = toInt64(v.min)
+		}
+		if v.max != nil && cv.MaxItems == nil {
+			cv.MaxItems = toInt64(v.max)
+		}
+	case "integer", "number":
+		if v.min != nil && cv.Minimum == nil {
+			cv.Minimum, cv.ExclusiveMinimum = v.min, v.exclusiveMin
+		}
+		if v.max != nil && cv.Maximum == nil {
+			cv.Maximum, cv.ExclusiveMaximum = v.max, v.exclusiveMax
+		}
+	}
+	if v.pattern != "" && cv.Pattern == "" && typ == "string" {
+		cv.Pattern = v.pattern
+	}
+	if len(cv.Enum) > 0 || typ == "array" {
+		return
+	}
+	if len(v.oneOf) > 0 {
+		for _, value := range v.oneOf {
+			cv.Enum = append(cv.Enum, convertExample(goTypeOf(typ), value))
+		}
+	} else if v.enum != nil {
+		cv.Enum = append(cv.Enum, v.enum.values...)
+	}
+}
+
+// describe appends the meaning of the enum values to desc.
+func (e *typeEnum) describe(desc string) string {
+	items := make([]string, 0, len(e.values))
+	for i, value := range e.values {
+		item := fmt.Sprint(value)
+		if e.descriptions[i] != "" {
+			item += ": " + e.descriptions[i]
+		}
+		items = append(items, item)
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s (%s)", desc, strings.Join(items, ", ")))
+}
+
+func (e *typeEnum) extensions() spec.Extensions {
+	ext := spec.Extensions{}
+	ext.Add("x-enum-varnames", e.names)
+	ext.Add("x-enum-descriptions", e.descriptions)
+
+	return ext
+}
+
+func toInt64(f *float64) *int64 {
+	i := int64(*f)
+	return &i
+}
+
+func goTypeOf(typ string) string {
+	switch typ {
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	default:
+		return typ
+	}
+}
+
+// applySchema sets the constraints of v to schema of a struct property.
+func (v *validation) applySchema(schema *spec.Schema) {
+	typ := ""
+	if len(schema.Type) > 0 {
+		typ = schema.Type[0]
+	}
+	sv := schema.Validations()
+	v.merge(&sv.CommonValidations, typ)
+	schema.SetValidations(sv)
+	if v.format != "" && schema.Format == "" && typ == "string" {
+		schema.Format = v.format
+	}
+
+	if v.enum == nil {
+		return
+	}
+	target := schema
+	if typ == "array" && schema.Items != nil && schema.Items.Schema != nil {
+		target = schema.Items.Schema
+		items := target.Validations()
+		(&validation{enum: v.enum}).merge(&items.CommonValidations, firstType(target.Type))
+		target.SetValidations(items)
+	}
+	for key, value := range v.enum.extensions() {
+		target.AddExtension(key, value)
+	}
+	schema.Description = v.enum.describe(schema.Description)
+}
+
+// applyParam sets the constraints of v to a query or header param.
+func (v *validation) applyParam(param *spec.Parameter) {
+	if v.required {
+		param.Required = true
+	}
+	v.merge(&param.CommonValidations, param.Type)
+	if v.format != "" && param.Format == "" && param.Type == "string" {
+		param.Format = v.format
+	}
+
+	if v.enum == nil {
+		return
+	}
+	if param.Type == "array" && param.Items != nil {
+		(&validation{enum: v.enum}).merge(&param.Items.CommonValidations, param.Items.Type)
+	}
+	for key, value := range v.enum.extensions() {
+		param.AddExtension(key, value)
+	}
+	param.Description = v.enum.describe(param.Description)
+}
+
+func firstType(types spec.StringOrArray) string {
+	if len(types) == 0 {
+		return ""
+	}
+
+	return types[0]
+}
+
+// fieldValidation reads the constraints of field declared in def, including
+// the enum of its named type.
+func (p *Parser) fieldValidation(def *typeSpecDef, field *ast.Field) *validation {
+	var tag reflect.StructTag
+	if field.Tag != nil {
+		tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+	}
+	v := parseValidation(tag)
+	v.enum = p.fieldEnum(def, field.Type)
+
+	return v
+}
+
+func (p *Parser) fieldEnum(def *typeSpecDef, expr ast.Expr) *typeEnum {
+	switch typ := expr.(type) {
+	case *ast.StarExpr:
+		return p.fieldEnum(def, typ.X)
+	case *ast.ArrayType:
+		return p.fieldEnum(def, typ.Elt)
+	case *ast.Ident, *ast.SelectorExpr:
+		if _, ok := p.basicTypeOf(expr); ok {
+			return nil
+		}
+		sub, err := p.lookupNamedType(def, expr)
+		if err != nil {
+			return nil
+		}
+		if _, ok := sub.spec.Type.(*ast.StructType); ok {
+			return nil
+		}
+		return p.typeEnum(sub)
+	}
+
+	return nil
+}
+
+// typeEnum evaluates the consts declared with the named type def in its
+// package, following iota the way the compiler does.
+func (p *Parser) typeEnum(def *typeSpecDef) *typeEnum {
+	if enum, ok := p.typeEnums[def.key()]; ok {
+		return enum
+	}
+
+	enum := new(typeEnum)
+	files, _ := p.loadTypeSpecDir(def.dir)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	for _, f := range files {
+		for _, decl := range f.file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			var (
+				typ    ast.Expr
+				values []ast.Expr
+			)
+			for index, s := range genDecl.Specs {
+				vs := s.(*ast.ValueSpec)
+				if vs.Values != nil {
+					typ, values = vs.Type, vs.Values
+				}
+				ident, ok := typ.(*ast.Ident)
+				if !ok || ident.Name != def.spec.Name.Name {
+					continue
+				}
+				for i, name := range vs.Names {
+					if i >= len(values) || name.Name == "_" {
+						continue
+					}
+					value, ok := constValue(values[i], index)
+					if !ok {
+						continue
+					}
+					desc := strings.TrimSpace(vs.Comment.Text())
+					if desc == "" {
+						desc = strings.TrimSpace(vs.Doc.Text())
+					}
+					enum.values = append(enum.values, value)
+					enum.names = append(enum.names, name.Name)
+					enum.descriptions = append(enum.descriptions, desc)
+				}
+			}
+		}
+	}
+	if len(enum.values) == 0 {
+		enum = nil
+	}
+	p.typeEnums[def.key()] = enum
+
+	return enum
+}
+
+func constValue(expr ast.Expr, iota int) (interface{}, bool) {
+	value := evalConst(expr, iota)
+	switch value.Kind() {
+	case constant.Int:
+		if v, ok := constant.Int64Val(value); ok {
+			return v, true
+		}
+	case constant.Float:
+		v, _ := constant.Float64Val(value)
+		return v, true
+	case constant.String:
+		return constant.StringVal(value), true
+	case constant.Bool:
+		return constant.BoolVal(value), true
+	}
+
+	return nil, false
+}
+
+func evalConst(expr ast.Expr, iota int) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota))
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true")
+		}
+	case *ast.ParenExpr:
+		return evalConst(e.X, iota)
+	case *ast.UnaryExpr:
+		return constant.UnaryOp(e.Op, evalConst(e.X, iota), 0)
+	case *ast.CallExpr:
+		// conversions like Status(1)
+		if len(e.Args) == 1 {
+			return evalConst(e.Args[0], iota)
+		}
+	case *ast.BinaryExpr:
+		x, y := evalConst(e.X, iota), evalConst(e.Y, iota)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			break
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			if s, ok := constant.Uint64Val(y); ok {
+				return constant.Shift(x, e.Op, uint(s))
+			}
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO:
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		default:
+			return constant.BinaryOp(x, e.Op, y)
+		}
+	}
+
+	return constant.MakeUnknown()
+}
+
+// applyValidations completes swagger with the constraints and enums of the
+// typespec fields, which swag doesn't read.
+func (p *Parser) applyValidations(swagger *spec.Swagger) error {
+	if swagger.Paths != nil {
+		for path, item := range swagger.Paths.Paths {
+			for method, op := range pathOperations(&item) {
+				fields := p.paramValidations[strings.ToUpper(method)+" "+path]
+				if op == nil || fields == nil {
+					continue
+				}
+				for i := range op.Parameters {
+					param := &op.Parameters[i]
+					if v, ok := fields[param.In+":"+param.Name]; ok {
+						v.applyParam(param)
+					}
+				}
+			}
+			swagger.Paths.Paths[path] = item
+		}
+	}
+
+	defs, err := p.definitionDefs(swagger)
+	if err != nil {
+		return err
+	}
+	for name, def := range defs {
+		schema := swagger.Definitions[name]
+		visiting := map[token.Pos]bool{def.key(): true}
+		p.validateSchema(def, def.spec.Type.(*ast.StructType), &schema, visiting)
+		swagger.Definitions[name] = schema
+	}
+
+	return nil
+}
+
+// definitionDefs maps the definitions of swagger to the typespec structs
+// they are generated from. Swag names a definition by the package name of its
+// type, or by the import path of the package with / replaced by _ when types
+// of packages sharing a name clash. A package name shared by several typespec
+// packages, like the versions of a package, resolves to the one whose type
+// the operations refer to.
+func (p *Parser) definitionDefs(swagger *spec.Swagger) (map[string]*typeSpecDef, error) {
+	pkgDirs, err := p.typeSpecPackageDirs()
+	if err != nil {
+		return nil, err
+	}
+	pathDirs := make(map[string]string)
+	for _, dirs := range pkgDirs {
+		for _, dir := range dirs {
+			importPath := p.moduleName() + filepath.ToSlash(strings.TrimPrefix(dir, p.cwd))
+			pathDirs[strings.ReplaceAll(importPath, "/", "_")] = dir
+		}
+	}
+
+	var referenced map[token.Pos]bool
+	defs := make(map[string]*typeSpecDef)
+	for name := range swagger.Definitions {
+		index := strings.LastIndex(name, ".")
+		if index == -1 {
+			continue
+		}
+		dirs := pkgDirs[name[:index]]
+		if dir, ok := pathDirs[name[:index]]; ok {
+			dirs = []string{dir}
+		}
+
+		var found []*typeSpecDef
+		for _, dir := range dirs {
+			def, err := p.findTypeDefInDir(dir, name[index+1:])
+			if err != nil {
+				continue
+			}
+			if _, ok := def.spec.Type.(*ast.StructType); ok {
+				found = append(found, def)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		defs[name] = found[0]
+		if len(found) > 1 {
+			if referenced == nil {
+				referenced = p.referencedTypeDefs()
+			}
+			for _, def := range found {
+				if referenced[def.key()] {
+					defs[name] = def
+					break
+				}
+			}
+		}
+	}
+
+	return defs, nil
+}
+
+// referencedTypeDefs returns the typespec types the operations refer to, from
+// their Request and Response structs through their fields, keyed by position.
+func (p *Parser) referencedTypeDefs() map[token.Pos]bool {
+	referenced := make(map[token.Pos]bool)
+	var visit func(def *typeSpecDef, expr ast.Expr)
+	visit = func(def *typeSpecDef, expr ast.Expr) {
+		switch typ := expr.(type) {
+		case *ast.StarExpr:
+			visit(def, typ.X)
+		case *ast.ArrayType:
+			visit(def, typ.Elt)
+		case *ast.MapType:
+			visit(def, typ.Value)
+		case *ast.StructType:
+			for _, field := range typ.Fields.List {
+				visit(def, field.Type)
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			if _, ok := p.basicTypeOf(expr); ok {
+				return
+			}
+			sub, err := p.lookupNamedType(def, expr)
+			if err != nil || referenced[sub.key()] {
+				return
+			}
+			referenced[sub.key()] = true
+			visit(sub, sub.spec.Type)
+		}
+	}
+
+	for _, typ := range p.operationTypes {
+		for _, suffix := range []string{"Request", "Response"} {
+			def, err := p.findTypeDefInDir(typ.dir, typ.funcName+suffix)
+			if err != nil || referenced[def.key()] {
+				continue
+			}
+			referenced[def.key()] = true
+			visit(def, def.spec.Type)
+		}
+	}
+
+	return referenced
+}
+
+// validateSchema sets the constraints of the fields of st to the properties
+// of schema, named the way swag names them.
+func (p *Parser) validateSchema(def *typeSpecDef, st *ast.StructType, schema *spec.Schema, visiting map[token.Pos]bool) {
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+		if strings.EqualFold(tag.Get("swaggerignore"), "true") {
+			continue
+		}
+		name := tag.Get("json")
+		if index := strings.Index(name, ","); index != -1 {
+			name = name[:index]
+		}
+		if name == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			if name != "" {
+				continue
+			}
+			// swag flattens the fields of embedded structs
+			if sub := p.exampleStructDef(def, field.Type); sub != nil && !visiting[sub.key()] {
+				visiting[sub.key()] = true
+				p.validateSchema(sub, sub.spec.Type.(*ast.StructType), schema, visiting)
+				delete(visiting, sub.key())
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			propName := name
+			if propName == "" {
+				propName = propertyName(ident.Name, p.propNamingStrategy)
+			}
+			prop, ok := schema.Properties[propName]
+			if !ok {
+				continue
+			}
+			v := p.fieldValidation(def, field)
+			v.applySchema(&prop)
+			if v.required && !containsString(schema.Required, propName) {
+				schema.Required = append(schema.Required, propName)
+			}
+			if anonymous, ok := field.Type.(*ast.StructType); ok && prop.Properties != nil {
+				p.validateSchema(def, anonymous, &prop, visiting)
+			}
+			schema.Properties[propName] = prop
+		}
+	}
+}
+
+// typeSpecPackageDirs maps package names to the typespec directories
+// declaring them, as swag names definitions by package name.
+func (p *Parser) typeSpecPackageDirs() (map[string][]string, error) {
+	dirs := make(map[string][]string)
+	root := filepath.Join(p.cwd, "api", "typespec")
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return dirs, nil
+	}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		files, err := p.loadTypeSpecDir(path)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			name := files[0].file.Name.Name
+			dirs[name] = append(dirs[name], path)
+		}
+		return nil
+	})
+
+	return dirs, err
+}
+
+func pathOperations(item *spec.PathItem) map[string]*spec.Operation {
+	return map[string]*spec.Operation{
+		"get":     item.Get,
+		"put":     item.Put,
+		"post":    item.Post,
+		"delete":  item.Delete,
+		"options": item.Options,
+		"head":    item.Head,
+		"patch":   item.Patch,
+	}
+}
+
+func propertyName(name, propertyStrategy string) string {
+	switch propertyStrategy {
+	case SnakeCase:
+		return str.ToSnakeCase(name)
+	case PascalCase:
+		return name
+	default:
+		return str.ToLowerCamelCase(name)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+package doc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want *validation
+	}{
+		{tag: ``, want: &validation{}},
+		{tag: `binding:"required"`, want: &validation{required: true}},
+		{tag: `binding:"min=1,max=20"`, want: &validation{min: float(1), max: float(20)}},
+		{tag: `validate:"gte=1,lte=100"`, want: &validation{min: float(1), max: float(100)}},
+		{tag: `binding:"gt=0,lt=10"`, want: &validation{min: float(0), exclusiveMin: true, max: float(10), exclusiveMax: true}},
+		{tag: `binding:"required,len=6,numeric"`, want: &validation{required: true, min: float(6), max: float(6), pattern: validationPatterns["numeric"]}},
+		{tag: `binding:"email"`, want: &validation{format: "email"}},
+		{tag: `binding:"oneof=red 'light blue' green"`, want: &validation{oneOf: []string{"red", "light blue", "green"}}},
+		// rules after dive apply to the elements
+		{tag: `binding:"max=3,dive,max=5"`, want: &validation{max: float(3)}},
+		{tag: `valid:"Required; Range(1, 10)"`, want: &validation{required: true, min: float(1), max: float(10)}},
+		{tag: `valid:"MaxSize(20);Match(/^a+$/)"`, want: &validation{max: float(20), pattern: "^a+$"}},
+		{tag: `valid:"Mobile"`, want: &validation{pattern: validationPatterns["Mobile"]}},
+	}
+	for _, tt := range tests {
+		if got := parseValidation(tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValidation(%s) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestTypeEnum(t *testing.T) {
+	cwd := writeTypeSpecs(t, map[string]string{
+		"api/typespec/usertype/status.go": `package usertype
+
+type Status int
+
+const (
+	StatusNone Status = iota // 无
+	StatusActive             // 正常
+	_
+	// 禁用
+	StatusBanned
+)
+
+type Level string
+
+const (
+	LevelLow  Level = "low"
+	LevelHigh Level = "high"
+)
+
+const Other = 1
+
+type Name string
+`,
+	})
+	defer os.RemoveAll(cwd)
+
+	tests := []struct {
+		typeName string
+		want     *typeEnum
+	}{
+		{
+			typeName: "Status",
+			want: &typeEnum{
+				values:       []interface{}{int64(0), int64(1), int64(3)},
+				names:        []string{"StatusNone", "StatusActive", "StatusBanned"},
+				descriptions: []string{"无", "正常", "禁用"},
+			},
+		},
+		{
+			typeName: "Level",
+			want: &typeEnum{
+				values:       []interface{}{"low", "high"},
+				names:        []string{"LevelLow", "LevelHigh"},
+				descriptions: []string{"", ""},
+			},
+		},
+		{typeName: "Name"},
+	}
+	p := NewParser(WithWorkDir(cwd))
+	for _, tt := range tests {
+		def, err := p.findTypeDefInDir(filepath.Join(cwd, "api/typespec/usertype"), tt.typeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.typeEnum(def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("typeEnum(%s) = %+v, want %+v", tt.typeName, got, tt.want)
+		}
+	}
+}
+
+func TestDefinitionDefs(t *testing.T) {
+	cwd := writeTypeSpecs(t, map[string]string{
+		"go.mod": "module demo\n",
+		"api/typespec/usertype/user.go": `package usertype
+
+type GetUserResponse struct{}
+
+type Tag struct{}
+`,
+		"api/typespec/v2/usertype/user.go": `package usertype
+
+type GetUserResponse struct {
+	Tags []Tag
+}
+
+type Tag struct{}
+`,
+		"api/typespec/ordertype/order.go": `package ordertype
+
+type Order struct{}
+`,
+	})
+	defer os.RemoveAll(cwd)
+
+	v1 := filepath.Join(cwd, "api/typespec/usertype")
+	v2 := filepath.Join(cwd, "api/typespec/v2/usertype")
+	p := NewParser(WithWorkDir(cwd))
+	p.operationTypes["GET /v2/user"] = &operationType{dir: v2, funcName: "GetUser"}
+	swagger := &spec.Swagger{SwaggerProps: spec.SwaggerProps{Definitions: spec.Definitions{
+		"demo_api_typespec_usertype.GetUserResponse":    {},
+		"demo_api_typespec_v2_usertype.GetUserResponse": {},
+		// only the Tag of v2 is referred to, so swag keeps its name
+		"usertype.Tag":    {},
+		"ordertype.Order": {},
+		"usertype.Absent": {},
+	}}}
+
+	defs, err := p.definitionDefs(swagger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"demo_api_typespec_usertype.GetUserResponse":    v1,
+		"demo_api_typespec_v2_usertype.GetUserResponse": v2,
+		"usertype.Tag":    v2,
+		"ordertype.Order": filepath.Join(cwd, "api/typespec/ordertype"),
+	}
+	got := make(map[string]string)
+	for name, def := range defs {
+		got[name] = def.dir
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("definitionDefs = %v, want %v", got, want)
+	}
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+// writeTypeSpecs writes files into a temporary directory, which is returned.
+func writeTypeSpecs(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ginctl-doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
@@ -0,0 +1,142 @@
+package doc
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var (
+	versionRegexp    = regexp.MustCompile(`^v[0-9]+[A-Za-z0-9]*$`)
+	versionDirRegexp = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// FileVersion returns the API version of the handlers in a rest file, which
+// is declared by @Version in its package comment, or else in the one of
+// doc.go of its package, or else by the vN directory it is in under
+// api/rest, e.g. api/rest/v2/user. It is empty for unversioned handlers.
+func FileVersion(path string) (string, error) {
+	for _, file := range []string{path, filepath.Join(filepath.Dir(path), "doc.go")} {
+		version, err := declaredVersion(file)
+		if err != nil || version != "" {
+			return version, err
+		}
+	}
+
+	dir := filepath.ToSlash(filepath.Dir(path))
+	index := strings.LastIndex(dir, "api/rest/")
+	if index == -1 {
+		return "", nil
+	}
+	for _, segment := range strings.Split(dir[index+len("api/rest/"):], "/") {
+		if versionDirRegexp.MatchString(segment) {
+			return segment, nil
+		}
+	}
+
+	return "", nil
+}
+
+func declaredVersion(file string) (string, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if astFile.Doc == nil {
+		return "", nil
+	}
+
+	var version string
+	for _, comment := range astFile.Doc.List {
+		commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
+		fields := strings.Fields(commentLine)
+		if len(fields) == 0 || strings.ToLower(fields[0]) != "@version" {
+			continue
+		}
+		if len(fields) != 2 || !versionRegexp.MatchString(fields[1]) {
+			return "", fmt.Errorf("%s: invalid @Version %s, expected a version like v1", fset.Position(comment.Pos()), strings.Join(fields[1:], " "))
+		}
+		if version != "" {
+			return "", fmt.Errorf("%s: duplicate @Version", fset.Position(comment.Pos()))
+		}
+		version = fields[1]
+	}
+
+	return version, nil
+}
+
+// restPackageName returns the name a rest package is referred to by in the
+// generated routes, prefixed by its version directory when it is in one so
+// that the same package of versions don't clash, e.g. v2user.
+func restPackageName(pkgPath string) string {
+	name := pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+	if index := strings.LastIndex(pkgPath, "api/rest/"); index != -1 {
+		for _, segment := range strings.Split(pkgPath[index+len("api/rest/"):], "/") {
+			if versionDirRegexp.MatchString(segment) && segment != name {
+				return segment + name
+			}
+		}
+	}
+
+	return name
+}
+
+// VersionPath returns path served by version.
+func VersionPath(version, path string) string {
+	if version == "" {
+		return path
+	}
+
+	return "/" + version + path
+}
+
+// writeVersionSwaggers writes the spec of each version into its directory
+// of outputDir, with only the operations of the version.
+func (p *Parser) writeVersionSwaggers(swagger *spec.Swagger, outputDir string) error {
+	versions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, version := range p.operationVersions {
+		if version != "" && !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		if p.audienceNames()[version] {
+			return fmt.Errorf("audience %s clashes with the version of the same name", version)
+		}
+
+		sub, err := p.filterSwagger(swagger, func(key string) bool {
+			return p.operationVersions[key] == version
+		})
+		if err != nil {
+			return err
+		}
+		sub.Info.Version = version
+
+		dir := filepath.Join(outputDir, version)
+		if err := writeSpec(sub, dir); err != nil {
+			return err
+		}
+		log.Printf("create %s spec at %+v", version, dir)
+	}
+
+	audiences := p.audienceNames()
+	return removeStaleSpecs(outputDir, func(name string) bool {
+		return versionRegexp.MatchString(name) && !seen[name] && !audiences[name]
+	})
+}
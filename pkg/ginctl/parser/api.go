@@ -7,6 +7,8 @@ import (
 	"go/token"
 	"path/filepath"
 	"strings"
+
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
 )
 
 type ApiParser struct {
@@ -41,31 +43,29 @@ func (p *ApiParser) Parse(file string) error {
 }
 
 func (p *ApiParser) parseAPI(file string) error {
-	fileTree, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ParseComments)
+	fset := token.NewFileSet()
+	fileTree, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
 	if err != nil {
 		return err
 	}
 	apiDecls := make(map[string]*ApiDecl)
 	for _, decl := range fileTree.Decls {
-		apiDecl := new(ApiDecl)
 		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			if funcDecl.Doc != nil {
-				comments := funcDecl.Doc.List
-				for _, comment := range comments {
-					commentLine := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-					if !strings.Contains(commentLine, "@") {
-						continue
-					}
-					fields := strings.Fields(commentLine)
-					attribute := fields[0]
-					lowerAttribute := strings.ToLower(attribute)
-					if lowerAttribute == "@router" {
-						apiDecl.Path = fields[1]
-						apiDecl.Method = strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-						apiDecl.Action = funcDecl.Name.String()
-						apiDecls[apiDecl.Action] = apiDecl
-					}
-				}
+			handler, err := annotation.ParseHandler(fset, funcDecl)
+			if err != nil {
+				return err
+			}
+			if handler == nil {
+				continue
+			}
+			if len(handler.Routes) > 1 {
+				return fmt.Errorf("%s: handler %s has more than one @Router, which an api decl can't hold", fset.Position(handler.Routes[1].Pos), handler.Name)
+			}
+			route := handler.Routes[0]
+			apiDecls[handler.Name] = &ApiDecl{
+				Action: handler.Name,
+				Path:   route.Path,
+				Method: strings.Join(route.Methods, ","),
 			}
 		}
 	}
@@ -0,0 +1,140 @@
+package publish
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+const (
+	defaultGitMessage = "update {{.App}} api doc"
+	defaultGitRemote  = "origin"
+	defaultGitAuthor  = "ginctl"
+)
+
+// filePublisher writes the doc to a local file, and commits it when the
+// file is in a git repository and git is configured.
+type filePublisher struct {
+	cfg  Config
+	opts *options
+}
+
+func newFilePublisher(cfg Config, o *options) (*filePublisher, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("%s: path is not configured", cfg.Name)
+	}
+	if cfg.Git != nil {
+		if cfg.Git.Message == "" {
+			cfg.Git.Message = defaultGitMessage
+		}
+		if cfg.Git.Remote == "" {
+			cfg.Git.Remote = defaultGitRemote
+		}
+		if cfg.Git.Author == "" {
+			cfg.Git.Author = defaultGitAuthor
+		}
+	}
+
+	return &filePublisher{cfg: cfg, opts: o}, nil
+}
+
+func (p *filePublisher) Name() string {
+	return p.cfg.Name
+}
+
+func (p *filePublisher) Payload(doc []byte) (*Payload, error) {
+	path, err := render("path", p.cfg.Path, newTemplateData(p.cfg, p.opts, doc))
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.opts.workDir, path)
+	}
+
+	payload := &Payload{Target: path, Body: doc}
+	if p.cfg.Git != nil {
+		payload.Secrets = []string{p.cfg.Git.Password}
+	}
+
+	return payload, nil
+}
+
+func (p *filePublisher) Publish(payload *Payload) error {
+	if err := os.MkdirAll(filepath.Dir(payload.Target), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(payload.Target, payload.Body, 0644); err != nil {
+		return err
+	}
+	if p.cfg.Git == nil {
+		return nil
+	}
+
+	return p.commit(payload)
+}
+
+func (p *filePublisher) commit(payload *Payload) error {
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(payload.Target), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("%s: open git repository of %s failed: %v", p.cfg.Name, payload.Target, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(wt.Filesystem.Root(), payload.Target)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Add(filepath.ToSlash(rel)); err != nil {
+		return err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return err
+	}
+	if fs, ok := status[filepath.ToSlash(rel)]; !ok || fs.Staging == git.Unmodified {
+		if p.opts.log != nil {
+			p.opts.log.Infof("%s is up to date, nothing to commit", rel)
+		}
+		return nil
+	}
+
+	message, err := render("message", p.cfg.Git.Message, newTemplateData(p.cfg, p.opts, payload.Body))
+	if err != nil {
+		return err
+	}
+	_, err = wt.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  p.cfg.Git.Author,
+			Email: p.cfg.Git.Email,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !p.cfg.Git.Push {
+		return nil
+	}
+
+	opts := &git.PushOptions{RemoteName: p.cfg.Git.Remote}
+	if p.cfg.Git.Username != "" || p.cfg.Git.Password != "" {
+		opts.Auth = &http.BasicAuth{
+			Username: p.cfg.Git.Username,
+			Password: p.cfg.Git.Password,
+		}
+	}
+	if err := repo.Push(opts); err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	return nil
+}
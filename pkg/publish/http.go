@@ -0,0 +1,85 @@
+package publish
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultHTTPBody = "{{.Doc}}"
+
+// httpPublisher sends the doc to a generic http endpoint, with the url,
+// headers and body rendered as templates.
+type httpPublisher struct {
+	cfg    Config
+	opts   *options
+	client *http.Client
+}
+
+func newHTTPPublisher(cfg Config, o *options) (*httpPublisher, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%s: url is not configured", cfg.Name)
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Body == "" {
+		cfg.Body = defaultHTTPBody
+	}
+	client, err := newHTTPClient(cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpPublisher{cfg: cfg, opts: o, client: client}, nil
+}
+
+func (p *httpPublisher) Name() string {
+	return p.cfg.Name
+}
+
+func (p *httpPublisher) Payload(doc []byte) (*Payload, error) {
+	data := newTemplateData(p.cfg, p.opts, doc)
+
+	url, err := render("url", p.cfg.URL, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := render("body", p.cfg.Body, data)
+	if err != nil {
+		return nil, err
+	}
+	payload := &Payload{
+		Target:  url,
+		Method:  p.cfg.Method,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    []byte(body),
+	}
+	for key, value := range p.cfg.Headers {
+		if payload.Headers[key], err = render("header", value, data); err != nil {
+			return nil, err
+		}
+		if isSecretHeader(key) {
+			payload.Secrets = append(payload.Secrets, payload.Headers[key])
+		}
+	}
+
+	return payload, nil
+}
+
+func (p *httpPublisher) Publish(payload *Payload) error {
+	_, err := send(p.client, payload)
+	return err
+}
+
+func isSecretHeader(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"authorization", "token", "secret", "key", "cookie"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,310 @@
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/go-season/ginctl/pkg/util"
+	"github.com/go-season/ginctl/pkg/util/log"
+)
+
+const (
+	TypeYapi = "yapi"
+	TypeHTTP = "http"
+	TypeFile = "file"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// secretMask replaces the secrets of a printed payload.
+const secretMask = "******"
+
+// Payload is what a publisher sends for a doc, printed instead of sent when
+// dry running.
+type Payload struct {
+	// Target is the url or file the doc is published to.
+	Target  string
+	Method  string
+	Headers map[string]string
+	Body    []byte
+	// Secrets are masked when the payload is printed.
+	Secrets []string
+}
+
+// String prints payload like a http request with its secrets masked.
+func (p *Payload) String() string {
+	var buf bytes.Buffer
+	if p.Method != "" {
+		buf.WriteString(p.Method + " ")
+	}
+	buf.WriteString(p.Target + "\n")
+
+	keys := make([]string, 0, len(p.Headers))
+	for key := range p.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		buf.WriteString(fmt.Sprintf("%s: %s\n", key, p.Headers[key]))
+	}
+	buf.WriteString("\n")
+	buf.Write(p.Body)
+	buf.WriteString("\n")
+
+	out := buf.String()
+	for _, secret := range p.Secrets {
+		if secret != "" {
+			out = strings.Replace(out, secret, secretMask, -1)
+		}
+	}
+
+	return out
+}
+
+// Publisher publishes the swagger doc of a project somewhere.
+type Publisher interface {
+	Name() string
+	// Payload builds what is published for doc.
+	Payload(doc []byte) (*Payload, error)
+	// Publish sends payload built by Payload.
+	Publish(payload *Payload) error
+}
+
+// Config configures a publisher, values may refer to environment variables
+// like ${YAPI_TOKEN} so credentials are kept out of the config.
+type Config struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Spec is the audience whose spec is published, the full spec when
+	// empty.
+	Spec string `yaml:"spec"`
+
+	// yapi
+	Server string `yaml:"server"`
+	Token  string `yaml:"token"`
+	Merge  string `yaml:"merge"`
+	// Consul is the consul server the yapi token is read from when no token
+	// is configured.
+	Consul string `yaml:"consul"`
+
+	// http
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	// Body is a text/template of the request body, {{.Doc}} by default.
+	Body    string `yaml:"body"`
+	Timeout string `yaml:"timeout"`
+
+	// file
+	Path string     `yaml:"path"`
+	Git  *GitConfig `yaml:"git"`
+}
+
+// GitConfig commits, and optionally pushes, the published file in the git
+// repository containing it.
+type GitConfig struct {
+	Message  string `yaml:"message"`
+	Push     bool   `yaml:"push"`
+	Remote   string `yaml:"remote"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Author   string `yaml:"author"`
+	Email    string `yaml:"email"`
+}
+
+type configFile struct {
+	Publishers []Config `yaml:"publishers"`
+}
+
+// LoadConfig reads the publishers of file, expanding the environment
+// variables in the credentials, servers and headers. Templates and bodies
+// are kept as they are, so that their $ aren't taken as variables.
+func LoadConfig(file string) ([]Config, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg configFile
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("parse publish config %s failed: %v", file, err)
+	}
+	if len(cfg.Publishers) == 0 {
+		return nil, fmt.Errorf("no publisher configured in %s", file)
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Publishers {
+		c := &cfg.Publishers[i]
+		if c.Name == "" {
+			c.Name = c.Type
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate publisher %s in %s, please name them", c.Name, file)
+		}
+		names[c.Name] = true
+		c.expandEnv()
+	}
+
+	return cfg.Publishers, nil
+}
+
+// expandEnv expands the environment variables in the values of c which may
+// refer to them.
+func (c *Config) expandEnv() {
+	for _, value := range []*string{&c.Server, &c.Token, &c.Consul, &c.URL} {
+		*value = os.ExpandEnv(*value)
+	}
+	for key, value := range c.Headers {
+		c.Headers[key] = os.ExpandEnv(value)
+	}
+	if c.Git != nil {
+		for _, value := range []*string{&c.Git.Remote, &c.Git.Username, &c.Git.Password} {
+			*value = os.ExpandEnv(*value)
+		}
+	}
+}
+
+type options struct {
+	log     log.Logger
+	workDir string
+	// dryRun builds payloads only to print them, without the secrets kept
+	// in remote services like consul.
+	dryRun bool
+}
+
+type Option func(*options)
+
+func WithLogger(log log.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+func WithWorkDir(dir string) Option {
+	return func(o *options) {
+		o.workDir = dir
+	}
+}
+
+func WithDryRun(dryRun bool) Option {
+	return func(o *options) {
+		o.dryRun = dryRun
+	}
+}
+
+// New creates the publisher of cfg.Type.
+func New(cfg Config, opts ...Option) (Publisher, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.workDir == "" {
+		o.workDir, _ = os.Getwd()
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+
+	switch cfg.Type {
+	case TypeYapi:
+		return newYapiPublisher(cfg, o)
+	case TypeHTTP:
+		return newHTTPPublisher(cfg, o)
+	case TypeFile:
+		return newFilePublisher(cfg, o)
+	default:
+		return nil, fmt.Errorf("not supported publisher type %s of %s", cfg.Type, cfg.Name)
+	}
+}
+
+// templateData is what url, path, body and message templates are rendered
+// with.
+type templateData struct {
+	Name   string
+	Spec   string
+	Module string
+	App    string
+	Doc    string
+}
+
+func newTemplateData(cfg Config, o *options, doc []byte) *templateData {
+	module := util.GetModuleName(o.workDir)
+	return &templateData{
+		Name:   cfg.Name,
+		Spec:   cfg.Spec,
+		Module: module,
+		App:    module[strings.LastIndex(module, "/")+1:],
+		Doc:    string(doc),
+	}
+}
+
+func render(name, text string, data *templateData) (string, error) {
+	tpl, err := template.New(name).Funcs(template.FuncMap{
+		// json quotes s as a json string
+		"json": func(s string) (string, error) {
+			b, err := json.Marshal(s)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template failed: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s template failed: %v", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+func newHTTPClient(timeout string) (*http.Client, error) {
+	d := defaultTimeout
+	if timeout != "" {
+		var err error
+		if d, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %v", timeout, err)
+		}
+	}
+
+	return &http.Client{Timeout: d}, nil
+}
+
+// send does the http request of payload and returns the response body,
+// failing on non 2xx status.
+func send(client *http.Client, payload *Payload) ([]byte, error) {
+	req, err := http.NewRequest(payload.Method, payload.Target, bytes.NewReader(payload.Body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range payload.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s failed, code: %d, body: %s", payload.Method, payload.Target, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
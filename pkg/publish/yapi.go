@@ -0,0 +1,156 @@
+package publish
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-season/ginctl/pkg/util"
+)
+
+const (
+	yapiRoute                   = "/api/open/import_data"
+	defaultYapiMerge            = "normal"
+	defaultYapiTokenPathPattern = "/v1/kv/yapi/%s/token"
+)
+
+// YapiResp is the response of the yapi import api.
+type YapiResp struct {
+	ErrCode int         `json:"errcode"`
+	ErrMsg  string      `json:"errmsg"`
+	Data    interface{} `json:"data"`
+}
+
+type yapiToken struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// yapiPublisher imports the doc into a yapi project, the token defaults to
+// YAPI_TOKEN and is read from consul when configured.
+type yapiPublisher struct {
+	cfg    Config
+	opts   *options
+	client *http.Client
+}
+
+func newYapiPublisher(cfg Config, o *options) (*yapiPublisher, error) {
+	if cfg.Server == "" {
+		cfg.Server = os.Getenv("YAPI_SERVER")
+	}
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("%s: yapi server is not configured", cfg.Name)
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("YAPI_TOKEN")
+	}
+	if cfg.Merge == "" {
+		cfg.Merge = defaultYapiMerge
+	}
+	client, err := newHTTPClient(cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &yapiPublisher{cfg: cfg, opts: o, client: client}, nil
+}
+
+func (p *yapiPublisher) Name() string {
+	return p.cfg.Name
+}
+
+func (p *yapiPublisher) Payload(doc []byte) (*Payload, error) {
+	token := p.cfg.Token
+	if token == "" && p.cfg.Consul != "" {
+		if p.opts.dryRun {
+			// the token would be printed masked, so consul isn't asked for it
+			token = secretMask
+		} else {
+			var err error
+			if token, err = p.loadTokenFromConsul(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%s: yapi token is not configured, please set token or YAPI_TOKEN", p.cfg.Name)
+	}
+
+	params := struct {
+		Type  string `json:"type"`
+		Token string `json:"token"`
+		Json  string `json:"json"`
+		Merge string `json:"merge"`
+	}{
+		Type:  "swagger",
+		Token: token,
+		Json:  string(doc),
+		Merge: p.cfg.Merge,
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payload{
+		Target:  strings.TrimSuffix(p.cfg.Server, "/") + yapiRoute,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    body,
+		Secrets: []string{token},
+	}, nil
+}
+
+func (p *yapiPublisher) Publish(payload *Payload) error {
+	body, err := send(p.client, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp YapiResp
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%s: import doc to yapi failed: %s", p.cfg.Name, resp.ErrMsg)
+	}
+	if p.opts.log != nil {
+		p.opts.log.Info(resp.ErrMsg)
+	}
+
+	return nil
+}
+
+func (p *yapiPublisher) loadTokenFromConsul() (string, error) {
+	modName := util.GetModuleName(p.opts.workDir)
+	if modName == "" {
+		return "", errors.New("un identify mod name, please check mod is correct")
+	}
+
+	path := fmt.Sprintf(defaultYapiTokenPathPattern, modName)
+	resp, err := p.client.Get(strings.TrimSuffix(p.cfg.Consul, "/") + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var data []yapiToken
+	if err := json.Unmarshal(content, &data); err != nil || len(data) == 0 {
+		return "", errors.New("not found token config in consul")
+	}
+	token, err := base64.StdEncoding.DecodeString(data[0].Value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
@@ -12,9 +12,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/go-season/ginctl/pkg/ginctl/annotation"
+	"github.com/go-season/ginctl/pkg/ginctl/cache"
+	"github.com/go-season/ginctl/pkg/ginctl/doc"
 	"github.com/go-season/ginctl/pkg/util"
 	"github.com/go-season/ginctl/pkg/util/file"
 	"github.com/go-season/ginctl/pkg/util/log"
@@ -26,6 +30,10 @@ const GinctlV100ReleaseTime = "g202105261512"
 const TabIndentWith4Space = "    "
 const TabIndentWith8Space = "        "
 
+// CallOptionPackage is the package of the options of the calls of the
+// services, placed beside the client.
+const CallOptionPackage = "callopt"
+
 type Generator struct {
 	*bytes.Buffer
 
@@ -36,6 +44,9 @@ type Generator struct {
 	isOld        bool
 	FileName     string
 	APIName      string
+	// Version is the API version of the handlers, whose clients are
+	// placed in the package of the version, e.g. v2/user.
+	Version string
 
 	APIDecls map[string]*APIDecl
 
@@ -45,6 +56,12 @@ type Generator struct {
 
 	Log log.Logger
 
+	cache *cache.Cache
+
+	// needPathPkg is whether the path of a method has params, which are
+	// formatted by fmt and escaped by net/url.
+	needPathPkg bool
+
 	Constants []*Constant
 	ImportMap map[string]string
 }
@@ -52,8 +69,25 @@ type Generator struct {
 type APIDecl struct {
 	method string
 	path   string
+	// pathExpr is the expression of the path requested, with the params
+	// substituted by the uri fields of the request.
+	pathExpr    string
+	description string
+	accept      string
+}
+
+// queryMethods are the methods whose requests are sent in the query, the
+// others being sent in the JSON body.
+var queryMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
 }
 
+// tagRegexp matches a key:"value" pair of a struct tag.
+var tagRegexp = regexp.MustCompile(`(\w+):"((?:[^"\\]|\\.)*)"`)
+
 type Field struct {
 	Name         string
 	Type         string
@@ -121,6 +155,13 @@ func WithWorkDir(dir string) Option {
 	}
 }
 
+// WithParseCache reads the handlers of unchanged files from the parse cache.
+func WithParseCache(c *cache.Cache) Option {
+	return func(g *Generator) {
+		g.cache = c
+	}
+}
+
 func WithOld(old bool) Option {
 	return func(g *Generator) {
 		g.isOld = old
@@ -141,16 +182,36 @@ func (g *Generator) Parse(file string) error {
 	return g.parseAPI(file)
 }
 
+// parseType parses the types and constants of the typespec file, which are
+// read from the parse cache while the file is unchanged.
 func (g *Generator) parseType(file string) error {
+	g.FileName = strings.TrimSuffix(filepath.Base(file), ".go")
+	g.APIName = strings.Title(str.SnakeToCamel(g.FileName))
+
+	// the imports of the typespec package are converted by the publish mode
+	key := fmt.Sprintf("%s:%t:%t", file, g.isPublish, g.isOld)
+	entry := new(typeSpecEntry)
+	if g.cache.Get("typespec", key, entry) {
+		entry.restore(g)
+		return nil
+	}
+	if err := g.parseTypeFile(file); err != nil {
+		return err
+	}
+	// the cache only saves parsing next time, so failing to store the
+	// types doesn't fail parsing them
+	_ = g.cache.Put("typespec", key, []string{file, filepath.Join(g.wd, "go.mod")}, newTypeSpecEntry(g))
+
+	return nil
+}
+
+func (g *Generator) parseTypeFile(file string) error {
 	fset := token.NewFileSet()
 	fileTree, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
 	if err != nil {
 		return err
 	}
 
-	g.FileName = strings.TrimSuffix(filepath.Base(file), ".go")
-	g.APIName = strings.Title(str.SnakeToCamel(g.FileName))
-
 	for _, importSpec := range fileTree.Imports {
 		name := importSpec.Name.String()
 		if importSpec.Name == nil {
@@ -194,7 +255,13 @@ func (g *Generator) parseType(file string) error {
 							}
 						case *ast.BinaryExpr:
 							expr := vs.Values[0].(*ast.BinaryExpr)
-							lv := expr.X.(*ast.BasicLit).Value
+							var lv string
+							switch expr.X.(type) {
+							case *ast.Ident:
+								lv = expr.X.(*ast.Ident).String()
+							case *ast.BasicLit:
+								lv = expr.X.(*ast.BasicLit).Value
+							}
 							op := expr.Op.String()
 							var rv string
 							switch expr.Y.(type) {
@@ -277,37 +344,127 @@ func (g *Generator) parseType(file string) error {
 func (g *Generator) parseAPI(file string) error {
 	apiFile := specToAPIPath(file)
 	fset := token.NewFileSet()
-	fileTree, err := parser.ParseFile(fset, apiFile, nil, parser.ParseComments)
+	fileTree, err := g.cache.ParseFile(fset, apiFile)
+	if err != nil {
+		return err
+	}
+	g.Version, err = doc.FileVersion(apiFile)
 	if err != nil {
 		return err
 	}
 
 	for _, decl := range fileTree.Decls {
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			for _, doc := range funcDecl.Doc.List {
-				commentLine := strings.TrimSpace(strings.TrimLeft(doc.Text, "//"))
-				fields := strings.Fields(commentLine)
-				attribute := fields[0]
-				lowerAttribute := strings.ToLower(attribute)
-				if lowerAttribute == "@router" {
-					routePath := fields[1]
-					method := strings.TrimRight(strings.TrimLeft(fields[2], "["), "]")
-					if method == http.MethodGet && !g.needQueryPkg {
-						g.needQueryPkg = true
-					}
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		handler, err := annotation.ParseHandler(fset, funcDecl)
+		if err != nil {
+			return err
+		}
+		if handler == nil {
+			continue
+		}
 
-					g.APIDecls[funcDecl.Name.String()] = &APIDecl{
-						method: method,
-						path:   routePath,
-					}
-				}
-			}
+		route, method, err := handler.SingleRoute(fset)
+		if err != nil {
+			return err
+		}
+		routePath := doc.VersionPath(g.Version, route.Path)
+		// routes registered on every method are requested by GET
+		if method == annotation.MethodAny {
+			method = http.MethodGet
+		}
+		if queryMethods[method] && !g.needQueryPkg {
+			g.needQueryPkg = true
+		}
+
+		pathExpr, err := g.pathExpr(routePath, handler.Name+"Request")
+		if err != nil {
+			return fmt.Errorf("%s: %v", fset.Position(route.Pos), err)
+		}
+		g.APIDecls[handler.Name] = &APIDecl{
+			method:      method,
+			path:        routePath,
+			pathExpr:    pathExpr,
+			description: handler.Description,
+			accept:      handler.Accept,
 		}
 	}
 
 	return nil
 }
 
+// pathExpr returns the expression of path requested by the method of the
+// request typeName, substituting the params of path with the fields of the
+// request whose uri tag is the param, e.g. "/user/" + url.PathEscape(fmt.Sprint(req.ID))
+// of /user/:id. A catch-all param is substituted as is, including its
+// leading slash, the way gin binds it.
+func (g *Generator) pathExpr(path, typeName string) (string, error) {
+	if !strings.ContainsAny(path, ":*") {
+		return strconv.Quote(path), nil
+	}
+
+	uriFields := make(map[string]string)
+	for _, decl := range g.RequestDecls {
+		if decl.Name != typeName {
+			continue
+		}
+		for _, field := range decl.Fields {
+			if uri := fieldURI(field); uri != "" && field.Name != "" {
+				uriFields[uri] = field.Name
+			}
+		}
+	}
+
+	var (
+		exprs   []string
+		literal string
+	)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if i > 0 {
+			literal += "/"
+		}
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			literal += segment
+			continue
+		}
+		fieldName, ok := uriFields[segment[1:]]
+		if !ok {
+			return "", fmt.Errorf("param %s of path %s has no field with uri:%s in %s", segment, path, strconv.Quote(segment[1:]), typeName)
+		}
+		if strings.HasPrefix(segment, "*") {
+			literal = strings.TrimSuffix(literal, "/")
+		}
+		if literal != "" {
+			exprs = append(exprs, strconv.Quote(literal))
+			literal = ""
+		}
+		if strings.HasPrefix(segment, "*") {
+			exprs = append(exprs, fmt.Sprintf("fmt.Sprint(req.%s)", fieldName))
+		} else {
+			exprs = append(exprs, fmt.Sprintf("url.PathEscape(fmt.Sprint(req.%s))", fieldName))
+		}
+	}
+	if literal != "" {
+		exprs = append(exprs, strconv.Quote(literal))
+	}
+	g.needPathPkg = true
+
+	return strings.Join(exprs, " + "), nil
+}
+
+// fieldURI returns the uri tag of field, which binds it to a path param.
+func fieldURI(field *Field) string {
+	uri := reflect.StructTag(strings.Trim(field.Tag, "`")).Get("uri")
+	if index := strings.Index(uri, ","); index != -1 {
+		uri = uri[:index]
+	}
+
+	return uri
+}
+
 func specToAPIPath(path string) string {
 	prefix := strings.TrimSuffix(filepath.Dir(path), "type")
 
@@ -494,9 +651,20 @@ func (g *Generator) checkAPIDef(fset *token.FileSet) bool {
 	return valid
 }
 
+// PackagePath returns the path of the client package relative to the sdk of
+// the project.
+func (g *Generator) PackagePath() string {
+	pkg := strings.Replace(g.FileName, "_", "", -1)
+	if g.Version != "" {
+		return g.Version + "/" + pkg
+	}
+
+	return pkg
+}
+
 func (g *Generator) GenGO() {
 	g.generateSpec()
-	dir := fmt.Sprintf("%s/sdk/%s/%s", g.wd, strings.Replace(util.GetModeBaseName(g.wd), "-", "", -1), strings.Replace(g.FileName, "_", "", -1))
+	dir := fmt.Sprintf("%s/sdk/%s/%s", g.wd, strings.Replace(util.GetModeBaseName(g.wd), "-", "", -1), g.PackagePath())
 	if ok, _ := file.PathExists(dir); !ok {
 		os.MkdirAll(dir, 0755)
 	}
@@ -663,11 +831,17 @@ func (g *Generator) generateClientMethod() {
 	g.P()
 	g.P("package ", strings.Replace(g.FileName, "_", "", -1))
 	g.P("import (")
+	g.P(strconv.Quote("context"))
+	if g.needPathPkg {
+		g.P(strconv.Quote("fmt"))
+		g.P(strconv.Quote("net/url"))
+	}
 	if g.needQueryPkg {
 		g.P(strconv.Quote("github.com/google/go-querystring/query"))
 	}
 	g.P(strconv.Quote("github.com/go-season/common/client"))
 	g.P(strconv.Quote("gitlab.idc.xiaozhu.com/xz/lib/component/xzapi"))
+	g.P(strconv.Quote(fmt.Sprintf("%s/%s", getServicePackagePrefix(g.isPublish, g.isOld), CallOptionPackage)))
 	g.P(")")
 	g.P()
 
@@ -678,7 +852,8 @@ func (g *Generator) generateClientMethod() {
 		if apidecl == nil {
 			continue
 		}
-		g.P(fmt.Sprintf("%s(req *%s) (%s, error)", funcName, decl.Name, g.ResponseDecls[i].Name))
+		g.P(fmt.Sprintf("%s(ctx context.Context, req *%s, opts ...%s.CallOption) (%s, error)",
+			funcName, decl.Name, CallOptionPackage, g.ResponseDecls[i].Name))
 	}
 	g.P("}")
 	g.P()
@@ -702,24 +877,33 @@ func (g *Generator) generateClientMethod() {
 		if apidecl == nil {
 			continue
 		}
-		g.P(fmt.Sprintf("func (%s *%sService) %s(req *%s) (%s, error) {",
-			short, str.ToLowerCamelCase(g.APIName), funcName, decl.Name, g.ResponseDecls[i].Name))
+		g.P(fmt.Sprintf("func (%s *%sService) %s(ctx context.Context, req *%s, opts ...%s.CallOption) (%s, error) {",
+			short, str.ToLowerCamelCase(g.APIName), funcName, decl.Name, CallOptionPackage, g.ResponseDecls[i].Name))
 		g.P("var (")
 		g.P("resp ", g.ResponseDecls[i].Name)
 		g.P("err error")
 		g.P(")")
 		g.P()
-		if apidecl.method == http.MethodGet {
+		// the call runs on a copy of the client carrying ctx, so that calls
+		// sharing the service don't share their contexts. The names don't
+		// shadow the receiver, which is the initial of the service.
+		g.P(fmt.Sprintf("callOpts := %s.New(opts...)", CallOptionPackage))
+		g.P("ctx, cancel := callOpts.Context(ctx)")
+		g.P("defer cancel()")
+		g.P(fmt.Sprintf("cli := %s.Client", short))
+		g.P("cli.Ctx = ctx")
+		g.P()
+		if queryMethods[apidecl.method] {
 			g.P("val, _ := query.Values(req)")
 		}
-		g.P(fmt.Sprintf("_, err = %s.ClientWithParseContent(&resp).%s(%s, client.Options{",
-			short, strings.Title(strings.ToLower(apidecl.method)), strconv.Quote(apidecl.path)))
-		if apidecl.method == http.MethodGet {
+		g.P(fmt.Sprintf("_, err = cli.ClientWithParseContent(&resp).%s(%s, callOpts.Options(client.Options{",
+			strings.Title(strings.ToLower(apidecl.method)), apidecl.pathExpr))
+		if queryMethods[apidecl.method] {
 			g.P("Query: val.Encode(),")
 		} else {
 			g.P("JSON: req,")
 		}
-		g.P("})")
+		g.P("}))")
 		g.P()
 		g.P("return resp, err")
 		g.P("}")
@@ -817,7 +1001,10 @@ func (g *Generator) generateStruct(fields []*Field) {
 	for _, field := range fields {
 		tag := field.Tag
 		structTag := reflect.StructTag(strings.Trim(tag, "`"))
-		if ft := structTag.Get("form"); ft != "" {
+		if uri := fieldURI(field); uri != "" {
+			// path params are neither in the query nor in the body
+			tag = pathParamTag(tag)
+		} else if ft := structTag.Get("form"); ft != "" {
 			tag = fmt.Sprintf("`%s %s`", strings.Trim(tag, "`"), fmt.Sprintf("url:%s", strconv.Quote(ft)))
 		}
 		if field.Name == "" {
@@ -832,6 +1019,22 @@ func (g *Generator) generateStruct(fields []*Field) {
 	}
 }
 
+// pathParamTag returns the tag of a path param field, which is tag without
+// its json, form and url keys, excluding it from the query and the body.
+func pathParamTag(tag string) string {
+	pairs := make([]string, 0)
+	for _, match := range tagRegexp.FindAllStringSubmatch(strings.Trim(tag, "`"), -1) {
+		switch match[1] {
+		case "json", "form", "url":
+		default:
+			pairs = append(pairs, match[0])
+		}
+	}
+	pairs = append(pairs, `json:"-"`, `url:"-"`)
+
+	return "`" + strings.Join(pairs, " ") + "`"
+}
+
 func (g *Generator) P(str ...string) {
 	for _, v := range str {
 		g.WriteString(v)
@@ -874,15 +1077,26 @@ func GenerateClientFactory(pkgList, serviceList []string, output string, isPubli
 	g.P("import (")
 	g.P(strconv.Quote("context"))
 	g.P(strconv.Quote("gitlab.idc.xiaozhu.com/xz/lib/component/xzapi"))
-	for _, pkg := range pkgList {
+	// packages of versions are referred to by their version and name, e.g.
+	// v2user, and so are their services, e.g. UserV2Service
+	pkgNames := make([]string, len(pkgList))
+	methodNames := make([]string, len(pkgList))
+	for i, pkg := range pkgList {
+		pkgNames[i] = strings.Replace(pkg, "/", "", -1)
+		methodNames[i] = serviceList[i]
+		if index := strings.LastIndex(pkg, "/"); index != -1 {
+			methodNames[i] += strings.Title(pkg[:index])
+			g.P(pkgNames[i], " ", strconv.Quote(fmt.Sprintf("%s/%s", prefix, pkg)))
+			continue
+		}
 		g.P(strconv.Quote(fmt.Sprintf("%s/%s", prefix, pkg)))
 	}
 	g.P(")")
 	g.P()
 
 	g.P("type Client interface {")
-	for i, service := range serviceList {
-		g.P(fmt.Sprintf("%sService() %s.Service", service, pkgList[i]))
+	for i := range serviceList {
+		g.P(fmt.Sprintf("%sService() %s.Service", methodNames[i], pkgNames[i]))
 	}
 	g.P("}")
 	g.P()
@@ -920,8 +1134,8 @@ func GenerateClientFactory(pkgList, serviceList []string, output string, isPubli
 	g.P()
 
 	for i, s := range serviceList {
-		g.P(fmt.Sprintf("func (c *client) %sService() %s.Service {", s, pkgList[i]))
-		g.P(fmt.Sprintf("return %s.New%sService(c.Client)", pkgList[i], s))
+		g.P(fmt.Sprintf("func (c *client) %sService() %s.Service {", methodNames[i], pkgNames[i]))
+		g.P(fmt.Sprintf("return %s.New%sService(c.Client)", pkgNames[i], s))
 		g.P("}")
 		g.P()
 	}
@@ -931,6 +1145,115 @@ func GenerateClientFactory(pkgList, serviceList []string, output string, isPubli
 	g.generateFile(clientPath)
 }
 
+// GenerateCallOptions generates the package of the options every method of
+// the services takes per call, shared by the services so that callers pass
+// the same options to all of them.
+func GenerateCallOptions(output string) error {
+	g := NewGenerator()
+
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	g.P("// Package ", CallOptionPackage, " is the options of a call of the services, e.g.")
+	g.P("//")
+	g.P("//\tresp, err := c.UserService().GetUser(ctx, req, ", CallOptionPackage, ".WithTimeout(time.Second))")
+	g.P("package ", CallOptionPackage)
+	g.P()
+	g.P("import (")
+	g.P(strconv.Quote("context"))
+	g.P(strconv.Quote("time"))
+	g.P()
+	g.P(strconv.Quote("github.com/go-season/common/client"))
+	g.P(")")
+	g.P()
+
+	g.P("// CallOptions are the options of a call.")
+	g.P("type CallOptions struct {")
+	g.P("Headers map[string]string")
+	g.P("// Timeout is the timeout of the call, which is unlimited when 0 besides")
+	g.P("// the deadline of its ctx.")
+	g.P("Timeout time.Duration")
+	g.P("// Retry overrides the retries of the client when not nil.")
+	g.P("Retry *int")
+	g.P("}")
+	g.P()
+	g.P("type CallOption func(o *CallOptions)")
+	g.P()
+
+	g.P("// WithHeader sets the header key of the request to value.")
+	g.P("func WithHeader(key, value string) CallOption {")
+	g.P("return func(o *CallOptions) {")
+	g.P("if o.Headers == nil {")
+	g.P("o.Headers = make(map[string]string)")
+	g.P("}")
+	g.P("o.Headers[key] = value")
+	g.P("}")
+	g.P("}")
+	g.P()
+	g.P("// WithTimeout cancels the call after timeout.")
+	g.P("func WithTimeout(timeout time.Duration) CallOption {")
+	g.P("return func(o *CallOptions) {")
+	g.P("o.Timeout = timeout")
+	g.P("}")
+	g.P("}")
+	g.P()
+	g.P("// WithRetry retries the call up to retry times instead of the retries of")
+	g.P("// the client.")
+	g.P("func WithRetry(retry int) CallOption {")
+	g.P("return func(o *CallOptions) {")
+	g.P("o.Retry = &retry")
+	g.P("}")
+	g.P("}")
+	g.P()
+
+	g.P("// New returns the options of a call made of opts.")
+	g.P("func New(opts ...CallOption) *CallOptions {")
+	g.P("o := new(CallOptions)")
+	g.P("for _, opt := range opts {")
+	g.P("opt(o)")
+	g.P("}")
+	g.P()
+	g.P("return o")
+	g.P("}")
+	g.P()
+	g.P("// Context returns the context the call runs with, derived from ctx, and")
+	g.P("// the func releasing it once the call returns.")
+	g.P("func (o *CallOptions) Context(ctx context.Context) (context.Context, context.CancelFunc) {")
+	g.P("if ctx == nil {")
+	g.P("ctx = context.Background()")
+	g.P("}")
+	g.P("if o.Timeout > 0 {")
+	g.P("return context.WithTimeout(ctx, o.Timeout)")
+	g.P("}")
+	g.P()
+	g.P("return ctx, func() {}")
+	g.P("}")
+	g.P()
+	g.P("// Options returns the client options of the request of the call, which")
+	g.P("// are opt with the headers and retries of the call.")
+	g.P("func (o *CallOptions) Options(opt client.Options) client.Options {")
+	g.P("if len(o.Headers) > 0 {")
+	g.P("if opt.Headers == nil {")
+	g.P("opt.Headers = make(map[string]string, len(o.Headers))")
+	g.P("}")
+	g.P("for key, value := range o.Headers {")
+	g.P("opt.Headers[key] = value")
+	g.P("}")
+	g.P("}")
+	g.P("if o.Retry != nil {")
+	g.P("opt.Retry = *o.Retry")
+	g.P("}")
+	g.P()
+	g.P("return opt")
+	g.P("}")
+
+	dir := fmt.Sprintf("%s/%s", output, CallOptionPackage)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return g.generateFile(fmt.Sprintf("%s/%s.go", dir, CallOptionPackage))
+}
+
 func getServicePackagePrefix(isPublish bool, isOld bool) string {
 	wd, _ := os.Getwd()
 	basename := util.GetModeBaseName(wd)
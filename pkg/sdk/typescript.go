@@ -0,0 +1,927 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-season/ginctl/pkg/util/log"
+)
+
+// LangGo and LangTypeScript are the languages of the sdk generated.
+const (
+	LangGo         = "go"
+	LangTypeScript = "ts"
+)
+
+// TSClientFile, TSBaseFile and TSIndexFile are the modules of the TypeScript
+// sdk besides the ones of the rest files, which are the client sending the
+// requests, the types of api/typespec/base.go and the entry of the sdk.
+const (
+	TSClientFile = "client"
+	TSBaseFile   = "base"
+	TSIndexFile  = "index"
+)
+
+var tsIdentRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsBasicTypes maps the basic types of go to the ones of TypeScript.
+var tsBasicTypes = map[string]string{
+	"string":      "string",
+	"bool":        "boolean",
+	"byte":        "number",
+	"rune":        "number",
+	"int":         "number",
+	"int8":        "number",
+	"int16":       "number",
+	"int32":       "number",
+	"int64":       "number",
+	"uint":        "number",
+	"uint8":       "number",
+	"uint16":      "number",
+	"uint32":      "number",
+	"uint64":      "number",
+	"float32":     "number",
+	"float64":     "number",
+	"interface{}": "any",
+	"any":         "any",
+	// time.Time is marshaled as a RFC 3339 string
+	"time.Time": "string",
+}
+
+// tsField is a field of a TypeScript interface.
+type tsField struct {
+	name     string
+	optional bool
+	// header and uri are the tags of the fields sent as a header or a path
+	// param instead of in the query or the body.
+	header string
+	uri    string
+}
+
+// GenerateTSClient generates the client of the TypeScript sdk, which sends
+// the requests of the services by a pluggable transport and unwraps the
+// content of the response of httppkg.
+func GenerateTSClient(output string) error {
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(output, TSClientFile+".ts"), tsClientTpl(), 0644)
+}
+
+// GenerateTSBase generates the types of api/typespec/base.go of the
+// TypeScript sdk, which the types of the rest files may refer to.
+func GenerateTSBase(path, output, dir string, log log.Logger) error {
+	g := NewGenerator(WithLogger(log), WithWorkDir(dir))
+	if err := g.parseType(path); err != nil {
+		return err
+	}
+
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	g.generateTSSpec()
+
+	return g.writeTSFile(filepath.Join(output, TSBaseFile+".ts"))
+}
+
+// GenTS generates the module of the rest file parsed into the TypeScript
+// sdk, which has the types of the file and its service.
+func (g *Generator) GenTS(output string) error {
+	// the imports depend on what the service uses
+	g.Reset()
+	g.generateTSSpec()
+	usesOmit := g.generateTSService()
+	body := g.String()
+
+	g.Reset()
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	prefix := g.tsImportPrefix()
+	// types are imported apart, which transpilers of isolated modules erase
+	imports := "HTTPClient"
+	if usesOmit {
+		imports += ", omit"
+	}
+	g.P(fmt.Sprintf("import type { CallOptions } from '%s%s';", prefix, TSClientFile))
+	g.P(fmt.Sprintf("import { %s } from '%s%s';", imports, prefix, TSClientFile))
+	if g.tsUsesBase() {
+		g.P(fmt.Sprintf("import type * as base from '%s%s';", prefix, TSBaseFile))
+	}
+	g.P()
+	g.WriteString(body)
+
+	return g.writeTSFile(filepath.Join(output, g.PackagePath()+".ts"))
+}
+
+// GenerateTSIndex generates the entry of the TypeScript sdk, whose
+// createClient returns the services of the packages, like NewClient of the
+// go sdk.
+func GenerateTSIndex(pkgList, serviceList []string, output string) error {
+	g := NewGenerator()
+
+	g.P("// Code generated by ginctl. DO NOT EDIT.")
+	g.P()
+	g.P(fmt.Sprintf("import type { ClientOptions } from './%s';", TSClientFile))
+	g.P(fmt.Sprintf("import { HTTPClient } from './%s';", TSClientFile))
+	classNames := make([]string, len(pkgList))
+	methodNames := make([]string, len(pkgList))
+	for i, pkg := range pkgList {
+		classNames[i] = serviceList[i] + "Service"
+		methodNames[i] = serviceList[i]
+		if index := strings.LastIndex(pkg, "/"); index != -1 {
+			// services of versions are named apart by their version, e.g.
+			// V2UserService and userV2Service
+			alias := strings.Title(strings.Replace(pkg[:index], "/", "", -1)) + classNames[i]
+			methodNames[i] += strings.Title(pkg[:index])
+			g.P(fmt.Sprintf("import { %s as %s } from './%s';", classNames[i], alias, pkg))
+			classNames[i] = alias
+			continue
+		}
+		g.P(fmt.Sprintf("import { %s } from './%s';", classNames[i], pkg))
+	}
+	g.P()
+	g.P(fmt.Sprintf("export * from './%s';", TSClientFile))
+	g.P()
+
+	g.P("export interface Client {")
+	for i := range pkgList {
+		g.P(fmt.Sprintf("  %sService(): %s;", lowerFirst(methodNames[i]), classNames[i]))
+	}
+	g.P("}")
+	g.P()
+
+	g.P("export function createClient(options: ClientOptions): Client {")
+	g.P("  const client = new HTTPClient(options);")
+	g.P()
+	g.P("  return {")
+	for i := range pkgList {
+		g.P(fmt.Sprintf("    %sService: () => new %s(client),", lowerFirst(methodNames[i]), classNames[i]))
+	}
+	g.P("  };")
+	g.P("}")
+
+	return g.writeTSFile(filepath.Join(output, TSIndexFile+".ts"))
+}
+
+func (g *Generator) writeTSFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, g.Bytes(), 0644)
+}
+
+// tsImportPrefix returns the relative path of the root of the sdk from the
+// module of the rest file.
+func (g *Generator) tsImportPrefix() string {
+	depth := strings.Count(g.PackagePath(), "/")
+	if depth == 0 {
+		return "./"
+	}
+
+	return strings.Repeat("../", depth)
+}
+
+// tsUsesBase reports whether the types of the rest file refer to the ones of
+// api/typespec/base.go.
+func (g *Generator) tsUsesBase() bool {
+	var uses func(fields []*Field) bool
+	uses = func(fields []*Field) bool {
+		for _, field := range fields {
+			if strings.Contains(field.Type, GinctlV100ReleaseTime+".") || uses(field.StructFields) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, decls := range [][]*StructType{g.GeneralDecls, g.RequestDecls, g.ResponseDecls} {
+		for _, decl := range decls {
+			if uses(decl.Fields) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (g *Generator) generateTSSpec() {
+	g.generateTSConstant()
+
+	for _, decl := range g.GeneralDecls {
+		g.generateTSInterface(decl, "json")
+	}
+
+	for i, decl := range g.RequestDecls {
+		g.generateTSInterface(decl, g.requestTagKey(decl))
+		if i < len(g.ResponseDecls) {
+			g.generateTSInterface(g.ResponseDecls[i], "json")
+		}
+	}
+}
+
+// requestTagKey returns the key of the tags naming the fields of the
+// request decl, which is form when it's sent in the query or a form body.
+func (g *Generator) requestTagKey(decl *StructType) string {
+	apidecl := g.APIDecls[strings.TrimSuffix(decl.Name, "Request")]
+	if apidecl == nil || tsEncoding(apidecl) == "json" {
+		return "json"
+	}
+
+	return "form"
+}
+
+// tsEncoding returns how the request of apidecl is sent, which is query,
+// form or json.
+func tsEncoding(apidecl *APIDecl) string {
+	if queryMethods[apidecl.method] {
+		return "query"
+	}
+	if strings.Contains(apidecl.accept, "x-www-form-urlencoded") {
+		return "form"
+	}
+
+	return "json"
+}
+
+// generateTSConstant generates the constants, whose iota groups are typed by
+// the union of their values, named by the common prefix of their names,
+// e.g. OrderStatus of OrderStatusPaid and OrderStatusCanceled. A union
+// whose name is taken by an interface or another union is left out.
+func (g *Generator) generateTSConstant() {
+	interfaces := g.tsInterfaceNames()
+	unions := make(map[string]string)
+	module := g.PackagePath()
+	if module == "" {
+		module = TSBaseFile
+	}
+	for _, cnst := range g.Constants {
+		if len(cnst.Group) == 0 {
+			if cnst.Name == "" {
+				continue
+			}
+			value := tsLiteral(cnst.Value.value)
+			if cnst.Value.isBinary {
+				value = fmt.Sprintf("%s %s %s", tsLiteral(cnst.Value.lv), cnst.Value.op, tsLiteral(cnst.Value.rv))
+			}
+			g.P(fmt.Sprintf("export const %s = %s;", cnst.Name, value))
+			g.P()
+			continue
+		}
+
+		names := make([]string, 0, len(cnst.Group))
+		for i, name := range cnst.Group {
+			if name == "_" {
+				continue
+			}
+			g.P(fmt.Sprintf("export const %s = %s;", name, tsIotaValue(cnst.Value, i)))
+			names = append(names, name)
+		}
+		if union := commonPrefix(names); union != "" {
+			if interfaces[union] {
+				g.Log.Warnf("union type %s of %s clashes with the interface of the same name in %s.ts, left out", union, strings.Join(names, ", "), module)
+				g.P()
+				continue
+			}
+			if group, ok := unions[union]; ok {
+				g.Log.Warnf("union type %s of %s clashes with the one of %s in %s.ts, left out", union, strings.Join(names, ", "), group, module)
+				g.P()
+				continue
+			}
+			unions[union] = strings.Join(names, ", ")
+			types := make([]string, len(names))
+			for i, name := range names {
+				types[i] = "typeof " + name
+			}
+			g.P(fmt.Sprintf("export type %s = %s;", union, strings.Join(types, " | ")))
+		}
+		g.P()
+	}
+}
+
+// tsInterfaceNames returns the names of the interfaces generated, which the
+// union types must not take.
+func (g *Generator) tsInterfaceNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, decls := range [][]*StructType{g.GeneralDecls, g.RequestDecls, g.ResponseDecls} {
+		for _, decl := range decls {
+			names[decl.Name] = true
+		}
+	}
+
+	return names
+}
+
+func (g *Generator) generateTSInterface(decl *StructType, tagKey string) {
+	extends := make([]string, 0)
+	for _, field := range decl.Fields {
+		if field.Name == "" && !field.isStruct {
+			// embedded types of external packages are opaque, which can't
+			// be extended
+			if typ := tsType(strings.TrimPrefix(field.Type, "*")); typ != "any" {
+				extends = append(extends, typ)
+			}
+		}
+	}
+
+	if len(extends) > 0 {
+		g.P(fmt.Sprintf("export interface %s extends %s {", decl.Name, strings.Join(extends, ", ")))
+	} else {
+		g.P(fmt.Sprintf("export interface %s {", decl.Name))
+	}
+	g.generateTSFields(decl.Fields, tagKey, "  ")
+	g.P("}")
+	g.P()
+}
+
+func (g *Generator) generateTSFields(fields []*Field, tagKey, indent string) {
+	for _, field := range fields {
+		f, ok := newTSField(field, tagKey)
+		if !ok {
+			continue
+		}
+		if comment := strings.TrimSpace(field.Comment); comment != "" {
+			g.P(indent, "/** ", strings.Replace(comment, "\n", " ", -1), " */")
+		}
+		key := tsKey(f.name)
+		if f.optional {
+			key += "?"
+		}
+		if !field.isStruct {
+			g.P(indent, key, ": ", tsType(field.Type), ";")
+			continue
+		}
+		g.P(indent, key, ": {")
+		g.generateTSFields(field.StructFields, tagKey, indent+"  ")
+		if field.Type == "[]" {
+			g.P(indent, "}[];")
+		} else {
+			g.P(indent, "};")
+		}
+	}
+}
+
+// generateTSService generates the service of the rest file, whose methods
+// send the requests the way gin binds them: uri fields in the path, header
+// fields in the headers and the others in the query or the body. It reports
+// whether the methods omit the fields of the path and the headers by omit.
+func (g *Generator) generateTSService() bool {
+	var usesOmit bool
+	g.P(fmt.Sprintf("export class %sService {", g.APIName))
+	g.P("  private readonly client: HTTPClient;")
+	g.P()
+	g.P("  constructor(client: HTTPClient) {")
+	g.P("    this.client = client;")
+	g.P("  }")
+
+	for i, decl := range g.RequestDecls {
+		funcName := strings.TrimSuffix(decl.Name, "Request")
+		apidecl := g.APIDecls[funcName]
+		if apidecl == nil || i >= len(g.ResponseDecls) {
+			continue
+		}
+		tagKey := g.requestTagKey(decl)
+
+		var (
+			uris    = make(map[string]string)
+			headers = make([]string, 0)
+			omitted = make([]string, 0)
+		)
+		for _, field := range decl.Fields {
+			f, ok := newTSField(field, tagKey)
+			if !ok {
+				continue
+			}
+			if f.uri != "" {
+				uris[f.uri] = f.name
+				omitted = append(omitted, tsString(f.name))
+			} else if f.header != "" {
+				headers = append(headers, fmt.Sprintf("%s: %s", tsKey(f.header), tsAccess("req", f.name)))
+				omitted = append(omitted, tsString(f.name))
+			}
+		}
+
+		g.P()
+		if apidecl.description != "" {
+			g.P("  /** ", apidecl.description, " */")
+		}
+		g.P(fmt.Sprintf("  %s(req: %s, options?: CallOptions): Promise<%s> {",
+			lowerFirst(funcName), decl.Name, g.ResponseDecls[i].Name))
+		g.P(fmt.Sprintf("    return this.client.request<%s>({", g.ResponseDecls[i].Name))
+		g.P(fmt.Sprintf("      method: '%s',", apidecl.method))
+		g.P(fmt.Sprintf("      path: %s,", tsPathExpr(apidecl.path, uris)))
+		if len(headers) > 0 {
+			g.P(fmt.Sprintf("      headers: { %s },", strings.Join(headers, ", ")))
+		}
+		payload := "req"
+		if len(omitted) > 0 {
+			usesOmit = true
+			payload = fmt.Sprintf("omit(req, [%s])", strings.Join(omitted, ", "))
+		}
+		g.P(fmt.Sprintf("      %s: %s,", tsEncoding(apidecl), payload))
+		g.P("    }, options);")
+		g.P("  }")
+	}
+
+	g.P("}")
+
+	return usesOmit
+}
+
+// newTSField returns the field of the interface of field, named by its tag
+// of tagKey, or by its json tag and name as the binding of gin falls back
+// to, which is false when the field is ignored or embedded.
+func newTSField(field *Field, tagKey string) (*tsField, bool) {
+	if field.Name == "" && !field.isStruct {
+		return nil, false
+	}
+
+	tag := reflect.StructTag(strings.Trim(field.Tag, "`"))
+	f := &tsField{
+		name:     field.Name,
+		optional: strings.HasPrefix(field.Type, "*"),
+		header:   tag.Get("header"),
+		uri:      fieldURI(field),
+	}
+	for _, key := range []string{tagKey, "json"} {
+		value, ok := tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(value, ",")
+		if parts[0] == "-" {
+			return nil, false
+		}
+		if parts[0] != "" {
+			f.name = parts[0]
+		}
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				f.optional = true
+			}
+		}
+		break
+	}
+	if f.uri != "" {
+		f.name = f.uri
+	}
+
+	return f, true
+}
+
+// tsType returns the TypeScript type of the go type typ of a field, e.g.
+// Record<string, number[]> of map[string][]int.
+func tsType(typ string) string {
+	if t, ok := tsBasicTypes[typ]; ok {
+		return t
+	}
+
+	switch {
+	case strings.HasPrefix(typ, "*"):
+		return tsType(typ[1:])
+	case strings.HasPrefix(typ, "[]"):
+		elem := tsType(typ[2:])
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case strings.HasPrefix(typ, "map["):
+		index := strings.Index(typ, "]")
+		if index == -1 {
+			return "any"
+		}
+		key := "string"
+		if tsType(typ[4:index]) == "number" {
+			key = "number"
+		}
+		return fmt.Sprintf("Record<%s, %s>", key, tsType(typ[index+1:]))
+	case strings.Contains(typ, "."):
+		parts := strings.SplitN(typ, ".", 2)
+		if parts[0] == GinctlV100ReleaseTime {
+			return "base." + parts[1]
+		}
+		// types of external packages are opaque to the sdk
+		return "any"
+	}
+
+	return typ
+}
+
+// tsPathExpr returns the expression of path, substituting its params with
+// the fields of req named by uris. A catch-all param is substituted as is,
+// including its leading slash, the way gin binds it.
+func tsPathExpr(path string, uris map[string]string) string {
+	var (
+		exprs   []string
+		literal string
+	)
+	for i, segment := range strings.Split(path, "/") {
+		if i > 0 {
+			literal += "/"
+		}
+		name, ok := uris[strings.TrimLeft(segment, ":*")]
+		if !ok || (!strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*")) {
+			literal += segment
+			continue
+		}
+		if strings.HasPrefix(segment, "*") {
+			literal = strings.TrimSuffix(literal, "/")
+		}
+		if literal != "" {
+			exprs = append(exprs, tsString(literal))
+			literal = ""
+		}
+		if strings.HasPrefix(segment, "*") {
+			exprs = append(exprs, fmt.Sprintf("String(%s)", tsAccess("req", name)))
+		} else {
+			exprs = append(exprs, fmt.Sprintf("encodeURIComponent(String(%s))", tsAccess("req", name)))
+		}
+	}
+	if literal != "" {
+		exprs = append(exprs, tsString(literal))
+	}
+
+	return strings.Join(exprs, " + ")
+}
+
+// tsIotaValue returns the value of the i-th constant of an iota group,
+// which is evaluated when the value is an integer expression of iota.
+func tsIotaValue(value ConstantValue, i int) string {
+	iota := strconv.Itoa(i)
+	if !value.isBinary {
+		if value.value == "iota" {
+			return iota
+		}
+		return tsLiteral(value.value)
+	}
+
+	lv, rv := value.lv, value.rv
+	if lv == "iota" {
+		lv = iota
+	}
+	if rv == "iota" {
+		rv = iota
+	}
+	l, lerr := strconv.ParseInt(tsLiteral(lv), 0, 64)
+	r, rerr := strconv.ParseInt(tsLiteral(rv), 0, 64)
+	if lerr == nil && rerr == nil {
+		switch value.op {
+		case "+":
+			return strconv.FormatInt(l+r, 10)
+		case "-":
+			return strconv.FormatInt(l-r, 10)
+		case "*":
+			return strconv.FormatInt(l*r, 10)
+		case "<<":
+			return strconv.FormatInt(l<<uint(r), 10)
+		case "|":
+			return strconv.FormatInt(l|r, 10)
+		}
+	}
+
+	return fmt.Sprintf("%s %s %s", tsLiteral(lv), value.op, tsLiteral(rv))
+}
+
+// tsLiteral returns the TypeScript literal of the go literal lit, e.g. 493
+// of the octal 0755, which TypeScript doesn't allow.
+func tsLiteral(lit string) string {
+	switch {
+	case strings.HasPrefix(lit, "\"") || strings.HasPrefix(lit, "`"):
+		if s, err := strconv.Unquote(lit); err == nil {
+			return tsString(s)
+		}
+	case strings.HasPrefix(lit, "'"):
+		if s, err := strconv.Unquote(lit); err == nil {
+			return strconv.Itoa(int([]rune(s)[0]))
+		}
+	default:
+		if n, err := strconv.ParseInt(strings.Replace(lit, "_", "", -1), 0, 64); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+	}
+
+	return lit
+}
+
+// tsString returns the single quoted TypeScript string literal of s.
+func tsString(s string) string {
+	quoted := strconv.Quote(s)
+	quoted = strings.Replace(quoted[1:len(quoted)-1], `\"`, `"`, -1)
+
+	return "'" + strings.Replace(quoted, "'", `\'`, -1) + "'"
+}
+
+// tsKey returns the key of a property of an interface, which is quoted when
+// it isn't an identifier, e.g. 'user-id'.
+func tsKey(name string) string {
+	if tsIdentRegexp.MatchString(name) {
+		return name
+	}
+
+	return tsString(name)
+}
+
+// tsAccess returns the expression of the property name of v.
+func tsAccess(v, name string) string {
+	if tsIdentRegexp.MatchString(name) {
+		return v + "." + name
+	}
+
+	return fmt.Sprintf("%s[%s]", v, tsString(name))
+}
+
+// commonPrefix returns the common words of the camel case names, which is
+// empty when they have none or it's one of the names.
+func commonPrefix(names []string) string {
+	if len(names) < 2 {
+		return ""
+	}
+
+	prefix := camelWords(names[0])
+	for _, name := range names[1:] {
+		words := camelWords(name)
+		n := 0
+		for n < len(prefix) && n < len(words) && prefix[n] == words[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	union := strings.Join(prefix, "")
+	for _, name := range names {
+		if name == union {
+			return ""
+		}
+	}
+
+	return union
+}
+
+// camelWords splits the camel case name into its words, e.g. Order, Status
+// and Paid of OrderStatusPaid.
+func camelWords(name string) []string {
+	words := make([]string, 0)
+	var word []rune
+	for _, r := range name {
+		if unicode.IsUpper(r) && len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0:0]
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func tsClientTpl() []byte {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by ginctl. DO NOT EDIT.\n\n")
+	b.WriteString(tsClientSource)
+
+	return b.Bytes()
+}
+
+const tsClientSource = `export type Method = 'GET' | 'POST' | 'PUT' | 'PATCH' | 'DELETE' | 'HEAD' | 'OPTIONS';
+
+export interface TransportRequest {
+  method: Method;
+  url: string;
+  headers: Record<string, string>;
+  body?: string;
+  /** timeout in milliseconds */
+  timeout?: number;
+  signal?: AbortSignal;
+}
+
+export interface TransportResponse {
+  status: number;
+  data: unknown;
+}
+
+/**
+ * Transport sends the requests of the client, which is fetchTransport by
+ * default, axiosTransport or one of the app, e.g. with its interceptors.
+ */
+export type Transport = (req: TransportRequest) => Promise<TransportResponse>;
+
+export const fetchTransport: Transport = async (req) => {
+  const controller = new AbortController();
+  const abort = () => controller.abort();
+  if (req.signal) {
+    if (req.signal.aborted) {
+      controller.abort();
+    } else {
+      req.signal.addEventListener('abort', abort);
+    }
+  }
+  const timer = req.timeout ? setTimeout(abort, req.timeout) : undefined;
+
+  try {
+    const resp = await fetch(req.url, {
+      method: req.method,
+      headers: req.headers,
+      body: req.body,
+      signal: controller.signal,
+    });
+    const text = await resp.text();
+    let data: unknown = text;
+    try {
+      data = text ? JSON.parse(text) : undefined;
+    } catch (e) {
+      // not json, e.g. the error page of a gateway
+    }
+    return { status: resp.status, data };
+  } finally {
+    if (timer !== undefined) {
+      clearTimeout(timer);
+    }
+    if (req.signal) {
+      req.signal.removeEventListener('abort', abort);
+    }
+  }
+};
+
+/** AxiosLike is the part of an axios instance axiosTransport uses. */
+export interface AxiosLike {
+  request(config: {
+    method: string;
+    url: string;
+    headers: Record<string, string>;
+    data?: string;
+    timeout?: number;
+    signal?: AbortSignal;
+    validateStatus?: (status: number) => boolean;
+  }): Promise<{ status: number; data: unknown }>;
+}
+
+export function axiosTransport(axios: AxiosLike): Transport {
+  return async (req) => {
+    const resp = await axios.request({
+      method: req.method,
+      url: req.url,
+      headers: req.headers,
+      data: req.body,
+      timeout: req.timeout,
+      signal: req.signal,
+      // the status of the envelope decides the errors
+      validateStatus: () => true,
+    });
+    return { status: resp.status, data: resp.data };
+  };
+}
+
+/**
+ * Envelope is the body of the responses of httppkg, whose content is the
+ * response of the handler when status is 200.
+ */
+export interface Envelope<T> {
+  status: number;
+  content: T;
+  errorMsg: string;
+  timestamp?: number;
+  timeStamp?: number;
+}
+
+export class APIError extends Error {
+  /** status is the status of the envelope, 0 when there is none. */
+  readonly status: number;
+  readonly httpStatus: number;
+
+  constructor(message: string, status: number, httpStatus: number) {
+    super(message);
+    this.name = 'APIError';
+    this.status = status;
+    this.httpStatus = httpStatus;
+  }
+}
+
+export interface APIRequest {
+  method: Method;
+  path: string;
+  headers?: Record<string, unknown>;
+  query?: object;
+  json?: object;
+  form?: object;
+}
+
+export interface ClientOptions {
+  baseURL: string;
+  transport?: Transport;
+  /** headers of all the requests */
+  headers?: Record<string, string>;
+  /** timeout of all the requests in milliseconds */
+  timeout?: number;
+}
+
+export interface CallOptions {
+  headers?: Record<string, string>;
+  /** timeout of the request in milliseconds */
+  timeout?: number;
+  signal?: AbortSignal;
+}
+
+export class HTTPClient {
+  private readonly options: ClientOptions;
+  private readonly transport: Transport;
+
+  constructor(options: ClientOptions) {
+    this.options = options;
+    this.transport = options.transport || fetchTransport;
+  }
+
+  async request<T>(req: APIRequest, options: CallOptions = {}): Promise<T> {
+    const headers: Record<string, string> = { ...this.options.headers };
+    for (const [key, value] of Object.entries(req.headers || {})) {
+      if (value !== undefined && value !== null) {
+        headers[key] = String(value);
+      }
+    }
+
+    let url = this.options.baseURL.replace(/\/+$/, '') + req.path;
+    const query = encode(req.query);
+    if (query) {
+      url += '?' + query;
+    }
+
+    let body: string | undefined;
+    if (req.json) {
+      headers['Content-Type'] = 'application/json';
+      body = JSON.stringify(req.json);
+    } else if (req.form) {
+      headers['Content-Type'] = 'application/x-www-form-urlencoded';
+      body = encode(req.form);
+    }
+    Object.assign(headers, options.headers);
+
+    const resp = await this.transport({
+      method: req.method,
+      url,
+      headers,
+      body,
+      timeout: options.timeout !== undefined ? options.timeout : this.options.timeout,
+      signal: options.signal,
+    });
+    // responses of HEAD have no body
+    if (req.method === 'HEAD' && resp.status < 400) {
+      return undefined as unknown as T;
+    }
+
+    const envelope = resp.data as Envelope<T> | undefined;
+    if (!envelope || typeof envelope !== 'object' || !('status' in envelope)) {
+      throw new APIError('invalid response of ' + req.method + ' ' + req.path, 0, resp.status);
+    }
+    if (envelope.status !== 200) {
+      throw new APIError(envelope.errorMsg, envelope.status, resp.status);
+    }
+
+    return envelope.content;
+  }
+}
+
+/** omit returns value without the properties keys. */
+export function omit(value: object, keys: string[]): Record<string, unknown> {
+  const rest: Record<string, unknown> = {};
+  for (const [key, item] of Object.entries(value)) {
+    if (keys.indexOf(key) === -1) {
+      rest[key] = item;
+    }
+  }
+
+  return rest;
+}
+
+/**
+ * encode encodes values the way gin binds forms, repeating the keys of
+ * arrays.
+ */
+function encode(values?: object): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(values || {})) {
+    const list: unknown[] = Array.isArray(value) ? value : [value];
+    for (const item of list) {
+      if (item === undefined || item === null) {
+        continue;
+      }
+      params.append(key, typeof item === 'object' ? JSON.stringify(item) : String(item));
+    }
+  }
+
+  return params.toString();
+}
+`
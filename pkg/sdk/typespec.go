@@ -0,0 +1,142 @@
+package sdk
+
+// typeSpecEntry is what parseType parses from a typespec file, stored in the
+// parse cache. The declarations are stored without their positions, which
+// are only reported when the file is invalid and not cached.
+type typeSpecEntry struct {
+	// APIName is the name of the general type named by the file, if any.
+	APIName       string            `json:"apiName"`
+	ImportMap     map[string]string `json:"importMap"`
+	Constants     []*constantEntry  `json:"constants"`
+	RequestDecls  []*structEntry    `json:"requestDecls"`
+	ResponseDecls []*structEntry    `json:"responseDecls"`
+	GeneralDecls  []*structEntry    `json:"generalDecls"`
+}
+
+type constantEntry struct {
+	Name     string   `json:"name"`
+	Group    []string `json:"group"`
+	IsBinary bool     `json:"isBinary,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	LV       string   `json:"lv,omitempty"`
+	RV       string   `json:"rv,omitempty"`
+	Op       string   `json:"op,omitempty"`
+}
+
+type structEntry struct {
+	Name   string        `json:"name"`
+	Fields []*fieldEntry `json:"fields"`
+}
+
+type fieldEntry struct {
+	Name         string        `json:"name"`
+	Type         string        `json:"type"`
+	Tag          string        `json:"tag,omitempty"`
+	IsStruct     bool          `json:"isStruct,omitempty"`
+	StructFields []*fieldEntry `json:"structFields,omitempty"`
+	Comment      string        `json:"comment,omitempty"`
+}
+
+func newTypeSpecEntry(g *Generator) *typeSpecEntry {
+	entry := &typeSpecEntry{
+		APIName:       g.APIName,
+		ImportMap:     g.ImportMap,
+		Constants:     make([]*constantEntry, 0, len(g.Constants)),
+		RequestDecls:  newStructEntries(g.RequestDecls),
+		ResponseDecls: newStructEntries(g.ResponseDecls),
+		GeneralDecls:  newStructEntries(g.GeneralDecls),
+	}
+	for _, cnst := range g.Constants {
+		entry.Constants = append(entry.Constants, &constantEntry{
+			Name:     cnst.Name,
+			Group:    cnst.Group,
+			IsBinary: cnst.Value.isBinary,
+			Value:    cnst.Value.value,
+			LV:       cnst.Value.lv,
+			RV:       cnst.Value.rv,
+			Op:       cnst.Value.op,
+		})
+	}
+
+	return entry
+}
+
+// restore sets the types and constants of entry to g, as parseType does.
+func (entry *typeSpecEntry) restore(g *Generator) {
+	g.APIName = entry.APIName
+	for name, path := range entry.ImportMap {
+		g.ImportMap[name] = path
+	}
+	for _, cnst := range entry.Constants {
+		g.Constants = append(g.Constants, &Constant{
+			Name:  cnst.Name,
+			Group: append(make([]string, 0, len(cnst.Group)), cnst.Group...),
+			Value: ConstantValue{
+				isBinary: cnst.IsBinary,
+				value:    cnst.Value,
+				lv:       cnst.LV,
+				rv:       cnst.RV,
+				op:       cnst.Op,
+			},
+		})
+	}
+	g.RequestDecls = append(g.RequestDecls, restoreStructs(entry.RequestDecls)...)
+	g.ResponseDecls = append(g.ResponseDecls, restoreStructs(entry.ResponseDecls)...)
+	g.GeneralDecls = append(g.GeneralDecls, restoreStructs(entry.GeneralDecls)...)
+}
+
+func newStructEntries(decls []*StructType) []*structEntry {
+	entries := make([]*structEntry, 0, len(decls))
+	for _, decl := range decls {
+		entries = append(entries, &structEntry{Name: decl.Name, Fields: newFieldEntries(decl.Fields)})
+	}
+
+	return entries
+}
+
+func newFieldEntries(fields []*Field) []*fieldEntry {
+	if fields == nil {
+		return nil
+	}
+	entries := make([]*fieldEntry, 0, len(fields))
+	for _, field := range fields {
+		entries = append(entries, &fieldEntry{
+			Name:         field.Name,
+			Type:         field.Type,
+			Tag:          field.Tag,
+			IsStruct:     field.isStruct,
+			StructFields: newFieldEntries(field.StructFields),
+			Comment:      field.Comment,
+		})
+	}
+
+	return entries
+}
+
+func restoreStructs(entries []*structEntry) []*StructType {
+	decls := make([]*StructType, 0, len(entries))
+	for _, entry := range entries {
+		decls = append(decls, &StructType{Name: entry.Name, Fields: restoreFields(entry.Fields)})
+	}
+
+	return decls
+}
+
+func restoreFields(entries []*fieldEntry) []*Field {
+	if entries == nil {
+		return nil
+	}
+	fields := make([]*Field, 0, len(entries))
+	for _, entry := range entries {
+		fields = append(fields, &Field{
+			Name:         entry.Name,
+			Type:         entry.Type,
+			Tag:          entry.Tag,
+			isStruct:     entry.IsStruct,
+			StructFields: restoreFields(entry.StructFields),
+			Comment:      entry.Comment,
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,120 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// context is the number of unchanged lines around the changes of a hunk.
+const context = 3
+
+type line struct {
+	op   diffmatchpatch.Operation
+	text string
+}
+
+// Unified returns the unified diff of from and to, named fromName and toName,
+// or an empty string when they are the same.
+func Unified(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	dmp := diffmatchpatch.New()
+	fromRunes, toRunes, lineArray := dmp.DiffLinesToRunes(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMainRunes(fromRunes, toRunes, false), lineArray)
+
+	var lines []line
+	for _, d := range diffs {
+		text := strings.TrimSuffix(d.Text, "\n")
+		for _, l := range strings.Split(text, "\n") {
+			lines = append(lines, line{op: d.Type, text: l})
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	// fromLine and toLine are the 1-based lines lines[i] is at
+	fromLine, toLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffmatchpatch.DiffEqual {
+			fromLine++
+			toLine++
+			i++
+			continue
+		}
+
+		// a hunk starts context lines before the change, and ends when
+		// more than twice context unchanged lines follow
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for equal := 0; end < len(lines) && equal <= 2*context; end++ {
+			if lines[end].op == diffmatchpatch.DiffEqual {
+				equal++
+			} else {
+				equal = 0
+			}
+		}
+		for end > i && lines[end-1].op == diffmatchpatch.DiffEqual && trailingEqual(lines[:end]) > context {
+			end--
+		}
+
+		hunkFrom, hunkTo := fromLine-(i-start), toLine-(i-start)
+		var fromCount, toCount int
+		var body strings.Builder
+		for _, l := range lines[start:end] {
+			switch l.op {
+			case diffmatchpatch.DiffEqual:
+				fromCount++
+				toCount++
+				body.WriteString(" " + l.text + "\n")
+			case diffmatchpatch.DiffDelete:
+				fromCount++
+				body.WriteString("-" + l.text + "\n")
+			case diffmatchpatch.DiffInsert:
+				toCount++
+				body.WriteString("+" + l.text + "\n")
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n%s", hunkRange(hunkFrom, fromCount), hunkRange(hunkTo, toCount), body.String())
+
+		for _, l := range lines[i:end] {
+			if l.op != diffmatchpatch.DiffInsert {
+				fromLine++
+			}
+			if l.op != diffmatchpatch.DiffDelete {
+				toLine++
+			}
+		}
+		i = end
+	}
+
+	return b.String()
+}
+
+func trailingEqual(lines []line) int {
+	n := 0
+	for i := len(lines) - 1; i >= 0 && lines[i].op == diffmatchpatch.DiffEqual; i-- {
+		n++
+	}
+
+	return n
+}
+
+// hunkRange formats the range of a hunk, whose start is the line before it
+// when it is empty.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}